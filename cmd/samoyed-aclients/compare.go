@@ -0,0 +1,143 @@
+package main
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Line up the same packet from each TNC so it's easy
+ *		to see which ones decoded it, and keep score.
+ *
+ * Description:	TNCs don't all report a packet at the same moment, so
+ *		a packet is held for a few seconds waiting for the same
+ *		one from the others, before its line is printed.
+ *
+ *		Packets are compared as text.  Serial port TNCs in
+ *		monitor mode add something like "<UI>:" after the
+ *		addresses, which is removed so they can be compared
+ *		with the network TNCs.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const DEFAULT_WINDOW = 3 * time.Second
+
+type compare_row struct {
+	key   string
+	first time.Time
+	text  []string /* From each client, empty if not heard. */
+}
+
+type comparison struct {
+	num_clients int
+	window      time.Duration
+
+	pending []*compare_row /* Not printed yet, oldest first. */
+
+	packets   int   /* Different packets. */
+	by_all    int   /* Decoded by every client. */
+	decoded   []int /* By each client. */
+	only_this []int /* Decoded by this client and no other. */
+	missed    []int /* Decoded by some other client but not this one. */
+}
+
+func new_comparison(num_clients int, window time.Duration) *comparison {
+	return &comparison{
+		num_clients: num_clients,
+		window:      window,
+		pending:     nil,
+		packets:     0,
+		by_all:      0,
+		decoded:     make([]int, num_clients),
+		only_this:   make([]int, num_clients),
+		missed:      make([]int, num_clients),
+	}
+}
+
+var monitorFrameType = regexp.MustCompile(`^([^ ]*:)\s*<+[^>]*>+:\s*`)
+
+func compare_key(text string) string {
+	return strings.TrimSpace(monitorFrameType.ReplaceAllString(text, "$1"))
+}
+
+/*
+ * Packet from one of the clients.
+ */
+
+func (c *comparison) add(client int, text string, now time.Time) {
+	var key = compare_key(text)
+
+	for _, r := range c.pending {
+		if r.key == key && r.text[client] == "" {
+			r.text[client] = text
+
+			return
+		}
+	}
+
+	var r = &compare_row{key: key, first: now, text: make([]string, c.num_clients)}
+	r.text[client] = text
+
+	c.pending = append(c.pending, r)
+}
+
+/*
+ * Print lines which have waited long enough, or all of them
+ * when finishing up, and count them.
+ */
+
+func (c *comparison) flush(w io.Writer, now time.Time, everything bool, column_width int) {
+	for len(c.pending) > 0 && (everything || now.Sub(c.pending[0].first) >= c.window) {
+		var r = c.pending[0]
+		c.pending = c.pending[1:]
+
+		c.packets++
+
+		var heard = 0
+
+		for j, text := range r.text {
+			fmt.Fprintf(w, "%*s", column_width, text)
+
+			if text != "" {
+				heard++
+				c.decoded[j]++
+			}
+		}
+
+		fmt.Fprintf(w, "\n")
+
+		if heard == c.num_clients {
+			c.by_all++
+		}
+
+		for j, text := range r.text {
+			if text == "" {
+				c.missed[j]++
+			} else if heard == 1 {
+				c.only_this[j]++
+			}
+		}
+	}
+}
+
+func (c *comparison) totals(w io.Writer, description []string, elapsed time.Duration) {
+	fmt.Fprintf(w, "\nTotals after %d minutes", int(elapsed.Minutes()))
+
+	for j := range c.num_clients {
+		fmt.Fprintf(w, ", %s %d", description[j], c.decoded[j])
+	}
+
+	fmt.Fprintf(w, "\n\n")
+
+	fmt.Fprintf(w, "%-20s %8s %8s %8s\n", "", "Decoded", "Only", "Missed")
+
+	for j := range c.num_clients {
+		fmt.Fprintf(w, "%-20s %8d %8d %8d\n", description[j], c.decoded[j], c.only_this[j], c.missed[j])
+	}
+
+	fmt.Fprintf(w, "\n%d different packets, %d decoded by all.\n\n", c.packets, c.by_all)
+}
@@ -9,12 +9,14 @@ package main
  * Description:	Establish connection with multiple servers and
  *		compare results side by side.
  *
- * Usage:	aclients port1=name1 port2=name2 ...
+ * Usage:	aclients [options] port1=name1 port2=name2 ...
  *
  * Example:	aclients  8000=AGWPE  192.168.1.64:8002=DireWolf  COM1=D710A
  *
  *		This will connect to multiple physical or virtual
  *		TNCs, read packets from them, and display results.
+ *		The same packet from each is shown on one line, and
+ *		totals show how many each decoded that others didn't.
  *
  *		Each port can have the following forms:
  *
@@ -31,11 +33,13 @@ import (
 	"io"
 	"net"
 	"os"
+	"os/signal"
 	"strings"
 	"time"
 	"unicode"
 
 	direwolf "github.com/doismellburning/samoyed/src"
+	"github.com/spf13/pflag"
 )
 
 /*------------------------------------------------------------------
@@ -54,15 +58,46 @@ const MAX_CLIENTS = 6
 const LINE_WIDTH = 120
 
 var column_width int
-var packet_count [MAX_CLIENTS]int
 
 const PRINT_MINUTES = 30
 
+type client_packet struct {
+	client int
+	text   string
+}
+
 func main() {
+	var window = pflag.DurationP("window", "w", DEFAULT_WINDOW, "How long to wait for the same packet from the other TNCs.")
+	var printMinutes = pflag.IntP("minutes", "m", PRINT_MINUTES, "Print totals every this many minutes.")
+	var duration = pflag.DurationP("duration", "t", 0, "Stop after this long, e.g. 2h, and print totals.  Default is to run until interrupted.")
+	var help = pflag.BoolP("help", "h", false, "Display help text.")
+
+	pflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Compare how well different TNCs decode AX.25 frames.\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... port1=name1 port2=name2 ...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Each port is host:tcp-port or tcp-port for AGW network protocol, or a serial port name.\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		pflag.PrintDefaults()
+	}
+
+	pflag.Parse()
+
+	if *help {
+		pflag.Usage()
+		os.Exit(0)
+	}
+
+	if *printMinutes < 1 {
+		fmt.Printf("Minutes between totals must be at least 1.\n")
+		os.Exit(1)
+	}
+
 	/*
 	 * Extract command line args.
 	 */
-	var num_clients = len(os.Args) - 1
+	var num_clients = pflag.NArg()
 
 	if num_clients < 1 || num_clients > MAX_CLIENTS {
 		fmt.Printf("Specify up to %d TNCs on the command line.\n", MAX_CLIENTS)
@@ -80,11 +115,11 @@ func main() {
 	/* a TCP port number at the hostname.  */
 	/* Otherwise, we treat it as a serial port name. */
 
-	var description [MAX_CLIENTS]string /* Name used in the output. */
+	var description = make([]string, num_clients) /* Name used in the output. */
 
 	for j := range num_clients {
 		/* Each command line argument should be of the form "port=description." */
-		var arg = os.Args[j+1]
+		var arg = pflag.Arg(j)
 		var _port, _description, descriptionFound = strings.Cut(arg, "=")
 
 		if !descriptionFound {
@@ -105,12 +140,9 @@ func main() {
 		}
 	}
 
-	var packetChans = make([]chan string, num_clients)
+	var packetChan = make(chan client_packet)
 
 	for j := range num_clients {
-		var ch = make(chan string)
-		packetChans[j] = ch
-
 		/* If port begins with digit, consider it to be TCP. */
 		/* Otherwise, treat as serial port name. */
 
@@ -120,53 +152,50 @@ func main() {
 		}
 
 		if unicode.IsDigit(rune(port[j][0])) {
-			go client_thread_net(j, hostname[j], port[j], description[j], ch)
+			go client_thread_net(j, hostname[j], port[j], description[j], packetChan)
 		} else {
-			go client_thread_serial(j, port[j], description[j], ch)
+			go client_thread_serial(j, port[j], description[j], packetChan)
 		}
 	}
 
 	var start_time = time.Now()
-	var next_print_time = start_time.Add(PRINT_MINUTES * time.Minute)
+	var next_print_time = start_time.Add(time.Duration(*printMinutes) * time.Minute)
+
+	var compare = new_comparison(num_clients, *window)
+
+	var interrupt = make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt)
+
+	var stop <-chan time.Time
+	if *duration > 0 {
+		stop = time.After(*duration)
+	}
+
+	var ticker = time.NewTicker(100 * time.Millisecond)
 
 	/*
 	 * Print results from clients.
 	 */
 	for {
-		direwolf.SLEEP_MS(100)
-
-		var something = false
-
-		var results = make([]string, len(packetChans))
-		for i, ch := range packetChans {
-			select {
-			case msg := <-ch:
-				something = true
-				results[i] = msg
-				packet_count[i]++
-			default:
-			}
-		}
+		select {
+		case p := <-packetChan:
+			compare.add(p.client, p.text, time.Now())
+		case now := <-ticker.C:
+			compare.flush(os.Stdout, now, false, column_width)
 
-		if something {
-			for _, result := range results {
-				fmt.Printf("%*s", column_width, result)
-			}
-
-			fmt.Printf("\n")
-		}
+			if now.After(next_print_time) {
+				next_print_time = now.Add(time.Duration(*printMinutes) * time.Minute)
 
-		var now = time.Now()
-		if now.After(next_print_time) {
-			next_print_time = now.Add(PRINT_MINUTES * time.Minute)
-
-			fmt.Printf("\nTotals after %d minutes", int(now.Sub(start_time).Minutes()))
-
-			for j := range num_clients {
-				fmt.Printf(", %s %d", description[j], packet_count[j])
+				compare.totals(os.Stdout, description, now.Sub(start_time))
 			}
-
-			fmt.Printf("\n\n")
+		case <-stop:
+			compare.flush(os.Stdout, time.Now(), true, column_width)
+			compare.totals(os.Stdout, description, time.Since(start_time))
+			os.Exit(0)
+		case <-interrupt:
+			compare.flush(os.Stdout, time.Now(), true, column_width)
+			compare.totals(os.Stdout, description, time.Since(start_time))
+			os.Exit(0)
 		}
 	}
 }
@@ -183,7 +212,7 @@ func main() {
  *
  *--------------------------------------------------------------------*/
 
-func client_thread_net(my_index int, hostname string, port string, description string, packetChan chan<- string) {
+func client_thread_net(my_index int, hostname string, port string, description string, packetChan chan<- client_packet) {
 	var conn, connErr = net.Dial("tcp4", net.JoinHostPort(hostname, port)) //nolint:gosec // G704: hostport should be provided by user-supplied config
 	if connErr != nil {
 		fmt.Printf("Client %d unable to connect to %s on %s, port %s\n",
@@ -265,7 +294,7 @@ func client_thread_net(my_index int, hostname string, port string, description s
 			var info = direwolf.AX25GetInfo(pp)
 
 			var fullResult = result + string(info)
-			packetChan <- fullResult
+			packetChan <- client_packet{client: my_index, text: fullResult}
 
 			direwolf.AX25Delete(pp)
 		}
@@ -284,7 +313,7 @@ func client_thread_net(my_index int, hostname string, port string, description s
  *
  *--------------------------------------------------------------------*/
 
-func client_thread_serial(my_index int, port string, description string, packetChan chan<- string) {
+func client_thread_serial(my_index int, port string, description string, packetChan chan<- client_packet) {
 	var fd = direwolf.SerialPortOpen(port, 9600)
 
 	if fd == nil {
@@ -350,7 +379,7 @@ func client_thread_serial(my_index int, port string, description string, packetC
 		 * Print it and add to counter.
 		 */
 		if len(buffer) > 0 {
-			packetChan <- string(buffer)
+			packetChan <- client_packet{client: my_index, text: string(buffer)}
 		}
 	}
 }
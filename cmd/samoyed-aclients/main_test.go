@@ -0,0 +1,56 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_compare_key(t *testing.T) {
+	// KPC-3+ monitor output, after the line break is removed, is the same as from AGW.
+	assert.Equal(t, "Q1TEST-1>T2QY5P,Q2TEST*,WIDE2-1:`c0+!h4>/]\"4a}",
+		compare_key("Q1TEST-1>T2QY5P,Q2TEST*,WIDE2-1: <<UI>>:`c0+!h4>/]\"4a}"))
+	assert.Equal(t, "Q3TEST>BEACON,Q4TEST,Q2TEST-1,WIDE2*:!4240.85N/07133.99W_PHG72604/ Pepperell, MA.",
+		compare_key("Q3TEST>BEACON,Q4TEST,Q2TEST-1,WIDE2*: <UI>: \t!4240.85N/07133.99W_PHG72604/ Pepperell, MA."))
+	assert.Equal(t, "Q1TEST>APDW18:>Hello", compare_key("Q1TEST>APDW18:>Hello"))
+}
+
+func Test_comparison(t *testing.T) {
+	var c = new_comparison(3, 2*time.Second)
+
+	var t0 = time.Unix(1000, 0)
+
+	c.add(0, "Q1TEST>APDW18:>one", t0)
+	c.add(2, "Q1TEST>APDW18: <UI>:>one", t0.Add(time.Second))
+	c.add(1, "Q1TEST>APDW18:>two", t0.Add(time.Second))
+	c.add(0, "Q1TEST>APDW18:>three", t0.Add(time.Second))
+	c.add(1, "Q1TEST>APDW18:>three", t0.Add(time.Second))
+	c.add(2, "Q1TEST>APDW18:>three", t0.Add(time.Second))
+
+	// Only the first has waited long enough.
+	var out strings.Builder
+	c.flush(&out, t0.Add(2*time.Second), false, 30)
+	assert.Equal(t, fmt.Sprintf("%30s%30s%30s\n", "Q1TEST>APDW18:>one", "", "Q1TEST>APDW18: <UI>:>one"), out.String())
+
+	// Same packet again later is a new line.
+	c.add(0, "Q1TEST>APDW18:>one", t0.Add(2*time.Second))
+
+	out.Reset()
+	c.flush(&out, t0.Add(3*time.Second), true, 30)
+	assert.Equal(t, 3, strings.Count(out.String(), "\n"))
+
+	assert.Equal(t, 4, c.packets)
+	assert.Equal(t, 1, c.by_all)
+	assert.Equal(t, []int{3, 2, 2}, c.decoded)
+	assert.Equal(t, []int{1, 1, 0}, c.only_this)
+	assert.Equal(t, []int{1, 2, 2}, c.missed)
+
+	out.Reset()
+	c.totals(&out, []string{"KPC3+", "DireWolf", "other"}, 90*time.Minute)
+	assert.Contains(t, out.String(), "Totals after 90 minutes, KPC3+ 3, DireWolf 2, other 2\n")
+	assert.Contains(t, out.String(), "DireWolf                    2        1        2\n")
+	assert.Contains(t, out.String(), "4 different packets, 1 decoded by all.\n")
+}
@@ -62,7 +62,6 @@ import (
 	"fmt"
 	"io"
 	"net"
-	"strconv"
 
 	direwolf "github.com/doismellburning/samoyed/src"
 )
@@ -290,9 +289,11 @@ func process_from_tnc(cmd *AGWPECommand) {
 	case 'U': // Received AX.25 frame in monitor format. (Enabled with 'm' command.)
 	case 'y': // Outstanding frames waiting on a Port
 	case 'Y': // How many frames waiting for transmit for a particular station
-		var dataStr = string(cmd.Data)
-
-		var frameCount, _ = strconv.Atoi(dataStr)
+		// The count is a 32 bit little endian number, not text.
+		var frameCount = 0
+		if len(cmd.Data) >= 4 {
+			frameCount = int(binary.LittleEndian.Uint32(cmd.Data))
+		}
 
 		agw_cb_Y_outstanding_frames_for_station(cmd.Header.Portx, cmd.Header.CallFrom, cmd.Header.CallTo, frameCount)
 	default:
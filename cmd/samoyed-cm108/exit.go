@@ -0,0 +1,13 @@
+package main
+
+/*
+ * Exit status, so scripts can tell what was found without reading the output.
+ */
+
+const (
+	EXIT_OK         = 0 // Found at least one device which can use GPIO for PTT.
+	EXIT_USAGE      = 1 // Bad command line.
+	EXIT_NO_DEVICES = 2 // No USB audio or HID devices found.
+	EXIT_NO_PTT     = 3 // Found some, but none can use GPIO for PTT.
+	EXIT_ERROR      = 4 // Couldn't take inventory or write to GPIO, or not supported on this platform.
+)
@@ -0,0 +1,101 @@
+package main
+
+/*
+ * Inventory in JSON for --json, e.g.
+ *
+ *	{
+ *	  "devices": [
+ *	    {
+ *	      "vid": "0d8c",
+ *	      "pid": "000c",
+ *	      "product": "C-Media USB Headphone Set",
+ *	      "sound": "/dev/snd/pcmC1D0p",
+ *	      "adevice": "plughw:1,0",
+ *	      "adevice_name": "plughw:Set,0",
+ *	      "hid": "/dev/hidraw0",
+ *	      "usb": "/dev/bus/usb/001/005",
+ *	      "devpath": "/devices/.../sound/card1",
+ *	      "gpio_ptt": true
+ *	    }
+ *	  ]
+ *	}
+ */
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	direwolf "github.com/doismellburning/samoyed/src"
+)
+
+type cm108_json_device struct {
+	VID         string `json:"vid"`
+	PID         string `json:"pid"`
+	Product     string `json:"product"`
+	Sound       string `json:"sound,omitempty"`
+	ADevice     string `json:"adevice,omitempty"`
+	ADeviceName string `json:"adevice_name,omitempty"`
+	HID         string `json:"hid,omitempty"`
+	USB         string `json:"usb,omitempty"`
+	Devpath     string `json:"devpath,omitempty"`
+	GPIOPTT     bool   `json:"gpio_ptt"` // Can use Audio Adapter GPIO for PTT.
+}
+
+type cm108_json_inventory struct {
+	Devices []cm108_json_device `json:"devices"`
+	Error   string              `json:"error,omitempty"`
+}
+
+func cm108_write_json(w io.Writer, things []*direwolf.CM108Thing, inventoryErr error) error {
+	var inventory = cm108_json_inventory{
+		Devices: []cm108_json_device{},
+		Error:   "",
+	}
+
+	if inventoryErr != nil {
+		inventory.Error = inventoryErr.Error()
+	}
+
+	for _, thing := range things {
+		inventory.Devices = append(inventory.Devices, cm108_json_device{
+			VID:         fmt.Sprintf("%04x", thing.VID),
+			PID:         fmt.Sprintf("%04x", thing.PID),
+			Product:     thing.Product,
+			Sound:       thing.DevnodeSound,
+			ADevice:     thing.Plughw,
+			ADeviceName: thing.Plughw2,
+			HID:         thing.DevnodeHidraw,
+			USB:         thing.DevnodeUSB,
+			Devpath:     thing.Devpath,
+			GPIOPTT:     direwolf.GOOD_DEVICE(thing.VID, thing.PID),
+		})
+	}
+
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+
+	return encoder.Encode(inventory)
+}
+
+/*
+ * Exit status for the inventory.
+ */
+
+func cm108_inventory_status(things []*direwolf.CM108Thing, inventoryErr error) int {
+	if inventoryErr != nil {
+		return EXIT_ERROR
+	}
+
+	if len(things) == 0 {
+		return EXIT_NO_DEVICES
+	}
+
+	for _, thing := range things {
+		if direwolf.GOOD_DEVICE(thing.VID, thing.PID) {
+			return EXIT_OK
+		}
+	}
+
+	return EXIT_NO_PTT
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	direwolf "github.com/doismellburning/samoyed/src"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cm108_write_json(t *testing.T) {
+	var things = []*direwolf.CM108Thing{
+		{
+			VID:           0x0d8c,
+			PID:           0x000c,
+			CardNumber:    "1",
+			CardName:      "Set",
+			Product:       "C-Media USB Headphone Set",
+			DevnodeSound:  "/dev/snd/pcmC1D0p",
+			Plughw:        "plughw:1,0",
+			Plughw2:       "plughw:Set,0",
+			Devpath:       "/devices/pci0000:00/usb1/1-1/sound/card1",
+			DevnodeHidraw: "/dev/hidraw0",
+			DevnodeUSB:    "/dev/bus/usb/001/005",
+		},
+	}
+
+	var buf bytes.Buffer
+
+	require.NoError(t, cm108_write_json(&buf, things, nil))
+	assert.JSONEq(t, `{"devices": [{
+		"vid": "0d8c",
+		"pid": "000c",
+		"product": "C-Media USB Headphone Set",
+		"sound": "/dev/snd/pcmC1D0p",
+		"adevice": "plughw:1,0",
+		"adevice_name": "plughw:Set,0",
+		"hid": "/dev/hidraw0",
+		"usb": "/dev/bus/usb/001/005",
+		"devpath": "/devices/pci0000:00/usb1/1-1/sound/card1",
+		"gpio_ptt": true
+	}]}`, buf.String())
+
+	buf.Reset()
+
+	require.NoError(t, cm108_write_json(&buf, nil, errors.New("can't enumerate")))
+	assert.JSONEq(t, `{"devices": [], "error": "can't enumerate"}`, buf.String())
+}
+
+func Test_cm108_inventory_status(t *testing.T) {
+	var good = &direwolf.CM108Thing{VID: 0x0d8c, PID: 0x0012}  //nolint:exhaustruct
+	var other = &direwolf.CM108Thing{VID: 0x046d, PID: 0x0a44} //nolint:exhaustruct
+
+	assert.Equal(t, EXIT_OK, cm108_inventory_status([]*direwolf.CM108Thing{other, good}, nil))
+	assert.Equal(t, EXIT_NO_PTT, cm108_inventory_status([]*direwolf.CM108Thing{other}, nil))
+	assert.Equal(t, EXIT_NO_DEVICES, cm108_inventory_status(nil, nil))
+	assert.Equal(t, EXIT_ERROR, cm108_inventory_status(nil, errors.New("oops")))
+}
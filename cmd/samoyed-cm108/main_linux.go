@@ -8,6 +8,7 @@ import (
 	"strconv"
 
 	direwolf "github.com/doismellburning/samoyed/src"
+	"github.com/spf13/pflag"
 )
 
 /*-------------------------------------------------------------------
@@ -23,11 +24,16 @@ import (
  *
  *		When specified the pin will be set high and low until interrupted.
  *
+ *		--json	List the devices as JSON instead.
+ *
+ * Exit status:	See exit.go.
+ *
  *------------------------------------------------------------------*/
 
 func cm108_usage() {
 	fmt.Printf("\n")
-	fmt.Printf("Usage:    %s  [ device-path [ gpio-num ] ]\n", filepath.Base(os.Args[0]))
+	fmt.Printf("Usage:    %s  [ --json ]\n", filepath.Base(os.Args[0]))
+	fmt.Printf("          %s  device-path [ gpio-num ]\n", filepath.Base(os.Args[0]))
 	fmt.Printf("\n")
 	fmt.Printf("With no command line arguments, this will produce a list of\n")
 	fmt.Printf("Audio devices and Human Interface Devices (HID) and indicate\n")
@@ -36,24 +42,39 @@ func cm108_usage() {
 	fmt.Printf("Specify the HID device path to test the PTT function.\n")
 	fmt.Printf("Its state should change once per second.\n")
 	fmt.Printf("GPIO 3 is the default.  A different number can be optionally specified.\n")
-	os.Exit(1)
+	fmt.Printf("\n")
+	fmt.Printf("--json lists the devices in JSON format for other programs.\n")
+	fmt.Printf("\n")
+	fmt.Printf("Exit status is %d if a device can use GPIO for PTT, %d for no devices found,\n", EXIT_OK, EXIT_NO_DEVICES)
+	fmt.Printf("%d if none of them can use GPIO for PTT, or %d for any other error.\n", EXIT_NO_PTT, EXIT_ERROR)
+	os.Exit(EXIT_USAGE)
 }
 
 func main() {
 	direwolf.TextColorInit(0) // Turn off text color.
 
-	if len(os.Args) >= 2 {
-		var path = os.Args[1]
+	var jsonOutput = pflag.Bool("json", false, "List devices in JSON format.")
+	var help = pflag.BoolP("help", "h", false, "Display help text.")
+
+	pflag.Usage = cm108_usage
+
+	pflag.Parse()
+
+	if *help || pflag.NArg() > 2 || (*jsonOutput && pflag.NArg() > 0) {
+		cm108_usage()
+	}
+
+	if pflag.NArg() >= 1 {
+		var path = pflag.Arg(0)
 
 		var gpio = 3
-		if len(os.Args) >= 3 {
-			gpio, _ = strconv.Atoi(os.Args[2])
+		if pflag.NArg() >= 2 {
+			gpio, _ = strconv.Atoi(pflag.Arg(1))
 		}
 
 		if gpio < 1 || gpio > 8 {
 			fmt.Printf("GPIO number must be in range of 1 - 8.\n")
 			cm108_usage()
-			os.Exit(1)
 		}
 
 		var state = 0
@@ -63,8 +84,7 @@ func main() {
 			var err = direwolf.CM108SetGPIOPin(path, gpio, state)
 			if err != 0 {
 				fmt.Printf("\nWRITE ERROR for USB Audio Adapter GPIO!\n")
-				cm108_usage()
-				os.Exit(1)
+				os.Exit(EXIT_ERROR)
 			}
 
 			direwolf.SLEEP_SEC(1)
@@ -75,11 +95,26 @@ func main() {
 
 	// Take inventory of USB Audio adapters and other HID devices.
 
-	var things, _ = direwolf.CM108Inventory(direwolf.MAXX_THINGS)
+	var things, inventoryErr = direwolf.CM108Inventory(direwolf.MAXX_THINGS)
+
+	var status = cm108_inventory_status(things, inventoryErr)
+
+	if *jsonOutput {
+		var err = cm108_write_json(os.Stdout, things, inventoryErr)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(EXIT_ERROR)
+		}
+
+		os.Exit(status)
+	}
 
 	if len(things) == 0 {
-		fmt.Printf("No relevant USB devices found!\n")
-		return
+		if inventoryErr == nil {
+			fmt.Printf("No relevant USB devices found!\n")
+		}
+
+		os.Exit(status)
 	}
 
 	/////////////////////////////////////////////
@@ -154,4 +189,6 @@ func main() {
 
 	fmt.Printf("LABEL=\"my_usb_audio_end\"\n")
 	fmt.Printf("\n")
+
+	os.Exit(status)
 }
@@ -4,8 +4,10 @@ package main
 
 import (
 	"fmt"
+	"os"
 )
 
 func main() {
 	fmt.Println("cm108 not supported on !linux")
+	os.Exit(EXIT_ERROR)
 }
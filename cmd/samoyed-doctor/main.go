@@ -0,0 +1,9 @@
+package main
+
+import (
+	direwolf "github.com/doismellburning/samoyed/src"
+)
+
+func main() {
+	direwolf.DoctorMain()
+}
@@ -3,42 +3,135 @@ package main
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"time"
 
 	direwolf "github.com/doismellburning/samoyed/src"
+	"github.com/spf13/pflag"
 )
 
 func main() {
-	var gpsPort = "COM22"
+	var flags = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+	var port = flags.StringP("port", "p", "/dev/ttyACM0", "Serial port for the GPS receiver.")
+	var baud = flags.IntP("baud", "b", 4800, "Serial port speed.  0 to leave it alone.")
+	var debug = flags.IntP("debug", "d", 3, "Debug level.  2 shows each update, 3 each sentence.")
+	var simulate = flags.StringP("simulate", "s", "", "Play back a log of NMEA sentences instead of using a receiver.  - for stdin.")
+	var speed = flags.Float64P("speed", "x", 1, "Playback speed for --simulate.  1 is real time, 10 ten times as fast, 0 as fast as possible.")
+	var help = flags.BoolP("help", "h", false, "Display help text.")
 
-	if len(os.Args) > 1 {
-		gpsPort = os.Args[1]
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s - print location from a GPS receiver sending NMEA sentences.\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Usage: dwgpsnmea [options] [ port ]\n")
+		fmt.Fprintf(os.Stderr, "       dwgpsnmea [options] --simulate file\n")
+		flags.PrintDefaults()
 	}
 
-	direwolf.DWGPSInit(gpsPort, 3)
+	_ = flags.Parse(os.Args[1:])
 
-	for {
-		var fix, lat, lon, speedKnots, track, altitude = direwolf.DWGPSRead()
+	if *help {
+		flags.Usage()
+		os.Exit(0)
+	}
+
+	// Port as an argument, as before there were options.
+	if flags.NArg() > 0 {
+		*port = flags.Arg(0)
+	}
+
+	if *speed < 0 {
+		fmt.Fprintf(os.Stderr, "Speed can't be negative.\n")
+		os.Exit(1)
+	}
+
+	var done <-chan error
+
+	var interval = 3 * time.Second
+
+	if *simulate != "" {
+		var r io.Reader = os.Stdin
+
+		if *simulate != "-" {
+			var f, err = os.Open(*simulate)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Can't open %s: %s\n", *simulate, err)
+				os.Exit(1)
+			}
+			defer f.Close()
+
+			r = f
+		}
+
+		direwolf.DWGPSInit("", 0, *debug)
+
+		done = direwolf.DWGPSReplay(r, *speed)
+
+		// Keep up with the playback.
+		if *speed > 1 {
+			interval = max(time.Duration(float64(interval) / *speed), 100*time.Millisecond)
+		}
+	} else {
+		direwolf.DWGPSInit(*port, *baud, *debug)
+	}
 
-		switch fix {
-		case int(direwolf.DWFIX_2D), int(direwolf.DWFIX_3D):
-			fmt.Printf("%.6f  %.6f", lat, lon)
-			fmt.Printf("  %.1f knots  %.0f degrees", speedKnots, track)
+	for {
+		print_location()
 
-			if fix == int(direwolf.DWFIX_3D) {
-				fmt.Printf("  altitude = %.1f meters", altitude)
+		select {
+		case err := <-done: // Never for a receiver.
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading %s: %s\n", *simulate, err)
+				os.Exit(1) //nolint:gocritic // Nothing to close if it couldn't be read.
 			}
 
-			fmt.Printf("\n")
-		case int(direwolf.DWFIX_NOT_SEEN), int(direwolf.DWFIX_NO_FIX):
-			fmt.Printf("Location currently not available.\n")
-		case int(direwolf.DWFIX_NOT_INIT):
-			fmt.Printf("GPS Init failed.\n")
-			os.Exit(1)
-		default:
-			fmt.Printf("ERROR getting GPS information.\n")
+			print_location()
+			fmt.Printf("End of %s.\n", *simulate)
+
+			return
+		case <-time.After(interval):
+		}
+	}
+}
+
+func print_location() {
+	var fix, lat, lon, speedKnots, track, altitude = direwolf.DWGPSRead()
+
+	switch fix {
+	case int(direwolf.DWFIX_2D), int(direwolf.DWFIX_3D):
+		fmt.Printf("%.6f  %.6f", lat, lon)
+		fmt.Printf("  %.1f knots  %.0f degrees", speedKnots, track)
+
+		if fix == int(direwolf.DWFIX_3D) {
+			fmt.Printf("  altitude = %.1f meters", altitude)
+		}
+
+		var quality, numSat, pdop, hdop, vdop = direwolf.DWGPSReadQuality()
+
+		if quality != direwolf.G_UNKNOWN {
+			fmt.Printf("  quality = %d", quality)
+		}
+
+		if numSat != direwolf.G_UNKNOWN {
+			fmt.Printf("  satellites = %d", numSat)
+		}
+
+		for _, dop := range []struct {
+			name  string
+			value float64
+		}{{"PDOP", pdop}, {"HDOP", hdop}, {"VDOP", vdop}} {
+			if dop.value != direwolf.G_UNKNOWN {
+				fmt.Printf("  %s = %.1f", dop.name, dop.value)
+			}
 		}
 
-		direwolf.SLEEP_SEC(3)
+		fmt.Printf("\n")
+	case int(direwolf.DWFIX_NOT_SEEN), int(direwolf.DWFIX_NO_FIX):
+		fmt.Printf("Location currently not available.\n")
+	case int(direwolf.DWFIX_NOT_INIT):
+		fmt.Printf("GPS Init failed.\n")
+		os.Exit(1)
+	default:
+		fmt.Printf("ERROR getting GPS information.\n")
 	}
 }
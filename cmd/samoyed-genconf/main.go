@@ -0,0 +1,7 @@
+package main
+
+import direwolf "github.com/doismellburning/samoyed/src"
+
+func main() {
+	direwolf.GenConfMain()
+}
@@ -2,84 +2,261 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"strconv"
+	"strings"
 
-	direwolf "github.com/doismellburning/samoyed/src"
-	"github.com/golang/geo/s1"
-	"github.com/golang/geo/s2"
-	"github.com/tzneal/coordconv"
+	"github.com/doismellburning/samoyed/src/geo"
 )
 
-func D2R(degrees float64) float64 {
-	return degrees * math.Pi / 180
+/* Results for one location.  Also the --json output, one object per line. */
+
+type utmJSON struct {
+	Zone       int     `json:"zone"`
+	Hemisphere string  `json:"hemisphere"`
+	Easting    float64 `json:"easting"`
+	Northing   float64 `json:"northing"`
+}
+
+type conversion struct {
+	Input     string   `json:"input"`
+	Lat       *float64 `json:"lat,omitempty"`
+	Lon       *float64 `json:"lon,omitempty"`
+	UTM       *utmJSON `json:"utm,omitempty"`
+	UTMError  string   `json:"utm_error,omitempty"`
+	MGRS      []string `json:"mgrs,omitempty"` // Precision 1 to 5.
+	MGRSError string   `json:"mgrs_error,omitempty"`
+	USNG      []string `json:"usng,omitempty"`
+	USNGError string   `json:"usng_error,omitempty"`
+	Error     string   `json:"error,omitempty"` // Input not understood.  Nothing else is there.
 }
 
 func main() {
-	if len(os.Args) != 3 {
-		usage()
+	var asJSON = false
+	var args []string
+
+	// Not pflag, which would take a negative longitude for an option.
+	for _, arg := range os.Args[1:] {
+		switch arg {
+		case "--json", "-j":
+			asJSON = true
+		case "--help", "-h":
+			usage()
+			return
+		default:
+			args = append(args, arg)
+		}
+	}
+
+	if len(args) == 1 && args[0] == "-" {
+		if !batch(os.Stdin, asJSON) {
+			os.Exit(1)
+		}
+
 		return
 	}
 
-	var lat, latErr = strconv.ParseFloat(os.Args[1], 64)
-	if latErr != nil {
-		fmt.Printf("Invalid latitude: %s\n\n", latErr)
+	if len(args) != 2 {
 		usage()
+		return
+	}
+
+	var c = convert(args)
+	if c.Error != "" {
+		if asJSON {
+			print_json(c)
+		} else {
+			fmt.Printf("%s\n\n", c.Error)
+			usage()
+		}
+
 		os.Exit(1)
 	}
 
-	var lon, lonErr = strconv.ParseFloat(os.Args[2], 64)
+	if asJSON {
+		print_json(c)
+	} else {
+		print_text(c)
+	}
+}
+
+/*
+ * One location per line from r, latitude and longitude separated by
+ * spaces or a comma.  Blank lines and lines starting with # are skipped.
+ * Returns false if any couldn't be understood.
+ */
+
+func batch(r io.Reader, asJSON bool) bool {
+	var ok = true
+
+	var scanner = bufio.NewScanner(r)
+	for scanner.Scan() {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var c = convert(strings.Fields(strings.ReplaceAll(line, ",", " ")))
+		c.Input = line
+
+		if c.Error != "" {
+			ok = false
+		}
+
+		if asJSON {
+			print_json(c)
+
+			continue
+		}
+
+		fmt.Printf("%s\n", line)
+
+		if c.Error != "" {
+			fmt.Printf("%s\n", c.Error)
+		} else {
+			print_text(c)
+		}
+
+		fmt.Printf("\n")
+	}
+
+	return ok
+}
+
+func parse_ll(fields []string) (float64, float64, error) {
+	if len(fields) != 2 {
+		return 0, 0, errors.New("expected latitude and longitude")
+	}
+
+	var lat, latErr = strconv.ParseFloat(fields[0], 64)
+	if latErr != nil {
+		return 0, 0, fmt.Errorf("invalid latitude: %w", latErr)
+	}
+
+	var lon, lonErr = strconv.ParseFloat(fields[1], 64)
 	if lonErr != nil {
-		fmt.Printf("Invalid longitude: %s\n\n", lonErr)
-		usage()
-		os.Exit(1)
+		return 0, 0, fmt.Errorf("invalid longitude: %w", lonErr)
 	}
 
-	var latlng = s2.LatLng{
-		Lat: s1.Angle(D2R(lat)),
-		Lng: s1.Angle(D2R(lon)),
+	return lat, lon, nil
+}
+
+func convert(fields []string) conversion {
+	var c conversion
+	c.Input = strings.Join(fields, " ")
+
+	var lat, lon, err = parse_ll(fields)
+	if err != nil {
+		c.Error = err.Error()
+
+		return c
 	}
 
+	c.Lat = &lat
+	c.Lon = &lon
+
 	// UTM
-	var utmCoord, utmErr = coordconv.DefaultUTMConverter.ConvertFromGeodetic(latlng, 0)
+	var utmCoord, utmErr = geo.ToUTM(lat, lon)
 	if utmErr == nil {
-		fmt.Printf("UTM zone = %d, hemisphere = %c, easting = %.0f, northing = %.0f\n", utmCoord.Zone, direwolf.HemisphereToRune(utmCoord.Hemisphere), utmCoord.Easting, utmCoord.Northing)
+		c.UTM = &utmJSON{
+			Zone:       utmCoord.Zone,
+			Hemisphere: string(geo.HemisphereToRune(utmCoord.Hemisphere)),
+			Easting:    math.Round(utmCoord.Easting),
+			Northing:   math.Round(utmCoord.Northing),
+		}
 	} else {
-		fmt.Printf("Conversion to UTM failed:\n%s\n\n", utmErr)
+		c.UTMError = utmErr.Error()
 
 		// Others could still succeed, keep going.
 	}
 
 	// Practice run with MGRS to see if it will succeed
 
-	var _, mgrsErr = coordconv.DefaultMGRSConverter.ConvertFromGeodetic(latlng, 5)
+	var _, mgrsErr = geo.ToMGRS(lat, lon, 5)
 	if mgrsErr == nil {
 		// OK, hope changing precision doesn't make a difference.
-		fmt.Printf("MGRS =")
+		for precision := 1; precision <= 5; precision++ {
+			var mgrs, _ = geo.ToMGRS(lat, lon, precision)
+			c.MGRS = append(c.MGRS, mgrs)
+		}
+	} else {
+		c.MGRSError = mgrsErr.Error()
+	}
+
+	// Same again for USNG.
 
+	var _, usngErr = geo.ToUSNG(lat, lon, 5)
+	if usngErr == nil {
 		for precision := 1; precision <= 5; precision++ {
-			var mgrsCoord, _ = coordconv.DefaultMGRSConverter.ConvertFromGeodetic(latlng, precision)
-			fmt.Printf("  %s", mgrsCoord)
+			var usng, _ = geo.ToUSNG(lat, lon, precision)
+			c.USNG = append(c.USNG, usng)
+		}
+	} else {
+		c.USNGError = usngErr.Error()
+	}
+
+	return c
+}
+
+func print_text(c conversion) {
+	if c.UTM != nil {
+		fmt.Printf("UTM zone = %d, hemisphere = %s, easting = %.0f, northing = %.0f\n", c.UTM.Zone, c.UTM.Hemisphere, c.UTM.Easting, c.UTM.Northing)
+	} else {
+		fmt.Printf("Conversion to UTM failed:\n%s\n\n", c.UTMError)
+	}
+
+	if c.MGRSError == "" {
+		fmt.Printf("MGRS =")
+
+		for _, m := range c.MGRS {
+			fmt.Printf("  %s", m)
 		}
 
 		fmt.Printf("\n")
 	} else {
-		fmt.Printf("Conversion to MGRS failed:\n%s\n", mgrsErr)
+		fmt.Printf("Conversion to MGRS failed:\n%s\n", c.MGRSError)
+	}
+
+	if c.USNGError == "" {
+		fmt.Printf("USNG =")
+
+		for _, u := range c.USNG {
+			fmt.Printf("  %s", u)
+		}
+
+		fmt.Printf("\n")
+	} else {
+		fmt.Printf("Conversion to USNG failed:\n%s\n", c.USNGError)
 	}
 }
 
+func print_json(c conversion) {
+	var b, _ = json.Marshal(c)
+	fmt.Printf("%s\n", b)
+}
+
 func usage() {
 	fmt.Printf("Latitude / Longitude to UTM conversion\n")
 	fmt.Printf("\n")
 	fmt.Printf("Usage:\n")
-	fmt.Printf("\tll2utm  latitude  longitude\n")
+	fmt.Printf("\tll2utm  [--json]  latitude  longitude\n")
+	fmt.Printf("\tll2utm  [--json]  -\n")
 	fmt.Printf("\n")
 	fmt.Printf("where,\n")
 	fmt.Printf("\tLatitude and longitude are in decimal degrees.\n")
 	fmt.Printf("\t   Use negative for south or west.\n")
 	fmt.Printf("\n")
-	fmt.Printf("Example:\n")
+	fmt.Printf("\t- reads one location per line from stdin, separated by spaces or a comma.\n")
+	fmt.Printf("\n")
+	fmt.Printf("\t--json gives a line of JSON for each location.\n")
+	fmt.Printf("\n")
+	fmt.Printf("Examples:\n")
 	fmt.Printf("\tll2utm 42.662139 -71.365553\n")
+	fmt.Printf("\tll2utm --json - < locations.txt\n")
 }
@@ -1,6 +1,10 @@
 package main
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"strings"
+)
 
 // Examples from ll2utm.c source, checked against direwolf Debian package
 
@@ -18,4 +22,34 @@ func Example_main_1() {
 	// Output:
 	// UTM zone = 19, hemisphere = N, easting = 306130, northing = 4726010
 	// MGRS =  19TCH02  19TCH0626  19TCH061260  19TCH06132600  19TCH0613026009
+	// USNG =  19T CH 0 2  19T CH 06 26  19T CH 061 260  19T CH 0613 2600  19T CH 06130 26009
+}
+
+func Example_main_json() {
+	os.Args = []string{"ll2utm", "--json", "42.662139", "-71.365553"}
+
+	main()
+	// Output:
+	// {"input":"42.662139 -71.365553","lat":42.662139,"lon":-71.365553,"utm":{"zone":19,"hemisphere":"N","easting":306130,"northing":4726010},"mgrs":["19TCH02","19TCH0626","19TCH061260","19TCH06132600","19TCH0613026009"],"usng":["19T CH 0 2","19T CH 06 26","19T CH 061 260","19T CH 0613 2600","19T CH 06130 26009"]}
+}
+
+func Example_batch() {
+	batch(strings.NewReader("# Westford\n42.662139 -71.365553\n\nnowhere\n"), false)
+	// Output:
+	// 42.662139 -71.365553
+	// UTM zone = 19, hemisphere = N, easting = 306130, northing = 4726010
+	// MGRS =  19TCH02  19TCH0626  19TCH061260  19TCH06132600  19TCH0613026009
+	// USNG =  19T CH 0 2  19T CH 06 26  19T CH 061 260  19T CH 0613 2600  19T CH 06130 26009
+	//
+	// nowhere
+	// expected latitude and longitude
+}
+
+func Example_batch_json() {
+	var ok = batch(strings.NewReader("-33.8568,151.2153\n42.6,west\n"), true)
+	fmt.Println(ok)
+	// Output:
+	// {"input":"-33.8568,151.2153","lat":-33.8568,"lon":151.2153,"utm":{"zone":56,"hemisphere":"S","easting":334901,"northing":6252289},"mgrs":["56HLH35","56HLH3452","56HLH349522","56HLH34905228","56HLH3490052288"],"usng":["56H LH 3 5","56H LH 34 52","56H LH 349 522","56H LH 3490 5228","56H LH 34900 52288"]}
+	// {"input":"42.6,west","error":"invalid longitude: strconv.ParseFloat: parsing \"west\": invalid syntax"}
+	// false
 }
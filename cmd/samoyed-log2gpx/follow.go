@@ -0,0 +1,206 @@
+package main
+
+/*
+ * --follow mode, for live tracking at an event.
+ *
+ * The log file is read from the start, then checked once a second
+ * for more.  Only complete lines are used, so a line still being
+ * written isn't mistaken for a short record.  Whenever something new
+ * arrives, the whole output is generated again and written to the
+ * output file and/or kept to be served over HTTP.  The output file is
+ * written under another name and renamed, so anything reading it
+ * never sees half a file.
+ *
+ * Given a directory, such as LOGDIR from the configuration file, the
+ * file for the current day is followed, moving on to the next one at
+ * midnight UTC to match the way the log files are named.
+ */
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+const FOLLOW_INTERVAL = time.Second
+
+var content_types = map[string]string{
+	"gpx":     "application/gpx+xml",
+	"geojson": "application/geo+json",
+}
+
+type follower struct {
+	path    string // As given: a log file, or a directory of daily log files.
+	is_dir  bool
+	current string // File now being read.
+	offset  int64  // How much of it has been used.
+}
+
+/*
+ * Read any complete lines added since last time.
+ * Returns true if there are new things.
+ */
+
+func (f *follower) poll() (bool, error) {
+	var name = f.path
+	if f.is_dir {
+		name = filepath.Join(f.path, time.Now().UTC().Format("2006-01-02.log"))
+	}
+
+	if name != f.current {
+		f.current = name
+		f.offset = 0
+	}
+
+	var fp, err = os.Open(name) //nolint:gosec
+	if err != nil {
+		if f.is_dir && errors.Is(err, fs.ErrNotExist) {
+			return false, nil // Nothing heard yet today.
+		}
+
+		return false, err
+	}
+
+	defer fp.Close()
+
+	var info, statErr = fp.Stat()
+	if statErr != nil {
+		return false, statErr
+	}
+
+	if info.Size() < f.offset {
+		f.offset = 0 // Truncated or replaced.  Start again.
+	}
+
+	if info.Size() == f.offset {
+		return false, nil
+	}
+
+	var data = make([]byte, info.Size()-f.offset)
+
+	var n, readErr = fp.ReadAt(data, f.offset)
+	if readErr != nil && !errors.Is(readErr, io.EOF) {
+		return false, readErr
+	}
+
+	var end = bytes.LastIndexByte(data[:n], '\n')
+	if end < 0 {
+		return false, nil
+	}
+
+	f.offset += int64(end + 1)
+
+	var before = len(things)
+
+	if err := read_csv(bytes.NewReader(data[:end+1])); err != nil {
+		return len(things) > before, fmt.Errorf("%s: %w", name, err)
+	}
+
+	return len(things) > before, nil
+}
+
+/*
+ * Latest output, for the HTTP server.
+ */
+
+type follow_output struct {
+	mu           sync.Mutex
+	data         []byte
+	content_type string
+}
+
+func (o *follow_output) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	o.mu.Lock()
+	var data = o.data
+	o.mu.Unlock()
+
+	w.Header().Set("Content-Type", o.content_type)
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Access-Control-Allow-Origin", "*") // So a map page served from elsewhere can fetch it.
+	_, _ = w.Write(data)
+}
+
+/*
+ * Write the output file so it is never seen half written.
+ */
+
+func write_replace(name string, data []byte) error {
+	var tmp = name + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o644); err != nil { //nolint:gosec // Meant to be shared.
+		return err
+	}
+
+	return os.Rename(tmp, name)
+}
+
+/*
+ * Only returns if it can't get started.
+ */
+
+func follow_log(path string, format string, write func(w io.Writer), output_file string, http_addr string) error {
+	var info, err = os.Stat(path)
+	if err != nil {
+		return err
+	}
+
+	var f = new(follower)
+	f.path = path
+	f.is_dir = info.IsDir()
+
+	var latest = new(follow_output)
+	latest.content_type = content_types[format]
+
+	if http_addr != "" {
+		var listener, listenErr = net.Listen("tcp", http_addr)
+		if listenErr != nil {
+			return listenErr
+		}
+
+		fmt.Fprintf(os.Stderr, "Serving %s at http://%s/\n", format, listener.Addr())
+
+		var server = new(http.Server)
+		server.Handler = latest
+		server.ReadHeaderTimeout = 10 * time.Second
+
+		go func() {
+			var serveErr = server.Serve(listener)
+			fmt.Fprintf(os.Stderr, "HTTP server stopped: %s\n", serveErr)
+			os.Exit(1)
+		}()
+	}
+
+	fmt.Fprintf(os.Stderr, "Following %s ...\n", path)
+
+	for first := true; ; first = false {
+		var changed, pollErr = f.poll()
+		if pollErr != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", pollErr)
+		}
+
+		if changed || first {
+			var buf bytes.Buffer
+			write(&buf)
+
+			latest.mu.Lock()
+			latest.data = buf.Bytes()
+			latest.mu.Unlock()
+
+			if output_file != "" {
+				if err := write_replace(output_file, buf.Bytes()); err != nil {
+					fmt.Fprintf(os.Stderr, "Can't write %s: %s\n", output_file, err)
+				}
+			}
+		}
+
+		time.Sleep(FOLLOW_INTERVAL)
+	}
+}
@@ -0,0 +1,117 @@
+package main
+
+/*
+ * GeoJSON (RFC 7946) output, for web maps which don't read GPX.
+ *
+ * Each entity gets a Point feature for its last known position and,
+ * if it moved, a LineString feature for its track, the same as the
+ * waypoint and track in GPX.  A track with gaps in it is a
+ * MultiLineString, like the GPX track segments.
+ */
+
+import (
+	"encoding/json"
+	"io"
+)
+
+type geojson_geometry struct {
+	Type        string `json:"type"`
+	Coordinates any    `json:"coordinates"`
+}
+
+type geojson_feature struct {
+	Type       string           `json:"type"`
+	Geometry   geojson_geometry `json:"geometry"`
+	Properties map[string]any   `json:"properties"`
+}
+
+type geojson_collection struct {
+	Type     string            `json:"type"`
+	Features []geojson_feature `json:"features"`
+}
+
+/*
+ * Note that GeoJSON puts longitude first.
+ */
+
+func geojson_position(thing *thing_t) []float64 {
+	if thing.alt != UNKNOWN_VALUE {
+		return []float64{thing.lon, thing.lat, thing.alt}
+	}
+
+	return []float64{thing.lon, thing.lat}
+}
+
+func write_geojson(w io.Writer) {
+	var collection = geojson_collection{
+		Type:     "FeatureCollection",
+		Features: []geojson_feature{},
+	}
+
+	group_things(func(first int, last int) {
+		var segments = [][][]float64{nil}
+
+		var moved bool
+
+		for i := first; i <= last; i++ {
+			if things[i].lat != things[first].lat || things[i].lon != things[first].lon {
+				moved = true
+			}
+
+			if i > first && new_segment(&things[i-1], &things[i]) {
+				segments = append(segments, nil)
+			}
+
+			var n = len(segments) - 1
+			segments[n] = append(segments[n], geojson_position(&things[i]))
+		}
+
+		if moved {
+			var geometry = geojson_geometry{Type: "LineString", Coordinates: segments[0]}
+			if len(segments) > 1 {
+				geometry = geojson_geometry{Type: "MultiLineString", Coordinates: segments}
+			}
+
+			collection.Features = append(collection.Features, geojson_feature{
+				Type:     "Feature",
+				Geometry: geometry,
+				Properties: map[string]any{
+					"name":  things[first].name,
+					"start": things[first].time,
+					"end":   things[last].time,
+				},
+			})
+		}
+
+		var properties = map[string]any{
+			"name": things[last].name,
+			"time": things[last].time,
+		}
+
+		if things[last].speed != UNKNOWN_VALUE {
+			properties["speed"] = things[last].speed
+		}
+
+		if things[last].course != UNKNOWN_VALUE {
+			properties["course"] = things[last].course
+		}
+
+		if len(things[last].desc) > 0 {
+			properties["desc"] = things[last].desc
+		}
+
+		if len(things[last].comment) > 0 {
+			properties["comment"] = things[last].comment
+		}
+
+		collection.Features = append(collection.Features, geojson_feature{
+			Type:       "Feature",
+			Geometry:   geojson_geometry{Type: "Point", Coordinates: geojson_position(&things[last])},
+			Properties: properties,
+		})
+	})
+
+	var encoder = json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(collection)
+}
@@ -11,6 +11,9 @@ import (
 	"slices"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
 )
 
 /*
@@ -33,33 +36,108 @@ var things []thing_t
 
 const UNKNOWN_VALUE = float64(-999) /* Special value to indicate unknown altitude, speed, course. */
 
+/*
+ * A track is split into segments where nothing was heard for longer than this,
+ * rather than joining the two ends with a straight line.  0 never splits.
+ */
+
+const DEFAULT_SEGMENT_GAP = 30 * time.Minute
+
+var segment_gap = DEFAULT_SEGMENT_GAP
+
 func KNOTS_TO_METERS_PER_SEC(x float64) float64 {
 	return ((x) * 0.51444444444)
 }
 
+/*
+ * Output formats, selected with --format.
+ */
+
+var writers = map[string]func(w io.Writer){
+	"gpx":     write_gpx,
+	"geojson": write_geojson,
+}
+
 func main() {
+	var flags = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+	var format = flags.StringP("format", "f", "gpx", "Output format: gpx or geojson.")
+	var outputFile = flags.StringP("output-file", "o", "", "Write to this file rather than stdout.")
+	var follow = flags.Bool("follow", false, "Keep reading a growing log file and update the output as it changes.\nGive one log file, or a LOGDIR directory to follow each day's file.")
+	var gap = flags.Duration("gap", DEFAULT_SEGMENT_GAP, "Start a new track segment after this long with no reports.  0 for never.")
+	var httpAddr = flags.String("http", "", "With --follow, serve the latest output over HTTP at this address, e.g. :8080.")
+	var help = flags.BoolP("help", "h", false, "Display help text.")
+
+	flags.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s - convert packet log files to GPX or GeoJSON.\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Usage: log2gpx [options] [ file ... ]\n")
+		fmt.Fprintf(os.Stderr, "       log2gpx --follow [options] { file | directory }\n")
+		flags.PrintDefaults()
+	}
+
+	_ = flags.Parse(os.Args[1:])
+
+	if *help {
+		flags.Usage()
+		os.Exit(1)
+	}
+
+	segment_gap = *gap
+
+	var write, ok = writers[*format]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "Unknown output format %s.  Use gpx or geojson.\n", *format)
+		os.Exit(1)
+	}
+
+	if *follow {
+		if flags.NArg() != 1 {
+			fmt.Fprintf(os.Stderr, "--follow needs exactly one log file or directory.\n")
+			os.Exit(1)
+		}
+
+		if *outputFile == "" && *httpAddr == "" {
+			fmt.Fprintf(os.Stderr, "--follow needs --output-file or --http, or both.\n")
+			os.Exit(1)
+		}
+
+		var err = follow_log(flags.Arg(0), *format, write, *outputFile, *httpAddr)
+		fmt.Fprintf(os.Stderr, "%s\n", err)
+		os.Exit(1)
+	}
+
+	if *httpAddr != "" {
+		fmt.Fprintf(os.Stderr, "--http is only for use with --follow.\n")
+		os.Exit(1)
+	}
+
 	/*
 	 * Read files listed or stdin if none.
 	 */
-	if len(os.Args) == 1 {
-		read_csv(os.Stdin)
-	} else {
-		for i, arg := range os.Args {
-			if i == 0 {
-				continue
-			}
+	var args = flags.Args()
+	if len(args) == 0 {
+		args = []string{"-"}
+	}
 
-			if arg == "-" {
-				read_csv(os.Stdin)
-			} else {
-				var fp, err = os.Open(arg) //nolint:gosec
-				if err == nil {
-					read_csv(fp)
-				} else {
-					fmt.Fprintf(os.Stderr, "Can't open %s for read: %s\n", arg, err)
-					os.Exit(1)
-				}
+	for _, arg := range args {
+		var err error
+
+		if arg == "-" {
+			err = read_csv(os.Stdin)
+		} else {
+			var fp, openErr = os.Open(arg) //nolint:gosec
+			if openErr != nil {
+				fmt.Fprintf(os.Stderr, "Can't open %s for read: %s\n", arg, openErr)
+				os.Exit(1)
 			}
+
+			err = read_csv(fp)
+			fp.Close()
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Can't read %s: %s\n", arg, err)
+			os.Exit(1)
 		}
 	}
 
@@ -68,11 +146,30 @@ func main() {
 		os.Exit(1)
 	}
 
-	/*
-	 * Sort the data so everything for the same name is adjacent and
-	 * in order of time.
-	 */
+	var w io.Writer = os.Stdout
+
+	if *outputFile != "" {
+		var f, err = os.Create(*outputFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Could not create %s: %s\n", *outputFile, err)
+			os.Exit(1)
+		}
+
+		defer f.Close()
+
+		w = f
+	}
+
+	write(w)
+}
 
+/*
+ * Sort the data so everything for the same name is adjacent and
+ * in order of time, then call fn for each group of records with
+ * the same name.
+ */
+
+func group_things(fn func(first int, last int)) {
 	slices.SortFunc(things, func(a, b thing_t) int {
 		if n := strings.Compare(a.name, b.name); n != 0 {
 			return n
@@ -91,12 +188,6 @@ func main() {
 	//    things[i].name);
 	//}
 
-	/*
-	 * GPX file header.
-	 */
-	fmt.Printf("<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n")
-	fmt.Printf("<gpx version=\"1.1\" creator=\"Dire Wolf\">\n")
-
 	/*
 	 * Group together all records for the same entity.
 	 */
@@ -108,30 +199,47 @@ func main() {
 			last++
 		}
 
-		process_things(first, last)
+		fn(first, last)
 		first = last + 1
 	}
+}
+
+func write_gpx(w io.Writer) {
+	/*
+	 * GPX file header.
+	 */
+	fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n")
+	fmt.Fprintf(w, "<gpx version=\"1.1\" creator=\"Dire Wolf\">\n")
+
+	group_things(func(first int, last int) {
+		process_things(w, first, last)
+	})
 
 	/*
 	 *  GPX file tail.
 	 */
-	fmt.Printf("</gpx>\n")
+	fmt.Fprintf(w, "</gpx>\n")
 }
 
 /*
  * Read from given file, already open, into things array.
  */
-func read_csv(fp *os.File) {
+func read_csv(fp io.Reader) error {
 	var reader = csv.NewReader(fp)
+	reader.FieldsPerRecord = -1 // Columns have been added over time.
 
 	for {
 		var fields, err = reader.Read()
 		if errors.Is(err, io.EOF) {
-			break
+			return nil
 		}
 
 		if err != nil {
-			panic(err)
+			return err
+		}
+
+		if len(fields) < 22 {
+			return fmt.Errorf("expected 22 fields, found %d", len(fields))
 		}
 
 		var pchan = fields[0]
@@ -296,6 +404,26 @@ func read_csv(fp *os.File) {
 	}
 }
 
+/*
+ * Should a track be broken between these two consecutive reports?
+ * Times which can't be understood never break it.
+ */
+
+func new_segment(prev *thing_t, next *thing_t) bool {
+	if segment_gap <= 0 {
+		return false
+	}
+
+	var t1, err1 = time.Parse(time.RFC3339, prev.time)
+
+	var t2, err2 = time.Parse(time.RFC3339, next.time)
+	if err1 != nil || err2 != nil {
+		return false
+	}
+
+	return t2.Sub(t1) > segment_gap
+}
+
 /*
  * Prepare text values for XML.
  * Replace significant characters with "predefined entities."
@@ -329,7 +457,7 @@ func xml_text(in string) string {
  * For moving entities, generate a GPX track.
  */
 
-func process_things(first int, last int) {
+func process_things(w io.Writer, first int, last int) {
 	var moved bool
 
 	for i := first + 1; i <= last; i++ {
@@ -350,39 +478,44 @@ func process_things(first int, last int) {
 
 		var safe_comment = xml_text(things[first].comment)
 
-		fmt.Printf("  <trk>\n")
-		fmt.Printf("    <name>%s</name>\n", safe_name)
-		fmt.Printf("    <trkseg>\n")
+		fmt.Fprintf(w, "  <trk>\n")
+		fmt.Fprintf(w, "    <name>%s</name>\n", safe_name)
+		fmt.Fprintf(w, "    <trkseg>\n")
 
 		for i := first; i <= last; i++ {
-			fmt.Printf("      <trkpt lat=\"%.6f\" lon=\"%.6f\">\n", things[i].lat, things[i].lon)
+			if i > first && new_segment(&things[i-1], &things[i]) {
+				fmt.Fprintf(w, "    </trkseg>\n")
+				fmt.Fprintf(w, "    <trkseg>\n")
+			}
+
+			fmt.Fprintf(w, "      <trkpt lat=\"%.6f\" lon=\"%.6f\">\n", things[i].lat, things[i].lon)
 
 			if things[i].speed != UNKNOWN_VALUE {
-				fmt.Printf("        <speed>%.1f</speed>\n", things[i].speed)
+				fmt.Fprintf(w, "        <speed>%.1f</speed>\n", things[i].speed)
 			}
 
 			if things[i].course != UNKNOWN_VALUE {
-				fmt.Printf("        <course>%.1f</course>\n", things[i].course)
+				fmt.Fprintf(w, "        <course>%.1f</course>\n", things[i].course)
 			}
 
 			if things[i].alt != UNKNOWN_VALUE {
-				fmt.Printf("        <ele>%.1f</ele>\n", things[i].alt)
+				fmt.Fprintf(w, "        <ele>%.1f</ele>\n", things[i].alt)
 			}
 
 			if len(things[i].desc) > 0 {
-				fmt.Printf("        <desc>%s</desc>\n", things[i].desc)
+				fmt.Fprintf(w, "        <desc>%s</desc>\n", things[i].desc)
 			}
 
 			if len(safe_comment) > 0 {
-				fmt.Printf("        <cmt>%s</cmt>\n", safe_comment)
+				fmt.Fprintf(w, "        <cmt>%s</cmt>\n", safe_comment)
 			}
 
-			fmt.Printf("        <time>%s</time>\n", things[i].time)
-			fmt.Printf("      </trkpt>\n")
+			fmt.Fprintf(w, "        <time>%s</time>\n", things[i].time)
+			fmt.Fprintf(w, "      </trkpt>\n")
 		}
 
-		fmt.Printf("    </trkseg>\n")
-		fmt.Printf("  </trk>\n")
+		fmt.Fprintf(w, "    </trkseg>\n")
+		fmt.Fprintf(w, "  </trk>\n")
 	}
 
 	// Future possibility?
@@ -395,20 +528,20 @@ func process_things(first int, last int) {
 
 	var safe_comment = xml_text(things[last].comment)
 
-	fmt.Printf("  <wpt lat=\"%.6f\" lon=\"%.6f\">\n", things[last].lat, things[last].lon)
+	fmt.Fprintf(w, "  <wpt lat=\"%.6f\" lon=\"%.6f\">\n", things[last].lat, things[last].lon)
 
 	if things[last].alt != UNKNOWN_VALUE {
-		fmt.Printf("    <ele>%.1f</ele>\n", things[last].alt)
+		fmt.Fprintf(w, "    <ele>%.1f</ele>\n", things[last].alt)
 	}
 
 	if len(things[last].desc) > 0 {
-		fmt.Printf("    <desc>%s</desc>\n", things[last].desc)
+		fmt.Fprintf(w, "    <desc>%s</desc>\n", things[last].desc)
 	}
 
 	if len(safe_comment) > 0 {
-		fmt.Printf("    <cmt>%s</cmt>\n", safe_comment)
+		fmt.Fprintf(w, "    <cmt>%s</cmt>\n", safe_comment)
 	}
 
-	fmt.Printf("    <name>%s</name>\n", safe_name)
-	fmt.Printf("  </wpt>\n")
+	fmt.Fprintf(w, "    <name>%s</name>\n", safe_name)
+	fmt.Fprintf(w, "  </wpt>\n")
 }
@@ -1,10 +1,17 @@
 package main
 
 import (
+	"bytes"
+	"encoding/json"
 	"io"
 	"os"
+	"path/filepath"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 // TODO Break down log2gpx into something easier to test...!
@@ -100,3 +107,126 @@ chan,utime,isotime,source,heard,level,error,dti,name,symbol,latitude,longitude,s
 		t.Errorf("Expected %q, got %q", expected, output.String())
 	}
 }
+
+const test_header = "chan,utime,isotime,source,heard,level,error,dti,name,symbol,latitude,longitude,speed,course,altitude,frequency,offset,tone,system,status,telemetry,comment\n"
+
+func Test_follower_poll(t *testing.T) {
+	things = nil
+
+	var f = new(follower)
+	f.path = filepath.Join(t.TempDir(), "2025-07-10.log")
+
+	var line1 = "0,1752183342,2025-07-10T21:35:42Z,Q1TEST-9,Q1TEST-9,198(105/99),0,0,Q1TEST-9,/j,1,1,1,,,,,,,,,\n"
+	var line2 = "0,1752183362,2025-07-10T21:36:02Z,Q1TEST-9,Q1TEST-9,198(105/99),0,0,Q1TEST-9,/j,2,2,2,,,,,,,,,\n"
+
+	// Second line only partly written so far.
+	require.NoError(t, os.WriteFile(f.path, []byte(test_header+line1+line2[:20]), 0o600))
+
+	var changed, err = f.poll()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Len(t, things, 1)
+
+	changed, err = f.poll()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	var fp, openErr = os.OpenFile(f.path, os.O_APPEND|os.O_WRONLY, 0)
+	require.NoError(t, openErr)
+	_, err = fp.WriteString(line2[20:])
+	require.NoError(t, err)
+	require.NoError(t, fp.Close())
+
+	changed, err = f.poll()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	require.Len(t, things, 2)
+	assert.InDelta(t, 2.0, things[1].lat, 0.0001)
+}
+
+func Test_follower_poll_directory(t *testing.T) {
+	things = nil
+
+	var f = new(follower)
+	f.path = t.TempDir()
+	f.is_dir = true
+
+	// Nothing logged yet today is not an error.
+	var changed, err = f.poll()
+	require.NoError(t, err)
+	assert.False(t, changed)
+
+	var today = filepath.Join(f.path, time.Now().UTC().Format("2006-01-02.log"))
+	require.NoError(t, os.WriteFile(today, []byte(test_header+
+		"0,1752183342,2025-07-10T21:35:42Z,Q1TEST,Q1TEST,198(105/99),0,0,Q1TEST,/-,1,1,,,,,,,,,,\n"), 0o600))
+
+	changed, err = f.poll()
+	require.NoError(t, err)
+	assert.True(t, changed)
+	assert.Len(t, things, 1)
+}
+
+func Test_write_geojson(t *testing.T) {
+	things = nil
+
+	require.NoError(t, read_csv(strings.NewReader(test_header+
+		"0,1752183342,2025-07-10T21:35:42Z,Q1TEST-9,Q1TEST-9,198(105/99),0,0,Q1TEST-9,/j,1,1,,,,,,,,,,\n"+
+		"0,1752183362,2025-07-10T21:36:02Z,Q1TEST-9,Q1TEST-9,198(105/99),0,0,Q1TEST-9,/j,2,2,,,100,,,,,,,\n"+
+		"0,1752183362,2025-07-10T21:36:02Z,Q2TEST,Q2TEST,198(105/99),0,0,Q2TEST,/-,3,4,,,,,,,,,,hello\n")))
+
+	var buf bytes.Buffer
+	write_geojson(&buf)
+
+	var collection geojson_collection
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &collection))
+
+	assert.Equal(t, "FeatureCollection", collection.Type)
+	require.Len(t, collection.Features, 3)
+
+	assert.Equal(t, "LineString", collection.Features[0].Geometry.Type)
+	assert.Equal(t, "Q1TEST-9", collection.Features[0].Properties["name"])
+
+	assert.Equal(t, "Point", collection.Features[1].Geometry.Type)
+	assert.Equal(t, []any{2.0, 2.0, 100.0}, collection.Features[1].Geometry.Coordinates)
+
+	assert.Equal(t, []any{4.0, 3.0}, collection.Features[2].Geometry.Coordinates)
+	assert.Equal(t, "hello", collection.Features[2].Properties["comment"])
+}
+
+func Test_track_segments(t *testing.T) {
+	var input = test_header +
+		"0,1,2025-07-10T21:00:00Z,Q1TEST-9,Q1TEST-9,1,0,0,Q1TEST-9,/j,1,1,,,,,,,,,,\n" +
+		"0,1,2025-07-10T21:10:00Z,Q1TEST-9,Q1TEST-9,1,0,0,Q1TEST-9,/j,2,2,,,,,,,,,,\n" +
+		"0,1,2025-07-10T23:10:00Z,Q1TEST-9,Q1TEST-9,1,0,0,Q1TEST-9,/j,3,3,,,,,,,,,,\n"
+
+	defer func() { segment_gap = DEFAULT_SEGMENT_GAP }()
+
+	t.Run("gpx", func(t *testing.T) {
+		things = nil
+		require.NoError(t, read_csv(strings.NewReader(input)))
+
+		var buf bytes.Buffer
+		write_gpx(&buf)
+		assert.Equal(t, 2, strings.Count(buf.String(), "<trkseg>"))
+
+		segment_gap = 0
+		buf.Reset()
+		write_gpx(&buf)
+		assert.Equal(t, 1, strings.Count(buf.String(), "<trkseg>"))
+
+		segment_gap = DEFAULT_SEGMENT_GAP
+	})
+
+	t.Run("geojson", func(t *testing.T) {
+		things = nil
+		require.NoError(t, read_csv(strings.NewReader(input)))
+
+		var buf bytes.Buffer
+		write_geojson(&buf)
+
+		var collection geojson_collection
+		require.NoError(t, json.Unmarshal(buf.Bytes(), &collection))
+		assert.Equal(t, "MultiLineString", collection.Features[0].Geometry.Type)
+		assert.Len(t, collection.Features[0].Geometry.Coordinates, 2)
+	})
+}
@@ -0,0 +1,72 @@
+/* Latitude / Longitude to and from Maidenhead locator conversion */
+package main
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/doismellburning/samoyed/src/geo"
+)
+
+func main() {
+	switch len(os.Args) {
+	case 2:
+		// Locator to latitude / longitude.
+		var lat, lon, err = geo.FromGridSquare(os.Args[1])
+		if err != nil {
+			fmt.Printf("Conversion from Maidenhead failed:\n%s\n", err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("from Maidenhead, latitude = %.6f, longitude = %.6f\n", lat, lon)
+	case 3:
+		var lat, latErr = strconv.ParseFloat(os.Args[1], 64)
+		if latErr != nil {
+			fmt.Printf("Invalid latitude: %s\n\n", latErr)
+			usage()
+			os.Exit(1)
+		}
+
+		var lon, lonErr = strconv.ParseFloat(os.Args[2], 64)
+		if lonErr != nil {
+			fmt.Printf("Invalid longitude: %s\n\n", lonErr)
+			usage()
+			os.Exit(1)
+		}
+
+		fmt.Printf("Maidenhead =")
+
+		for chars := 4; chars <= 10; chars += 2 {
+			var mh, err = geo.ToGridSquare(lat, lon, chars)
+			if err != nil {
+				fmt.Printf("\nConversion to Maidenhead failed:\n%s\n", err)
+				os.Exit(1)
+			}
+
+			fmt.Printf("  %s", mh)
+		}
+
+		fmt.Printf("\n")
+	default:
+		usage()
+	}
+}
+
+func usage() {
+	fmt.Printf("Latitude / Longitude to and from Maidenhead locator conversion\n")
+	fmt.Printf("\n")
+	fmt.Printf("Usage:\n")
+	fmt.Printf("\tmheadconv  latitude  longitude\n")
+	fmt.Printf("\tmheadconv  locator\n")
+	fmt.Printf("\n")
+	fmt.Printf("where,\n")
+	fmt.Printf("\tLatitude and longitude are in decimal degrees.\n")
+	fmt.Printf("\t   Use negative for south or west.\n")
+	fmt.Printf("\tLocator is 2 to 12 characters, e.g. FN42 or FN42hp.\n")
+	fmt.Printf("\t   The center of the square is given.\n")
+	fmt.Printf("\n")
+	fmt.Printf("Examples:\n")
+	fmt.Printf("\tmheadconv 42.662139 -71.365553\n")
+	fmt.Printf("\tmheadconv FN42HP68\n")
+}
@@ -0,0 +1,31 @@
+package main
+
+import "os"
+
+func Example_main_1() {
+	os.Args = []string{"mheadconv", "42.662139", "-71.365553"}
+
+	main()
+	// Output: Maidenhead =  FN42  FN42HP  FN42HP68  FN42HP68DV
+}
+
+func Example_main_2() {
+	os.Args = []string{"mheadconv", "FN42HP68"}
+
+	main()
+	// Output: from Maidenhead, latitude = 42.660417, longitude = -71.362500
+}
+
+func Example_main_3() {
+	os.Args = []string{"mheadconv", "fn42hp"} // Handle lowercase letters
+
+	main()
+	// Output: from Maidenhead, latitude = 42.645833, longitude = -71.375000
+}
+
+func Example_main_4() {
+	os.Args = []string{"mheadconv", "-33.8688", "151.2093"}
+
+	main()
+	// Output: Maidenhead =  QF56  QF56OD  QF56OD51  QF56OD51CL
+}
@@ -0,0 +1,186 @@
+package main
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Keep track of each connection being tested.
+ *
+ * Description:	A link is a connection from one TNC, given by its
+ *		position on the command line counting from 0, to another.
+ *		It can go through digipeaters.  For example
+ *
+ *			0-1
+ *			0-2 via DW1
+ *			1-2 via WIDE1-1,Q1TEST-3
+ *
+ *		A TNC can be in several links at once, e.g. 0-1 and 0-2,
+ *		to test multiple concurrent connections.  A TNC on a
+ *		serial port can only be in one, because there is nothing
+ *		to tell us which connection its output is for.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+type link_t struct {
+	tnc         [2]int   /* TNC at each end.  tnc[0] starts the connection. */
+	via         []string /* Digipeaters between. */
+	description string
+
+	/* Everything below is for each end, the same as tnc. */
+
+	connected [2]bool
+
+	sent [2]int /* Data packets sent. */
+
+	rec_send_seq [2]int /* Each data packet will contain a sequence number. */
+	/* This is used to verify that all have been */
+	/* received in the correct order. */
+
+	rec_reply_seq [2]int /* Same for the replies. */
+
+	send_errors  [2]int /* Data packets received out of order or damaged. */
+	reply_errors [2]int /* Same for the replies. */
+
+	pending [2]string /* Partial line received from the network. */
+
+	outstanding [2]int /* Frames sent but not yet acknowledged, from the AGW 'Y' reply. */
+	/* -1 while waiting for the reply. */
+}
+
+var links []*link_t
+
+/*
+ * Without any links given, the first TNC connects to each of the others.
+ */
+
+func default_links(n int) []string {
+	var specs []string
+
+	for j := 1; j < n; j++ {
+		specs = append(specs, fmt.Sprintf("0-%d", j))
+	}
+
+	return specs
+}
+
+func parse_link(spec string, n int) (*link_t, error) {
+	var fields = strings.Fields(spec)
+
+	if len(fields) != 1 && (len(fields) != 3 || !strings.EqualFold(fields[1], "via")) {
+		return nil, fmt.Errorf("link \"%s\" should be like 0-1 or 0-2 via DIGI", spec)
+	}
+
+	var a, b, found = strings.Cut(fields[0], "-")
+	if !found {
+		return nil, fmt.Errorf("link \"%s\" should be like 0-1 or 0-2 via DIGI", spec)
+	}
+
+	var l = new(link_t)
+	l.description = spec
+
+	for i, s := range []string{a, b} {
+		var j, err = strconv.Atoi(s)
+		if err != nil || j < 0 || j >= n {
+			return nil, fmt.Errorf("link \"%s\": TNC number must be 0 to %d", spec, n-1)
+		}
+
+		l.tnc[i] = j
+	}
+
+	if l.tnc[0] == l.tnc[1] {
+		return nil, fmt.Errorf("link \"%s\" is from a TNC to itself", spec)
+	}
+
+	if len(fields) == 3 {
+		for digi := range strings.SplitSeq(fields[2], ",") {
+			if digi == "" || len(digi) > 9 {
+				return nil, fmt.Errorf("link \"%s\": \"%s\" is not a valid digipeater", spec, digi)
+			}
+
+			l.via = append(l.via, strings.ToUpper(digi))
+		}
+
+		if len(l.via) > 7 {
+			return nil, fmt.Errorf("link \"%s\": no more than 7 digipeaters", spec)
+		}
+	}
+
+	return l, nil
+}
+
+/*
+ * Set up links from their descriptions.  serial[j] is true for a
+ * TNC on a serial port.
+ */
+
+func make_links(specs []string, serial []bool) ([]*link_t, error) {
+	var n = len(serial)
+
+	if len(specs) == 0 {
+		return nil, errors.New("no links to test")
+	}
+
+	var result []*link_t
+
+	var count = make([]int, n)
+
+	for _, spec := range specs {
+		var l, err = parse_link(spec, n)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, other := range result {
+			if (other.tnc[0] == l.tnc[0] && other.tnc[1] == l.tnc[1]) || (other.tnc[0] == l.tnc[1] && other.tnc[1] == l.tnc[0]) {
+				return nil, fmt.Errorf("links \"%s\" and \"%s\" are between the same TNCs", other.description, spec)
+			}
+		}
+
+		count[l.tnc[0]]++
+		count[l.tnc[1]]++
+
+		result = append(result, l)
+	}
+
+	for j := range n {
+		if count[j] == 0 {
+			return nil, fmt.Errorf("TNC %d isn't in any link", j)
+		}
+
+		if serial[j] && count[j] > 1 {
+			return nil, fmt.Errorf("TNC %d is on a serial port so it can only be in one link", j)
+		}
+	}
+
+	return result, nil
+}
+
+/*
+ * Find which link, and which end of it, a TNC is talking about.
+ * For a serial port TNC, there is only one.
+ */
+
+func find_link(my_index int, peer string) (*link_t, int) {
+	for _, l := range links {
+		for end := range 2 {
+			if l.tnc[end] != my_index {
+				continue
+			}
+
+			if peer == "" || strings.EqualFold(peer, tnc_address[l.tnc[1-end]]) {
+				return l, end
+			}
+		}
+	}
+
+	return nil, 0
+}
+
+func (l *link_t) name(end int) string {
+	return tnc_address[l.tnc[end]] + " -> " + tnc_address[l.tnc[1-end]]
+}
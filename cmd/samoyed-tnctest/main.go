@@ -3,12 +3,19 @@ package main
 
 /*------------------------------------------------------------------
  *
- * Purpose:   	Test AX.25 connected mode between two TNCs.
+ * Purpose:   	Test AX.25 connected mode between two or more TNCs.
  *
  * Description:	The first TNC will connect to the second TNC and send a bunch of data.
  *		Proper transfer of data will be verified.
  *
- * Usage:	tnctest  [options]  port0=name0  port1=name1
+ *		With more than two TNCs, the first connects to each of
+ *		the others at the same time, unless other links are
+ *		given with --link.  See link.go.
+ *
+ * Usage:	tnctest  [options]  port0=name0  port1=name1 ...
+ *
+ *		What is sent can be changed with options or a
+ *		scenario file.  See scenario.go.
  *
  * Example:	tnctest  localhost:8000=direwolf  COM1=KPC-3+
  *
@@ -52,18 +59,16 @@ import (
 
 	direwolf "github.com/doismellburning/samoyed/src"
 	"github.com/pkg/term"
+	"github.com/spf13/pflag"
 )
 
-const MAX_TNC = 2 // Just 2 for now.
-// Could be more later for multiple concurrent connections.
+const MAX_TNC = 10
 
 const LINE_WIDTH = 120
 
 /* Obtained from the command line. */
 
 var num_tnc int /* How many TNCs for this test? */
-/* Initially only 2 but long term we might */
-/* enhance it to allow multiple concurrent connections. */
 
 var column_width int
 
@@ -91,39 +96,116 @@ var tnc_address [MAX_TNC]string /* Name of the TNC used in the frames.  Original
  * Current state for each TNC.
  */
 
-var is_connected [MAX_TNC]int = [MAX_TNC]int{-1, -1} /* -1 = not yet available. */
-/* 0 = not connected. */
-/* 1 = not connected. */
+var is_available [MAX_TNC]bool /* Set when ready to go. */
+/* Connections are in links. */
 
 var have_cmd_prompt [MAX_TNC]bool /* Set if "cmd:" was the last thing seen. */
 
-var last_rec_seq [MAX_TNC]int /* Each data packet will contain a sequence number. */
-/* This is used to verify that all have been */
-/* received in the correct order. */
-
 /*
  * Start time so we can print relative elapsed time.
  */
 
 var start_time time.Time
 
-var max_count int
+var scen *scenario_t
 
 const ETX_BREAK = "\003\003\003"
 
 func main() {
-	// max_count = 20
-	// max_count = 200
-	// max_count = 6
-	// max_count = 1000
-	max_count = 9999
-
 	start_time = time.Now()
 
+	var scenarioFile = pflag.String("scenario", "", "Read the test scenario from this YAML file.  Options below override it.")
+	var count = pflag.IntP("count", "n", 0, "Number of data frames from each sender.  Default 9999.")
+	var sizes = pflag.IntSlice("size", nil, fmt.Sprintf("Data frame sizes in bytes, used in turn.  Minimum and default %d.", MIN_FRAME_SIZE))
+	var burst = pflag.IntSlice("burst", nil, "Frames in each burst, used in turn.  Default 1.")
+	var ramp = pflag.Bool("ramp", false, "After the burst list is used up, keep making bursts one frame larger.  Default if no scenario.")
+	var delay = pflag.Int("delay", 0, "Milliseconds to wait after each burst.  Default 3000.")
+	var frameDelay = pflag.Int("frame-delay", 0, "Additional milliseconds to wait for each frame in the burst.  Default 1000.")
+	var paclen = pflag.Int("paclen", 0, "Set PACLEN on serial port TNCs.")
+	var direction = pflag.String("direction", "", "Which way data is sent on each link: forward (from the TNC which connects), reverse, or both.")
+	var noAlphabet = pflag.Bool("no-alphabet", false, "Don't send the A, AB, ABC, ... lines used to check segmentation.")
+	var linkSpecs = pflag.StringArray("link", nil, "Connection to test, e.g. \"0-1\" or \"0-2 via DW1\".  Repeat for several at once.  Default is from the first TNC to each of the others.")
+	var help = pflag.BoolP("help", "h", false, "Display help text.")
+
+	pflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Test AX.25 connected mode between two or more TNCs.\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Usage: %s [OPTION]... port0=name0 port1=name1 ...\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Each port is host:tcp-port or tcp-port for AGW network protocol, or a serial port name.\n")
+		fmt.Fprintf(os.Stderr, "TNCs are numbered from 0 in the order given.\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		pflag.PrintDefaults()
+	}
+
+	pflag.Parse()
+
+	if *help {
+		pflag.Usage()
+		os.Exit(0)
+	}
+
+	scen = default_scenario()
+
+	if *scenarioFile != "" {
+		var err error
+
+		scen, err = read_scenario(*scenarioFile)
+		if err != nil {
+			fmt.Printf("Scenario: %s\n", err)
+			os.Exit(1)
+		}
+	}
+
+	if pflag.CommandLine.Changed("count") {
+		scen.Count = *count
+	}
+
+	if pflag.CommandLine.Changed("size") {
+		scen.Sizes = *sizes
+	}
+
+	if pflag.CommandLine.Changed("burst") {
+		scen.Burst = *burst
+		scen.Ramp = *ramp
+	} else if pflag.CommandLine.Changed("ramp") {
+		scen.Ramp = *ramp
+	}
+
+	if pflag.CommandLine.Changed("delay") {
+		scen.DelayMS = *delay
+	}
+
+	if pflag.CommandLine.Changed("frame-delay") {
+		scen.FrameDelayMS = *frameDelay
+	}
+
+	if pflag.CommandLine.Changed("paclen") {
+		scen.Paclen = *paclen
+	}
+
+	if pflag.CommandLine.Changed("direction") {
+		scen.Direction = *direction
+	}
+
+	if *noAlphabet {
+		scen.Alphabet = false
+	}
+
+	if pflag.CommandLine.Changed("link") {
+		scen.Links = *linkSpecs
+	}
+
+	var checkErr = scen.check()
+	if checkErr != nil {
+		fmt.Printf("Scenario: %s\n", checkErr)
+		os.Exit(1)
+	}
+
 	/*
 	 * Extract command line args.
 	 */
-	num_tnc = len(os.Args) - 1
+	num_tnc = pflag.NArg()
 
 	if num_tnc < 2 || num_tnc > MAX_TNC {
 		fmt.Printf("Specify minimum 2, maximum %d TNCs on the command line.\n", MAX_TNC)
@@ -138,9 +220,9 @@ func main() {
 
 	for j := range num_tnc {
 		/* Each command line argument should be of the form "port=description." */
-		var parts = strings.Split(os.Args[j+1], "=")
+		var parts = strings.Split(pflag.Arg(j), "=")
 		if len(parts) != 2 {
-			fmt.Printf("Internal error 1\n")
+			fmt.Printf("Expected port=description, not \"%s\".\n", pflag.Arg(j))
 			os.Exit(1)
 		}
 
@@ -156,9 +238,7 @@ func main() {
 			hostname[j] = portParts[0]
 			port[j] = portParts[1]
 		}
-	}
 
-	for j := range num_tnc {
 		/* If port begins with digit, consider it to be TCP. */
 		/* Otherwise, treat as serial port name. */
 		tnctest_using_tcp[j] = unicode.IsDigit(rune(port[j][0]))
@@ -170,8 +250,31 @@ func main() {
 		} else {
 			tnc_address[j] = fmt.Sprintf("TNC%d", j)
 		}
+	}
+
+	if scen.Links == nil {
+		scen.Links = default_links(num_tnc)
+	}
+
+	var serial = make([]bool, num_tnc)
+	for j := range num_tnc {
+		serial[j] = !tnctest_using_tcp[j]
+	}
 
+	var linkErr error
+
+	links, linkErr = make_links(scen.Links, serial)
+	if linkErr != nil {
+		fmt.Printf("%s\n", linkErr)
+		os.Exit(1)
+	}
+
+	for j := range num_tnc {
 		if tnctest_using_tcp[j] {
+			if scen.Paclen > 0 {
+				fmt.Printf("TNC %d: PACLEN can't be set with the AGW network protocol.  Set it in the TNC configuration.\n", j)
+			}
+
 			go tnc_thread_net(j, hostname[j], port[j], description[j], tnc_address[j])
 		} else {
 			go tnc_thread_serial(j, port[j], description[j], tnc_address[j])
@@ -188,7 +291,7 @@ func main() {
 		ready = true
 
 		for j := range num_tnc {
-			if is_connected[j] < 0 {
+			if !is_available[j] {
 				ready = false
 			}
 		}
@@ -197,31 +300,30 @@ func main() {
 	fmt.Printf("Andiamo!\n")
 
 	/*
-	 * First, establish a connection from TNC number 0 to the other(s).
+	 * First, establish each connection.
 	 * Wait until successful.
 	 */
 
 	fmt.Printf("Trying to establish connection...\n")
 
-	tnc_connect(0, 1)
+	for _, l := range links {
+		tnc_connect(l)
+	}
 
 	var timeout = 600
-	for ready := false; !ready && timeout > 0; {
+	for !all_connected(true) && timeout > 0 {
 		direwolf.SLEEP_MS(100)
 
 		timeout--
-		ready = true
-
-		for j := range num_tnc {
-			if is_connected[j] <= 0 {
-				ready = false
-			}
-		}
 	}
 
 	if timeout == 0 {
 		fmt.Printf("ERROR: Gave up waiting for connect!\n")
-		tnc_disconnect(1, 0) // Tell other TNC.
+
+		for _, l := range links {
+			tnc_disconnect(l.tnc[1], l.tnc[0]) // Tell other TNC.
+		}
+
 		direwolf.SLEEP_MS(5000)
 		fmt.Printf("TEST FAILED!\n")
 		os.Exit(1)
@@ -236,52 +338,51 @@ func main() {
 
 	fmt.Printf("Send data...\n")
 
-	var send_count int
+	var senders = scen.senders()
+
+	for b := 0; links[0].sent[senders[0]] < scen.Count; b++ {
+		var burst_size = scen.burst_size(b)
 
-	var burst_size = 1
-	for send_count < max_count {
-		for n := 1; n <= burst_size && send_count < max_count; n++ {
-			send_count++
-			var data = fmt.Sprintf("%04d send data\r", send_count)
-			tnc_send_data(0, 1, data)
+		for _, l := range links {
+			for _, end := range senders {
+				for n := 1; n <= burst_size && l.sent[end] < scen.Count; n++ {
+					l.sent[end]++
+					tnc_send_data(l.tnc[end], l.tnc[1-end], scen.send_data(l.sent[end]))
+				}
+			}
 		}
 
-		direwolf.SLEEP_MS(3000 + 1000*burst_size)
-		// SLEEP_MS(3000 + 500 * burst_size);		// OK for low error rate
-		// SLEEP_MS(3000 + 3000 * burst_size);
-		// SLEEP_MS(3000);
+		for _, l := range links {
+			for _, end := range senders {
+				tnc_ask_outstanding(l, end)
+			}
+		}
 
-		burst_size++
+		time.Sleep(scen.burst_delay(burst_size))
 	}
 
 	/*
 	 * Hang around until we get last expected reply or there is too much time with no activity.
 	 */
 
-	var last0 = last_rec_seq[0]
-	var last1 = last_rec_seq[1]
+	var activity = received_total()
 	var no_activity = 0
 	var INACTIVE_TIMEOUT = 120
 
-	for last_rec_seq[0] != max_count && no_activity < INACTIVE_TIMEOUT {
+	for !all_replied(senders) && no_activity < INACTIVE_TIMEOUT {
 		direwolf.SLEEP_MS(1000)
 
 		no_activity++
 
-		if last_rec_seq[0] > last0 {
-			last0 = last_rec_seq[0]
-			no_activity = 0
-		}
-
-		if last_rec_seq[1] > last1 {
-			last1 = last_rec_seq[1]
+		if received_total() != activity {
+			activity = received_total()
 			no_activity = 0
 		}
 	}
 
 	var errors = 0
 
-	if last_rec_seq[0] == max_count {
+	if all_replied(senders) {
 		fmt.Printf("Got last expected reply.\n")
 	} else {
 		fmt.Printf("ERROR: Timeout - No incoming activity for %d seconds.\n", no_activity)
@@ -292,8 +393,37 @@ func main() {
 	/*
 	 * Did we get all expected replies?
 	 */
-	if last_rec_seq[0] != max_count {
-		fmt.Printf("ERROR: Last received reply was %d when we were expecting %d.\n", last_rec_seq[0], max_count)
+	for _, l := range links {
+		for _, end := range senders {
+			if l.rec_reply_seq[end] != scen.Count {
+				fmt.Printf("ERROR: %s: Last reply received was %d when we were expecting %d.\n", l.name(end), l.rec_reply_seq[end], scen.Count)
+
+				errors++
+			}
+		}
+	}
+
+	/*
+	 * Before disconnecting, make sure everything sent has been acknowledged.
+	 * Only the AGW network protocol can tell us.
+	 */
+
+	for timeout = 10; timeout > 0; timeout-- {
+		for _, l := range links {
+			for end := range 2 {
+				tnc_ask_outstanding(l, end)
+			}
+		}
+
+		direwolf.SLEEP_MS(1000)
+
+		if all_acknowledged() {
+			break
+		}
+	}
+
+	if timeout == 0 {
+		fmt.Printf("ERROR: Frames still not acknowledged before disconnect.\n")
 
 		errors++
 	}
@@ -302,30 +432,47 @@ func main() {
 	 * Ask for disconnect.  Wait until complete.
 	 */
 
-	tnc_disconnect(0, 1)
+	for _, l := range links {
+		tnc_disconnect(l.tnc[0], l.tnc[1])
+	}
 
 	timeout = 200 // 20 sec should be generous.
-	for ready := false; !ready && timeout > 0; {
+	for !all_connected(false) && timeout > 0 {
 		direwolf.SLEEP_MS(100)
 
 		timeout--
-		ready = true
-
-		for j := range num_tnc {
-			if is_connected[j] != 0 {
-				ready = false
-			}
-		}
 	}
 
 	if timeout == 0 {
 		fmt.Printf("ERROR: Gave up waiting for disconnect!\n")
-		tnc_reset(1, 0) // Don't leave TNC in bad state for next time.
+
+		for _, l := range links {
+			tnc_reset(l.tnc[1], l.tnc[0]) // Don't leave TNC in bad state for next time.
+		}
+
 		direwolf.SLEEP_MS(10000)
 
 		errors++
 	}
 
+	var rows []summary_row
+
+	for _, l := range links {
+		for _, end := range senders {
+			rows = append(rows, summary_row{
+				name:     l.name(end),
+				sent:     l.sent[end],
+				received: l.rec_send_seq[1-end],
+				replies:  l.rec_reply_seq[end],
+				errors:   l.send_errors[1-end] + l.reply_errors[end],
+			})
+		}
+	}
+
+	if !write_summary(os.Stdout, rows, scen.Count, time.Since(start_time)) {
+		errors++
+	}
+
 	if errors != 0 {
 		fmt.Printf("TEST FAILED!\n")
 		os.Exit(1)
@@ -335,6 +482,60 @@ func main() {
 	os.Exit(0)
 }
 
+/*
+ * Are both ends of every link connected, or disconnected?
+ */
+
+func all_connected(want bool) bool {
+	for _, l := range links {
+		if l.connected[0] != want || l.connected[1] != want {
+			return false
+		}
+	}
+
+	return true
+}
+
+/*
+ * Has the other end acknowledged everything each AGW TNC sent?
+ */
+
+func all_acknowledged() bool {
+	for _, l := range links {
+		for end := range 2 {
+			if tnctest_using_tcp[l.tnc[end]] && l.outstanding[end] != 0 {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
+func received_total() int {
+	var total = 0
+
+	for _, l := range links {
+		for end := range 2 {
+			total += l.rec_send_seq[end] + l.rec_reply_seq[end]
+		}
+	}
+
+	return total
+}
+
+func all_replied(senders []int) bool {
+	for _, l := range links {
+		for _, end := range senders {
+			if l.rec_reply_seq[end] != scen.Count {
+				return false
+			}
+		}
+	}
+
+	return true
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        process_rec_data
@@ -342,60 +543,124 @@ func main() {
  * Purpose:     Look for our data with text sequence numbers, not to be
  *		confused with the AX.25 I frame sequence numbers.
  *
- * Inputs:	my_index	- 0 for the call originator.
- *				  >1 for the other end which answers.
+ * Inputs:	l		- Link it was received on.
+ *
+ *		end		- Which end received it.
+ *				  0 for the call originator.
+ *				  1 for the other end which answers.
  *
- *		data		- Should look something like this:
+ *		data		- One line, which should look something like this:
  *				   9999 send data
  *				   9999 reply
  *
- * Global In/Out:	last_rec_seq[my_index]
+ * Returns:	false if it isn't one of ours.
+ *
+ * Global In/Out:	l.rec_send_seq[end], l.rec_reply_seq[end]
  *
  * Description:	Look for expected format.
  *		Extract the sequence number.
  *		Verify that it is the next expected one.
  *		Update it.
+ *		Reply to data.
+ *
+ *		Errors are counted rather than stopping the test, so a
+ *		long run shows how many there were.  After a sequence
+ *		error, we continue from the number received.
  *
  *--------------------------------------------------------------------*/
 
-func process_rec_data(my_index int, data string) {
+func process_rec_data(l *link_t, end int, data string) bool {
+	var my_index = l.tnc[end]
+
 	data = strings.TrimSpace(data) // Remove trailing \r
 	var before, after, _ = strings.Cut(data, " ")
 
-	if strings.HasPrefix(after, "send") {
-		if my_index > 0 {
-			last_rec_seq[my_index]++
+	var n, numErr = strconv.Atoi(before)
 
-			var n, _ = strconv.Atoi(before)
-			if n != last_rec_seq[my_index] {
-				fmt.Printf("%*s%s: Received %d when %d was expected (%s).\n", my_index*column_width, "", tnc_address[my_index], n, last_rec_seq[my_index], data)
-				direwolf.SLEEP_MS(10000)
-				fmt.Printf("TEST FAILED!\n")
-				os.Exit(1)
-			}
+	switch {
+	case numErr == nil && len(before) == 4 && strings.HasPrefix(after, "send"):
+		l.rec_send_seq[end]++
+
+		if n != l.rec_send_seq[end] {
+			fmt.Printf("%*s%s: Received %d when %d was expected.\n", my_index*column_width, "", l.name(1-end), n, l.rec_send_seq[end])
+
+			l.send_errors[end]++
+			l.rec_send_seq[end] = n
+		} else if data != strings.TrimSpace(scen.send_data(n)) {
+			fmt.Printf("%*s%s: Frame %d is not what was sent (%s).\n", my_index*column_width, "", l.name(1-end), n, data)
+
+			l.send_errors[end]++
 		}
-	} else if strings.HasPrefix(after, "reply") {
-		if my_index == 0 {
-			last_rec_seq[my_index]++
 
-			var n, _ = strconv.Atoi(before)
-			if n != last_rec_seq[my_index] {
-				fmt.Printf("%*s%s: Received %d when %d was expected.\n", my_index*column_width, "", tnc_address[my_index], n, last_rec_seq[my_index])
-				direwolf.SLEEP_MS(10000)
-				fmt.Printf("TEST FAILED!\n")
-				os.Exit(1)
+		// Expected message.  Send reply.
+		var reply = fmt.Sprintf("%04d reply\r", n)
+		tnc_send_data(my_index, l.tnc[1-end], reply)
+
+		// HACK!
+		// It gets very confusing because N(S) and N(R) are very close.
+		// Send a couple dozen I frames so they will be easier to distinguish visually.
+
+		// We change the length each time to test segmentation.
+		// Set PACLEN to some very small number like 5.
+
+		if n == 1 && scen.Alphabet && scen.Count > 1 {
+			for j := 1; j <= 26; j++ {
+				var reply = fmt.Sprintf("%.*s\r", j, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
+				tnc_send_data(my_index, l.tnc[1-end], reply)
 			}
 		}
-	} else if strings.HasPrefix(data, "A") {
+	case numErr == nil && len(before) == 4 && strings.HasPrefix(after, "reply"):
+		l.rec_reply_seq[end]++
+
+		if n != l.rec_reply_seq[end] {
+			fmt.Printf("%*s%s: Received %d when %d was expected.\n", my_index*column_width, "", l.name(1-end), n, l.rec_reply_seq[end])
+
+			l.reply_errors[end]++
+			l.rec_reply_seq[end] = n
+		}
+	case strings.HasPrefix(data, "A"):
 		if !strings.HasPrefix("ABCDEFGHIJKLMNOPQRSTUVWXYZ", data) { //nolint:gocritic
-			fmt.Printf("%*s%s: Segmentation is broken.\n", my_index*column_width, "", tnc_address[my_index])
-			direwolf.SLEEP_MS(10000)
-			fmt.Printf("TEST FAILED!\n")
-			os.Exit(1)
+			fmt.Printf("%*s%s: Segmentation is broken.\n", my_index*column_width, "", l.name(1-end))
+
+			l.reply_errors[end]++
 		}
-	} else {
-		panic("Unexpected data: " + data)
+	default:
+		return false
+	}
+
+	return true
+}
+
+type summary_row struct {
+	name     string // From -> to.
+	sent     int
+	received int // By the other end.
+	replies  int // Received back.
+	errors   int // Out of order or damaged, at either end.
+}
+
+/*
+ * Table at the end of the test.  Returns false if anything is missing.
+ */
+
+func write_summary(w io.Writer, rows []summary_row, count int, elapsed time.Duration) bool {
+	var ok = true
+
+	fmt.Fprintf(w, "\n%-14s %8s %8s %8s %8s  %s\n", "Direction", "Sent", "Received", "Replies", "Errors", "Result")
+
+	for _, r := range rows {
+		var result = "OK"
+		if r.sent != count || r.received != count || r.replies != count || r.errors != 0 {
+			result = "FAILED"
+			ok = false
+		}
+
+		fmt.Fprintf(w, "%-14s %8d %8d %8d %8d  %s\n", r.name, r.sent, r.received, r.replies, r.errors, result)
 	}
+
+	fmt.Fprintf(w, "\n%d frames expected from each sender.  Elapsed time %s.\n\n", count, elapsed.Round(time.Second))
+
+	return ok
 }
 
 /*-------------------------------------------------------------------
@@ -410,7 +675,7 @@ func process_rec_data(my_index int, data string) {
  *				  and sent to a common function to check that they
  *				  all arrived in order.
  *
- * Global Out:	is_connected	- Updated when connected/disconnected notfications are received.
+ * Global Out:	links		- Updated when connected/disconnected notifications are received.
  *
  * Description:	Perform any necessary configuration for the TNC then wait
  *		for responses and process them.
@@ -468,7 +733,7 @@ func tnc_thread_net(my_index int, hostname string, port string, description stri
 	 */
 	fmt.Printf("TNC %d now available.  %s on %s, port %s\n",
 		my_index, description, hostname, port)
-	is_connected[my_index] = 0
+	is_available[my_index] = true
 
 	/*
 	 * Print what we get from TNC.
@@ -499,50 +764,99 @@ func tnc_thread_net(my_index int, hostname string, port string, description stri
 		 * What did we get?
 		 */
 
+		/*
+		 * For connected mode, the other station is the source.
+		 */
+
+		var peer = direwolf.ByteArrayToString(mon_cmd.CallFrom[:])
+
+		if mon_cmd.DataKind == 'Y' {
+			// The 'Y' reply can have the calls either way around.
+			peer = outstanding_peer(tnc_address, direwolf.ByteArrayToString(mon_cmd.CallTo[:]), peer)
+		}
+
+		var l, end = find_link(my_index, peer)
+
 		switch mon_cmd.DataKind {
 		case 'C': // AX.25 Connection Received
-			fmt.Printf("%*s[R %.3f] *** Connected to %s ***\n", my_index*column_width, "", time.Since(start_time).Seconds(), mon_cmd.CallFrom)
-			is_connected[my_index] = 1
+			fmt.Printf("%*s[R %.3f] *** Connected to %s ***\n", my_index*column_width, "", time.Since(start_time).Seconds(), peer)
+
+			if l != nil {
+				l.connected[end] = true
+			}
 		case 'D': // Connected AX.25 Data
 			fmt.Printf("%*s[R %.3f] %s\n", my_index*column_width, "", time.Since(start_time).Seconds(), data)
 
-			process_rec_data(my_index, string(data))
+			if l == nil {
+				fmt.Printf("%*s%s: Data from unexpected station %s.\n", my_index*column_width, "", tnc_address, peer)
+
+				continue
+			}
 
-			var before, after, _ = strings.Cut(string(data), " ")
+			// A line could be split across frames, e.g. with a small PACLEN.
+			l.pending[end] += string(data)
 
-			if unicode.IsDigit(rune(before[0])) && unicode.IsDigit(rune(before[1])) && unicode.IsDigit(rune(before[2])) && unicode.IsDigit(rune(before[3])) &&
-				strings.HasPrefix(after, "send") {
-				// Expected message.   Make sure it is expected sequence and send reply.
-				var n, _ = strconv.Atoi(before)
-				var reply = fmt.Sprintf("%04d reply\r", n)
-				tnc_send_data(my_index, 1-my_index, reply)
+			for {
+				var line, rest, found = strings.Cut(l.pending[end], "\r")
+				if !found {
+					break
+				}
 
-				// HACK!
-				// It gets very confusing because N(S) and N(R) are very close.
-				// Send a couple dozen I frames so they will be easier to distinguish visually.
-				// Currently don't have the same in serial port version.
+				l.pending[end] = rest
 
-				// We change the length each time to test segmentation.
-				// Set PACLEN to some very small number like 5.
+				if !process_rec_data(l, end, line) {
+					fmt.Printf("%*s%s: Unexpected data (%s).\n", my_index*column_width, "", l.name(1-end), line)
 
-				if n == 1 && max_count > 1 {
-					for j := 1; j <= 26; j++ {
-						var reply = fmt.Sprintf("%.*s\r", j, "ABCDEFGHIJKLMNOPQRSTUVWXYZ")
-						tnc_send_data(my_index, 1-my_index, reply)
-					}
+					l.reply_errors[end]++
 				}
 			}
 		case 'd': // Disconnected
-			fmt.Printf("%*s[R %.3f] *** Disconnected from %s ***\n", my_index*column_width, "", time.Since(start_time).Seconds(), mon_cmd.CallFrom)
-			is_connected[my_index] = 0
+			fmt.Printf("%*s[R %.3f] *** Disconnected from %s ***\n", my_index*column_width, "", time.Since(start_time).Seconds(), peer)
+
+			if l != nil {
+				l.connected[end] = false
+			}
 		case 'y': // Outstanding frames waiting on a Port
-			fmt.Printf("%*s[R %.3f] *** Outstanding frames waiting %d ***\n", my_index*column_width, "", time.Since(start_time).Seconds(), 123) // TODO
+			fmt.Printf("%*s[R %.3f] *** Outstanding frames waiting on port %d: %d ***\n", my_index*column_width, "", time.Since(start_time).Seconds(), mon_cmd.Portx, agw_count(data))
+		case 'Y': // Outstanding frames for a connection
+			var n = agw_count(data)
+
+			fmt.Printf("%*s[R %.3f] *** Outstanding frames for %s: %d ***\n", my_index*column_width, "", time.Since(start_time).Seconds(), peer, n)
+
+			if l != nil {
+				l.outstanding[end] = n
+			}
 		default:
 			// fmt.Printf("%*s[R %.3f] --- Ignoring cmd kind '%c' ---\n", my_index*column_width, "", time.Since(start_time).Seconds(), mon_cmd.DataKind);
 		}
 	}
 }
 
+/*
+ * The 'y' and 'Y' replies have a 32 bit little endian count.
+ */
+
+func agw_count(data []byte) int {
+	if len(data) < 4 {
+		return 0
+	}
+
+	return int(binary.LittleEndian.Uint32(data))
+}
+
+/*
+ * Other station in a 'Y' reply.  Which call is ours depends on who
+ * started the connection so take whichever isn't.
+ */
+
+func outstanding_peer(my_address string, call_to string, call_from string) string {
+	if strings.EqualFold(call_from, my_address) {
+		return call_to
+	}
+
+	return call_from
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        tnc_thread_serial
@@ -555,7 +869,7 @@ func tnc_thread_net(my_index int, hostname string, port string, description stri
  *				  and sent to a common function to check that they
  *				  all arrived in order.
  *
- * Global Out:	is_connected	- Updated when connected/disconnected notfications are received.
+ * Global Out:	links		- Updated when connected/disconnected notifications are received.
  *
  * Description:	Perform any necessary configuration for the TNC then wait
  *		for responses and process them.
@@ -592,6 +906,12 @@ func tnc_thread_serial(my_index int, port string, description string, tnc_addres
 
 	// Don't want to stop tty output when typing begins.
 
+	if scen.Paclen > 0 {
+		cmd = fmt.Sprintf("paclen %d\r", scen.Paclen)
+		direwolf.SerialPortWrite(tnctest_serial_fd[my_index], []byte(cmd))
+		direwolf.SLEEP_MS(200)
+	}
+
 	cmd = "flow off\r"
 	direwolf.SerialPortWrite(tnctest_serial_fd[my_index], []byte(cmd))
 
@@ -601,7 +921,7 @@ func tnc_thread_serial(my_index int, port string, description string, tnc_addres
 	/* Success. */
 
 	fmt.Printf("TNC %d now available.  %s on %s\n", my_index, description, port)
-	is_connected[my_index] = 0
+	is_available[my_index] = true
 
 	/*
 	 * Read and print.
@@ -646,12 +966,18 @@ func tnc_thread_serial(my_index int, port string, description string, tnc_addres
 		if len(result) > 0 {
 			fmt.Printf("%*s[R %.3f] %s\n", my_index*column_width, "", time.Since(start_time).Seconds(), result)
 
-			if result == "*** CONNECTED" {
-				is_connected[my_index] = 1
+			/* Serial port TNCs can only be in one link. */
+			var l, end = find_link(my_index, "")
+			if l == nil {
+				continue
+			}
+
+			if strings.HasPrefix(result, "*** CONNECTED") {
+				l.connected[end] = true
 			}
 
-			if result == "*** DISCONNECTED" {
-				is_connected[my_index] = 0
+			if strings.HasPrefix(result, "*** DISCONNECTED") {
+				l.connected[end] = false
 			}
 
 			if result == "Not while connected" {
@@ -660,23 +986,17 @@ func tnc_thread_serial(my_index int, port string, description string, tnc_addres
 				panic("???")
 			}
 
-			process_rec_data(my_index, result)
-
-			var before, after, _ = strings.Cut(result, " ")
-
-			if unicode.IsDigit(rune(before[0])) && unicode.IsDigit(rune(before[1])) && unicode.IsDigit(rune(before[2])) && unicode.IsDigit(rune(before[3])) &&
-				strings.HasPrefix(after, "send") {
-				// Expected message.   Make sure it is expected sequence and send reply.
-				var n, _ = strconv.Atoi(before)
-				var reply = fmt.Sprintf("%04d reply\r", n)
-				tnc_send_data(my_index, 1-my_index, reply)
-			}
+			// Anything not recognized is from the TNC itself.
+			process_rec_data(l, end, result)
 		}
 	}
 }
 
-func tnc_connect(from int, to int) {
-	fmt.Printf("%*s[T %.3f] *** Send connect request ***\n", from*column_width, "", time.Since(start_time).Seconds())
+func tnc_connect(l *link_t) {
+	var from = l.tnc[0]
+	var to = l.tnc[1]
+
+	fmt.Printf("%*s[T %.3f] *** Send connect request to %s ***\n", from*column_width, "", time.Since(start_time).Seconds(), tnc_address[to])
 
 	if tnctest_using_tcp[from] {
 		var cmd direwolf.AGWPEHeader
@@ -685,7 +1005,24 @@ func tnc_connect(from int, to int) {
 		copy(cmd.CallFrom[:], tnc_address[from])
 		copy(cmd.CallTo[:], tnc_address[to])
 
+		/* Digipeaters are number of them followed by 10 bytes for each. */
+
+		var via []byte
+
+		if len(l.via) > 0 {
+			cmd.DataKind = 'v'
+			via = make([]byte, 1+10*len(l.via))
+			via[0] = byte(len(l.via))
+
+			for j, digi := range l.via {
+				copy(via[1+10*j:], digi)
+			}
+
+			cmd.DataLen = uint32(len(via)) //nolint:gosec // No more than 71.
+		}
+
 		binary.Write(tnctest_server_sock[from], binary.LittleEndian, cmd)
+		writeFull(tnctest_server_sock[from], via)
 	} else {
 		if !have_cmd_prompt[from] {
 			var cmd string
@@ -702,6 +1039,10 @@ func tnc_connect(from int, to int) {
 		}
 
 		var cmd = fmt.Sprintf("connect %s\r", tnc_address[to])
+		if len(l.via) > 0 {
+			cmd = fmt.Sprintf("connect %s via %s\r", tnc_address[to], strings.Join(l.via, ","))
+		}
+
 		direwolf.SerialPortWrite(tnctest_serial_fd[from], []byte(cmd))
 	}
 }
@@ -737,6 +1078,35 @@ func tnc_disconnect(from int, to int) {
 	}
 }
 
+/*
+ * Ask how many frames are waiting on the port ('y') and not yet
+ * acknowledged on the link ('Y').  The replies are printed as they
+ * arrive and the link count goes in l.outstanding.
+ * Serial port TNCs have no way to tell us.
+ */
+
+func tnc_ask_outstanding(l *link_t, end int) {
+	var from = l.tnc[end]
+	var to = l.tnc[1-end]
+
+	if !tnctest_using_tcp[from] {
+		return
+	}
+
+	var cmd direwolf.AGWPEHeader
+
+	cmd.DataKind = 'y'
+	binary.Write(tnctest_server_sock[from], binary.LittleEndian, cmd)
+
+	l.outstanding[end] = -1
+
+	cmd.DataKind = 'Y'
+	copy(cmd.CallFrom[:], tnc_address[from])
+	copy(cmd.CallTo[:], tnc_address[to])
+
+	binary.Write(tnctest_server_sock[from], binary.LittleEndian, cmd)
+}
+
 func tnc_reset(from int, to int) {
 	_ = to // Upstream doesn't use it, it's not clear to me why / what it might have been for /KG
 
@@ -802,7 +1172,7 @@ func tnc_send_data(from int, to int, data string) {
 
 		if timeout == 0 {
 			fmt.Printf("ERROR: Gave up waiting while TNC busy.\n")
-			tnc_disconnect(0, 1)
+			tnc_disconnect(from, to)
 			direwolf.SLEEP_MS(5000)
 			fmt.Printf("TEST FAILED!\n")
 			os.Exit(1)
@@ -0,0 +1,201 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_default_scenario(t *testing.T) {
+	var s = default_scenario()
+	require.NoError(t, s.check())
+
+	// Same as before scenarios: bursts of 1, 2, 3, ... with 3 seconds plus 1 per frame between.
+	assert.Equal(t, 1, s.burst_size(0))
+	assert.Equal(t, 2, s.burst_size(1))
+	assert.Equal(t, 10, s.burst_size(9))
+	assert.Equal(t, 5*time.Second, s.burst_delay(2))
+	assert.Equal(t, "0042 send\r", s.send_data(42))
+	assert.Equal(t, []int{0}, s.senders())
+}
+
+func Test_read_scenario(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "soak.yaml")
+
+	require.NoError(t, os.WriteFile(path, []byte(`
+count: 20
+sizes: [10, 16]
+burst: [1, 3]
+ramp: false
+direction: both
+`), 0600))
+
+	var s, err = read_scenario(path)
+	require.NoError(t, err)
+
+	assert.Equal(t, 20, s.Count)
+	assert.Equal(t, 3000, s.DelayMS) // Default kept.
+	assert.Equal(t, []int{0, 1}, s.senders())
+
+	assert.Equal(t, []int{1, 3, 1, 3}, []int{s.burst_size(0), s.burst_size(1), s.burst_size(2), s.burst_size(3)})
+
+	assert.Equal(t, "0001 send\r", s.send_data(1))
+	assert.Equal(t, "0002 send abcde\r", s.send_data(2))
+	assert.Len(t, s.send_data(4), 16)
+
+	require.NoError(t, os.WriteFile(path, []byte("count: 20\nbursts: [1]\n"), 0600))
+	_, err = read_scenario(path)
+	require.ErrorContains(t, err, "bursts")
+
+	require.NoError(t, os.WriteFile(path, []byte("direction: sideways\n"), 0600))
+	_, err = read_scenario(path)
+	require.ErrorContains(t, err, "sideways")
+
+	require.NoError(t, os.WriteFile(path, []byte("sizes: [5]\n"), 0600))
+	_, err = read_scenario(path)
+	require.ErrorContains(t, err, "frame size")
+}
+
+func Test_process_rec_data_replies(t *testing.T) {
+	scen = default_scenario()
+	num_tnc = 2
+	tnc_address[0] = "DW0"
+	tnc_address[1] = "DW1"
+
+	var l = new(link_t)
+	l.tnc = [2]int{0, 1}
+
+	assert.True(t, process_rec_data(l, 0, "0001 reply\r"))
+	assert.True(t, process_rec_data(l, 0, "ABCD"))
+	assert.True(t, process_rec_data(l, 0, "0002 reply"))
+	assert.Equal(t, 2, l.rec_reply_seq[0])
+	assert.Equal(t, 0, l.reply_errors[0])
+
+	// Missing one is counted and then we carry on from there.
+	assert.True(t, process_rec_data(l, 0, "0004 reply"))
+	assert.True(t, process_rec_data(l, 0, "0005 reply"))
+	assert.Equal(t, 5, l.rec_reply_seq[0])
+	assert.Equal(t, 1, l.reply_errors[0])
+
+	assert.True(t, process_rec_data(l, 0, "ABD"))
+	assert.Equal(t, 2, l.reply_errors[0])
+
+	assert.False(t, process_rec_data(l, 0, "cmd:"))
+	assert.False(t, process_rec_data(l, 0, "*** CONNECTED"))
+
+	// Nothing for the other end.
+	assert.Equal(t, [2]int{5, 0}, l.rec_reply_seq)
+}
+
+func Test_agw_count(t *testing.T) {
+	assert.Equal(t, 0, agw_count(nil))
+	assert.Equal(t, 3, agw_count([]byte{3, 0, 0, 0}))
+	assert.Equal(t, 258, agw_count([]byte{2, 1, 0, 0}))
+}
+
+func Test_outstanding_peer(t *testing.T) {
+	assert.Equal(t, "DW1", outstanding_peer("DW0", "DW1", "DW0"))
+	assert.Equal(t, "DW1", outstanding_peer("DW0", "DW0", "DW1"), "other end started the connection")
+}
+
+func Test_all_acknowledged(t *testing.T) {
+	tnctest_using_tcp[0] = true
+	tnctest_using_tcp[1] = false
+
+	t.Cleanup(func() {
+		tnctest_using_tcp[0] = false
+		links = nil
+	})
+
+	var l = new(link_t)
+	l.tnc = [2]int{0, 1}
+	l.outstanding = [2]int{-1, 5}
+	links = []*link_t{l}
+
+	assert.False(t, all_acknowledged(), "waiting for the reply")
+
+	l.outstanding[0] = 2
+	assert.False(t, all_acknowledged())
+
+	l.outstanding[0] = 0
+	assert.True(t, all_acknowledged(), "serial port TNC can't tell us")
+}
+
+func Test_make_links(t *testing.T) {
+	var tcp = []bool{false, false, false}
+
+	var l, err = make_links(default_links(3), tcp)
+	require.NoError(t, err)
+	require.Len(t, l, 2)
+	assert.Equal(t, [2]int{0, 1}, l[0].tnc)
+	assert.Equal(t, [2]int{0, 2}, l[1].tnc)
+
+	l, err = make_links([]string{"0-1", "1-2 via dw0,Q1TEST-3"}, tcp)
+	require.NoError(t, err)
+	assert.Equal(t, [2]int{1, 2}, l[1].tnc)
+	assert.Equal(t, []string{"DW0", "Q1TEST-3"}, l[1].via)
+
+	for spec, msg := range map[string]string{
+		"0-3":          "0 to 2",
+		"1-1":          "itself",
+		"0-1 by 2":     "should be like",
+		"0-1 via ,DW2": "not a valid digipeater",
+	} {
+		_, err = make_links([]string{spec, "0-2"}, tcp)
+		require.ErrorContains(t, err, msg, spec)
+	}
+
+	_, err = make_links([]string{"0-1", "1-0", "0-2"}, tcp)
+	require.ErrorContains(t, err, "same TNCs")
+
+	_, err = make_links([]string{"0-1"}, tcp)
+	require.ErrorContains(t, err, "TNC 2 isn't in any link")
+
+	_, err = make_links([]string{"0-1", "0-2"}, []bool{true, false, false})
+	require.ErrorContains(t, err, "serial port")
+}
+
+func Test_find_link(t *testing.T) {
+	num_tnc = 3
+	tnc_address[0] = "DW0"
+	tnc_address[1] = "DW1"
+	tnc_address[2] = "DW2"
+
+	defer func() { links = nil }()
+
+	var err error
+
+	links, err = make_links([]string{"0-1", "2-0"}, []bool{false, false, false})
+	require.NoError(t, err)
+
+	var l, end = find_link(0, "DW2")
+	assert.Same(t, links[1], l)
+	assert.Equal(t, 1, end)
+
+	l, end = find_link(1, "DW0")
+	assert.Same(t, links[0], l)
+	assert.Equal(t, 1, end)
+	assert.Equal(t, "DW1 -> DW0", l.name(end))
+
+	l, _ = find_link(1, "DW2")
+	assert.Nil(t, l)
+}
+
+func Test_write_summary(t *testing.T) {
+	var out strings.Builder
+
+	var ok = write_summary(&out, []summary_row{
+		{name: "DW0 -> DW1", sent: 100, received: 100, replies: 100, errors: 0},
+		{name: "DW1 -> DW0", sent: 100, received: 100, replies: 98, errors: 1},
+	}, 100, 95*time.Minute)
+
+	assert.False(t, ok)
+	assert.Contains(t, out.String(), "DW0 -> DW1          100      100      100        0  OK\n")
+	assert.Contains(t, out.String(), "DW1 -> DW0          100      100       98        1  FAILED\n")
+	assert.Contains(t, out.String(), "Elapsed time 1h35m0s.")
+}
@@ -0,0 +1,192 @@
+package main
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Describe what tnctest should send, so it can be used
+ *		as a configurable soak test rather than one fixed run.
+ *
+ * Description:	A scenario can be read from a YAML file with --scenario
+ *		and any part of it overridden on the command line, e.g.
+ *
+ *			count: 500		# Data frames from each sender.
+ *			sizes: [10, 64, 256]	# Bytes in each frame, used in turn.
+ *			burst: [1, 2, 4]	# Frames sent together, used in turn.
+ *			ramp: false		# Keep increasing burst size after the list.
+ *			delay_ms: 3000		# Pause after each burst ...
+ *			frame_delay_ms: 1000	# ... plus this for each frame in it.
+ *			paclen: 5		# Sent to serial port TNCs.
+ *			direction: both		# forward, reverse, or both.
+ *			alphabet: true		# Lines of A, AB, ABC ... to check segmentation.
+ *			links: [0-1, 0-2 via DW1]	# See link.go.
+ *
+ *		With no scenario the original behavior is used:  9999
+ *		minimal frames, bursts of 1, 2, 3, ... frames, from the
+ *		first TNC to each of the others.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+const MIN_FRAME_SIZE = len("0000 send\r")
+
+type scenario_t struct {
+	Count        int      `yaml:"count"`
+	Sizes        []int    `yaml:"sizes"`
+	Burst        []int    `yaml:"burst"`
+	Ramp         bool     `yaml:"ramp"`
+	DelayMS      int      `yaml:"delay_ms"`
+	FrameDelayMS int      `yaml:"frame_delay_ms"`
+	Paclen       int      `yaml:"paclen"`
+	Direction    string   `yaml:"direction"`
+	Alphabet     bool     `yaml:"alphabet"`
+	Links        []string `yaml:"links"`
+}
+
+func default_scenario() *scenario_t {
+	return &scenario_t{
+		Count:        9999,
+		Sizes:        []int{MIN_FRAME_SIZE},
+		Burst:        []int{1},
+		Ramp:         true,
+		DelayMS:      3000,
+		FrameDelayMS: 1000,
+		Paclen:       0,
+		Direction:    "forward",
+		Alphabet:     true,
+		Links:        nil,
+	}
+}
+
+/*
+ * Values not in the file keep their defaults.
+ */
+
+func read_scenario(path string) (*scenario_t, error) {
+	var s = default_scenario()
+
+	var f, err = os.Open(path) //nolint:gosec // Named on the command line.
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var decoder = yaml.NewDecoder(f)
+	decoder.KnownFields(true)
+
+	err = decoder.Decode(s)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return s, s.check()
+}
+
+func (s *scenario_t) check() error {
+	if s.Count < 1 || s.Count > 9999 {
+		return fmt.Errorf("count must be 1 to 9999, not %d", s.Count)
+	}
+
+	if len(s.Sizes) == 0 {
+		return errors.New("sizes must have at least one value")
+	}
+
+	for _, size := range s.Sizes {
+		if size < MIN_FRAME_SIZE || size > 2048 {
+			return fmt.Errorf("frame size must be %d to 2048, not %d", MIN_FRAME_SIZE, size)
+		}
+	}
+
+	if len(s.Burst) == 0 {
+		return errors.New("burst must have at least one value")
+	}
+
+	for _, b := range s.Burst {
+		if b < 1 {
+			return fmt.Errorf("burst size must be at least 1, not %d", b)
+		}
+	}
+
+	if s.DelayMS < 0 || s.FrameDelayMS < 0 {
+		return errors.New("delays can't be negative")
+	}
+
+	if s.Paclen < 0 || s.Paclen > 256 {
+		return fmt.Errorf("paclen must be 1 to 256, or 0 to leave alone, not %d", s.Paclen)
+	}
+
+	if _, ok := scenarioDirections[s.Direction]; !ok {
+		return fmt.Errorf("direction must be forward, reverse, or both, not \"%s\"", s.Direction)
+	}
+
+	return nil
+}
+
+/* Which end of each link sends data, for each direction. */
+
+var scenarioDirections = map[string][]int{
+	"forward": {0},
+	"reverse": {1},
+	"both":    {0, 1},
+}
+
+func (s *scenario_t) senders() []int {
+	return scenarioDirections[s.Direction]
+}
+
+/*
+ * Size of burst number n, counting from 0.
+ */
+
+func (s *scenario_t) burst_size(n int) int {
+	if n < len(s.Burst) {
+		return s.Burst[n]
+	}
+
+	if s.Ramp {
+		return s.Burst[len(s.Burst)-1] + n - len(s.Burst) + 1
+	}
+
+	return s.Burst[n%len(s.Burst)]
+}
+
+func (s *scenario_t) burst_delay(size int) time.Duration {
+	return time.Duration(s.DelayMS+s.FrameDelayMS*size) * time.Millisecond
+}
+
+/*
+ * Data frame with sequence number n, counting from 1.
+ * Padded with letters to the size for that frame, including the
+ * trailing carriage return, so truncation can be noticed.
+ */
+
+func (s *scenario_t) send_data(n int) string {
+	var size = s.Sizes[(n-1)%len(s.Sizes)]
+
+	var data = fmt.Sprintf("%04d send", n)
+
+	if size > MIN_FRAME_SIZE {
+		data += " " + frame_padding(size-MIN_FRAME_SIZE-1)
+	}
+
+	return data + "\r"
+}
+
+func frame_padding(n int) string {
+	var b strings.Builder
+
+	for i := range n {
+		b.WriteByte(byte('a' + i%26))
+	}
+
+	return b.String()
+}
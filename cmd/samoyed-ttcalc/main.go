@@ -6,6 +6,7 @@ package main
  *
  * Description:	Demonstration of how Dire Wolf can be used
  *		as a DTMF / Speech interface for ham radio applications.
+ *		Start here if you want to write your own.
  *
  * Usage:	Start up direwolf with configuration:
  *			- DTMF decoder enabled.
@@ -21,6 +22,27 @@ package main
  *		with the touch tone pad.
  *		The result is sent back with speech, e.g. "Twenty Four."
  *
+ * How it works:
+ *		This talks to direwolf with the AGW network protocol.
+ *		Every message, in either direction, is a 36 byte header,
+ *		direwolf.AGWPEHeader, followed by DataLen bytes of data.
+ *
+ *		1. Send 'k' to receive all frames in raw format.
+ *
+ *		2. Received frames come back as 'K' with the radio
+ *		   channel in Portx.  The data is a zero byte then the
+ *		   AX.25 frame without the FCS.
+ *
+ *		3. Touch tone sequences are in a special frame with
+ *		   "t" at the start of the information part, followed
+ *		   by the buttons pushed.
+ *
+ *		4. Send a reply as 'K' in the same format.  A frame
+ *		   with destination SPEECH is spoken rather than sent
+ *		   as a packet.  MORSE would send Morse code instead.
+ *
+ *		Replace calculator() with your own application.
+ *
  *---------------------------------------------------------------*/
 
 import (
@@ -32,18 +54,39 @@ import (
 	"unicode"
 
 	direwolf "github.com/doismellburning/samoyed/src"
+	"github.com/doismellburning/samoyed/src/ax25"
+	"github.com/spf13/pflag"
 )
 
 func main() {
-	var hostname = "localhost"
-	var port = "8000"
+	var hostname = pflag.StringP("hostname", "h", "localhost", "Host where direwolf is running.")
+	var port = pflag.StringP("port", "p", "8000", "AGW network protocol TCP port.")
+	var listenChannel = pflag.IntP("channel", "c", -1, "Only use touch tones heard on this radio channel.  Default is all.")
+	var help = pflag.Bool("help", false, "Display help text.")
+
+	pflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s - touch tone to speech calculator, an example DTMF application.\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Send a formula such as \"2*3A4#\" with touch tones and hear the answer.\n")
+		fmt.Fprintf(os.Stderr, "* is multiply, A is add, and # is equals.\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		pflag.PrintDefaults()
+	}
+
+	pflag.Parse()
+
+	if *help || pflag.NArg() > 0 {
+		pflag.Usage()
+		os.Exit(1)
+	}
 
 	/*
 	 * Try to attach to Dire Wolf.
 	 */
 
-	var server_sock, err = connect_to_server(hostname, port)
+	var server_sock, err = connect_to_server(*hostname, *port)
 	if err != nil {
+		fmt.Printf("Unable to connect to %s, port %s: %s\n", *hostname, *port, err)
 		os.Exit(1)
 	}
 
@@ -53,10 +96,7 @@ func main() {
 	 * Note: Monitor format is only for UI frames.
 	 */
 
-	var mon_cmd direwolf.AGWPEHeader
-	mon_cmd.DataKind = 'k'
-
-	var writeErr = binary.Write(server_sock, binary.LittleEndian, mon_cmd)
+	var writeErr = agw_send(server_sock, 0, 'k', nil)
 	if writeErr != nil {
 		fmt.Printf("Write error, %v, enabling monitor mode.\n", writeErr)
 		os.Exit(1)
@@ -67,7 +107,7 @@ func main() {
 	 */
 
 	for {
-		var readErr = binary.Read(server_sock, binary.LittleEndian, &mon_cmd)
+		var hdr, data, readErr = agw_receive(server_sock)
 		if readErr != nil {
 			if readErr == io.EOF {
 				fmt.Println("Connection to server closed.")
@@ -78,86 +118,54 @@ func main() {
 			os.Exit(1)
 		}
 
-		if mon_cmd.DataLen > direwolf.AX25_MAX_PACKET_LEN {
-			fmt.Printf("Got invalid data length %d from server.\n", mon_cmd.DataLen)
-			os.Exit(1)
-		}
-
-		var data = make([]byte, mon_cmd.DataLen)
-		if mon_cmd.DataLen > 0 {
-			_, readErr = io.ReadFull(server_sock, data)
-			if readErr != nil {
-				fmt.Printf("Read error, client received %s when reading %d data bytes. Terminating.\n", readErr, mon_cmd.DataLen)
-				os.Exit(1)
-			}
+		if hdr.DataKind != 'K' || len(data) < 1 {
+			continue
 		}
 
 		/*
 		 * Print it.
 		 */
 
-		if mon_cmd.DataKind == 'K' {
-			var channel = mon_cmd.Portx
-			var alevel direwolf.ALevel
-			var pp = direwolf.AX25FromFrame(data[1:mon_cmd.DataLen], alevel)
+		var channel = hdr.Portx
+
+		var f, parseErr = ax25.ParseFrame(data[1:])
+		if parseErr != nil {
+			continue
+		}
+
+		var pinfo = f.Info
 
-			var result = direwolf.AX25FormatAddrs(pp)
+		fmt.Printf("[%d] %s\n", channel, f)
 
-			var pinfo = direwolf.AX25GetInfo(pp)
+		if *listenChannel >= 0 && int(channel) != *listenChannel {
+			continue
+		}
 
-			fmt.Printf("[%d] %s%s\n", channel, result, string(pinfo))
+		/*
+		 * Look for Special touch tone packet with "t" in first position of the Information part.
+		 */
 
+		if len(pinfo) > 0 && pinfo[0] == 't' {
 			/*
-			 * Look for Special touch tone packet with "t" in first position of the Information part.
+			 * Send touch tone sequence to calculator and get the answer.
+			 *
+			 * Put your own application here instead.  Here are some ideas:
+			 *
+			 *  http://www.tapr.org/pipermail/aprssig/2015-January/044069.html
 			 */
+			var n = calculator(string(pinfo[1:]))
+			fmt.Printf("\nCalculator returns %d\n\n", n)
 
-			if len(pinfo) > 0 && pinfo[0] == 't' {
-				/*
-				 * Send touch tone sequence to calculator and get the answer.
-				 *
-				 * Put your own application here instead.  Here are some ideas:
-				 *
-				 *  http://www.tapr.org/pipermail/aprssig/2015-January/044069.html
-				 */
-				var n = calculator(string(pinfo[1:]))
-				fmt.Printf("\nCalculator returns %d\n\n", n)
-
-				/*
-				 * Convert to AX.25 frame.
-				 * Notice that the special destination will cause it to be spoken.
-				 */
-				var reply_text = fmt.Sprintf("N0CALL>SPEECH:%d", n)
-				var reply_pp = direwolf.AX25FromText(reply_text, true)
-
-				/*
-				 * Send it to the TNC.
-				 * In this example we are transmitting speech on the same channel
-				 * where the tones were heard.  We could also send AX.25 frames to
-				 * other radio channels.
-				 */
-				var hdr direwolf.AGWPEHeader
-				hdr.Portx = channel
-				hdr.DataKind = 'K'
-
-				var reply_bytes = direwolf.AX25Pack(reply_pp)
-				hdr.DataLen = 1 + uint32(len(reply_bytes))
-
-				var replyWriteErr = binary.Write(server_sock, binary.LittleEndian, hdr)
-				if replyWriteErr == nil {
-					_, replyWriteErr = server_sock.Write([]byte{0x0})
-				}
-				if replyWriteErr == nil {
-					_, replyWriteErr = server_sock.Write(reply_bytes)
-				}
-				if replyWriteErr != nil {
-					fmt.Printf("Write error, %v, sending reply.\n", replyWriteErr)
-					os.Exit(1)
-				}
-
-				direwolf.AX25Delete(reply_pp)
+			/*
+			 * In this example we are transmitting speech on the same channel
+			 * where the tones were heard.  We could also send AX.25 frames to
+			 * other radio channels.
+			 */
+			var replyErr = send_speech(server_sock, channel, fmt.Sprint(n))
+			if replyErr != nil {
+				fmt.Printf("Write error, %v, sending reply.\n", replyErr)
+				os.Exit(1)
 			}
-
-			direwolf.AX25Delete(pp)
 		}
 	}
 } /* main */
@@ -183,6 +191,9 @@ func main() {
  *		Adding functions to B, C, and D is left as an
  *		exercise for the reader.
  *
+ *		The # might be missing if the sequence ended with a
+ *		time out, so the end is taken the same way.
+ *
  * Examples:	2 * 3 A 4 #			Ten
  *		5 * 1 0 0 A 3 #			Five Hundred Three
  *
@@ -230,12 +241,11 @@ func calculator(str string) int {
 			num = 0
 			lastop = ADD
 		} else if p == '#' {
-			result = do_lastop(lastop, result, num)
-			return result
+			break
 		}
 	}
 
-	panic("Should never get here!")
+	return do_lastop(lastop, result, num)
 }
 
 /*------------------------------------------------------------------
@@ -247,7 +257,7 @@ func calculator(str string) int {
  * Inputs:	hostname
  *		port
  *
- * Returns:	File descriptor or -1 for error.
+ * Returns:	Connection or error.
  *
  *---------------------------------------------------------------*/
 
@@ -265,3 +275,89 @@ func connect_to_server(hostname string, port string) (net.Conn, error) {
 
 	return conn, connErr
 }
+
+/*------------------------------------------------------------------
+ *
+ * Name: 	agw_send
+ *
+ * Purpose:	Send one AGW network protocol message.
+ *
+ * Inputs:	w		- Connection to direwolf.
+ *		channel		- Radio channel, or 0 if not applicable.
+ *		kind		- Message type, e.g. 'k' or 'K'.
+ *		data		- Data following the header, may be empty.
+ *
+ *---------------------------------------------------------------*/
+
+func agw_send(w io.Writer, channel byte, kind byte, data []byte) error {
+	var hdr direwolf.AGWPEHeader
+	hdr.Portx = channel
+	hdr.DataKind = kind
+	hdr.DataLen = uint32(len(data)) //nolint:gosec // Frames are small.
+
+	var msg = make([]byte, 0, binary.Size(hdr)+len(data))
+
+	msg, _ = binary.Append(msg, binary.LittleEndian, hdr)
+	msg = append(msg, data...)
+
+	var _, err = w.Write(msg)
+
+	return err
+}
+
+/*------------------------------------------------------------------
+ *
+ * Name: 	agw_receive
+ *
+ * Purpose:	Wait for the next AGW network protocol message.
+ *
+ * Returns:	Header, data following it, and any error.
+ *
+ *---------------------------------------------------------------*/
+
+func agw_receive(r io.Reader) (direwolf.AGWPEHeader, []byte, error) {
+	var hdr direwolf.AGWPEHeader
+
+	var err = binary.Read(r, binary.LittleEndian, &hdr)
+	if err != nil {
+		return hdr, nil, err
+	}
+
+	if hdr.DataLen > direwolf.AX25_MAX_PACKET_LEN {
+		return hdr, nil, fmt.Errorf("invalid data length %d from server", hdr.DataLen)
+	}
+
+	var data = make([]byte, hdr.DataLen)
+
+	_, err = io.ReadFull(r, data)
+	if err != nil {
+		return hdr, nil, fmt.Errorf("reading %d data bytes: %w", hdr.DataLen, err)
+	}
+
+	return hdr, data, nil
+}
+
+/*------------------------------------------------------------------
+ *
+ * Name: 	send_speech
+ *
+ * Purpose:	Have direwolf speak some text on a radio channel.
+ *
+ * Description:	This is an ordinary AX.25 frame to transmit but
+ *		the special destination causes it to be spoken.
+ *
+ *---------------------------------------------------------------*/
+
+func send_speech(w io.Writer, channel byte, text string) error {
+	var reply, err = ax25.ParseTextFormat("N0CALL>SPEECH:"+text, true)
+	if err != nil {
+		return fmt.Errorf("can't make a frame to speak \"%s\": %w", text, err)
+	}
+
+	var frame, packErr = reply.Pack()
+	if packErr != nil {
+		return packErr
+	}
+
+	return agw_send(w, channel, 'K', append([]byte{0}, frame...))
+}
@@ -1,9 +1,13 @@
 package main
 
 import (
+	"bytes"
+	"strings"
 	"testing"
 
+	direwolf "github.com/doismellburning/samoyed/src"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_calculator(t *testing.T) {
@@ -11,4 +15,41 @@ func Test_calculator(t *testing.T) {
 	assert.Equal(t, 10, calculator("2*3A4#"))
 	assert.Equal(t, 503, calculator("5*100A3#"))
 	assert.Equal(t, 50, calculator("6a4*5#"))
+	assert.Equal(t, 24, calculator("2*3*4#"))
+	assert.Equal(t, 7, calculator("7#123"))
+	assert.Equal(t, 12, calculator("3*4"), "time out without #")
+	assert.Equal(t, 0, calculator(""))
+}
+
+func Test_send_speech(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, send_speech(&buf, 2, "24"))
+
+	var hdr, data, err = agw_receive(&buf)
+	require.NoError(t, err)
+
+	assert.Equal(t, byte(2), hdr.Portx)
+	assert.Equal(t, byte('K'), hdr.DataKind)
+	require.NotEmpty(t, data)
+	assert.Equal(t, byte(0), data[0])
+
+	var alevel direwolf.ALevel
+
+	var pp = direwolf.AX25FromFrame(data[1:], alevel)
+	require.NotNil(t, pp)
+
+	// Only the destination matters to the server; the source is a placeholder.
+	assert.True(t, strings.HasSuffix(direwolf.AX25FormatAddrs(pp), ">SPEECH:"), direwolf.AX25FormatAddrs(pp))
+	assert.Equal(t, "24", string(direwolf.AX25GetInfo(pp)))
+	assert.Zero(t, buf.Len())
+}
+
+func Test_agw_receive_bad_length(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, agw_send(&buf, 0, 'K', make([]byte, direwolf.AX25_MAX_PACKET_LEN+1)))
+
+	var _, _, err = agw_receive(&buf)
+	assert.Error(t, err)
 }
@@ -2,105 +2,276 @@
 package main
 
 import (
+	"bufio"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"math"
 	"os"
 	"strconv"
 	"strings"
 	"unicode"
 
+	"github.com/doismellburning/samoyed/src/geo"
 	"github.com/tzneal/coordconv"
 )
 
-func R2D(radians float64) float64 {
-	return radians * 180 / math.Pi
+/* Results for one location.  Also the --json output, one object per line. */
+
+type latLonJSON struct {
+	Lat float64 `json:"lat"` // Rounded to 6 decimal places, as printed.
+	Lon float64 `json:"lon"`
+}
+
+type conversion struct {
+	Input     string      `json:"input"`
+	UTM       *latLonJSON `json:"utm,omitempty"`
+	UTMError  string      `json:"utm_error,omitempty"`
+	USNG      *latLonJSON `json:"usng,omitempty"`
+	USNGError string      `json:"usng_error,omitempty"`
+	MGRS      *latLonJSON `json:"mgrs,omitempty"` // Only tried without spaces.
+	MGRSError string      `json:"mgrs_error,omitempty"`
+	Error     string      `json:"error,omitempty"` // Input not understood.  Nothing else is there.
+}
+
+func lat_lon_json(lat, lon float64) *latLonJSON {
+	return &latLonJSON{
+		Lat: math.Round(lat*1e6) / 1e6,
+		Lon: math.Round(lon*1e6) / 1e6,
+	}
+}
+
+/*
+ * Three arguments are UTM, unless the easting isn't a number, which
+ * means it's USNG written with spaces, e.g. "19T CH 0613 2600".
+ */
+
+func is_utm(fields []string) bool {
+	if len(fields) != 3 {
+		return false
+	}
+
+	var _, err = strconv.ParseFloat(fields[1], 64)
+
+	return err == nil
 }
 
 func main() {
-	if len(os.Args) == 4 {
-		// 3 command line arguments for UTM
-		var zlet rune
-
-		var zoneStr = os.Args[1] // e.g. "19T" or just "19"
-		if len(zoneStr) > 0 {
-			var last = zoneStr[len(zoneStr)-1]
-			if (last >= 'A' && last <= 'Z') || (last >= 'a' && last <= 'z') {
-				zlet = rune(last)
-				zoneStr = zoneStr[:len(zoneStr)-1]
-			}
-		}
-		zlet = unicode.ToUpper(zlet)
+	var asJSON = false
+	var args []string
 
-		var zone, zoneErr = strconv.Atoi(zoneStr)
-		if zoneErr != nil {
-			fmt.Printf("Invalid zone: %s\n\n", zoneErr)
+	// Same as ll2utm, which can't use pflag because of negative numbers.
+	for _, arg := range os.Args[1:] {
+		switch arg {
+		case "--json", "-j":
+			asJSON = true
+		case "--help", "-h":
 			usage()
+		default:
+			args = append(args, arg)
+		}
+	}
+
+	if len(args) == 1 && args[0] == "-" {
+		if !batch(os.Stdin, asJSON) {
+			os.Exit(1)
 		}
 
-		var hemisphere coordconv.Hemisphere
-		if zlet == 0 {
-			hemisphere = coordconv.HemisphereNorth
+		return
+	}
+
+	var c = convert(args)
+
+	if asJSON {
+		print_json(c)
+
+		if c.Error != "" {
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if c.Error != "" {
+		if len(args) > 0 {
+			fmt.Printf("%s\n\n", c.Error)
+		}
+
+		usage()
+	}
+
+	print_text(c)
+}
+
+/*
+ * One location per line from r, in any of the forms allowed on the
+ * command line.  Blank lines and lines starting with # are skipped.
+ * Returns false if any couldn't be understood.
+ */
+
+func batch(r io.Reader, asJSON bool) bool {
+	var ok = true
+
+	var scanner = bufio.NewScanner(r)
+	for scanner.Scan() {
+		var line = strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		var c = convert(strings.Fields(line))
+		c.Input = line
+
+		if c.Error != "" {
+			ok = false
+		}
+
+		if asJSON {
+			print_json(c)
+
+			continue
+		}
+
+		fmt.Printf("%s\n", line)
+
+		if c.Error != "" {
+			fmt.Printf("%s\n", c.Error)
 		} else {
-			if !strings.ContainsRune("CDEFGHJKLMNPQRSTUVWX", zlet) {
-				fmt.Printf("Latitudinal band must be one of CDEFGHJKLMNPQRSTUVWX.")
-				usage()
-			}
+			print_text(c)
+		}
 
-			if zlet >= 'N' {
-				hemisphere = coordconv.HemisphereNorth
-			} else {
-				hemisphere = coordconv.HemisphereSouth
-			}
+		fmt.Printf("\n")
+	}
+
+	return ok
+}
+
+func parse_utm(fields []string) (coordconv.UTMCoord, error) {
+	var utmCoord coordconv.UTMCoord
+
+	var zlet rune
+
+	var zoneStr = fields[0] // e.g. "19T" or just "19"
+	if len(zoneStr) > 0 {
+		var last = zoneStr[len(zoneStr)-1]
+		if (last >= 'A' && last <= 'Z') || (last >= 'a' && last <= 'z') {
+			zlet = rune(last)
+			zoneStr = zoneStr[:len(zoneStr)-1]
 		}
+	}
+	zlet = unicode.ToUpper(zlet)
 
-		var easting, eastingErr = strconv.ParseFloat(os.Args[2], 64)
-		if eastingErr != nil {
-			fmt.Printf("Invalid easting: %s\n\n", eastingErr)
-			usage()
+	var zone, zoneErr = strconv.Atoi(zoneStr)
+	if zoneErr != nil {
+		return utmCoord, fmt.Errorf("invalid zone: %w", zoneErr)
+	}
+
+	var hemisphere coordconv.Hemisphere
+	if zlet == 0 {
+		hemisphere = coordconv.HemisphereNorth
+	} else {
+		if !strings.ContainsRune("CDEFGHJKLMNPQRSTUVWX", zlet) {
+			return utmCoord, errors.New("latitudinal band must be one of CDEFGHJKLMNPQRSTUVWX")
 		}
 
-		var northing, northingErr = strconv.ParseFloat(os.Args[3], 64)
-		if northingErr != nil {
-			fmt.Printf("Invalid northing: %s\n\n", northingErr)
-			usage()
+		if zlet >= 'N' {
+			hemisphere = coordconv.HemisphereNorth
+		} else {
+			hemisphere = coordconv.HemisphereSouth
 		}
+	}
+
+	var easting, eastingErr = strconv.ParseFloat(fields[1], 64)
+	if eastingErr != nil {
+		return utmCoord, fmt.Errorf("invalid easting: %w", eastingErr)
+	}
+
+	var northing, northingErr = strconv.ParseFloat(fields[2], 64)
+	if northingErr != nil {
+		return utmCoord, fmt.Errorf("invalid northing: %w", northingErr)
+	}
+
+	utmCoord.Zone = zone
+	utmCoord.Hemisphere = hemisphere
+	utmCoord.Easting = easting
+	utmCoord.Northing = northing
 
-		var utmCoord = coordconv.UTMCoord{
-			Zone:       zone,
-			Hemisphere: hemisphere,
-			Easting:    easting,
-			Northing:   northing,
+	return utmCoord, nil
+}
+
+func convert(fields []string) conversion {
+	var c conversion
+	c.Input = strings.Join(fields, " ")
+
+	if is_utm(fields) {
+		// 3 fields for UTM
+		var utmCoord, err = parse_utm(fields)
+		if err != nil {
+			c.Error = err.Error()
+
+			return c
 		}
 
-		var latlng, utmErr = coordconv.DefaultUTMConverter.ConvertToGeodetic(utmCoord)
+		var lat, lon, utmErr = geo.FromUTM(utmCoord.Zone, utmCoord.Hemisphere, utmCoord.Easting, utmCoord.Northing)
 		if utmErr == nil {
-			var lat = R2D(float64(latlng.Lat))
-			var lon = R2D(float64(latlng.Lng))
-
-			fmt.Printf("from UTM, latitude = %.6f, longitude = %.6f\n", lat, lon)
+			c.UTM = lat_lon_json(lat, lon)
 		} else {
-			fmt.Printf("Conversion from UTM failed:\n%s\n\n", utmErr)
-		}
-	} else if len(os.Args) == 2 {
-		// One command line argument, MGRS.
-		var mgrsLatlng, mgrsErr = coordconv.DefaultMGRSConverter.ConvertToGeodetic(os.Args[1])
-		if mgrsErr == nil {
-			var lat = R2D(float64(mgrsLatlng.Lat))
-			var lon = R2D(float64(mgrsLatlng.Lng))
-			fmt.Printf("from MGRS, latitude = %.6f, longitude = %.6f\n", lat, lon)
+			c.UTMError = utmErr.Error()
+		}
+	} else if len(fields) >= 1 && len(fields) <= 4 {
+		// USNG or MGRS.  USNG can have spaces, so might be split up.
+		var lat, lon, usngErr = geo.FromUSNG(c.Input)
+		if usngErr == nil {
+			c.USNG = lat_lon_json(lat, lon)
 		} else {
-			fmt.Printf("Conversion from MGRS failed:\n%s\n\n", mgrsErr)
+			c.USNGError = usngErr.Error()
+		}
+
+		// MGRS is never written with spaces.
+		if len(fields) == 1 && !strings.Contains(c.Input, " ") {
+			var lat, lon, mgrsErr = geo.FromMGRS(c.Input)
+			if mgrsErr == nil {
+				c.MGRS = lat_lon_json(lat, lon)
+			} else {
+				c.MGRSError = mgrsErr.Error()
+			}
 		}
 	} else {
-		usage()
+		c.Error = "expected zone, easting, and northing, or a USNG or MGRS location"
+	}
+
+	return c
+}
+
+func print_text(c conversion) {
+	for _, from := range []struct {
+		name   string
+		result *latLonJSON
+		err    string
+	}{
+		{"UTM", c.UTM, c.UTMError},
+		{"USNG", c.USNG, c.USNGError},
+		{"MGRS", c.MGRS, c.MGRSError},
+	} {
+		if from.result != nil {
+			fmt.Printf("from %s, latitude = %.6f, longitude = %.6f\n", from.name, from.result.Lat, from.result.Lon)
+		} else if from.err != "" {
+			fmt.Printf("Conversion from %s failed:\n%s\n\n", from.name, from.err)
+		}
 	}
 }
 
+func print_json(c conversion) {
+	var b, _ = json.Marshal(c)
+	fmt.Printf("%s\n", b)
+}
+
 func usage() {
 	fmt.Println("UTM to Latitude / Longitude conversion")
 	fmt.Println("")
 	fmt.Println("Usage:")
-	fmt.Println("\tutm2ll  zone  easting  northing")
+	fmt.Println("\tutm2ll  [--json]  zone  easting  northing")
 	fmt.Println("")
 	fmt.Println("where,")
 	fmt.Println("\tzone is UTM zone 1 thru 60 with optional latitudinal band.")
@@ -108,14 +279,23 @@ func usage() {
 	fmt.Println("\tnorthing is y coordinate in meters")
 	fmt.Println("")
 	fmt.Println("or:")
-	fmt.Println("\tutm2ll  x")
+	fmt.Println("\tutm2ll  [--json]  x")
 	fmt.Println("")
 	fmt.Println("where,")
-	fmt.Println("\tx is USNG or MGRS location.")
+	fmt.Println("\tx is USNG or MGRS location.  USNG can have spaces.")
+	fmt.Println("")
+	fmt.Println("or:")
+	fmt.Println("\tutm2ll  [--json]  -")
+	fmt.Println("")
+	fmt.Println("\tto read one location per line from stdin, in either form.")
+	fmt.Println("")
+	fmt.Println("--json gives a line of JSON for each location.")
 	fmt.Println("")
 	fmt.Println("Examples:")
 	fmt.Println("\tutm2ll 19T 306130 4726010")
 	fmt.Println("\tutm2ll 19TCH06132600")
+	fmt.Println("\tutm2ll 19T CH 0613 2600")
+	fmt.Println("\tutm2ll --json - < locations.txt")
 
 	os.Exit(1)
 }
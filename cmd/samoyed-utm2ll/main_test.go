@@ -1,6 +1,10 @@
 package main
 
-import "os"
+import (
+	"fmt"
+	"os"
+	"strings"
+)
 
 // Examples from utm2ll.c source, checked against direwolf Debian package
 
@@ -21,6 +25,7 @@ func Example_main_2() {
 
 	main()
 	// Output:
+	// from USNG, latitude = 42.662049, longitude = -71.365550
 	// from MGRS, latitude = 42.662049, longitude = -71.365550
 }
 
@@ -30,3 +35,54 @@ func Example_main_3() {
 	main()
 	// Output: from UTM, latitude = 42.662139, longitude = -71.365553
 }
+
+func Example_main_4() {
+	os.Args = []string{"utm2ll", "19T", "CH", "0613", "2600"} // USNG with spaces
+
+	main()
+	// Output: from USNG, latitude = 42.662049, longitude = -71.365550
+}
+
+func Example_main_5() {
+	os.Args = []string{"utm2ll", "19t ch 0613 2600"}
+
+	main()
+	// Output: from USNG, latitude = 42.662049, longitude = -71.365550
+}
+
+func Example_main_6() {
+	os.Args = []string{"utm2ll", "19T", "CH", "06132600"} // Not UTM, easting isn't a number
+
+	main()
+	// Output: from USNG, latitude = 42.662049, longitude = -71.365550
+}
+
+func Example_main_json() {
+	os.Args = []string{"utm2ll", "--json", "19TCH06132600"}
+
+	main()
+	// Output: {"input":"19TCH06132600","usng":{"lat":42.662049,"lon":-71.36555},"mgrs":{"lat":42.662049,"lon":-71.36555}}
+}
+
+func Example_batch() {
+	batch(strings.NewReader("# Westford\n19T 306130 4726010\n\n19T CH 0613 2600\n19I 306130 4726010\n"), false)
+	// Output:
+	// 19T 306130 4726010
+	// from UTM, latitude = 42.662139, longitude = -71.365553
+	//
+	// 19T CH 0613 2600
+	// from USNG, latitude = 42.662049, longitude = -71.365550
+	//
+	// 19I 306130 4726010
+	// latitudinal band must be one of CDEFGHJKLMNPQRSTUVWX
+}
+
+func Example_batch_json() {
+	var ok = batch(strings.NewReader("19t 306130 4726010\n19T CH 0613 2600\n1 2 3 4 5\n"), true)
+	fmt.Println(ok)
+	// Output:
+	// {"input":"19t 306130 4726010","utm":{"lat":42.662139,"lon":-71.365553}}
+	// {"input":"19T CH 0613 2600","usng":{"lat":42.662049,"lon":-71.36555}}
+	// {"input":"1 2 3 4 5","error":"expected zone, easting, and northing, or a USNG or MGRS location"}
+	// false
+}
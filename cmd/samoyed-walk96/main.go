@@ -46,7 +46,7 @@ func main() {
 	direwolf.SerialPortWrite(tnc, []byte(cmd))
 
 	var debug_gps = 0
-	direwolf.DWGPSInit(gpsSerialPort, debug_gps)
+	direwolf.DWGPSInit(gpsSerialPort, 0, debug_gps)
 
 	direwolf.SLEEP_SEC(1) /* Wait for sample before reading. */
 
@@ -0,0 +1,350 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Tell someone when an unattended station has a problem.
+ *
+ * Description:	Configured with
+ *
+ *			ALERT AUDIO IGATE=10 NORX=30
+ *			ALERTHOOK https://ntfy.example.com/mysite
+ *			ALERTHOOK /usr/local/bin/page-operator
+ *
+ *		AUDIO	- Audio input device failed.  We exit after this
+ *			  so a service manager can restart us.
+ *		IGATE	- IGate not connected to the server for this
+ *			  many minutes.
+ *		NORX	- No packets decoded from radio for this many
+ *			  minutes.
+ *
+ *		For each hook, a URL gets an HTTP POST of JSON like this:
+ *
+ *			{"event":"igate_disconnected","state":"firing",
+ *			 "message":"IGate not connected for 10 minutes.",
+ *			 "time":"...","host":"digi1"}
+ *
+ *		Anything else is a command which is run with the same
+ *		JSON on its stdin and ALERT_EVENT, ALERT_STATE, and
+ *		ALERT_MESSAGE in its environment.
+ *
+ *		state is "resolved" when an IGate or NORX problem goes
+ *		away, so there is only one alert each time it happens.
+ *
+ *		ptt_stuck is always sent, when TXTIMEOUT has to turn
+ *		off a transmitter.  See ptt_watchdog.go.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+	"time"
+)
+
+const ALERT_AUDIO_LOST = "audio_lost"
+const ALERT_IGATE_DISCONNECTED = "igate_disconnected"
+const ALERT_NO_PACKETS = "no_packets"
+const ALERT_PTT_STUCK = "ptt_stuck"
+
+/* How often to check for problems. */
+
+const ALERT_CHECK_INTERVAL = 30 * time.Second
+
+/* Longest time to wait for a hook. */
+
+const ALERT_HOOK_TIMEOUT = 10 * time.Second
+
+type AlertEvent struct {
+	Event   string    `json:"event"`
+	State   string    `json:"state"` // "firing" or "resolved"
+	Message string    `json:"message"`
+	Time    time.Time `json:"time"`
+	Host    string    `json:"host"`
+}
+
+type AlertService struct {
+	mu    sync.Mutex
+	hooks []string
+
+	audio        bool
+	igateMinutes int // 0 to not check.
+	noRxMinutes  int // 0 to not check.
+
+	igateDownSince time.Time // Zero while connected.
+	lastRx         time.Time
+	firing         map[string]bool
+
+	host    string
+	running sync.WaitGroup // Hooks still running.
+
+	igateConnected func() time.Time // Replaced for testing.
+}
+
+var alertService *AlertService
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	NewAlertService
+ *
+ * Purpose:	Set up alerts from the configuration.
+ *
+ * Inputs:	mc	- ALERT and ALERTHOOK settings.
+ *
+ *		igate	- True if an IGate is configured.  IGATE= is
+ *			  ignored without one.
+ *
+ *		now	- Start time.  Nothing has been received yet and
+ *			  the IGate isn't connected yet.
+ *
+ * Returns:	nil if there is nothing to do.
+ *
+ *---------------------------------------------------------------*/
+
+func NewAlertService(mc *misc_config_s, igate bool, now time.Time) *AlertService {
+	if len(mc.alert_hooks) == 0 {
+		if mc.alert_audio || mc.alert_igate_minutes > 0 || mc.alert_norx_minutes > 0 {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("ALERT is configured but there is no ALERTHOOK to send alerts to.\n")
+		}
+
+		return nil
+	}
+
+	var as = new(AlertService)
+	as.hooks = mc.alert_hooks
+	as.audio = mc.alert_audio
+	as.noRxMinutes = mc.alert_norx_minutes
+	as.igateDownSince = now
+	as.lastRx = now
+	as.firing = make(map[string]bool)
+	as.igateConnected = igate_get_connected_since
+
+	if igate {
+		as.igateMinutes = mc.alert_igate_minutes
+	}
+
+	as.host, _ = os.Hostname()
+
+	return as
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Start
+ *
+ * Purpose:	Check for IGate and NORX problems from now on.
+ *
+ *---------------------------------------------------------------*/
+
+func (as *AlertService) Start() {
+	if as == nil || (as.igateMinutes == 0 && as.noRxMinutes == 0) {
+		return
+	}
+
+	go func() {
+		for now := range time.Tick(ALERT_CHECK_INTERVAL) {
+			as.check(now)
+		}
+	}()
+}
+
+func (as *AlertService) check(now time.Time) {
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	if as.igateMinutes > 0 {
+		if as.igateConnected().IsZero() {
+			if as.igateDownSince.IsZero() {
+				as.igateDownSince = now
+			}
+
+			if now.Sub(as.igateDownSince) >= time.Duration(as.igateMinutes)*time.Minute {
+				as.fireLocked(ALERT_IGATE_DISCONNECTED, now, fmt.Sprintf("IGate not connected for %d minutes.", as.igateMinutes))
+			}
+		} else {
+			as.igateDownSince = time.Time{}
+			as.resolveLocked(ALERT_IGATE_DISCONNECTED, now, "IGate connected again.")
+		}
+	}
+
+	if as.noRxMinutes > 0 {
+		if now.Sub(as.lastRx) >= time.Duration(as.noRxMinutes)*time.Minute {
+			as.fireLocked(ALERT_NO_PACKETS, now, fmt.Sprintf("No packets decoded for %d minutes.", as.noRxMinutes))
+		} else {
+			as.resolveLocked(ALERT_NO_PACKETS, now, "Packets are being decoded again.")
+		}
+	}
+}
+
+/* A packet was decoded from radio. */
+
+func (as *AlertService) PacketReceived(now time.Time) {
+	if as == nil {
+		return
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.lastRx = now
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	AudioLost
+ *
+ * Purpose:	Alert for audio input failure.
+ *
+ * Inputs:	a	- Audio device number.
+ *
+ * Description:	We are about to exit so wait for the hooks to finish.
+ *
+ *---------------------------------------------------------------*/
+
+func (as *AlertService) AudioLost(a int) {
+	if as == nil || !as.audio {
+		return
+	}
+
+	as.mu.Lock()
+	as.fireLocked(ALERT_AUDIO_LOST, time.Now(), fmt.Sprintf("Audio device %d input failed.", a))
+	as.mu.Unlock()
+
+	as.running.Wait()
+}
+
+/* TXTIMEOUT turned off a transmitter. */
+
+func (as *AlertService) PTTStuck(message string) {
+	if as == nil {
+		return
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.fireLocked(ALERT_PTT_STUCK, time.Now(), message)
+}
+
+/* and whatever was transmitting has let go since. */
+
+func (as *AlertService) PTTReleased(channel int) {
+	if as == nil {
+		return
+	}
+
+	as.mu.Lock()
+	defer as.mu.Unlock()
+
+	as.resolveLocked(ALERT_PTT_STUCK, time.Now(), fmt.Sprintf("Channel %d transmitter released.", channel))
+}
+
+func (as *AlertService) fireLocked(event string, now time.Time, message string) {
+	if as.firing[event] {
+		return
+	}
+
+	as.firing[event] = true
+
+	text_color_set(DW_COLOR_ERROR)
+	dw_printf("Alert: %s\n", message)
+
+	as.sendLocked(&AlertEvent{Event: event, State: "firing", Message: message, Time: now, Host: as.host})
+}
+
+func (as *AlertService) resolveLocked(event string, now time.Time, message string) {
+	if !as.firing[event] {
+		return
+	}
+
+	as.firing[event] = false
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("Alert resolved: %s\n", message)
+
+	as.sendLocked(&AlertEvent{Event: event, State: "resolved", Message: message, Time: now, Host: as.host})
+}
+
+func (as *AlertService) sendLocked(ev *AlertEvent) {
+	var j, _ = json.Marshal(ev)
+
+	for _, hook := range as.hooks {
+		/* Don't hold up anything else. */
+
+		as.running.Add(1)
+
+		go func() {
+			defer as.running.Done()
+
+			var err error
+			if strings.HasPrefix(hook, "http://") || strings.HasPrefix(hook, "https://") {
+				err = alert_post(hook, j)
+			} else {
+				err = alert_run(hook, ev, j)
+			}
+
+			if err != nil {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Alert hook %s failed: %s\n", hook, err)
+			}
+		}()
+	}
+}
+
+func alert_post(url string, j []byte) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), ALERT_HOOK_TIMEOUT)
+	defer cancel()
+
+	var req, err = http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(j))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp, postErr = http.DefaultClient.Do(req)
+	if postErr != nil {
+		return postErr
+	}
+
+	resp.Body.Close()
+
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("HTTP status %s", resp.Status)
+	}
+
+	return nil
+}
+
+func alert_run(hook string, ev *AlertEvent, j []byte) error {
+	var ctx, cancel = context.WithTimeout(context.Background(), ALERT_HOOK_TIMEOUT)
+	defer cancel()
+
+	var cmd = exec.CommandContext(ctx, hook) //nolint:gosec // Named in the configuration file.
+	cmd.Env = append(os.Environ(),
+		"ALERT_EVENT="+ev.Event,
+		"ALERT_STATE="+ev.State,
+		"ALERT_MESSAGE="+ev.Message)
+	cmd.Stdin = bytes.NewReader(append(j, '\n'))
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	return cmd.Run()
+}
+
+/* Wait for any hooks still running, e.g. when exiting. */
+
+func (as *AlertService) Close() {
+	if as == nil {
+		return
+	}
+
+	as.running.Wait()
+}
@@ -0,0 +1,202 @@
+package direwolf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * Web hook which remembers what it was sent.
+ */
+
+type alertTestReceiver struct {
+	mu     sync.Mutex
+	events []AlertEvent
+}
+
+func alert_test_server(t *testing.T) (*alertTestReceiver, string) {
+	t.Helper()
+
+	var r = new(alertTestReceiver)
+
+	var srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		var ev AlertEvent
+		if json.NewDecoder(req.Body).Decode(&ev) == nil {
+			r.mu.Lock()
+			r.events = append(r.events, ev)
+			r.mu.Unlock()
+		}
+	}))
+
+	t.Cleanup(srv.Close)
+
+	return r, srv.URL
+}
+
+func (r *alertTestReceiver) take(as *AlertService) []AlertEvent {
+	as.Close()
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var events = r.events
+	r.events = nil
+
+	return events
+}
+
+func Test_NewAlertService(t *testing.T) {
+	var mc misc_config_s
+	mc.alert_norx_minutes = 30
+
+	assert.Nil(t, NewAlertService(&mc, true, time.Now()), "nowhere to send alerts")
+
+	var as *AlertService
+
+	// All safe when not configured.
+	as.Start()
+	as.PacketReceived(time.Now())
+	as.AudioLost(0)
+	as.Close()
+
+	mc.alert_hooks = []string{"http://localhost/"}
+	mc.alert_igate_minutes = 10
+
+	as = NewAlertService(&mc, false, time.Now())
+	require.NotNil(t, as)
+	assert.Zero(t, as.igateMinutes, "no IGate configured")
+	assert.Equal(t, 30, as.noRxMinutes)
+}
+
+func Test_alert_igate(t *testing.T) {
+	var r, url = alert_test_server(t)
+
+	var mc misc_config_s
+	mc.alert_hooks = []string{url}
+	mc.alert_igate_minutes = 10
+
+	var start = time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+	var connected time.Time
+
+	var as = NewAlertService(&mc, true, start)
+	as.igateConnected = func() time.Time { return connected }
+
+	// Never connected counts from the start.
+	as.check(start.Add(9 * time.Minute))
+	assert.Empty(t, r.take(as))
+
+	as.check(start.Add(10 * time.Minute))
+	as.check(start.Add(11 * time.Minute))
+
+	var events = r.take(as)
+	require.Len(t, events, 1, "only once")
+	assert.Equal(t, ALERT_IGATE_DISCONNECTED, events[0].Event)
+	assert.Equal(t, "firing", events[0].State)
+	assert.Equal(t, "IGate not connected for 10 minutes.", events[0].Message)
+	assert.True(t, start.Add(10*time.Minute).Equal(events[0].Time))
+
+	connected = start.Add(12 * time.Minute)
+	as.check(start.Add(12 * time.Minute))
+
+	events = r.take(as)
+	require.Len(t, events, 1)
+	assert.Equal(t, "resolved", events[0].State)
+
+	// Disconnected again.  Counts from when it was noticed.
+	connected = time.Time{}
+	as.check(start.Add(20 * time.Minute))
+	as.check(start.Add(29 * time.Minute))
+	assert.Empty(t, r.take(as))
+
+	as.check(start.Add(30 * time.Minute))
+	assert.Len(t, r.take(as), 1)
+}
+
+func Test_alert_norx(t *testing.T) {
+	var r, url = alert_test_server(t)
+
+	var mc misc_config_s
+	mc.alert_hooks = []string{url}
+	mc.alert_norx_minutes = 30
+
+	var start = time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+
+	var as = NewAlertService(&mc, false, start)
+
+	as.PacketReceived(start.Add(10 * time.Minute))
+	as.check(start.Add(35 * time.Minute))
+	assert.Empty(t, r.take(as))
+
+	as.check(start.Add(40 * time.Minute))
+
+	var events = r.take(as)
+	require.Len(t, events, 1)
+	assert.Equal(t, ALERT_NO_PACKETS, events[0].Event)
+	assert.Equal(t, "firing", events[0].State)
+
+	as.PacketReceived(start.Add(41 * time.Minute))
+	as.check(start.Add(41 * time.Minute))
+
+	events = r.take(as)
+	require.Len(t, events, 1)
+	assert.Equal(t, ALERT_NO_PACKETS, events[0].Event)
+	assert.Equal(t, "resolved", events[0].State)
+}
+
+func Test_alert_ptt_stuck(t *testing.T) {
+	var r, url = alert_test_server(t)
+
+	var mc misc_config_s
+	mc.alert_hooks = []string{url}
+
+	var as = NewAlertService(&mc, false, time.Now())
+
+	as.PTTStuck("Channel 0 PTT forced off after 60 seconds.")
+	as.PTTStuck("Channel 0 PTT forced off after 60 seconds.")
+
+	var events = r.take(as)
+	require.Len(t, events, 1)
+	assert.Equal(t, ALERT_PTT_STUCK, events[0].Event)
+	assert.Equal(t, "firing", events[0].State)
+
+	as.PTTReleased(0)
+
+	events = r.take(as)
+	require.Len(t, events, 1)
+	assert.Equal(t, "resolved", events[0].State)
+}
+
+func Test_alert_command(t *testing.T) {
+	var dir = t.TempDir()
+	var out = filepath.Join(dir, "out")
+	var script = filepath.Join(dir, "page")
+
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\necho \"$ALERT_EVENT $ALERT_STATE $ALERT_MESSAGE\" > "+out+"\ncat >> "+out+"\n"), 0o700)) //nolint:gosec
+
+	var mc misc_config_s
+	mc.alert_hooks = []string{script}
+	mc.alert_audio = true
+
+	var as = NewAlertService(&mc, false, time.Now())
+	as.AudioLost(1)
+
+	var data, err = os.ReadFile(out) //nolint:gosec
+	require.NoError(t, err)
+
+	var first, rest, _ = strings.Cut(string(data), "\n")
+	assert.Equal(t, "audio_lost firing Audio device 1 input failed.", first)
+
+	var ev AlertEvent
+	require.NoError(t, json.Unmarshal([]byte(rest), &ev))
+	assert.Equal(t, ALERT_AUDIO_LOST, ev.Event)
+}
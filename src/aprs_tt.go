@@ -33,7 +33,7 @@ import (
 	"strings"
 	"unicode"
 
-	"github.com/tzneal/coordconv"
+	"github.com/doismellburning/samoyed/src/geo"
 )
 
 /* Error codes for sending responses to user. */
@@ -218,6 +218,9 @@ type tt_config_s struct {
 	}
 
 	ttcmd string /* Command to generate custom audible response. */
+
+	remote_pin  string               /* DTMFPIN for remote control. */
+	remote_cmds []*dtmf_remote_cmd_s /* DTMFCMD.  See dtmf_remote.go. */
 }
 
 /*
@@ -479,6 +482,7 @@ func (g *TTGateway) Sequence(channel int, msg string) {
 		return
 	}
 
+	pp.tx_reason = TX_REASON_APRSTT
 	tq_append(channel, TQ_PRIO_0_HI, pp)
 } /* end Sequence */
 
@@ -1084,6 +1088,16 @@ func (g *TTGateway) parseAprstt3Call(state *ttParseState, e string) int {
 	return (0)
 } /* end parseAprstt3Call */
 
+/* Same grid, but USNG may be written in lower case or with spaces. */
+
+func tt_mgrs_convert(typ ttlocTypeT) func(string) (float64, float64, error) {
+	if typ == TTLOC_USNG {
+		return geo.FromUSNG
+	}
+
+	return geo.FromMGRS
+}
+
 /*------------------------------------------------------------------
  *
  * Name:        parseLocation
@@ -1128,9 +1142,6 @@ func (g *TTGateway) parseAprstt3Call(state *ttParseState, e string) int {
  *
  *----------------------------------------------------------------*/
 
-/* R_M is the average radius of earth in meters. */
-const R_M = 6371000.0
-
 func (g *TTGateway) parseLocation(state *ttParseState, e string) int {
 	if g.debug > 0 {
 		text_color_set(DW_COLOR_DEBUG)
@@ -1180,21 +1191,11 @@ func (g *TTGateway) parseLocation(state *ttParseState, e string) int {
 				// return error code?
 			}
 
-			var lat0 = D2R(float64(g.config.ttlocs[ipat].vector.lat))
-			var lon0 = D2R(float64(g.config.ttlocs[ipat].vector.lon))
 			var d, _ = strconv.ParseFloat(dstr, 64)
 			var dist = d * float64(g.config.ttlocs[ipat].vector.scale)
 			var b, _ = strconv.ParseFloat(bstr, 64)
-			var bearing = D2R(b)
-
-			/* Equations and caluculators found here: */
-			/* http://movable-type.co.uk/scripts/latlong.html */
-			/* This should probably be a function in latlong.c in case we have another use for it someday. */
 
-			state.latitude = R2D(math.Asin(math.Sin(lat0)*math.Cos(dist/R_M) + math.Cos(lat0)*math.Sin(dist/R_M)*math.Cos(bearing)))
-
-			state.longitude = R2D(lon0 + math.Atan2(math.Sin(bearing)*math.Sin(dist/R_M)*math.Cos(lat0),
-				math.Cos(dist/R_M)-math.Sin(lat0)*math.Sin(D2R(state.latitude))))
+			state.latitude, state.longitude = geo.Destination(float64(g.config.ttlocs[ipat].vector.lat), float64(g.config.ttlocs[ipat].vector.lon), dist/1000, b)
 
 			state.dao[2] = e[0]
 			state.dao[3] = e[1]
@@ -1276,19 +1277,12 @@ func (g *TTGateway) parseLocation(state *ttParseState, e string) int {
 				state.locText = fmt.Sprintf("%d %.0f %.0f", g.config.ttlocs[ipat].utm.lzone, easting, northing)
 			}
 
-			var hemi = HemisphereRuneToCoordconvHemisphere(g.config.ttlocs[ipat].utm.hemi)
-
-			var utm = coordconv.UTMCoord{
-				Zone:       g.config.ttlocs[ipat].utm.lzone,
-				Hemisphere: hemi,
-				Easting:    easting,
-				Northing:   northing,
-			}
+			var hemi = geo.HemisphereFromRune(g.config.ttlocs[ipat].utm.hemi)
 
-			var geo, geoErr = coordconv.DefaultUTMConverter.ConvertToGeodetic(utm)
+			var lat, lon, geoErr = geo.FromUTM(g.config.ttlocs[ipat].utm.lzone, hemi, easting, northing)
 			if geoErr == nil {
-				state.latitude = R2D(float64(geo.Lat))
-				state.longitude = R2D(float64(geo.Lng))
+				state.latitude = lat
+				state.longitude = lon
 
 				// dw_printf ("DEBUG: from UTM, latitude = %.6f, longitude = %.6f\n", state.latitude, state.longitude);
 			} else {
@@ -1323,11 +1317,10 @@ func (g *TTGateway) parseLocation(state *ttParseState, e string) int {
 
 			state.locText = loc
 
-			// Apparently also does USNG!
-			var geo, convertErr = coordconv.DefaultMGRSConverter.ConvertToGeodetic(loc)
+			var lat, lon, convertErr = tt_mgrs_convert(g.config.ttlocs[ipat].ttlocType)(loc)
 			if convertErr == nil {
-				state.latitude = R2D(float64(geo.Lat))
-				state.longitude = R2D(float64(geo.Lng))
+				state.latitude = lat
+				state.longitude = lon
 
 				// dw_printf ("DEBUG: from MGRS/USNG, latitude = %.6f, longitude = %.6f\n", state.latitude, state.longitude);
 			} else {
@@ -1360,10 +1353,13 @@ func (g *TTGateway) parseLocation(state *ttParseState, e string) int {
 				// dw_printf ("Case MHEAD: Resulting text \"%s\".\n", mh);
 				state.locText = mh
 
-				var lat, lon, err = ll_from_grid_square(state.locText)
+				var lat, lon, err = geo.FromGridSquare(state.locText)
 				if err == nil {
 					state.latitude = lat
 					state.longitude = lon
+				} else {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("%s\n", err)
 				}
 			}
 
@@ -1384,10 +1380,13 @@ func (g *TTGateway) parseLocation(state *ttParseState, e string) int {
 			if errs == 0 {
 				state.locText = mh
 
-				var lat, lon, err = ll_from_grid_square(state.locText)
+				var lat, lon, err = geo.FromGridSquare(state.locText)
 				if err == nil {
 					state.latitude = lat
 					state.longitude = lon
+				} else {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("%s\n", err)
 				}
 			}
 
@@ -40,52 +40,23 @@ package direwolf
 
 import (
 	"bufio"
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
 	"math"
 	"os"
+	"runtime"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
-	"unsafe"
 
 	"github.com/spf13/pflag"
 )
 
-type atest_header_t struct {
-	RIFF     [4]byte /* "RIFF" */
-	Filesize int32   /* file length - 8 */
-	WAVE     [4]byte /* "WAVE" */
-}
-
-type atest_chunk_t struct {
-	Id       [4]byte /* "LIST" or "fmt " */
-	Datasize int32
-}
-
-type atest_format_t struct {
-	Wformattag      int16 /* 1 for PCM. */
-	Nchannels       int16 /* 1 for mono, 2 for stereo. */
-	Nsamplespersec  int32 /* sampling freq, Hz. */
-	Navgbytespersec int32 /* = nblockalign*nsamplespersec. */
-	Nblockalign     int16 /* = wbitspersample/8 * nchannels. */
-	Wbitspersample  int16 /* 16 or 8. */
-}
-
-type atest_wav_data_t struct {
-	Data     [4]byte /* "data" */
-	Datasize int32
-}
-
 var ATEST_C = false
 
-var header atest_header_t
-var chunk atest_chunk_t
-var format atest_format_t
-var wav_data atest_wav_data_t
+var wav_data_size int64 /* Bytes of samples not read yet. */
 
 var atestFP *os.File
 var atestBuf *bufio.Reader
@@ -178,6 +149,11 @@ Higher values = Try modifying more bits to get a good CRC.`)
 x = FX.25
 o = DCD output control
 2 = IL2P`)
+	var jsonFile = pflag.String("json", "", "Write number decoded from each file, for each profile, to this file as JSON.")
+	var junitFile = pflag.String("junit", "", "Write results to this file as JUnit XML.")
+	var compareLog = pflag.StringSlice("compare-log", nil, "Compare with what was decoded originally, from this direwolf -l log file.")
+	var comparePcap = pflag.StringSlice("compare-pcap", nil, "Compare with what was decoded originally, from this direwolf --pcap file.")
+	var jobs = pflag.Int("jobs", runtime.NumCPU(), "Number of profiles to test at the same time when -P lists more than one, e.g. -P A,B,E+.")
 	var help = pflag.Bool("help", false, "Display help text.")
 
 	pflag.Usage = func() {
@@ -199,6 +175,12 @@ o = DCD output control
 		fmt.Fprintf(os.Stderr, "$ atest -B 9600 test9.wav\n")
 		fmt.Fprintf(os.Stderr, "\n")
 		fmt.Fprintf(os.Stderr, "Try different combinations of options to compare decoding performance.\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "$ atest -P A,B,E+ --junit results.xml test1.wav\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "See how a newer version does with a recording of what was received that day.\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "$ atest --compare-log 2024-05-06.log 2024-05-06.wav\n")
 	}
 
 	// !!! PARSE !!!
@@ -411,6 +393,25 @@ o = DCD output control
 		my_audio_config.achan[0].profiles = ""
 	}
 
+	if strings.Contains(*modemProfile, ",") {
+		if len(*compareLog) > 0 || len(*comparePcap) > 0 {
+			text_color_set(DW_COLOR_ERROR)
+			fmt.Printf("Comparing with the original log works with only one profile at a time.\n")
+			os.Exit(1)
+		}
+
+		if len(pflag.Args()) == 0 {
+			text_color_set(DW_COLOR_ERROR)
+			fmt.Printf("Specify .WAV file name on command line.\n\n")
+			pflag.Usage()
+			os.Exit(1)
+		}
+
+		atest_multiple_profiles(strings.Split(*modemProfile, ","), *jobs, *jsonFile, *junitFile)
+
+		return
+	}
+
 	// Needs to be after -B, -j, -J.
 	if *modemProfile != "" {
 		fmt.Printf("Demodulator profile set to \"%s\"\n", *modemProfile)
@@ -429,142 +430,97 @@ o = DCD output control
 	FX25Init(d_x_opt)
 	il2p_init(d_2_opt)
 
-	var start_time = time.Now()
-	var total_filetime float64
-	var packets_decoded_total = 0
-
-	for _, wavFileName := range pflag.Args() {
-		var err error
-
-		atestFP, err = os.Open(wavFileName) //nolint:gosec // File path from CLI is expected for this tool
-		if err != nil {
-			text_color_set(DW_COLOR_ERROR)
-			fmt.Printf("Couldn't open file %s for read: %s\n", wavFileName, err)
-			// perror ("more info?");
-			os.Exit(1)
-		}
-
-		/*
-		 * Read the file header.
-		 * Doesn't handle all possible cases but good enough for our purposes.
-		 */
+	/*
+	 * Read what was decoded originally, if comparing.
+	 */
 
-		err = binary.Read(atestFP, binary.LittleEndian, &header)
-		if err != nil {
-			text_color_set(DW_COLOR_ERROR)
-			fmt.Printf("WAV file error: Could not read file header: %s\n", err)
-			os.Exit(1)
-		}
+	var original atestHeard
 
-		if string(header.RIFF[:]) != "RIFF" || string(header.WAVE[:]) != "WAVE" {
-			text_color_set(DW_COLOR_ERROR)
-			fmt.Printf("This is not a .WAV format file.\n")
-			os.Exit(1)
-		}
+	if len(*compareLog) > 0 || len(*comparePcap) > 0 {
+		original = make(atestHeard)
+		atestNow = make(atestHeard)
 
-		err = binary.Read(atestFP, binary.LittleEndian, &chunk)
-		if err != nil {
-			text_color_set(DW_COLOR_ERROR)
-			fmt.Printf("WAV file error: Could not read chunk header: %s\n", err)
-			os.Exit(1)
-		}
+		var channels = func(channel int) bool { return decode_only == 2 || channel == decode_only }
 
-		for string(chunk.Id[:]) != "fmt " {
-			if chunk.Datasize < 0 {
-				text_color_set(DW_COLOR_ERROR)
-				fmt.Printf("WAV file error: Invalid chunk datasize %d.\n", chunk.Datasize)
-				os.Exit(1)
-			}
-
-			_, err = atestFP.Seek(int64(chunk.Datasize)+int64(chunk.Datasize%2), io.SeekCurrent)
+		for _, path := range *compareLog {
+			var err = atest_read_log(path, channels, original)
 			if err != nil {
 				text_color_set(DW_COLOR_ERROR)
-				fmt.Printf("WAV file error: Could not Seek: %s.\n", err)
+				fmt.Printf("Couldn't read log file: %s\n", err)
 				os.Exit(1)
 			}
+		}
 
-			err = binary.Read(atestFP, binary.LittleEndian, &chunk)
+		for _, path := range *comparePcap {
+			var err = atest_read_pcap(path, channels, original)
 			if err != nil {
 				text_color_set(DW_COLOR_ERROR)
-				fmt.Printf("WAV file error: Could not find \"fmt \" chunk.\n")
+				fmt.Printf("Couldn't read capture file: %s\n", err)
 				os.Exit(1)
 			}
 		}
+	}
 
-		if chunk.Datasize != 16 && chunk.Datasize != 18 {
-			text_color_set(DW_COLOR_ERROR)
-			fmt.Printf("WAV file error: Need fmt chunk datasize of 16 or 18.  Found %d.\n", chunk.Datasize)
-			os.Exit(1)
-		}
-
-		binary.Read(atestFP, binary.LittleEndian, &format)
+	var start_time = time.Now()
+	var total_filetime float64
+	var packets_decoded_total = 0
 
-		// KG If Datasize > sizeof(format), skip until the actual data
-		var formatSize = int32(unsafe.Sizeof(format))
-		if chunk.Datasize > formatSize {
-			var extra = chunk.Datasize - formatSize
+	var result = atestResult{
+		Profile:        atest_profile_name(*modemProfile),
+		Files:          []atestFileResult{},
+		Decoded:        0,
+		ElapsedSeconds: 0,
+		Failure:        "",
+	}
 
-			_, err = atestFP.Seek(int64(extra), io.SeekCurrent)
-			if err != nil {
-				text_color_set(DW_COLOR_ERROR)
-				fmt.Printf("WAV file error: Could not Seek: %s.\n", err)
-				os.Exit(1)
-			}
-		}
+	for _, wavFileName := range pflag.Args() {
+		var err error
 
-		err = binary.Read(atestFP, binary.LittleEndian, &wav_data)
+		atestFP, err = os.Open(wavFileName) //nolint:gosec // File path from CLI is expected for this tool
 		if err != nil {
 			text_color_set(DW_COLOR_ERROR)
-			fmt.Printf("WAV file error: Could not read data chunk header: %s\n", err)
+			fmt.Printf("Couldn't open file %s for read: %s\n", wavFileName, err)
+			// perror ("more info?");
 			os.Exit(1)
 		}
 
-		for string(wav_data.Data[:]) != "data" {
-			if wav_data.Datasize < 0 {
-				text_color_set(DW_COLOR_ERROR)
-				fmt.Printf("WAV file error: Invalid chunk datasize %d.\n", wav_data.Datasize)
-				os.Exit(1)
-			}
-
-			_, err = atestFP.Seek(int64(wav_data.Datasize)+int64(wav_data.Datasize%2), io.SeekCurrent)
-			if err != nil {
-				text_color_set(DW_COLOR_ERROR)
-				fmt.Printf("WAV file error: Could not Seek: %s.\n", err)
-				os.Exit(1)
-			}
+		/*
+		 * Read the file header.
+		 */
 
-			err = binary.Read(atestFP, binary.LittleEndian, &wav_data)
-			if err != nil {
-				text_color_set(DW_COLOR_ERROR)
-				fmt.Printf("WAV file error: Could not find \"data\" chunk.\n")
-				os.Exit(1)
-			}
+		var wf, dataSize, headerErr = wav_read_header(atestFP)
+		if headerErr != nil {
+			text_color_set(DW_COLOR_ERROR)
+			fmt.Printf("WAV file error: %s.\n", headerErr)
+			os.Exit(1)
 		}
 
-		if format.Wformattag != 1 {
+		if wf.format != WAV_FORMAT_PCM {
 			text_color_set(DW_COLOR_ERROR)
-			fmt.Printf("Sorry, I only understand audio format 1 (PCM).  This file has %d.\n", format.Wformattag)
+			fmt.Printf("Sorry, I only understand audio format 1 (PCM).  This file has %d.\n", wf.format)
 			os.Exit(1)
 		}
 
-		if format.Nchannels != 1 && format.Nchannels != 2 {
+		if wf.channels != 1 && wf.channels != 2 {
 			text_color_set(DW_COLOR_ERROR)
-			fmt.Printf("Sorry, I only understand 1 or 2 channels.  This file has %d.\n", format.Nchannels)
+			fmt.Printf("Sorry, I only understand 1 or 2 channels.  This file has %d.\n", wf.channels)
 			os.Exit(1)
 		}
 
-		if format.Wbitspersample != 8 && format.Wbitspersample != 16 {
+		if wf.bits != 8 && wf.bits != 16 {
 			text_color_set(DW_COLOR_ERROR)
-			fmt.Printf("Sorry, I only understand 8 or 16 bits per sample.  This file has %d.\n", format.Wbitspersample)
+			fmt.Printf("Sorry, I only understand 8 or 16 bits per sample.  This file has %d.\n", wf.bits)
 			os.Exit(1)
 		}
 
-		my_audio_config.adev[0].samples_per_sec = int(format.Nsamplespersec)
-		my_audio_config.adev[0].bits_per_sample = int(format.Wbitspersample)
-		my_audio_config.adev[0].num_channels = int(format.Nchannels)
+		wav_data_size = int64(dataSize)
+
+		my_audio_config.adev[0].samples_per_sec = wf.rate
+		my_audio_config.adev[0].bits_per_sample = wf.bits
+		my_audio_config.adev[0].num_channels = wf.channels
 
 		my_audio_config.chan_medium[0] = MEDIUM_RADIO
-		if format.Nchannels == 2 {
+		if wf.channels == 2 {
 			my_audio_config.chan_medium[1] = MEDIUM_RADIO
 		}
 
@@ -574,12 +530,12 @@ o = DCD output control
 			my_audio_config.adev[0].bits_per_sample,
 			(my_audio_config.adev[0].num_channels))
 		// nnum_channels is known to be 1 or 2.
-		var one_filetime = float64(wav_data.Datasize) /
+		var one_filetime = float64(wav_data_size) /
 			float64((my_audio_config.adev[0].bits_per_sample/8)*(my_audio_config.adev[0].num_channels)*my_audio_config.adev[0].samples_per_sec)
 		total_filetime += one_filetime
 
 		fmt.Printf("%d audio bytes in file.  Duration = %.1f seconds.\n",
-			wav_data.Datasize,
+			wav_data_size,
 			one_filetime)
 		fmt.Printf("Fix Bits level = %d\n", my_audio_config.achan[0].fix_bits)
 
@@ -643,6 +599,8 @@ o = DCD output control
 		fmt.Printf("%d from %s\n", packets_decoded_one, wavFileName)
 		packets_decoded_total += packets_decoded_one
 
+		result.Files = append(result.Files, atestFileResult{File: wavFileName, Decoded: packets_decoded_one, DurationSeconds: one_filetime})
+
 		atestFP.Close()
 	}
 
@@ -650,22 +608,102 @@ o = DCD output control
 
 	fmt.Printf("%d packets decoded in %.3f seconds.  %.1f x realtime\n", packets_decoded_total, elapsed.Seconds(), total_filetime/float64(elapsed.Seconds()))
 
+	if original != nil {
+		atest_compare_report(os.Stdout, original, atestNow)
+	}
+
 	if d_o_opt > 0 {
 		fmt.Printf("DCD count = %d\n", dcd_count)
 		fmt.Printf("DCD missing errors = %d\n", dcd_missing_errors)
 	}
 
 	if *errorIfLessThan != -1 && packets_decoded_total < *errorIfLessThan {
-		text_color_set(DW_COLOR_ERROR)
-		fmt.Printf("\n * * * TEST FAILED: number decoded is less than %d * * * \n", *errorIfLessThan)
-		os.Exit(1)
+		result.Failure = fmt.Sprintf("number decoded is less than %d", *errorIfLessThan)
 	}
 
 	if *errorIfGreaterThan != -1 && packets_decoded_total > *errorIfGreaterThan {
+		result.Failure = fmt.Sprintf("number decoded is greater than %d", *errorIfGreaterThan)
+	}
+
+	result.Decoded = packets_decoded_total
+	result.ElapsedSeconds = elapsed.Seconds()
+
+	if !atest_write_results(*jsonFile, *junitFile, []atestResult{result}) {
+		os.Exit(1)
+	}
+
+	if result.Failure != "" {
 		text_color_set(DW_COLOR_ERROR)
-		fmt.Printf("\n * * * TEST FAILED: number decoded is greater than %d * * * \n", *errorIfGreaterThan)
+		fmt.Printf("\n * * * TEST FAILED: %s * * * \n", result.Failure)
+		os.Exit(1)
+	}
+}
+
+/*
+ * Test each profile of a comma separated list in its own process,
+ * then summarize.
+ */
+
+func atest_multiple_profiles(profiles []string, jobs int, json_file string, junit_file string) {
+	var results = atest_profiles(profiles, jobs)
+
+	var failed = false
+
+	text_color_set(DW_COLOR_INFO)
+	fmt.Printf("\n")
+
+	for _, r := range results {
+		fmt.Printf("%-10s %d decoded in %.3f seconds", r.Profile, r.Decoded, r.ElapsedSeconds)
+
+		for _, f := range r.Files {
+			fmt.Printf(", %d from %s", f.Decoded, f.File)
+		}
+
+		fmt.Printf("\n")
+
+		if r.Failure != "" {
+			failed = true
+		}
+	}
+
+	if !atest_write_results(json_file, junit_file, results) {
 		os.Exit(1)
 	}
+
+	for _, r := range results {
+		if r.Failure != "" {
+			text_color_set(DW_COLOR_ERROR)
+			fmt.Printf("\n * * * TEST FAILED: profile %s: %s * * * \n", r.Profile, r.Failure)
+		}
+	}
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+func atest_write_results(json_file string, junit_file string, results []atestResult) bool {
+	if json_file != "" {
+		var err = atest_write_json(json_file, results)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			fmt.Printf("Couldn't write %s: %s\n", json_file, err)
+
+			return false
+		}
+	}
+
+	if junit_file != "" {
+		var err = atest_write_junit(junit_file, results)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			fmt.Printf("Couldn't write %s: %s\n", junit_file, err)
+
+			return false
+		}
+	}
+
+	return true
 }
 
 /*
@@ -673,13 +711,13 @@ o = DCD output control
  */
 
 func audio_get_fake(_ int) int {
-	if wav_data.Datasize <= 0 {
+	if wav_data_size <= 0 {
 		e_o_f = true
 		return (-1)
 	}
 
 	var data, err = atestBuf.ReadByte()
-	wav_data.Datasize--
+	wav_data_size--
 
 	if errors.Is(err, io.EOF) {
 		text_color_set(DW_COLOR_ERROR)
@@ -816,6 +854,10 @@ func dlq_rec_frame_fake(channel int, subchan int, slice int, pp *packet_t, aleve
 		dw_printf("------\n")
 	}
 
+	if atestNow != nil {
+		atestNow.add(pp)
+	}
+
 	/*
 		#if 0		// temp experiment
 
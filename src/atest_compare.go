@@ -0,0 +1,231 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Compare what atest decodes from a recording with what
+ *		was decoded when it was originally received.
+ *
+ * Description:	Record the receive audio along with the usual daily
+ *		log, e.g.
+ *
+ *			arecord -f S16_LE -r 44100 2024-05-06.wav
+ *
+ *		then later run it through a newer version:
+ *
+ *			atest --compare-log 2024-05-06.log 2024-05-06.wav
+ *
+ *		and see how many more (or fewer) packets are decoded,
+ *		and from which stations.
+ *
+ *		The original can be the CSV log file from direwolf -l
+ *		or a capture from direwolf --pcap.  Only APRS packets are
+ *		counted because those are the only ones in the log.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+/* Number of APRS packets from each station. */
+
+type atestHeard map[string]int
+
+/* Packets decoded now, when comparing.  nil otherwise. */
+
+var atestNow atestHeard
+
+func (h atestHeard) add(pp *packet_t) {
+	if !ax25_is_aprs(pp) {
+		return
+	}
+
+	var A = decode_aprs(pp, true, "")
+
+	h[A.g_src]++
+}
+
+func (h atestHeard) total() int {
+	var n = 0
+
+	for _, count := range h {
+		n += count
+	}
+
+	return n
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        atest_read_log
+ *
+ * Purpose:     Count the stations in a log file from direwolf -l.
+ *
+ * Inputs:	path		- CSV log file.
+ *
+ *		channels	- Which radio channels to count.
+ *
+ *		h		- Counts are added here.
+ *
+ *--------------------------------------------------------------------*/
+
+func atest_read_log(path string, channels func(int) bool, h atestHeard) error {
+	var f, err = os.Open(path) //nolint:gosec // Named on the command line.
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	var r = csv.NewReader(f)
+	r.FieldsPerRecord = -1
+
+	var header, headerErr = r.Read()
+	if headerErr != nil {
+		return fmt.Errorf("%s: %w", path, headerErr)
+	}
+
+	var chanCol = slices.Index(header, "chan")
+	var sourceCol = slices.Index(header, "source")
+
+	if chanCol < 0 || sourceCol < 0 {
+		return fmt.Errorf("%s: not a direwolf log file, missing chan or source column", path)
+	}
+
+	for {
+		var record, readErr = r.Read()
+		if errors.Is(readErr, io.EOF) {
+			return nil
+		} else if readErr != nil {
+			return fmt.Errorf("%s: %w", path, readErr)
+		}
+
+		if len(record) <= max(chanCol, sourceCol) {
+			continue
+		}
+
+		/* Skip beacons and other things logged with a fake channel. */
+
+		var channel, atoiErr = strconv.Atoi(record[chanCol])
+		if atoiErr != nil || channel < 0 || channel >= MAX_RADIO_CHANS || !channels(channel) {
+			continue
+		}
+
+		h[record[sourceCol]]++
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        atest_read_pcap
+ *
+ * Purpose:     Count the stations in a capture from direwolf --pcap.
+ *
+ * Description:	Transmitted frames are skipped, using the comment.
+ *
+ *--------------------------------------------------------------------*/
+
+func atest_read_pcap(path string, channels func(int) bool, h atestHeard) error {
+	var f, err = os.Open(path) //nolint:gosec // Named on the command line.
+	if err != nil {
+		return err
+	}
+
+	defer f.Close()
+
+	err = pcapng_read(f, func(frame []byte, comment string) {
+		var channel int
+
+		var n, _ = fmt.Sscanf(comment, "rx channel %d", &channel)
+		if n != 1 || !channels(channel) {
+			return
+		}
+
+		var alevel ALevel
+
+		var pp = AX25FromFrame(frame, alevel)
+		if pp == nil {
+			return
+		}
+
+		h.add(pp)
+		AX25Delete(pp)
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	return nil
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        atest_compare_report
+ *
+ * Purpose:     Show the difference between the original and now.
+ *
+ * Description:	Only stations with a different count are listed,
+ *		biggest change first.
+ *
+ *--------------------------------------------------------------------*/
+
+func atest_compare_report(w io.Writer, original atestHeard, now atestHeard) {
+	var stations []string
+
+	for s := range original {
+		if original[s] != now[s] {
+			stations = append(stations, s)
+		}
+	}
+
+	for s := range now {
+		if _, ok := original[s]; !ok {
+			stations = append(stations, s)
+		}
+	}
+
+	var abs = func(n int) int { return max(n, -n) }
+
+	slices.SortFunc(stations, func(a, b string) int {
+		var da, db = abs(now[a] - original[a]), abs(now[b] - original[b])
+		if da != db {
+			return db - da
+		}
+
+		return strings.Compare(a, b)
+	})
+
+	fmt.Fprintf(w, "\n%-12s %8s %8s %8s\n", "Station", "Original", "Now", "Change")
+
+	for _, s := range stations {
+		fmt.Fprintf(w, "%-12s %8d %8d %+8d\n", s, original[s], now[s], now[s]-original[s])
+	}
+
+	var same = len(original) + len(now) - len(stations)
+	for s := range now {
+		if _, ok := original[s]; ok {
+			same--
+		}
+	}
+
+	if same > 0 {
+		fmt.Fprintf(w, "%d other stations the same.\n", same)
+	}
+
+	var to, tn = original.total(), now.total()
+
+	fmt.Fprintf(w, "%-12s %8d %8d %+8d", "Total", to, tn, tn-to)
+
+	if to > 0 {
+		fmt.Fprintf(w, "  (%+.1f%%)", 100*float64(tn-to)/float64(to))
+	}
+
+	fmt.Fprintf(w, "\n")
+}
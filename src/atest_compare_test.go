@@ -0,0 +1,92 @@
+package direwolf
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func atest_compare_all_channels(int) bool { return true }
+
+func Test_atest_read_log(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.log")
+
+	require.NoError(t, os.WriteFile(path, []byte(
+		"chan,utime,isotime,source,heard,level,error,dti,name,symbol,latitude,longitude,speed,course,altitude,frequency,offset,tone,system,status,telemetry,comment\n"+
+			"0,1715000000,2024-05-06T12:53:20Z,Q1TEST,Q1TEST,50(25/24),0,!,Q1TEST,/>,42.1,-71.1,,,,,,,,,,\"Hello, world\"\n"+
+			"0,1715000001,2024-05-06T12:53:21Z,Q1TEST,DIGI,50(25/24),0,!,Q1TEST,/>,42.1,-71.1,,,,,,,,,,\n"+
+			"1,1715000002,2024-05-06T12:53:22Z,Q1TEST-2,Q1TEST-2,40(20/19),0,>,Q1TEST-2,,,,,,,,,,,,,\n"+
+			"999,1715000003,2024-05-06T12:53:23Z,Q1TEST-9,,,,/,,,,,,,,,,,,,,\n"), 0o600))
+
+	var h = make(atestHeard)
+
+	require.NoError(t, atest_read_log(path, atest_compare_all_channels, h))
+	assert.Equal(t, atestHeard{"Q1TEST": 2, "Q1TEST-2": 1}, h)
+
+	h = make(atestHeard)
+
+	require.NoError(t, atest_read_log(path, func(channel int) bool { return channel == 1 }, h))
+	assert.Equal(t, atestHeard{"Q1TEST-2": 1}, h)
+
+	require.NoError(t, os.WriteFile(path, []byte("something,else\n1,2\n"), 0o600))
+	assert.Error(t, atest_read_log(path, atest_compare_all_channels, h))
+}
+
+func Test_atest_read_pcap(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "test.pcapng")
+
+	var pw, err = NewPcapngWriter(path)
+	require.NoError(t, err)
+
+	for _, p := range []struct {
+		text    string
+		comment string
+	}{
+		{"Q1TEST>APDW18,WIDE2-1:!4237.14N/07120.83W-", "rx channel 0, demodulator 0, slicer 0"},
+		{"Q1TEST>APDW18:>Hello", "rx channel 0"},
+		{"Q1TEST-2>APDW18:}Q1TEST-3>APDW18,TCPIP,Q1TEST-2*:>Third party", "rx channel 1"},
+		{"Q1TEST-5>APDW18:>Sent by us", "tx channel 0, priority L"},
+		{"Q1TEST-6>Q1TEST-7:Not APRS", "rx channel 0"},
+	} {
+		var pp = AX25FromText(p.text, true)
+		require.NotNil(t, pp, p.text)
+
+		if p.text == "Q1TEST-6>Q1TEST-7:Not APRS" {
+			ax25_set_pid(pp, AX25_PID_NETROM)
+		}
+
+		pw.WriteFrame(time.Now(), AX25Pack(pp), p.comment)
+		AX25Delete(pp)
+	}
+
+	pw.Close()
+
+	var h = make(atestHeard)
+
+	require.NoError(t, atest_read_pcap(path, atest_compare_all_channels, h))
+	assert.Equal(t, atestHeard{"Q1TEST": 2, "Q1TEST-3": 1}, h)
+}
+
+func Test_atest_compare_report(t *testing.T) {
+	var original = atestHeard{"Q1TEST": 10, "Q1TEST-1": 5, "Q1TEST-2": 3, "Q1TEST-3": 1}
+	var now = atestHeard{"Q1TEST": 12, "Q1TEST-1": 5, "Q1TEST-2": 1, "Q1TEST-4": 2}
+
+	var buf bytes.Buffer
+
+	atest_compare_report(&buf, original, now)
+
+	assert.Equal(t, `
+Station      Original      Now   Change
+Q1TEST             10       12       +2
+Q1TEST-2            3        1       -2
+Q1TEST-4            0        2       +2
+Q1TEST-3            1        0       -1
+1 other stations the same.
+Total              19       20       +1  (+5.3%)
+`, buf.String())
+}
@@ -0,0 +1,272 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Machine readable results from atest, and running
+ *		several demodulator profiles at once.
+ *
+ * Description:	--json FILE writes the number of frames decoded from
+ *		each .WAV file, and the total, for each profile.
+ *		--junit FILE writes the same as JUnit XML so a CI
+ *		system can show it.  Each file is a test case, plus
+ *		one for the total which fails if outside the -L / -G
+ *		limits.
+ *
+ *		-P with a comma separated list, e.g. "-P A,B,E+", tests
+ *		each profile separately.  The demodulators keep their
+ *		state in package variables, so each profile is run as
+ *		another atest process, up to --jobs at a time, and the
+ *		results are collected afterward.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/spf13/pflag"
+)
+
+type atestFileResult struct {
+	File            string  `json:"file"`
+	Decoded         int     `json:"decoded"`
+	DurationSeconds float64 `json:"duration_seconds"`
+}
+
+type atestResult struct {
+	Profile        string            `json:"profile"`
+	Files          []atestFileResult `json:"files"`
+	Decoded        int               `json:"decoded"`
+	ElapsedSeconds float64           `json:"elapsed_seconds"`
+	Failure        string            `json:"failure,omitempty"` // -L or -G limit not met.
+}
+
+type atestResults struct {
+	Results []atestResult `json:"results"`
+}
+
+func atest_profile_name(profiles string) string {
+	if profiles == "" {
+		return "default"
+	}
+
+	return profiles
+}
+
+func atest_write_json(path string, results []atestResult) error {
+	var data, err = json.MarshalIndent(atestResults{Results: results}, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(data, '\n'), 0644) //nolint:gosec // Results aren't secret.
+}
+
+func atest_read_json(path string) ([]atestResult, error) {
+	var data, err = os.ReadFile(path) //nolint:gosec // Written by our own child process.
+	if err != nil {
+		return nil, err
+	}
+
+	var results atestResults
+
+	err = json.Unmarshal(data, &results)
+
+	return results.Results, err
+}
+
+type junitFailure struct {
+	Message string `xml:"message,attr"`
+}
+
+type junitTestCase struct {
+	Classname string        `xml:"classname,attr"`
+	Name      string        `xml:"name,attr"`
+	Time      string        `xml:"time,attr,omitempty"`
+	Failure   *junitFailure `xml:"failure,omitempty"`
+	SystemOut string        `xml:"system-out,omitempty"`
+}
+
+type junitTestSuite struct {
+	Name      string          `xml:"name,attr"`
+	Tests     int             `xml:"tests,attr"`
+	Failures  int             `xml:"failures,attr"`
+	Time      string          `xml:"time,attr"`
+	TestCases []junitTestCase `xml:"testcase"`
+}
+
+type junitTestSuites struct {
+	XMLName xml.Name         `xml:"testsuites"`
+	Suites  []junitTestSuite `xml:"testsuite"`
+}
+
+func atest_write_junit(path string, results []atestResult) error {
+	var suites junitTestSuites
+
+	for _, r := range results {
+		var suite = junitTestSuite{
+			Name:      "atest profile " + r.Profile,
+			Tests:     0,
+			Failures:  0,
+			Time:      strconv.FormatFloat(r.ElapsedSeconds, 'f', 3, 64),
+			TestCases: nil,
+		}
+
+		var classname = "atest." + r.Profile
+
+		for _, f := range r.Files {
+			suite.TestCases = append(suite.TestCases, junitTestCase{
+				Classname: classname,
+				Name:      filepath.Base(f.File),
+				Time:      "",
+				Failure:   nil,
+				SystemOut: fmt.Sprintf("%d decoded from %s, %.1f seconds of audio", f.Decoded, f.File, f.DurationSeconds),
+			})
+		}
+
+		var total = junitTestCase{
+			Classname: classname,
+			Name:      "total",
+			Time:      "",
+			Failure:   nil,
+			SystemOut: fmt.Sprintf("%d decoded", r.Decoded),
+		}
+
+		if r.Failure != "" {
+			total.Failure = &junitFailure{Message: r.Failure}
+			suite.Failures++
+		}
+
+		suite.TestCases = append(suite.TestCases, total)
+		suite.Tests = len(suite.TestCases)
+
+		suites.Suites = append(suites.Suites, suite)
+	}
+
+	var data, err = xml.MarshalIndent(suites, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append([]byte(xml.Header), append(data, '\n')...), 0644) //nolint:gosec // Results aren't secret.
+}
+
+/*
+ * Command line for running one profile in another process.
+ * Options are passed on as given, except for those which apply
+ * to the whole run.
+ */
+
+func atest_child_args(flags *pflag.FlagSet, profile string, json_file string) []string {
+	var args []string
+
+	flags.Visit(func(f *pflag.Flag) {
+		switch f.Name {
+		case "modem-profile", "jobs", "json", "junit":
+			return
+		}
+
+		if sv, ok := f.Value.(pflag.SliceValue); ok {
+			for _, v := range sv.GetSlice() {
+				args = append(args, "--"+f.Name+"="+v)
+			}
+
+			return
+		}
+
+		args = append(args, "--"+f.Name+"="+f.Value.String())
+	})
+
+	args = append(args, "--modem-profile="+profile, "--json="+json_file, "--")
+
+	return append(args, flags.Args()...)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        atest_profiles
+ *
+ * Purpose:     Test several demodulator profiles in parallel.
+ *
+ * Inputs:	profiles	- List from -P option.
+ *
+ *		jobs		- Maximum number running at once.
+ *
+ * Returns:	Results for each profile in the order given.  A profile
+ *		whose process didn't produce results has Failure set.
+ *
+ * Description:	Output from each run is only shown if it failed
+ *		without writing its results, because it would be a
+ *		jumble otherwise.
+ *
+ *--------------------------------------------------------------------*/
+
+func atest_profiles(profiles []string, jobs int) []atestResult {
+	var results = make([]atestResult, len(profiles))
+
+	var self, err = os.Executable()
+	if err != nil {
+		self = os.Args[0]
+	}
+
+	var tmpdir, tmpErr = os.MkdirTemp("", "atest")
+	if tmpErr != nil {
+		for i, p := range profiles {
+			results[i] = atestResult{Profile: p, Files: nil, Decoded: 0, ElapsedSeconds: 0, Failure: tmpErr.Error()}
+		}
+
+		return results
+	}
+
+	defer os.RemoveAll(tmpdir)
+
+	var wg sync.WaitGroup
+
+	var sem = make(chan struct{}, max(jobs, 1))
+
+	var outputs = make([][]byte, len(profiles))
+
+	for i, p := range profiles {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			sem <- struct{}{}
+			defer func() { <-sem }()
+
+			var json_file = filepath.Join(tmpdir, strconv.Itoa(i)+".json")
+
+			var cmd = exec.Command(self, atest_child_args(pflag.CommandLine, p, json_file)...) //nolint:gosec // Ourselves.
+
+			var output, _ = cmd.CombinedOutput()
+
+			var r, readErr = atest_read_json(json_file)
+			if readErr != nil || len(r) != 1 {
+				results[i] = atestResult{Profile: p, Files: nil, Decoded: 0, ElapsedSeconds: 0, Failure: "atest did not complete"}
+				outputs[i] = output
+
+				return
+			}
+
+			results[i] = r[0]
+		}()
+	}
+
+	wg.Wait()
+
+	for i, output := range outputs {
+		if output != nil {
+			text_color_set(DW_COLOR_ERROR)
+			fmt.Printf("Profile %s did not complete:\n%s\n", profiles[i], output)
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,96 @@
+package direwolf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_atest_json(t *testing.T) {
+	atestRestore(t)
+
+	// AtestMain registers flags on pflag.CommandLine.  Leave a fresh one for the next test.
+	pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+
+	var oldArgs = os.Args
+
+	defer func() {
+		os.Args = oldArgs
+		pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+	}()
+
+	var tmpdir = t.TempDir()
+
+	var f = filepath.Join(tmpdir, "extra_chunks.wav")
+	require.NoError(t, os.WriteFile(f, buildWAVWithExtraChunks(t), 0600))
+
+	var json_file = filepath.Join(tmpdir, "results.json")
+	var junit_file = filepath.Join(tmpdir, "results.xml")
+
+	os.Args = []string{"atest", "--json", json_file, "--junit", junit_file, f}
+
+	AssertOutputContains(t, AtestMain, "0 packets decoded")
+
+	var results, err = atest_read_json(json_file)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+	assert.Equal(t, "default", results[0].Profile)
+	assert.Equal(t, 0, results[0].Decoded)
+	assert.Empty(t, results[0].Failure)
+	require.Len(t, results[0].Files, 1)
+	assert.Equal(t, f, results[0].Files[0].File)
+	assert.InDelta(t, 0.1, results[0].Files[0].DurationSeconds, 0.001)
+
+	var junit, junitErr = os.ReadFile(junit_file)
+	require.NoError(t, junitErr)
+	assert.Contains(t, string(junit), `<testsuite name="atest profile default" tests="2" failures="0"`)
+}
+
+func Test_atest_write_junit(t *testing.T) {
+	var results = []atestResult{
+		{Profile: "A", Files: []atestFileResult{{File: "dir/01.wav", Decoded: 900, DurationSeconds: 600}}, Decoded: 900, ElapsedSeconds: 1.5, Failure: ""},
+		{Profile: "E+", Files: []atestFileResult{{File: "dir/01.wav", Decoded: 800, DurationSeconds: 600}}, Decoded: 800, ElapsedSeconds: 2, Failure: "number decoded is less than 850"},
+	}
+
+	var path = filepath.Join(t.TempDir(), "results.xml")
+	require.NoError(t, atest_write_junit(path, results))
+
+	var data, err = os.ReadFile(path)
+	require.NoError(t, err)
+
+	var xml = string(data)
+	assert.Contains(t, xml, `<testsuite name="atest profile A" tests="2" failures="0" time="1.500">`)
+	assert.Contains(t, xml, `<testcase classname="atest.A" name="01.wav">`)
+	assert.Contains(t, xml, `<system-out>900 decoded from dir/01.wav, 600.0 seconds of audio</system-out>`)
+	assert.Contains(t, xml, `<testsuite name="atest profile E+" tests="2" failures="1" time="2.000">`)
+	assert.Contains(t, xml, `<failure message="number decoded is less than 850"></failure>`)
+}
+
+func Test_atest_child_args(t *testing.T) {
+	var flags = pflag.NewFlagSet("atest", pflag.ContinueOnError)
+	flags.StringP("bitrate", "B", "1200", "")
+	flags.StringP("modem-profile", "P", "", "")
+	flags.IntP("error-if-less-than", "L", -1, "")
+	flags.StringSliceP("debug", "d", []string{}, "")
+	flags.Int("jobs", 4, "")
+	flags.String("json", "", "")
+	flags.String("junit", "", "")
+
+	require.NoError(t, flags.Parse([]string{"-B", "300", "-P", "A,B", "-L", "5", "-dx", "-do", "--jobs", "2", "--junit", "r.xml", "a.wav", "--", "-b.wav"}))
+
+	assert.Equal(t, []string{
+		"--bitrate=300",
+		"--debug=x",
+		"--debug=o",
+		"--error-if-less-than=5",
+		"--modem-profile=C",
+		"--json=/tmp/c.json",
+		"--",
+		"a.wav",
+		"-b.wav",
+	}, atest_child_args(flags, "C", "/tmp/c.json"))
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/binary"
 	"io"
 	"os"
-	"os/exec"
 	"path/filepath"
 	"testing"
 
@@ -14,19 +13,24 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
-// Examples from original atest.c source, using packets generated by Dire Wolf gen_packets until we port that...
+// Examples from original atest.c source, using packets from our gen_packets.
+
+/* AtestMain fakes PTT and received frames from then on.  Put them back for the other tests. */
+
+func atestRestore(t *testing.T) {
+	t.Helper()
+
+	t.Cleanup(func() { ATEST_C = false })
+}
 
 func Test_atest_basic_1(t *testing.T) {
+	atestRestore(t)
+
 	var tmpdir = t.TempDir()
 
 	var f = filepath.Join(tmpdir, "test1.wav")
 
-	var cmd = exec.Command("gen_packets", "-o", f) //nolint:gosec
-
-	var err = cmd.Run()
-	if err != nil {
-		t.Fail()
-	}
+	genPacketsRun(t, "-o", f)
 
 	os.Args = []string{"atest", f}
 
@@ -113,6 +117,8 @@ func buildWAVWithExtraChunks(t *testing.T) []byte {
 // arbitrary metadata chunks (before "fmt " and between "fmt " and "data")
 // and successfully decodes a file containing such chunks.
 func Test_atest_extraChunks(t *testing.T) {
+	atestRestore(t)
+
 	// AtestMain registers flags on pflag.CommandLine; reset it so this test
 	// can run independently of Test_atest_basic_1.
 	pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
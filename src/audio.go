@@ -6,7 +6,9 @@ package direwolf
  * Purpose:   	Interface to audio device commonly called a "sound card" for
  *		historical reasons.
  *
- *		This version uses PortAudio for cross-platform audio support.
+ *		Sound cards are opened with PortAudio for cross-platform
+ *		support, or directly with ALSA or OSS, in pure Go, for
+ *		names like alsa:hw:1,0 or oss:/dev/dsp.  See audio_device.go.
  *
  * References:	PortAudio documentation: http://www.portaudio.com/
  *		Go bindings: https://github.com/gordonklaus/portaudio
@@ -19,11 +21,11 @@ package direwolf
  *
  *		1.2 - Add ability to use more than one audio device.
  *		Go port - Replaced ALSA with PortAudio for cross-platform support.
+ *		Go port - Native ALSA and OSS without cgo, behind AudioDevice.
  *
  *---------------------------------------------------------------*/
 
 import (
-	"encoding/binary"
 	"errors"
 	"fmt"
 	"io"
@@ -31,8 +33,7 @@ import (
 	"os"
 	"strings"
 	"sync"
-
-	"github.com/gordonklaus/portaudio"
+	"time"
 )
 
 /*
@@ -42,13 +43,30 @@ import (
 type ptt_method_t int
 
 const (
-	PTT_METHOD_NONE   ptt_method_t = iota /* VOX or no transmit. */
-	PTT_METHOD_SERIAL                     /* Serial port RTS or DTR. */
-	PTT_METHOD_GPIO                       /* General purpose I/O using sysfs, deprecated after 2020, Linux only. */
-	PTT_METHOD_GPIOD                      /* General purpose I/O, using libgpiod, Linux only. */
-	PTT_METHOD_LPT                        /* Parallel printer port, Linux only. */
-	PTT_METHOD_HAMLIB                     /* HAMLib, Linux only. */
-	PTT_METHOD_CM108                      /* GPIO pin of CM108/CM119/etc.  Linux only. */
+	PTT_METHOD_NONE     ptt_method_t = iota /* VOX or no transmit. */
+	PTT_METHOD_SERIAL                       /* Serial port RTS or DTR. */
+	PTT_METHOD_GPIOD                        /* General purpose I/O, using the GPIO character device, Linux only. */
+	PTT_METHOD_LPT                          /* Parallel printer port, Linux only. */
+	PTT_METHOD_HAMLIB                       /* HAMLib, Linux only. */
+	PTT_METHOD_CM108                        /* GPIO pin of CM108/CM119/etc.  Linux only. */
+	PTT_METHOD_CAT                          /* Radio's own CAT commands, without hamlib. */
+	PTT_METHOD_HTTP                         /* Relay on the network, HTTP GET to switch. */
+	PTT_METHOD_MQTT                         /* Relay on the network, MQTT publish to switch. */
+	PTT_METHOD_MCP23017                     /* MCP23017 I2C GPIO expander.  Linux only. */
+)
+
+/*
+ * Bias for a GPIO line, set when the line is requested.
+ * Needs Linux 5.5 or later for anything but "as is".
+ */
+
+type gpio_bias_t int
+
+const (
+	GPIO_BIAS_AS_IS     gpio_bias_t = iota /* Leave it however the kernel or device tree has it. */
+	GPIO_BIAS_DISABLED                     /* No pull up or down. */
+	GPIO_BIAS_PULL_UP                      /* Internal pull up resistor. */
+	GPIO_BIAS_PULL_DOWN                    /* Internal pull down resistor. */
 )
 
 type ptt_line_t int
@@ -65,6 +83,7 @@ const (
 	AUDIO_IN_TYPE_SOUNDCARD audio_in_type_e = iota
 	AUDIO_IN_TYPE_SDR_UDP
 	AUDIO_IN_TYPE_STDIN
+	AUDIO_IN_TYPE_FILE
 )
 
 // Type of communication medium associated with the channel.
@@ -149,13 +168,20 @@ type dtmf_decode_t int
 const (
 	DTMF_DECODE_OFF dtmf_decode_t = iota
 	DTMF_DECODE_ON
+	DTMF_DECODE_ONLY /* Touch tones but not packets, to save CPU time. */
 )
 
 const OCTYPE_PTT = 0
 const OCTYPE_DCD = 1
 const OCTYPE_CON = 2
+const OCTYPE_SEQ1 = 3 /* Switched on after PTT, and off before it.  See ptt_sequence.go. */
+const OCTYPE_SEQ2 = 4 /* and after / before SEQ1. */
+const OCTYPE_IND1 = 5 /* Extra outputs following PTT, DCD, or CON.  See ptt_indicator.go. */
+const OCTYPE_IND2 = 6
+const OCTYPE_IND3 = 7
+const OCTYPE_IND4 = 8
 
-const NUM_OCTYPES = 3 /* number of values above.   i.e. last value +1. */
+const NUM_OCTYPES = 9 /* number of values above.   i.e. last value +1. */
 
 const MAX_GPIO_NAME_LEN = 20 // 12 would cover any case I've seen so this should be safe
 
@@ -280,7 +306,7 @@ type achan_param_s struct {
 	/* Index following structure by one of these: */
 
 	octrl [NUM_OCTYPES]struct {
-		ptt_method ptt_method_t /* none, serial port, GPIO, LPT, HAMLIB, CM108. */
+		ptt_method ptt_method_t /* none, serial port, GPIOD, LPT, HAMLIB, CM108, CAT, HTTP, MQTT, MCP23017. */
 
 		ptt_device string /* Serial device name for PTT.  e.g. COM1 or /dev/ttyS0 */
 		/* Also used for HAMLIB.  Could be host:port when model is 1. */
@@ -303,19 +329,17 @@ type achan_param_s struct {
 		/* For CM108/CM119, this should be in range of 1-8. */
 
 		out_gpio_name string
-		/* originally, gpio number NN was assumed to simply */
-		/* have the name gpioNN but this turned out not to be */
-		/* the case for CubieBoard where it was longer. */
-		/* This is filled in by ptt_init so we don't have to */
-		/* recalculate it each time we access it. */
-		/* Also GPIO chip name for GPIOD method. Looks like 'gpiochip4' */
+		/* GPIO chip device path for GPIOD method. Looks like '/dev/gpiochip4' */
+		/* "PTT GPIO nn" is line nn of /dev/gpiochip0. */
+
+		out_gpio_bias gpio_bias_t /* Pull up, pull down, or leave as is. */
 
 		/* This could probably be collapsed into ptt_device instead of being separate. */
 
 		ptt_lpt_bit int /* Bit number for parallel printer port.  */
 		/* Bit 0 = pin 2, ..., bit 7 = pin 9. */
 
-		ptt_invert  bool /* Invert the output. */
+		ptt_invert  bool /* Invert the output.  GPIOD lines are requested active low instead. */
 		ptt_invert2 bool /* Invert the secondary output. */
 
 		//#ifdef USE_HAMLIB
@@ -325,19 +349,28 @@ type achan_param_s struct {
 		/* If zero, hamlib will come up with a default for pariticular rig. */
 		//#endif
 
+		ptt_cat_type cat_type_t /* Icom, Yaesu, etc. for PTT_METHOD_CAT.  Also uses ptt_device and ptt_rate. */
+		ptt_cat_addr int        /* Icom CI-V address of the radio. */
+
+		ptt_i2c_addr int /* MCP23017 address.  ptt_device is the I2C bus and out_gpio_num the pin, 0 for A0 thru 15 for B7. */
+
+		ind_follows int /* For OCTYPE_IND1 and up, the output this one follows. */
+
+		/* For MQTT, ptt_device is the broker. */
+		ptt_remote_on  string /* HTTP URL or MQTT payload to turn on. */
+		ptt_remote_off string /* and to turn off. */
+		ptt_mqtt_topic string
 	}
 
 	ictrl [NUM_ICTYPES]struct {
-		method ptt_method_t /* none, serial port, GPIO, LPT. */
+		method ptt_method_t /* none, GPIOD, or CM108. */
+
+		in_gpio_num int /* GPIO line number, or 1 thru 8 for CM108. */
 
-		in_gpio_num int /* GPIO number */
+		in_gpio_name string /* GPIO chip device path, as for out_gpio_name, */
+		/* or HID device for CM108. */
 
-		in_gpio_name string
-		/* originally, gpio number NN was assumed to simply */
-		/* have the name gpioNN but this turned out not to be */
-		/* the case for CubieBoard where it was longer. */
-		/* This is filled in by ptt_init so we don't have to */
-		/* recalculate it each time we access it. */
+		in_gpio_bias gpio_bias_t /* Pull up, pull down, or leave as is. */
 
 		invert bool /* true = active low */
 	}
@@ -369,6 +402,26 @@ type achan_param_s struct {
 
 	fulldup bool /* Full Duplex. */
 
+	tx_timeout int /* Longest time, in seconds, PTT can stay on before */
+	/* we force it off.  0 for no limit.  See ptt_watchdog.go. */
+
+	txseq_ms []int /* Delays, in milliseconds, after PTT, SEQ1, and SEQ2 */
+	/* are turned on.  See ptt_sequence.go. */
+
+	rig_poll int /* Seconds between asking hamlib for frequency and */
+	/* mode.  0 for never.  See rig_poll.go. */
+
+	chan_profiles []*chan_profile_s /* Other frequencies and modems to switch */
+	/* to.  See profile.go. */
+
+	response_gap int /* Seconds of rest between audible responses. */
+
+	response_max int /* Audible responses allowed to wait.  See xmit_response.go. */
+
+	cwid_every int /* Seconds between Morse code IDs, if we transmitted.  0 for none.  See cwid.go. */
+
+	cwid_wpm int /* Speed of the ID. */
+
 }
 
 type audio_s struct {
@@ -383,6 +436,16 @@ type audio_s struct {
 
 	tts_script string /* Script for text to speech. */
 
+	tts_espeak string /* espeak-ng or espeak for SPEECH ESPEAK, instead of a script. */
+
+	tts_voice string /* Optional voice for espeak. */
+
+	tx_priority [TX_NUM_REASONS]int /* Who goes first when channels share a */
+	/* transmitter.  See tx_arbiter.go. */
+
+	tx_slice int /* Seconds a channel can keep a shared transmitter */
+	/* while another is waiting.  0 for no limit. */
+
 	statistics_interval int /* Number of seconds between the audio */
 	/* statistics reports.  This is set by */
 	/* the "-a" option.  0 to disable feature. */
@@ -408,6 +471,9 @@ type audio_s struct {
 	timestamp_format string /* -T option */
 	/* Precede received & transmitted frames with timestamp. */
 	/* Command line option uses "strftime" format string. */
+	/* ISO 8601 if empty. */
+
+	timestamp_location *time.Location /* --monitor-time.  nil for no timestamp. */
 
 	/* originally a "channel" was always connected to an internal modem. */
 	/* In version 1.6, this is generalized so that a channel (as seen by client application) */
@@ -425,6 +491,9 @@ type audio_s struct {
 	mycall [MAX_TOTAL_CHANS]string /* Call associated with this radio channel. */
 	/* Could all be the same or different. */
 
+	chan_name [MAX_TOTAL_CHANS]string /* Optional human-readable name, e.g. "VHF 144.39", */
+	/* from CHANNEL n NAME=...  Empty if not set. */
+
 	chan_medium [MAX_TOTAL_CHANS]medium_e
 	// MEDIUM_NONE for invalid.
 	// MEDIUM_RADIO for internal modem.  (only possibility earlier)
@@ -442,6 +511,15 @@ type audio_s struct {
 
 	nettnc_port [MAX_TOTAL_CHANS]int // Network TNC TCP port.
 
+	// Network TNC which is really another instance's BRIDGEPORT.  See bridge.go.
+
+	nettnc_bridge      [MAX_TOTAL_CHANS]bool   // BCHANNEL rather than NCHANNEL.
+	nettnc_remote_chan [MAX_TOTAL_CHANS]int    // Channel on the other instance.
+	nettnc_tls         [MAX_TOTAL_CHANS]bool   // Connect with TLS.
+	nettnc_tls_cert    [MAX_TOTAL_CHANS]string // Our certificate and key files, for the other end to check.
+	nettnc_tls_key     [MAX_TOTAL_CHANS]string
+	nettnc_tls_ca      [MAX_TOTAL_CHANS]string // CA certificate file, if not a system one.
+
 	achan [MAX_RADIO_CHANS]achan_param_s
 
 	/* TODO KG
@@ -674,9 +752,9 @@ func (rb *audioRingBuffer) close() {
 /* Current state for each of the audio devices. */
 
 type adev_s struct {
-	// PortAudio streams (replaces ALSA handles)
-	inputStream  *portaudio.Stream
-	outputStream *portaudio.Stream
+	// Sound card or stdin, see audio_device.go.  nil for UDP.
+	in  AudioDevice
+	out AudioDevice
 
 	// Audio format
 	bytesPerFrame int
@@ -684,14 +762,6 @@ type adev_s struct {
 	numChannels   int
 	bitsPerSample int
 
-	// Ring buffer for input audio (filled by callback)
-	inputRingBuf *audioRingBuffer
-
-	// Output buffers for blocking writes
-	outputBuf16   []int16 // Output buffer for 16-bit blocking writes
-	outputBuf8    []uint8 // Output buffer for 8-bit blocking writes
-	outputStarted bool    // Whether output stream is currently started
-
 	// Byte-level buffers (maintains existing interface for non-soundcard input)
 	inbufSizeInBytes  int
 	inbuf             []byte
@@ -701,12 +771,6 @@ type adev_s struct {
 	outbuf            []byte
 	outbufLen         int
 
-	// Frames per buffer for PortAudio
-	framesPerBuffer int
-
-	// Pre-allocated scratch buffer for zero-allocation int16→byte conversion in the input callback.
-	inputScratchBuf []byte
-
 	// Input type
 	g_audio_in_type audio_in_type_e
 
@@ -719,37 +783,27 @@ type adev_s struct {
 
 var adev [MAX_ADEVS]*adev_s
 
-// portaudioMu guards portaudioRefCount and ensures Initialize/Terminate are
-// correctly paired even if audio_open/audio_close are called concurrently.
-var portaudioMu sync.Mutex
-var portaudioRefCount int
-
-// anyDeviceRequiresPortAudio reports whether any configured audio device needs
-// PortAudio (i.e. is a soundcard rather than stdin or UDP).  Used to skip
-// portaudio.Initialize() when all devices are stdin/UDP, so that samoyed can
-// run on systems with no working PortAudio host backend (issue #501).
-func anyDeviceRequiresPortAudio(pa *audio_s) bool {
+// audio_none reports whether there are no audio devices at all, after
+// "ADEVICE none".  There are no radio channels then, only network ones.
+func audio_none(pa *audio_s) bool {
 	for a := range MAX_ADEVS {
-		if pa.adev[a].defined == 0 {
-			continue
-		}
-
-		var inName = pa.adev[a].adevice_in
-		var inIsStdinOrDash = strings.EqualFold(inName, "stdin") || inName == "-"
-		var inIsUDP = strings.HasPrefix(strings.ToLower(inName), "udp:")
-
-		if !inIsStdinOrDash && !inIsUDP {
-			return true
+		if pa.adev[a].defined != 0 {
+			return false
 		}
+	}
 
-		var outIsUDP = strings.HasPrefix(strings.ToLower(pa.adev[a].adevice_out), "udp:")
+	return true
+}
 
-		if !outIsUDP {
-			return true
-		}
+// chan_name_suffix returns the channel name, if one was configured, ready to
+// follow the channel number in monitor output, e.g. "[0 VHF 144.39] ".
+// Two-radio setups are much easier to follow that way than by number alone.
+func chan_name_suffix(pa *audio_s, channel int) string {
+	if pa == nil || channel < 0 || channel >= MAX_TOTAL_CHANS || pa.chan_name[channel] == "" {
+		return ""
 	}
 
-	return false
+	return " " + pa.chan_name[channel]
 }
 
 // Originally 40.  Version 1.2, try 10 for lower latency.
@@ -767,13 +821,6 @@ func calcbufsize(rate int, chans int, bits int) int {
 	return (size2)
 }
 
-/*
- * Find a PortAudio device by name.
- * Supports:
- *   - "default" or "" -> system default device
- *   - "hw:X,Y" style ALSA names -> search by substring
- *   - Direct device name matching
- */
 // parseALSADeviceName parses ALSA device names like "hw:Card,Dev,Sub" or
 // "plughw:Card,Dev,Sub" and returns the card name and device number.
 // Returns ("", -1) if the name doesn't match the ALSA pattern.
@@ -842,126 +889,6 @@ func resolveALSACardNumber(cardID string) (int, bool) {
 	return num, ok
 }
 
-// matchPortAudioDeviceByName searches devices for one matching name using
-// several strategies: exact match, substring match, and ALSA-style name
-// matching (including resolution of udev-assigned card IDs via
-// /proc/asound/cards). Returns nil if no match is found.
-func matchPortAudioDeviceByName(name string, forInput bool, devices []*portaudio.DeviceInfo) *portaudio.DeviceInfo {
-	var devMatchesDirection = func(dev *portaudio.DeviceInfo) bool {
-		if forInput {
-			return dev.MaxInputChannels > 0
-		}
-
-		return dev.MaxOutputChannels > 0
-	}
-
-	// Try exact match first.
-	for _, dev := range devices {
-		if dev.Name == name && devMatchesDirection(dev) {
-			return dev
-		}
-	}
-
-	// Try substring match (check both directions).
-	for _, dev := range devices {
-		if devMatchesDirection(dev) {
-			var nameLower = strings.ToLower(name)
-			var devLower = strings.ToLower(dev.Name)
-			if strings.Contains(devLower, nameLower) || strings.Contains(nameLower, devLower) {
-				return dev
-			}
-		}
-	}
-
-	// Try ALSA-style name matching.
-	// Config names like "plughw:Loopback,1,1" need to match PortAudio names
-	// like "Loopback: PCM (hw:0,1)".
-	// Extract card name and device number from the config, then match against
-	// the card name prefix and (hw:X,Dev) pattern in PortAudio device names.
-	var cardName, devNum = parseALSADeviceName(name)
-	if cardName != "" {
-		// First try matching by card name substring in PortAudio device names.
-		// This covers the common case where the ALSA card name matches part of
-		// the PortAudio description (e.g. "Loopback" appearing in "Loopback: PCM (hw:0,1)").
-		for _, dev := range devices {
-			if !devMatchesDirection(dev) {
-				continue
-			}
-			var devLower = strings.ToLower(dev.Name)
-			if !strings.Contains(devLower, strings.ToLower(cardName)) {
-				continue
-			}
-			if devNum >= 0 {
-				var target = fmt.Sprintf(",%d)", devNum)
-				if strings.Contains(dev.Name, target) {
-					return dev
-				}
-			} else {
-				return dev
-			}
-		}
-
-		// The card name may be a udev-assigned ALSA card ID that differs from
-		// the hardware description PortAudio uses. Resolve it to a numeric card
-		// index via /proc/asound/cards (Linux) and match by (hw:N,M).
-		if cardNum, ok := resolveALSACardNumber(cardName); ok {
-			for _, dev := range devices {
-				if !devMatchesDirection(dev) {
-					continue
-				}
-				if devNum >= 0 {
-					var target = fmt.Sprintf("(hw:%d,%d)", cardNum, devNum)
-					if strings.Contains(dev.Name, target) {
-						return dev
-					}
-				} else {
-					var target = fmt.Sprintf("(hw:%d,", cardNum)
-					if strings.Contains(dev.Name, target) {
-						return dev
-					}
-				}
-			}
-		}
-	}
-
-	return nil
-}
-
-func findPortAudioDevice(name string, forInput bool) *portaudio.DeviceInfo {
-	// Handle default device
-	if name == "" || strings.ToLower(name) == "default" {
-		if forInput {
-			var dev, err = portaudio.DefaultInputDevice()
-			if err != nil {
-				return nil
-			}
-
-			return dev
-		} else {
-			var dev, err = portaudio.DefaultOutputDevice()
-			if err != nil {
-				return nil
-			}
-
-			return dev
-		}
-	}
-
-	// Search through all devices
-	var devices, err = portaudio.Devices()
-	if err != nil {
-		return nil
-	}
-
-	var dev = matchPortAudioDeviceByName(name, forInput, devices)
-	if dev == nil {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Could not match audio device '%s' to any PortAudio device.\n", name)
-	}
-
-	return dev
-}
-
 /*------------------------------------------------------------------
  *
  * Name:        audio_open
@@ -995,23 +922,15 @@ func audio_open(pa *audio_s) int {
 	var portaudioAcquired = false
 
 	if anyDeviceRequiresPortAudio(pa) {
-		portaudioMu.Lock()
-
-		if portaudioRefCount == 0 {
-			var err = portaudio.Initialize()
-			if err != nil {
-				portaudioMu.Unlock()
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("PortAudio initialization failed: %v\n", err)
+		var err = portaudio_acquire()
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("PortAudio initialization failed: %v\n", err)
 
-				return -1
-			}
+			return -1
 		}
 
-		portaudioRefCount++
 		portaudioAcquired = true
-
-		portaudioMu.Unlock()
 	}
 
 	// If audio_open fails after this point, roll back the refcount increment
@@ -1020,21 +939,12 @@ func audio_open(pa *audio_s) int {
 
 	defer func() {
 		if portaudioAcquired && !openSucceeded {
-			portaudioMu.Lock()
-
-			portaudioRefCount--
-			if portaudioRefCount == 0 {
-				portaudio.Terminate()
-			}
-
-			portaudioMu.Unlock()
+			portaudio_release()
 		}
 	}()
 
 	for a := range MAX_ADEVS {
 		adev[a] = new(adev_s)
-		adev[a].inputStream = nil
-		adev[a].outputStream = nil
 	}
 
 	/*
@@ -1088,6 +998,25 @@ func audio_open(pa *audio_s) int {
 			adev[a].outbuf = nil
 			adev[a].outbufLen = 0
 
+			/*
+			 * A WAV file says what's in it, so that replaces ARATE and ACHANNELS.
+			 */
+			var wav *wavAudioDevice
+
+			if is_wav_name(pa.adev[a].adevice_in) {
+				var err error
+
+				wav, err = wav_open(pa.adev[a].adevice_in)
+				if err != nil {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Could not open audio file: %v\n", err)
+
+					return -1
+				}
+
+				wav_use_format(pa, a, wav)
+			}
+
 			// Store audio format
 			adev[a].sampleRate = pa.adev[a].samples_per_sec
 			adev[a].numChannels = pa.adev[a].num_channels
@@ -1106,6 +1035,10 @@ func audio_open(pa *audio_s) int {
 				pa.adev[a].adevice_in = "stdin"
 			}
 
+			if wav != nil {
+				adev[a].g_audio_in_type = AUDIO_IN_TYPE_FILE
+			}
+
 			if strings.HasPrefix(strings.ToLower(pa.adev[a].adevice_in), "udp:") {
 				adev[a].g_audio_in_type = AUDIO_IN_TYPE_SDR_UDP
 				/* Supply default port if none specified. */
@@ -1141,8 +1074,13 @@ func audio_open(pa *audio_s) int {
 
 			// Calculate buffer size
 			var bufSizeInBytes = calcbufsize(pa.adev[a].samples_per_sec, pa.adev[a].num_channels, pa.adev[a].bits_per_sample)
-			var framesPerBuffer = bufSizeInBytes / adev[a].bytesPerFrame
-			adev[a].framesPerBuffer = framesPerBuffer
+
+			var format = audio_format_s{
+				rate:              pa.adev[a].samples_per_sec,
+				channels:          pa.adev[a].num_channels,
+				bits:              pa.adev[a].bits_per_sample,
+				frames_per_buffer: bufSizeInBytes / adev[a].bytesPerFrame,
+			}
 
 			/*
 			 * Now attempt actual opens.
@@ -1154,73 +1092,13 @@ func audio_open(pa *audio_s) int {
 
 			switch adev[a].g_audio_in_type {
 			/*
-			 * Soundcard - PortAudio with callback mode.
-			 * Callback mode is more reliable than blocking read because the
-			 * callback runs on a dedicated audio thread with better timing
-			 * guarantees than Go goroutines.
+			 * Soundcard - PortAudio, ALSA, or OSS, depending on the name.
+			 * See audio_device.go.
 			 */
 			case AUDIO_IN_TYPE_SOUNDCARD:
-				var inputDev = findPortAudioDevice(audio_in_name, true)
-				if inputDev == nil {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Could not find audio input device: %s\n", audio_in_name)
-
-					return -1
-				}
-
-				// Create ring buffer for audio data.
-				// Size it to hold ~1 second of audio for plenty of headroom.
-				// This accommodates Go scheduler delays and processing latency.
-				var ringBufSize = pa.adev[a].samples_per_sec * pa.adev[a].num_channels * pa.adev[a].bits_per_sample / 8
-				adev[a].inputRingBuf = newAudioRingBuffer(ringBufSize)
-
-				// Create input stream parameters
-				var inputParams = portaudio.StreamParameters{
-					Input: portaudio.StreamDeviceParameters{
-						Device:   inputDev,
-						Channels: pa.adev[a].num_channels,
-						Latency:  inputDev.DefaultHighInputLatency,
-					},
-					Output:          portaudio.StreamDeviceParameters{Device: nil, Channels: 0, Latency: 0},
-					SampleRate:      float64(pa.adev[a].samples_per_sec),
-					FramesPerBuffer: framesPerBuffer,
-					Flags:           portaudio.NoFlag,
-				}
-
-				// Open input stream with callback.
-				// The callback receives audio data and writes it to the ring buffer.
-				// IMPORTANT: Capture the ring buffer pointer now, not in the closure,
-				// to avoid the classic Go closure-over-loop-variable bug.
-				var inRingBuf = adev[a].inputRingBuf
 				var err error
 
-				if pa.adev[a].bits_per_sample == 16 {
-					// Pre-allocate a scratch buffer sized for one full callback invocation
-					// so the callback performs zero heap allocations at runtime.
-					adev[a].inputScratchBuf = make([]byte, framesPerBuffer*pa.adev[a].num_channels*2)
-					var inScratchBuf = adev[a].inputScratchBuf
-					adev[a].inputStream, err = portaudio.OpenStream(
-						inputParams,
-						func(in []int16) {
-							// Reuse the pre-allocated scratch buffer; slice to actual length.
-							var scratch = inScratchBuf[:len(in)*2]
-							for i, sample := range in {
-								binary.LittleEndian.PutUint16(scratch[i*2:], uint16(sample))
-							}
-
-							inRingBuf.write(scratch)
-						},
-					)
-				} else {
-					adev[a].inputStream, err = portaudio.OpenStream(
-						inputParams,
-						func(in []uint8) {
-							// Write uint8 samples directly to ring buffer
-							inRingBuf.write(in)
-						},
-					)
-				}
-
+				adev[a].in, err = audio_device_open(audio_in_name, true, format)
 				if err != nil {
 					text_color_set(DW_COLOR_ERROR)
 					dw_printf("Could not open audio device %s for input: %v\n", audio_in_name, err)
@@ -1228,14 +1106,6 @@ func audio_open(pa *audio_s) int {
 					return -1
 				}
 
-				err = adev[a].inputStream.Start()
-				if err != nil {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Could not start audio input stream: %v\n", err)
-
-					return -1
-				}
-
 				adev[a].inbufSizeInBytes = bufSizeInBytes
 
 			/*
@@ -1267,8 +1137,16 @@ func audio_open(pa *audio_s) int {
 				 */
 			case AUDIO_IN_TYPE_STDIN:
 				/* Do we need to adjust any properties of stdin? */
+				adev[a].in = newFileAudioDevice(os.Stdin, nil)
 				adev[a].inbufSizeInBytes = 1024
 
+				/*
+				 * WAV file, already opened above.
+				 */
+			case AUDIO_IN_TYPE_FILE:
+				adev[a].in = wav
+				adev[a].inbufSizeInBytes = bufSizeInBytes
+
 			default:
 				text_color_set(DW_COLOR_ERROR)
 				dw_printf("Internal error, invalid audio_in_type\n")
@@ -1297,45 +1175,13 @@ func audio_open(pa *audio_s) int {
 				adev[a].outbufSizeInBytes = UDP_AUDIO_OUT_BUF_MAXLEN
 			} else {
 				/*
-				 * Soundcard - blocking write mode.
-				 * audio_flush_real fills the typed output buffer and calls Write() to
-				 * send it to PortAudio. The stream is started lazily on first write
-				 * and stopped in audio_wait to avoid underflows during idle periods.
+				 * Soundcard - blocking writes.
+				 * audio_flush_real sends each buffer full and audio_wait
+				 * waits for it to be played before PTT is turned off.
 				 */
-
-				var outputDev = findPortAudioDevice(audio_out_name, false)
-				if outputDev == nil {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Could not find audio output device: %s\n", audio_out_name)
-
-					return -1
-				}
-
-				// Create output stream parameters
-				var outputParams = portaudio.StreamParameters{
-					Input: portaudio.StreamDeviceParameters{Device: nil, Channels: 0, Latency: 0},
-					Output: portaudio.StreamDeviceParameters{
-						Device:   outputDev,
-						Channels: pa.adev[a].num_channels,
-						Latency:  outputDev.DefaultHighOutputLatency,
-					},
-					SampleRate:      float64(pa.adev[a].samples_per_sec),
-					FramesPerBuffer: framesPerBuffer,
-					Flags:           portaudio.NoFlag,
-				}
-
-				// Open output stream in blocking write mode.
-				// Pass a pointer to a typed buffer; Write() will send buffer contents to PortAudio.
 				var err error
 
-				if pa.adev[a].bits_per_sample == 16 {
-					adev[a].outputBuf16 = make([]int16, framesPerBuffer*pa.adev[a].num_channels)
-					adev[a].outputStream, err = portaudio.OpenStream(outputParams, &adev[a].outputBuf16)
-				} else {
-					adev[a].outputBuf8 = make([]uint8, framesPerBuffer*pa.adev[a].num_channels)
-					adev[a].outputStream, err = portaudio.OpenStream(outputParams, &adev[a].outputBuf8)
-				}
-
+				adev[a].out, err = audio_device_open(audio_out_name, false, format)
 				if err != nil {
 					text_color_set(DW_COLOR_ERROR)
 					dw_printf("Could not open audio device %s for output: %v\n", audio_out_name, err)
@@ -1343,10 +1189,6 @@ func audio_open(pa *audio_s) int {
 					return -1
 				}
 
-				// Output stream is opened but NOT started here.
-				// It will be started lazily on first write in audio_flush_real
-				// and stopped in audio_wait, to avoid underflows during idle periods.
-
 				adev[a].outbufSizeInBytes = bufSizeInBytes
 			}
 
@@ -1402,15 +1244,14 @@ func audio_get_real(a int) int {
 
 	switch adev[a].g_audio_in_type {
 	/*
-	 * Soundcard - PortAudio callback mode.
-	 * Audio data is written to the ring buffer by the callback.
-	 * We just read one byte from it here.
+	 * Soundcard, stdin, or WAV file.  Fill inbuf with as much as is available
+	 * in one read rather than once per byte.
 	 */
-	case AUDIO_IN_TYPE_SOUNDCARD:
-		Assert(adev[a].inputRingBuf != nil)
+	case AUDIO_IN_TYPE_SOUNDCARD, AUDIO_IN_TYPE_STDIN, AUDIO_IN_TYPE_FILE:
+		Assert(adev[a].in != nil)
 
-		// Check for overflow (data was dropped in the callback)
-		if adev[a].inputRingBuf.checkOverflow() {
+		// Check for overflow (data was dropped because we didn't keep up)
+		if o, ok := adev[a].in.(audioOverflower); ok && o.Overflowed() {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Audio input overflow on device %d - some samples lost\n", a)
 			dw_printf("If receiving is fine and strange things happen when transmitting, it is probably RF energy\n")
@@ -1422,13 +1263,33 @@ func audio_get_real(a int) int {
 				save_audio_config_p.statistics_interval)
 		}
 
-		// Drain the ring buffer into inbuf in a single bulk read when exhausted.
-		// This acquires the ring buffer mutex only once per inbuf-worth of data
-		// rather than once per byte.
 		for adev[a].inbufNext >= adev[a].inbufLen {
-			var n, ok = adev[a].inputRingBuf.readChunk(adev[a].inbuf)
-			if !ok {
-				// Ring buffer was closed - stream ended
+			var n, err = adev[a].in.Read(adev[a].inbuf)
+			if err != nil {
+				if errors.Is(err, io.EOF) {
+					if adev[a].g_audio_in_type == AUDIO_IN_TYPE_STDIN {
+						dlq_wait_until_idle(5 * time.Second)
+
+						text_color_set(DW_COLOR_INFO)
+						dw_printf("\nEnd of file on stdin.  Exiting.\n")
+						os.Exit(0)
+					}
+
+					if adev[a].g_audio_in_type == AUDIO_IN_TYPE_FILE {
+						dlq_wait_until_idle(5 * time.Second)
+
+						text_color_set(DW_COLOR_INFO)
+						dw_printf("\nEnd of file %s.  Exiting.\n", save_audio_config_p.adev[a].adevice_in)
+						os.Exit(0)
+					}
+
+					// Device was closed.
+					return -1
+				}
+
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Error reading from audio device %d: %v\n", a, err)
+
 				return -1
 			}
 
@@ -1443,11 +1304,6 @@ func audio_get_real(a int) int {
 			}
 		}
 
-		var b = adev[a].inbuf[adev[a].inbufNext]
-		adev[a].inbufNext++
-
-		return int(b)
-
 		/*
 		 * UDP.
 		 */
@@ -1480,34 +1336,6 @@ func audio_get_real(a int) int {
 				n/(save_audio_config_p.adev[a].num_channels*save_audio_config_p.adev[a].bits_per_sample/8),
 				save_audio_config_p.statistics_interval)
 		}
-
-		/*
-		 * stdin.
-		 */
-	case AUDIO_IN_TYPE_STDIN:
-		for adev[a].inbufNext >= adev[a].inbufLen {
-			var n, err = os.Stdin.Read(adev[a].inbuf)
-			if err != nil {
-				if errors.Is(err, io.EOF) {
-					text_color_set(DW_COLOR_INFO)
-					dw_printf("\nEnd of file on stdin.  Exiting.\n")
-					os.Exit(0)
-				}
-
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Error reading from stdin: %v\n", err)
-
-				return -1
-			}
-
-			audio_stats(a,
-				save_audio_config_p.adev[a].num_channels,
-				n/(save_audio_config_p.adev[a].num_channels*save_audio_config_p.adev[a].bits_per_sample/8),
-				save_audio_config_p.statistics_interval)
-
-			adev[a].inbufLen = n
-			adev[a].inbufNext = 0
-		}
 	}
 
 	var n int
@@ -1599,61 +1427,21 @@ func audio_flush_real(a int) int {
 		return 0
 	}
 
-	if adev[a].outputStream == nil {
+	if adev[a].out == nil {
 		adev[a].outbufLen = 0
 		return -1
 	}
 
-	if adev[a].outputBuf16 != nil {
-		var nSamples = adev[a].outbufLen / 2
-		for i := range nSamples {
-			var lo = adev[a].outbuf[i*2]
-			var hi = adev[a].outbuf[i*2+1]
-			adev[a].outputBuf16[i] = int16(uint16(lo) | uint16(hi)<<8)
-		}
-
-		for i := nSamples; i < len(adev[a].outputBuf16); i++ {
-			adev[a].outputBuf16[i] = 0
-		}
-	} else if adev[a].outputBuf8 != nil {
-		copy(adev[a].outputBuf8, adev[a].outbuf[:adev[a].outbufLen])
-
-		for i := adev[a].outbufLen; i < len(adev[a].outputBuf8); i++ {
-			adev[a].outputBuf8[i] = 128
-		}
-	}
-
-	// Start the output stream lazily on first write.
-	if !adev[a].outputStarted {
-		var err = adev[a].outputStream.Start()
-		if err != nil {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Could not start audio output stream: %v\n", err)
-
-			return -1
-		}
-
-		adev[a].outputStarted = true
-	}
+	var _, err = adev[a].out.Write(adev[a].outbuf[:adev[a].outbufLen])
+	adev[a].outbufLen = 0
 
-	var err = adev[a].outputStream.Write()
 	if err != nil {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Audio output write error: %v\n", err)
 
-		var stopErr = adev[a].outputStream.Stop()
-		if stopErr != nil {
-			dw_printf("Audio output stream stop error: %v\n", stopErr)
-		}
-
-		adev[a].outputStarted = false
-		adev[a].outbufLen = 0
-
 		return -1
 	}
 
-	adev[a].outbufLen = 0
-
 	return 0
 } /* end audio_flush */
 
@@ -1681,16 +1469,14 @@ func audio_wait(a int) {
 		return
 	}
 
-	// Stop the output stream — Pa_StopStream drains remaining buffers
-	// before returning. It will be restarted lazily on next write.
-	if adev[a].outputStream != nil && adev[a].outputStarted {
-		var err = adev[a].outputStream.Stop()
+	// Wait for what's been written to be played.  The device starts
+	// again by itself on the next write.
+	if adev[a].out != nil {
+		var err = adev[a].out.Drain()
 		if err != nil {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("audio_wait: failed to stop output stream for device %d: %v\n", a, err)
+			dw_printf("audio_wait: failed to drain output for device %d: %v\n", a, err)
 		}
-
-		adev[a].outputStarted = false
 	}
 } /* end audio_wait */
 
@@ -1710,35 +1496,19 @@ func audio_close() int { //nolint:unparam
 	var err = 0
 
 	for a := range MAX_ADEVS {
-		if adev[a] != nil && (adev[a].inputStream != nil || adev[a].outputStream != nil || adev[a].udp_sock != nil || adev[a].udp_out_sock != nil) {
+		if adev[a] != nil && (adev[a].in != nil || adev[a].out != nil || adev[a].udp_sock != nil || adev[a].udp_out_sock != nil) {
 			audio_wait(a)
 
-			if adev[a].inputStream != nil {
-				adev[a].inputStream.Stop()
-				adev[a].inputStream.Close()
-				adev[a].inputStream = nil
+			if adev[a].in != nil {
+				adev[a].in.Close()
+				adev[a].in = nil
 			}
 
-			// Close output stream (already stopped by audio_wait above)
-			if adev[a].outputStream != nil {
-				if adev[a].outputStarted {
-					adev[a].outputStream.Stop()
-					adev[a].outputStarted = false
-				}
-
-				adev[a].outputStream.Close()
-				adev[a].outputStream = nil
+			if adev[a].out != nil {
+				adev[a].out.Close()
+				adev[a].out = nil
 			}
 
-			// Then close ring buffers
-			if adev[a].inputRingBuf != nil {
-				adev[a].inputRingBuf.close()
-				adev[a].inputRingBuf = nil
-			}
-
-			adev[a].outputBuf16 = nil
-			adev[a].outputBuf8 = nil
-
 			if adev[a].udp_sock != nil {
 				adev[a].udp_sock.Close()
 				adev[a].udp_sock = nil
@@ -1761,16 +1531,7 @@ func audio_close() int { //nolint:unparam
 	}
 
 	// Terminate PortAudio when the last audio device is closed.
-	portaudioMu.Lock()
-
-	if portaudioRefCount > 0 {
-		portaudioRefCount--
-		if portaudioRefCount == 0 {
-			portaudio.Terminate()
-		}
-	}
-
-	portaudioMu.Unlock()
+	portaudio_release()
 
 	return (err)
 } /* end audio_close */
@@ -0,0 +1,362 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	ALSA sound cards, in pure Go, for audio_device.go.
+ *
+ * Description:	Talks to the kernel through /dev/snd/pcmC<card>D<device>c
+ *		(capture) or p (playback), the same way alsa-lib does
+ *		for a "hw:" device, but without cgo.  There's none of
+ *		the alsa-lib plugins, so no rate or format conversion
+ *		and no mixing with other programs.  The cheap USB audio
+ *		adapters used for radios take 44100 or 48000, 16 bits.
+ *
+ * References:	include/uapi/sound/asound.h in the Linux source.
+ *		tinyalsa, https://github.com/tinyalsa/tinyalsa, does
+ *		the same in C.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+/* struct snd_mask */
+
+type snd_mask struct {
+	bits [8]uint32
+}
+
+/* struct snd_interval.  flags are the openmin:1, openmax:1, integer:1, empty:1 bit fields. */
+
+type snd_interval struct {
+	min   uint32
+	max   uint32
+	flags uint32
+}
+
+const SND_INTERVAL_INTEGER = 1 << 2
+
+/* struct snd_pcm_hw_params */
+
+type snd_pcm_hw_params struct {
+	flags     uint32
+	masks     [3]snd_mask
+	mres      [5]snd_mask
+	intervals [12]snd_interval
+	ires      [9]snd_interval
+	rmask     uint32
+	cmask     uint32
+	info      uint32
+	msbits    uint32
+	rate_num  uint32
+	rate_den  uint32
+	fifo_size uint /* snd_pcm_uframes_t, which is unsigned long. */
+	reserved  [64]byte
+}
+
+/* struct snd_xferi */
+
+type snd_xferi struct {
+	result int /* snd_pcm_sframes_t, which is long. */
+	buf    unsafe.Pointer
+	frames uint
+}
+
+/* Parameters.  The first three are masks and the rest are intervals. */
+
+const (
+	SNDRV_PCM_HW_PARAM_ACCESS         = 0
+	SNDRV_PCM_HW_PARAM_FORMAT         = 1
+	SNDRV_PCM_HW_PARAM_SUBFORMAT      = 2
+	SNDRV_PCM_HW_PARAM_FIRST_INTERVAL = 8
+	SNDRV_PCM_HW_PARAM_SAMPLE_BITS    = 8
+	SNDRV_PCM_HW_PARAM_FRAME_BITS     = 9
+	SNDRV_PCM_HW_PARAM_CHANNELS       = 10
+	SNDRV_PCM_HW_PARAM_RATE           = 11
+	SNDRV_PCM_HW_PARAM_PERIOD_SIZE    = 13
+)
+
+const SNDRV_PCM_ACCESS_RW_INTERLEAVED = 3
+const SNDRV_PCM_FORMAT_U8 = 1
+const SNDRV_PCM_FORMAT_S16_LE = 2
+const SNDRV_PCM_SUBFORMAT_STD = 0
+
+/* _IOC from include/uapi/asm-generic/ioctl.h, with type 'A' for ALSA PCM. */
+
+const IOC_NONE = 0
+const IOC_WRITE = 1
+const IOC_READ = 2
+
+const SNDRV_PCM_IOCTL_HW_PARAMS = (IOC_READ|IOC_WRITE)<<30 | unsafe.Sizeof(snd_pcm_hw_params{})<<16 | 'A'<<8 | 0x11 //nolint:exhaustruct
+const SNDRV_PCM_IOCTL_PREPARE = IOC_NONE<<30 | 'A'<<8 | 0x40
+const SNDRV_PCM_IOCTL_DROP = IOC_NONE<<30 | 'A'<<8 | 0x43
+const SNDRV_PCM_IOCTL_DRAIN = IOC_NONE<<30 | 'A'<<8 | 0x44
+const SNDRV_PCM_IOCTL_WRITEI_FRAMES = IOC_WRITE<<30 | unsafe.Sizeof(snd_xferi{})<<16 | 'A'<<8 | 0x50 //nolint:exhaustruct
+const SNDRV_PCM_IOCTL_READI_FRAMES = IOC_READ<<30 | unsafe.Sizeof(snd_xferi{})<<16 | 'A'<<8 | 0x51   //nolint:exhaustruct
+
+/* Everything allowed, like snd_pcm_hw_params_any, before narrowing it down. */
+
+func (h *snd_pcm_hw_params) any() {
+	for i := range h.masks {
+		for j := range h.masks[i].bits {
+			h.masks[i].bits[j] = ^uint32(0)
+		}
+	}
+
+	for i := range h.intervals {
+		h.intervals[i] = snd_interval{min: 0, max: ^uint32(0), flags: 0}
+	}
+
+	h.rmask = ^uint32(0)
+	h.cmask = 0
+	h.info = ^uint32(0)
+}
+
+func (h *snd_pcm_hw_params) set_mask(param int, value int) {
+	var m = &h.masks[param]
+
+	clear(m.bits[:])
+	m.bits[value>>5] |= 1 << (value & 31)
+}
+
+func (h *snd_pcm_hw_params) set_int(param int, value int) {
+	h.intervals[param-SNDRV_PCM_HW_PARAM_FIRST_INTERVAL] = snd_interval{min: uint32(value), max: uint32(value), flags: SND_INTERVAL_INTEGER} //nolint:gosec
+}
+
+func (h *snd_pcm_hw_params) set_min(param int, value int) {
+	h.intervals[param-SNDRV_PCM_HW_PARAM_FIRST_INTERVAL].min = uint32(value) //nolint:gosec
+}
+
+func alsa_ioctl(fd int, req uintptr, arg unsafe.Pointer) error {
+	for {
+		var _, _, errno = unix.Syscall(unix.SYS_IOCTL, uintptr(fd), req, uintptr(arg))
+		if errno == syscall.EINTR {
+			continue
+		}
+
+		if errno != 0 {
+			return errno
+		}
+
+		return nil
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        alsa_device_path
+ *
+ * Purpose:     Kernel device for an ALSA name.
+ *
+ * Inputs:	name	- hw:1,0  plughw:FTDX10,0  default  or empty.
+ *			  Card names are looked up in /proc/asound/cards.
+ *
+ *--------------------------------------------------------------------*/
+
+func alsa_device_path(name string, forInput bool) (string, error) {
+	var card, dev = 0, 0
+
+	if name != "" && name != DEFAULT_ADEVICE {
+		var cardName, devNum = parseALSADeviceName(name)
+		if cardName == "" {
+			return "", fmt.Errorf("%q is not an ALSA device name like hw:1,0", name)
+		}
+
+		var n, err = strconv.Atoi(cardName)
+		if err != nil {
+			var ok bool
+
+			n, ok = resolveALSACardNumber(cardName)
+			if !ok {
+				return "", fmt.Errorf("no ALSA card named %s in %s", cardName, alsaCardsPath)
+			}
+		}
+
+		card = n
+
+		if devNum >= 0 {
+			dev = devNum
+		}
+	}
+
+	var direction = 'p'
+	if forInput {
+		direction = 'c'
+	}
+
+	return fmt.Sprintf("/dev/snd/pcmC%dD%d%c", card, dev, direction), nil
+}
+
+type alsaDevice struct {
+	fd          int
+	bytes       int /* Per frame. */
+	forInput    bool
+	closed      atomic.Bool
+	overflowed  atomic.Bool
+	needPrepare bool /* After Drain. */
+}
+
+func alsa_open(name string, forInput bool, format audio_format_s) (AudioDevice, error) {
+	var path, err = alsa_device_path(name, forInput)
+	if err != nil {
+		return nil, err
+	}
+
+	var fd, openErr = unix.Open(path, unix.O_RDWR|unix.O_CLOEXEC, 0)
+	if openErr != nil {
+		return nil, fmt.Errorf("%s: %w", path, openErr)
+	}
+
+	var h snd_pcm_hw_params
+
+	h.any()
+	h.set_mask(SNDRV_PCM_HW_PARAM_ACCESS, SNDRV_PCM_ACCESS_RW_INTERLEAVED)
+
+	if format.bits == 8 {
+		h.set_mask(SNDRV_PCM_HW_PARAM_FORMAT, SNDRV_PCM_FORMAT_U8)
+	} else {
+		h.set_mask(SNDRV_PCM_HW_PARAM_FORMAT, SNDRV_PCM_FORMAT_S16_LE)
+	}
+
+	h.set_mask(SNDRV_PCM_HW_PARAM_SUBFORMAT, SNDRV_PCM_SUBFORMAT_STD)
+	h.set_int(SNDRV_PCM_HW_PARAM_SAMPLE_BITS, format.bits)
+	h.set_int(SNDRV_PCM_HW_PARAM_FRAME_BITS, format.bits*format.channels)
+	h.set_int(SNDRV_PCM_HW_PARAM_CHANNELS, format.channels)
+	h.set_int(SNDRV_PCM_HW_PARAM_RATE, format.rate)
+	h.set_min(SNDRV_PCM_HW_PARAM_PERIOD_SIZE, format.frames_per_buffer)
+
+	err = alsa_ioctl(fd, SNDRV_PCM_IOCTL_HW_PARAMS, unsafe.Pointer(&h)) //nolint:gosec
+	if err != nil {
+		unix.Close(fd)
+
+		return nil, fmt.Errorf("%s can't do %d samples/sec, %d bits, %d channels: %w",
+			path, format.rate, format.bits, format.channels, err)
+	}
+
+	err = alsa_ioctl(fd, SNDRV_PCM_IOCTL_PREPARE, nil)
+	if err != nil {
+		unix.Close(fd)
+
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var d = &alsaDevice{fd: fd, bytes: format.bytes_per_frame(), forInput: forInput} //nolint:exhaustruct
+
+	return d, nil
+}
+
+/*
+ * Transfer whole frames.  The capture starts by itself with the first
+ * read and the playback with the first write.  After an overrun or
+ * underrun, EPIPE, prepare and carry on.
+ */
+
+func (d *alsaDevice) transfer(req uintptr, p []byte) (int, error) {
+	var frames = len(p) / d.bytes
+	if frames == 0 {
+		return 0, nil
+	}
+
+	for {
+		if d.closed.Load() {
+			return 0, io.EOF
+		}
+
+		var x = snd_xferi{result: 0, buf: unsafe.Pointer(&p[0]), frames: uint(frames)} //nolint:gosec
+
+		var err = alsa_ioctl(d.fd, req, unsafe.Pointer(&x)) //nolint:gosec
+		if errors.Is(err, syscall.EPIPE) {
+			if d.forInput {
+				d.overflowed.Store(true)
+			}
+
+			err = alsa_ioctl(d.fd, SNDRV_PCM_IOCTL_PREPARE, nil)
+			if err == nil {
+				continue
+			}
+		}
+
+		if err != nil {
+			if d.closed.Load() {
+				return 0, io.EOF
+			}
+
+			return 0, err
+		}
+
+		return x.result * d.bytes, nil
+	}
+}
+
+func (d *alsaDevice) Read(p []byte) (int, error) {
+	if !d.forInput {
+		return 0, errors.New("audio device is not open for input")
+	}
+
+	return d.transfer(SNDRV_PCM_IOCTL_READI_FRAMES, p)
+}
+
+func (d *alsaDevice) Overflowed() bool {
+	return d.overflowed.Swap(false)
+}
+
+func (d *alsaDevice) Write(p []byte) (int, error) {
+	if d.forInput {
+		return 0, errors.New("audio device is not open for output")
+	}
+
+	if d.needPrepare {
+		var err = alsa_ioctl(d.fd, SNDRV_PCM_IOCTL_PREPARE, nil)
+		if err != nil {
+			return 0, err
+		}
+
+		d.needPrepare = false
+	}
+
+	var total = 0
+
+	for total+d.bytes <= len(p) {
+		var n, err = d.transfer(SNDRV_PCM_IOCTL_WRITEI_FRAMES, p[total:])
+		if err != nil {
+			return total, err
+		}
+
+		total += n
+	}
+
+	return total, nil
+}
+
+/* Blocks until played.  That leaves the device stopped, so prepare it before the next write. */
+
+func (d *alsaDevice) Drain() error {
+	if d.forInput {
+		return nil
+	}
+
+	d.needPrepare = true
+
+	return alsa_ioctl(d.fd, SNDRV_PCM_IOCTL_DRAIN, nil)
+}
+
+/* Stopping first wakes up a Read waiting in another goroutine. */
+
+func (d *alsaDevice) Close() error {
+	if d.closed.Swap(true) {
+		return nil
+	}
+
+	alsa_ioctl(d.fd, SNDRV_PCM_IOCTL_DROP, nil)
+
+	return unix.Close(d.fd)
+}
@@ -0,0 +1,72 @@
+package direwolf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"unsafe"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/* These must match what the kernel expects, or nothing works. */
+
+func Test_alsa_ioctl_numbers(t *testing.T) {
+	if unsafe.Sizeof(uintptr(0)) != 8 {
+		t.Skip("values below are for 64 bit")
+	}
+
+	assert.Equal(t, uintptr(608), unsafe.Sizeof(snd_pcm_hw_params{})) //nolint:exhaustruct
+	assert.Equal(t, uintptr(0xc2604111), uintptr(SNDRV_PCM_IOCTL_HW_PARAMS))
+	assert.Equal(t, uintptr(0x4140), uintptr(SNDRV_PCM_IOCTL_PREPARE))
+	assert.Equal(t, uintptr(0x4144), uintptr(SNDRV_PCM_IOCTL_DRAIN))
+	assert.Equal(t, uintptr(0x40184150), uintptr(SNDRV_PCM_IOCTL_WRITEI_FRAMES))
+	assert.Equal(t, uintptr(0x80184151), uintptr(SNDRV_PCM_IOCTL_READI_FRAMES))
+}
+
+func Test_alsa_hw_params(t *testing.T) {
+	var h snd_pcm_hw_params
+
+	h.any()
+	h.set_mask(SNDRV_PCM_HW_PARAM_FORMAT, SNDRV_PCM_FORMAT_S16_LE)
+	h.set_int(SNDRV_PCM_HW_PARAM_RATE, 48000)
+
+	assert.Equal(t, [8]uint32{1 << SNDRV_PCM_FORMAT_S16_LE}, h.masks[SNDRV_PCM_HW_PARAM_FORMAT].bits)
+	assert.Equal(t, snd_interval{min: 48000, max: 48000, flags: SND_INTERVAL_INTEGER}, h.intervals[SNDRV_PCM_HW_PARAM_RATE-SNDRV_PCM_HW_PARAM_FIRST_INTERVAL])
+	assert.Equal(t, ^uint32(0), h.intervals[SNDRV_PCM_HW_PARAM_CHANNELS-SNDRV_PCM_HW_PARAM_FIRST_INTERVAL].max)
+}
+
+func Test_alsa_device_path(t *testing.T) {
+	var cards = filepath.Join(t.TempDir(), "cards")
+	require.NoError(t, os.WriteFile(cards, []byte(" 2 [FTDX10         ]: USB-Audio - USB AUDIO  CODEC\n"), 0o600))
+
+	var saved = alsaCardsPath
+	alsaCardsPath = cards
+
+	t.Cleanup(func() { alsaCardsPath = saved })
+
+	var tests = []struct {
+		name     string
+		forInput bool
+		want     string
+	}{
+		{"hw:1,0", true, "/dev/snd/pcmC1D0c"},
+		{"plughw:1,3", false, "/dev/snd/pcmC1D3p"},
+		{"hw:FTDX10,0", true, "/dev/snd/pcmC2D0c"},
+		{"default", false, "/dev/snd/pcmC0D0p"},
+		{"hw:1", true, "/dev/snd/pcmC1D0c"},
+	}
+
+	for _, tt := range tests {
+		var path, err = alsa_device_path(tt.name, tt.forInput)
+		require.NoError(t, err, tt.name)
+		assert.Equal(t, tt.want, path, tt.name)
+	}
+
+	var _, err = alsa_device_path("hw:NoSuchCard,0", true)
+	require.ErrorContains(t, err, "NoSuchCard")
+
+	_, err = alsa_device_path("USB Audio CODEC", true)
+	require.Error(t, err)
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package direwolf
+
+import "errors"
+
+func alsa_open(_ string, _ bool, _ audio_format_s) (AudioDevice, error) {
+	return nil, errors.New("ALSA is only supported on Linux")
+}
+
+func alsa_device_path(_ string, _ bool) (string, error) {
+	return "", errors.New("ALSA is only supported on Linux")
+}
@@ -0,0 +1,216 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Sound cards, and things which act like them, for audio.go.
+ *
+ * Description:	Each way of getting samples in and out is an AudioDevice.
+ *		The name from ADEVICE picks which one:
+ *
+ *		alsa:hw:1,0	ALSA, directly through the kernel, in pure Go.
+ *				plughw: is taken as hw: because there is no
+ *				alsa-lib to convert the rate or format, so the
+ *				card must accept ARATE and 16 or 8 bits as is.
+ *				Card names from /proc/asound/cards work too,
+ *				e.g. alsa:hw:FTDX10,0.
+ *
+ *		oss:/dev/dsp	OSS, for FreeBSD or Linux with OSS emulation.
+ *		/dev/dsp1	Also OSS.
+ *
+ *		stdin or -	Raw samples from standard input.
+ *
+ *		name.wav	A WAV file, for receiving only.  See audio_wav.go.
+ *
+ *		anything else	PortAudio, which needs cgo and libportaudio.
+ *				With "go build -tags noportaudio" there is no
+ *				PortAudio, and these are taken as ALSA names,
+ *				so "default" is the first card, hw:0,0.
+ *
+ *		UDP isn't here because it comes in packets rather than
+ *		as a stream.  It's still handled in audio.go.
+ *
+ *		Tests can put any AudioDevice in adev[a], e.g. a
+ *		fileAudioDevice reading synthetic samples from memory.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+)
+
+/*
+ * Samples are interleaved, 16 bit little endian signed or 8 bit unsigned,
+ * as everywhere else.
+ */
+
+type AudioDevice interface {
+	// Read blocks until at least one whole frame is available.
+	// io.EOF at end of file or after Close.
+	Read(p []byte) (int, error)
+
+	// Write blocks until everything has been accepted for playing.
+	// Playing starts by itself with the first write after Drain.
+	Write(p []byte) (int, error)
+
+	// Drain waits until everything written has been played.
+	// Called before PTT is turned off.
+	Drain() error
+
+	Close() error
+}
+
+/* Optional, for devices which can tell us samples were lost. */
+
+type audioOverflower interface {
+	Overflowed() bool // Since the last call.
+}
+
+type audio_format_s struct {
+	rate              int /* Samples per second. */
+	channels          int /* 1 or 2. */
+	bits              int /* 8 or 16. */
+	frames_per_buffer int /* How much to transfer at once, ONE_BUF_TIME ms worth. */
+}
+
+func (f *audio_format_s) bytes_per_frame() int {
+	return f.channels * f.bits / 8
+}
+
+type audio_backend_e int
+
+const (
+	AUDIO_BACKEND_PORTAUDIO audio_backend_e = iota
+	AUDIO_BACKEND_ALSA
+	AUDIO_BACKEND_OSS
+	AUDIO_BACKEND_STDIN
+	AUDIO_BACKEND_UDP
+	AUDIO_BACKEND_WAV
+)
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        audio_backend
+ *
+ * Purpose:     Which AudioDevice handles an ADEVICE name.
+ *
+ * Returns:	The backend, and the name to give it, without any prefix.
+ *
+ *--------------------------------------------------------------------*/
+
+func audio_backend(name string, forInput bool) (audio_backend_e, string) {
+	var lower = strings.ToLower(name)
+
+	switch {
+	case forInput && (lower == "stdin" || name == "-"):
+		return AUDIO_BACKEND_STDIN, name
+	case forInput && is_wav_name(name):
+		return AUDIO_BACKEND_WAV, name
+	case strings.HasPrefix(lower, "udp:"):
+		return AUDIO_BACKEND_UDP, name
+	case strings.HasPrefix(lower, "alsa:"):
+		return AUDIO_BACKEND_ALSA, name[len("alsa:"):]
+	case strings.HasPrefix(lower, "oss:"):
+		return AUDIO_BACKEND_OSS, name[len("oss:"):]
+	case strings.HasPrefix(name, "/dev/dsp"):
+		return AUDIO_BACKEND_OSS, name
+	case !portaudio_available:
+		return AUDIO_BACKEND_ALSA, name
+	default:
+		return AUDIO_BACKEND_PORTAUDIO, name
+	}
+}
+
+// anyDeviceRequiresPortAudio reports whether any configured audio device needs
+// PortAudio (i.e. is a soundcard rather than stdin, UDP, ALSA, or OSS).  Used to
+// skip portaudio.Initialize() when it isn't needed, so that samoyed can
+// run on systems with no working PortAudio host backend (issue #501).
+func anyDeviceRequiresPortAudio(pa *audio_s) bool {
+	for a := range MAX_ADEVS {
+		if pa.adev[a].defined == 0 {
+			continue
+		}
+
+		var in, _ = audio_backend(pa.adev[a].adevice_in, true)
+		var out, _ = audio_backend(pa.adev[a].adevice_out, false)
+
+		if in == AUDIO_BACKEND_PORTAUDIO || out == AUDIO_BACKEND_PORTAUDIO {
+			return true
+		}
+	}
+
+	return false
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        audio_device_open
+ *
+ * Purpose:     Open a sound card, stdin, or WAV file, for one direction.
+ *
+ * Inputs:	name	- From ADEVICE.
+ *		forInput - true for receive, false for transmit.
+ *		format	- Sample rate, channels, bits.
+ *
+ *--------------------------------------------------------------------*/
+
+func audio_device_open(name string, forInput bool, format audio_format_s) (AudioDevice, error) {
+	var backend, rest = audio_backend(name, forInput)
+
+	switch backend {
+	case AUDIO_BACKEND_STDIN:
+		return newFileAudioDevice(os.Stdin, nil), nil
+	case AUDIO_BACKEND_WAV:
+		return wav_open(rest)
+	case AUDIO_BACKEND_ALSA:
+		return alsa_open(rest, forInput, format)
+	case AUDIO_BACKEND_OSS:
+		return oss_open(rest, forInput, format)
+	case AUDIO_BACKEND_PORTAUDIO:
+		return portaudio_open(rest, forInput, format)
+	default:
+		return nil, errors.New("not a sound card")
+	}
+}
+
+/*
+ * Raw samples from a file or anything else which can be read,
+ * and to anything which can be written.  Either can be nil.
+ */
+
+type fileAudioDevice struct {
+	r io.Reader
+	w io.Writer
+}
+
+func newFileAudioDevice(r io.Reader, w io.Writer) *fileAudioDevice {
+	return &fileAudioDevice{r: r, w: w}
+}
+
+func (d *fileAudioDevice) Read(p []byte) (int, error) {
+	if d.r == nil {
+		return 0, errors.New("audio device is not open for input")
+	}
+
+	return d.r.Read(p)
+}
+
+func (d *fileAudioDevice) Write(p []byte) (int, error) {
+	if d.w == nil {
+		return 0, errors.New("audio device is not open for output")
+	}
+
+	return d.w.Write(p)
+}
+
+func (d *fileAudioDevice) Drain() error {
+	return nil
+}
+
+/* Doesn't close stdin, which might still be wanted. */
+
+func (d *fileAudioDevice) Close() error {
+	return nil
+}
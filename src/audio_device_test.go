@@ -0,0 +1,134 @@
+package direwolf
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_audio_backend(t *testing.T) {
+	var tests = []struct {
+		name     string
+		forInput bool
+		backend  audio_backend_e
+		rest     string
+	}{
+		{"stdin", true, AUDIO_BACKEND_STDIN, "stdin"},
+		{"-", true, AUDIO_BACKEND_STDIN, "-"},
+		{"udp:7355", true, AUDIO_BACKEND_UDP, "udp:7355"},
+		{"UDP:127.0.0.1:7355", false, AUDIO_BACKEND_UDP, "UDP:127.0.0.1:7355"},
+		{"alsa:hw:1,0", true, AUDIO_BACKEND_ALSA, "hw:1,0"},
+		{"ALSA:plughw:FTDX10,0", false, AUDIO_BACKEND_ALSA, "plughw:FTDX10,0"},
+		{"oss:/dev/dsp1", true, AUDIO_BACKEND_OSS, "/dev/dsp1"},
+		{"/dev/dsp", false, AUDIO_BACKEND_OSS, "/dev/dsp"},
+		{"capture.WAV", true, AUDIO_BACKEND_WAV, "capture.WAV"},
+	}
+
+	for _, tt := range tests {
+		var backend, rest = audio_backend(tt.name, tt.forInput)
+		assert.Equal(t, tt.backend, backend, tt.name)
+		assert.Equal(t, tt.rest, rest, tt.name)
+	}
+
+	// Everything else is for PortAudio, if we have it.
+
+	var backend, rest = audio_backend("plughw:1,0", true)
+	assert.Equal(t, "plughw:1,0", rest)
+
+	if portaudio_available {
+		assert.Equal(t, AUDIO_BACKEND_PORTAUDIO, backend)
+	} else {
+		assert.Equal(t, AUDIO_BACKEND_ALSA, backend)
+	}
+
+	// stdin and WAV files are only for receiving.
+	backend, _ = audio_backend("stdin", false)
+	assert.NotEqual(t, AUDIO_BACKEND_STDIN, backend)
+
+	backend, _ = audio_backend("capture.wav", false)
+	assert.NotEqual(t, AUDIO_BACKEND_WAV, backend)
+}
+
+func Test_anyDeviceRequiresPortAudio_native(t *testing.T) {
+	assert.False(t, anyDeviceRequiresPortAudio(makeAudioConfig("alsa:hw:1,0", "alsa:hw:1,0")))
+	assert.False(t, anyDeviceRequiresPortAudio(makeAudioConfig("oss:/dev/dsp", "udp:127.0.0.1:1234")))
+	assert.Equal(t, portaudio_available, anyDeviceRequiresPortAudio(makeAudioConfig("stdin", "plughw:1,0")))
+}
+
+/* Synthetic samples from memory, the way a test can stand in for a sound card. */
+
+func Test_audio_get_real_injected(t *testing.T) {
+	var dev = setupAdev0(t)
+
+	var saved = save_audio_config_p
+	t.Cleanup(func() { save_audio_config_p = saved })
+
+	save_audio_config_p = new(audio_s)
+	save_audio_config_p.adev[0].num_channels = 1
+	save_audio_config_p.adev[0].bits_per_sample = 16
+
+	var samples = []byte{0x01, 0x02, 0x03, 0x04, 0x05, 0x06}
+
+	dev.g_audio_in_type = AUDIO_IN_TYPE_SOUNDCARD
+	dev.in = newFileAudioDevice(bytes.NewReader(samples), nil)
+	dev.inbufSizeInBytes = 1024
+	dev.inbuf = make([]byte, dev.inbufSizeInBytes)
+
+	for _, b := range samples {
+		assert.Equal(t, int(b), audio_get_real(0))
+	}
+
+	// End of the samples is the same as the device being closed.
+	assert.Equal(t, -1, audio_get_real(0))
+}
+
+type drainCountingDevice struct {
+	fileAudioDevice
+
+	drained int
+}
+
+func (d *drainCountingDevice) Drain() error {
+	d.drained++
+
+	return nil
+}
+
+func Test_audio_flush_real_device(t *testing.T) {
+	var dev = setupAdev0(t)
+
+	var played bytes.Buffer
+
+	var out = &drainCountingDevice{fileAudioDevice: fileAudioDevice{r: nil, w: &played}} //nolint:exhaustruct
+
+	dev.out = out
+	dev.outbufSizeInBytes = 8
+	dev.outbuf = make([]byte, dev.outbufSizeInBytes)
+
+	for _, b := range []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10} {
+		require.GreaterOrEqual(t, audio_put_real(0, b), 0)
+	}
+
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8}, played.Bytes(), "one buffer full")
+
+	audio_wait(0)
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}, played.Bytes())
+	assert.Equal(t, 1, out.drained)
+
+	// Write errors are reported, and the buffer is emptied.
+
+	dev.out = newFileAudioDevice(nil, errorWriter{})
+	dev.outbuf[0] = 1
+	dev.outbufLen = 1
+	assert.Equal(t, -1, audio_flush_real(0))
+	assert.Zero(t, dev.outbufLen)
+}
+
+type errorWriter struct{}
+
+func (errorWriter) Write(_ []byte) (int, error) {
+	return 0, errors.New("device unplugged")
+}
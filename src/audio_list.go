@@ -0,0 +1,113 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	List the audio devices for "direwolf --list-audio".
+ *
+ * Description:	Rather than guessing something like plughw:1,0 for
+ *		ADEVICE, show every capture and playback device that
+ *		PortAudio can see, from each of its backends (ALSA,
+ *		PulseAudio, JACK, Core Audio, ...), with the number of
+ *		channels and the usual sample rates it accepts.
+ *
+ *		Each one ends with an ADEVICE line which can be copied
+ *		into the configuration file as it is.  That uses the
+ *		full device name, which always matches exactly.
+ *
+ *		Without PortAudio, see audio_device.go, the ALSA
+ *		devices known to the kernel are listed instead.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+func audio_list_capability(channels int, rates []int) string {
+	var s = fmt.Sprintf("%d channel", channels)
+	if channels != 1 {
+		s += "s"
+	}
+
+	if len(rates) == 0 {
+		return s + ", none of the usual sample rates"
+	}
+
+	var r = make([]string, len(rates))
+	for i, rate := range rates {
+		r[i] = fmt.Sprint(rate)
+	}
+
+	return s + ", " + strings.Join(r, " ") + " samples/sec"
+}
+
+/*
+ * Device name the way it needs to be written in the configuration
+ * file.  See config_lexer.go for the quoting rules.
+ */
+
+func adevice_quote(name string) string {
+	if name != "" && !strings.ContainsAny(name, " \t\"#") {
+		return name
+	}
+
+	var r = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
+	return `"` + r.Replace(name) + `"`
+}
+
+/* Where the kernel lists ALSA devices.  A variable for tests. */
+
+var alsaPCMPath = "/proc/asound/pcm" //nolint:gochecknoglobals
+
+/*
+ * List ALSA devices from /proc/asound/pcm, with lines like
+ *
+ *	00-00: ALC887-VD Analog : ALC887-VD Analog : playback 1 : capture 1
+ */
+
+func audio_list_alsa(w io.Writer, content string) {
+	var found = false
+
+	for line := range strings.SplitSeq(content, "\n") {
+		var fields = strings.Split(line, ":")
+		if len(fields) < 3 {
+			continue
+		}
+
+		var card, dev int
+
+		var _, err = fmt.Sscanf(strings.TrimSpace(fields[0]), "%d-%d", &card, &dev)
+		if err != nil {
+			continue
+		}
+
+		if !found {
+			fmt.Fprintf(w, "ALSA devices:\n")
+
+			found = true
+		}
+
+		var name = fmt.Sprintf("alsa:hw:%d,%d", card, dev)
+
+		fmt.Fprintf(w, "\n  %-14s %s\n", name, strings.TrimSpace(fields[1]))
+
+		var directions []string
+
+		for _, f := range fields[3:] {
+			var direction, _, _ = strings.Cut(strings.TrimSpace(f), " ")
+			if direction == "playback" || direction == "capture" {
+				directions = append(directions, direction)
+			}
+		}
+
+		fmt.Fprintf(w, "       %s\n", strings.Join(directions, " and "))
+		fmt.Fprintf(w, "       ADEVICE %s\n", name)
+	}
+
+	if !found {
+		fmt.Fprintf(w, "No ALSA devices found.\n")
+	}
+}
@@ -0,0 +1,122 @@
+//go:build !noportaudio
+
+package direwolf
+
+/* "direwolf --list-audio" with PortAudio.  See audio_list.go. */
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+/* Sample rates to try.  These are the ones normally used for ARATE. */
+
+var audioListRates = []int{8000, 11025, 16000, 22050, 44100, 48000, 96000}
+
+func audio_list(w io.Writer) error {
+	var err = portaudio.Initialize()
+	if err != nil {
+		return fmt.Errorf("PortAudio initialization failed: %w", err)
+	}
+
+	defer portaudio.Terminate()
+
+	var devices, devicesErr = portaudio.Devices()
+	if devicesErr != nil {
+		return fmt.Errorf("could not list audio devices: %w", devicesErr)
+	}
+
+	var defaultIn, _ = portaudio.DefaultInputDevice()
+	var defaultOut, _ = portaudio.DefaultOutputDevice()
+
+	if len(devices) == 0 {
+		fmt.Fprintf(w, "No audio devices found.\n")
+
+		return nil
+	}
+
+	fmt.Fprintf(w, "Audio devices:\n")
+
+	for _, dev := range devices {
+		var inRates, outRates []int
+
+		if dev.MaxInputChannels > 0 {
+			inRates = audio_list_rates(dev, true)
+		}
+
+		if dev.MaxOutputChannels > 0 {
+			outRates = audio_list_rates(dev, false)
+		}
+
+		audio_list_device(w, dev, inRates, outRates, dev == defaultIn, dev == defaultOut)
+	}
+
+	fmt.Fprintf(w, "\n")
+	fmt.Fprintf(w, "Use \"ADEVICE default\" for the default devices shown above, or\n")
+	fmt.Fprintf(w, "\"ADEVICE input-device output-device\" if they are different.\n")
+
+	return nil
+}
+
+/*
+ * Which of the usual rates a device accepts for 16 bit mono.
+ */
+
+func audio_list_rates(dev *portaudio.DeviceInfo, forInput bool) []int {
+	var rates []int
+
+	for _, rate := range audioListRates {
+		var p = portaudio.StreamParameters{
+			Input:           portaudio.StreamDeviceParameters{Device: nil, Channels: 0, Latency: 0},
+			Output:          portaudio.StreamDeviceParameters{Device: nil, Channels: 0, Latency: 0},
+			SampleRate:      float64(rate),
+			FramesPerBuffer: portaudio.FramesPerBufferUnspecified,
+			Flags:           portaudio.NoFlag,
+		}
+
+		if forInput {
+			p.Input = portaudio.StreamDeviceParameters{Device: dev, Channels: 1, Latency: dev.DefaultHighInputLatency}
+		} else {
+			p.Output = portaudio.StreamDeviceParameters{Device: dev, Channels: 1, Latency: dev.DefaultHighOutputLatency}
+		}
+
+		var buf []int16
+
+		if portaudio.IsFormatSupported(p, &buf) == nil {
+			rates = append(rates, rate)
+		}
+	}
+
+	return rates
+}
+
+func audio_list_device(w io.Writer, dev *portaudio.DeviceInfo, inRates []int, outRates []int, isDefaultIn bool, isDefaultOut bool) {
+	var hostAPI = "?"
+	if dev.HostApi != nil {
+		hostAPI = dev.HostApi.Name
+	}
+
+	fmt.Fprintf(w, "\n%3d  %s  [%s]", dev.Index, dev.Name, hostAPI)
+
+	if isDefaultIn {
+		fmt.Fprintf(w, "  (default input)")
+	}
+
+	if isDefaultOut {
+		fmt.Fprintf(w, "  (default output)")
+	}
+
+	fmt.Fprintf(w, "\n")
+
+	if dev.MaxInputChannels > 0 {
+		fmt.Fprintf(w, "       capture:   %s\n", audio_list_capability(dev.MaxInputChannels, inRates))
+	}
+
+	if dev.MaxOutputChannels > 0 {
+		fmt.Fprintf(w, "       playback:  %s\n", audio_list_capability(dev.MaxOutputChannels, outRates))
+	}
+
+	fmt.Fprintf(w, "       ADEVICE %s\n", adevice_quote(dev.Name))
+}
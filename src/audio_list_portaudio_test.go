@@ -0,0 +1,30 @@
+//go:build !noportaudio
+
+package direwolf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/gordonklaus/portaudio"
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_audio_list_device(t *testing.T) {
+	var dev = &portaudio.DeviceInfo{ //nolint:exhaustruct
+		Index:             3,
+		Name:              "USB Audio Device: - (hw:1,0)",
+		MaxInputChannels:  1,
+		MaxOutputChannels: 2,
+		HostApi:           &portaudio.HostApiInfo{Name: "ALSA"}, //nolint:exhaustruct
+	}
+
+	var buf bytes.Buffer
+
+	audio_list_device(&buf, dev, []int{44100, 48000}, nil, true, false)
+
+	assert.Equal(t, "\n  3  USB Audio Device: - (hw:1,0)  [ALSA]  (default input)\n"+
+		"       capture:   1 channel, 44100 48000 samples/sec\n"+
+		"       playback:  2 channels, none of the usual sample rates\n"+
+		"       ADEVICE \"USB Audio Device: - (hw:1,0)\"\n", buf.String())
+}
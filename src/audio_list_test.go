@@ -0,0 +1,46 @@
+package direwolf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_adevice_quote(t *testing.T) {
+	assert.Equal(t, "default", adevice_quote("default"))
+	assert.Equal(t, "plughw:1,0", adevice_quote("plughw:1,0"))
+	assert.Equal(t, `"USB Audio Device: - (hw:1,0)"`, adevice_quote("USB Audio Device: - (hw:1,0)"))
+	assert.Equal(t, `"My \"best\" card"`, adevice_quote(`My "best" card`))
+	assert.Equal(t, `""`, adevice_quote(""))
+
+	// Round trip through the configuration file tokenizer.
+	for _, name := range []string{"plughw:1,0", "USB Audio Device: - (hw:1,0)", `My "best" card`, `back\slash #2`} {
+		var lex = newConfigLexer("ADEVICE " + adevice_quote(name))
+		lex.next(false)
+		assert.Equal(t, name, lex.next(false))
+	}
+}
+
+func Test_audio_list_alsa(t *testing.T) {
+	var buf bytes.Buffer
+
+	audio_list_alsa(&buf, "00-00: ALC887-VD Analog : ALC887-VD Analog : playback 1 : capture 1\n"+
+		"01-00: USB Audio : USB Audio : playback 1 : capture 1\n"+
+		"00-03: HDMI 0 : HDMI 0 : playback 1\n")
+
+	assert.Equal(t, "ALSA devices:\n"+
+		"\n  alsa:hw:0,0    ALC887-VD Analog\n"+
+		"       playback and capture\n"+
+		"       ADEVICE alsa:hw:0,0\n"+
+		"\n  alsa:hw:1,0    USB Audio\n"+
+		"       playback and capture\n"+
+		"       ADEVICE alsa:hw:1,0\n"+
+		"\n  alsa:hw:0,3    HDMI 0\n"+
+		"       playback\n"+
+		"       ADEVICE alsa:hw:0,3\n", buf.String())
+
+	buf.Reset()
+	audio_list_alsa(&buf, "")
+	assert.Equal(t, "No ALSA devices found.\n", buf.String())
+}
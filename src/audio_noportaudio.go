@@ -0,0 +1,43 @@
+//go:build noportaudio
+
+package direwolf
+
+/* Built without PortAudio.  Sound card names go to ALSA instead.  See audio_device.go. */
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const portaudio_available = false
+
+var errNoPortAudio = errors.New("built without PortAudio, use alsa: or oss: device names")
+
+func portaudio_acquire() error {
+	return errNoPortAudio
+}
+
+func portaudio_release() {}
+
+func portaudio_open(_ string, _ bool, _ audio_format_s) (AudioDevice, error) {
+	return nil, errNoPortAudio
+}
+
+func portaudio_find(_ string, _ bool) (string, int, bool) {
+	return "", 0, false
+}
+
+/* For "direwolf --list-audio", the ALSA devices from the kernel. */
+
+func audio_list(w io.Writer) error {
+	var content, err = os.ReadFile(alsaPCMPath)
+	if err != nil {
+		return fmt.Errorf("could not list ALSA devices: %w", err)
+	}
+
+	audio_list_alsa(w, string(content))
+
+	return nil
+}
@@ -0,0 +1,112 @@
+//go:build linux || freebsd
+
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	OSS sound cards, in pure Go, for audio_device.go.
+ *
+ * Description:	/dev/dsp and friends.  Native on FreeBSD, and on
+ *		Linux with OSS emulation (snd-pcm-oss) or OSS 4.
+ *
+ * References:	http://manuals.opensound.com/developer/
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"os"
+	"unsafe"
+
+	"golang.org/x/sys/unix"
+)
+
+/* _SIOWR('P', n, int) and _SIO('P', 1) from soundcard.h. */
+
+const SNDCTL_DSP_SYNC = 0x00005001
+const SNDCTL_DSP_SPEED = 0xC0045002
+const SNDCTL_DSP_SETFMT = 0xC0045005
+const SNDCTL_DSP_CHANNELS = 0xC0045006
+
+const AFMT_U8 = 0x00000008
+const AFMT_S16_LE = 0x00000010
+
+type ossDevice struct {
+	f *os.File
+}
+
+/* Ask for a setting.  The driver says what it used instead, if it can't. */
+
+func oss_ioctl(f *os.File, req uintptr, value int) (int, error) {
+	var v = int32(value) //nolint:gosec
+
+	var _, _, errno = unix.Syscall(unix.SYS_IOCTL, f.Fd(), req, uintptr(unsafe.Pointer(&v))) //nolint:gosec
+	if errno != 0 {
+		return 0, errno
+	}
+
+	return int(v), nil
+}
+
+func oss_open(name string, forInput bool, format audio_format_s) (AudioDevice, error) {
+	var flag = os.O_WRONLY
+	if forInput {
+		flag = os.O_RDONLY
+	}
+
+	var f, err = os.OpenFile(name, flag, 0) //nolint:gosec // This comes from user-supplied config
+	if err != nil {
+		return nil, err
+	}
+
+	var afmt = AFMT_S16_LE
+	if format.bits == 8 {
+		afmt = AFMT_U8
+	}
+
+	for _, s := range []struct {
+		what  string
+		req   uintptr
+		value int
+	}{
+		{"format", SNDCTL_DSP_SETFMT, afmt},
+		{"channels", SNDCTL_DSP_CHANNELS, format.channels},
+		{"samples/sec", SNDCTL_DSP_SPEED, format.rate},
+	} {
+		var got, ioctlErr = oss_ioctl(f, s.req, s.value)
+		if ioctlErr != nil {
+			f.Close()
+
+			return nil, fmt.Errorf("%s: can't set %s: %w", name, s.what, ioctlErr)
+		}
+
+		if got != s.value {
+			f.Close()
+
+			return nil, fmt.Errorf("%s: asked for %d %s but it can only do %d", name, s.value, s.what, got)
+		}
+	}
+
+	return &ossDevice{f: f}, nil
+}
+
+func (d *ossDevice) Read(p []byte) (int, error) {
+	return d.f.Read(p)
+}
+
+func (d *ossDevice) Write(p []byte) (int, error) {
+	return d.f.Write(p)
+}
+
+func (d *ossDevice) Drain() error {
+	var _, _, errno = unix.Syscall(unix.SYS_IOCTL, d.f.Fd(), SNDCTL_DSP_SYNC, 0)
+	if errno != 0 {
+		return errno
+	}
+
+	return nil
+}
+
+func (d *ossDevice) Close() error {
+	return d.f.Close()
+}
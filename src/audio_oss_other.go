@@ -0,0 +1,9 @@
+//go:build !linux && !freebsd
+
+package direwolf
+
+import "errors"
+
+func oss_open(_ string, _ bool, _ audio_format_s) (AudioDevice, error) {
+	return nil, errors.New("OSS is only supported on Linux and FreeBSD")
+}
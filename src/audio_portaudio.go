@@ -0,0 +1,423 @@
+//go:build !noportaudio
+
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Sound cards through PortAudio, for audio_device.go.
+ *
+ * Description:	PortAudio needs cgo and libportaudio.  Build with
+ *		"-tags noportaudio" to leave it out and use ALSA or
+ *		OSS directly.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/gordonklaus/portaudio"
+)
+
+const portaudio_available = true
+
+// portaudioMu guards portaudioRefCount and ensures Initialize/Terminate are
+// correctly paired even if audio_open/audio_close are called concurrently.
+var portaudioMu sync.Mutex //nolint:gochecknoglobals
+var portaudioRefCount int  //nolint:gochecknoglobals
+
+func portaudio_acquire() error {
+	portaudioMu.Lock()
+	defer portaudioMu.Unlock()
+
+	if portaudioRefCount == 0 {
+		var err = portaudio.Initialize()
+		if err != nil {
+			return err
+		}
+	}
+
+	portaudioRefCount++
+
+	return nil
+}
+
+func portaudio_release() {
+	portaudioMu.Lock()
+	defer portaudioMu.Unlock()
+
+	if portaudioRefCount > 0 {
+		portaudioRefCount--
+		if portaudioRefCount == 0 {
+			portaudio.Terminate()
+		}
+	}
+}
+
+type portaudioDevice struct {
+	stream *portaudio.Stream
+
+	// Input: filled by the callback.
+	// Callback mode is more reliable than blocking read because the
+	// callback runs on a dedicated audio thread with better timing
+	// guarantees than Go goroutines.
+	ringBuf *audioRingBuffer
+
+	// Output: blocking writes of one buffer at a time.
+	outputBuf16 []int16
+	outputBuf8  []uint8
+	started     bool // Started lazily on first write, stopped by Drain.
+}
+
+func portaudio_open(name string, forInput bool, format audio_format_s) (AudioDevice, error) {
+	var dev = findPortAudioDevice(name, forInput)
+	if dev == nil {
+		return nil, fmt.Errorf("could not find audio device %s", name)
+	}
+
+	if forInput {
+		return portaudio_open_input(dev, format)
+	}
+
+	return portaudio_open_output(dev, format)
+}
+
+func portaudio_open_input(dev *portaudio.DeviceInfo, format audio_format_s) (AudioDevice, error) {
+	var d = new(portaudioDevice)
+
+	// Size the ring buffer to hold ~1 second of audio for plenty of headroom.
+	// This accommodates Go scheduler delays and processing latency.
+	d.ringBuf = newAudioRingBuffer(format.rate * format.bytes_per_frame())
+
+	var params = portaudio.StreamParameters{
+		Input: portaudio.StreamDeviceParameters{
+			Device:   dev,
+			Channels: format.channels,
+			Latency:  dev.DefaultHighInputLatency,
+		},
+		Output:          portaudio.StreamDeviceParameters{Device: nil, Channels: 0, Latency: 0},
+		SampleRate:      float64(format.rate),
+		FramesPerBuffer: format.frames_per_buffer,
+		Flags:           portaudio.NoFlag,
+	}
+
+	// The callback receives audio data and writes it to the ring buffer.
+	var ringBuf = d.ringBuf
+
+	var err error
+
+	if format.bits == 16 {
+		// Pre-allocate a scratch buffer sized for one full callback invocation
+		// so the callback performs zero heap allocations at runtime.
+		var scratchBuf = make([]byte, format.frames_per_buffer*format.channels*2)
+
+		d.stream, err = portaudio.OpenStream(params, func(in []int16) {
+			var scratch = scratchBuf[:len(in)*2]
+			for i, sample := range in {
+				binary.LittleEndian.PutUint16(scratch[i*2:], uint16(sample))
+			}
+
+			ringBuf.write(scratch)
+		})
+	} else {
+		d.stream, err = portaudio.OpenStream(params, func(in []uint8) {
+			ringBuf.write(in)
+		})
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	err = d.stream.Start()
+	if err != nil {
+		d.stream.Close()
+
+		return nil, fmt.Errorf("could not start audio input stream: %w", err)
+	}
+
+	return d, nil
+}
+
+func portaudio_open_output(dev *portaudio.DeviceInfo, format audio_format_s) (AudioDevice, error) {
+	var d = new(portaudioDevice)
+
+	var params = portaudio.StreamParameters{
+		Input: portaudio.StreamDeviceParameters{Device: nil, Channels: 0, Latency: 0},
+		Output: portaudio.StreamDeviceParameters{
+			Device:   dev,
+			Channels: format.channels,
+			Latency:  dev.DefaultHighOutputLatency,
+		},
+		SampleRate:      float64(format.rate),
+		FramesPerBuffer: format.frames_per_buffer,
+		Flags:           portaudio.NoFlag,
+	}
+
+	// Blocking write mode.
+	// Pass a pointer to a typed buffer; Write() will send buffer contents to PortAudio.
+	var err error
+
+	if format.bits == 16 {
+		d.outputBuf16 = make([]int16, format.frames_per_buffer*format.channels)
+		d.stream, err = portaudio.OpenStream(params, &d.outputBuf16)
+	} else {
+		d.outputBuf8 = make([]uint8, format.frames_per_buffer*format.channels)
+		d.stream, err = portaudio.OpenStream(params, &d.outputBuf8)
+	}
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Output stream is opened but NOT started here.
+	// It will be started lazily on first write
+	// and stopped in Drain, to avoid underflows during idle periods.
+
+	return d, nil
+}
+
+func (d *portaudioDevice) Read(p []byte) (int, error) {
+	if d.ringBuf == nil {
+		return 0, errors.New("audio device is not open for input")
+	}
+
+	var n, ok = d.ringBuf.readChunk(p)
+	if !ok {
+		return 0, io.EOF
+	}
+
+	return n, nil
+}
+
+func (d *portaudioDevice) Overflowed() bool {
+	return d.ringBuf != nil && d.ringBuf.checkOverflow()
+}
+
+/* Up to one buffer full, from audio_flush_real.  The rest is silence. */
+
+func (d *portaudioDevice) Write(p []byte) (int, error) {
+	switch {
+	case d.outputBuf16 != nil:
+		if len(p) > len(d.outputBuf16)*2 {
+			p = p[:len(d.outputBuf16)*2]
+		}
+
+		var nSamples = len(p) / 2
+		for i := range nSamples {
+			d.outputBuf16[i] = int16(binary.LittleEndian.Uint16(p[i*2:]))
+		}
+
+		clear(d.outputBuf16[nSamples:])
+	case d.outputBuf8 != nil:
+		if len(p) > len(d.outputBuf8) {
+			p = p[:len(d.outputBuf8)]
+		}
+
+		copy(d.outputBuf8, p)
+
+		for i := len(p); i < len(d.outputBuf8); i++ {
+			d.outputBuf8[i] = 128
+		}
+	default:
+		return 0, errors.New("audio device is not open for output")
+	}
+
+	// Start the output stream lazily on first write.
+	if !d.started {
+		var err = d.stream.Start()
+		if err != nil {
+			return 0, fmt.Errorf("could not start audio output stream: %w", err)
+		}
+
+		d.started = true
+	}
+
+	var err = d.stream.Write()
+	if err != nil {
+		var stopErr = d.stream.Stop()
+		if stopErr != nil {
+			err = fmt.Errorf("%w, and stopping: %w", err, stopErr)
+		}
+
+		d.started = false
+
+		return 0, err
+	}
+
+	return len(p), nil
+}
+
+/* Pa_StopStream plays remaining buffers before returning. */
+
+func (d *portaudioDevice) Drain() error {
+	if d.ringBuf != nil || !d.started {
+		return nil
+	}
+
+	d.started = false
+
+	return d.stream.Stop()
+}
+
+func (d *portaudioDevice) Close() error {
+	if d.ringBuf != nil || d.started {
+		d.stream.Stop()
+		d.started = false
+	}
+
+	var err = d.stream.Close()
+
+	// Then close ring buffer
+	if d.ringBuf != nil {
+		d.ringBuf.close()
+	}
+
+	return err
+}
+
+/* For doctor.go: the PortAudio name and channels of a device. */
+
+func portaudio_find(name string, forInput bool) (string, int, bool) {
+	var dev = findPortAudioDevice(name, forInput)
+	if dev == nil {
+		return "", 0, false
+	}
+
+	if forInput {
+		return dev.Name, dev.MaxInputChannels, true
+	}
+
+	return dev.Name, dev.MaxOutputChannels, true
+}
+
+/*
+ * Find a PortAudio device by name.
+ * Supports:
+ *   - "default" or "" -> system default device
+ *   - "hw:X,Y" style ALSA names -> search by substring
+ *   - Direct device name matching
+ */
+// matchPortAudioDeviceByName searches devices for one matching name using
+// several strategies: exact match, substring match, and ALSA-style name
+// matching (including resolution of udev-assigned card IDs via
+// /proc/asound/cards). Returns nil if no match is found.
+func matchPortAudioDeviceByName(name string, forInput bool, devices []*portaudio.DeviceInfo) *portaudio.DeviceInfo {
+	var devMatchesDirection = func(dev *portaudio.DeviceInfo) bool {
+		if forInput {
+			return dev.MaxInputChannels > 0
+		}
+
+		return dev.MaxOutputChannels > 0
+	}
+
+	// Try exact match first.
+	for _, dev := range devices {
+		if dev.Name == name && devMatchesDirection(dev) {
+			return dev
+		}
+	}
+
+	// Try substring match (check both directions).
+	for _, dev := range devices {
+		if devMatchesDirection(dev) {
+			var nameLower = strings.ToLower(name)
+			var devLower = strings.ToLower(dev.Name)
+			if strings.Contains(devLower, nameLower) || strings.Contains(nameLower, devLower) {
+				return dev
+			}
+		}
+	}
+
+	// Try ALSA-style name matching.
+	// Config names like "plughw:Loopback,1,1" need to match PortAudio names
+	// like "Loopback: PCM (hw:0,1)".
+	// Extract card name and device number from the config, then match against
+	// the card name prefix and (hw:X,Dev) pattern in PortAudio device names.
+	var cardName, devNum = parseALSADeviceName(name)
+	if cardName != "" {
+		// First try matching by card name substring in PortAudio device names.
+		// This covers the common case where the ALSA card name matches part of
+		// the PortAudio description (e.g. "Loopback" appearing in "Loopback: PCM (hw:0,1)").
+		for _, dev := range devices {
+			if !devMatchesDirection(dev) {
+				continue
+			}
+			var devLower = strings.ToLower(dev.Name)
+			if !strings.Contains(devLower, strings.ToLower(cardName)) {
+				continue
+			}
+			if devNum >= 0 {
+				var target = fmt.Sprintf(",%d)", devNum)
+				if strings.Contains(dev.Name, target) {
+					return dev
+				}
+			} else {
+				return dev
+			}
+		}
+
+		// The card name may be a udev-assigned ALSA card ID that differs from
+		// the hardware description PortAudio uses. Resolve it to a numeric card
+		// index via /proc/asound/cards (Linux) and match by (hw:N,M).
+		if cardNum, ok := resolveALSACardNumber(cardName); ok {
+			for _, dev := range devices {
+				if !devMatchesDirection(dev) {
+					continue
+				}
+				if devNum >= 0 {
+					var target = fmt.Sprintf("(hw:%d,%d)", cardNum, devNum)
+					if strings.Contains(dev.Name, target) {
+						return dev
+					}
+				} else {
+					var target = fmt.Sprintf("(hw:%d,", cardNum)
+					if strings.Contains(dev.Name, target) {
+						return dev
+					}
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func findPortAudioDevice(name string, forInput bool) *portaudio.DeviceInfo {
+	// Handle default device
+	if name == "" || strings.ToLower(name) == "default" {
+		if forInput {
+			var dev, err = portaudio.DefaultInputDevice()
+			if err != nil {
+				return nil
+			}
+
+			return dev
+		} else {
+			var dev, err = portaudio.DefaultOutputDevice()
+			if err != nil {
+				return nil
+			}
+
+			return dev
+		}
+	}
+
+	// Search through all devices
+	var devices, err = portaudio.Devices()
+	if err != nil {
+		return nil
+	}
+
+	var dev = matchPortAudioDeviceByName(name, forInput, devices)
+	if dev == nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Could not match audio device '%s' to any PortAudio device.\n", name)
+	}
+
+	return dev
+}
@@ -0,0 +1,263 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Receive from a WAV file, e.g. a recording of a busy
+ *		channel, instead of a sound card.
+ *
+ * Description:	"samoyed -c my.conf capture.wav", or ADEVICE with a
+ *		name ending in .wav, reads the file as fast as it can
+ *		be decoded and exits at the end.
+ *
+ *		The sample rate and number of channels come from the
+ *		file, replacing ARATE and ACHANNELS.  8, 16, 24, and 32
+ *		bit integer samples are accepted.  The modems only
+ *		understand 8 and 16, so 24 and 32 are cut down to 16.
+ *
+ *		Progress is shown every 10% of the way.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type wavAudioDevice struct {
+	name string
+	f    *os.File
+	r    *bufio.Reader
+
+	rate     int
+	channels int
+	bits     int /* In the file. */
+
+	remaining int64 /* Bytes of samples left.  -1 if unknown, until end of file. */
+	total     int64 /* Same, at the start. */
+	done      int64
+	reported  int64 /* Tenths of the way, last shown. */
+
+	buf []byte
+}
+
+/* Is this the name of a WAV file?  Only for receiving. */
+
+func is_wav_name(name string) bool {
+	return strings.HasSuffix(strings.ToLower(name), ".wav")
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        wav_open
+ *
+ * Purpose:     Open a WAV file and find the samples.
+ *
+ * Inputs:	path	- File name.
+ *
+ * Returns:	Device ready to Read samples, 8 or 16 bits, or an
+ *		error if it isn't a WAV file we can use.
+ *
+ *--------------------------------------------------------------------*/
+
+func wav_open(path string) (*wavAudioDevice, error) {
+	var f, err = os.Open(path) //nolint:gosec // Named by the user.
+	if err != nil {
+		return nil, err
+	}
+
+	var d = &wavAudioDevice{ //nolint:exhaustruct
+		name: filepath.Base(path),
+		f:    f,
+		r:    bufio.NewReader(f),
+	}
+
+	err = d.readHeader()
+	if err != nil {
+		f.Close()
+
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	return d, nil
+}
+
+/* Find the samples, and check that we can use them. */
+
+func (d *wavAudioDevice) readHeader() error {
+	var wf, size, err = wav_read_header(d.r)
+	if err != nil {
+		return err
+	}
+
+	d.channels = wf.channels
+	d.rate = wf.rate
+	d.bits = wf.bits
+
+	switch {
+	case wf.format != WAV_FORMAT_PCM:
+		return fmt.Errorf("format %d, only PCM (1) is supported", wf.format)
+	case d.channels != 1 && d.channels != 2:
+		return fmt.Errorf("%d channels, only 1 or 2 are supported", d.channels)
+	case d.bits != 8 && d.bits != 16 && d.bits != 24 && d.bits != 32:
+		return fmt.Errorf("%d bits per sample, only 8, 16, 24, or 32 are supported", d.bits)
+	case d.rate < MIN_SAMPLES_PER_SEC || d.rate > MAX_SAMPLES_PER_SEC:
+		return fmt.Errorf("%d samples per second, should be %d to %d", d.rate, MIN_SAMPLES_PER_SEC, MAX_SAMPLES_PER_SEC)
+	case wf.blockAlign != d.channels*d.bits/8:
+		return fmt.Errorf("block size %d doesn't match %d channels of %d bits", wf.blockAlign, d.channels, d.bits)
+	}
+
+	d.remaining = int64(size) - int64(size)%int64(wf.blockAlign)
+	if size == 0 || size == WAV_SIZE_UNKNOWN {
+		d.remaining = -1
+	}
+
+	d.total = d.remaining
+
+	return nil
+}
+
+/* What the modems get, 8 or 16. */
+
+func (d *wavAudioDevice) out_bits() int {
+	return min(d.bits, 16)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        wav_use_format
+ *
+ * Purpose:     Use the sample rate and channels from the file
+ *		rather than the configuration.
+ *
+ *--------------------------------------------------------------------*/
+
+func wav_use_format(pa *audio_s, a int, d *wavAudioDevice) {
+	var first = ADEVFIRSTCHAN(a)
+
+	text_color_set(DW_COLOR_INFO)
+
+	if d.channels == 2 && pa.chan_medium[first+1] == MEDIUM_NONE {
+		pa.chan_medium[first+1] = MEDIUM_RADIO // Same as ACHANNELS 2.
+	}
+
+	if d.channels == 1 && pa.adev[a].num_channels == 2 {
+		dw_printf("%s is mono, so channel %d won't hear anything.\n", d.name, first+1)
+	}
+
+	pa.adev[a].samples_per_sec = d.rate
+	pa.adev[a].num_channels = d.channels
+	pa.adev[a].bits_per_sample = d.out_bits()
+
+	var length = "length unknown"
+	if d.total > 0 {
+		length = d.duration(d.total).Round(time.Second).String() + " long"
+	}
+
+	dw_printf("%s: %d samples per second, %d bits, %d channel(s), %s.\n", d.name, d.rate, d.bits, d.channels, length)
+}
+
+/* How long that many bytes of samples would play. */
+
+func (d *wavAudioDevice) duration(bytes int64) time.Duration {
+	return time.Duration(bytes) * time.Second / time.Duration(d.rate*d.channels*d.bits/8)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        Read
+ *
+ * Purpose:     Get samples for audio.go.
+ *
+ * Description:	Whole frames only.  An incomplete one at the end of
+ *		the file is dropped.  24 and 32 bit samples keep the
+ *		most significant 16 bits.
+ *
+ *--------------------------------------------------------------------*/
+
+func (d *wavAudioDevice) Read(p []byte) (int, error) {
+	var inFrame = d.channels * d.bits / 8
+	var outFrame = d.channels * d.out_bits() / 8
+
+	var want = int64(len(p) / outFrame * inFrame)
+	if d.remaining >= 0 {
+		want = min(want, d.remaining)
+	}
+
+	if want == 0 {
+		return 0, io.EOF
+	}
+
+	if int64(len(d.buf)) < want {
+		d.buf = make([]byte, want)
+	}
+
+	var n, err = io.ReadFull(d.r, d.buf[:want])
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return 0, err
+	}
+
+	n -= n % inFrame
+	if n == 0 {
+		return 0, io.EOF
+	}
+
+	if d.remaining >= 0 {
+		d.remaining -= int64(n)
+	}
+
+	d.progress(int64(n))
+
+	if d.bits <= 16 {
+		return copy(p, d.buf[:n]), nil
+	}
+
+	var size = d.bits / 8
+	var out = 0
+
+	for i := 0; i < n; i += size {
+		p[out] = d.buf[i+size-2]
+		p[out+1] = d.buf[i+size-1]
+		out += 2
+	}
+
+	return out, nil
+}
+
+/* Every 10% of the way, if we know how long it is. */
+
+func (d *wavAudioDevice) progress(n int64) {
+	d.done += n
+
+	if d.total <= 0 {
+		return
+	}
+
+	var tenths = d.done * 10 / d.total
+	if tenths == d.reported {
+		return
+	}
+
+	d.reported = tenths
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("%s: %d%%, %s of %s\n", d.name, tenths*10,
+		d.duration(d.done).Round(time.Second), d.duration(d.total).Round(time.Second))
+}
+
+func (d *wavAudioDevice) Write(p []byte) (int, error) {
+	return 0, errors.New("can't transmit to a WAV file")
+}
+
+func (d *wavAudioDevice) Drain() error {
+	return nil
+}
+
+func (d *wavAudioDevice) Close() error {
+	return d.f.Close()
+}
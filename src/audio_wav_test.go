@@ -0,0 +1,118 @@
+package direwolf
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/* Write a WAV file, from wavTestFile, for wav_open. */
+
+func wavTestPath(t *testing.T, b []byte) string {
+	t.Helper()
+
+	var path = filepath.Join(t.TempDir(), "capture.wav")
+	require.NoError(t, os.WriteFile(path, b, 0o600))
+
+	return path
+}
+
+func readAllWAV(t *testing.T, d *wavAudioDevice) []byte {
+	t.Helper()
+
+	var all []byte
+	var p = make([]byte, 4)
+
+	for {
+		var n, err = d.Read(p)
+		if err == io.EOF {
+			return all
+		}
+
+		require.NoError(t, err)
+		all = append(all, p[:n]...)
+	}
+}
+
+func Test_wav_open_24bit_stereo(t *testing.T) {
+	// Two frames of left and right, then part of a third.
+
+	var samples = []byte{
+		0x01, 0x02, 0x03, 0x11, 0x12, 0x13,
+		0x21, 0x22, 0x23, 0x31, 0x32, 0x33,
+		0x41, 0x42,
+	}
+
+	var path = wavTestPath(t, wavTestFile(WAV_FORMAT_PCM, 2, 24, 44100, uint32(len(samples)), samples))
+
+	var d, err = wav_open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { d.Close() })
+
+	assert.Equal(t, 44100, d.rate)
+	assert.Equal(t, 2, d.channels)
+	assert.Equal(t, 16, d.out_bits())
+
+	assert.Equal(t, []byte{0x02, 0x03, 0x12, 0x13, 0x22, 0x23, 0x32, 0x33}, readAllWAV(t, d), "top 16 bits, and no partial frame")
+
+	_, err = d.Write([]byte{0})
+	assert.Error(t, err)
+}
+
+func Test_wav_open_unknown_length(t *testing.T) {
+	var samples = []byte{1, 2, 3, 4, 5, 6, 7}
+
+	var path = wavTestPath(t, wavTestFile(WAV_FORMAT_PCM, 1, 16, 8000, WAV_SIZE_UNKNOWN, samples))
+
+	var d, err = wav_open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { d.Close() })
+
+	assert.Equal(t, []byte{1, 2, 3, 4, 5, 6}, readAllWAV(t, d), "until the end of the file")
+}
+
+func Test_wav_open_bad(t *testing.T) {
+	for name, path := range map[string]string{
+		"float":     wavTestPath(t, wavTestFile(3, 1, 32, 48000, 4, []byte{0, 0, 0, 0})),
+		"channels":  wavTestPath(t, wavTestFile(WAV_FORMAT_PCM, 3, 16, 48000, 6, make([]byte, 6))),
+		"bits":      wavTestPath(t, wavTestFile(WAV_FORMAT_PCM, 1, 12, 48000, 2, make([]byte, 2))),
+		"rate":      wavTestPath(t, wavTestFile(WAV_FORMAT_PCM, 1, 16, 1000, 2, make([]byte, 2))),
+		"not a WAV": filepath.Join("testdata", "decode_aprs", "other.txt"),
+		"missing":   filepath.Join(t.TempDir(), "nothing.wav"),
+	} {
+		var _, err = wav_open(path)
+		assert.Error(t, err, name)
+	}
+
+	// Cut short before the samples.
+
+	var b = wavTestFile(WAV_FORMAT_PCM, 1, 16, 48000, 0, nil)
+
+	var _, err = wav_open(wavTestPath(t, b[:len(b)-8]))
+	assert.Error(t, err)
+}
+
+func Test_wav_use_format(t *testing.T) {
+	var path = wavTestPath(t, wavTestFile(WAV_FORMAT_PCM, 2, 24, 22050, 12, make([]byte, 12)))
+
+	var d, err = wav_open(path)
+	require.NoError(t, err)
+	t.Cleanup(func() { d.Close() })
+
+	var pa = new(audio_s)
+	pa.adev[0].num_channels = 1
+	pa.adev[0].samples_per_sec = 44100
+	pa.adev[0].bits_per_sample = 16
+	pa.chan_medium[0] = MEDIUM_RADIO
+
+	wav_use_format(pa, 0, d)
+
+	assert.Equal(t, 22050, pa.adev[0].samples_per_sec)
+	assert.Equal(t, 2, pa.adev[0].num_channels)
+	assert.Equal(t, 16, pa.adev[0].bits_per_sample)
+	assert.Equal(t, MEDIUM_RADIO, pa.chan_medium[1], "like ACHANNELS 2")
+}
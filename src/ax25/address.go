@@ -0,0 +1,223 @@
+/*------------------------------------------------------------------
+ *
+ * Purpose:	AX.25 frames, for any Go program which needs to take
+ *		them apart or put them together, without the rest of
+ *		the TNC.
+ *
+ * Description:	address.go	Station addresses such as WB2OSZ-15.
+ *
+ *		frame.go	Whole frames, to and from the bytes sent
+ *				over the air (without the FCS) and the
+ *				"TNC-2" monitor format, e.g.
+ *
+ *					WB2OSZ-15>APDW17,WIDE1-1*:!4237.14NS07120.83W#
+ *
+ *		hex_dump.go	Raw bytes for troubleshooting.
+ *
+ *		This follows ax25_pad.go, which is still what the TNC
+ *		uses internally, but reports problems as errors rather
+ *		than printing them.
+ *
+ *		Only modulo 8, which is what APRS and most connected
+ *		mode stations use.  A modulo 128 I or S frame has two
+ *		control octets, and nothing in the frame says so.
+ *
+ *------------------------------------------------------------------*/
+
+// Package ax25 parses and builds AX.25 frames and their text
+// representation, for the TNC and for other programs.
+package ax25
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+/* Address positions in a frame. */
+
+const (
+	Destination = 0
+	Source      = 1
+	Repeater1   = 2
+)
+
+const MinAddrs = 2  /* Destination & Source. */
+const MaxAddrs = 10 /* Destination, Source, 8 digipeaters. */
+
+const MaxCallsignLen = 6 /* Over the air. */
+
+/*
+ * From an IGate server, we can get longer names such as
+ * "BM2142POS" or "T2NUENGLD" after a q-construct.
+ */
+
+const maxLenientCallsignLen = 11
+
+/* The 7th octet of each address. */
+
+const ssidHMask = 0x80
+const ssidRRMask = 0x60
+const ssidRRShift = 5
+const ssidSSIDMask = 0x1e
+const ssidSSIDShift = 1
+const ssidLastMask = 0x01
+
+// An Address is one station address, such as WB2OSZ-15.
+type Address struct {
+	Callsign string
+	SSID     int  /* 0 to 15. */
+	H        bool /* "Has been repeated" for a digipeater, command/response for source and destination. */
+	Reserved int  /* The two RR bits.  3 unless something is using them. */
+}
+
+/*------------------------------------------------------------------------------
+ *
+ * Name:	ParseAddress
+ *
+ * Purpose:	Parse address with optional ssid.
+ *
+ * Inputs:	s	- Input such as "WB2OSZ-15*"
+ *
+ *		strict	- True for strict checking (6 characters, no lower case,
+ *			  SSID must be in range of 0 to 15).
+ *			  Strict is appropriate for packets sent
+ *			  over the radio.  Communication with IGate
+ *			  allows lower case (e.g. "qAR") and longer
+ *			  names.  We get messages like this from a server.
+ *				KB1POR>APU25N,TCPIP*,qAC,T2NUENGLD:...
+ *
+ * Returns:	The address, with H set if "*" was found at the end.
+ *
+ *------------------------------------------------------------------------------*/
+
+func ParseAddress(s string, strict bool) (Address, error) {
+	var a = Address{Callsign: "", SSID: 0, H: false, Reserved: 3}
+
+	if s == "" {
+		return a, fmt.Errorf("address \"%s\" is empty", s)
+	}
+
+	var maxlen = maxLenientCallsignLen
+	if strict {
+		maxlen = MaxCallsignLen
+	}
+
+	var rest = s
+
+	for i, p := range s {
+		if p == '-' || p == '*' {
+			break
+		}
+
+		if i >= maxlen {
+			return a, fmt.Errorf("address is too long. \"%s\" has more than %d characters", s, maxlen)
+		}
+
+		if !unicode.IsLetter(p) && !unicode.IsNumber(p) {
+			return a, fmt.Errorf("address \"%s\" contains character other than letter or digit in character position %d", s, i)
+		}
+
+		if strict && unicode.IsLower(p) {
+			return a, fmt.Errorf("address has lower case letters. \"%s\" must be all upper case", s)
+		}
+
+		a.Callsign += string(p)
+	}
+
+	rest = rest[len(a.Callsign):]
+
+	if strings.HasPrefix(rest, "-") {
+		rest = rest[1:]
+
+		var n = 0
+		for n < len(rest) && rest[n] != '*' {
+			n++
+		}
+
+		if n > 2 {
+			return a, fmt.Errorf("SSID is too long. SSID part of \"%s\" has more than 2 characters", s)
+		}
+
+		var k, err = strconv.Atoi(rest[:n])
+		if err != nil {
+			return a, fmt.Errorf("malformed SSID: \"%s\" could not be parsed", s)
+		}
+
+		if k < 0 || k > 15 {
+			return a, fmt.Errorf("SSID out of range. SSID of \"%s\" not in range of 0 to 15", s)
+		}
+
+		a.SSID = k
+		rest = rest[n:]
+	}
+
+	if strings.HasPrefix(rest, "*") {
+		a.H = true
+		rest = rest[1:]
+	}
+
+	if rest != "" {
+		return a, fmt.Errorf("invalid character \"%c\" found in address \"%s\"", rest[0], s)
+	}
+
+	return a, nil
+}
+
+/* e.g. "WB2OSZ-15", or "WB2OSZ" when the SSID is 0.  Never an "*". */
+
+func (a Address) String() string {
+	if a.SSID == 0 {
+		return a.Callsign
+	}
+
+	return fmt.Sprintf("%s-%d", a.Callsign, a.SSID)
+}
+
+/*
+ * Address as 7 octets.  Characters are shifted left one bit and
+ * blank padded.  A longer name, from an IGate server, is truncated.
+ */
+
+func (a Address) pack(last bool) [7]byte {
+	var b [7]byte
+
+	for i := range MaxCallsignLen {
+		var c byte = ' '
+		if i < len(a.Callsign) {
+			c = a.Callsign[i]
+		}
+
+		b[i] = c << 1
+	}
+
+	b[6] = byte(a.Reserved<<ssidRRShift)&ssidRRMask | byte(a.SSID<<ssidSSIDShift)&ssidSSIDMask //nolint:gosec
+
+	if a.H {
+		b[6] |= ssidHMask
+	}
+
+	if last {
+		b[6] |= ssidLastMask
+	}
+
+	return b
+}
+
+/* Reverse of pack.  Trailing blanks are removed. */
+
+func unpackAddress(b []byte) Address {
+	var call strings.Builder
+
+	for _, c := range b[:MaxCallsignLen] {
+		call.WriteByte(c >> 1)
+	}
+
+	return Address{
+		Callsign: strings.TrimRight(call.String(), " "),
+		SSID:     int(b[6]&ssidSSIDMask) >> ssidSSIDShift,
+		H:        b[6]&ssidHMask != 0,
+		Reserved: int(b[6]&ssidRRMask) >> ssidRRShift,
+	}
+}
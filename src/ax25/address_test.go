@@ -0,0 +1,61 @@
+package ax25
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseAddress(t *testing.T) {
+	var a, err = ParseAddress("Q1TEST-15*", true)
+	require.NoError(t, err)
+	assert.Equal(t, Address{Callsign: "Q1TEST", SSID: 15, H: true, Reserved: 3}, a)
+	assert.Equal(t, "Q1TEST-15", a.String())
+
+	a, err = ParseAddress("WIDE2", true)
+	require.NoError(t, err)
+	assert.Equal(t, Address{Callsign: "WIDE2", SSID: 0, H: false, Reserved: 3}, a)
+	assert.Equal(t, "WIDE2", a.String())
+
+	// From an IGate server.
+
+	a, err = ParseAddress("T2NUENGLD", false)
+	require.NoError(t, err)
+	assert.Equal(t, "T2NUENGLD", a.Callsign)
+
+	_, err = ParseAddress("qAC", false)
+	require.NoError(t, err)
+
+	for _, bad := range []string{
+		"",
+		"T2NUENGLD", // Too long.
+		"qAC",       // Lower case.
+		"Q1TEST-16", // SSID out of range.
+		"Q1TEST-100",
+		"Q1TEST-A",
+		"Q1TEST-",
+		"Q1/TEST",
+		"Q1TEST*X",
+	} {
+		_, err = ParseAddress(bad, true)
+		assert.Error(t, err, bad)
+	}
+}
+
+func Test_Address_pack(t *testing.T) {
+	var a = Address{Callsign: "Q1TEST", SSID: 5, H: true, Reserved: 3}
+
+	var b = a.pack(true)
+	assert.Equal(t, [7]byte{'Q' << 1, '1' << 1, 'T' << 1, 'E' << 1, 'S' << 1, 'T' << 1, 0x80 | 0x60 | 5<<1 | 1}, b)
+	assert.Equal(t, a, unpackAddress(b[:]))
+
+	// Blank padded, and longer names from IGate servers are truncated.
+
+	b = Address{Callsign: "AB", SSID: 0, H: false, Reserved: 3}.pack(false)
+	assert.Equal(t, "AB", unpackAddress(b[:]).Callsign)
+	assert.Equal(t, byte(' '<<1), b[5])
+
+	b = Address{Callsign: "T2NUENGLD", SSID: 0, H: false, Reserved: 3}.pack(false)
+	assert.Equal(t, "T2NUEN", unpackAddress(b[:]).Callsign)
+}
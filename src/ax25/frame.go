@@ -0,0 +1,318 @@
+package ax25
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+const MaxInfoLen = 2048 /* Maximum size for APRS. */
+
+const MinPacketLen = 2*7 + 1
+const MaxPacketLen = MaxAddrs*7 + 2 + 3 + MaxInfoLen
+
+const ControlUI = 0x03   /* UI frame, with the P/F bit clear. */
+const PIDNoLayer3 = 0xf0 /* Protocol ID used for APRS. */
+const PIDEscape = 0xff   /* Another protocol ID octet follows. */
+const controlUIMask = 0xef
+
+// A Frame is an AX.25 frame without the FCS.
+type Frame struct {
+	Addrs   []Address /* Destination, Source, then up to 8 digipeaters. */
+	Control byte
+	PID     byte /* Only for I and UI frames.  See HasPID. */
+	Info    []byte
+}
+
+/*
+ * I and UI frames have a protocol ID.  After PIDEscape, the
+ * next octet is left at the start of Info.
+ */
+
+func (f *Frame) HasPID() bool {
+	return f.Control&0x01 == 0 || f.Control&controlUIMask == ControlUI
+}
+
+/* Index of the last digipeater with H set, or Source if none. */
+
+func (f *Frame) Heard() int {
+	var result = Source
+
+	for i := Repeater1; i < len(f.Addrs); i++ {
+		if f.Addrs[i].H {
+			result = i
+		}
+	}
+
+	return result
+}
+
+/*------------------------------------------------------------------------------
+ *
+ * Name:	ParseFrame
+ *
+ * Purpose:	Split apart an HDLC frame to components.
+ *
+ * Inputs:	data	- Frame bytes, without the FCS, which someone
+ *			  else has checked.
+ *
+ * Returns:	The frame, which doesn't share memory with data.
+ *
+ *------------------------------------------------------------------------------*/
+
+func ParseFrame(data []byte) (*Frame, error) {
+	if len(data) < MinPacketLen || len(data) > MaxPacketLen {
+		return nil, fmt.Errorf("frame length %d not in allowable range of %d to %d", len(data), MinPacketLen, MaxPacketLen)
+	}
+
+	/* The address field ends with the "last" bit. */
+
+	var addrBytes = 0
+	for addrBytes < len(data) && data[addrBytes]&ssidLastMask == 0 {
+		addrBytes++
+	}
+
+	addrBytes++
+
+	if addrBytes > len(data) || addrBytes%7 != 0 || addrBytes/7 < MinAddrs || addrBytes/7 > MaxAddrs {
+		return nil, errors.New("not an AX.25 frame: can't find end of address field")
+	}
+
+	if addrBytes >= len(data) {
+		return nil, errors.New("frame has no control octet")
+	}
+
+	var f = &Frame{Addrs: nil, Control: data[addrBytes], PID: 0, Info: nil}
+
+	for i := 0; i < addrBytes; i += 7 {
+		f.Addrs = append(f.Addrs, unpackAddress(data[i:i+7]))
+	}
+
+	var rest = data[addrBytes+1:]
+
+	if f.HasPID() {
+		if len(rest) == 0 {
+			return nil, errors.New("frame has no protocol ID")
+		}
+
+		f.PID = rest[0]
+		rest = rest[1:]
+	}
+
+	f.Info = bytes.Clone(rest)
+	if f.Info == nil {
+		f.Info = []byte{}
+	}
+
+	return f, nil
+}
+
+/*------------------------------------------------------------------------------
+ *
+ * Name:	ParseTextFormat
+ *
+ * Purpose:	Parse a frame in human-readable monitoring format.
+ *
+ * Input:	monitor	- "TNC-2" monitor format for packet.  i.e.
+ *				source>dest[,repeater1,repeater2,...]:information
+ *
+ *			The information part can have non-printable characters
+ *			in the form of <0xff>.  This will be converted to single
+ *			bytes.  e.g.  <0x0d> is carriage return.
+ *
+ *		strict	- True to enforce rules for packets sent over the air.
+ *			  False to be more lenient for packets from IGate server.
+ *			  A q-construct, such as qAR, is then changed to
+ *			  upper case so it can be packed.
+ *
+ * Returns:	A UI frame, with no layer 3 protocol, as used by APRS.
+ *
+ *------------------------------------------------------------------------------*/
+
+func ParseTextFormat(monitor string, strict bool) (*Frame, error) {
+	var addrs, info, found = strings.Cut(monitor, ":")
+	if !found {
+		return nil, errors.New("no \":\" after the addresses")
+	}
+
+	var src, rest, hasDest = strings.Cut(addrs, ">")
+	if !hasDest {
+		return nil, errors.New("no source address")
+	}
+
+	var source, err = ParseAddress(src, strict)
+	if err != nil {
+		return nil, fmt.Errorf("bad source address: %w", err)
+	}
+
+	var dst, via, _ = strings.Cut(rest, ",")
+
+	dest, err := ParseAddress(dst, strict)
+	if err != nil {
+		return nil, fmt.Errorf("bad destination address: %w", err)
+	}
+
+	/* Command/response bits, as in ax25_pad.go. */
+
+	source.H = true
+	dest.H = true
+
+	var f = &Frame{Addrs: []Address{dest, source}, Control: ControlUI, PID: PIDNoLayer3, Info: nil}
+
+	/*
+	 * Adjacent commas are an error, not collapsed, for this bizarre case.
+	 *	AISAT-1>CQ,,::CQ-0     :From  AMSAT INDIA & Exseed Space |114304|48|45|42{962
+	 * Any more than 8 digipeaters are dropped.
+	 */
+
+	for via != "" && len(f.Addrs) < MaxAddrs {
+		var pa string
+
+		pa, via, _ = strings.Cut(via, ",")
+
+		if !strict && len(pa) >= 3 && strings.HasPrefix(pa, "qA") {
+			pa = "QA" + strings.ToUpper(pa[2:3]) + pa[3:]
+		}
+
+		var digi, err = ParseAddress(pa, strict)
+		if err != nil {
+			return nil, fmt.Errorf("bad digipeater address: %w", err)
+		}
+
+		f.Addrs = append(f.Addrs, digi)
+
+		/* "*" means heard from this one, so all before it have repeated it too. */
+
+		if digi.H {
+			for k := Repeater1; k < len(f.Addrs); k++ {
+				f.Addrs[k].H = true
+			}
+		}
+	}
+
+	f.Info, err = unescapeInfo(info)
+	if err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+/* Translate hexadecimal values like <0xff> to single bytes. */
+
+func unescapeInfo(s string) ([]byte, error) {
+	var info = []byte{}
+
+	for s != "" {
+		if len(info) >= MaxInfoLen {
+			return nil, fmt.Errorf("info part too long (max %d bytes)", MaxInfoLen)
+		}
+
+		if len(s) >= 6 && strings.HasPrefix(s, "<0x") && s[5] == '>' {
+			var v, err = strconv.ParseUint(s[3:5], 16, 8)
+			if err == nil {
+				info = append(info, byte(v))
+				s = s[6:]
+
+				continue
+			}
+		}
+
+		info = append(info, s[0])
+		s = s[1:]
+	}
+
+	return info, nil
+}
+
+/*------------------------------------------------------------------
+ *
+ * Function:	Pack
+ *
+ * Purpose:	Put all the pieces into format ready for transmission.
+ *
+ * Returns:	Frame bytes, without the FCS.
+ *
+ *------------------------------------------------------------------*/
+
+func (f *Frame) Pack() ([]byte, error) {
+	if len(f.Addrs) < MinAddrs || len(f.Addrs) > MaxAddrs {
+		return nil, fmt.Errorf("%d addresses not in allowable range of %d to %d", len(f.Addrs), MinAddrs, MaxAddrs)
+	}
+
+	if len(f.Info) > MaxInfoLen {
+		return nil, fmt.Errorf("info part too long (max %d bytes)", MaxInfoLen)
+	}
+
+	var result = make([]byte, 0, len(f.Addrs)*7+2+len(f.Info))
+
+	for i, a := range f.Addrs {
+		if a.Callsign == "" {
+			return nil, fmt.Errorf("address %d is empty", i)
+		}
+
+		var b = a.pack(i == len(f.Addrs)-1)
+		result = append(result, b[:]...)
+	}
+
+	result = append(result, f.Control)
+
+	if f.HasPID() {
+		result = append(result, f.PID)
+	}
+
+	result = append(result, f.Info...)
+
+	return result, nil
+}
+
+/*------------------------------------------------------------------
+ *
+ * Function:	String
+ *
+ * Purpose:	Monitor format, the reverse of ParseTextFormat.
+ *
+ *			SRC>DST,RPT1,RPT2*,RPT3:information
+ *
+ *		An asterisk is displayed after the last digipeater
+ *		with the "H" bit set.  Control characters in the
+ *		information part are shown like <0x0d>.
+ *
+ *------------------------------------------------------------------*/
+
+func (f *Frame) String() string {
+	if len(f.Addrs) < MinAddrs {
+		return ""
+	}
+
+	var result strings.Builder
+
+	result.WriteString(f.Addrs[Source].String())
+	result.WriteString(">")
+	result.WriteString(f.Addrs[Destination].String())
+
+	var heard = f.Heard()
+
+	for i := Repeater1; i < len(f.Addrs); i++ {
+		result.WriteString(",")
+		result.WriteString(f.Addrs[i].String())
+
+		if i == heard {
+			result.WriteString("*")
+		}
+	}
+
+	result.WriteString(":")
+
+	for _, c := range f.Info {
+		if c < ' ' || c == 0x7f {
+			fmt.Fprintf(&result, "<0x%02x>", c)
+		} else {
+			result.WriteByte(c)
+		}
+	}
+
+	return result.String()
+}
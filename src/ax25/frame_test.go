@@ -0,0 +1,125 @@
+package ax25
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_ParseTextFormat(t *testing.T) {
+	var f, err = ParseTextFormat("Q1TEST-7>APDW17,WIDE1-1,WIDE2-1*,WIDE3:>Hello<0x0d>", true)
+	require.NoError(t, err)
+
+	require.Len(t, f.Addrs, 5)
+	assert.Equal(t, "APDW17", f.Addrs[Destination].String())
+	assert.Equal(t, "Q1TEST-7", f.Addrs[Source].String())
+	assert.True(t, f.Addrs[Repeater1].H, "repeated before the one with *")
+	assert.True(t, f.Addrs[Repeater1+1].H)
+	assert.False(t, f.Addrs[Repeater1+2].H)
+	assert.Equal(t, Repeater1+1, f.Heard())
+
+	assert.Equal(t, byte(ControlUI), f.Control)
+	assert.Equal(t, byte(PIDNoLayer3), f.PID)
+	assert.Equal(t, []byte(">Hello\r"), f.Info)
+
+	assert.Equal(t, "Q1TEST-7>APDW17,WIDE1-1,WIDE2-1*,WIDE3:>Hello<0x0d>", f.String())
+
+	// No digipeaters, and nothing after the ":".
+
+	f, err = ParseTextFormat("Q1TEST>Q2TEST:", true)
+	require.NoError(t, err)
+	assert.Len(t, f.Addrs, 2)
+	assert.Equal(t, Source, f.Heard())
+	assert.Empty(t, f.Info)
+
+	// From an IGate server.
+
+	f, err = ParseTextFormat("Q1TEST>APRS,TCPIP*,qAC,T2NUENGLD:!", false)
+	require.NoError(t, err)
+	assert.Equal(t, "QAC", f.Addrs[Repeater1+1].Callsign)
+
+	for _, bad := range []string{
+		"Q1TEST>APRS,TCPIP*,qAC,T2NUENGLD:!",
+		"Q1TEST>APRS",
+		"APRS:!",
+		"Q1TEST>APRS,,WIDE1-1:!",
+		"Q1TEST->APRS:!",
+		"Q1TEST>APRS-16:!",
+	} {
+		_, err = ParseTextFormat(bad, true)
+		assert.Error(t, err, bad)
+	}
+}
+
+func Test_Frame_Pack(t *testing.T) {
+	var f, err = ParseTextFormat("Q1TEST>Q2TEST,WIDE1-1*:hi", true)
+	require.NoError(t, err)
+
+	var b, packErr = f.Pack()
+	require.NoError(t, packErr)
+
+	assert.Equal(t, []byte{
+		'Q' << 1, '2' << 1, 'T' << 1, 'E' << 1, 'S' << 1, 'T' << 1, 0xe0,
+		'Q' << 1, '1' << 1, 'T' << 1, 'E' << 1, 'S' << 1, 'T' << 1, 0xe0,
+		'W' << 1, 'I' << 1, 'D' << 1, 'E' << 1, '1' << 1, ' ' << 1, 0xe3,
+		0x03, 0xf0, 'h', 'i',
+	}, b)
+
+	var g, parseErr = ParseFrame(b)
+	require.NoError(t, parseErr)
+	assert.Equal(t, f, g)
+
+	// Not a copy of b.
+
+	b[len(b)-1] = 'o'
+	assert.Equal(t, []byte("hi"), g.Info)
+
+	_, err = (&Frame{Addrs: f.Addrs[:1], Control: ControlUI, PID: PIDNoLayer3, Info: nil}).Pack()
+	require.Error(t, err)
+
+	_, err = (&Frame{Addrs: f.Addrs, Control: ControlUI, PID: PIDNoLayer3, Info: make([]byte, MaxInfoLen+1)}).Pack()
+	require.Error(t, err)
+}
+
+func Test_ParseFrame(t *testing.T) {
+	var f, err = ParseTextFormat("Q1TEST>Q2TEST:", true)
+	require.NoError(t, err)
+
+	// U frame SABM, with no protocol ID.
+
+	f.Control = 0x3f
+
+	var b, packErr = f.Pack()
+	require.NoError(t, packErr)
+	assert.Len(t, b, 15)
+
+	var g, parseErr = ParseFrame(b)
+	require.NoError(t, parseErr)
+	assert.False(t, g.HasPID())
+	assert.Equal(t, byte(0x3f), g.Control)
+	assert.Empty(t, g.Info)
+
+	// Too short, no end of address field, or UI frame without protocol ID.
+
+	_, err = ParseFrame(b[:14])
+	require.Error(t, err)
+
+	var noLast = append([]byte{}, b...)
+	noLast[13] &^= ssidLastMask
+	_, err = ParseFrame(noLast)
+	require.Error(t, err)
+
+	b[14] = ControlUI
+	_, err = ParseFrame(b)
+	require.Error(t, err)
+}
+
+func Test_HexDump(t *testing.T) {
+	assert.Empty(t, HexDump(nil))
+
+	assert.Equal(t,
+		"  000:  51 31 54 45 53 54 3e 41 50 52 53 3a 68 65 6c 6c  Q1TEST>APRS:hell\n"+
+			"  010:  6f 0d                                            o.\n",
+		HexDump([]byte("Q1TEST>APRS:hello\r")))
+}
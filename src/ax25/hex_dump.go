@@ -0,0 +1,42 @@
+package ax25
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*
+ * 16 bytes to a line, with the offset first and printable
+ * characters last.
+ *
+ *	  000:  86 a2 40 40 40 40 60 ae 64 8e 9e b4 a6 e1 03 f0  ..@@@@`.d.......
+ */
+
+func HexDump(p []byte) string {
+	var result strings.Builder
+
+	for offset := 0; offset < len(p); offset += 16 {
+		var line = p[offset:min(offset+16, len(p))]
+
+		fmt.Fprintf(&result, "  %03x: ", offset)
+
+		for _, c := range line {
+			fmt.Fprintf(&result, " %02x", c)
+		}
+
+		result.WriteString(strings.Repeat("   ", 16-len(line)))
+		result.WriteString("  ")
+
+		for _, c := range line {
+			if c >= 0x20 && c <= 0x7E {
+				result.WriteByte(c)
+			} else {
+				result.WriteByte('.')
+			}
+		}
+
+		result.WriteString("\n")
+	}
+
+	return result.String()
+}
@@ -468,6 +468,13 @@ type ax25_dlsm_t struct {
 
 var list_head *ax25_dlsm_t
 
+/*
+ * Time for the T1, T3, and TM201 timers.
+ * Tests can replace it with a FakeClock rather than waiting.
+ */
+
+var dl_clock Clock = SystemClock{}
+
 /*
  * Registered callsigns for incoming connections.
  */
@@ -745,7 +752,7 @@ func get_link_handle(addrs [AX25_MAX_ADDRS]string, num_addr int, channel int, cl
 	var p = new(ax25_dlsm_t)
 
 	p.magic1 = MAGIC1
-	p.start_time = time.Now()
+	p.start_time = dl_clock.Now()
 	p.stream_id = next_stream_id
 	next_stream_id++
 	p.modulo = 8
@@ -4630,7 +4637,7 @@ func test_frame(S *ax25_dlsm_t, cr cmdres_t, pf int, info []byte) {
  *------------------------------------------------------------------------------*/
 
 func dl_timer_expiry() {
-	var now = time.Now()
+	var now = dl_clock.Now()
 
 	// Examine all of the data link state machines.
 	// Process only those where timer:
@@ -4698,7 +4705,7 @@ const DW_COLOR_DEBUG_TIMER = DW_COLOR_ERROR
 
 func t1_expiry(S *ax25_dlsm_t) {
 	if s_debug_timers {
-		var now = time.Now()
+		var now = dl_clock.Now()
 
 		text_color_set(DW_COLOR_DEBUG_TIMER)
 		dw_printf("t1_expiry (), [now=%.3f], state=%d, rc=%d\n", now.Sub(S.start_time).Seconds(), S.state, S.rc)
@@ -4856,7 +4863,7 @@ func t1_expiry(S *ax25_dlsm_t) {
 
 func t3_expiry(S *ax25_dlsm_t) {
 	if s_debug_timers {
-		var now = time.Now()
+		var now = dl_clock.Now()
 
 		text_color_set(DW_COLOR_DEBUG_TIMER)
 		dw_printf("t3_expiry (), [now=%.3f]\n", now.Sub(S.start_time).Seconds())
@@ -4893,7 +4900,7 @@ func tm201_expiry(S *ax25_dlsm_t) {
 	var nopid = 0
 
 	if s_debug_timers {
-		var now = time.Now()
+		var now = dl_clock.Now()
 
 		text_color_set(DW_COLOR_DEBUG_TIMER)
 		dw_printf("tm201_expiry (), [now=%.3f], state=%d, rc=%d\n", now.Sub(S.start_time).Seconds(), S.state, S.rc)
@@ -6053,7 +6060,7 @@ func complete_negotiation(S *ax25_dlsm_t, param *xid_param_s) {
 //###################################################################################
 
 func START_T1(S *ax25_dlsm_t) {
-	var now = time.Now()
+	var now = dl_clock.Now()
 
 	if s_debug_timers {
 		var pc, _, from_line, _ = runtime.Caller(1)
@@ -6074,7 +6081,7 @@ func START_T1(S *ax25_dlsm_t) {
 } /* end start_t1 */
 
 func STOP_T1(S *ax25_dlsm_t) {
-	var now = time.Now()
+	var now = dl_clock.Now()
 
 	RESUME_T1(S) // adjust expire time if paused.
 
@@ -6119,7 +6126,7 @@ func PAUSE_T1(S *ax25_dlsm_t) {
 		// Stopped so there is nothing to do.
 	} else if S.t1_paused_at.IsZero() {
 		// Running and not paused.
-		var now = time.Now()
+		var now = dl_clock.Now()
 
 		S.t1_paused_at = now
 
@@ -6144,7 +6151,7 @@ func RESUME_T1(S *ax25_dlsm_t) {
 	} else if S.t1_paused_at.IsZero() {
 		// Running but not paused.
 	} else {
-		var now = time.Now()
+		var now = dl_clock.Now()
 		var paused_for = now.Sub(S.t1_paused_at)
 
 		S.t1_exp = S.t1_exp.Add(paused_for)
@@ -6164,7 +6171,7 @@ func RESUME_T1(S *ax25_dlsm_t) {
 // I don't think there is a need to pause it due to the large time frame.
 
 func START_T3(S *ax25_dlsm_t) {
-	var now = time.Now()
+	var now = dl_clock.Now()
 
 	if s_debug_timers {
 		var pc, _, from_line, _ = runtime.Caller(1)
@@ -6179,7 +6186,7 @@ func START_T3(S *ax25_dlsm_t) {
 
 func STOP_T3(S *ax25_dlsm_t) {
 	if s_debug_timers {
-		var now = time.Now()
+		var now = dl_clock.Now()
 		var pc, _, from_line, _ = runtime.Caller(1)
 		var from_func = runtime.FuncForPC(pc).Name()
 
@@ -6200,7 +6207,7 @@ func STOP_T3(S *ax25_dlsm_t) {
 // Simpler because we don't need to keep track of time remaining when stopped.
 
 func START_TM201(S *ax25_dlsm_t) {
-	var now = time.Now()
+	var now = dl_clock.Now()
 
 	if s_debug_timers {
 		var pc, _, from_line, _ = runtime.Caller(1)
@@ -6219,7 +6226,7 @@ func START_TM201(S *ax25_dlsm_t) {
 } /* end start_tm201 */
 
 func STOP_TM201(S *ax25_dlsm_t) {
-	var now = time.Now()
+	var now = dl_clock.Now()
 
 	if s_debug_timers {
 		var pc, _, from_line, _ = runtime.Caller(1)
@@ -6237,7 +6244,7 @@ func PAUSE_TM201(S *ax25_dlsm_t) {
 		// Stopped so there is nothing to do.
 	} else if S.tm201_paused_at.IsZero() {
 		// Running and not paused.
-		var now = time.Now()
+		var now = dl_clock.Now()
 
 		S.tm201_paused_at = now
 
@@ -6262,7 +6269,7 @@ func RESUME_TM201(S *ax25_dlsm_t) {
 	} else if S.tm201_paused_at.IsZero() {
 		// Running but not paused.
 	} else {
-		var now = time.Now()
+		var now = dl_clock.Now()
 		var paused_for = now.Sub(S.tm201_paused_at)
 
 		S.tm201_exp = S.tm201_exp.Add(paused_for)
@@ -237,8 +237,14 @@ type packet_t struct {
 
 	seq int /* unique sequence number for debugging. */
 
+	trace_id uint64 /* Same for a received frame and all copies made of it.  See trace.go. */
+
+	tx_reason tx_reason_t /* Why we are transmitting it.  See tx_activity.go. */
+
 	release_time time.Time /* When to release from the SATgate mode delay queue. */
 
+	response bool /* Released from the response queue.  See xmit_response.go. */
+
 	nextp *packet_t /* Pointer to next in queue. */
 
 	num_addr int /* Number of addresses in frame. */
@@ -746,10 +752,24 @@ func AX25FromFrame(data []byte, alevel ALevel) *packet_t {
 	this_p.frame_data[flen] = 0
 	this_p.frame_len = flen
 
-	/* Find number of addresses. */
+	/*
+	 * Find number of addresses.
+	 *
+	 * The C version went ahead with 0 addresses when the address part
+	 * made no sense, leaving everything after to cope with it.
+	 * Reject it here instead.  It needs between 2 and 10 addresses,
+	 * the last with the end-of-address bit set.
+	 */
 
 	this_p.num_addr = (-1)
-	ax25_get_num_addr(this_p)
+	if ax25_get_num_addr(this_p) < AX25_MIN_ADDRS {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Frame address field is not valid.  It must be %d to %d addresses, the last with the end-of-address bit set.\n", AX25_MIN_ADDRS, AX25_MAX_ADDRS)
+
+		AX25Delete(this_p)
+
+		return (nil)
+	}
 
 	return (this_p)
 }
@@ -1061,6 +1081,10 @@ func ax25_unwrap_third_party(from_pp *packet_t) *packet_t {
 
 	var result_pp = AX25FromText(string(info[1:]), true)
 
+	if result_pp != nil {
+		result_pp.trace_id = from_pp.trace_id
+	}
+
 	return (result_pp)
 }
 
@@ -2809,6 +2833,12 @@ func ax25_m_m_crc(pp *packet_t) uint16 {
 const MAXSAFE = AX25_MAX_INFO_LEN
 
 func AX25SafePrint(info []byte, ascii_only bool) {
+	dw_printf("%s", ax25_safe_string(info, ascii_only))
+} /* end AX25SafePrint */
+
+/* Same as AX25SafePrint but return the result for writing elsewhere. */
+
+func ax25_safe_string(info []byte, ascii_only bool) string {
 	if len(info) > MAXSAFE {
 		info = info[:MAXSAFE]
 	}
@@ -2833,10 +2863,8 @@ func AX25SafePrint(info []byte, ascii_only bool) {
 		}
 	}
 
-	// TODO1.2: should return string rather printing to remove a race condition.
-
-	dw_printf("%s", safe_str.String())
-} /* end AX25SafePrint */
+	return safe_str.String()
+} /* end ax25_safe_string */
 
 /*------------------------------------------------------------------
  *
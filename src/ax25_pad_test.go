@@ -1,9 +1,12 @@
 package direwolf
 
 import (
+	"strings"
 	"testing"
 
+	"github.com/doismellburning/samoyed/src/ax25"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
 func Test_ax25_unwrap_third_party(t *testing.T) {
@@ -17,6 +20,43 @@ func Test_ax25_unwrap_third_party(t *testing.T) {
 	assert.Equal(t, "D>E,F:", addrs)
 }
 
+/*
+ * The address field has to end, with the end-of-address bit, after 2 to 10
+ * addresses.  Anything else used to give a packet with no addresses, so
+ * everything which looked at it said "Internal error", and ax25_get_h with
+ * the index from ax25_get_heard failed an assertion.
+ */
+
+func Test_AX25FromFrame_too_few_addresses(t *testing.T) {
+	var alevel ALevel
+
+	// Only a destination, APRS, with the end-of-address bit set.
+
+	var frame = []byte{0x82, 0xa0, 0xa4, 0xa6, 0x40, 0x40, 0x61, 0x03, 0xf0}
+	frame = append(frame, "!4237.14N/07120.83W-"...)
+
+	AssertOutputContains(t, func() { assert.Nil(t, AX25FromFrame(frame, alevel)) }, "Frame address field is not valid")
+
+	// No end-of-address bit at all, or not at the end of an address.
+
+	assert.Nil(t, AX25FromFrame(make([]byte, 20), alevel))
+
+	frame[6] = 0x60
+	assert.Nil(t, AX25FromFrame(frame, alevel))
+
+	// Source and destination is enough.
+
+	var pp = AX25FromText("Q1TEST>APRS:!4237.14N/07120.83W-", true)
+	var packed = AX25Pack(pp)
+	AX25Delete(pp)
+
+	pp = AX25FromFrame(packed, alevel)
+	if assert.NotNil(t, pp) {
+		assert.Equal(t, AX25_MIN_ADDRS, ax25_get_num_addr(pp))
+		AX25Delete(pp)
+	}
+}
+
 func Test_ax25_set_info(t *testing.T) {
 	var p = AX25FromText("D>E,F:info", true)
 	var initialInfo = AX25GetInfo(p)
@@ -32,3 +72,37 @@ func Test_ax25_set_info(t *testing.T) {
 	// Make sure we didn't break stuff along the way
 	assert.Equal(t, "D>E,F:", AX25FormatAddrs(p))
 }
+
+/* The ax25 package, for other programs, must agree with what we send and receive. */
+
+func Test_ax25_package_same(t *testing.T) {
+	for _, tc := range []struct {
+		monitor string
+		strict  bool
+	}{
+		{"Q1TEST>Q2TEST:", true},
+		{"Q1TEST-7>APDW17,WIDE1-1,WIDE2-1*,WIDE3:>Hello<0x0d><0x00>", true},
+		{"Q1TEST>APRS,TCPIP*,qAC,T2NUENGLD:!4237.14NS07120.83W#", false},
+		{"AISAT-1>CQ,:hi", true},
+	} {
+		var pp = AX25FromText(tc.monitor, tc.strict)
+		require.NotNil(t, pp, tc.monitor)
+
+		var f, err = ax25.ParseTextFormat(tc.monitor, tc.strict)
+		require.NoError(t, err, tc.monitor)
+
+		var b, packErr = f.Pack()
+		require.NoError(t, packErr)
+		assert.Equal(t, AX25Pack(pp), b, tc.monitor)
+
+		var g, parseErr = ax25.ParseFrame(AX25Pack(pp))
+		require.NoError(t, parseErr)
+		assert.Equal(t, AX25FormatAddrs(pp), strings.SplitAfterN(g.String(), ":", 2)[0])
+		assert.Equal(t, AX25GetInfo(pp), g.Info)
+	}
+
+	assert.Nil(t, AX25FromText("Q1TEST>APRS,,WIDE1-1:!", true))
+
+	var _, err = ax25.ParseTextFormat("Q1TEST>APRS,,WIDE1-1:!", true)
+	assert.Error(t, err)
+}
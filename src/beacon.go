@@ -11,14 +11,26 @@ package direwolf
 import (
 	"fmt"
 	"math"
+	"math/rand"
+	"sync/atomic"
 	"time"
+
+	"github.com/doismellburning/samoyed/src/geo"
 )
 
 type BeaconService struct {
 	modemConfig       *audio_s
 	miscConfig        *misc_config_s
 	igateConfig       *igate_config_s
-	trackerDebugLevel int
+	trackerDebugLevel atomic.Int32
+
+	/* GEOFENCE state, used only by the beacon thread. */
+
+	geofenceInside  []bool        /* nil until the first GPS fix. */
+	geofenceComment string        /* Replaces tracker beacon COMMENT if not "". */
+	sbDefault       smartbeacon_s /* From SMARTBEACONING, when not in a GEOFENCE. */
+
+	clock Clock /* SystemClock, or FakeClock for testing. */
 }
 
 /*-------------------------------------------------------------------
@@ -47,10 +59,17 @@ type BeaconService struct {
  *--------------------------------------------------------------------*/
 
 func NewBeaconService(pmodem *audio_s, pconfig *misc_config_s, pigate *igate_config_s) *BeaconService {
+	return newBeaconServiceClock(pmodem, pconfig, pigate, SystemClock{})
+}
+
+/* Same, with the schedule taken from the given clock rather than the time of day. */
+
+func newBeaconServiceClock(pmodem *audio_s, pconfig *misc_config_s, pigate *igate_config_s, clock Clock) *BeaconService {
 	var bs = &BeaconService{ //nolint:exhaustruct
 		modemConfig: pmodem,
 		miscConfig:  pconfig,
 		igateConfig: pigate,
+		clock:       clock,
 	}
 
 	/*
@@ -184,7 +203,7 @@ func NewBeaconService(pmodem *audio_s, pconfig *misc_config_s, pigate *igate_con
 	 * Calculate first time for each beacon from the 'slot' or 'delay' value.
 	 */
 
-	var now = time.Now()
+	var now = bs.clock.Now()
 
 	for j := 0; j < bs.miscConfig.num_beacons; j++ {
 		var bp = &(bs.miscConfig.beacon[j])
@@ -239,27 +258,25 @@ func NewBeaconService(pmodem *audio_s, pconfig *misc_config_s, pigate *igate_con
 				text_color_set(DW_COLOR_ERROR)
 				dw_printf("Config file, line %d: Time between slotted beacons has been adjusted to %d seconds.\n", bp.lineno, bp.every)
 			}
-			/*
-			 * Determine when next slot time will arrive.
-			 */
-			bp.delay = bp.slot - (now.Minute()*60 + now.Second())
-			for bp.delay > bp.every {
-				bp.delay -= bp.every
-			}
+		}
 
-			for bp.delay < 5 {
-				bp.delay += bp.every
-			}
+		/*
+		 * With AFTER, the clock doesn't start until the GPS or IGate is ready.
+		 */
+		if bp.after != BEACON_AFTER_START {
+			bp.waiting = true
+		} else {
+			bp.next = beacon_first_time(bp, now)
 		}
 
-		bs.miscConfig.beacon[j].next = now.Add(time.Duration(bs.miscConfig.beacon[j].delay) * time.Second)
+		bp.jitter_off = beacon_jitter(bp)
 	}
 
 	return bs
 } /* end NewBeaconService */
 
 func (bs *BeaconService) SetDebug(level int) {
-	bs.trackerDebugLevel = level
+	bs.trackerDebugLevel.Store(int32(level))
 }
 
 /*-------------------------------------------------------------------
@@ -287,10 +304,134 @@ func (bs *BeaconService) Start() {
 	}
 }
 
+/*-------------------------------------------------------------------
+ *
+ * Name:        SendNow
+ *
+ * Purpose:     Send all beacons now, out of schedule, e.g. by remote control.
+ *		The schedule isn't changed.
+ *
+ *--------------------------------------------------------------------*/
+
+func (bs *BeaconService) SendNow() {
+	bs.SendMatching(func(*beacon_s) bool { return true })
+}
+
+/* Send only some of them, e.g. positions in answer to a query.  Returns how many. */
+
+func (bs *BeaconService) SendMatching(match func(bp *beacon_s) bool) int {
+	var gpsinfo dwgps_info_t
+
+	dwgps_read(&gpsinfo)
+
+	var n = 0
+
+	for j := range bs.miscConfig.num_beacons {
+		if bs.miscConfig.beacon[j].btype != BEACON_IGNORE && match(&bs.miscConfig.beacon[j]) {
+			bs.send(j, &gpsinfo)
+			n++
+		}
+	}
+
+	return n
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        beacon_first_time
+ *
+ * Purpose:     When to send the first beacon, from the 'slot' or 'delay' value.
+ *
+ * Inputs:	bp	- Beacon.
+ *
+ *		now	- Starting up, or when the AFTER condition was met.
+ *
+ *--------------------------------------------------------------------*/
+
+func beacon_first_time(bp *beacon_s, now time.Time) time.Time {
+	if bp.slot != G_UNKNOWN {
+		/*
+		 * Determine when next slot time will arrive.
+		 */
+		bp.delay = bp.slot - (now.Minute()*60 + now.Second())
+		for bp.delay > bp.every {
+			bp.delay -= bp.every
+		}
+
+		for bp.delay < 5 {
+			bp.delay += bp.every
+		}
+	}
+
+	return now.Add(time.Duration(bp.delay) * time.Second)
+}
+
+/* Random offset, up to JITTER either way, for the next transmission. */
+
+func beacon_jitter(bp *beacon_s) time.Duration {
+	if bp.jitter <= 0 {
+		return 0
+	}
+
+	return time.Duration(rand.Intn(2*bp.jitter+1)-bp.jitter) * time.Second //nolint:gosec // Not for security
+}
+
+/* Time to send it, after any jitter. */
+
+func (bp *beacon_s) due() time.Time {
+	return bp.next.Add(bp.jitter_off)
+}
+
+/* Has the AFTER condition been met? */
+
+func beacon_after_ready(after beacon_after_e) bool {
+	switch after {
+	case BEACON_AFTER_GPS:
+		var gpsinfo dwgps_info_t
+
+		return dwgps_read(&gpsinfo) >= DWFIX_2D
+	case BEACON_AFTER_IGATE:
+		return !igate_get_connected_since().IsZero()
+	default:
+		return true
+	}
+}
+
+/* How often to check while waiting for AFTER. */
+
+const BEACON_AFTER_POLL = 5 * time.Second
+
 func IS_GOOD(x int) bool {
 	return (3600/(x))*(x) == 3600
 }
 
+/*-------------------------------------------------------------------
+ *
+ * Name:        tbeacon_fix_ok
+ *
+ * Purpose:     Is the GPS location good enough for a tracker beacon?
+ *
+ * Inputs:	bp	- Tracker beacon, for MAXHDOP.
+ *
+ *		gpsinfo	- Current GPS information.
+ *
+ * Returns:	false for no fix, or HDOP above MAXHDOP.
+ *		An unknown HDOP, e.g. from GPSFIXED, is good enough.
+ *
+ *--------------------------------------------------------------------*/
+
+func tbeacon_fix_ok(bp *beacon_s, gpsinfo *dwgps_info_t) bool {
+	if gpsinfo.fix < DWFIX_2D {
+		return false
+	}
+
+	if bp.max_hdop > 0 && gpsinfo.hdop != G_UNKNOWN && gpsinfo.hdop > bp.max_hdop {
+		return false
+	}
+
+	return true
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        thread
@@ -324,9 +465,30 @@ func (bs *BeaconService) thread() {
 		}
 	}
 
-	var now = time.Now()
+	var now = bs.clock.Now()
 	var sb_prev_time time.Time /* Time of most recent transmission. */
 	var sb_prev_course float64 /* Most recent course reported. */
+	var sb_prev_lat float64    /* Location of most recent transmission. */
+	var sb_prev_lon float64
+
+	/* Distance from most recent transmission, for corner pegging. */
+	var sb_moved = func(gpsinfo *dwgps_info_t) float64 {
+		if sb_prev_time.IsZero() {
+			return G_UNKNOWN
+		}
+
+		return 1000 * geo.DistanceKM(sb_prev_lat, sb_prev_lon, gpsinfo.dlat, gpsinfo.dlon)
+	}
+
+	/*
+	 * GEOFENCE areas only apply to tracker beacons.
+	 */
+	var fences []*geofence_s
+	if number_of_tbeacons > 0 {
+		fences = bs.miscConfig.geofence
+	}
+
+	bs.sbDefault = bs.miscConfig.smartbeacon()
 
 	for {
 		/*
@@ -336,11 +498,19 @@ func (bs *BeaconService) thread() {
 		var earliest = now.Add(time.Hour)
 
 		for j := range bs.miscConfig.num_beacons {
-			if bs.miscConfig.beacon[j].btype != BEACON_IGNORE {
-				var t = bs.miscConfig.beacon[j].next
-				if t.Before(earliest) {
-					earliest = t
-				}
+			var bp = &(bs.miscConfig.beacon[j])
+
+			if bp.btype == BEACON_IGNORE {
+				continue
+			}
+
+			var t = bp.due()
+			if bp.waiting {
+				t = now.Add(BEACON_AFTER_POLL)
+			}
+
+			if t.Before(earliest) {
+				earliest = t
 			}
 		}
 
@@ -356,14 +526,21 @@ func (bs *BeaconService) thread() {
 			}
 		}
 
+		if len(fences) > 0 {
+			var t = now.Add(GEOFENCE_CHECK_INTERVAL)
+			if t.Before(earliest) {
+				earliest = t
+			}
+		}
+
 		if earliest.After(now) {
-			SLEEP_SEC(int(earliest.Sub(now).Seconds()))
+			bs.clock.Sleep(earliest.Sub(now))
 		}
 
 		/*
 		 * Woke up.  See what needs to be done.
 		 */
-		now = time.Now()
+		now = bs.clock.Now()
 
 		/*
 		 * Get information from GPS if being used.
@@ -376,7 +553,7 @@ func (bs *BeaconService) thread() {
 			var fix = dwgps_read(&gpsinfo)
 			var my_speed_mph = DW_KNOTS_TO_MPH(float64(gpsinfo.speed_knots))
 
-			if bs.trackerDebugLevel >= 1 {
+			if bs.trackerDebugLevel.Load() >= 1 {
 				var hms = now.Format("15:04:05")
 
 				text_color_set(DW_COLOR_DEBUG)
@@ -394,13 +571,17 @@ func (bs *BeaconService) thread() {
 			/* Don't complain here for no fix. */
 			/* Possibly at the point where about to transmit. */
 
+			if len(fences) > 0 && fix >= DWFIX_2D {
+				bs.geofenceCheck(fences, &gpsinfo)
+			}
+
 			/*
 			 * Run SmartBeaconing calculation if configured and GPS data available.
 			 */
 			if bs.miscConfig.sb_configured && fix >= DWFIX_2D {
 				var tnext = bs.sbCalculateNextTime(now,
 					DW_KNOTS_TO_MPH(float64(gpsinfo.speed_knots)), float64(gpsinfo.track),
-					sb_prev_time, sb_prev_course)
+					sb_prev_time, sb_prev_course, sb_moved(&gpsinfo))
 
 				for j := range bs.miscConfig.num_beacons {
 					if bs.miscConfig.beacon[j].btype == BEACON_TRACKER {
@@ -424,7 +605,16 @@ func (bs *BeaconService) thread() {
 				continue
 			}
 
-			if !bp.next.After(now) {
+			if bp.waiting {
+				if beacon_after_ready(bp.after) {
+					bp.waiting = false
+					bp.next = beacon_first_time(bp, now)
+				}
+
+				continue
+			}
+
+			if !bp.due().After(now) {
 				/* Send the beacon. */
 				bs.send(j, &gpsinfo)
 
@@ -432,7 +622,7 @@ func (bs *BeaconService) thread() {
 				/* Easy for fixed interval.  SmartBeaconing takes more effort. */
 
 				if bp.btype == BEACON_TRACKER {
-					if gpsinfo.fix < DWFIX_2D {
+					if !tbeacon_fix_ok(bp, &gpsinfo) {
 						/* Fix not available so beacon was not sent. */
 						if bs.miscConfig.sb_configured {
 							/* Try again in a couple seconds. */
@@ -447,10 +637,12 @@ func (bs *BeaconService) thread() {
 						/* Compute next time if not turning. */
 						sb_prev_time = now
 						sb_prev_course = float64(gpsinfo.track)
+						sb_prev_lat = gpsinfo.dlat
+						sb_prev_lon = gpsinfo.dlon
 
 						bp.next = bs.sbCalculateNextTime(now,
 							float64(DW_KNOTS_TO_MPH(float64(gpsinfo.speed_knots))), float64(gpsinfo.track),
-							sb_prev_time, sb_prev_course)
+							sb_prev_time, sb_prev_course, 0)
 					} else {
 						/* Tracker beacon, fixed spacing. */
 						bp.next = bp.next.Add(time.Duration(bp.every) * time.Second)
@@ -478,11 +670,71 @@ func (bs *BeaconService) thread() {
 						dw_printf("\nSystem clock appears to have jumped forward.  Beacon schedule updated.\n\n")
 					}
 				}
+
+				bp.jitter_off = beacon_jitter(bp)
 			} /* if time to send it */
 		} /* for each configured beacon */
 	} /* do forever */
 } /* end thread */
 
+/*-------------------------------------------------------------------
+ *
+ * Name:        geofenceCheck
+ *
+ * Purpose:     See if we have entered or left any GEOFENCE area.
+ *
+ * Inputs:	fences	- Areas from the configuration.
+ *
+ *		gpsinfo	- Current location, with a fix.
+ *
+ * Description:	Send a tracker beacon for any ENTER or EXIT, then
+ *		set the COMMENT and SmartBeaconing for where we are now.
+ *
+ *--------------------------------------------------------------------*/
+
+func (bs *BeaconService) geofenceCheck(fences []*geofence_s, gpsinfo *dwgps_info_t) {
+	var inside, entered, left = geofence_update(fences, bs.geofenceInside, gpsinfo.dlat, gpsinfo.dlon)
+	bs.geofenceInside = inside
+
+	var tracker = -1
+	for j := range bs.miscConfig.num_beacons {
+		if bs.miscConfig.beacon[j].btype == BEACON_TRACKER {
+			tracker = j
+
+			break
+		}
+	}
+
+	for _, g := range left {
+		text_color_set(DW_COLOR_INFO)
+		dw_printf("Left GEOFENCE %s.\n", g.name)
+
+		if g.exit_comment != "" && tracker >= 0 {
+			bs.geofenceComment = g.exit_comment
+			bs.send(tracker, gpsinfo)
+		}
+	}
+
+	for _, g := range entered {
+		text_color_set(DW_COLOR_INFO)
+		dw_printf("Entered GEOFENCE %s.\n", g.name)
+
+		if g.enter_comment != "" && tracker >= 0 {
+			bs.geofenceComment = g.enter_comment
+			bs.send(tracker, gpsinfo)
+		}
+	}
+
+	bs.geofenceComment = geofence_comment(fences, inside)
+
+	var sb = geofence_smartbeacon(fences, inside)
+	if sb == nil {
+		sb = &bs.sbDefault
+	}
+
+	bs.miscConfig.set_smartbeacon(*sb)
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        sbCalculateNextTime
@@ -501,6 +753,9 @@ func (bs *BeaconService) thread() {
  *
  *		last_xmit_course	- Direction included in most recent transmission.
  *
+ *		moved_m			- Meters travelled since most recent transmission.
+ *					  G_UNKNOWN if not known.
+ *
  * Global In:	bs.miscConfig.
  *			sb_configured	TRUE if SmartBeaconing is configured.
  *			sb_fast_speed	MPH
@@ -510,6 +765,8 @@ func (bs *BeaconService) thread() {
  *			sb_turn_time	seconds
  *			sb_turn_angle	degrees
  *			sb_turn_slope	degrees * MPH
+ *			sb_turn_decay	seconds, 0 for none
+ *			sb_min_turn_dist meters, 0 for none
  *
  * Returns:	Time of next transmission.
  *		Could vary from now to sb_slow_rate in the future.
@@ -517,6 +774,19 @@ func (bs *BeaconService) thread() {
  * Caution:	The algorithm is defined in MPH units.    GPS uses knots.
  *		The caller must be careful about using the proper conversions.
  *
+ * Description:	Two additions to the usual algorithm for corner pegging:
+ *
+ *		sb_turn_decay	- The turn threshold gets smaller, down to
+ *				  half, over this many seconds after the
+ *				  last beacon.  A long gentle curve, which
+ *				  never changes direction quickly enough,
+ *				  eventually gets a beacon.
+ *
+ *		sb_min_turn_dist - Don't corner peg until we have moved at
+ *				  least this far.  Stops a burst of beacons
+ *				  from GPS course wandering in slow traffic
+ *				  or while maneuvering in a parking lot.
+ *
  *--------------------------------------------------------------------*/
 
 /* Difference between two angles. */
@@ -531,7 +801,7 @@ func heading_change(a, b float64) float64 {
 	}
 }
 
-func (bs *BeaconService) sbCalculateNextTime(now time.Time, current_speed_mph float64, current_course float64, last_xmit_time time.Time, last_xmit_course float64) time.Time {
+func (bs *BeaconService) sbCalculateNextTime(now time.Time, current_speed_mph float64, current_course float64, last_xmit_time time.Time, last_xmit_course float64, moved_m float64) time.Time {
 	var beacon_rate int
 
 	/*
@@ -549,7 +819,7 @@ func (bs *BeaconService) sbCalculateNextTime(now time.Time, current_speed_mph fl
 		beacon_rate = int(math.Round(float64(bs.miscConfig.sb_fast_rate*bs.miscConfig.sb_fast_speed) / current_speed_mph))
 	}
 
-	if bs.trackerDebugLevel >= 2 {
+	if bs.trackerDebugLevel.Load() >= 2 {
 		text_color_set(DW_COLOR_DEBUG)
 		dw_printf("SmartBeaconing: Beacon Rate = %d seconds for %.1f MPH\n", beacon_rate, current_speed_mph)
 	}
@@ -564,8 +834,21 @@ func (bs *BeaconService) sbCalculateNextTime(now time.Time, current_speed_mph fl
 		var change = heading_change(current_course, last_xmit_course)
 		var turn_threshold = float64(bs.miscConfig.sb_turn_angle) + float64(bs.miscConfig.sb_turn_slope)/current_speed_mph
 
-		if change > turn_threshold && !now.Before(last_xmit_time.Add(time.Duration(bs.miscConfig.sb_turn_time)*time.Second)) {
-			if bs.trackerDebugLevel >= 2 {
+		if bs.miscConfig.sb_turn_decay > 0 {
+			var fraction = min(1, now.Sub(last_xmit_time).Seconds()/float64(bs.miscConfig.sb_turn_decay))
+			turn_threshold *= 1 - fraction/2
+		}
+
+		var far_enough = bs.miscConfig.sb_min_turn_dist == 0 ||
+			(moved_m != G_UNKNOWN && moved_m >= float64(bs.miscConfig.sb_min_turn_dist))
+
+		if bs.trackerDebugLevel.Load() >= 2 {
+			text_color_set(DW_COLOR_DEBUG)
+			dw_printf("SmartBeaconing: Heading change %.0f, turn threshold %.0f\n", change, turn_threshold)
+		}
+
+		if change > turn_threshold && far_enough && !now.Before(last_xmit_time.Add(time.Duration(bs.miscConfig.sb_turn_time)*time.Second)) {
+			if bs.trackerDebugLevel.Load() >= 2 {
 				text_color_set(DW_COLOR_DEBUG)
 				dw_printf("SmartBeaconing: Send now for heading change of %.0f\n", change)
 			}
@@ -574,6 +857,11 @@ func (bs *BeaconService) sbCalculateNextTime(now time.Time, current_speed_mph fl
 		}
 	}
 
+	if bs.trackerDebugLevel.Load() >= 1 {
+		text_color_set(DW_COLOR_DEBUG)
+		dw_printf("SmartBeaconing: Next beacon at %s, in %.0f seconds\n", next_time.Format("15:04:05"), max(0, next_time.Sub(now).Seconds()))
+	}
+
 	return (next_time)
 } /* end sbCalculateNextTime */
 
@@ -668,45 +956,95 @@ func (bs *BeaconService) send(j int, gpsinfo *dwgps_info_t) {
 
 	// TODO: test & document.
 
-	var super_comment = ""
-	if bp.comment != "" {
-		super_comment = bp.comment
-	}
+	var var_comment = ""
 
 	if bp.commentcmd != "" {
 		/* Run given command to get variable part of comment. */
-		var var_comment, k = dw_run_cmd(bp.commentcmd, 2)
+		var out, k = dw_run_cmd(bp.commentcmd, 2)
 		if k == nil {
-			super_comment += string(var_comment)
+			var_comment = string(out)
 		} else {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("xBEACON, config file line %d, COMMENTCMD failure: %s.\n", bp.lineno, k)
 		}
 	}
 
+	/*
+	 * With RIGINFO=1, the frequency the radio is actually on, and its mode.
+	 */
+
+	var freq = bp.freq
+	var mode = ""
+
+	if bp.riginfo {
+		var mhz, m = rig_freq_mode(bp.sendto_chan)
+		if mhz != 0 {
+			freq = mhz
+			mode = m
+		}
+	}
+
+	/*
+	 * The comment can be different for radio and IGate.
+	 * Any GEOFENCE comment replaces both.
+	 */
+
+	var comment_for = func(igate bool) string {
+		var c = bp.comment
+
+		if igate && bp.is_comment != "" {
+			c = bp.is_comment
+		} else if !igate && bp.rf_comment != "" {
+			c = bp.rf_comment
+		}
+
+		if bp.btype == BEACON_TRACKER && bs.geofenceComment != "" {
+			c = bs.geofenceComment
+		}
+
+		c += var_comment
+
+		if mode != "" {
+			c = mode + IfThenElse(c != "", " "+c, "")
+		}
+
+		return c
+	}
+
 	/*
 	 * Add the info part depending on beacon type.
+	 * It's a function of the comment so we can send it two ways.
 	 */
+	var encode func(comment string) string
+
 	switch bp.btype {
 	case BEACON_POSITION:
-		beacon_text += EncodePosition(bp.messaging, bp.compress,
-			bp.lat, bp.lon, bp.ambiguity,
-			int(math.Round(DW_METERS_TO_FEET(float64(bp.alt_m)))),
-			bp.symtab, bp.symbol,
-			int(bp.power), int(bp.height), int(bp.gain), bp.dir,
-			G_UNKNOWN, G_UNKNOWN, /* course, speed */
-			bp.freq, bp.tone, bp.offset,
-			super_comment)
+		encode = func(comment string) string {
+			return EncodePosition(bp.messaging, bp.compress,
+				bp.lat, bp.lon, bp.ambiguity,
+				int(math.Round(DW_METERS_TO_FEET(float64(bp.alt_m)))),
+				bp.symtab, bp.symbol,
+				int(bp.power), int(bp.height), int(bp.gain), bp.dir,
+				G_UNKNOWN, G_UNKNOWN, /* course, speed */
+				freq, bp.tone, bp.offset,
+				comment)
+		}
 
 	case BEACON_OBJECT:
-		beacon_text += encode_object(bp.objname, bp.compress, time.Now(), bp.lat, bp.lon, bp.ambiguity,
-			bp.symtab, bp.symbol,
-			int(bp.power), int(bp.height), int(bp.gain), bp.dir,
-			G_UNKNOWN, G_UNKNOWN, /* course, speed */
-			bp.freq, bp.tone, bp.offset, super_comment)
+		var now = bs.clock.Now()
+
+		encode = func(comment string) string {
+			return encode_object(bp.objname, bp.compress, now, bp.lat, bp.lon, bp.ambiguity,
+				bp.symtab, bp.symbol,
+				int(bp.power), int(bp.height), int(bp.gain), bp.dir,
+				G_UNKNOWN, G_UNKNOWN, /* course, speed */
+				freq, bp.tone, bp.offset, comment)
+		}
 
 	case BEACON_TRACKER:
-		if gpsinfo.fix >= DWFIX_2D {
+		if tbeacon_fix_ok(bp, gpsinfo) {
+			bp.nofix_sent = false
+
 			/* Transmit altitude only if user asked for it. */
 			/* A positive altitude in the config file enables */
 			/* transmission of altitude from GPS. */
@@ -721,20 +1059,24 @@ func (bs *BeaconService) send(j int, gpsinfo *dwgps_info_t) {
 				coarse = int(math.Round(float64(gpsinfo.track)))
 			}
 
-			beacon_text += EncodePosition(bp.messaging, bp.compress,
-				float64(gpsinfo.dlat), float64(gpsinfo.dlon), bp.ambiguity, my_alt_ft,
-				bp.symtab, bp.symbol,
-				int(bp.power), int(bp.height), int(bp.gain), bp.dir,
-				coarse, int(math.Round(float64(gpsinfo.speed_knots))),
-				float64(bp.freq), float64(bp.tone), float64(bp.offset),
-				super_comment)
+			encode = func(comment string) string {
+				return EncodePosition(bp.messaging, bp.compress,
+					float64(gpsinfo.dlat), float64(gpsinfo.dlon), bp.ambiguity, my_alt_ft,
+					bp.symtab, bp.symbol,
+					int(bp.power), int(bp.height), int(bp.gain), bp.dir,
+					coarse, int(math.Round(float64(gpsinfo.speed_knots))),
+					float64(freq), float64(bp.tone), float64(bp.offset),
+					comment)
+			}
+
+			ownTrack.Beacon(bs.clock.Now(), gpsinfo)
 
 			/* Write to log file for testing. */
 			/* The idea is to run log2gpx and map the result rather than */
 			/* actually transmitting and relying on someone else to receive */
 			/* the signals. */
 
-			if bs.trackerDebugLevel >= 3 {
+			if bs.trackerDebugLevel.Load() >= 3 {
 				var A decode_aprs_t
 				A.g_freq = G_UNKNOWN
 				A.g_offset = G_UNKNOWN
@@ -755,31 +1097,50 @@ func (bs *BeaconService) send(j int, gpsinfo *dwgps_info_t) {
 				packetLogger.Write(999, &A, nil, alevel, 0)
 			}
 		} else {
-			return /* No fix.  Skip this time. */
+			if bs.trackerDebugLevel.Load() >= 1 && gpsinfo.fix >= DWFIX_2D {
+				text_color_set(DW_COLOR_DEBUG)
+				dw_printf("Tracker beacon skipped, HDOP %.1f is above MAXHDOP %.1f.\n", gpsinfo.hdop, bp.max_hdop)
+			}
+
+			/* No fix.  Skip this time. */
+			/* Send a status instead, only once until we have a fix again, */
+			/* so the map doesn't show a bogus location. */
+
+			if bp.nofix == "" || bp.nofix_sent {
+				return
+			}
+
+			bp.nofix_sent = true
+
+			encode = func(string) string { return ">" + bp.nofix }
 		}
 
 	case BEACON_CUSTOM:
+		var info string
+
 		if bp.custom_info != "" {
 			/* Fixed handcrafted text. */
-			beacon_text += bp.custom_info
+			info = bp.custom_info
 		} else if bp.custom_infocmd != "" {
 			/* Run given command to obtain the info part for packet. */
 			var info_part, k = dw_run_cmd(bp.custom_infocmd, 2)
 			if k == nil {
-				beacon_text += string(info_part)
+				info = string(info_part)
 			} else {
 				text_color_set(DW_COLOR_ERROR)
 				dw_printf("CBEACON, config file line %d, INFOCMD failure: %s.\n", bp.lineno, k)
 
-				beacon_text = "" // abort!
+				return // abort!
 			}
 		} else {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Internal error. custom_info is null.\n")
 
-			beacon_text = "" // abort!
+			return // abort!
 		}
 
+		encode = func(string) string { return info }
+
 	case BEACON_IGATE:
 		{
 			var last_minutes = 30
@@ -793,24 +1154,32 @@ func (bs *BeaconService) send(j int, gpsinfo *dwgps_info_t) {
 				igate_get_upl_cnt(),
 				igate_get_dnl_cnt())
 
-			beacon_text += stuff
+			encode = func(string) string { return stuff }
 		}
 	default:
+		return
 	} /* switch beacon type. */
 
 	/*
-	 * Parse monitor format into form for transmission.
+	 * Send to desired destination(s).
 	 */
-	if beacon_text == "" {
-		return
+	if bp.sendto_type == SENDTO_IGATE || bp.also_igate {
+		bs.sendText(bp, SENDTO_IGATE, beacon_text+encode(comment_for(true)))
 	}
 
+	if bp.sendto_type != SENDTO_IGATE {
+		bs.sendText(bp, bp.sendto_type, beacon_text+encode(comment_for(false)))
+	}
+} /* end send */
+
+/* Parse monitor format into form for transmission and send it one way. */
+
+func (bs *BeaconService) sendText(bp *beacon_s, sendto sendto_type_e, beacon_text string) {
 	var strict = true // Strict packet checking because they will go over air.
 	var pp = AX25FromText(beacon_text, strict)
 
 	if pp != nil {
-		/* Send to desired destination. */
-		switch bp.sendto_type {
+		switch sendto {
 		case SENDTO_IGATE:
 			text_color_set(DW_COLOR_XMIT)
 			dw_printf("[ig] %s\n", beacon_text)
@@ -822,6 +1191,7 @@ func (bs *BeaconService) send(j int, gpsinfo *dwgps_info_t) {
 			var alevel ALevel
 			dlq_rec_frame(bp.sendto_chan, 0, 0, pp, alevel, fec_type_none, 0, "")
 		default:
+			pp.tx_reason = TX_REASON_BEACON
 			tq_append(bp.sendto_chan, TQ_PRIO_1_LO, pp)
 		}
 	} else {
@@ -829,4 +1199,4 @@ func (bs *BeaconService) send(j int, gpsinfo *dwgps_info_t) {
 		dw_printf("Config file: Failed to parse packet constructed from line %d.\n", bp.lineno)
 		dw_printf("%s\n", beacon_text)
 	}
-} /* end send */
+}
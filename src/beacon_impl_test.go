@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"pgregory.net/rapid"
 )
 
@@ -70,6 +71,29 @@ func Test_heading_change(t *testing.T) {
 	}
 }
 
+// tbeacon_fix_ok tests
+
+func Test_tbeacon_fix_ok(t *testing.T) {
+	var bp = new(beacon_s)
+	var gpsinfo = new(dwgps_info_t)
+	gpsinfo.hdop = 4
+
+	gpsinfo.fix = DWFIX_NO_FIX
+	assert.False(t, tbeacon_fix_ok(bp, gpsinfo))
+
+	gpsinfo.fix = DWFIX_2D
+	assert.True(t, tbeacon_fix_ok(bp, gpsinfo), "no MAXHDOP")
+
+	bp.max_hdop = 2.5
+	assert.False(t, tbeacon_fix_ok(bp, gpsinfo), "HDOP above MAXHDOP")
+
+	gpsinfo.hdop = 1.2
+	assert.True(t, tbeacon_fix_ok(bp, gpsinfo))
+
+	gpsinfo.hdop = G_UNKNOWN
+	assert.True(t, tbeacon_fix_ok(bp, gpsinfo), "unknown HDOP")
+}
+
 // sbCalculateNextTime tests
 // Fast and slow speed exact-rate cases are covered by property tests below.
 
@@ -79,7 +103,7 @@ func Test_sbCalculateNextTime_mid_speed_proportional(t *testing.T) {
 	// At 30 MPH (between 5 and 60), rate = (30 * 60) / 30 = 60 seconds
 	var lastXmit = now.Add(-120 * time.Second)
 
-	var next = bs.sbCalculateNextTime(now, 30, 90, lastXmit, 90)
+	var next = bs.sbCalculateNextTime(now, 30, 90, lastXmit, 90, G_UNKNOWN)
 
 	var expected = lastXmit.Add(60 * time.Second)
 	assert.Equal(t, expected, next)
@@ -90,7 +114,7 @@ func Test_sbCalculateNextTime_unknown_speed(t *testing.T) {
 	var now = time.Now()
 	var lastXmit = now.Add(-2000 * time.Second)
 
-	var next = bs.sbCalculateNextTime(now, G_UNKNOWN, G_UNKNOWN, lastXmit, G_UNKNOWN)
+	var next = bs.sbCalculateNextTime(now, G_UNKNOWN, G_UNKNOWN, lastXmit, G_UNKNOWN, G_UNKNOWN)
 
 	// Unknown speed: rate = (fast_rate + slow_rate) / 2 = (30 + 1800) / 2 = 915
 	var expected = lastXmit.Add(915 * time.Second)
@@ -104,7 +128,7 @@ func Test_sbCalculateNextTime_corner_pegging(t *testing.T) {
 	var lastXmit = now.Add(-20 * time.Second)
 
 	// Large heading change: 90 degrees > turn_threshold (30 + 255/30 = 38.5)
-	var next = bs.sbCalculateNextTime(now, 30, 180, lastXmit, 90)
+	var next = bs.sbCalculateNextTime(now, 30, 180, lastXmit, 90, G_UNKNOWN)
 
 	assert.Equal(t, now, next, "corner pegging should trigger immediate transmission")
 }
@@ -115,7 +139,7 @@ func Test_sbCalculateNextTime_corner_pegging_suppressed_too_soon(t *testing.T) {
 	// Last transmitted only 5s ago (< sb_turn_time of 15s), so no corner pegging
 	var lastXmit = now.Add(-5 * time.Second)
 
-	var next = bs.sbCalculateNextTime(now, 30, 180, lastXmit, 90)
+	var next = bs.sbCalculateNextTime(now, 30, 180, lastXmit, 90, G_UNKNOWN)
 
 	// Should NOT be now — should be the normal rate-based next time
 	assert.NotEqual(t, now, next)
@@ -128,12 +152,42 @@ func Test_sbCalculateNextTime_no_corner_peg_below_threshold(t *testing.T) {
 	var lastXmit = now.Add(-20 * time.Second)
 
 	// Heading change of 5 degrees is below threshold (~38.5 at 30 MPH)
-	var next = bs.sbCalculateNextTime(now, 30, 95, lastXmit, 90)
+	var next = bs.sbCalculateNextTime(now, 30, 95, lastXmit, 90, G_UNKNOWN)
 
 	// Should be rate-based, not now
 	assert.NotEqual(t, now, next)
 }
 
+func Test_sbCalculateNextTime_turn_decay(t *testing.T) {
+	var bs = &BeaconService{miscConfig: makeSBConfig()} //nolint:exhaustruct
+	bs.miscConfig.sb_turn_decay = 60
+	var now = time.Now()
+
+	// 30 degrees is below the threshold of 38.5 at 30 MPH, right after the turn time.
+	var next = bs.sbCalculateNextTime(now, 30, 120, now.Add(-15*time.Second), 90, G_UNKNOWN)
+	assert.NotEqual(t, now, next)
+
+	// After 60 seconds the threshold is half, about 19 degrees.
+	next = bs.sbCalculateNextTime(now, 30, 120, now.Add(-60*time.Second), 90, G_UNKNOWN)
+	assert.Equal(t, now, next)
+}
+
+func Test_sbCalculateNextTime_min_turn_distance(t *testing.T) {
+	var bs = &BeaconService{miscConfig: makeSBConfig()} //nolint:exhaustruct
+	bs.miscConfig.sb_min_turn_dist = 50
+	var now = time.Now()
+	var lastXmit = now.Add(-20 * time.Second)
+
+	var next = bs.sbCalculateNextTime(now, 30, 180, lastXmit, 90, 20)
+	assert.NotEqual(t, now, next, "not far enough")
+
+	next = bs.sbCalculateNextTime(now, 30, 180, lastXmit, 90, G_UNKNOWN)
+	assert.NotEqual(t, now, next, "distance unknown")
+
+	next = bs.sbCalculateNextTime(now, 30, 180, lastXmit, 90, 80)
+	assert.Equal(t, now, next)
+}
+
 // NewBeaconService validation tests
 
 func Test_NewBeaconService_obeacon_without_objname_is_ignored(t *testing.T) {
@@ -330,6 +384,113 @@ func Test_NewBeaconService_slotted_beacon_adjusts_interval_if_not_IS_GOOD(t *tes
 		"slot beacon interval should have been adjusted to a valid divisor of 3600")
 }
 
+func Test_NewBeaconService_after_waits(t *testing.T) {
+	var modem = makeBeaconModemConfig()
+	var cfg = new(misc_config_s)
+	var igate = new(igate_config_s)
+
+	cfg.num_beacons = 1
+	cfg.beacon[0].btype = BEACON_POSITION
+	cfg.beacon[0].delay = 120
+	cfg.beacon[0].slot = G_UNKNOWN
+	cfg.beacon[0].every = 600
+	cfg.beacon[0].lat = 42.0
+	cfg.beacon[0].lon = -71.0
+	cfg.beacon[0].after = BEACON_AFTER_IGATE
+
+	var bs = NewBeaconService(modem, cfg, igate)
+
+	assert.True(t, bs.miscConfig.beacon[0].waiting)
+	assert.True(t, bs.miscConfig.beacon[0].next.IsZero(), "no schedule until logged in")
+	assert.False(t, beacon_after_ready(BEACON_AFTER_IGATE))
+	assert.True(t, beacon_after_ready(BEACON_AFTER_START))
+}
+
+func Test_BeaconService_thread_fake_clock(t *testing.T) {
+	var modem = makeBeaconModemConfig()
+	var cfg = new(misc_config_s)
+	var igate = new(igate_config_s)
+
+	cfg.num_beacons = 1
+	cfg.beacon[0].btype = BEACON_CUSTOM
+	cfg.beacon[0].sendto_type = SENDTO_RECV
+	cfg.beacon[0].sendto_chan = 0
+	cfg.beacon[0].delay = 60
+	cfg.beacon[0].slot = G_UNKNOWN
+	cfg.beacon[0].every = 600
+	cfg.beacon[0].custom_info = ">On the air"
+
+	var start = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+	var clock = NewFakeClock(start)
+
+	var bs = newBeaconServiceClock(modem, cfg, igate, clock)
+	assert.Equal(t, start.Add(time.Minute), bs.miscConfig.beacon[0].next)
+
+	dlq_init()
+	go bs.thread()
+
+	// Each beacon is "received" on channel 0 through the data link queue.
+	var sent = func() int {
+		var n = 0
+
+		for item := dlq_remove(); item != nil; item = dlq_remove() {
+			assert.Equal(t, ">On the air", string(AX25GetInfo(item.pp)))
+			n++
+		}
+
+		return n
+	}
+
+	var asleep = func() bool { return clock.Sleepers() == 1 }
+
+	require.Eventually(t, asleep, time.Second, time.Millisecond)
+	assert.Zero(t, sent())
+
+	clock.Advance(time.Minute)
+	require.Eventually(t, asleep, time.Second, time.Millisecond)
+	assert.Equal(t, 1, sent())
+	assert.Equal(t, start.Add(11*time.Minute), bs.miscConfig.beacon[0].next)
+
+	clock.Advance(9 * time.Minute)
+	require.Eventually(t, asleep, time.Second, time.Millisecond)
+	assert.Zero(t, sent(), "not due yet")
+
+	clock.Advance(time.Hour)
+	require.Eventually(t, asleep, time.Second, time.Millisecond)
+	assert.Equal(t, 1, sent(), "schedule catches up with a jump rather than sending them all")
+}
+
+func Test_beacon_first_time_slot(t *testing.T) {
+	var bp = &beacon_s{slot: 30, every: 600} //nolint:exhaustruct
+	var now = time.Date(2026, 1, 1, 12, 3, 0, 0, time.UTC)
+
+	assert.Equal(t, time.Date(2026, 1, 1, 12, 10, 30, 0, time.UTC), beacon_first_time(bp, now))
+
+	bp = &beacon_s{slot: G_UNKNOWN, delay: 60} //nolint:exhaustruct
+	assert.Equal(t, now.Add(time.Minute), beacon_first_time(bp, now))
+}
+
+func Test_beacon_jitter_within_limit(t *testing.T) {
+	var bp = &beacon_s{jitter: 30} //nolint:exhaustruct
+	var seen = map[time.Duration]bool{}
+
+	for range 1000 {
+		var off = beacon_jitter(bp)
+		assert.LessOrEqual(t, off, 30*time.Second)
+		assert.GreaterOrEqual(t, off, -30*time.Second)
+		assert.Zero(t, off%time.Second)
+		seen[off] = true
+	}
+
+	assert.Greater(t, len(seen), 10, "should be spread out")
+
+	bp.next = time.Now()
+	bp.jitter_off = -5 * time.Second
+	assert.Equal(t, bp.next.Add(-5*time.Second), bp.due())
+
+	assert.Zero(t, beacon_jitter(&beacon_s{})) //nolint:exhaustruct
+}
+
 // Start tests
 
 func Test_BeaconService_Start_no_goroutine_if_all_ignored(t *testing.T) {
@@ -348,7 +509,7 @@ func Test_BeaconService_Start_no_goroutine_if_all_ignored(t *testing.T) {
 func Test_BeaconService_SetDebug(t *testing.T) {
 	var bs = &BeaconService{} //nolint:exhaustruct
 	bs.SetDebug(2)
-	assert.Equal(t, 2, bs.trackerDebugLevel)
+	assert.Equal(t, int32(2), bs.trackerDebugLevel.Load())
 }
 
 // Property-based tests
@@ -405,7 +566,7 @@ func Test_sbCalculateNextTime_fast_speed_rate_exact(t *testing.T) {
 		var lastXmit = time.Now().Add(-time.Duration(rapid.IntRange(cfg.sb_turn_time, 3600).Draw(t, "elapsed")) * time.Second)
 		var now = lastXmit.Add(time.Duration(rapid.IntRange(cfg.sb_turn_time, 3600).Draw(t, "sinceXmit")) * time.Second)
 
-		var next = bs.sbCalculateNextTime(now, speed, course, lastXmit, course)
+		var next = bs.sbCalculateNextTime(now, speed, course, lastXmit, course, G_UNKNOWN)
 		var expected = lastXmit.Add(time.Duration(cfg.sb_fast_rate) * time.Second)
 
 		assert.Equal(t, expected, next)
@@ -426,7 +587,7 @@ func Test_sbCalculateNextTime_slow_speed_rate_exact(t *testing.T) {
 		var lastXmit = time.Now().Add(-time.Duration(rapid.IntRange(cfg.sb_turn_time, 3600).Draw(t, "elapsed")) * time.Second)
 		var now = lastXmit.Add(time.Duration(rapid.IntRange(cfg.sb_turn_time, 3600).Draw(t, "sinceXmit")) * time.Second)
 
-		var next = bs.sbCalculateNextTime(now, speed, course, lastXmit, course)
+		var next = bs.sbCalculateNextTime(now, speed, course, lastXmit, course, G_UNKNOWN)
 		var expected = lastXmit.Add(time.Duration(cfg.sb_slow_rate) * time.Second)
 
 		assert.Equal(t, expected, next)
@@ -445,7 +606,7 @@ func Test_sbCalculateNextTime_result_within_rate_bounds(t *testing.T) {
 		var lastXmit = time.Now().Add(-time.Duration(rapid.IntRange(cfg.sb_turn_time, 3600).Draw(t, "elapsed")) * time.Second)
 		var now = lastXmit.Add(time.Duration(rapid.IntRange(cfg.sb_turn_time, 3600).Draw(t, "sinceXmit")) * time.Second)
 
-		var next = bs.sbCalculateNextTime(now, speed, course, lastXmit, course)
+		var next = bs.sbCalculateNextTime(now, speed, course, lastXmit, course, G_UNKNOWN)
 		var lo = lastXmit.Add(time.Duration(cfg.sb_fast_rate) * time.Second)
 		var hi = lastXmit.Add(time.Duration(cfg.sb_slow_rate) * time.Second)
 
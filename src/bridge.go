@@ -0,0 +1,362 @@
+//nolint:gochecknoglobals
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Share radio channels with other instances over a TCP
+ *		or TLS trunk.
+ *
+ * Description:	Two stations at different sites each have radios.
+ *		With a bridge, each sees the other's radio channels
+ *		as virtual channels of its own, so one can digipeat,
+ *		IGate, or serve applications for both.
+ *
+ *		   Site A				Site B
+ *
+ *		BRIDGEPORT 8010			BCHANNEL 10 site-a 8010 0
+ *
+ *		Everything A receives on radio channel 0 appears at B
+ *		on channel 10.  Anything B transmits on channel 10 goes
+ *		out of A's radio on channel 0.  For B's radios to show
+ *		up at A, do the same the other way around.
+ *
+ *		The trunk carries KISS frames, with the channel in the
+ *		upper nybble as usual.  The listening side sends what
+ *		it hears on all of its radio channels, and the BCHANNEL
+ *		end (see nettnc.go) picks out the one it wants.
+ *
+ *		Only radio channels are sent and transmitted, never
+ *		virtual channels, so bridges can't form a loop.
+ *
+ *		Whoever connects can transmit with our radios, so both
+ *		ends must have a certificate, and the BRIDGEPORT end
+ *		accepts only those signed by the CA it is given.  Plain
+ *		TCP, open to anyone who can reach the port, must be
+ *		asked for with INSECURE.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"bufio"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+var s_bridge_audio_config_p *audio_s
+
+var s_bridge_mu sync.Mutex
+var s_bridge_peer [MAX_NET_CLIENTS]net.Conn
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        bridge_init
+ *
+ * Purpose:     Listen for other instances if BRIDGEPORT is configured.
+ *
+ *--------------------------------------------------------------------*/
+
+func bridge_init(pa *audio_s, mc *misc_config_s) {
+	s_bridge_audio_config_p = pa
+
+	if mc.bridge_port == 0 {
+		return
+	}
+
+	Assert(mc.bridge_cert != "" || mc.bridge_insecure)
+
+	var listener, err = bridge_listen(mc.bridge_port, mc.bridge_cert, mc.bridge_key, mc.bridge_client_ca)
+	if err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Bridge: %s\n", err)
+		os.Exit(1)
+	}
+
+	if mc.bridge_insecure {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Bridge port %d is INSECURE.  Anyone who can connect to it can transmit with your radios.\n", mc.bridge_port)
+	}
+
+	text_color_set(DW_COLOR_INFO)
+
+	if mc.bridge_cert != "" {
+		dw_printf("Ready to accept bridge connections, with TLS, on port %d ...\n", mc.bridge_port)
+	} else {
+		dw_printf("Ready to accept bridge connections on port %d ...\n", mc.bridge_port)
+	}
+
+	go bridge_accept_thread(listener)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        bridge_listen
+ *
+ * Inputs:	port	- TCP port.  0 for any free one.
+ *		cert	- Our certificate file, or "" for plain TCP.
+ *		key	- Its key file.
+ *		ca	- File with the certificate of whoever signed the
+ *			  other instances' certificates.  Those without
+ *			  one are refused.
+ *
+ *--------------------------------------------------------------------*/
+
+func bridge_listen(port int, cert string, key string, ca string) (net.Listener, error) {
+	var address = net.JoinHostPort("", strconv.Itoa(port))
+
+	if cert == "" {
+		return net.Listen("tcp", address)
+	}
+
+	var pair, err = tls.LoadX509KeyPair(cert, key)
+	if err != nil {
+		return nil, fmt.Errorf("can't load certificate for TLS: %w", err)
+	}
+
+	var pool, poolErr = bridge_ca_pool(ca)
+	if poolErr != nil {
+		return nil, poolErr
+	}
+
+	return tls.Listen("tcp", address, &tls.Config{ //nolint:exhaustruct
+		Certificates: []tls.Certificate{pair},
+		ClientAuth:   tls.RequireAndVerifyClientCert,
+		ClientCAs:    pool,
+		MinVersion:   tls.VersionTLS12,
+	})
+}
+
+func bridge_ca_pool(ca string) (*x509.CertPool, error) {
+	var pem, err = os.ReadFile(ca)
+	if err != nil {
+		return nil, fmt.Errorf("can't read CA certificate for TLS: %w", err)
+	}
+
+	var pool = x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, errors.New("no certificates found in " + ca)
+	}
+
+	return pool, nil
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        bridge_tls_client_config
+ *
+ * Purpose:     TLS settings for the BCHANNEL end.
+ *
+ * Inputs:	host	- Name to check in the certificate.
+ *		cert	- Our certificate file, for the other end to check.
+ *		key	- Its key file.
+ *		ca	- File with the certificate of whoever signed the
+ *			  other end's certificate, or "" to use the system ones.
+ *
+ *--------------------------------------------------------------------*/
+
+func bridge_tls_client_config(host string, cert string, key string, ca string) (*tls.Config, error) {
+	var pair, err = tls.LoadX509KeyPair(cert, key)
+	if err != nil {
+		return nil, fmt.Errorf("can't load certificate for TLS: %w", err)
+	}
+
+	var config = &tls.Config{ //nolint:exhaustruct
+		Certificates: []tls.Certificate{pair},
+		ServerName:   host,
+		MinVersion:   tls.VersionTLS12,
+	}
+
+	if ca != "" {
+		config.RootCAs, err = bridge_ca_pool(ca)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return config, nil
+}
+
+func bridge_accept_thread(listener net.Listener) {
+	for {
+		var conn, err = listener.Accept()
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Bridge: %s\n", err)
+
+			return
+		}
+
+		go bridge_peer_start(conn)
+	}
+}
+
+/* Long enough for a slow link, short enough that a stalled client doesn't hang around. */
+
+const BRIDGE_HANDSHAKE_TIMEOUT = 30 * time.Second
+
+func bridge_peer_start(conn net.Conn) {
+	// Check the other instance's certificate before anything is sent or transmitted.
+
+	if tlsConn, ok := conn.(*tls.Conn); ok {
+		_ = conn.SetDeadline(time.Now().Add(BRIDGE_HANDSHAKE_TIMEOUT))
+
+		var err = tlsConn.Handshake()
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Bridge: Refusing %s: %s\n", conn.RemoteAddr(), err)
+			conn.Close()
+
+			return
+		}
+
+		_ = conn.SetDeadline(time.Time{})
+	}
+
+	s_bridge_mu.Lock()
+
+	var peer = -1
+
+	for p := range MAX_NET_CLIENTS {
+		if s_bridge_peer[p] == nil {
+			peer = p
+			s_bridge_peer[p] = conn
+
+			break
+		}
+	}
+
+	s_bridge_mu.Unlock()
+
+	if peer < 0 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Bridge: Too many connections.  Refusing %s.\n", conn.RemoteAddr())
+		conn.Close()
+
+		return
+	}
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("Bridge connection %d from %s.\n", peer, conn.RemoteAddr())
+
+	bridge_peer_thread(peer, conn)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        bridge_peer_thread
+ *
+ * Purpose:     Transmit frames sent by the other instance.
+ *
+ *--------------------------------------------------------------------*/
+
+func bridge_peer_thread(peer int, conn net.Conn) {
+	var reader = bufio.NewReader(conn)
+
+	for {
+		var msg, err = reader.ReadBytes(FEND)
+		if err != nil {
+			break
+		}
+
+		if len(msg) < 2 || len(msg) > MAX_KISS_LEN {
+			continue // Leading FEND, or junk.
+		}
+
+		var fb = frame_buf_get()
+		fb.data = kiss_unwrap_to(fb.data, msg)
+		bridge_process_msg(peer, fb.data)
+		frame_buf_put(fb)
+	}
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("Bridge connection %d has gone away.\n", peer)
+
+	bridge_close(peer, conn)
+}
+
+func bridge_process_msg(peer int, kiss_msg []byte) {
+	if len(kiss_msg) < 1 || kiss_msg[0]&0xf != KISS_CMD_DATA_FRAME {
+		return // Ignore anything else.
+	}
+
+	var channel = int(kiss_msg[0] >> 4)
+
+	if s_bridge_audio_config_p.chan_medium[channel] != MEDIUM_RADIO {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Bridge connection %d: Can't transmit on channel %d.  It's not a radio channel.\n", peer, channel)
+
+		return
+	}
+
+	var alevel ALevel
+
+	var pp = AX25FromFrame(kiss_msg[1:], alevel)
+	if pp == nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Bridge connection %d: Invalid frame for channel %d.\n", peer, channel)
+
+		return
+	}
+
+	tq_append(channel, TQ_PRIO_1_LO, pp)
+}
+
+func bridge_close(peer int, conn net.Conn) {
+	s_bridge_mu.Lock()
+	defer s_bridge_mu.Unlock()
+
+	if s_bridge_peer[peer] == conn {
+		s_bridge_peer[peer] = nil
+	}
+
+	conn.Close()
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        bridge_send_rec_packet
+ *
+ * Purpose:     Send a frame received over the radio to the other
+ *		instances connected to our BRIDGEPORT.
+ *
+ * Inputs:	channel	- Where it was received.  Only radio channels are sent.
+ *		fbuf	- Frame, without the FCS.
+ *
+ *--------------------------------------------------------------------*/
+
+func bridge_send_rec_packet(channel int, fbuf []byte) {
+	if s_bridge_audio_config_p == nil || s_bridge_audio_config_p.chan_medium[channel] != MEDIUM_RADIO {
+		return
+	}
+
+	var fb = frame_buf_get()
+	fb.data = append(fb.data, byte(channel<<4)|KISS_CMD_DATA_FRAME)
+	fb.data = append(fb.data, fbuf...)
+
+	var kiss_buff = KissEncapsulate(fb.data)
+
+	frame_buf_put(fb)
+
+	s_bridge_mu.Lock()
+	var peers = s_bridge_peer
+	s_bridge_mu.Unlock()
+
+	for peer, conn := range peers {
+		if conn == nil {
+			continue
+		}
+
+		var _, err = conn.Write(kiss_buff)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Bridge connection %d: %s.  Closing connection.\n", peer, err)
+			bridge_close(peer, conn)
+		}
+	}
+}
@@ -0,0 +1,365 @@
+package direwolf
+
+import (
+	"bufio"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_config_init_bridge(t *testing.T) {
+	var audio, misc = configFromString(t, "BRIDGEPORT 8010 site.crt site.key clients.crt\nBCHANNEL 10 site-a 8010 1 TLS b.crt b.key ca.crt\nBCHANNEL 11 site-b 8011 0\n")
+
+	assert.Equal(t, 8010, misc.bridge_port)
+	assert.Equal(t, "site.crt", misc.bridge_cert)
+	assert.Equal(t, "site.key", misc.bridge_key)
+	assert.Equal(t, "clients.crt", misc.bridge_client_ca)
+	assert.False(t, misc.bridge_insecure)
+
+	assert.Equal(t, MEDIUM_NETTNC, audio.chan_medium[10])
+	assert.True(t, audio.nettnc_bridge[10])
+	assert.Equal(t, "site-a", audio.nettnc_addr[10])
+	assert.Equal(t, 8010, audio.nettnc_port[10])
+	assert.Equal(t, 1, audio.nettnc_remote_chan[10])
+	assert.True(t, audio.nettnc_tls[10])
+	assert.Equal(t, "b.crt", audio.nettnc_tls_cert[10])
+	assert.Equal(t, "b.key", audio.nettnc_tls_key[10])
+	assert.Equal(t, "ca.crt", audio.nettnc_tls_ca[10])
+
+	assert.True(t, audio.nettnc_bridge[11])
+	assert.False(t, audio.nettnc_tls[11])
+
+	audio, _ = configFromString(t, "BCHANNEL 10 site-a 8010 1 SSL\n")
+	assert.Equal(t, MEDIUM_NONE, audio.chan_medium[10])
+
+	audio, _ = configFromString(t, "BCHANNEL 10 site-a 8010 1 TLS ca.crt\n")
+	assert.Equal(t, MEDIUM_NONE, audio.chan_medium[10], "no certificate of our own")
+
+	// Nobody can connect without a certificate unless asked for.
+
+	_, misc = configFromString(t, "BRIDGEPORT 8010\n")
+	assert.Zero(t, misc.bridge_port)
+
+	_, misc = configFromString(t, "BRIDGEPORT 8010 site.crt site.key\n")
+	assert.Zero(t, misc.bridge_port)
+
+	_, misc = configFromString(t, "BRIDGEPORT 8010 insecure\n")
+	assert.Equal(t, 8010, misc.bridge_port)
+	assert.True(t, misc.bridge_insecure)
+	assert.Empty(t, misc.bridge_cert)
+}
+
+/* Our BRIDGEPORT, on any free port, and a connection to it from "another instance". */
+
+func bridgeTestPeer(t *testing.T) net.Conn {
+	t.Helper()
+
+	var pa = new(audio_s)
+	pa.chan_medium[0] = MEDIUM_RADIO
+	pa.chan_medium[10] = MEDIUM_NETTNC
+
+	tq_init(pa)
+
+	s_bridge_audio_config_p = pa
+
+	var listener, err = bridge_listen(0, "", "", "")
+	require.NoError(t, err)
+
+	go bridge_accept_thread(listener)
+
+	var conn, dialErr = net.Dial("tcp", listener.Addr().String())
+	require.NoError(t, dialErr)
+
+	t.Cleanup(func() {
+		conn.Close()
+		listener.Close()
+
+		s_bridge_mu.Lock()
+		s_bridge_peer = [MAX_NET_CLIENTS]net.Conn{}
+		s_bridge_mu.Unlock()
+	})
+
+	require.Eventually(t, func() bool {
+		s_bridge_mu.Lock()
+		defer s_bridge_mu.Unlock()
+
+		for _, c := range s_bridge_peer {
+			if c != nil {
+				return true
+			}
+		}
+
+		return false
+	}, time.Second, time.Millisecond)
+
+	return conn
+}
+
+func Test_bridge_send_rec_packet(t *testing.T) {
+	var conn = bridgeTestPeer(t)
+
+	var pp = AX25FromText("Q1TEST>Q2TEST:on channel 10", true)
+	require.NotNil(t, pp)
+
+	bridge_send_rec_packet(10, AX25Pack(pp)) // Virtual channel, not sent.
+	AX25Delete(pp)
+
+	pp = AX25FromText("Q1TEST>Q2TEST:on channel 0", true)
+	require.NotNil(t, pp)
+
+	var frame = AX25Pack(pp)
+	AX25Delete(pp)
+
+	bridge_send_rec_packet(0, frame)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+	var reader = bufio.NewReader(conn)
+
+	var _, err = reader.ReadBytes(FEND) // Leading FEND.
+	require.NoError(t, err)
+
+	var msg, readErr = reader.ReadBytes(FEND)
+	require.NoError(t, readErr)
+
+	assert.Equal(t, append([]byte{0x00}, frame...), kiss_unwrap(msg))
+}
+
+func Test_bridge_transmit(t *testing.T) {
+	var conn = bridgeTestPeer(t)
+
+	var pp = AX25FromText("Q2TEST>Q1TEST:from the other site", true)
+	require.NotNil(t, pp)
+
+	var frame = AX25Pack(pp)
+	AX25Delete(pp)
+
+	// Channel 10 isn't a radio so only the channel 0 one is transmitted.
+
+	var _, err = conn.Write(KissEncapsulate(append([]byte{0xa0}, frame...)))
+	require.NoError(t, err)
+
+	_, err = conn.Write(KissEncapsulate(append([]byte{0x00}, frame...)))
+	require.NoError(t, err)
+
+	var sent *packet_t
+
+	require.Eventually(t, func() bool {
+		sent = tq_remove(0, TQ_PRIO_1_LO)
+
+		return sent != nil
+	}, time.Second, time.Millisecond)
+
+	assert.Equal(t, "from the other site", string(AX25GetInfo(sent)))
+	AX25Delete(sent)
+}
+
+func Test_bridge_channel_picks_remote_channel(t *testing.T) {
+	dlq_init()
+
+	s_tnc_bridge[10] = true
+	s_tnc_remote_chan[10] = 1
+
+	t.Cleanup(func() { s_tnc_bridge[10] = false })
+
+	var pp = AX25FromText("Q1TEST>Q2TEST:heard", true)
+	require.NotNil(t, pp)
+
+	var frame = AX25Pack(pp)
+	AX25Delete(pp)
+
+	var kf KISSFrame
+
+	for _, kiss_cmd := range []byte{0x00, 0x10, 0x16} {
+		for _, b := range KissEncapsulate(append([]byte{kiss_cmd}, frame...)) {
+			my_kiss_rec_byte(&kf, b, 0, 10)
+		}
+	}
+
+	// Only the data frame from remote channel 1.
+
+	var item = dlq_remove()
+	require.NotNil(t, item)
+	assert.Equal(t, 10, item._chan)
+	assert.Equal(t, "heard", string(AX25GetInfo(item.pp)))
+
+	assert.Nil(t, dlq_remove())
+}
+
+/* A CA for the TLS tests, with its certificate in a file. */
+
+type bridgeTestCA struct {
+	cert *x509.Certificate
+	key  *ecdsa.PrivateKey
+	file string
+}
+
+func bridgeTestWritePEM(t *testing.T, name string, kind string, der []byte) string {
+	t.Helper()
+
+	var path = filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: kind, Bytes: der}), 0o600))
+
+	return path
+}
+
+func newBridgeTestCA(t *testing.T, name string) *bridgeTestCA {
+	t.Helper()
+
+	var key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var template = &x509.Certificate{ //nolint:exhaustruct
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: name}, //nolint:exhaustruct
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+
+	var der, certErr = x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, certErr)
+
+	var cert, parseErr = x509.ParseCertificate(der)
+	require.NoError(t, parseErr)
+
+	return &bridgeTestCA{cert: cert, key: key, file: bridgeTestWritePEM(t, name+".crt", "CERTIFICATE", der)}
+}
+
+/* Certificate and key files for localhost, good for either end. */
+
+func (ca *bridgeTestCA) issue(t *testing.T, name string) (string, string) {
+	t.Helper()
+
+	var key, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	var template = &x509.Certificate{ //nolint:exhaustruct
+		SerialNumber: big.NewInt(2),
+		Subject:      pkix.Name{CommonName: name}, //nolint:exhaustruct
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+	}
+
+	var der, certErr = x509.CreateCertificate(rand.Reader, template, ca.cert, &key.PublicKey, ca.key)
+	require.NoError(t, certErr)
+
+	var keyDER, keyErr = x509.MarshalECPrivateKey(key)
+	require.NoError(t, keyErr)
+
+	return bridgeTestWritePEM(t, name+".crt", "CERTIFICATE", der), bridgeTestWritePEM(t, name+".key", "EC PRIVATE KEY", keyDER)
+}
+
+func Test_bridge_tls_client_certificate(t *testing.T) {
+	var ca = newBridgeTestCA(t, "bridge-ca")
+	var rogue = newBridgeTestCA(t, "rogue-ca")
+
+	var serverCert, serverKey = ca.issue(t, "site-a")
+
+	var listener, err = bridge_listen(0, serverCert, serverKey, ca.file)
+	require.NoError(t, err)
+
+	go bridge_accept_thread(listener)
+
+	t.Cleanup(func() {
+		listener.Close()
+
+		s_bridge_mu.Lock()
+		for _, c := range s_bridge_peer {
+			if c != nil {
+				c.Close()
+			}
+		}
+		s_bridge_peer = [MAX_NET_CLIENTS]net.Conn{}
+		s_bridge_mu.Unlock()
+	})
+
+	var address = net.JoinHostPort("localhost", strconv.Itoa(listener.Addr().(*net.TCPAddr).Port))
+
+	var peers = func() int {
+		s_bridge_mu.Lock()
+		defer s_bridge_mu.Unlock()
+
+		var n = 0
+
+		for _, c := range s_bridge_peer {
+			if c != nil {
+				n++
+			}
+		}
+
+		return n
+	}
+
+	// The server finds out about a bad client certificate after the client
+	// thinks the handshake is done, so look for it on the first read.
+
+	var refused = func(config *tls.Config) {
+		t.Helper()
+
+		var conn, dialErr = tls.Dial("tcp", address, config)
+		if dialErr != nil {
+			return
+		}
+
+		defer conn.Close()
+
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+
+		var _, readErr = conn.Read(make([]byte, 1))
+		require.Error(t, readErr)
+
+		var netErr net.Error
+		assert.False(t, errors.As(readErr, &netErr) && netErr.Timeout(), "should be refused, not left waiting")
+	}
+
+	var pool = x509.NewCertPool()
+	pool.AddCert(ca.cert)
+
+	t.Run("no certificate", func(t *testing.T) {
+		refused(&tls.Config{RootCAs: pool, ServerName: "localhost", MinVersion: tls.VersionTLS12}) //nolint:exhaustruct
+	})
+
+	t.Run("signed by someone else", func(t *testing.T) {
+		var cert, key = rogue.issue(t, "site-x")
+
+		var config, configErr = bridge_tls_client_config("localhost", cert, key, ca.file)
+		require.NoError(t, configErr)
+
+		refused(config)
+	})
+
+	assert.Zero(t, peers())
+
+	var cert, key = ca.issue(t, "site-b")
+
+	var config, configErr = bridge_tls_client_config("localhost", cert, key, ca.file)
+	require.NoError(t, configErr)
+
+	var conn, dialErr = tls.Dial("tcp", address, config)
+	require.NoError(t, dialErr)
+
+	t.Cleanup(func() { conn.Close() })
+
+	require.Eventually(t, func() bool { return peers() == 1 }, 5*time.Second, time.Millisecond)
+}
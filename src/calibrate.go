@@ -0,0 +1,243 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Calibration for "direwolf -x".
+ *
+ * Description:	Transmit tones for adjusting the transmit audio level
+ *		and deviation:
+ *
+ *			a = Alternating mark/space tones.
+ *			m = Steady mark tone (e.g. 1200Hz).
+ *			s = Steady space tone (e.g. 2200Hz).
+ *			p = Silence (Set PTT only).
+ *
+ *		A long transmission, such as when going back and forth
+ *		to the radio with a deviation meter, needs to be
+ *		identified so the callsign is sent in Morse code every
+ *		so often.
+ *
+ *		There is also a receive counterpart:
+ *
+ *			r = Show the received audio level.
+ *
+ *		This is for use while another radio sends the tones.
+ *		The mark and space amplitudes are shown too, because a
+ *		large difference between them points to pre-emphasis or
+ *		de-emphasis problems.
+ *
+ *		Or let an assistant take you through it:
+ *
+ *			c = Calibration assistant.  See calibrate_assist.go.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"time"
+)
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        calibrate_parse
+ *
+ * Purpose:     Parse the -x option value.
+ *
+ * Inputs:	s	- Type letter with optional channel number before
+ *			  or after, e.g. "m", "a1", "1".
+ *
+ * Returns:	Type, channel, and error.  Type is 'a' if only a
+ *		channel is given.
+ *
+ *--------------------------------------------------------------------*/
+
+func calibrate_parse(s string) (rune, int, error) {
+	var ctype = ' '
+	var channel = 0
+
+	for _, p := range s {
+		switch p {
+		case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+			channel = channel*10 + int(p-'0')
+
+			if ctype == ' ' {
+				ctype = 'a'
+			}
+		case 'a', 'm', 's', 'p', 'r', 'c':
+			ctype = p
+		default:
+			return ctype, channel, fmt.Errorf("invalid option '%c' for -x.  Must be a, m, s, p, r, or c", p)
+		}
+	}
+
+	if channel < 0 || channel >= MAX_RADIO_CHANS {
+		return ctype, channel, fmt.Errorf("invalid channel %d for -x", channel)
+	}
+
+	return ctype, channel, nil
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        calibrate
+ *
+ * Purpose:     Do the calibration then exit.
+ *
+ * Inputs:	pa		- Audio configuration.
+ *
+ *		option		- Value of -x option.
+ *
+ *		duration	- How long to run.
+ *
+ *		idEvery		- How often to send CW ID while transmitting.
+ *				  0 for never.
+ *
+ *		monitor		- Channel listening to the transmitter for
+ *				  the calibration assistant, or -1 for none.
+ *
+ * Description:	Audio and tone generation must already be initialized.
+ *		For receive, the demodulators are started here.
+ *
+ *--------------------------------------------------------------------*/
+
+func calibrate(pa *audio_s, option string, duration time.Duration, idEvery time.Duration, monitor int) {
+	var ctype, channel, err = calibrate_parse(option)
+	if err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		fmt.Printf("%s\n", err)
+		text_color_set(DW_COLOR_INFO)
+		os.Exit(1)
+	}
+
+	if pa.chan_medium[channel] != MEDIUM_RADIO {
+		text_color_set(DW_COLOR_ERROR)
+		fmt.Printf("\nChannel %d is not configured as a radio channel.\n", channel)
+		text_color_set(DW_COLOR_INFO)
+		os.Exit(1)
+	}
+
+	if ctype == 'r' {
+		rec_calibrate(pa, channel, duration)
+		os.Exit(0)
+	}
+
+	if pa.achan[channel].mark_freq == 0 || pa.achan[channel].space_freq == 0 {
+		text_color_set(DW_COLOR_ERROR)
+		fmt.Printf("\nMark/Space frequencies not defined for channel %d. Cannot calibrate using this modem type.\n", channel)
+		text_color_set(DW_COLOR_INFO)
+		os.Exit(1)
+	}
+
+	var mycall = pa.mycall[channel]
+
+	if ctype == 'c' {
+		if IsNoCall(mycall) {
+			text_color_set(DW_COLOR_ERROR)
+			fmt.Printf("\nMYCALL must be set for channel %d to send test frames.\n", channel)
+			text_color_set(DW_COLOR_INFO)
+			os.Exit(1)
+		}
+
+		cal_assist(pa, channel, monitor)
+		os.Exit(0)
+	}
+
+	if idEvery > 0 && duration > idEvery && IsNoCall(mycall) {
+		text_color_set(DW_COLOR_ERROR)
+		fmt.Printf("\nMYCALL must be set for channel %d to send CW ID during calibration.\n", channel)
+		text_color_set(DW_COLOR_INFO)
+		os.Exit(1)
+	}
+
+	xmit_calibrate(pa, channel, ctype, duration, idEvery)
+	os.Exit(0)
+}
+
+func xmit_calibrate(pa *audio_s, channel int, ctype rune, duration time.Duration, idEvery time.Duration) {
+	var achan = &pa.achan[channel]
+
+	text_color_set(DW_COLOR_INFO)
+
+	switch ctype {
+	case 'a': // Alternating tones: -x a
+		fmt.Printf("\nSending alternating mark/space calibration tones (%d/%dHz) on channel %d.\n", achan.mark_freq, achan.space_freq, channel)
+	case 'm': // "Mark" tone: -x m
+		fmt.Printf("\nSending mark calibration tone (%dHz) on channel %d.\n", achan.mark_freq, channel)
+	case 's': // "Space" tone: -x s
+		fmt.Printf("\nSending space calibration tone (%dHz) on channel %d.\n", achan.space_freq, channel)
+	case 'p': // Silence - set PTT only: -x p
+		fmt.Printf("\nSending silence (Set PTT only) on channel %d.\n", channel)
+	}
+
+	fmt.Printf("Stopping after %s.  Press control-C to terminate sooner.\n", duration)
+
+	if idEvery > 0 && duration > idEvery {
+		fmt.Printf("Sending CW ID %s every %s.\n", pa.mycall[channel], idEvery)
+	}
+
+	ptt_set(OCTYPE_PTT, channel, 1)
+
+	/* Send one second at a time so we can keep track of when to ID. */
+
+	var sinceID time.Duration
+
+	for elapsed := time.Duration(0); elapsed < duration; elapsed += time.Second {
+		if idEvery > 0 && sinceID >= idEvery {
+			morse_send(channel, pa.mycall[channel], MORSE_DEFAULT_WPM, 250, 250)
+			sinceID = 0
+		}
+
+		if ctype == 'p' {
+			SLEEP_MS(1000)
+		} else {
+			for n := range achan.baud {
+				switch ctype {
+				case 'a':
+					tone_gen_put_bit(channel, n&1)
+				case 'm':
+					tone_gen_put_bit(channel, 1)
+				case 's':
+					tone_gen_put_bit(channel, 0)
+				}
+			}
+		}
+
+		sinceID += time.Second
+	}
+
+	ptt_set(OCTYPE_PTT, channel, 0)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        rec_calibrate
+ *
+ * Purpose:     Show the received audio level once a second.
+ *
+ *--------------------------------------------------------------------*/
+
+func rec_calibrate(pa *audio_s, channel int, duration time.Duration) {
+	text_color_set(DW_COLOR_INFO)
+	fmt.Printf("\nShowing received audio level on channel %d while another station sends calibration tones.\n", channel)
+	fmt.Printf("Adjust for a level of about 50.  Stopping after %s.  Press control-C to terminate sooner.\n\n", duration)
+
+	recv_init(pa)
+
+	for elapsed := time.Duration(0); elapsed < duration; elapsed += time.Second {
+		SLEEP_MS(1000)
+
+		fmt.Printf("%s\n", rec_calibrate_text(channel, demod_get_audio_level(channel, 0)))
+	}
+}
+
+func rec_calibrate_text(channel int, alevel ALevel) string {
+	var s = fmt.Sprintf("Channel %d audio level = %s", channel, ax25_alevel_to_text(alevel))
+
+	if alevel.mark > 0 && alevel.space > 0 {
+		s += fmt.Sprintf("    mark/space %+.1f dB", 20*math.Log10(float64(alevel.mark)/float64(alevel.space)))
+	}
+
+	return s
+}
@@ -0,0 +1,434 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Calibration assistant for "direwolf -x c".
+ *
+ * Description:	Walks through the part of setting up a radio that
+ *		most often goes wrong, and recommends settings:
+ *
+ *		1. Transmit audio level.  Alternating tones are sent
+ *		   and the level is measured.  The user adjusts the
+ *		   sound card mixer or radio, and it is tried again,
+ *		   until it is close enough.
+ *
+ *		2. TXDELAY.  Test frames are sent with shorter and
+ *		   shorter TXDELAY until they stop getting through.
+ *		   A little more than the shortest that worked is
+ *		   recommended.
+ *
+ *		With --calibration-monitor, another channel listens to
+ *		the transmitter, e.g. a second radio on the other side
+ *		of a stereo sound card, and does the measuring.  Its
+ *		receive level should first be set, with -x r, to about
+ *		50 for a station known to be good.
+ *
+ *		Otherwise the user is asked: what a deviation meter
+ *		showed, and how many test frames another station
+ *		decoded.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const CAL_TARGET_LEVEL = 50 /* Received audio level, as with -x r. */
+
+const CAL_TARGET_DEVIATION = 3.0 /* kHz, for 1200 baud AFSK on FM. */
+
+const CAL_CLOSE_DB = 1.0 /* Close enough to the target. */
+
+const CAL_TWIST_DB = 3.0 /* More difference between mark and space suggests pre-emphasis problems. */
+
+const CAL_LEVEL_SECONDS = 5
+
+const CAL_LEVEL_TRIES = 5
+
+const CAL_FRAMES_EACH = 3
+
+/* TXDELAY to try, in milliseconds, longest first. */
+
+var CAL_TXDELAY_STEPS = []int{500, 400, 300, 250, 200, 150, 100, 50}
+
+type calAssist struct {
+	channel int
+	monitor int /* Channel listening to the transmitter, or -1 to ask the user. */
+
+	in *bufio.Reader
+
+	/* Send alternating tones, returning the level heard on the monitor channel at the end. */
+	tones func(seconds int) ALevel
+
+	/* Send test frames, each in its own transmission, returning how many the monitor channel decoded. */
+	frames func(txdelay int, count int) int
+}
+
+type calResult struct {
+	levelDB float64 /* Change to the transmit level still needed. */
+	levelOK bool    /* Whether it could be measured at all. */
+
+	txdelay int /* Recommended, in milliseconds.  0 if none worked. */
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        cal_assist
+ *
+ * Purpose:     Run the calibration assistant then exit.
+ *
+ * Inputs:	pa		- Audio configuration.
+ *
+ *		channel		- Transmitting channel.
+ *
+ *		monitor		- Channel to measure with, or -1 to ask
+ *				  the user.
+ *
+ * Description:	Audio, tone generation, and PTT must already be
+ *		initialized.  For a monitor channel, the demodulators
+ *		are started here.
+ *
+ *--------------------------------------------------------------------*/
+
+func cal_assist(pa *audio_s, channel int, monitor int) {
+	if monitor >= 0 {
+		if monitor == channel || monitor >= MAX_RADIO_CHANS || pa.chan_medium[monitor] != MEDIUM_RADIO {
+			text_color_set(DW_COLOR_ERROR)
+			fmt.Printf("\nMonitor channel %d must be a different radio channel than %d.\n", monitor, channel)
+			text_color_set(DW_COLOR_INFO)
+			os.Exit(1)
+		}
+
+		recv_init(pa)
+	}
+
+	var a = &calAssist{
+		channel: channel,
+		monitor: monitor,
+		in:      bufio.NewReader(os.Stdin),
+		tones: func(seconds int) ALevel {
+			return cal_send_tones(pa, channel, monitor, seconds)
+		},
+		frames: func(txdelay int, count int) int {
+			return cal_send_frames(pa, channel, monitor, txdelay, count)
+		},
+	}
+
+	a.run()
+}
+
+func (a *calAssist) run() calResult {
+	var result calResult
+
+	text_color_set(DW_COLOR_INFO)
+	fmt.Printf("\nCalibration assistant for channel %d.\n", a.channel)
+
+	if a.monitor >= 0 {
+		fmt.Printf("Channel %d will listen to the transmitter.  Its receive level should already be set to\n", a.monitor)
+		fmt.Printf("about %d, with -x r, for a station known to be good.\n", CAL_TARGET_LEVEL)
+	} else {
+		fmt.Printf("You will need a deviation meter, or a service monitor, and another station to decode test frames.\n")
+	}
+
+	/* Step 1: transmit level. */
+
+	fmt.Printf("\nStep 1: Transmit audio level.\n")
+
+	for try := 1; ; try++ {
+		fmt.Printf("\nSending alternating tones for %d seconds.\n", CAL_LEVEL_SECONDS)
+
+		var alevel = a.tones(CAL_LEVEL_SECONDS)
+
+		result.levelDB, result.levelOK = a.measureLevel(alevel)
+		if !result.levelOK {
+			break
+		}
+
+		if math.Abs(result.levelDB) <= CAL_CLOSE_DB {
+			fmt.Printf("The transmit level is good.\n")
+
+			break
+		}
+
+		fmt.Printf("%s\n", cal_level_advice(result.levelDB))
+
+		if try == CAL_LEVEL_TRIES {
+			break
+		}
+
+		var answer, ok = a.ask("Adjust the level, then press Enter to try again, or type q to go on: ")
+		if !ok || strings.EqualFold(answer, "q") {
+			break
+		}
+	}
+
+	/* Step 2: TXDELAY. */
+
+	fmt.Printf("\nStep 2: TXDELAY.  Sending %d test frames at a time, with shorter and shorter TXDELAY.\n", CAL_FRAMES_EACH)
+
+	if a.monitor < 0 {
+		fmt.Printf("Watch what the other station decodes.\n")
+	}
+
+	var good = -1 /* Index into CAL_TXDELAY_STEPS of shortest that worked. */
+
+	for i, ms := range CAL_TXDELAY_STEPS {
+		fmt.Printf("\nTXDELAY %d ms...\n", ms)
+
+		var heard = a.frames(ms, CAL_FRAMES_EACH)
+
+		if a.monitor < 0 {
+			heard = a.askCount(fmt.Sprintf("How many of the %d did the other station decode? ", CAL_FRAMES_EACH))
+		}
+
+		fmt.Printf("%d of %d decoded.\n", heard, CAL_FRAMES_EACH)
+
+		if heard < CAL_FRAMES_EACH {
+			break
+		}
+
+		good = i
+	}
+
+	result.txdelay = cal_pick_txdelay(good)
+
+	/* Summary. */
+
+	fmt.Printf("\nRecommendations for channel %d:\n\n", a.channel)
+
+	switch {
+	case !result.levelOK:
+		fmt.Printf("    Transmit level could not be measured.\n")
+	case math.Abs(result.levelDB) <= CAL_CLOSE_DB:
+		fmt.Printf("    Transmit level is good.  Leave it where it is.\n")
+	default:
+		fmt.Printf("    %s\n", cal_level_advice(result.levelDB))
+	}
+
+	if result.txdelay > 0 {
+		fmt.Printf("    TXDELAY %d\t\t# %d ms, in the configuration file.\n", result.txdelay/10, result.txdelay)
+	} else {
+		fmt.Printf("    No test frames got through, even with TXDELAY %d ms.  Check the transmit level and PTT.\n", CAL_TXDELAY_STEPS[0])
+	}
+
+	fmt.Printf("\n")
+
+	return result
+}
+
+/* How far the transmit level is from right, in dB, or false if it can't be told. */
+
+func (a *calAssist) measureLevel(alevel ALevel) (float64, bool) {
+	if a.monitor < 0 {
+		var answer, _ = a.ask("Deviation you measured, in kHz, or Enter if you couldn't: ")
+
+		var khz, err = strconv.ParseFloat(answer, 64)
+		if err != nil || khz <= 0 {
+			fmt.Printf("Not measured.\n")
+
+			return 0, false
+		}
+
+		return cal_level_db(khz, CAL_TARGET_DEVIATION), true
+	}
+
+	fmt.Printf("%s\n", rec_calibrate_text(a.monitor, alevel))
+
+	if alevel.rec < 5 {
+		fmt.Printf("Nothing heard on channel %d.  Is that radio on the same frequency?\n", a.monitor)
+
+		return 0, false
+	}
+
+	if alevel.mark > 0 && alevel.space > 0 {
+		var twist = cal_level_db(float64(alevel.space), float64(alevel.mark))
+		if math.Abs(twist) > CAL_TWIST_DB {
+			fmt.Printf("Mark and space differ by %.1f dB.  Check whether the transmit audio goes through pre-emphasis.\n", twist)
+		}
+	}
+
+	return cal_level_db(float64(alevel.rec), CAL_TARGET_LEVEL), true
+}
+
+/* A line from the user, or false at the end of input. */
+
+func (a *calAssist) ask(question string) (string, bool) {
+	fmt.Printf("%s", question)
+
+	var line, err = a.in.ReadString('\n')
+	if err != nil && line == "" {
+		fmt.Printf("\n")
+
+		return "", false
+	}
+
+	return strings.TrimSpace(line), true
+}
+
+func (a *calAssist) askCount(question string) int {
+	for {
+		var answer, ok = a.ask(question)
+		if !ok {
+			return 0
+		}
+
+		var n, err = strconv.Atoi(answer)
+		if err == nil && n >= 0 && n <= CAL_FRAMES_EACH {
+			return n
+		}
+	}
+}
+
+/* Change needed to go from have to want, in dB. */
+
+func cal_level_db(have float64, want float64) float64 {
+	return 20 * math.Log10(want/have)
+}
+
+func cal_level_advice(db float64) string {
+	if db > 0 {
+		return fmt.Sprintf("Turn the transmit audio level up by about %.1f dB.", db)
+	}
+
+	return fmt.Sprintf("Turn the transmit audio level down by about %.1f dB.", -db)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        cal_pick_txdelay
+ *
+ * Purpose:     Recommend a TXDELAY from the test results.
+ *
+ * Inputs:	good	- Index into CAL_TXDELAY_STEPS of the shortest
+ *			  that got all frames through.  -1 for none.
+ *
+ * Returns:	Milliseconds.  One step longer than the shortest that
+ *		worked, for some margin.  0 if none worked.
+ *
+ *--------------------------------------------------------------------*/
+
+func cal_pick_txdelay(good int) int {
+	if good < 0 {
+		return 0
+	}
+
+	return CAL_TXDELAY_STEPS[max(good-1, 0)]
+}
+
+/* Alternating tones, then the level heard on the monitor channel. */
+
+func cal_send_tones(pa *audio_s, channel int, monitor int, seconds int) ALevel {
+	ptt_set(OCTYPE_PTT, channel, 1)
+
+	var start = time.Now()
+
+	for range seconds {
+		for n := range pa.achan[channel].baud {
+			tone_gen_put_bit(channel, n&1)
+		}
+	}
+
+	audio_flush(ACHAN2ADEV(channel))
+	audio_wait(ACHAN2ADEV(channel))
+
+	// Some outputs, such as UDP, don't wait for the audio to be played.
+
+	var wait_more = time.Duration(seconds)*time.Second - time.Since(start)
+	if wait_more > 0 {
+		SLEEP_MS(int(wait_more.Milliseconds()))
+	}
+
+	var alevel ALevel
+	if monitor >= 0 {
+		alevel = demod_get_audio_level(monitor, 0)
+	}
+
+	ptt_set(OCTYPE_PTT, channel, 0)
+
+	return alevel
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        cal_send_frames
+ *
+ * Purpose:     Send test frames, each in its own transmission, and
+ *		count how many the monitor channel decodes.
+ *
+ * Inputs:	txdelay	- Milliseconds of flags after PTT is turned on.
+ *
+ *		count	- How many.
+ *
+ * Description:	This does what the transmit thread does, without the
+ *		queue or waiting for a clear channel, so TXDELAY can be
+ *		different for each.
+ *
+ *--------------------------------------------------------------------*/
+
+func cal_send_frames(pa *audio_s, channel int, monitor int, txdelay int, count int) int {
+	var achan = &pa.achan[channel]
+	var heard = 0
+
+	for n := 1; n <= count; n++ {
+		var info = fmt.Sprintf("Calibration TXDELAY %d ms, %d of %d", txdelay, n, count)
+
+		var pp = AX25FromText(fmt.Sprintf("%s>TEST:%s", pa.mycall[channel], info), true)
+		if pp == nil {
+			return 0
+		}
+
+		ptt_set(OCTYPE_PTT, channel, 1)
+
+		var start = time.Now()
+
+		var bits = layer2_preamble_postamble(channel, txdelay*achan.baud/1000/8, false, pa)
+		bits += layer2_send_frame(channel, pp, false, pa)
+		bits += layer2_preamble_postamble(channel, achan.txtail*10*achan.baud/1000/8, true, pa)
+
+		AX25Delete(pp)
+
+		audio_wait(ACHAN2ADEV(channel))
+
+		var wait_more = time.Duration(bits)*time.Second/time.Duration(achan.baud) - time.Since(start)
+		if wait_more > 0 {
+			SLEEP_MS(int(wait_more.Milliseconds()))
+		}
+
+		ptt_set(OCTYPE_PTT, channel, 0)
+
+		if monitor >= 0 {
+			SLEEP_MS(500) // Time to decode.
+
+			heard += cal_count_heard(monitor, info)
+		}
+
+		SLEEP_MS(500)
+	}
+
+	return heard
+}
+
+/* Take what was received off the queue, counting the test frame we're looking for. */
+
+func cal_count_heard(monitor int, info string) int {
+	var heard = 0
+
+	for {
+		var item = dlq_remove()
+		if item == nil {
+			return heard
+		}
+
+		if item._type == DLQ_REC_FRAME && item._chan == monitor && item.pp != nil && string(AX25GetInfo(item.pp)) == info {
+			heard = 1
+		}
+
+		dlq_delete(item)
+	}
+}
@@ -0,0 +1,129 @@
+package direwolf
+
+import (
+	"bufio"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_calibrate_parse(t *testing.T) {
+	var tests = []struct {
+		in      string
+		ctype   rune
+		channel int
+	}{
+		{"a", 'a', 0},
+		{"m", 'm', 0},
+		{"s1", 's', 1},
+		{"1p", 'p', 1},
+		{"r", 'r', 0},
+		{"r2", 'r', 2},
+		{"1", 'a', 1},
+		{"c1", 'c', 1},
+	}
+
+	for _, tc := range tests {
+		var ctype, channel, err = calibrate_parse(tc.in)
+		require.NoError(t, err, tc.in)
+		assert.Equal(t, tc.ctype, ctype, tc.in)
+		assert.Equal(t, tc.channel, channel, tc.in)
+	}
+
+	var _, _, err = calibrate_parse("x")
+	require.Error(t, err)
+
+	_, _, err = calibrate_parse("a99")
+	require.Error(t, err)
+}
+
+func Test_rec_calibrate_text(t *testing.T) {
+	assert.Equal(t, "Channel 0 audio level = 50(25/25)    mark/space +0.0 dB",
+		rec_calibrate_text(0, ALevel{rec: 50, mark: 25, space: 25}))
+	assert.Equal(t, "Channel 1 audio level = 48(40/20)    mark/space +6.0 dB",
+		rec_calibrate_text(1, ALevel{rec: 48, mark: 40, space: 20}))
+
+	// 9600 baud shows the + and - peaks, not mark and space.
+	assert.Equal(t, "Channel 0 audio level = 60(+30/-29)",
+		rec_calibrate_text(0, ALevel{rec: 60, mark: 30, space: -29}))
+}
+
+func Test_cal_pick_txdelay(t *testing.T) {
+	assert.Equal(t, 0, cal_pick_txdelay(-1), "none worked")
+	assert.Equal(t, 500, cal_pick_txdelay(0), "nothing longer to try")
+	assert.Equal(t, 250, cal_pick_txdelay(4), "one step longer than 200")
+	assert.Equal(t, 100, cal_pick_txdelay(len(CAL_TXDELAY_STEPS)-1))
+}
+
+func Test_cal_level_db(t *testing.T) {
+	assert.InDelta(t, 6.02, cal_level_db(25, 50), 0.01)
+	assert.InDelta(t, -3.52, cal_level_db(4.5, 3), 0.01)
+
+	assert.Equal(t, "Turn the transmit audio level up by about 6.0 dB.", cal_level_advice(6.02))
+	assert.Equal(t, "Turn the transmit audio level down by about 3.5 dB.", cal_level_advice(-3.52))
+}
+
+/* A transmitter whose level the user turns up, and which needs 200 ms to get going. */
+
+func Test_calAssist_monitor(t *testing.T) {
+	var levels = []ALevel{{rec: 20, mark: 15, space: 5}, {rec: 48, mark: 24, space: 24}}
+	var tried []int
+
+	var a = &calAssist{
+		channel: 0,
+		monitor: 1,
+		in:      bufio.NewReader(strings.NewReader("\n")),
+		tones: func(seconds int) ALevel {
+			var l = levels[0]
+			levels = levels[1:]
+
+			return l
+		},
+		frames: func(txdelay int, count int) int {
+			tried = append(tried, txdelay)
+
+			if txdelay < 200 {
+				return count - 1
+			}
+
+			return count
+		},
+	}
+
+	var result = a.run()
+
+	assert.True(t, result.levelOK)
+	assert.InDelta(t, 0.35, result.levelDB, 0.01, "good enough after one adjustment")
+	assert.Empty(t, levels)
+
+	assert.Equal(t, []int{500, 400, 300, 250, 200, 150}, tried, "stops at the first failure")
+	assert.Equal(t, 250, result.txdelay)
+}
+
+/* No monitor, so the user answers.  Giving up on the level, and nothing gets through. */
+
+func Test_calAssist_ask(t *testing.T) {
+	var a = &calAssist{
+		channel: 0,
+		monitor: -1,
+		in:      bufio.NewReader(strings.NewReader("6\nq\nmost\n1\n")),
+		tones:   func(seconds int) ALevel { return ALevel{} },
+		frames:  func(txdelay int, count int) int { return count },
+	}
+
+	var result = a.run()
+
+	assert.True(t, result.levelOK)
+	assert.InDelta(t, -6.02, result.levelDB, 0.01, "6 kHz is twice as much as wanted")
+	assert.Equal(t, 0, result.txdelay, "1 of 3 at 500 ms")
+
+	// Nothing more to read, so nothing measured.
+
+	a.in = bufio.NewReader(strings.NewReader(""))
+	result = a.run()
+
+	assert.False(t, result.levelOK)
+	assert.Equal(t, 0, result.txdelay)
+}
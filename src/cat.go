@@ -0,0 +1,272 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	PTT and frequency read for common radios, using their
+ *		own serial port CAT commands rather than hamlib.
+ *
+ * Description:	PTT CAT  type  device  [ rate ]  [ address ]
+ *
+ *		icom	CI-V.  Binary frames of FE FE to from command ... FD.
+ *			The address is the radio's CI-V address, e.g. 0x94
+ *			for an IC-7300.  We use the usual controller address, E0.
+ *
+ *		yaesu	Newer Yaesu radios (FT-991, FT-891, FT-710, FTDX10, ...)
+ *			using ASCII commands terminated by ';'.
+ *
+ *		ft817	FT-817, FT-818, FT-857, and FT-897.  Five byte
+ *			commands, four parameter bytes then the opcode.
+ *
+ *		kenwood	Kenwood, Elecraft, and the many others which copied
+ *			them.  ASCII commands, mostly the same as newer Yaesu.
+ *
+ *		This only needs to key the transmitter and read the
+ *		frequency.  Anything more and hamlib is the right answer.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+type cat_type_t int
+
+const (
+	CAT_NONE cat_type_t = iota
+	CAT_ICOM
+	CAT_YAESU
+	CAT_FT817
+	CAT_KENWOOD
+)
+
+var cat_type_names = map[string]cat_type_t{
+	"icom":    CAT_ICOM,
+	"yaesu":   CAT_YAESU,
+	"ft817":   CAT_FT817,
+	"kenwood": CAT_KENWOOD,
+}
+
+func cat_type_from_name(name string) (cat_type_t, bool) {
+	var typ, ok = cat_type_names[strings.ToLower(name)]
+
+	return typ, ok
+}
+
+func cat_type_name(typ cat_type_t) string {
+	for name, t := range cat_type_names {
+		if t == typ {
+			return name
+		}
+	}
+
+	return "none"
+}
+
+const CIV_PREAMBLE = 0xfe
+const CIV_END = 0xfd
+const CIV_CONTROLLER = 0xe0
+
+const CIV_CMD_READ_FREQ = 0x03
+const CIV_CMD_PTT = 0x1c
+
+/* Longest reply we will wait for before giving up on finding the end. */
+
+const CAT_MAX_REPLY = 32
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	cat_ptt_command
+ *
+ * Purpose:	Command to turn the transmitter on or off.
+ *
+ * Inputs:	typ	- Type of radio.
+ *		addr	- CI-V address, only for Icom.
+ *		on	- true to transmit.
+ *
+ *--------------------------------------------------------------------*/
+
+func cat_ptt_command(typ cat_type_t, addr int, on bool) []byte {
+	switch typ {
+	case CAT_ICOM:
+		return []byte{CIV_PREAMBLE, CIV_PREAMBLE, byte(addr), CIV_CONTROLLER, CIV_CMD_PTT, 0x00, byte(IfThenElse(on, 1, 0)), CIV_END}
+	case CAT_YAESU:
+		return []byte(IfThenElse(on, "TX1;", "TX0;"))
+	case CAT_FT817:
+		return []byte{0, 0, 0, 0, byte(IfThenElse(on, 0x08, 0x88))}
+	case CAT_KENWOOD:
+		return []byte(IfThenElse(on, "TX;", "RX;"))
+	case CAT_NONE:
+	}
+
+	return nil
+}
+
+/* Command to read the frequency. */
+
+func cat_freq_command(typ cat_type_t, addr int) []byte {
+	switch typ {
+	case CAT_ICOM:
+		return []byte{CIV_PREAMBLE, CIV_PREAMBLE, byte(addr), CIV_CONTROLLER, CIV_CMD_READ_FREQ, CIV_END}
+	case CAT_YAESU, CAT_KENWOOD:
+		return []byte("FA;")
+	case CAT_FT817:
+		return []byte{0, 0, 0, 0, 0x03}
+	case CAT_NONE:
+	}
+
+	return nil
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	cat_read_reply
+ *
+ * Purpose:	Read one reply from the radio.
+ *
+ * Description:	CI-V frames end with FD, ASCII replies with ';', and
+ *		the FT-817 always sends 5 bytes for a frequency.
+ *		A read of nothing is taken as the serial port timing out.
+ *
+ *--------------------------------------------------------------------*/
+
+var errCATNoAnswer = errors.New("no answer from radio")
+
+func cat_read_reply(r io.Reader, typ cat_type_t) ([]byte, error) {
+	var reply []byte
+	var b = make([]byte, 1)
+
+	for len(reply) < CAT_MAX_REPLY {
+		var n, err = r.Read(b)
+		if n == 0 {
+			if err == nil || errors.Is(err, io.EOF) {
+				err = errCATNoAnswer
+			}
+
+			return reply, err
+		}
+
+		reply = append(reply, b[0])
+
+		switch typ {
+		case CAT_ICOM:
+			if b[0] == CIV_END {
+				return reply, nil
+			}
+		case CAT_YAESU, CAT_KENWOOD:
+			if b[0] == ';' {
+				return reply, nil
+			}
+		case CAT_FT817:
+			if len(reply) == 5 {
+				return reply, nil
+			}
+		case CAT_NONE:
+			return reply, nil
+		}
+	}
+
+	return reply, fmt.Errorf("reply from radio is longer than %d bytes", CAT_MAX_REPLY)
+}
+
+/* Two decimal digits per byte, as Icom and Yaesu send numbers. */
+
+func cat_bcd(b byte) int64 {
+	return int64(b>>4)*10 + int64(b&0x0f)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	cat_parse_freq
+ *
+ * Purpose:	Get the frequency from a reply.
+ *
+ * Returns:	Frequency in Hz, and true if this reply was a frequency.
+ *		Something else, like the echo of our own command on the
+ *		CI-V bus, or an acknowledgement of a PTT command still
+ *		waiting to be read, returns false and should be skipped.
+ *
+ *--------------------------------------------------------------------*/
+
+func cat_parse_freq(typ cat_type_t, addr int, reply []byte) (int64, bool) {
+	switch typ {
+	case CAT_ICOM:
+		// FE FE E0 addr 03 then 5 bytes of BCD, least significant first, FD.
+		if len(reply) != 11 || reply[0] != CIV_PREAMBLE || reply[1] != CIV_PREAMBLE ||
+			reply[2] != CIV_CONTROLLER || int(reply[3]) != addr || reply[4] != CIV_CMD_READ_FREQ {
+			return 0, false
+		}
+
+		var freq int64
+		for i := 9; i >= 5; i-- {
+			freq = freq*100 + cat_bcd(reply[i])
+		}
+
+		return freq, true
+
+	case CAT_YAESU, CAT_KENWOOD:
+		// FA then the frequency in Hz, 9 digits for Yaesu, 11 for Kenwood.
+		var s = string(reply)
+		if !strings.HasPrefix(s, "FA") || !strings.HasSuffix(s, ";") {
+			return 0, false
+		}
+
+		var freq, err = strconv.ParseInt(s[2:len(s)-1], 10, 64)
+
+		return freq, err == nil
+
+	case CAT_FT817:
+		// 4 bytes of BCD in units of 10 Hz, most significant first, then the mode.
+		if len(reply) != 5 {
+			return 0, false
+		}
+
+		var freq int64
+		for i := range 4 {
+			freq = freq*100 + cat_bcd(reply[i])
+		}
+
+		return freq * 10, true
+
+	case CAT_NONE:
+	}
+
+	return 0, false
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	cat_get_freq
+ *
+ * Purpose:	Ask the radio for its frequency.
+ *
+ * Inputs:	rw	- Serial port, with a read timeout.
+ *
+ * Returns:	Frequency in Hz.
+ *
+ *--------------------------------------------------------------------*/
+
+func cat_get_freq(rw io.ReadWriter, typ cat_type_t, addr int) (int64, error) {
+	var _, err = rw.Write(cat_freq_command(typ, addr))
+	if err != nil {
+		return 0, err
+	}
+
+	// Skip a few replies that aren't what we want.
+	for range 4 {
+		var reply, readErr = cat_read_reply(rw, typ)
+		if readErr != nil {
+			return 0, readErr
+		}
+
+		var freq, ok = cat_parse_freq(typ, addr, reply)
+		if ok {
+			return freq, nil
+		}
+	}
+
+	return 0, errors.New("no frequency in reply from radio")
+}
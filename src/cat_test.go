@@ -0,0 +1,77 @@
+package direwolf
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cat_ptt_command(t *testing.T) {
+	assert.Equal(t, []byte{0xfe, 0xfe, 0x94, 0xe0, 0x1c, 0x00, 0x01, 0xfd}, cat_ptt_command(CAT_ICOM, 0x94, true))
+	assert.Equal(t, []byte{0xfe, 0xfe, 0x94, 0xe0, 0x1c, 0x00, 0x00, 0xfd}, cat_ptt_command(CAT_ICOM, 0x94, false))
+	assert.Equal(t, []byte("TX1;"), cat_ptt_command(CAT_YAESU, 0, true))
+	assert.Equal(t, []byte("TX0;"), cat_ptt_command(CAT_YAESU, 0, false))
+	assert.Equal(t, []byte{0, 0, 0, 0, 0x08}, cat_ptt_command(CAT_FT817, 0, true))
+	assert.Equal(t, []byte{0, 0, 0, 0, 0x88}, cat_ptt_command(CAT_FT817, 0, false))
+	assert.Equal(t, []byte("TX;"), cat_ptt_command(CAT_KENWOOD, 0, true))
+	assert.Equal(t, []byte("RX;"), cat_ptt_command(CAT_KENWOOD, 0, false))
+}
+
+func Test_cat_parse_freq(t *testing.T) {
+	// 144.390 MHz
+	var freq, ok = cat_parse_freq(CAT_ICOM, 0x94, []byte{0xfe, 0xfe, 0xe0, 0x94, 0x03, 0x00, 0x00, 0x39, 0x44, 0x01, 0xfd})
+	assert.True(t, ok)
+	assert.Equal(t, int64(144390000), freq)
+
+	// Echo of our own command, or from another radio.
+	_, ok = cat_parse_freq(CAT_ICOM, 0x94, []byte{0xfe, 0xfe, 0x94, 0xe0, 0x03, 0xfd})
+	assert.False(t, ok)
+	_, ok = cat_parse_freq(CAT_ICOM, 0x94, []byte{0xfe, 0xfe, 0xe0, 0x88, 0x03, 0x00, 0x00, 0x39, 0x44, 0x01, 0xfd})
+	assert.False(t, ok)
+
+	freq, ok = cat_parse_freq(CAT_YAESU, 0, []byte("FA014074000;"))
+	assert.True(t, ok)
+	assert.Equal(t, int64(14074000), freq)
+
+	freq, ok = cat_parse_freq(CAT_KENWOOD, 0, []byte("FA00144390000;"))
+	assert.True(t, ok)
+	assert.Equal(t, int64(144390000), freq)
+
+	_, ok = cat_parse_freq(CAT_KENWOOD, 0, []byte("?;"))
+	assert.False(t, ok)
+
+	freq, ok = cat_parse_freq(CAT_FT817, 0, []byte{0x14, 0x43, 0x90, 0x00, 0x08})
+	assert.True(t, ok)
+	assert.Equal(t, int64(144390000), freq)
+}
+
+// catRadio answers a frequency request with whatever it was given.
+type catRadio struct {
+	bytes.Buffer
+	sent []byte
+}
+
+func (r *catRadio) Write(p []byte) (int, error) {
+	r.sent = append(r.sent, p...)
+	return len(p), nil
+}
+
+func Test_cat_get_freq(t *testing.T) {
+	var radio = new(catRadio)
+	// CI-V echo, an acknowledgement left over from PTT, then the answer.
+	radio.Buffer.Write([]byte{0xfe, 0xfe, 0x94, 0xe0, 0x03, 0xfd})
+	radio.Buffer.Write([]byte{0xfe, 0xfe, 0xe0, 0x94, 0xfb, 0xfd})
+	radio.Buffer.Write([]byte{0xfe, 0xfe, 0xe0, 0x94, 0x03, 0x00, 0x00, 0x39, 0x44, 0x01, 0xfd})
+
+	var freq, err = cat_get_freq(radio, CAT_ICOM, 0x94)
+	require.NoError(t, err)
+	assert.Equal(t, int64(144390000), freq)
+	assert.Equal(t, []byte{0xfe, 0xfe, 0x94, 0xe0, 0x03, 0xfd}, radio.sent)
+
+	// Nobody home.
+	radio = new(catRadio)
+	_, err = cat_get_freq(radio, CAT_KENWOOD, 0)
+	assert.ErrorIs(t, err, errCATNoAnswer)
+}
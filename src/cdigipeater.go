@@ -135,6 +135,7 @@ func cdigipeater(from_chan int, pp *packet_t) {
 					save_cdigi_config_p.alias[from_chan][to_chan], to_chan,
 					save_cdigi_config_p.cfilter_str[from_chan][to_chan])
 				if result != nil {
+					result.tx_reason = TX_REASON_DIGIPEAT
 					tq_append(to_chan, TQ_PRIO_0_HI, result)
 					cdigi_count[from_chan][to_chan]++
 				}
@@ -155,6 +156,7 @@ func cdigipeater(from_chan int, pp *packet_t) {
 					save_cdigi_config_p.alias[from_chan][to_chan], to_chan,
 					save_cdigi_config_p.cfilter_str[from_chan][to_chan])
 				if result != nil {
+					result.tx_reason = TX_REASON_DIGIPEAT
 					tq_append(to_chan, TQ_PRIO_0_HI, result)
 					cdigi_count[from_chan][to_chan]++
 				}
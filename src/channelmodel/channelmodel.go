@@ -0,0 +1,137 @@
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Simulate an imperfect radio channel for testing the
+ *		demodulators.
+ *
+ * Description:	gen_packets uses this to add noise, frequency offset,
+ *		and bit rate error to the audio it writes.  atest and
+ *		direwolf use it for the -e option to flip received bits.
+ *		Keeping it all in one place means every tool simulates
+ *		the channel the same way.
+ *
+ *		The random numbers come from the same simple generator as
+ *		Dire Wolf so results are identical on every platform.  The
+ *		test scripts depend on this: with a different sequence
+ *		some of them decode a few packets outside the expected
+ *		range.
+ *
+ *------------------------------------------------------------------*/
+
+// Package channelmodel simulates noise and other impairments of a radio channel.
+package channelmodel
+
+import (
+	"math"
+)
+
+const RAND_MAX = 0x7fffffff
+
+// Rand is a linear congruential generator giving the same sequence everywhere.
+type Rand struct {
+	seed int32
+}
+
+// NewRand starts a sequence with the seed Dire Wolf uses.
+func NewRand() *Rand {
+	var r = new(Rand)
+	r.seed = 1
+
+	return r
+}
+
+// Next returns the next number in the range 0 .. RAND_MAX.
+func (r *Rand) Next() int32 {
+	r.seed = int32((uint32(r.seed)*1103515245 + 12345) & RAND_MAX) //nolint:gosec // Masked to 31 bits.
+
+	return r.seed
+}
+
+// Float returns the next number in the range 0 .. 1.
+func (r *Rand) Float() float64 {
+	return float64(r.Next()) / float64(RAND_MAX) // calculate as double to preserve all 31 bits.
+}
+
+// Symmetric returns the next number in the range -1 .. +1.
+func (r *Rand) Symmetric() float64 {
+	return (float64(r.Next()) - float64(RAND_MAX)/2.0) / (float64(RAND_MAX) / 2.0)
+}
+
+// Model is the set of impairments to apply.  The zero value, apart from
+// Rand, is a perfect channel.
+type Model struct {
+	// NoiseLevel sets how much white noise is added to audio samples.
+	// It is uniform, up to 5 * NoiseLevel of full scale.
+	NoiseLevel float64
+
+	// BitErrorRate is the fraction of received bits to flip.
+	BitErrorRate float64
+
+	// OffsetHz is added to the tone frequencies, like a mistuned SSB receiver.
+	OffsetHz float64
+
+	// SkewPercent is the error in the transmitted bit rate.
+	SkewPercent float64
+
+	Rand *Rand
+}
+
+// New returns a perfect channel with its own random number sequence.
+func New() *Model {
+	var m = new(Model)
+	m.Rand = NewRand()
+
+	return m
+}
+
+// AddNoise adds noise to one 16 bit audio sample, clipping at full scale.
+// A random number is used even if NoiseLevel is 0.
+func (m *Model) AddNoise(sample int16) int16 {
+	var s = int32(sample)
+
+	s += int32(5 * m.Rand.Symmetric() * m.NoiseLevel * 32767)
+
+	s = max(min(s, 32767), -32767)
+
+	return int16(s) //nolint:gosec // Clipped above.
+}
+
+// FlipBit returns the received bit, possibly flipped according to
+// BitErrorRate.  No random number is used if BitErrorRate is 0.
+func (m *Model) FlipBit(bit bool) bool {
+	if m.BitErrorRate == 0 {
+		return bit
+	}
+
+	if m.BitErrorRate > m.Rand.Float() {
+		return !bit
+	}
+
+	return bit
+}
+
+// Tones applies OffsetHz and SkewPercent to the modem settings.
+func (m *Model) Tones(mark int, space int, baud int) (int, int, int) {
+	var offset = int(math.Round(m.OffsetHz))
+
+	return mark + offset, space + offset, SkewBaud(baud, m.SkewPercent)
+}
+
+// SkewBaud gives the bit rate with a percentage error.
+// It is an integer so there is some roundoff.
+func SkewBaud(baud int, percent float64) int {
+	return int(float64(baud) * (1. + percent/100.))
+}
+
+// NoiseLevelForSNR gives the NoiseLevel for a signal to noise ratio.
+// peak is the amplitude of the sine wave signal, as a fraction of full scale.
+// The noise is white across the whole audio bandwidth, so the ratio within
+// the modem's passband is better than this.
+func NoiseLevelForSNR(peak float64, snrDB float64) float64 {
+	// Power of sine wave is a²/2.
+	var snr = math.Pow(10, snrDB/10)
+
+	// Noise is uniform in -n .. +n so power is n²/3.
+	var n = peak * math.Sqrt(3/(2*snr))
+
+	return n / 5
+}
@@ -0,0 +1,98 @@
+package channelmodel
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRand(t *testing.T) {
+	// Same sequence as rand() in Dire Wolf.
+	var r = NewRand()
+
+	assert.Equal(t, int32(1103527590), r.Next())
+	assert.Equal(t, int32(377401575), r.Next())
+	assert.Equal(t, int32(662824084), r.Next())
+
+	for range 1000 {
+		var f = r.Float()
+		assert.True(t, f >= 0 && f <= 1)
+
+		var s = r.Symmetric()
+		assert.True(t, s >= -1 && s <= 1)
+	}
+}
+
+func TestAddNoise(t *testing.T) {
+	var m = New()
+
+	assert.Equal(t, int16(1234), m.AddNoise(1234), "no noise")
+
+	m.NoiseLevel = 0.01
+
+	var n1 = m.AddNoise(0)
+	assert.NotZero(t, n1)
+	assert.LessOrEqual(t, math.Abs(float64(n1)), 5*0.01*32767)
+
+	// Clipped.
+	m.NoiseLevel = 10
+
+	for range 100 {
+		var s = m.AddNoise(32000)
+		assert.True(t, s >= -32767 && s <= 32767)
+	}
+
+	// Same again from the start.
+	var m2 = New()
+	m2.NoiseLevel = 0.01
+
+	m2.AddNoise(0)
+	assert.Equal(t, n1, m2.AddNoise(0))
+}
+
+func TestFlipBit(t *testing.T) {
+	var m = New()
+
+	assert.True(t, m.FlipBit(true))
+	assert.Equal(t, NewRand().Next(), m.Rand.Next(), "no random numbers used without errors")
+
+	m.BitErrorRate = 0.1
+
+	var flipped = 0
+
+	for range 10000 {
+		if m.FlipBit(false) {
+			flipped++
+		}
+	}
+
+	assert.InDelta(t, 1000, flipped, 100)
+}
+
+func TestTones(t *testing.T) {
+	var m = New()
+
+	var mark, space, baud = m.Tones(1200, 2200, 1200)
+	assert.Equal(t, []int{1200, 2200, 1200}, []int{mark, space, baud})
+
+	m.OffsetHz = -25.4
+	m.SkewPercent = 1
+
+	mark, space, baud = m.Tones(1200, 2200, 1200)
+	assert.Equal(t, []int{1175, 2175, 1212}, []int{mark, space, baud})
+
+	assert.Equal(t, 9504, SkewBaud(9600, -1))
+}
+
+func TestNoiseLevelForSNR(t *testing.T) {
+	// 0 dB: noise power equals signal power.
+	var peak = 0.25
+	var n = 5 * NoiseLevelForSNR(peak, 0)
+
+	assert.InDelta(t, peak*peak/2, n*n/3, 1e-12)
+
+	// Each 10 dB is a tenth of the noise power.
+	var ratio = NoiseLevelForSNR(peak, 0) / NoiseLevelForSNR(peak, 10)
+	assert.InDelta(t, math.Sqrt(10), ratio, 1e-9)
+}
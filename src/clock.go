@@ -0,0 +1,112 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Tell the time, in a way that tests can control.
+ *
+ * Description:	The AX.25 link timers, beacon schedule, and duplicate
+ *		detection all work in seconds or minutes.  Rather than
+ *		sleeping that long, a test can give them a FakeClock
+ *		and move it forward instantly.
+ *
+ *		Everything else uses SystemClock, i.e. the time package.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"sync"
+	"time"
+)
+
+type Clock interface {
+	Now() time.Time
+	Sleep(d time.Duration)
+}
+
+type SystemClock struct{}
+
+func (SystemClock) Now() time.Time {
+	return time.Now()
+}
+
+func (SystemClock) Sleep(d time.Duration) {
+	time.Sleep(d)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        FakeClock
+ *
+ * Purpose:     Virtual time for tests.
+ *
+ * Description:	Time stands still until Advance is called.
+ *		Sleep blocks until Advance moves the time past the
+ *		end of the sleep, so a thread under test wakes up
+ *		just as it would have, without the wait.
+ *
+ *--------------------------------------------------------------------*/
+
+type FakeClock struct {
+	mu       sync.Mutex
+	now      time.Time
+	sleepers []*fakeSleeper
+}
+
+type fakeSleeper struct {
+	until time.Time
+	wake  chan struct{}
+}
+
+func NewFakeClock(start time.Time) *FakeClock {
+	return &FakeClock{now: start} //nolint:exhaustruct
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.now
+}
+
+func (c *FakeClock) Sleep(d time.Duration) {
+	if d <= 0 {
+		return
+	}
+
+	c.mu.Lock()
+	var s = &fakeSleeper{until: c.now.Add(d), wake: make(chan struct{})}
+	c.sleepers = append(c.sleepers, s)
+	c.mu.Unlock()
+
+	<-s.wake
+}
+
+/* Move time forward, waking any sleepers whose time has come. */
+
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.now = c.now.Add(d)
+
+	var still []*fakeSleeper
+
+	for _, s := range c.sleepers {
+		if c.now.Before(s.until) {
+			still = append(still, s)
+		} else {
+			close(s.wake)
+		}
+	}
+
+	c.sleepers = still
+}
+
+/* Number of threads in Sleep, so a test can wait until one has gone to sleep. */
+
+func (c *FakeClock) Sleepers() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.sleepers)
+}
@@ -0,0 +1,60 @@
+package direwolf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_FakeClock_advance_wakes_sleepers(t *testing.T) {
+	var start = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	var clock = NewFakeClock(start)
+
+	var woke = make(chan time.Time)
+
+	go func() {
+		clock.Sleep(10 * time.Minute)
+		woke <- clock.Now()
+	}()
+
+	require.Eventually(t, func() bool { return clock.Sleepers() == 1 }, time.Second, time.Millisecond)
+
+	clock.Advance(9 * time.Minute)
+	assert.Equal(t, 1, clock.Sleepers(), "not time yet")
+
+	clock.Advance(time.Minute)
+	assert.Equal(t, start.Add(10*time.Minute), <-woke)
+	assert.Zero(t, clock.Sleepers())
+
+	clock.Sleep(0) // Doesn't block.
+}
+
+func Test_dl_timers_fake_clock(t *testing.T) {
+	var clock = NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+	dl_clock = clock
+
+	t.Cleanup(func() { dl_clock = SystemClock{} })
+
+	var S = new(ax25_dlsm_t)
+	S.t1v = 3 * time.Second
+
+	START_T1(S)
+	assert.Equal(t, clock.Now().Add(3*time.Second), S.t1_exp)
+
+	// Channel busy for a minute doesn't count against T1.
+
+	PAUSE_T1(S)
+	clock.Advance(time.Minute)
+	RESUME_T1(S)
+	assert.Equal(t, clock.Now().Add(3*time.Second), S.t1_exp)
+
+	clock.Advance(time.Second)
+	STOP_T1(S)
+	assert.Equal(t, 2*time.Second, S.t1_remaining_when_last_stopped)
+	assert.False(t, IS_T1_RUNNING(S))
+
+	START_T3(S)
+	assert.Equal(t, clock.Now().Add(T3_DEFAULT), S.t3_exp)
+}
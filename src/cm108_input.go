@@ -0,0 +1,73 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Use a GPIO pin of the CM108/CM119 (or compatible) as an input,
+ *		e.g. for TXINH from a shared repeater site interlock.
+ *
+ * Description:	We can't ask for the current state of the pins.
+ *		The chip sends a 4 byte HID input report whenever one of its
+ *		inputs changes:
+ *
+ *			byte 0	- Volume and mute buttons.
+ *			byte 1	- GPIO pins, LSB is GPIO1, bit 1 is GPIO2, etc.
+ *			byte 2, 3 - Not used here.
+ *
+ *		so a goroutine reads reports as they arrive and keeps the
+ *		latest.  Until the first report, all pins are taken as low.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"errors"
+	"io"
+	"os"
+	"sync/atomic"
+)
+
+const CM108_INPUT_REPORT_LEN = 4
+
+type cm108_input_s struct {
+	r    io.ReadCloser
+	gpio atomic.Int32 // Byte 1 of the latest report.
+}
+
+/* Start watching a device, already open, for input reports. */
+
+func cm108_input_watch(r io.ReadCloser) *cm108_input_s {
+	var c = &cm108_input_s{r: r} //nolint:exhaustruct
+
+	go c.reader()
+
+	return c
+}
+
+func (c *cm108_input_s) reader() {
+	var report = make([]byte, CM108_INPUT_REPORT_LEN)
+
+	for {
+		var n, err = c.r.Read(report)
+		if err != nil {
+			if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrClosedPipe) && !errors.Is(err, os.ErrClosed) {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("CM108 GPIO input stopped: %s\n", err)
+			}
+
+			return
+		}
+
+		if n >= 2 {
+			c.gpio.Store(int32(report[1]))
+		}
+	}
+}
+
+/* Current level, 0 or 1, of GPIO pin num, 1 thru 8. */
+
+func (c *cm108_input_s) pin(num int) int {
+	return int(c.gpio.Load()>>(num-1)) & 1
+}
+
+func (c *cm108_input_s) close() {
+	c.r.Close()
+}
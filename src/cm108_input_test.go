@@ -0,0 +1,49 @@
+package direwolf
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_cm108_input(t *testing.T) {
+	var r, w = io.Pipe()
+	var c = cm108_input_watch(r)
+
+	t.Cleanup(c.close)
+
+	assert.Equal(t, 0, c.pin(1), "low until the first report")
+
+	w.Write([]byte{0x00, 0x04, 0x00, 0x00}) //nolint:errcheck
+	assert.Eventually(t, func() bool { return c.pin(3) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, c.pin(1))
+
+	w.Write([]byte{0x02, 0x01, 0x00, 0x00}) //nolint:errcheck
+	assert.Eventually(t, func() bool { return c.pin(1) == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, 0, c.pin(3))
+}
+
+func TestGetInputRealCM108(t *testing.T) {
+	var r, w = io.Pipe()
+
+	var cfg audio_s
+	cfg.chan_medium[0] = MEDIUM_RADIO
+	cfg.achan[0].ictrl[ICTYPE_TXINH].method = PTT_METHOD_CM108
+	cfg.achan[0].ictrl[ICTYPE_TXINH].in_gpio_num = 2
+	cfg.achan[0].ictrl[ICTYPE_TXINH].invert = true
+	save_audio_config_p = &cfg
+	cm108_in[0][ICTYPE_TXINH] = cm108_input_watch(r)
+
+	t.Cleanup(func() {
+		cm108_in[0][ICTYPE_TXINH].close()
+		cm108_in[0][ICTYPE_TXINH] = nil
+		save_audio_config_p = nil
+	})
+
+	assert.Equal(t, 1, get_input_real(ICTYPE_TXINH, 0), "active low, and low until the first report")
+
+	w.Write([]byte{0x00, 0x02, 0x00, 0x00}) //nolint:errcheck
+	assert.Eventually(t, func() bool { return get_input_real(ICTYPE_TXINH, 0) == 0 }, time.Second, time.Millisecond)
+}
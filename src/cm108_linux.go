@@ -84,6 +84,8 @@ import (
 	"os"
 	"regexp"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/jochenvg/go-udev"
 	"golang.org/x/sys/unix"
@@ -432,6 +434,107 @@ func cm108_find_ptt(output_audio_device string) string {
 	return ptt_device
 }
 
+/*-------------------------------------------------------------------
+ *
+ * Name:	usb_radio_find
+ *
+ * Purpose:	Find the sound card and CAT serial port of a radio with
+ *		both on one USB cable, such as the IC-705 or IC-7300.
+ *
+ * Inputs:	model		- Radio name, e.g. "IC-705".
+ *
+ * Returns:	Audio device, like plughw:CARD=CODEC,DEV=0, and serial
+ *		port, like /dev/ttyACM0.  Empty strings if not found.
+ *
+ * Description:	The radio's name is in the USB product string (IC-705)
+ *		or serial number (the IC-7300's CP2102) of the serial port.
+ *		The sound card is either part of the same USB device or
+ *		behind the same hub inside the radio.
+ *		The first serial port is the one for CI-V.
+ *
+ *------------------------------------------------------------------*/
+
+func usb_radio_find(model string) (string, string) {
+	var u = udev.Udev{}
+
+	var e = u.NewEnumerate()
+	e.AddMatchSubsystem("tty")
+
+	var ttys, ttysErr = e.Devices()
+	if ttysErr != nil {
+		return "", ""
+	}
+
+	var port, usb_path, hub_path string
+
+	for _, dev := range ttys {
+		var usb = dev.ParentWithSubsystemDevtype("usb", "usb_device")
+		if usb == nil || dev.Devnode() == "" {
+			continue
+		}
+
+		if !strings.Contains(usb.SysattrValue("product"), model) && !strings.Contains(usb.SysattrValue("serial"), model) {
+			continue
+		}
+
+		if port == "" || dev.Devnode() < port {
+			port = dev.Devnode()
+			usb_path = usb.Syspath()
+
+			if hub := usb.Parent(); hub != nil {
+				hub_path = hub.Syspath()
+			}
+		}
+	}
+
+	if port == "" {
+		return "", ""
+	}
+
+	var e2 = u.NewEnumerate()
+	e2.AddMatchSubsystem("sound")
+
+	var cards, cardsErr = e2.Devices()
+	if cardsErr != nil {
+		return "", ""
+	}
+
+	// Same USB device first, so another sound card plugged into
+	// the same hub as an IC-705 isn't picked by mistake.
+
+	var audio, hub_audio string
+
+	for _, dev := range cards {
+		var usb = dev.ParentWithSubsystemDevtype("usb", "usb_device")
+		if !strings.HasPrefix(dev.Sysname(), "controlC") || usb == nil || dev.Parent() == nil {
+			continue
+		}
+
+		var card = dev.Parent().SysattrValue("id")
+		if card == "" {
+			continue
+		}
+
+		if usb.Syspath() == usb_path && audio == "" {
+			audio = "plughw:CARD=" + card + ",DEV=0"
+		}
+
+		if hub := usb.Parent(); hub != nil && hub.Syspath() == hub_path && hub_audio == "" {
+			hub_audio = "plughw:CARD=" + card + ",DEV=0"
+		}
+	}
+
+	if audio == "" {
+		audio = hub_audio
+	}
+
+	if audio == "" {
+		return "", ""
+	}
+
+	return audio, port
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:	CM108SetGPIOPin
@@ -449,13 +552,10 @@ func cm108_find_ptt(output_audio_device string) string {
  *
  * Errors:	A descriptive error message will be printed for any problem.
  *
- * Shortcut:	For our initial implementation we are making the simplifying
- *		restriction of using only one GPIO pin per device and limit
- *		configuration to PTT only.
- *		Longer term, we might want to have DCD, and maybe other
- *		controls thru the same chip.
- *		In this case, we would need to retain bit masks for each
- *		device so new data can be merged with old before sending it out.
+ * Description:	We can't change a single pin, only write all of them at once.
+ *		The last bit masks written to each device are kept, and the
+ *		new pin merged in, so PTT, DCD, and CON can share one device
+ *		without disturbing each other.
  *
  *------------------------------------------------------------------*/
 
@@ -474,12 +574,55 @@ func CM108SetGPIOPin(name string, num int, state int) int {
 		return (-1)
 	}
 
-	var iomask = 1 << (num - 1)     // 0=input, 1=output
-	var iodata = state << (num - 1) // 0=low, 1=high
+	cm108_pins_mutex.Lock()
+	defer cm108_pins_mutex.Unlock()
+
+	var iomask, iodata = cm108_merge(name, num, state)
 
 	return cm108_write(name, iomask, iodata)
 } /* end CM108SetGPIOPin */
 
+/*
+ * Last direction mask and output data for each device.
+ * Pins we have never set stay as inputs.
+ */
+
+type cm108_pins_s struct {
+	iomask int // 0=input, 1=output
+	iodata int // 0=low, 1=high
+}
+
+var cm108_pins = make(map[string]*cm108_pins_s)
+var cm108_pins_mutex sync.Mutex
+
+/* Merge one pin into what was last written to the device.  Caller holds cm108_pins_mutex. */
+
+func cm108_merge(name string, num int, state int) (int, int) {
+	var pins, ok = cm108_pins[name]
+	if !ok {
+		pins = new(cm108_pins_s)
+		cm108_pins[name] = pins
+	}
+
+	var bit = 1 << (num - 1)
+
+	pins.iomask |= bit
+	pins.iodata = (pins.iodata &^ bit) | (state << (num - 1))
+
+	return pins.iomask, pins.iodata
+}
+
+/* Open a device, such as /dev/hidraw2, to read its GPIO inputs.  See cm108_input.go. */
+
+func cm108_input_open(name string) (*cm108_input_s, error) {
+	var fd, err = os.Open(name) //nolint:gosec // This comes from user-supplied config
+	if err != nil {
+		return nil, err
+	}
+
+	return cm108_input_watch(fd), nil
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:	cm108_write
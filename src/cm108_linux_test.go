@@ -0,0 +1,28 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_cm108_merge(t *testing.T) {
+	t.Cleanup(func() { delete(cm108_pins, "/dev/hidrawtest") })
+
+	var iomask, iodata = cm108_merge("/dev/hidrawtest", 3, 1) // PTT on.
+	assert.Equal(t, 0x04, iomask)
+	assert.Equal(t, 0x04, iodata)
+
+	iomask, iodata = cm108_merge("/dev/hidrawtest", 1, 1) // DCD on, PTT still on.
+	assert.Equal(t, 0x05, iomask)
+	assert.Equal(t, 0x05, iodata)
+
+	iomask, iodata = cm108_merge("/dev/hidrawtest", 3, 0) // PTT off, DCD still on.
+	assert.Equal(t, 0x05, iomask)
+	assert.Equal(t, 0x01, iodata)
+
+	iomask, iodata = cm108_merge("/dev/hidrawother", 2, 1) // Other devices are separate.
+	assert.Equal(t, 0x02, iomask)
+	assert.Equal(t, 0x02, iodata)
+	delete(cm108_pins, "/dev/hidrawother")
+}
@@ -2,6 +2,8 @@
 
 package direwolf
 
+import "errors"
+
 func cm108_find_ptt(_ string) string {
 	return ""
 }
@@ -9,3 +11,11 @@ func cm108_find_ptt(_ string) string {
 func CM108SetGPIOPin(_ string, _ int, _ int) int {
 	return -1
 }
+
+func cm108_input_open(_ string) (*cm108_input_s, error) {
+	return nil, errors.New("CM108 GPIO input is only supported on Linux")
+}
+
+func usb_radio_find(_ string) (string, string) {
+	return "", ""
+}
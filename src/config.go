@@ -16,17 +16,21 @@ package direwolf
 import (
 	"bufio"
 	"fmt"
+	"io"
 	"math"
 	"net"
+	"net/url"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 	"unicode"
 
-	"github.com/tzneal/coordconv"
+	"github.com/doismellburning/samoyed/src/geo"
 )
 
 const DEFAULT_GPSD_PORT = 2947 // Taken from gps.h
@@ -55,6 +59,16 @@ const (
 	SENDTO_RECV
 )
 
+/* When DELAY counts from, for the first beacon. */
+
+type beacon_after_e int
+
+const (
+	BEACON_AFTER_START beacon_after_e = iota
+	BEACON_AFTER_GPS                  /* First GPS fix. */
+	BEACON_AFTER_IGATE                /* Logged in to the IGate server. */
+)
+
 const MAX_BEACONS = 30
 const MAX_KISS_TCP_PORTS = (MAX_RADIO_CHANS + 1)
 
@@ -82,8 +96,19 @@ type beacon_s struct {
 
 	sendto_chan int /* Transmit or simulated receive channel for above.  Should be 0 for IGate. */
 
+	also_igate bool /* Send to the IGate server as well as the channel, e.g. SENDTO=0,IG. */
+
 	delay int /* Seconds to delay before first transmission. */
 
+	after beacon_after_e /* Count the delay from this rather than starting up. */
+
+	waiting bool /* Still waiting for the above to happen. */
+
+	jitter int /* Seconds, either way, to move each transmission at random. */
+	/* So a fleet of trackers turned on together don't all beacon at once. */
+
+	jitter_off time.Duration /* Random offset for the next transmission. */
+
 	slot int /* Seconds after hour for slotted time beacons. */
 	/* If specified, it overrides any 'delay' value. */
 
@@ -130,12 +155,29 @@ type beacon_s struct {
 	offset float64 /* MHz. */
 
 	comment    string /* Comment or empty. */
+	rf_comment string /* Instead of comment, for radio or IGate, if not empty. */
+	is_comment string
 	commentcmd string /* Command to append more to Comment or empty. */
+
+	riginfo bool /* Use frequency and mode from RIGPOLL.  See rig_poll.go. */
+
+	max_hdop float64 /* TBEACON: No position while GPS HDOP is above this.  0 for no limit. */
+	nofix    string  /* TBEACON: Status to send instead of position without a fix.  Empty for nothing. */
+
+	nofix_sent bool /* Status already sent since the fix was lost. */
 }
 
 type misc_config_s struct {
+	query query_config_s /* Answering APRS queries.  See query.go. */
+
+	vote []*vote_config_s /* Channels hearing the same frequency.  See vote.go. */
+
 	agwpe_port int /* TCP Port number for the "AGW TCPIP Socket Interface" */
 
+	http_port int /* TCP Port number for HTTP, e.g. WebSocket packet stream.  0 for none. */
+
+	http_origins []string /* Other web pages allowed to open a WebSocket, or "*" for any. */
+
 	// Previously we allowed only a single TCP port for KISS.
 	// An increasing number of people want to run multiple radios.
 	// Unfortunately, most applications don't know how to deal with multi-radio TNCs.
@@ -155,6 +197,19 @@ type misc_config_s struct {
 	kiss_port [MAX_KISS_TCP_PORTS]int /* TCP Port number for the "TCP KISS" protocol. */
 	kiss_chan [MAX_KISS_TCP_PORTS]int /* Radio Channel number for this port or -1 for all.  */
 
+	// KISSCLIENT is the other way around.  We connect to a KISS TCP
+	// server, such as a remote TNC or another instance behind NAT,
+	// and treat it like a client application attached to KISSPORT.
+
+	kiss_client_addr [MAX_KISS_TCP_PORTS]string /* host:port to connect to.  Empty if not used. */
+	kiss_client_chan [MAX_KISS_TCP_PORTS]int    /* Radio channel or -1 for all. */
+
+	bridge_port      int    /* TCP port for other instances to BCHANNEL to.  0 for none. */
+	bridge_cert      string /* Certificate and key files for TLS. */
+	bridge_key       string
+	bridge_client_ca string /* CA which signed the other instances' certificates. */
+	bridge_insecure  bool   /* Plain TCP, from anyone, if explicitly asked for. */
+
 	kiss_copy      bool /* Data from network KISS client is copied to all others. */
 	enable_kiss_pt bool /* Enable pseudo terminal for KISS. */
 	/* Want this to be off by default because it hangs */
@@ -183,6 +238,17 @@ type misc_config_s struct {
 	gpsd_port int /* Port number for gpsd server. */
 	/* Default is  2947. */
 
+	gps_fixed_lat float64 /* Surveyed location when there is no GPS fix, or G_UNKNOWN. */
+	gps_fixed_lon float64
+	gps_fixed_alt float64 /* meters, or G_UNKNOWN. */
+
+	gps_api       bool   /* Accept location from the control socket or HTTP server. */
+	gps_api_token string /* Needed to POST /position.  Empty means it can't. */
+
+	gps_priority []dwgps_source_t /* Order to try position sources.  Empty for default. */
+
+	gps_timeout int /* Seconds before a GPS is considered stale.  0 for never. */
+
 	waypoint_serial_port string /* Serial port name for sending NMEA waypoint sentences */
 	/* to a GPS map display or other mapping application. */
 	/* e.g. COM22, /dev/ttyACM0 */
@@ -193,12 +259,28 @@ type misc_config_s struct {
 
 	waypoint_udp_portnum int /* UDP port. */
 
+	waypoint_tcp_port int /* Listen for applications to connect here. */
+
 	waypoint_formats int /* Which sentence formats should be generated? */
 
 	log_daily_names bool /* True to generate new log file each day. */
 
 	log_path string /* Either directory or full file name depending on above. */
 
+	log_format string /* "csv" or "json". */
+
+	log_max_size  int64 /* Start a new log file after this many bytes.  0 for no limit. */
+	log_keep      int   /* Number of old log files to keep.  0 for all. */
+	log_keep_days int   /* Remove old log files not written for this many days.  0 for never. */
+	log_compress  bool  /* gzip old log files. */
+
+	control_socket string /* Unix socket path for runtime queries, e.g. "show channels".  Empty to disable. */
+
+	alert_hooks         []string /* URLs or commands to send alerts to.  See alert.go. */
+	alert_audio         bool     /* Alert for audio input failure. */
+	alert_igate_minutes int      /* Alert after IGate not connected for this long.  0 for never. */
+	alert_norx_minutes  int      /* Alert after nothing decoded for this long.  0 for never. */
+
 	dns_sd_enabled bool   /* DNS Service Discovery announcement enabled. */
 	dns_sd_name    string /* Name announced on dns-sd; defaults to "Dire Wolf on <hostname>" */
 
@@ -211,6 +293,14 @@ type misc_config_s struct {
 	sb_turn_angle int  /* degrees */
 	sb_turn_slope int  /* degrees * MPH */
 
+	sb_turn_decay    int /* Seconds for turn threshold to decay to half.  0 for none. */
+	sb_min_turn_dist int /* Meters to travel before corner pegging.  0 for none. */
+
+	geofence []*geofence_s /* Areas where beacons change.  See geofence.go. */
+
+	filecast     []*filecast_s /* Files to broadcast.  See filecast.go. */
+	filecast_dir string        /* Where to save files received.  Empty for none. */
+
 	// AX.25 connected mode.
 
 	frack int /* Number of seconds to wait for ack to transmission. */
@@ -238,6 +328,10 @@ type misc_config_s struct {
 
 	noxid_count int /* Number of station addresses in array above. */
 
+	pbbs_channel int    /* Mailbox for connected stations.  See pbbs.go. */
+	pbbs_mycall  string /* Its callsign.  Empty for none. */
+	pbbs_file    string /* Where messages are kept. */
+
 	// Beacons.
 
 	num_beacons int /* Number of beacons defined. */
@@ -682,97 +776,75 @@ func check_via_path(via_path string) int {
 
 /*-------------------------------------------------------------------
  *
- * Name:        split
- *
- * Purpose:     Separate a line into command and parameters.
+ * Name:        config_open
  *
- * Inputs:	string		- Complete command line to start process.
- *				  nil for subsequent calls.
+ * Purpose:     Open the configuration file.
  *
- *		rest_of_line	- Caller wants remainder of line, not just
- *				  the next parameter.
+ * Inputs:	fname	- File name, or:
  *
- * Returns:	Pointer to next part with any quoting removed.
+ *			  -		Standard input.
+ *			  env:NAME	Contents of environment variable NAME.
  *
- * Description:	the configuration file started out very simple and strtok
- *		was used to split up the lines.  As more complicated options
- *		were added, there were several different situations where
- *		parameter values might contain spaces.  These were handled
- *		inconsistently in different places.  In version 1.3, we now
- *		treat them consistently in one place.
+ *			  The last two are for containers, where it's easier
+ *			  to pass the configuration in than to mount a file.
  *
+ * Errors:	Exit if it can't be read.  No point going on without it.
  *
  *--------------------------------------------------------------------*/
 
-const MAXCMDLEN = 1200
+const CONFIG_STDIN = "-"
+const CONFIG_ENV_PREFIX = "env:"
 
-var splitCmd string
+func config_open(fname string) io.ReadCloser {
+	if fname == CONFIG_STDIN {
+		dw_printf("\nReading config file from standard input\n")
 
-func split(str string, rest_of_line bool) string {
-	/*
-	 * If string is provided, make a copy.
-	 * Drop any CRLF at the end.
-	 * Change any tabs to spaces so we don't have to check for it later.
-	 */
-	if str != "" {
-		splitCmd = ""
+		return io.NopCloser(os.Stdin)
+	}
 
-		for _, c := range str {
-			switch c {
-			case '\t':
-				splitCmd += " "
-			case '\n', '\r':
-				// Nothing
-			default:
-				splitCmd += string(c)
-			}
+	if name, found := strings.CutPrefix(fname, CONFIG_ENV_PREFIX); found {
+		var content, ok = os.LookupEnv(name)
+		if !ok {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("ERROR - Environment variable %s, for the configuration, is not set.\n", name)
+			os.Exit(1)
 		}
+
+		dw_printf("\nReading config from environment variable %s\n", name)
+
+		return io.NopCloser(strings.NewReader(content))
 	}
 
 	/*
-	 * Get next part, separated by whitespace, keeping spaces within quotes.
-	 * Quotation marks inside need to be doubled.
+	 * There have been cases where someone had multiple direwolf.conf files
+	 * in different places and wasted a lot of time and effort because the
+	 * wrong one was being used.
+	 *
+	 * In version 1.8, I will attempt to display the full absolute path so there
+	 * is no confusion.
 	 */
-
-	splitCmd = strings.TrimSpace(splitCmd)
-
-	var token strings.Builder
-	var in_quotes = false
-
-	var parsedLen int
-
-outerLoop:
-	for parsedLen = 0; parsedLen < len(splitCmd); parsedLen++ {
-		var c = splitCmd[parsedLen]
-		switch c {
-		case '"':
-			if in_quotes {
-				if parsedLen+1 < len(splitCmd) && splitCmd[parsedLen+1] == '"' {
-					token.WriteString(string(c))
-					parsedLen++
-				} else {
-					in_quotes = false
-				}
-			} else {
-				in_quotes = true
-			}
-		case ' ':
-			if in_quotes || rest_of_line {
-				token.WriteString(string(c))
-			} else {
-				break outerLoop
-			}
-		default:
-			token.WriteString(string(c))
-		}
+	var absFilePath, absFilePathErr = filepath.Abs(fname)
+	if absFilePathErr != nil {
+		dw_printf("Error getting absolute path for config file %s: %s\n", fname, absFilePathErr)
+		os.Exit(1)
 	}
 
-	splitCmd = splitCmd[parsedLen:]
+	var fp, fpErr = os.Open(absFilePath) //nolint:gosec
+	if fpErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("ERROR - Could not open configuration file %s: %s\n", absFilePath, fpErr)
+		dw_printf("Try using -c command line option for alternate location.\n")
+		dw_printf("A sample direwolf.conf file should be found in one of:\n")
+		dw_printf("    /usr/local/share/doc/direwolf/conf/\n")
+		dw_printf("    /usr/share/doc/direwolf/conf/\n")
+		rtfm()
+		os.Exit(1)
+	}
 
-	// dw_printf("split out: '%s'\n", token);
+	dw_printf("\nReading config file %s\n", absFilePath)
 
-	return token.String()
-} /* end split */
+	return fp
+}
 
 /*-------------------------------------------------------------------
  *
@@ -782,6 +854,7 @@ outerLoop:
  *
  * Inputs:	fname		- Name of configuration file.  Either default of direwolf.conf
  *					or specified by user with -c command line option.
+ *					See config_open for the special names.
  *
  * Outputs:	p_audio_config		- Radio channel parameters stored here.
  *
@@ -821,8 +894,11 @@ type parseState struct {
 	channel int
 	adevice int
 	line    int
-	text    string // current raw scanner line
-	keyword string // original (not uppercased) keyword token
+	text    string       // current raw scanner line
+	lex     *configLexer // tokenizer for the rest of the current line
+	keyword string       // original (not uppercased) keyword token
+
+	deprecated map[string][]int // lines where each deprecated option was used, see config_deprecated
 
 	audio *audio_s
 	digi  *digi_config_s
@@ -842,6 +918,7 @@ var configHandlers = map[string]configHandler{
 	"CHANNEL":        handleCHANNEL,
 	"ICHANNEL":       handleICHANNEL,
 	"NCHANNEL":       handleNCHANNEL,
+	"BCHANNEL":       handleBCHANNEL,
 	"MYCALL":         handleMYCALL,
 	"MODEM":          handleMODEM,
 	"DTMF":           handleDTMF,
@@ -849,12 +926,23 @@ var configHandlers = map[string]configHandler{
 	"PTT":            handlePTTDCDCON,
 	"DCD":            handlePTTDCDCON,
 	"CON":            handlePTTDCDCON,
+	"SEQ1":           handlePTTDCDCON,
+	"SEQ2":           handlePTTDCDCON,
+	"INDICATOR":      handleINDICATOR,
+	"TXSEQ":          handleTXSEQ,
+	"RIGPOLL":        handleRIGPOLL,
+	"PROFILE":        handlePROFILE,
 	"TXINH":          handleTXINH,
 	"DWAIT":          handleDWAIT,
 	"SLOTTIME":       handleSLOTTIME,
 	"PERSIST":        handlePERSIST,
 	"TXDELAY":        handleTXDELAY,
 	"TXTAIL":         handleTXTAIL,
+	"TXTIMEOUT":      handleTXTIMEOUT,
+	"RESPONSEGAP":    handleRESPONSEGAP,
+	"TXPRIORITY":     handleTXPRIORITY,
+	"TXSLICE":        handleTXSLICE,
+	"CWID":           handleCWID,
 	"FULLDUP":        handleFULLDUP,
 	"SPEECH":         handleSPEECH,
 	"FX25TX":         handleFX25TX,
@@ -883,6 +971,10 @@ var configHandlers = map[string]configHandler{
 	"TTERR":          handleTTERR,
 	"TTSTATUS":       handleTTSTATUS,
 	"TTCMD":          handleTTCMD,
+	"DTMFPIN":        handleDTMFPIN,
+	"DTMFCMD":        handleDTMFCMD,
+	"QUERY":          handleQUERY,
+	"VOTE":           handleVOTE,
 	"IGSERVER":       handleIGSERVER,
 	"IGLOGIN":        handleIGLOGIN,
 	"IGTXVIA":        handleIGTXVIA,
@@ -891,7 +983,11 @@ var configHandlers = map[string]configHandler{
 	"IGMSP":          handleIGMSP,
 	"SATGATE":        handleSATGATE,
 	"AGWPORT":        handleAGWPORT,
+	"HTTPPORT":       handleHTTPPORT,
+	"HTTPORIGIN":     handleHTTPORIGIN,
 	"KISSPORT":       handleKISSPORT,
+	"KISSCLIENT":     handleKISSCLIENT,
+	"BRIDGEPORT":     handleBRIDGEPORT,
 	"NULLMODEM":      handleNULLMODEM,
 	"SERIALKISS":     handleNULLMODEM,
 	"SERIALKISSPOLL": handleSERIALKISSPOLL,
@@ -903,6 +999,18 @@ var configHandlers = map[string]configHandler{
 	"WAYPOINT":       handleWAYPOINT,
 	"LOGDIR":         handleLOGDIR,
 	"LOGFILE":        handleLOGFILE,
+	"LOGFORMAT":      handleLOGFORMAT,
+	"LOGROTATE":      handleLOGROTATE,
+	"CONTROLSOCKET":  handleCONTROLSOCKET,
+	"ALERT":          handleALERT,
+	"ALERTHOOK":      handleALERTHOOK,
+	"GEOFENCE":       handleGEOFENCE,
+	"FILECAST":       handleFILECAST,
+	"FILECASTDIR":    handleFILECASTDIR,
+	"GPSAPI":         handleGPSAPI,
+	"GPSFIXED":       handleGPSFIXED,
+	"GPSPRIORITY":    handleGPSPRIORITY,
+	"GPSTIMEOUT":     handleGPSTIMEOUT,
 	"BEACON":         handleBEACON,
 	"PBEACON":        handleXBEACON,
 	"OBEACON":        handleXBEACON,
@@ -919,6 +1027,7 @@ var configHandlers = map[string]configHandler{
 	"MAXV22":         handleMAXV22,
 	"V20":            handleV20,
 	"NOXID":          handleNOXID,
+	"PBBS":           handlePBBS,
 }
 
 func config_init(fname string, p_audio_config *audio_s,
@@ -937,6 +1046,234 @@ func config_init(fname string, p_audio_config *audio_s,
 	/*
 	 * First apply defaults.
 	 */
+	config_set_defaults(p_audio_config, p_digi_config, p_tt_config, p_igate_config, p_misc_config)
+
+	// Persistent context as we work through the file
+	var ps = &parseState{
+		channel:    0,
+		adevice:    0,
+		line:       0,
+		text:       "",
+		lex:        nil,
+		keyword:    "",
+		deprecated: nil,
+		audio:      p_audio_config,
+		digi:       p_digi_config,
+		cdigi:      p_cdigi_config,
+		tt:         p_tt_config,
+		igate:      p_igate_config,
+		misc:       p_misc_config,
+	}
+
+	/*
+	 * Try to extract options from a file.
+	 */
+
+	var fp = config_open(fname)
+	defer fp.Close()
+
+	var scanner = bufio.NewScanner(fp)
+	for scanner.Scan() {
+		ps.text = scanner.Text()
+		ps.line++
+
+		config_line(ps)
+	}
+
+	config_deprecation_summary(ps)
+
+	/*
+	 * Heuristic warnings come first, while the IGate login and
+	 * beacons are still as written, before the checks below
+	 * start disabling things.
+	 */
+
+	for _, warning := range config_lint(ps) {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: Warning: %s\n", warning)
+	}
+
+	/*
+	 * A little error checking for option interactions.
+	 */
+
+	/*
+	 * Require that MYCALL be set when digipeating or IGating.
+	 *
+	 * Suggest that beaconing be enabled when digipeating.
+	 */
+
+	for i := range MAX_TOTAL_CHANS {
+		for j := range MAX_TOTAL_CHANS {
+			/* APRS digipeating. */
+			if ps.digi.enabled[i][j] {
+				if IsNoCall(ps.audio.mycall[i]) {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Config file: MYCALL must be set for receive channel %d before digipeating is allowed.\n", i)
+					ps.digi.enabled[i][j] = false
+				}
+
+				if IsNoCall(ps.audio.mycall[j]) {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Config file: MYCALL must be set for transmit channel %d before digipeating is allowed.\n", j)
+					ps.digi.enabled[i][j] = false
+				}
+
+				var b = 0
+
+				for k := 0; k < ps.misc.num_beacons; k++ {
+					if ps.misc.beacon[k].sendto_chan == j {
+						b++
+					}
+				}
+
+				if b == 0 {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Config file: Beaconing should be configured for channel %d when digipeating is enabled.\n", j)
+					// It's a recommendation, not a requirement.
+					// Was there some good reason to turn it off in earlier version?
+					//ps.digi.enabled[i][j] = 0;
+				}
+			}
+
+			/* Connected mode digipeating. */
+
+			if i < MAX_RADIO_CHANS && j < MAX_RADIO_CHANS && ps.cdigi.enabled[i][j] {
+				if IsNoCall(ps.audio.mycall[i]) {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Config file: MYCALL must be set for receive channel %d before digipeating is allowed.\n", i)
+					ps.cdigi.enabled[i][j] = false
+				}
+
+				if IsNoCall(ps.audio.mycall[j]) {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Config file: MYCALL must be set for transmit channel %d before digipeating is allowed.\n", j)
+					ps.cdigi.enabled[i][j] = false
+				}
+
+				var b = 0
+
+				for k := 0; k < ps.misc.num_beacons; k++ {
+					if ps.misc.beacon[k].sendto_chan == j {
+						b++
+					}
+				}
+
+				if b == 0 {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Config file: Beaconing should be configured for channel %d when digipeating is enabled.\n", j)
+					// It's a recommendation, not a requirement.
+				}
+			}
+		}
+
+		/* When IGate is enabled, all radio channels must have a callsign associated. */
+
+		if len(ps.igate.t2_login) > 0 &&
+			(ps.audio.chan_medium[i] == MEDIUM_RADIO || ps.audio.chan_medium[i] == MEDIUM_NETTNC) {
+			if IsNoCall(ps.audio.mycall[i]) {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file: MYCALL must be set for receive channel %d before Rx IGate is allowed.\n", i)
+
+				ps.igate.t2_login = ""
+			}
+			// Currently we can have only one transmit channel.
+			// This might be generalized someday to allow more.
+			if ps.igate.tx_chan >= 0 && IsNoCall(ps.audio.mycall[ps.igate.tx_chan]) {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file: MYCALL must be set for transmit channel %d before Tx IGate is allowed.\n", i)
+
+				ps.igate.tx_chan = -1
+			}
+		}
+	}
+
+	// Apply default IS>RF IGate filter if none specified.  New in 1.4.
+	// This will handle eventual case of multiple transmit channels.
+
+	if len(ps.igate.t2_login) > 0 {
+		for j := range MAX_TOTAL_CHANS {
+			if ps.audio.chan_medium[j] == MEDIUM_RADIO || ps.audio.chan_medium[j] == MEDIUM_NETTNC {
+				if ps.digi.filter_str[MAX_TOTAL_CHANS][j] == "" {
+					ps.digi.filter_str[MAX_TOTAL_CHANS][j] = "i/180"
+				}
+			}
+		}
+	}
+
+	// Terrible hack.  But what can we do?
+
+	if ps.misc.maxv22 < 0 {
+		ps.misc.maxv22 = ps.misc.retry / 3
+	}
+} /* end config_init */
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        config_line
+ *
+ * Purpose:     Process one line of the configuration file.
+ *
+ * Inputs:	ps.text	- The line.  Blank lines and comments are ignored.
+ *
+ * Description:	Split out of config_init so a PRESET can feed the lines
+ *		it expands to through the same handlers.
+ *
+ *--------------------------------------------------------------------*/
+
+func config_line(ps *parseState) {
+	if ps.text == "" || ps.text[0] == '#' || ps.text[0] == '*' {
+		return
+	}
+
+	ps.lex = newConfigLexer(ps.text)
+
+	var t = ps.lex.next(false)
+
+	if t == "" {
+		return
+	}
+
+	ps.keyword = t
+	var keyword = strings.ToUpper(t)
+	// Some config keywords actually incorporate a device number, e.g. ADEVICE0
+	if strings.HasPrefix(keyword, "ADEVICE") {
+		handleADEVICE(ps)
+	} else if strings.HasPrefix(keyword, "PAIDEVICE") {
+		handlePAIDEVICE(ps)
+	} else if strings.HasPrefix(keyword, "PAODEVICE") {
+		handlePAODEVICE(ps)
+	} else if keyword == "PRESET" {
+		// Not in configHandlers because it calls back into here.
+		handlePRESET(ps)
+	} else if handler, ok := configHandlers[keyword]; ok {
+		handler(ps)
+	} else {
+		/*
+		 * Invalid command.
+		 */
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: Unrecognized command '%s' on line %d.\n", t, ps.line)
+	}
+} /* end config_line */
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        config_set_defaults
+ *
+ * Purpose:     Apply the compiled-in default values for all configuration
+ *		settings, before any configuration file is read.
+ *
+ * Description:	Split out of config_init so the same defaults can be used
+ *		to generate an annotated sample configuration file.
+ *
+ *--------------------------------------------------------------------*/
+
+func config_set_defaults(p_audio_config *audio_s,
+	p_digi_config *digi_config_s,
+	p_tt_config *tt_config_s,
+	p_igate_config *igate_config_s,
+	p_misc_config *misc_config_s) {
 	p_audio_config.igate_vchannel = -1 // none.
 
 	/* First audio device is always available with defaults. */
@@ -1005,10 +1342,15 @@ func config_init(fname string, p_audio_config *audio_s,
 		p_audio_config.achan[channel].txdelay = DEFAULT_TXDELAY
 		p_audio_config.achan[channel].txtail = DEFAULT_TXTAIL
 		p_audio_config.achan[channel].fulldup = DEFAULT_FULLDUP
+		p_audio_config.achan[channel].response_gap = DEFAULT_RESPONSE_GAP
+		p_audio_config.achan[channel].response_max = DEFAULT_RESPONSE_MAX
 	}
 
 	p_audio_config.fx25_auto_enable = AX25_N2_RETRY_DEFAULT / 2
 
+	p_audio_config.tx_priority = tx_priority_defaults()
+	p_audio_config.tx_slice = DEFAULT_TXSLICE
+
 	/* First channel should always be valid. */
 	/* If there is no ADEVICE, it uses default device in mono. */
 
@@ -1058,6 +1400,11 @@ func config_init(fname string, p_audio_config *audio_s,
 		p_misc_config.kiss_chan[i] = -1
 	}
 
+	for i := range MAX_KISS_TCP_PORTS {
+		p_misc_config.kiss_client_addr[i] = ""
+		p_misc_config.kiss_client_chan[i] = -1
+	}
+
 	p_misc_config.kiss_port[0] = DEFAULT_KISS_PORT
 	p_misc_config.kiss_chan[0] = -1 // all channels.
 
@@ -1093,10 +1440,24 @@ func config_init(fname string, p_audio_config *audio_s,
 	p_misc_config.kiss_serial_poll = 0
 
 	p_misc_config.gpsnmea_port = ""
+	p_misc_config.gps_fixed_lat = G_UNKNOWN
+	p_misc_config.gps_fixed_lon = G_UNKNOWN
+	p_misc_config.gps_fixed_alt = G_UNKNOWN
+	p_misc_config.gps_timeout = 30
 	p_misc_config.waypoint_serial_port = ""
 
 	p_misc_config.log_daily_names = false
 	p_misc_config.log_path = ""
+	p_misc_config.log_format = "csv"
+	p_misc_config.log_max_size = 0
+	p_misc_config.log_keep = 0
+	p_misc_config.log_keep_days = 0
+	p_misc_config.log_compress = false
+
+	p_misc_config.alert_hooks = nil
+	p_misc_config.alert_audio = false
+	p_misc_config.alert_igate_minutes = 0
+	p_misc_config.alert_norx_minutes = 0
 
 	/* connected mode. */
 
@@ -1118,213 +1479,7 @@ func config_init(fname string, p_audio_config *audio_s,
 	/* Might work with a partial v2.2 implementation */
 	/* on the other end. */
 	p_misc_config.noxid_count = 0
-
-	// Persistent context as we work through the file
-	var ps = &parseState{
-		channel: 0,
-		adevice: 0,
-		line:    0,
-		text:    "",
-		keyword: "",
-		audio:   p_audio_config,
-		digi:    p_digi_config,
-		cdigi:   p_cdigi_config,
-		tt:      p_tt_config,
-		igate:   p_igate_config,
-		misc:    p_misc_config,
-	}
-
-	/*
-	 * Try to extract options from a file.
-	 */
-
-	/*
-	 * There have been cases where someone had multiple direwolf.conf files
-	 * in different places and wasted a lot of time and effort because the
-	 * wrong one was being used.
-	 *
-	 * In version 1.8, I will attempt to display the full absolute path so there
-	 * is no confusion.
-	 */
-	var absFilePath, absFilePathErr = filepath.Abs(fname)
-	if absFilePathErr != nil {
-		dw_printf("Error getting absolute path for config file %s: %s\n", fname, absFilePathErr)
-		os.Exit(1)
-	}
-
-	var fp, fpErr = os.Open(absFilePath) //nolint:gosec
-	if fpErr != nil {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("ERROR - Could not open configuration file %s: %s\n", absFilePath, fpErr)
-		dw_printf("Try using -c command line option for alternate location.\n")
-		dw_printf("A sample direwolf.conf file should be found in one of:\n")
-		dw_printf("    /usr/local/share/doc/direwolf/conf/\n")
-		dw_printf("    /usr/share/doc/direwolf/conf/\n")
-		rtfm()
-		os.Exit(1)
-	} else {
-		defer fp.Close()
-	}
-
-	dw_printf("\nReading config file %s\n", absFilePath)
-
-	var scanner = bufio.NewScanner(fp)
-	for scanner.Scan() {
-		ps.text = scanner.Text()
-		ps.line++
-
-		if ps.text == "" || ps.text[0] == '#' || ps.text[0] == '*' {
-			continue
-		}
-
-		var t = split(ps.text, false)
-
-		if t == "" {
-			continue
-		}
-
-		ps.keyword = t
-		var keyword = strings.ToUpper(t)
-		// Some config keywords actually incorporate a device number, e.g. ADEVICE0
-		if strings.HasPrefix(keyword, "ADEVICE") {
-			if handleADEVICE(ps) {
-				continue
-			}
-		} else if strings.HasPrefix(keyword, "PAIDEVICE") {
-			if handlePAIDEVICE(ps) {
-				continue
-			}
-		} else if strings.HasPrefix(keyword, "PAODEVICE") {
-			if handlePAODEVICE(ps) {
-				continue
-			}
-		} else if handler, ok := configHandlers[keyword]; ok {
-			if handler(ps) {
-				continue
-			}
-		} else {
-			/*
-			 * Invalid command.
-			 */
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file: Unrecognized command '%s' on line %d.\n", t, ps.line)
-		}
-	}
-
-	/*
-	 * A little error checking for option interactions.
-	 */
-
-	/*
-	 * Require that MYCALL be set when digipeating or IGating.
-	 *
-	 * Suggest that beaconing be enabled when digipeating.
-	 */
-
-	for i := range MAX_TOTAL_CHANS {
-		for j := range MAX_TOTAL_CHANS {
-			/* APRS digipeating. */
-			if ps.digi.enabled[i][j] {
-				if IsNoCall(ps.audio.mycall[i]) {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file: MYCALL must be set for receive channel %d before digipeating is allowed.\n", i)
-					ps.digi.enabled[i][j] = false
-				}
-
-				if IsNoCall(ps.audio.mycall[j]) {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file: MYCALL must be set for transmit channel %d before digipeating is allowed.\n", j)
-					ps.digi.enabled[i][j] = false
-				}
-
-				var b = 0
-
-				for k := 0; k < ps.misc.num_beacons; k++ {
-					if ps.misc.beacon[k].sendto_chan == j {
-						b++
-					}
-				}
-
-				if b == 0 {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file: Beaconing should be configured for channel %d when digipeating is enabled.\n", j)
-					// It's a recommendation, not a requirement.
-					// Was there some good reason to turn it off in earlier version?
-					//ps.digi.enabled[i][j] = 0;
-				}
-			}
-
-			/* Connected mode digipeating. */
-
-			if i < MAX_RADIO_CHANS && j < MAX_RADIO_CHANS && ps.cdigi.enabled[i][j] {
-				if IsNoCall(ps.audio.mycall[i]) {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file: MYCALL must be set for receive channel %d before digipeating is allowed.\n", i)
-					ps.cdigi.enabled[i][j] = false
-				}
-
-				if IsNoCall(ps.audio.mycall[j]) {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file: MYCALL must be set for transmit channel %d before digipeating is allowed.\n", j)
-					ps.cdigi.enabled[i][j] = false
-				}
-
-				var b = 0
-
-				for k := 0; k < ps.misc.num_beacons; k++ {
-					if ps.misc.beacon[k].sendto_chan == j {
-						b++
-					}
-				}
-
-				if b == 0 {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file: Beaconing should be configured for channel %d when digipeating is enabled.\n", j)
-					// It's a recommendation, not a requirement.
-				}
-			}
-		}
-
-		/* When IGate is enabled, all radio channels must have a callsign associated. */
-
-		if len(ps.igate.t2_login) > 0 &&
-			(ps.audio.chan_medium[i] == MEDIUM_RADIO || ps.audio.chan_medium[i] == MEDIUM_NETTNC) {
-			if IsNoCall(ps.audio.mycall[i]) {
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Config file: MYCALL must be set for receive channel %d before Rx IGate is allowed.\n", i)
-
-				ps.igate.t2_login = ""
-			}
-			// Currently we can have only one transmit channel.
-			// This might be generalized someday to allow more.
-			if ps.igate.tx_chan >= 0 && IsNoCall(ps.audio.mycall[ps.igate.tx_chan]) {
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Config file: MYCALL must be set for transmit channel %d before Tx IGate is allowed.\n", i)
-
-				ps.igate.tx_chan = -1
-			}
-		}
-	}
-
-	// Apply default IS>RF IGate filter if none specified.  New in 1.4.
-	// This will handle eventual case of multiple transmit channels.
-
-	if len(ps.igate.t2_login) > 0 {
-		for j := range MAX_TOTAL_CHANS {
-			if ps.audio.chan_medium[j] == MEDIUM_RADIO || ps.audio.chan_medium[j] == MEDIUM_NETTNC {
-				if ps.digi.filter_str[MAX_TOTAL_CHANS][j] == "" {
-					ps.digi.filter_str[MAX_TOTAL_CHANS][j] = "i/180"
-				}
-			}
-		}
-	}
-
-	// Terrible hack.  But what can we do?
-
-	if ps.misc.maxv22 < 0 {
-		ps.misc.maxv22 = ps.misc.retry / 3
-	}
-} /* end config_init */
+} /* end config_set_defaults */
 
 // handleADEVICE handles the ADEVICE[n] keyword.
 func handleADEVICE(ps *parseState) bool {
@@ -1340,6 +1495,9 @@ func handleADEVICE(ps *parseState) bool {
 	 *	This allows multiple modems (i.e. data speeds) on the same audio interface.
 	 *
 	 *			ADEVICEn   = n				-- Copy from different already defined channel.
+	 *
+	 *			ADEVICE    none				-- No audio at all.  Only the IGate, network
+	 *								   TNCs, and client applications.
 	 */
 	/* Note that ALSA name can contain comma such as hw:1,0 */
 	/* "ADEVICE" is equivalent to "ADEVICE0". */
@@ -1366,7 +1524,7 @@ func handleADEVICE(ps *parseState) bool {
 		ps.adevice = i
 	}
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Config file: Missing name of audio device for ADEVICE command on line %d.\n", ps.line)
@@ -1388,7 +1546,7 @@ func handleADEVICE(ps *parseState) bool {
 	// New case for release 1.8.
 
 	if t == "=" {
-		t = split("", false)
+		t = ps.lex.next(false)
 		text_color_set(DW_COLOR_ERROR)
 		if t == "" {
 			dw_printf("Config file: ADEVICE%d mapping syntax requires a source device number on line %d.\n", ps.adevice, ps.line)
@@ -1399,6 +1557,17 @@ func handleADEVICE(ps *parseState) bool {
 		return true
 	}
 
+	// No radio, e.g. an APRS-IS client or gateway on a server without a sound card.
+	// Also undoes the default device 0.
+
+	if strings.EqualFold(t, "none") {
+		ps.audio.adev[ps.adevice].defined = 0
+		ps.audio.chan_medium[ADEVFIRSTCHAN(ps.adevice)] = MEDIUM_NONE
+		ps.audio.chan_medium[ADEVFIRSTCHAN(ps.adevice)+1] = MEDIUM_NONE
+
+		return false
+	}
+
 	ps.audio.adev[ps.adevice].defined = 1
 
 	/* First channel of device is valid. */
@@ -1408,7 +1577,7 @@ func handleADEVICE(ps *parseState) bool {
 	ps.audio.adev[ps.adevice].adevice_in = t
 	ps.audio.adev[ps.adevice].adevice_out = t
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t != "" {
 		// Different audio devices for receive and transmit.
 		ps.audio.adev[ps.adevice].adevice_out = t
@@ -1419,6 +1588,8 @@ func handleADEVICE(ps *parseState) bool {
 
 // handlePAIDEVICE handles PAIDEVICE[n].
 func handlePAIDEVICE(ps *parseState) bool {
+	config_deprecated(ps, "PAIDEVICE")
+
 	// ps.keyword holds the original token e.g. "PAIDEVICE" or "PAIDEVICE1".
 	ps.adevice = 0
 	if len(ps.keyword) > 9 && unicode.IsDigit(rune(ps.keyword[9])) {
@@ -1433,7 +1604,7 @@ func handlePAIDEVICE(ps *parseState) bool {
 		return true
 	}
 
-	var t = split("", true)
+	var t = ps.lex.next(true)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Config file: Missing name of audio device for PAIDEVICE command on line %d.\n", ps.line)
@@ -1452,6 +1623,8 @@ func handlePAIDEVICE(ps *parseState) bool {
 
 // handlePAODEVICE handles PAODEVICE[n].
 func handlePAODEVICE(ps *parseState) bool {
+	config_deprecated(ps, "PAODEVICE")
+
 	// ps.keyword holds the original token e.g. "PAODEVICE" or "PAODEVICE1".
 	ps.adevice = 0
 	if len(ps.keyword) > 9 && unicode.IsDigit(rune(ps.keyword[9])) {
@@ -1466,7 +1639,7 @@ func handlePAODEVICE(ps *parseState) bool {
 		return true
 	}
 
-	var t = split("", true)
+	var t = ps.lex.next(true)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Config file: Missing name of audio device for PAODEVICE command on line %d.\n", ps.line)
@@ -1488,7 +1661,7 @@ func handleARATE(ps *parseState) bool {
 	/*
 	 * ARATE 		- Audio samples per second, 11025, 22050, 44100, etc.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing audio sample rate for ARATE command.\n", ps.line)
@@ -1512,7 +1685,7 @@ func handleACHANNELS(ps *parseState) bool {
 	/*
 	 * ACHANNELS 		- Number of audio channels for current device: 1 or 2
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing number of audio channels for ACHANNELS command.\n", ps.line)
@@ -1549,7 +1722,7 @@ func handleCHANNEL(ps *parseState) bool {
 
 	// TODO: allow full range so mycall can be set for network channels.
 	// Watch out for achan[] out of bounds.
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing channel number for CHANNEL command.\n", ps.line)
@@ -1578,6 +1751,29 @@ func handleCHANNEL(ps *parseState) bool {
 					ps.line, n, ACHAN2ADEV(n))
 			}
 		}
+
+		/*
+		 * CHANNEL n NAME="VHF 144.39"	- Optional name to show along with the channel number.
+		 */
+		for t = ps.lex.next(false); t != ""; t = ps.lex.next(false) {
+			var keyword, value, found = strings.Cut(t, "=")
+			if !found || !strings.EqualFold(keyword, "NAME") {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: Unrecognized option '%s' for CHANNEL command.  Only NAME= is allowed.\n", ps.line, t)
+
+				continue
+			}
+
+			if strings.Contains(value, ";") {
+				// The AGW "port information" reply uses ';' as a separator.
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: CHANNEL NAME can't contain ';'.\n", ps.line)
+
+				continue
+			}
+
+			ps.audio.chan_name[n] = value
+		}
 	} else {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Channel number must in range of 0 to %d.\n", ps.line, MAX_RADIO_CHANS-1)
@@ -1595,7 +1791,7 @@ func handleICHANNEL(ps *parseState) bool {
 	 *	In the future there might be other typs of virtual channels.
 	 *	This does not change the current channel number used by MODEM, PTT, etc.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing virtual channel number for ICHANNEL command.\n", ps.line)
@@ -1640,7 +1836,7 @@ func handleNCHANNEL(ps *parseState) bool {
 	 *
 	 * FIXME: Can't set mycall for nchannel.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing virtual channel number for NCHANNEL command.\n", ps.line)
@@ -1665,7 +1861,7 @@ func handleNCHANNEL(ps *parseState) bool {
 		return true
 	}
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing network TNC address for NCHANNEL command.\n", ps.line)
@@ -1675,7 +1871,7 @@ func handleNCHANNEL(ps *parseState) bool {
 
 	ps.audio.nettnc_addr[nchan] = t
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing network TNC TCP port for NCHANNEL command.\n", ps.line)
@@ -1694,12 +1890,114 @@ func handleNCHANNEL(ps *parseState) bool {
 	return false
 }
 
+// handleBCHANNEL handles the BCHANNEL keyword.
+func handleBCHANNEL(ps *parseState) bool {
+	/*
+	 * BCHANNEL chan addr port remote-chan [ TLS certfile keyfile [ cafile ] ]
+	 *
+	 *	Like NCHANNEL but the other end is another instance's BRIDGEPORT.
+	 *	Frames it receives on remote-chan appear here on virtual channel chan,
+	 *	and what we send on chan is transmitted by it on remote-chan.
+	 *
+	 *	TLS to encrypt the connection, with our certificate and key to
+	 *	prove who we are.  cafile is needed if the other instance has a
+	 *	certificate of its own making.
+	 */
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing virtual channel number for BCHANNEL command.\n", ps.line)
+
+		return true
+	}
+
+	var bchan, bchanErr = strconv.Atoi(t)
+	if bchanErr != nil || bchan < MAX_RADIO_CHANS || bchan >= MAX_TOTAL_CHANS {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: BCHANNEL number must be in range of %d to %d.\n", ps.line, MAX_RADIO_CHANS, MAX_TOTAL_CHANS-1)
+
+		return true
+	}
+
+	if ps.audio.chan_medium[bchan] != MEDIUM_NONE {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: BCHANNEL can't use channel %d because it is already in use.\n", ps.line, bchan)
+
+		return true
+	}
+
+	var addr = ps.lex.next(false)
+	if addr == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing bridge address for BCHANNEL command.\n", ps.line)
+
+		return true
+	}
+
+	t = ps.lex.next(false)
+	var port, portErr = strconv.Atoi(t)
+	if portErr != nil || port < MIN_IP_PORT_NUMBER || port > MAX_IP_PORT_NUMBER {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid TCP port number \"%s\" for BCHANNEL command. Must be in range %d to %d.\n",
+			ps.line, t, MIN_IP_PORT_NUMBER, MAX_IP_PORT_NUMBER)
+
+		return true
+	}
+
+	t = ps.lex.next(false)
+	var remote, remoteErr = strconv.Atoi(t)
+	if remoteErr != nil || remote < 0 || remote >= MAX_RADIO_CHANS {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid remote channel \"%s\" for BCHANNEL command. Must be in range 0 to %d.\n",
+			ps.line, t, MAX_RADIO_CHANS-1)
+
+		return true
+	}
+
+	var use_tls = false
+	var cert, key, ca string
+
+	t = ps.lex.next(false)
+	if t != "" {
+		if !strings.EqualFold(t, "TLS") {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Expected TLS, not \"%s\", for BCHANNEL command.\n", ps.line, t)
+
+			return true
+		}
+
+		use_tls = true
+		cert = ps.lex.next(false)
+		key = ps.lex.next(false)
+		ca = ps.lex.next(false)
+
+		if key == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: BCHANNEL with TLS needs a certificate file and a key file for the other end to check.\n", ps.line)
+
+			return true
+		}
+	}
+
+	ps.audio.chan_medium[bchan] = MEDIUM_NETTNC
+	ps.audio.nettnc_addr[bchan] = addr
+	ps.audio.nettnc_port[bchan] = port
+	ps.audio.nettnc_bridge[bchan] = true
+	ps.audio.nettnc_remote_chan[bchan] = remote
+	ps.audio.nettnc_tls[bchan] = use_tls
+	ps.audio.nettnc_tls_cert[bchan] = cert
+	ps.audio.nettnc_tls_key[bchan] = key
+	ps.audio.nettnc_tls_ca[bchan] = ca
+
+	return false
+}
+
 // handleMYCALL handles the MYCALL keyword.
 func handleMYCALL(ps *parseState) bool {
 	/*
 	 * MYCALL station
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Config file: Missing value for MYCALL command on line %d.\n", ps.line)
@@ -1761,7 +2059,7 @@ func handleMODEM(ps *parseState) bool {
 		return true
 	}
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing data transmission speed for MODEM command.\n", ps.line)
@@ -1833,7 +2131,7 @@ func handleMODEM(ps *parseState) bool {
 
 	/* Get any options. */
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t == "" {
 		/* all done. */
 		return false
@@ -1841,8 +2139,7 @@ func handleMODEM(ps *parseState) bool {
 
 	if alldigits(t) {
 		/* old style */
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Old style (pre version 1.2) format will no longer be supported in next version.\n", ps.line)
+		config_deprecated(ps, "MODEM_OLD_STYLE")
 
 		n, _ = strconv.Atoi(t)
 		/* Originally the upper limit was 3000. */
@@ -1862,7 +2159,7 @@ func handleMODEM(ps *parseState) bool {
 
 		/* Get space frequency */
 
-		t = split("", false)
+		t = ps.lex.next(false)
 		if t == "" {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Line %d: Missing tone frequency for space.\n", ps.line)
@@ -1899,7 +2196,7 @@ func handleMODEM(ps *parseState) bool {
 
 		/* New feature in 0.9 - Optional filter profile(s). */
 
-		t = split("", false)
+		t = ps.lex.next(false)
 		if t != "" {
 			/* Look for some combination of letter(s) and + */
 			if unicode.IsLetter(rune(t[0])) || t[0] == '+' {
@@ -1914,7 +2211,7 @@ func handleMODEM(ps *parseState) bool {
 
 				ps.audio.achan[ps.channel].profiles = t
 
-				t = split("", false)
+				t = ps.lex.next(false)
 				if len(ps.audio.achan[ps.channel].profiles) > 1 && t != "" {
 					text_color_set(DW_COLOR_ERROR)
 					dw_printf("Line %d: Can't combine multiple demodulator types and multiple frequencies.\n", ps.line)
@@ -1937,7 +2234,7 @@ func handleMODEM(ps *parseState) bool {
 
 			ps.audio.achan[ps.channel].num_freq = n
 
-			t = split("", false)
+			t = ps.lex.next(false)
 			if t != "" {
 				n, _ = strconv.Atoi(t)
 				if n < 5 || n > int(math.Abs(float64(ps.audio.achan[ps.channel].mark_freq-ps.audio.achan[ps.channel].space_freq))/2) {
@@ -2058,7 +2355,7 @@ func handleMODEM(ps *parseState) bool {
 				dw_printf("Line %d: Unrecognized option for MODEM: %s\n", ps.line, t)
 			}
 
-			t = split("", false)
+			t = ps.lex.next(false)
 		}
 
 		/* A later place catches disallowed combination of + and @. */
@@ -2072,11 +2369,14 @@ func handleMODEM(ps *parseState) bool {
 // handleDTMF handles the DTMF keyword.
 func handleDTMF(ps *parseState) bool {
 	/*
-	 * DTMF  		- Enable DTMF decoder.
+	 * DTMF  [ ONLY | OFF ]	- Enable DTMF decoder.
+	 *
+	 *	ONLY	- Touch tones but no packet demodulator, to reduce CPU requirements.
+	 *		  We can still transmit.
+	 *	OFF	- Turn it off again, e.g. after TTOBJ.
 	 *
 	 * Future possibilities:
 	 *	Option to determine if it goes to APRStt gateway and/or application.
-	 *	Disable normal demodulator to reduce CPU requirements.
 	 */
 	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
@@ -2085,12 +2385,27 @@ func handleDTMF(ps *parseState) bool {
 		return true
 	}
 
-	ps.audio.achan[ps.channel].dtmf_decode = DTMF_DECODE_ON
-	return false
-}
+	var t = ps.lex.next(false)
 
-// handleFIX_BITS handles the FIX_BITS keyword.
-func handleFIX_BITS(ps *parseState) bool {
+	switch {
+	case t == "":
+		ps.audio.achan[ps.channel].dtmf_decode = DTMF_DECODE_ON
+	case strings.EqualFold(t, "ONLY"):
+		ps.audio.achan[ps.channel].dtmf_decode = DTMF_DECODE_ONLY
+	case strings.EqualFold(t, "OFF"):
+		ps.audio.achan[ps.channel].dtmf_decode = DTMF_DECODE_OFF
+	default:
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Expected ONLY or OFF, or nothing, after DTMF, not \"%s\".\n", ps.line, t)
+
+		return true
+	}
+
+	return false
+}
+
+// handleFIX_BITS handles the FIX_BITS keyword.
+func handleFIX_BITS(ps *parseState) bool {
 	/*
 	 * FIX_BITS  n  [ APRS | AX25 | NONE ] [ PASSALL ]
 	 *
@@ -2105,7 +2420,7 @@ func handleFIX_BITS(ps *parseState) bool {
 		return true
 	}
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing value for FIX_BITS command.\n", ps.line)
@@ -2133,7 +2448,7 @@ func handleFIX_BITS(ps *parseState) bool {
 		dw_printf("and you see messages like \"Audio input device 0 error code -32: Broken pipe\"\n")
 	}
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	for t != "" {
 		// If more than one sanity test, we silently take the last one.
 		if strings.EqualFold(t, "APRS") {
@@ -2155,7 +2470,7 @@ func handleFIX_BITS(ps *parseState) bool {
 			dw_printf("Line %d: Invalid option '%s' for FIX_BITS.\n", ps.line, t)
 		}
 
-		t = split("", false)
+		t = ps.lex.next(false)
 	}
 	return false
 }
@@ -2166,13 +2481,19 @@ func handlePTTDCDCON(ps *parseState) bool {
 	 * PTT 		- Push To Talk signal line.
 	 * DCD		- Data Carrier Detect indicator.
 	 * CON		- Connected to another station indicator.
+	 * SEQ1, SEQ2	- More lines switched after PTT.  See TXSEQ.
 	 *
 	 * xxx  serial-port [-]rts-or-dtr [ [-]rts-or-dtr ]
-	 * xxx  GPIO  [-]gpio-num
+	 * xxx  GPIO  [-]gpio-num [ BIAS=pull-up|pull-down|disabled ]
+	 * xxx  GPIOD  chip  [-]line-num [ BIAS=pull-up|pull-down|disabled ]
 	 * xxx  LPT  [-]bit-num
 	 * PTT  RIG  model  port [ rate ]
 	 * PTT  RIG  AUTO  port [ rate ]
-	 * PTT  CM108 [ [-]bit-num ] [ hid-device ]
+	 * xxx  CM108 [ [-]bit-num ] [ hid-device ]
+	 * xxx  MCP23017  bus  address  [-]pin
+	 * PTT  CAT  type  port [ rate ] [ address ]
+	 * xxx  HTTP  on-url  off-url
+	 * xxx  MQTT  broker  topic [ on-payload off-payload ]
 	 *
 	 * 		When model is 2, port would host:port like 127.0.0.1:4532
 	 *		Otherwise, port would be a serial port like /dev/ttyS0
@@ -2195,12 +2516,24 @@ func handlePTTDCDCON(ps *parseState) bool {
 	} else if strings.EqualFold(ps.keyword, "DCD") {
 		ot = OCTYPE_DCD
 		otname = "DCD"
+	} else if strings.EqualFold(ps.keyword, "SEQ1") {
+		ot = OCTYPE_SEQ1
+		otname = "SEQ1"
+	} else if strings.EqualFold(ps.keyword, "SEQ2") {
+		ot = OCTYPE_SEQ2
+		otname = "SEQ2"
 	} else {
 		ot = OCTYPE_CON
 		otname = "CON"
 	}
 
-	var t = split("", false)
+	return parse_output(ps, ot, otname)
+}
+
+/* The rest of a PTT, DCD, etc. line, after the keyword.  Also used for INDICATOR. */
+
+func parse_output(ps *parseState, ot int, otname string) bool {
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Config file line %d: Missing output control device for %s command.\n",
@@ -2209,7 +2542,7 @@ func handlePTTDCDCON(ps *parseState) bool {
 		return true
 	}
 
-	if strings.EqualFold(t, "GPIO") {
+	if strings.EqualFold(t, "GPIO") || strings.EqualFold(t, "GPIOD") {
 		/* GPIO case, Linux only. */
 
 		/* TODO KG
@@ -2218,58 +2551,27 @@ func handlePTTDCDCON(ps *parseState) bool {
 		   	      dw_printf ("Config file line %d: %s with GPIO is only available on Linux.\n", ps.line, otname);
 		   #else
 		*/
-		t = split("", false)
-		if t == "" {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file line %d: Missing GPIO number for %s.\n", ps.line, otname)
 
-			return true
-		}
-
-		var gpio, _ = strconv.Atoi(t)
-		if gpio < 0 {
-			ps.audio.achan[ps.channel].octrl[ot].out_gpio_num = -1 * gpio
-			ps.audio.achan[ps.channel].octrl[ot].ptt_invert = true
-		} else {
-			ps.audio.achan[ps.channel].octrl[ot].out_gpio_num = gpio
-			ps.audio.achan[ps.channel].octrl[ot].ptt_invert = false
-		}
+		// GPIO nn was the sysfs number.  Both now use the GPIO character
+		// device, and GPIO nn is line nn of the first chip, which is
+		// the same thing on a Raspberry Pi.
 
-		ps.audio.achan[ps.channel].octrl[ot].ptt_method = PTT_METHOD_GPIO
-		// #endif
-	} else if strings.EqualFold(t, "GPIOD") {
-		/*
-			#if __WIN32__
-				      text_color_set(DW_COLOR_ERROR);
-				      dw_printf ("Config file line %d: %s with GPIOD is only available on Linux.\n", ps.line, otname);
-			#else
-		*/
-		// #if defined(USE_GPIOD)
-		t = split("", false)
-		if t == "" {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file line %d: Missing GPIO chip name for %s.\n", ps.line, otname)
-			dw_printf("Use the \"gpioinfo\" command to get a list of gpio chip names and corresponding I/O lines.\n")
+		var chip = "/dev/gpiochip0"
 
-			return true
-		}
+		if strings.EqualFold(t, "GPIOD") {
+			t = ps.lex.next(false)
+			if t == "" {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file line %d: Missing GPIO chip name for %s.\n", ps.line, otname)
+				dw_printf("Use the \"gpioinfo\" command to get a list of gpio chip names and corresponding I/O lines.\n")
 
-		// Issue 590.  Originally we used the chip name, like gpiochip3, and fed it into
-		// gpiod_chip_open_by_name.   This function has disappeared in Debian 13 Trixie.
-		// We must now specify the full device path, like /dev/gpiochip3, for the only
-		// remaining open function gpiod_chip_open.
-		// We will allow the user to specify either the name or full device path.
-		// While we are here, also allow only the number as used by the gpiod utilities.
+				return true
+			}
 
-		if t[0] == '/' { // Looks like device path.  Use as given.
-			ps.audio.achan[ps.channel].octrl[ot].out_gpio_name = t
-		} else if unicode.IsDigit(rune(t[0])) { // or if digit, prepend "/dev/gpiochip"
-			ps.audio.achan[ps.channel].octrl[ot].out_gpio_name = "/dev/gpiochip" + t
-		} else { // otherwise, prepend "/dev/" to the name
-			ps.audio.achan[ps.channel].octrl[ot].out_gpio_name = "/dev/" + t
+			chip = gpio_chip_path(t)
 		}
 
-		t = split("", false)
+		t = ps.lex.next(false)
 		if t == "" {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Config file line %d: Missing GPIO number for %s.\n", ps.line, otname)
@@ -2286,20 +2588,20 @@ func handlePTTDCDCON(ps *parseState) bool {
 			ps.audio.achan[ps.channel].octrl[ot].ptt_invert = false
 		}
 
+		var bias, ok = parse_gpio_bias(ps, otname)
+		if !ok {
+			return true
+		}
+
+		ps.audio.achan[ps.channel].octrl[ot].out_gpio_name = chip
+		ps.audio.achan[ps.channel].octrl[ot].out_gpio_bias = bias
 		ps.audio.achan[ps.channel].octrl[ot].ptt_method = PTT_METHOD_GPIOD
-		/* TODO KG
-		#else
-			      text_color_set(DW_COLOR_ERROR);
-			      dw_printf ("Application was not built with optional support for GPIOD.\n");
-			      dw_printf ("Install packages gpiod and libgpiod-dev, remove 'build' subdirectory, then rebuild.\n");
-		#endif // USE_GPIOD
-		*/
 		//#endif /* __WIN32__ */
 	} else if strings.EqualFold(t, "LPT") {
 		/* Parallel printer case, x86 Linux only. */
 
 		//#if  ( defined(__i386__) || defined(__x86_64__) ) && ( defined(__linux__) || defined(__unix__) )
-		t = split("", false)
+		t = ps.lex.next(false)
 		if t == "" {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Config file line %d: Missing LPT bit number for %s.\n", ps.line, otname)
@@ -2325,7 +2627,7 @@ func handlePTTDCDCON(ps *parseState) bool {
 		*/
 	} else if strings.EqualFold(t, "RIG") {
 		// TODO KG #ifdef USE_HAMLIB
-		t = split("", false)
+		t = ps.lex.next(false)
 		if t == "" {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Config file line %d: Missing model number for hamlib.\n", ps.line)
@@ -2356,7 +2658,7 @@ func handlePTTDCDCON(ps *parseState) bool {
 			ps.audio.achan[ps.channel].octrl[ot].ptt_model = n
 		}
 
-		t = split("", false)
+		t = ps.lex.next(false)
 		if t == "" {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Config file line %d: Missing port for hamlib.\n", ps.line)
@@ -2368,7 +2670,7 @@ func handlePTTDCDCON(ps *parseState) bool {
 
 		// Optional serial port rate for CAT control PTT.
 
-		t = split("", false)
+		t = ps.lex.next(false)
 		if t != "" {
 			if !alldigits(t) {
 				text_color_set(DW_COLOR_ERROR)
@@ -2380,7 +2682,7 @@ func handlePTTDCDCON(ps *parseState) bool {
 			ps.audio.achan[ps.channel].octrl[ot].ptt_rate = n
 		}
 
-		t = split("", false)
+		t = ps.lex.next(false)
 		if t != "" {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Config file line %d: %s was not expected after model & port for hamlib.\n", ps.line, t)
@@ -2403,23 +2705,182 @@ func handlePTTDCDCON(ps *parseState) bool {
 		// #endif
 
 		//#endif
-	} else if strings.EqualFold(t, "CM108") {
-		/* CM108 - GPIO of USB sound card. case, Linux and Windows only. */
+	} else if strings.EqualFold(t, "CAT") {
+		/* The radio's own commands, without hamlib.  See cat.go. */
 
-		// TODO KG #if USE_CM108
 		if ot != OCTYPE_PTT {
-			// Future project:  Allow DCD and CON via the same device.
-			// This gets more complicated because we can't selectively change a single GPIO bit.
-			// We would need to keep track of what is currently there, change one bit, in our local
-			// copy of the status and then write out the byte for all of the pins.
-			// Let's keep it simple with just PTT for the first stab at this.
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file line %d: PTT CM108 option is only valid for PTT, not %s.\n", ps.line, otname)
+			dw_printf("Config file line %d: CAT can only be used for PTT, not %s.\n", ps.line, otname)
+
+			return true
+		}
+
+		t = ps.lex.next(false)
+
+		var typ, ok = cat_type_from_name(t)
+		if !ok {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: CAT radio type must be icom, yaesu, ft817, or kenwood, not \"%s\".\n", ps.line, t)
+
+			return true
+		}
+
+		t = ps.lex.next(false)
+		if t == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: Missing serial port for CAT.\n", ps.line)
+
+			return true
+		}
+
+		var device = t
+		var rate = 0
+		var addr = 0
+
+		t = ps.lex.next(false)
+		if t != "" {
+			if !alldigits(t) {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file line %d: An optional number is required here for CAT serial port speed: %s\n", ps.line, t)
+
+				return true
+			}
+
+			rate, _ = strconv.Atoi(t)
+
+			t = ps.lex.next(false)
+		}
+
+		if t != "" {
+			var n, err = strconv.ParseInt(t, 0, 0)
+			if err != nil || n < 1 || n > 0xdf {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file line %d: CI-V address \"%s\" should be like 0x94, in range of 0x01 to 0xdf.\n", ps.line, t)
+
+				return true
+			}
+
+			addr = int(n)
+		}
+
+		if typ == CAT_ICOM && addr == 0 {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: Icom CAT needs the radio's CI-V address, e.g. 0x94 for an IC-7300.\n", ps.line)
+
+			return true
+		}
+
+		ps.audio.achan[ps.channel].octrl[ot].ptt_device = device
+		ps.audio.achan[ps.channel].octrl[ot].ptt_rate = rate
+		ps.audio.achan[ps.channel].octrl[ot].ptt_cat_type = typ
+		ps.audio.achan[ps.channel].octrl[ot].ptt_cat_addr = addr
+		ps.audio.achan[ps.channel].octrl[ot].ptt_method = PTT_METHOD_CAT
+	} else if strings.EqualFold(t, "HTTP") {
+		/* Relay on the network.  See ptt_remote.go. */
+
+		var on = ps.lex.next(false)
+		var off = ps.lex.next(false)
+
+		if off == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: %s HTTP needs a URL to turn on and another to turn off.\n", ps.line, otname)
+
+			return true
+		}
+
+		for _, u := range []string{on, off} {
+			if !strings.HasPrefix(u, "http://") && !strings.HasPrefix(u, "https://") {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file line %d: %s HTTP \"%s\" should start with http:// or https://.\n", ps.line, otname, u)
+
+				return true
+			}
+		}
+
+		ps.audio.achan[ps.channel].octrl[ot].ptt_remote_on = on
+		ps.audio.achan[ps.channel].octrl[ot].ptt_remote_off = off
+		ps.audio.achan[ps.channel].octrl[ot].ptt_method = PTT_METHOD_HTTP
+	} else if strings.EqualFold(t, "MQTT") {
+		var broker = ps.lex.next(false)
+		var topic = ps.lex.next(false)
+
+		if topic == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: %s MQTT needs a broker and a topic.\n", ps.line, otname)
+
+			return true
+		}
+
+		var on = "ON"
+		var off = "OFF"
+
+		t = ps.lex.next(false)
+		if t != "" {
+			on = t
+			off = ps.lex.next(false)
+
+			if off == "" {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file line %d: %s MQTT needs both on and off payloads, or neither for ON and OFF.\n", ps.line, otname)
+
+				return true
+			}
+		}
+
+		ps.audio.achan[ps.channel].octrl[ot].ptt_device = broker
+		ps.audio.achan[ps.channel].octrl[ot].ptt_mqtt_topic = topic
+		ps.audio.achan[ps.channel].octrl[ot].ptt_remote_on = on
+		ps.audio.achan[ps.channel].octrl[ot].ptt_remote_off = off
+		ps.audio.achan[ps.channel].octrl[ot].ptt_method = PTT_METHOD_MQTT
+	} else if strings.EqualFold(t, "MCP23017") {
+		/* I2C GPIO expander.  See mcp23017.go. */
+
+		var bus = ps.lex.next(false)
+		var saddr = ps.lex.next(false)
+		var spin = ps.lex.next(false)
+
+		if spin == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: %s MCP23017 needs I2C bus, address, and pin, e.g. 1 0x20 A3.\n", ps.line, otname)
+
+			return true
+		}
+
+		if alldigits(bus) {
+			bus = "/dev/i2c-" + bus
+		}
+
+		var addr, err = strconv.ParseInt(saddr, 0, 0)
+		if err != nil || addr < MCP23017_ADDR_MIN || addr > MCP23017_ADDR_MAX {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: MCP23017 address should be 0x%02x thru 0x%02x, not %s.\n", ps.line, MCP23017_ADDR_MIN, MCP23017_ADDR_MAX, saddr)
+
+			return true
+		}
+
+		var invert = strings.HasPrefix(spin, "-")
+
+		var pin, ok = mcp23017_parse_pin(strings.TrimPrefix(spin, "-"))
+		if !ok {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: MCP23017 pin should be A0 thru A7 or B0 thru B7, not %s.\n", ps.line, spin)
 
 			return true
 		}
 
-		ps.audio.achan[ps.channel].octrl[ot].out_gpio_num = 3 // All known designs use GPIO 3.
+		ps.audio.achan[ps.channel].octrl[ot].ptt_device = bus
+		ps.audio.achan[ps.channel].octrl[ot].ptt_i2c_addr = int(addr)
+		ps.audio.achan[ps.channel].octrl[ot].out_gpio_num = pin
+		ps.audio.achan[ps.channel].octrl[ot].ptt_invert = invert
+		ps.audio.achan[ps.channel].octrl[ot].ptt_method = PTT_METHOD_MCP23017
+	} else if strings.EqualFold(t, "CM108") {
+		/* CM108 - GPIO of USB sound card. case, Linux and Windows only. */
+
+		// TODO KG #if USE_CM108
+		// DCD and CON can share the device with PTT, on other pins.
+		// Only PTT has a default pin.
+
+		ps.audio.achan[ps.channel].octrl[ot].out_gpio_num = IfThenElse(ot == OCTYPE_PTT, 3, 0) // All known designs use GPIO 3 for PTT.
 		// User can override for special cases.
 		ps.audio.achan[ps.channel].octrl[ot].ptt_invert = false // High for transmit.
 		ps.audio.achan[ps.channel].octrl[ot].ptt_device = ""
@@ -2432,14 +2893,14 @@ func handlePTTDCDCON(ps *parseState) bool {
 		ps.audio.achan[ps.channel].octrl[ot].ptt_device = cm108_find_ptt(ps.audio.adev[ACHAN2ADEV(ps.channel)].adevice_out)
 
 		for {
-			t = split("", false)
+			t = ps.lex.next(false)
 			if t == "" {
 				break
 			}
 
 			if t[0] == '-' {
 				var gpio, _ = strconv.Atoi(t[1:])
-				ps.audio.achan[ps.channel].octrl[ot].out_gpio_num = -1 * gpio
+				ps.audio.achan[ps.channel].octrl[ot].out_gpio_num = gpio
 				ps.audio.achan[ps.channel].octrl[ot].ptt_invert = true
 			} else if unicode.IsDigit(rune(t[0])) {
 				var gpio, _ = strconv.Atoi(t)
@@ -2455,6 +2916,13 @@ func handlePTTDCDCON(ps *parseState) bool {
 			}
 		}
 
+		if ps.audio.achan[ps.channel].octrl[ot].out_gpio_num == 0 {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: %s CM108 needs a GPIO number.  GPIO 3 is normally used for PTT.\n", ps.line, otname)
+
+			return true
+		}
+
 		if ps.audio.achan[ps.channel].octrl[ot].out_gpio_num < 1 || ps.audio.achan[ps.channel].octrl[ot].out_gpio_num > 8 {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Config file line %d: CM108 GPIO number %d is not in range of 1 thru 8.\n", ps.line,
@@ -2495,7 +2963,7 @@ func handlePTTDCDCON(ps *parseState) bool {
 		/* serial port case. */
 		ps.audio.achan[ps.channel].octrl[ot].ptt_device = t
 
-		t = split("", false)
+		t = ps.lex.next(false)
 		if t == "" {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Config file line %d: Missing RTS or DTR after %s device name.\n",
@@ -2530,7 +2998,7 @@ func handlePTTDCDCON(ps *parseState) bool {
 		/* Some interfaces want the two control lines driven with opposite polarity. */
 		/* e.g.   PTT COM1 RTS -DTR  */
 
-		t = split("", false)
+		t = ps.lex.next(false)
 		if t != "" {
 			if strings.EqualFold(t, "rts") {
 				ps.audio.achan[ps.channel].octrl[ot].ptt_line2 = PTT_LINE_RTS
@@ -2564,6 +3032,110 @@ func handlePTTDCDCON(ps *parseState) bool {
 	return false
 }
 
+/*
+ * GPIO chip for GPIOD.
+ *
+ * Issue 590.  Originally we used the chip name, like gpiochip3, and fed it into
+ * gpiod_chip_open_by_name.   This function has disappeared in Debian 13 Trixie.
+ * We must now specify the full device path, like /dev/gpiochip3, for the only
+ * remaining open function gpiod_chip_open.
+ * We will allow the user to specify either the name or full device path.
+ * While we are here, also allow only the number as used by the gpiod utilities.
+ */
+
+func gpio_chip_path(t string) string {
+	if t[0] == '/' { // Looks like device path.  Use as given.
+		return t
+	} else if unicode.IsDigit(rune(t[0])) { // or if digit, prepend "/dev/gpiochip"
+		return "/dev/gpiochip" + t
+	}
+
+	return "/dev/" + t // otherwise, prepend "/dev/" to the name
+}
+
+/*
+ * Optional BIAS=pull-up, pull-down, or disabled after a GPIO line number.
+ * Without it the bias is left as it was.
+ */
+
+func parse_gpio_bias(ps *parseState, name string) (gpio_bias_t, bool) {
+	var t = ps.lex.next(false)
+	if t == "" {
+		return GPIO_BIAS_AS_IS, true
+	}
+
+	var value, found = strings.CutPrefix(strings.ToUpper(t), "BIAS=")
+	if !found {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file line %d: Unexpected \"%s\" after GPIO number for %s.\n", ps.line, t, name)
+
+		return GPIO_BIAS_AS_IS, false
+	}
+
+	switch value {
+	case "PULL-UP", "PULLUP", "UP":
+		return GPIO_BIAS_PULL_UP, true
+	case "PULL-DOWN", "PULLDOWN", "DOWN":
+		return GPIO_BIAS_PULL_DOWN, true
+	case "DISABLED", "NONE", "OFF":
+		return GPIO_BIAS_DISABLED, true
+	case "AS-IS", "ASIS":
+		return GPIO_BIAS_AS_IS, true
+	}
+
+	text_color_set(DW_COLOR_ERROR)
+	dw_printf("Config file line %d: %s BIAS must be pull-up, pull-down, or disabled, not \"%s\".\n", ps.line, name, t[len("BIAS="):])
+
+	return GPIO_BIAS_AS_IS, false
+}
+
+// handleINDICATOR handles the INDICATOR keyword.
+func handleINDICATOR(ps *parseState) bool {
+	/*
+	 * INDICATOR  PTT|DCD|CON  method ...
+	 *
+	 *		Another output following PTT, DCD, or CON, in addition to
+	 *		any given by the PTT, DCD, or CON command.  Methods are the
+	 *		same.  e.g.  INDICATOR DCD GPIO 5
+	 */
+	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: INDICATOR can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
+
+		return true
+	}
+
+	var t = ps.lex.next(false)
+
+	var follows int
+
+	if strings.EqualFold(t, "PTT") {
+		follows = OCTYPE_PTT
+	} else if strings.EqualFold(t, "DCD") {
+		follows = OCTYPE_DCD
+	} else if strings.EqualFold(t, "CON") {
+		follows = OCTYPE_CON
+	} else {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: INDICATOR should be followed by PTT, DCD, or CON, not \"%s\".\n", ps.line, t)
+
+		return true
+	}
+
+	for ot := OCTYPE_IND1; ot < NUM_OCTYPES; ot++ {
+		if ps.audio.achan[ps.channel].octrl[ot].ptt_method == PTT_METHOD_NONE {
+			ps.audio.achan[ps.channel].octrl[ot].ind_follows = follows
+
+			return parse_output(ps, ot, "INDICATOR "+strings.ToUpper(t))
+		}
+	}
+
+	text_color_set(DW_COLOR_ERROR)
+	dw_printf("Line %d: No more than %d INDICATOR lines for a channel.\n", ps.line, NUM_OCTYPES-OCTYPE_IND1)
+
+	return true
+}
+
 // handleTXINH handles the TXINH keyword.
 func handleTXINH(ps *parseState) bool {
 	/*
@@ -2571,7 +3143,9 @@ func handleTXINH(ps *parseState) bool {
 	 *
 	 * TXINH - TX holdoff input
 	 *
-	 * TXINH GPIO [-]gpio-num (only type supported so far)
+	 * TXINH GPIO [-]gpio-num [ BIAS=... ]
+	 * TXINH GPIOD chip [-]line-num [ BIAS=... ]
+	 * TXINH CM108 [-]gpio-num [ hid-device ]
 	 */
 	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
@@ -2581,7 +3155,7 @@ func handleTXINH(ps *parseState) bool {
 	}
 	var itname = "TXINH"
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Config file line %d: Missing input type name for %s command.\n", ps.line, itname)
@@ -2589,14 +3163,28 @@ func handleTXINH(ps *parseState) bool {
 		return true
 	}
 
-	if strings.EqualFold(t, "GPIO") {
+	if strings.EqualFold(t, "GPIO") || strings.EqualFold(t, "GPIOD") {
 		/* TODO KG
 		#if __WIN32__
 			      text_color_set(DW_COLOR_ERROR);
 			      dw_printf ("Config file line %d: %s with GPIO is only available on Linux.\n", ps.line, itname);
 		#else
 		*/
-		t = split("", false)
+		var chip = "/dev/gpiochip0"
+
+		if strings.EqualFold(t, "GPIOD") {
+			t = ps.lex.next(false)
+			if t == "" {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file line %d: Missing GPIO chip name for %s.\n", ps.line, itname)
+
+				return true
+			}
+
+			chip = gpio_chip_path(t)
+		}
+
+		t = ps.lex.next(false)
 		if t == "" {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Config file line %d: Missing GPIO number for %s.\n", ps.line, itname)
@@ -2613,9 +3201,57 @@ func handleTXINH(ps *parseState) bool {
 			ps.audio.achan[ps.channel].ictrl[ICTYPE_TXINH].invert = false
 		}
 
-		ps.audio.achan[ps.channel].ictrl[ICTYPE_TXINH].method = PTT_METHOD_GPIO
+		var bias, ok = parse_gpio_bias(ps, itname)
+		if !ok {
+			return true
+		}
+
+		ps.audio.achan[ps.channel].ictrl[ICTYPE_TXINH].in_gpio_name = chip
+		ps.audio.achan[ps.channel].ictrl[ICTYPE_TXINH].in_gpio_bias = bias
+		ps.audio.achan[ps.channel].ictrl[ICTYPE_TXINH].method = PTT_METHOD_GPIOD
 		// #endif
+	} else if strings.EqualFold(t, "CM108") {
+		/* GPIO of USB sound card, normally the same one used for PTT. */
+
+		t = ps.lex.next(false)
+		if t == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: Missing GPIO number for %s.\n", ps.line, itname)
+
+			return true
+		}
+
+		var gpio, err = strconv.Atoi(t)
+		if err != nil || gpio == 0 || gpio < -8 || gpio > 8 {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: CM108 GPIO number \"%s\" is not in range of 1 thru 8.\n", ps.line, t)
+
+			return true
+		}
+
+		var device = ps.lex.next(false)
+		if device == "" {
+			device = cm108_find_ptt(ps.audio.adev[ACHAN2ADEV(ps.channel)].adevice_out)
+		}
+
+		if device == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file line %d: Could not determine USB Audio GPIO device for audio output %s.\n", ps.line,
+				ps.audio.adev[ACHAN2ADEV(ps.channel)].adevice_out)
+			dw_printf("You must explicitly mention a device name such as /dev/hidraw1.\n")
+
+			return true
+		}
+
+		ps.audio.achan[ps.channel].ictrl[ICTYPE_TXINH].in_gpio_num = max(gpio, -gpio)
+		ps.audio.achan[ps.channel].ictrl[ICTYPE_TXINH].invert = gpio < 0
+		ps.audio.achan[ps.channel].ictrl[ICTYPE_TXINH].in_gpio_name = device
+		ps.audio.achan[ps.channel].ictrl[ICTYPE_TXINH].method = PTT_METHOD_CM108
+	} else {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file line %d: %s type \"%s\" is not supported.  Use GPIO, GPIOD, or CM108.\n", ps.line, itname, t)
 	}
+
 	return false
 }
 
@@ -2627,6 +3263,8 @@ func handleDWAIT(ps *parseState) bool {
 	 * Why did I do this?  Just add more to TXDELAY.
 	 * Now undocumented in User Guide.  Might disappear someday.
 	 */
+	config_deprecated(ps, "DWAIT")
+
 	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: DWAIT can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
@@ -2634,7 +3272,7 @@ func handleDWAIT(ps *parseState) bool {
 		return true
 	}
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing delay time for DWAIT command.\n", ps.line)
@@ -2667,7 +3305,7 @@ func handleSLOTTIME(ps *parseState) bool {
 		return true
 	}
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing delay time for SLOTTIME command.\n", ps.line)
@@ -2706,7 +3344,7 @@ func handlePERSIST(ps *parseState) bool {
 		return true
 	}
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing probability for PERSIST command.\n", ps.line)
@@ -2742,7 +3380,7 @@ func handleTXDELAY(ps *parseState) bool {
 		return true
 	}
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing time for TXDELAY command.\n", ps.line)
@@ -2781,793 +3419,776 @@ func handleTXDELAY(ps *parseState) bool {
 	return false
 }
 
-// handleTXTAIL handles the TXTAIL keyword.
-func handleTXTAIL(ps *parseState) bool {
+// handleTXTIMEOUT handles the TXTIMEOUT keyword.
+func handleTXTIMEOUT(ps *parseState) bool {
 	/*
-	 * TXTAIL n		- For transmit timing. n = 10 mS units.
+	 * TXTIMEOUT n		- Force PTT off after n seconds.  0 for no limit.
 	 */
 	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TXTAIL can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
+		dw_printf("Line %d: TXTIMEOUT can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
 		return true
 	}
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing time for TXTAIL command.\n", ps.line)
+		dw_printf("Line %d: Missing time for TXTIMEOUT command.\n", ps.line)
 
 		return true
 	}
 
-	var n, _ = strconv.Atoi(t)
-	if n >= 0 && n <= 255 {
-		if n < 5 {
-			dw_printf("Line %d: Setting TXTAIL that small is a REALLY BAD idea if you want other stations to hear you.\n",
-				ps.line)
-			dw_printf("Read the Dire Wolf User Guide, \"Radio Channel - Transmit Timing\"\n")
-			dw_printf("section, to understand what this means.\n")
-			dw_printf("Why don't you just use the default rather than reducing reliability?\n")
-		} else if n >= 50 {
-			dw_printf("Line %d: Keeping with tradition, going back to the 1980s, TXTAIL is in 10 millisecond units.\n",
-				ps.line)
-			dw_printf("Line %d: The value %d would be %.3f seconds which seems rather excessive.  Are you sure you want that?\n",
-				ps.line, n, float64(n)*10./1000.)
-			dw_printf("Read the Dire Wolf User Guide, \"Radio Channel - Transmit Timing\"\n")
-			dw_printf("section, to understand what this means.\n")
-			dw_printf("Why don't you just use the default?\n")
-		}
+	var n, err = strconv.Atoi(t)
+	if err != nil || n < 0 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: TXTIMEOUT should be a number of seconds, not \"%s\".\n", ps.line, t)
 
-		ps.audio.achan[ps.channel].txtail = n
-	} else {
-		ps.audio.achan[ps.channel].txtail = DEFAULT_TXTAIL
+		return true
+	}
 
+	if n > 0 && n < 10 {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Invalid time for transmit timing. Using %d.\n",
-			ps.line, ps.audio.achan[ps.channel].txtail)
+		dw_printf("Line %d: TXTIMEOUT %d seconds might cut off a long transmission, such as several frames at once in connected mode.\n", ps.line, n)
 	}
+
+	ps.audio.achan[ps.channel].tx_timeout = n
+
 	return false
 }
 
-// handleFULLDUP handles the FULLDUP keyword.
-func handleFULLDUP(ps *parseState) bool {
+// handleRESPONSEGAP handles the RESPONSEGAP keyword.
+func handleRESPONSEGAP(ps *parseState) bool {
 	/*
-	 * FULLDUP  {on|off} 		- Full Duplex
+	 * RESPONSEGAP n [ max ]	- Rest n seconds between audible responses, with up to max waiting.
 	 */
 	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: FULLDUP can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
+		dw_printf("Line %d: RESPONSEGAP can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
 		return true
 	}
 
-	var t = split("", false)
-	if t == "" {
+	var t = ps.lex.next(false)
+
+	var n, err = strconv.Atoi(t)
+	if err != nil || n < 0 || n > 600 {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing parameter for FULLDUP command.  Expecting ON or OFF.\n", ps.line)
+		dw_printf("Line %d: RESPONSEGAP should be a number of seconds, 0 to 600, not \"%s\".\n", ps.line, t)
 
 		return true
 	}
 
-	if strings.EqualFold(t, "ON") {
-		ps.audio.achan[ps.channel].fulldup = true
-	} else if strings.EqualFold(t, "OFF") {
-		ps.audio.achan[ps.channel].fulldup = false
-	} else {
-		ps.audio.achan[ps.channel].fulldup = false
-
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Expected ON or OFF for FULLDUP.\n", ps.line)
-	}
-	return false
-}
-
-// handleSPEECH handles the SPEECH keyword.
-func handleSPEECH(ps *parseState) bool {
-	/*
-	 * SPEECH  script
-	 *
-	 * Specify script for text-to-speech function.
-	 */
-	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: SPEECH can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
+	ps.audio.achan[ps.channel].response_gap = n
 
-		return true
+	t = ps.lex.next(false)
+	if t == "" {
+		return false
 	}
 
-	var t = split("", false)
-	if t == "" {
+	var m, merr = strconv.Atoi(t)
+	if merr != nil || m < 1 || m > 100 {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing script for Text-to-Speech function.\n", ps.line)
+		dw_printf("Line %d: RESPONSEGAP maximum waiting should be 1 to 100, not \"%s\".\n", ps.line, t)
 
 		return true
 	}
 
-	/* See if we can run it. */
+	ps.audio.achan[ps.channel].response_max = m
 
-	/*
-	   TODO KG Do we *actually* want to do this...? If so, let's do it when we've ported this to Go...
-
-	   	 if (xmit_speak_it(t, -1, " ") == 0) {
-	   	   if (strlcpy (ps.audio.tts_script, t, sizeof(ps.audio.tts_script)) >= sizeof(ps.audio.tts_script)) {
-	   	     text_color_set(DW_COLOR_ERROR);
-	   	     dw_printf ("Line %d: Script for text-to-speech function is too long.\n", ps.line);
-	   	   }
-	   	 } else {
-	   	   text_color_set(DW_COLOR_ERROR);
-	   	   dw_printf ("Line %d: Error trying to run Text-to-Speech function.\n", ps.line);
-	   	   continue;
-	   	}
-	*/
 	return false
 }
 
-// handleFX25TX handles the FX25TX keyword.
-func handleFX25TX(ps *parseState) bool {
+// handleTXPRIORITY handles the TXPRIORITY keyword.
+func handleTXPRIORITY(ps *parseState) bool {
 	/*
-	 * FX25TX n		- Enable FX.25 transmission.  Default off.
-	 *				0 = off, 1 = auto mode, others are suggestions for testing
-	 *				or special cases.  16, 32, 64 is number of parity bytes to add.
-	 *				Also set by "-X n" command line option.
-	 *				V1.7 changed from global to per-channel setting.
+	 * TXPRIORITY reason=n [ reason=n ... ]	- Who goes first when channels share a transmitter.
 	 */
-	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: FX25TX can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
-
-		return true
-	}
-
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing FEC mode for FX25TX command.\n", ps.line)
+		dw_printf("Line %d: Missing reason=priority for TXPRIORITY command.\n", ps.line)
 
 		return true
 	}
 
-	var n, _ = strconv.Atoi(t)
-	if n >= 0 && n < 200 {
-		ps.audio.achan[ps.channel].fx25_strength = n
-		ps.audio.achan[ps.channel].layer2_xmit = LAYER2_FX25
-	} else {
-		ps.audio.achan[ps.channel].fx25_strength = 1
-		ps.audio.achan[ps.channel].layer2_xmit = LAYER2_FX25
-
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Unreasonable value for FX.25 transmission mode. Using %d.\n",
-			ps.line, ps.audio.achan[ps.channel].fx25_strength)
+	for ; t != ""; t = ps.lex.next(false) {
+		var err = tx_priority_parse(&ps.audio.tx_priority, t)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: TXPRIORITY: %s.\n", ps.line, err)
+		}
 	}
+
 	return false
 }
 
-// handleFX25AUTO handles the FX25AUTO keyword.
-func handleFX25AUTO(ps *parseState) bool {
+// handleTXSLICE handles the TXSLICE keyword.
+func handleTXSLICE(ps *parseState) bool {
 	/*
-	 * FX25AUTO n		- Enable Automatic use of FX.25 for connected mode.  *** Not Implemented ***
-	 *				Automatically enable, for that session only, when an identical
-	 *				frame is sent more than this number of times.
-	 *				Default 5 based on half of default RETRY.
-	 *				0 to disable feature.
-	 *				Current a global setting.  Could be per channel someday.
+	 * TXSLICE n		- Seconds before a channel has to let another use a shared transmitter.
 	 */
-	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: FX25AUTO can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
+	var t = ps.lex.next(false)
 
-		return true
-	}
-
-	var t = split("", false)
-	if t == "" {
+	var n, err = strconv.Atoi(t)
+	if err != nil || n < 0 || n > 600 {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing count for FX25AUTO command.\n", ps.line)
+		dw_printf("Line %d: TXSLICE should be a number of seconds, 0 to 600, not \"%s\".\n", ps.line, t)
 
 		return true
 	}
 
-	var n, _ = strconv.Atoi(t)
-	if n >= 0 && n < 20 {
-		ps.audio.fx25_auto_enable = n
-	} else {
-		ps.audio.fx25_auto_enable = AX25_N2_RETRY_DEFAULT / 2
+	ps.audio.tx_slice = n
 
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Unreasonable count for connected mode automatic FX.25. Using %d.\n",
-			ps.line, ps.audio.fx25_auto_enable)
-	}
 	return false
 }
 
-// handleIL2PTX handles the IL2PTX keyword.
-func handleIL2PTX(ps *parseState) bool {
+// handleQUERY handles the QUERY keyword.
+func handleQUERY(ps *parseState) bool {
 	/*
-	 * IL2PTX  [ + - ] [ 0 1 ]	- Enable IL2P transmission.  Default off.
-	 *				"+" means normal polarity. Redundant since it is the default.
-	 *					(command line -I for first channel)
-	 *				"-" means inverted polarity. Do not use for 1200 bps.
-	 *					(command line -i for first channel)
-	 *				"0" means weak FEC.  Not recommended.
-	 *				"1" means stronger FEC.  "Max FEC."  Default if not specified.
+	 * QUERY [ APRS ] [ APRSP ] [ APRSS ] [ VER ] [ INFO=text ] [ VIA=path ]
 	 */
-	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: IL2PTX can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
-
-		return true
-	}
-
-	ps.audio.achan[ps.channel].layer2_xmit = LAYER2_IL2P
-	ps.audio.achan[ps.channel].il2p_max_fec = 1
-	ps.audio.achan[ps.channel].il2p_invert_polarity = 0
-	ps.audio.achan[ps.channel].il2p_crc = true
+	var q = &ps.misc.query
+	var any_type = false
 
 	for {
-		var t = split("", false)
+		var t = ps.lex.next(false)
 		if t == "" {
 			break
 		}
 
-		for _, c := range t {
-			switch c {
-			case '+':
-				ps.audio.achan[ps.channel].il2p_invert_polarity = 0
-			case '-':
-				ps.audio.achan[ps.channel].il2p_invert_polarity = 1
-			case '0':
-				ps.audio.achan[ps.channel].il2p_max_fec = 0
-			case '1':
-				ps.audio.achan[ps.channel].il2p_max_fec = 1
-			case 'C':
-				ps.audio.achan[ps.channel].il2p_crc = true
-			case 'c':
-				ps.audio.achan[ps.channel].il2p_crc = false
-			default:
+		var keyword, value, found = strings.Cut(t, "=")
+
+		switch {
+		case found && strings.EqualFold(keyword, "INFO"):
+			q.info = value
+		case found && strings.EqualFold(keyword, "VIA"):
+			if check_via_path(value) < 0 {
 				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Line %d: Invalid parameter '%c' for IL2PTX command.\n", ps.line, c)
+				dw_printf("Line %d: Invalid via path for QUERY.\n", ps.line)
 
-				continue
+				return true
 			}
+
+			q.via = value
+		case strings.EqualFold(t, "APRS"):
+			q.general = true
+			any_type = true
+		case strings.EqualFold(t, "APRSP"):
+			q.aprsp = true
+			any_type = true
+		case strings.EqualFold(t, "APRSS"):
+			q.aprss = true
+			any_type = true
+		case strings.EqualFold(t, "VER"):
+			q.ver = true
+			any_type = true
+		default:
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Unknown query type \"%s\" for QUERY.  Expected APRS, APRSP, APRSS, VER, INFO=, or VIA=.\n", ps.line, t)
+
+			return true
 		}
 	}
+
+	if !any_type {
+		q.general = true
+		q.aprsp = true
+		q.aprss = true
+		q.ver = true
+	}
+
+	q.enabled = true
+
 	return false
 }
 
-// handleDIGIPEAT handles the DIGIPEAT keyword.
-func handleDIGIPEAT(ps *parseState) bool {
-	/*
-	 * ==================== APRS Digipeater parameters ====================
-	 */
-
+// handleVOTE handles the VOTE keyword.
+func handleVOTE(ps *parseState) bool {
 	/*
-	 * DIGIPEAT  from-chan  to-chan  alias-pattern  wide-pattern  [ OFF|DROP|MARK|TRACE | ATGP=alias ]
+	 * VOTE chan chan [ chan ... ] [ WINDOW=ms ]
 	 *
-	 * ATGP is an ugly hack for the specific need of ATGP which needs more that 8 digipeaters.
-	 * DO NOT put this in the User Guide.  On a need to know basis.
+	 *	Channels must already be defined, as radio or network TNC channels.
 	 */
-	var t = split("", false)
-	if t == "" {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing FROM-channel on line %d.\n", ps.line)
+	var vc = &vote_config_s{window_ms: DEFAULT_VOTE_WINDOW} //nolint:exhaustruct
 
-		return true
-	}
+	for {
+		var t = ps.lex.next(false)
+		if t == "" {
+			break
+		}
 
-	if !alldigits(t) {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: '%s' is not allowed for FROM-channel.  It must be a number.\n",
-			ps.line, t)
+		if keyword, value, found := strings.Cut(t, "="); found && strings.EqualFold(keyword, "WINDOW") {
+			var ms, msErr = strconv.Atoi(value)
+			if msErr != nil || ms < 10 || ms > 10000 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: VOTE WINDOW must be 10 to 10000 ms, not \"%s\".\n", ps.line, value)
 
-		return true
+				return true
+			}
+
+			vc.window_ms = ms
+
+			continue
+		}
+
+		var ch, chErr = strconv.Atoi(t)
+		if chErr != nil || ch < 0 || ch >= MAX_TOTAL_CHANS {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Invalid channel \"%s\" for VOTE.  Must be in range 0 to %d.\n", ps.line, t, MAX_TOTAL_CHANS-1)
+
+			return true
+		}
+
+		if ps.audio.chan_medium[ch] != MEDIUM_RADIO && ps.audio.chan_medium[ch] != MEDIUM_NETTNC {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Channel %d for VOTE is not a radio, NCHANNEL, or BCHANNEL channel.\n", ps.line, ch)
+
+			return true
+		}
+
+		for _, other := range ps.misc.vote {
+			if slices.Contains(other.channels, ch) {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: Channel %d is already in another VOTE.\n", ps.line, ch)
+
+				return true
+			}
+		}
+
+		if slices.Contains(vc.channels, ch) {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Channel %d is listed twice for VOTE.\n", ps.line, ch)
+
+			return true
+		}
+
+		vc.channels = append(vc.channels, ch)
 	}
 
-	var from_chan, _ = strconv.Atoi(t)
-	if from_chan < 0 || from_chan >= MAX_TOTAL_CHANS {
+	if len(vc.channels) < 2 {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: FROM-channel must be in range of 0 to %d on line %d.\n",
-			MAX_TOTAL_CHANS-1, ps.line)
+		dw_printf("Line %d: VOTE needs at least two channels.\n", ps.line)
 
 		return true
 	}
 
-	// Channels specified must be radio channels or network TNCs.
+	ps.misc.vote = append(ps.misc.vote, vc)
 
-	if ps.audio.chan_medium[from_chan] != MEDIUM_RADIO &&
-		ps.audio.chan_medium[from_chan] != MEDIUM_NETTNC {
+	return false
+}
+
+// handleCWID handles the CWID keyword.
+func handleCWID(ps *parseState) bool {
+	/*
+	 * CWID minutes [ wpm ]	- Morse code ID, if anything was transmitted since the last one.
+	 */
+	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: FROM-channel %d is not valid.\n",
-			ps.line, from_chan)
+		dw_printf("Line %d: CWID can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
 		return true
 	}
 
-	t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing TO-channel on line %d.\n", ps.line)
+		dw_printf("Line %d: Missing time for CWID command.\n", ps.line)
 
 		return true
 	}
 
-	if !alldigits(t) {
+	var every = parse_interval(t, ps.line)
+	if every < 60 {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: '%s' is not allowed for TO-channel.  It must be a number.\n",
-			ps.line, t)
+		dw_printf("Line %d: CWID should be at least 1 minute, not \"%s\".\n", ps.line, t)
 
 		return true
 	}
 
-	var to_chan, _ = strconv.Atoi(t)
-	if to_chan < 0 || to_chan >= MAX_TOTAL_CHANS {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: TO-channel must be in range of 0 to %d on line %d.\n",
-			MAX_TOTAL_CHANS-1, ps.line)
+	var wpm = DEFAULT_CWID_WPM
 
-		return true
+	t = ps.lex.next(false)
+	if t != "" {
+		var n, err = strconv.Atoi(t)
+		if err != nil || n < 2 || n > 30 {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: CWID speed should be 2 to 30 words per minute, not \"%s\".\n", ps.line, t)
+
+			return true
+		}
+
+		wpm = n
 	}
 
-	if ps.audio.chan_medium[to_chan] != MEDIUM_RADIO &&
-		ps.audio.chan_medium[to_chan] != MEDIUM_NETTNC {
+	ps.audio.achan[ps.channel].cwid_every = every
+	ps.audio.achan[ps.channel].cwid_wpm = wpm
+
+	return false
+}
+
+// handleRIGPOLL handles the RIGPOLL keyword.
+func handleRIGPOLL(ps *parseState) bool {
+	/*
+	 * RIGPOLL n		- Ask hamlib for frequency and mode every n seconds.  0 for never.
+	 */
+	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: TO-channel %d is not valid.\n",
-			ps.line, to_chan)
+		dw_printf("Line %d: RIGPOLL can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
 		return true
 	}
 
-	t = split("", false)
-	if t == "" {
+	var t = ps.lex.next(false)
+
+	var n, err = strconv.Atoi(t)
+	if err != nil || n < 0 || n > 3600 {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing alias pattern on line %d.\n", ps.line)
+		dw_printf("Line %d: RIGPOLL should be a number of seconds, 0 to 3600, not \"%s\".\n", ps.line, t)
 
 		return true
 	}
 
-	var r, err = regexp.Compile(t)
-	if err != nil {
+	if n > 0 && ps.audio.achan[ps.channel].octrl[OCTYPE_PTT].ptt_method != PTT_METHOD_HAMLIB {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Invalid alias matching pattern on line %d:\n%s\n", ps.line, err)
+		dw_printf("Line %d: RIGPOLL needs PTT RIG, for hamlib or rigctld, earlier for the same channel.\n", ps.line)
 
 		return true
 	}
 
-	ps.digi.alias[from_chan][to_chan] = r
+	ps.audio.achan[ps.channel].rig_poll = n
 
-	t = split("", false)
-	if t == "" {
+	return false
+}
+
+// handlePROFILE handles the PROFILE keyword.
+func handlePROFILE(ps *parseState) bool {
+	/*
+	 * PROFILE name [ FREQ=MHz ] [ MODE=mode ] [ AT=hh:mm ] [ MODEM speed [ options ] ]
+	 *
+	 *		- Another frequency and modem for the channel.  See profile.go.
+	 */
+	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing wide pattern on line %d.\n", ps.line)
+		dw_printf("Line %d: PROFILE can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
 		return true
 	}
 
-	r, err = regexp.Compile(t)
-	if err != nil {
+	var a = &ps.audio.achan[ps.channel]
+
+	var name = ps.lex.next(false)
+	if name == "" || strings.Contains(name, "=") || strings.EqualFold(name, "MODEM") {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Invalid wide matching pattern on line %d:\n%s\n", ps.line, err)
+		dw_printf("Line %d: PROFILE needs a name first.\n", ps.line)
 
 		return true
 	}
 
-	ps.digi.wide[from_chan][to_chan] = r
+	for _, p := range a.chan_profiles {
+		if strings.EqualFold(p.name, name) {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Channel %d already has a profile called %s.\n", ps.line, ps.channel, name)
 
-	ps.digi.enabled[from_chan][to_chan] = true
-	ps.digi.preempt[from_chan][to_chan] = PREEMPT_OFF
+			return true
+		}
+	}
 
-	t = split("", false)
-	if t != "" {
-		if strings.EqualFold(t, "OFF") {
-			ps.digi.preempt[from_chan][to_chan] = PREEMPT_OFF
-			t = split("", false)
-		} else if strings.EqualFold(t, "DROP") {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file, line %d: Preemptive digipeating DROP option is discouraged.\n", ps.line)
-			dw_printf("It can create a via path which is misleading about the actual path taken.\n")
-			dw_printf("PREEMPT is the best choice for this feature.\n")
+	var p = &chan_profile_s{name: name, at: -1} //nolint:exhaustruct
 
-			ps.digi.preempt[from_chan][to_chan] = PREEMPT_DROP
-			t = split("", false)
-		} else if strings.EqualFold(t, "MARK") {
+	for t := ps.lex.next(false); t != ""; t = ps.lex.next(false) {
+		if strings.EqualFold(t, "MODEM") {
+			// Let MODEM do the work, then put the channel's own settings back.
+			var own = chan_modem_from(a)
+
+			var skip = handleMODEM(ps)
+
+			p.has_modem = true
+			p.modem = chan_modem_from(a)
+			own.apply(a)
+
+			if skip {
+				return true
+			}
+
+			break
+		}
+
+		var keyword, value, found = strings.Cut(t, "=")
+		if !found {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file, line %d: Preemptive digipeating MARK option is discouraged.\n", ps.line)
-			dw_printf("It can create a via path which is misleading about the actual path taken.\n")
-			dw_printf("PREEMPT is the best choice for this feature.\n")
+			dw_printf("Line %d: PROFILE expected keyword=value or MODEM, not \"%s\".\n", ps.line, t)
 
-			ps.digi.preempt[from_chan][to_chan] = PREEMPT_MARK
-			t = split("", false)
-		} else if (strings.EqualFold(t, "TRACE")) || (strings.HasPrefix(strings.ToUpper(t), "PREEMPT")) {
-			ps.digi.preempt[from_chan][to_chan] = PREEMPT_TRACE
-			t = split("", false)
-		} else if strings.HasPrefix(strings.ToUpper(t), "ATGP=") {
-			ps.digi.atgp[from_chan][to_chan] = t[5:]
-			t = split("", false)
+			return true
 		}
-	}
 
-	if t != "" {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: Found \"%s\" where end of line was expected.\n", ps.line, t)
+		switch strings.ToUpper(keyword) {
+		case "FREQ":
+			var f, err = strconv.ParseFloat(value, 64)
+			if err != nil || f <= 0 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: PROFILE FREQ should be in MHz, not \"%s\".\n", ps.line, value)
+
+				return true
+			}
+
+			p.freq = f
+		case "MODE":
+			if _, ok := rig_mode_value(value); !ok {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: PROFILE MODE \"%s\" isn't a hamlib mode, such as FM, USB, or PKTUSB.\n", ps.line, value)
+
+				return true
+			}
+
+			p.mode = strings.ToUpper(value)
+		case "AT":
+			var at, err = time.Parse("15:04", value)
+			if err != nil {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: PROFILE AT should be a time of day, such as 19:30, not \"%s\".\n", ps.line, value)
+
+				return true
+			}
+
+			p.at = at.Hour()*60 + at.Minute()
+		default:
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Unexpected \"%s\" for PROFILE.\n", ps.line, t)
+
+			return true
+		}
 	}
-	return false
-}
 
-// handleDEDUPE handles the DEDUPE keyword.
-func handleDEDUPE(ps *parseState) bool {
-	/*
-	 * DEDUPE 		- Time to suppress digipeating of duplicate APRS packets.
-	 */
-	var t = split("", false)
-	if t == "" {
+	if (p.freq != 0 || p.mode != "") && a.octrl[OCTYPE_PTT].ptt_method != PTT_METHOD_HAMLIB {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing time for DEDUPE command.\n", ps.line)
+		dw_printf("Line %d: PROFILE with FREQ or MODE needs PTT RIG, for hamlib or rigctld, earlier for the same channel.\n", ps.line)
 
 		return true
 	}
 
-	var n, _ = strconv.Atoi(t)
-	if n >= 0 && n < 600 {
-		ps.digi.dedupe_time = n
-	} else {
-		ps.digi.dedupe_time = DEFAULT_DEDUPE
+	a.chan_profiles = append(a.chan_profiles, p)
 
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Unreasonable value for dedupe time. Using %d.\n",
-			ps.line, ps.digi.dedupe_time)
-	}
 	return false
 }
 
-// handleREGEN handles the REGEN keyword.
-func handleREGEN(ps *parseState) bool {
+// handleTXSEQ handles the TXSEQ keyword.
+func handleTXSEQ(ps *parseState) bool {
 	/*
-	 * REGEN 		- Signal regeneration.
+	 * TXSEQ ms [ ms [ ms ] ]	- Delays after PTT, SEQ1, SEQ2 are turned on.
 	 */
-	var t = split("", false)
-	if t == "" {
+	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing FROM-channel on line %d.\n", ps.line)
+		dw_printf("Line %d: TXSEQ can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
 		return true
 	}
 
-	if !alldigits(t) {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: '%s' is not allowed for FROM-channel.  It must be a number.\n",
-			ps.line, t)
+	var delays []int
 
-		return true
+	for t := ps.lex.next(false); t != ""; t = ps.lex.next(false) {
+		var n, err = strconv.Atoi(t)
+		if err != nil || n < 0 || n > 1000 {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: TXSEQ delay should be 0 to 1000 milliseconds, not \"%s\".\n", ps.line, t)
+
+			return true
+		}
+
+		delays = append(delays, n)
 	}
 
-	var from_chan, _ = strconv.Atoi(t)
-	if from_chan < 0 || from_chan >= MAX_RADIO_CHANS {
+	if len(delays) == 0 || len(delays) > 1+len(ptt_sequence_lines) {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: FROM-channel must be in range of 0 to %d on line %d.\n",
-			MAX_RADIO_CHANS-1, ps.line)
+		dw_printf("Line %d: TXSEQ needs from 1 to %d delays, in milliseconds.\n", ps.line, 1+len(ptt_sequence_lines))
 
 		return true
 	}
 
-	// Only radio channels are valid for regenerate.
+	ps.audio.achan[ps.channel].txseq_ms = delays
 
-	if ps.audio.chan_medium[from_chan] != MEDIUM_RADIO {
+	return false
+}
+
+// handleTXTAIL handles the TXTAIL keyword.
+func handleTXTAIL(ps *parseState) bool {
+	/*
+	 * TXTAIL n		- For transmit timing. n = 10 mS units.
+	 */
+	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: FROM-channel %d is not valid.\n",
-			ps.line, from_chan)
+		dw_printf("Line %d: TXTAIL can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
 		return true
 	}
 
-	t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing TO-channel on line %d.\n", ps.line)
+		dw_printf("Line %d: Missing time for TXTAIL command.\n", ps.line)
 
 		return true
 	}
 
-	if !alldigits(t) {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: '%s' is not allowed for TO-channel.  It must be a number.\n",
-			ps.line, t)
+	var n, _ = strconv.Atoi(t)
+	if n >= 0 && n <= 255 {
+		if n < 5 {
+			dw_printf("Line %d: Setting TXTAIL that small is a REALLY BAD idea if you want other stations to hear you.\n",
+				ps.line)
+			dw_printf("Read the Dire Wolf User Guide, \"Radio Channel - Transmit Timing\"\n")
+			dw_printf("section, to understand what this means.\n")
+			dw_printf("Why don't you just use the default rather than reducing reliability?\n")
+		} else if n >= 50 {
+			dw_printf("Line %d: Keeping with tradition, going back to the 1980s, TXTAIL is in 10 millisecond units.\n",
+				ps.line)
+			dw_printf("Line %d: The value %d would be %.3f seconds which seems rather excessive.  Are you sure you want that?\n",
+				ps.line, n, float64(n)*10./1000.)
+			dw_printf("Read the Dire Wolf User Guide, \"Radio Channel - Transmit Timing\"\n")
+			dw_printf("section, to understand what this means.\n")
+			dw_printf("Why don't you just use the default?\n")
+		}
 
-		return true
+		ps.audio.achan[ps.channel].txtail = n
+	} else {
+		ps.audio.achan[ps.channel].txtail = DEFAULT_TXTAIL
+
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid time for transmit timing. Using %d.\n",
+			ps.line, ps.audio.achan[ps.channel].txtail)
 	}
+	return false
+}
 
-	var to_chan, _ = strconv.Atoi(t)
-	if to_chan < 0 || to_chan >= MAX_RADIO_CHANS {
+// handleFULLDUP handles the FULLDUP keyword.
+func handleFULLDUP(ps *parseState) bool {
+	/*
+	 * FULLDUP  {on|off} 		- Full Duplex
+	 */
+	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: TO-channel must be in range of 0 to %d on line %d.\n",
-			MAX_RADIO_CHANS-1, ps.line)
+		dw_printf("Line %d: FULLDUP can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
 		return true
 	}
 
-	if ps.audio.chan_medium[to_chan] != MEDIUM_RADIO {
+	var t = ps.lex.next(false)
+	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: TO-channel %d is not valid.\n",
-			ps.line, to_chan)
+		dw_printf("Line %d: Missing parameter for FULLDUP command.  Expecting ON or OFF.\n", ps.line)
 
 		return true
 	}
 
-	ps.digi.regen[from_chan][to_chan] = true
+	if strings.EqualFold(t, "ON") {
+		ps.audio.achan[ps.channel].fulldup = true
+	} else if strings.EqualFold(t, "OFF") {
+		ps.audio.achan[ps.channel].fulldup = false
+	} else {
+		ps.audio.achan[ps.channel].fulldup = false
+
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Expected ON or OFF for FULLDUP.\n", ps.line)
+	}
 	return false
 }
 
-// handleCDIGIPEAT handles the CDIGIPEAT keyword.
-func handleCDIGIPEAT(ps *parseState) bool {
-	/*
-	 * ==================== Connected Digipeater parameters ====================
-	 */
-
+// handleSPEECH handles the SPEECH keyword.
+func handleSPEECH(ps *parseState) bool {
 	/*
-	 * CDIGIPEAT  from-chan  to-chan [ alias-pattern ]
+	 * SPEECH  script
+	 *
+	 * Specify script for text-to-speech function.
 	 */
-	var t = split("", false)
-	if t == "" {
+	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing FROM-channel on line %d.\n", ps.line)
+		dw_printf("Line %d: SPEECH can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
 		return true
 	}
 
-	if !alldigits(t) {
+	var t = ps.lex.next(false)
+	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: '%s' is not allowed for FROM-channel.  It must be a number.\n",
-			ps.line, t)
+		dw_printf("Line %d: Missing script for Text-to-Speech function.\n", ps.line)
 
 		return true
 	}
 
-	var from_chan, _ = strconv.Atoi(t)
-	if from_chan < 0 || from_chan >= MAX_RADIO_CHANS {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: FROM-channel must be in range of 0 to %d on line %d.\n",
-			MAX_RADIO_CHANS-1, ps.line)
-
-		return true
-	}
+	/* Built in, using espeak, with optional voice. */
 
-	// For connected mode Link layer, only internal modems should be allowed.
-	// A network TNC probably would not provide information about channel status.
-	// There is discussion about this in the document called
-	// Why-is-9600-only-twice-as-fast-as-1200.pdf
+	if strings.EqualFold(t, SPEECH_ESPEAK) {
+		ps.audio.tts_script = ""
+		ps.audio.tts_espeak = speech_espeak_find()
+		ps.audio.tts_voice = ps.lex.next(false)
 
-	if ps.audio.chan_medium[from_chan] != MEDIUM_RADIO {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: FROM-channel %d is not valid.\n",
-			ps.line, from_chan)
-		dw_printf("Only internal modems can be used for connected mode packet.\n")
+		if ps.audio.tts_espeak == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Can't find %s for SPEECH ESPEAK.  Speech will be sent as Morse code.\n",
+				ps.line, strings.Join(speech_espeak_names, " or "))
+		}
 
-		return true
+		return false
 	}
 
-	t = split("", false)
-	if t == "" {
+	/* See if we can run it, without actually running it. */
+
+	var _, err = exec.LookPath(t)
+	if err != nil {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing TO-channel on line %d.\n", ps.line)
+		dw_printf("Line %d: Can't run Text-to-Speech script %s: %s\n", ps.line, t, err)
 
 		return true
 	}
 
-	if !alldigits(t) {
+	ps.audio.tts_script = t
+	ps.audio.tts_espeak = ""
+	return false
+}
+
+// handleFX25TX handles the FX25TX keyword.
+func handleFX25TX(ps *parseState) bool {
+	/*
+	 * FX25TX n		- Enable FX.25 transmission.  Default off.
+	 *				0 = off, 1 = auto mode, others are suggestions for testing
+	 *				or special cases.  16, 32, 64 is number of parity bytes to add.
+	 *				Also set by "-X n" command line option.
+	 *				V1.7 changed from global to per-channel setting.
+	 */
+	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: '%s' is not allowed for TO-channel.  It must be a number.\n",
-			ps.line, t)
+		dw_printf("Line %d: FX25TX can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
 		return true
 	}
 
-	var to_chan, _ = strconv.Atoi(t)
-	if to_chan < 0 || to_chan >= MAX_RADIO_CHANS {
+	var t = ps.lex.next(false)
+	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: TO-channel must be in range of 0 to %d on line %d.\n",
-			MAX_RADIO_CHANS-1, ps.line)
+		dw_printf("Line %d: Missing FEC mode for FX25TX command.\n", ps.line)
 
 		return true
 	}
 
-	if ps.audio.chan_medium[to_chan] != MEDIUM_RADIO {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: TO-channel %d is not valid.\n",
-			ps.line, to_chan)
-		dw_printf("Only internal modems can be used for connected mode packet.\n")
-
-		return true
-	}
-
-	t = split("", false)
-	if t != "" {
-		var r, err = regexp.Compile(t)
-		if err == nil {
-			ps.cdigi.alias[from_chan][to_chan] = r
-			ps.cdigi.has_alias[from_chan][to_chan] = true
-		} else {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file: Invalid alias matching pattern on line %d:\n%s\n", ps.line, err)
-
-			return true
-		}
-
-		t = split("", false)
-	}
-
-	ps.cdigi.enabled[from_chan][to_chan] = true
+	var n, _ = strconv.Atoi(t)
+	if n >= 0 && n < 200 {
+		ps.audio.achan[ps.channel].fx25_strength = n
+		ps.audio.achan[ps.channel].layer2_xmit = LAYER2_FX25
+	} else {
+		ps.audio.achan[ps.channel].fx25_strength = 1
+		ps.audio.achan[ps.channel].layer2_xmit = LAYER2_FX25
 
-	if t != "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: Found \"%s\" where end of line was expected.\n", ps.line, t)
+		dw_printf("Line %d: Unreasonable value for FX.25 transmission mode. Using %d.\n",
+			ps.line, ps.audio.achan[ps.channel].fx25_strength)
 	}
 	return false
 }
 
-// handleFILTER handles the FILTER keyword.
-func handleFILTER(ps *parseState) bool {
+// handleFX25AUTO handles the FX25AUTO keyword.
+func handleFX25AUTO(ps *parseState) bool {
 	/*
-	 * ==================== Packet Filtering for APRS digipeater or IGate ====================
+	 * FX25AUTO n		- Enable Automatic use of FX.25 for connected mode.  *** Not Implemented ***
+	 *				Automatically enable, for that session only, when an identical
+	 *				frame is sent more than this number of times.
+	 *				Default 5 based on half of default RETRY.
+	 *				0 to disable feature.
+	 *				Current a global setting.  Could be per channel someday.
 	 */
+	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: FX25AUTO can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
-	/*
-	 * FILTER  from-chan  to-chan  filter_specification_expression
-	 * FILTER  from-chan  IG       filter_specification_expression
-	 * FILTER  IG         to-chan  filter_specification_expression
-	 *
-	 *
-	 * Note that we have three different config file filter commands:
-	 *
-	 *	FILTER		- Originally for APRS digipeating but later enhanced
-	 *			  to include IGate client side.  Maybe it should be
-	 *			  renamed AFILTER to make it clearer after adding CFILTER.
-	 *
-	 *			  Both internal modem and NET TNC channels allowed here.
-	 *			  "IG" should be used for the IGate, NOT a virtual channel
-	 *			  assigned to it.
-	 *
-	 *	CFILTER		- Similar for connected moded digipeater.
-	 *
-	 *			  Only internal modems can be used because they provide
-	 *			  information about radio channel status.
-	 *			  A remote network TNC might not provide the necessary
-	 *			  status for correct operation.
-	 *			  There is discussion about this in the document called
-	 *			  Why-is-9600-only-twice-as-fast-as-1200.pdf
-	 *
-	 *	IGFILTER	- APRS-IS (IGate) server side - completely different.
-	 *			  I'm not happy with this name because IG sounds like IGate
-	 *			  which is really the client side.  More comments later.
-	 *			  Maybe it should be called subscribe or something like that
-	 *			  because the subscriptions are cumulative.
-	 */
-	var from_chan int
-	var to_chan int
+		return true
+	}
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing FROM-channel on line %d.\n", ps.line)
+		dw_printf("Line %d: Missing count for FX25AUTO command.\n", ps.line)
 
 		return true
 	}
 
-	if t[0] == 'i' || t[0] == 'I' {
-		from_chan = MAX_TOTAL_CHANS
-
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: FILTER IG ... on line %d.\n", ps.line)
-		dw_printf("Warning! Don't mess with IS>RF filtering unless you are an expert and have an unusual situation.\n")
-		dw_printf("Warning! The default is fine for nearly all situations.\n")
-		dw_printf("Warning! Be sure to read carefully and understand  \"Successful-APRS-Gateway-Operation.pdf\" .\n")
-		dw_printf("Warning! If you insist, be sure to add \" | i/180 \" so you don't break messaging.\n")
+	var n, _ = strconv.Atoi(t)
+	if n >= 0 && n < 20 {
+		ps.audio.fx25_auto_enable = n
 	} else {
-		var fromChanErr error
-
-		from_chan, fromChanErr = strconv.Atoi(t)
-		if from_chan < 0 || from_chan >= MAX_TOTAL_CHANS || fromChanErr != nil {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file: Filter FROM-channel must be in range of 0 to %d or \"IG\" on line %d.\n",
-				MAX_TOTAL_CHANS-1, ps.line)
-
-			return true
-		}
-
-		if ps.audio.chan_medium[from_chan] != MEDIUM_RADIO &&
-			ps.audio.chan_medium[from_chan] != MEDIUM_NETTNC {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file, line %d: FROM-channel %d is not valid.\n",
-				ps.line, from_chan)
-
-			return true
-		}
-
-		if ps.audio.chan_medium[from_chan] == MEDIUM_IGATE {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file, line %d: Use 'IG' rather than %d for FROM-channel.\n",
-				ps.line, from_chan)
+		ps.audio.fx25_auto_enable = AX25_N2_RETRY_DEFAULT / 2
 
-			return true
-		}
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Unreasonable count for connected mode automatic FX.25. Using %d.\n",
+			ps.line, ps.audio.fx25_auto_enable)
 	}
+	return false
+}
 
-	t = split("", false)
-	if t == "" {
+// handleIL2PTX handles the IL2PTX keyword.
+func handleIL2PTX(ps *parseState) bool {
+	/*
+	 * IL2PTX  [ + - ] [ 0 1 ]	- Enable IL2P transmission.  Default off.
+	 *				"+" means normal polarity. Redundant since it is the default.
+	 *					(command line -I for first channel)
+	 *				"-" means inverted polarity. Do not use for 1200 bps.
+	 *					(command line -i for first channel)
+	 *				"0" means weak FEC.  Not recommended.
+	 *				"1" means stronger FEC.  "Max FEC."  Default if not specified.
+	 */
+	if ps.channel < 0 || ps.channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing TO-channel on line %d.\n", ps.line)
+		dw_printf("Line %d: IL2PTX can only be used with radio channel 0 - %d.\n", ps.line, MAX_RADIO_CHANS-1)
 
 		return true
 	}
 
-	if t[0] == 'i' || t[0] == 'I' {
-		to_chan = MAX_TOTAL_CHANS
-
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: FILTER ... IG ... on line %d.\n", ps.line)
-		dw_printf("Warning! Don't mess with RF>IS filtering unless you are an expert and have an unusual situation.\n")
-		dw_printf("Warning! Expected behavior is for everything to go from RF to IS.\n")
-		dw_printf("Warning! The default is fine for nearly all situations.\n")
-		dw_printf("Warning! Be sure to read carefully and understand  \"Successful-APRS-Gateway-Operation.pdf\" .\n")
-	} else {
-		var toChanErr error
-
-		to_chan, toChanErr = strconv.Atoi(t)
-		if to_chan < 0 || to_chan >= MAX_TOTAL_CHANS || toChanErr != nil {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file: Filter TO-channel must be in range of 0 to %d or \"IG\" on line %d.\n",
-				MAX_TOTAL_CHANS-1, ps.line)
-
-			return true
-		}
-
-		if ps.audio.chan_medium[to_chan] != MEDIUM_RADIO &&
-			ps.audio.chan_medium[to_chan] != MEDIUM_NETTNC {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file, line %d: TO-channel %d is not valid.\n",
-				ps.line, to_chan)
+	ps.audio.achan[ps.channel].layer2_xmit = LAYER2_IL2P
+	ps.audio.achan[ps.channel].il2p_max_fec = 1
+	ps.audio.achan[ps.channel].il2p_invert_polarity = 0
+	ps.audio.achan[ps.channel].il2p_crc = true
 
-			return true
+	for {
+		var t = ps.lex.next(false)
+		if t == "" {
+			break
 		}
 
-		if ps.audio.chan_medium[to_chan] == MEDIUM_IGATE {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file, line %d: Use 'IG' rather than %d for TO-channel.\n",
-				ps.line, to_chan)
+		for _, c := range t {
+			switch c {
+			case '+':
+				ps.audio.achan[ps.channel].il2p_invert_polarity = 0
+			case '-':
+				ps.audio.achan[ps.channel].il2p_invert_polarity = 1
+			case '0':
+				ps.audio.achan[ps.channel].il2p_max_fec = 0
+			case '1':
+				ps.audio.achan[ps.channel].il2p_max_fec = 1
+			case 'C':
+				ps.audio.achan[ps.channel].il2p_crc = true
+			case 'c':
+				ps.audio.achan[ps.channel].il2p_crc = false
+			default:
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: Invalid parameter '%c' for IL2PTX command.\n", ps.line, c)
 
-			return true
+				continue
+			}
 		}
 	}
-
-	t = split("", true) /* Take rest of ps.line including spaces. */
-
-	if t == "" {
-		t = " " /* Empty means permit nothing. */
-	}
-
-	if ps.digi.filter_str[from_chan][to_chan] != "" {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: Replacing previous filter for same from/to pair:\n        %s\n", ps.line, ps.digi.filter_str[from_chan][to_chan])
-		ps.digi.filter_str[from_chan][to_chan] = ""
-	}
-
-	ps.digi.filter_str[from_chan][to_chan] = t
-
-	// TODO:  Do a test run to see errors now instead of waiting.
 	return false
 }
 
-// handleCFILTER handles the CFILTER keyword.
-func handleCFILTER(ps *parseState) bool {
+// handleDIGIPEAT handles the DIGIPEAT keyword.
+func handleDIGIPEAT(ps *parseState) bool {
 	/*
-	 * ==================== Packet Filtering for connected digipeater ====================
+	 * ==================== APRS Digipeater parameters ====================
 	 */
 
 	/*
-	 * CFILTER  from-chan  to-chan  filter_specification_expression
+	 * DIGIPEAT  from-chan  to-chan  alias-pattern  wide-pattern  [ OFF|DROP|MARK|TRACE | ATGP=alias ]
 	 *
-	 * Why did I put this here?
-	 * What would be a useful use case?  Perhaps block by source or destination?
+	 * ATGP is an ugly hack for the specific need of ATGP which needs more that 8 digipeaters.
+	 * DO NOT put this in the User Guide.  On a need to know basis.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Config file: Missing FROM-channel on line %d.\n", ps.line)
@@ -3575,19 +4196,27 @@ func handleCFILTER(ps *parseState) bool {
 		return true
 	}
 
-	var from_chan, fromChanErr = strconv.Atoi(t)
-	if from_chan < 0 || from_chan >= MAX_RADIO_CHANS || fromChanErr != nil {
+	if !alldigits(t) {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Filter FROM-channel must be in range of 0 to %d on line %d.\n",
-			MAX_RADIO_CHANS-1, ps.line)
+		dw_printf("Config file, line %d: '%s' is not allowed for FROM-channel.  It must be a number.\n",
+			ps.line, t)
 
 		return true
 	}
 
-	// DO NOT allow a network TNC here.
-	// Must be internal modem to have necessary knowledge about channel status.
+	var from_chan, _ = strconv.Atoi(t)
+	if from_chan < 0 || from_chan >= MAX_TOTAL_CHANS {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: FROM-channel must be in range of 0 to %d on line %d.\n",
+			MAX_TOTAL_CHANS-1, ps.line)
 
-	if ps.audio.chan_medium[from_chan] != MEDIUM_RADIO {
+		return true
+	}
+
+	// Channels specified must be radio channels or network TNCs.
+
+	if ps.audio.chan_medium[from_chan] != MEDIUM_RADIO &&
+		ps.audio.chan_medium[from_chan] != MEDIUM_NETTNC {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Config file, line %d: FROM-channel %d is not valid.\n",
 			ps.line, from_chan)
@@ -3595,7 +4224,7 @@ func handleCFILTER(ps *parseState) bool {
 		return true
 	}
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Config file: Missing TO-channel on line %d.\n", ps.line)
@@ -3603,1038 +4232,1539 @@ func handleCFILTER(ps *parseState) bool {
 		return true
 	}
 
-	var to_chan, toChanErr = strconv.Atoi(t)
-	if to_chan < 0 || to_chan >= MAX_RADIO_CHANS || toChanErr != nil {
+	if !alldigits(t) {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Filter TO-channel must be in range of 0 to %d on line %d.\n",
-			MAX_RADIO_CHANS-1, ps.line)
+		dw_printf("Config file, line %d: '%s' is not allowed for TO-channel.  It must be a number.\n",
+			ps.line, t)
 
 		return true
 	}
 
-	if ps.audio.chan_medium[to_chan] != MEDIUM_RADIO {
+	var to_chan, _ = strconv.Atoi(t)
+	if to_chan < 0 || to_chan >= MAX_TOTAL_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: TO-channel %d is not valid.\n",
-			ps.line, to_chan)
+		dw_printf("Config file: TO-channel must be in range of 0 to %d on line %d.\n",
+			MAX_TOTAL_CHANS-1, ps.line)
 
 		return true
 	}
 
-	t = split("", true) /* Take rest of ps.line including spaces. */
-
-	if t == "" {
-		t = " " /* Empty means permit nothing. */
-	}
-
-	ps.cdigi.cfilter_str[from_chan][to_chan] = t
-
-	// TODO1.2:  Do a test run to see errors now instead of waiting.
-	return false
-}
-
-// handleTTCORRAL handles the TTCORRAL keyword.
-func handleTTCORRAL(ps *parseState) bool {
-	/*
-	 * ==================== APRStt gateway ====================
-	 */
-
-	/*
-	 * TTCORRAL 		- How to handle unknown positions
-	 *
-	 * TTCORRAL  latitude  longitude  offset-or-ambiguity
-	 */
-
-	var t = split("", false)
-	if t == "" {
+	if ps.audio.chan_medium[to_chan] != MEDIUM_RADIO &&
+		ps.audio.chan_medium[to_chan] != MEDIUM_NETTNC {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing latitude for TTCORRAL command.\n", ps.line)
+		dw_printf("Config file, line %d: TO-channel %d is not valid.\n",
+			ps.line, to_chan)
+
 		return true
 	}
-	ps.tt.corral_lat = parse_ll(t, LAT, ps.line)
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing longitude for TTCORRAL command.\n", ps.line)
+		dw_printf("Config file: Missing alias pattern on line %d.\n", ps.line)
+
 		return true
 	}
-	ps.tt.corral_lon = parse_ll(t, LON, ps.line)
 
-	t = split("", false)
-	if t == "" {
+	var r, err = regexp.Compile(t)
+	if err != nil {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing offset-or-ambiguity for TTCORRAL command.\n", ps.line)
+		dw_printf("Config file: Invalid alias matching pattern on line %d:\n%s\n", ps.line, err)
+
 		return true
 	}
-	ps.tt.corral_offset = parse_ll(t, LAT, ps.line)
-	if ps.tt.corral_offset == 1 ||
-		ps.tt.corral_offset == 2 ||
-		ps.tt.corral_offset == 3 {
-		ps.tt.corral_ambiguity = int(ps.tt.corral_offset)
-		ps.tt.corral_offset = 0
-	}
-
-	// dw_printf ("DEBUG: corral %f %f %f %d\n", p_tt_config.corral_lat,
-	//
-	//	p_tt_config.corral_lon, p_tt_config.corral_offset, p_tt_config.corral_ambiguity);
-	return false
-}
-
-// handleTTPOINT handles the TTPOINT keyword.
-func handleTTPOINT(ps *parseState) bool {
-	/*
-	 * TTPOINT 		- Define a point represented by touch tone sequence.
-	 *
-	 * TTPOINT   pattern  latitude  longitude
-	 */
-
-	var tl = new(ttloc_s)
-	tl.ttlocType = TTLOC_POINT
 
-	// Pattern: B and digits
+	ps.digi.alias[from_chan][to_chan] = r
 
-	var t = split("", false)
+	t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing pattern for TTPOINT command.\n", ps.line)
+		dw_printf("Config file: Missing wide pattern on line %d.\n", ps.line)
+
 		return true
 	}
-	tl.pattern = t
 
-	if t[0] != 'B' {
+	r, err = regexp.Compile(t)
+	if err != nil {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTPOINT pattern must begin with upper case 'B'.\n", ps.line)
+		dw_printf("Config file: Invalid wide matching pattern on line %d:\n%s\n", ps.line, err)
+
+		return true
 	}
 
-	for _, j := range t[1:] {
-		if !unicode.IsDigit(j) {
+	ps.digi.wide[from_chan][to_chan] = r
+
+	ps.digi.enabled[from_chan][to_chan] = true
+	ps.digi.preempt[from_chan][to_chan] = PREEMPT_OFF
+
+	t = ps.lex.next(false)
+	if t != "" {
+		if strings.EqualFold(t, "OFF") {
+			ps.digi.preempt[from_chan][to_chan] = PREEMPT_OFF
+			t = ps.lex.next(false)
+		} else if strings.EqualFold(t, "DROP") {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: TTPOINT pattern must be B and digits only.\n", ps.line)
+			dw_printf("Config file, line %d: Preemptive digipeating DROP option is discouraged.\n", ps.line)
+			dw_printf("It can create a via path which is misleading about the actual path taken.\n")
+			dw_printf("PREEMPT is the best choice for this feature.\n")
+
+			ps.digi.preempt[from_chan][to_chan] = PREEMPT_DROP
+			t = ps.lex.next(false)
+		} else if strings.EqualFold(t, "MARK") {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file, line %d: Preemptive digipeating MARK option is discouraged.\n", ps.line)
+			dw_printf("It can create a via path which is misleading about the actual path taken.\n")
+			dw_printf("PREEMPT is the best choice for this feature.\n")
+
+			ps.digi.preempt[from_chan][to_chan] = PREEMPT_MARK
+			t = ps.lex.next(false)
+		} else if (strings.EqualFold(t, "TRACE")) || (strings.HasPrefix(strings.ToUpper(t), "PREEMPT")) {
+			ps.digi.preempt[from_chan][to_chan] = PREEMPT_TRACE
+			t = ps.lex.next(false)
+		} else if strings.HasPrefix(strings.ToUpper(t), "ATGP=") {
+			ps.digi.atgp[from_chan][to_chan] = t[5:]
+			t = ps.lex.next(false)
 		}
 	}
 
-	// Latitude
+	if t != "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file, line %d: Found \"%s\" where end of line was expected.\n", ps.line, t)
+	}
+	return false
+}
 
-	t = split("", false)
+// handleDEDUPE handles the DEDUPE keyword.
+func handleDEDUPE(ps *parseState) bool {
+	/*
+	 * DEDUPE 		- Time to suppress digipeating of duplicate APRS packets.
+	 */
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing latitude for TTPOINT command.\n", ps.line)
+		dw_printf("Line %d: Missing time for DEDUPE command.\n", ps.line)
+
 		return true
 	}
-	tl.point.lat = parse_ll(t, LAT, ps.line)
 
-	// Longitude
+	var n, _ = strconv.Atoi(t)
+	if n >= 0 && n < 600 {
+		ps.digi.dedupe_time = n
+	} else {
+		ps.digi.dedupe_time = DEFAULT_DEDUPE
 
-	t = split("", false)
-	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing longitude for TTPOINT command.\n", ps.line)
-		return true
+		dw_printf("Line %d: Unreasonable value for dedupe time. Using %d.\n",
+			ps.line, ps.digi.dedupe_time)
 	}
-	tl.point.lon = parse_ll(t, LON, ps.line)
-
-	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
 	return false
 }
 
-// handleTTVECTOR handles the TTVECTOR keyword.
-func handleTTVECTOR(ps *parseState) bool {
+// handleREGEN handles the REGEN keyword.
+func handleREGEN(ps *parseState) bool {
 	/*
-	 * TTVECTOR 		- Touch tone location with bearing and distance.
-	 *
-	 * TTVECTOR   pattern  latitude  longitude  scale  unit
+	 * REGEN 		- Signal regeneration.
 	 */
-
-	var tl = new(ttloc_s)
-	tl.ttlocType = TTLOC_VECTOR
-	tl.pattern = ""
-	tl.vector.lat = 0
-	tl.vector.lon = 0
-	tl.vector.scale = 1
-
-	// Pattern: B5bbbd...
-
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing pattern for TTVECTOR command.\n", ps.line)
+		dw_printf("Config file: Missing FROM-channel on line %d.\n", ps.line)
+
 		return true
 	}
-	tl.pattern = t
 
-	if t[0] != 'B' {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTVECTOR pattern must begin with upper case 'B'.\n", ps.line)
-	}
-	if !strings.HasPrefix(t[1:], "5bbb") {
+	if !alldigits(t) {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTVECTOR pattern would normally contain \"5bbb\".\n", ps.line)
-	}
-	for j := 1; j < len(t); j++ {
-		if !unicode.IsDigit(rune(t[j])) && t[j] != 'b' && t[j] != 'd' {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: TTVECTOR pattern must contain only B, digits, b, and d.\n", ps.line)
-		}
-	}
+		dw_printf("Config file, line %d: '%s' is not allowed for FROM-channel.  It must be a number.\n",
+			ps.line, t)
 
-	// Latitude
+		return true
+	}
 
-	t = split("", false)
-	if t == "" {
+	var from_chan, _ = strconv.Atoi(t)
+	if from_chan < 0 || from_chan >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing latitude for TTVECTOR command.\n", ps.line)
+		dw_printf("Config file: FROM-channel must be in range of 0 to %d on line %d.\n",
+			MAX_RADIO_CHANS-1, ps.line)
+
 		return true
 	}
-	tl.vector.lat = parse_ll(t, LAT, ps.line)
 
-	// Longitude
+	// Only radio channels are valid for regenerate.
 
-	t = split("", false)
-	if t == "" {
+	if ps.audio.chan_medium[from_chan] != MEDIUM_RADIO {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing longitude for TTVECTOR command.\n", ps.line)
+		dw_printf("Config file, line %d: FROM-channel %d is not valid.\n",
+			ps.line, from_chan)
+
 		return true
 	}
-	tl.vector.lon = parse_ll(t, LON, ps.line)
-
-	// Longitude
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing scale for TTVECTOR command.\n", ps.line)
+		dw_printf("Config file: Missing TO-channel on line %d.\n", ps.line)
+
 		return true
 	}
-	var scale, scaleErr = strconv.ParseFloat(t, 64)
-	if scaleErr != nil {
+
+	if !alldigits(t) {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Invalid scale \"%s\" for TTVECTOR command.\n", ps.line, t)
+		dw_printf("Config file, line %d: '%s' is not allowed for TO-channel.  It must be a number.\n",
+			ps.line, t)
+
 		return true
 	}
 
-	// Unit.
-
-	t = split("", false)
-	if t == "" {
+	var to_chan, _ = strconv.Atoi(t)
+	if to_chan < 0 || to_chan >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing unit for TTVECTOR command.\n", ps.line)
+		dw_printf("Config file: TO-channel must be in range of 0 to %d on line %d.\n",
+			MAX_RADIO_CHANS-1, ps.line)
+
 		return true
 	}
 
-	var meters float64
-	for j := 0; j < len(units) && meters == 0; j++ {
-		if strings.EqualFold(units[j].name, t) {
-			meters = units[j].meters
-		}
-	}
-	if meters == 0 {
+	if ps.audio.chan_medium[to_chan] != MEDIUM_RADIO {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Unrecognized unit for TTVECTOR command.  Using miles.\n", ps.line)
-		meters = 1609.344
+		dw_printf("Config file, line %d: TO-channel %d is not valid.\n",
+			ps.line, to_chan)
+
+		return true
 	}
-	tl.vector.scale = scale * meters
 
-	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
+	ps.digi.regen[from_chan][to_chan] = true
 	return false
 }
 
-// handleTTGRID handles the TTGRID keyword.
-func handleTTGRID(ps *parseState) bool {
+// handleCDIGIPEAT handles the CDIGIPEAT keyword.
+func handleCDIGIPEAT(ps *parseState) bool {
 	/*
-	 * TTGRID 		- Define a grid for touch tone locations.
-	 *
-	 * TTGRID   pattern  min-latitude  min-longitude  max-latitude  max-longitude
+	 * ==================== Connected Digipeater parameters ====================
 	 */
 
-	var tl = new(ttloc_s)
-	tl.ttlocType = TTLOC_GRID
-
-	// Pattern: B [digit] x... y...
-
-	var t = split("", false)
-	if t == "" {
+	/*
+	 * CDIGIPEAT  from-chan  to-chan [ alias-pattern ]
+	 */
+	var t = ps.lex.next(false)
+	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing pattern for TTGRID command.\n", ps.line)
+		dw_printf("Config file: Missing FROM-channel on line %d.\n", ps.line)
+
 		return true
 	}
-	tl.pattern = t
 
-	if t[0] != 'B' {
+	if !alldigits(t) {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTGRID pattern must begin with upper case 'B'.\n", ps.line)
+		dw_printf("Config file, line %d: '%s' is not allowed for FROM-channel.  It must be a number.\n",
+			ps.line, t)
+
+		return true
 	}
-	for j := 1; j < len(t); j++ {
-		if !unicode.IsDigit(rune(t[j])) && t[j] != 'x' && t[j] != 'y' {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: TTGRID pattern must be B, optional digit, xxx, yyy.\n", ps.line)
-		}
+
+	var from_chan, _ = strconv.Atoi(t)
+	if from_chan < 0 || from_chan >= MAX_RADIO_CHANS {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: FROM-channel must be in range of 0 to %d on line %d.\n",
+			MAX_RADIO_CHANS-1, ps.line)
+
+		return true
 	}
 
-	// Minimum Latitude - all zeros in received data
+	// For connected mode Link layer, only internal modems should be allowed.
+	// A network TNC probably would not provide information about channel status.
+	// There is discussion about this in the document called
+	// Why-is-9600-only-twice-as-fast-as-1200.pdf
 
-	t = split("", false)
-	if t == "" {
+	if ps.audio.chan_medium[from_chan] != MEDIUM_RADIO {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing minimum latitude for TTGRID command.\n", ps.line)
+		dw_printf("Config file, line %d: FROM-channel %d is not valid.\n",
+			ps.line, from_chan)
+		dw_printf("Only internal modems can be used for connected mode packet.\n")
+
 		return true
 	}
-	tl.grid.lat0 = parse_ll(t, LAT, ps.line)
-
-	// Minimum Longitude - all zeros in received data
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing minimum longitude for TTGRID command.\n", ps.line)
+		dw_printf("Config file: Missing TO-channel on line %d.\n", ps.line)
+
 		return true
 	}
-	tl.grid.lon0 = parse_ll(t, LON, ps.line)
-
-	// Maximum Latitude - all nines in received data
 
-	t = split("", false)
-	if t == "" {
+	if !alldigits(t) {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing maximum latitude for TTGRID command.\n", ps.line)
+		dw_printf("Config file, line %d: '%s' is not allowed for TO-channel.  It must be a number.\n",
+			ps.line, t)
+
 		return true
 	}
-	tl.grid.lat9 = parse_ll(t, LAT, ps.line)
 
-	// Maximum Longitude - all nines in received data
+	var to_chan, _ = strconv.Atoi(t)
+	if to_chan < 0 || to_chan >= MAX_RADIO_CHANS {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: TO-channel must be in range of 0 to %d on line %d.\n",
+			MAX_RADIO_CHANS-1, ps.line)
 
-	t = split("", false)
-	if t == "" {
+		return true
+	}
+
+	if ps.audio.chan_medium[to_chan] != MEDIUM_RADIO {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing maximum longitude for TTGRID command.\n", ps.line)
+		dw_printf("Config file, line %d: TO-channel %d is not valid.\n",
+			ps.line, to_chan)
+		dw_printf("Only internal modems can be used for connected mode packet.\n")
+
 		return true
 	}
-	tl.grid.lon9 = parse_ll(t, LON, ps.line)
 
-	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
+	t = ps.lex.next(false)
+	if t != "" {
+		var r, err = regexp.Compile(t)
+		if err == nil {
+			ps.cdigi.alias[from_chan][to_chan] = r
+			ps.cdigi.has_alias[from_chan][to_chan] = true
+		} else {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file: Invalid alias matching pattern on line %d:\n%s\n", ps.line, err)
+
+			return true
+		}
+
+		t = ps.lex.next(false)
+	}
+
+	ps.cdigi.enabled[from_chan][to_chan] = true
+
+	if t != "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file, line %d: Found \"%s\" where end of line was expected.\n", ps.line, t)
+	}
 	return false
 }
 
-// handleTTUTM handles the TTUTM keyword.
-func handleTTUTM(ps *parseState) bool {
+// handleFILTER handles the FILTER keyword.
+func handleFILTER(ps *parseState) bool {
 	/*
-	 * TTUTM 		- Specify UTM zone for touch tone locations.
-	 *
-	 * TTUTM   pattern  zone [ scale [ x-offset y-offset ] ]
+	 * ==================== Packet Filtering for APRS digipeater or IGate ====================
 	 */
 
-	var tl = new(ttloc_s)
-	tl.ttlocType = TTLOC_UTM
-	tl.utm.scale = 1
-
-	// Pattern: B [digit] x... y...
+	/*
+	 * FILTER  from-chan  to-chan  filter_specification_expression
+	 * FILTER  from-chan  IG       filter_specification_expression
+	 * FILTER  IG         to-chan  filter_specification_expression
+	 *
+	 *
+	 * Note that we have three different config file filter commands:
+	 *
+	 *	FILTER		- Originally for APRS digipeating but later enhanced
+	 *			  to include IGate client side.  Maybe it should be
+	 *			  renamed AFILTER to make it clearer after adding CFILTER.
+	 *
+	 *			  Both internal modem and NET TNC channels allowed here.
+	 *			  "IG" should be used for the IGate, NOT a virtual channel
+	 *			  assigned to it.
+	 *
+	 *	CFILTER		- Similar for connected moded digipeater.
+	 *
+	 *			  Only internal modems can be used because they provide
+	 *			  information about radio channel status.
+	 *			  A remote network TNC might not provide the necessary
+	 *			  status for correct operation.
+	 *			  There is discussion about this in the document called
+	 *			  Why-is-9600-only-twice-as-fast-as-1200.pdf
+	 *
+	 *	IGFILTER	- APRS-IS (IGate) server side - completely different.
+	 *			  I'm not happy with this name because IG sounds like IGate
+	 *			  which is really the client side.  More comments later.
+	 *			  Maybe it should be called subscribe or something like that
+	 *			  because the subscriptions are cumulative.
+	 */
+	var from_chan int
+	var to_chan int
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing pattern for TTUTM command.\n", ps.line)
+		dw_printf("Config file: Missing FROM-channel on line %d.\n", ps.line)
+
 		return true
 	}
-	tl.pattern = t
 
-	if t[0] != 'B' {
+	if t[0] == 'i' || t[0] == 'I' {
+		from_chan = MAX_TOTAL_CHANS
+
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTUTM pattern must begin with upper case 'B'.\n", ps.line)
-		return true
-	}
-	for j := 1; j < len(t); j++ {
-		if !unicode.IsDigit(rune(t[j])) && t[j] != 'x' && t[j] != 'y' {
+		dw_printf("Config file: FILTER IG ... on line %d.\n", ps.line)
+		dw_printf("Warning! Don't mess with IS>RF filtering unless you are an expert and have an unusual situation.\n")
+		dw_printf("Warning! The default is fine for nearly all situations.\n")
+		dw_printf("Warning! Be sure to read carefully and understand  \"Successful-APRS-Gateway-Operation.pdf\" .\n")
+		dw_printf("Warning! If you insist, be sure to add \" | i/180 \" so you don't break messaging.\n")
+	} else {
+		var fromChanErr error
+
+		from_chan, fromChanErr = strconv.Atoi(t)
+		if from_chan < 0 || from_chan >= MAX_TOTAL_CHANS || fromChanErr != nil {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: TTUTM pattern must be B, optional digit, xxx, yyy.\n", ps.line)
-			// Bail out somehow.  continue would match inner for.
+			dw_printf("Config file: Filter FROM-channel must be in range of 0 to %d or \"IG\" on line %d.\n",
+				MAX_TOTAL_CHANS-1, ps.line)
+
+			return true
 		}
-	}
 
-	// Zone 1 - 60 and optional latitudinal letter.
+		if ps.audio.chan_medium[from_chan] != MEDIUM_RADIO &&
+			ps.audio.chan_medium[from_chan] != MEDIUM_NETTNC {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file, line %d: FROM-channel %d is not valid.\n",
+				ps.line, from_chan)
+
+			return true
+		}
+
+		if ps.audio.chan_medium[from_chan] == MEDIUM_IGATE {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file, line %d: Use 'IG' rather than %d for FROM-channel.\n",
+				ps.line, from_chan)
+
+			return true
+		}
+	}
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing zone for TTUTM command.\n", ps.line)
+		dw_printf("Config file: Missing TO-channel on line %d.\n", ps.line)
+
 		return true
 	}
 
-	tl.utm.latband, tl.utm.hemi, tl.utm.lzone = parse_utm_zone(t)
+	if t[0] == 'i' || t[0] == 'I' {
+		to_chan = MAX_TOTAL_CHANS
 
-	// Optional scale.
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: FILTER ... IG ... on line %d.\n", ps.line)
+		dw_printf("Warning! Don't mess with RF>IS filtering unless you are an expert and have an unusual situation.\n")
+		dw_printf("Warning! Expected behavior is for everything to go from RF to IS.\n")
+		dw_printf("Warning! The default is fine for nearly all situations.\n")
+		dw_printf("Warning! Be sure to read carefully and understand  \"Successful-APRS-Gateway-Operation.pdf\" .\n")
+	} else {
+		var toChanErr error
 
-	t = split("", false)
-	if t != "" {
-		var scaleVal, scaleErr = strconv.ParseFloat(t, 64)
-		if scaleErr != nil {
+		to_chan, toChanErr = strconv.Atoi(t)
+		if to_chan < 0 || to_chan >= MAX_TOTAL_CHANS || toChanErr != nil {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: Invalid scale \"%s\" for TTUTM command.\n", ps.line, t)
+			dw_printf("Config file: Filter TO-channel must be in range of 0 to %d or \"IG\" on line %d.\n",
+				MAX_TOTAL_CHANS-1, ps.line)
+
 			return true
 		}
 
-		tl.utm.scale = scaleVal
-
-		// Optional x offset.
-
-		t = split("", false)
-		if t != "" {
-			var xOffset, xErr = strconv.ParseFloat(t, 64)
-			if xErr != nil {
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Line %d: Invalid x offset \"%s\" for TTUTM command.\n", ps.line, t)
-				return true
-			}
-
-			tl.utm.x_offset = xOffset
+		if ps.audio.chan_medium[to_chan] != MEDIUM_RADIO &&
+			ps.audio.chan_medium[to_chan] != MEDIUM_NETTNC {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file, line %d: TO-channel %d is not valid.\n",
+				ps.line, to_chan)
 
-			// Optional y offset.
+			return true
+		}
 
-			t = split("", false)
-			if t != "" {
-				var yOffset, yErr = strconv.ParseFloat(t, 64)
-				if yErr != nil {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Line %d: Invalid y offset \"%s\" for TTUTM command.\n", ps.line, t)
-					return true
-				}
+		if ps.audio.chan_medium[to_chan] == MEDIUM_IGATE {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file, line %d: Use 'IG' rather than %d for TO-channel.\n",
+				ps.line, to_chan)
 
-				tl.utm.y_offset = yOffset
-			}
+			return true
 		}
 	}
 
-	// Practice run to see if conversion might fail later with actual location.
+	t = ps.lex.next(true) /* Take rest of ps.line including spaces. */
 
-	var utm = coordconv.UTMCoord{
-		Zone:       tl.utm.lzone,
-		Hemisphere: HemisphereRuneToCoordconvHemisphere(tl.utm.hemi),
-		Easting:    tl.utm.x_offset + 5*tl.utm.scale,
-		Northing:   tl.utm.y_offset + 5*tl.utm.scale,
+	if t == "" {
+		t = " " /* Empty means permit nothing. */
 	}
-	var _, geoErr = coordconv.DefaultUTMConverter.ConvertToGeodetic(utm)
 
-	if geoErr != nil {
+	if ps.digi.filter_str[from_chan][to_chan] != "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Invalid UTM location: \n%s\n", ps.line, geoErr)
-		return true
+		dw_printf("Config file, line %d: Replacing previous filter for same from/to pair:\n        %s\n", ps.line, ps.digi.filter_str[from_chan][to_chan])
+		ps.digi.filter_str[from_chan][to_chan] = ""
 	}
 
-	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
+	ps.digi.filter_str[from_chan][to_chan] = t
+
+	// TODO:  Do a test run to see errors now instead of waiting.
 	return false
 }
 
-// handleTTUSNGMGRS handles the TTUSNGMGRS keyword.
-func handleTTUSNGMGRS(ps *parseState) bool {
+// handleCFILTER handles the CFILTER keyword.
+func handleCFILTER(ps *parseState) bool {
 	/*
-	 * TTUSNG, TTMGRS 		- Specify zone/square for touch tone locations.
-	 *
-	 * TTUSNG   pattern  zone_square
-	 * TTMGRS   pattern  zone_square
+	 * ==================== Packet Filtering for connected digipeater ====================
 	 */
 
-	var tl = new(ttloc_s)
-
-	// TODO1.2: in progress...
-	if strings.EqualFold(ps.keyword, "TTMGRS") {
-		tl.ttlocType = TTLOC_MGRS
-	} else {
-		tl.ttlocType = TTLOC_USNG
-	}
-
-	// Pattern: B [digit] x... y...
-
-	var t = split("", false)
+	/*
+	 * CFILTER  from-chan  to-chan  filter_specification_expression
+	 *
+	 * Why did I put this here?
+	 * What would be a useful use case?  Perhaps block by source or destination?
+	 */
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing pattern for TTUSNG/TTMGRS command.\n", ps.line)
+		dw_printf("Config file: Missing FROM-channel on line %d.\n", ps.line)
+
 		return true
 	}
-	tl.pattern = t
 
-	if t[0] != 'B' {
+	var from_chan, fromChanErr = strconv.Atoi(t)
+	if from_chan < 0 || from_chan >= MAX_RADIO_CHANS || fromChanErr != nil {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTUSNG/TTMGRS pattern must begin with upper case 'B'.\n", ps.line)
+		dw_printf("Config file: Filter FROM-channel must be in range of 0 to %d on line %d.\n",
+			MAX_RADIO_CHANS-1, ps.line)
+
 		return true
 	}
-	var num_x = 0
-	var num_y = 0
-	for j := 1; j < len(t); j++ {
-		if !unicode.IsDigit(rune(t[j])) && t[j] != 'x' && t[j] != 'y' {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: TTUSNG/TTMGRS pattern must be B, optional digit, xxx, yyy.\n", ps.line)
-			// Bail out somehow.  continue would match inner for.
-		}
-		if t[j] == 'x' {
-			num_x++
-		}
-		if t[j] == 'y' {
-			num_y++
-		}
-	}
-	if num_x < 1 || num_x > 5 || num_x != num_y {
+
+	// DO NOT allow a network TNC here.
+	// Must be internal modem to have necessary knowledge about channel status.
+
+	if ps.audio.chan_medium[from_chan] != MEDIUM_RADIO {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTUSNG/TTMGRS must have 1 to 5 x and same number y.\n", ps.line)
+		dw_printf("Config file, line %d: FROM-channel %d is not valid.\n",
+			ps.line, from_chan)
+
 		return true
 	}
 
-	// Zone 1 - 60 and optional latitudinal letter.
-
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing zone & square for TTUSNG/TTMGRS command.\n", ps.line)
+		dw_printf("Config file: Missing TO-channel on line %d.\n", ps.line)
+
 		return true
 	}
-	tl.mgrs.zone = t
 
-	// Try converting it rather do our own error checking.
+	var to_chan, toChanErr = strconv.Atoi(t)
+	if to_chan < 0 || to_chan >= MAX_RADIO_CHANS || toChanErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: Filter TO-channel must be in range of 0 to %d on line %d.\n",
+			MAX_RADIO_CHANS-1, ps.line)
 
-	var _, convertErr = coordconv.DefaultMGRSConverter.ConvertToGeodetic(tl.mgrs.zone)
-	if convertErr != nil {
+		return true
+	}
+
+	if ps.audio.chan_medium[to_chan] != MEDIUM_RADIO {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Invalid USNG/MGRS zone & square:  %s\n%s\n", ps.line, tl.mgrs.zone, convertErr)
+		dw_printf("Config file, line %d: TO-channel %d is not valid.\n",
+			ps.line, to_chan)
+
 		return true
 	}
 
-	// Should be the end.
+	t = ps.lex.next(true) /* Take rest of ps.line including spaces. */
 
-	t = split("", false)
-	if t != "" {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Unexpected stuff at end ignored:  %s\n", ps.line, t)
+	if t == "" {
+		t = " " /* Empty means permit nothing. */
 	}
 
-	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
+	ps.cdigi.cfilter_str[from_chan][to_chan] = t
+
+	// TODO1.2:  Do a test run to see errors now instead of waiting.
 	return false
 }
 
-// handleTTMHEAD handles the TTMHEAD keyword.
-func handleTTMHEAD(ps *parseState) bool {
+// handleTTCORRAL handles the TTCORRAL keyword.
+func handleTTCORRAL(ps *parseState) bool {
 	/*
-	 * TTMHEAD 		- Define pattern to be used for Maidenhead Locator.
-	 *
-	 * TTMHEAD   pattern   [ prefix ]
-	 *
-	 *			Pattern would be  B[0-9A-D]xxxx...
-	 *			Optional prefix is 10, 6, or 4 digits.
-	 *
-	 *			The total number of digits in both must be 4, 6, 10, or 12.
+	 * ==================== APRStt gateway ====================
 	 */
 
-	// TODO1.3:  TTMHEAD needs testing.
-
-	var tl = new(ttloc_s)
-	tl.ttlocType = TTLOC_MHEAD
-
-	// Pattern: B, optional additional button, some number of xxxx... for matching
+	/*
+	 * TTCORRAL 		- How to handle unknown positions
+	 *
+	 * TTCORRAL  latitude  longitude  offset-or-ambiguity
+	 */
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing pattern for TTMHEAD command.\n", ps.line)
+		dw_printf("Line %d: Missing latitude for TTCORRAL command.\n", ps.line)
 		return true
 	}
-	tl.pattern = t
+	ps.tt.corral_lat = parse_ll(t, LAT, ps.line)
 
-	if t[0] != 'B' {
+	t = ps.lex.next(false)
+	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTMHEAD pattern must begin with upper case 'B'.\n", ps.line)
+		dw_printf("Line %d: Missing longitude for TTCORRAL command.\n", ps.line)
 		return true
 	}
+	ps.tt.corral_lon = parse_ll(t, LON, ps.line)
 
-	// Optionally one of 0-9ABCD
-
-	var j int
-	if len(t) > 1 && (strings.ContainsRune("ABCD", rune(t[1])) || unicode.IsDigit(rune(t[1]))) {
-		j = 2
-	} else {
-		j = 1
-	}
-
-	var count_x = 0
-	var count_other = 0
-	for k := j; k < len(t); k++ {
-		if t[k] == 'x' {
-			count_x++
-		} else {
-			count_other++
-		}
-	}
-
-	if count_other != 0 {
+	t = ps.lex.next(false)
+	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTMHEAD must have only lower case x to match received data.\n", ps.line)
+		dw_printf("Line %d: Missing offset-or-ambiguity for TTCORRAL command.\n", ps.line)
 		return true
 	}
-
-	// optional prefix
-
-	t = split("", false)
-	if t != "" {
-		tl.mhead.prefix = t
-
-		if !alldigits(t) || (len(t) != 4 && len(t) != 6 && len(t) != 10) {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: TTMHEAD prefix must be 4, 6, or 10 digits.\n", ps.line)
-			return true
-		}
-
-		var _, mhErrors = tt_mhead_to_text(t, false)
-		if mhErrors != 0 {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: TTMHEAD prefix not a valid DTMF sequence.\n", ps.line)
-			return true
-		}
-	}
-
-	var k = len(tl.mhead.prefix) + count_x
-
-	if k != 4 && k != 6 && k != 10 && k != 12 {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTMHEAD prefix and user data must have a total of 4, 6, 10, or 12 digits.\n", ps.line)
-		return true
+	ps.tt.corral_offset = parse_ll(t, LAT, ps.line)
+	if ps.tt.corral_offset == 1 ||
+		ps.tt.corral_offset == 2 ||
+		ps.tt.corral_offset == 3 {
+		ps.tt.corral_ambiguity = int(ps.tt.corral_offset)
+		ps.tt.corral_offset = 0
 	}
 
-	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
+	// dw_printf ("DEBUG: corral %f %f %f %d\n", p_tt_config.corral_lat,
+	//
+	//	p_tt_config.corral_lon, p_tt_config.corral_offset, p_tt_config.corral_ambiguity);
 	return false
 }
 
-// handleTTSATSQ handles the TTSATSQ keyword.
-func handleTTSATSQ(ps *parseState) bool {
+// handleTTPOINT handles the TTPOINT keyword.
+func handleTTPOINT(ps *parseState) bool {
 	/*
-	 * TTSATSQ 		- Define pattern to be used for Satellite square.
-	 *
-	 * TTSATSQ   pattern
-	 *
-	 *			Pattern would be  B[0-9A-D]xxxx
+	 * TTPOINT 		- Define a point represented by touch tone sequence.
 	 *
-	 *			Must have exactly 4 x.
+	 * TTPOINT   pattern  latitude  longitude
 	 */
 
-	// TODO1.2:  TTSATSQ To be continued...
-
 	var tl = new(ttloc_s)
-	tl.ttlocType = TTLOC_SATSQ
+	tl.ttlocType = TTLOC_POINT
 
-	// Pattern: B, optional additional button, exactly xxxx for matching
+	// Pattern: B and digits
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing pattern for TTSATSQ command.\n", ps.line)
+		dw_printf("Line %d: Missing pattern for TTPOINT command.\n", ps.line)
 		return true
 	}
 	tl.pattern = t
 
 	if t[0] != 'B' {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTSATSQ pattern must begin with upper case 'B'.\n", ps.line)
-		return true
+		dw_printf("Line %d: TTPOINT pattern must begin with upper case 'B'.\n", ps.line)
 	}
 
-	// Optionally one of 0-9ABCD
+	for _, j := range t[1:] {
+		if !unicode.IsDigit(j) {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: TTPOINT pattern must be B and digits only.\n", ps.line)
+		}
+	}
 
-	var j int
-	if len(t) > 1 && (strings.ContainsRune("ABCD", rune(t[1])) || unicode.IsDigit(rune(t[1]))) {
-		j = 2
-	} else {
-		j = 1
+	// Latitude
+
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing latitude for TTPOINT command.\n", ps.line)
+		return true
 	}
+	tl.point.lat = parse_ll(t, LAT, ps.line)
 
-	if t[j:] != "xxxx" {
+	// Longitude
+
+	t = ps.lex.next(false)
+	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTSATSQ pattern must end with exactly xxxx in lower case.\n", ps.line)
+		dw_printf("Line %d: Missing longitude for TTPOINT command.\n", ps.line)
 		return true
 	}
+	tl.point.lon = parse_ll(t, LON, ps.line)
 
 	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
 	return false
 }
 
-// handleTTAMBIG handles the TTAMBIG keyword.
-func handleTTAMBIG(ps *parseState) bool {
+// handleTTVECTOR handles the TTVECTOR keyword.
+func handleTTVECTOR(ps *parseState) bool {
 	/*
-	 * TTAMBIG 		- Define pattern to be used for Object Location Ambiguity.
-	 *
-	 * TTAMBIG   pattern
-	 *
-	 *			Pattern would be  B[0-9A-D]x
+	 * TTVECTOR 		- Touch tone location with bearing and distance.
 	 *
-	 *			Must have exactly one x.
+	 * TTVECTOR   pattern  latitude  longitude  scale  unit
 	 */
 
-	// TODO1.3:  TTAMBIG To be continued...
-
 	var tl = new(ttloc_s)
-	tl.ttlocType = TTLOC_AMBIG
+	tl.ttlocType = TTLOC_VECTOR
+	tl.pattern = ""
+	tl.vector.lat = 0
+	tl.vector.lon = 0
+	tl.vector.scale = 1
 
-	// Pattern: B, optional additional button, exactly x for matching
+	// Pattern: B5bbbd...
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing pattern for TTAMBIG command.\n", ps.line)
+		dw_printf("Line %d: Missing pattern for TTVECTOR command.\n", ps.line)
 		return true
 	}
 	tl.pattern = t
 
 	if t[0] != 'B' {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTAMBIG pattern must begin with upper case 'B'.\n", ps.line)
+		dw_printf("Line %d: TTVECTOR pattern must begin with upper case 'B'.\n", ps.line)
+	}
+	if !strings.HasPrefix(t[1:], "5bbb") {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: TTVECTOR pattern would normally contain \"5bbb\".\n", ps.line)
+	}
+	for j := 1; j < len(t); j++ {
+		if !unicode.IsDigit(rune(t[j])) && t[j] != 'b' && t[j] != 'd' {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: TTVECTOR pattern must contain only B, digits, b, and d.\n", ps.line)
+		}
+	}
+
+	// Latitude
+
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing latitude for TTVECTOR command.\n", ps.line)
 		return true
 	}
+	tl.vector.lat = parse_ll(t, LAT, ps.line)
 
-	// Optionally one of 0-9ABCD
+	// Longitude
 
-	var j int
-	if len(t) > 1 && (strings.ContainsRune("ABCD", rune(t[1])) || unicode.IsDigit(rune(t[1]))) {
-		j = 2
-	} else {
-		j = 1
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing longitude for TTVECTOR command.\n", ps.line)
+		return true
+	}
+	tl.vector.lon = parse_ll(t, LON, ps.line)
+
+	// Longitude
+
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing scale for TTVECTOR command.\n", ps.line)
+		return true
+	}
+	var scale, scaleErr = strconv.ParseFloat(t, 64)
+	if scaleErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid scale \"%s\" for TTVECTOR command.\n", ps.line, t)
+		return true
 	}
 
-	if t[j:] != "x" {
+	// Unit.
+
+	t = ps.lex.next(false)
+	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: TTAMBIG pattern must end with exactly one x in lower case.\n", ps.line)
+		dw_printf("Line %d: Missing unit for TTVECTOR command.\n", ps.line)
 		return true
 	}
 
+	var meters float64
+	for j := 0; j < len(units) && meters == 0; j++ {
+		if strings.EqualFold(units[j].name, t) {
+			meters = units[j].meters
+		}
+	}
+	if meters == 0 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Unrecognized unit for TTVECTOR command.  Using miles.\n", ps.line)
+		meters = 1609.344
+	}
+	tl.vector.scale = scale * meters
+
 	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
 	return false
 }
 
-// handleTTMACRO handles the TTMACRO keyword.
-func handleTTMACRO(ps *parseState) bool {
+// handleTTGRID handles the TTGRID keyword.
+func handleTTGRID(ps *parseState) bool {
 	/*
-	 * TTMACRO 		- Define compact message format with full expansion
-	 *
-	 * TTMACRO   pattern  definition
-	 *
-	 *		pattern can contain:
-	 *			0-9 which must match exactly.
-	 *				In version 1.2, also allow A,B,C,D for exact match.
-	 *			x, y, z which are used for matching of variable fields.
-	 *
-	 *		definition can contain:
-	 *			0-9, A, B, C, D, *, #, x, y, z.
-	 *			Not sure why # was included in there.
-	 *
-	 *	    new for version 1.3 - in progress
-	 *
-	 *			AA{objname}
-	 *			AB{symbol}
-	 *			AC{call}
-	 *
-	 *		These provide automatic conversion from plain text to the TT encoding.
+	 * TTGRID 		- Define a grid for touch tone locations.
 	 *
+	 * TTGRID   pattern  min-latitude  min-longitude  max-latitude  max-longitude
 	 */
 
 	var tl = new(ttloc_s)
-	tl.ttlocType = TTLOC_MACRO
+	tl.ttlocType = TTLOC_GRID
 
-	// Pattern: Any combination of digits, x, y, and z.
-	// Also make note of which letters are used in pattern and definition.
-	// Version 1.2: also allow A,B,C,D in the pattern.
+	// Pattern: B [digit] x... y...
 
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing pattern for TTMACRO command.\n", ps.line)
+		dw_printf("Line %d: Missing pattern for TTGRID command.\n", ps.line)
 		return true
 	}
 	tl.pattern = t
 
-	var p_count [3]int
-	var tt_error = 0
-
-	for j := 0; j < len(t); j++ {
-		if !strings.ContainsRune("0123456789ABCDxyz", rune(t[j])) {
+	if t[0] != 'B' {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: TTGRID pattern must begin with upper case 'B'.\n", ps.line)
+	}
+	for j := 1; j < len(t); j++ {
+		if !unicode.IsDigit(rune(t[j])) && t[j] != 'x' && t[j] != 'y' {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: TTMACRO pattern can contain only digits, A, B, C, D, and lower case x, y, or z.\n", ps.line)
-			tt_error++
-			break
-		}
-		// Count how many x, y, z in the pattern.
-		if t[j] >= 'x' && t[j] <= 'z' {
-			p_count[t[j]-'x']++
+			dw_printf("Line %d: TTGRID pattern must be B, optional digit, xxx, yyy.\n", ps.line)
 		}
 	}
 
-	//text_color_set(DW_COLOR_DEBUG);
-	//dw_printf ("Line %d: TTMACRO pattern \"%s\" p_count = %d %d %d.\n", line, t, p_count[0], p_count[1], p_count[2]);
-
-	// Next we should find the definition.
-	// It can contain touch tone characters and lower case x, y, z for substitutions.
+	// Minimum Latitude - all zeros in received data
 
-	t = split("", true)
+	t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing definition for TTMACRO command.\n", ps.line)
-		tl.macro.definition = "" // Don't die on null pointer later.
+		dw_printf("Line %d: Missing minimum latitude for TTGRID command.\n", ps.line)
 		return true
 	}
+	tl.grid.lat0 = parse_ll(t, LAT, ps.line)
 
-	// Make a pass over the definition, looking for the xx{...} substitutions.
-	// These are done just once when reading the configuration file.
-
-	var tmp = t               // Chomp through this
-	var otemp strings.Builder // Result after any substitution
-
-	tmp = strings.TrimSpace(tmp)
-
-	for len(tmp) != 0 {
+	// Minimum Longitude - all zeros in received data
 
-		if strings.HasPrefix(tmp, "AC{") {
-			// Convert to fixed length 10 digit callsign.
-			tmp = tmp[3:]
-			var stemp strings.Builder
-			for len(tmp) > 0 && tmp[0] != '}' && tmp[0] != '*' {
-				stemp.WriteString(string(tmp[0]))
-				tmp = tmp[1:]
-			}
-			if len(tmp) > 0 && tmp[0] == '}' {
-				var ttemp, errs = tt_text_to_call10(stemp.String(), false)
-				if errs == 0 {
-					//text_color_set(DW_COLOR_DEBUG);
-					//dw_printf ("DEBUG Line %d: AC{%s} -> AC%s\n", line, stemp, ttemp);
-					otemp.WriteString("AC" + ttemp)
-				} else {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Line %d: AC{%s} could not be converted to tones for callsign.\n", ps.line, stemp.String())
-					tt_error++
-				}
-				tmp = tmp[1:]
-			} else {
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Line %d: AC{... is missing matching } in TTMACRO definition.\n", ps.line)
-				tt_error++
-			}
-		} else if strings.HasPrefix(tmp, "AA{") {
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing minimum longitude for TTGRID command.\n", ps.line)
+		return true
+	}
+	tl.grid.lon0 = parse_ll(t, LON, ps.line)
 
-			// Convert to object name.
+	// Maximum Latitude - all nines in received data
 
-			tmp = tmp[3:]
-			var stemp string
-			for len(tmp) > 0 && tmp[0] != '}' && tmp[0] != '*' {
-				stemp += string(tmp[0])
-				tmp = tmp[1:]
-			}
-			if len(tmp) > 0 && tmp[0] == '}' {
-				if len(stemp) > 9 {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Line %d: Object name %s has been truncated to 9 characters.\n", ps.line, stemp)
-					stemp = stemp[:9]
-				}
-				var ttemp, errs = tt_text_to_two_key(stemp, false)
-				if errs == 0 {
-					//text_color_set(DW_COLOR_DEBUG);
-					//dw_printf ("DEBUG Line %d: AA{%s} -> AA%s\n", line, stemp, ttemp);
-					otemp.WriteString("AA" + ttemp)
-				} else {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Line %d: AA{%s} could not be converted to tones for object name.\n", ps.line, stemp)
-					tt_error++
-				}
-				tmp = tmp[1:]
-			} else {
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Line %d: AA{... is missing matching } in TTMACRO definition.\n", ps.line)
-				tt_error++
-			}
-		} else if strings.HasPrefix(tmp, "AB{") {
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing maximum latitude for TTGRID command.\n", ps.line)
+		return true
+	}
+	tl.grid.lat9 = parse_ll(t, LAT, ps.line)
 
-			// Attempt conversion from description to symbol code.
+	// Maximum Longitude - all nines in received data
 
-			tmp = tmp[3:]
-			var stemp strings.Builder
-			for len(tmp) > 0 && tmp[0] != '}' && tmp[0] != '*' {
-				stemp.WriteString(string(tmp[0]))
-				tmp = tmp[1:]
-			}
-			if len(tmp) > 0 && tmp[0] == '}' {
-				// First try to find something matching the description.
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing maximum longitude for TTGRID command.\n", ps.line)
+		return true
+	}
+	tl.grid.lon9 = parse_ll(t, LON, ps.line)
 
-				var symtab, symbol, ok = aprsSymbolData.symbols_code_from_description(' ', stemp.String())
+	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
+	return false
+}
 
-				if !ok {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Line %d: Couldn't convert \"%s\" to APRS symbol code.  Using default.\n", ps.line, stemp.String())
-					symtab = '\\' // Alternate
-					symbol = 'A'  // Box
-				}
+// handleTTUTM handles the TTUTM keyword.
+func handleTTUTM(ps *parseState) bool {
+	/*
+	 * TTUTM 		- Specify UTM zone for touch tone locations.
+	 *
+	 * TTUTM   pattern  zone [ scale [ x-offset y-offset ] ]
+	 */
 
-				// Convert symtab(overlay) & symbol to tone sequence.
+	var tl = new(ttloc_s)
+	tl.ttlocType = TTLOC_UTM
+	tl.utm.scale = 1
 
-				var ttemp = aprsSymbolData.symbols_to_tones(symtab, symbol)
+	// Pattern: B [digit] x... y...
 
-				//text_color_set(DW_COLOR_DEBUG);
-				//dw_printf ("DEBUG config file Line %d: AB{%s} -> %s\n", line, stemp, ttemp);
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing pattern for TTUTM command.\n", ps.line)
+		return true
+	}
+	tl.pattern = t
 
-				otemp.WriteString(ttemp)
-				tmp = tmp[1:]
-			} else {
+	if t[0] != 'B' {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: TTUTM pattern must begin with upper case 'B'.\n", ps.line)
+		return true
+	}
+	for j := 1; j < len(t); j++ {
+		if !unicode.IsDigit(rune(t[j])) && t[j] != 'x' && t[j] != 'y' {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: TTUTM pattern must be B, optional digit, xxx, yyy.\n", ps.line)
+			return true
+		}
+	}
+
+	// Zone 1 - 60 and optional latitudinal letter.
+
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing zone for TTUTM command.\n", ps.line)
+		return true
+	}
+
+	tl.utm.latband, tl.utm.hemi, tl.utm.lzone = parse_utm_zone(t)
+
+	// Optional scale.
+
+	t = ps.lex.next(false)
+	if t != "" {
+		var scaleVal, scaleErr = strconv.ParseFloat(t, 64)
+		if scaleErr != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Invalid scale \"%s\" for TTUTM command.\n", ps.line, t)
+			return true
+		}
+
+		tl.utm.scale = scaleVal
+
+		// Optional x offset.
+
+		t = ps.lex.next(false)
+		if t != "" {
+			var xOffset, xErr = strconv.ParseFloat(t, 64)
+			if xErr != nil {
 				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Line %d: AB{... is missing matching } in TTMACRO definition.\n", ps.line)
-				tt_error++
+				dw_printf("Line %d: Invalid x offset \"%s\" for TTUTM command.\n", ps.line, t)
+				return true
 			}
-		} else if strings.HasPrefix(tmp, "CA{") {
 
-			// Convert to enhanced comment that can contain any ASCII character.
+			tl.utm.x_offset = xOffset
 
-			tmp = tmp[3:]
-			var stemp strings.Builder
-			for len(tmp) > 0 && tmp[0] != '}' && tmp[0] != '*' {
-				stemp.WriteString(string(tmp[0]))
-				tmp = tmp[1:]
-			}
-			if len(tmp) > 0 && tmp[0] == '}' {
-				var ttemp, errs = tt_text_to_ascii2d(stemp.String(), false)
-				if errs == 0 {
-					//text_color_set(DW_COLOR_DEBUG);
-					//dw_printf ("DEBUG Line %d: CA{%s} -> CA%s\n", line, stemp, ttemp);
-					otemp.WriteString("CA" + ttemp)
-				} else {
+			// Optional y offset.
+
+			t = ps.lex.next(false)
+			if t != "" {
+				var yOffset, yErr = strconv.ParseFloat(t, 64)
+				if yErr != nil {
 					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Line %d: CA{%s} could not be converted to tones for enhanced comment.\n", ps.line, stemp.String())
-					tt_error++
+					dw_printf("Line %d: Invalid y offset \"%s\" for TTUTM command.\n", ps.line, t)
+					return true
 				}
-				tmp = tmp[1:]
-			} else {
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Line %d: CA{... is missing matching } in TTMACRO definition.\n", ps.line)
-				tt_error++
+
+				tl.utm.y_offset = yOffset
 			}
-		} else if strings.ContainsRune("0123456789ABCD*#xyz", rune(tmp[0])) {
-			otemp.WriteString(string(tmp[0]))
-			tmp = tmp[1:]
-		} else {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: TTMACRO definition can contain only 0-9, A, B, C, D, *, #, x, y, z.\n", ps.line)
-			tt_error++
-			tmp = tmp[1:]
 		}
 	}
 
-	// Make sure that number of x, y, z, in pattern and definition match.
+	// Practice run to see if conversion might fail later with actual location.
 
-	var d_count [3]int
+	var _, _, geoErr = geo.FromUTM(tl.utm.lzone, geo.HemisphereFromRune(tl.utm.hemi), tl.utm.x_offset+5*tl.utm.scale, tl.utm.y_offset+5*tl.utm.scale)
 
-	var otempStr = otemp.String()
-	for j := 0; j < len(otempStr); j++ {
-		if otempStr[j] >= 'x' && otempStr[j] <= 'z' {
-			d_count[otempStr[j]-'x']++
-		}
+	if geoErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid UTM location: \n%s\n", ps.line, geoErr)
+		return true
 	}
 
-	// A little validity checking.
+	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
+	return false
+}
 
-	for j := range 3 {
-		if p_count[j] > 0 && d_count[j] == 0 {
+// handleTTUSNGMGRS handles the TTUSNGMGRS keyword.
+func handleTTUSNGMGRS(ps *parseState) bool {
+	/*
+	 * TTUSNG, TTMGRS 		- Specify zone/square for touch tone locations.
+	 *
+	 * TTUSNG   pattern  zone_square
+	 * TTMGRS   pattern  zone_square
+	 */
+
+	var tl = new(ttloc_s)
+
+	if strings.EqualFold(ps.keyword, "TTMGRS") {
+		tl.ttlocType = TTLOC_MGRS
+	} else {
+		tl.ttlocType = TTLOC_USNG
+	}
+
+	// Pattern: B [digit] x... y...
+
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing pattern for TTUSNG/TTMGRS command.\n", ps.line)
+		return true
+	}
+	tl.pattern = t
+
+	if t[0] != 'B' {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: TTUSNG/TTMGRS pattern must begin with upper case 'B'.\n", ps.line)
+		return true
+	}
+	var num_x = 0
+	var num_y = 0
+	for j := 1; j < len(t); j++ {
+		if !unicode.IsDigit(rune(t[j])) && t[j] != 'x' && t[j] != 'y' {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: '%c' is in TTMACRO pattern but is not used in definition.\n", ps.line, 'x'+j)
+			dw_printf("Line %d: TTUSNG/TTMGRS pattern must be B, optional digit, xxx, yyy.\n", ps.line)
+			return true
 		}
-		if d_count[j] > 0 && p_count[j] == 0 {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: '%c' is referenced in TTMACRO definition but does not appear in the pattern.\n", ps.line, 'x'+j)
+		if t[j] == 'x' {
+			num_x++
+		}
+		if t[j] == 'y' {
+			num_y++
 		}
 	}
+	if num_x < 1 || num_x > 5 || num_x != num_y {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: TTUSNG/TTMGRS must have 1 to 5 x and same number y.\n", ps.line)
+		return true
+	}
 
-	//text_color_set(DW_COLOR_DEBUG);
-	//dw_printf ("DEBUG Config Line %d: %s -> %s\n", line, t, otemp);
+	// Zone 1 - 60 and optional latitudinal letter.
 
-	if tt_error == 0 {
-		tl.macro.definition = otemp.String()
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing zone & square for TTUSNG/TTMGRS command.\n", ps.line)
+		return true
 	}
+	tl.mgrs.zone = t
 
-	if tt_error == 0 {
-		ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
-	} else {
-		dw_printf("Line %d: Errors found in TTMACRO, skipping.\n", ps.line)
+	// Try converting it rather do our own error checking.
+	// USNG may be lower case.
+
+	var _, _, convertErr = tt_mgrs_convert(tl.ttlocType)(tl.mgrs.zone)
+	if convertErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid USNG/MGRS zone & square:  %s\n%s\n", ps.line, tl.mgrs.zone, convertErr)
+		return true
+	}
+
+	// Should be the end.
+
+	t = ps.lex.next(false)
+	if t != "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Unexpected stuff at end ignored:  %s\n", ps.line, t)
 	}
+
+	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
 	return false
 }
 
-// handleTTOBJ handles the TTOBJ keyword.
-func handleTTOBJ(ps *parseState) bool {
+// handleTTMHEAD handles the TTMHEAD keyword.
+func handleTTMHEAD(ps *parseState) bool {
 	/*
-	 * TTOBJ 		- TT Object Report options.
+	 * TTMHEAD 		- Define pattern to be used for Maidenhead Locator.
 	 *
-	 * TTOBJ  recv-chan  where-to  [ via-path ]
+	 * TTMHEAD   pattern   [ prefix ]
 	 *
-	 *	whereto is any combination of transmit channel, APP, IG.
+	 *			Pattern would be  B[0-9A-D]xxxx...
+	 *			Optional prefix is 10, 6, or 4 digits.
+	 *
+	 *			The total number of digits in both must be 4, 6, 10, or 12.
 	 */
 
-	var t = split("", false)
+	var tl = new(ttloc_s)
+	tl.ttlocType = TTLOC_MHEAD
+
+	// Pattern: B, optional additional button, some number of xxxx... for matching
+
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing DTMF receive channel for TTOBJ command.\n", ps.line)
+		dw_printf("Line %d: Missing pattern for TTMHEAD command.\n", ps.line)
 		return true
 	}
+	tl.pattern = t
 
-	var r, rErr = strconv.Atoi(t)
-	if r < 0 || r > MAX_RADIO_CHANS-1 || rErr != nil {
+	if t[0] != 'B' {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: DTMF receive channel must be in range of 0 to %d on line %d.\n",
-			MAX_RADIO_CHANS-1, ps.line)
+		dw_printf("Line %d: TTMHEAD pattern must begin with upper case 'B'.\n", ps.line)
 		return true
 	}
 
-	// I suppose we need internal modem channel here.
-	// otherwise a DTMF decoder would not be available.
+	// Optionally one of 0-9ABCD
 
-	if ps.audio.chan_medium[r] != MEDIUM_RADIO {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: TTOBJ DTMF receive channel %d is not valid.\n",
-			ps.line, r)
-		return true
+	var j int
+	if len(t) > 1 && (strings.ContainsRune("ABCD", rune(t[1])) || unicode.IsDigit(rune(t[1]))) {
+		j = 2
+	} else {
+		j = 1
 	}
 
-	t = split("", false)
-	if t == "" {
+	var count_x = 0
+	var count_other = 0
+	for k := j; k < len(t); k++ {
+		if t[k] == 'x' {
+			count_x++
+		} else {
+			count_other++
+		}
+	}
+
+	if count_other != 0 {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing transmit channel for TTOBJ command.\n", ps.line)
+		dw_printf("Line %d: TTMHEAD must have only lower case x to match received data.\n", ps.line)
 		return true
 	}
 
-	// Can have any combination of channel number, APP, IG, separated by commas.
-	// Parse as a comma-separated list so multi-digit channels (>= 10) work correctly.
+	// optional prefix
 
-	var x = -1
-	var app = 0
-	var ig = 0
-	var whereToValid = true
+	t = ps.lex.next(false)
+	if t != "" {
+		tl.mhead.prefix = t
 
-	for part := range strings.SplitSeq(t, ",") {
-		part = strings.TrimSpace(part)
-		if strings.EqualFold(part, "APP") || strings.EqualFold(part, "A") {
-			app = 1
-		} else if strings.EqualFold(part, "IG") || strings.EqualFold(part, "I") {
-			ig = 1
-		} else {
-			var chanNum, chanErr = strconv.Atoi(part)
-			if chanErr == nil {
-				x = chanNum
-				if x < 0 || x > MAX_TOTAL_CHANS-1 {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file: Transmit channel must be in range of 0 to %d on line %d.\n", MAX_TOTAL_CHANS-1, ps.line)
-					x = -1
-					whereToValid = false
-				} else if ps.audio.chan_medium[x] != MEDIUM_RADIO &&
-					ps.audio.chan_medium[x] != MEDIUM_NETTNC {
+		if !alldigits(t) || (len(t) != 4 && len(t) != 6 && len(t) != 10) {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: TTMHEAD prefix must be 4, 6, or 10 digits.\n", ps.line)
+			return true
+		}
+
+		var _, mhErrors = tt_mhead_to_text(t, false)
+		if mhErrors != 0 {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: TTMHEAD prefix not a valid DTMF sequence.\n", ps.line)
+			return true
+		}
+	}
+
+	var k = len(tl.mhead.prefix) + count_x
+
+	if k != 4 && k != 6 && k != 10 && k != 12 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: TTMHEAD prefix and user data must have a total of 4, 6, 10, or 12 digits.\n", ps.line)
+		return true
+	}
+
+	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
+	return false
+}
+
+// handleTTSATSQ handles the TTSATSQ keyword.
+func handleTTSATSQ(ps *parseState) bool {
+	/*
+	 * TTSATSQ 		- Define pattern to be used for Satellite square.
+	 *
+	 * TTSATSQ   pattern
+	 *
+	 *			Pattern would be  B[0-9A-D]xxxx
+	 *
+	 *			Must have exactly 4 x.
+	 */
+
+	var tl = new(ttloc_s)
+	tl.ttlocType = TTLOC_SATSQ
+
+	// Pattern: B, optional additional button, exactly xxxx for matching
+
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing pattern for TTSATSQ command.\n", ps.line)
+		return true
+	}
+	tl.pattern = t
+
+	if t[0] != 'B' {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: TTSATSQ pattern must begin with upper case 'B'.\n", ps.line)
+		return true
+	}
+
+	// Optionally one of 0-9ABCD
+
+	var j int
+	if len(t) > 1 && (strings.ContainsRune("ABCD", rune(t[1])) || unicode.IsDigit(rune(t[1]))) {
+		j = 2
+	} else {
+		j = 1
+	}
+
+	if t[j:] != "xxxx" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: TTSATSQ pattern must end with exactly xxxx in lower case.\n", ps.line)
+		return true
+	}
+
+	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
+	return false
+}
+
+// handleTTAMBIG handles the TTAMBIG keyword.
+func handleTTAMBIG(ps *parseState) bool {
+	/*
+	 * TTAMBIG 		- Define pattern to be used for Object Location Ambiguity.
+	 *
+	 * TTAMBIG   pattern
+	 *
+	 *			Pattern would be  B[0-9A-D]x
+	 *
+	 *			Must have exactly one x.
+	 */
+
+	var tl = new(ttloc_s)
+	tl.ttlocType = TTLOC_AMBIG
+
+	// Pattern: B, optional additional button, exactly x for matching
+
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing pattern for TTAMBIG command.\n", ps.line)
+		return true
+	}
+	tl.pattern = t
+
+	if t[0] != 'B' {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: TTAMBIG pattern must begin with upper case 'B'.\n", ps.line)
+		return true
+	}
+
+	// Optionally one of 0-9ABCD
+
+	var j int
+	if len(t) > 1 && (strings.ContainsRune("ABCD", rune(t[1])) || unicode.IsDigit(rune(t[1]))) {
+		j = 2
+	} else {
+		j = 1
+	}
+
+	if t[j:] != "x" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: TTAMBIG pattern must end with exactly one x in lower case.\n", ps.line)
+		return true
+	}
+
+	ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
+	return false
+}
+
+// handleTTMACRO handles the TTMACRO keyword.
+func handleTTMACRO(ps *parseState) bool {
+	/*
+	 * TTMACRO 		- Define compact message format with full expansion
+	 *
+	 * TTMACRO   pattern  definition
+	 *
+	 *		pattern can contain:
+	 *			0-9 which must match exactly.
+	 *				In version 1.2, also allow A,B,C,D for exact match.
+	 *			x, y, z which are used for matching of variable fields.
+	 *
+	 *		definition can contain:
+	 *			0-9, A, B, C, D, *, #, x, y, z.
+	 *			Not sure why # was included in there.
+	 *
+	 *	    new for version 1.3
+	 *
+	 *			AA{objname}
+	 *			AB{symbol}
+	 *			AC{call}
+	 *			CA{comment}
+	 *
+	 *		These provide automatic conversion from plain text to the TT encoding.
+	 *
+	 */
+
+	var tl = new(ttloc_s)
+	tl.ttlocType = TTLOC_MACRO
+
+	// Pattern: Any combination of digits, x, y, and z.
+	// Also make note of which letters are used in pattern and definition.
+	// Version 1.2: also allow A,B,C,D in the pattern.
+
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing pattern for TTMACRO command.\n", ps.line)
+		return true
+	}
+	tl.pattern = t
+
+	var p_count [3]int
+	var tt_error = 0
+
+	for j := 0; j < len(t); j++ {
+		if !strings.ContainsRune("0123456789ABCDxyz", rune(t[j])) {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: TTMACRO pattern can contain only digits, A, B, C, D, and lower case x, y, or z.\n", ps.line)
+			tt_error++
+			break
+		}
+		// Count how many x, y, z in the pattern.
+		if t[j] >= 'x' && t[j] <= 'z' {
+			p_count[t[j]-'x']++
+		}
+	}
+
+	//text_color_set(DW_COLOR_DEBUG);
+	//dw_printf ("Line %d: TTMACRO pattern \"%s\" p_count = %d %d %d.\n", line, t, p_count[0], p_count[1], p_count[2]);
+
+	// Next we should find the definition.
+	// It can contain touch tone characters and lower case x, y, z for substitutions.
+
+	t = ps.lex.next(true)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing definition for TTMACRO command.\n", ps.line)
+		tl.macro.definition = "" // Don't die on null pointer later.
+		return true
+	}
+
+	// Make a pass over the definition, looking for the xx{...} substitutions.
+	// These are done just once when reading the configuration file.
+
+	var tmp = t               // Chomp through this
+	var otemp strings.Builder // Result after any substitution
+
+	tmp = strings.TrimSpace(tmp)
+
+	for len(tmp) != 0 {
+
+		if strings.HasPrefix(tmp, "AC{") {
+			// Convert to fixed length 10 digit callsign.
+			tmp = tmp[3:]
+			var stemp strings.Builder
+			for len(tmp) > 0 && tmp[0] != '}' && tmp[0] != '*' {
+				stemp.WriteString(string(tmp[0]))
+				tmp = tmp[1:]
+			}
+			if len(tmp) > 0 && tmp[0] == '}' {
+				var ttemp, errs = tt_text_to_call10(stemp.String(), false)
+				if errs == 0 {
+					//text_color_set(DW_COLOR_DEBUG);
+					//dw_printf ("DEBUG Line %d: AC{%s} -> AC%s\n", line, stemp, ttemp);
+					otemp.WriteString("AC" + ttemp)
+				} else {
 					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file, line %d: TTOBJ transmit channel %d is not valid.\n", ps.line, x)
-					x = -1
-					whereToValid = false
+					dw_printf("Line %d: AC{%s} could not be converted to tones for callsign.\n", ps.line, stemp.String())
+					tt_error++
 				}
+				tmp = tmp[1:]
 			} else {
-				/* Check for legacy compact form like "APPIG" or "AIG" accepted by the old parser. */
-				var isLegacy = true
-				for _, c := range part {
-					if !unicode.IsDigit(c) && !strings.ContainsRune("aApPiIgG", c) {
-						isLegacy = false
-						break
-					}
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: AC{... is missing matching } in TTMACRO definition.\n", ps.line)
+				tt_error++
+			}
+		} else if strings.HasPrefix(tmp, "AA{") {
+
+			// Convert to object name.
+
+			tmp = tmp[3:]
+			var stemp string
+			for len(tmp) > 0 && tmp[0] != '}' && tmp[0] != '*' {
+				stemp += string(tmp[0])
+				tmp = tmp[1:]
+			}
+			if len(tmp) > 0 && tmp[0] == '}' {
+				if len(stemp) > 9 {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Line %d: Object name %s has been truncated to 9 characters.\n", ps.line, stemp)
+					stemp = stemp[:9]
 				}
-				if isLegacy {
+				var ttemp, errs = tt_text_to_two_key(stemp, false)
+				if errs == 0 {
+					//text_color_set(DW_COLOR_DEBUG);
+					//dw_printf ("DEBUG Line %d: AA{%s} -> AA%s\n", line, stemp, ttemp);
+					otemp.WriteString("AA" + ttemp)
+				} else {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Line %d: AA{%s} could not be converted to tones for object name.\n", ps.line, stemp)
+					tt_error++
+				}
+				tmp = tmp[1:]
+			} else {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: AA{... is missing matching } in TTMACRO definition.\n", ps.line)
+				tt_error++
+			}
+		} else if strings.HasPrefix(tmp, "AB{") {
+
+			// Attempt conversion from description to symbol code.
+
+			tmp = tmp[3:]
+			var stemp strings.Builder
+			for len(tmp) > 0 && tmp[0] != '}' && tmp[0] != '*' {
+				stemp.WriteString(string(tmp[0]))
+				tmp = tmp[1:]
+			}
+			if len(tmp) > 0 && tmp[0] == '}' {
+				// First try to find something matching the description.
+
+				var symtab, symbol, ok = aprsSymbolData.symbols_code_from_description(' ', stemp.String())
+
+				if !ok {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Line %d: Couldn't convert \"%s\" to APRS symbol code.  Using default.\n", ps.line, stemp.String())
+					symtab = '\\' // Alternate
+					symbol = 'A'  // Box
+				}
+
+				// Convert symtab(overlay) & symbol to tone sequence.
+
+				var ttemp = aprsSymbolData.symbols_to_tones(symtab, symbol)
+
+				//text_color_set(DW_COLOR_DEBUG);
+				//dw_printf ("DEBUG config file Line %d: AB{%s} -> %s\n", line, stemp, ttemp);
+
+				otemp.WriteString(ttemp)
+				tmp = tmp[1:]
+			} else {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: AB{... is missing matching } in TTMACRO definition.\n", ps.line)
+				tt_error++
+			}
+		} else if strings.HasPrefix(tmp, "CA{") {
+
+			// Convert to enhanced comment that can contain any ASCII character.
+
+			tmp = tmp[3:]
+			var stemp strings.Builder
+			for len(tmp) > 0 && tmp[0] != '}' && tmp[0] != '*' {
+				stemp.WriteString(string(tmp[0]))
+				tmp = tmp[1:]
+			}
+			if len(tmp) > 0 && tmp[0] == '}' {
+				var ttemp, errs = tt_text_to_ascii2d(stemp.String(), false)
+				if errs == 0 {
+					//text_color_set(DW_COLOR_DEBUG);
+					//dw_printf ("DEBUG Line %d: CA{%s} -> CA%s\n", line, stemp, ttemp);
+					otemp.WriteString("CA" + ttemp)
+				} else {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Line %d: CA{%s} could not be converted to tones for enhanced comment.\n", ps.line, stemp.String())
+					tt_error++
+				}
+				tmp = tmp[1:]
+			} else {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: CA{... is missing matching } in TTMACRO definition.\n", ps.line)
+				tt_error++
+			}
+		} else if strings.ContainsRune("0123456789ABCD*#xyz", rune(tmp[0])) {
+			otemp.WriteString(string(tmp[0]))
+			tmp = tmp[1:]
+		} else {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: TTMACRO definition can contain only 0-9, A, B, C, D, *, #, x, y, z.\n", ps.line)
+			tt_error++
+			tmp = tmp[1:]
+		}
+	}
+
+	// Make sure that number of x, y, z, in pattern and definition match.
+
+	var d_count [3]int
+
+	var otempStr = otemp.String()
+	for j := 0; j < len(otempStr); j++ {
+		if otempStr[j] >= 'x' && otempStr[j] <= 'z' {
+			d_count[otempStr[j]-'x']++
+		}
+	}
+
+	// A little validity checking.
+
+	for j := range 3 {
+		if p_count[j] > 0 && d_count[j] == 0 {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: '%c' is in TTMACRO pattern but is not used in definition.\n", ps.line, 'x'+j)
+		}
+		if d_count[j] > 0 && p_count[j] == 0 {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: '%c' is referenced in TTMACRO definition but does not appear in the pattern.\n", ps.line, 'x'+j)
+		}
+	}
+
+	//text_color_set(DW_COLOR_DEBUG);
+	//dw_printf ("DEBUG Config Line %d: %s -> %s\n", line, t, otemp);
+
+	if tt_error == 0 {
+		tl.macro.definition = otemp.String()
+	}
+
+	if tt_error == 0 {
+		ps.tt.ttlocs = append(ps.tt.ttlocs, tl)
+	} else {
+		dw_printf("Line %d: Errors found in TTMACRO, skipping.\n", ps.line)
+	}
+	return false
+}
+
+// handleTTOBJ handles the TTOBJ keyword.
+func handleTTOBJ(ps *parseState) bool {
+	/*
+	 * TTOBJ 		- TT Object Report options.
+	 *
+	 * TTOBJ  recv-chan  where-to  [ via-path ]
+	 *
+	 *	whereto is any combination of transmit channel, APP, IG.
+	 */
+
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing DTMF receive channel for TTOBJ command.\n", ps.line)
+		return true
+	}
+
+	var r, rErr = strconv.Atoi(t)
+	if r < 0 || r > MAX_RADIO_CHANS-1 || rErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: DTMF receive channel must be in range of 0 to %d on line %d.\n",
+			MAX_RADIO_CHANS-1, ps.line)
+		return true
+	}
+
+	// I suppose we need internal modem channel here.
+	// otherwise a DTMF decoder would not be available.
+
+	if ps.audio.chan_medium[r] != MEDIUM_RADIO {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file, line %d: TTOBJ DTMF receive channel %d is not valid.\n",
+			ps.line, r)
+		return true
+	}
+
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing transmit channel for TTOBJ command.\n", ps.line)
+		return true
+	}
+
+	// Can have any combination of channel number, APP, IG, separated by commas.
+	// Parse as a comma-separated list so multi-digit channels (>= 10) work correctly.
+
+	var x = -1
+	var app = 0
+	var ig = 0
+	var whereToValid = true
+
+	for part := range strings.SplitSeq(t, ",") {
+		part = strings.TrimSpace(part)
+		if strings.EqualFold(part, "APP") || strings.EqualFold(part, "A") {
+			app = 1
+		} else if strings.EqualFold(part, "IG") || strings.EqualFold(part, "I") {
+			ig = 1
+		} else {
+			var chanNum, chanErr = strconv.Atoi(part)
+			if chanErr == nil {
+				x = chanNum
+				if x < 0 || x > MAX_TOTAL_CHANS-1 {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Config file: Transmit channel must be in range of 0 to %d on line %d.\n", MAX_TOTAL_CHANS-1, ps.line)
+					x = -1
+					whereToValid = false
+				} else if ps.audio.chan_medium[x] != MEDIUM_RADIO &&
+					ps.audio.chan_medium[x] != MEDIUM_NETTNC {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Config file, line %d: TTOBJ transmit channel %d is not valid.\n", ps.line, x)
+					x = -1
+					whereToValid = false
+				}
+			} else {
+				/* Check for legacy compact form like "APPIG" or "AIG" accepted by the old parser. */
+				var isLegacy = true
+				for _, c := range part {
+					if !unicode.IsDigit(c) && !strings.ContainsRune("aApPiIgG", c) {
+						isLegacy = false
+						break
+					}
+				}
+				if isLegacy {
 					for _, c := range part {
 						switch {
 						case c == 'a' || c == 'A':
@@ -4666,922 +5796,1684 @@ func handleTTOBJ(ps *parseState) bool {
 		}
 	}
 
-	if !whereToValid {
+	if !whereToValid {
+		return true
+	}
+
+	// This enables the DTMF decoder on the specified channel.
+	// Additional channels can be enabled with the DTMF command.
+	// Note that DTMF command does not enable the APRStt gateway.
+
+	//text_color_set(DW_COLOR_DEBUG);
+	//dw_printf ("Debug TTOBJ r=%d, x=%d, app=%d, ig=%d\n", r, x, app, ig);
+
+	if ps.audio.achan[r].dtmf_decode == DTMF_DECODE_OFF {
+		ps.audio.achan[r].dtmf_decode = DTMF_DECODE_ON
+	}
+
+	ps.tt.gateway_enabled = 1
+	ps.tt.obj_recv_chan = r
+	ps.tt.obj_xmit_chan = x
+	ps.tt.obj_send_to_app = app
+	ps.tt.obj_send_to_ig = ig
+
+	t = ps.lex.next(false)
+	if t != "" {
+
+		if check_via_path(t) >= 0 {
+			ps.tt.obj_xmit_via = t
+		} else {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file, line %d: invalid via path.\n", ps.line)
+		}
+	}
+	return false
+}
+
+// handleTTERR handles the TTERR keyword.
+func handleTTERR(ps *parseState) bool {
+	/*
+	 * TTERR 		- TT responses for success or errors.
+	 *
+	 * TTERR  msg_id  method  text...
+	 */
+
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing message identifier for TTERR command.\n", ps.line)
+		return true
+	}
+
+	var msg_num = -1
+	for n := range TT_ERROR_MAXP1 {
+		if strings.EqualFold(t, ttErrorString(n)) {
+			msg_num = n
+			break
+		}
+	}
+	if msg_num < 0 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid message identifier for TTERR command.\n", ps.line)
+		// pick one of ...
+		return true
+	}
+
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing method (SPEECH, MORSE) for TTERR command.\n", ps.line)
+		return true
+	}
+
+	t = strings.ToUpper(t)
+
+	var method, ssid, _, ok = ax25_parse_addr(-1, t, 1)
+	if !ok {
+		return true // function above prints any error message
+	}
+
+	if method != "MORSE" && method != "SPEECH" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Response method of %s must be SPEECH or MORSE for TTERR command.\n", ps.line, method)
+		return true
+	}
+
+	t = ps.lex.next(true)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing response text for TTERR command.\n", ps.line)
+		return true
+	}
+
+	//text_color_set(DW_COLOR_DEBUG);
+	//dw_printf ("Line %d: TTERR debug %d %s-%d \"%s\"\n", line, msg_num, method, ssid, t);
+
+	Assert(msg_num >= 0 && msg_num < TT_ERROR_MAXP1)
+
+	// Keep any SSID.  For MORSE it is half the speed in words per minute.
+
+	ps.tt.response[msg_num].method = IfThenElse(ssid > 0, fmt.Sprintf("%s-%d", method, ssid), method)
+	ps.tt.response[msg_num].mtext = t
+	return false
+}
+
+// handleTTSTATUS handles the TTSTATUS keyword.
+func handleTTSTATUS(ps *parseState) bool {
+	/*
+	 * TTSTATUS 		- TT custom status messages.
+	 *
+	 * TTSTATUS  status_id  text...
+	 */
+
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing status number for TTSTATUS command.\n", ps.line)
+		return true
+	}
+
+	var status_num, _ = strconv.Atoi(t)
+
+	if status_num < 1 || status_num > 9 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Status number for TTSTATUS command must be in range of 1 to 9.\n", ps.line)
+		return true
+	}
+
+	t = ps.lex.next(true)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing status text for TTSTATUS command.\n", ps.line)
+		return true
+	}
+
+	//text_color_set(DW_COLOR_DEBUG);
+	//dw_printf ("Line %d: TTSTATUS debug %d \"%s\"\n", line, status_num, t);
+
+	t = strings.TrimSpace(t)
+
+	ps.tt.status[status_num] = t
+	return false
+}
+
+// handleTTCMD handles the TTCMD keyword.
+func handleTTCMD(ps *parseState) bool {
+	/*
+	 * TTCMD 		- Command to run when valid sequence is received.
+	 *			  Any text generated will be sent back to user.
+	 *
+	 * TTCMD ...
+	 */
+	var t = ps.lex.next(true)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing command for TTCMD command.\n", ps.line)
+		return true
+	}
+
+	ps.tt.ttcmd = t
+	return false
+}
+
+// handleDTMFPIN handles the DTMFPIN keyword.
+func handleDTMFPIN(ps *parseState) bool {
+	/*
+	 * DTMFPIN  pin		- PIN for DTMF remote control.
+	 *			  4 to 16 buttons, 0-9 and A-D.
+	 */
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing PIN for DTMFPIN command.\n", ps.line)
+		return true
+	}
+
+	t = strings.ToUpper(t)
+
+	if !dtmf_remote_buttons_ok(t) || len(t) < 4 || len(t) > 16 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: DTMFPIN must be 4 to 16 of 0-9 and A-D.\n", ps.line)
+		return true
+	}
+
+	ps.tt.remote_pin = t
+	return false
+}
+
+// handleDTMFCMD handles the DTMFCMD keyword.
+func handleDTMFCMD(ps *parseState) bool {
+	/*
+	 * DTMFCMD  code  BEACON		- Send beacons now.
+	 * DTMFCMD  code  IGATE  ON|OFF	- Turn the IGate on or off.
+	 * DTMFCMD  code  RUN  command...	- Run a shell command.
+	 *
+	 * Sent as D pin * code #.  See dtmf_remote.go.
+	 */
+	if ps.tt.remote_pin == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: DTMFPIN must come before DTMFCMD.\n", ps.line)
+		return true
+	}
+
+	var cmd = new(dtmf_remote_cmd_s)
+
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing code for DTMFCMD command.\n", ps.line)
+		return true
+	}
+
+	cmd.code = strings.ToUpper(t)
+
+	if !dtmf_remote_buttons_ok(cmd.code) || len(cmd.code) > 8 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: DTMFCMD code must be 1 to 8 of 0-9 and A-D.\n", ps.line)
+		return true
+	}
+
+	for _, other := range ps.tt.remote_cmds {
+		if other.code == cmd.code {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: DTMFCMD code %s is already used.\n", ps.line, cmd.code)
+			return true
+		}
+	}
+
+	t = ps.lex.next(false)
+
+	if strings.EqualFold(t, "BEACON") {
+		cmd.action = DTMF_REMOTE_BEACON
+	} else if strings.EqualFold(t, "IGATE") {
+		t = ps.lex.next(false)
+		if strings.EqualFold(t, "ON") {
+			cmd.action = DTMF_REMOTE_IGATE_ON
+		} else if strings.EqualFold(t, "OFF") {
+			cmd.action = DTMF_REMOTE_IGATE_OFF
+		} else {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Expected ON or OFF after DTMFCMD IGATE.\n", ps.line)
+			return true
+		}
+	} else if strings.EqualFold(t, "RUN") {
+		cmd.action = DTMF_REMOTE_RUN
+		cmd.command = strings.TrimSpace(ps.lex.next(true))
+
+		if cmd.command == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Missing command after DTMFCMD RUN.\n", ps.line)
+			return true
+		}
+	} else {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: DTMFCMD action must be BEACON, IGATE, or RUN.\n", ps.line)
+		return true
+	}
+
+	ps.tt.remote_cmds = append(ps.tt.remote_cmds, cmd)
+	return false
+}
+
+// handleIGSERVER handles the IGSERVER keyword.
+func handleIGSERVER(ps *parseState) bool {
+	/*
+	 * ==================== Internet gateway ====================
+	 */
+
+	/*
+	 * IGSERVER 		- Name of IGate server.
+	 *
+	 * IGSERVER  hostname [ port ] 				-- original implementation.
+	 *
+	 * IGSERVER  hostname:port				-- more in line with usual conventions.
+	 */
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing IGate server name for IGSERVER command.\n", ps.line)
+
+		return true
+	}
+
+	ps.igate.t2_server_name = t
+
+	/* If there is a : in the name, split it out as the port number.
+	 * Use net.SplitHostPort to correctly handle bracketed IPv6 addresses like [::1]:8080.
+	 */
+
+	if strings.Contains(t, ":") {
+		var hostname, portStr, splitErr = net.SplitHostPort(t)
+		if splitErr == nil {
+			ps.igate.t2_server_name = hostname
+
+			var port, portErr = strconv.Atoi(portStr)
+			if port >= MIN_IP_PORT_NUMBER && port <= MAX_IP_PORT_NUMBER && portErr == nil {
+				ps.igate.t2_server_port = port
+			} else {
+				ps.igate.t2_server_port = DEFAULT_IGATE_PORT
+
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: Invalid port number for IGate server. Using default %d.\n",
+					ps.line, ps.igate.t2_server_port)
+			}
+		} else {
+			/* net.SplitHostPort failed (e.g. malformed input like "host:"); fall back to simple cut. */
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Could not parse IGate server address '%s': %v\n",
+				ps.line, t, splitErr)
+			ps.igate.t2_server_name, _, _ = strings.Cut(t, ":")
+		}
+	}
+
+	/* Alternatively, the port number could be separated by white space. */
+
+	t = ps.lex.next(false)
+	if t != "" {
+		var n, _ = strconv.Atoi(t)
+		if n >= MIN_IP_PORT_NUMBER && n <= MAX_IP_PORT_NUMBER {
+			ps.igate.t2_server_port = n
+		} else {
+			ps.igate.t2_server_port = DEFAULT_IGATE_PORT
+
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Invalid port number for IGate server. Using default %d.\n",
+				ps.line, ps.igate.t2_server_port)
+		}
+	}
+	// dw_printf ("DEBUG  server=%s   port=%d\n", p_igate_config.t2_server_name, p_igate_config.t2_server_port);
+	// exit (0);
+	return false
+}
+
+// handleIGLOGIN handles the IGLOGIN keyword.
+func handleIGLOGIN(ps *parseState) bool {
+	/*
+	 * IGLOGIN 		- Login callsign and passcode for IGate server
+	 *
+	 * IGLOGIN  callsign  passcode
+	 *
+	 * The passcode can also be file:path or credential:name, to keep it out
+	 * of this file.  See config_secret.
+	 */
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing login callsign for IGLOGIN command.\n", ps.line)
+
+		return true
+	}
+	// TODO: Wouldn't hurt to do validity checking of format.
+	ps.igate.t2_login = t
+
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing passcode for IGLOGIN command.\n", ps.line)
+
+		return true
+	}
+
+	var passcode, secretErr = config_secret(t)
+	if secretErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Could not get passcode for IGLOGIN command: %s\n", ps.line, secretErr)
+
+		return true
+	}
+
+	ps.igate.t2_passcode = passcode
+	return false
+}
+
+// handleIGTXVIA handles the IGTXVIA keyword.
+func handleIGTXVIA(ps *parseState) bool {
+	/*
+	 * IGTXVIA 		- Transmit channel and VIA path for messages from IGate server
+	 *
+	 * IGTXVIA  channel  [ path ]
+	 */
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing transmit channel for IGTXVIA command.\n", ps.line)
+
+		return true
+	}
+
+	var n, nErr = strconv.Atoi(t)
+	if nErr != nil || n < 0 || n > MAX_TOTAL_CHANS-1 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: Transmit channel must be in range of 0 to %d on line %d.\n",
+			MAX_TOTAL_CHANS-1, ps.line)
+
+		return true
+	}
+
+	ps.igate.tx_chan = n
+
+	t = ps.lex.next(false)
+	if t != "" {
+		// TODO KG#if 1	// proper checking
+		n = check_via_path(t)
+		if n >= 0 {
+			ps.igate.max_digi_hops = n
+			ps.igate.tx_via = "," + t
+		} else {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file, line %d: invalid via path.\n", ps.line)
+		}
+
+		/* TODO KG #else	// previously
+
+		   	      char *p;
+		   	      ps.igate.tx_via[0] = ',';
+		   	      strlcpy (ps.igate.tx_via + 1, t, sizeof(ps.igate.tx_via)-1);
+		   	      for (p = ps.igate.tx_via; *p != 0; p++) {
+		   	        if (islower(*p)) {
+		   		  *p = toupper(*p);	// silently force upper case.
+		   	        }
+		   	      }
+		   #endif
+		*/
+	}
+	return false
+}
+
+// handleIGFILTER handles the IGFILTER keyword.
+func handleIGFILTER(ps *parseState) bool {
+	/*
+	 * IGFILTER 		- IGate Server side filters.
+	 *			  Is this name too confusing.  Too similar to FILTER IG 0 ...
+	 *			  Maybe SSFILTER suggesting Server Side.
+	 *			  SUBSCRIBE might be better because it's not a filter that limits.
+	 *
+	 * IGFILTER  filter-spec ...
+	 */
+	var t = ps.lex.next(true) /* Take rest of ps.line as one string. */
+
+	if ps.igate.t2_filter != "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Warning - IGFILTER already configured (%s), this one (%s) will be ignored.\n", ps.line, ps.igate.t2_filter, t)
+
+		return true
+	}
+
+	if t != "" {
+		ps.igate.t2_filter = t
+
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Warning - IGFILTER is a rarely needed expert level feature.\n", ps.line)
+		dw_printf("If you don't have a special situation and a good understanding of\n")
+		dw_printf("how this works, you probably should not be messing with it.\n")
+		dw_printf("The default behavior is appropriate for most situations.\n")
+		dw_printf("Please read \"Successful-APRS-IGate-Operation.pdf\".\n")
+	}
+	return false
+}
+
+// handleIGTXLIMIT handles the IGTXLIMIT keyword.
+func handleIGTXLIMIT(ps *parseState) bool {
+	/*
+	 * IGTXLIMIT 		- Limit transmissions during 1 and 5 minute intervals.
+	 *
+	 * IGTXLIMIT  one-minute-limit  five-minute-limit
+	 */
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing one minute limit for IGTXLIMIT command.\n", ps.line)
+
+		return true
+	}
+
+	var n, _ = strconv.Atoi(t)
+	if n < 1 {
+		ps.igate.tx_limit_1 = 1
+	} else if n <= IGATE_TX_LIMIT_1_MAX {
+		ps.igate.tx_limit_1 = n
+	} else {
+		ps.igate.tx_limit_1 = IGATE_TX_LIMIT_1_MAX
+
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: One minute transmit limit has been reduced to %d.\n",
+			ps.line, ps.igate.tx_limit_1)
+		dw_printf("You won't make friends by setting a limit this high.\n")
+	}
+
+	t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing five minute limit for IGTXLIMIT command.\n", ps.line)
+
+		return true
+	}
+
+	n, _ = strconv.Atoi(t)
+	if n < 1 {
+		ps.igate.tx_limit_5 = 1
+	} else if n <= IGATE_TX_LIMIT_5_MAX {
+		ps.igate.tx_limit_5 = n
+	} else {
+		ps.igate.tx_limit_5 = IGATE_TX_LIMIT_5_MAX
+
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Five minute transmit limit has been reduced to %d.\n",
+			ps.line, ps.igate.tx_limit_5)
+		dw_printf("You won't make friends by setting a limit this high.\n")
+	}
+	return false
+}
+
+// handleIGMSP handles the IGMSP keyword.
+func handleIGMSP(ps *parseState) bool {
+	/*
+	 * IGMSP 		- Number of times to send position of message sender.
+	 *
+	 * IGMSP  n
+	 */
+	var t = ps.lex.next(false)
+	if t != "" {
+		var n, _ = strconv.Atoi(t)
+		if n >= 0 && n <= 10 {
+			ps.igate.igmsp = n
+		} else {
+			ps.igate.igmsp = 1
+
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Unreasonable number of times for message sender position.  Using default 1.\n", ps.line)
+		}
+	} else {
+		ps.igate.igmsp = 1
+
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing number of times for message sender position.  Using default 1.\n", ps.line)
+	}
+	return false
+}
+
+// handleSATGATE handles the SATGATE keyword.
+func handleSATGATE(ps *parseState) bool {
+	/*
+	 * SATGATE 		- Special SATgate mode to delay packets heard directly.
+	 *
+	 * SATGATE [ n ]
+	 */
+	config_deprecated(ps, "SATGATE")
+
+	var t = ps.lex.next(false)
+	if t != "" {
+		var n, _ = strconv.Atoi(t)
+		if n >= MIN_SATGATE_DELAY && n <= MAX_SATGATE_DELAY {
+			ps.igate.satgate_delay = n
+		} else {
+			ps.igate.satgate_delay = DEFAULT_SATGATE_DELAY
+
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Unreasonable SATgate delay.  Using default.\n", ps.line)
+		}
+	} else {
+		ps.igate.satgate_delay = DEFAULT_SATGATE_DELAY
+	}
+	return false
+}
+
+// handleAGWPORT handles the AGWPORT keyword.
+func handleAGWPORT(ps *parseState) bool {
+	/*
+	 * ==================== All the left overs ====================
+	 */
+
+	/*
+	 * AGWPORT 		- Port number for "AGW TCPIP Socket Interface"
+	 *
+	 * In version 1.2 we allow 0 to disable listening.
+	 */
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing port number for AGWPORT command.\n", ps.line)
+
+		return true
+	}
+	var n, nErr = strconv.Atoi(t)
+	if nErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid port number \"%s\" for AGWPORT command.\n", ps.line, t)
+
+		return true
+	}
+
+	t = ps.lex.next(false)
+	if t != "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Unexpected \"%s\" after the port number.\n", ps.line, t)
+		dw_printf("Perhaps you were trying to use feature available only with KISSPORT.\n")
+
+		return true
+	}
+
+	if (n >= MIN_IP_PORT_NUMBER && n <= MAX_IP_PORT_NUMBER) || n == 0 {
+		ps.misc.agwpe_port = n
+	} else {
+		ps.misc.agwpe_port = DEFAULT_AGWPE_PORT
+
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid port number for AGW TCPIP Socket Interface. Using %d.\n",
+			ps.line, ps.misc.agwpe_port)
+	}
+	return false
+}
+
+// handleHTTPPORT handles the HTTPPORT keyword.
+func handleHTTPPORT(ps *parseState) bool {
+	/*
+	 * HTTPPORT 		- Port number for HTTP, such as the WebSocket packet stream.
+	 *
+	 * Disabled by default.  0 also disables.
+	 */
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing port number for HTTPPORT command.\n", ps.line)
+
+		return true
+	}
+
+	var n, nErr = strconv.Atoi(t)
+	if nErr != nil || ((n < MIN_IP_PORT_NUMBER || n > MAX_IP_PORT_NUMBER) && n != 0) {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid port number \"%s\" for HTTPPORT command.\n", ps.line, t)
+
+		return true
+	}
+
+	ps.misc.http_port = n
+
+	return false
+}
+
+// handleHTTPORIGIN handles the HTTPORIGIN keyword.
+func handleHTTPORIGIN(ps *parseState) bool {
+	/*
+	 * HTTPORIGIN origin [ origin ... ]	- Web pages served elsewhere which can
+	 *					  open a WebSocket, e.g. https://map.example.org.
+	 *
+	 * Only our own pages can by default.  * allows any.  Can be repeated.
+	 */
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing origin for HTTPORIGIN command.\n", ps.line)
+
+		return true
+	}
+
+	for ; t != ""; t = ps.lex.next(false) {
+		if t == "*" {
+			ps.misc.http_origins = append(ps.misc.http_origins, t)
+
+			continue
+		}
+
+		// As a browser sends it:  scheme://host[:port] and nothing more.
+
+		var u, err = url.Parse(strings.TrimSuffix(t, "/"))
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" || u.Path != "" || u.RawQuery != "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: HTTPORIGIN \"%s\" should be like https://host or http://host:port.\n", ps.line, t)
+
+			continue
+		}
+
+		ps.misc.http_origins = append(ps.misc.http_origins, u.Scheme+"://"+u.Host)
+	}
+
+	return false
+}
+
+// handleKISSPORT handles the KISSPORT keyword.
+func handleKISSPORT(ps *parseState) bool {
+	/*
+	 * KISSPORT port [ chan ]		- Port number for KISS over IP.
+	 */
+
+	// Previously we allowed only a single TCP port for KISS.
+	// An increasing number of people want to run multiple radios.
+	// Unfortunately, most applications don't know how to deal with multi-radio TNCs.
+	// They ignore the channel on receive and always transmit to channel 0.
+	// Running multiple instances of direwolf is a work-around but this leads to
+	// more complex configuration and we lose the cross-channel digipeating capability.
+	// In release 1.7 we add a new feature to assign a single radio channel to a TCP port.
+	// e.g.
+	//
+	//	KISSPORT 8001		# default, all channels.  Radio channel = KISS channel.
+	//
+	//	KISSPORT 7000 0		# Only radio channel 0 for receive.
+	//				# Transmit to radio channel 0, ignoring KISS channel.
+	//
+	//	KISSPORT 7001 1		# Only radio channel 1 for receive.  KISS channel set to 0.
+	//				# Transmit to radio channel 1, ignoring KISS channel.
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing TCP port number for KISSPORT command.\n", ps.line)
+
+		return true
+	}
+	var n, nErr = strconv.Atoi(t)
+	if nErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid TCP port number \"%s\" for KISSPORT command.\n", ps.line, t)
+
+		return true
+	}
+
+	var tcp_port int
+	if (n >= MIN_IP_PORT_NUMBER && n <= MAX_IP_PORT_NUMBER) || n == 0 {
+		tcp_port = n
+	} else {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid TCP port number for KISS TCPIP Socket Interface.\n", ps.line)
+		dw_printf("Use something in the range of %d to %d.\n", MIN_IP_PORT_NUMBER, MAX_IP_PORT_NUMBER)
+
 		return true
 	}
 
-	// This enables the DTMF decoder on the specified channel.
-	// Additional channels can be enabled with the DTMF command.
-	// Note that DTMF command does not enable the APRStt gateway.
+	t = ps.lex.next(false)
+	var kissChannel = -1 // optional.  default to all if not specified.
+
+	if t != "" {
+		var channelErr error
+
+		kissChannel, channelErr = strconv.Atoi(t)
+		if kissChannel < 0 || kissChannel >= MAX_TOTAL_CHANS || channelErr != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Invalid channel %d for KISSPORT command.  Must be in range 0 thru %d.\n", ps.line, kissChannel, MAX_TOTAL_CHANS-1)
 
-	//text_color_set(DW_COLOR_DEBUG);
-	//dw_printf ("Debug TTOBJ r=%d, x=%d, app=%d, ig=%d\n", r, x, app, ig);
+			return true
+		}
+	}
 
-	ps.audio.achan[r].dtmf_decode = DTMF_DECODE_ON
-	ps.tt.gateway_enabled = 1
-	ps.tt.obj_recv_chan = r
-	ps.tt.obj_xmit_chan = x
-	ps.tt.obj_send_to_app = app
-	ps.tt.obj_send_to_ig = ig
+	// "KISSPORT 0" is used to remove the default entry.
 
-	t = split("", false)
-	if t != "" {
+	if tcp_port == 0 {
+		ps.misc.kiss_port[0] = 0 // Should all be wiped out?
+	} else {
+		// Try to find an empty slot.
+		// A duplicate TCP port number will overwrite the previous value.
+		var slot = -1
+		for i := 0; i < MAX_KISS_TCP_PORTS && slot == -1; i++ {
+			if ps.misc.kiss_port[i] == tcp_port { //nolint:staticcheck
+				slot = i
+				if !(slot == 0 && tcp_port == DEFAULT_KISS_PORT) {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Line %d: Warning: Duplicate TCP port %d will overwrite previous value.\n", ps.line, tcp_port)
+				}
+			} else if ps.misc.kiss_port[i] == 0 {
+				slot = i
+			}
+		}
 
-		if check_via_path(t) >= 0 {
-			ps.tt.obj_xmit_via = t
+		if slot >= 0 {
+			ps.misc.kiss_port[slot] = tcp_port
+			ps.misc.kiss_chan[slot] = kissChannel
 		} else {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file, line %d: invalid via path.\n", ps.line)
+			dw_printf("Line %d: Too many KISSPORT commands.\n", ps.line)
 		}
 	}
 	return false
 }
 
-// handleTTERR handles the TTERR keyword.
-func handleTTERR(ps *parseState) bool {
+// handleKISSCLIENT handles the KISSCLIENT keyword.
+func handleKISSCLIENT(ps *parseState) bool {
 	/*
-	 * TTERR 		- TT responses for success or errors.
+	 * KISSCLIENT host:port [ chan ]	- Connect to a KISS TCP server.
 	 *
-	 * TTERR  msg_id  method  text...
+	 *	The remote end is treated like a client application
+	 *	attached to KISSPORT with the same channel.
 	 */
-
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing message identifier for TTERR command.\n", ps.line)
-		return true
-	}
+		dw_printf("Line %d: Missing host:port for KISSCLIENT command.\n", ps.line)
 
-	var msg_num = -1
-	for n := range TT_ERROR_MAXP1 {
-		if strings.EqualFold(t, ttErrorString(n)) {
-			msg_num = n
-			break
-		}
-	}
-	if msg_num < 0 {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Invalid message identifier for TTERR command.\n", ps.line)
-		// pick one of ...
 		return true
 	}
 
-	t = split("", false)
-	if t == "" {
+	var host, port, splitErr = net.SplitHostPort(t)
+	var n, nErr = strconv.Atoi(port)
+	if splitErr != nil || host == "" || nErr != nil || n < MIN_IP_PORT_NUMBER || n > MAX_IP_PORT_NUMBER {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing method (SPEECH, MORSE) for TTERR command.\n", ps.line)
+		dw_printf("Line %d: Invalid address \"%s\" for KISSCLIENT command.  Use host:port with port in range of %d to %d.\n",
+			ps.line, t, MIN_IP_PORT_NUMBER, MAX_IP_PORT_NUMBER)
+
 		return true
 	}
 
-	t = strings.ToUpper(t)
+	t = ps.lex.next(false)
+	var kissChannel = -1 // optional.  default to all if not specified.
 
-	var method, _, _, ok = ax25_parse_addr(-1, t, 1)
-	if !ok {
-		return true // function above prints any error message
-	}
+	if t != "" {
+		var channelErr error
 
-	if method != "MORSE" && method != "SPEECH" {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Response method of %s must be SPEECH or MORSE for TTERR command.\n", ps.line, method)
-		return true
-	}
+		kissChannel, channelErr = strconv.Atoi(t)
+		if kissChannel < 0 || kissChannel >= MAX_TOTAL_CHANS || channelErr != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Invalid channel \"%s\" for KISSCLIENT command.  Must be in range 0 thru %d.\n", ps.line, t, MAX_TOTAL_CHANS-1)
 
-	t = split("", true)
-	if t == "" {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing response text for TTERR command.\n", ps.line)
-		return true
+			return true
+		}
 	}
 
-	//text_color_set(DW_COLOR_DEBUG);
-	//dw_printf ("Line %d: TTERR debug %d %s-%d \"%s\"\n", line, msg_num, method, ssid, t);
-
-	Assert(msg_num >= 0 && msg_num < TT_ERROR_MAXP1)
+	for i := range MAX_KISS_TCP_PORTS {
+		if ps.misc.kiss_client_addr[i] == "" {
+			ps.misc.kiss_client_addr[i] = net.JoinHostPort(host, port)
+			ps.misc.kiss_client_chan[i] = kissChannel
 
-	ps.tt.response[msg_num].method = method
+			return false
+		}
+	}
 
-	// TODO1.3: Need SSID too!
+	text_color_set(DW_COLOR_ERROR)
+	dw_printf("Line %d: Too many KISSCLIENT commands.\n", ps.line)
 
-	ps.tt.response[msg_num].mtext = t
-	return false
+	return true
 }
 
-// handleTTSTATUS handles the TTSTATUS keyword.
-func handleTTSTATUS(ps *parseState) bool {
+// handleBRIDGEPORT handles the BRIDGEPORT keyword.
+func handleBRIDGEPORT(ps *parseState) bool {
 	/*
-	 * TTSTATUS 		- TT custom status messages.
+	 * BRIDGEPORT port certfile keyfile cafile
+	 * BRIDGEPORT port INSECURE
 	 *
-	 * TTSTATUS  status_id  text...
+	 *	Let other instances use our radio channels with BCHANNEL.
+	 *	They can transmit with our radios, so they must connect with
+	 *	TLS and a certificate signed by the CA in cafile.
+	 *
+	 *	INSECURE allows plain TCP from anyone who can reach the port,
+	 *	for a network which is protected some other way.
 	 */
-
-	var t = split("", false)
-	if t == "" {
+	var t = ps.lex.next(false)
+	var n, nErr = strconv.Atoi(t)
+	if nErr != nil || n < MIN_IP_PORT_NUMBER || n > MAX_IP_PORT_NUMBER {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing status number for TTSTATUS command.\n", ps.line)
-		return true
-	}
-
-	var status_num, _ = strconv.Atoi(t)
+		dw_printf("Line %d: Invalid TCP port number \"%s\" for BRIDGEPORT command. Must be in range %d to %d.\n",
+			ps.line, t, MIN_IP_PORT_NUMBER, MAX_IP_PORT_NUMBER)
 
-	if status_num < 1 || status_num > 9 {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Status number for TTSTATUS command must be in range of 1 to 9.\n", ps.line)
 		return true
 	}
 
-	t = split("", true)
-	if t == "" {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing status text for TTSTATUS command.\n", ps.line)
-		return true
-	}
+	var cert = ps.lex.next(false)
 
-	//text_color_set(DW_COLOR_DEBUG);
-	//dw_printf ("Line %d: TTSTATUS debug %d \"%s\"\n", line, status_num, t);
+	if strings.EqualFold(cert, "INSECURE") {
+		ps.misc.bridge_port = n
+		ps.misc.bridge_insecure = true
 
-	t = strings.TrimSpace(t)
+		return false
+	}
 
-	ps.tt.status[status_num] = t
-	return false
-}
+	var key = ps.lex.next(false)
+	var ca = ps.lex.next(false)
 
-// handleTTCMD handles the TTCMD keyword.
-func handleTTCMD(ps *parseState) bool {
-	/*
-	 * TTCMD 		- Command to run when valid sequence is received.
-	 *			  Any text generated will be sent back to user.
-	 *
-	 * TTCMD ...
-	 */
-	var t = split("", true)
-	if t == "" {
+	if ca == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing command for TTCMD command.\n", ps.line)
+		dw_printf("Line %d: BRIDGEPORT needs a certificate file, a key file, and a CA file to check the other instances' certificates.\n", ps.line)
+		dw_printf("Anyone who can connect can transmit with your radios.  Use INSECURE, in place of the files, only if the port is protected some other way.\n")
+
 		return true
 	}
 
-	ps.tt.ttcmd = t
+	ps.misc.bridge_port = n
+	ps.misc.bridge_cert = cert
+	ps.misc.bridge_key = key
+	ps.misc.bridge_client_ca = ca
+
 	return false
 }
 
-// handleIGSERVER handles the IGSERVER keyword.
-func handleIGSERVER(ps *parseState) bool {
-	/*
-	 * ==================== Internet gateway ====================
-	 */
-
+// handleNULLMODEM handles the NULLMODEM keyword.
+func handleNULLMODEM(ps *parseState) bool {
 	/*
-	 * IGSERVER 		- Name of IGate server.
-	 *
-	 * IGSERVER  hostname [ port ] 				-- original implementation.
+	 * NULLMODEM name [ speed ]	- Device name for serial port or our end of the virtual "null modem"
+	 * SERIALKISS name  [ speed ]
 	 *
-	 * IGSERVER  hostname:port				-- more in line with usual conventions.
+	 * Version 1.5:  Added SERIALKISS which is equivalent to NULLMODEM.
+	 * The original name sort of made sense when it was used only for one end of a virtual
+	 * null modem cable on Windows only.  Now it is also available for Linux.
+	 * TODO1.5: In retrospect, this doesn't seem like such a good name.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing IGate server name for IGSERVER command.\n", ps.line)
+		dw_printf("Config file: Missing serial port name on line %d.\n", ps.line)
 
 		return true
-	}
-
-	ps.igate.t2_server_name = t
-
-	/* If there is a : in the name, split it out as the port number.
-	 * Use net.SplitHostPort to correctly handle bracketed IPv6 addresses like [::1]:8080.
-	 */
-
-	if strings.Contains(t, ":") {
-		var hostname, portStr, splitErr = net.SplitHostPort(t)
-		if splitErr == nil {
-			ps.igate.t2_server_name = hostname
-
-			var port, portErr = strconv.Atoi(portStr)
-			if port >= MIN_IP_PORT_NUMBER && port <= MAX_IP_PORT_NUMBER && portErr == nil {
-				ps.igate.t2_server_port = port
-			} else {
-				ps.igate.t2_server_port = DEFAULT_IGATE_PORT
-
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Line %d: Invalid port number for IGate server. Using default %d.\n",
-					ps.line, ps.igate.t2_server_port)
-			}
-		} else {
-			/* net.SplitHostPort failed (e.g. malformed input like "host:"); fall back to simple cut. */
+	} else {
+		if ps.misc.kiss_serial_port != "" {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: Could not parse IGate server address '%s': %v\n",
-				ps.line, t, splitErr)
-			ps.igate.t2_server_name, _, _ = strings.Cut(t, ":")
+			dw_printf("Config file: Warning serial port name on line %d replaces earlier value.\n", ps.line)
 		}
-	}
 
-	/* Alternatively, the port number could be separated by white space. */
+		ps.misc.kiss_serial_port = t
+		ps.misc.kiss_serial_speed = 0
+		ps.misc.kiss_serial_poll = 0
+	}
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t != "" {
-		var n, _ = strconv.Atoi(t)
-		if n >= MIN_IP_PORT_NUMBER && n <= MAX_IP_PORT_NUMBER {
-			ps.igate.t2_server_port = n
-		} else {
-			ps.igate.t2_server_port = DEFAULT_IGATE_PORT
-
+		var n, nErr = strconv.Atoi(t)
+		if nErr != nil {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: Invalid port number for IGate server. Using default %d.\n",
-				ps.line, ps.igate.t2_server_port)
+			dw_printf("Config file: Invalid speed \"%s\" for NULLMODEM/SERIALKISS command on line %d.\n", t, ps.line)
+
+			return true
 		}
+
+		ps.misc.kiss_serial_speed = n
 	}
-	// dw_printf ("DEBUG  server=%s   port=%d\n", p_igate_config.t2_server_name, p_igate_config.t2_server_port);
-	// exit (0);
 	return false
 }
 
-// handleIGLOGIN handles the IGLOGIN keyword.
-func handleIGLOGIN(ps *parseState) bool {
+// handleSERIALKISSPOLL handles the SERIALKISSPOLL keyword.
+func handleSERIALKISSPOLL(ps *parseState) bool {
 	/*
-	 * IGLOGIN 		- Login callsign and passcode for IGate server
-	 *
-	 * IGLOGIN  callsign  passcode
+	 * SERIALKISSPOLL name		- Poll for serial port name that might come and go.
+	 *			  	  e.g. /dev/rfcomm0 for bluetooth.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing login callsign for IGLOGIN command.\n", ps.line)
+		dw_printf("Config file: Missing serial port name on line %d.\n", ps.line)
 
 		return true
+	} else {
+		if ps.misc.kiss_serial_port != "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file: Warning serial port name on line %d replaces earlier value.\n", ps.line)
+		}
+
+		ps.misc.kiss_serial_port = t
+		ps.misc.kiss_serial_speed = 0
+		ps.misc.kiss_serial_poll = 1 // set polling.
 	}
-	// TODO: Wouldn't hurt to do validity checking of format.
-	ps.igate.t2_login = t
+	return false
+}
+
+// handleKISSCOPY handles the KISSCOPY keyword.
+func handleKISSCOPY(ps *parseState) bool {
+	/*
+	 * KISSCOPY 		- Data from network KISS client is copied to all others.
+	 *			  This does not apply to pseudo terminal KISS.
+	 */
+	ps.misc.kiss_copy = true
+	return false
+}
 
-	t = split("", false)
+// handleDNSSD handles the DNSSD keyword.
+func handleDNSSD(ps *parseState) bool {
+	/*
+	 * DNSSD 		- Enable or disable (1/0) dns-sd, DNS Service Discovery announcements
+	 * DNSSDNAME            - Set DNS-SD service name, defaults to "Dire Wolf on <hostname>"
+	 */
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing passcode for IGLOGIN command.\n", ps.line)
+		dw_printf("Line %d: Missing integer value for DNSSD command.\n", ps.line)
 
 		return true
 	}
 
-	ps.igate.t2_passcode = t
+	var n, nErr = strconv.Atoi(t)
+	if nErr != nil || (n != 0 && n != 1) {
+		ps.misc.dns_sd_enabled = false
+
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid integer value for DNSSD. Disabling dns-sd.\n", ps.line)
+	} else {
+		ps.misc.dns_sd_enabled = n != 0
+	}
 	return false
 }
 
-// handleIGTXVIA handles the IGTXVIA keyword.
-func handleIGTXVIA(ps *parseState) bool {
-	/*
-	 * IGTXVIA 		- Transmit channel and VIA path for messages from IGate server
-	 *
-	 * IGTXVIA  channel  [ path ]
-	 */
-	var t = split("", false)
+// handleDNSSDNAME handles the DNSSDNAME keyword.
+func handleDNSSDNAME(ps *parseState) bool {
+	var t = ps.lex.next(true)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing transmit channel for IGTXVIA command.\n", ps.line)
+		dw_printf("Line %d: Missing service name for DNSSDNAME.\n", ps.line)
 
 		return true
+	} else {
+		ps.misc.dns_sd_name = t
 	}
+	return false
+}
 
-	var n, nErr = strconv.Atoi(t)
-	if nErr != nil || n < 0 || n > MAX_TOTAL_CHANS-1 {
+// handleGPSNMEA handles the GPSNMEA keyword.
+func handleGPSNMEA(ps *parseState) bool {
+	/*
+	 * GPSNMEA  serial-device  [ speed ]		- Direct connection to GPS receiver.
+	 */
+	var t = ps.lex.next(false)
+	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Transmit channel must be in range of 0 to %d on line %d.\n",
-			MAX_TOTAL_CHANS-1, ps.line)
+		dw_printf("Config file, line %d: Missing serial port name for GPS receiver.\n", ps.line)
 
 		return true
 	}
 
-	ps.igate.tx_chan = n
+	ps.misc.gpsnmea_port = t
 
-	t = split("", false)
+	t = ps.lex.next(false)
 	if t != "" {
-		// TODO KG#if 1	// proper checking
-		n = check_via_path(t)
-		if n >= 0 {
-			ps.igate.max_digi_hops = n
-			ps.igate.tx_via = "," + t
-		} else {
+		var n, nErr = strconv.Atoi(t)
+		if nErr != nil {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file, line %d: invalid via path.\n", ps.line)
-		}
+			dw_printf("Config file, line %d: Invalid speed \"%s\" for GPSNMEA command.\n", ps.line, t)
 
-		/* TODO KG #else	// previously
+			return true
+		}
 
-		   	      char *p;
-		   	      ps.igate.tx_via[0] = ',';
-		   	      strlcpy (ps.igate.tx_via + 1, t, sizeof(ps.igate.tx_via)-1);
-		   	      for (p = ps.igate.tx_via; *p != 0; p++) {
-		   	        if (islower(*p)) {
-		   		  *p = toupper(*p);	// silently force upper case.
-		   	        }
-		   	      }
-		   #endif
-		*/
+		ps.misc.gpsnmea_speed = n
+	} else {
+		ps.misc.gpsnmea_speed = 4800 // The standard at one time.
 	}
 	return false
 }
 
-// handleIGFILTER handles the IGFILTER keyword.
-func handleIGFILTER(ps *parseState) bool {
+// handleGPSD handles the GPSD keyword.
+func handleGPSD(ps *parseState) bool {
 	/*
-	 * IGFILTER 		- IGate Server side filters.
-	 *			  Is this name too confusing.  Too similar to FILTER IG 0 ...
-	 *			  Maybe SSFILTER suggesting Server Side.
-	 *			  SUBSCRIBE might be better because it's not a filter that limits.
+	 * GPSD		- Use GPSD server.
 	 *
-	 * IGFILTER  filter-spec ...
+	 * GPSD [ host [ port ] ]
 	 */
-	var t = split("", true) /* Take rest of ps.line as one string. */
-
-	if ps.igate.t2_filter != "" {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Warning - IGFILTER already configured (%s), this one (%s) will be ignored.\n", ps.line, ps.igate.t2_filter, t)
-
-		return true
-	}
+	ps.misc.gpsd_host = "localhost"
+	ps.misc.gpsd_port = DEFAULT_GPSD_PORT
 
+	var t = ps.lex.next(false)
 	if t != "" {
-		ps.igate.t2_filter = t
+		ps.misc.gpsd_host = t
 
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Warning - IGFILTER is a rarely needed expert level feature.\n", ps.line)
-		dw_printf("If you don't have a special situation and a good understanding of\n")
-		dw_printf("how this works, you probably should not be messing with it.\n")
-		dw_printf("The default behavior is appropriate for most situations.\n")
-		dw_printf("Please read \"Successful-APRS-IGate-Operation.pdf\".\n")
+		t = ps.lex.next(false)
+		if t != "" {
+			var n, _ = strconv.Atoi(t)
+			if (n >= MIN_IP_PORT_NUMBER && n <= MAX_IP_PORT_NUMBER) || n == 0 {
+				ps.misc.gpsd_port = n
+			} else {
+				ps.misc.gpsd_port = DEFAULT_GPSD_PORT
+
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Line %d: Invalid port number for GPSD Socket Interface. Using default of %d.\n",
+					ps.line, ps.misc.gpsd_port)
+			}
+		}
 	}
 	return false
 }
 
-// handleIGTXLIMIT handles the IGTXLIMIT keyword.
-func handleIGTXLIMIT(ps *parseState) bool {
+// handleGPSAPI handles the GPSAPI keyword.
+func handleGPSAPI(ps *parseState) bool {
 	/*
-	 * IGTXLIMIT 		- Limit transmissions during 1 and 5 minute intervals.
-	 *
-	 * IGTXLIMIT  one-minute-limit  five-minute-limit
+	 * GPSAPI [ token ]	- Accept location from an external system, with "set position"
+	 *			  on the control socket, or POST /position to the HTTP server
+	 *			  with "Authorization: Bearer token".
 	 */
-	var t = split("", false)
-	if t == "" {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing one minute limit for IGTXLIMIT command.\n", ps.line)
+	ps.misc.gps_api = true
+	ps.misc.gps_api_token = ps.lex.next(false)
+	return false
+}
 
-		return true
-	}
+// handleGPSFIXED handles the GPSFIXED keyword.
+func handleGPSFIXED(ps *parseState) bool {
+	/*
+	 * GPSFIXED LAT=lat LONG=long [ ALT=meters ]	- Surveyed location, used when there is no GPS fix.
+	 */
+	for t := ps.lex.next(false); t != ""; t = ps.lex.next(false) {
+		var keyword, value, found = strings.Cut(t, "=")
+		if !found || value == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file: Expected keyword=value, not \"%s\", for GPSFIXED on line %d.\n", t, ps.line)
 
-	var n, _ = strconv.Atoi(t)
-	if n < 1 {
-		ps.igate.tx_limit_1 = 1
-	} else if n <= IGATE_TX_LIMIT_1_MAX {
-		ps.igate.tx_limit_1 = n
-	} else {
-		ps.igate.tx_limit_1 = IGATE_TX_LIMIT_1_MAX
+			continue
+		}
 
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: One minute transmit limit has been reduced to %d.\n",
-			ps.line, ps.igate.tx_limit_1)
-		dw_printf("You won't make friends by setting a limit this high.\n")
+		switch strings.ToUpper(keyword) {
+		case "LAT":
+			ps.misc.gps_fixed_lat = parse_ll(value, LAT, ps.line)
+		case "LONG", "LON":
+			ps.misc.gps_fixed_lon = parse_ll(value, LON, ps.line)
+		case "ALT", "ALTITUDE":
+			var alt, err = strconv.ParseFloat(value, 64)
+			if err != nil {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file: Invalid ALT \"%s\" for GPSFIXED on line %d.  It is in meters.\n", value, ps.line)
+			} else {
+				ps.misc.gps_fixed_alt = alt
+			}
+		default:
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file: Unexpected \"%s\" for GPSFIXED on line %d.\n", keyword, ps.line)
+		}
 	}
 
-	t = split("", false)
-	if t == "" {
+	if ps.misc.gps_fixed_lat == G_UNKNOWN || ps.misc.gps_fixed_lon == G_UNKNOWN {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing five minute limit for IGTXLIMIT command.\n", ps.line)
+		dw_printf("Config file: GPSFIXED on line %d needs LAT and LONG.\n", ps.line)
 
-		return true
-	}
-
-	n, _ = strconv.Atoi(t)
-	if n < 1 {
-		ps.igate.tx_limit_5 = 1
-	} else if n <= IGATE_TX_LIMIT_5_MAX {
-		ps.igate.tx_limit_5 = n
-	} else {
-		ps.igate.tx_limit_5 = IGATE_TX_LIMIT_5_MAX
+		ps.misc.gps_fixed_lat = G_UNKNOWN
+		ps.misc.gps_fixed_lon = G_UNKNOWN
 
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Five minute transmit limit has been reduced to %d.\n",
-			ps.line, ps.igate.tx_limit_5)
-		dw_printf("You won't make friends by setting a limit this high.\n")
+		return true
 	}
 	return false
 }
 
-// handleIGMSP handles the IGMSP keyword.
-func handleIGMSP(ps *parseState) bool {
+// handleGPSPRIORITY handles the GPSPRIORITY keyword.
+func handleGPSPRIORITY(ps *parseState) bool {
 	/*
-	 * IGMSP 		- Number of times to send position of message sender.
-	 *
-	 * IGMSP  n
+	 * GPSPRIORITY source ...	- Order to try position sources: NMEA, GPSD, API, FIXED.
 	 */
-	var t = split("", false)
-	if t != "" {
-		var n, _ = strconv.Atoi(t)
-		if n >= 0 && n <= 10 {
-			ps.igate.igmsp = n
-		} else {
-			ps.igate.igmsp = 1
+	ps.misc.gps_priority = nil
 
+	for t := ps.lex.next(false); t != ""; t = ps.lex.next(false) {
+		var src, ok = dwgps_source_from_name(t)
+		if !ok {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: Unreasonable number of times for message sender position.  Using default 1.\n", ps.line)
+			dw_printf("Config file: Unknown position source \"%s\" for GPSPRIORITY on line %d.\n", t, ps.line)
+
+			continue
 		}
-	} else {
-		ps.igate.igmsp = 1
 
+		if !slices.Contains(ps.misc.gps_priority, src) {
+			ps.misc.gps_priority = append(ps.misc.gps_priority, src)
+		}
+	}
+	return false
+}
+
+// handleGPSTIMEOUT handles the GPSTIMEOUT keyword.
+func handleGPSTIMEOUT(ps *parseState) bool {
+	/*
+	 * GPSTIMEOUT seconds	- Use the next source after no update for this long.  0 for never.
+	 */
+	var t = ps.lex.next(false)
+
+	var n, err = strconv.Atoi(t)
+	if err != nil || n < 0 {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing number of times for message sender position.  Using default 1.\n", ps.line)
+		dw_printf("Config file: Invalid number of seconds \"%s\" for GPSTIMEOUT on line %d.\n", t, ps.line)
+
+		return true
 	}
+
+	ps.misc.gps_timeout = n
 	return false
 }
 
-// handleSATGATE handles the SATGATE keyword.
-func handleSATGATE(ps *parseState) bool {
+// handleWAYPOINT handles the WAYPOINT keyword.
+func handleWAYPOINT(ps *parseState) bool {
 	/*
-	 * SATGATE 		- Special SATgate mode to delay packets heard directly.
+	 * WAYPOINT		- Generate WPL and AIS NMEA sentences for display on map.
 	 *
-	 * SATGATE [ n ]
+	 * WAYPOINT  serial-device [ formats ]
+	 * WAYPOINT  host:udpport [ formats ]
+	 * WAYPOINT  TCP:port [ formats ]
+	 *
+	 * May be used more than once to send to several places.
 	 */
-	text_color_set(DW_COLOR_INFO)
-	dw_printf("Line %d: SATGATE is pretty useless and will be removed in a future version.\n", ps.line)
+	var t = ps.lex.next(false)
+	if t == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: Missing output device for WAYPOINT on line %d.\n", ps.line)
 
-	var t = split("", false)
-	if t != "" {
-		var n, _ = strconv.Atoi(t)
-		if n >= MIN_SATGATE_DELAY && n <= MAX_SATGATE_DELAY {
-			ps.igate.satgate_delay = n
+		return true
+	}
+
+	/* If there is a ':' in the name, split it into hostname:udpportnum. */
+	/* Otherwise assume it is serial port name. */
+
+	if len(t) > 4 && strings.EqualFold(t[:4], "TCP:") {
+		var port, _ = strconv.Atoi(t[4:])
+		if port >= MIN_IP_PORT_NUMBER && port <= MAX_IP_PORT_NUMBER {
+			ps.misc.waypoint_tcp_port = port
 		} else {
-			ps.igate.satgate_delay = DEFAULT_SATGATE_DELAY
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Invalid TCP port number %d for sending waypoints.\n", ps.line, port)
+		}
+	} else if strings.Contains(t, ":") {
+		var hostname, portStr, _ = strings.Cut(t, ":")
 
+		var port, _ = strconv.Atoi(portStr)
+		if port >= MIN_IP_PORT_NUMBER && port <= MAX_IP_PORT_NUMBER {
+			ps.misc.waypoint_udp_hostname = hostname
+			if ps.misc.waypoint_udp_hostname == "" {
+				ps.misc.waypoint_udp_hostname = "localhost"
+			}
+
+			ps.misc.waypoint_udp_portnum = port
+		} else {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: Unreasonable SATgate delay.  Using default.\n", ps.line)
+			dw_printf("Line %d: Invalid UDP port number %d for sending waypoints.\n", ps.line, port)
 		}
 	} else {
-		ps.igate.satgate_delay = DEFAULT_SATGATE_DELAY
+		ps.misc.waypoint_serial_port = t
 	}
-	return false
-}
 
-// handleAGWPORT handles the AGWPORT keyword.
-func handleAGWPORT(ps *parseState) bool {
-	/*
-	 * ==================== All the left overs ====================
-	 */
+	/* Anything remaining is the formats to enable. */
+
+	t = ps.lex.next(true)
+	for _, c := range t {
+		switch unicode.ToUpper(c) {
+		case 'N':
+			ps.misc.waypoint_formats |= WPL_FORMAT_NMEA_GENERIC
+		case 'G':
+			ps.misc.waypoint_formats |= WPL_FORMAT_GARMIN
+		case 'M':
+			ps.misc.waypoint_formats |= WPL_FORMAT_MAGELLAN
+		case 'K':
+			ps.misc.waypoint_formats |= WPL_FORMAT_KENWOOD
+		case 'A':
+			ps.misc.waypoint_formats |= WPL_FORMAT_AIS
+		case ' ', ',':
+		default:
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file: Invalid output format '%c' for WAYPOINT on line %d.\n", c, ps.line)
+		}
+	}
+	return false
+}
 
+// handleGEOFENCE handles the GEOFENCE keyword.
+func handleGEOFENCE(ps *parseState) bool {
 	/*
-	 * AGWPORT 		- Port number for "AGW TCPIP Socket Interface"
+	 * GEOFENCE name LAT=lat LONG=long RADIUS=km options...
+	 * GEOFENCE name POLYGON=lat,long/lat,long/lat,long... options...
 	 *
-	 * In version 1.2 we allow 0 to disable listening.
+	 * Options are ENTER="comment" EXIT="comment" COMMENT="comment"
+	 * SMARTBEACONING=fast_speed,fast_rate,...
+	 *
+	 * See geofence.go.
 	 */
-	var t = split("", false)
-	if t == "" {
+	var name = ps.lex.next(false)
+	if name == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing port number for AGWPORT command.\n", ps.line)
+		dw_printf("Config file: Missing name for GEOFENCE on line %d.\n", ps.line)
 
 		return true
 	}
-	var n, nErr = strconv.Atoi(t)
-	if nErr != nil {
+
+	var g = new(geofence_s)
+	g.name = name
+	g.lineno = ps.line
+	g.lat = G_UNKNOWN
+	g.lon = G_UNKNOWN
+
+	for t := ps.lex.next(false); t != ""; t = ps.lex.next(false) {
+		var keyword, value, found = strings.Cut(t, "=")
+		if !found || value == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file: Expected keyword=value, not \"%s\", for GEOFENCE on line %d.\n", t, ps.line)
+
+			continue
+		}
+
+		switch strings.ToUpper(keyword) {
+		case "LAT":
+			g.lat = parse_ll(value, LAT, ps.line)
+		case "LONG", "LON":
+			g.lon = parse_ll(value, LON, ps.line)
+		case "RADIUS":
+			var r, err = strconv.ParseFloat(value, 64)
+			if err != nil || r <= 0 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file: Invalid RADIUS \"%s\" for GEOFENCE on line %d.  It is in km.\n", value, ps.line)
+			} else {
+				g.radius_km = r
+			}
+		case "POLYGON":
+			for corner := range strings.SplitSeq(value, "/") {
+				var lat, lon, ok = strings.Cut(corner, ",")
+				if !ok || lat == "" || lon == "" {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Config file: Polygon corner \"%s\" should be latitude,longitude for GEOFENCE on line %d.\n", corner, ps.line)
+					g.polygon = nil
+
+					break
+				}
+
+				g.polygon = append(g.polygon, [2]float64{parse_ll(lat, LAT, ps.line), parse_ll(lon, LON, ps.line)})
+			}
+		case "ENTER":
+			g.enter_comment = value
+		case "EXIT":
+			g.exit_comment = value
+		case "COMMENT":
+			g.comment = value
+		case "SMARTBEACONING", "SMARTBEACON":
+			var sb = ps.misc.smartbeacon()
+			if parse_smartbeaconing(strings.Split(value, ","), ps.line, &sb) {
+				g.sb = &sb
+			}
+		default:
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file: Unexpected \"%s\" for GEOFENCE on line %d.\n", keyword, ps.line)
+		}
+	}
+
+	var circle = g.radius_km > 0 && g.lat != G_UNKNOWN && g.lon != G_UNKNOWN
+
+	if circle == (len(g.polygon) > 0) {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Invalid port number \"%s\" for AGWPORT command.\n", ps.line, t)
+		dw_printf("Config file: GEOFENCE on line %d needs either LAT, LONG, and RADIUS, or POLYGON.\n", ps.line)
 
 		return true
 	}
 
-	t = split("", false)
-	if t != "" {
+	if len(g.polygon) > 0 && len(g.polygon) < 3 {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Unexpected \"%s\" after the port number.\n", ps.line, t)
-		dw_printf("Perhaps you were trying to use feature available only with KISSPORT.\n")
+		dw_printf("Config file: GEOFENCE polygon on line %d needs at least 3 corners.\n", ps.line)
 
 		return true
 	}
 
-	if (n >= MIN_IP_PORT_NUMBER && n <= MAX_IP_PORT_NUMBER) || n == 0 {
-		ps.misc.agwpe_port = n
-	} else {
-		ps.misc.agwpe_port = DEFAULT_AGWPE_PORT
+	ps.misc.geofence = append(ps.misc.geofence, g)
 
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Invalid port number for AGW TCPIP Socket Interface. Using %d.\n",
-			ps.line, ps.misc.agwpe_port)
-	}
 	return false
 }
 
-// handleKISSPORT handles the KISSPORT keyword.
-func handleKISSPORT(ps *parseState) bool {
+// handleFILECAST handles the FILECAST keyword.
+func handleFILECAST(ps *parseState) bool {
 	/*
-	 * KISSPORT port [ chan ]		- Port number for KISS over IP.
+	 * FILECAST channel path [ EVERY=minutes ] [ VIA=digi1,... ] [ BLOCK=bytes ] [ NAME=name ]
+	 *
+	 * See filecast.go.
 	 */
+	var t = ps.lex.next(false)
 
-	// Previously we allowed only a single TCP port for KISS.
-	// An increasing number of people want to run multiple radios.
-	// Unfortunately, most applications don't know how to deal with multi-radio TNCs.
-	// They ignore the channel on receive and always transmit to channel 0.
-	// Running multiple instances of direwolf is a work-around but this leads to
-	// more complex configuration and we lose the cross-channel digipeating capability.
-	// In release 1.7 we add a new feature to assign a single radio channel to a TCP port.
-	// e.g.
-	//
-	//	KISSPORT 8001		# default, all channels.  Radio channel = KISS channel.
-	//
-	//	KISSPORT 7000 0		# Only radio channel 0 for receive.
-	//				# Transmit to radio channel 0, ignoring KISS channel.
-	//
-	//	KISSPORT 7001 1		# Only radio channel 1 for receive.  KISS channel set to 0.
-	//				# Transmit to radio channel 1, ignoring KISS channel.
-	var t = split("", false)
-	if t == "" {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing TCP port number for KISSPORT command.\n", ps.line)
-
-		return true
-	}
-	var n, nErr = strconv.Atoi(t)
-	if nErr != nil {
+	var channel, channelErr = strconv.Atoi(t)
+	if channelErr != nil || channel < 0 || channel >= MAX_RADIO_CHANS {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Invalid TCP port number \"%s\" for KISSPORT command.\n", ps.line, t)
+		dw_printf("Config file: FILECAST on line %d needs a radio channel, 0 thru %d, not \"%s\".\n", ps.line, MAX_RADIO_CHANS-1, t)
 
 		return true
 	}
 
-	var tcp_port int
-	if (n >= MIN_IP_PORT_NUMBER && n <= MAX_IP_PORT_NUMBER) || n == 0 {
-		tcp_port = n
-	} else {
+	var path = ps.lex.next(false)
+	if path == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Invalid TCP port number for KISS TCPIP Socket Interface.\n", ps.line)
-		dw_printf("Use something in the range of %d to %d.\n", MIN_IP_PORT_NUMBER, MAX_IP_PORT_NUMBER)
+		dw_printf("Config file: Missing file name for FILECAST on line %d.\n", ps.line)
 
 		return true
 	}
 
-	t = split("", false)
-	var kissChannel = -1 // optional.  default to all if not specified.
-
-	if t != "" {
-		var channelErr error
+	var f = &filecast_s{
+		channel: channel,
+		path:    path,
+		name:    filepath.Base(path),
+		every:   FILECAST_DEFAULT_EVERY,
+		via:     nil,
+		block:   FILECAST_DEFAULT_BLOCK,
+		lineno:  ps.line,
+	}
 
-		kissChannel, channelErr = strconv.Atoi(t)
-		if kissChannel < 0 || kissChannel >= MAX_TOTAL_CHANS || channelErr != nil {
+	for t := ps.lex.next(false); t != ""; t = ps.lex.next(false) {
+		var keyword, value, found = strings.Cut(t, "=")
+		if !found || value == "" {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: Invalid channel %d for KISSPORT command.  Must be in range 0 thru %d.\n", ps.line, kissChannel, MAX_TOTAL_CHANS-1)
+			dw_printf("Config file: Expected keyword=value, not \"%s\", for FILECAST on line %d.\n", t, ps.line)
 
-			return true
+			continue
 		}
-	}
 
-	// "KISSPORT 0" is used to remove the default entry.
+		switch strings.ToUpper(keyword) {
+		case "EVERY":
+			var minutes, err = strconv.ParseFloat(value, 64)
+			if err != nil || minutes < 1 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file: Invalid EVERY \"%s\" for FILECAST on line %d.  It is in minutes, at least 1.\n", value, ps.line)
+			} else {
+				f.every = time.Duration(minutes * float64(time.Minute))
+			}
+		case "VIA":
+			f.via = nil
 
-	if tcp_port == 0 {
-		ps.misc.kiss_port[0] = 0 // Should all be wiped out?
-	} else {
-		// Try to find an empty slot.
-		// A duplicate TCP port number will overwrite the previous value.
-		var slot = -1
-		for i := 0; i < MAX_KISS_TCP_PORTS && slot == -1; i++ {
-			if ps.misc.kiss_port[i] == tcp_port { //nolint:staticcheck
-				slot = i
-				if !(slot == 0 && tcp_port == DEFAULT_KISS_PORT) {
+			for digi := range strings.SplitSeq(value, ",") {
+				var _, _, _, ok = ax25_parse_addr(AX25_REPEATER_1, digi, 1)
+				if !ok || len(f.via) >= AX25_MAX_REPEATERS {
 					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Line %d: Warning: Duplicate TCP port %d will overwrite previous value.\n", ps.line, tcp_port)
+					dw_printf("Config file: Invalid VIA \"%s\" for FILECAST on line %d.\n", value, ps.line)
+					f.via = nil
+
+					break
 				}
-			} else if ps.misc.kiss_port[i] == 0 {
-				slot = i
-			}
-		}
 
-		if slot >= 0 {
-			ps.misc.kiss_port[slot] = tcp_port
-			ps.misc.kiss_chan[slot] = kissChannel
-		} else {
+				f.via = append(f.via, digi)
+			}
+		case "BLOCK":
+			var n, err = strconv.Atoi(value)
+			if err != nil || n < FILECAST_MIN_BLOCK || n > FILECAST_MAX_BLOCK {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file: BLOCK for FILECAST on line %d should be %d to %d bytes, not \"%s\".\n",
+					ps.line, FILECAST_MIN_BLOCK, FILECAST_MAX_BLOCK, value)
+			} else {
+				f.block = n
+			}
+		case "NAME":
+			f.name = value
+		default:
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: Too many KISSPORT commands.\n", ps.line)
+			dw_printf("Config file: Unexpected \"%s\" for FILECAST on line %d.\n", keyword, ps.line)
 		}
 	}
+
+	if !filecast_name_ok(f.name) {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: FILECAST on line %d can't send the name \"%s\".  Use NAME= for something shorter, without / \\ or :.\n", ps.line, f.name)
+
+		return true
+	}
+
+	ps.misc.filecast = append(ps.misc.filecast, f)
+
 	return false
 }
 
-// handleNULLMODEM handles the NULLMODEM keyword.
-func handleNULLMODEM(ps *parseState) bool {
+// handleFILECASTDIR handles the FILECASTDIR keyword.
+func handleFILECASTDIR(ps *parseState) bool {
 	/*
-	 * NULLMODEM name [ speed ]	- Device name for serial port or our end of the virtual "null modem"
-	 * SERIALKISS name  [ speed ]
-	 *
-	 * Version 1.5:  Added SERIALKISS which is equivalent to NULLMODEM.
-	 * The original name sort of made sense when it was used only for one end of a virtual
-	 * null modem cable on Windows only.  Now it is also available for Linux.
-	 * TODO1.5: In retrospect, this doesn't seem like such a good name.
+	 * FILECASTDIR directory	- Save files received from FILECAST.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing serial port name on line %d.\n", ps.line)
+		dw_printf("Config file: Missing directory name for FILECASTDIR on line %d.\n", ps.line)
 
 		return true
-	} else {
-		if ps.misc.kiss_serial_port != "" {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file: Warning serial port name on line %d replaces earlier value.\n", ps.line)
-		}
-
-		ps.misc.kiss_serial_port = t
-		ps.misc.kiss_serial_speed = 0
-		ps.misc.kiss_serial_poll = 0
 	}
 
-	t = split("", false)
-	if t != "" {
-		var n, nErr = strconv.Atoi(t)
-		if nErr != nil {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file: Invalid speed \"%s\" for NULLMODEM/SERIALKISS command on line %d.\n", t, ps.line)
-
-			return true
-		}
+	ps.misc.filecast_dir = t
 
-		ps.misc.kiss_serial_speed = n
-	}
 	return false
 }
 
-// handleSERIALKISSPOLL handles the SERIALKISSPOLL keyword.
-func handleSERIALKISSPOLL(ps *parseState) bool {
+// handleLOGDIR handles the LOGDIR keyword.
+func handleLOGDIR(ps *parseState) bool {
 	/*
-	 * SERIALKISSPOLL name		- Poll for serial port name that might come and go.
-	 *			  	  e.g. /dev/rfcomm0 for bluetooth.
+	 * LOGDIR	- Directory name for automatically named daily log files.  Use "." for current working directory.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing serial port name on line %d.\n", ps.line)
+		dw_printf("Config file: Missing directory name for LOGDIR on line %d.\n", ps.line)
 
 		return true
 	} else {
-		if ps.misc.kiss_serial_port != "" {
+		if ps.misc.log_path != "" {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file: Warning serial port name on line %d replaces earlier value.\n", ps.line)
+			dw_printf("Config file: LOGDIR on line %d is replacing an earlier LOGDIR or LOGFILE.\n", ps.line)
 		}
 
-		ps.misc.kiss_serial_port = t
-		ps.misc.kiss_serial_speed = 0
-		ps.misc.kiss_serial_poll = 1 // set polling.
+		ps.misc.log_daily_names = true
+		ps.misc.log_path = t
 	}
-	return false
-}
 
-// handleKISSCOPY handles the KISSCOPY keyword.
-func handleKISSCOPY(ps *parseState) bool {
-	/*
-	 * KISSCOPY 		- Data from network KISS client is copied to all others.
-	 *			  This does not apply to pseudo terminal KISS.
-	 */
-	ps.misc.kiss_copy = true
+	t = ps.lex.next(false)
+	if t != "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file: LOGDIR on line %d should have directory path and nothing more.\n", ps.line)
+	}
 	return false
 }
 
-// handleDNSSD handles the DNSSD keyword.
-func handleDNSSD(ps *parseState) bool {
+// handleLOGFILE handles the LOGFILE keyword.
+func handleLOGFILE(ps *parseState) bool {
 	/*
-	 * DNSSD 		- Enable or disable (1/0) dns-sd, DNS Service Discovery announcements
-	 * DNSSDNAME            - Set DNS-SD service name, defaults to "Dire Wolf on <hostname>"
+	 * LOGFILE	- Log file name, including any directory part.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing integer value for DNSSD command.\n", ps.line)
+		dw_printf("Config file: Missing file name for LOGFILE on line %d.\n", ps.line)
 
 		return true
-	}
+	} else {
+		if ps.misc.log_path != "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file: LOGFILE on line %d is replacing an earlier LOGDIR or LOGFILE.\n", ps.line)
+		}
 
-	var n, nErr = strconv.Atoi(t)
-	if nErr != nil || (n != 0 && n != 1) {
-		ps.misc.dns_sd_enabled = false
+		ps.misc.log_daily_names = false
+		ps.misc.log_path = t
+	}
 
+	t = ps.lex.next(false)
+	if t != "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Invalid integer value for DNSSD. Disabling dns-sd.\n", ps.line)
-	} else {
-		ps.misc.dns_sd_enabled = n != 0
+		dw_printf("Config file: LOGFILE on line %d should have file name and nothing more.\n", ps.line)
 	}
 	return false
 }
 
-// handleDNSSDNAME handles the DNSSDNAME keyword.
-func handleDNSSDNAME(ps *parseState) bool {
-	var t = split("", true)
-	if t == "" {
+// handleLOGFORMAT handles the LOGFORMAT keyword.
+func handleLOGFORMAT(ps *parseState) bool {
+	/*
+	 * LOGFORMAT  CSV | JSON	- What LOGDIR and LOGFILE write.  JSON is one object per line.
+	 */
+	var t = ps.lex.next(false)
+	if !strings.EqualFold(t, "CSV") && !strings.EqualFold(t, "JSON") {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Line %d: Missing service name for DNSSDNAME.\n", ps.line)
+		dw_printf("Config file: LOGFORMAT on line %d must be CSV or JSON.\n", ps.line)
 
 		return true
-	} else {
-		ps.misc.dns_sd_name = t
 	}
+
+	ps.misc.log_format = strings.ToLower(t)
+
 	return false
 }
 
-// handleGPSNMEA handles the GPSNMEA keyword.
-func handleGPSNMEA(ps *parseState) bool {
+// handleLOGROTATE handles the LOGROTATE keyword.
+func handleLOGROTATE(ps *parseState) bool {
 	/*
-	 * GPSNMEA  serial-device  [ speed ]		- Direct connection to GPS receiver.
+	 * LOGROTATE [ size=n ] [ keep=n ] [ days=n ] [ compress ]
+	 *
+	 *	size	- Start a new log file at this size.  K, M, or G suffix allowed.
+	 *	keep	- Number of old log files to keep.
+	 *	days	- Remove old log files not written for this many days.
+	 *	compress - gzip old log files.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file, line %d: Missing serial port name for GPS receiver.\n", ps.line)
+		dw_printf("Config file: LOGROTATE on line %d needs at least one of size=, keep=, days=, or compress.\n", ps.line)
 
 		return true
 	}
 
-	ps.misc.gpsnmea_port = t
-
-	t = split("", false)
-	if t != "" {
-		var n, nErr = strconv.Atoi(t)
-		if nErr != nil {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file, line %d: Invalid speed \"%s\" for GPSNMEA command.\n", ps.line, t)
+	for ; t != ""; t = ps.lex.next(false) {
+		if strings.EqualFold(t, "COMPRESS") {
+			ps.misc.log_compress = true
 
-			return true
+			continue
 		}
 
-		ps.misc.gpsnmea_speed = n
-	} else {
-		ps.misc.gpsnmea_speed = 4800 // The standard at one time.
-	}
-	return false
-}
-
-// handleGPSD handles the GPSD keyword.
-func handleGPSD(ps *parseState) bool {
-	/*
-	 * GPSD		- Use GPSD server.
-	 *
-	 * GPSD [ host [ port ] ]
-	 */
-
-	/*
-	   TODO KG
+		var keyword, value, found = strings.Cut(t, "=")
+		if !found {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file: Unexpected \"%s\" for LOGROTATE on line %d.\n", t, ps.line)
 
-	   	#if __WIN32__
+			continue
+		}
 
-	   		    text_color_set(DW_COLOR_ERROR);
-	   		    dw_printf ("Config file, line %d: The GPSD interface is not available for Windows.\n", ps.line);
-	   		    continue;
+		switch strings.ToUpper(keyword) {
+		case "SIZE":
+			var n, err = log_parse_size(value)
+			if err != nil {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file: Invalid size \"%s\" for LOGROTATE on line %d.  Use something like 10M.\n", value, ps.line)
 
-	   	#elif ENABLE_GPSD
-	*/
-	dw_printf("Warning: GPSD support currently disabled pending a rewrite of the integration.\n")
+				continue
+			}
 
-	ps.misc.gpsd_host = "localhost"
-	ps.misc.gpsd_port = DEFAULT_GPSD_PORT
+			ps.misc.log_max_size = n
+		case "KEEP", "DAYS":
+			var n, err = strconv.Atoi(value)
+			if err != nil || n < 1 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file: Invalid %s \"%s\" for LOGROTATE on line %d.\n", strings.ToLower(keyword), value, ps.line)
 
-	var t = split("", false)
-	if t != "" {
-		ps.misc.gpsd_host = t
+				continue
+			}
 
-		t = split("", false)
-		if t != "" {
-			var n, _ = strconv.Atoi(t)
-			if (n >= MIN_IP_PORT_NUMBER && n <= MAX_IP_PORT_NUMBER) || n == 0 {
-				ps.misc.gpsd_port = n
+			if strings.EqualFold(keyword, "KEEP") {
+				ps.misc.log_keep = n
 			} else {
-				ps.misc.gpsd_port = DEFAULT_GPSD_PORT
-
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Line %d: Invalid port number for GPSD Socket Interface. Using default of %d.\n",
-					ps.line, ps.misc.gpsd_port)
+				ps.misc.log_keep_days = n
 			}
+		default:
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Config file: Unknown option \"%s\" for LOGROTATE on line %d.\n", keyword, ps.line)
 		}
 	}
-	/*
-	   	TODO KG
-
-	   #else
-
-	   	text_color_set(DW_COLOR_ERROR);
-	   	dw_printf ("Config file, line %d: The GPSD interface has not been enabled.\n", ps.line);
-	   	dw_printf ("Install gpsd and libgps-dev packages then rebuild direwolf.\n");
-	   	continue;
 
-	   #endif
-	*/
 	return false
 }
 
-// handleWAYPOINT handles the WAYPOINT keyword.
-func handleWAYPOINT(ps *parseState) bool {
+// handleALERT handles the ALERT keyword.
+func handleALERT(ps *parseState) bool {
 	/*
-	 * WAYPOINT		- Generate WPL and AIS NMEA sentences for display on map.
-	 *
-	 * WAYPOINT  serial-device [ formats ]
-	 * WAYPOINT  host:udpport [ formats ]
+	 * ALERT [ AUDIO ] [ IGATE=minutes ] [ NORX=minutes ]
 	 *
+	 *	AUDIO	- Audio input device failed.
+	 *	IGATE	- IGate not connected to server for this long.
+	 *	NORX	- Nothing decoded from radio for this long.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing output device for WAYPOINT on line %d.\n", ps.line)
+		dw_printf("Config file: ALERT on line %d needs at least one of AUDIO, IGATE=, or NORX=.\n", ps.line)
 
 		return true
 	}
 
-	/* If there is a ':' in the name, split it into hostname:udpportnum. */
-	/* Otherwise assume it is serial port name. */
-
-	if strings.Contains(t, ":") {
-		var hostname, portStr, _ = strings.Cut(t, ":")
+	for ; t != ""; t = ps.lex.next(false) {
+		if strings.EqualFold(t, "AUDIO") {
+			ps.misc.alert_audio = true
 
-		var port, _ = strconv.Atoi(portStr)
-		if port >= MIN_IP_PORT_NUMBER && port <= MAX_IP_PORT_NUMBER {
-			ps.misc.waypoint_udp_hostname = hostname
-			if ps.misc.waypoint_udp_hostname == "" {
-				ps.misc.waypoint_udp_hostname = "localhost"
-			}
+			continue
+		}
 
-			ps.misc.waypoint_udp_portnum = port
-		} else {
+		var keyword, value, found = strings.Cut(t, "=")
+		if !found || !(strings.EqualFold(keyword, "IGATE") || strings.EqualFold(keyword, "NORX")) {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Line %d: Invalid UDP port number %d for sending waypoints.\n", ps.line, port)
-		}
-	} else {
-		ps.misc.waypoint_serial_port = t
-	}
+			dw_printf("Config file: Unexpected \"%s\" for ALERT on line %d.\n", t, ps.line)
 
-	/* Anything remaining is the formats to enable. */
+			continue
+		}
 
-	t = split("", true)
-	for _, c := range t {
-		switch unicode.ToUpper(c) {
-		case 'N':
-			ps.misc.waypoint_formats |= WPL_FORMAT_NMEA_GENERIC
-		case 'G':
-			ps.misc.waypoint_formats |= WPL_FORMAT_GARMIN
-		case 'M':
-			ps.misc.waypoint_formats |= WPL_FORMAT_MAGELLAN
-		case 'K':
-			ps.misc.waypoint_formats |= WPL_FORMAT_KENWOOD
-		case 'A':
-			ps.misc.waypoint_formats |= WPL_FORMAT_AIS
-		case ' ', ',':
-		default:
+		var n, err = strconv.Atoi(value)
+		if err != nil || n < 1 {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file: Invalid output format '%c' for WAYPOINT on line %d.\n", c, ps.line)
+			dw_printf("Config file: Invalid number of minutes \"%s\" for ALERT on line %d.\n", value, ps.line)
+
+			continue
+		}
+
+		if strings.EqualFold(keyword, "IGATE") {
+			ps.misc.alert_igate_minutes = n
+		} else {
+			ps.misc.alert_norx_minutes = n
 		}
 	}
+
 	return false
 }
 
-// handleLOGDIR handles the LOGDIR keyword.
-func handleLOGDIR(ps *parseState) bool {
+// handleALERTHOOK handles the ALERTHOOK keyword.
+func handleALERTHOOK(ps *parseState) bool {
 	/*
-	 * LOGDIR	- Directory name for automatically named daily log files.  Use "." for current working directory.
+	 * ALERTHOOK	- URL for HTTP POST, or command to run, for each alert.  Can be repeated.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing directory name for LOGDIR on line %d.\n", ps.line)
+		dw_printf("Config file: Missing URL or command for ALERTHOOK on line %d.\n", ps.line)
 
 		return true
-	} else {
-		if ps.misc.log_path != "" {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file: LOGDIR on line %d is replacing an earlier LOGDIR or LOGFILE.\n", ps.line)
-		}
-
-		ps.misc.log_daily_names = true
-		ps.misc.log_path = t
 	}
 
-	t = split("", false)
+	ps.misc.alert_hooks = append(ps.misc.alert_hooks, t)
+
+	t = ps.lex.next(false)
 	if t != "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: LOGDIR on line %d should have directory path and nothing more.\n", ps.line)
+		dw_printf("Config file: ALERTHOOK on line %d should have URL or command and nothing more.\n", ps.line)
 	}
 	return false
 }
 
-// handleLOGFILE handles the LOGFILE keyword.
-func handleLOGFILE(ps *parseState) bool {
+// handleCONTROLSOCKET handles the CONTROLSOCKET keyword.
+func handleCONTROLSOCKET(ps *parseState) bool {
 	/*
-	 * LOGFILE	- Log file name, including any directory part.
+	 * CONTROLSOCKET	- Unix socket for inspecting a running instance.  See control.go.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: Missing file name for LOGFILE on line %d.\n", ps.line)
+		dw_printf("Config file: Missing socket path for CONTROLSOCKET on line %d.\n", ps.line)
 
 		return true
-	} else {
-		if ps.misc.log_path != "" {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Config file: LOGFILE on line %d is replacing an earlier LOGDIR or LOGFILE.\n", ps.line)
-		}
-
-		ps.misc.log_daily_names = false
-		ps.misc.log_path = t
 	}
 
-	t = split("", false)
+	ps.misc.control_socket = t
+
+	t = ps.lex.next(false)
 	if t != "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Config file: LOGFILE on line %d should have file name and nothing more.\n", ps.line)
+		dw_printf("Config file: CONTROLSOCKET on line %d should have socket path and nothing more.\n", ps.line)
 	}
 	return false
 }
@@ -5629,12 +7521,9 @@ func handleXBEACON(ps *parseState) bool {
 		/* Save line number because some errors will be reported later. */
 		ps.misc.beacon[ps.misc.num_beacons].lineno = ps.line
 
-		// Pass "" so beacon_options continues from the current split() position
-		// rather than reinitialising the tokenizer. The main parse loop already
-		// called split(ps.text, false) to extract the keyword, leaving any
-		// options as the remaining state; passing "" here reads those options
-		// correctly and also handles the case where there are none.
-		if beacon_options("", &(ps.misc.beacon[ps.misc.num_beacons]), ps.line, ps.audio) == nil {
+		// The main parse loop has already taken the keyword from ps.lex,
+		// leaving any options for beacon_options to read.
+		if beacon_options(ps.lex, &(ps.misc.beacon[ps.misc.num_beacons]), ps.line, ps.audio) == nil {
 			ps.misc.num_beacons++
 		}
 	} else {
@@ -5649,73 +7538,106 @@ func handleXBEACON(ps *parseState) bool {
 // handleSMARTBEACON handles the SMARTBEACON keyword.
 func handleSMARTBEACON(ps *parseState) bool {
 	/*
-	 * SMARTBEACONING [ fast_speed fast_rate slow_speed slow_rate turn_time turn_angle turn_slope ]
+	 * SMARTBEACONING [ fast_speed fast_rate slow_speed slow_rate turn_time turn_angle turn_slope ] [ TURNDECAY=sec ] [ MINTURNDIST=meters ]
 	 *
 	 * Parameters must be all or nothing.
 	 */
-	dw_printf("SMARTBEACONING support currently disabled due to mid-stage porting complexity - line %d skipped.\n", ps.line)
-
-	/* TODO KG
-	   #define SB_NUM(name,sbvar,minn,maxx,unit)  							\
-	   	var t = split("", false);									\
-	   	    if (t == "") {									\
-	   	      if (strcmp(name, "fast speed") == 0) {						\
-	   	        ps.misc.sb_configured = 1;						\
-	   	        continue;									\
-	   	      }											\
-	   	      text_color_set(DW_COLOR_ERROR);							\
-	   	      dw_printf ("Line %d: Missing %s for SmartBeaconing.\n", ps.line, name);		\
-	   	      continue;										\
-	   	    }											\
-	   	    var n, _ = strconv.Atoi(t);									\
-	               if (n >= minn && n <= maxx) {							\
-	   	      ps.misc.sbvar = n;								\
-	   	    }											\
-	   	    else {										\
-	   	      text_color_set(DW_COLOR_ERROR);							\
-	                 dw_printf ("Line %d: Invalid %s for SmartBeaconing. Using default %d %s.\n",	\
-	   			ps.line, name, ps.misc.sbvar, unit);				\
-	      	    }
-	*/
+	var values []string
+	for t := ps.lex.next(false); t != ""; t = ps.lex.next(false) {
+		var keyword, value, found = strings.Cut(t, "=")
+		if !found {
+			values = append(values, t)
 
-	/* TODO KG
-	   #define SB_TIME(name,sbvar,minn,maxx,unit)  							\
-	   	    t = split("", false);									\
-	   	    if (t == "") {									\
-	   	      text_color_set(DW_COLOR_ERROR);							\
-	   	      dw_printf ("Line %d: Missing %s for SmartBeaconing.\n", ps.line, name);		\
-	   	      continue;										\
-	   	    }											\
-	   	    n = parse_interval(t,ps.line);								\
-	               if (n >= minn && n <= maxx) {							\
-	   	      ps.misc.sbvar = n;								\
-	   	    }											\
-	   	    else {										\
-	   	      text_color_set(DW_COLOR_ERROR);							\
-	                 dw_printf ("Line %d: Invalid %s for SmartBeaconing. Using default %d %s.\n",	\
-	   			ps.line, name, ps.misc.sbvar, unit);				\
-	      	    }
-	*/
+			continue
+		}
 
-	/* TODO KG
-	   SB_NUM("fast speed", sb_fast_speed, 2, 90, "MPH")
-	   SB_TIME("fast rate", sb_fast_rate, 10, 300, "seconds")
+		var n, err = strconv.Atoi(value)
 
-	   SB_NUM("slow speed", sb_slow_speed, 1, 30, "MPH")
-	   SB_TIME("slow rate", sb_slow_rate, 30, 3600, "seconds")
+		switch {
+		case strings.EqualFold(keyword, "TURNDECAY") && err == nil && n >= 0 && n <= 3600:
+			ps.misc.sb_turn_decay = n
+		case strings.EqualFold(keyword, "MINTURNDIST") && err == nil && n >= 0 && n <= 10000:
+			ps.misc.sb_min_turn_dist = n
+		default:
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Invalid \"%s\" for SmartBeaconing.  Expected TURNDECAY=seconds or MINTURNDIST=meters.\n", ps.line, t)
+		}
+	}
 
-	   SB_TIME("turn time", sb_turn_time, 5, 180, "seconds")
-	   SB_NUM("turn angle", sb_turn_angle, 5, 90, "degrees")
-	   SB_NUM("turn slope", sb_turn_slope, 1, 255, "deg*mph")
+	var sb = ps.misc.smartbeacon()
 
-	   ps.misc.sb_configured = 1
-	*/
+	if len(values) == 0 || parse_smartbeaconing(values, ps.line, &sb) {
+		ps.misc.set_smartbeacon(sb)
+		ps.misc.sb_configured = true
+	}
 
 	/* If I was ambitious, I might allow optional */
 	/* unit at end for miles or km / hour. */
 	return false
 }
 
+/*------------------------------------------------------------------
+ *
+ * Name:        parse_smartbeaconing
+ *
+ * Purpose:     Parse the SmartBeaconing parameters.
+ *
+ * Inputs:	values	- fast_speed fast_rate slow_speed slow_rate
+ *			  turn_time turn_angle turn_slope.
+ *			  Times are seconds or minutes:seconds.
+ *
+ *		line	- For error messages.
+ *
+ *		sb	- Previous values.  Any that are invalid are left alone.
+ *
+ * Returns:	false if the wrong number of values.
+ *
+ *----------------------------------------------------------------*/
+
+func parse_smartbeaconing(values []string, line int, sb *smartbeacon_s) bool {
+	if len(values) != 7 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: SmartBeaconing needs 7 values: fast_speed fast_rate slow_speed slow_rate turn_time turn_angle turn_slope.\n", line)
+
+		return false
+	}
+
+	var sb_value = func(t string, name string, sbvar *int, minn int, maxx int, unit string, interval bool) {
+		var n int
+		if interval {
+			n = parse_interval(t, line)
+		} else {
+			n, _ = strconv.Atoi(t)
+		}
+
+		if n >= minn && n <= maxx {
+			*sbvar = n
+		} else {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Line %d: Invalid %s for SmartBeaconing. Using default %d %s.\n", line, name, *sbvar, unit)
+		}
+	}
+
+	// Times are minutes:seconds or seconds, not minutes, as for beacons.
+	var sb_time = func(t string) string {
+		if strings.Contains(t, ":") {
+			return t
+		}
+
+		return "0:" + t
+	}
+
+	sb_value(values[0], "fast speed", &sb.fast_speed, 2, 90, "MPH", false)
+	sb_value(sb_time(values[1]), "fast rate", &sb.fast_rate, 10, 300, "seconds", true)
+	sb_value(values[2], "slow speed", &sb.slow_speed, 1, 30, "MPH", false)
+	sb_value(sb_time(values[3]), "slow rate", &sb.slow_rate, 30, 3600, "seconds", true)
+	sb_value(sb_time(values[4]), "turn time", &sb.turn_time, 5, 180, "seconds", true)
+	sb_value(values[5], "turn angle", &sb.turn_angle, 5, 90, "degrees", false)
+	sb_value(values[6], "turn slope", &sb.turn_slope, 1, 255, "deg*mph", false)
+
+	return true
+}
+
 // handleFRACK handles the FRACK keyword.
 func handleFRACK(ps *parseState) bool {
 	/*
@@ -5725,7 +7647,7 @@ func handleFRACK(ps *parseState) bool {
 	/*
 	 * FRACK  n 		- Number of seconds to wait for ack to transmission.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing value for FRACK.\n", ps.line)
@@ -5748,7 +7670,7 @@ func handleRETRY(ps *parseState) bool {
 	/*
 	 * RETRY  n 		- Number of times to retry before giving up.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing value for RETRY.\n", ps.line)
@@ -5771,7 +7693,7 @@ func handlePACLEN(ps *parseState) bool {
 	/*
 	 * PACLEN  n 		- Maximum number of bytes in information part.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing value for PACLEN.\n", ps.line)
@@ -5796,7 +7718,7 @@ func handleMAXFRAME(ps *parseState) bool {
 	 *
 	 * Window size would make more sense but everyone else calls it MAXFRAME.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing value for MAXFRAME.\n", ps.line)
@@ -5822,7 +7744,7 @@ func handleEMAXFRAME(ps *parseState) bool {
 	/*
 	 * EMAXFRAME  n 		- Max frames to send before ACK.  mod 128 "Window" size.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing value for EMAXFRAME.\n", ps.line)
@@ -5848,7 +7770,7 @@ func handleMAXV22(ps *parseState) bool {
 	/*
 	 * MAXV22  n 		- Max number of SABME sent before trying SABM.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing value for MAXV22.\n", ps.line)
@@ -5873,7 +7795,7 @@ func handleV20(ps *parseState) bool {
 	 *					  When connecting to these, skip SABME and go right to SABM.
 	 *					  Possible to have multiple and they are cumulative.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing address(es) for V20.\n", ps.line)
@@ -5895,7 +7817,7 @@ func handleV20(ps *parseState) bool {
 			// continue processing any others following.
 		}
 
-		t = split("", false)
+		t = ps.lex.next(false)
 	}
 	return false
 }
@@ -5908,7 +7830,7 @@ func handleNOXID(ps *parseState) bool {
 	 *					  AX.25 for Linux is the one known case so far.
 	 *					  Possible to have multiple and they are cumulative.
 	 */
-	var t = split("", false)
+	var t = ps.lex.next(false)
 	if t == "" {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Line %d: Missing address(es) for NOXID.\n", ps.line)
@@ -5930,8 +7852,54 @@ func handleNOXID(ps *parseState) bool {
 			// continue processing any others following.
 		}
 
-		t = split("", false)
+		t = ps.lex.next(false)
+	}
+	return false
+}
+
+// handlePBBS handles the PBBS keyword.
+func handlePBBS(ps *parseState) bool {
+	/*
+	 * PBBS  channel  callsign  file	- Mailbox for other stations to connect to.
+	 *					  See pbbs.go.
+	 */
+	var t = ps.lex.next(false)
+
+	var channel, err = strconv.Atoi(t)
+	if err != nil || channel < 0 || channel >= MAX_TOTAL_CHANS {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: PBBS needs a channel, 0 thru %d, not \"%s\".\n", ps.line, MAX_TOTAL_CHANS-1, t)
+
+		return true
+	}
+
+	var call = strings.ToUpper(ps.lex.next(false))
+
+	var _, _, _, ok = ax25_parse_addr(AX25_DESTINATION, call, 2)
+	if !ok {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Invalid callsign \"%s\" for PBBS.\n", ps.line, call)
+
+		return true
+	}
+
+	var file = ps.lex.next(false)
+	if file == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing file name for PBBS messages.\n", ps.line)
+
+		return true
+	}
+
+	if ps.misc.pbbs_mycall != "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: PBBS is replacing an earlier one.  There can be only one.\n", ps.line)
 	}
+
+	ps.misc.pbbs_channel = channel
+	ps.misc.pbbs_mycall = call
+	ps.misc.pbbs_file = file
+
 	return false
 }
 
@@ -5943,7 +7911,7 @@ func handleNOXID(ps *parseState) bool {
 // e.g.  IBEACON DELAY=1 EVERY=1 SENDTO=IG OVERLAY=R SYMBOL="igate" LAT=37^44.46N LONG=122^27.19W COMMENT="N1KOL-1 IGATE"
 // Just ignores overlay, symbol, lat, long, and comment.
 
-func beacon_options(cmd string, b *beacon_s, line int, p_audio_config *audio_s) error { //nolint:unparam
+func beacon_options(lex *configLexer, b *beacon_s, line int, p_audio_config *audio_s) error { //nolint:unparam
 	b.sendto_type = SENDTO_XMIT
 	b.sendto_chan = 0
 	b.delay = 60
@@ -5969,7 +7937,7 @@ func beacon_options(cmd string, b *beacon_s, line int, p_audio_config *audio_s)
 	var northing float64 = G_UNKNOWN
 
 	for {
-		var t = split("", false)
+		var t = lex.next(false)
 		if t == "" {
 			break
 		}
@@ -6024,6 +7992,18 @@ func beacon_options(cmd string, b *beacon_s, line int, p_audio_config *audio_s)
 		// end
 		if strings.EqualFold(keyword, "DELAY") {
 			b.delay = parse_interval(value, line)
+		} else if strings.EqualFold(keyword, "AFTER") {
+			switch {
+			case strings.EqualFold(value, "GPS"):
+				b.after = BEACON_AFTER_GPS
+			case strings.EqualFold(value, "IG") || strings.EqualFold(value, "IGATE"):
+				b.after = BEACON_AFTER_IGATE
+			default:
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file, line %d: AFTER should be GPS or IG, not \"%s\".\n", line, value)
+			}
+		} else if strings.EqualFold(keyword, "JITTER") {
+			b.jitter = parse_interval(value, line)
 		} else if strings.EqualFold(keyword, "SLOT") {
 			var n = parse_interval(value, line)
 			if n < 1 || n > 3600 {
@@ -6037,83 +8017,103 @@ func beacon_options(cmd string, b *beacon_s, line int, p_audio_config *audio_s)
 		} else if strings.EqualFold(keyword, "EVERY") {
 			b.every = parse_interval(value, line)
 		} else if strings.EqualFold(keyword, "SENDTO") {
-			if len(value) == 0 {
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Config file, line %d: Missing value for SENDTO option.\n", line)
+			// A radio channel, IG, or both, e.g. SENDTO=0,IG
+			var igate = false
+			var radio = 0
 
-				continue
-			} else if value[0] == 'i' || value[0] == 'I' {
-				b.sendto_type = SENDTO_IGATE
-				b.sendto_chan = 0
-			} else if value[0] == 'r' || value[0] == 'R' {
-				var n, nErr = strconv.Atoi(value[1:])
-				if nErr != nil {
+			for _, part := range strings.Split(value, ",") {
+				if len(part) == 0 {
 					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file, line %d: Non-numeric channel \"%s\" for SENDTO=r option.\n", line, value[1:])
+					dw_printf("Config file, line %d: Missing value for SENDTO option.\n", line)
 
 					continue
-				}
-				if n < 0 || n >= MAX_TOTAL_CHANS {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file, line %d: Simulated receive on channel %d is not valid.\n", line, n)
+				} else if part[0] == 'i' || part[0] == 'I' {
+					igate = true
+				} else if part[0] == 'r' || part[0] == 'R' {
+					var n, nErr = strconv.Atoi(part[1:])
+					if nErr != nil {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Config file, line %d: Non-numeric channel \"%s\" for SENDTO=r option.\n", line, part[1:])
 
-					continue
-				}
-				if p_audio_config.chan_medium[n] == MEDIUM_NONE {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file, line %d: Simulated receive on channel %d is not valid.\n", line, n)
+						continue
+					}
+					if n < 0 || n >= MAX_TOTAL_CHANS {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Config file, line %d: Simulated receive on channel %d is not valid.\n", line, n)
 
-					continue
-				}
+						continue
+					}
+					if p_audio_config.chan_medium[n] == MEDIUM_NONE {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Config file, line %d: Simulated receive on channel %d is not valid.\n", line, n)
 
-				b.sendto_type = SENDTO_RECV
-				b.sendto_chan = n
-			} else if value[0] == 't' || value[0] == 'T' || value[0] == 'x' || value[0] == 'X' {
-				var n, nErr = strconv.Atoi(value[1:])
-				if nErr != nil {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file, line %d: Non-numeric channel \"%s\" for SENDTO=t option.\n", line, value[1:])
+						continue
+					}
 
-					continue
-				}
-				if n < 0 || n >= MAX_TOTAL_CHANS {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file, line %d: Send to channel %d is not valid.\n", line, n)
+					b.sendto_type = SENDTO_RECV
+					b.sendto_chan = n
+					radio++
+				} else if part[0] == 't' || part[0] == 'T' || part[0] == 'x' || part[0] == 'X' {
+					var n, nErr = strconv.Atoi(part[1:])
+					if nErr != nil {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Config file, line %d: Non-numeric channel \"%s\" for SENDTO=t option.\n", line, part[1:])
 
-					continue
-				}
-				if p_audio_config.chan_medium[n] == MEDIUM_NONE {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file, line %d: Send to channel %d is not valid.\n", line, n)
+						continue
+					}
+					if n < 0 || n >= MAX_TOTAL_CHANS {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Config file, line %d: Send to channel %d is not valid.\n", line, n)
 
-					continue
-				}
+						continue
+					}
+					if p_audio_config.chan_medium[n] == MEDIUM_NONE {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Config file, line %d: Send to channel %d is not valid.\n", line, n)
 
-				b.sendto_type = SENDTO_XMIT
-				b.sendto_chan = n
-			} else {
-				var n, nErr = strconv.Atoi(value)
-				if nErr != nil {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file, line %d: Non-numeric channel \"%s\" for SENDTO option.\n", line, value)
+						continue
+					}
 
-					continue
-				}
-				if n < 0 || n >= MAX_TOTAL_CHANS {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file, line %d: Send to channel %d is not valid.\n", line, n)
+					b.sendto_type = SENDTO_XMIT
+					b.sendto_chan = n
+					radio++
+				} else {
+					var n, nErr = strconv.Atoi(part)
+					if nErr != nil {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Config file, line %d: Non-numeric channel \"%s\" for SENDTO option.\n", line, part)
 
-					continue
-				}
-				if p_audio_config.chan_medium[n] == MEDIUM_NONE {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Config file, line %d: Send to channel %d is not valid.\n", line, n)
+						continue
+					}
+					if n < 0 || n >= MAX_TOTAL_CHANS {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Config file, line %d: Send to channel %d is not valid.\n", line, n)
 
-					continue
+						continue
+					}
+					if p_audio_config.chan_medium[n] == MEDIUM_NONE {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Config file, line %d: Send to channel %d is not valid.\n", line, n)
+
+						continue
+					}
+
+					b.sendto_type = SENDTO_XMIT
+					b.sendto_chan = n
+					radio++
 				}
+			}
+
+			if radio > 1 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file, line %d: SENDTO can have only one channel, and IG.\n", line)
+			}
 
-				b.sendto_type = SENDTO_XMIT
-				b.sendto_chan = n
+			if igate && radio > 0 {
+				b.also_igate = true
+			} else if igate {
+				b.sendto_type = SENDTO_IGATE
+				b.sendto_chan = 0
 			}
 		} else if strings.EqualFold(keyword, "SOURCE") {
 			b.source = strings.ToUpper(value) /* silently force upper case. */
@@ -6243,14 +8243,31 @@ func beacon_options(cmd string, b *beacon_s, line int, p_audio_config *audio_s)
 			b.offset = f
 		} else if strings.EqualFold(keyword, "COMMENT") {
 			b.comment = value
+		} else if strings.EqualFold(keyword, "RFCOMMENT") {
+			b.rf_comment = value
+		} else if strings.EqualFold(keyword, "ISCOMMENT") {
+			b.is_comment = value
 		} else if strings.EqualFold(keyword, "COMMENTCMD") {
 			b.commentcmd = value
+		} else if strings.EqualFold(keyword, "RIGINFO") {
+			var n, _ = strconv.Atoi(value)
+			b.riginfo = n != 0
 		} else if strings.EqualFold(keyword, "COMPRESS") || strings.EqualFold(keyword, "COMPRESSED") {
 			var n, _ = strconv.Atoi(value)
 			b.compress = n != 0
 		} else if strings.EqualFold(keyword, "MESSAGING") {
 			var n, _ = strconv.Atoi(value)
 			b.messaging = n != 0
+		} else if strings.EqualFold(keyword, "MAXHDOP") {
+			var f, err = strconv.ParseFloat(value, 64)
+			if err != nil || f <= 0 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Config file, line %d: MAXHDOP must be a positive number, not \"%s\".\n", line, value)
+			} else {
+				b.max_hdop = f
+			}
+		} else if strings.EqualFold(keyword, "NOFIX") {
+			b.nofix = value
 		} else {
 			text_color_set(DW_COLOR_ERROR)
 			dw_printf("Config file, line %d: Invalid option keyword, %s.\n", line, keyword)
@@ -6264,6 +8281,18 @@ func beacon_options(cmd string, b *beacon_s, line int, p_audio_config *audio_s)
 		dw_printf("Config file, line %d: Can't use both INFO and INFOCMD at the same time.\n", line)
 	}
 
+	if b.jitter != 0 && b.slot != G_UNKNOWN {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file, line %d: JITTER can't be used with SLOT.\n", line)
+
+		b.jitter = 0
+	}
+
+	if b.btype != BEACON_TRACKER && (b.max_hdop > 0 || b.nofix != "") {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Config file, line %d: MAXHDOP and NOFIX are only for TBEACON.\n", line)
+	}
+
 	if b.compress && b.ambiguity != 0 {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Config file, line %d: Position ambiguity can't be used with compressed location format.\n", line)
@@ -6278,19 +8307,10 @@ func beacon_options(cmd string, b *beacon_s, line int, p_audio_config *audio_s)
 		if len(zone) > 0 && easting != G_UNKNOWN && northing != G_UNKNOWN {
 			var _, _hemi, lzone = parse_utm_zone(zone)
 
-			var hemi = HemisphereRuneToCoordconvHemisphere(_hemi)
-
-			var utm = coordconv.UTMCoord{
-				Zone:       lzone,
-				Hemisphere: hemi,
-				Easting:    float64(easting),
-				Northing:   float64(northing),
-			}
-
-			var geo, geoErr = coordconv.DefaultUTMConverter.ConvertToGeodetic(utm)
+			var lat, lon, geoErr = geo.FromUTM(lzone, geo.HemisphereFromRune(_hemi), float64(easting), float64(northing))
 			if geoErr == nil {
-				b.lat = R2D(float64(geo.Lat))
-				b.lon = R2D(float64(geo.Lng))
+				b.lat = lat
+				b.lon = lon
 			} else {
 				text_color_set(DW_COLOR_ERROR)
 				dw_printf("Line %d: Invalid UTM location: \n%s\n", line, geoErr)
@@ -0,0 +1,133 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	One place for configuration options that are on their
+ *		way out.
+ *
+ * Description:	Each handler for a legacy form calls config_deprecated
+ *		rather than printing its own message, so every warning
+ *		says the same things: what was used, what to use
+ *		instead, and when it will stop working.
+ *
+ *		Releases are numbered by date, year.month.day, so the
+ *		removal is given as year.month: the first release made
+ *		in that month or later won't have the option.
+ *
+ *		A summary is given after the whole file has been read so
+ *		the warnings aren't lost among everything else printed
+ *		at startup.
+ *
+ *		To retire an option: add it here, call config_deprecated
+ *		from its handler, and when it is finally removed delete
+ *		both.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type configDeprecation struct {
+	what        string // The legacy form, as the user would recognise it.
+	replacement string // What to use instead.
+	removal     string // Release, year.month, which will no longer have it.
+}
+
+var configDeprecations = map[string]configDeprecation{
+	"MODEM_OLD_STYLE": {
+		what:        "Old style (pre Dire Wolf 1.2) MODEM options \"baud mark space ...\"",
+		replacement: "MODEM speed mark:space [profile] [num@offset], e.g. \"MODEM 1200 1200:2200 A\"",
+		removal:     "2027.07",
+	},
+	"DWAIT": {
+		what:        "DWAIT",
+		replacement: "a larger TXDELAY (both are in 10 ms units)",
+		removal:     "2027.01",
+	},
+	"PAIDEVICE": {
+		what:        "PAIDEVICE",
+		replacement: "ADEVICE, quoting a name that contains spaces, e.g. ADEVICE \"USB Audio CODEC\" default",
+		removal:     "2027.01",
+	},
+	"PAODEVICE": {
+		what:        "PAODEVICE",
+		replacement: "the second name of ADEVICE, e.g. ADEVICE default \"USB Audio CODEC\"",
+		removal:     "2027.01",
+	},
+	"SATGATE": {
+		what:        "SATGATE",
+		replacement: "nothing; it only delayed packets heard directly and was of little use",
+		removal:     "2027.01",
+	},
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        config_deprecated
+ *
+ * Purpose:     Warn that a legacy form was used on the current line.
+ *
+ * Inputs:	ps	- Parse state, for the line number and to
+ *			  remember it for the summary.
+ *
+ *		key	- Entry in configDeprecations.
+ *
+ * Description:	An unknown key is a mistake in the caller, but the
+ *		option itself is still handled, so it only gets a
+ *		message rather than stopping the configuration from
+ *		being read.
+ *
+ *--------------------------------------------------------------------*/
+
+func config_deprecated(ps *parseState, key string) {
+	var d, found = configDeprecations[key]
+	if !found {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: INTERNAL ERROR: No deprecation details for %s.  Please report this.\n", ps.line, key)
+
+		return
+	}
+
+	text_color_set(DW_COLOR_ERROR)
+	dw_printf("Line %d: %s is deprecated and will be removed in release %s.\n", ps.line, d.what, d.removal)
+	dw_printf("Use %s instead.\n", d.replacement)
+
+	if ps.deprecated == nil {
+		ps.deprecated = make(map[string][]int)
+	}
+
+	ps.deprecated[key] = append(ps.deprecated[key], ps.line)
+}
+
+// config_deprecation_summary repeats, briefly, the deprecated options that were used.
+func config_deprecation_summary(ps *parseState) {
+	if len(ps.deprecated) == 0 {
+		return
+	}
+
+	var keys = make([]string, 0, len(ps.deprecated))
+	for key := range ps.deprecated {
+		keys = append(keys, key)
+	}
+
+	sort.Strings(keys)
+
+	text_color_set(DW_COLOR_ERROR)
+	dw_printf("\nThe configuration file uses deprecated options which will be removed:\n")
+
+	for _, key := range keys {
+		var lines = make([]string, 0, len(ps.deprecated[key]))
+		for _, line := range ps.deprecated[key] {
+			lines = append(lines, strconv.Itoa(line))
+		}
+
+		var d = configDeprecations[key]
+
+		dw_printf("    %s (line %s), in release %s\n", d.what, strings.Join(lines, ", "), d.removal)
+	}
+
+	dw_printf("\n")
+}
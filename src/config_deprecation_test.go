@@ -0,0 +1,63 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_configDeprecations_complete(t *testing.T) {
+	for key, d := range configDeprecations {
+		assert.NotEmpty(t, d.what, key)
+		assert.NotEmpty(t, d.replacement, key)
+		assert.Regexp(t, `^20[0-9][0-9]\.(0[1-9]|1[0-2])$`, d.removal, key)
+	}
+}
+
+func Test_config_deprecated_says_when(t *testing.T) {
+	var ps = new(parseState)
+	ps.line = 4
+
+	AssertOutputContains(t, func() { config_deprecated(ps, "DWAIT") },
+		"Line 4: DWAIT is deprecated and will be removed in release "+configDeprecations["DWAIT"].removal+".\n")
+
+	AssertOutputContains(t, func() { config_deprecation_summary(ps) },
+		"    DWAIT (line 4), in release "+configDeprecations["DWAIT"].removal+"\n")
+}
+
+func Test_config_deprecated_records_lines(t *testing.T) {
+	var ps = new(parseState)
+
+	ps.line = 3
+	config_deprecated(ps, "DWAIT")
+	ps.line = 9
+	config_deprecated(ps, "DWAIT")
+	ps.line = 12
+	config_deprecated(ps, "SATGATE")
+
+	assert.Equal(t, map[string][]int{"DWAIT": {3, 9}, "SATGATE": {12}}, ps.deprecated)
+
+	assert.NotPanics(t, func() { config_deprecation_summary(ps) })
+}
+
+func Test_config_deprecated_unknown_key(t *testing.T) {
+	var ps = new(parseState)
+	ps.line = 7
+
+	assert.NotPanics(t, func() { config_deprecated(ps, "NOSUCHTHING") })
+	assert.Empty(t, ps.deprecated)
+}
+
+func Test_config_deprecated_options_still_work(t *testing.T) {
+	// Deprecated doesn't mean ignored, not until the option is removed.
+	var audio, _ = configFromString(t,
+		"DWAIT 5\n"+
+			"MODEM 1200 1000 2000\n"+
+			"PAIDEVICE USB Audio CODEC\n",
+	)
+
+	assert.Equal(t, 5, audio.achan[0].dwait)
+	assert.Equal(t, 1000, audio.achan[0].mark_freq)
+	assert.Equal(t, 2000, audio.achan[0].space_freq)
+	assert.Equal(t, "USB Audio CODEC", audio.adev[0].adevice_in)
+}
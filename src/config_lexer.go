@@ -0,0 +1,130 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Split configuration file lines into tokens.
+ *
+ * Description:	This replaces the original split() function which kept
+ *		the remainder of the line in a package level variable
+ *		and had its quoting rules spread across the code.
+ *
+ *		Rules:
+ *
+ *		- Tokens are separated by spaces and/or tabs.
+ *
+ *		- Double quotes group text containing spaces into a
+ *		  single token.  The quotes themselves are removed.
+ *		  Quotes can appear anywhere in a token, e.g.
+ *		  comment="Hello world" gives comment=Hello world.
+ *
+ *		- Inside quotes, a quotation mark can be written as
+ *		  either "" (for compatibility with Dire Wolf) or \".
+ *		  A backslash can be written as \\.  Any other backslash
+ *		  is taken literally, as is any backslash outside quotes,
+ *		  because '\' is the APRS alternate symbol table.
+ *
+ *		- A '#' at the start of a token, followed by whitespace
+ *		  or the end of the line, begins a trailing comment and
+ *		  the rest of the line is ignored.  A '#' elsewhere, such
+ *		  as symbol=# or a DTMF sequence, is an ordinary character.
+ *
+ *		- CR and LF are discarded.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"strings"
+)
+
+type configLexer struct {
+	rest string // Unconsumed part of the line.
+}
+
+// newConfigLexer prepares a line from the configuration file for tokenizing.
+func newConfigLexer(line string) *configLexer {
+	var l = new(configLexer)
+
+	l.rest = strings.Map(func(r rune) rune {
+		switch r {
+		case '\t':
+			return ' '
+		case '\r', '\n':
+			return -1
+		default:
+			return r
+		}
+	}, line)
+
+	return l
+}
+
+// next returns the next token with quoting removed, or "" if there are none left.
+// If restOfLine is true, the remainder of the line is returned as a single
+// token, with quoting still removed and any trailing comment dropped.
+func (l *configLexer) next(restOfLine bool) string {
+	l.rest = strings.TrimLeft(l.rest, " ")
+
+	if l.isComment() {
+		l.rest = ""
+
+		return ""
+	}
+
+	var token strings.Builder
+
+	var inQuotes = false
+
+	var i = 0
+
+loop:
+	for i < len(l.rest) {
+		var c = l.rest[i]
+
+		switch {
+		case c == '"' && !inQuotes:
+			inQuotes = true
+		case c == '"' && i+1 < len(l.rest) && l.rest[i+1] == '"':
+			token.WriteByte('"')
+			i++
+		case c == '"':
+			inQuotes = false
+		case c == '\\' && inQuotes && i+1 < len(l.rest) && (l.rest[i+1] == '"' || l.rest[i+1] == '\\'):
+			token.WriteByte(l.rest[i+1])
+			i++
+		case c == ' ' && !inQuotes:
+			if !restOfLine {
+				break loop
+			}
+
+			// Keep the space unless what follows is a trailing comment.
+			var j = i
+			for j < len(l.rest) && l.rest[j] == ' ' {
+				j++
+			}
+
+			if j == len(l.rest) || (l.rest[j] == '#' && (j+1 == len(l.rest) || l.rest[j+1] == ' ')) {
+				i = len(l.rest)
+
+				break loop
+			}
+
+			token.WriteString(l.rest[i:j])
+			i = j
+
+			continue
+		default:
+			token.WriteByte(c)
+		}
+
+		i++
+	}
+
+	l.rest = l.rest[i:]
+
+	return token.String()
+}
+
+// isComment reports whether the unconsumed text starts with a trailing comment.
+func (l *configLexer) isComment() bool {
+	return strings.HasPrefix(l.rest, "#") && (len(l.rest) == 1 || l.rest[1] == ' ')
+}
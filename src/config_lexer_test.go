@@ -0,0 +1,185 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// lexAll tokenizes a whole line.  If restOfLineAfter is >= 0, the token at
+// that position (and everything after it) is read as the rest of the line.
+func lexAll(line string, restOfLineAfter int) []string {
+	var l = newConfigLexer(line)
+
+	var tokens []string
+
+	for {
+		var t = l.next(restOfLineAfter >= 0 && len(tokens) >= restOfLineAfter)
+		if t == "" {
+			return tokens
+		}
+
+		tokens = append(tokens, t)
+	}
+}
+
+func Test_configLexer_rules(t *testing.T) {
+	tests := []struct {
+		name      string
+		line      string
+		restAfter int
+		want      []string
+	}{
+		{"empty line", "", -1, nil},
+		{"only spaces", "   \t  ", -1, nil},
+		{"simple tokens", "hello world", -1, []string{"hello", "world"}},
+		{"tabs and runs of spaces", "\thello \t  world\t", -1, []string{"hello", "world"}},
+		{"CRLF dropped", "hello world\r\n", -1, []string{"hello", "world"}},
+		{"quoted token", `"hello world"`, -1, []string{"hello world"}},
+		{"quotes mid token", `comment="hello world" x`, -1, []string{"comment=hello world", "x"}},
+		{"doubled quote inside quotes", `"say ""hi"""`, -1, []string{`say "hi"`}},
+		{"backslash quote inside quotes", `"say \"hi\""`, -1, []string{`say "hi"`}},
+		{"backslash backslash inside quotes", `"a\\b"`, -1, []string{`a\b`}},
+		{"other backslash inside quotes literal", `"C:\dir"`, -1, []string{`C:\dir`}},
+		{"backslash outside quotes literal", `symbol=\k`, -1, []string{`symbol=\k`}},
+		{"unterminated quote runs to end", `"hello world`, -1, []string{"hello world"}},
+		{"trailing comment", "MYCALL Q1TEST # my call", -1, []string{"MYCALL", "Q1TEST"}},
+		{"trailing comment no text", "MYCALL Q1TEST #", -1, []string{"MYCALL", "Q1TEST"}},
+		{"hash inside token", "symbol=# x#y", -1, []string{"symbol=#", "x#y"}},
+		{"hash token not comment", "TTMACRO #1 B9", -1, []string{"TTMACRO", "#1", "B9"}},
+		{"quoted hash not comment", `info="# not a comment"`, -1, []string{"info=# not a comment"}},
+		{"leading whitespace comment", "   # indented comment", -1, nil},
+		{"rest of line", "IGFILTER m/50 t/p", 1, []string{"IGFILTER", "m/50 t/p"}},
+		{"rest of line keeps inner spacing", "X a  b", 1, []string{"X", "a  b"}},
+		{"rest of line drops trailing spaces", "X a b   ", 1, []string{"X", "a b"}},
+		{"rest of line drops trailing comment", "X a b # note", 1, []string{"X", "a b"}},
+		{"rest of line removes quotes", `X "a b" c`, 1, []string{"X", "a b c"}},
+		{"utf-8 preserved", `comment="mañana °"`, -1, []string{"comment=mañana °"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, lexAll(tt.line, tt.restAfter))
+		})
+	}
+}
+
+// Test_configLexer_keywords covers the argument shapes used by each configuration keyword.
+// restAfter is the position of the first token the handler reads with restOfLine set, or -1.
+func Test_configLexer_keywords(t *testing.T) {
+	tests := []struct {
+		line      string
+		restAfter int
+		want      []string
+	}{
+		{"ADEVICE plughw:1,0", -1, []string{"ADEVICE", "plughw:1,0"}},
+		{"ADEVICE1 \"USB Audio CODEC\" plughw:2,0", -1, []string{"ADEVICE1", "USB Audio CODEC", "plughw:2,0"}},
+		{"ADEVICE default udp:localhost:7355", -1, []string{"ADEVICE", "default", "udp:localhost:7355"}},
+		{"PAIDEVICE 0", 1, []string{"PAIDEVICE", "0"}},
+		{"PAODEVICE \"Speakers (USB)\"", 1, []string{"PAODEVICE", "Speakers (USB)"}},
+		{"ARATE 48000", -1, []string{"ARATE", "48000"}},
+		{"ACHANNELS 2", -1, []string{"ACHANNELS", "2"}},
+		{"CHANNEL 1", -1, []string{"CHANNEL", "1"}},
+		{"ICHANNEL 3", -1, []string{"ICHANNEL", "3"}},
+		{"NCHANNEL 4 127.0.0.1 8001", -1, []string{"NCHANNEL", "4", "127.0.0.1", "8001"}},
+		{"MYCALL Q1TEST-9", -1, []string{"MYCALL", "Q1TEST-9"}},
+		{"MODEM 1200 1200:2200 7@30 /4 E+", -1, []string{"MODEM", "1200", "1200:2200", "7@30", "/4", "E+"}},
+		{"MODEM 9600", -1, []string{"MODEM", "9600"}},
+		{"DTMF", -1, []string{"DTMF"}},
+		{"FIX_BITS 1 AX25 PASSALL", -1, []string{"FIX_BITS", "1", "AX25", "PASSALL"}},
+		{"PTT /dev/ttyUSB0 RTS -DTR", -1, []string{"PTT", "/dev/ttyUSB0", "RTS", "-DTR"}},
+		{"PTT GPIO -25", -1, []string{"PTT", "GPIO", "-25"}},
+		{"PTT GPIOD gpiochip0 25", -1, []string{"PTT", "GPIOD", "gpiochip0", "25"}},
+		{"PTT CM108 /dev/hidraw1 3", -1, []string{"PTT", "CM108", "/dev/hidraw1", "3"}},
+		{"PTT RIG 2 localhost:4532", -1, []string{"PTT", "RIG", "2", "localhost:4532"}},
+		{"DCD GPIO 24", -1, []string{"DCD", "GPIO", "24"}},
+		{"CON GPIO 23", -1, []string{"CON", "GPIO", "23"}},
+		{"TXINH GPIO -18", -1, []string{"TXINH", "GPIO", "-18"}},
+		{"DWAIT 0", -1, []string{"DWAIT", "0"}},
+		{"SLOTTIME 10", -1, []string{"SLOTTIME", "10"}},
+		{"PERSIST 63", -1, []string{"PERSIST", "63"}},
+		{"TXDELAY 30", -1, []string{"TXDELAY", "30"}},
+		{"TXTAIL 10", -1, []string{"TXTAIL", "10"}},
+		{"FULLDUP ON", -1, []string{"FULLDUP", "ON"}},
+		{"SPEECH ./dwespeak.sh", 1, []string{"SPEECH", "./dwespeak.sh"}},
+		{"FX25TX 16", -1, []string{"FX25TX", "16"}},
+		{"FX25AUTO 5", -1, []string{"FX25AUTO", "5"}},
+		{"IL2PTX 1 +", -1, []string{"IL2PTX", "1", "+"}},
+		{"DIGIPEAT 0 0 ^WIDE[3-7]-[1-7]$|^TEST$ ^WIDE[12]-[12]$ TRACE", -1, []string{"DIGIPEAT", "0", "0", "^WIDE[3-7]-[1-7]$|^TEST$", "^WIDE[12]-[12]$", "TRACE"}},
+		{"DIGIPEAT 0 1 ^WIDE[12]-[12]$ ^WIDE[12]-[12]$ ATGP=HOP", -1, []string{"DIGIPEAT", "0", "1", "^WIDE[12]-[12]$", "^WIDE[12]-[12]$", "ATGP=HOP"}},
+		{"DEDUPE 30", -1, []string{"DEDUPE", "30"}},
+		{"REGEN 0 1", -1, []string{"REGEN", "0", "1"}},
+		{"CDIGIPEAT 0 1 ^Q1TEST", -1, []string{"CDIGIPEAT", "0", "1", "^Q1TEST"}},
+		{"FILTER 0 0 t/m | b/Q1TEST", 3, []string{"FILTER", "0", "0", "t/m | b/Q1TEST"}},
+		{"FILTER IG 0 ( t/p & r/42/-71/50 )", 3, []string{"FILTER", "IG", "0", "( t/p & r/42/-71/50 )"}},
+		{"CFILTER 0 0 ! d/Q1TEST", 3, []string{"CFILTER", "0", "0", "! d/Q1TEST"}},
+		{"TTCORRAL 37^55.50N 81^7.00W 0^0.02N", -1, []string{"TTCORRAL", "37^55.50N", "81^7.00W", "0^0.02N"}},
+		{"TTPOINT B01 37^55.37N 81^7.86W", -1, []string{"TTPOINT", "B01", "37^55.37N", "81^7.86W"}},
+		{"TTVECTOR B5bbbddd 37^55.37N 81^7.86W 0.01 mi", -1, []string{"TTVECTOR", "B5bbbddd", "37^55.37N", "81^7.86W", "0.01", "mi"}},
+		{"TTGRID Byyyxxx 37^50.00N 81^00.00W 37^59.99N 81^09.99W", -1, []string{"TTGRID", "Byyyxxx", "37^50.00N", "81^00.00W", "37^59.99N", "81^09.99W"}},
+		{"TTUTM B6xxxyyy 19T 10 300000 4720000", -1, []string{"TTUTM", "B6xxxyyy", "19T", "10", "300000", "4720000"}},
+		{"TTUSNG B7xxxyyy 19TCH", -1, []string{"TTUSNG", "B7xxxyyy", "19TCH"}},
+		{"TTMGRS B7xxxyyy 19TCH", -1, []string{"TTMGRS", "B7xxxyyy", "19TCH"}},
+		{"TTMHEAD BAxxxxxx FN42", -1, []string{"TTMHEAD", "BAxxxxxx", "FN42"}},
+		{"TTSATSQ BAxxxx", -1, []string{"TTSATSQ", "BAxxxx"}},
+		{"TTAMBIG AB", -1, []string{"TTAMBIG", "AB"}},
+		{"TTMACRO xx1yy B9xx*AB166*AA2B4C5B3B0Ayy", -1, []string{"TTMACRO", "xx1yy", "B9xx*AB166*AA2B4C5B3B0Ayy"}},
+		{"TTOBJ 0 WIDE1-1", -1, []string{"TTOBJ", "0", "WIDE1-1"}},
+		{"TTERR OK SPEECH Message Received.", 2, []string{"TTERR", "OK", "SPEECH Message Received."}},
+		{"TTSTATUS 1 \"/off duty\"", 2, []string{"TTSTATUS", "1", "/off duty"}},
+		{"TTCMD ./tt_command.sh", 1, []string{"TTCMD", "./tt_command.sh"}},
+		{"IGSERVER noam.aprs2.net:14580", -1, []string{"IGSERVER", "noam.aprs2.net:14580"}},
+		{"IGSERVER noam.aprs2.net 14580", -1, []string{"IGSERVER", "noam.aprs2.net", "14580"}},
+		{"IGLOGIN Q1TEST-10 12345", -1, []string{"IGLOGIN", "Q1TEST-10", "12345"}},
+		{"IGTXVIA 0 WIDE1-1,WIDE2-1", -1, []string{"IGTXVIA", "0", "WIDE1-1,WIDE2-1"}},
+		{"IGFILTER m/50 t/p", 1, []string{"IGFILTER", "m/50 t/p"}},
+		{"IGTXLIMIT 6 10", -1, []string{"IGTXLIMIT", "6", "10"}},
+		{"IGMSP 1", -1, []string{"IGMSP", "1"}},
+		{"SATGATE 10", -1, []string{"SATGATE", "10"}},
+		{"AGWPORT 8000", -1, []string{"AGWPORT", "8000"}},
+		{"KISSPORT 7001 1", -1, []string{"KISSPORT", "7001", "1"}},
+		{"NULLMODEM /dev/rfcomm0 9600", -1, []string{"NULLMODEM", "/dev/rfcomm0", "9600"}},
+		{"SERIALKISSPOLL 5", -1, []string{"SERIALKISSPOLL", "5"}},
+		{"KISSCOPY", -1, []string{"KISSCOPY"}},
+		{"DNSSD 1", -1, []string{"DNSSD", "1"}},
+		{"DNSSDNAME \"Samoyed on my Pi\"", 1, []string{"DNSSDNAME", "Samoyed on my Pi"}},
+		{"GPSNMEA /dev/ttyACM0 4800", -1, []string{"GPSNMEA", "/dev/ttyACM0", "4800"}},
+		{"GPSD localhost:2947", -1, []string{"GPSD", "localhost:2947"}},
+		{"WAYPOINT udp:localhost:10110 GK", -1, []string{"WAYPOINT", "udp:localhost:10110", "GK"}},
+		{"LOGDIR /var/log/samoyed", -1, []string{"LOGDIR", "/var/log/samoyed"}},
+		{"LOGFILE \"/var/log/my packets.log\"", -1, []string{"LOGFILE", "/var/log/my packets.log"}},
+		{"PBEACON delay=1 every=30 overlay=S symbol=\"digi\" lat=42^37.14N long=071^20.83W comment=\"Chelmsford MA\"", -1,
+			[]string{"PBEACON", "delay=1", "every=30", "overlay=S", "symbol=digi", "lat=42^37.14N", "long=071^20.83W", "comment=Chelmsford MA"}},
+		{"OBEACON objname=\"Net\" symbol=/# sendto=IG", -1, []string{"OBEACON", "objname=Net", "symbol=/#", "sendto=IG"}},
+		{"TBEACON every=1:00 via=WIDE1-1 symbol=\\k", -1, []string{"TBEACON", "every=1:00", "via=WIDE1-1", "symbol=\\k"}},
+		{"CBEACON delay=0:10 info=\">Hello, \"\"world\"\"\"", -1, []string{"CBEACON", "delay=0:10", `info=>Hello, "world"`}},
+		{"CBEACON infocmd=\"telem-volts.py /dev/ttyUSB0\"", -1, []string{"CBEACON", "infocmd=telem-volts.py /dev/ttyUSB0"}},
+		{"IBEACON sendto=0 delay=1 every=60", -1, []string{"IBEACON", "sendto=0", "delay=1", "every=60"}},
+		{"SMARTBEACONING 60 1:30 5 15:00 0:15 30 255", -1, []string{"SMARTBEACONING", "60", "1:30", "5", "15:00", "0:15", "30", "255"}},
+		{"FRACK 4", -1, []string{"FRACK", "4"}},
+		{"RETRY 10", -1, []string{"RETRY", "10"}},
+		{"PACLEN 128", -1, []string{"PACLEN", "128"}},
+		{"MAXFRAME 4", -1, []string{"MAXFRAME", "4"}},
+		{"EMAXFRAME 8", -1, []string{"EMAXFRAME", "8"}},
+		{"MAXV22 3", -1, []string{"MAXV22", "3"}},
+		{"V20 Q1TEST Q2TEST-5", -1, []string{"V20", "Q1TEST", "Q2TEST-5"}},
+		{"NOXID Q1TEST", -1, []string{"NOXID", "Q1TEST"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.line, func(t *testing.T) {
+			assert.Equal(t, tt.want, lexAll(tt.line, tt.restAfter))
+		})
+	}
+}
+
+func Test_configLexer_mixed_modes(t *testing.T) {
+	// Handlers often read a few tokens and then ask for the rest of the line.
+	var l = newConfigLexer(`TTERR D_MSG SPEECH "Hello ""there""" # ignored`)
+
+	assert.Equal(t, "TTERR", l.next(false))
+	assert.Equal(t, "D_MSG", l.next(false))
+	assert.Equal(t, `SPEECH Hello "there"`, l.next(true))
+	assert.Empty(t, l.next(false))
+	assert.Empty(t, l.next(true))
+}
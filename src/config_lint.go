@@ -0,0 +1,187 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Look for common configuration mistakes.
+ *
+ * Description:	config_init already rejects things that can't work.
+ *		These are heuristics for things that are accepted but
+ *		are probably not what was intended, e.g. a sample
+ *		configuration that was only partly edited.
+ *
+ *		Each warning says what looks wrong and what to change.
+ *		Nothing in the configuration is modified here.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"strings"
+)
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        config_lint
+ *
+ * Purpose:     Heuristic checks on the configuration after the whole
+ *		file has been read.
+ *
+ * Inputs:	ps	- Parse state with all of the configuration.
+ *
+ * Returns:	Warnings, in a stable order, for config_init to display.
+ *
+ *--------------------------------------------------------------------*/
+
+func config_lint(ps *parseState) []string {
+	var warnings []string
+
+	warnings = append(warnings, lint_igate_mycall(ps)...)
+	warnings = append(warnings, lint_ptt(ps)...)
+	warnings = append(warnings, lint_ports(ps)...)
+	warnings = append(warnings, lint_beacon_position(ps)...)
+
+	return warnings
+}
+
+// lint_igate_mycall catches IGLOGIN being set while a radio channel
+// still has no callsign, which would otherwise quietly disable the IGate.
+func lint_igate_mycall(ps *parseState) []string {
+	var warnings []string
+
+	if ps.igate.t2_login == "" {
+		return nil
+	}
+
+	for ch := range MAX_TOTAL_CHANS {
+		if ps.audio.chan_medium[ch] != MEDIUM_RADIO && ps.audio.chan_medium[ch] != MEDIUM_NETTNC {
+			continue
+		}
+
+		if IsNoCall(ps.audio.mycall[ch]) {
+			warnings = append(warnings, fmt.Sprintf(
+				"IGLOGIN %s is set but MYCALL for channel %d is missing or still N0CALL.\n"+
+					"Put \"MYCALL %s\" (or another callsign) after \"CHANNEL %d\", otherwise the IGate will be disabled.",
+				ps.igate.t2_login, ch, ps.igate.t2_login, ch))
+		}
+	}
+
+	return warnings
+}
+
+// lint_ptt catches radio channels which are expected to transmit
+// but have no PTT method configured.
+func lint_ptt(ps *parseState) []string {
+	var warnings []string
+
+	for ch := range MAX_RADIO_CHANS {
+		if ps.audio.chan_medium[ch] != MEDIUM_RADIO {
+			continue
+		}
+
+		if ps.audio.achan[ch].octrl[OCTYPE_PTT].ptt_method != PTT_METHOD_NONE {
+			continue
+		}
+
+		var reasons []string
+
+		for k := 0; k < ps.misc.num_beacons; k++ {
+			var b = &ps.misc.beacon[k]
+			if b.sendto_type == SENDTO_XMIT && b.sendto_chan == ch {
+				reasons = append(reasons, fmt.Sprintf("beacon on line %d", b.lineno))
+			}
+		}
+
+		var digipeats = false
+
+		for from := range MAX_TOTAL_CHANS {
+			if ps.digi.enabled[from][ch] {
+				digipeats = true
+			}
+		}
+
+		for from := range MAX_RADIO_CHANS {
+			if ps.cdigi.enabled[from][ch] {
+				digipeats = true
+			}
+		}
+
+		if digipeats {
+			reasons = append(reasons, "digipeater")
+		}
+
+		if ps.igate.t2_login != "" && ps.igate.tx_chan == ch {
+			reasons = append(reasons, "IGate IS>RF")
+		}
+
+		if len(reasons) > 0 {
+			warnings = append(warnings, fmt.Sprintf(
+				"Channel %d will transmit (%s) but has no PTT configured.\n"+
+					"Add a PTT line after \"CHANNEL %d\", e.g. \"PTT /dev/ttyUSB0 RTS\" or \"PTT CM108\".  Ignore this if the radio interface uses VOX.",
+				ch, strings.Join(reasons, ", "), ch))
+		}
+	}
+
+	return warnings
+}
+
+// lint_ports catches the AGW network protocol, KISS over TCP, and HTTP
+// being given the same port, so only whichever starts first would work.
+func lint_ports(ps *parseState) []string {
+	var warnings []string
+
+	for i := range MAX_KISS_TCP_PORTS {
+		if ps.misc.agwpe_port != 0 && ps.misc.kiss_port[i] == ps.misc.agwpe_port {
+			warnings = append(warnings, fmt.Sprintf(
+				"AGWPORT and KISSPORT both use TCP port %d.\n"+
+					"Change one of them; the defaults are AGWPORT %d and KISSPORT %d.",
+				ps.misc.agwpe_port, DEFAULT_AGWPE_PORT, DEFAULT_KISS_PORT))
+		}
+
+		if ps.misc.http_port != 0 && ps.misc.kiss_port[i] == ps.misc.http_port {
+			warnings = append(warnings, fmt.Sprintf(
+				"HTTPPORT and KISSPORT both use TCP port %d.\nChange one of them.", ps.misc.http_port))
+		}
+	}
+
+	if ps.misc.http_port != 0 && ps.misc.http_port == ps.misc.agwpe_port {
+		warnings = append(warnings, fmt.Sprintf(
+			"HTTPPORT and AGWPORT both use TCP port %d.\nChange one of them.", ps.misc.http_port))
+	}
+
+	return warnings
+}
+
+// lint_beacon_position catches beacons which have nothing to take
+// their position from.
+func lint_beacon_position(ps *parseState) []string {
+	var warnings []string
+
+	for k := 0; k < ps.misc.num_beacons; k++ {
+		var b = &ps.misc.beacon[k]
+
+		switch b.btype {
+		case BEACON_POSITION, BEACON_OBJECT:
+			if b.lat == G_UNKNOWN || b.lon == G_UNKNOWN {
+				var keyword = "PBEACON"
+				if b.btype == BEACON_OBJECT {
+					keyword = "OBEACON"
+				}
+
+				warnings = append(warnings, fmt.Sprintf(
+					"%s on line %d has no position so it will not be sent.\n"+
+						"Add LAT= and LONG= (or ZONE=, EASTING= and NORTHING=), or use TBEACON with a GPS receiver.",
+					keyword, b.lineno))
+			}
+		case BEACON_TRACKER:
+			if ps.misc.gpsnmea_port == "" && ps.misc.gpsd_host == "" && !ps.misc.gps_api {
+				warnings = append(warnings, fmt.Sprintf(
+					"TBEACON on line %d has no GPS to get its position from so it will not be sent.\n"+
+						"Add GPSNMEA with the serial port of the GPS receiver, or use PBEACON with a fixed LAT= and LONG=.",
+					b.lineno))
+			}
+		default:
+		}
+	}
+
+	return warnings
+}
@@ -0,0 +1,189 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lintState returns a parse state holding the defaults, with channel 0
+// a radio channel that has a callsign and PTT, so each test only has to
+// introduce the mistake it is looking for.
+func lintState() *parseState {
+	var ps = new(parseState)
+	ps.audio = new(audio_s)
+	ps.digi = new(digi_config_s)
+	ps.cdigi = new(cdigi_config_s)
+	ps.tt = new(tt_config_s)
+	ps.igate = new(igate_config_s)
+	ps.misc = new(misc_config_s)
+
+	config_set_defaults(ps.audio, ps.digi, ps.tt, ps.igate, ps.misc)
+
+	ps.audio.chan_medium[0] = MEDIUM_RADIO
+	ps.audio.mycall[0] = "Q1TEST"
+	ps.audio.achan[0].octrl[OCTYPE_PTT].ptt_method = PTT_METHOD_SERIAL
+
+	return ps
+}
+
+func addLintBeacon(ps *parseState, btype beacon_type_e, lineno int) *beacon_s {
+	var b = &ps.misc.beacon[ps.misc.num_beacons]
+	ps.misc.num_beacons++
+
+	b.btype = btype
+	b.lineno = lineno
+	b.sendto_type = SENDTO_XMIT
+	b.sendto_chan = 0
+	b.lat = G_UNKNOWN
+	b.lon = G_UNKNOWN
+
+	return b
+}
+
+func Test_config_lint_clean(t *testing.T) {
+	var ps = lintState()
+
+	var b = addLintBeacon(ps, BEACON_POSITION, 10)
+	b.lat = 42.5
+	b.lon = -71.25
+
+	assert.Empty(t, config_lint(ps))
+}
+
+func Test_config_lint_igate_mycall(t *testing.T) {
+	var ps = lintState()
+	ps.igate.t2_login = "Q2TEST-10"
+	ps.audio.mycall[0] = "N0CALL"
+
+	var warnings = config_lint(ps)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "MYCALL for channel 0")
+	assert.Contains(t, warnings[0], `"MYCALL Q2TEST-10"`)
+
+	ps.igate.t2_login = ""
+	assert.Empty(t, config_lint(ps), "no IGate, nothing to complain about")
+}
+
+func Test_config_lint_ptt(t *testing.T) {
+	t.Run("receive only is fine", func(t *testing.T) {
+		var ps = lintState()
+		ps.audio.achan[0].octrl[OCTYPE_PTT].ptt_method = PTT_METHOD_NONE
+
+		assert.Empty(t, config_lint(ps))
+	})
+
+	t.Run("all reasons listed", func(t *testing.T) {
+		var ps = lintState()
+		ps.audio.achan[0].octrl[OCTYPE_PTT].ptt_method = PTT_METHOD_NONE
+
+		var b = addLintBeacon(ps, BEACON_POSITION, 12)
+		b.lat = 42.5
+		b.lon = -71.25
+
+		ps.digi.enabled[0][0] = true
+		ps.igate.t2_login = "Q1TEST"
+		ps.igate.tx_chan = 0
+
+		var warnings = config_lint(ps)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], "Channel 0 will transmit (beacon on line 12, digipeater, IGate IS>RF)")
+		assert.Contains(t, warnings[0], "VOX")
+	})
+
+	t.Run("connected mode digipeater", func(t *testing.T) {
+		var ps = lintState()
+		ps.audio.achan[0].octrl[OCTYPE_PTT].ptt_method = PTT_METHOD_NONE
+		ps.cdigi.enabled[0][0] = true
+
+		var warnings = config_lint(ps)
+		require.Len(t, warnings, 1)
+		assert.Contains(t, warnings[0], "(digipeater)")
+	})
+
+	t.Run("beacon to another channel", func(t *testing.T) {
+		var ps = lintState()
+		ps.audio.achan[0].octrl[OCTYPE_PTT].ptt_method = PTT_METHOD_NONE
+
+		var b = addLintBeacon(ps, BEACON_CUSTOM, 12)
+		b.sendto_type = SENDTO_IGATE
+
+		assert.Empty(t, config_lint(ps))
+	})
+}
+
+func Test_config_lint_ports(t *testing.T) {
+	var ps = lintState()
+	assert.Empty(t, config_lint(ps), "defaults must not overlap")
+
+	ps.misc.kiss_port[1] = ps.misc.agwpe_port
+
+	var warnings = config_lint(ps)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "AGWPORT and KISSPORT both use TCP port 8000")
+
+	ps.misc.agwpe_port = 0
+	assert.Empty(t, config_lint(ps), "AGW disabled")
+
+	ps.misc.http_port = DEFAULT_KISS_PORT
+
+	warnings = config_lint(ps)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "HTTPPORT and KISSPORT both use TCP port 8001")
+
+	ps.misc.http_port = 8080
+	ps.misc.agwpe_port = 8080
+
+	warnings = config_lint(ps)
+	require.Len(t, warnings, 1)
+	assert.Contains(t, warnings[0], "HTTPPORT and AGWPORT")
+}
+
+func Test_config_lint_beacon_position(t *testing.T) {
+	var tests = []struct {
+		name   string
+		btype  beacon_type_e
+		gps    string
+		expect string
+	}{
+		{"position", BEACON_POSITION, "", "PBEACON on line 20 has no position"},
+		{"object", BEACON_OBJECT, "", "OBEACON on line 20 has no position"},
+		{"tracker without gps", BEACON_TRACKER, "", "TBEACON on line 20 has no GPS"},
+		{"tracker with gps", BEACON_TRACKER, "/dev/ttyACM0", ""},
+		{"custom", BEACON_CUSTOM, "", ""},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			var ps = lintState()
+			ps.misc.gpsnmea_port = tc.gps
+			addLintBeacon(ps, tc.btype, 20)
+
+			var warnings = config_lint(ps)
+			if tc.expect == "" {
+				assert.Empty(t, warnings)
+			} else {
+				require.Len(t, warnings, 1)
+				assert.Contains(t, warnings[0], tc.expect)
+			}
+		})
+	}
+
+	t.Run("tracker with gps api", func(t *testing.T) {
+		var ps = lintState()
+		ps.misc.gps_api = true
+		addLintBeacon(ps, BEACON_TRACKER, 20)
+
+		assert.Empty(t, config_lint(ps))
+	})
+}
+
+func Test_config_lint_from_file(t *testing.T) {
+	// config_init displays the warnings; check it gets that far without upset.
+	var audio, misc = configFromString(t, "MYCALL Q1TEST\nAGWPORT 8001\nKISSPORT 8001\nPBEACON\n")
+
+	assert.Equal(t, "Q1TEST", audio.mycall[0])
+	assert.Equal(t, 8001, misc.agwpe_port)
+	assert.Equal(t, 1, misc.num_beacons)
+}
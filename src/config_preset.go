@@ -0,0 +1,234 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Named shortcuts for common hardware and modem setups.
+ *
+ * Description:	Getting ADEVICE, MODEM and PTT right for a popular
+ *		interface is a frequent stumbling block for new users.
+ *		Instead, the configuration file can say, for example,
+ *
+ *			PRESET digirig-vhf-1200
+ *
+ *		which is processed exactly as if the lines it expands to
+ *		had been written in its place.  Anything that needs to be
+ *		different can be set by the usual commands after it,
+ *		e.g. a different serial port with another PTT line.
+ *
+ *		The expansion is displayed at startup so there is no
+ *		mystery about what was done.
+ *
+ *		"{adevice}" in an expansion is replaced by ADEVICE with
+ *		the number of the audio device for the current channel.
+ *		MODEM and PTT apply to the current channel as usual, so
+ *		use CHANNEL first for anything other than channel 0.
+ *
+ *		Radios with USB audio and CAT on one cable, like the IC-705,
+ *		are looked for on USB by name.  "{audio}" and "{port}" are
+ *		replaced by the sound card and serial port found, or the
+ *		usual names if the radio isn't plugged in.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+type configPreset struct {
+	description string
+	lines       []string
+
+	radio string /* Name to look for on USB, for {audio} and {port}.  See usb_radio_find. */
+	audio string /* Used for {audio} when the radio isn't found. */
+	port  string /* Used for {port} when the radio isn't found. */
+}
+
+var configPresets = map[string]configPreset{
+	"digirig-vhf-1200": {
+		description: "Digirig Mobile, 1200 baud AFSK, PTT on serial port RTS",
+		lines: []string{
+			"{adevice} plughw:CARD=Device,DEV=0",
+			"MODEM 1200",
+			"PTT /dev/ttyUSB0 RTS",
+		},
+	},
+	"digirig-vhf-9600": {
+		description: "Digirig Mobile, 9600 baud G3RUH, PTT on serial port RTS",
+		lines: []string{
+			"{adevice} plughw:CARD=Device,DEV=0",
+			"ARATE 48000",
+			"MODEM 9600",
+			"PTT /dev/ttyUSB0 RTS",
+		},
+	},
+	"signalink-vhf-1200": {
+		description: "SignaLink USB, 1200 baud AFSK, PTT by the interface's own VOX",
+		lines: []string{
+			"{adevice} plughw:CARD=CODEC,DEV=0",
+			"MODEM 1200",
+		},
+	},
+	"cm108-vhf-1200": {
+		description: "CM108/CM119 based interface (e.g. AIOC, DMK URI), 1200 baud AFSK, PTT on GPIO 3",
+		lines: []string{
+			"{adevice} plughw:CARD=Device,DEV=0",
+			"MODEM 1200",
+			"PTT CM108",
+		},
+	},
+	"ic-705-vhf-1200": {
+		description: "Icom IC-705 on one USB cable, 1200 baud AFSK, PTT by CI-V",
+		lines: []string{
+			"{adevice} {audio}",
+			"MODEM 1200",
+			"PTT CAT icom {port} 19200 0xa4",
+		},
+		radio: "IC-705",
+		audio: "plughw:CARD=CODEC,DEV=0",
+		port:  "/dev/ttyACM0",
+	},
+	"ic-705-hf-300": {
+		description: "Icom IC-705 on one USB cable, HF 300 baud AFSK, PTT by CI-V",
+		lines: []string{
+			"{adevice} {audio}",
+			"MODEM 300 1600:1800 7@30",
+			"PTT CAT icom {port} 19200 0xa4",
+		},
+		radio: "IC-705",
+		audio: "plughw:CARD=CODEC,DEV=0",
+		port:  "/dev/ttyACM0",
+	},
+	"ic-7300-hf-300": {
+		description: "Icom IC-7300 on one USB cable, HF 300 baud AFSK, PTT by CI-V",
+		lines: []string{
+			"{adevice} {audio}",
+			"MODEM 300 1600:1800 7@30",
+			"PTT CAT icom {port} 19200 0x94",
+		},
+		radio: "IC-7300",
+		audio: "plughw:CARD=CODEC,DEV=0",
+		port:  "/dev/ttyUSB0",
+	},
+	"hf-300": {
+		description: "HF packet, 300 baud AFSK 1600/1800 Hz, with extra decoders for mistuning",
+		lines: []string{
+			"MODEM 300 1600:1800 7@30",
+		},
+	},
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        config_preset_lines
+ *
+ * Purpose:     Get the configuration lines for a preset.
+ *
+ * Inputs:	name	- Preset name.  Case doesn't matter.
+ *
+ *		adevice	- Audio device number for "{adevice}".
+ *
+ * Returns:	Lines to process, and whether the preset exists.
+ *
+ *--------------------------------------------------------------------*/
+
+func config_preset_lines(name string, adevice int) ([]string, bool) {
+	var p, found = configPresets[strings.ToLower(name)]
+	if !found {
+		return nil, false
+	}
+
+	var audio, port = p.audio, p.port
+
+	if p.radio != "" {
+		var found_audio, found_port = config_preset_find_radio(p.radio)
+		if found_audio != "" && found_port != "" {
+			text_color_set(DW_COLOR_INFO)
+			dw_printf("Found %s with audio %s and CAT on %s.\n", p.radio, found_audio, found_port)
+
+			audio, port = found_audio, found_port
+		} else {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Couldn't find %s on USB.  Is it plugged in and turned on?\n", p.radio)
+		}
+	}
+
+	var r = strings.NewReplacer("{adevice}", "ADEVICE"+strconv.Itoa(adevice), "{audio}", audio, "{port}", port)
+
+	var lines = make([]string, 0, len(p.lines))
+	for _, line := range p.lines {
+		lines = append(lines, r.Replace(line))
+	}
+
+	return lines, true
+}
+
+/* Replaced in tests. */
+
+var config_preset_find_radio = usb_radio_find
+
+// config_preset_names returns the preset names in a stable order.
+func config_preset_names() []string {
+	var names = make([]string, 0, len(configPresets))
+	for name := range configPresets {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+func handlePRESET(ps *parseState) bool {
+	/*
+	 * PRESET name		- Same as the ADEVICE, MODEM, PTT, etc. lines it stands for.
+	 */
+	var name = ps.lex.next(false)
+	if name == "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Missing name for PRESET command.  Available presets:\n", ps.line)
+		config_preset_list()
+
+		return true
+	}
+
+	var lines, found = config_preset_lines(name, ACHAN2ADEV(ps.channel))
+	if !found {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Unknown PRESET \"%s\".  Available presets:\n", ps.line, name)
+		config_preset_list()
+
+		return true
+	}
+
+	if t := ps.lex.next(false); t != "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Line %d: Unexpected \"%s\" after PRESET name ignored.\n", ps.line, t)
+	}
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("Line %d: PRESET %s is the same as:\n", ps.line, strings.ToLower(name))
+
+	for _, line := range lines {
+		dw_printf("    %s\n", line)
+	}
+
+	// Process each line as if it had been written here.
+	var text, lex, keyword = ps.text, ps.lex, ps.keyword
+
+	for _, line := range lines {
+		ps.text = line
+		config_line(ps)
+	}
+
+	ps.text, ps.lex, ps.keyword = text, lex, keyword
+
+	return true
+}
+
+func config_preset_list() {
+	for _, name := range config_preset_names() {
+		dw_printf("    %-20s %s\n", name, configPresets[name].description)
+	}
+}
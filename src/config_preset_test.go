@@ -0,0 +1,83 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_config_preset_lines(t *testing.T) {
+	var lines, found = config_preset_lines("Digirig-VHF-1200", 1)
+	assert.True(t, found)
+	assert.Equal(t, "ADEVICE1 plughw:CARD=Device,DEV=0", lines[0])
+
+	_, found = config_preset_lines("no-such-preset", 0)
+	assert.False(t, found)
+
+	// The list shown for an unknown name needs a description of each.
+	for _, name := range config_preset_names() {
+		assert.NotEmpty(t, configPresets[name].description, name)
+	}
+}
+
+func Test_config_init_preset(t *testing.T) {
+	t.Run("digirig sets audio device, modem and PTT", func(t *testing.T) {
+		var cfg, _ = configFromString(t, "PRESET digirig-vhf-9600\n")
+		assert.Equal(t, "plughw:CARD=Device,DEV=0", cfg.adev[0].adevice_in)
+		assert.Equal(t, 48000, cfg.adev[0].samples_per_sec)
+		assert.Equal(t, 9600, cfg.achan[0].baud)
+		assert.Equal(t, PTT_METHOD_SERIAL, cfg.achan[0].octrl[OCTYPE_PTT].ptt_method)
+		assert.Equal(t, "/dev/ttyUSB0", cfg.achan[0].octrl[OCTYPE_PTT].ptt_device)
+		assert.Equal(t, PTT_LINE_RTS, cfg.achan[0].octrl[OCTYPE_PTT].ptt_line)
+	})
+
+	t.Run("hf-300 sets modem with multiple decoders", func(t *testing.T) {
+		var cfg, _ = configFromString(t, "PRESET hf-300\n")
+		assert.Equal(t, 300, cfg.achan[0].baud)
+		assert.Equal(t, 1600, cfg.achan[0].mark_freq)
+		assert.Equal(t, 1800, cfg.achan[0].space_freq)
+		assert.Equal(t, 7, cfg.achan[0].num_freq)
+		assert.Equal(t, 30, cfg.achan[0].offset)
+	})
+
+	t.Run("later lines override the preset", func(t *testing.T) {
+		var cfg, _ = configFromString(t, "PRESET digirig-vhf-1200\nPTT /dev/ttyUSB1 DTR\n")
+		assert.Equal(t, 1200, cfg.achan[0].baud)
+		assert.Equal(t, "/dev/ttyUSB1", cfg.achan[0].octrl[OCTYPE_PTT].ptt_device)
+		assert.Equal(t, PTT_LINE_DTR, cfg.achan[0].octrl[OCTYPE_PTT].ptt_line)
+	})
+
+	t.Run("ic-705 uses what was found on USB", func(t *testing.T) {
+		config_preset_find_radio = func(model string) (string, string) {
+			assert.Equal(t, "IC-705", model)
+			return "plughw:CARD=IC705,DEV=0", "/dev/ttyACM1"
+		}
+
+		t.Cleanup(func() { config_preset_find_radio = usb_radio_find })
+
+		var cfg, _ = configFromString(t, "PRESET ic-705-vhf-1200\n")
+		assert.Equal(t, "plughw:CARD=IC705,DEV=0", cfg.adev[0].adevice_in)
+		assert.Equal(t, 1200, cfg.achan[0].baud)
+		assert.Equal(t, PTT_METHOD_CAT, cfg.achan[0].octrl[OCTYPE_PTT].ptt_method)
+		assert.Equal(t, "/dev/ttyACM1", cfg.achan[0].octrl[OCTYPE_PTT].ptt_device)
+		assert.Equal(t, 0xa4, cfg.achan[0].octrl[OCTYPE_PTT].ptt_cat_addr)
+	})
+
+	t.Run("ic-7300 falls back to the usual names", func(t *testing.T) {
+		config_preset_find_radio = func(string) (string, string) { return "", "" }
+
+		t.Cleanup(func() { config_preset_find_radio = usb_radio_find })
+
+		var cfg, _ = configFromString(t, "PRESET ic-7300-hf-300\n")
+		assert.Equal(t, "plughw:CARD=CODEC,DEV=0", cfg.adev[0].adevice_in)
+		assert.Equal(t, 300, cfg.achan[0].baud)
+		assert.Equal(t, "/dev/ttyUSB0", cfg.achan[0].octrl[OCTYPE_PTT].ptt_device)
+		assert.Equal(t, 0x94, cfg.achan[0].octrl[OCTYPE_PTT].ptt_cat_addr)
+	})
+
+	t.Run("unknown preset changes nothing", func(t *testing.T) {
+		var cfg, _ = configFromString(t, "PRESET no-such-preset\n")
+		assert.Equal(t, DEFAULT_BAUD, cfg.achan[0].baud)
+		assert.Equal(t, PTT_METHOD_NONE, cfg.achan[0].octrl[OCTYPE_PTT].ptt_method)
+	})
+}
@@ -0,0 +1,114 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Read secrets, such as the APRS-IS passcode, from somewhere
+ *		other than the main configuration file.
+ *
+ * Description:	Keeping secrets out of the configuration file means it can
+ *		be world-readable and kept in version control.  Where a
+ *		secret is expected, the value can be written as:
+ *
+ *		file:/etc/samoyed/passcode
+ *
+ *			Use the contents of the named file.
+ *
+ *		credential:passcode
+ *
+ *			Use a systemd credential, e.g. from
+ *			LoadCredential=passcode:/etc/samoyed/passcode
+ *			in the service unit.  systemd makes these
+ *			available in $CREDENTIALS_DIRECTORY.
+ *
+ *		env:APRSIS_PASSCODE
+ *
+ *			Use an environment variable, which is how
+ *			Docker and Kubernetes usually pass secrets.
+ *
+ *		Anything else is taken literally, as before.
+ *		Leading and trailing white space, including the final
+ *		newline most editors add, is removed from file contents.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const SECRET_FILE_PREFIX = "file:"
+const SECRET_CREDENTIAL_PREFIX = "credential:"
+const SECRET_ENV_PREFIX = "env:"
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        config_secret
+ *
+ * Purpose:     Resolve a secret value from the configuration file.
+ *
+ * Inputs:	value	- As written in the configuration file.
+ *
+ * Returns:	The secret, and any error reading it.
+ *		A warning is displayed if a secret file can be
+ *		read by anyone, since that defeats the purpose.
+ *
+ *--------------------------------------------------------------------*/
+
+func config_secret(value string) (string, error) {
+	var path string
+
+	if name, found := strings.CutPrefix(value, SECRET_ENV_PREFIX); found {
+		var secret = strings.TrimSpace(os.Getenv(name))
+		if secret == "" {
+			return "", fmt.Errorf("environment variable %q is not set or empty", name)
+		}
+
+		return secret, nil
+	}
+
+	if name, found := strings.CutPrefix(value, SECRET_FILE_PREFIX); found {
+		path = name
+	} else if name, found := strings.CutPrefix(value, SECRET_CREDENTIAL_PREFIX); found {
+		var dir = os.Getenv("CREDENTIALS_DIRECTORY")
+		if dir == "" {
+			return "", fmt.Errorf("systemd credential %q requested but CREDENTIALS_DIRECTORY is not set; add LoadCredential= to the service unit", name)
+		}
+
+		if name == "" || strings.ContainsRune(name, '/') {
+			return "", fmt.Errorf("invalid systemd credential name %q", name)
+		}
+
+		path = filepath.Join(dir, name)
+	} else {
+		return value, nil
+	}
+
+	if path == "" {
+		return "", errors.New("missing file name after " + SECRET_FILE_PREFIX)
+	}
+
+	var info, statErr = os.Stat(path)
+	if statErr != nil {
+		return "", statErr
+	}
+
+	if info.Mode().Perm()&0o004 != 0 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Warning: Secret file %s can be read by anyone.  Try: chmod o-r %s\n", path, path)
+	}
+
+	var content, readErr = os.ReadFile(path) //nolint:gosec // Path comes from the config file.
+	if readErr != nil {
+		return "", readErr
+	}
+
+	var secret = strings.TrimSpace(string(content))
+	if secret == "" {
+		return "", fmt.Errorf("%s is empty", path)
+	}
+
+	return secret, nil
+}
@@ -0,0 +1,85 @@
+package direwolf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_config_secret_literal(t *testing.T) {
+	var secret, err = config_secret("12345")
+	require.NoError(t, err)
+	assert.Equal(t, "12345", secret)
+}
+
+func Test_config_secret_file(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "passcode")
+	require.NoError(t, os.WriteFile(path, []byte("  12345\n"), 0o600))
+
+	var secret, err = config_secret("file:" + path)
+	require.NoError(t, err)
+	assert.Equal(t, "12345", secret)
+
+	_, err = config_secret("file:" + path + ".missing")
+	require.Error(t, err)
+
+	_, err = config_secret("file:")
+	require.Error(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte("\n"), 0o600))
+	_, err = config_secret("file:" + path)
+	require.Error(t, err, "empty file")
+}
+
+func Test_config_secret_credential(t *testing.T) {
+	var dir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "igpasscode"), []byte("12345"), 0o600))
+
+	t.Setenv("CREDENTIALS_DIRECTORY", dir)
+
+	var secret, err = config_secret("credential:igpasscode")
+	require.NoError(t, err)
+	assert.Equal(t, "12345", secret)
+
+	_, err = config_secret("credential:../igpasscode")
+	require.Error(t, err)
+
+	t.Setenv("CREDENTIALS_DIRECTORY", "")
+
+	_, err = config_secret("credential:igpasscode")
+	require.ErrorContains(t, err, "LoadCredential=")
+}
+
+func Test_config_secret_env(t *testing.T) {
+	t.Setenv("Q1TEST_PASSCODE", "12345\n")
+
+	var secret, err = config_secret("env:Q1TEST_PASSCODE")
+	require.NoError(t, err)
+	assert.Equal(t, "12345", secret)
+
+	t.Setenv("Q1TEST_PASSCODE", "")
+
+	_, err = config_secret("env:Q1TEST_PASSCODE")
+	require.ErrorContains(t, err, "Q1TEST_PASSCODE")
+}
+
+func Test_config_init_iglogin_secret_file(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "passcode")
+	require.NoError(t, os.WriteFile(path, []byte("12345\n"), 0o600))
+
+	var tmpFile, err = os.CreateTemp(t.TempDir(), "direwolf*.conf")
+	require.NoError(t, err)
+	_, err = tmpFile.WriteString("IGLOGIN Q1TEST-10 file:" + path + "\n")
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+
+	var igateConfig igate_config_s
+
+	config_init(tmpFile.Name(), new(audio_s), new(digi_config_s), new(cdigi_config_s),
+		new(tt_config_s), &igateConfig, new(misc_config_s))
+
+	assert.Equal(t, "12345", igateConfig.t2_passcode)
+}
@@ -2,6 +2,7 @@ package direwolf
 
 import (
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -141,29 +142,6 @@ func Test_parse_ll(t *testing.T) {
 	}
 }
 
-// --- split ---
-
-func Test_split(t *testing.T) {
-	tests := []struct {
-		name       string
-		input      string
-		restOfLine bool
-		want       string
-	}{
-		{"simple token", "hello world", false, "hello"},
-		{"quoted token", `"hello world"`, false, "hello world"},
-		{"quoted token at end of string", `"hello"`, false, "hello"},
-		{"doubled quote inside quotes", `"say ""hi"""`, false, `say "hi"`},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			var result = split(tt.input, tt.restOfLine)
-			assert.Equal(t, tt.want, result)
-		})
-	}
-}
-
 // --- IsNoCall ---
 
 func Test_IsNoCall(t *testing.T) {
@@ -216,6 +194,50 @@ func configFromString(t *testing.T, content string) (*audio_s, *misc_config_s) {
 	return audioConfig, &miscConfig
 }
 
+// --- config_init from somewhere other than a file, for containers ---
+
+func Test_config_init_env_and_stdin(t *testing.T) {
+	var load = func(fname string) *audio_s {
+		var audioConfig = new(audio_s)
+		var digiConfig digi_config_s
+		var cdigiConfig cdigi_config_s
+		var ttConfig tt_config_s
+		var igateConfig igate_config_s
+		var miscConfig misc_config_s
+
+		config_init(fname, audioConfig, &digiConfig, &cdigiConfig,
+			&ttConfig, &igateConfig, &miscConfig)
+
+		return audioConfig
+	}
+
+	t.Run("environment variable", func(t *testing.T) {
+		t.Setenv("Q1TEST_CONFIG", "MYCALL Q1TEST\nADEVICE none\n")
+
+		var cfg = load("env:Q1TEST_CONFIG")
+		assert.Equal(t, "Q1TEST", cfg.mycall[0])
+		assert.True(t, audio_none(cfg))
+	})
+
+	t.Run("stdin", func(t *testing.T) {
+		var path = filepath.Join(t.TempDir(), "stdin")
+		require.NoError(t, os.WriteFile(path, []byte("MYCALL Q2TEST\n"), 0o600))
+
+		var f, err = os.Open(path)
+		require.NoError(t, err)
+
+		defer f.Close()
+
+		var saved = os.Stdin
+		os.Stdin = f
+
+		t.Cleanup(func() { os.Stdin = saved })
+
+		var cfg = load("-")
+		assert.Equal(t, "Q2TEST", cfg.mycall[0])
+	})
+}
+
 // --- config_init MYCALL directive ---
 
 func Test_config_init_mycall(t *testing.T) {
@@ -349,6 +371,28 @@ func Test_config_init_adevice(t *testing.T) {
 		assert.Equal(t, 0, cfg.adev[1].defined)
 		assert.Equal(t, MEDIUM_NONE, cfg.chan_medium[ADEVFIRSTCHAN(1)])
 	})
+
+	t.Run("ADEVICE none leaves no radio channels", func(t *testing.T) {
+		var cfg, misc = configFromString(t, "ADEVICE none\n"+
+			"MYCALL Q1TEST\n"+
+			"ICHANNEL 10\n"+
+			"IGSERVER noam.aprs2.net\n"+
+			"IGLOGIN Q1TEST 12345\n"+
+			"PBEACON LAT=42^37.14N LONG=71^20.83W SENDTO=IG\n"+
+			"PBEACON LAT=42^37.14N LONG=71^20.83W\n") // Default is channel 0.
+
+		assert.True(t, audio_none(cfg))
+		assert.Equal(t, MEDIUM_NONE, cfg.chan_medium[0])
+		assert.Equal(t, MEDIUM_IGATE, cfg.chan_medium[10])
+		assert.Equal(t, "Q1TEST", cfg.mycall[0], "beacons to APRS-IS use channel 0 MYCALL")
+		assert.Equal(t, 1, misc.num_beacons)
+	})
+
+	t.Run("default device is used without ADEVICE", func(t *testing.T) {
+		var cfg, _ = configFromString(t, "MYCALL Q1TEST\n")
+		assert.False(t, audio_none(cfg))
+		assert.Equal(t, MEDIUM_RADIO, cfg.chan_medium[0])
+	})
 }
 
 // --- config_init CHANNEL directive ---
@@ -366,6 +410,27 @@ func Test_config_init_channel(t *testing.T) {
 		assert.Equal(t, DEFAULT_TXDELAY, cfg.achan[0].txdelay)
 		assert.Equal(t, 42, cfg.achan[1].txdelay)
 	})
+
+	t.Run("NAME option", func(t *testing.T) {
+		var cfg, _ = configFromString(t,
+			"CHANNEL 0 NAME=\"VHF 144.39\"\n"+
+				"CHANNEL 1 name=HF\n",
+		)
+		assert.Equal(t, "VHF 144.39", cfg.chan_name[0])
+		assert.Equal(t, "HF", cfg.chan_name[1])
+		assert.Equal(t, " VHF 144.39", chan_name_suffix(cfg, 0))
+		assert.Empty(t, chan_name_suffix(cfg, 2))
+		assert.Empty(t, chan_name_suffix(cfg, -1))
+	})
+
+	t.Run("unknown option or ';' in NAME is ignored", func(t *testing.T) {
+		var cfg, _ = configFromString(t,
+			"CHANNEL 0 COLOUR=red\n"+
+				"CHANNEL 1 NAME=\"a;b\"\n",
+		)
+		assert.Empty(t, cfg.chan_name[0])
+		assert.Empty(t, cfg.chan_name[1])
+	})
 }
 
 // --- config_init MODEM directive ---
@@ -472,6 +537,353 @@ func Test_config_init_agwport(t *testing.T) {
 	})
 }
 
+// --- config_init HTTPPORT directive ---
+
+func Test_config_init_httpport(t *testing.T) {
+	var _, misc = configFromString(t, "")
+	assert.Equal(t, 0, misc.http_port, "disabled by default")
+
+	_, misc = configFromString(t, "HTTPPORT 8080\n")
+	assert.Equal(t, 8080, misc.http_port)
+
+	_, misc = configFromString(t, "HTTPPORT 80\n")
+	assert.Equal(t, 0, misc.http_port, "privileged port rejected")
+
+	_, misc = configFromString(t, "HTTPPORT web\n")
+	assert.Equal(t, 0, misc.http_port)
+}
+
+func Test_config_init_httporigin(t *testing.T) {
+	var _, misc = configFromString(t, "")
+	assert.Empty(t, misc.http_origins, "only our own pages by default")
+
+	_, misc = configFromString(t, "HTTPORIGIN https://map.example.org/ http://10.0.0.5:8000\nHTTPORIGIN *\n")
+	assert.Equal(t, []string{"https://map.example.org", "http://10.0.0.5:8000", "*"}, misc.http_origins)
+
+	_, misc = configFromString(t, "HTTPORIGIN map.example.org https://map.example.org/aprs ws://map.example.org\n")
+	assert.Empty(t, misc.http_origins)
+}
+
+// --- config_init LOGROTATE directive ---
+
+func Test_config_init_logrotate(t *testing.T) {
+	var _, misc = configFromString(t, "")
+	assert.Equal(t, int64(0), misc.log_max_size, "no limit by default")
+	assert.False(t, misc.log_compress)
+
+	_, misc = configFromString(t, "LOGROTATE size=10M keep=30 days=90 compress\n")
+	assert.Equal(t, int64(10*1024*1024), misc.log_max_size)
+	assert.Equal(t, 30, misc.log_keep)
+	assert.Equal(t, 90, misc.log_keep_days)
+	assert.True(t, misc.log_compress)
+
+	_, misc = configFromString(t, "LOGROTATE size=lots keep=-1 colour=blue\n")
+	assert.Equal(t, int64(0), misc.log_max_size)
+	assert.Equal(t, 0, misc.log_keep)
+}
+
+func Test_config_init_logformat(t *testing.T) {
+	var _, misc = configFromString(t, "")
+	assert.Equal(t, "csv", misc.log_format)
+
+	_, misc = configFromString(t, "LOGFORMAT json\n")
+	assert.Equal(t, "json", misc.log_format)
+
+	_, misc = configFromString(t, "LOGFORMAT xml\n")
+	assert.Equal(t, "csv", misc.log_format)
+}
+
+func Test_config_init_alert(t *testing.T) {
+	var _, misc = configFromString(t, "ALERT AUDIO IGATE=10 NORX=30\nALERTHOOK https://ntfy.example.com/q1test\nALERTHOOK /usr/local/bin/page\n")
+	assert.True(t, misc.alert_audio)
+	assert.Equal(t, 10, misc.alert_igate_minutes)
+	assert.Equal(t, 30, misc.alert_norx_minutes)
+	assert.Equal(t, []string{"https://ntfy.example.com/q1test", "/usr/local/bin/page"}, misc.alert_hooks)
+
+	_, misc = configFromString(t, "ALERT IGATE=soon NORX=0 LOUD\n")
+	assert.False(t, misc.alert_audio)
+	assert.Zero(t, misc.alert_igate_minutes)
+	assert.Zero(t, misc.alert_norx_minutes)
+}
+
+// --- config_init PTT and TXINH with GPIO ---
+
+func Test_config_init_gpio(t *testing.T) {
+	var audio, _ = configFromString(t, "PTT GPIO -25\n")
+	var octrl = audio.achan[0].octrl[OCTYPE_PTT]
+	assert.Equal(t, PTT_METHOD_GPIOD, octrl.ptt_method)
+	assert.Equal(t, "/dev/gpiochip0", octrl.out_gpio_name)
+	assert.Equal(t, 25, octrl.out_gpio_num)
+	assert.True(t, octrl.ptt_invert)
+	assert.Equal(t, GPIO_BIAS_AS_IS, octrl.out_gpio_bias)
+
+	audio, _ = configFromString(t, "PTT GPIOD gpiochip4 17 BIAS=pull-down\nDCD GPIOD 2 5\nCON GPIOD /dev/gpiochip1 6 bias=disabled\n")
+	octrl = audio.achan[0].octrl[OCTYPE_PTT]
+	assert.Equal(t, "/dev/gpiochip4", octrl.out_gpio_name)
+	assert.Equal(t, 17, octrl.out_gpio_num)
+	assert.False(t, octrl.ptt_invert)
+	assert.Equal(t, GPIO_BIAS_PULL_DOWN, octrl.out_gpio_bias)
+	assert.Equal(t, "/dev/gpiochip2", audio.achan[0].octrl[OCTYPE_DCD].out_gpio_name)
+	assert.Equal(t, "/dev/gpiochip1", audio.achan[0].octrl[OCTYPE_CON].out_gpio_name)
+	assert.Equal(t, GPIO_BIAS_DISABLED, audio.achan[0].octrl[OCTYPE_CON].out_gpio_bias)
+
+	// Bad bias is an error and the line is ignored.
+	audio, _ = configFromString(t, "PTT GPIO 25 BIAS=sideways\n")
+	assert.Equal(t, PTT_METHOD_NONE, audio.achan[0].octrl[OCTYPE_PTT].ptt_method)
+
+	audio, _ = configFromString(t, "TXINH GPIO -22 BIAS=pull-up\n")
+	var ictrl = audio.achan[0].ictrl[ICTYPE_TXINH]
+	assert.Equal(t, PTT_METHOD_GPIOD, ictrl.method)
+	assert.Equal(t, "/dev/gpiochip0", ictrl.in_gpio_name)
+	assert.Equal(t, 22, ictrl.in_gpio_num)
+	assert.True(t, ictrl.invert)
+	assert.Equal(t, GPIO_BIAS_PULL_UP, ictrl.in_gpio_bias)
+
+	audio, _ = configFromString(t, "TXINH GPIOD 4 3\n")
+	assert.Equal(t, "/dev/gpiochip4", audio.achan[0].ictrl[ICTYPE_TXINH].in_gpio_name)
+	assert.Equal(t, 3, audio.achan[0].ictrl[ICTYPE_TXINH].in_gpio_num)
+}
+
+func Test_config_init_ptt_cat(t *testing.T) {
+	var audio, _ = configFromString(t, "PTT CAT icom /dev/ttyUSB0 19200 0x94\n")
+	var octrl = audio.achan[0].octrl[OCTYPE_PTT]
+	assert.Equal(t, PTT_METHOD_CAT, octrl.ptt_method)
+	assert.Equal(t, CAT_ICOM, octrl.ptt_cat_type)
+	assert.Equal(t, "/dev/ttyUSB0", octrl.ptt_device)
+	assert.Equal(t, 19200, octrl.ptt_rate)
+	assert.Equal(t, 0x94, octrl.ptt_cat_addr)
+
+	audio, _ = configFromString(t, "PTT CAT Kenwood /dev/ttyUSB1\n")
+	octrl = audio.achan[0].octrl[OCTYPE_PTT]
+	assert.Equal(t, PTT_METHOD_CAT, octrl.ptt_method)
+	assert.Equal(t, CAT_KENWOOD, octrl.ptt_cat_type)
+	assert.Equal(t, 0, octrl.ptt_rate)
+
+	// Icom needs an address, and only PTT can use CAT.
+	audio, _ = configFromString(t, "PTT CAT icom /dev/ttyUSB0 19200\nDCD CAT kenwood /dev/ttyUSB1\nCON CAT motorola /dev/ttyUSB2\n")
+	assert.Equal(t, PTT_METHOD_NONE, audio.achan[0].octrl[OCTYPE_PTT].ptt_method)
+	assert.Equal(t, PTT_METHOD_NONE, audio.achan[0].octrl[OCTYPE_DCD].ptt_method)
+	assert.Equal(t, PTT_METHOD_NONE, audio.achan[0].octrl[OCTYPE_CON].ptt_method)
+}
+
+func Test_config_init_ptt_remote(t *testing.T) {
+	var audio, _ = configFromString(t, "PTT HTTP http://relay1/relay/0?turn=on http://relay1/relay/0?turn=off\nDCD MQTT mqtt://q1test:pw@broker:1884 station/dcd\nCON MQTT broker station/con 1 0\n")
+	var octrl = audio.achan[0].octrl[OCTYPE_PTT]
+	assert.Equal(t, PTT_METHOD_HTTP, octrl.ptt_method)
+	assert.Equal(t, "http://relay1/relay/0?turn=on", octrl.ptt_remote_on)
+	assert.Equal(t, "http://relay1/relay/0?turn=off", octrl.ptt_remote_off)
+
+	octrl = audio.achan[0].octrl[OCTYPE_DCD]
+	assert.Equal(t, PTT_METHOD_MQTT, octrl.ptt_method)
+	assert.Equal(t, "mqtt://q1test:pw@broker:1884", octrl.ptt_device)
+	assert.Equal(t, "station/dcd", octrl.ptt_mqtt_topic)
+	assert.Equal(t, "ON", octrl.ptt_remote_on)
+	assert.Equal(t, "OFF", octrl.ptt_remote_off)
+
+	octrl = audio.achan[0].octrl[OCTYPE_CON]
+	assert.Equal(t, "1", octrl.ptt_remote_on)
+	assert.Equal(t, "0", octrl.ptt_remote_off)
+
+	audio, _ = configFromString(t, "PTT HTTP http://relay1/on\nDCD MQTT broker\nCON HTTP relay1/on relay1/off\n")
+	assert.Equal(t, PTT_METHOD_NONE, audio.achan[0].octrl[OCTYPE_PTT].ptt_method)
+	assert.Equal(t, PTT_METHOD_NONE, audio.achan[0].octrl[OCTYPE_DCD].ptt_method)
+	assert.Equal(t, PTT_METHOD_NONE, audio.achan[0].octrl[OCTYPE_CON].ptt_method)
+}
+
+func Test_config_init_txtimeout(t *testing.T) {
+	var audio, _ = configFromString(t, "TXTIMEOUT 60\nCHANNEL 1\nTXTIMEOUT forever\n")
+	assert.Equal(t, 60, audio.achan[0].tx_timeout)
+	assert.Equal(t, 0, audio.achan[1].tx_timeout)
+}
+
+func Test_config_init_cm108(t *testing.T) {
+	var audio, _ = configFromString(t, "PTT CM108 /dev/hidraw1\nDCD CM108 1 /dev/hidraw1\nCON CM108 -2 /dev/hidraw1\n")
+	assert.Equal(t, PTT_METHOD_CM108, audio.achan[0].octrl[OCTYPE_PTT].ptt_method)
+	assert.Equal(t, 3, audio.achan[0].octrl[OCTYPE_PTT].out_gpio_num)
+	assert.Equal(t, PTT_METHOD_CM108, audio.achan[0].octrl[OCTYPE_DCD].ptt_method)
+	assert.Equal(t, 1, audio.achan[0].octrl[OCTYPE_DCD].out_gpio_num)
+	assert.Equal(t, 2, audio.achan[0].octrl[OCTYPE_CON].out_gpio_num)
+	assert.True(t, audio.achan[0].octrl[OCTYPE_CON].ptt_invert)
+
+	// DCD has no default pin.
+	audio, _ = configFromString(t, "DCD CM108 /dev/hidraw1\n")
+	assert.Equal(t, PTT_METHOD_NONE, audio.achan[0].octrl[OCTYPE_DCD].ptt_method)
+
+	audio, _ = configFromString(t, "TXINH CM108 -4 /dev/hidraw1\n")
+	var ictrl = audio.achan[0].ictrl[ICTYPE_TXINH]
+	assert.Equal(t, PTT_METHOD_CM108, ictrl.method)
+	assert.Equal(t, 4, ictrl.in_gpio_num)
+	assert.True(t, ictrl.invert)
+	assert.Equal(t, "/dev/hidraw1", ictrl.in_gpio_name)
+
+	audio, _ = configFromString(t, "TXINH CM108 9 /dev/hidraw1\n")
+	assert.Equal(t, PTT_METHOD_NONE, audio.achan[0].ictrl[ICTYPE_TXINH].method)
+}
+
+func Test_config_init_rigpoll(t *testing.T) {
+	var audio, _ = configFromString(t, "PTT RIG 2 localhost:4532\nRIGPOLL 10\n")
+	assert.Equal(t, 10, audio.achan[0].rig_poll)
+
+	// Only with hamlib.
+	audio, _ = configFromString(t, "PTT GPIO 17\nRIGPOLL 10\n")
+	assert.Equal(t, 0, audio.achan[0].rig_poll)
+
+	var _, misc = configFromString(t, "MYCALL Q1TEST\nPTT RIG 2 localhost:4532\nRIGPOLL 10\nPBEACON LAT=42^37.14N LONG=71^20.83W RIGINFO=1\n")
+	require.Equal(t, 1, misc.num_beacons)
+	assert.True(t, misc.beacon[0].riginfo)
+}
+
+func Test_config_init_mcp23017(t *testing.T) {
+	var audio, _ = configFromString(t, "PTT MCP23017 1 0x20 A3\nDCD MCP23017 /dev/i2c-3 0x27 -b7\n")
+	var octrl = audio.achan[0].octrl[OCTYPE_PTT]
+	assert.Equal(t, PTT_METHOD_MCP23017, octrl.ptt_method)
+	assert.Equal(t, "/dev/i2c-1", octrl.ptt_device)
+	assert.Equal(t, 0x20, octrl.ptt_i2c_addr)
+	assert.Equal(t, 3, octrl.out_gpio_num)
+	assert.False(t, octrl.ptt_invert)
+
+	octrl = audio.achan[0].octrl[OCTYPE_DCD]
+	assert.Equal(t, "/dev/i2c-3", octrl.ptt_device)
+	assert.Equal(t, 0x27, octrl.ptt_i2c_addr)
+	assert.Equal(t, 15, octrl.out_gpio_num)
+	assert.True(t, octrl.ptt_invert)
+
+	for _, bad := range []string{"PTT MCP23017 1 0x20\n", "PTT MCP23017 1 0x40 A3\n", "PTT MCP23017 1 0x20 A9\n"} {
+		audio, _ = configFromString(t, bad)
+		assert.Equal(t, PTT_METHOD_NONE, audio.achan[0].octrl[OCTYPE_PTT].ptt_method, bad)
+	}
+}
+
+func Test_config_init_indicator(t *testing.T) {
+	var audio, _ = configFromString(t, "PTT GPIO 17\nINDICATOR PTT GPIO 5\nINDICATOR DCD MCP23017 1 0x20 A0\nINDICATOR con HTTP http://panel/on http://panel/off\n")
+	assert.Equal(t, PTT_METHOD_GPIOD, audio.achan[0].octrl[OCTYPE_PTT].ptt_method)
+	assert.Equal(t, 17, audio.achan[0].octrl[OCTYPE_PTT].out_gpio_num)
+
+	assert.Equal(t, PTT_METHOD_GPIOD, audio.achan[0].octrl[OCTYPE_IND1].ptt_method)
+	assert.Equal(t, 5, audio.achan[0].octrl[OCTYPE_IND1].out_gpio_num)
+	assert.Equal(t, OCTYPE_PTT, audio.achan[0].octrl[OCTYPE_IND1].ind_follows)
+
+	assert.Equal(t, PTT_METHOD_MCP23017, audio.achan[0].octrl[OCTYPE_IND2].ptt_method)
+	assert.Equal(t, OCTYPE_DCD, audio.achan[0].octrl[OCTYPE_IND2].ind_follows)
+
+	assert.Equal(t, PTT_METHOD_HTTP, audio.achan[0].octrl[OCTYPE_IND3].ptt_method)
+	assert.Equal(t, OCTYPE_CON, audio.achan[0].octrl[OCTYPE_IND3].ind_follows)
+
+	// Bad ones don't use up a slot.
+	audio, _ = configFromString(t, "INDICATOR TXINH GPIO 5\nINDICATOR PTT CAT icom /dev/ttyUSB0 19200 0x94\nINDICATOR DCD GPIO 6\n")
+	assert.Equal(t, PTT_METHOD_GPIOD, audio.achan[0].octrl[OCTYPE_IND1].ptt_method)
+	assert.Equal(t, OCTYPE_DCD, audio.achan[0].octrl[OCTYPE_IND1].ind_follows)
+	assert.Equal(t, PTT_METHOD_NONE, audio.achan[0].octrl[OCTYPE_IND2].ptt_method)
+}
+
+func Test_config_init_txseq(t *testing.T) {
+	var audio, _ = configFromString(t, "SEQ1 GPIOD gpiochip0 27\nSEQ2 GPIOD gpiochip0 22 BIAS=pull-down\nTXSEQ 30 50 100\n")
+	assert.Equal(t, PTT_METHOD_GPIOD, audio.achan[0].octrl[OCTYPE_SEQ1].ptt_method)
+	assert.Equal(t, 27, audio.achan[0].octrl[OCTYPE_SEQ1].out_gpio_num)
+	assert.Equal(t, GPIO_BIAS_PULL_DOWN, audio.achan[0].octrl[OCTYPE_SEQ2].out_gpio_bias)
+	assert.Equal(t, []int{30, 50, 100}, audio.achan[0].txseq_ms)
+
+	audio, _ = configFromString(t, "TXSEQ 30 50 100 200\n")
+	assert.Nil(t, audio.achan[0].txseq_ms)
+
+	audio, _ = configFromString(t, "TXSEQ -5\n")
+	assert.Nil(t, audio.achan[0].txseq_ms)
+}
+
+// --- config_init SMARTBEACONING and GEOFENCE directives ---
+
+func Test_config_init_smartbeaconing(t *testing.T) {
+	var _, misc = configFromString(t, "SMARTBEACONING 50 1:30 4 900 20 25 200\n")
+	assert.True(t, misc.sb_configured)
+	assert.Equal(t, smartbeacon_s{fast_speed: 50, fast_rate: 90, slow_speed: 4, slow_rate: 900, turn_time: 20, turn_angle: 25, turn_slope: 200}, misc.smartbeacon())
+
+	// Defaults.
+	_, misc = configFromString(t, "SMARTBEACONING\n")
+	assert.True(t, misc.sb_configured)
+	assert.Equal(t, 180, misc.sb_fast_rate)
+
+	// Out of range keeps the default.
+	_, misc = configFromString(t, "SMARTBEACONING 500 180 5 1800 15 30 255\n")
+	assert.Equal(t, 60, misc.sb_fast_speed)
+
+	_, misc = configFromString(t, "SMARTBEACONING 60 180\n")
+	assert.False(t, misc.sb_configured)
+
+	_, misc = configFromString(t, "SMARTBEACONING 60 180 5 1800 15 30 255 TURNDECAY=120 MINTURNDIST=50\n")
+	assert.True(t, misc.sb_configured)
+	assert.Equal(t, 120, misc.sb_turn_decay)
+	assert.Equal(t, 50, misc.sb_min_turn_dist)
+	assert.Equal(t, 255, misc.sb_turn_slope)
+}
+
+func Test_config_init_geofence(t *testing.T) {
+	var _, misc = configFromString(t,
+		"GEOFENCE hamfest LAT=42^37.14N LONG=071^20.83W RADIUS=2 ENTER=\"Arrived\" EXIT=\"Left\" COMMENT=\"Talk-in 146.52\"\n"+
+			"GEOFENCE city POLYGON=42.40,-71.10/42.40,-71.00/42.30,-71.00 SMARTBEACONING=30,60,3,600,10,20,255\n")
+	require.Len(t, misc.geofence, 2)
+
+	var g = misc.geofence[0]
+	assert.Equal(t, "hamfest", g.name)
+	assert.InDelta(t, 42.619, g.lat, 0.001)
+	assert.InDelta(t, -71.347, g.lon, 0.001)
+	assert.InDelta(t, 2.0, g.radius_km, 0.001)
+	assert.Equal(t, "Arrived", g.enter_comment)
+	assert.Equal(t, "Left", g.exit_comment)
+	assert.Equal(t, "Talk-in 146.52", g.comment)
+	assert.Nil(t, g.sb)
+
+	g = misc.geofence[1]
+	assert.Len(t, g.polygon, 3)
+	assert.InDelta(t, -71.10, g.polygon[0][1], 0.001)
+	require.NotNil(t, g.sb)
+	assert.Equal(t, 60, g.sb.fast_rate)
+
+	// Need one kind of area.
+	_, misc = configFromString(t, "GEOFENCE nowhere COMMENT=\"x\"\nGEOFENCE line POLYGON=42,-71/43,-71\n")
+	assert.Empty(t, misc.geofence)
+}
+
+// --- config_init GPSD, GPSFIXED, GPSPRIORITY, and GPSTIMEOUT directives ---
+
+func Test_config_init_gps_sources(t *testing.T) {
+	var _, misc = configFromString(t, "GPSD\nGPSFIXED LAT=42^37.14N LONG=071^20.83W ALT=50\nGPSPRIORITY gpsd fixed\nGPSTIMEOUT 60\n")
+	assert.Equal(t, "localhost", misc.gpsd_host)
+	assert.Equal(t, DEFAULT_GPSD_PORT, misc.gpsd_port)
+	assert.InDelta(t, 42.619, misc.gps_fixed_lat, 0.001)
+	assert.InDelta(t, -71.347, misc.gps_fixed_lon, 0.001)
+	assert.InDelta(t, 50.0, misc.gps_fixed_alt, 0.001)
+	assert.Equal(t, []dwgps_source_t{DWGPS_SOURCE_GPSD, DWGPS_SOURCE_FIXED}, misc.gps_priority)
+	assert.Equal(t, 60, misc.gps_timeout)
+	assert.False(t, misc.gps_api)
+
+	_, misc = configFromString(t, "GPSFIXED LAT=42^37.14N\nGPSPRIORITY NMEA SATELLITE NMEA\n")
+	assert.InDelta(t, G_UNKNOWN, misc.gps_fixed_lat, 0.001)
+	assert.Equal(t, []dwgps_source_t{DWGPS_SOURCE_NMEA}, misc.gps_priority)
+	assert.Equal(t, 30, misc.gps_timeout)
+
+	_, misc = configFromString(t, "GPSAPI\nGPSPRIORITY API FIXED\n")
+	assert.True(t, misc.gps_api)
+	assert.Empty(t, misc.gps_api_token)
+	assert.Equal(t, []dwgps_source_t{DWGPS_SOURCE_API, DWGPS_SOURCE_FIXED}, misc.gps_priority)
+
+	_, misc = configFromString(t, "GPSAPI s3cret\n")
+	assert.True(t, misc.gps_api)
+	assert.Equal(t, "s3cret", misc.gps_api_token)
+}
+
+// --- config_init WAYPOINT directive ---
+
+func Test_config_init_waypoint(t *testing.T) {
+	var _, misc = configFromString(t, "WAYPOINT tcp:10110 NA\nWAYPOINT 192.168.1.5:10110 K\n")
+	assert.Equal(t, 10110, misc.waypoint_tcp_port)
+	assert.Equal(t, "192.168.1.5", misc.waypoint_udp_hostname)
+	assert.Equal(t, 10110, misc.waypoint_udp_portnum)
+	assert.Equal(t, WPL_FORMAT_NMEA_GENERIC|WPL_FORMAT_AIS|WPL_FORMAT_KENWOOD, misc.waypoint_formats)
+
+	_, misc = configFromString(t, "WAYPOINT TCP:0\n")
+	assert.Zero(t, misc.waypoint_tcp_port)
+}
+
 // --- config_init KISSPORT directive ---
 
 func Test_config_init_kissport(t *testing.T) {
@@ -482,6 +894,20 @@ func Test_config_init_kissport(t *testing.T) {
 	})
 }
 
+func Test_config_init_kissclient(t *testing.T) {
+	var _, misc = configFromString(t, "KISSCLIENT remote.example.net:8001 1\nKISSCLIENT [::1]:8002\n")
+	assert.Equal(t, "remote.example.net:8001", misc.kiss_client_addr[0])
+	assert.Equal(t, 1, misc.kiss_client_chan[0])
+	assert.Equal(t, "[::1]:8002", misc.kiss_client_addr[1])
+	assert.Equal(t, -1, misc.kiss_client_chan[1], "all channels")
+	assert.Equal(t, DEFAULT_KISS_PORT, misc.kiss_port[0], "still listening too")
+
+	for _, bad := range []string{"KISSCLIENT", "KISSCLIENT remote.example.net", "KISSCLIENT :8001", "KISSCLIENT remote.example.net:99999", "KISSCLIENT remote.example.net:8001 99"} {
+		_, misc = configFromString(t, bad+"\n")
+		assert.Empty(t, misc.kiss_client_addr[0], bad)
+	}
+}
+
 // --- config_init MODEM all-options success ---
 
 func Test_config_init_modem_returns_success(t *testing.T) {
@@ -535,6 +961,57 @@ func Test_config_init_beacon_sendto_non_numeric(t *testing.T) {
 	})
 }
 
+// --- config_init SENDTO radio and IGate ---
+
+func Test_config_init_beacon_sendto_both(t *testing.T) {
+	var _, misc = configFromString(t, "MYCALL Q1TEST\nPBEACON LAT=42^37.14N LONG=71^20.83W SENDTO=0,IG RFCOMMENT=rf ISCOMMENT=\"via the Internet\"\n"+
+		"PBEACON LAT=42^37.14N LONG=71^20.83W SENDTO=IG\n"+
+		"PBEACON LAT=42^37.14N LONG=71^20.83W SENDTO=ig,0\n")
+	require.Equal(t, 3, misc.num_beacons)
+
+	assert.Equal(t, SENDTO_XMIT, misc.beacon[0].sendto_type)
+	assert.Equal(t, 0, misc.beacon[0].sendto_chan)
+	assert.True(t, misc.beacon[0].also_igate)
+	assert.Equal(t, "rf", misc.beacon[0].rf_comment)
+	assert.Equal(t, "via the Internet", misc.beacon[0].is_comment)
+
+	assert.Equal(t, SENDTO_IGATE, misc.beacon[1].sendto_type)
+	assert.False(t, misc.beacon[1].also_igate)
+
+	assert.Equal(t, SENDTO_XMIT, misc.beacon[2].sendto_type)
+	assert.True(t, misc.beacon[2].also_igate, "either order")
+}
+
+// --- config_init TBEACON MAXHDOP and NOFIX ---
+
+func Test_config_init_tbeacon_nofix(t *testing.T) {
+	var _, misc = configFromString(t, "MYCALL Q1TEST\nTBEACON MAXHDOP=2.5 NOFIX=\"no GPS fix\"\n")
+	require.Equal(t, 1, misc.num_beacons)
+	assert.InDelta(t, 2.5, misc.beacon[0].max_hdop, 0.001)
+	assert.Equal(t, "no GPS fix", misc.beacon[0].nofix)
+
+	_, misc = configFromString(t, "MYCALL Q1TEST\nTBEACON MAXHDOP=-1\n")
+	require.Equal(t, 1, misc.num_beacons)
+	assert.Zero(t, misc.beacon[0].max_hdop)
+	assert.Empty(t, misc.beacon[0].nofix)
+}
+
+// --- config_init beacon JITTER and AFTER ---
+
+func Test_config_init_beacon_jitter_after(t *testing.T) {
+	var _, misc = configFromString(t, "MYCALL Q1TEST\nTBEACON JITTER=0:45 AFTER=GPS\nPBEACON LAT=42^37.14N LONG=71^20.83W AFTER=ig\n")
+	require.Equal(t, 2, misc.num_beacons)
+	assert.Equal(t, 45, misc.beacon[0].jitter)
+	assert.Equal(t, BEACON_AFTER_GPS, misc.beacon[0].after)
+	assert.Zero(t, misc.beacon[1].jitter)
+	assert.Equal(t, BEACON_AFTER_IGATE, misc.beacon[1].after)
+
+	_, misc = configFromString(t, "MYCALL Q1TEST\nTBEACON SLOT=0:30 JITTER=1 AFTER=sunrise\n")
+	require.Equal(t, 1, misc.num_beacons)
+	assert.Zero(t, misc.beacon[0].jitter, "not with SLOT")
+	assert.Equal(t, BEACON_AFTER_START, misc.beacon[0].after)
+}
+
 // --- config_init SENDTO beacon option (empty value) ---
 
 func Test_config_init_beacon_sendto_empty(t *testing.T) {
@@ -560,3 +1037,296 @@ func Test_config_init_pbeacon_no_options(t *testing.T) {
 		})
 	})
 }
+
+// --- config_init TTPOINT, TTVECTOR, and TTGRID ---
+
+func ttConfigFromString(t *testing.T, content string) *tt_config_s {
+	t.Helper()
+
+	var tmpFile, err = os.CreateTemp(t.TempDir(), "direwolf*.conf")
+	require.NoError(t, err)
+	_, err = tmpFile.WriteString(content)
+	require.NoError(t, err)
+	require.NoError(t, tmpFile.Close())
+
+	var audioConfig = new(audio_s)
+	var digiConfig digi_config_s
+	var cdigiConfig cdigi_config_s
+	var ttConfig tt_config_s
+	var igateConfig igate_config_s
+	var miscConfig misc_config_s
+
+	config_init(tmpFile.Name(), audioConfig, &digiConfig, &cdigiConfig,
+		&ttConfig, &igateConfig, &miscConfig)
+
+	return &ttConfig
+}
+
+func Test_config_init_ttloc_point_vector_grid(t *testing.T) {
+	var tt = ttConfigFromString(t, `
+TTPOINT  B01  37^55.37N  81^7.86W
+TTVECTOR  B5bbbddd  37^55.37N  81^7.86W  0.01  mi
+TTVECTOR  B5bbbdddd  53^00.00N  1^00.00W  1  km
+TTGRID   Byyyxxx  37^50.00N  81^00.00W  37^59.99N  81^09.99W
+`)
+
+	require.Len(t, tt.ttlocs, 4)
+
+	assert.Equal(t, TTLOC_POINT, tt.ttlocs[0].ttlocType)
+	assert.Equal(t, "B01", tt.ttlocs[0].pattern)
+	assert.InDelta(t, 37+55.37/60., tt.ttlocs[0].point.lat, 0.00001)
+	assert.InDelta(t, -(81 + 7.86/60.), tt.ttlocs[0].point.lon, 0.00001)
+
+	assert.Equal(t, TTLOC_VECTOR, tt.ttlocs[1].ttlocType)
+	assert.Equal(t, "B5bbbddd", tt.ttlocs[1].pattern)
+	assert.InDelta(t, 16.09344, tt.ttlocs[1].vector.scale, 0.00001)
+
+	assert.Equal(t, TTLOC_VECTOR, tt.ttlocs[2].ttlocType)
+	assert.InDelta(t, 53., tt.ttlocs[2].vector.lat, 0.00001)
+	assert.InDelta(t, -1., tt.ttlocs[2].vector.lon, 0.00001)
+	assert.InDelta(t, 1000., tt.ttlocs[2].vector.scale, 0.00001)
+
+	assert.Equal(t, TTLOC_GRID, tt.ttlocs[3].ttlocType)
+	assert.Equal(t, "Byyyxxx", tt.ttlocs[3].pattern)
+	assert.InDelta(t, 37+50./60., tt.ttlocs[3].grid.lat0, 0.00001)
+	assert.InDelta(t, -81., tt.ttlocs[3].grid.lon0, 0.00001)
+	assert.InDelta(t, 37+59.99/60., tt.ttlocs[3].grid.lat9, 0.00001)
+	assert.InDelta(t, -(81 + 9.99/60.), tt.ttlocs[3].grid.lon9, 0.00001)
+
+	// Missing arguments leave nothing behind.
+	tt = ttConfigFromString(t, "TTPOINT B01 37^55.37N\nTTVECTOR B5bbbddd 37^55.37N 81^7.86W\nTTGRID Byyyxxx 37^50.00N\n")
+	assert.Empty(t, tt.ttlocs)
+}
+
+// --- config_init TTUTM, TTUSNG, and TTMGRS ---
+
+func Test_config_init_ttloc_utm_usng_mgrs(t *testing.T) {
+	var tt = ttConfigFromString(t, `
+TTUTM  B6xxxyyy  19T  10  300000  4720000
+TTUSNG  B7xxxyyy  19tch
+TTMGRS  B8xxyy  19TCH
+`)
+
+	require.Len(t, tt.ttlocs, 3)
+
+	assert.Equal(t, TTLOC_UTM, tt.ttlocs[0].ttlocType)
+	assert.Equal(t, 19, tt.ttlocs[0].utm.lzone)
+	assert.Equal(t, 'T', tt.ttlocs[0].utm.latband)
+	assert.Equal(t, 'N', tt.ttlocs[0].utm.hemi)
+	assert.InDelta(t, 10., tt.ttlocs[0].utm.scale, 0.001)
+	assert.InDelta(t, 300000., tt.ttlocs[0].utm.x_offset, 0.001)
+	assert.InDelta(t, 4720000., tt.ttlocs[0].utm.y_offset, 0.001)
+
+	assert.Equal(t, TTLOC_USNG, tt.ttlocs[1].ttlocType)
+	assert.Equal(t, "19tch", tt.ttlocs[1].mgrs.zone)
+
+	assert.Equal(t, TTLOC_MGRS, tt.ttlocs[2].ttlocType)
+	assert.Equal(t, "19TCH", tt.ttlocs[2].mgrs.zone)
+
+	// Each of these fails the check or practice conversion and is dropped.
+	tt = ttConfigFromString(t, `
+TTUTM  B6xxxzzz  19T
+TTUTM  B6xxxyyy  61T
+TTUTM  B6xxxyyy  19T  1  0  0
+TTUSNG  B7xxxyy  19TCH
+TTMGRS  B8xxyy  19TIH
+`)
+	assert.Empty(t, tt.ttlocs)
+}
+
+// --- config_init TTMHEAD, TTSATSQ, and TTAMBIG ---
+
+func Test_config_init_ttloc_mhead_satsq_ambig(t *testing.T) {
+	var tt = ttConfigFromString(t, `
+TTMHEAD  BAxxxxxx  326129
+TTMHEAD  BAxxxx
+TTSATSQ  BAxxxx
+TTAMBIG  BAx
+`)
+
+	require.Len(t, tt.ttlocs, 4)
+
+	assert.Equal(t, TTLOC_MHEAD, tt.ttlocs[0].ttlocType)
+	assert.Equal(t, "BAxxxxxx", tt.ttlocs[0].pattern)
+	assert.Equal(t, "326129", tt.ttlocs[0].mhead.prefix)
+
+	assert.Equal(t, TTLOC_MHEAD, tt.ttlocs[1].ttlocType)
+	assert.Empty(t, tt.ttlocs[1].mhead.prefix)
+
+	assert.Equal(t, TTLOC_SATSQ, tt.ttlocs[2].ttlocType)
+	assert.Equal(t, "BAxxxx", tt.ttlocs[2].pattern)
+
+	assert.Equal(t, TTLOC_AMBIG, tt.ttlocs[3].ttlocType)
+	assert.Equal(t, "BAx", tt.ttlocs[3].pattern)
+
+	// Each of these is rejected.
+	tt = ttConfigFromString(t, `
+TTMHEAD  BAxxxyyy
+TTMHEAD  BAxxxxx  326129
+TTMHEAD  BAxxxx  32612
+TTMHEAD  BAxxxx  9999
+TTSATSQ  BAxxx
+TTAMBIG  BAxx
+TTAMBIG  Ax
+`)
+	assert.Empty(t, tt.ttlocs)
+}
+
+// --- config_init TTMACRO ---
+
+func Test_config_init_ttmacro(t *testing.T) {
+	var tt = ttConfigFromString(t, `
+TTMACRO  xxyyy  B9xx*AB166*AA2B4C5B3B0Ayyy
+TTMACRO  xxyyy  B9xx*AC{Q1TEST}*AA{ABC}*AB{ambulance}*CA{Hi!}*Ayyy
+TTMACRO  99xx  B01*ACxx
+`)
+
+	require.Len(t, tt.ttlocs, 3)
+
+	assert.Equal(t, TTLOC_MACRO, tt.ttlocs[0].ttlocType)
+	assert.Equal(t, "xxyyy", tt.ttlocs[0].pattern)
+	assert.Equal(t, "B9xx*AB166*AA2B4C5B3B0Ayyy", tt.ttlocs[0].macro.definition)
+
+	assert.Equal(t, "B9xx*AC1183781133*AA2A2B2C*AB165*CA407301*Ayyy", tt.ttlocs[1].macro.definition)
+
+	assert.Equal(t, "B01*ACxx", tt.ttlocs[2].macro.definition)
+
+	// Each of these has errors and is skipped.
+	tt = ttConfigFromString(t, `
+TTMACRO  xxyyw  B9xx*Ayyy
+TTMACRO  xxyyy  B9xx*AC{Q1TEST*Ayyy
+TTMACRO  xxyyy  B9xx*AC{Q1TESTXYZ}*Ayyy
+TTMACRO  xxyyy  B9xx*AE*Ayyy
+TTMACRO  xxyyy
+`)
+	assert.Empty(t, tt.ttlocs)
+}
+
+// --- config_init TTOBJ, TTERR, TTSTATUS, and TTCMD ---
+
+func Test_config_init_ttobj(t *testing.T) {
+	var tt = ttConfigFromString(t, "TTOBJ 0 0,APP WIDE2-1\n")
+	assert.Equal(t, 1, tt.gateway_enabled)
+	assert.Equal(t, 0, tt.obj_recv_chan)
+	assert.Equal(t, 0, tt.obj_xmit_chan)
+	assert.Equal(t, 1, tt.obj_send_to_app)
+	assert.Equal(t, 0, tt.obj_send_to_ig)
+	assert.Equal(t, "WIDE2-1", tt.obj_xmit_via)
+
+	tt = ttConfigFromString(t, "TTOBJ 0 IG\n")
+	assert.Equal(t, 1, tt.gateway_enabled)
+	assert.Equal(t, -1, tt.obj_xmit_chan)
+	assert.Equal(t, 1, tt.obj_send_to_ig)
+
+	// Channel 1 isn't configured.
+	tt = ttConfigFromString(t, "TTOBJ 1 APP\n")
+	assert.Equal(t, 0, tt.gateway_enabled)
+
+	tt = ttConfigFromString(t, "TTOBJ 0 1\n")
+	assert.Equal(t, 0, tt.gateway_enabled)
+}
+
+func Test_config_init_tterr(t *testing.T) {
+	var tt = ttConfigFromString(t, `
+TTERR  OK  SPEECH  Message Received.
+TTERR  INVALID_CALL  morse-6  BAD CALL
+TTERR  NO_SUCH  MORSE  ?
+TTERR  D_MSG  BEEP  ?
+`)
+
+	assert.Equal(t, "SPEECH", tt.response[TT_ERROR_OK].method)
+	assert.Equal(t, "Message Received.", tt.response[TT_ERROR_OK].mtext)
+
+	assert.Equal(t, "MORSE-6", tt.response[TT_ERROR_INVALID_CALL].method)
+	assert.Equal(t, "BAD CALL", tt.response[TT_ERROR_INVALID_CALL].mtext)
+
+	// Defaults are kept.
+	assert.Equal(t, "MORSE", tt.response[TT_ERROR_D_MSG].method)
+	assert.Equal(t, "?", tt.response[TT_ERROR_D_MSG].mtext)
+}
+
+func Test_config_init_ttstatus_ttcmd(t *testing.T) {
+	var tt = ttConfigFromString(t, `
+TTSTATUS  9  /At the finish line
+TTSTATUS  10  /Too many
+TTCMD  /usr/local/bin/tt-response.sh
+`)
+
+	assert.Equal(t, "/enroute", tt.status[2])
+	assert.Equal(t, "/At the finish line", tt.status[9])
+	assert.Equal(t, "/usr/local/bin/tt-response.sh", tt.ttcmd)
+}
+
+// --- config_init SPEECH ---
+
+func Test_config_init_speech(t *testing.T) {
+	var audio, _ = configFromString(t, "SPEECH sh\n")
+	assert.Equal(t, "sh", audio.tts_script)
+	assert.Empty(t, audio.tts_espeak)
+
+	audio, _ = configFromString(t, "SPEECH /no/such/dwespeak.sh\n")
+	assert.Empty(t, audio.tts_script)
+
+	audio, _ = configFromString(t, "SPEECH espeak en-us\n")
+	assert.Empty(t, audio.tts_script)
+	assert.Equal(t, speech_espeak_find(), audio.tts_espeak)
+	assert.Equal(t, "en-us", audio.tts_voice)
+}
+
+// --- config_init DTMFPIN and DTMFCMD ---
+
+func Test_config_init_dtmfcmd(t *testing.T) {
+	var tt = ttConfigFromString(t, `
+DTMFCMD  11  BEACON
+DTMFPIN  4321a
+DTMFCMD  11  BEACON
+DTMFCMD  20  IGATE OFF
+DTMFCMD  21  igate on
+DTMFCMD  3a  RUN  systemctl restart gate
+DTMFCMD  11  IGATE ON
+DTMFCMD  40  IGATE
+DTMFCMD  41  RUN
+DTMFCMD  4E  BEACON
+DTMFCMD  42  REBOOT
+`)
+
+	assert.Equal(t, "4321A", tt.remote_pin)
+
+	require.Len(t, tt.remote_cmds, 4)
+	assert.Equal(t, dtmf_remote_cmd_s{code: "11", action: DTMF_REMOTE_BEACON}, *tt.remote_cmds[0]) //nolint:exhaustruct
+	assert.Equal(t, DTMF_REMOTE_IGATE_OFF, tt.remote_cmds[1].action)
+	assert.Equal(t, DTMF_REMOTE_IGATE_ON, tt.remote_cmds[2].action)
+	assert.Equal(t, dtmf_remote_cmd_s{code: "3A", action: DTMF_REMOTE_RUN, command: "systemctl restart gate"}, *tt.remote_cmds[3])
+
+	tt = ttConfigFromString(t, "DTMFPIN 123\nDTMFPIN 12345678901234567\nDTMFPIN 12#4\n")
+	assert.Empty(t, tt.remote_pin)
+}
+
+func Test_config_init_responsegap(t *testing.T) {
+	var audio, _ = configFromString(t, "RESPONSEGAP 10 2\nCHANNEL 1\nRESPONSEGAP 0\n")
+	assert.Equal(t, 10, audio.achan[0].response_gap)
+	assert.Equal(t, 2, audio.achan[0].response_max)
+	assert.Equal(t, 0, audio.achan[1].response_gap)
+	assert.Equal(t, DEFAULT_RESPONSE_MAX, audio.achan[1].response_max)
+}
+
+func Test_config_init_dtmf_only(t *testing.T) {
+	var audio, _ = configFromString(t, "DTMF ONLY\nTTOBJ 0 APP\nCHANNEL 1\nDTMF\nDTMF OFF\nDTMF maybe\n")
+	assert.Equal(t, DTMF_DECODE_ONLY, audio.achan[0].dtmf_decode, "TTOBJ keeps ONLY")
+	assert.Equal(t, DTMF_DECODE_OFF, audio.achan[1].dtmf_decode)
+
+	audio, _ = configFromString(t, "TTOBJ 0 APP\n")
+	assert.Equal(t, DTMF_DECODE_ON, audio.achan[0].dtmf_decode)
+}
+
+func Test_config_init_cwid(t *testing.T) {
+	var audio, _ = configFromString(t, "CWID 10\nCHANNEL 1\nCWID 9:30 15\n")
+	assert.Equal(t, 600, audio.achan[0].cwid_every)
+	assert.Equal(t, DEFAULT_CWID_WPM, audio.achan[0].cwid_wpm)
+	assert.Equal(t, 570, audio.achan[1].cwid_every)
+	assert.Equal(t, 15, audio.achan[1].cwid_wpm)
+
+	audio, _ = configFromString(t, "CWID 0:30\nCHANNEL 1\nCWID 10 99\n")
+	assert.Zero(t, audio.achan[0].cwid_every, "too often")
+	assert.Zero(t, audio.achan[1].cwid_every)
+}
@@ -0,0 +1,610 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Let an operator inspect the effective configuration of
+ *		a running instance, and turn debug output up or down,
+ *		without restarting it.
+ *
+ * Description:	Enabled with CONTROLSOCKET in the configuration file, e.g.
+ *
+ *			CONTROLSOCKET /run/samoyed/control.sock
+ *
+ *		This listens on a Unix domain socket, so access is
+ *		controlled by the file system permissions of the socket
+ *		and its directory.  The socket is only accessible to
+ *		the user and group we run as.  The protocol is one line of text per
+ *		command, so something like socat is enough for a client:
+ *
+ *			socat - UNIX-CONNECT:/run/samoyed/control.sock
+ *
+ *		Commands:
+ *
+ *			show channels
+ *			show beacons
+ *			show igate
+ *			show position
+ *			show profiles
+ *			set loglevel <area> <level>
+ *			set position <lat> <lon> [alt=m] [speed=knots] [course=deg]
+ *			set profile <channel> <name>
+ *			inject <channel> <packet>
+ *			help
+ *
+ *		The area for set loglevel is the same letter as the
+ *		-d command line option, e.g. "set loglevel o 1" is
+ *		like -d o for PTT.  Level 0 turns it off again.
+ *
+ *		set position is for an external system to give our
+ *		location, with GPSAPI in the configuration file.
+ *		Latitude and longitude are decimal degrees, negative
+ *		for south and west.
+ *
+ *		set profile tunes the radio and changes the modem for
+ *		a channel to one of its PROFILEs.  See profile.go.
+ *
+ *		inject handles a packet, in monitor format, as if it had
+ *		just been received on the channel.  It bypasses the modem
+ *		and goes everywhere a received packet does: digipeater,
+ *		IGate, client applications, logs.  This is for trying out
+ *		a configuration without a radio, or replaying a log, e.g.
+ *
+ *			inject 0 Q1TEST>APRS,WIDE1-1:!4237.14N/07120.83W-
+ *
+ *		Be careful with a live station.  It might transmit the
+ *		result or send it to the APRS-IS servers.
+ *
+ *		Each reply ends with a line "OK", or a line starting
+ *		with "ERROR:", so a script knows when it has it all.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// controlDebugArea is something whose debug level can be changed at run time.
+type controlDebugArea struct {
+	description string
+	set         func(level int)
+}
+
+// ControlService answers queries on the control socket.
+type ControlService struct {
+	audioConfigP *audio_s
+	igateConfigP *igate_config_s
+	miscConfigP  *misc_config_s
+	debugAreas   map[rune]controlDebugArea
+	listener     net.Listener
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        NewControlService
+ *
+ * Purpose:     Prepare the control socket service.
+ *		This is called once from the main program.
+ *
+ * Inputs:	ac, ic, mc	- Configuration to report.
+ *				  mc.control_socket empty means disabled.
+ *
+ * Description:	Debug areas are added afterward with AddDebugArea
+ *		because they belong to services started elsewhere.
+ *		Nothing is listening until Start is called, after
+ *		they have all been added.
+ *
+ *--------------------------------------------------------------------*/
+
+func NewControlService(ac *audio_s, ic *igate_config_s, mc *misc_config_s) *ControlService {
+	var cs = new(ControlService)
+	cs.audioConfigP = ac
+	cs.igateConfigP = ic
+	cs.miscConfigP = mc
+	cs.debugAreas = make(map[rune]controlDebugArea)
+
+	return cs
+}
+
+// AddDebugArea makes a debug level adjustable with "set loglevel".
+// The set function is called from the control socket's goroutine, so
+// the level it changes must be safe to read from others, e.g. atomic.
+// All areas must be added before Start.
+func (cs *ControlService) AddDebugArea(area rune, description string, set func(level int)) {
+	Assert(cs.listener == nil)
+
+	cs.debugAreas[area] = controlDebugArea{description: description, set: set}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        Start
+ *
+ * Purpose:     Start listening on the control socket, if configured.
+ *
+ * Description:	The socket is created with permission only for its
+ *		owner and group, whatever the umask, because anyone who
+ *		can connect can change how we run and inject packets.
+ *		The directory permissions can restrict it further.
+ *
+ *		Close removes the socket again.
+ *
+ *--------------------------------------------------------------------*/
+
+const CONTROL_SOCKET_UMASK = 0o117 /* srw-rw---- */
+
+func (cs *ControlService) Start() {
+	var mc = cs.miscConfigP
+
+	if mc.control_socket == "" {
+		return
+	}
+
+	// Left behind if we didn't exit cleanly last time.  Only remove a socket, never anything else.
+	var info, statErr = os.Lstat(mc.control_socket)
+	if statErr == nil && info.Mode()&os.ModeSocket != 0 {
+		_ = os.Remove(mc.control_socket)
+	}
+
+	// The umask applies to the whole process, but this happens during start up before anything else creates files.
+	var oldUmask = syscall.Umask(CONTROL_SOCKET_UMASK)
+	var listener, listenErr = net.Listen("unix", mc.control_socket)
+	syscall.Umask(oldUmask)
+
+	if listenErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Could not create control socket %s: %s\n", mc.control_socket, listenErr)
+
+		return
+	}
+
+	cs.listener = listener
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("Ready to accept control commands on %s ...\n", mc.control_socket)
+
+	go cs.acceptThread()
+}
+
+// Close stops listening and removes the socket file.
+func (cs *ControlService) Close() {
+	if cs == nil || cs.listener == nil {
+		return
+	}
+
+	// A Unix socket listener removes its file when closed.
+	_ = cs.listener.Close()
+}
+
+func (cs *ControlService) acceptThread() {
+	for {
+		var conn, acceptErr = cs.listener.Accept()
+		if acceptErr != nil {
+			if errors.Is(acceptErr, net.ErrClosed) {
+				return
+			}
+
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Control socket accept failed: %s\n", acceptErr)
+
+			continue
+		}
+
+		go cs.clientThread(conn)
+	}
+}
+
+func (cs *ControlService) clientThread(conn net.Conn) {
+	defer conn.Close()
+
+	var scanner = bufio.NewScanner(conn)
+	for scanner.Scan() {
+		var reply = cs.command(scanner.Text())
+		if _, err := conn.Write([]byte(reply)); err != nil {
+			return
+		}
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        command
+ *
+ * Purpose:     Process one line from a control client.
+ *
+ * Inputs:	line	- Command, e.g. "show channels".  Case doesn't matter.
+ *
+ * Returns:	Complete reply, ending with "OK" or "ERROR: ..." line.
+ *
+ *--------------------------------------------------------------------*/
+
+func (cs *ControlService) command(line string) string {
+	var words = strings.Fields(line)
+	if len(words) == 0 {
+		return ""
+	}
+
+	var reply strings.Builder
+	var err error
+
+	// A packet can have spaces in it, so inject gets the rest of the line as is.
+
+	var key = strings.ToLower(strings.Join(words[:min(2, len(words))], " "))
+
+	var verb, rest, _ = strings.Cut(strings.TrimSpace(line), " ")
+	if strings.EqualFold(verb, "inject") {
+		key = "inject"
+	}
+
+	switch key {
+	case "show channels":
+		cs.showChannels(&reply)
+	case "show beacons":
+		cs.showBeacons(&reply)
+	case "show igate":
+		cs.showIGate(&reply)
+	case "show position":
+		cs.showPosition(&reply)
+	case "show profiles":
+		cs.showProfiles(&reply)
+	case "set loglevel":
+		err = cs.setLogLevel(&reply, words[2:])
+	case "set position":
+		err = cs.setPosition(&reply, words[2:])
+	case "set profile":
+		err = cs.setProfile(&reply, words[2:])
+	case "inject":
+		err = cs.inject(&reply, rest)
+	case "help":
+		cs.help(&reply)
+	default:
+		err = fmt.Errorf("unknown command %q, try help", line)
+	}
+
+	if err != nil {
+		fmt.Fprintf(&reply, "ERROR: %s\n", err)
+	} else {
+		reply.WriteString("OK\n")
+	}
+
+	return reply.String()
+}
+
+func (cs *ControlService) help(w *strings.Builder) {
+	w.WriteString("show channels\n")
+	w.WriteString("show beacons\n")
+	w.WriteString("show igate\n")
+	w.WriteString("show position\n")
+	w.WriteString("show profiles\n")
+	w.WriteString("set loglevel <area> <level>\n")
+	w.WriteString("set position <lat> <lon> [alt=m] [speed=knots] [course=deg]\n")
+	w.WriteString("set profile <channel> <name>\n")
+	w.WriteString("inject <channel> <packet>\n")
+
+	var areas = make([]rune, 0, len(cs.debugAreas))
+	for area := range cs.debugAreas {
+		areas = append(areas, area)
+	}
+
+	sort.Slice(areas, func(i, j int) bool { return areas[i] < areas[j] })
+
+	for _, area := range areas {
+		fmt.Fprintf(w, "	%c	%s\n", area, cs.debugAreas[area].description)
+	}
+}
+
+func (cs *ControlService) showChannels(w *strings.Builder) {
+	var ac = cs.audioConfigP
+
+	for ch := range MAX_TOTAL_CHANS {
+		var medium string
+
+		switch ac.chan_medium[ch] {
+		case MEDIUM_RADIO:
+			var a = &ac.achan[ch]
+			medium = fmt.Sprintf("radio, audio device %d, %d baud", ACHAN2ADEV(ch), a.baud)
+
+			if a.mark_freq != 0 && a.space_freq != 0 {
+				medium += fmt.Sprintf(" %d:%d", a.mark_freq, a.space_freq)
+			}
+
+			if a.octrl[OCTYPE_PTT].ptt_method == PTT_METHOD_NONE {
+				medium += ", no PTT"
+			}
+		case MEDIUM_IGATE:
+			medium = "APRS-IS"
+		case MEDIUM_NETTNC:
+			if ac.nettnc_bridge[ch] {
+				medium = fmt.Sprintf("bridge to channel %d of %s:%d", ac.nettnc_remote_chan[ch], ac.nettnc_addr[ch], ac.nettnc_port[ch])
+			} else {
+				medium = fmt.Sprintf("network TNC %s:%d", ac.nettnc_addr[ch], ac.nettnc_port[ch])
+			}
+		default:
+			continue
+		}
+
+		var mycall = ac.mycall[ch]
+		if IsNoCall(mycall) {
+			mycall = "(no MYCALL)"
+		}
+
+		fmt.Fprintf(w, "%d%s: %s, %s\n", ch, chan_name_suffix(ac, ch), mycall, medium)
+	}
+}
+
+func (cs *ControlService) showBeacons(w *strings.Builder) {
+	var mc = cs.miscConfigP
+
+	var names = map[beacon_type_e]string{
+		BEACON_IGNORE:   "ignored",
+		BEACON_POSITION: "PBEACON",
+		BEACON_OBJECT:   "OBEACON",
+		BEACON_TRACKER:  "TBEACON",
+		BEACON_CUSTOM:   "CBEACON",
+		BEACON_IGATE:    "IBEACON",
+	}
+
+	for k := 0; k < mc.num_beacons; k++ {
+		var b = &mc.beacon[k]
+
+		var sendto string
+
+		switch b.sendto_type {
+		case SENDTO_XMIT:
+			sendto = "channel " + strconv.Itoa(b.sendto_chan)
+		case SENDTO_IGATE:
+			sendto = "APRS-IS"
+		case SENDTO_RECV:
+			sendto = "receive channel " + strconv.Itoa(b.sendto_chan)
+		default:
+			sendto = "?"
+		}
+
+		if b.also_igate {
+			sendto += " and APRS-IS"
+		}
+
+		fmt.Fprintf(w, "line %d: %s to %s every %d:%02d", b.lineno, names[b.btype], sendto, b.every/60, b.every%60)
+
+		if b.lat != G_UNKNOWN && b.lon != G_UNKNOWN {
+			fmt.Fprintf(w, " at %.4f %.4f", b.lat, b.lon)
+		}
+
+		w.WriteString("\n")
+	}
+}
+
+func (cs *ControlService) showIGate(w *strings.Builder) {
+	var ic = cs.igateConfigP
+
+	if ic.t2_server_name == "" || ic.t2_login == "" {
+		w.WriteString("IGate not configured.\n")
+
+		return
+	}
+
+	// Never show the passcode itself.
+	var passcode = "set"
+	if ic.t2_passcode == "" {
+		passcode = "not set"
+	}
+
+	fmt.Fprintf(w, "server %s:%d, login %s, passcode %s\n", ic.t2_server_name, ic.t2_server_port, ic.t2_login, passcode)
+
+	if ic.t2_filter != "" {
+		fmt.Fprintf(w, "server filter %s\n", ic.t2_filter)
+	}
+
+	if ic.tx_chan >= 0 {
+		fmt.Fprintf(w, "IS>RF on channel %d via %q, limit %d/1 min %d/5 min\n", ic.tx_chan, ic.tx_via, ic.tx_limit_1, ic.tx_limit_5)
+	} else {
+		w.WriteString("IS>RF disabled\n")
+	}
+}
+
+func (cs *ControlService) setLogLevel(w *strings.Builder, args []string) error {
+	if len(args) != 2 || len([]rune(args[0])) != 1 {
+		return errors.New("usage: set loglevel <area> <level>")
+	}
+
+	var area = []rune(args[0])[0]
+
+	var da, found = cs.debugAreas[area]
+	if !found {
+		return fmt.Errorf("unknown area %q, see help", args[0])
+	}
+
+	var level, levelErr = strconv.Atoi(args[1])
+	if levelErr != nil || level < 0 {
+		return fmt.Errorf("level must be a number, 0 or more, not %q", args[1])
+	}
+
+	da.set(level)
+
+	fmt.Fprintf(w, "%s debug level now %d\n", da.description, level)
+
+	return nil
+}
+
+func (cs *ControlService) showPosition(w *strings.Builder) {
+	var gpsinfo dwgps_info_t
+
+	var fix = dwgps_read(&gpsinfo)
+	if fix < DWFIX_2D {
+		w.WriteString("No position.\n")
+
+		return
+	}
+
+	fmt.Fprintf(w, "%.6f %.6f from %s", gpsinfo.dlat, gpsinfo.dlon, gpsinfo.source)
+
+	if fix == DWFIX_3D {
+		fmt.Fprintf(w, ", altitude %.0f m", gpsinfo.altitude)
+	}
+
+	if gpsinfo.speed_knots != G_UNKNOWN {
+		fmt.Fprintf(w, ", %.1f knots", gpsinfo.speed_knots)
+	}
+
+	if gpsinfo.track != G_UNKNOWN {
+		fmt.Fprintf(w, ", course %.0f", gpsinfo.track)
+	}
+
+	fmt.Fprintf(w, ", %s ago\n", time.Since(gpsinfo.timestamp).Round(time.Second))
+}
+
+func (cs *ControlService) setPosition(w *strings.Builder, args []string) error {
+	const usage = "usage: set position <lat> <lon> [alt=m] [speed=knots] [course=deg]"
+
+	if len(args) < 2 {
+		return errors.New(usage)
+	}
+
+	var lat, latErr = strconv.ParseFloat(args[0], 64)
+	var lon, lonErr = strconv.ParseFloat(args[1], 64)
+
+	if latErr != nil || lonErr != nil {
+		return errors.New(usage)
+	}
+
+	var alt, speed, course float64 = G_UNKNOWN, G_UNKNOWN, G_UNKNOWN
+
+	for _, arg := range args[2:] {
+		var keyword, value, _ = strings.Cut(arg, "=")
+
+		var n, err = strconv.ParseFloat(value, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not a number, %s", arg, usage)
+		}
+
+		switch strings.ToLower(keyword) {
+		case "alt":
+			alt = n
+		case "speed":
+			speed = n
+		case "course":
+			course = n
+		default:
+			return fmt.Errorf("unexpected %q, %s", arg, usage)
+		}
+	}
+
+	var err = dwgps_set_position(lat, lon, alt, speed, course)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Position now %.6f %.6f\n", lat, lon)
+
+	return nil
+}
+
+/* e.g. "0: *day, 144.390000 MHz FM, 1200 baud 1200:2200, at 07:00" */
+
+func (cs *ControlService) showProfiles(w *strings.Builder) {
+	if profileSvc == nil {
+		w.WriteString("No profiles.\n")
+
+		return
+	}
+
+	var ac = cs.audioConfigP
+
+	for ch := range MAX_RADIO_CHANS {
+		if ac.chan_medium[ch] != MEDIUM_RADIO {
+			continue
+		}
+
+		var active = profileSvc.Active(ch)
+
+		for _, p := range ac.achan[ch].chan_profiles {
+			var mark = " "
+			if p.name == active {
+				mark = "*"
+			}
+
+			var modem = profileSvc.modemFor(ch, p)
+
+			fmt.Fprintf(w, "%d: %s%s%s\n", ch, mark, p.name, p.describe(&modem))
+		}
+	}
+}
+
+func (cs *ControlService) setProfile(w *strings.Builder, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: set profile <channel> <name>")
+	}
+
+	var channel, err = strconv.Atoi(args[0])
+	if err != nil {
+		return fmt.Errorf("channel must be a number, not %q", args[0])
+	}
+
+	var switchErr = profileSvc.Switch(channel, args[1])
+	if switchErr != nil {
+		return switchErr
+	}
+
+	fmt.Fprintf(w, "Channel %d now using profile %s\n", channel, profileSvc.Active(channel))
+
+	return nil
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        inject
+ *
+ * Purpose:     Handle a packet as if it was received on a channel.
+ *
+ * Inputs:	args	- Channel, then the packet in monitor format.
+ *
+ * Description:	This goes into the received frame queue like one
+ *		from a network TNC, so the audio level isn't shown.
+ *
+ *--------------------------------------------------------------------*/
+
+func (cs *ControlService) inject(w *strings.Builder, args string) error {
+	const usage = "usage: inject <channel> <packet>"
+
+	var first, monitor, _ = strings.Cut(strings.TrimSpace(args), " ")
+	monitor = strings.TrimSpace(monitor)
+
+	if first == "" || monitor == "" {
+		return errors.New(usage)
+	}
+
+	var channel, err = strconv.Atoi(first)
+	if err != nil {
+		return fmt.Errorf("channel must be a number, not %q", first)
+	}
+
+	if channel < 0 || channel >= MAX_TOTAL_CHANS || cs.audioConfigP.chan_medium[channel] == MEDIUM_NONE {
+		return fmt.Errorf("channel %d is not configured", channel)
+	}
+
+	// Anything from the air has to be valid AX.25.  Only APRS-IS can have
+	// lower case or long names, such as "qAR" or "T2NUENGLD".
+
+	var strict = cs.audioConfigP.chan_medium[channel] != MEDIUM_IGATE
+
+	var pp = AX25FromText(monitor, strict)
+	if pp == nil {
+		return fmt.Errorf("can't parse packet %q", monitor)
+	}
+
+	var alevel ALevel
+
+	dlq_rec_frame(channel, -3, 0, pp, alevel, fec_type_none, RETRY_NONE, "Injected")
+
+	fmt.Fprintf(w, "Injected on channel %d\n", channel)
+
+	return nil
+}
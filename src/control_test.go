@@ -0,0 +1,173 @@
+package direwolf
+
+import (
+	"bufio"
+	"net"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestControlService() *ControlService {
+	var ac = new(audio_s)
+	var ic = new(igate_config_s)
+	var mc = new(misc_config_s)
+
+	config_set_defaults(ac, new(digi_config_s), new(tt_config_s), ic, mc)
+
+	ac.chan_medium[0] = MEDIUM_RADIO
+	ac.mycall[0] = "Q1TEST"
+	ac.chan_name[0] = "VHF"
+
+	return NewControlService(ac, ic, mc)
+}
+
+func Test_control_show_channels(t *testing.T) {
+	var cs = newTestControlService()
+	cs.audioConfigP.chan_medium[3] = MEDIUM_NETTNC
+	cs.audioConfigP.nettnc_addr[3] = "localhost"
+	cs.audioConfigP.nettnc_port[3] = 8001
+
+	var reply = cs.command("show channels")
+
+	assert.Contains(t, reply, "0 VHF: Q1TEST, radio, audio device 0, 1200 baud 1200:2200, no PTT\n")
+	assert.Contains(t, reply, "3: (no MYCALL), network TNC localhost:8001\n")
+	assert.True(t, strings.HasSuffix(reply, "OK\n"))
+}
+
+func Test_control_show_beacons(t *testing.T) {
+	var cs = newTestControlService()
+
+	var b = &cs.miscConfigP.beacon[0]
+	cs.miscConfigP.num_beacons = 1
+	b.btype = BEACON_POSITION
+	b.lineno = 12
+	b.sendto_type = SENDTO_XMIT
+	b.every = 600
+	b.lat = 42.5
+	b.lon = -71.25
+
+	assert.Equal(t, "line 12: PBEACON to channel 0 every 10:00 at 42.5000 -71.2500\nOK\n", cs.command("show beacons"))
+}
+
+func Test_control_show_igate(t *testing.T) {
+	var cs = newTestControlService()
+	assert.Equal(t, "IGate not configured.\nOK\n", cs.command("SHOW IGATE"))
+
+	cs.igateConfigP.t2_server_name = "noam.aprs2.net"
+	cs.igateConfigP.t2_login = "Q1TEST-10"
+	cs.igateConfigP.t2_passcode = "12345"
+
+	var reply = cs.command("show igate")
+	assert.Contains(t, reply, "login Q1TEST-10, passcode set")
+	assert.NotContains(t, reply, "12345")
+	assert.Contains(t, reply, "IS>RF disabled")
+}
+
+func Test_control_set_loglevel(t *testing.T) {
+	var cs = newTestControlService()
+
+	var got = -1
+	cs.AddDebugArea('o', "Output control", func(level int) { got = level })
+
+	assert.Equal(t, "Output control debug level now 2\nOK\n", cs.command("set loglevel o 2"))
+	assert.Equal(t, 2, got)
+
+	assert.Contains(t, cs.command("set loglevel z 1"), "ERROR: unknown area")
+	assert.Contains(t, cs.command("set loglevel o lots"), "ERROR: level must be a number")
+	assert.Contains(t, cs.command("set loglevel"), "ERROR: usage")
+	assert.Contains(t, cs.command("help"), "	o	Output control\n")
+	assert.Contains(t, cs.command("reboot"), "ERROR: unknown command")
+	assert.Empty(t, cs.command("   "))
+}
+
+func Test_control_position(t *testing.T) {
+	var cs = newTestControlService()
+
+	dwgps_test_reset(t, dwgps_default_priority, 30*time.Second)
+
+	assert.Equal(t, "No position.\nOK\n", cs.command("show position"))
+	assert.Equal(t, "ERROR: GPSAPI is not in the configuration file\n", cs.command("set position 42.5 -71.25"))
+
+	dwgps_test_enable_api(t)
+
+	assert.True(t, strings.HasPrefix(cs.command("set position 42.5"), "ERROR: usage:"))
+	assert.True(t, strings.HasPrefix(cs.command("set position 42.5 -71.25 height=3"), "ERROR: unexpected"))
+	assert.True(t, strings.HasPrefix(cs.command("set position 42.5 -71.25 alt=high"), "ERROR: \"alt=high\" is not a number"))
+
+	assert.Equal(t, "Position now 42.500000 -71.250000\nOK\n", cs.command("set position 42.5 -71.25 alt=33 speed=12.5 course=270"))
+	assert.Equal(t, "42.500000 -71.250000 from API, altitude 33 m, 12.5 knots, course 270, 0s ago\nOK\n", cs.command("show position"))
+}
+
+func Test_control_inject(t *testing.T) {
+	var cs = newTestControlService()
+	cs.audioConfigP.chan_medium[2] = MEDIUM_IGATE
+
+	dlq_init()
+
+	assert.Equal(t, "Injected on channel 0\nOK\n", cs.command("inject 0 Q2OTHR>APRS,WIDE1-1:>Hello  there"))
+
+	var item = dlq_remove()
+	require.NotNil(t, item)
+	assert.Equal(t, 0, item._chan)
+	assert.Equal(t, -3, item.subchan, "like a network TNC")
+	assert.Equal(t, "Q2OTHR>APRS,WIDE1-1:", AX25FormatAddrs(item.pp))
+	assert.Equal(t, ">Hello  there", string(AX25GetInfo(item.pp)), "spaces left alone")
+
+	// Only APRS-IS can have names like these.
+
+	assert.True(t, strings.HasPrefix(cs.command("inject 0 Q2OTHR>APRS,TCPIP*,qAC,T2NUENGLD:!"), "ERROR: can't parse"))
+	assert.Equal(t, "Injected on channel 2\nOK\n", cs.command("INJECT 2 Q2OTHR>APRS,TCPIP*,qAC,T2NUENGLD:!"))
+
+	item = dlq_remove()
+	require.NotNil(t, item)
+	assert.Equal(t, 2, item._chan)
+
+	assert.True(t, strings.HasPrefix(cs.command("inject 0"), "ERROR: usage:"))
+	assert.True(t, strings.HasPrefix(cs.command("inject zero Q2OTHR>APRS:!"), "ERROR: channel must be a number"))
+	assert.Equal(t, "ERROR: channel 1 is not configured\n", cs.command("inject 1 Q2OTHR>APRS:!"))
+	assert.Nil(t, dlq_remove())
+}
+
+func Test_control_socket(t *testing.T) {
+	var ac = new(audio_s)
+	var ic = new(igate_config_s)
+	var mc = new(misc_config_s)
+	mc.control_socket = filepath.Join(t.TempDir(), "control.sock")
+
+	var cs = NewControlService(ac, ic, mc)
+	cs.Start()
+	require.NotNil(t, cs.listener)
+	t.Cleanup(cs.Close)
+
+	var info, statErr = os.Stat(mc.control_socket)
+	require.NoError(t, statErr)
+	assert.Equal(t, os.FileMode(0o660), info.Mode().Perm())
+
+	var conn, err = net.Dial("unix", mc.control_socket)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	_, err = conn.Write([]byte("show igate\n"))
+	require.NoError(t, err)
+
+	var reader = bufio.NewReader(conn)
+
+	var line, readErr = reader.ReadString('\n')
+	require.NoError(t, readErr)
+	assert.Equal(t, "IGate not configured.\n", line)
+
+	line, readErr = reader.ReadString('\n')
+	require.NoError(t, readErr)
+	assert.Equal(t, "OK\n", line)
+
+	cs.Close()
+
+	_, statErr = os.Lstat(mc.control_socket)
+	assert.True(t, os.IsNotExist(statErr), "socket file left behind")
+}
@@ -0,0 +1,90 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Identify in Morse code, for transmissions which don't
+ *		otherwise carry our call, such as EAS or M17 tests.
+ *
+ * Description:	CWID  minutes  [ wpm ]  for a radio channel.
+ *
+ *		Every so many minutes, if the channel has transmitted
+ *		anything since the last ID, send MYCALL in Morse code.
+ *		Nothing is sent while the channel is quiet, and the ID
+ *		itself doesn't count as something to identify.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"time"
+)
+
+const DEFAULT_CWID_WPM = 20
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	cwid_due
+ *
+ * Purpose:	Is an ID needed?
+ *
+ * Inputs:	last_tx	- End of the last transmission which wasn't an ID.
+ *
+ *		last_id	- When we last sent an ID.  Zero for never.
+ *
+ *--------------------------------------------------------------------*/
+
+func cwid_due(last_tx time.Time, last_id time.Time) bool {
+	return !last_tx.IsZero() && last_tx.After(last_id)
+}
+
+/* Monitor format, for tq_append.  The destination SSID gives the speed. */
+
+func cwid_text(mycall string, wpm int) string {
+	return fmt.Sprintf("%s>MORSE-%d:%s", mycall, wpm/2, mycall)
+}
+
+func cwid_thread(channel int, mycall string, every time.Duration, wpm int) {
+	var last_id time.Time
+
+	for {
+		time.Sleep(every)
+
+		if !cwid_due(txActivity.LastTransmit(channel), last_id) {
+			continue
+		}
+
+		var pp = AX25FromText(cwid_text(mycall, wpm), false)
+		if pp == nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Can't send CW ID \"%s\" for channel %d.\n", mycall, channel)
+
+			return
+		}
+
+		pp.tx_reason = TX_REASON_CWID
+		tq_append(channel, TQ_PRIO_1_LO, pp)
+
+		last_id = time.Now()
+	}
+}
+
+/* Start a thread for each channel with CWID. */
+
+func cwid_init(p_audio_config *audio_s) {
+	for c := range MAX_RADIO_CHANS {
+		if p_audio_config.chan_medium[c] != MEDIUM_RADIO || p_audio_config.achan[c].cwid_every == 0 {
+			continue
+		}
+
+		if IsNoCall(p_audio_config.mycall[c]) {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("MYCALL must be set for CWID on channel %d.\n", c)
+
+			continue
+		}
+
+		go cwid_thread(c, p_audio_config.mycall[c],
+			time.Duration(p_audio_config.achan[c].cwid_every)*time.Second,
+			p_audio_config.achan[c].cwid_wpm)
+	}
+}
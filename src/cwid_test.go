@@ -0,0 +1,27 @@
+package direwolf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_cwid_due(t *testing.T) {
+	var now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	assert.False(t, cwid_due(time.Time{}, time.Time{}), "never transmitted")
+	assert.True(t, cwid_due(now, time.Time{}), "never identified")
+	assert.True(t, cwid_due(now, now.Add(-10*time.Minute)))
+	assert.False(t, cwid_due(now.Add(-11*time.Minute), now.Add(-10*time.Minute)), "quiet since the last ID")
+}
+
+func Test_cwid_text(t *testing.T) {
+	var pp = AX25FromText(cwid_text("Q1TEST-1", 20), false)
+	require.NotNil(t, pp)
+
+	assert.Equal(t, FLAVOR_MORSE, frame_flavor(pp))
+	assert.Equal(t, 10, ax25_get_ssid(pp, AX25_DESTINATION), "twice this is the speed")
+	assert.Equal(t, "Q1TEST-1", string(AX25GetInfo(pp)))
+}
@@ -26,6 +26,8 @@ import (
 	"strings"
 	"time"
 	"unicode"
+
+	"github.com/doismellburning/samoyed/src/geo"
 )
 
 type packet_type_e int
@@ -163,7 +165,7 @@ type decode_aprs_t struct {
 	g_footprint_lon    float64 /* Set all to G_UNKNOWN if not used. */
 	g_footprint_radius float64 /* Radius in miles. */
 
-	g_query_callsign string //nolint:unused
+	g_query_callsign string
 	/* Directed query may contain callsign.  */
 	/* e.g. tell me all objects from that callsign. */
 
@@ -610,10 +612,13 @@ func decode_aprs_print(A *decode_aprs_t) {
 
 	if len(A.g_maidenhead) > 0 {
 		if A.g_lat == G_UNKNOWN && A.g_lon == G_UNKNOWN {
-			var lat, lon, err = ll_from_grid_square(A.g_maidenhead)
+			var lat, lon, err = geo.FromGridSquare(A.g_maidenhead)
 			if err == nil {
 				A.g_lat = lat
 				A.g_lon = lon
+			} else {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("%s\n", err)
 			}
 		}
 
@@ -2372,7 +2377,7 @@ func aprs_general_query(A *decode_aprs_t, info []byte, quiet bool) { //nolint:un
 	after = bytes.TrimSpace(after)
 
 	var parts = bytes.Split(after, []byte{','})
-	if len(parts) != 3 {
+	if len(parts) == 3 {
 		var lat, latErr = strconv.ParseFloat(string(parts[0]), 64)
 
 		if latErr != nil || lat < -90 || lat > 90 {
@@ -2395,7 +2400,7 @@ func aprs_general_query(A *decode_aprs_t, info []byte, quiet bool) { //nolint:un
 			return
 		}
 
-		var radius, radiusErr = strconv.ParseFloat(string(parts[1]), 64)
+		var radius, radiusErr = strconv.ParseFloat(string(parts[2]), 64)
 
 		if radiusErr != nil || radius <= 0 || radius > 9999 {
 			if !A.g_quiet {
@@ -2478,14 +2483,22 @@ Andrew, KA2DDO
 author of YAAC
 */
 
-func aprs_directed_station_query(A *decode_aprs_t, addressee []byte, query []byte, quiet bool) {
-	//char query_type[20];		/* Does the query type always need to be exactly 5 characters? */
-	/* If not, how would we know where the extra optional information starts? */
+func aprs_directed_station_query(A *decode_aprs_t, addressee []byte, query []byte, quiet bool) { //nolint:unparam
+	/* Does the query type always need to be exactly 5 characters? */
+	/* Not for the newer ?VER and ?INFO.  Anything longer than 5 */
+	/* must be one of the originals followed by a callsign. */
 
-	//char callsign[AX25_MAX_ADDR_LEN];
+	// Ignore any message number.
 
-	//if (strlen(query) < 5) ...
+	query, _, _ = bytes.Cut(query, []byte{'{'})
+	query = bytes.TrimSpace(query)
+
+	if len(query) > 5 {
+		A.g_query_callsign = strings.ToUpper(string(bytes.TrimSpace(query[5:])))
+		query = query[:5]
+	}
 
+	A.g_query_type = strings.ToUpper(string(query))
 } /* end aprs_directed_station_query */
 
 /*------------------------------------------------------------------
@@ -3141,7 +3154,7 @@ func decode_position(A *decode_aprs_t, ppos *position_t) {
 
 func decode_compressed_position(A *decode_aprs_t, pcpos *compressed_position_t) {
 	if isdigit91(pcpos.Y[0]) && isdigit91(pcpos.Y[1]) && isdigit91(pcpos.Y[2]) && isdigit91(pcpos.Y[3]) {
-		A.g_lat = 90 - float64((pcpos.Y[0]-33)*91*91*91+(pcpos.Y[1]-33)*91*91+(pcpos.Y[2]-33)*91+(pcpos.Y[3]-33))/380926.0
+		A.g_lat = 90 - float64((int(pcpos.Y[0])-33)*91*91*91+(int(pcpos.Y[1])-33)*91*91+(int(pcpos.Y[2])-33)*91+(int(pcpos.Y[3])-33))/380926.0
 	} else {
 		if !A.g_quiet {
 			text_color_set(DW_COLOR_ERROR)
@@ -3152,7 +3165,7 @@ func decode_compressed_position(A *decode_aprs_t, pcpos *compressed_position_t)
 	}
 
 	if isdigit91(pcpos.X[0]) && isdigit91(pcpos.X[1]) && isdigit91(pcpos.X[2]) && isdigit91(pcpos.X[3]) {
-		A.g_lon = -180 + float64((pcpos.X[0]-33)*91*91*91+(pcpos.X[1]-33)*91*91+(pcpos.X[2]-33)*91+(pcpos.X[3]-33))/190463.0
+		A.g_lon = -180 + float64((int(pcpos.X[0])-33)*91*91*91+(int(pcpos.X[1])-33)*91*91+(int(pcpos.X[2])-33)*91+(int(pcpos.X[3])-33))/190463.0
 	} else {
 		if !A.g_quiet {
 			text_color_set(DW_COLOR_ERROR)
@@ -3776,7 +3789,7 @@ func data_extension_comment(A *decode_aprs_t, pdext []byte) bool { //nolint:unpa
 
 		/* Bearing and Number/Range/Quality? */
 
-		if pdext[7] == '/' && pdext[11] == '/' {
+		if len(pdext) >= 15 && pdext[7] == '/' && pdext[11] == '/' {
 			process_comment(A, pdext[7+8:])
 		} else {
 			process_comment(A, pdext[7:])
@@ -0,0 +1,164 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Let decode_aprs read packet captures made by other tools.
+ *
+ * Description:	A file named on the command line is recognized as one of:
+ *
+ *		pcap	- As written by tcpdump, Wireshark, etc.  The link
+ *			  type must be DLT_AX25 (3) or DLT_AX25_KISS (202).
+ *			  The newer pcapng format is not handled; Wireshark
+ *			  can save as pcap.
+ *
+ *		KISS	- Raw bytes as sent to or from a KISS TNC, e.g.
+ *			  captured with "socat -u TCP:localhost:8001 - > file".
+ *			  Recognized by FEND as the first byte.
+ *
+ *		text	- Anything else is read a line at a time, the same
+ *			  as stdin.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+const PCAP_MAGIC = 0xa1b2c3d4      // Microsecond time stamps.
+const PCAP_MAGIC_NANO = 0xa1b23c4d // Nanosecond time stamps.
+
+const PCAP_HEADER_LEN = 24
+const PCAP_RECORD_HEADER_LEN = 16
+
+const DLT_AX25 = 3
+const DLT_AX25_KISS = 202
+
+func decode_aprs_file(name string) error {
+	var data, err = os.ReadFile(name) //nolint:gosec // Named on the command line.
+	if err != nil {
+		return err
+	}
+
+	if len(data) >= 4 {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			var magic = order.Uint32(data)
+			if magic == PCAP_MAGIC || magic == PCAP_MAGIC_NANO {
+				return decode_aprs_pcap(data, order)
+			}
+		}
+	}
+
+	if len(data) > 0 && data[0] == FEND {
+		decode_aprs_kiss_stream(data)
+
+		return nil
+	}
+
+	decode_aprs_text(bytes.NewReader(data))
+
+	return nil
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        decode_aprs_pcap
+ *
+ * Purpose:     Decode each frame in a pcap file.
+ *
+ * Inputs:	data	- Whole file.
+ *
+ *		order	- Byte order, from the magic number.
+ *
+ * Returns:	Error if the file isn't usable.  A truncated final
+ *		record, as when the capture was interrupted, is
+ *		reported but not treated as an error.
+ *
+ *--------------------------------------------------------------------*/
+
+func decode_aprs_pcap(data []byte, order binary.ByteOrder) error {
+	if len(data) < PCAP_HEADER_LEN {
+		return errors.New("pcap file header is incomplete")
+	}
+
+	var nano = order.Uint32(data) == PCAP_MAGIC_NANO
+
+	// Upper bits of the link type field are sometimes used for FCS information.
+	var linktype = order.Uint32(data[20:24]) & 0xffff
+	if linktype != DLT_AX25 && linktype != DLT_AX25_KISS {
+		return fmt.Errorf("pcap link type is %d, expected DLT_AX25 (%d) or DLT_AX25_KISS (%d)", linktype, DLT_AX25, DLT_AX25_KISS)
+	}
+
+	var offset = PCAP_HEADER_LEN
+
+	for n := 1; offset < len(data); n++ {
+		if offset+PCAP_RECORD_HEADER_LEN > len(data) {
+			fmt.Printf("\npcap record %d is incomplete.\n", n)
+
+			return nil
+		}
+
+		var sec = order.Uint32(data[offset:])
+		var frac = order.Uint32(data[offset+4:])
+		var incl_len = int(order.Uint32(data[offset+8:]))
+
+		offset += PCAP_RECORD_HEADER_LEN
+
+		if offset+incl_len > len(data) {
+			fmt.Printf("\npcap record %d is incomplete.\n", n)
+
+			return nil
+		}
+
+		var packet = data[offset : offset+incl_len]
+		offset += incl_len
+
+		if !nano {
+			frac *= 1000
+		}
+
+		var t = time.Unix(int64(sec), int64(frac)).UTC()
+
+		fmt.Printf("\n--- pcap record %d, %s ---\n", n, t.Format("2006-01-02T15:04:05.000Z"))
+
+		if len(packet) == 0 {
+			fmt.Printf("Empty.\n")
+
+			continue
+		}
+
+		if linktype == DLT_AX25_KISS {
+			// Type indicator byte, then the frame without any KISS escapes.
+			if packet[0]&0x0f != 0 {
+				fmt.Printf("Not a data frame.  KISS type indicator is 0x%02x.\n", packet[0])
+
+				continue
+			}
+
+			packet = packet[1:]
+		}
+
+		decode_aprs_frame(packet)
+	}
+
+	return nil
+}
+
+/*
+ * Raw KISS byte stream.  Each frame is between FENDs.
+ */
+
+func decode_aprs_kiss_stream(data []byte) {
+	for kiss_frame := range bytes.SplitSeq(data, []byte{FEND}) {
+		if len(kiss_frame) == 0 {
+			continue // Back to back FENDs are allowed.
+		}
+
+		fmt.Printf("\n")
+		decode_aprs_kiss(kiss_frame)
+	}
+}
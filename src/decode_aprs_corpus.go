@@ -0,0 +1,280 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Check the APRS decoder against a collection of sample
+ *		packets with known results.
+ *
+ * Description:	decode_aprs --corpus DIR reads every *.txt file in DIR.
+ *		Each file contains one or more cases separated by blank
+ *		lines.  A case starts with the packet, in monitoring
+ *		format or as hexadecimal bytes of an AX.25 frame, followed
+ *		by the decoded fields expected, e.g.
+ *
+ *			# Comments are allowed.
+ *			packet: WB2OSZ-1>APN383,qAR,N1EDU-2:!4237.14NS07120.83W#PHG7130Chelmsford, MA
+ *			latitude: 42.6190
+ *			longitude: -71.3472
+ *			symbol: S#
+ *			mfr: Kantronics KPC-3 rom versions
+ *
+ *		Only the fields listed are checked.  Numbers are compared
+ *		to as many decimal places as the expected value has, so
+ *		42.6190 matches 42.61900000001.  "unknown" is expected for
+ *		a number that isn't present.
+ *
+ *		The intent is for the expected values to come from the
+ *		original C implementation, so differences in the port
+ *		are found as soon as they are introduced.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+var corpusFields = map[string]func(A *decode_aprs_t) string{
+	"src":            func(A *decode_aprs_t) string { return A.g_src },
+	"dest":           func(A *decode_aprs_t) string { return A.g_dest },
+	"type":           func(A *decode_aprs_t) string { return A.g_data_type_desc },
+	"symbol":         func(A *decode_aprs_t) string { return string([]byte{A.g_symbol_table, A.g_symbol_code}) },
+	"latitude":       func(A *decode_aprs_t) string { return corpus_float(A.g_lat) },
+	"longitude":      func(A *decode_aprs_t) string { return corpus_float(A.g_lon) },
+	"maidenhead":     func(A *decode_aprs_t) string { return A.g_maidenhead },
+	"name":           func(A *decode_aprs_t) string { return A.g_name },
+	"addressee":      func(A *decode_aprs_t) string { return A.g_addressee },
+	"message_number": func(A *decode_aprs_t) string { return A.g_message_number },
+	"speed_mph":      func(A *decode_aprs_t) string { return corpus_float(A.g_speed_mph) },
+	"course":         func(A *decode_aprs_t) string { return corpus_float(A.g_course) },
+	"altitude_ft":    func(A *decode_aprs_t) string { return corpus_float(A.g_altitude_ft) },
+	"power":          func(A *decode_aprs_t) string { return corpus_int(A.g_power) },
+	"height":         func(A *decode_aprs_t) string { return corpus_int(A.g_height) },
+	"gain":           func(A *decode_aprs_t) string { return corpus_int(A.g_gain) },
+	"directivity":    func(A *decode_aprs_t) string { return A.g_directivity },
+	"mfr":            func(A *decode_aprs_t) string { return A.g_mfr },
+	"mic_e_status":   func(A *decode_aprs_t) string { return A.g_mic_e_status },
+	"freq":           func(A *decode_aprs_t) string { return corpus_float(A.g_freq) },
+	"tone":           func(A *decode_aprs_t) string { return corpus_float(A.g_tone) },
+	"dcs":            func(A *decode_aprs_t) string { return corpus_int(A.g_dcs) },
+	"offset":         func(A *decode_aprs_t) string { return corpus_int(A.g_offset) },
+	"query_type":     func(A *decode_aprs_t) string { return A.g_query_type },
+	"weather":        func(A *decode_aprs_t) string { return A.g_weather },
+	"telemetry":      func(A *decode_aprs_t) string { return A.g_telemetry },
+	"comment":        func(A *decode_aprs_t) string { return A.g_comment },
+}
+
+func corpus_float(f float64) string {
+	if f == G_UNKNOWN {
+		return "unknown"
+	}
+
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
+
+func corpus_int(n int) string {
+	if n == G_UNKNOWN {
+		return "unknown"
+	}
+
+	return strconv.Itoa(n)
+}
+
+/*
+ * Compare numbers only to the precision of the expected value.
+ */
+
+func corpus_match(expected string, actual string) bool {
+	if expected == actual {
+		return true
+	}
+
+	var e, eErr = strconv.ParseFloat(expected, 64)
+
+	var a, aErr = strconv.ParseFloat(actual, 64)
+	if eErr != nil || aErr != nil {
+		return false
+	}
+
+	var decimals = 0
+	if _, frac, found := strings.Cut(expected, "."); found {
+		decimals = len(frac)
+	}
+
+	return math.Abs(e-a) <= 0.5*math.Pow(10, -float64(decimals))
+}
+
+var corpusHexPattern = regexp.MustCompile("^[[:xdigit:]]{2}( ?[[:xdigit:]]{2})*$")
+
+func corpus_packet(text string) *packet_t {
+	if corpusHexPattern.MatchString(text) {
+		var frame, err = hex.DecodeString(strings.ReplaceAll(text, " ", ""))
+		if err != nil {
+			return nil
+		}
+
+		var alevel ALevel
+
+		return AX25FromFrame(frame, alevel)
+	}
+
+	return AX25FromText(text, true)
+}
+
+type corpusCase struct {
+	file     string
+	line     int // Of the packet.
+	packet   string
+	expected map[string]string
+	lines    map[string]int // Where each expected value is, for reporting.
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        decode_aprs_corpus
+ *
+ * Purpose:     Decode every case in a corpus directory and report
+ *		where the results differ from those expected.
+ *
+ * Inputs:	dir	- Directory of *.txt files.
+ *
+ * Returns:	Number of mismatches, and error if the corpus
+ *		couldn't be read.
+ *
+ * Outputs:	A line on stdout for each mismatch, then a summary.
+ *
+ *--------------------------------------------------------------------*/
+
+func decode_aprs_corpus(dir string) (int, error) {
+	var files, err = filepath.Glob(filepath.Join(dir, "*.txt"))
+	if err != nil {
+		return 0, err
+	}
+
+	if len(files) == 0 {
+		return 0, fmt.Errorf("no *.txt files in %s", dir)
+	}
+
+	sort.Strings(files)
+
+	var count, mismatches int
+
+	for _, file := range files {
+		var cases, readErr = corpus_read(file)
+		if readErr != nil {
+			return mismatches, readErr
+		}
+
+		for _, c := range cases {
+			count++
+			mismatches += corpus_check(c)
+		}
+	}
+
+	fmt.Printf("%d packets, %d mismatches.\n", count, mismatches)
+
+	return mismatches, nil
+}
+
+func corpus_read(file string) ([]*corpusCase, error) {
+	var f, err = os.Open(file) //nolint:gosec // Named on the command line.
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close()
+
+	var cases []*corpusCase
+
+	var current *corpusCase
+
+	var scanner = bufio.NewScanner(f)
+	for lineno := 1; scanner.Scan(); lineno++ {
+		var line = scanner.Text()
+
+		if strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.TrimSpace(line) == "" {
+			current = nil
+
+			continue
+		}
+
+		var key, value, found = strings.Cut(line, ":")
+		if !found {
+			return nil, fmt.Errorf("%s:%d: expected \"field: value\"", file, lineno)
+		}
+
+		// One space after the colon is for readability.  Anything more is part of the value.
+		value = strings.TrimPrefix(value, " ")
+		key = strings.TrimSpace(key)
+
+		if key == "packet" {
+			current = new(corpusCase)
+			current.file = file
+			current.line = lineno
+			current.packet = value
+			current.expected = make(map[string]string)
+			current.lines = make(map[string]int)
+			cases = append(cases, current)
+
+			continue
+		}
+
+		if current == nil {
+			return nil, fmt.Errorf("%s:%d: %s before packet", file, lineno, key)
+		}
+
+		if _, known := corpusFields[key]; !known {
+			return nil, fmt.Errorf("%s:%d: unknown field %s", file, lineno, key)
+		}
+
+		current.expected[key] = value
+		current.lines[key] = lineno
+	}
+
+	return cases, scanner.Err()
+}
+
+func corpus_check(c *corpusCase) int {
+	var pp = corpus_packet(c.packet)
+	if pp == nil {
+		fmt.Printf("%s:%d: could not parse packet\n", c.file, c.line)
+
+		return 1
+	}
+
+	defer AX25Delete(pp)
+
+	var A = decode_aprs(pp, true, "")
+
+	var keys = make([]string, 0, len(c.expected))
+	for key := range c.expected {
+		keys = append(keys, key)
+	}
+
+	sort.Slice(keys, func(i, j int) bool { return c.lines[keys[i]] < c.lines[keys[j]] })
+
+	var mismatches = 0
+
+	for _, key := range keys {
+		var actual = corpusFields[key](A)
+		if !corpus_match(c.expected[key], actual) {
+			fmt.Printf("%s:%d: %s: expected %q, got %q\n", c.file, c.lines[key], key, c.expected[key], actual)
+
+			mismatches++
+		}
+	}
+
+	return mismatches
+}
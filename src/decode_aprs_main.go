@@ -25,6 +25,10 @@ package direwolf
  *		If it begins with 00 or C0 (which would be impossible for AX.25 address) process as KISS.
  *		Also print these formats.
  *
+ *		Files named on the command line are read instead of stdin.
+ *		These can also be packet captures from other tools, see
+ *		decode_aprs_capture.go.
+ *
  * Outputs:	stdout
  *
  * Description:	./decode_aprs < decode_aprs.txt
@@ -59,9 +63,12 @@ import (
 	"bufio"
 	"encoding/hex"
 	"fmt"
+	"io"
 	"os"
 	"regexp"
 	"strings"
+
+	"github.com/spf13/pflag"
 )
 
 func DecodeAPRSMain() {
@@ -72,7 +79,65 @@ func DecodeAPRSMain() {
 	deviceIDData = NewDeviceIDData()
 	aprsSymbolData = NewAPRSSymbolData()
 
-	var scanner = bufio.NewScanner(os.Stdin)
+	var corpus = pflag.String("corpus", "", "Check the decoder against the sample packets and expected results in this directory.")
+	var help = pflag.BoolP("help", "h", false, "Display help text.")
+
+	pflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s - explain APRS packets.\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Usage: decode_aprs [options] [ file ... ]\n")
+		pflag.PrintDefaults()
+	}
+
+	pflag.Parse()
+
+	if *help {
+		pflag.Usage()
+		os.Exit(1)
+	}
+
+	if *corpus != "" {
+		var mismatches, err = decode_aprs_corpus(*corpus)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s\n", err)
+			os.Exit(1)
+		}
+
+		if mismatches > 0 {
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	if pflag.NArg() == 0 {
+		decode_aprs_text(os.Stdin)
+
+		return
+	}
+
+	for _, arg := range pflag.Args() {
+		var err error
+
+		if arg == "-" {
+			decode_aprs_text(os.Stdin)
+		} else {
+			err = decode_aprs_file(arg)
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", arg, err)
+			os.Exit(1)
+		}
+	}
+}
+
+/*
+ * Text, one packet per line, in monitoring format or hexadecimal.
+ */
+
+func decode_aprs_text(r io.Reader) {
+	var scanner = bufio.NewScanner(r)
 	for scanner.Scan() {
 		var line = scanner.Text()
 		if len(line) == 0 || strings.HasPrefix(line, "#") {
@@ -128,51 +193,11 @@ func DecodeAPRSLine(line string) {
 		}
 
 		if bytes[0] == 0 {
-			// Treat as KISS.  Undo any KISS encoding.
-			var kiss_frame = bytes
-
-			fmt.Printf("--- KISS frame ---\n")
-			HexDump(kiss_frame)
-
-			// Put FEND at end to keep kiss_unwrap happy.
-			// Having one at the beginning is optional.
-
-			kiss_frame = append(kiss_frame, FEND)
-
-			// In the more general case, we would need to include
-			// the command byte because it could be escaped.
-			// Here we know it is 0, so we take a short cut and
-			// remove it before, rather than after, the conversion.
-
-			bytes = kiss_unwrap(kiss_frame[1:])
-		}
-
-		// Treat as AX.25.
-
-		var alevel ALevel
-
-		var pp = AX25FromFrame(bytes, alevel)
-		if pp != nil {
-			fmt.Printf("--- AX.25 frame ---\n")
-			ax25_hex_dump(pp)
-			fmt.Printf("-------------------\n")
-
-			var addrs = AX25FormatAddrs(pp)
-			fmt.Printf("%s", addrs)
-
-			var info = AX25GetInfo(pp)
-			AX25SafePrint(info, true) // Display non-ASCII to hexadecimal.
-			fmt.Printf("\n")
-
-			var A = decode_aprs(pp, false, "") // Extract information into structure.
-
-			decode_aprs_print(A) // Now print it in human readable format.
-
-			ax25_check_addresses(pp) // Errors for invalid addresses.
-
-			AX25Delete(pp)
+			// Treat as KISS.
+			decode_aprs_kiss(bytes)
 		} else {
-			fmt.Printf("Could not construct AX.25 frame from bytes supplied!\n\n")
+			// Treat as AX.25.
+			decode_aprs_frame(bytes)
 		}
 	} else {
 		// Normal monitoring format.
@@ -194,3 +219,59 @@ func DecodeAPRSLine(line string) {
 		}
 	}
 }
+
+/*
+ * KISS frame, as found between FENDs: type indicator then escaped AX.25 frame.
+ */
+
+func decode_aprs_kiss(kiss_frame []byte) {
+	fmt.Printf("--- KISS frame ---\n")
+	HexDump(kiss_frame)
+
+	// Put FEND at end to keep kiss_unwrap happy.
+	// Having one at the beginning is optional.
+
+	var unwrapped = kiss_unwrap(append(kiss_frame[:len(kiss_frame):len(kiss_frame)], FEND))
+	if len(unwrapped) == 0 {
+		return
+	}
+
+	if unwrapped[0]&0x0f != 0 {
+		fmt.Printf("Not a data frame.  KISS type indicator is 0x%02x.\n", unwrapped[0])
+		return
+	}
+
+	decode_aprs_frame(unwrapped[1:])
+}
+
+/*
+ * AX.25 frame without FCS.
+ */
+
+func decode_aprs_frame(frame []byte) {
+	var alevel ALevel
+
+	var pp = AX25FromFrame(frame, alevel)
+	if pp != nil {
+		fmt.Printf("--- AX.25 frame ---\n")
+		ax25_hex_dump(pp)
+		fmt.Printf("-------------------\n")
+
+		var addrs = AX25FormatAddrs(pp)
+		fmt.Printf("%s", addrs)
+
+		var info = AX25GetInfo(pp)
+		AX25SafePrint(info, true) // Display non-ASCII to hexadecimal.
+		fmt.Printf("\n")
+
+		var A = decode_aprs(pp, false, "") // Extract information into structure.
+
+		decode_aprs_print(A) // Now print it in human readable format.
+
+		ax25_check_addresses(pp) // Errors for invalid addresses.
+
+		AX25Delete(pp)
+	} else {
+		fmt.Printf("Could not construct AX.25 frame from bytes supplied!\n\n")
+	}
+}
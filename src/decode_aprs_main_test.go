@@ -1,7 +1,14 @@
 package direwolf
 
 import (
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"os"
+	"path/filepath"
 	"testing"
+
+	"github.com/stretchr/testify/require"
 )
 
 func Test_DecodeAPRSLine1(t *testing.T) {
@@ -59,3 +66,126 @@ func Test_DecodeAPRSLine3NoSpaces(t *testing.T) {
 		)
 	}, expected)
 }
+
+const echolinkFrameHex = "82a0aeae6260e0829668844040609c68b0ae8640e040ae92888a646303f03e454d36346e652f23204563686f6c696e6b203134352e3331302f313030687a20546f6e65"
+
+func writePcap(t *testing.T, linktype uint32, packets ...[]byte) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	var header = []any{uint32(PCAP_MAGIC), uint16(2), uint16(4), int32(0), uint32(0), uint32(65535), linktype}
+	for _, v := range header {
+		require.NoError(t, binary.Write(&buf, binary.LittleEndian, v))
+	}
+
+	for _, p := range packets {
+		for _, v := range []uint32{1752183342, 500000, uint32(len(p)), uint32(len(p))} { //nolint:gosec
+			require.NoError(t, binary.Write(&buf, binary.LittleEndian, v))
+		}
+
+		buf.Write(p)
+	}
+
+	var name = filepath.Join(t.TempDir(), "capture.pcap")
+	require.NoError(t, os.WriteFile(name, buf.Bytes(), 0o600))
+
+	return name
+}
+
+func Test_decode_aprs_file_pcap(t *testing.T) {
+	deviceIDData = NewDeviceIDData()
+
+	DECODE_APRS_UTIL = true
+
+	defer func() { DECODE_APRS_UTIL = false }()
+
+	var frame, _ = hex.DecodeString(echolinkFrameHex)
+
+	var name = writePcap(t, DLT_AX25, frame)
+
+	AssertOutputContains(t, func() { require.NoError(t, decode_aprs_file(name)) }, "Echolink")
+	AssertOutputContains(t, func() { require.NoError(t, decode_aprs_file(name)) }, "pcap record 1, 2025-07-10T21:35:42.500Z")
+
+	name = writePcap(t, DLT_AX25_KISS, append([]byte{0x06}, 0x01), append([]byte{0x00}, frame...))
+
+	AssertOutputContains(t, func() { require.NoError(t, decode_aprs_file(name)) }, "Not a data frame.  KISS type indicator is 0x06.")
+	AssertOutputContains(t, func() { require.NoError(t, decode_aprs_file(name)) }, "Echolink")
+
+	name = writePcap(t, 1)
+	require.ErrorContains(t, decode_aprs_file(name), "link type is 1")
+}
+
+func Test_decode_aprs_file_kiss(t *testing.T) {
+	deviceIDData = NewDeviceIDData()
+
+	DECODE_APRS_UTIL = true
+
+	defer func() { DECODE_APRS_UTIL = false }()
+
+	var frame, _ = hex.DecodeString(echolinkFrameHex)
+
+	var stream []byte
+	stream = append(stream, FEND, 0x00)
+	stream = append(stream, frame...)
+	stream = append(stream, FEND, FEND, 0x10) // Port 1.
+	stream = append(stream, frame...)
+	stream = append(stream, FEND)
+
+	var name = filepath.Join(t.TempDir(), "capture.kiss")
+	require.NoError(t, os.WriteFile(name, stream, 0o600))
+
+	AssertOutputContains(t, func() { require.NoError(t, decode_aprs_file(name)) }, "Echolink")
+}
+
+func Test_decode_aprs_file_text(t *testing.T) {
+	deviceIDData = NewDeviceIDData()
+
+	DECODE_APRS_UTIL = true
+
+	defer func() { DECODE_APRS_UTIL = false }()
+
+	var name = filepath.Join(t.TempDir(), "packets.txt")
+	require.NoError(t, os.WriteFile(name, []byte("# comment\nQ1TEST-1>APN383,qAR,Q2TEST-2:!4237.14NS07120.83W#PHG7130Chelmsford, MA\n"), 0o600))
+
+	AssertOutputContains(t, func() { require.NoError(t, decode_aprs_file(name)) }, "Kantronics")
+}
+
+func Test_decode_aprs_corpus(t *testing.T) {
+	deviceIDData = NewDeviceIDData()
+
+	DECODE_APRS_UTIL = true
+
+	defer func() { DECODE_APRS_UTIL = false }()
+
+	var mismatches, err = decode_aprs_corpus(filepath.Join("testdata", "decode_aprs"))
+	require.NoError(t, err)
+	require.Zero(t, mismatches)
+}
+
+func Test_decode_aprs_corpus_mismatch(t *testing.T) {
+	deviceIDData = NewDeviceIDData()
+
+	DECODE_APRS_UTIL = true
+
+	defer func() { DECODE_APRS_UTIL = false }()
+
+	var dir = t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.txt"), []byte(
+		"packet: Q1TEST>APDW17:!4903.50N/07201.75W-\nlatitude: 49.06\nlongitude: -72.1\n"), 0o600))
+
+	var mismatches int
+
+	AssertOutputContains(t, func() {
+		var err error
+		mismatches, err = decode_aprs_corpus(dir)
+		require.NoError(t, err)
+	}, "bad.txt:3: longitude: expected \"-72.1\", got \"-72.02916666666667\"")
+
+	require.Equal(t, 1, mismatches)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "bad.txt"), []byte("packet: Q1TEST>APDW17:>\ncolour: red\n"), 0o600))
+
+	var _, err = decode_aprs_corpus(dir)
+	require.ErrorContains(t, err, "unknown field colour")
+}
@@ -98,6 +98,7 @@ type DedupeService struct {
 	historyTime time.Duration /* Number of seconds to keep information */
 	insertNext  int           /* Index, in array below, where next item should be stored. */
 	history     [HISTORY_MAX]historyEntry
+	clock       Clock /* SystemClock, or FakeClock for testing. */
 }
 
 /*------------------------------------------------------------------------------
@@ -120,6 +121,7 @@ type DedupeService struct {
 func NewDedupeService(ttl time.Duration) *DedupeService {
 	var ds = new(DedupeService)
 	ds.historyTime = ttl
+	ds.clock = SystemClock{}
 	return ds
 }
 
@@ -160,7 +162,7 @@ func NewDedupeService(ttl time.Duration) *DedupeService {
  *------------------------------------------------------------------------------*/
 
 func (ds *DedupeService) Remember(pp *packet_t, channel int) {
-	ds.history[ds.insertNext].time_stamp = time.Now()
+	ds.history[ds.insertNext].time_stamp = ds.clock.Now()
 	ds.history[ds.insertNext].checksum = ax25_dedupe_crc(pp)
 	ds.history[ds.insertNext].xmit_channel = channel
 
@@ -193,7 +195,7 @@ func (ds *DedupeService) Remember(pp *packet_t, channel int) {
 
 func (ds *DedupeService) Check(pp *packet_t, channel int) bool {
 	var crc = ax25_dedupe_crc(pp)
-	var now = time.Now()
+	var now = ds.clock.Now()
 
 	for _, h := range ds.history {
 		if h.checksum != crc {
@@ -36,15 +36,15 @@ func Test_dedupe_check_expired_not_duplicate(t *testing.T) {
 	var pp = AX25FromText("W1AW>APRS:test packet", true)
 	require.NotNil(t, pp)
 
+	var clock = NewFakeClock(time.Now())
+	ds.clock = clock
+
 	ds.Remember(pp, 0)
 
-	// Wind the recorded timestamp back past the TTL so the entry is expired.
-	var idx = ds.insertNext - 1
-	if idx < 0 {
-		idx = HISTORY_MAX - 1
-	}
-	ds.history[idx].time_stamp = time.Now().Add(-1 * time.Hour)
+	clock.Advance(30 * time.Second)
+	assert.True(t, ds.Check(pp, 0), "still a duplicate right up to the TTL")
 
+	clock.Advance(time.Millisecond)
 	assert.False(t, ds.Check(pp, 0), "expired entry should not be considered a duplicate")
 }
 
@@ -55,720 +55,743 @@ func demod_init(pa *audio_s) int {
 
 	for channel := range MAX_RADIO_CHANS {
 		if save_audio_config_p.chan_medium[channel] == MEDIUM_RADIO {
+			demod_init_channel(channel)
+		}
+	} /* for chan ... */
+
+	// Now the virtual channels.  FIXME:  could be single loop.
+
+	for channel := MAX_RADIO_CHANS; channel < MAX_TOTAL_CHANS; channel++ {
+		// FIXME dw_printf ("-------- virtual channel loop %d \n", channel);
+		if channel == save_audio_config_p.igate_vchannel {
+			text_color_set(DW_COLOR_DEBUG)
+			dw_printf("Channel %d: IGate virtual channel.\n", channel)
+		}
+	}
+
+	return (0)
+} /* end demod_init */
+
+/*------------------------------------------------------------------
+ *
+ * Name:        demod_init_channel
+ *
+ * Purpose:     Set up the demodulator(s) for one radio channel.
+ *
+ * Description:	Part of demod_init, separated so a channel profile
+ *		can change the modem while the others keep running.
+ *
+ *----------------------------------------------------------------*/
+
+func demod_init_channel(channel int) {
+	/*
+	 * These are derived from config file parameters.
+	 *
+	 * num_subchan is number of demodulators.
+	 * This can be increased by:
+	 *	Multiple frequencies.
+	 *	Multiple letters (not sure if I will continue this).
+	 *
+	 * num_slicers is set to max by the "+" option.
+	 */
+	save_audio_config_p.achan[channel].num_subchan = 1
+	save_audio_config_p.achan[channel].num_slicers = 1
+
+	switch save_audio_config_p.achan[channel].modem_type {
+	case MODEM_OFF:
+
+	case MODEM_AFSK, MODEM_EAS:
+		if save_audio_config_p.achan[channel].modem_type == MODEM_EAS {
+			if save_audio_config_p.achan[channel].fix_bits != RETRY_NONE {
+				text_color_set(DW_COLOR_INFO)
+				dw_printf("Channel %d: FIX_BITS option has been turned off for EAS.\n", channel)
+				save_audio_config_p.achan[channel].fix_bits = RETRY_NONE
+			}
+
+			if save_audio_config_p.achan[channel].passall {
+				text_color_set(DW_COLOR_INFO)
+				dw_printf("Channel %d: PASSALL option has been turned off for EAS.\n", channel)
+				save_audio_config_p.achan[channel].passall = false
+			}
+		}
+
+		/*
+		 * Tear apart the profile and put it back together in a normalized form:
+		 *	- At least one letter, supply suitable default if necessary.
+		 *	- Upper case only.
+		 *	- Any plus will be at the end.
+		 */
+		var num_letters = 0
+		var just_letters string
+		var have_plus = 0
+
+		var profileStr = save_audio_config_p.achan[channel].profiles
+		for i, p := range profileStr {
+			if unicode.IsLower(p) {
+				just_letters += string(unicode.ToUpper(p))
+				num_letters++
+			} else if unicode.IsUpper(p) {
+				just_letters += string(p)
+				num_letters++
+			} else if p == '+' {
+				have_plus = 1
+
+				if i+1 != len(profileStr) {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Channel %d: + option must appear at end of demodulator types \"%s\" \n",
+						channel, save_audio_config_p.achan[channel].profiles)
+				}
+			} else if p == '-' {
+				have_plus = -1
+
+				if i+1 != len(profileStr) {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Channel %d: - option must appear at end of demodulator types \"%s\" \n",
+						channel, save_audio_config_p.achan[channel].profiles)
+				}
+			} else {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Channel %d: Demodulator types \"%s\" can contain only letters and + - characters.\n",
+					channel, save_audio_config_p.achan[channel].profiles)
+			}
+		}
+
+		Assert(num_letters == len(just_letters))
+
+		/*
+		 * Pick a good default demodulator if none specified.
+		 * Previously, we had "D" optimized for 300 bps.
+		 * Gone in 1.7 so it is always "A+".
+		 */
+		if num_letters == 0 {
+			just_letters = "A"
+			num_letters = 1
+
+			if have_plus != -1 {
+				have_plus = 1 // Add as default for version 1.2
+				// If not explicitly turned off.
+			}
+		}
+
+		/*
+		 * Special case for ARM.
+		 * The higher end ARM chips have loads of power but many people
+		 * are using a single core Pi Zero or similar.
+		 * (I'm still using a model 1 for my digipeater/IGate!)
+		 * Decreasing CPU requirement has a negligible impact on decoding performance.
+		 *
+		 * 	atest -PA- 01_Track_1.wav		--> 1002 packets decoded.
+		 * 	atest -PA- -D3 01_Track_1.wav		--> 997 packets decoded.
+		 *
+		 * Someone concerned about 1/2 of one percent difference can add "-D 1"
+		 */
+		/* TODO KG
+		#if __arm__
+			      if (save_audio_config_p.achan[channel].decimate == 0) {
+			        if (save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec > 40000) {
+			          save_audio_config_p.achan[channel].decimate = 3;
+			        }
+			      }
+		#endif
+		*/
+
+		/*
+		 * Number of filter taps is proportional to number of audio samples in a "symbol" duration.
+		 * These can get extremely large for low speeds, e.g. 300 baud.
+		 * In this case, increase the decimation ration.  Crude approximation. Could be improved.
+		 */
+		if save_audio_config_p.achan[channel].decimate == 0 &&
+			save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec > 40000 &&
+			save_audio_config_p.achan[channel].baud < 600 {
+			// Avoid enormous number of filter taps.
+			save_audio_config_p.achan[channel].decimate = 3
+		}
+
+		/*
+		 * Put it back together again.
+		 */
+		Assert(num_letters == len(just_letters))
+
+		/* At this point, have_plus can have 3 values: */
+		/* 	1 = turned on, either explicitly or by applied default */
+		/*	-1 = explicitly turned off.  change to 0 here so it is false. */
+		/* 	0 = off by default. */
+
+		if have_plus == -1 {
+			have_plus = 0
+		}
+
+		save_audio_config_p.achan[channel].profiles = just_letters
+
+		Assert(len(save_audio_config_p.achan[channel].profiles) >= 1)
+
+		if have_plus != 0 {
+			save_audio_config_p.achan[channel].profiles += "+"
+		}
+
+		/* These can be increased later for the multi-frequency case. */
+
+		save_audio_config_p.achan[channel].num_subchan = num_letters
+		save_audio_config_p.achan[channel].num_slicers = 1
+
+		/*
+		 * Some error checking - Can use only one of these:
+		 *
+		 *	- Multiple letters.
+		 *	- New + multi-slicer.
+		 *	- Multiple frequencies.
+		 */
+
+		if have_plus != 0 && save_audio_config_p.achan[channel].num_freq > 1 {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Channel %d: Demodulator + option can't be combined with multiple frequencies.\n", channel)
+			save_audio_config_p.achan[channel].num_subchan = 1 // Will be set higher later.
+			save_audio_config_p.achan[channel].num_freq = 1
+		}
+
+		if num_letters > 1 && save_audio_config_p.achan[channel].num_freq > 1 {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Channel %d: Multiple demodulator types can't be combined with multiple frequencies.\n", channel)
+
+			save_audio_config_p.achan[channel].profiles = string(save_audio_config_p.achan[channel].profiles[0])
+			num_letters = 1
+		}
+
+		if save_audio_config_p.achan[channel].decimate == 0 {
+			save_audio_config_p.achan[channel].decimate = 1
+			if strings.Contains(just_letters, "B") && save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec > 40000 {
+				save_audio_config_p.achan[channel].decimate = 3
+			}
+		}
+
+		text_color_set(DW_COLOR_DEBUG)
+		dw_printf("Channel %d: %d baud, AFSK %d & %d Hz, %s, %d sample rate",
+			channel, save_audio_config_p.achan[channel].baud,
+			save_audio_config_p.achan[channel].mark_freq, save_audio_config_p.achan[channel].space_freq,
+			save_audio_config_p.achan[channel].profiles,
+			save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec)
+
+		if save_audio_config_p.achan[channel].decimate != 1 {
+			dw_printf(" / %d", save_audio_config_p.achan[channel].decimate)
+		}
+
+		dw_printf(", Tx %s", layer2_tx[(save_audio_config_p.achan[channel].layer2_xmit)])
+
+		if save_audio_config_p.achan[channel].dtmf_decode == DTMF_DECODE_ONLY {
+			dw_printf(", DTMF decoder only, no packets")
+		} else if save_audio_config_p.achan[channel].dtmf_decode != DTMF_DECODE_OFF {
+			dw_printf(", DTMF decoder enabled")
+		}
+
+		dw_printf(".\n")
+
+		/*
+		 * Initialize the demodulator(s).
+		 *
+		 * We have 3 cases to consider.
+		 */
+
+		// TODO1.3: revisit this logic now that it is less restrictive.
+
+		if num_letters > 1 {
 			/*
-			 * These are derived from config file parameters.
+			 * Multiple letters, usually for 1200 baud.
+			 * Each one corresponds to a demodulator and subchannel.
 			 *
-			 * num_subchan is number of demodulators.
-			 * This can be increased by:
-			 *	Multiple frequencies.
-			 *	Multiple letters (not sure if I will continue this).
-			 *
-			 * num_slicers is set to max by the "+" option.
+			 * An interesting experiment but probably not too useful.
+			 * Can't have multiple frequency pairs.
+			 * In version 1.3 this can be combined with the + option.
 			 */
-			save_audio_config_p.achan[channel].num_subchan = 1
-			save_audio_config_p.achan[channel].num_slicers = 1
+			save_audio_config_p.achan[channel].num_subchan = num_letters
 
-			switch save_audio_config_p.achan[channel].modem_type {
-			case MODEM_OFF:
-
-			case MODEM_AFSK, MODEM_EAS:
-				if save_audio_config_p.achan[channel].modem_type == MODEM_EAS {
-					if save_audio_config_p.achan[channel].fix_bits != RETRY_NONE {
-						text_color_set(DW_COLOR_INFO)
-						dw_printf("Channel %d: FIX_BITS option has been turned off for EAS.\n", channel)
-						save_audio_config_p.achan[channel].fix_bits = RETRY_NONE
-					}
-
-					if save_audio_config_p.achan[channel].passall {
-						text_color_set(DW_COLOR_INFO)
-						dw_printf("Channel %d: PASSALL option has been turned off for EAS.\n", channel)
-						save_audio_config_p.achan[channel].passall = false
-					}
-				}
+			if save_audio_config_p.achan[channel].num_subchan != num_letters {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("INTERNAL ERROR, chan=%d, num_subchan(%d) != strlen(\"%s\")\n",
+					channel, save_audio_config_p.achan[channel].num_subchan, save_audio_config_p.achan[channel].profiles)
+			}
 
-				/*
-				 * Tear apart the profile and put it back together in a normalized form:
-				 *	- At least one letter, supply suitable default if necessary.
-				 *	- Upper case only.
-				 *	- Any plus will be at the end.
-				 */
-				var num_letters = 0
-				var just_letters string
-				var have_plus = 0
-
-				var profileStr = save_audio_config_p.achan[channel].profiles
-				for i, p := range profileStr {
-					if unicode.IsLower(p) {
-						just_letters += string(unicode.ToUpper(p))
-						num_letters++
-					} else if unicode.IsUpper(p) {
-						just_letters += string(p)
-						num_letters++
-					} else if p == '+' {
-						have_plus = 1
-
-						if i+1 != len(profileStr) {
-							text_color_set(DW_COLOR_ERROR)
-							dw_printf("Channel %d: + option must appear at end of demodulator types \"%s\" \n",
-								channel, save_audio_config_p.achan[channel].profiles)
-						}
-					} else if p == '-' {
-						have_plus = -1
-
-						if i+1 != len(profileStr) {
-							text_color_set(DW_COLOR_ERROR)
-							dw_printf("Channel %d: - option must appear at end of demodulator types \"%s\" \n",
-								channel, save_audio_config_p.achan[channel].profiles)
-						}
-					} else {
-						text_color_set(DW_COLOR_ERROR)
-						dw_printf("Channel %d: Demodulator types \"%s\" can contain only letters and + - characters.\n",
-							channel, save_audio_config_p.achan[channel].profiles)
-					}
-				}
+			if save_audio_config_p.achan[channel].num_freq != 1 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("INTERNAL ERROR, chan=%d, num_freq(%d) != 1\n",
+					channel, save_audio_config_p.achan[channel].num_freq)
+			}
+
+			for d := 0; d < save_audio_config_p.achan[channel].num_subchan; d++ {
+				Assert(d >= 0 && d < MAX_SUBCHANS)
+
+				var D = &demodulator_state[channel][d]
 
-				Assert(num_letters == len(just_letters))
-
-				/*
-				 * Pick a good default demodulator if none specified.
-				 * Previously, we had "D" optimized for 300 bps.
-				 * Gone in 1.7 so it is always "A+".
-				 */
-				if num_letters == 0 {
-					just_letters = "A"
-					num_letters = 1
-
-					if have_plus != -1 {
-						have_plus = 1 // Add as default for version 1.2
-						// If not explicitly turned off.
-					}
+				var profile = save_audio_config_p.achan[channel].profiles[d]
+				var mark = save_audio_config_p.achan[channel].mark_freq
+				var space = save_audio_config_p.achan[channel].space_freq
+
+				if save_audio_config_p.achan[channel].num_subchan != 1 {
+					text_color_set(DW_COLOR_DEBUG)
+					dw_printf("        %d.%d: %c %d & %d\n", channel, d, profile, mark, space)
 				}
 
-				/*
-				 * Special case for ARM.
-				 * The higher end ARM chips have loads of power but many people
-				 * are using a single core Pi Zero or similar.
-				 * (I'm still using a model 1 for my digipeater/IGate!)
-				 * Decreasing CPU requirement has a negligible impact on decoding performance.
-				 *
-				 * 	atest -PA- 01_Track_1.wav		--> 1002 packets decoded.
-				 * 	atest -PA- -D3 01_Track_1.wav		--> 997 packets decoded.
-				 *
-				 * Someone concerned about 1/2 of one percent difference can add "-D 1"
-				 */
-				/* TODO KG
-				#if __arm__
-					      if (save_audio_config_p.achan[channel].decimate == 0) {
-					        if (save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec > 40000) {
-					          save_audio_config_p.achan[channel].decimate = 3;
-					        }
-					      }
-				#endif
-				*/
-
-				/*
-				 * Number of filter taps is proportional to number of audio samples in a "symbol" duration.
-				 * These can get extremely large for low speeds, e.g. 300 baud.
-				 * In this case, increase the decimation ration.  Crude approximation. Could be improved.
-				 */
-				if save_audio_config_p.achan[channel].decimate == 0 &&
-					save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec > 40000 &&
-					save_audio_config_p.achan[channel].baud < 600 {
-					// Avoid enormous number of filter taps.
-					save_audio_config_p.achan[channel].decimate = 3
+				demod_afsk_init(save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
+					save_audio_config_p.achan[channel].baud,
+					mark,
+					space,
+					rune(profile),
+					D)
+
+				if have_plus != 0 {
+					/* I'm not happy about putting this hack here. */
+					/* should pass in as a parameter rather than adding on later. */
+					save_audio_config_p.achan[channel].num_slicers = MAX_SLICERS
+					D.num_slicers = MAX_SLICERS
 				}
 
-				/*
-				 * Put it back together again.
-				 */
-				Assert(num_letters == len(just_letters))
+				/* For signal level reporting, we want a longer term view. */
+				// TODO: Should probably move this into the init functions.
 
-				/* At this point, have_plus can have 3 values: */
-				/* 	1 = turned on, either explicitly or by applied default */
-				/*	-1 = explicitly turned off.  change to 0 here so it is false. */
-				/* 	0 = off by default. */
+				D.quick_attack = D.agc_fast_attack * 0.2
+				D.sluggish_decay = D.agc_slow_decay * 0.2
+			}
+		} else if have_plus != 0 {
+			/*
+			 * PLUS - which (formerly) implies we have only one letter and one frequency pair.
+			 *
+			 * One demodulator feeds multiple slicers, each a subchannel.
+			 */
+			if num_letters != 1 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("INTERNAL ERROR, chan=%d, strlen(\"%s\") != 1\n",
+					channel, just_letters)
+			}
 
-				if have_plus == -1 {
-					have_plus = 0
-				}
+			if save_audio_config_p.achan[channel].num_freq != 1 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("INTERNAL ERROR, chan=%d, num_freq(%d) != 1\n",
+					channel, save_audio_config_p.achan[channel].num_freq)
+			}
 
-				save_audio_config_p.achan[channel].profiles = just_letters
+			if save_audio_config_p.achan[channel].num_freq != save_audio_config_p.achan[channel].num_subchan {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("INTERNAL ERROR, chan=%d, num_freq(%d) != num_subchan(%d)\n",
+					channel, save_audio_config_p.achan[channel].num_freq, save_audio_config_p.achan[channel].num_subchan)
+			}
 
-				Assert(len(save_audio_config_p.achan[channel].profiles) >= 1)
+			var D = &demodulator_state[channel][0]
 
-				if have_plus != 0 {
-					save_audio_config_p.achan[channel].profiles += "+"
-				}
+			/* I'm not happy about putting this hack here. */
+			/* This belongs in demod_afsk_init but it doesn't have access to the audio config. */
 
-				/* These can be increased later for the multi-frequency case. */
+			save_audio_config_p.achan[channel].num_slicers = MAX_SLICERS
 
-				save_audio_config_p.achan[channel].num_subchan = num_letters
-				save_audio_config_p.achan[channel].num_slicers = 1
+			demod_afsk_init(save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
+				save_audio_config_p.achan[channel].baud,
+				save_audio_config_p.achan[channel].mark_freq,
+				save_audio_config_p.achan[channel].space_freq,
+				rune(save_audio_config_p.achan[channel].profiles[0]),
+				D)
 
-				/*
-				 * Some error checking - Can use only one of these:
-				 *
-				 *	- Multiple letters.
-				 *	- New + multi-slicer.
-				 *	- Multiple frequencies.
-				 */
+			if have_plus != 0 {
+				/* I'm not happy about putting this hack here. */
+				/* should pass in as a parameter rather than adding on later. */
+				save_audio_config_p.achan[channel].num_slicers = MAX_SLICERS
+				D.num_slicers = MAX_SLICERS
+			}
 
-				if have_plus != 0 && save_audio_config_p.achan[channel].num_freq > 1 {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Channel %d: Demodulator + option can't be combined with multiple frequencies.\n", channel)
-					save_audio_config_p.achan[channel].num_subchan = 1 // Will be set higher later.
-					save_audio_config_p.achan[channel].num_freq = 1
-				}
+			/* For signal level reporting, we want a longer term view. */
 
-				if num_letters > 1 && save_audio_config_p.achan[channel].num_freq > 1 {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Channel %d: Multiple demodulator types can't be combined with multiple frequencies.\n", channel)
+			D.quick_attack = D.agc_fast_attack * 0.2
+			D.sluggish_decay = D.agc_slow_decay * 0.2
+		} else {
+			/*
+			 * One letter.
+			 * Can be combined with multiple frequencies.
+			 */
+			if num_letters != 1 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("INTERNAL ERROR, chan=%d, strlen(\"%s\") != 1\n",
+					channel, save_audio_config_p.achan[channel].profiles)
+			}
 
-					save_audio_config_p.achan[channel].profiles = string(save_audio_config_p.achan[channel].profiles[0])
-					num_letters = 1
-				}
+			save_audio_config_p.achan[channel].num_subchan = save_audio_config_p.achan[channel].num_freq
 
-				if save_audio_config_p.achan[channel].decimate == 0 {
-					save_audio_config_p.achan[channel].decimate = 1
-					if strings.Contains(just_letters, "B") && save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec > 40000 {
-						save_audio_config_p.achan[channel].decimate = 3
-					}
-				}
+			for d := 0; d < save_audio_config_p.achan[channel].num_freq; d++ {
+				Assert(d >= 0 && d < MAX_SUBCHANS)
 
-				text_color_set(DW_COLOR_DEBUG)
-				dw_printf("Channel %d: %d baud, AFSK %d & %d Hz, %s, %d sample rate",
-					channel, save_audio_config_p.achan[channel].baud,
-					save_audio_config_p.achan[channel].mark_freq, save_audio_config_p.achan[channel].space_freq,
-					save_audio_config_p.achan[channel].profiles,
-					save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec)
+				var D = &demodulator_state[channel][d]
 
-				if save_audio_config_p.achan[channel].decimate != 1 {
-					dw_printf(" / %d", save_audio_config_p.achan[channel].decimate)
-				}
+				var profile = save_audio_config_p.achan[channel].profiles[0]
 
-				dw_printf(", Tx %s", layer2_tx[(save_audio_config_p.achan[channel].layer2_xmit)])
+				var k = d*save_audio_config_p.achan[channel].offset - ((save_audio_config_p.achan[channel].num_freq-1)*save_audio_config_p.achan[channel].offset)/2
+				var mark = save_audio_config_p.achan[channel].mark_freq + k
+				var space = save_audio_config_p.achan[channel].space_freq + k
 
-				if save_audio_config_p.achan[channel].dtmf_decode != DTMF_DECODE_OFF {
-					dw_printf(", DTMF decoder enabled")
+				if save_audio_config_p.achan[channel].num_freq != 1 {
+					text_color_set(DW_COLOR_DEBUG)
+					dw_printf("        %d.%d: %c %d & %d\n", channel, d, profile, mark, space)
 				}
 
-				dw_printf(".\n")
-
-				/*
-				 * Initialize the demodulator(s).
-				 *
-				 * We have 3 cases to consider.
-				 */
-
-				// TODO1.3: revisit this logic now that it is less restrictive.
-
-				if num_letters > 1 {
-					/*
-					 * Multiple letters, usually for 1200 baud.
-					 * Each one corresponds to a demodulator and subchannel.
-					 *
-					 * An interesting experiment but probably not too useful.
-					 * Can't have multiple frequency pairs.
-					 * In version 1.3 this can be combined with the + option.
-					 */
-					save_audio_config_p.achan[channel].num_subchan = num_letters
-
-					if save_audio_config_p.achan[channel].num_subchan != num_letters {
-						text_color_set(DW_COLOR_ERROR)
-						dw_printf("INTERNAL ERROR, chan=%d, num_subchan(%d) != strlen(\"%s\")\n",
-							channel, save_audio_config_p.achan[channel].num_subchan, save_audio_config_p.achan[channel].profiles)
-					}
-
-					if save_audio_config_p.achan[channel].num_freq != 1 {
-						text_color_set(DW_COLOR_ERROR)
-						dw_printf("INTERNAL ERROR, chan=%d, num_freq(%d) != 1\n",
-							channel, save_audio_config_p.achan[channel].num_freq)
-					}
-
-					for d := 0; d < save_audio_config_p.achan[channel].num_subchan; d++ {
-						Assert(d >= 0 && d < MAX_SUBCHANS)
-
-						var D = &demodulator_state[channel][d]
-
-						var profile = save_audio_config_p.achan[channel].profiles[d]
-						var mark = save_audio_config_p.achan[channel].mark_freq
-						var space = save_audio_config_p.achan[channel].space_freq
-
-						if save_audio_config_p.achan[channel].num_subchan != 1 {
-							text_color_set(DW_COLOR_DEBUG)
-							dw_printf("        %d.%d: %c %d & %d\n", channel, d, profile, mark, space)
-						}
-
-						demod_afsk_init(save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
-							save_audio_config_p.achan[channel].baud,
-							mark,
-							space,
-							rune(profile),
-							D)
-
-						if have_plus != 0 {
-							/* I'm not happy about putting this hack here. */
-							/* should pass in as a parameter rather than adding on later. */
-							save_audio_config_p.achan[channel].num_slicers = MAX_SLICERS
-							D.num_slicers = MAX_SLICERS
-						}
-
-						/* For signal level reporting, we want a longer term view. */
-						// TODO: Should probably move this into the init functions.
-
-						D.quick_attack = D.agc_fast_attack * 0.2
-						D.sluggish_decay = D.agc_slow_decay * 0.2
-					}
-				} else if have_plus != 0 {
-					/*
-					 * PLUS - which (formerly) implies we have only one letter and one frequency pair.
-					 *
-					 * One demodulator feeds multiple slicers, each a subchannel.
-					 */
-					if num_letters != 1 {
-						text_color_set(DW_COLOR_ERROR)
-						dw_printf("INTERNAL ERROR, chan=%d, strlen(\"%s\") != 1\n",
-							channel, just_letters)
-					}
-
-					if save_audio_config_p.achan[channel].num_freq != 1 {
-						text_color_set(DW_COLOR_ERROR)
-						dw_printf("INTERNAL ERROR, chan=%d, num_freq(%d) != 1\n",
-							channel, save_audio_config_p.achan[channel].num_freq)
-					}
-
-					if save_audio_config_p.achan[channel].num_freq != save_audio_config_p.achan[channel].num_subchan {
-						text_color_set(DW_COLOR_ERROR)
-						dw_printf("INTERNAL ERROR, chan=%d, num_freq(%d) != num_subchan(%d)\n",
-							channel, save_audio_config_p.achan[channel].num_freq, save_audio_config_p.achan[channel].num_subchan)
-					}
-
-					var D = &demodulator_state[channel][0]
+				demod_afsk_init(save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
+					save_audio_config_p.achan[channel].baud,
+					mark, space,
+					rune(profile),
+					D)
 
+				if have_plus != 0 {
 					/* I'm not happy about putting this hack here. */
-					/* This belongs in demod_afsk_init but it doesn't have access to the audio config. */
-
+					/* should pass in as a parameter rather than adding on later. */
 					save_audio_config_p.achan[channel].num_slicers = MAX_SLICERS
-
-					demod_afsk_init(save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
-						save_audio_config_p.achan[channel].baud,
-						save_audio_config_p.achan[channel].mark_freq,
-						save_audio_config_p.achan[channel].space_freq,
-						rune(save_audio_config_p.achan[channel].profiles[0]),
-						D)
-
-					if have_plus != 0 {
-						/* I'm not happy about putting this hack here. */
-						/* should pass in as a parameter rather than adding on later. */
-						save_audio_config_p.achan[channel].num_slicers = MAX_SLICERS
-						D.num_slicers = MAX_SLICERS
-					}
-
-					/* For signal level reporting, we want a longer term view. */
-
-					D.quick_attack = D.agc_fast_attack * 0.2
-					D.sluggish_decay = D.agc_slow_decay * 0.2
-				} else {
-					/*
-					 * One letter.
-					 * Can be combined with multiple frequencies.
-					 */
-					if num_letters != 1 {
-						text_color_set(DW_COLOR_ERROR)
-						dw_printf("INTERNAL ERROR, chan=%d, strlen(\"%s\") != 1\n",
-							channel, save_audio_config_p.achan[channel].profiles)
-					}
-
-					save_audio_config_p.achan[channel].num_subchan = save_audio_config_p.achan[channel].num_freq
-
-					for d := 0; d < save_audio_config_p.achan[channel].num_freq; d++ {
-						Assert(d >= 0 && d < MAX_SUBCHANS)
-
-						var D = &demodulator_state[channel][d]
-
-						var profile = save_audio_config_p.achan[channel].profiles[0]
-
-						var k = d*save_audio_config_p.achan[channel].offset - ((save_audio_config_p.achan[channel].num_freq-1)*save_audio_config_p.achan[channel].offset)/2
-						var mark = save_audio_config_p.achan[channel].mark_freq + k
-						var space = save_audio_config_p.achan[channel].space_freq + k
-
-						if save_audio_config_p.achan[channel].num_freq != 1 {
-							text_color_set(DW_COLOR_DEBUG)
-							dw_printf("        %d.%d: %c %d & %d\n", channel, d, profile, mark, space)
-						}
-
-						demod_afsk_init(save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
-							save_audio_config_p.achan[channel].baud,
-							mark, space,
-							rune(profile),
-							D)
-
-						if have_plus != 0 {
-							/* I'm not happy about putting this hack here. */
-							/* should pass in as a parameter rather than adding on later. */
-							save_audio_config_p.achan[channel].num_slicers = MAX_SLICERS
-							D.num_slicers = MAX_SLICERS
-						}
-
-						/* For signal level reporting, we want a longer term view. */
-
-						D.quick_attack = D.agc_fast_attack * 0.2
-						D.sluggish_decay = D.agc_slow_decay * 0.2
-					} /* for each freq pair */
+					D.num_slicers = MAX_SLICERS
 				}
 
-			case MODEM_QPSK: // New for 1.4
-				// In versions 1.4 and 1.5, V.26 "Alternative A" was used.
-				// years later, I discover that the MFJ-2400 used "Alternative B."
-				// It looks like the other two manufacturers use the same but we
-				// can't be sure until we find one for compatibility testing.
-				// In version 1.6 we add a choice for the user.
-				// If neither one was explicitly specified, print a message and take
-				// a default.  My current thinking is that we default to direwolf <= 1.5
-				// compatible for version 1.6 and MFJ compatible after that.
-				if save_audio_config_p.achan[channel].v26_alternative == V26_UNSPECIFIED {
-					text_color_set(DW_COLOR_ERROR)
-					dw_printf("Two incompatible versions of 2400 bps QPSK are now available.\n")
-					dw_printf("For compatibility with direwolf <= 1.5, use 'V26A' modem option in config file.\n")
-					dw_printf("For compatibility MFJ-2400 use 'V26B' modem option in config file.\n")
-					dw_printf("Command line options -j and -J can be used for channel 0.\n")
-					dw_printf("For more information, read the Dire Wolf User Guide and\n")
-					dw_printf("2400-4800-PSK-for-APRS-Packet-Radio.pdf.\n")
-					dw_printf("The default is now MFJ-2400 compatibility mode.\n")
-
-					save_audio_config_p.achan[channel].v26_alternative = V26_DEFAULT
-				}
+				/* For signal level reporting, we want a longer term view. */
 
-				// TODO: See how much CPU this takes on ARM and decide if we should have different defaults.
+				D.quick_attack = D.agc_fast_attack * 0.2
+				D.sluggish_decay = D.agc_slow_decay * 0.2
+			} /* for each freq pair */
+		}
 
-				if save_audio_config_p.achan[channel].profiles == "" {
-					//#if __arm__
-					//	        strlcpy (save_audio_config_p.achan[channel].profiles, "R", sizeof(save_audio_config_p.achan[channel].profiles));
-					//#else
-					save_audio_config_p.achan[channel].profiles = "PQRS"
-					//#endif
-				}
+	case MODEM_QPSK: // New for 1.4
+		// In versions 1.4 and 1.5, V.26 "Alternative A" was used.
+		// years later, I discover that the MFJ-2400 used "Alternative B."
+		// It looks like the other two manufacturers use the same but we
+		// can't be sure until we find one for compatibility testing.
+		// In version 1.6 we add a choice for the user.
+		// If neither one was explicitly specified, print a message and take
+		// a default.  My current thinking is that we default to direwolf <= 1.5
+		// compatible for version 1.6 and MFJ compatible after that.
+		if save_audio_config_p.achan[channel].v26_alternative == V26_UNSPECIFIED {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Two incompatible versions of 2400 bps QPSK are now available.\n")
+			dw_printf("For compatibility with direwolf <= 1.5, use 'V26A' modem option in config file.\n")
+			dw_printf("For compatibility MFJ-2400 use 'V26B' modem option in config file.\n")
+			dw_printf("Command line options -j and -J can be used for channel 0.\n")
+			dw_printf("For more information, read the Dire Wolf User Guide and\n")
+			dw_printf("2400-4800-PSK-for-APRS-Packet-Radio.pdf.\n")
+			dw_printf("The default is now MFJ-2400 compatibility mode.\n")
+
+			save_audio_config_p.achan[channel].v26_alternative = V26_DEFAULT
+		}
 
-				save_audio_config_p.achan[channel].num_subchan = len(save_audio_config_p.achan[channel].profiles)
+		// TODO: See how much CPU this takes on ARM and decide if we should have different defaults.
 
-				save_audio_config_p.achan[channel].decimate = 1 // think about this later.
+		if save_audio_config_p.achan[channel].profiles == "" {
+			//#if __arm__
+			//	        strlcpy (save_audio_config_p.achan[channel].profiles, "R", sizeof(save_audio_config_p.achan[channel].profiles));
+			//#else
+			save_audio_config_p.achan[channel].profiles = "PQRS"
+			//#endif
+		}
 
-				text_color_set(DW_COLOR_DEBUG)
-				dw_printf("Channel %d: %d bps, QPSK, %s, %d sample rate",
-					channel, save_audio_config_p.achan[channel].baud,
-					save_audio_config_p.achan[channel].profiles,
-					save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec)
+		save_audio_config_p.achan[channel].num_subchan = len(save_audio_config_p.achan[channel].profiles)
 
-				if save_audio_config_p.achan[channel].decimate != 1 {
-					dw_printf(" / %d", save_audio_config_p.achan[channel].decimate)
-				}
+		save_audio_config_p.achan[channel].decimate = 1 // think about this later.
 
-				dw_printf(", Tx %s", layer2_tx[(int)(save_audio_config_p.achan[channel].layer2_xmit)])
+		text_color_set(DW_COLOR_DEBUG)
+		dw_printf("Channel %d: %d bps, QPSK, %s, %d sample rate",
+			channel, save_audio_config_p.achan[channel].baud,
+			save_audio_config_p.achan[channel].profiles,
+			save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec)
 
-				if save_audio_config_p.achan[channel].v26_alternative == V26_B {
-					dw_printf(", compatible with MFJ-2400")
-				} else {
-					dw_printf(", compatible with earlier direwolf")
-				}
+		if save_audio_config_p.achan[channel].decimate != 1 {
+			dw_printf(" / %d", save_audio_config_p.achan[channel].decimate)
+		}
 
-				if save_audio_config_p.achan[channel].dtmf_decode != DTMF_DECODE_OFF {
-					dw_printf(", DTMF decoder enabled")
-				}
+		dw_printf(", Tx %s", layer2_tx[(int)(save_audio_config_p.achan[channel].layer2_xmit)])
 
-				dw_printf(".\n")
+		if save_audio_config_p.achan[channel].v26_alternative == V26_B {
+			dw_printf(", compatible with MFJ-2400")
+		} else {
+			dw_printf(", compatible with earlier direwolf")
+		}
 
-				for d := 0; d < save_audio_config_p.achan[channel].num_subchan; d++ {
-					Assert(d >= 0 && d < MAX_SUBCHANS)
-					var D = &demodulator_state[channel][d]
-					var profile = save_audio_config_p.achan[channel].profiles[d]
+		if save_audio_config_p.achan[channel].dtmf_decode == DTMF_DECODE_ONLY {
+			dw_printf(", DTMF decoder only, no packets")
+		} else if save_audio_config_p.achan[channel].dtmf_decode != DTMF_DECODE_OFF {
+			dw_printf(", DTMF decoder enabled")
+		}
 
-					//text_color_set(DW_COLOR_DEBUG);
-					//dw_printf ("About to call demod_psk_init for Q-PSK case, modem_type=%d, profile='%c'\n",
-					//	save_audio_config_p.achan[channel].modem_type, profile);
+		dw_printf(".\n")
 
-					demod_psk_init(save_audio_config_p.achan[channel].modem_type,
-						save_audio_config_p.achan[channel].v26_alternative,
-						save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
-						save_audio_config_p.achan[channel].baud,
-						rune(profile),
-						D)
+		for d := 0; d < save_audio_config_p.achan[channel].num_subchan; d++ {
+			Assert(d >= 0 && d < MAX_SUBCHANS)
+			var D = &demodulator_state[channel][d]
+			var profile = save_audio_config_p.achan[channel].profiles[d]
 
-					//text_color_set(DW_COLOR_DEBUG);
-					//dw_printf ("Returned from demod_psk_init\n");
+			//text_color_set(DW_COLOR_DEBUG);
+			//dw_printf ("About to call demod_psk_init for Q-PSK case, modem_type=%d, profile='%c'\n",
+			//	save_audio_config_p.achan[channel].modem_type, profile);
 
-					/* For signal level reporting, we want a longer term view. */
-					/* Guesses based on 9600.  Maybe revisit someday. */
+			demod_psk_init(save_audio_config_p.achan[channel].modem_type,
+				save_audio_config_p.achan[channel].v26_alternative,
+				save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
+				save_audio_config_p.achan[channel].baud,
+				rune(profile),
+				D)
 
-					D.quick_attack = 0.080 * 0.2
-					D.sluggish_decay = 0.00012 * 0.2
-				}
+			//text_color_set(DW_COLOR_DEBUG);
+			//dw_printf ("Returned from demod_psk_init\n");
 
-			case MODEM_8PSK: // New for 1.4
-				// TODO: See how much CPU this takes on ARM and decide if we should have different defaults.
-				if save_audio_config_p.achan[channel].profiles == "" {
-					//#if __arm__
-					//	        strlcpy (save_audio_config_p.achan[channel].profiles, "V", sizeof(save_audio_config_p.achan[channel].profiles));
-					//#else
-					save_audio_config_p.achan[channel].profiles = "TUVW"
-					//#endif
-				}
+			/* For signal level reporting, we want a longer term view. */
+			/* Guesses based on 9600.  Maybe revisit someday. */
 
-				save_audio_config_p.achan[channel].num_subchan = len(save_audio_config_p.achan[channel].profiles)
+			D.quick_attack = 0.080 * 0.2
+			D.sluggish_decay = 0.00012 * 0.2
+		}
 
-				save_audio_config_p.achan[channel].decimate = 1 // think about this later
+	case MODEM_8PSK: // New for 1.4
+		// TODO: See how much CPU this takes on ARM and decide if we should have different defaults.
+		if save_audio_config_p.achan[channel].profiles == "" {
+			//#if __arm__
+			//	        strlcpy (save_audio_config_p.achan[channel].profiles, "V", sizeof(save_audio_config_p.achan[channel].profiles));
+			//#else
+			save_audio_config_p.achan[channel].profiles = "TUVW"
+			//#endif
+		}
 
-				text_color_set(DW_COLOR_DEBUG)
-				dw_printf("Channel %d: %d bps, 8PSK, %s, %d sample rate",
-					channel, save_audio_config_p.achan[channel].baud,
-					save_audio_config_p.achan[channel].profiles,
-					save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec)
+		save_audio_config_p.achan[channel].num_subchan = len(save_audio_config_p.achan[channel].profiles)
 
-				if save_audio_config_p.achan[channel].decimate != 1 {
-					dw_printf(" / %d", save_audio_config_p.achan[channel].decimate)
-				}
+		save_audio_config_p.achan[channel].decimate = 1 // think about this later
 
-				dw_printf(", Tx %s", layer2_tx[(int)(save_audio_config_p.achan[channel].layer2_xmit)])
+		text_color_set(DW_COLOR_DEBUG)
+		dw_printf("Channel %d: %d bps, 8PSK, %s, %d sample rate",
+			channel, save_audio_config_p.achan[channel].baud,
+			save_audio_config_p.achan[channel].profiles,
+			save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec)
 
-				if save_audio_config_p.achan[channel].dtmf_decode != DTMF_DECODE_OFF {
-					dw_printf(", DTMF decoder enabled")
-				}
+		if save_audio_config_p.achan[channel].decimate != 1 {
+			dw_printf(" / %d", save_audio_config_p.achan[channel].decimate)
+		}
 
-				dw_printf(".\n")
+		dw_printf(", Tx %s", layer2_tx[(int)(save_audio_config_p.achan[channel].layer2_xmit)])
 
-				for d := 0; d < save_audio_config_p.achan[channel].num_subchan; d++ {
-					Assert(d >= 0 && d < MAX_SUBCHANS)
-					var D = &demodulator_state[channel][d]
-					var profile = save_audio_config_p.achan[channel].profiles[d]
+		if save_audio_config_p.achan[channel].dtmf_decode == DTMF_DECODE_ONLY {
+			dw_printf(", DTMF decoder only, no packets")
+		} else if save_audio_config_p.achan[channel].dtmf_decode != DTMF_DECODE_OFF {
+			dw_printf(", DTMF decoder enabled")
+		}
 
-					//text_color_set(DW_COLOR_DEBUG);
-					//dw_printf ("About to call demod_psk_init for 8-PSK case, modem_type=%d, profile='%c'\n",
-					//	save_audio_config_p.achan[channel].modem_type, profile);
+		dw_printf(".\n")
 
-					demod_psk_init(save_audio_config_p.achan[channel].modem_type,
-						save_audio_config_p.achan[channel].v26_alternative,
-						save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
-						save_audio_config_p.achan[channel].baud,
-						rune(profile),
-						D)
+		for d := 0; d < save_audio_config_p.achan[channel].num_subchan; d++ {
+			Assert(d >= 0 && d < MAX_SUBCHANS)
+			var D = &demodulator_state[channel][d]
+			var profile = save_audio_config_p.achan[channel].profiles[d]
 
-					//text_color_set(DW_COLOR_DEBUG);
-					//dw_printf ("Returned from demod_psk_init\n");
+			//text_color_set(DW_COLOR_DEBUG);
+			//dw_printf ("About to call demod_psk_init for 8-PSK case, modem_type=%d, profile='%c'\n",
+			//	save_audio_config_p.achan[channel].modem_type, profile);
 
-					/* For signal level reporting, we want a longer term view. */
-					/* Guesses based on 9600.  Maybe revisit someday. */
+			demod_psk_init(save_audio_config_p.achan[channel].modem_type,
+				save_audio_config_p.achan[channel].v26_alternative,
+				save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
+				save_audio_config_p.achan[channel].baud,
+				rune(profile),
+				D)
 
-					D.quick_attack = 0.080 * 0.2
-					D.sluggish_decay = 0.00012 * 0.2
-				}
+			//text_color_set(DW_COLOR_DEBUG);
+			//dw_printf ("Returned from demod_psk_init\n");
 
-			case MODEM_BPSK:
-				if save_audio_config_p.achan[channel].profiles == "" {
-					save_audio_config_p.achan[channel].profiles = "LMNO"
-				}
+			/* For signal level reporting, we want a longer term view. */
+			/* Guesses based on 9600.  Maybe revisit someday. */
 
-				save_audio_config_p.achan[channel].num_subchan = len(save_audio_config_p.achan[channel].profiles)
+			D.quick_attack = 0.080 * 0.2
+			D.sluggish_decay = 0.00012 * 0.2
+		}
 
-				save_audio_config_p.achan[channel].decimate = 1
+	case MODEM_BPSK:
+		if save_audio_config_p.achan[channel].profiles == "" {
+			save_audio_config_p.achan[channel].profiles = "LMNO"
+		}
 
-				text_color_set(DW_COLOR_DEBUG)
-				dw_printf("Channel %d: %d bps, BPSK, %s, %d sample rate",
-					channel, save_audio_config_p.achan[channel].baud,
-					save_audio_config_p.achan[channel].profiles,
-					save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec)
+		save_audio_config_p.achan[channel].num_subchan = len(save_audio_config_p.achan[channel].profiles)
 
-				if save_audio_config_p.achan[channel].decimate != 1 {
-					dw_printf(" / %d", save_audio_config_p.achan[channel].decimate)
-				}
+		save_audio_config_p.achan[channel].decimate = 1
+
+		text_color_set(DW_COLOR_DEBUG)
+		dw_printf("Channel %d: %d bps, BPSK, %s, %d sample rate",
+			channel, save_audio_config_p.achan[channel].baud,
+			save_audio_config_p.achan[channel].profiles,
+			save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec)
+
+		if save_audio_config_p.achan[channel].decimate != 1 {
+			dw_printf(" / %d", save_audio_config_p.achan[channel].decimate)
+		}
 
-				dw_printf(", Tx %s", layer2_tx[(int)(save_audio_config_p.achan[channel].layer2_xmit)])
+		dw_printf(", Tx %s", layer2_tx[(int)(save_audio_config_p.achan[channel].layer2_xmit)])
 
-				if save_audio_config_p.achan[channel].dtmf_decode != DTMF_DECODE_OFF {
-					dw_printf(", DTMF decoder enabled")
+		if save_audio_config_p.achan[channel].dtmf_decode == DTMF_DECODE_ONLY {
+			dw_printf(", DTMF decoder only, no packets")
+		} else if save_audio_config_p.achan[channel].dtmf_decode != DTMF_DECODE_OFF {
+			dw_printf(", DTMF decoder enabled")
+		}
+
+		dw_printf(".\n")
+
+		for d := 0; d < save_audio_config_p.achan[channel].num_subchan; d++ {
+			Assert(d >= 0 && d < MAX_SUBCHANS)
+			var D = &demodulator_state[channel][d]
+			var profile = save_audio_config_p.achan[channel].profiles[d]
+
+			demod_psk_init(save_audio_config_p.achan[channel].modem_type,
+				V26_UNSPECIFIED,
+				save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
+				save_audio_config_p.achan[channel].baud,
+				rune(profile),
+				D)
+
+			D.quick_attack = 0.080 * 0.2
+			D.sluggish_decay = 0.00012 * 0.2
+		}
+
+	//TODO: how about MODEM_OFF case?
+
+	default: /* Not AFSK */
+		/*
+		   case MODEM_BASEBAND:
+		   case MODEM_SCRAMBLE:
+		   case MODEM_AIS:
+		*/
+		{
+			// For AIS we will accept only a good CRC without any fixup attempts.
+			// Even with that, there are still a lot of CRC false matches with random noise.
+			if save_audio_config_p.achan[channel].modem_type == MODEM_AIS {
+				if save_audio_config_p.achan[channel].fix_bits != RETRY_NONE {
+					text_color_set(DW_COLOR_INFO)
+					dw_printf("Channel %d: FIX_BITS option has been turned off for AIS.\n", channel)
+					save_audio_config_p.achan[channel].fix_bits = RETRY_NONE
+				}
+
+				if save_audio_config_p.achan[channel].passall {
+					text_color_set(DW_COLOR_INFO)
+					dw_printf("Channel %d: PASSALL option has been turned off for AIS.\n", channel)
+					save_audio_config_p.achan[channel].passall = false
 				}
+			}
+
+			if save_audio_config_p.achan[channel].profiles == "" {
+				/* Apply default if not set earlier. */
+				/* Not sure if it should be on for ARM too. */
+				/* Need to take a look at CPU usage and performance difference. */
+
+				/* Version 1.5:  Remove special case for ARM. */
+				/* We want higher performance to be the default. */
+				/* "MODEM 9600 -" can be used on very slow CPU if necessary. */
+				save_audio_config_p.achan[channel].profiles = "+"
+			}
 
-				dw_printf(".\n")
+			/*
+			 * We need a minimum number of audio samples per bit time for good performance.
+			 * Easier to check here because demod_9600_init might have an adjusted sample rate.
+			 */
 
-				for d := 0; d < save_audio_config_p.achan[channel].num_subchan; d++ {
-					Assert(d >= 0 && d < MAX_SUBCHANS)
-					var D = &demodulator_state[channel][d]
-					var profile = save_audio_config_p.achan[channel].profiles[d]
+			var ratio = float64(save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec) / float64(save_audio_config_p.achan[channel].baud)
 
-					demod_psk_init(save_audio_config_p.achan[channel].modem_type,
-						V26_UNSPECIFIED,
-						save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec/save_audio_config_p.achan[channel].decimate,
-						save_audio_config_p.achan[channel].baud,
-						rune(profile),
-						D)
+			/*
+			 * Set reasonable upsample ratio if user did not override.
+			 */
 
-					D.quick_attack = 0.080 * 0.2
-					D.sluggish_decay = 0.00012 * 0.2
+			if save_audio_config_p.achan[channel].upsample == 0 {
+				if ratio < 4 {
+					// This is extreme.
+					// No one should be using a sample rate this low but
+					// amazingly a recording with 22050 rate can be decoded.
+					// 3 and 4 are the same.  Need more tests.
+					save_audio_config_p.achan[channel].upsample = 4
+				} else if ratio < 5 {
+					// example: 44100 / 9600 is 4.59
+					// 3 is slightly better than 2 or 4.
+					save_audio_config_p.achan[channel].upsample = 3
+				} else if ratio < 10 {
+					// example: 48000 / 9600 = 5
+					// 3 is slightly better than 2 or 4.
+					save_audio_config_p.achan[channel].upsample = 3
+				} else if ratio < 15 {
+					// ... guessing
+					save_audio_config_p.achan[channel].upsample = 2
+				} else { // >= 15
+					//
+					// An example of this might be .....
+					// Probably no benefit.
+					save_audio_config_p.achan[channel].upsample = 1
 				}
+			}
 
-			//TODO: how about MODEM_OFF case?
-
-			default: /* Not AFSK */
-				/*
-				   case MODEM_BASEBAND:
-				   case MODEM_SCRAMBLE:
-				   case MODEM_AIS:
-				*/
-				{
-					// For AIS we will accept only a good CRC without any fixup attempts.
-					// Even with that, there are still a lot of CRC false matches with random noise.
-					if save_audio_config_p.achan[channel].modem_type == MODEM_AIS {
-						if save_audio_config_p.achan[channel].fix_bits != RETRY_NONE {
-							text_color_set(DW_COLOR_INFO)
-							dw_printf("Channel %d: FIX_BITS option has been turned off for AIS.\n", channel)
-							save_audio_config_p.achan[channel].fix_bits = RETRY_NONE
-						}
-
-						if save_audio_config_p.achan[channel].passall {
-							text_color_set(DW_COLOR_INFO)
-							dw_printf("Channel %d: PASSALL option has been turned off for AIS.\n", channel)
-							save_audio_config_p.achan[channel].passall = false
-						}
-					}
-
-					if save_audio_config_p.achan[channel].profiles == "" {
-						/* Apply default if not set earlier. */
-						/* Not sure if it should be on for ARM too. */
-						/* Need to take a look at CPU usage and performance difference. */
-
-						/* Version 1.5:  Remove special case for ARM. */
-						/* We want higher performance to be the default. */
-						/* "MODEM 9600 -" can be used on very slow CPU if necessary. */
-						save_audio_config_p.achan[channel].profiles = "+"
-					}
-
-					/*
-					 * We need a minimum number of audio samples per bit time for good performance.
-					 * Easier to check here because demod_9600_init might have an adjusted sample rate.
-					 */
-
-					var ratio = float64(save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec) / float64(save_audio_config_p.achan[channel].baud)
-
-					/*
-					 * Set reasonable upsample ratio if user did not override.
-					 */
-
-					if save_audio_config_p.achan[channel].upsample == 0 {
-						if ratio < 4 {
-							// This is extreme.
-							// No one should be using a sample rate this low but
-							// amazingly a recording with 22050 rate can be decoded.
-							// 3 and 4 are the same.  Need more tests.
-							save_audio_config_p.achan[channel].upsample = 4
-						} else if ratio < 5 {
-							// example: 44100 / 9600 is 4.59
-							// 3 is slightly better than 2 or 4.
-							save_audio_config_p.achan[channel].upsample = 3
-						} else if ratio < 10 {
-							// example: 48000 / 9600 = 5
-							// 3 is slightly better than 2 or 4.
-							save_audio_config_p.achan[channel].upsample = 3
-						} else if ratio < 15 {
-							// ... guessing
-							save_audio_config_p.achan[channel].upsample = 2
-						} else { // >= 15
-							//
-							// An example of this might be .....
-							// Probably no benefit.
-							save_audio_config_p.achan[channel].upsample = 1
-						}
-					}
-
-					/* TODO KG
-					#ifdef TUNE_UPSAMPLE
-						      save_audio_config_p.achan[channel].upsample = TUNE_UPSAMPLE;
-					#endif
-					*/
+			/* TODO KG
+			#ifdef TUNE_UPSAMPLE
+				      save_audio_config_p.achan[channel].upsample = TUNE_UPSAMPLE;
+			#endif
+			*/
 
-					text_color_set(DW_COLOR_DEBUG)
-					dw_printf("Channel %d: %d baud, %s, %s, %d sample rate x %d",
-						channel,
-						save_audio_config_p.achan[channel].baud,
-						IfThenElse(save_audio_config_p.achan[channel].modem_type == MODEM_AIS, "AIS", "K9NG/G3RUH"),
-						save_audio_config_p.achan[channel].profiles,
-						save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec,
-						save_audio_config_p.achan[channel].upsample)
-					dw_printf(", Tx %s", layer2_tx[(int)(save_audio_config_p.achan[channel].layer2_xmit)])
+			text_color_set(DW_COLOR_DEBUG)
+			dw_printf("Channel %d: %d baud, %s, %s, %d sample rate x %d",
+				channel,
+				save_audio_config_p.achan[channel].baud,
+				IfThenElse(save_audio_config_p.achan[channel].modem_type == MODEM_AIS, "AIS", "K9NG/G3RUH"),
+				save_audio_config_p.achan[channel].profiles,
+				save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec,
+				save_audio_config_p.achan[channel].upsample)
+			dw_printf(", Tx %s", layer2_tx[(int)(save_audio_config_p.achan[channel].layer2_xmit)])
+
+			if save_audio_config_p.achan[channel].dtmf_decode == DTMF_DECODE_ONLY {
+				dw_printf(", DTMF decoder only, no packets")
+			} else if save_audio_config_p.achan[channel].dtmf_decode != DTMF_DECODE_OFF {
+				dw_printf(", DTMF decoder enabled")
+			}
+
+			dw_printf(".\n")
 
-					if save_audio_config_p.achan[channel].dtmf_decode != DTMF_DECODE_OFF {
-						dw_printf(", DTMF decoder enabled")
-					}
+			var D = &demodulator_state[channel][0] // first subchannel
 
-					dw_printf(".\n")
+			save_audio_config_p.achan[channel].num_subchan = 1
+			save_audio_config_p.achan[channel].num_slicers = 1
 
-					var D = &demodulator_state[channel][0] // first subchannel
+			if strings.Contains(save_audio_config_p.achan[channel].profiles, "+") {
+				/* I'm not happy about putting this hack here. */
+				/* This belongs in demod_9600_init but it doesn't have access to the audio config. */
+				save_audio_config_p.achan[channel].num_slicers = MAX_SLICERS
+			}
 
-					save_audio_config_p.achan[channel].num_subchan = 1
-					save_audio_config_p.achan[channel].num_slicers = 1
+			text_color_set(DW_COLOR_INFO)
+			dw_printf("The ratio of audio samples per sec (%d) to data rate in baud (%d) is %.1f\n",
+				save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec,
+				save_audio_config_p.achan[channel].baud,
+				ratio)
 
-					if strings.Contains(save_audio_config_p.achan[channel].profiles, "+") {
-						/* I'm not happy about putting this hack here. */
-						/* This belongs in demod_9600_init but it doesn't have access to the audio config. */
-						save_audio_config_p.achan[channel].num_slicers = MAX_SLICERS
-					}
+			if ratio < 3 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("There is little hope of success with such a low ratio.  Use a higher sample rate.\n")
+			} else if ratio < 5 {
+				dw_printf("This is on the low side for best performance.  Can you use a higher sample rate?\n")
 
-					text_color_set(DW_COLOR_INFO)
-					dw_printf("The ratio of audio samples per sec (%d) to data rate in baud (%d) is %.1f\n",
-						save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec,
-						save_audio_config_p.achan[channel].baud,
-						ratio)
-
-					if ratio < 3 {
-						text_color_set(DW_COLOR_ERROR)
-						dw_printf("There is little hope of success with such a low ratio.  Use a higher sample rate.\n")
-					} else if ratio < 5 {
-						dw_printf("This is on the low side for best performance.  Can you use a higher sample rate?\n")
-
-						if save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec == 44100 {
-							dw_printf("For example, can you use 48000 rather than 44100?\n")
-						}
-					} else if ratio < 6 {
-						dw_printf("Increasing the sample rate should improve decoder performance.\n")
-					} else if ratio > 15 {
-						dw_printf("Sample rate is more than adequate.  You might lower it if CPU load is a concern.\n")
-					} else {
-						dw_printf("This is a suitable ratio for good performance.\n")
-					}
-
-					demod_9600_init(save_audio_config_p.achan[channel].modem_type,
-						save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec,
-						save_audio_config_p.achan[channel].upsample,
-						save_audio_config_p.achan[channel].baud, D)
-
-					if strings.Contains(save_audio_config_p.achan[channel].profiles, "+") {
-						/* I'm not happy about putting this hack here. */
-						/* should pass in as a parameter rather than adding on later. */
-						save_audio_config_p.achan[channel].num_slicers = MAX_SLICERS
-						D.num_slicers = MAX_SLICERS
-					}
-
-					/* For signal level reporting, we want a longer term view. */
-
-					D.quick_attack = D.agc_fast_attack * 0.2
-					D.sluggish_decay = D.agc_slow_decay * 0.2
+				if save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec == 44100 {
+					dw_printf("For example, can you use 48000 rather than 44100?\n")
 				}
+			} else if ratio < 6 {
+				dw_printf("Increasing the sample rate should improve decoder performance.\n")
+			} else if ratio > 15 {
+				dw_printf("Sample rate is more than adequate.  You might lower it if CPU load is a concern.\n")
+			} else {
+				dw_printf("This is a suitable ratio for good performance.\n")
+			}
 
-			} /* switch on modulation type. */
-		} /* if channel medium is radio */
+			demod_9600_init(save_audio_config_p.achan[channel].modem_type,
+				save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec,
+				save_audio_config_p.achan[channel].upsample,
+				save_audio_config_p.achan[channel].baud, D)
 
-		// FIXME dw_printf ("-------- end of loop for chn %d \n", channel);
-	} /* for chan ... */
+			if strings.Contains(save_audio_config_p.achan[channel].profiles, "+") {
+				/* I'm not happy about putting this hack here. */
+				/* should pass in as a parameter rather than adding on later. */
+				save_audio_config_p.achan[channel].num_slicers = MAX_SLICERS
+				D.num_slicers = MAX_SLICERS
+			}
 
-	// Now the virtual channels.  FIXME:  could be single loop.
+			/* For signal level reporting, we want a longer term view. */
 
-	for channel := MAX_RADIO_CHANS; channel < MAX_TOTAL_CHANS; channel++ {
-		// FIXME dw_printf ("-------- virtual channel loop %d \n", channel);
-		if channel == save_audio_config_p.igate_vchannel {
-			text_color_set(DW_COLOR_DEBUG)
-			dw_printf("Channel %d: IGate virtual channel.\n", channel)
+			D.quick_attack = D.agc_fast_attack * 0.2
+			D.sluggish_decay = D.agc_slow_decay * 0.2
 		}
-	}
 
-	return (0)
-} /* end demod_init */
+	} /* switch on modulation type. */
+} /* end demod_init_channel */
 
 /*------------------------------------------------------------------
  *
@@ -198,6 +198,8 @@ func digipeater(from_chan int, pp *packet_t) {
 					save_digi_config_p.filter_str[from_chan][to_chan])
 				if result != nil {
 					dedupeService.Remember(pp, to_chan)
+					trace_printf(pp, "APRS digipeater: to channel %d, high priority", to_chan)
+					result.tx_reason = TX_REASON_DIGIPEAT
 					tq_append(to_chan, TQ_PRIO_0_HI, result) //  High priority queue.
 					digi_count[from_chan][to_chan]++
 				}
@@ -222,6 +224,8 @@ func digipeater(from_chan int, pp *packet_t) {
 					save_digi_config_p.filter_str[from_chan][to_chan])
 				if result != nil {
 					dedupeService.Remember(pp, to_chan)
+					trace_printf(pp, "APRS digipeater: to channel %d, low priority", to_chan)
+					result.tx_reason = TX_REASON_DIGIPEAT
 					tq_append(to_chan, TQ_PRIO_1_LO, result) // Low priority queue.
 					digi_count[from_chan][to_chan]++
 				}
@@ -294,6 +298,8 @@ func digipeat_match(
 	 */
 	if filter_str != "" {
 		if pfilter(from_chan, to_chan, filter_str, pp, true) != 1 {
+			trace_printf(pp, "APRS digipeater: not to channel %d, rejected by filter", to_chan)
+
 			return (nil)
 		}
 	}
@@ -325,6 +331,8 @@ func digipeat_match(
 	var r = ax25_get_first_not_repeated(pp)
 
 	if r < AX25_REPEATER_1 {
+		trace_printf(pp, "APRS digipeater: not to channel %d, no unused digipeater in path", to_chan)
+
 		return (nil)
 	}
 
@@ -367,6 +375,8 @@ func digipeat_match(
 	 */
 	var source = ax25_get_addr_with_ssid(pp, AX25_SOURCE)
 	if source == mycall_rec {
+		trace_printf(pp, "APRS digipeater: not to channel %d, my own", to_chan)
+
 		return (nil)
 	}
 
@@ -392,6 +402,8 @@ func digipeat_match(
 		text_color_set(DW_COLOR_INFO)
 		dw_printf("Digipeater: Drop redundant packet to channel %d.\n", to_chan)
 		//#endif
+		trace_printf(pp, "APRS digipeater: not to channel %d, sent recently", to_chan)
+
 		return nil
 	}
 
@@ -564,6 +576,8 @@ func digipeat_match(
 	 * Don't repeat it if we get here.
 	 */
 
+	trace_printf(pp, "APRS digipeater: not to channel %d, %s doesn't match", to_chan, repeater)
+
 	return (nil)
 }
 
@@ -599,6 +613,7 @@ func digi_regen(from_chan int, pp *packet_t) {
 			var result = ax25_dup(pp)
 			if result != nil {
 				// TODO:  if AX.25 and has been digipeated, put in HI queue?
+				result.tx_reason = TX_REASON_DIGIPEAT
 				tq_append(to_chan, TQ_PRIO_1_LO, result)
 			}
 		}
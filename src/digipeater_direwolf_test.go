@@ -105,7 +105,10 @@ func digipeater_test(t *testing.T, in, out string) {
 func Test_Digipeater(t *testing.T) {
 	digipeaterTestMyCall = "WB2OSZ-9"
 
+	var clock = NewFakeClock(time.Now())
+
 	dedupeService = NewDedupeService(100 * time.Millisecond)
+	dedupeService.clock = clock
 
 	/*
 	 * Compile the patterns.
@@ -219,7 +222,7 @@ func Test_Digipeater(t *testing.T) {
 	/*
 	 * Allow same thing after adequate time.
 	 */
-	time.Sleep(250 * time.Millisecond)
+	clock.Advance(250 * time.Millisecond)
 
 	digipeater_test(t, "W1XYZ>TESTD,R3*,WIDE3-2:info1",
 		"W1XYZ>TESTD,R3,WB2OSZ-9*,WIDE3-1:info1")
@@ -13,7 +13,6 @@ import (
 	"time"
 	"unicode"
 
-	"github.com/lestrrat-go/strftime"
 	"github.com/spf13/pflag"
 	goHamlib "github.com/xylo04/goHamlib"
 )
@@ -45,6 +44,8 @@ var q_d_opt bool /* "-q d" Quiet, suppress the printing of description of APRS p
 
 var A_opt_ais_to_obj bool /* "-A" Convert received AIS to APRS "Object Report." */
 
+const HEADLESS_CONFIG_ENV = "SAMOYED_CONFIG" /* Configuration text for --headless. */
+
 var audio_config *audio_s
 var dw_tt_config tt_config_s
 var misc_config *misc_config_s
@@ -56,7 +57,10 @@ var beaconService *BeaconService
 var kissNetSvc *KissNetService
 var mheardDB *MHeardDB
 var xmitSvc *XmitService
+var controlSvc *ControlService
 var ttGateway *TTGateway
+var dtmfRemote *DTMFRemote
+var queryResponder *QueryResponder
 
 /*-------------------------------------------------------------------
  *
@@ -102,7 +106,12 @@ a = Alternating mark/space tones.
 m = Steady mark tone (e.g. 1200Hz).
 s = Steady space tone (e.g. 2200Hz).
 p = Silence (Set PTT only).
+r = Show received audio level while another radio sends tones.
+c = Calibration assistant, recommending transmit level and TXDELAY.
 Optionally add a number to specify radio channel.`)
+	var calibrationTime = pflag.Duration("calibration-time", time.Minute, "How long -x runs.")
+	var calibrationID = pflag.Duration("calibration-id", 10*time.Minute, "Send MYCALL in Morse code this often during -x.  0 for never.")
+	var calibrationMonitor = pflag.Int("calibration-monitor", -1, "Channel listening to the transmitter for -x c.  Default is to ask you instead.")
 	var audioSampleRate = pflag.IntP("audio-sample-rate", "r", 0, "Audio sample rate, per sec.")
 	var audioChannels = pflag.IntP("audio-channels", "n", 0, "Number of audio channels, 1 or 2.")
 	var bitsPerSample = pflag.IntP("bits-per-sample", "b", 0, "Bits per audio sample, 8 or 16.")
@@ -135,11 +144,27 @@ x = Silence FX.25 information.`)
 	var symbolDump = pflag.BoolP("symbol-dump", "S", false, "Print symbol tables and exit.")
 	var errorRateStr = pflag.StringP("error-rate", "E", "", "Error rate percentage for clobbering frames - transmitted frames by default, prefix with R to affect received frames")
 	var timestampFormat = pflag.StringP("timestamp-format", "T", "", "Precede received frames with 'strftime' format time stamp.")
+	var monitorTime = pflag.String("monitor-time", "", "Precede sent and received frames with the time: local, utc, or off.  ISO 8601 unless -T gives a format.")
+	var logFormat = pflag.String("log-format", "", "What -l and -L write: csv, the default, or json for one object per line.")
+	var logTime = pflag.String("log-time", "utc", "Time zone for -l, -L, and --tnc2-log: utc or local.")
 	var bitErrorRate = pflag.Float64P("bit-error-rate", "e", 0.0, "Receive Bit Error Rate (BER).")
 	var fx25CheckBytes = pflag.IntP("fx25-check-bytes", "X", 0, "1 to enable FX.25 transmit.  16, 32, 64 for specific number of check bytes.")
 	var il2pNormal = pflag.IntP("il2p", "I", -1, "Enable IL2P transmit.  n=1 is recommended.  0 uses weaker FEC.")
 	var il2pInverted = pflag.IntP("il2p-inverted", "i", -1, "Enable IL2P transmit, inverted polarity.  n=1 is recommended.  0 uses weaker FEC.")
 	var aisToAPRS = pflag.BoolP("ais-to-aprs", "A", false, "Convert AIS positions to APRS Object Reports.")
+	var listAudio = pflag.Bool("list-audio", false, "List audio devices, with values to use for ADEVICE, and exit.")
+	var pcapFile = pflag.String("pcap", "", "Save all frames sent and received in this pcapng file, for Wireshark.")
+	var tnc2LogFile = pflag.String("tnc2-log", "", "Append each frame received to this file in TNC2 monitor format, with the time.")
+	var txLogFile = pflag.String("tx-log", "", "Append a CSV line to this file for each transmission, with duration and reason.")
+	var ownTrackFile = pflag.String("own-track", "", "Keep this GPX file up to date with our own track from the GPS.")
+	var logJSON = pflag.String("log-json", "", "Also write everything printed to this file as JSON log records.  - for stderr.")
+	var logLevel = pflag.StringSlice("log-level", nil, "Lowest level for --log-json: debug, info, warn, or error.  subsystem=level for one subsystem, e.g. kiss=debug.")
+	var easJSON = pflag.String("eas-json", "", "Append each EAS alert received to this file as a line of JSON.")
+	var easHook = pflag.String("eas-hook", "", "Run this command for each EAS alert received, with the JSON on stdin.")
+	var trace = pflag.Bool("trace", false, "Print what happens to each received frame: client delivery, IGate, digipeating, and transmit.")
+	var tui = pflag.Bool("tui", false, "Full screen monitor with a pane for each channel, stations heard, and audio levels.")
+	var headless = pflag.Bool("headless", false, `For containers.  No colors, JSON log records on stdout instead of text,
+and the configuration from $SAMOYED_CONFIG if set and there's no -c.`)
 
 	var showVersion = pflag.BoolP("version", "V", false, "Show version.")
 	var help = pflag.BoolP("help", "h", false, "Display help text.")
@@ -147,7 +172,7 @@ x = Silence FX.25 information.`)
 	pflag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "%s - a software 'soundcard' modem/TNC and APRS encoder/decoder.\n", os.Args[0])
 		fmt.Fprintf(os.Stderr, "\n")
-		fmt.Fprintf(os.Stderr, "Usage: direwolf [options] [ - | stdin | UDP:nnnn]\n")
+		fmt.Fprintf(os.Stderr, "Usage: direwolf [options] [ - | stdin | UDP:nnnn | file.wav]\n")
 		pflag.PrintDefaults()
 		fmt.Fprintf(os.Stderr, "\n")
 		fmt.Fprintf(os.Stderr, "After any options, there can be a single command line argument for the source of\n")
@@ -185,6 +210,17 @@ x = Silence FX.25 information.`)
 		os.Exit(0)
 	}
 
+	if *listAudio {
+		var err = audio_list(os.Stdout)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("%s\n", err)
+			os.Exit(1)
+		}
+
+		os.Exit(0)
+	}
+
 	if *aisToAPRS {
 		A_opt_ais_to_obj = true
 	}
@@ -268,6 +304,48 @@ x = Silence FX.25 information.`)
 		}
 	}
 
+	/*
+	 * Headless, for Docker or Kubernetes.  Nobody is watching a terminal,
+	 * so nothing that needs one, and everything printed goes to stdout as
+	 * JSON for the log collector.  The configuration can come from the
+	 * environment instead of a mounted file.
+	 */
+	if *headless {
+		if *tui || *transmitCalibration != "" {
+			fmt.Fprintf(os.Stderr, "--tui and -x can't be used with --headless.\n")
+			os.Exit(1)
+		}
+
+		*textColor = 0
+
+		if !pflag.Lookup("config-file").Changed {
+			if _, ok := os.LookupEnv(HEADLESS_CONFIG_ENV); ok {
+				*configFileName = CONFIG_ENV_PREFIX + HEADLESS_CONFIG_ENV
+			}
+		}
+	}
+
+	if *logJSON != "" || *headless {
+		var levels, err = log_parse_levels(*logLevel, *debugStr, *quietStr)
+		if err == nil {
+			if *logJSON != "" {
+				err = log_json_open(*logJSON, levels)
+			} else {
+				log_json_stdout(levels)
+			}
+
+			log_json_only = *headless
+		}
+
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "--log-json: %s\n", err)
+			os.Exit(1)
+		}
+	} else if len(*logLevel) > 0 {
+		fmt.Fprintf(os.Stderr, "--log-level can only be used with --log-json or --headless.\n")
+		os.Exit(1)
+	}
+
 	var input_file string
 
 	if len(pflag.Args()) > 0 {
@@ -460,6 +538,28 @@ x = Silence FX.25 information.`)
 
 	audio_config.timestamp_format = *timestampFormat
 
+	// -T by itself is local time, as it always was.
+
+	if *monitorTime == "" && *timestampFormat != "" {
+		*monitorTime = "local"
+	}
+
+	if *monitorTime != "" {
+		var loc, err = timestamp_location(*monitorTime)
+		if err != nil {
+			fmt.Printf("--monitor-time: %s\n", err)
+			os.Exit(1)
+		}
+
+		audio_config.timestamp_location = loc
+	}
+
+	var logLocation, logLocationErr = timestamp_location(*logTime)
+	if logLocationErr != nil || logLocation == nil {
+		fmt.Printf("--log-time must be utc or local, not %q.\n", *logTime)
+		os.Exit(1)
+	}
+
 	// temp - only xmit errors.
 
 	if *errorRateStr != "" {
@@ -498,6 +598,15 @@ x = Silence FX.25 information.`)
 		misc_config.log_path = *logDir
 	}
 
+	if *logFormat != "" {
+		if !strings.EqualFold(*logFormat, "csv") && !strings.EqualFold(*logFormat, "json") {
+			fmt.Printf("--log-format must be csv or json, not %q.\n", *logFormat)
+			os.Exit(1)
+		}
+
+		misc_config.log_format = strings.ToLower(*logFormat)
+	}
+
 	if *enablePseudoTerminal {
 		misc_config.enable_kiss_pt = true
 	}
@@ -506,6 +615,16 @@ x = Silence FX.25 information.`)
 		audio_config.adev[0].adevice_in = input_file
 	}
 
+	if *configFileName == CONFIG_STDIN {
+		for a := range MAX_ADEVS {
+			var in = audio_config.adev[a].adevice_in
+			if audio_config.adev[a].defined != 0 && (strings.EqualFold(in, "stdin") || in == "-") {
+				fmt.Printf("The configuration and the audio can't both come from stdin.\n")
+				os.Exit(1)
+			}
+		}
+	}
+
 	audio_config.recv_ber = *bitErrorRate
 
 	if *fx25CheckBytes > 0 {
@@ -552,6 +671,8 @@ x = Silence FX.25 information.`)
 		}
 	}
 
+	traceEnabled = *trace
+
 	// Done parsing, let's start doing!
 
 	// TODO: control development/beta/release by version.h instead of changing here.
@@ -565,13 +686,14 @@ x = Silence FX.25 information.`)
 
 	setup_sigint_handler()
 
+	systemdNotifier = NewSystemdNotifier()
+
 	/*
 	 * Open the audio source
 	 *	- soundcard
 	 *	- stdin
 	 *	- UDP
-	 * Files not supported at this time.
-	 * Can always "cat" the file and pipe it into stdin.
+	 *	- WAV file.  See audio_wav.go.
 	 */
 	deviceIDData = NewDeviceIDData()
 
@@ -584,9 +706,15 @@ x = Silence FX.25 information.`)
 		os.Exit(1)
 	}
 
+	if audio_none(audio_config) {
+		text_color_set(DW_COLOR_INFO)
+		dw_printf("No audio device.  Running without a radio, for the IGate, network channels, and client applications only.\n")
+	}
+
 	/*
 	 * Initialize the demodulator(s) and layer 2 decoder (HDLC, IL2P).
 	 */
+	profileSvc = NewProfileService(audio_config, SystemClock{}) // Before multi_modem_init touches the modem settings.
 	multi_modem_init(audio_config)
 	FX25Init(d_x_opt)
 	il2p_init(d_2_opt)
@@ -596,7 +724,9 @@ x = Silence FX.25 information.`)
 	 * an internal modem and radio.
 	 * I put it here so channel properties would come out in right order.
 	 */
+	voter = NewVoter(misc_config.vote, SystemClock{}) // Before anything can be received.
 	nettnc_init(audio_config)
+	bridge_init(audio_config, misc_config)
 
 	/*
 	 * Initialize the touch tone decoder & APRStt gateway.
@@ -612,6 +742,7 @@ x = Silence FX.25 information.`)
 	 */
 	gen_tone_init(audio_config, audio_amplitude, false)
 	morse_init(audio_config, audio_amplitude)
+	speech_init(audio_amplitude)
 
 	if !(audio_config.adev[0].bits_per_sample == 8 || audio_config.adev[0].bits_per_sample == 16) {
 		panic("audio_config.adev[0].bits_per_sample == 8 || audio_config.adev[0].bits_per_sample == 16")
@@ -633,109 +764,22 @@ x = Silence FX.25 information.`)
 
 	/*
 	 * If -x N option specified, transmit calibration tones for transmitter
-	 * audio level adjustment, up to 1 minute then quit.
+	 * audio level adjustment, or show received level, then quit.
 	 * a: Alternating mark/space tones
 	 * m: Mark tone (e.g. 1200Hz)
 	 * s: Space tone (e.g. 2200Hz)
 	 * p: Set PTT only.
+	 * r: Show received audio level.
+	 * c: Calibration assistant.
 	 * A leading or trailing number is the channel.
 	 */
 
 	if *transmitCalibration != "" {
-		var transmitCalibrationType = ' '
-		var transmitCalibrationChannel int
-
-		for _, p := range *transmitCalibration {
-			switch p {
-			case '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
-				transmitCalibrationChannel = transmitCalibrationChannel*10 + int(p-'0')
-
-				if transmitCalibrationType == ' ' {
-					transmitCalibrationType = 'a'
-				}
-			case 'a':
-				transmitCalibrationType = p // Alternating tones
-			case 'm':
-				transmitCalibrationType = p // Mark tone
-			case 's':
-				transmitCalibrationType = p // Space tone
-			case 'p':
-				transmitCalibrationType = p // Set PTT only
-			default:
-				text_color_set(DW_COLOR_ERROR)
-				fmt.Printf("Invalid option '%c' for -x. Must be a, m, s, or p.\n", p)
-				text_color_set(DW_COLOR_INFO)
-				os.Exit(1)
-			}
-		}
-
-		if transmitCalibrationChannel < 0 || transmitCalibrationChannel >= MAX_RADIO_CHANS {
-			text_color_set(DW_COLOR_ERROR)
-			fmt.Printf("Invalid channel %d for -x. \n", transmitCalibrationChannel)
-			text_color_set(DW_COLOR_INFO)
-			os.Exit(1)
-		}
-
-		if audio_config.chan_medium[transmitCalibrationChannel] == MEDIUM_RADIO {
-			if audio_config.achan[transmitCalibrationChannel].mark_freq != 0 && audio_config.achan[transmitCalibrationChannel].space_freq != 0 {
-				var max_duration = 60
-				var n = audio_config.achan[transmitCalibrationChannel].baud * max_duration
-
-				text_color_set(DW_COLOR_INFO)
-				ptt_set(OCTYPE_PTT, transmitCalibrationChannel, 1)
-
-				switch transmitCalibrationType {
-				default:
-				case 'a': // Alternating tones: -x a
-					fmt.Printf("\nSending alternating mark/space calibration tones (%d/%dHz) on channel %d.\nPress control-C to terminate.\n",
-						audio_config.achan[transmitCalibrationChannel].mark_freq,
-						audio_config.achan[transmitCalibrationChannel].space_freq,
-						transmitCalibrationChannel)
-
-					for n > 0 {
-						tone_gen_put_bit(transmitCalibrationChannel, n&1)
-						n--
-					}
-				case 'm': // "Mark" tone: -x m
-					fmt.Printf("\nSending mark calibration tone (%dHz) on channel %d.\nPress control-C to terminate.\n",
-						audio_config.achan[transmitCalibrationChannel].mark_freq, transmitCalibrationChannel)
-
-					for n > 0 {
-						tone_gen_put_bit(transmitCalibrationChannel, 1)
-
-						n--
-					}
-				case 's': // "Space" tone: -x s
-					fmt.Printf("\nSending space calibration tone (%dHz) on channel %d.\nPress control-C to terminate.\n",
-						audio_config.achan[transmitCalibrationChannel].space_freq, transmitCalibrationChannel)
-
-					for n > 0 {
-						tone_gen_put_bit(transmitCalibrationChannel, 0)
-
-						n--
-					}
-				case 'p': // Silence - set PTT only: -x p
-					fmt.Printf("\nSending silence (Set PTT only) on channel %d.\nPress control-C to terminate.\n", transmitCalibrationChannel)
-					SLEEP_SEC(max_duration)
-				}
-
-				ptt_set(OCTYPE_PTT, transmitCalibrationChannel, 0)
-				text_color_set(DW_COLOR_INFO)
-				os.Exit(0)
-			} else {
-				text_color_set(DW_COLOR_ERROR)
-				fmt.Printf("\nMark/Space frequencies not defined for channel %d. Cannot calibrate using this modem type.\n", transmitCalibrationChannel)
-				text_color_set(DW_COLOR_INFO)
-				os.Exit(1)
-			}
-		} else {
-			text_color_set(DW_COLOR_ERROR)
-			fmt.Printf("\nChannel %d is not configured as a radio channel.\n", transmitCalibrationChannel)
-			text_color_set(DW_COLOR_INFO)
-			os.Exit(1)
-		}
+		calibrate(audio_config, *transmitCalibration, *calibrationTime, *calibrationID, *calibrationMonitor)
 	}
 
+	profileSvc.Start() // Now that ptt_init has opened the radios.
+
 	/*
 	 * Initialize the digipeater and IGate functions.
 	 */
@@ -746,11 +790,26 @@ x = Silence FX.25 information.`)
 	pfilter_init(&igate_config, d_f_opt)
 	ax25_link_init(misc_config, d_c_opt)
 
+	var pbbsErr error
+
+	pbbsService, pbbsErr = NewPBBS(misc_config)
+	if pbbsErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("%s\n", pbbsErr)
+		os.Exit(1)
+	}
+
+	pbbsService.Start()
+
 	/*
 	 * Provide the AGW & KISS socket interfaces for use by a client application.
 	 */
 	server_init(audio_config, misc_config)
 	kissNetSvc = NewKissNetService(misc_config)
+	http_server_init(audio_config, &igate_config, misc_config)
+
+	alertService = NewAlertService(misc_config, igate_config.t2_server_name != "", time.Now())
+	alertService.Start()
 	kissNetSvc.SetDebug(d_n_opt)
 
 	// TODO KG This checks `misc_config.kiss_port > 0` but `kiss_port` is now an array?
@@ -771,6 +830,16 @@ x = Silence FX.25 information.`)
 	 */
 	dwgps_init(misc_config, d_g_opt)
 
+	if misc_config.gpsnmea_port != "" || misc_config.gpsd_host != "" || misc_config.gps_api {
+		if *ownTrackFile != "" || misc_config.http_port > 0 {
+			ownTrack.Start(audio_config.mycall[0], *ownTrackFile)
+		}
+	} else if *ownTrackFile != "" {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("--own-track needs GPSNMEA, GPSD, or GPSAPI in the configuration file.\n")
+		os.Exit(1)
+	}
+
 	var waypointErr error
 	waypointSender, waypointErr = NewWaypointSender(misc_config)
 	if waypointErr != nil {
@@ -787,16 +856,98 @@ x = Silence FX.25 information.`)
 	 */
 
 	packetLogger = NewPacketLogger(misc_config.log_daily_names, misc_config.log_path)
+	packetLogger.SetLocation(logLocation) // Before SetRotation, which tidies up by file name.
+	packetLogger.SetFormat(misc_config.log_format)
+	packetLogger.SetRotation(misc_config.log_max_size, misc_config.log_keep, misc_config.log_keep_days, misc_config.log_compress)
+
+	if *pcapFile != "" {
+		var err error
+
+		pcapWriter, err = NewPcapngWriter(*pcapFile)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Can't create packet capture file %s: %s\n", *pcapFile, err)
+			os.Exit(1)
+		}
+	}
+
+	if *tnc2LogFile != "" {
+		var err error
+
+		tnc2Logger, err = NewTnc2Logger(*tnc2LogFile)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Can't open TNC2 log file %s: %s\n", *tnc2LogFile, err)
+			os.Exit(1)
+		}
+
+		tnc2Logger.SetLocation(logLocation)
+	}
+
+	if *txLogFile != "" {
+		var err = txActivity.SetLogFile(*txLogFile)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Can't open transmit log file %s: %s\n", *txLogFile, err)
+			os.Exit(1)
+		}
+	}
+
+	var easErr error
+
+	easExporter, easErr = NewEASExporter(*easJSON, *easHook)
+	if easErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Can't export EAS alerts: %s\n", easErr)
+		os.Exit(1)
+	}
+
+	filecastService = NewFilecastService(audio_config, misc_config)
+	filecastService.Start()
+
 	beaconService = NewBeaconService(audio_config, misc_config, &igate_config)
 	beaconService.SetDebug(d_t_opt)
 	beaconService.Start()
 
+	dtmfRemote = NewDTMFRemote(&dw_tt_config, beaconService.SendNow)
+	queryResponder = NewQueryResponder(&misc_config.query, audio_config, beaconService.SendMatching)
+
+	cwid_init(audio_config)
+
+	/*
+	 * Allow the effective configuration to be inspected while running.
+	 * Debug areas use the same letters as the -d option.
+	 */
+	controlSvc = NewControlService(audio_config, &igate_config, misc_config)
+	controlSvc.AddDebugArea('a', "AGW network protocol client", server_set_debug)
+	controlSvc.AddDebugArea('k', "Serial port KISS", func(n int) {
+		kissserial_set_debug(n)
+		kisspt_set_debug(n)
+	})
+	controlSvc.AddDebugArea('n', "Network KISS", kissNetSvc.SetDebug)
+	controlSvc.AddDebugArea('o', "Output control such as PTT", ptt_set_debug)
+	controlSvc.AddDebugArea('i', "IGate", igate_set_debug)
+	controlSvc.AddDebugArea('t', "Tracker beacons", beaconService.SetDebug)
+	controlSvc.AddDebugArea('w', "Waypoints", waypointSender.SetDebug)
+	controlSvc.Start()
+
 	/*
 	 * Get sound samples and decode them.
 	 * Use hot attribute for all functions called for every audio sample.
 	 */
 
 	recv_init(audio_config)
+
+	if *tui {
+		var err = tui_start(audio_config)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Can't use full screen monitor: %s\n", err)
+		}
+	}
+
+	systemdNotifier.Ready()
+
 	recv_process()
 }
 
@@ -836,6 +987,18 @@ func app_process_rec_packet(channel int, subchan int, slice int, pp *packet_t, a
 	Assert(slice >= 0 && slice < MAX_SLICERS)
 	Assert(pp != nil) // 1.1J+
 
+	trace_assign(pp)
+	trace_printf(pp, "received on channel %d, %s", channel, AX25FormatAddrs(pp))
+
+	pcapWriter.WriteFrame(time.Now(), AX25Pack(pp), pcap_rec_comment(channel, subchan, slice, alevel, fec_type, retries))
+	tnc2Logger.Write(time.Now(), pp)
+
+	if subchan >= 0 {
+		alertService.PacketReceived(time.Now())
+	}
+	easExporter.Received(channel, AX25GetInfo(pp), time.Now())
+	filecastService.Received(channel, pp)
+
 	// Extra stuff before slice indicators.
 	// Can indicate FX.25/IL2P or fix_bits.
 	var display_retries string
@@ -949,23 +1112,20 @@ func app_process_rec_packet(channel int, subchan int, slice int, pp *packet_t, a
 
 	// -1 for APRStt DTMF decoder.
 
-	var ts string // optional time stamp
+	var cname = chan_name_suffix(audio_config, channel) // optional channel name
 
-	if len(audio_config.timestamp_format) > 0 {
-		var formattedTime, _ = strftime.Format(audio_config.timestamp_format, time.Now())
-		ts = " " + formattedTime // space after channel.
-	}
+	var ts = monitor_timestamp(audio_config, time.Now())
 
 	switch subchan {
 	case -1: // dtmf
 		text_color_set(DW_COLOR_REC)
-		dw_printf("[%d.dtmf%s] ", channel, ts)
+		dw_printf("[%d.dtmf%s%s] ", channel, cname, ts)
 	case -2: // APRS-IS
 		text_color_set(DW_COLOR_REC)
-		dw_printf("[%d.is%s] ", channel, ts)
+		dw_printf("[%d.is%s%s] ", channel, cname, ts)
 	case -3: // nettnc
 		text_color_set(DW_COLOR_REC)
-		dw_printf("[%d%s] ", channel, ts)
+		dw_printf("[%d%s%s] ", channel, cname, ts)
 	default:
 		if ax25_is_aprs(pp) {
 			text_color_set(DW_COLOR_REC)
@@ -974,13 +1134,13 @@ func app_process_rec_packet(channel int, subchan int, slice int, pp *packet_t, a
 		}
 
 		if audio_config.achan[channel].num_subchan > 1 && audio_config.achan[channel].num_slicers == 1 {
-			dw_printf("[%d.%d%s] ", channel, subchan, ts)
+			dw_printf("[%d.%d%s%s] ", channel, subchan, cname, ts)
 		} else if audio_config.achan[channel].num_subchan == 1 && audio_config.achan[channel].num_slicers > 1 {
-			dw_printf("[%d.%d%s] ", channel, slice, ts)
+			dw_printf("[%d.%d%s%s] ", channel, slice, cname, ts)
 		} else if audio_config.achan[channel].num_subchan > 1 && audio_config.achan[channel].num_slicers > 1 {
-			dw_printf("[%d.%d.%d%s] ", channel, subchan, slice, ts)
+			dw_printf("[%d.%d.%d%s%s] ", channel, subchan, slice, cname, ts)
 		} else {
-			dw_printf("[%d%s] ", channel, ts)
+			dw_printf("[%d%s%s] ", channel, cname, ts)
 		}
 	}
 
@@ -1081,6 +1241,10 @@ func app_process_rec_packet(channel int, subchan int, slice int, pp *packet_t, a
 
 		mheardDB.SaveRF(channel, A, pp, alevel, retries)
 
+		// Answer ?APRS?, ?APRSP, ?VER, etc.
+
+		queryResponder.Heard(channel, A)
+
 		// For AIS, we have an option to convert the NMEA format, in User Defined data,
 		// into an APRS "Object Report" and send that to the clients as well.
 
@@ -1135,6 +1299,11 @@ func app_process_rec_packet(channel int, subchan int, slice int, pp *packet_t, a
 	kissNetSvc.SendRecPacket(channel, KISS_CMD_DATA_FRAME, fbuf, len(fbuf), nil, -1)   // KISS TCP
 	kissserial_send_rec_packet(channel, KISS_CMD_DATA_FRAME, fbuf, len(fbuf), nil, -1) // KISS serial port
 	kisspt_send_rec_packet(channel, KISS_CMD_DATA_FRAME, fbuf, len(fbuf), nil, -1)     // KISS pseudo terminal
+	packetStream.Send(channel, pp, alevel)                                             // WebSocket
+	bridge_send_rec_packet(channel, fbuf)                                              // Other instances
+
+	trace_printf(pp, "sent to %d AGW, %d KISS TCP, %d WebSocket clients",
+		server_num_monitoring_clients(), kissNetSvc.NumClients(channel), packetStream.countChannel(channel))
 
 	if A_opt_ais_to_obj && len(ais_obj_packet) != 0 {
 		var ao_pp = AX25FromText(ais_obj_packet, true)
@@ -1156,6 +1325,8 @@ func app_process_rec_packet(channel int, subchan int, slice int, pp *packet_t, a
 	 */
 
 	if channel == audio_config.igate_vchannel {
+		trace_printf(pp, "from ICHANNEL, no IGate or digipeating")
+
 		return
 	}
 
@@ -1168,13 +1339,17 @@ func app_process_rec_packet(channel int, subchan int, slice int, pp *packet_t, a
 	 */
 
 	if subchan == -1 { // from DTMF decoder
-		if dw_tt_config.gateway_enabled > 0 && len(pinfo) >= 2 {
+		if len(pinfo) >= 2 && dtmfRemote.Sequence(channel, string(pinfo[1:])) {
+			trace_printf(pp, "sent to DTMF remote control")
+		} else if dw_tt_config.gateway_enabled > 0 && len(pinfo) >= 2 {
+			trace_printf(pp, "sent to APRStt gateway")
 			ttGateway.Sequence(channel, string(pinfo[1:]))
 		}
 	} else if len(pinfo) >= 2 && pinfo[0] == 't' && dw_tt_config.gateway_enabled > 0 {
 		// For testing.
 		// Would be nice to verify it was generated locally,
 		// not received over the air.
+		trace_printf(pp, "sent to APRStt gateway")
 		ttGateway.Sequence(channel, string(pinfo[1:]))
 	} else {
 		/*
@@ -1186,6 +1361,8 @@ func app_process_rec_packet(channel int, subchan int, slice int, pp *packet_t, a
 		 */
 		if ax25_is_aprs(pp) && (retries == RETRY_NONE || fec_type == fec_type_fx25 || fec_type == fec_type_il2p) {
 			igate_send_rec_packet(channel, pp)
+		} else {
+			trace_printf(pp, "IGate: not APRS or not a perfect CRC")
 		}
 
 		/* Send out a regenerated copy. Applies to all types, not just APRS. */
@@ -1204,6 +1381,8 @@ func app_process_rec_packet(channel int, subchan int, slice int, pp *packet_t, a
 		 */
 		if ax25_is_aprs(pp) && (retries == RETRY_NONE || fec_type == fec_type_fx25 || fec_type == fec_type_il2p) {
 			digipeater(channel, pp)
+		} else {
+			trace_printf(pp, "APRS digipeater: not APRS or not a perfect CRC")
 		}
 
 		/*
@@ -1222,7 +1401,7 @@ func app_process_rec_packet(channel int, subchan int, slice int, pp *packet_t, a
 func setup_sigint_handler() {
 	var sigChan = make(chan os.Signal, 1)
 
-	signal.Notify(sigChan, syscall.SIGINT)
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM) // SIGTERM is how systemd stops us.
 
 	go func() {
 		<-sigChan
@@ -1231,11 +1410,21 @@ func setup_sigint_handler() {
 }
 
 func cleanup() {
+	tui_stop()
+
+	systemdNotifier.Stopping()
+
 	text_color_set(DW_COLOR_INFO)
 	dw_printf("\nQRT\n")
 	if packetLogger != nil {
 		packetLogger.Close()
 	}
+	pcapWriter.Close()
+	tnc2Logger.Close()
+	txActivity.Close()
+	easExporter.Close()
+	alertService.Close()
+	controlSvc.Close()
 	ptt_term()
 	dwgps_term()
 
@@ -1243,6 +1432,8 @@ func cleanup() {
 		waypointSender.Close()
 	}
 
+	log_json_close()
+
 	SLEEP_SEC(1)
 	os.Exit(0)
 }
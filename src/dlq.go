@@ -280,9 +280,17 @@ func dlq_rec_frame_real(channel int, subchannel int, slice int, pp *packet_t, al
 func dlq_rec_frame(channel int, subchannel int, slice int, pp *packet_t, alevel ALevel, fec_type fec_type_t, retries BitFixLevel, spectrum string) {
 	if ATEST_C {
 		dlq_rec_frame_fake(channel, subchannel, slice, pp, alevel, fec_type, retries, spectrum)
-	} else {
-		dlq_rec_frame_real(channel, subchannel, slice, pp, alevel, fec_type, retries, spectrum)
+
+		return
+	}
+
+	// Copies from voting receivers wait to see which is best.
+
+	if voter != nil && voter.Add(channel, subchannel, slice, pp, alevel, fec_type, retries, spectrum) {
+		return
 	}
+
+	dlq_rec_frame_real(channel, subchannel, slice, pp, alevel, fec_type, retries, spectrum)
 }
 
 /*-------------------------------------------------------------------
@@ -887,6 +895,36 @@ func dlq_wait_while_empty(timeout time.Time) bool {
 	return (timed_out_result)
 } /* end dlq_wait_while_empty */
 
+/*-------------------------------------------------------------------
+ *
+ * Name:        dlq_wait_until_idle
+ *
+ * Purpose:     Wait for everything in the queue to be processed.
+ *
+ * Inputs:	timeout	- Longest time to wait.
+ *
+ * Description:	At the end of stdin or a WAV file, the last frame
+ *		received could still be waiting here.  Exiting right
+ *		away would lose it.
+ *
+ *--------------------------------------------------------------------*/
+
+func dlq_wait_until_idle(timeout time.Duration) {
+	var deadline = time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		dlq_mutex.Lock()
+		var idle = dlq_queue_head == nil && recv_thread_is_waiting
+		dlq_mutex.Unlock()
+
+		if idle {
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        dlq_remove
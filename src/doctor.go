@@ -0,0 +1,412 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Check that everything needed is in place before
+ *		starting direwolf, and say what to fix if not.
+ *
+ * Description:	These are the troubleshooting steps people usually go
+ *		through by hand when something doesn't work:
+ *
+ *		- Does the configuration file read without complaint?
+ *		- Can the audio devices be found?
+ *		- Do the PTT devices exist, with permission to use them?
+ *		- Are the AGW and KISS TCP ports free?
+ *		- Can the APRS-IS server be reached?
+ *
+ *		Nothing is transmitted.  Serial ports are not opened
+ *		because that could key the radio.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"golang.org/x/sys/unix"
+)
+
+type doctor_status_t int
+
+const (
+	DOCTOR_PASS doctor_status_t = iota
+	DOCTOR_WARN
+	DOCTOR_FAIL
+	DOCTOR_SKIP
+)
+
+var doctorStatusNames = map[doctor_status_t]string{
+	DOCTOR_PASS: "PASS",
+	DOCTOR_WARN: "WARN",
+	DOCTOR_FAIL: "FAIL",
+	DOCTOR_SKIP: "SKIP",
+}
+
+type doctorResult struct {
+	status doctor_status_t
+	check  string
+	detail string
+}
+
+func doctor_result(status doctor_status_t, check string, format string, a ...any) doctorResult {
+	return doctorResult{status: status, check: check, detail: fmt.Sprintf(format, a...)}
+}
+
+func DoctorMain() {
+	var configFileName = pflag.StringP("config-file", "c", "direwolf.conf", "Configuration file name.")
+	var timeout = pflag.DurationP("timeout", "t", 5*time.Second, "How long to wait for the APRS-IS server.")
+	var help = pflag.BoolP("help", "h", false, "Display help text.")
+
+	pflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s - check the audio, PTT, network, and configuration for problems.\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Usage: doctor [options]\n")
+		pflag.PrintDefaults()
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Exit status is 1 if any check failed.\n")
+	}
+
+	pflag.Parse()
+
+	if *help || pflag.NArg() > 0 {
+		pflag.Usage()
+		os.Exit(1)
+	}
+
+	var results, failed = doctor(*configFileName, *timeout)
+
+	doctor_report(os.Stdout, results)
+
+	if failed {
+		os.Exit(1)
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        doctor
+ *
+ * Purpose:     Run all of the checks.
+ *
+ * Inputs:	fname		- Configuration file name.
+ *
+ *		timeout		- Time allowed to connect to APRS-IS.
+ *
+ * Returns:	Results of each check, and whether any failed.
+ *
+ * Description:	The configuration is needed for everything else so
+ *		nothing more is checked if it can't be read.
+ *
+ *--------------------------------------------------------------------*/
+
+func doctor(fname string, timeout time.Duration) ([]doctorResult, bool) {
+	var results []doctorResult
+
+	var audio = new(audio_s)
+	var digi = new(digi_config_s)
+	var cdigi = new(cdigi_config_s)
+	var tt = new(tt_config_s)
+	var igate = new(igate_config_s)
+	var misc = new(misc_config_s)
+
+	var absFilePath, _ = filepath.Abs(fname)
+
+	var _, statErr = os.Stat(absFilePath)
+	if statErr != nil {
+		results = append(results, doctor_result(DOCTOR_FAIL, "Configuration", "%s.  Use -c for a different file, or samoyed-genconf to make one.", statErr))
+
+		return results, true
+	}
+
+	config_init(absFilePath, audio, digi, cdigi, tt, igate, misc)
+
+	dw_printf("\n")
+
+	results = append(results, doctor_result(DOCTOR_PASS, "Configuration", "Read %s.  Check for any messages above.", absFilePath))
+
+	var ps = &parseState{ //nolint:exhaustruct
+		audio: audio,
+		digi:  digi,
+		cdigi: cdigi,
+		tt:    tt,
+		igate: igate,
+		misc:  misc,
+	}
+
+	for _, warning := range config_lint(ps) {
+		results = append(results, doctor_result(DOCTOR_WARN, "Configuration", "%s", warning))
+	}
+
+	results = append(results, doctor_audio(audio)...)
+	results = append(results, doctor_ptt(audio)...)
+	results = append(results, doctor_ports(misc)...)
+	results = append(results, doctor_aprsis(igate, timeout))
+
+	var failed = false
+
+	for _, r := range results {
+		if r.status == DOCTOR_FAIL {
+			failed = true
+		}
+	}
+
+	return results, failed
+}
+
+func doctor_report(w io.Writer, results []doctorResult) {
+	var counts = make(map[doctor_status_t]int)
+
+	for _, r := range results {
+		counts[r.status]++
+
+		// Continuation lines of longer explanations line up after the check name.
+		var detail = strings.ReplaceAll(r.detail, "\n", "\n"+strings.Repeat(" ", 30))
+
+		fmt.Fprintf(w, "[%s]  %-22s %s\n", doctorStatusNames[r.status], r.check, detail)
+	}
+
+	fmt.Fprintf(w, "\n%d passed, %d warnings, %d failed, %d skipped.\n",
+		counts[DOCTOR_PASS], counts[DOCTOR_WARN], counts[DOCTOR_FAIL], counts[DOCTOR_SKIP])
+}
+
+/*
+ * Audio devices.  Only sound cards need to be found, not stdin or UDP.
+ */
+
+func doctor_audio(pa *audio_s) []doctorResult {
+	var results []doctorResult
+
+	var portaudioOK = false
+
+	if anyDeviceRequiresPortAudio(pa) {
+		var err = portaudio_acquire()
+		if err != nil {
+			return []doctorResult{doctor_result(DOCTOR_FAIL, "Audio", "PortAudio initialization failed: %s", err)}
+		}
+
+		defer portaudio_release()
+
+		portaudioOK = true
+	}
+
+	if audio_none(pa) {
+		return []doctorResult{doctor_result(DOCTOR_SKIP, "Audio", "ADEVICE none, so there is no radio.")}
+	}
+
+	for a := range MAX_ADEVS {
+		if pa.adev[a].defined == 0 {
+			continue
+		}
+
+		var check = fmt.Sprintf("Audio device %d", a)
+
+		for _, forInput := range []bool{true, false} {
+			var name = pa.adev[a].adevice_out
+			var direction = "output"
+
+			if forInput {
+				name = pa.adev[a].adevice_in
+				direction = "input"
+			}
+
+			var backend, rest = audio_backend(name, forInput)
+
+			switch backend {
+			case AUDIO_BACKEND_STDIN, AUDIO_BACKEND_UDP:
+				results = append(results, doctor_result(DOCTOR_SKIP, check, "%s \"%s\" is not a sound card.", direction, name))
+
+			case AUDIO_BACKEND_ALSA, AUDIO_BACKEND_OSS:
+				results = append(results, doctor_audio_node(check, direction, name, rest, backend, forInput))
+
+			case AUDIO_BACKEND_PORTAUDIO:
+				if !portaudioOK {
+					continue
+				}
+
+				var devName, channels, found = portaudio_find(name, forInput)
+				if !found {
+					results = append(results, doctor_result(DOCTOR_FAIL, check,
+						"%s \"%s\" not found.  Use \"arecord -l\" and \"aplay -l\" to see what is available.", direction, name))
+
+					continue
+				}
+
+				if channels < pa.adev[a].num_channels {
+					results = append(results, doctor_result(DOCTOR_FAIL, check,
+						"%s \"%s\" is %s which has %d channels but %d are needed.", direction, name, devName, channels, pa.adev[a].num_channels))
+
+					continue
+				}
+
+				results = append(results, doctor_result(DOCTOR_PASS, check, "%s \"%s\" is %s.", direction, name, devName))
+			}
+		}
+	}
+
+	return results
+}
+
+/*
+ * ALSA or OSS, without PortAudio.  The device must exist and be
+ * ours to use.  It isn't opened, because that could take it from
+ * a running direwolf.
+ */
+
+func doctor_audio_node(check string, direction string, name string, rest string, backend audio_backend_e, forInput bool) doctorResult {
+	var path = rest
+
+	if backend == AUDIO_BACKEND_ALSA {
+		var err error
+
+		path, err = alsa_device_path(rest, forInput)
+		if err != nil {
+			return doctor_result(DOCTOR_FAIL, check, "%s \"%s\": %s", direction, name, err)
+		}
+	}
+
+	var err = unix.Access(path, unix.R_OK|unix.W_OK)
+	if err != nil {
+		return doctor_result(DOCTOR_FAIL, check, "%s \"%s\" is %s: %s", direction, name, path, err)
+	}
+
+	return doctor_result(DOCTOR_PASS, check, "%s \"%s\" is %s.", direction, name, path)
+}
+
+/*
+ * PTT devices.  Serial ports, HID, and GPIO chips must exist and
+ * be readable and writable by us, without changing their state.
+ */
+
+func doctor_ptt(pa *audio_s) []doctorResult {
+	var results []doctorResult
+
+	for ch := range MAX_RADIO_CHANS {
+		if pa.chan_medium[ch] != MEDIUM_RADIO {
+			continue
+		}
+
+		var octrl = &pa.achan[ch].octrl[OCTYPE_PTT]
+		var check = fmt.Sprintf("PTT channel %d", ch)
+
+		switch octrl.ptt_method {
+		case PTT_METHOD_NONE:
+			results = append(results, doctor_result(DOCTOR_SKIP, check, "No PTT configured, VOX or receive only."))
+		case PTT_METHOD_SERIAL:
+			results = append(results, doctor_device(check, octrl.ptt_device, "Serial port",
+				"Add yourself to the \"dialout\" group, then log out and in again."))
+		case PTT_METHOD_CM108:
+			if octrl.ptt_device == "" {
+				results = append(results, doctor_result(DOCTOR_FAIL, check, "No CM108 device.  Run samoyed-cm108 to see what is available."))
+			} else {
+				results = append(results, doctor_device(check, octrl.ptt_device, "CM108 GPIO "+strconv.Itoa(octrl.out_gpio_num),
+					"See the udev rule in the User Guide to allow access to hidraw devices."))
+			}
+		case PTT_METHOD_GPIOD:
+			var chip = octrl.out_gpio_name
+			if !strings.HasPrefix(chip, "/") {
+				chip = "/dev/" + chip
+			}
+
+			results = append(results, doctor_device(check, chip, "GPIO line "+strconv.Itoa(octrl.out_gpio_num),
+				"Add yourself to the \"gpio\" group, then log out and in again."))
+		case PTT_METHOD_CAT:
+			results = append(results, doctor_device(check, octrl.ptt_device, "CAT "+cat_type_name(octrl.ptt_cat_type),
+				"Add yourself to the \"dialout\" group, then log out and in again."))
+		case PTT_METHOD_MCP23017:
+			results = append(results, doctor_device(check, octrl.ptt_device,
+				fmt.Sprintf("MCP23017 0x%02x %s", octrl.ptt_i2c_addr, mcp23017_pin_name(octrl.out_gpio_num)),
+				"Enable I2C with raspi-config, and add yourself to the \"i2c\" group, then log out and in again."))
+		case PTT_METHOD_LPT, PTT_METHOD_HAMLIB:
+			results = append(results, doctor_result(DOCTOR_SKIP, check, "Parallel port and Hamlib PTT are not checked."))
+		case PTT_METHOD_HTTP, PTT_METHOD_MQTT:
+			results = append(results, doctor_result(DOCTOR_SKIP, check, "Network relay PTT is not checked, to avoid switching it."))
+		}
+	}
+
+	return results
+}
+
+func doctor_device(check string, path string, what string, hint string) doctorResult {
+	var _, statErr = os.Stat(path)
+	if statErr != nil {
+		return doctor_result(DOCTOR_FAIL, check, "%s: %s", what, statErr)
+	}
+
+	var accessErr = unix.Access(path, unix.R_OK|unix.W_OK)
+	if accessErr != nil {
+		return doctor_result(DOCTOR_FAIL, check, "%s: %s: %s.\n%s", what, path, accessErr, hint)
+	}
+
+	return doctor_result(DOCTOR_PASS, check, "%s: %s", what, path)
+}
+
+/*
+ * TCP ports we listen on.  In use probably means another copy is running.
+ */
+
+func doctor_ports(misc *misc_config_s) []doctorResult {
+	var results []doctorResult
+
+	var names = []string{"AGWPORT"}
+	var ports = []int{misc.agwpe_port}
+
+	for i := range MAX_KISS_TCP_PORTS {
+		names = append(names, "KISSPORT")
+		ports = append(ports, misc.kiss_port[i])
+	}
+
+	for i, port := range ports {
+		if port == 0 {
+			continue
+		}
+
+		var check = fmt.Sprintf("%s %d", names[i], port)
+
+		var listener, err = net.Listen("tcp", ":"+strconv.Itoa(port))
+		if err != nil {
+			results = append(results, doctor_result(DOCTOR_FAIL, check, "%s.  Is direwolf or another application already running?", err))
+
+			continue
+		}
+
+		listener.Close()
+
+		results = append(results, doctor_result(DOCTOR_PASS, check, "Available."))
+	}
+
+	return results
+}
+
+/*
+ * APRS-IS server, only if the IGate is configured.
+ */
+
+func doctor_aprsis(igate *igate_config_s, timeout time.Duration) doctorResult {
+	var check = "APRS-IS"
+
+	if igate.t2_server_name == "" {
+		return doctor_result(DOCTOR_SKIP, check, "No IGSERVER configured.")
+	}
+
+	var address = net.JoinHostPort(igate.t2_server_name, strconv.Itoa(igate.t2_server_port))
+
+	var conn, err = net.DialTimeout("tcp", address, timeout)
+	if err != nil {
+		return doctor_result(DOCTOR_FAIL, check, "Can't connect to %s: %s", address, err)
+	}
+
+	conn.Close()
+
+	if igate.t2_login == "" {
+		return doctor_result(DOCTOR_WARN, check, "Connected to %s but IGLOGIN is not set.", address)
+	}
+
+	return doctor_result(DOCTOR_PASS, check, "Connected to %s.", address)
+}
@@ -0,0 +1,115 @@
+package direwolf
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_doctor_missing_config(t *testing.T) {
+	var results, failed = doctor(filepath.Join(t.TempDir(), "nonexistent.conf"), time.Second)
+
+	assert.True(t, failed)
+	require.Len(t, results, 1)
+	assert.Equal(t, DOCTOR_FAIL, results[0].status)
+	assert.Equal(t, "Configuration", results[0].check)
+}
+
+func Test_doctor(t *testing.T) {
+	// Something already listening on the AGW port.
+	var busy, err = net.Listen("tcp", ":0")
+	require.NoError(t, err)
+
+	defer busy.Close()
+
+	var port = busy.Addr().(*net.TCPAddr).Port
+
+	var ptt = filepath.Join(t.TempDir(), "ttyUSB0")
+	require.NoError(t, os.WriteFile(ptt, nil, 0600))
+
+	var fname = filepath.Join(t.TempDir(), "direwolf.conf")
+	require.NoError(t, os.WriteFile(fname, fmt.Appendf(nil,
+		"ADEVICE - udp:localhost:7356\n"+
+			"CHANNEL 0\n"+
+			"MYCALL Q1TEST\n"+
+			"PTT %s RTS\n"+
+			"AGWPORT %d\n"+
+			"KISSPORT 0\n", ptt, port), 0600))
+
+	var results, failed = doctor(fname, time.Second)
+
+	assert.True(t, failed)
+	assert.Contains(t, results, doctorResult{status: DOCTOR_PASS, check: "PTT channel 0", detail: "Serial port: " + ptt})
+	assert.Contains(t, results, doctorResult{status: DOCTOR_SKIP, check: "Audio device 0", detail: "input \"-\" is not a sound card."})
+	assert.Contains(t, results, doctorResult{status: DOCTOR_SKIP, check: "APRS-IS", detail: "No IGSERVER configured."})
+
+	var found = false
+
+	for _, r := range results {
+		if r.check == fmt.Sprintf("AGWPORT %d", port) {
+			assert.Equal(t, DOCTOR_FAIL, r.status)
+
+			found = true
+		}
+	}
+
+	assert.True(t, found)
+}
+
+func Test_doctor_audio_none(t *testing.T) {
+	var pa, _ = configFromString(t, "ADEVICE none\n")
+
+	assert.Equal(t, []doctorResult{{status: DOCTOR_SKIP, check: "Audio", detail: "ADEVICE none, so there is no radio."}}, doctor_audio(pa))
+}
+
+func Test_doctor_device(t *testing.T) {
+	var dir = t.TempDir()
+
+	var r = doctor_device("PTT channel 0", filepath.Join(dir, "hidraw9"), "CM108 GPIO 3", "")
+	assert.Equal(t, DOCTOR_FAIL, r.status)
+
+	var path = filepath.Join(dir, "hidraw0")
+	require.NoError(t, os.WriteFile(path, nil, 0600))
+
+	r = doctor_device("PTT channel 0", path, "CM108 GPIO 3", "")
+	assert.Equal(t, DOCTOR_PASS, r.status)
+	assert.Equal(t, "CM108 GPIO 3: "+path, r.detail)
+}
+
+func Test_doctor_aprsis(t *testing.T) {
+	var server, err = net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+
+	var igate = new(igate_config_s)
+	igate.t2_server_name = "127.0.0.1"
+	igate.t2_server_port = server.Addr().(*net.TCPAddr).Port
+
+	assert.Equal(t, DOCTOR_WARN, doctor_aprsis(igate, time.Second).status)
+
+	igate.t2_login = "Q1TEST"
+	assert.Equal(t, DOCTOR_PASS, doctor_aprsis(igate, time.Second).status)
+
+	server.Close()
+	assert.Equal(t, DOCTOR_FAIL, doctor_aprsis(igate, time.Second).status)
+}
+
+func Test_doctor_report(t *testing.T) {
+	var buf bytes.Buffer
+
+	doctor_report(&buf, []doctorResult{
+		{status: DOCTOR_PASS, check: "KISSPORT 8001", detail: "Available."},
+		{status: DOCTOR_FAIL, check: "PTT channel 0", detail: "Serial port: permission denied.\nAdd yourself to the \"dialout\" group."},
+	})
+
+	assert.Equal(t, "[PASS]  KISSPORT 8001          Available.\n"+
+		"[FAIL]  PTT channel 0          Serial port: permission denied.\n"+
+		"                              Add yourself to the \"dialout\" group.\n"+
+		"\n1 passed, 0 warnings, 1 failed, 0 skipped.\n", buf.String())
+}
@@ -0,0 +1,204 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Remote control of an unattended station with touch tones.
+ *
+ * Description:	Configured with
+ *
+ *			DTMFPIN  pin
+ *			DTMFCMD  code  BEACON
+ *			DTMFCMD  code  IGATE  ON|OFF
+ *			DTMFCMD  code  RUN  command...
+ *
+ *		and sent as
+ *
+ *			D pin * code #
+ *
+ *		e.g. "D4321*11#".  The APRStt gateway doesn't use a
+ *		leading D so the two can share a channel.
+ *		The DTMF decoder must be turned on for the channel,
+ *		with DTMF or TTOBJ.
+ *
+ *		Every attempt is logged, with the channel, right or wrong.
+ *		After a few wrong PINs in a row, everything is ignored for
+ *		a while to slow down anyone trying to guess it.
+ *
+ *		Remember that anyone listening hears the PIN.
+ *		This keeps out the casual, not the determined.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type dtmf_remote_action_t int
+
+const (
+	DTMF_REMOTE_BEACON dtmf_remote_action_t = iota
+	DTMF_REMOTE_IGATE_ON
+	DTMF_REMOTE_IGATE_OFF
+	DTMF_REMOTE_RUN
+)
+
+type dtmf_remote_cmd_s struct {
+	code    string /* Buttons after the PIN, e.g. "11". */
+	action  dtmf_remote_action_t
+	command string /* For RUN. */
+}
+
+/* Wrong PINs in a row before locking out, and for how long. */
+
+const DTMF_REMOTE_MAX_FAILS = 3
+
+const DTMF_REMOTE_LOCKOUT = 5 * time.Minute
+
+/* PIN and command codes use the same buttons. */
+
+func dtmf_remote_buttons_ok(s string) bool {
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789ABCD", c) {
+			return false
+		}
+	}
+
+	return s != ""
+}
+
+type DTMFRemote struct {
+	config *tt_config_s
+	beacon func() /* Send all beacons now. */
+
+	/* Audible response, replaced for testing. */
+	respond func(channel int, method string, text string)
+
+	mutex       sync.Mutex
+	fails       int
+	lockedUntil time.Time
+}
+
+func NewDTMFRemote(config *tt_config_s, beacon func()) *DTMFRemote {
+	return &DTMFRemote{ //nolint:exhaustruct
+		config:  config,
+		beacon:  beacon,
+		respond: dtmf_remote_respond,
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Sequence
+ *
+ * Purpose:	Act on a complete touch tone sequence if it is for us.
+ *
+ * Inputs:	channel	- Radio channel it came from.
+ *		msg	- Buttons, ending with #.
+ *
+ * Returns:	true if it was a remote control sequence, right or
+ *		wrong, so it shouldn't go anywhere else.
+ *
+ *--------------------------------------------------------------------*/
+
+func (r *DTMFRemote) Sequence(channel int, msg string) bool {
+	if r == nil || len(r.config.remote_cmds) == 0 || !strings.HasPrefix(msg, "D") {
+		return false
+	}
+
+	r.mutex.Lock()
+	defer r.mutex.Unlock()
+
+	var now = time.Now()
+
+	if now.Before(r.lockedUntil) {
+		dtmf_remote_log(DW_COLOR_ERROR, channel, "Ignored, locked out after too many wrong PINs until %s.", r.lockedUntil.Format("15:04:05"))
+		return true
+	}
+
+	var pin, code, _ = strings.Cut(strings.TrimSuffix(msg[1:], "#"), "*")
+
+	if subtle.ConstantTimeCompare([]byte(pin), []byte(r.config.remote_pin)) != 1 {
+		r.fails++
+		dtmf_remote_log(DW_COLOR_ERROR, channel, "Wrong PIN, %d in a row.", r.fails)
+
+		if r.fails >= DTMF_REMOTE_MAX_FAILS {
+			r.lockedUntil = now.Add(DTMF_REMOTE_LOCKOUT)
+			r.fails = 0
+			dtmf_remote_log(DW_COLOR_ERROR, channel, "Locked out for %s.", DTMF_REMOTE_LOCKOUT)
+		}
+
+		return true
+	}
+
+	r.fails = 0
+
+	for _, cmd := range r.config.remote_cmds {
+		if cmd.code == code {
+			r.run(channel, cmd)
+			return true
+		}
+	}
+
+	dtmf_remote_log(DW_COLOR_ERROR, channel, "Unknown command %s.", code)
+
+	var resp = r.config.response[TT_ERROR_MACRO_NOMATCH]
+	r.respond(channel, resp.method, resp.mtext)
+
+	return true
+}
+
+func (r *DTMFRemote) run(channel int, cmd *dtmf_remote_cmd_s) {
+	var ok = true
+
+	switch cmd.action {
+	case DTMF_REMOTE_BEACON:
+		dtmf_remote_log(DW_COLOR_INFO, channel, "Command %s, send beacons now.", cmd.code)
+		r.beacon()
+	case DTMF_REMOTE_IGATE_ON:
+		dtmf_remote_log(DW_COLOR_INFO, channel, "Command %s, turn IGate on.", cmd.code)
+		igate_set_enabled(true)
+	case DTMF_REMOTE_IGATE_OFF:
+		dtmf_remote_log(DW_COLOR_INFO, channel, "Command %s, turn IGate off.", cmd.code)
+		igate_set_enabled(false)
+	case DTMF_REMOTE_RUN:
+		dtmf_remote_log(DW_COLOR_INFO, channel, "Command %s, run %s", cmd.code, cmd.command)
+
+		var c = exec.Command("sh", "-c", cmd.command) //nolint:gosec // Trust the user-supplied config
+		c.Env = append(os.Environ(), "DTMF_CHANNEL="+strconv.Itoa(channel), "DTMF_CODE="+cmd.code)
+		c.Stdout = os.Stdout
+		c.Stderr = os.Stderr
+
+		var err = c.Run()
+		if err != nil {
+			dtmf_remote_log(DW_COLOR_ERROR, channel, "Command %s failed: %s", cmd.code, err)
+			ok = false
+		}
+	}
+
+	var resp = r.config.response[IfThenElse(ok, TT_ERROR_OK, TT_ERROR_INTERNAL)]
+	r.respond(channel, resp.method, resp.mtext)
+}
+
+func dtmf_remote_log(color dw_color_e, channel int, format string, a ...any) {
+	text_color_set(color)
+	dw_printf("DTMF remote control, channel %d: %s\n", channel, fmt.Sprintf(format, a...))
+}
+
+/* Same way as the APRStt gateway responds. */
+
+func dtmf_remote_respond(channel int, method string, text string) {
+	var pp = AX25FromText(fmt.Sprintf("APRSTT>%s:%s", method, text), false)
+	if pp == nil {
+		return
+	}
+
+	pp.tx_reason = TX_REASON_APRSTT
+	tq_append(channel, TQ_PRIO_0_HI, pp)
+}
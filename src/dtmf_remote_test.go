@@ -0,0 +1,105 @@
+package direwolf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestDTMFRemote(t *testing.T, cmds ...*dtmf_remote_cmd_s) (*DTMFRemote, *int, *[]string) {
+	t.Helper()
+
+	var config = new(tt_config_s)
+	config.remote_pin = "4321"
+	config.remote_cmds = cmds
+	config.response[TT_ERROR_OK].mtext = "R"
+	config.response[TT_ERROR_INTERNAL].mtext = "?"
+	config.response[TT_ERROR_MACRO_NOMATCH].mtext = "?"
+
+	var beacons = 0
+	var responses []string
+
+	var r = NewDTMFRemote(config, func() { beacons++ })
+	r.respond = func(_ int, _ string, text string) {
+		responses = append(responses, text)
+	}
+
+	t.Cleanup(func() { igate_set_enabled(true) })
+
+	return r, &beacons, &responses
+}
+
+func Test_dtmf_remote_not_ours(t *testing.T) {
+	var r, _, _ = newTestDTMFRemote(t)
+	assert.False(t, r.Sequence(0, "D4321*11#"), "nothing configured")
+
+	r, _, _ = newTestDTMFRemote(t, &dtmf_remote_cmd_s{code: "11", action: DTMF_REMOTE_BEACON}) //nolint:exhaustruct
+	assert.False(t, r.Sequence(0, "4321*11#"), "APRStt, no leading D")
+
+	var nilRemote *DTMFRemote
+	assert.False(t, nilRemote.Sequence(0, "D4321*11#"))
+}
+
+func Test_dtmf_remote_commands(t *testing.T) {
+	var r, beacons, responses = newTestDTMFRemote(t,
+		&dtmf_remote_cmd_s{code: "11", action: DTMF_REMOTE_BEACON},    //nolint:exhaustruct
+		&dtmf_remote_cmd_s{code: "20", action: DTMF_REMOTE_IGATE_OFF}, //nolint:exhaustruct
+		&dtmf_remote_cmd_s{code: "21", action: DTMF_REMOTE_IGATE_ON},  //nolint:exhaustruct
+	)
+
+	assert.True(t, r.Sequence(0, "D4321*11#"))
+	assert.Equal(t, 1, *beacons)
+
+	assert.True(t, r.Sequence(0, "D4321*20#"))
+	assert.True(t, igate_disabled.Load())
+
+	assert.True(t, r.Sequence(0, "D4321*21#"))
+	assert.False(t, igate_disabled.Load())
+
+	assert.True(t, r.Sequence(0, "D4321*99#"))
+
+	assert.Equal(t, []string{"R", "R", "R", "?"}, *responses)
+}
+
+func Test_dtmf_remote_run(t *testing.T) {
+	var out = filepath.Join(t.TempDir(), "out")
+
+	var r, _, responses = newTestDTMFRemote(t,
+		&dtmf_remote_cmd_s{code: "30", action: DTMF_REMOTE_RUN, command: "echo $DTMF_CHANNEL $DTMF_CODE > " + out},
+		&dtmf_remote_cmd_s{code: "31", action: DTMF_REMOTE_RUN, command: "exit 1"},
+	)
+
+	assert.True(t, r.Sequence(2, "D4321*30#"))
+
+	var b, err = os.ReadFile(out)
+	require.NoError(t, err)
+	assert.Equal(t, "2 30\n", string(b))
+
+	assert.True(t, r.Sequence(2, "D4321*31#"))
+
+	assert.Equal(t, []string{"R", "?"}, *responses)
+}
+
+func Test_dtmf_remote_wrong_pin(t *testing.T) {
+	var r, beacons, responses = newTestDTMFRemote(t, &dtmf_remote_cmd_s{code: "11", action: DTMF_REMOTE_BEACON}) //nolint:exhaustruct
+
+	// A right PIN resets the count.
+	assert.True(t, r.Sequence(0, "D1111*11#"))
+	assert.True(t, r.Sequence(0, "D432*11#"))
+	assert.True(t, r.Sequence(0, "D4321*11#"))
+	assert.Equal(t, 1, *beacons)
+
+	for range DTMF_REMOTE_MAX_FAILS {
+		assert.True(t, r.Sequence(0, "D43210*11#"))
+	}
+
+	// Now even the right PIN is ignored.
+	assert.True(t, r.Sequence(0, "D4321*11#"))
+	assert.Equal(t, 1, *beacons)
+
+	// No response to wrong PINs.
+	assert.Equal(t, []string{"R"}, *responses)
+}
@@ -5,14 +5,24 @@ package direwolf
  *
  * Purpose:   	Interface for obtaining location from GPS.
  *
- * Description:	This is a wrapper for two different implementations:
+ * Description:	This is a wrapper for different position sources:
  *
  *		(1) Read NMEA sentences from a serial port (or USB
  *		    that looks line one).  Available for all platforms.
  *
- *		(2) Read from gpsd.  Not available for Windows.
- *		    Including this is optional because it depends
- *		    on another external software component.
+ *		(2) Read from gpsd, over the network.
+ *
+ *		(3) Set by an external system through the control socket
+ *		    or HTTP server, with GPSAPI in the configuration.
+ *
+ *		(4) A fixed, surveyed, location from the configuration.
+ *
+ *		Any or all of these can be used at the same time.
+ *		dwgps_read uses the first one, in GPSPRIORITY order,
+ *		which has a fix and has been updated within GPSTIMEOUT
+ *		seconds.  For example, a digipeater with a GPS that is
+ *		sometimes disconnected can fall back to its surveyed
+ *		location.
  *
  *
  * API:		dwgps_init	Connect to data stream at start up time.
@@ -21,15 +31,20 @@ package direwolf
  *
  *		dwgps_print	Print contents of structure for debugging.
  *
+ *		dwgps_set_position  Location from an external system.
+ *
  *		dwgps_term	Shutdown on exit.
  *
  *
- * from below:	dwgps_set_data	Called from other two implementations to
+ * from below:	dwgps_set_data	Called from the other implementations to
  *				save data until it is needed.
  *
  *---------------------------------------------------------------*/
 
 import (
+	"errors"
+	"fmt"
+	"strings"
 	"sync"
 	"time"
 )
@@ -66,20 +81,69 @@ type dwgps_info_t struct {
 	speed_knots float64   /* libgps uses meters/sec but we use GPS usual knots. */
 	track       float64   /* What is difference between track and course? */
 	altitude    float64   /* meters above mean sea level. Valid if fix == 3. */
+	quality     int       /* From GGA: 1 = GPS, 2 = DGPS, 4 = RTK, etc.  G_UNKNOWN if not known. */
+	num_sat     int       /* Satellites used.  G_UNKNOWN if not known. */
+	pdop        float64   /* Dilution of precision, position, horizontal, and */
+	hdop        float64   /* vertical.  Smaller is better.  G_UNKNOWN if not known. */
+	vdop        float64
+	source      dwgps_source_t /* Where this came from. */
+}
+
+type dwgps_source_t int
+
+const (
+	DWGPS_SOURCE_NMEA dwgps_source_t = iota
+	DWGPS_SOURCE_GPSD
+	DWGPS_SOURCE_API
+	DWGPS_SOURCE_FIXED
+	DWGPS_NUM_SOURCES
+)
+
+func (s dwgps_source_t) String() string {
+	switch s {
+	case DWGPS_SOURCE_NMEA:
+		return "NMEA"
+	case DWGPS_SOURCE_GPSD:
+		return "GPSD"
+	case DWGPS_SOURCE_API:
+		return "API"
+	case DWGPS_SOURCE_FIXED:
+		return "FIXED"
+	default:
+		return "unknown"
+	}
 }
 
+func dwgps_source_from_name(name string) (dwgps_source_t, bool) {
+	for s := range DWGPS_NUM_SOURCES {
+		if strings.EqualFold(name, s.String()) {
+			return s, true
+		}
+	}
+
+	return 0, false
+}
+
+/* Used when there is no GPSPRIORITY. */
+
+var dwgps_default_priority = []dwgps_source_t{DWGPS_SOURCE_NMEA, DWGPS_SOURCE_GPSD, DWGPS_SOURCE_API, DWGPS_SOURCE_FIXED}
+
 var s_dwgps_debug = 0 /* Enable debug output. */
 /* >= 2 show updates from GPS. */
 /* >= 1 show results from dwgps_read. */
 
 /*
  * The GPS reader threads deposit current data here when it becomes available.
- * dwgps_read returns it to the requesting application.
+ * dwgps_read returns the best of them to the requesting application.
  *
  * A critical region to avoid inconsistency between fields.
  */
 
-var s_dwgps_info = new(dwgps_info_t)
+var s_dwgps_info [DWGPS_NUM_SOURCES]dwgps_info_t
+
+var s_dwgps_priority = dwgps_default_priority
+
+var s_dwgps_timeout time.Duration /* 0 for never stale. */
 
 var s_gps_mutex sync.Mutex
 
@@ -104,21 +168,54 @@ var s_gps_mutex sync.Mutex
  * Description:	Call corresponding functions for implementations.
  * 		Normally we would expect someone to use either GPSNMEA or
  *		GPSD but there is nothing to prevent use of both at the
- *		same time.
+ *		same time, along with GPSAPI and GPSFIXED.
  *
  *--------------------------------------------------------------------*/
 
 func dwgps_init(pconfig *misc_config_s, debug int) {
-	dwgps_clear(s_dwgps_info) // Init the global
+	s_gps_mutex.Lock()
+
+	for s := range DWGPS_NUM_SOURCES {
+		dwgps_clear(&s_dwgps_info[s])
+		s_dwgps_info[s].fix = DWFIX_NOT_INIT
+		s_dwgps_info[s].source = s
+	}
+
+	s_dwgps_priority = dwgps_default_priority
+	if len(pconfig.gps_priority) > 0 {
+		s_dwgps_priority = pconfig.gps_priority
+	}
+
+	s_dwgps_timeout = time.Duration(pconfig.gps_timeout) * time.Second
+
+	s_gps_mutex.Unlock()
 
 	s_dwgps_debug = debug
 
 	dwgpsnmea_init(pconfig, debug)
-
-	/* TODO KG
-	#if ENABLE_GPSD
 	dwgpsd_init(pconfig, debug)
-	*/
+
+	if pconfig.gps_api {
+		s_gps_mutex.Lock()
+		s_dwgps_info[DWGPS_SOURCE_API].fix = DWFIX_NOT_SEEN
+		s_gps_mutex.Unlock()
+	}
+
+	if pconfig.gps_fixed_lat != G_UNKNOWN && pconfig.gps_fixed_lon != G_UNKNOWN {
+		var info dwgps_info_t
+		dwgps_clear(&info)
+		info.timestamp = time.Now()
+		info.fix = DWFIX_2D
+		info.dlat = pconfig.gps_fixed_lat
+		info.dlon = pconfig.gps_fixed_lon
+
+		if pconfig.gps_fixed_alt != G_UNKNOWN {
+			info.fix = DWFIX_3D
+			info.altitude = pconfig.gps_fixed_alt
+		}
+
+		dwgps_set_data(DWGPS_SOURCE_FIXED, &info)
+	}
 
 	SLEEP_MS(500) /* So receive thread(s) can clear the */
 	/* not init status before it gets checked. */
@@ -140,6 +237,11 @@ func dwgps_clear(gpsinfo *dwgps_info_t) {
 	gpsinfo.speed_knots = G_UNKNOWN
 	gpsinfo.track = G_UNKNOWN
 	gpsinfo.altitude = G_UNKNOWN
+	gpsinfo.quality = G_UNKNOWN
+	gpsinfo.num_sat = G_UNKNOWN
+	gpsinfo.pdop = G_UNKNOWN
+	gpsinfo.hdop = G_UNKNOWN
+	gpsinfo.vdop = G_UNKNOWN
 }
 
 /*-------------------------------------------------------------------
@@ -152,13 +254,16 @@ func dwgps_clear(gpsinfo *dwgps_info_t) {
  *
  * Returns:	Position fix quality.  Same as in structure.
  *
+ * Description:	The first source, in priority order, with a fix that
+ *		isn't stale.  If there is none, the first one which has
+ *		been initialized, with a stale fix reported as no fix.
  *
  *--------------------------------------------------------------------*/
 
 func dwgps_read(gpsinfo *dwgps_info_t) dwfix_t {
 	s_gps_mutex.Lock()
 
-	*gpsinfo = *s_dwgps_info
+	*gpsinfo = dwgps_select(time.Now())
 
 	s_gps_mutex.Unlock()
 
@@ -167,10 +272,42 @@ func dwgps_read(gpsinfo *dwgps_info_t) dwfix_t {
 		dwgps_print("gps_read: ", gpsinfo)
 	}
 
-	// TODO: Should we check timestamp and complain if very stale?
-	// or should we leave that up to the caller?
+	return (gpsinfo.fix)
+}
+
+/* Caller must hold s_gps_mutex. */
+
+func dwgps_select(now time.Time) dwgps_info_t {
+	var fallback *dwgps_info_t
+
+	for _, s := range s_dwgps_priority {
+		var info = &s_dwgps_info[s]
+
+		var stale = s != DWGPS_SOURCE_FIXED && s_dwgps_timeout > 0 && now.Sub(info.timestamp) > s_dwgps_timeout
+
+		if info.fix >= DWFIX_2D && !stale {
+			return *info
+		}
+
+		if fallback == nil && info.fix != DWFIX_NOT_INIT {
+			fallback = info
+		}
+	}
+
+	if fallback == nil {
+		var none dwgps_info_t
+		dwgps_clear(&none)
+		none.fix = DWFIX_NOT_INIT
 
-	return (s_dwgps_info.fix)
+		return none
+	}
+
+	var result = *fallback
+	if result.fix >= DWFIX_2D {
+		result.fix = DWFIX_NO_FIX // Stale.
+	}
+
+	return result
 }
 
 /*-------------------------------------------------------------------
@@ -187,14 +324,84 @@ func dwgps_read(gpsinfo *dwgps_info_t) dwfix_t {
  *--------------------------------------------------------------------*/
 
 func dwgps_print(msg string, gpsinfo *dwgps_info_t) {
-	dw_printf("%stime=%s fix=%d lat=%.6f lon=%.6f trk=%.0f spd=%.1f alt=%.0f\n",
-		msg,
+	dw_printf("%ssrc=%s time=%s fix=%d lat=%.6f lon=%.6f trk=%.0f spd=%.1f alt=%.0f qual=%d sat=%d pdop=%.1f hdop=%.1f vdop=%.1f\n",
+		msg, gpsinfo.source,
 		gpsinfo.timestamp.Format(time.RFC3339), gpsinfo.fix,
 		gpsinfo.dlat, gpsinfo.dlon,
 		gpsinfo.track, gpsinfo.speed_knots,
-		gpsinfo.altitude)
+		gpsinfo.altitude,
+		gpsinfo.quality, gpsinfo.num_sat,
+		gpsinfo.pdop, gpsinfo.hdop, gpsinfo.vdop)
 } /* end dwgps_set_data */
 
+/*-------------------------------------------------------------------
+ *
+ * Name:        dwgps_set_position
+ *
+ * Purpose:     Location from an external system, for the control
+ *		socket and HTTP server.
+ *
+ * Inputs:	lat, lon	- Degrees.  Negative for south or west.
+ *
+ *		alt		- Meters above mean sea level, or G_UNKNOWN.
+ *
+ *		speed_knots	- Speed, or G_UNKNOWN.
+ *
+ *		track		- Course in degrees, or G_UNKNOWN.
+ *
+ * Returns:	Error if GPSAPI is not configured or a value is out
+ *		of range.
+ *
+ * Description:	Like a GPS, this becomes stale after GPSTIMEOUT, so
+ *		the external system should keep sending it.
+ *
+ *--------------------------------------------------------------------*/
+
+func dwgps_set_position(lat float64, lon float64, alt float64, speed_knots float64, track float64) error {
+	if !dwgps_api_enabled() {
+		return errors.New("GPSAPI is not in the configuration file")
+	}
+
+	if lat < -90 || lat > 90 {
+		return fmt.Errorf("latitude %g is not in range -90 to 90", lat)
+	}
+
+	if lon < -180 || lon > 180 {
+		return fmt.Errorf("longitude %g is not in range -180 to 180", lon)
+	}
+
+	if speed_knots != G_UNKNOWN && speed_knots < 0 {
+		return fmt.Errorf("speed %g is negative", speed_knots)
+	}
+
+	if track != G_UNKNOWN && (track < 0 || track > 360) {
+		return fmt.Errorf("course %g is not in range 0 to 360", track)
+	}
+
+	var info dwgps_info_t
+	dwgps_clear(&info)
+	info.timestamp = time.Now()
+	info.fix = DWFIX_2D
+	info.dlat = lat
+	info.dlon = lon
+	info.speed_knots = speed_knots
+	info.track = track
+
+	if alt != G_UNKNOWN {
+		info.fix = DWFIX_3D
+		info.altitude = alt
+	}
+
+	if s_dwgps_debug >= 2 {
+		text_color_set(DW_COLOR_DEBUG)
+		dwgps_print("API: ", &info)
+	}
+
+	dwgps_set_data(DWGPS_SOURCE_API, &info)
+
+	return nil
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        dwgps_term
@@ -209,11 +416,7 @@ func dwgps_print(msg string, gpsinfo *dwgps_info_t) {
 
 func dwgps_term() {
 	dwgpsnmea_term()
-
-	/* TODO KG
-	#if ENABLE_GPSD
 	dwgpsd_term()
-	*/
 } /* end dwgps_term */
 
 /*-------------------------------------------------------------------
@@ -222,18 +425,30 @@ func dwgps_term() {
  *
  * Purpose:     Called by the GPS interfaces when new data is available.
  *
- * Inputs:	gpsinfo		- Structure with latitude, longitude, etc.
+ * Inputs:	source		- Which one.
+ *
+ *		gpsinfo		- Structure with latitude, longitude, etc.
  *
  *--------------------------------------------------------------------*/
 
-func dwgps_set_data(gpsinfo *dwgps_info_t) {
-	/* Debug print is handled by the two callers so */
+func dwgps_set_data(source dwgps_source_t, gpsinfo *dwgps_info_t) {
+	/* Debug print is handled by the callers so */
 	/* we can distinguish the source. */
 	s_gps_mutex.Lock()
 
-	*s_dwgps_info = *gpsinfo
+	s_dwgps_info[source] = *gpsinfo
+	s_dwgps_info[source].source = source
 
 	s_gps_mutex.Unlock()
 } /* end dwgps_set_data */
 
 /* end dwgps.c */
+
+/* Was GPSAPI in the configuration file? */
+
+func dwgps_api_enabled() bool {
+	s_gps_mutex.Lock()
+	defer s_gps_mutex.Unlock()
+
+	return s_dwgps_info[DWGPS_SOURCE_API].fix != DWFIX_NOT_INIT
+}
@@ -0,0 +1,124 @@
+package direwolf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func dwgps_test_reset(t *testing.T, priority []dwgps_source_t, timeout time.Duration) {
+	t.Helper()
+
+	s_gps_mutex.Lock()
+	defer s_gps_mutex.Unlock()
+
+	for s := range DWGPS_NUM_SOURCES {
+		dwgps_clear(&s_dwgps_info[s])
+		s_dwgps_info[s].fix = DWFIX_NOT_INIT
+	}
+
+	s_dwgps_priority = priority
+	s_dwgps_timeout = timeout
+}
+
+func dwgps_test_fix(when time.Time, lat float64) *dwgps_info_t {
+	var info = new(dwgps_info_t)
+	dwgps_clear(info)
+	info.timestamp = when
+	info.fix = DWFIX_2D
+	info.dlat = lat
+	info.dlon = -71.0
+
+	return info
+}
+
+func Test_dwgps_read_priority_and_fallback(t *testing.T) {
+	dwgps_test_reset(t, dwgps_default_priority, 30*time.Second)
+
+	var gpsinfo dwgps_info_t
+
+	// Nothing configured.
+	assert.Equal(t, DWFIX_NOT_INIT, dwgps_read(&gpsinfo))
+
+	// Surveyed location only.  Never stale.
+	dwgps_set_data(DWGPS_SOURCE_FIXED, dwgps_test_fix(time.Now().Add(-time.Hour), 42.0))
+	assert.Equal(t, DWFIX_2D, dwgps_read(&gpsinfo))
+	assert.Equal(t, DWGPS_SOURCE_FIXED, gpsinfo.source)
+
+	// GPS has priority.
+	dwgps_set_data(DWGPS_SOURCE_NMEA, dwgps_test_fix(time.Now(), 42.5))
+	assert.Equal(t, DWFIX_2D, dwgps_read(&gpsinfo))
+	assert.Equal(t, DWGPS_SOURCE_NMEA, gpsinfo.source)
+	assert.InDelta(t, 42.5, gpsinfo.dlat, 0.0001)
+
+	// GPS lost its fix.
+	var lost = dwgps_test_fix(time.Now(), 42.5)
+	lost.fix = DWFIX_NO_FIX
+	dwgps_set_data(DWGPS_SOURCE_NMEA, lost)
+	dwgps_read(&gpsinfo)
+	assert.Equal(t, DWGPS_SOURCE_FIXED, gpsinfo.source)
+
+	// GPS stopped sending.
+	dwgps_set_data(DWGPS_SOURCE_NMEA, dwgps_test_fix(time.Now().Add(-time.Minute), 42.5))
+	dwgps_read(&gpsinfo)
+	assert.Equal(t, DWGPS_SOURCE_FIXED, gpsinfo.source)
+	assert.InDelta(t, 42.0, gpsinfo.dlat, 0.0001)
+}
+
+func Test_dwgps_read_stale_without_fallback(t *testing.T) {
+	dwgps_test_reset(t, []dwgps_source_t{DWGPS_SOURCE_GPSD, DWGPS_SOURCE_NMEA}, 30*time.Second)
+
+	var gpsinfo dwgps_info_t
+
+	dwgps_set_data(DWGPS_SOURCE_NMEA, dwgps_test_fix(time.Now().Add(-time.Minute), 42.5))
+	assert.Equal(t, DWFIX_NO_FIX, dwgps_read(&gpsinfo))
+	assert.Equal(t, DWGPS_SOURCE_NMEA, gpsinfo.source)
+
+	// 0 for never stale, as before there was a timeout.
+	dwgps_test_reset(t, dwgps_default_priority, 0)
+	dwgps_set_data(DWGPS_SOURCE_NMEA, dwgps_test_fix(time.Now().Add(-time.Hour), 42.5))
+	assert.Equal(t, DWFIX_2D, dwgps_read(&gpsinfo))
+}
+
+func dwgps_test_enable_api(t *testing.T) {
+	t.Helper()
+
+	s_gps_mutex.Lock()
+	s_dwgps_info[DWGPS_SOURCE_API].fix = DWFIX_NOT_SEEN
+	s_gps_mutex.Unlock()
+}
+
+func Test_dwgps_set_position(t *testing.T) {
+	dwgps_test_reset(t, dwgps_default_priority, 30*time.Second)
+
+	var gpsinfo dwgps_info_t
+
+	require.ErrorContains(t, dwgps_set_position(42.5, -71.25, G_UNKNOWN, G_UNKNOWN, G_UNKNOWN), "GPSAPI")
+	assert.Equal(t, DWFIX_NOT_INIT, dwgps_read(&gpsinfo))
+
+	dwgps_test_enable_api(t)
+	dwgps_set_data(DWGPS_SOURCE_FIXED, dwgps_test_fix(time.Now(), 42.0))
+
+	require.Error(t, dwgps_set_position(91, -71.25, G_UNKNOWN, G_UNKNOWN, G_UNKNOWN))
+	require.Error(t, dwgps_set_position(42.5, -181, G_UNKNOWN, G_UNKNOWN, G_UNKNOWN))
+	require.Error(t, dwgps_set_position(42.5, -71.25, G_UNKNOWN, -1, G_UNKNOWN))
+	require.Error(t, dwgps_set_position(42.5, -71.25, G_UNKNOWN, G_UNKNOWN, 361))
+
+	// Still the fixed location.
+	dwgps_read(&gpsinfo)
+	assert.Equal(t, DWGPS_SOURCE_FIXED, gpsinfo.source)
+
+	require.NoError(t, dwgps_set_position(42.5, -71.25, 33, 12.5, 270))
+	assert.Equal(t, DWFIX_3D, dwgps_read(&gpsinfo))
+	assert.Equal(t, DWGPS_SOURCE_API, gpsinfo.source)
+	assert.InDelta(t, 42.5, gpsinfo.dlat, 0.0001)
+	assert.InDelta(t, -71.25, gpsinfo.dlon, 0.0001)
+	assert.InDelta(t, 33.0, gpsinfo.altitude, 0.0001)
+	assert.InDelta(t, 12.5, gpsinfo.speed_knots, 0.0001)
+	assert.InDelta(t, 270.0, gpsinfo.track, 0.0001)
+
+	require.NoError(t, dwgps_set_position(42.5, -71.25, G_UNKNOWN, G_UNKNOWN, G_UNKNOWN))
+	assert.Equal(t, DWFIX_2D, dwgps_read(&gpsinfo))
+}
@@ -0,0 +1,283 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Interface to location data from gpsd.
+ *
+ * Description:	Rather than linking with libgps, talk to gpsd over TCP.
+ *		After
+ *
+ *			?WATCH={"enable":true,"json":true};
+ *
+ *		it sends a JSON object on each line.  We use
+ *
+ *			{"class":"TPV","mode":3,"lat":42.61875,"lon":-71.347212,
+ *			 "altMSL":33.5,"speed":2.6,"track":291.4,...}
+ *
+ *		for location, and SKY for satellites and dilution of
+ *		precision.
+ *
+ *		If the connection is lost, we try again every
+ *		GPSD_RECONNECT_INTERVAL, so gpsd can be restarted.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"bufio"
+	"encoding/json"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const GPSD_RECONNECT_INTERVAL = 30 * time.Second
+
+var s_gpsd_mu sync.Mutex
+var s_gpsd_conn net.Conn
+var s_gpsd_stop bool
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        dwgpsd_init
+ *
+ * Purpose:    	Start reading from gpsd.
+ *
+ * Inputs:	pconfig		- gpsd_host and gpsd_port.
+ *
+ *		debug		- If >= 2, show updates.  If >= 3, each line.
+ *
+ * Returns:	0 if not configured, 1 if started.
+ *
+ *--------------------------------------------------------------------*/
+
+func dwgpsd_init(pconfig *misc_config_s, debug int) int {
+	if pconfig.gpsd_host == "" {
+		return 0
+	}
+
+	var port = pconfig.gpsd_port
+	if port == 0 {
+		port = DEFAULT_GPSD_PORT
+	}
+
+	var addr = net.JoinHostPort(pconfig.gpsd_host, strconv.Itoa(port))
+
+	s_gpsd_mu.Lock()
+	s_gpsd_stop = false
+	s_gpsd_mu.Unlock()
+
+	go dwgpsd_thread(addr, debug)
+
+	return 1
+}
+
+func dwgpsd_thread(addr string, debug int) {
+	var info = new(dwgps_info_t)
+	dwgps_clear(info)
+
+	for {
+		var conn, err = net.DialTimeout("tcp", addr, 10*time.Second)
+
+		s_gpsd_mu.Lock()
+		if s_gpsd_stop {
+			s_gpsd_mu.Unlock()
+
+			if conn != nil {
+				conn.Close()
+			}
+
+			return
+		}
+
+		s_gpsd_conn = conn
+		s_gpsd_mu.Unlock()
+
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("GPSD: Can't connect to %s: %s\n", addr, err)
+
+			info.fix = DWFIX_ERROR
+			dwgps_set_data(DWGPS_SOURCE_GPSD, info)
+
+			time.Sleep(GPSD_RECONNECT_INTERVAL)
+
+			continue
+		}
+
+		text_color_set(DW_COLOR_INFO)
+		dw_printf("GPSD: Connected to %s.\n", addr)
+
+		info.fix = DWFIX_NOT_SEEN
+		dwgps_set_data(DWGPS_SOURCE_GPSD, info)
+
+		_, err = conn.Write([]byte("?WATCH={\"enable\":true,\"json\":true};\n"))
+
+		var scanner = bufio.NewScanner(conn)
+		for err == nil && scanner.Scan() {
+			if debug >= 3 {
+				text_color_set(DW_COLOR_DEBUG)
+				dw_printf("%s\n", scanner.Text())
+			}
+
+			if dwgpsd_process(info, scanner.Bytes()) {
+				info.timestamp = time.Now()
+
+				if debug >= 2 {
+					text_color_set(DW_COLOR_DEBUG)
+					dwgps_print("GPSD: ", info)
+				}
+
+				dwgps_set_data(DWGPS_SOURCE_GPSD, info)
+			}
+		}
+
+		conn.Close()
+
+		s_gpsd_mu.Lock()
+		var stop = s_gpsd_stop
+		s_gpsd_mu.Unlock()
+
+		if stop {
+			return
+		}
+
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("GPSD: Lost connection to %s.  Trying again in %s.\n", addr, GPSD_RECONNECT_INTERVAL)
+
+		info.fix = DWFIX_ERROR
+		dwgps_set_data(DWGPS_SOURCE_GPSD, info)
+
+		time.Sleep(GPSD_RECONNECT_INTERVAL)
+	}
+}
+
+/* The parts of TPV and SKY reports we use.  Anything might be missing. */
+
+type gpsdReport struct {
+	Class  string   `json:"class"`
+	Mode   int      `json:"mode"`
+	Lat    *float64 `json:"lat"`
+	Lon    *float64 `json:"lon"`
+	AltMSL *float64 `json:"altMSL"`
+	Alt    *float64 `json:"alt"` // Before gpsd 3.20.
+	Speed  *float64 `json:"speed"`
+	Track  *float64 `json:"track"`
+
+	Pdop       *float64 `json:"pdop"`
+	Hdop       *float64 `json:"hdop"`
+	Vdop       *float64 `json:"vdop"`
+	USat       *int     `json:"uSat"`
+	Satellites []struct {
+		Used bool `json:"used"`
+	} `json:"satellites"`
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        dwgpsd_process
+ *
+ * Purpose:    	Update location information from one line sent by gpsd.
+ *
+ * In/Out:	info	- Location information.
+ *
+ * Inputs:	line	- JSON object.
+ *
+ * Returns:	True for a new location, from TPV.
+ *
+ *--------------------------------------------------------------------*/
+
+func dwgpsd_process(info *dwgps_info_t, line []byte) bool {
+	var r gpsdReport
+	if json.Unmarshal(line, &r) != nil {
+		return false
+	}
+
+	switch r.Class {
+	case "TPV":
+		switch r.Mode {
+		case 2:
+			info.fix = DWFIX_2D
+		case 3:
+			info.fix = DWFIX_3D
+		default:
+			info.fix = DWFIX_NO_FIX
+		}
+
+		info.dlat = G_UNKNOWN
+		info.dlon = G_UNKNOWN
+		info.altitude = G_UNKNOWN
+		info.speed_knots = G_UNKNOWN
+		info.track = G_UNKNOWN
+
+		if r.Lat != nil && r.Lon != nil {
+			info.dlat = *r.Lat
+			info.dlon = *r.Lon
+		} else if info.fix >= DWFIX_2D {
+			info.fix = DWFIX_NO_FIX
+		}
+
+		if info.fix == DWFIX_3D {
+			if r.AltMSL != nil {
+				info.altitude = *r.AltMSL
+			} else if r.Alt != nil {
+				info.altitude = *r.Alt
+			}
+		}
+
+		if r.Speed != nil {
+			info.speed_knots = *r.Speed * 3600 / 1852 // meters/sec to knots.
+		}
+
+		if r.Track != nil {
+			info.track = *r.Track
+		}
+
+		return true
+	case "SKY":
+		if r.Pdop != nil {
+			info.pdop = *r.Pdop
+		}
+
+		if r.Hdop != nil {
+			info.hdop = *r.Hdop
+		}
+
+		if r.Vdop != nil {
+			info.vdop = *r.Vdop
+		}
+
+		if r.USat != nil {
+			info.num_sat = *r.USat
+		} else if len(r.Satellites) > 0 {
+			info.num_sat = 0
+			for _, s := range r.Satellites {
+				if s.Used {
+					info.num_sat++
+				}
+			}
+		}
+	}
+
+	return false
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        dwgpsd_term
+ *
+ * Purpose:    	Stop reading from gpsd before exiting.
+ *
+ *--------------------------------------------------------------------*/
+
+func dwgpsd_term() {
+	s_gpsd_mu.Lock()
+	defer s_gpsd_mu.Unlock()
+
+	s_gpsd_stop = true
+
+	if s_gpsd_conn != nil {
+		s_gpsd_conn.Close()
+		s_gpsd_conn = nil
+	}
+}
@@ -0,0 +1,41 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_dwgpsd_process(t *testing.T) {
+	var info = new(dwgps_info_t)
+	dwgps_clear(info)
+
+	assert.False(t, dwgpsd_process(info, []byte(`{"class":"VERSION","release":"3.25"}`)))
+	assert.False(t, dwgpsd_process(info, []byte(`not json`)))
+
+	assert.False(t, dwgpsd_process(info, []byte(`{"class":"SKY","hdop":1.3,"pdop":2.5,"vdop":2.1,"satellites":[{"PRN":4,"used":true},{"PRN":5,"used":false},{"PRN":9,"used":true}]}`)))
+	assert.InDelta(t, 1.3, info.hdop, 0.001)
+	assert.Equal(t, 2, info.num_sat)
+
+	assert.True(t, dwgpsd_process(info, []byte(`{"class":"TPV","device":"/dev/ttyACM0","mode":3,"time":"2024-05-06T12:00:00.000Z","lat":42.618750,"lon":-71.347212,"altMSL":33.5,"alt":30.1,"track":291.4,"speed":2.6}`)))
+	assert.Equal(t, DWFIX_3D, info.fix)
+	assert.InDelta(t, 42.618750, info.dlat, 0.000001)
+	assert.InDelta(t, -71.347212, info.dlon, 0.000001)
+	assert.InDelta(t, 33.5, info.altitude, 0.001)
+	assert.InDelta(t, 5.05, info.speed_knots, 0.01)
+	assert.InDelta(t, 291.4, info.track, 0.001)
+	assert.InDelta(t, 2.5, info.pdop, 0.001) // Kept from SKY.
+
+	// Older gpsd only has alt.
+	assert.True(t, dwgpsd_process(info, []byte(`{"class":"TPV","mode":3,"lat":42.0,"lon":-71.0,"alt":30.1}`)))
+	assert.InDelta(t, 30.1, info.altitude, 0.001)
+	assert.InDelta(t, G_UNKNOWN, info.speed_knots, 0.001)
+
+	assert.True(t, dwgpsd_process(info, []byte(`{"class":"TPV","mode":2,"lat":42.0,"lon":-71.0,"altMSL":33.5}`)))
+	assert.Equal(t, DWFIX_2D, info.fix)
+	assert.InDelta(t, G_UNKNOWN, info.altitude, 0.001)
+
+	assert.True(t, dwgpsd_process(info, []byte(`{"class":"TPV","mode":1}`)))
+	assert.Equal(t, DWFIX_NO_FIX, info.fix)
+	assert.InDelta(t, G_UNKNOWN, info.dlat, 0.001)
+}
@@ -8,7 +8,7 @@ package direwolf
  * Description:	This version is available for all operating systems.
  *
  *
- * TODO:	GPS is no longer the only game in town.
+ * Note:	GPS is no longer the only game in town.
  *		"GNSS" is often seen as a more general term to include
  *		other similar systems.  Some receivers will receive
  *		multiple types at the same time and combine them
@@ -22,11 +22,15 @@ package direwolf
  *			$GBxxx = BeiDou
  *			$GNxxx = Any combination
  *
+ *		Any of these are accepted.
+ *
  *---------------------------------------------------------------*/
 
 import (
+	"bufio"
 	"errors"
 	"fmt"
+	"io"
 	"strconv"
 	"strings"
 	"time"
@@ -82,10 +86,10 @@ var s_gpsnmea_port_fd *term.Term
 func dwgpsnmea_init(pconfig *misc_config_s, debug int) int {
 	//dwgps_info_t info;
 	//int e;
-	s_debug = debug
+	s_debug.Store(int32(debug))
 	s_save_configp = pconfig
 
-	if s_debug >= 2 {
+	if s_debug.Load() >= 2 {
 		text_color_set(DW_COLOR_DEBUG)
 		dw_printf("dwgpsnmea_init()\n")
 	}
@@ -146,7 +150,7 @@ func read_gpsnmea_thread(fd *term.Term) {
 	// Make buffer considerably larger to be safe.
 	const NMEA_MAX_LEN = 160
 
-	if s_debug >= 2 {
+	if s_debug.Load() >= 2 {
 		text_color_set(DW_COLOR_DEBUG)
 		dw_printf("read_gpsnmea_thread (%+v)\n", fd)
 	}
@@ -155,12 +159,12 @@ func read_gpsnmea_thread(fd *term.Term) {
 	dwgps_clear(info)
 	info.fix = DWFIX_NOT_SEEN /* clear not init state. */
 
-	if s_debug >= 2 {
+	if s_debug.Load() >= 2 {
 		text_color_set(DW_COLOR_DEBUG)
 		dwgps_print("GPSNMEA: ", info)
 	}
 
-	dwgps_set_data(info)
+	dwgps_set_data(DWGPS_SOURCE_NMEA, info)
 
 	var gps_msg string
 
@@ -177,12 +181,12 @@ func read_gpsnmea_thread(fd *term.Term) {
 
 			info.fix = DWFIX_ERROR
 
-			if s_debug >= 2 {
+			if s_debug.Load() >= 2 {
 				text_color_set(DW_COLOR_DEBUG)
 				dwgps_print("GPSNMEA: ", info)
 			}
 
-			dwgps_set_data(info)
+			dwgps_set_data(DWGPS_SOURCE_NMEA, info)
 
 			serial_port_close(s_gpsnmea_port_fd)
 			s_gpsnmea_port_fd = nil
@@ -198,82 +202,7 @@ func read_gpsnmea_thread(fd *term.Term) {
 			// Start of new sentence.
 			gps_msg = string(ch)
 		case '\r', '\n':
-			if len(gps_msg) >= 6 && gps_msg[0] == '$' {
-				if s_debug >= 3 {
-					text_color_set(DW_COLOR_DEBUG)
-					dw_printf("%s\n", gps_msg)
-				}
-
-				/* Process sentence. */
-				// TODO: More general: Ignore the second letter rather than recognizing only GP... and GN...
-
-				if strings.HasPrefix(gps_msg, "$GPRMC") || strings.HasPrefix(gps_msg, "$GNRMC") {
-					// Here we just tuck away the course and speed.
-					// Fix and location will be updated by GxGGA.
-					var f = dwgpsnmea_gprmc(gps_msg, false)
-
-					if f.Fix == DWFIX_ERROR {
-						/* Parse error.  Shouldn't happen.  Better luck next time. */
-						text_color_set(DW_COLOR_ERROR)
-						dw_printf("GPSNMEA: Error parsing $GPRMC sentence.\n")
-						dw_printf("%s\n", gps_msg)
-					} else {
-						if f.Knots != G_UNKNOWN {
-							info.speed_knots = f.Knots
-						}
-
-						if f.Course != G_UNKNOWN {
-							info.track = f.Course
-						}
-					}
-				} else if strings.HasPrefix(gps_msg, "$GPGGA") || strings.HasPrefix(gps_msg, "$GNGGA") {
-					var f = dwgpsnmea_gpgga(gps_msg, false)
-
-					if f.Fix == DWFIX_ERROR {
-						/* Parse error.  Shouldn't happen.  Better luck next time. */
-						text_color_set(DW_COLOR_ERROR)
-						dw_printf("GPSNMEA: Error parsing $GPGGA sentence.\n")
-						dw_printf("%s\n", gps_msg)
-					} else {
-						if f.Lat != G_UNKNOWN {
-							info.dlat = f.Lat
-						}
-
-						if f.Lon != G_UNKNOWN {
-							info.dlon = f.Lon
-						}
-
-						if f.Alt != G_UNKNOWN {
-							info.altitude = f.Alt
-						}
-
-						if f.Fix != info.fix { // Print change in location fix.
-							text_color_set(DW_COLOR_INFO)
-
-							switch f.Fix {
-							case DWFIX_NO_FIX:
-								dw_printf("GPSNMEA: Location fix has been lost.\n")
-							case DWFIX_2D:
-								dw_printf("GPSNMEA: Location fix is now 2D.\n")
-							case DWFIX_3D:
-								dw_printf("GPSNMEA: Location fix is now 3D.\n")
-							default:
-							}
-
-							info.fix = f.Fix
-						}
-
-						info.timestamp = time.Now()
-
-						if s_debug >= 2 {
-							text_color_set(DW_COLOR_DEBUG)
-							dwgps_print("GPSNMEA: ", info)
-						}
-
-						dwgps_set_data(info)
-					}
-				}
-			}
+			dwgpsnmea_sentence(info, gps_msg)
 
 			gps_msg = ""
 		default:
@@ -284,6 +213,276 @@ func read_gpsnmea_thread(fd *term.Term) {
 	} /* while (1) */
 } /* end read_gpsnmea_thread */
 
+/*-------------------------------------------------------------------
+ *
+ * Name:        dwgpsnmea_sentence
+ *
+ * Purpose:     Take one sentence from the receiver, or a log file,
+ *		and make any new location available to others.
+ *
+ * Inputs:	info	- Current information, updated in place.
+ *
+ *		msg	- Sentence, starting with $.  Anything else
+ *			  is ignored.
+ *
+ *--------------------------------------------------------------------*/
+
+func dwgpsnmea_sentence(info *dwgps_info_t, msg string) {
+	if len(msg) < 6 || msg[0] != '$' {
+		return
+	}
+
+	if s_debug.Load() >= 3 {
+		text_color_set(DW_COLOR_DEBUG)
+		dw_printf("%s\n", msg)
+	}
+
+	if dwgpsnmea_process(info, msg) {
+		info.timestamp = time.Now()
+
+		if s_debug.Load() >= 2 {
+			text_color_set(DW_COLOR_DEBUG)
+			dwgps_print("GPSNMEA: ", info)
+		}
+
+		dwgps_set_data(DWGPS_SOURCE_NMEA, info)
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        dwgpsnmea_replay
+ *
+ * Purpose:     Simulate a GPS receiver by playing back a log of
+ *		its NMEA sentences, for testing trackers indoors.
+ *
+ * Inputs:	r	- Log, one sentence per line.  Anything before
+ *			  the $, such as a time stamp, is ignored.
+ *
+ *		speed	- 1 for real time, 10 for ten times as fast, etc.
+ *			  0 for as fast as possible.
+ *
+ * Returns:	Channel for the read error, or nil, at the end of the log.
+ *
+ * Description:	The time in GGA and RMC sentences sets the pace.
+ *		Sentences go through dwgpsnmea_sentence just like
+ *		those from a serial port, so the application sees
+ *		no difference.
+ *
+ *--------------------------------------------------------------------*/
+
+func dwgpsnmea_replay(r io.Reader, speed float64) <-chan error {
+	var info = new(dwgps_info_t)
+	dwgps_clear(info)
+	info.fix = DWFIX_NOT_SEEN /* clear not init state. */
+
+	dwgps_set_data(DWGPS_SOURCE_NMEA, info)
+
+	var done = make(chan error, 1)
+
+	go func() {
+		var prev time.Duration = -1
+
+		var scanner = bufio.NewScanner(r)
+		for scanner.Scan() {
+			var line = scanner.Text()
+
+			var start = strings.IndexByte(line, '$')
+			if start < 0 {
+				continue
+			}
+
+			var msg = strings.TrimSpace(line[start:])
+
+			if t, ok := nmea_time_of_day(msg); ok {
+				if prev >= 0 && speed > 0 {
+					time.Sleep(time.Duration(float64(nmea_time_difference(prev, t)) / speed))
+				}
+
+				prev = t
+			}
+
+			dwgpsnmea_sentence(info, msg)
+		}
+
+		done <- scanner.Err()
+		close(done)
+	}()
+
+	return done
+}
+
+/*
+ * Time of day, from the UTC field of a GGA or RMC sentence, e.g. "003518.710".
+ */
+
+func nmea_time_of_day(msg string) (time.Duration, bool) {
+	if len(msg) < 6 || (msg[3:6] != "GGA" && msg[3:6] != "RMC") {
+		return 0, false
+	}
+
+	var fields = strings.SplitN(msg, ",", 3)
+	if len(fields) < 3 || len(fields[1]) < 6 {
+		return 0, false
+	}
+
+	var hh, hhErr = strconv.Atoi(fields[1][0:2])
+	var mm, mmErr = strconv.Atoi(fields[1][2:4])
+	var ss, ssErr = strconv.ParseFloat(fields[1][4:], 64)
+
+	if hhErr != nil || mmErr != nil || ssErr != nil {
+		return 0, false
+	}
+
+	return time.Duration(hh)*time.Hour + time.Duration(mm)*time.Minute + time.Duration(ss*float64(time.Second)), true
+}
+
+/*
+ * How long to wait from one sentence time to the next.  Going back in
+ * time is midnight if close to it, otherwise a glitch in the log so no wait.
+ */
+
+func nmea_time_difference(prev, t time.Duration) time.Duration {
+	if t >= prev {
+		return t - prev
+	}
+
+	if prev-t > 23*time.Hour {
+		return t + 24*time.Hour - prev
+	}
+
+	return 0
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        dwgpsnmea_process
+ *
+ * Purpose:     Update GPS information from one NMEA sentence.
+ *
+ * Inputs:	info	- Current information, updated in place.
+ *
+ *		msg	- Sentence, starting with $.
+ *
+ * Returns:	True if info should be made available to others.
+ *
+ * Description:	Any talker ID is accepted, e.g. $GP for GPS, $GN for
+ *		a combination of systems.
+ *
+ *		GGA has fix and location, RMC or VTG course and speed,
+ *		and GSA the dilution of precision.  Location is only
+ *		taken from GGA so fix and altitude go along with it.
+ *
+ *--------------------------------------------------------------------*/
+
+func dwgpsnmea_process(info *dwgps_info_t, msg string) bool {
+	if len(msg) < 6 || msg[0] != '$' {
+		return false
+	}
+
+	var sentence_type = msg[3:6]
+
+	switch sentence_type {
+	case "RMC":
+		// Here we just tuck away the course and speed.
+		// Fix and location will be updated by GxGGA.
+		var f = dwgpsnmea_gprmc(msg, false)
+
+		if f.Fix == DWFIX_ERROR {
+			/* Parse error.  Shouldn't happen.  Better luck next time. */
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("GPSNMEA: Error parsing $GPRMC sentence.\n")
+			dw_printf("%s\n", msg)
+		} else {
+			if f.Knots != G_UNKNOWN {
+				info.speed_knots = f.Knots
+			}
+
+			if f.Course != G_UNKNOWN {
+				info.track = f.Course
+			}
+		}
+	case "VTG":
+		var f = dwgpsnmea_gpvtg(msg, false)
+
+		if f.Fix == DWFIX_ERROR {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("GPSNMEA: Error parsing $GPVTG sentence.\n")
+			dw_printf("%s\n", msg)
+		} else {
+			if f.Knots != G_UNKNOWN {
+				info.speed_knots = f.Knots
+			}
+
+			if f.Course != G_UNKNOWN {
+				info.track = f.Course
+			}
+		}
+	case "GSA":
+		var f = dwgpsnmea_gpgsa(msg, false)
+
+		if f.Fix == DWFIX_ERROR {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("GPSNMEA: Error parsing $GPGSA sentence.\n")
+			dw_printf("%s\n", msg)
+		} else {
+			info.pdop = f.PDOP
+			info.hdop = f.HDOP
+			info.vdop = f.VDOP
+		}
+	case "GGA":
+		var f = dwgpsnmea_gpgga(msg, false)
+
+		if f.Fix == DWFIX_ERROR {
+			/* Parse error.  Shouldn't happen.  Better luck next time. */
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("GPSNMEA: Error parsing $GPGGA sentence.\n")
+			dw_printf("%s\n", msg)
+
+			return false
+		}
+
+		if f.Lat != G_UNKNOWN {
+			info.dlat = f.Lat
+		}
+
+		if f.Lon != G_UNKNOWN {
+			info.dlon = f.Lon
+		}
+
+		if f.Alt != G_UNKNOWN {
+			info.altitude = f.Alt
+		}
+
+		info.quality = f.Quality
+		info.num_sat = f.Sat
+
+		if f.HDOP != G_UNKNOWN {
+			info.hdop = f.HDOP
+		}
+
+		if f.Fix != info.fix { // Print change in location fix.
+			text_color_set(DW_COLOR_INFO)
+
+			switch f.Fix {
+			case DWFIX_NO_FIX:
+				dw_printf("GPSNMEA: Location fix has been lost.\n")
+			case DWFIX_2D:
+				dw_printf("GPSNMEA: Location fix is now 2D.\n")
+			case DWFIX_3D:
+				dw_printf("GPSNMEA: Location fix is now 3D.\n")
+			default:
+			}
+
+			info.fix = f.Fix
+		}
+
+		return true
+	}
+
+	return false
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:	remove_checksum
@@ -495,6 +694,8 @@ func dwgpsnmea_gprmc(sentence string, quiet bool) *GPRMCResult {
  *		odlon		longitude
  *		oalt		altitude in meters
  *		onsat		number of satellites.
+ *		oquality	fix quality: 1 = GPS, 2 = DGPS, 4 = RTK, ...
+ *		ohdop		horizontal dilution of precision.
  *
  *					Left undefined if not valid.
  *
@@ -514,20 +715,24 @@ func dwgpsnmea_gprmc(sentence string, quiet bool) *GPRMCResult {
  *--------------------------------------------------------------------*/
 
 type GPGGAResult struct {
-	Lat float64
-	Lon float64
-	Alt float64
-	Sat int
-	Fix dwfix_t
+	Lat     float64
+	Lon     float64
+	Alt     float64
+	Sat     int
+	Quality int
+	HDOP    float64
+	Fix     dwfix_t
 }
 
 func dwgpsnmea_gpgga(sentence string, quiet bool) *GPGGAResult {
 	var result = &GPGGAResult{
-		Lat: G_UNKNOWN,
-		Lon: G_UNKNOWN,
-		Alt: G_UNKNOWN,
-		Sat: G_UNKNOWN,
-		Fix: DWFIX_NO_FIX,
+		Lat:     G_UNKNOWN,
+		Lon:     G_UNKNOWN,
+		Alt:     G_UNKNOWN,
+		Sat:     G_UNKNOWN,
+		Quality: G_UNKNOWN,
+		HDOP:    G_UNKNOWN,
+		Fix:     DWFIX_NO_FIX,
 	}
 
 	sentence, err := remove_checksum(sentence, quiet)
@@ -557,8 +762,6 @@ func dwgpsnmea_gpgga(sentence string, quiet bool) *GPGGAResult {
 
 	_ = ptype
 	_ = ptime
-	_ = pnum_sat
-	_ = phdop
 	_ = palt_u
 	_ = pheight
 	_ = pheight_u
@@ -608,7 +811,19 @@ func dwgpsnmea_gpgga(sentence string, quiet bool) *GPGGAResult {
 		return result
 	}
 
-	// TODO: num sat...  Why would we care?
+	/* These are only for information so don't fail without them. */
+
+	if quality, err := strconv.Atoi(pfix); err == nil {
+		result.Quality = quality
+	}
+
+	if num_sat, err := strconv.Atoi(pnum_sat); err == nil {
+		result.Sat = num_sat
+	}
+
+	if hdop, err := strconv.ParseFloat(phdop, 64); err == nil {
+		result.HDOP = hdop
+	}
 
 	/*
 	 * We can distinguish between 2D & 3D fix by presence
@@ -648,6 +863,190 @@ func dwgpsnmea_gpgga(sentence string, quiet bool) *GPGGAResult {
 	}
 } /* end dwgpsnmea_gpgga */
 
+/*-------------------------------------------------------------------
+ *
+ * Name:        dwgpsnmea_gpvtg
+ *
+ * Purpose:    	Parse $GPVTG sentence and extract interesting parts.
+ *
+ * Inputs:	sentence	NMEA sentence.
+ *
+ *		quiet		suppress printing of error messages.
+ *
+ * Outputs:	oknots		speed
+ *		ocourse		direction of travel, true.
+ *
+ *					Left undefined if not valid.
+ *
+ * Note:	Some receivers send this rather than RMC for course and speed.
+ *
+ * Returns:	DWFIX_ERROR	Parse error.
+ *		DWFIX_NO_FIX	Mode is N, not valid.
+ *		DWFIX_2D	Valid course and speed.
+ *
+ * Examples:	$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48
+ *		$GPVTG,,T,,M,0.021,N,0.039,K,A*2A
+ *		$GNVTG,,,,,,,,,N*2E
+ *
+ *--------------------------------------------------------------------*/
+
+type GPVTGResult struct {
+	Knots  float64
+	Course float64
+	Fix    dwfix_t
+}
+
+func dwgpsnmea_gpvtg(sentence string, quiet bool) *GPVTGResult {
+	var result = &GPVTGResult{
+		Knots:  G_UNKNOWN,
+		Course: G_UNKNOWN,
+		Fix:    DWFIX_NO_FIX,
+	}
+
+	sentence, err := remove_checksum(sentence, quiet)
+	if err != nil {
+		result.Fix = DWFIX_ERROR
+		return result
+	}
+
+	var f = strings.Split(sentence, ",")
+
+	/*
+	 * 0	$GPVTG
+	 * 1,2	Course, T for true.
+	 * 3,4	Course, M for magnetic.
+	 * 5,6	Speed, N for knots.
+	 * 7,8	Speed, K for km/h.
+	 * 9	In version 2.3, mode: A=autonomous, D=differential, E=estimated, N=not valid.
+	 */
+
+	if len(f) < 9 {
+		if !quiet {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Too few fields in GPVTG sentence.\n")
+		}
+
+		result.Fix = DWFIX_ERROR
+
+		return result
+	}
+
+	if len(f) >= 10 && f[9] == "N" {
+		return result /* Not valid.  Don't parse. */
+	}
+
+	var knots, knotsErr = strconv.ParseFloat(f[5], 64)
+	if knotsErr != nil {
+		/* Empty before there is a fix. */
+		return result
+	}
+
+	result.Knots = knots
+
+	if course, courseErr := strconv.ParseFloat(f[1], 64); courseErr == nil {
+		result.Course = course
+	} /* When stationary, this field might be empty. */
+
+	result.Fix = DWFIX_2D
+
+	return result
+} /* end dwgpsnmea_gpvtg */
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        dwgpsnmea_gpgsa
+ *
+ * Purpose:    	Parse $GPGSA sentence and extract interesting parts.
+ *
+ * Inputs:	sentence	NMEA sentence.
+ *
+ *		quiet		suppress printing of error messages.
+ *
+ * Outputs:	opdop		position dilution of precision.
+ *		ohdop		horizontal dilution of precision.
+ *		ovdop		vertical dilution of precision.
+ *
+ *					Left undefined if not valid.
+ *
+ * Returns:	DWFIX_ERROR	Parse error.
+ *		DWFIX_NO_FIX	No fix.
+ *		DWFIX_2D	2D fix.
+ *		DWFIX_3D	3D fix.
+ *
+ * Examples:	$GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.3,2.1*39
+ *		$GPGSA,A,1,,,,,,,,,,,,,99.99,99.99,99.99*30
+ *
+ *--------------------------------------------------------------------*/
+
+type GPGSAResult struct {
+	PDOP float64
+	HDOP float64
+	VDOP float64
+	Fix  dwfix_t
+}
+
+func dwgpsnmea_gpgsa(sentence string, quiet bool) *GPGSAResult {
+	var result = &GPGSAResult{
+		PDOP: G_UNKNOWN,
+		HDOP: G_UNKNOWN,
+		VDOP: G_UNKNOWN,
+		Fix:  DWFIX_NO_FIX,
+	}
+
+	sentence, err := remove_checksum(sentence, quiet)
+	if err != nil {
+		result.Fix = DWFIX_ERROR
+		return result
+	}
+
+	var f = strings.Split(sentence, ",")
+
+	/*
+	 * 0	$GPGSA
+	 * 1	Selection mode, A=auto or M=manual.
+	 * 2	Fix, 1=none, 2=2D, 3=3D.
+	 * 3-14	Satellites used.
+	 * 15	PDOP.
+	 * 16	HDOP.
+	 * 17	VDOP.
+	 * 18	In version 4.10, system ID.
+	 */
+
+	if len(f) < 18 {
+		if !quiet {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Too few fields in GPGSA sentence.\n")
+		}
+
+		result.Fix = DWFIX_ERROR
+
+		return result
+	}
+
+	switch f[2] {
+	case "2":
+		result.Fix = DWFIX_2D
+	case "3":
+		result.Fix = DWFIX_3D
+	default:
+		return result /* No fix.  DOP would be meaningless. */
+	}
+
+	if pdop, pdopErr := strconv.ParseFloat(f[15], 64); pdopErr == nil {
+		result.PDOP = pdop
+	}
+
+	if hdop, hdopErr := strconv.ParseFloat(f[16], 64); hdopErr == nil {
+		result.HDOP = hdop
+	}
+
+	if vdop, vdopErr := strconv.ParseFloat(f[17], 64); vdopErr == nil {
+		result.VDOP = vdop
+	}
+
+	return result
+} /* end dwgpsnmea_gpgsa */
+
 /*-------------------------------------------------------------------
  *
  * Name:        dwgpsnmea_term
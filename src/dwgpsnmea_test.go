@@ -1,7 +1,9 @@
 package direwolf
 
 import (
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -106,6 +108,9 @@ func Test_dwgpsnmea_gpgga(t *testing.T) {
 		assert.InDelta(t, 42.618750, result.Lat, 0.0001)
 		assert.InDelta(t, -71.347212, result.Lon, 0.001)
 		assert.InDelta(t, 33.5, result.Alt, 0.001)
+		assert.Equal(t, 1, result.Quality)
+		assert.Equal(t, 3, result.Sat)
+		assert.InDelta(t, 5.9, result.HDOP, 0.001)
 	})
 
 	t.Run("fix field zero returns no fix", func(t *testing.T) {
@@ -123,3 +128,132 @@ func Test_dwgpsnmea_gpgga(t *testing.T) {
 		assert.Equal(t, DWFIX_ERROR, result.Fix)
 	})
 }
+
+// --- dwgpsnmea_gpvtg ---
+
+func Test_dwgpsnmea_gpvtg(t *testing.T) {
+	t.Run("course and speed", func(t *testing.T) {
+		var result = dwgpsnmea_gpvtg("$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48", true)
+
+		require.NotNil(t, result)
+		assert.Equal(t, DWFIX_2D, result.Fix)
+		assert.InDelta(t, 5.5, result.Knots, 0.001)
+		assert.InDelta(t, 54.7, result.Course, 0.001)
+	})
+
+	t.Run("stationary with no course", func(t *testing.T) {
+		var result = dwgpsnmea_gpvtg("$GPVTG,,T,,M,0.021,N,0.039,K,A*2A", true)
+
+		require.NotNil(t, result)
+		assert.Equal(t, DWFIX_2D, result.Fix)
+		assert.InDelta(t, 0.021, result.Knots, 0.0001)
+		assert.InDelta(t, G_UNKNOWN, result.Course, 0.001)
+	})
+
+	t.Run("mode not valid", func(t *testing.T) {
+		var result = dwgpsnmea_gpvtg("$GNVTG,,,,,,,,,N*2E", true)
+
+		require.NotNil(t, result)
+		assert.Equal(t, DWFIX_NO_FIX, result.Fix)
+		assert.InDelta(t, G_UNKNOWN, result.Knots, 0.001)
+	})
+
+	t.Run("bad checksum returns error", func(t *testing.T) {
+		var result = dwgpsnmea_gpvtg("$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*00", true)
+
+		require.NotNil(t, result)
+		assert.Equal(t, DWFIX_ERROR, result.Fix)
+	})
+}
+
+// --- dwgpsnmea_gpgsa ---
+
+func Test_dwgpsnmea_gpgsa(t *testing.T) {
+	t.Run("3D fix with dilution of precision", func(t *testing.T) {
+		var result = dwgpsnmea_gpgsa("$GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.3,2.1*39", true)
+
+		require.NotNil(t, result)
+		assert.Equal(t, DWFIX_3D, result.Fix)
+		assert.InDelta(t, 2.5, result.PDOP, 0.001)
+		assert.InDelta(t, 1.3, result.HDOP, 0.001)
+		assert.InDelta(t, 2.1, result.VDOP, 0.001)
+	})
+
+	t.Run("no fix", func(t *testing.T) {
+		var result = dwgpsnmea_gpgsa("$GPGSA,A,1,,,,,,,,,,,,,99.99,99.99,99.99*30", true)
+
+		require.NotNil(t, result)
+		assert.Equal(t, DWFIX_NO_FIX, result.Fix)
+		assert.InDelta(t, G_UNKNOWN, result.PDOP, 0.001)
+	})
+}
+
+// --- dwgpsnmea_process ---
+
+func Test_dwgpsnmea_process(t *testing.T) {
+	var info = new(dwgps_info_t)
+	dwgps_clear(info)
+
+	// Any talker ID, e.g. GN for a multi-constellation receiver.
+
+	assert.False(t, dwgpsnmea_process(info, "$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48"))
+	assert.False(t, dwgpsnmea_process(info, "$GPGSA,A,3,04,05,,09,12,,,24,,,,,2.5,1.3,2.1*39"))
+	assert.True(t, dwgpsnmea_process(info, "$GNGGA,003518.710,4237.1250,N,07120.8327,W,1,03,5.9,33.5,M,-33.5,M,,0000*45"))
+
+	assert.Equal(t, DWFIX_3D, info.fix)
+	assert.InDelta(t, 42.618750, info.dlat, 0.0001)
+	assert.InDelta(t, 33.5, info.altitude, 0.001)
+	assert.InDelta(t, 5.5, info.speed_knots, 0.001)
+	assert.InDelta(t, 54.7, info.track, 0.001)
+	assert.Equal(t, 1, info.quality)
+	assert.Equal(t, 3, info.num_sat)
+	assert.InDelta(t, 2.5, info.pdop, 0.001)
+	assert.InDelta(t, 5.9, info.hdop, 0.001) // Latest, from GGA.
+	assert.InDelta(t, 2.1, info.vdop, 0.001)
+}
+
+// --- dwgpsnmea_replay ---
+
+func Test_nmea_time_of_day(t *testing.T) {
+	var tod, ok = nmea_time_of_day("$GPGGA,003518.710,4237.1250,N,07120.8327,W,1,03,5.9,33.5,M,-33.5,M,,0000*5B")
+	require.True(t, ok)
+	assert.Equal(t, 35*time.Minute+18710*time.Millisecond, tod)
+
+	tod, ok = nmea_time_of_day("$GNRMC,235959,A,4237.1250,N,07120.8327,W,5.5,54.7,010124,,*00")
+	require.True(t, ok)
+	assert.Equal(t, 23*time.Hour+59*time.Minute+59*time.Second, tod)
+
+	_, ok = nmea_time_of_day("$GPVTG,054.7,T,034.4,M,005.5,N,010.2,K*48")
+	assert.False(t, ok)
+
+	_, ok = nmea_time_of_day("$GPGGA,,,,,,0,00,99.99,,,,,,*48")
+	assert.False(t, ok)
+}
+
+func Test_nmea_time_difference(t *testing.T) {
+	assert.Equal(t, time.Second, nmea_time_difference(10*time.Second, 11*time.Second))
+	assert.Equal(t, 2*time.Second, nmea_time_difference(24*time.Hour-time.Second, time.Second), "midnight")
+	assert.Equal(t, time.Duration(0), nmea_time_difference(11*time.Second, 10*time.Second), "glitch")
+}
+
+func Test_dwgpsnmea_replay(t *testing.T) {
+	dwgps_test_reset(t, dwgps_default_priority, 0)
+
+	var log = strings.Join([]string{
+		"2024-05-06 00:35:18 $GPGGA,003518.00,4237.1250,N,07120.8327,W,1,03,5.9,33.5,M,-33.5,M,,0000*6D",
+		"not a sentence",
+		"$GPGGA,003519.00,4237.2250,N,07120.8327,W,1,03,5.9,33.5,M,-33.5,M,,0000*6F",
+		"$GPGGA,003520.00,4237.3250,N,07120.8327,W,1,03,5.9,33.5,M,-33.5,M,,0000*64",
+	}, "\r\n")
+
+	var start = time.Now()
+
+	// 2 seconds of sentences at 20 times real time.
+	require.NoError(t, <-dwgpsnmea_replay(strings.NewReader(log), 20))
+	assert.GreaterOrEqual(t, time.Since(start), 90*time.Millisecond)
+
+	var gpsinfo dwgps_info_t
+	assert.Equal(t, DWFIX_3D, dwgps_read(&gpsinfo))
+	assert.Equal(t, DWGPS_SOURCE_NMEA, gpsinfo.source)
+	assert.InDelta(t, 42.622083, gpsinfo.dlat, 0.0001) // The last one.
+}
@@ -0,0 +1,458 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Structured logging, with log/slog, of everything printed.
+ *
+ * Description:	Everything printed with dw_printf still goes to the
+ *		console as before.  With "direwolf --log-json file" each
+ *		line is also written as a JSON record, e.g.
+ *
+ *			{"time":"...","level":"INFO","msg":"...","subsystem":"igate"}
+ *
+ *		Use "--log-json -" for stderr, which is what journald
+ *		collects from a systemd service.
+ *
+ *		The level comes from the text color set before printing:
+ *		DW_COLOR_ERROR is ERROR and DW_COLOR_DEBUG is DEBUG.
+ *		The others are INFO, with "kind" of rec, decoded, or
+ *		xmit for received and transmitted packets.
+ *
+ *		The subsystem comes from the source file which printed
+ *		it.  "--log-level" gives the lowest level written, for
+ *		all or for each subsystem:
+ *
+ *			--log-level info,kiss=debug,demod=warn
+ *
+ *		The -d options set DEBUG for their subsystem and "-q d"
+ *		sets WARN for aprs, unless --log-level says otherwise.
+ *
+ *		With --headless, for containers, the JSON records go to
+ *		stdout, or the --log-json file, and the plain text isn't
+ *		printed at all.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+/* Source file name prefix and subsystem.  First match is used. */
+
+type logSubsystem struct {
+	prefix    string
+	subsystem string
+}
+
+var logSubsystems = []logSubsystem{
+	{"agwpe", "agw"},
+	{"server", "agw"},
+	{"aprs_tt", "aprstt"},
+	{"tt_", "aprstt"},
+	{"dtmf", "aprstt"},
+	{"audio", "audio"},
+	{"ax25_", "ax25"},
+	{"xid", "ax25"},
+	{"beacon", "beacon"},
+	{"config", "config"},
+	{"decode_aprs", "aprs"},
+	{"encode_aprs", "aprs"},
+	{"deviceid", "aprs"},
+	{"symbols", "aprs"},
+	{"demod", "demod"},
+	{"dsp", "demod"},
+	{"hdlc_rec", "demod"},
+	{"multi_modem", "demod"},
+	{"pll_dcd", "demod"},
+	{"recv", "demod"},
+	{"cdigipeater", "digipeater"},
+	{"digipeater", "digipeater"},
+	{"pfilter", "filter"},
+	{"fx25", "fec"},
+	{"il2p", "fec"},
+	{"dwgps", "gps"},
+	{"waypoint", "gps"},
+	{"igate", "igate"},
+	{"kiss", "kiss"},
+	{"mheard", "mheard"},
+	{"ptt", "ptt"},
+	{"cm108", "ptt"},
+	{"gpiod", "ptt"},
+	{"xmit", "xmit"},
+	{"tq", "xmit"},
+	{"hdlc_send", "xmit"},
+	{"gen_tone", "xmit"},
+	{"morse", "xmit"},
+}
+
+const LOG_DEFAULT_SUBSYSTEM = "direwolf"
+
+/* Subsystem for each -d option letter. */
+
+var logDebugSubsystems = map[rune][]string{
+	'2': {"fec"},
+	'a': {"agw"},
+	'c': {"ax25"},
+	'd': {"aprstt"},
+	'f': {"filter"},
+	'g': {"gps"},
+	'h': {"ptt"},
+	'i': {"igate"},
+	'k': {"kiss"},
+	'm': {"mheard"},
+	'n': {"kiss"},
+	'o': {"ptt"},
+	't': {"beacon"},
+	'w': {"gps"},
+	'x': {"fec"},
+}
+
+func log_subsystem(file string) string {
+	var base = filepath.Base(file)
+
+	for _, s := range logSubsystems {
+		if strings.HasPrefix(base, s.prefix) {
+			return s.subsystem
+		}
+	}
+
+	return LOG_DEFAULT_SUBSYSTEM
+}
+
+/* Lowest level written, for all and for each subsystem. */
+
+type logLevels struct {
+	all       slog.Level
+	subsystem map[string]slog.Level
+}
+
+func (l *logLevels) level(subsystem string) slog.Level {
+	if level, ok := l.subsystem[subsystem]; ok {
+		return level
+	}
+
+	return l.all
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	log_parse_levels
+ *
+ * Purpose:	Combine the --log-level, -d, and -q options.
+ *
+ * Inputs:	spec	- Values of --log-level, each "level" or
+ *			  "subsystem=level".
+ *
+ *		debug	- Value of -d option.
+ *
+ *		quiet	- Value of -q option.
+ *
+ * Returns:	Levels, or error for an unknown level or subsystem.
+ *
+ *--------------------------------------------------------------------*/
+
+func log_parse_levels(spec []string, debug string, quiet string) (*logLevels, error) {
+	var l = new(logLevels)
+	l.all = slog.LevelDebug
+	l.subsystem = make(map[string]slog.Level)
+
+	var explicit = make(map[string]slog.Level)
+
+	for _, s := range spec {
+		var name, levelText, found = strings.Cut(s, "=")
+		if !found {
+			levelText = name
+			name = ""
+		}
+
+		var level slog.Level
+
+		var err = level.UnmarshalText([]byte(levelText))
+		if err != nil {
+			return nil, fmt.Errorf("invalid log level \"%s\", should be debug, info, warn, or error", levelText)
+		}
+
+		if name == "" {
+			l.all = level
+
+			continue
+		}
+
+		var known = slices.ContainsFunc(logSubsystems, func(ls logSubsystem) bool { return ls.subsystem == name })
+
+		if name != LOG_DEFAULT_SUBSYSTEM && !known {
+			return nil, fmt.Errorf("unknown subsystem \"%s\" for log level", name)
+		}
+
+		explicit[name] = level
+	}
+
+	for _, d := range debug {
+		for _, s := range logDebugSubsystems[d] {
+			l.subsystem[s] = slog.LevelDebug
+		}
+	}
+
+	if strings.ContainsRune(quiet, 'd') {
+		l.subsystem["aprs"] = slog.LevelWarn
+	}
+
+	for name, level := range explicit {
+		l.subsystem[name] = level
+	}
+
+	return l, nil
+}
+
+/*
+ * Goroutines print at the same time, so the color and the pieces of
+ * an unfinished line are kept for each goroutine.  Otherwise a line
+ * from one could get the level of, or be joined to, another's.
+ */
+
+type logGoroutine struct {
+	color     dw_color_e
+	line      strings.Builder
+	level     slog.Level
+	kind      string
+	subsystem string
+}
+
+/* Client connections come and go with their goroutines, so forget them all after this many. */
+
+const LOG_MAX_GOROUTINES = 1000
+
+type dwLogger struct {
+	mu         sync.Mutex
+	logger     *slog.Logger
+	levels     *logLevels
+	f          *os.File // nil if not writing to a file.
+	goroutines map[uint64]*logGoroutine
+}
+
+var structuredLog atomic.Pointer[dwLogger]
+
+var log_json_only bool /* --headless.  dw_printf writes only the JSON records. */
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	log_json_open
+ *
+ * Purpose:	Start writing JSON log records.
+ *
+ * Inputs:	path	- File name, appended to, or "-" for stderr.
+ *
+ *		levels	- From log_parse_levels.
+ *
+ *--------------------------------------------------------------------*/
+
+func log_json_open(path string, levels *logLevels) error {
+	var w io.Writer = os.Stderr
+
+	var f *os.File
+
+	if path != "-" {
+		var err error
+
+		f, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gosec // Named on the command line.
+		if err != nil {
+			return err
+		}
+
+		w = f
+	}
+
+	var l = new_dw_logger(w, levels)
+	l.f = f
+
+	structuredLog.Store(l)
+
+	return nil
+}
+
+/* For --headless without --log-json. */
+
+func log_json_stdout(levels *logLevels) {
+	structuredLog.Store(new_dw_logger(os.Stdout, levels))
+}
+
+func new_dw_logger(w io.Writer, levels *logLevels) *dwLogger {
+	var l = new(dwLogger)
+	l.logger = slog.New(slog.NewJSONHandler(w, &slog.HandlerOptions{Level: slog.LevelDebug})) //nolint:exhaustruct
+	l.levels = levels
+	l.goroutines = make(map[uint64]*logGoroutine)
+
+	return l
+}
+
+func log_json_close() {
+	var l = structuredLog.Swap(nil)
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var ids = slices.Sorted(maps.Keys(l.goroutines))
+
+	for _, id := range ids {
+		l.flush(l.goroutines[id])
+	}
+
+	if l.f != nil {
+		l.f.Close()
+	}
+}
+
+func (l *dwLogger) goroutine(id uint64) *logGoroutine {
+	var g = l.goroutines[id]
+	if g != nil {
+		return g
+	}
+
+	if len(l.goroutines) >= LOG_MAX_GOROUTINES {
+		for id, g := range l.goroutines {
+			if g.line.Len() == 0 {
+				delete(l.goroutines, id)
+			}
+		}
+	}
+
+	g = new(logGoroutine)
+	l.goroutines[id] = g
+
+	return g
+}
+
+/* Called by text_color_set. */
+
+func (l *dwLogger) color_set(id uint64, color dw_color_e) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.goroutine(id).color = color
+}
+
+/*
+ * Called by dw_printf with what it printed.  The level, kind, and
+ * subsystem are taken from the first piece of each line.
+ */
+
+func (l *dwLogger) printed(id uint64, s string, file string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	var g = l.goroutine(id)
+
+	for s != "" {
+		if g.line.Len() == 0 {
+			g.level, g.kind = log_color_level(g.color)
+			g.subsystem = log_subsystem(file)
+		}
+
+		var before, after, found = strings.Cut(s, "\n")
+
+		g.line.WriteString(before)
+
+		if !found {
+			return
+		}
+
+		l.flush(g)
+
+		s = after
+	}
+}
+
+func (l *dwLogger) flush(g *logGoroutine) {
+	var msg = strings.TrimSpace(g.line.String())
+
+	g.line.Reset()
+
+	if msg == "" || g.level < l.levels.level(g.subsystem) {
+		return
+	}
+
+	var attrs = []slog.Attr{slog.String("subsystem", g.subsystem)}
+
+	if g.kind != "" {
+		attrs = append(attrs, slog.String("kind", g.kind))
+	}
+
+	l.logger.LogAttrs(context.Background(), g.level, msg, attrs...)
+}
+
+func log_color_level(color dw_color_e) (slog.Level, string) {
+	switch color {
+	case DW_COLOR_ERROR:
+		return slog.LevelError, ""
+	case DW_COLOR_DEBUG:
+		return slog.LevelDebug, ""
+	case DW_COLOR_REC:
+		return slog.LevelInfo, "rec"
+	case DW_COLOR_DECODED:
+		return slog.LevelInfo, "decoded"
+	case DW_COLOR_XMIT:
+		return slog.LevelInfo, "xmit"
+	default:
+		return slog.LevelInfo, ""
+	}
+}
+
+/* For dw_printf.  skip is the number of callers to skip to find who printed. */
+
+func log_printed(s string, skip int) {
+	var l = structuredLog.Load()
+	if l == nil {
+		return
+	}
+
+	var _, file, _, ok = runtime.Caller(skip + 1)
+	if !ok {
+		file = ""
+	}
+
+	l.printed(goroutine_id(), s, file)
+}
+
+/* For text_color_set. */
+
+func log_color_set(color dw_color_e) {
+	var l = structuredLog.Load()
+	if l == nil {
+		return
+	}
+
+	l.color_set(goroutine_id(), color)
+}
+
+/*
+ * Go doesn't give goroutines an identity, but the first line of a
+ * stack trace is "goroutine 123 [running]:".
+ */
+
+func goroutine_id() uint64 {
+	var buf [64]byte
+
+	var n = runtime.Stack(buf[:], false)
+
+	var text = strings.TrimPrefix(string(buf[:n]), "goroutine ")
+	text, _, _ = strings.Cut(text, " ")
+
+	var id, _ = strconv.ParseUint(text, 10, 64)
+
+	return id
+}
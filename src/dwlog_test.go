@@ -0,0 +1,226 @@
+package direwolf
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_log_subsystem(t *testing.T) {
+	assert.Equal(t, "kiss", log_subsystem("/home/q1test/samoyed/src/kissnet.go"))
+	assert.Equal(t, "ax25", log_subsystem("ax25_link.go"))
+	assert.Equal(t, "digipeater", log_subsystem("cdigipeater.go"))
+	assert.Equal(t, "demod", log_subsystem("hdlc_rec2.go"))
+	assert.Equal(t, "xmit", log_subsystem("hdlc_send.go"))
+	assert.Equal(t, LOG_DEFAULT_SUBSYSTEM, log_subsystem("direwolf.go"))
+	assert.Equal(t, LOG_DEFAULT_SUBSYSTEM, log_subsystem(""))
+}
+
+func Test_log_parse_levels(t *testing.T) {
+	var l, err = log_parse_levels(nil, "", "")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelDebug, l.level("kiss"))
+
+	l, err = log_parse_levels([]string{"info", "demod=warn"}, "ki", "d")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelInfo, l.level("audio"))
+	assert.Equal(t, slog.LevelWarn, l.level("demod"))
+	assert.Equal(t, slog.LevelDebug, l.level("kiss"))
+	assert.Equal(t, slog.LevelDebug, l.level("igate"))
+	assert.Equal(t, slog.LevelWarn, l.level("aprs"))
+
+	// --log-level wins over -d.
+	l, err = log_parse_levels([]string{"ERROR", "kiss=info", "direwolf=warn"}, "k", "")
+	require.NoError(t, err)
+	assert.Equal(t, slog.LevelInfo, l.level("kiss"))
+	assert.Equal(t, slog.LevelWarn, l.level(LOG_DEFAULT_SUBSYSTEM))
+	assert.Equal(t, slog.LevelError, l.level("igate"))
+
+	_, err = log_parse_levels([]string{"loud"}, "", "")
+	require.Error(t, err)
+
+	_, err = log_parse_levels([]string{"nosuch=info"}, "", "")
+	require.Error(t, err)
+}
+
+func log_test_records(t *testing.T, buf *bytes.Buffer) []map[string]any {
+	t.Helper()
+
+	var records []map[string]any
+
+	for line := range strings.SplitSeq(strings.TrimSpace(buf.String()), "\n") {
+		if line == "" {
+			continue
+		}
+
+		var r map[string]any
+
+		require.NoError(t, json.Unmarshal([]byte(line), &r), line)
+		records = append(records, r)
+	}
+
+	return records
+}
+
+func Test_log_printed(t *testing.T) {
+	var buf bytes.Buffer
+
+	var levels, err = log_parse_levels([]string{"info"}, "", "")
+	require.NoError(t, err)
+
+	var l = new_dw_logger(&buf, levels)
+
+	l.color_set(1, DW_COLOR_DEBUG)
+	l.printed(1, "\n", "direwolf.go")
+	l.color_set(1, DW_COLOR_REC)
+	l.printed(1, "[0] ", "direwolf.go")
+	l.color_set(1, DW_COLOR_INFO)
+	l.printed(1, "Q1TEST>APDW18:", "ax25_pad.go")
+	l.color_set(1, DW_COLOR_ERROR)
+	l.printed(1, ">Hello\nAudio input level is too high.\n", "audio.go")
+	l.color_set(1, DW_COLOR_DEBUG)
+	l.printed(1, "Debugging\n", "kiss.go")
+	l.color_set(1, DW_COLOR_XMIT)
+	l.printed(1, "Partial", "xmit.go")
+
+	var records = log_test_records(t, &buf)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "INFO", records[0]["level"])
+	assert.Equal(t, "[0] Q1TEST>APDW18:>Hello", records[0]["msg"])
+	assert.Equal(t, "rec", records[0]["kind"])
+	assert.Equal(t, LOG_DEFAULT_SUBSYSTEM, records[0]["subsystem"])
+
+	// Rest of the piece starts a new line.
+	assert.Equal(t, "ERROR", records[1]["level"])
+	assert.Equal(t, "Audio input level is too high.", records[1]["msg"])
+	assert.Equal(t, "audio", records[1]["subsystem"])
+	assert.NotContains(t, records[1], "kind")
+
+	// Unfinished line written at close.
+	structuredLog.Store(l)
+
+	log_json_close()
+	assert.Nil(t, structuredLog.Load())
+
+	records = log_test_records(t, &buf)
+	require.Len(t, records, 3)
+	assert.Equal(t, "Partial", records[2]["msg"])
+	assert.Equal(t, "xmit", records[2]["kind"])
+}
+
+func Test_log_printed_goroutines(t *testing.T) {
+	var buf bytes.Buffer
+
+	var levels, _ = log_parse_levels(nil, "", "")
+
+	var l = new_dw_logger(&buf, levels)
+
+	// Two goroutines printing lines in pieces at the same time.
+	l.color_set(1, DW_COLOR_REC)
+	l.printed(1, "[0] ", "recv.go")
+	l.color_set(2, DW_COLOR_ERROR)
+	l.printed(2, "Could not ", "kissnet.go")
+	l.printed(1, "Q1TEST>APDW18:>Hello", "ax25_pad.go")
+	l.printed(2, "connect.\n", "kissnet.go")
+	l.printed(1, "\n", "recv.go")
+
+	var records = log_test_records(t, &buf)
+	require.Len(t, records, 2)
+
+	assert.Equal(t, "ERROR", records[0]["level"])
+	assert.Equal(t, "Could not connect.", records[0]["msg"])
+	assert.Equal(t, "kiss", records[0]["subsystem"])
+
+	assert.Equal(t, "INFO", records[1]["level"])
+	assert.Equal(t, "[0] Q1TEST>APDW18:>Hello", records[1]["msg"])
+	assert.Equal(t, "rec", records[1]["kind"])
+	assert.Equal(t, "demod", records[1]["subsystem"])
+}
+
+func Test_log_dw_printf_concurrent(t *testing.T) {
+	var buf bytes.Buffer
+
+	var levels, _ = log_parse_levels(nil, "", "")
+
+	structuredLog.Store(new_dw_logger(&buf, levels))
+
+	defer structuredLog.Store(nil)
+
+	var wg sync.WaitGroup
+
+	for range 8 {
+		wg.Go(func() {
+			for range 50 {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Something ")
+				dw_printf("failed.\n")
+				text_color_set(DW_COLOR_INFO)
+				dw_printf("Something ")
+				dw_printf("worked.\n")
+			}
+		})
+	}
+
+	wg.Wait()
+
+	var records = log_test_records(t, &buf)
+	require.Len(t, records, 800)
+
+	for _, r := range records {
+		if r["msg"] == "Something failed." {
+			assert.Equal(t, "ERROR", r["level"])
+		} else {
+			assert.Equal(t, "Something worked.", r["msg"])
+			assert.Equal(t, "INFO", r["level"])
+		}
+	}
+}
+
+func Test_log_dw_printf(t *testing.T) {
+	var buf bytes.Buffer
+
+	var levels, _ = log_parse_levels(nil, "", "")
+
+	structuredLog.Store(new_dw_logger(&buf, levels))
+
+	defer structuredLog.Store(nil)
+
+	text_color_set(DW_COLOR_ERROR)
+	dw_printf("Something %s.\n", "failed")
+	text_color_set(DW_COLOR_INFO)
+
+	var records = log_test_records(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "ERROR", records[0]["level"])
+	assert.Equal(t, "Something failed.", records[0]["msg"])
+	assert.Equal(t, LOG_DEFAULT_SUBSYSTEM, records[0]["subsystem"], "dwlog_test.go")
+}
+
+func Test_log_json_only(t *testing.T) {
+	var buf bytes.Buffer
+
+	var levels, _ = log_parse_levels(nil, "", "")
+
+	structuredLog.Store(new_dw_logger(&buf, levels))
+	log_json_only = true
+
+	defer func() {
+		structuredLog.Store(nil)
+		log_json_only = false
+	}()
+
+	var n, err = dw_printf("Heard %s.\n", "Q1TEST")
+	require.NoError(t, err)
+	assert.Equal(t, len("Heard Q1TEST.\n"), n)
+
+	var records = log_test_records(t, &buf)
+	require.Len(t, records, 1)
+	assert.Equal(t, "Heard Q1TEST.", records[0]["msg"])
+}
@@ -0,0 +1,383 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Export Emergency Alert System (EAS) alerts so they can
+ *		trigger something else, such as home automation or paging.
+ *
+ * Description:	The EAS Specific Area Message Encoding (SAME) header
+ *		looks like this:
+ *
+ *			ZCZC-ORG-EEE-PSSCCC-PSSCCC+TTTT-JJJHHMM-LLLLLLLL-
+ *
+ *		ORG	 - Originator, e.g. WXR for National Weather Service.
+ *		EEE	 - Event code, e.g. TOR for Tornado Warning.
+ *		PSSCCC	 - Up to 31 locations.  P is part of the county,
+ *			   SS is the state FIPS code, CCC is the county.
+ *		TTTT	 - How long the alert is valid, hours and minutes.
+ *		JJJHHMM	 - When issued, day of year, hours and minutes, UTC.
+ *		LLLLLLLL - Station sending it.
+ *
+ *		Each header is sent three times so we get it up to three
+ *		times.  Repeats within a short time are ignored.
+ *
+ *		"direwolf --eas-json file" appends one JSON object per line
+ *		for each alert.  "direwolf --eas-hook command" runs the
+ *		command with the same JSON on its stdin and the more
+ *		useful parts in environment variables:
+ *
+ *			EAS_ORIGINATOR, EAS_EVENT, EAS_EVENT_NAME,
+ *			EAS_LOCATIONS (space separated), EAS_ISSUED,
+ *			EAS_EXPIRES, EAS_SENDER, EAS_CHANNEL, EAS_MESSAGE
+ *
+ * References:	47 CFR 11.31, https://www.ecfr.gov/current/title-47/part-11
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+type EASAlert struct {
+	Channel        int       `json:"channel"`
+	Received       time.Time `json:"received"`
+	Originator     string    `json:"originator"`
+	OriginatorName string    `json:"originator_name,omitempty"`
+	Event          string    `json:"event"`
+	EventName      string    `json:"event_name,omitempty"`
+	Locations      []string  `json:"locations"`
+	Issued         time.Time `json:"issued"`
+	Expires        time.Time `json:"expires"`
+	Sender         string    `json:"sender"`
+	Message        string    `json:"message"`
+}
+
+var easOriginators = map[string]string{
+	"EAN": "Emergency Action Notification Network",
+	"PEP": "Primary Entry Point System",
+	"CIV": "Civil authorities",
+	"WXR": "National Weather Service",
+	"EAS": "Broadcast station or cable system",
+}
+
+var easEvents = map[string]string{
+	"EAN": "Emergency Action Notification",
+	"NPT": "National Periodic Test",
+	"RMT": "Required Monthly Test",
+	"RWT": "Required Weekly Test",
+	"ADR": "Administrative Message",
+	"AVW": "Avalanche Warning",
+	"AVA": "Avalanche Watch",
+	"BLU": "Blue Alert",
+	"BZW": "Blizzard Warning",
+	"CAE": "Child Abduction Emergency",
+	"CDW": "Civil Danger Warning",
+	"CEM": "Civil Emergency Message",
+	"CFW": "Coastal Flood Warning",
+	"CFA": "Coastal Flood Watch",
+	"DSW": "Dust Storm Warning",
+	"EQW": "Earthquake Warning",
+	"EVI": "Evacuation Immediate",
+	"EWW": "Extreme Wind Warning",
+	"FRW": "Fire Warning",
+	"FFW": "Flash Flood Warning",
+	"FFA": "Flash Flood Watch",
+	"FFS": "Flash Flood Statement",
+	"FLW": "Flood Warning",
+	"FLA": "Flood Watch",
+	"FLS": "Flood Statement",
+	"HMW": "Hazardous Materials Warning",
+	"HWW": "High Wind Warning",
+	"HWA": "High Wind Watch",
+	"HUW": "Hurricane Warning",
+	"HUA": "Hurricane Watch",
+	"HLS": "Hurricane Statement",
+	"LEW": "Law Enforcement Warning",
+	"LAE": "Local Area Emergency",
+	"NMN": "Network Message Notification",
+	"TOE": "911 Telephone Outage Emergency",
+	"NUW": "Nuclear Power Plant Warning",
+	"DMO": "Practice/Demo Warning",
+	"RHW": "Radiological Hazard Warning",
+	"SVR": "Severe Thunderstorm Warning",
+	"SVA": "Severe Thunderstorm Watch",
+	"SVS": "Severe Weather Statement",
+	"SPW": "Shelter in Place Warning",
+	"SMW": "Special Marine Warning",
+	"SPS": "Special Weather Statement",
+	"SSA": "Storm Surge Watch",
+	"SSW": "Storm Surge Warning",
+	"TOR": "Tornado Warning",
+	"TOA": "Tornado Watch",
+	"TRW": "Tropical Storm Warning",
+	"TRA": "Tropical Storm Watch",
+	"TSW": "Tsunami Warning",
+	"TSA": "Tsunami Watch",
+	"VOW": "Volcano Warning",
+	"WSW": "Winter Storm Warning",
+	"WSA": "Winter Storm Watch",
+}
+
+var easHeaderRegexp = regexp.MustCompile(`^ZCZC-([A-Z]{3})-([A-Z]{3})((?:-[0-9]{6}){1,31})\+([0-9]{4})-([0-9]{3})([0-9]{2})([0-9]{2})-([^-]{1,8})-`)
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	eas_parse
+ *
+ * Purpose:	Take apart a SAME header.
+ *
+ * Inputs:	msg	- Header starting with ZCZC.
+ *
+ *		now	- When received.  Needed for the year which is
+ *			  not in the header.
+ *
+ * Returns:	Alert, or error if not a valid header.
+ *		"NNNN" for end of message is an error too.
+ *
+ *--------------------------------------------------------------------*/
+
+func eas_parse(msg string, now time.Time) (*EASAlert, error) {
+	var m = easHeaderRegexp.FindStringSubmatch(msg)
+	if m == nil {
+		return nil, fmt.Errorf("not a valid EAS header: %s", msg)
+	}
+
+	var a = new(EASAlert)
+
+	a.Received = now
+	a.Originator = m[1]
+	a.OriginatorName = easOriginators[m[1]]
+	a.Event = m[2]
+	a.EventName = easEvents[m[2]]
+	a.Locations = strings.Split(m[3][1:], "-")
+	a.Sender = m[8]
+	a.Message = msg[:len(m[0])]
+
+	var purgeH, _ = strconv.Atoi(m[4][:2])
+	var purgeM, _ = strconv.Atoi(m[4][2:])
+
+	var day, _ = strconv.Atoi(m[5])
+	var hour, _ = strconv.Atoi(m[6])
+	var minute, _ = strconv.Atoi(m[7])
+
+	if day < 1 || day > 366 || hour > 23 || minute > 59 {
+		return nil, fmt.Errorf("invalid EAS issue time %s%s%s: %s", m[5], m[6], m[7], msg)
+	}
+
+	/* Pick the year which puts the issue time closest to now. */
+
+	var utc = now.UTC()
+
+	a.Issued = time.Date(utc.Year(), 1, day, hour, minute, 0, 0, time.UTC)
+
+	if a.Issued.Sub(utc) > 180*24*time.Hour {
+		a.Issued = time.Date(utc.Year()-1, 1, day, hour, minute, 0, 0, time.UTC)
+	} else if utc.Sub(a.Issued) > 180*24*time.Hour {
+		a.Issued = time.Date(utc.Year()+1, 1, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	a.Expires = a.Issued.Add(time.Duration(purgeH)*time.Hour + time.Duration(purgeM)*time.Minute)
+
+	return a, nil
+}
+
+/* Environment variables for the hook command. */
+
+func (a *EASAlert) environ() []string {
+	return []string{
+		"EAS_ORIGINATOR=" + a.Originator,
+		"EAS_EVENT=" + a.Event,
+		"EAS_EVENT_NAME=" + a.EventName,
+		"EAS_LOCATIONS=" + strings.Join(a.Locations, " "),
+		"EAS_ISSUED=" + a.Issued.Format(time.RFC3339),
+		"EAS_EXPIRES=" + a.Expires.Format(time.RFC3339),
+		"EAS_SENDER=" + a.Sender,
+		"EAS_CHANNEL=" + strconv.Itoa(a.Channel),
+		"EAS_MESSAGE=" + a.Message,
+	}
+}
+
+/* Same header again within this time is a repeat. */
+
+const EAS_REPEAT_TIME = 30 * time.Second
+
+type EASExporter struct {
+	mu   sync.Mutex
+	w    io.Writer
+	f    *os.File // nil if not writing to a file.
+	hook string   // Command to run for each alert.  Empty for none.
+
+	last     [MAX_RADIO_CHANS]string // Most recent header on each channel.
+	lastTime [MAX_RADIO_CHANS]time.Time
+
+	wg sync.WaitGroup // Hook commands still running.
+}
+
+var easExporter *EASExporter
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	NewEASExporter
+ *
+ * Purpose:	Get ready to export alerts.
+ *
+ * Inputs:	path	- JSON file, appended to.  Empty for none.
+ *
+ *		hook	- Command to run.  Empty for none.
+ *
+ * Returns:	nil if there is nothing to do.
+ *
+ *---------------------------------------------------------------*/
+
+func NewEASExporter(path string, hook string) (*EASExporter, error) {
+	if path == "" && hook == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	var e = new(EASExporter)
+	e.hook = hook
+
+	if path != "" {
+		var f, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gosec // Named on the command line.
+		if err != nil {
+			return nil, err
+		}
+
+		e.w = f
+		e.f = f
+	}
+
+	if hook != "" {
+		var _, err = exec.LookPath(hook)
+		if err != nil {
+			if e.f != nil {
+				e.f.Close()
+			}
+
+			return nil, fmt.Errorf("EAS hook command: %w", err)
+		}
+	}
+
+	return e, nil
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Received
+ *
+ * Purpose:	Export an alert if this is a new one.
+ *
+ * Inputs:	channel	- Radio channel where heard.
+ *
+ *		info	- Information part of the packet from the EAS
+ *			  demodulator, {DE followed by the header.
+ *
+ *		now	- Time received.
+ *
+ * Description:	Safe to call with nil receiver or for packets which are
+ *		not EAS.
+ *
+ *---------------------------------------------------------------*/
+
+func (e *EASExporter) Received(channel int, info []byte, now time.Time) {
+	if e == nil || channel < 0 || channel >= MAX_RADIO_CHANS {
+		return
+	}
+
+	var prefix = "{" + string(USER_DEF_USER_ID) + string(USER_DEF_TYPE_EAS)
+
+	if !strings.HasPrefix(string(info), prefix) {
+		return
+	}
+
+	var msg = string(info[len(prefix):])
+
+	if !strings.HasPrefix(msg, "ZCZC") {
+		return // End of message.
+	}
+
+	var a, err = eas_parse(msg, now)
+	if err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("%v\n", err)
+
+		return
+	}
+
+	a.Channel = channel
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if a.Message == e.last[channel] && now.Sub(e.lastTime[channel]) < EAS_REPEAT_TIME {
+		return
+	}
+
+	e.last[channel] = a.Message
+	e.lastTime[channel] = now
+
+	var j, _ = json.Marshal(a)
+
+	if e.w != nil {
+		var _, writeErr = fmt.Fprintf(e.w, "%s\n", j)
+		if writeErr != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Error writing EAS alert: %s\n", writeErr)
+		}
+	}
+
+	if e.hook != "" {
+		/* Don't hold up the receive path. */
+
+		e.wg.Add(1)
+
+		go func() {
+			defer e.wg.Done()
+
+			eas_run_hook(e.hook, a, j)
+		}()
+	}
+}
+
+func eas_run_hook(hook string, a *EASAlert, j []byte) {
+	var cmd = exec.Command(hook) //nolint:gosec // Named on the command line.
+	cmd.Env = append(os.Environ(), a.environ()...)
+	cmd.Stdin = strings.NewReader(string(j) + "\n")
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+
+	var err = cmd.Run()
+	if err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("EAS hook command %s failed: %s\n", hook, err)
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Close
+ *
+ * Purpose:	Wait for hook commands to finish and close the file.
+ *
+ *---------------------------------------------------------------*/
+
+func (e *EASExporter) Close() {
+	if e == nil {
+		return
+	}
+
+	e.wg.Wait()
+
+	if e.f != nil {
+		e.f.Close()
+	}
+}
@@ -0,0 +1,139 @@
+package direwolf
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const easTestHeader = "ZCZC-WXR-TOR-029037-029165+0030-1051700-KEAX/NWS-"
+
+func Test_eas_parse(t *testing.T) {
+	var now = time.Date(2024, 4, 14, 17, 1, 0, 0, time.UTC)
+
+	var a, err = eas_parse(easTestHeader, now)
+	require.NoError(t, err)
+
+	assert.Equal(t, "WXR", a.Originator)
+	assert.Equal(t, "National Weather Service", a.OriginatorName)
+	assert.Equal(t, "TOR", a.Event)
+	assert.Equal(t, "Tornado Warning", a.EventName)
+	assert.Equal(t, []string{"029037", "029165"}, a.Locations)
+	assert.Equal(t, "KEAX/NWS", a.Sender)
+	assert.Equal(t, time.Date(2024, 4, 14, 17, 0, 0, 0, time.UTC), a.Issued) // Leap year.
+	assert.Equal(t, time.Date(2024, 4, 14, 17, 30, 0, 0, time.UTC), a.Expires)
+	assert.Equal(t, easTestHeader, a.Message)
+
+	/* Junk after the header is dropped. */
+
+	a, err = eas_parse(easTestHeader+"\rxyz", now)
+	require.NoError(t, err)
+	assert.Equal(t, easTestHeader, a.Message)
+
+	/* Issued late December, received early January. */
+
+	a, err = eas_parse("ZCZC-EAS-RWT-000000+0015-3652355-WABC/FM -", time.Date(2025, 1, 1, 0, 5, 0, 0, time.UTC))
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 12, 30, 23, 55, 0, 0, time.UTC), a.Issued)
+	assert.Equal(t, "WABC/FM ", a.Sender)
+
+	for _, bad := range []string{
+		"NNNN",
+		"ZCZC-WXR-TOR+0030-1051700-KEAX/NWS-",
+		"ZCZC-WXR-TOR-02903+0030-1051700-KEAX/NWS-",
+		"ZCZC-WXR-TOR-029037+0030-4001700-KEAX/NWS-",
+		"ZCZC-WXR-TOR-029037+0030-1052500-KEAX/NWS-",
+	} {
+		_, err = eas_parse(bad, now)
+		assert.Error(t, err, bad)
+	}
+}
+
+func eas_test_info(msg string) []byte {
+	return []byte("{" + string(USER_DEF_USER_ID) + string(USER_DEF_TYPE_EAS) + msg)
+}
+
+func Test_eas_exporter_json(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "alerts.json")
+
+	var e, err = NewEASExporter(path, "")
+	require.NoError(t, err)
+
+	var now = time.Date(2024, 4, 14, 17, 1, 0, 0, time.UTC)
+
+	e.Received(0, eas_test_info(easTestHeader), now)
+	e.Received(0, eas_test_info(easTestHeader), now.Add(time.Second))     // Repeat.
+	e.Received(1, eas_test_info(easTestHeader), now.Add(2*time.Second))   // Different channel.
+	e.Received(0, eas_test_info("NNNN"), now.Add(3*time.Second))          // End of message.
+	e.Received(0, []byte("{DA!AIVDM,1,1,,A,..."), now.Add(4*time.Second)) // Not EAS.
+	e.Received(0, eas_test_info(easTestHeader), now.Add(time.Hour))       // Sent again later.
+	e.Close()
+
+	var data, readErr = os.ReadFile(path)
+	require.NoError(t, readErr)
+
+	var lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+
+	var a EASAlert
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &a))
+	assert.Equal(t, 1, a.Channel)
+	assert.Equal(t, "TOR", a.Event)
+	assert.Equal(t, []string{"029037", "029165"}, a.Locations)
+	assert.Equal(t, now.Add(2*time.Second), a.Received)
+
+	assert.Contains(t, lines[0], `"expires":"2024-04-14T17:30:00Z"`)
+}
+
+func Test_eas_exporter_hook(t *testing.T) {
+	var dir = t.TempDir()
+	var out = filepath.Join(dir, "out")
+	var hook = filepath.Join(dir, "hook.sh")
+
+	require.NoError(t, os.WriteFile(hook, []byte("#!/bin/sh\necho \"$EAS_EVENT $EAS_LOCATIONS $EAS_CHANNEL\" > "+out+"\ncat >> "+out+"\n"), 0o700)) //nolint:gosec
+
+	var e, err = NewEASExporter("", hook)
+	require.NoError(t, err)
+
+	e.Received(2, eas_test_info(easTestHeader), time.Date(2024, 4, 14, 17, 1, 0, 0, time.UTC))
+	e.Close()
+
+	var data, readErr = os.ReadFile(out)
+	require.NoError(t, readErr)
+
+	var lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 2)
+	assert.Equal(t, "TOR 029037 029165 2", lines[0])
+	assert.Contains(t, lines[1], `"event_name":"Tornado Warning"`)
+}
+
+func Test_eas_exporter_none(t *testing.T) {
+	var e, err = NewEASExporter("", "")
+	require.NoError(t, err)
+	assert.Nil(t, e)
+
+	e.Received(0, eas_test_info(easTestHeader), time.Now())
+	e.Close()
+
+	_, err = NewEASExporter("", "/nonexistent/eas-hook")
+	assert.Error(t, err)
+}
+
+func Test_eas_exporter_buffer(t *testing.T) {
+	var buf bytes.Buffer
+
+	var e = new(EASExporter)
+	e.w = &buf
+
+	e.Received(0, eas_test_info(easTestHeader), time.Date(2024, 4, 14, 17, 1, 0, 0, time.UTC))
+
+	assert.True(t, strings.HasPrefix(buf.String(), `{"channel":0,"received":"2024-04-14T17:01:00Z","originator":"WXR",`))
+}
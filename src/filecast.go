@@ -0,0 +1,448 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Broadcast small files, such as weather maps or BBS lists,
+ *		with UI frames, for links where nothing comes back.
+ *
+ * Description:	Configured with
+ *
+ *			FILECAST  channel  path  [ EVERY=minutes ]  [ VIA=digi1,... ]  [ BLOCK=bytes ]  [ NAME=name ]
+ *
+ *		Every so often (default 10 minutes), the file is read
+ *		again and sent as a header followed by numbered blocks.
+ *		Nothing is acknowledged.  A receiver which missed some
+ *		blocks fills in the gaps on the next time around, like
+ *		a carousel.
+ *
+ *		Frames go to "FILES" with protocol ID FILECAST_PID, so
+ *		they aren't taken for APRS and don't go to the IGate.
+ *		The information part is
+ *
+ *			H<id> <blocks> <size> <name>	- Header.
+ *			D<id> <n> <data>		- Block n, starting from 0.
+ *
+ *		<id> is 8 hex digits, the CRC-32 of the name, a nul byte,
+ *		and the contents, so a changed file is a different one.
+ *		<data> is BLOCK bytes (default 128) as is, except the
+ *		last which can be shorter.
+ *
+ *		Files are received only if there is a directory for them:
+ *
+ *			FILECASTDIR  directory
+ *
+ *		A complete file is saved as directory/SOURCE/name.  Parts
+ *		of files, and what has been saved, are forgotten after
+ *		an hour without hearing them.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"bytes"
+	"fmt"
+	"hash/crc32"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const FILECAST_PID = 0xf5 /* Not assigned to anything else. */
+
+const FILECAST_DEST = "FILES"
+
+const FILECAST_DEFAULT_EVERY = 10 * time.Minute
+
+const FILECAST_DEFAULT_BLOCK = 128
+
+const FILECAST_MIN_BLOCK = 16
+
+const FILECAST_MAX_BLOCK = 1024
+
+const FILECAST_MAX_SIZE = 64 * 1024 /* A few minutes at 1200 baud. */
+
+const FILECAST_MAX_NAME = 64
+
+const FILECAST_EXPIRE = time.Hour
+
+type filecast_s struct {
+	channel int
+	path    string
+	name    string /* Defaults to the last part of path. */
+	every   time.Duration
+	via     []string
+	block   int
+	lineno  int
+}
+
+type filecastPartial struct {
+	name   string
+	size   int
+	total  int /* Number of blocks.  -1 until the header is heard. */
+	blocks map[int][]byte
+	bytes  int /* Sum of the block lengths so far. */
+	heard  time.Time
+}
+
+type FilecastService struct {
+	modemConfig *audio_s
+	senders     []*filecast_s
+	dir         string /* Where to save received files.  Empty for none. */
+	clock       Clock
+
+	mu      sync.Mutex
+	partial map[string]*filecastPartial /* By source and id. */
+	done    map[string]time.Time        /* Saved already, and when last heard. */
+}
+
+var filecastService *FilecastService
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	NewFilecastService
+ *
+ * Purpose:	Get ready to send and receive files.
+ *
+ * Returns:	nil if there is nothing to do.
+ *
+ *---------------------------------------------------------------*/
+
+func NewFilecastService(modemConfig *audio_s, miscConfig *misc_config_s) *FilecastService {
+	if len(miscConfig.filecast) == 0 && miscConfig.filecast_dir == "" {
+		return nil
+	}
+
+	return &FilecastService{ //nolint:exhaustruct
+		modemConfig: modemConfig,
+		senders:     miscConfig.filecast,
+		dir:         miscConfig.filecast_dir,
+		clock:       SystemClock{},
+		partial:     make(map[string]*filecastPartial),
+		done:        make(map[string]time.Time),
+	}
+}
+
+/* Start a carousel for each FILECAST. */
+
+func (fs *FilecastService) Start() {
+	if fs == nil {
+		return
+	}
+
+	for _, f := range fs.senders {
+		go func() {
+			for {
+				fs.send(f)
+				fs.clock.Sleep(f.every)
+			}
+		}()
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	filecast_id
+ *
+ * Purpose:	Identify one version of a file.
+ *
+ *---------------------------------------------------------------*/
+
+func filecast_id(name string, data []byte) string {
+	var crc = crc32.NewIEEE()
+	crc.Write([]byte(name))
+	crc.Write([]byte{0})
+	crc.Write(data)
+
+	return fmt.Sprintf("%08x", crc.Sum32())
+}
+
+/* Received names become file names, so nothing that could go somewhere else. */
+
+func filecast_name_ok(name string) bool {
+	return name != "" && name != "." && name != ".." &&
+		len(name) <= FILECAST_MAX_NAME &&
+		!strings.ContainsAny(name, "/\\:\x00") &&
+		!strings.ContainsFunc(name, func(r rune) bool { return r < ' ' || r == 0x7f })
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	filecast_frames
+ *
+ * Purpose:	Split a file into the information parts of frames.
+ *
+ * Inputs:	name	- File name for the receiver.
+ *		data	- Contents.
+ *		block	- Bytes in each block.
+ *
+ * Returns:	Header followed by the blocks.
+ *
+ *---------------------------------------------------------------*/
+
+func filecast_frames(name string, data []byte, block int) [][]byte {
+	var id = filecast_id(name, data)
+	var total = (len(data) + block - 1) / block
+
+	var frames = [][]byte{fmt.Appendf(nil, "H%s %d %d %s", id, total, len(data), name)}
+
+	for n := range total {
+		var end = min((n+1)*block, len(data))
+		frames = append(frames, append(fmt.Appendf(nil, "D%s %d ", id, n), data[n*block:end]...))
+	}
+
+	return frames
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	send
+ *
+ * Purpose:	Send one FILECAST all the way around once.
+ *
+ * Description:	The file is read each time so it can be changed
+ *		without restarting.
+ *
+ *---------------------------------------------------------------*/
+
+func (fs *FilecastService) send(f *filecast_s) {
+	if fs.modemConfig.chan_medium[f.channel] != MEDIUM_RADIO {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("FILECAST on config file line %d: Channel %d is not a radio channel.\n", f.lineno, f.channel)
+
+		return
+	}
+
+	var mycall = fs.modemConfig.mycall[f.channel]
+	if IsNoCall(mycall) {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("MYCALL not set for FILECAST to chan %d in config file line %d.\n", f.channel, f.lineno)
+
+		return
+	}
+
+	var data, err = os.ReadFile(f.path)
+	if err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("FILECAST on config file line %d: %s\n", f.lineno, err)
+
+		return
+	}
+
+	if len(data) > FILECAST_MAX_SIZE {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("FILECAST on config file line %d: %s is %d bytes.  The most is %d.\n", f.lineno, f.path, len(data), FILECAST_MAX_SIZE)
+
+		return
+	}
+
+	var addrs [AX25_MAX_ADDRS]string
+	addrs[AX25_DESTINATION] = FILECAST_DEST
+	addrs[AX25_SOURCE] = mycall
+	copy(addrs[AX25_REPEATER_1:], f.via)
+
+	for _, info := range filecast_frames(f.name, data, f.block) {
+		var pp = ax25_u_frame(addrs, AX25_REPEATER_1+len(f.via), cr_cmd, frame_type_U_UI, 0, FILECAST_PID, info)
+		if pp == nil {
+			return
+		}
+
+		pp.tx_reason = TX_REASON_BEACON // Lowest priority, like a beacon.
+		tq_append(f.channel, TQ_PRIO_1_LO, pp)
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Received
+ *
+ * Purpose:	Collect the blocks of files from others.
+ *
+ * Inputs:	channel	- Where heard.
+ *		pp	- Received packet.
+ *
+ * Description:	Safe to call with nil receiver or for any packet.
+ *		The blocks can arrive in any order, even before the
+ *		header.
+ *
+ *---------------------------------------------------------------*/
+
+func (fs *FilecastService) Received(channel int, pp *packet_t) {
+	if fs == nil || fs.dir == "" {
+		return
+	}
+
+	if ax25_get_num_addr(pp) < 2 || ax25_get_control(pp)&^0x10 != 0x03 || ax25_get_pid(pp) != FILECAST_PID {
+		return
+	}
+
+	var info = AX25GetInfo(pp)
+	if len(info) < 1 {
+		return
+	}
+
+	var kind = info[0]
+
+	var id, rest, _ = bytes.Cut(info[1:], []byte(" "))
+	if len(id) != 8 {
+		return
+	}
+
+	var first, data, _ = bytes.Cut(rest, []byte(" "))
+
+	var n, err = strconv.Atoi(string(first))
+	if err != nil || n < 0 {
+		return
+	}
+
+	var source = ax25_get_addr_with_ssid(pp, AX25_SOURCE)
+	var key = source + "/" + string(id)
+	var now = fs.clock.Now()
+
+	fs.mu.Lock()
+	defer fs.mu.Unlock()
+
+	fs.expire(now)
+
+	if _, ok := fs.done[key]; ok {
+		fs.done[key] = now // Still going around.
+
+		return
+	}
+
+	var p = fs.partial[key]
+	if p == nil {
+		p = &filecastPartial{name: "", size: 0, total: -1, blocks: make(map[int][]byte), bytes: 0, heard: now}
+		fs.partial[key] = p
+	}
+
+	p.heard = now
+
+	switch kind {
+	case 'H':
+		// Here n is the number of blocks.
+
+		var sizeText, name, _ = bytes.Cut(data, []byte(" "))
+
+		var size, sizeErr = strconv.Atoi(string(sizeText))
+		if sizeErr != nil || size < 0 || size > FILECAST_MAX_SIZE ||
+			n > (size+FILECAST_MIN_BLOCK-1)/FILECAST_MIN_BLOCK ||
+			n < (size+FILECAST_MAX_BLOCK-1)/FILECAST_MAX_BLOCK ||
+			!filecast_name_ok(string(name)) {
+			delete(fs.partial, key)
+
+			return
+		}
+
+		p.name = string(name)
+		p.size = size
+		p.total = n
+
+		for i, b := range p.blocks {
+			if i >= n {
+				p.bytes -= len(b)
+				delete(p.blocks, i)
+			}
+		}
+	case 'D':
+		if _, ok := p.blocks[n]; ok {
+			return
+		}
+
+		if (p.total >= 0 && n >= p.total) || p.bytes+len(data) > FILECAST_MAX_SIZE {
+			delete(fs.partial, key)
+
+			return
+		}
+
+		p.blocks[n] = append([]byte(nil), data...)
+		p.bytes += len(data)
+	default:
+		return
+	}
+
+	if p.total < 0 || len(p.blocks) < p.total {
+		return
+	}
+
+	delete(fs.partial, key)
+
+	var content = make([]byte, 0, p.bytes)
+	for i := range p.total {
+		content = append(content, p.blocks[i]...)
+	}
+
+	if len(content) != p.size || filecast_id(p.name, content) != string(id) {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("File %s from %s on channel %d is damaged.  Trying again next time around.\n", p.name, source, channel)
+
+		return
+	}
+
+	fs.done[key] = now
+
+	var path, saveErr = fs.save(source, p.name, content)
+	if saveErr != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Can't save file %s from %s: %s\n", p.name, source, saveErr)
+
+		return
+	}
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("Received file %s from %s on channel %d, %d bytes, saved as %s\n", p.name, source, channel, len(content), path)
+}
+
+/* Forget what hasn't been heard for a while.  Caller holds the lock. */
+
+func (fs *FilecastService) expire(now time.Time) {
+	for key, p := range fs.partial {
+		if now.Sub(p.heard) > FILECAST_EXPIRE {
+			delete(fs.partial, key)
+		}
+	}
+
+	for key, heard := range fs.done {
+		if now.Sub(heard) > FILECAST_EXPIRE {
+			delete(fs.done, key)
+		}
+	}
+}
+
+/* Write to a temporary file first, so nothing else sees half of it. */
+
+func (fs *FilecastService) save(source string, name string, content []byte) (string, error) {
+	var dir = filepath.Join(fs.dir, source)
+
+	var err = os.MkdirAll(dir, 0o755) //nolint:gosec // Files to share.
+	if err != nil {
+		return "", err
+	}
+
+	var tmp, tmpErr = os.CreateTemp(dir, ".filecast*")
+	if tmpErr != nil {
+		return "", tmpErr
+	}
+
+	var _, writeErr = tmp.Write(content)
+	var closeErr = tmp.Close()
+
+	if writeErr == nil {
+		writeErr = closeErr
+	}
+
+	var path = filepath.Join(dir, name)
+
+	if writeErr == nil {
+		writeErr = os.Rename(tmp.Name(), path)
+	}
+
+	if writeErr != nil {
+		os.Remove(tmp.Name())
+
+		return "", writeErr
+	}
+
+	return path, nil
+}
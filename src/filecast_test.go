@@ -0,0 +1,144 @@
+package direwolf
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_config_init_filecast(t *testing.T) {
+	var _, misc = configFromString(t, "FILECAST 1 /var/wx/map.png EVERY=5 VIA=WIDE1-1,WIDE2-1 BLOCK=64\n"+
+		"FILECAST 0 bbs-list.txt NAME=bbs.txt BLOCK=5000\n"+
+		"FILECAST 0 x NAME=../x\n"+
+		"FILECAST radio x\n"+
+		"FILECASTDIR /var/files\n")
+
+	require.Len(t, misc.filecast, 2)
+
+	var f = misc.filecast[0]
+	assert.Equal(t, 1, f.channel)
+	assert.Equal(t, "map.png", f.name)
+	assert.Equal(t, 5*time.Minute, f.every)
+	assert.Equal(t, []string{"WIDE1-1", "WIDE2-1"}, f.via)
+	assert.Equal(t, 64, f.block)
+
+	f = misc.filecast[1]
+	assert.Equal(t, "bbs.txt", f.name)
+	assert.Equal(t, FILECAST_DEFAULT_EVERY, f.every)
+	assert.Empty(t, f.via)
+	assert.Equal(t, FILECAST_DEFAULT_BLOCK, f.block, "too big, so default")
+
+	assert.Equal(t, "/var/files", misc.filecast_dir)
+}
+
+func Test_filecast_frames(t *testing.T) {
+	var frames = filecast_frames("a.txt", []byte("hello world"), 4)
+	var id = filecast_id("a.txt", []byte("hello world"))
+
+	assert.Equal(t, [][]byte{
+		[]byte("H" + id + " 3 11 a.txt"),
+		[]byte("D" + id + " 0 hell"),
+		[]byte("D" + id + " 1 o wo"),
+		[]byte("D" + id + " 2 rld"),
+	}, frames)
+
+	assert.NotEqual(t, id, filecast_id("b.txt", []byte("hello world")), "name is part of it")
+
+	assert.True(t, filecast_name_ok("wx map.png"))
+
+	for _, bad := range []string{"", ".", "..", "../x", "a/b", "a\\b", "c:x", "a\nb"} {
+		assert.False(t, filecast_name_ok(bad), bad)
+	}
+}
+
+func filecastPackets(t *testing.T, source string, frames [][]byte) []*packet_t {
+	t.Helper()
+
+	var addrs [AX25_MAX_ADDRS]string
+	addrs[AX25_DESTINATION] = FILECAST_DEST
+	addrs[AX25_SOURCE] = source
+
+	var packets []*packet_t
+
+	for _, info := range frames {
+		var pp = ax25_u_frame(addrs, 2, cr_cmd, frame_type_U_UI, 0, FILECAST_PID, info)
+		require.NotNil(t, pp)
+
+		packets = append(packets, pp)
+	}
+
+	return packets
+}
+
+func Test_FilecastService_Received(t *testing.T) {
+	var dir = t.TempDir()
+	var clock = NewFakeClock(time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	var fs = NewFilecastService(new(audio_s), &misc_config_s{filecast_dir: dir}) //nolint:exhaustruct
+	require.NotNil(t, fs)
+	fs.clock = clock
+
+	var content = []byte("Line 1\nLine 2 \x00\xff binary is fine\n")
+	var packets = filecastPackets(t, "Q1TEST-7", filecast_frames("bbs.txt", content, 16))
+	var saved = filepath.Join(dir, "Q1TEST-7", "bbs.txt")
+
+	// Not APRS, so ignored.
+
+	fs.Received(0, AX25FromText("Q1TEST-7>APRS:Hbbs.txt", true))
+
+	// Header last, with a block missing the first time around.
+
+	var reversed = slices.Clone(packets)
+	slices.Reverse(reversed)
+
+	for _, pp := range reversed[1:] {
+		fs.Received(0, pp)
+	}
+
+	assert.NoFileExists(t, saved)
+
+	fs.Received(0, reversed[0])
+	fs.Received(0, reversed[0])
+
+	var got, err = os.ReadFile(saved)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+
+	// Already done, so not saved again the next time around.
+
+	require.NoError(t, os.Remove(saved))
+
+	for _, pp := range packets {
+		fs.Received(0, pp)
+	}
+
+	assert.NoFileExists(t, saved)
+
+	// Unless it has been forgotten.
+
+	clock.Advance(FILECAST_EXPIRE + time.Minute)
+
+	for _, pp := range packets {
+		fs.Received(0, pp)
+	}
+
+	assert.FileExists(t, saved)
+
+	// Somewhere else is never allowed.
+
+	var frames = filecast_frames("x", []byte("evil"), 16)
+	frames[0] = []byte("H" + filecast_id("../x", []byte("evil")) + " 1 4 ../x")
+	frames[1] = []byte("D" + filecast_id("../x", []byte("evil")) + " 0 evil")
+
+	for _, pp := range filecastPackets(t, "Q2OTHR", frames) {
+		fs.Received(0, pp)
+	}
+
+	assert.NoFileExists(t, filepath.Join(dir, "x"))
+	assert.NoDirExists(t, filepath.Join(dir, "Q2OTHR"))
+}
@@ -0,0 +1,62 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Reusable buffers for frames on their way through.
+ *
+ * Description:	Every frame received, or sent by a client application,
+ *		used to get a fresh byte slice or two: one for the KISS
+ *		unescaping, another for each copy with the AGW "TNC" byte
+ *		stuck on the front, and so on.  None of those live past
+ *		the function that made them, so we borrow a buffer from
+ *		the pool instead and give it back when done.
+ *
+ *		Whoever calls frame_buf_put must be sure nothing kept
+ *		a reference to the data.  Anything that needs to keep
+ *		it, e.g. AX25FromFrame, makes its own copy.
+ *
+ *		packet_t objects are not pooled.  AX25GetInfo returns a
+ *		slice into the frame and plenty of callers hold on to
+ *		that after AX25Delete.
+ *
+ *------------------------------------------------------------------*/
+
+import "sync"
+
+/* Big enough for anything coming through KISS, with the escapes. */
+
+const FRAME_BUF_SIZE = MAX_KISS_LEN
+
+type frame_buf_t struct {
+	data []byte
+}
+
+var frame_buf_pool = sync.Pool{ //nolint:gochecknoglobals
+	New: func() any {
+		return &frame_buf_t{data: make([]byte, 0, FRAME_BUF_SIZE)}
+	},
+}
+
+/* Get an empty buffer.  Append to fb.data as usual. */
+
+func frame_buf_get() *frame_buf_t {
+	var fb = frame_buf_pool.Get().(*frame_buf_t) //nolint:forcetypeassert
+
+	fb.data = fb.data[:0]
+
+	return fb
+}
+
+/*
+ * Give it back.  Anything that grew far beyond the usual size
+ * is left for the garbage collector so one giant frame doesn't
+ * keep a giant buffer around forever.
+ */
+
+func frame_buf_put(fb *frame_buf_t) {
+	if cap(fb.data) > 4*FRAME_BUF_SIZE {
+		return
+	}
+
+	frame_buf_pool.Put(fb)
+}
@@ -0,0 +1,128 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_frame_buf_get_is_empty(t *testing.T) {
+	var fb = frame_buf_get()
+	fb.data = append(fb.data, "Q1TEST>Q2TEST:hello"...)
+	frame_buf_put(fb)
+
+	fb = frame_buf_get()
+	assert.Empty(t, fb.data)
+	assert.GreaterOrEqual(t, cap(fb.data), FRAME_BUF_SIZE)
+	frame_buf_put(fb)
+}
+
+func Test_frame_buf_put_oversized(t *testing.T) {
+	var fb = frame_buf_get()
+	fb.data = make([]byte, 0, 5*FRAME_BUF_SIZE)
+	frame_buf_put(fb) // Dropped, not pooled.
+
+	fb = frame_buf_get()
+	assert.LessOrEqual(t, cap(fb.data), 4*FRAME_BUF_SIZE)
+	frame_buf_put(fb)
+}
+
+func Test_kiss_unwrap_to(t *testing.T) {
+	var fb = frame_buf_get()
+	defer frame_buf_put(fb)
+
+	var din = []byte{0x00, 'A', FEND, 'B', FESC, 'C'}
+
+	fb.data = kiss_unwrap_to(fb.data, KissEncapsulate(din))
+	assert.Equal(t, din, fb.data)
+
+	// Second use of the same buffer must not see the first.
+
+	fb.data = kiss_unwrap_to(fb.data[:0], KissEncapsulate([]byte{0x00, 'Z'}))
+	assert.Equal(t, []byte{0x00, 'Z'}, fb.data)
+}
+
+func Test_rrbb_reuse_is_clean(t *testing.T) {
+	var b = rrbb_new(0, 0, 0, false, 0, 0)
+	rrbb_append_bit(b, 1)
+	rrbb_append_bit(b, 0)
+	b.speed_error = 1.5
+	rrbb_delete(b)
+
+	b = rrbb_new(1, 0, 0, true, 5, 1)
+	assert.Equal(t, 0, rrbb_get_len(b))
+	assert.Equal(t, 1, b.channel)
+	assert.InDelta(t, 0.0, b.speed_error, 0)
+	assert.True(t, b.is_scrambled)
+	assert.Equal(t, 5, b.descram_state)
+	rrbb_delete(b)
+}
+
+/* Frames like we'd see from a KISS client. */
+
+func benchKissFrame(b *testing.B) []byte {
+	b.Helper()
+
+	var pp = AX25FromText("Q1TEST>Q2TEST,WIDE1-1,WIDE2-1:!4237.14N/07120.83W-PHG7140 Pooled buffer benchmark", true)
+	require.NotNil(b, pp)
+
+	defer AX25Delete(pp)
+
+	return KissEncapsulate(append([]byte{0x00}, AX25Pack(pp)...))
+}
+
+func BenchmarkKissUnwrap(b *testing.B) {
+	var kissed = benchKissFrame(b)
+
+	b.Run("alloc", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_ = kiss_unwrap(kissed)
+		}
+	})
+
+	b.Run("pooled", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			var fb = frame_buf_get()
+			fb.data = kiss_unwrap_to(fb.data, kissed)
+			frame_buf_put(fb)
+		}
+	})
+}
+
+func BenchmarkRrbbNewDelete(b *testing.B) {
+	b.ReportAllocs()
+
+	for i := 0; i < b.N; i++ {
+		var block = rrbb_new(0, 0, 0, false, 0, 0)
+		rrbb_append_bit(block, 1)
+		rrbb_delete(block)
+	}
+}
+
+func BenchmarkLayer2FrameData(b *testing.B) {
+	var pp = AX25FromText("Q1TEST>Q2TEST,WIDE1-1,WIDE2-1:!4237.14N/07120.83W-PHG7140 Pooled buffer benchmark", true)
+	require.NotNil(b, pp)
+
+	defer AX25Delete(pp)
+
+	b.Run("pack", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_ = fcs_calc(AX25Pack(pp))
+		}
+	})
+
+	b.Run("frame_data", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_ = fcs_calc(ax25_get_frame_data(pp))
+		}
+	})
+}
@@ -0,0 +1,214 @@
+package direwolf
+
+// Fuzz targets for the decoders which see data from over the air or
+// from client applications.  Seeded from the decode_aprs corpus.
+//
+// go test runs only the seeds.  To fuzz one, e.g.
+//
+//	go test ./src -run '^$' -fuzz '^FuzzAX25FromFrame$' -fuzztime 5m
+//
+// Anything found goes in testdata/fuzz so it is checked from then on.
+
+import (
+	"bytes"
+	"math/bits"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+/* Packets, in monitor format, from the decode_aprs corpus. */
+
+func fuzzSeedPackets(f *testing.F) []string {
+	f.Helper()
+
+	var files, err = filepath.Glob(filepath.Join("testdata", "decode_aprs", "*.txt"))
+	require.NoError(f, err)
+	require.NotEmpty(f, files)
+
+	var packets []string
+
+	for _, file := range files {
+		var cases, readErr = corpus_read(file)
+		require.NoError(f, readErr)
+
+		for _, c := range cases {
+			packets = append(packets, c.packet)
+		}
+	}
+
+	return packets
+}
+
+/* Same, as frames. */
+
+func fuzzSeedFrames(f *testing.F) [][]byte {
+	f.Helper()
+
+	var frames [][]byte
+
+	for _, p := range fuzzSeedPackets(f) {
+		var pp = AX25FromText(p, false)
+		if pp != nil {
+			frames = append(frames, AX25Pack(pp))
+			AX25Delete(pp)
+		}
+	}
+
+	return frames
+}
+
+func FuzzAX25FromText(f *testing.F) {
+	for _, p := range fuzzSeedPackets(f) {
+		f.Add(p)
+	}
+
+	f.Add("Q1TEST>Q2TEST,WIDE1-1*,WIDE2-1:<0x00><0xff>")
+	f.Add(">:")
+
+	f.Fuzz(func(t *testing.T, monitor string) {
+		var pp = AX25FromText(monitor, false)
+		if pp == nil {
+			return
+		}
+
+		var n = ax25_get_num_addr(pp)
+		require.GreaterOrEqual(t, n, AX25_MIN_ADDRS)
+		require.LessOrEqual(t, n, AX25_MAX_ADDRS)
+
+		AX25Delete(pp)
+	})
+}
+
+func FuzzAX25FromFrame(f *testing.F) {
+	for _, frame := range fuzzSeedFrames(f) {
+		f.Add(frame)
+	}
+
+	f.Add([]byte{})
+	f.Add(make([]byte, 15))
+
+	f.Fuzz(func(t *testing.T, frame []byte) {
+		var alevel ALevel
+
+		var pp = AX25FromFrame(frame, alevel)
+		if pp == nil {
+			return
+		}
+
+		var n = ax25_get_num_addr(pp)
+		require.GreaterOrEqual(t, n, AX25_MIN_ADDRS)
+		require.LessOrEqual(t, n, AX25_MAX_ADDRS)
+		require.LessOrEqual(t, len(AX25GetInfo(pp)), len(frame))
+
+		AX25Delete(pp)
+	})
+}
+
+func FuzzKissUnwrap(f *testing.F) {
+	for _, frame := range fuzzSeedFrames(f) {
+		f.Add(KissEncapsulate(append([]byte{0x00}, frame...)))
+	}
+
+	f.Add([]byte{FEND, 0x00, FESC, TFEND, FESC, TFESC, FEND})
+	f.Add([]byte{FEND, 0x00, FESC, FEND})
+	f.Add([]byte{FESC})
+
+	f.Fuzz(func(t *testing.T, msg []byte) {
+		var out, _ = kiss_unwrap_check(msg)
+		require.LessOrEqual(t, len(out), len(msg), "unescaping never makes it longer")
+
+		// Whatever we got back goes through encapsulation unchanged.
+
+		var again, problems = kiss_unwrap_check(KissEncapsulate(out))
+		require.Empty(t, problems)
+		require.True(t, bytes.Equal(out, again))
+	})
+}
+
+func FuzzFX25TagMatch(f *testing.F) {
+	for c := CTAG_MIN; c <= CTAG_MAX; c++ {
+		f.Add(tags[c].value)
+		f.Add(tags[c].value ^ 0x8000000000000101) // 3 bits wrong.
+	}
+
+	f.Add(uint64(0))
+	f.Add(^uint64(0))
+
+	f.Fuzz(func(t *testing.T, v uint64) {
+		var c = fx25_tag_find_match(v)
+		if c == -1 {
+			for k := CTAG_MIN; k <= CTAG_MAX; k++ {
+				require.Greater(t, bits.OnesCount64(v^tags[k].value), CLOSE_ENOUGH)
+			}
+
+			return
+		}
+
+		require.GreaterOrEqual(t, c, CTAG_MIN)
+		require.LessOrEqual(t, c, CTAG_MAX)
+		require.LessOrEqual(t, bits.OnesCount64(v^tags[c].value), CLOSE_ENOUGH)
+	})
+}
+
+func FuzzIL2PDecodeFrame(f *testing.F) {
+	il2p_init(0)
+
+	for _, p := range fuzzSeedPackets(f) {
+		var pp = AX25FromText(p, false)
+		if pp == nil {
+			continue
+		}
+
+		for max_fec := range 2 {
+			var encoded, elen = il2p_encode_frame(pp, max_fec)
+			if elen > 0 {
+				f.Add(encoded[:elen])
+			}
+		}
+
+		AX25Delete(pp)
+	}
+
+	f.Add(make([]byte, IL2P_HEADER_SIZE+IL2P_HEADER_PARITY))
+
+	f.Fuzz(func(t *testing.T, irec []byte) {
+		var pp = il2p_decode_frame(irec)
+		if pp != nil {
+			AX25Delete(pp)
+		}
+	})
+}
+
+func FuzzDecodeAPRS(f *testing.F) {
+	deviceIDData = NewDeviceIDData()
+
+	for _, p := range fuzzSeedPackets(f) {
+		var pp = AX25FromText(p, false)
+		if pp == nil {
+			continue
+		}
+
+		f.Add(ax25_get_addr_with_ssid(pp, AX25_DESTINATION), AX25GetInfo(pp))
+		AX25Delete(pp)
+	}
+
+	f.Add("APRS", []byte("`"))
+	f.Add("T2SP0W", []byte("`c5Bl!n>/]"))
+	f.Add("APRS", []byte("}Q1TEST>APRS,TCPIP,Q2TEST*:}"))
+
+	f.Fuzz(func(t *testing.T, dest string, info []byte) {
+		// Mic-E puts part of the position in the destination.
+		var pp = AX25FromText("Q1TEST>"+dest+":", false)
+		if pp == nil || ax25_get_num_addr(pp) != AX25_MIN_ADDRS {
+			return
+		}
+
+		ax25_set_info(pp, info)
+
+		decode_aprs(pp, true, "")
+
+		AX25Delete(pp)
+	})
+}
@@ -39,6 +39,12 @@ package direwolf
  *			gen_packets -n 100 -o z2.wav
  *			atest z2.wav
  *
+ *		With bit errors, 1 in 1000 flipped on the way to the
+ *		modulator, to test FX.25, IL2P, and atest -F:
+ *
+ *			gen_packets -e 1e-3 -N 100 -o z4.wav
+ *			atest -F1 z4.wav
+ *
  *		Variable speed. e.g. 95% to 105% of normal speed.
  *		Required parameter is max % below and above normal.
  *		Optionally specify step other than 0.1%.
@@ -48,6 +54,11 @@ package direwolf
  *			gen_packets -v 5
  *			gen_packets -v 5,0.5
  *
+ *		A set of files over a range of noise, mistuning, and
+ *		bit rate error.  See gen_packets_sweep.go.
+ *
+ *			gen_packets --sweep-snr 0:20:2 -o sweep.wav
+ *
  *------------------------------------------------------------------*/
 
 import (
@@ -59,6 +70,7 @@ import (
 	"strconv"
 	"strings"
 
+	"github.com/doismellburning/samoyed/src/channelmodel"
 	"github.com/spf13/pflag"
 )
 
@@ -78,14 +90,11 @@ type wav_header struct { /* .WAV file header. */
 	datasize        int32   /* number of bytes following. */
 }
 
-const MY_RAND_MAX = 0x7fffffff
-
 var GEN_PACKETS = false // Switch between fakes and reals at runtime
 
 var modem audio_s
 var g_morse_wpm = 0 /* Send morse code at this speed. */
 var g_add_noise = false
-var g_noise_level float64 = 0
 
 var genPacketsOutFile *os.File
 
@@ -96,14 +105,10 @@ var byte_count int /* Number of data bytes written to file. Will be written to h
 
 var gen_header wav_header
 
-var genPacketsRandSeed int32 = 1
-
 // Although the tests in `test-scripts` all call `atest` with an acceptable *range* of packets, the only way I could get them all to pass was by reimplementing this exact PRNG from Dire Wolf's gen_packets.c - all my attempts to use Go's `math/rand` resulted in decodes that would fall outside of the acceptable range. It's far from impossible that I somehow screwed up my use of `math/rand`, but I think it more likely that the tests depend on this exact PRNG implementation, which I should address at some point. /KG
 // Yep, if seed is 1, tests pass; if seed is 2, test96f64 decodes 68 not 71+; if seed is 3 then test96f16 decodes 62 not 63+ /KG
-func genPacketsRand() int32 {
-	genPacketsRandSeed = int32((uint32(genPacketsRandSeed)*1103515245 + 12345) & MY_RAND_MAX)
-	return genPacketsRandSeed
-}
+// The PRNG now lives in channelmodel, shared with the receive side.
+var genPacketsChannel = channelmodel.New()
 
 func GenPacketsMain() {
 	GEN_PACKETS = true // Use the _fake functions
@@ -157,7 +162,11 @@ EAS for Emergency Alert System (EAS) Specific Area Message Encoding (SAME).`)
 	var fx25CheckBytes = pflag.IntP("fx25-check-bytes", "X", 0, "1 to enable FX.25 transmit.  16, 32, 64 for specific number of check bytes.")
 	var il2pNormal = pflag.IntP("il2p", "I", -1, "Enable IL2P transmit.  n=1 is recommended.  0 uses weaker FEC.")
 	var il2pInverted = pflag.IntP("il2p-inverted", "i", -1, "Enable IL2P transmit, inverted polarity.  n=1 is recommended.  0 uses weaker FEC.")
+	var bitErrorRate = pflag.Float64P("bit-error-rate", "e", 0.0, "Transmit Bit Error Rate (BER), e.g. 1e-3.")
 	var variableSpeedStr = pflag.StringP("variable-speed", "v", "", "max[,incr] Variable speed with specified maximum error and increment.")
+	var sweepSNR = pflag.String("sweep-snr", "", "min:max:step Write a file for each signal to noise ratio, dB.")
+	var sweepOffset = pflag.String("sweep-offset", "", "min:max:step Write a file for each AFSK frequency offset, Hz.")
+	var sweepSkew = pflag.String("sweep-skew", "", "min:max:step Write a file for each bit rate error, percent.")
 	var help = pflag.BoolP("help", "h", false, "Display help text.")
 
 	pflag.Usage = func() {
@@ -281,6 +290,19 @@ EAS for Emergency Alert System (EAS) Specific Area Message Encoding (SAME).`)
 		}
 	}
 
+	if *bitErrorRate < 0 || *bitErrorRate >= 1 {
+		text_color_set(DW_COLOR_ERROR)
+		fmt.Printf("Bit error rate must be at least 0 and less than 1, not %g.\n", *bitErrorRate)
+		os.Exit(1)
+	}
+
+	if *bitErrorRate > 0 {
+		genPacketsChannel.BitErrorRate = *bitErrorRate
+
+		text_color_set(DW_COLOR_INFO)
+		fmt.Printf("Bit error rate set to %g.\n", *bitErrorRate)
+	}
+
 	var variable_speed_max_error float64 = 0 // both in percent
 	var variable_speed_increment = 0.1
 
@@ -490,6 +512,23 @@ EAS for Emergency Alert System (EAS) Specific Area Message Encoding (SAME).`)
 		os.Exit(1)
 	}
 
+	if *sweepSNR != "" || *sweepOffset != "" || *sweepSkew != "" {
+		if *noisyPacketCount > 0 || *variableSpeedStr != "" || len(pflag.Args()) > 0 || g_morse_wpm > 0 || modem.achan[0].modem_type == MODEM_EAS {
+			text_color_set(DW_COLOR_ERROR)
+			fmt.Printf("The sweep options can't be used with -n, -v, -M, EAS, or user defined content.\n")
+			os.Exit(1)
+		}
+
+		var err = gen_packets_sweep(*outputFile, *amplitude, packet_count, *sweepSNR, *sweepOffset, *sweepSkew)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			fmt.Printf("ERROR - %s\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
 	var err = audio_file_open(*outputFile, &modem)
 
 	if err < 0 {
@@ -587,7 +626,7 @@ EAS for Emergency Alert System (EAS) Specific Area Message Encoding (SAME).`)
 
 		for speed_error := -variable_speed_max_error; speed_error <= variable_speed_max_error+0.001; speed_error += variable_speed_increment {
 			// Baud is int so we get some roundoff.  Make it real?
-			modem.achan[0].baud = int(float64(normal_speed) * (1. + speed_error/100.))
+			modem.achan[0].baud = channelmodel.SkewBaud(normal_speed, speed_error)
 			gen_tone_init(&modem, *amplitude/2, true)
 
 			var stemp = fmt.Sprintf("WB2OSZ-15>TEST:, speed %+0.1f%%  The quick brown fox jumps over the lazy dog!", speed_error)
@@ -602,21 +641,21 @@ EAS for Emergency Alert System (EAS) Specific Area Message Encoding (SAME).`)
 		for i := 1; i <= packet_count; i++ {
 			if modem.achan[0].baud < 600 {
 				/* e.g. 300 bps AFSK - About 2/3 should be decoded properly. */
-				g_noise_level = float64(*amplitude) * .0048 * (float64(i) / float64(packet_count))
+				genPacketsChannel.NoiseLevel = float64(*amplitude) * .0048 * (float64(i) / float64(packet_count))
 			} else if modem.achan[0].baud < 1800 {
 				/* e.g. 1200 bps AFSK - About 2/3 should be decoded properly. */
-				g_noise_level = float64(*amplitude) * .0023 * (float64(i) / float64(packet_count))
+				genPacketsChannel.NoiseLevel = float64(*amplitude) * .0023 * (float64(i) / float64(packet_count))
 			} else if modem.achan[0].baud < 3600 {
 				/* e.g. 2400 bps QPSK - T.B.D. */
-				g_noise_level = float64(*amplitude) * .0015 * (float64(i) / float64(packet_count))
+				genPacketsChannel.NoiseLevel = float64(*amplitude) * .0015 * (float64(i) / float64(packet_count))
 			} else if modem.achan[0].baud < 7200 {
 				/* e.g. 4800 bps - T.B.D. */
-				g_noise_level = float64(*amplitude) * .0007 * (float64(i) / float64(packet_count))
+				genPacketsChannel.NoiseLevel = float64(*amplitude) * .0007 * (float64(i) / float64(packet_count))
 			} else {
 				/* e.g. 9600 */
-				g_noise_level = 0.33 * (float64(*amplitude) / 200.0) * (float64(i) / float64(packet_count))
+				genPacketsChannel.NoiseLevel = 0.33 * (float64(*amplitude) / 200.0) * (float64(i) / float64(packet_count))
 				// temp test
-				// g_noise_level = 0.20 * (amplitude / 200.0) * (float64(i) / float64(packet_count));
+				// genPacketsChannel.NoiseLevel = 0.20 * (amplitude / 200.0) * (float64(i) / float64(packet_count));
 			}
 
 			var stemp = fmt.Sprintf("WB2OSZ-15>TEST:,The quick brown fox jumps over the lazy dog!  %04d of %04d", i, packet_count)
@@ -692,23 +731,10 @@ func audio_file_open(fname string, pa *audio_s) int {
 		return (-1)
 	}
 
-	// TODO KG Can't get memcpy to work, so just stuff it in manually
-	// C.memcpy(unsafe.Pointer(&gen_header.riff[0]), unsafe.Pointer(C.CString("RIFF")), 4)
-	gen_header.riff[0] = 'R'
-	gen_header.riff[1] = 'I'
-	gen_header.riff[2] = 'F'
-	gen_header.riff[3] = 'F'
+	copy(gen_header.riff[:], "RIFF")
 	gen_header.filesize = 0
-	// C.memcpy(unsafe.Pointer(&gen_header.wave[0]), unsafe.Pointer(C.CString("WAVE")), 4)
-	gen_header.wave[0] = 'W'
-	gen_header.wave[1] = 'A'
-	gen_header.wave[2] = 'V'
-	gen_header.wave[3] = 'E'
-	// C.memcpy(unsafe.Pointer(&gen_header.fmt[0]), unsafe.Pointer(C.CString("fmt ")), 4)
-	gen_header.fmt[0] = 'f'
-	gen_header.fmt[1] = 'm'
-	gen_header.fmt[2] = 't'
-	gen_header.fmt[3] = ' '
+	copy(gen_header.wave[:], "WAVE")
+	copy(gen_header.fmt[:], "fmt ")
 	gen_header.fmtsize = 16   // Always 16.
 	gen_header.wformattag = 1 // 1 for PCM.
 
@@ -718,11 +744,7 @@ func audio_file_open(fname string, pa *audio_s) int {
 
 	gen_header.nblockalign = gen_header.wbitspersample / 8 * gen_header.nchannels
 	gen_header.navgbytespersec = int32(gen_header.nblockalign) * gen_header.nsamplespersec
-	// C.memcpy(unsafe.Pointer(&gen_header.data[0]), unsafe.Pointer(C.CString("data")), 4)
-	gen_header.data[0] = 'd'
-	gen_header.data[1] = 'a'
-	gen_header.data[2] = 't'
-	gen_header.data[3] = 'a'
+	copy(gen_header.data[:], "data")
 	gen_header.datasize = 0
 
 	if !(gen_header.nchannels == 1 || gen_header.nchannels == 2) {
@@ -878,7 +900,7 @@ func send_packet(str string) {
 			// Then throw in a random amount of time so that receiving
 			// DPLL will need to adjust to a new phase.
 
-			var n = int(float64(samples_per_symbol) * (32 + float64(genPacketsRand())/float64(MY_RAND_MAX)))
+			var n = int(float64(samples_per_symbol) * (32 + genPacketsChannel.Rand.Float()))
 
 			for range n {
 				gen_tone_put_sample(c, 0, 0)
@@ -921,22 +943,10 @@ func audio_put_fake(_ int, c uint8) int {
 		} else {
 			sample16 |= int16(c) << 8 /* insert upper byte. */
 			byte_count++
-			var s = int32(sample16) // sign extend.
 
 			/* Add random noise to the signal. */
-			/* r should be in range of -1 .. +1. */
-
-			var r = (float64(genPacketsRand()) - float64(MY_RAND_MAX)/2.0) / (float64(MY_RAND_MAX) / 2.0)
-
-			s += int32(5 * r * g_noise_level * 32767)
 
-			if s > 32767 {
-				s = 32767
-			}
-
-			if s < -32767 {
-				s = -32767
-			}
+			var s = genPacketsChannel.AddNoise(sample16)
 
 			var n, writeErr = genPacketsOutBuf.Write([]byte{byte(s & 0xff), byte(s>>8) & 0xff})
 			if writeErr != nil {
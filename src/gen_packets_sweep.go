@@ -0,0 +1,246 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Generate test audio over a range of impairments, for
+ *		measuring how well the demodulators cope.
+ *
+ * Description:	gen_packets --sweep-snr, --sweep-offset and --sweep-skew
+ *		each take a range "min:max:step", or a single value.
+ *		A .WAV file is written for every combination, named
+ *		after the output file with the settings added, e.g.
+ *
+ *			gen_packets --sweep-snr 0:20:5 --sweep-offset -50:50:25 -o sweep.wav
+ *
+ *		writes sweep_snr0_off-50.wav ... sweep_snr20_off+50.wav,
+ *		and sweep.csv listing each file with its settings and the
+ *		number of packets in it.  Run atest on each file and compare
+ *		the number decoded to get a score for each point.
+ *
+ *		snr	- Signal to noise ratio, dB.  The noise is white
+ *			  across the whole audio bandwidth, so the ratio
+ *			  within the modem's passband is better than this.
+ *
+ *		offset	- Hz added to both AFSK tones, like a mistuned
+ *			  SSB receiver.
+ *
+ *		skew	- Percent error in the transmitted bit rate, the
+ *			  same as -v but one rate per file.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/doismellburning/samoyed/src/channelmodel"
+)
+
+const SWEEP_DEFAULT_PACKETS = 10
+
+type sweepAxis struct {
+	name   string    // For file names.
+	values []float64 // Values to use.
+	swept  bool      // false if not given, so values is just the one neutral value.
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        parse_sweep_range
+ *
+ * Purpose:     Parse a sweep option.
+ *
+ * Inputs:	s	- "min:max:step" or a single value.
+ *
+ * Returns:	Values from min to max inclusive.
+ *
+ *--------------------------------------------------------------------*/
+
+func parse_sweep_range(s string) ([]float64, error) {
+	var parts = strings.Split(s, ":")
+
+	var nums = make([]float64, 0, len(parts))
+
+	for _, p := range parts {
+		var f, err = strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return nil, fmt.Errorf("\"%s\" is not a number in \"%s\"", p, s)
+		}
+
+		nums = append(nums, f)
+	}
+
+	switch len(nums) {
+	case 1:
+		return nums, nil
+	case 3:
+		var lo, hi, step = nums[0], nums[1], nums[2]
+		if step <= 0 || hi < lo {
+			return nil, fmt.Errorf("\"%s\" should be min:max:step with max at least min and step more than 0", s)
+		}
+
+		var values []float64
+		// Count steps rather than adding up so rounding doesn't lose the last one.
+		for i := 0; lo+float64(i)*step <= hi+step/1000; i++ {
+			values = append(values, math.Round((lo+float64(i)*step)*1e6)/1e6)
+		}
+
+		return values, nil
+	default:
+		return nil, fmt.Errorf("\"%s\" should be min:max:step or a single value", s)
+	}
+}
+
+func sweep_file_name(base string, axes []sweepAxis, values []float64) string {
+	var name = base
+
+	for i, axis := range axes {
+		if !axis.swept {
+			continue
+		}
+
+		if axis.name == "snr" {
+			name += fmt.Sprintf("_%s%g", axis.name, values[i])
+		} else {
+			name += fmt.Sprintf("_%s%+g", axis.name, values[i])
+		}
+	}
+
+	return name + ".wav"
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        gen_packets_sweep
+ *
+ * Purpose:     Write one audio file for each combination of impairments.
+ *
+ * Inputs:	out_file	- Name given with -o.  Used as the base for
+ *				  the generated names and the .csv list.
+ *
+ *		amplitude	- As -a option.
+ *
+ *		count		- Packets in each file.
+ *
+ *		snr, offset, skew - Option values, empty if not used.
+ *
+ *		The modem global must already be set up from the other options.
+ *
+ *--------------------------------------------------------------------*/
+
+func gen_packets_sweep(out_file string, amplitude int, count int, snr string, offset string, skew string) error {
+	var axes = []sweepAxis{
+		{name: "snr", values: []float64{math.Inf(1)}, swept: false},
+		{name: "off", values: []float64{0}, swept: false},
+		{name: "skew", values: []float64{0}, swept: false},
+	}
+
+	for i, opt := range []string{snr, offset, skew} {
+		if opt == "" {
+			continue
+		}
+
+		var values, err = parse_sweep_range(opt)
+		if err != nil {
+			return err
+		}
+
+		axes[i].values = values
+		axes[i].swept = true
+	}
+
+	if axes[1].swept && modem.achan[0].modem_type != MODEM_AFSK {
+		return errors.New("frequency offset can only be used with AFSK")
+	}
+
+	if modem.adev[0].bits_per_sample != 16 {
+		return errors.New("noise can only be added to 16 bit audio")
+	}
+
+	if count <= 0 {
+		count = SWEEP_DEFAULT_PACKETS
+	}
+
+	var base = strings.TrimSuffix(out_file, filepath.Ext(out_file))
+
+	var manifest, err = os.Create(base + ".csv") //nolint:gosec // Named on the command line.
+	if err != nil {
+		return err
+	}
+
+	defer manifest.Close()
+
+	fmt.Fprintf(manifest, "file,snr_db,offset_hz,skew_percent,packets\n")
+
+	var normal_baud = modem.achan[0].baud
+
+	var normal_mark = modem.achan[0].mark_freq
+
+	var normal_space = modem.achan[0].space_freq
+
+	defer func() {
+		modem.achan[0].baud = normal_baud
+		modem.achan[0].mark_freq = normal_mark
+		modem.achan[0].space_freq = normal_space
+		g_add_noise = false
+		genPacketsChannel.NoiseLevel = 0
+		genPacketsChannel.OffsetHz = 0
+		genPacketsChannel.SkewPercent = 0
+	}()
+
+	FX25Init(1)
+	il2p_init(0)
+
+	for _, s := range axes[0].values {
+		for _, o := range axes[1].values {
+			for _, k := range axes[2].values {
+				var name = sweep_file_name(base, axes, []float64{s, o, k})
+
+				genPacketsChannel.OffsetHz = o
+				genPacketsChannel.SkewPercent = k
+
+				modem.achan[0].mark_freq, modem.achan[0].space_freq, modem.achan[0].baud =
+					genPacketsChannel.Tones(normal_mark, normal_space, normal_baud)
+
+				if audio_file_open(name, &modem) < 0 {
+					return fmt.Errorf("can't open %s", name)
+				}
+
+				gen_tone_init(&modem, amplitude/2, true)
+
+				var label string
+
+				var snr_text string
+
+				g_add_noise = !math.IsInf(s, 1)
+				if g_add_noise {
+					genPacketsChannel.NoiseLevel = channelmodel.NoiseLevelForSNR(float64(amplitude/2)/100, s)
+					snr_text = strconv.FormatFloat(s, 'g', -1, 64)
+					label = fmt.Sprintf("snr %g dB ", s)
+				}
+
+				label += fmt.Sprintf("offset %+g Hz skew %+g%%", o, k)
+
+				text_color_set(DW_COLOR_INFO)
+				fmt.Printf("%s: %s\n", name, label)
+
+				for i := 1; i <= count; i++ {
+					send_packet(fmt.Sprintf("WB2OSZ-15>TEST:,The quick brown fox jumps over the lazy dog!  %s  %04d of %04d", label, i, count))
+				}
+
+				if audio_file_close() < 0 {
+					return fmt.Errorf("can't finish writing %s", name)
+				}
+
+				fmt.Fprintf(manifest, "%s,%s,%g,%g,%d\n", filepath.Base(name), snr_text, o, k, count)
+			}
+		}
+	}
+
+	return manifest.Close()
+}
@@ -0,0 +1,74 @@
+package direwolf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parse_sweep_range(t *testing.T) {
+	var values, err = parse_sweep_range("0:20:5")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{0, 5, 10, 15, 20}, values)
+
+	values, err = parse_sweep_range("-0.3:0.3:0.1")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{-0.3, -0.2, -0.1, 0, 0.1, 0.2, 0.3}, values)
+
+	values, err = parse_sweep_range("7")
+	require.NoError(t, err)
+	assert.Equal(t, []float64{7}, values)
+
+	for _, bad := range []string{"", "1:2", "1:x:1", "5:0:1", "0:5:0"} {
+		_, err = parse_sweep_range(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func Test_gen_packets_sweep(t *testing.T) {
+	GEN_PACKETS = true
+
+	defer func() {
+		GEN_PACKETS = false
+		var zero audio_s
+		modem = zero
+	}()
+
+	modem.adev[0].defined = 1
+	modem.adev[0].num_channels = 1
+	modem.adev[0].samples_per_sec = DEFAULT_SAMPLES_PER_SEC
+	modem.adev[0].bits_per_sample = 16
+	modem.chan_medium[0] = MEDIUM_RADIO
+	modem.achan[0].modem_type = MODEM_AFSK
+	modem.achan[0].mark_freq = DEFAULT_MARK_FREQ
+	modem.achan[0].space_freq = DEFAULT_SPACE_FREQ
+	modem.achan[0].baud = DEFAULT_BAUD
+
+	var dir = t.TempDir()
+
+	require.NoError(t, gen_packets_sweep(filepath.Join(dir, "sweep.wav"), 50, 2, "10:20:10", "", "-1:1:1"))
+
+	var manifest, err = os.ReadFile(filepath.Join(dir, "sweep.csv"))
+	require.NoError(t, err)
+	assert.Equal(t, "file,snr_db,offset_hz,skew_percent,packets\n"+
+		"sweep_snr10_skew-1.wav,10,0,-1,2\n"+
+		"sweep_snr10_skew+0.wav,10,0,0,2\n"+
+		"sweep_snr10_skew+1.wav,10,0,1,2\n"+
+		"sweep_snr20_skew-1.wav,20,0,-1,2\n"+
+		"sweep_snr20_skew+0.wav,20,0,0,2\n"+
+		"sweep_snr20_skew+1.wav,20,0,1,2\n", string(manifest))
+
+	var info, statErr = os.Stat(filepath.Join(dir, "sweep_snr20_skew+1.wav"))
+	require.NoError(t, statErr)
+	assert.Greater(t, info.Size(), int64(10000))
+
+	// Settings are put back afterward.
+	assert.Equal(t, DEFAULT_BAUD, modem.achan[0].baud)
+	assert.False(t, g_add_noise)
+
+	modem.achan[0].modem_type = MODEM_SCRAMBLE
+	require.ErrorContains(t, gen_packets_sweep(filepath.Join(dir, "x.wav"), 50, 1, "", "0:100:50", ""), "AFSK")
+}
@@ -0,0 +1,85 @@
+package direwolf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/doismellburning/samoyed/src/channelmodel"
+	"github.com/spf13/pflag"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/* Run gen_packets in this process, e.g. to make a file for atest, then put everything back. */
+
+func genPacketsRun(t *testing.T, args ...string) {
+	t.Helper()
+
+	var oldArgs = os.Args
+
+	var restore = func() {
+		os.Args = oldArgs
+		pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+
+		GEN_PACKETS = false
+		modem = audio_s{} //nolint:exhaustruct
+		genPacketsChannel = channelmodel.New()
+		g_add_noise = false
+		g_morse_wpm = 0
+	}
+
+	restore()
+	os.Args = append([]string{"gen_packets"}, args...)
+
+	GenPacketsMain()
+
+	restore()
+}
+
+/* Number decoded by atest, with any options. */
+
+func genPacketsDecoded(t *testing.T, args ...string) int {
+	t.Helper()
+
+	atestRestore(t)
+
+	var oldArgs = os.Args
+
+	defer func() {
+		os.Args = oldArgs
+		pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+	}()
+
+	pflag.CommandLine = pflag.NewFlagSet(os.Args[0], pflag.ExitOnError)
+
+	var json_file = filepath.Join(t.TempDir(), "results.json")
+	os.Args = append([]string{"atest", "--json", json_file}, args...)
+
+	AssertOutputContains(t, AtestMain, "packets decoded")
+
+	var results, err = atest_read_json(json_file)
+	require.NoError(t, err)
+	require.Len(t, results, 1)
+
+	return results[0].Decoded
+}
+
+func Test_gen_packets_bit_error_rate(t *testing.T) {
+	var dir = t.TempDir()
+	var clean = filepath.Join(dir, "clean.wav")
+	var errors = filepath.Join(dir, "errors.wav")
+
+	genPacketsRun(t, "-N", "20", "-o", clean)
+	genPacketsRun(t, "-e", "1e-3", "-N", "20", "-o", errors)
+
+	assert.Equal(t, 20, genPacketsDecoded(t, clean))
+
+	var plain = genPacketsDecoded(t, errors)
+	var fixed = genPacketsDecoded(t, "-F1", errors)
+
+	t.Logf("With bit errors, %d decoded, %d fixing single bits", plain, fixed)
+
+	assert.Less(t, plain, 20, "some frames damaged")
+	assert.Greater(t, fixed, plain, "some of them fixable")
+}
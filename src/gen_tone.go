@@ -116,85 +116,7 @@ func gen_tone_init(audio_config_p *audio_s, amp int, gen_packets bool) int { //n
 
 	for channel := range MAX_RADIO_CHANS {
 		if audio_config_p.chan_medium[channel] == MEDIUM_RADIO {
-			var a = ACHAN2ADEV(channel)
-
-			/* TODO KG
-			#if DEBUG
-				text_color_set(DW_COLOR_DEBUG);
-				dw_printf ("gen_tone_init: channel=%d, modem_type=%d, bps=%d, samples_per_sec=%d\n",
-					channel,
-					save_audio_config_p.achan[channel].modem_type,
-					audio_config_p.achan[channel].baud,
-					audio_config_p.adev[a].samples_per_sec);
-			#endif
-			*/
-
-			tone_phase[channel] = 0
-			bit_len_acc[channel] = 0
-			lfsr[channel] = 0
-
-			ticks_per_sample[channel] = (int)((TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
-
-			// The terminology is all wrong here.  Didn't matter with 1200 and 9600.
-			// The config speed should be bits per second rather than baud.
-			// ticks_per_bit should be ticks_per_symbol.
-
-			switch save_audio_config_p.achan[channel].modem_type {
-			case MODEM_BPSK:
-				audio_config_p.achan[channel].mark_freq = 1800
-				audio_config_p.achan[channel].space_freq = audio_config_p.achan[channel].mark_freq // Not Used.
-
-				// 1 bit per symbol, so symbol time equals bit time
-				ticks_per_bit[channel] = (int)((TICKS_PER_CYCLE / float64(audio_config_p.achan[channel].baud)) + 0.5)
-				f1_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].mark_freq) * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
-				f2_change_per_sample[channel] = f1_change_per_sample[channel] // Not used.
-				samples_per_symbol[channel] = float64(audio_config_p.adev[a].samples_per_sec) / float64(audio_config_p.achan[channel].baud)
-
-				tone_phase[channel] = PHASE_SHIFT_45
-
-			case MODEM_QPSK:
-				audio_config_p.achan[channel].mark_freq = 1800
-				audio_config_p.achan[channel].space_freq = audio_config_p.achan[channel].mark_freq // Not Used.
-
-				// symbol time is 1 / (half of bps)
-				ticks_per_bit[channel] = (int)((TICKS_PER_CYCLE / (float64(audio_config_p.achan[channel].baud) * 0.5)) + 0.5)
-				f1_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].mark_freq) * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
-				f2_change_per_sample[channel] = f1_change_per_sample[channel] // Not used.
-				samples_per_symbol[channel] = 2. * float64(audio_config_p.adev[a].samples_per_sec) / float64(audio_config_p.achan[channel].baud)
-
-				tone_phase[channel] = PHASE_SHIFT_45 // Just to mimic first attempt.
-				// ??? Why?  We are only concerned with the difference
-				// from one symbol to the next.
-
-			case MODEM_8PSK:
-				audio_config_p.achan[channel].mark_freq = 1800
-				audio_config_p.achan[channel].space_freq = audio_config_p.achan[channel].mark_freq // Not Used.
-
-				// symbol time is 1 / (third of bps)
-				ticks_per_bit[channel] = (int)((TICKS_PER_CYCLE / (float64(audio_config_p.achan[channel].baud) / 3.)) + 0.5)
-				f1_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].mark_freq) * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
-				f2_change_per_sample[channel] = f1_change_per_sample[channel] // Not used.
-				samples_per_symbol[channel] = 3. * float64(audio_config_p.adev[a].samples_per_sec) / float64(audio_config_p.achan[channel].baud)
-
-			case MODEM_BASEBAND, MODEM_SCRAMBLE, MODEM_AIS:
-				// Tone is half baud.
-				ticks_per_bit[channel] = (int)((TICKS_PER_CYCLE / float64(audio_config_p.achan[channel].baud)) + 0.5)
-				f1_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].baud) * 0.5 * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
-				samples_per_symbol[channel] = float64(audio_config_p.adev[a].samples_per_sec) / float64(audio_config_p.achan[channel].baud)
-
-			case MODEM_EAS: //  EAS.
-				// TODO: Proper fix would be to use float for baud, mark, space.
-				ticks_per_bit[channel] = (int)(math.Floor((TICKS_PER_CYCLE / 520.833333333333) + 0.5))
-				samples_per_symbol[channel] = float64(audio_config_p.adev[a].samples_per_sec)/520.83333 + 0.5
-				f1_change_per_sample[channel] = (uint)((2083.33333333333 * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
-				f2_change_per_sample[channel] = (uint)((1562.5000000 * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
-
-			default: // AFSK
-				ticks_per_bit[channel] = (int)((TICKS_PER_CYCLE / float64(audio_config_p.achan[channel].baud)) + 0.5)
-				samples_per_symbol[channel] = float64(audio_config_p.adev[a].samples_per_sec) / float64(audio_config_p.achan[channel].baud)
-				f1_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].mark_freq) * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
-				f2_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].space_freq) * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
-			}
+			gen_tone_init_channel(audio_config_p, channel)
 		}
 	}
 
@@ -222,6 +144,90 @@ func gen_tone_init(audio_config_p *audio_s, amp int, gen_packets bool) int { //n
 	return (0)
 } /* end gen_tone_init */
 
+/* Part of gen_tone_init for one channel.  Also used when a channel profile changes the modem. */
+
+func gen_tone_init_channel(audio_config_p *audio_s, channel int) {
+	var a = ACHAN2ADEV(channel)
+
+	/* TODO KG
+	#if DEBUG
+		text_color_set(DW_COLOR_DEBUG);
+		dw_printf ("gen_tone_init: channel=%d, modem_type=%d, bps=%d, samples_per_sec=%d\n",
+			channel,
+			save_audio_config_p.achan[channel].modem_type,
+			audio_config_p.achan[channel].baud,
+			audio_config_p.adev[a].samples_per_sec);
+	#endif
+	*/
+
+	tone_phase[channel] = 0
+	bit_len_acc[channel] = 0
+	lfsr[channel] = 0
+
+	ticks_per_sample[channel] = (int)((TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
+
+	// The terminology is all wrong here.  Didn't matter with 1200 and 9600.
+	// The config speed should be bits per second rather than baud.
+	// ticks_per_bit should be ticks_per_symbol.
+
+	switch save_audio_config_p.achan[channel].modem_type {
+	case MODEM_BPSK:
+		audio_config_p.achan[channel].mark_freq = 1800
+		audio_config_p.achan[channel].space_freq = audio_config_p.achan[channel].mark_freq // Not Used.
+
+		// 1 bit per symbol, so symbol time equals bit time
+		ticks_per_bit[channel] = (int)((TICKS_PER_CYCLE / float64(audio_config_p.achan[channel].baud)) + 0.5)
+		f1_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].mark_freq) * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
+		f2_change_per_sample[channel] = f1_change_per_sample[channel] // Not used.
+		samples_per_symbol[channel] = float64(audio_config_p.adev[a].samples_per_sec) / float64(audio_config_p.achan[channel].baud)
+
+		tone_phase[channel] = PHASE_SHIFT_45
+
+	case MODEM_QPSK:
+		audio_config_p.achan[channel].mark_freq = 1800
+		audio_config_p.achan[channel].space_freq = audio_config_p.achan[channel].mark_freq // Not Used.
+
+		// symbol time is 1 / (half of bps)
+		ticks_per_bit[channel] = (int)((TICKS_PER_CYCLE / (float64(audio_config_p.achan[channel].baud) * 0.5)) + 0.5)
+		f1_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].mark_freq) * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
+		f2_change_per_sample[channel] = f1_change_per_sample[channel] // Not used.
+		samples_per_symbol[channel] = 2. * float64(audio_config_p.adev[a].samples_per_sec) / float64(audio_config_p.achan[channel].baud)
+
+		tone_phase[channel] = PHASE_SHIFT_45 // Just to mimic first attempt.
+		// ??? Why?  We are only concerned with the difference
+		// from one symbol to the next.
+
+	case MODEM_8PSK:
+		audio_config_p.achan[channel].mark_freq = 1800
+		audio_config_p.achan[channel].space_freq = audio_config_p.achan[channel].mark_freq // Not Used.
+
+		// symbol time is 1 / (third of bps)
+		ticks_per_bit[channel] = (int)((TICKS_PER_CYCLE / (float64(audio_config_p.achan[channel].baud) / 3.)) + 0.5)
+		f1_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].mark_freq) * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
+		f2_change_per_sample[channel] = f1_change_per_sample[channel] // Not used.
+		samples_per_symbol[channel] = 3. * float64(audio_config_p.adev[a].samples_per_sec) / float64(audio_config_p.achan[channel].baud)
+
+	case MODEM_BASEBAND, MODEM_SCRAMBLE, MODEM_AIS:
+		// Tone is half baud.
+		ticks_per_bit[channel] = (int)((TICKS_PER_CYCLE / float64(audio_config_p.achan[channel].baud)) + 0.5)
+		f1_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].baud) * 0.5 * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
+		samples_per_symbol[channel] = float64(audio_config_p.adev[a].samples_per_sec) / float64(audio_config_p.achan[channel].baud)
+
+	case MODEM_EAS: //  EAS.
+		// TODO: Proper fix would be to use float for baud, mark, space.
+		ticks_per_bit[channel] = (int)(math.Floor((TICKS_PER_CYCLE / 520.833333333333) + 0.5))
+		samples_per_symbol[channel] = float64(audio_config_p.adev[a].samples_per_sec)/520.83333 + 0.5
+		f1_change_per_sample[channel] = (uint)((2083.33333333333 * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
+		f2_change_per_sample[channel] = (uint)((1562.5000000 * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
+
+	default: // AFSK
+		ticks_per_bit[channel] = (int)((TICKS_PER_CYCLE / float64(audio_config_p.achan[channel].baud)) + 0.5)
+		samples_per_symbol[channel] = float64(audio_config_p.adev[a].samples_per_sec) / float64(audio_config_p.achan[channel].baud)
+		f1_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].mark_freq) * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
+		f2_change_per_sample[channel] = (uint)((float64(audio_config_p.achan[channel].space_freq) * TICKS_PER_CYCLE / float64(audio_config_p.adev[a].samples_per_sec)) + 0.5)
+	}
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        tone_gen_put_bit
@@ -331,6 +337,13 @@ func tone_gen_put_bit_real(channel int, dat int) {
 		dat = 0
 	}
 
+	// gen_packets -e option to introduce bit errors.
+	// No random number is used otherwise, so the noise is unchanged.
+
+	if GEN_PACKETS {
+		dat = IfThenElse(genPacketsChannel.FlipBit(dat&1 == 1), 1, 0)
+	}
+
 	// TODO: change to switch instead of if if if
 
 	if save_audio_config_p.achan[channel].modem_type == MODEM_BPSK {
@@ -0,0 +1,398 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Generate an annotated sample configuration file.
+ *
+ * Description:	Dire Wolf shipped a hand-maintained sample configuration
+ *		which drifted out of step with the code over time.
+ *		Instead we generate one from the compiled-in defaults
+ *		applied by config_set_defaults, so the values shown in
+ *		the comments are always the values actually used when
+ *		a setting is left out.  Settings with no default, or
+ *		where the default is off, show an example and say so.
+ *
+ *		Every setting is commented out so the generated file
+ *		is a valid configuration that behaves exactly like
+ *		having no configuration at all.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/pflag"
+)
+
+func GenConfMain() {
+	var outputFile = pflag.StringP("output-file", "o", "", "Write sample configuration to this file rather than stdout.")
+	var help = pflag.BoolP("help", "h", false, "Display help text.")
+
+	pflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s - generate an annotated sample configuration file.\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "Usage: genconf [options]\n")
+		pflag.PrintDefaults()
+	}
+
+	pflag.Parse()
+
+	if *help {
+		pflag.Usage()
+		os.Exit(0)
+	}
+
+	if *outputFile == "" {
+		var err = genconf(os.Stdout)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing configuration: %s\n", err)
+			os.Exit(1)
+		}
+
+		return
+	}
+
+	var f, err = os.Create(*outputFile)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Could not create %s: %s\n", *outputFile, err)
+		os.Exit(1)
+	}
+
+	err = genconf(f)
+
+	/* A full disk might not show up until the file is closed. */
+
+	var closeErr = f.Close()
+	if err == nil {
+		err = closeErr
+	}
+
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error writing %s: %s\n", *outputFile, err)
+		os.Exit(1)
+	}
+}
+
+// genconfWriter accumulates the first write error so the template below
+// doesn't need to check every line.
+type genconfWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (g *genconfWriter) printf(format string, a ...any) {
+	if g.err != nil {
+		return
+	}
+
+	_, g.err = fmt.Fprintf(g.w, format, a...)
+}
+
+func (g *genconfWriter) section(title string) {
+	g.printf("\n")
+	g.printf("#############################################################\n")
+	g.printf("#                                                           #\n")
+	g.printf("#               %-44s#\n", title)
+	g.printf("#                                                           #\n")
+	g.printf("#############################################################\n")
+	g.printf("\n")
+}
+
+// genconf writes a sample configuration file, annotated with the defaults.
+func genconf(w io.Writer) error {
+	var audio = new(audio_s)
+	var digi = new(digi_config_s)
+	var tt = new(tt_config_s)
+	var igate = new(igate_config_s)
+	var misc = new(misc_config_s)
+
+	config_set_defaults(audio, digi, tt, igate, misc)
+
+	var adev = &audio.adev[0]
+	var achan = &audio.achan[0]
+
+	var g = new(genconfWriter)
+	g.w = w
+
+	g.printf("#############################################################\n")
+	g.printf("#                                                           #\n")
+	g.printf("#        Sample configuration file for Samoyed              #\n")
+	g.printf("#                                                           #\n")
+	g.printf("#############################################################\n")
+	g.printf("#\n")
+	g.printf("# Generated by samoyed-genconf from the compiled-in defaults.\n")
+	g.printf("#\n")
+	g.printf("# Every setting below is commented out.  Where a setting has a default,\n")
+	g.printf("# the value shown is that default.  Settings with no default, such as\n")
+	g.printf("# MYCALL, PTT and PBEACON, show an example instead.\n")
+	g.printf("# Remove the leading \"#\" and change the value to override it.\n")
+	g.printf("#\n")
+	g.printf("# These are the most likely settings you might change:\n")
+	g.printf("#\n")
+	g.printf("#	(1)	MYCALL - call sign and SSID for your station.\n")
+	g.printf("#	(2)	PBEACON - enable position beaconing.\n")
+	g.printf("#	(3)	DIGIPEAT - configure digipeating rules.\n")
+	g.printf("#	(4)	IGSERVER, IGLOGIN - IGate server and login.\n")
+	g.printf("#\n")
+	g.printf("# See the Dire Wolf User Guide for full details of each option:\n")
+	g.printf("#	https://github.com/wb2osz/direwolf/tree/master/doc\n")
+
+	g.section("FIRST AUDIO DEVICE PROPERTIES")
+
+	g.printf("# Audio device for input and output.  A single name is used for both;\n")
+	g.printf("# give two names to use different devices for input and output.\n")
+	g.printf("# Examples: plughw:1,0   \"USB Audio CODEC\"   stdin   udp:7355\n")
+	g.printf("#\n")
+	g.printf("#ADEVICE %s\n", adev.adevice_in)
+	g.printf("\n")
+	g.printf("# Or use a PRESET for a common interface, which sets ADEVICE, MODEM\n")
+	g.printf("# and PTT together.  Put any changes on the lines after it.\n")
+
+	for _, name := range config_preset_names() {
+		g.printf("#	%-20s %s\n", name, configPresets[name].description)
+	}
+
+	g.printf("# There is no default preset.  For example:\n")
+	g.printf("#\n")
+	g.printf("#PRESET digirig-vhf-1200\n")
+	g.printf("\n")
+	g.printf("# Audio samples per second.\n")
+	g.printf("#\n")
+	g.printf("#ARATE %d\n", adev.samples_per_sec)
+	g.printf("\n")
+	g.printf("# Number of audio channels: 1 for mono (one radio), 2 for stereo (two radios).\n")
+	g.printf("#\n")
+	g.printf("#ACHANNELS %d\n", adev.num_channels)
+
+	g.section("CHANNEL 0 PROPERTIES")
+
+	g.printf("# Subsequent settings apply to this radio channel.  The optional\n")
+	g.printf("# NAME is shown next to the channel number in monitor output and\n")
+	g.printf("# in the port list given to AGW network protocol clients.\n")
+	g.printf("#\n")
+	g.printf("#CHANNEL 0 NAME=\"VHF 144.39\"\n")
+	g.printf("\n")
+	g.printf("# Station call sign and optional SSID.  Required for transmitting,\n")
+	g.printf("# digipeating and IGating.  There is no default.  For example:\n")
+	g.printf("#\n")
+	g.printf("#MYCALL Q1TEST-1\n")
+	g.printf("\n")
+	g.printf("# Modem speed and tones.  Common choices:\n")
+	g.printf("#	MODEM 1200	VHF/UHF AFSK, 1200/2200 Hz.\n")
+	g.printf("#	MODEM 300	HF SSB AFSK, 1600/1800 Hz.\n")
+	g.printf("#	MODEM 9600	G3RUH / K9NG scrambled baseband.\n")
+	g.printf("#\n")
+	g.printf("#MODEM %d %d:%d\n", achan.baud, achan.mark_freq, achan.space_freq)
+	g.printf("\n")
+	g.printf("# Attempt to fix frames with bad CRC.  0 (none) is strongly recommended\n")
+	g.printf("# for APRS, where a corrupted packet is worse than a lost one.\n")
+	g.printf("#\n")
+	g.printf("#FIX_BITS %d\n", int(achan.fix_bits))
+	g.printf("\n")
+	g.printf("# Push To Talk.  There is no default; transmitting requires one of:\n")
+	g.printf("#	PTT /dev/ttyUSB0 RTS		Serial port control line.\n")
+	g.printf("#	PTT GPIO 25			Linux GPIO pin, line 25 of gpiochip0.\n")
+	g.printf("#	PTT GPIOD gpiochip4 -17		Line 17 of another chip, active low.\n")
+	g.printf("#	PTT CM108			CM108/CM119 USB audio adapter GPIO.\n")
+	g.printf("#	PTT RIG 2 localhost:4532	hamlib rigctld.\n")
+	g.printf("#	PTT CAT icom /dev/ttyUSB0 19200 0x94	Radio's own CAT commands.\n")
+	g.printf("# For example:\n")
+	g.printf("#\n")
+	g.printf("#PTT CM108\n")
+	g.printf("\n")
+	g.printf("# Channel access timing.  Times are in units of 10 milliseconds.\n")
+	g.printf("#\n")
+	g.printf("#SLOTTIME %d\n", achan.slottime)
+	g.printf("#PERSIST %d\n", achan.persist)
+	g.printf("#TXDELAY %d\n", achan.txdelay)
+	g.printf("#TXTAIL %d\n", achan.txtail)
+	g.printf("\n")
+	g.printf("# Full duplex transmits without waiting for a clear channel.\n")
+	g.printf("#\n")
+	g.printf("#FULLDUP %s\n", genconfOnOff(achan.fulldup))
+	g.printf("\n")
+	g.printf("# Forward error correction for transmitted frames.  Receiving FX.25 and\n")
+	g.printf("# IL2P is always enabled, but both are off for transmit by default.\n")
+	g.printf("# For example, FX.25 with the strength picked to suit each frame, or\n")
+	g.printf("# IL2P with the larger amount of error correction:\n")
+	g.printf("#\n")
+	g.printf("#FX25TX 1\n")
+	g.printf("#IL2PTX 1\n")
+
+	g.section("DIGIPEATER PROPERTIES")
+
+	g.printf("# Typical APRS digipeater: repeat WIDE1-1 and WIDEn-N from channel 0\n")
+	g.printf("# back to channel 0, tracing the path.\n")
+	g.printf("#\n")
+	g.printf("#DIGIPEAT 0 0 ^WIDE[3-7]-[1-7]$|^TEST$ ^WIDE[12]-[12]$\n")
+	g.printf("\n")
+	g.printf("# Seconds during which an identical packet will not be digipeated again.\n")
+	g.printf("#\n")
+	g.printf("#DEDUPE %d\n", digi.dedupe_time)
+
+	g.section("INTERNET GATEWAY")
+
+	g.printf("# APRS-IS server and login.  Passcode can be found with any of the\n")
+	g.printf("# many online passcode generators.\n")
+	g.printf("#\n")
+	g.printf("#IGSERVER noam.aprs2.net:%d\n", igate.t2_server_port)
+	g.printf("#IGLOGIN Q1TEST-10 12345\n")
+	g.printf("#\n")
+	g.printf("# To keep the passcode out of this file, read it from another file\n")
+	g.printf("# or from a systemd credential (LoadCredential=igpasscode:...).\n")
+	g.printf("#\n")
+	g.printf("#IGLOGIN Q1TEST-10 file:/etc/samoyed/igpasscode\n")
+	g.printf("#IGLOGIN Q1TEST-10 credential:igpasscode\n")
+	g.printf("\n")
+	g.printf("# Transmit channel and path for packets from APRS-IS to radio.\n")
+	g.printf("# Disabled by default.\n")
+	g.printf("#\n")
+	g.printf("#IGTXVIA 0 WIDE1-1\n")
+	g.printf("\n")
+	g.printf("# Limit on packets sent to radio in 1 and 5 minutes.\n")
+	g.printf("#\n")
+	g.printf("#IGTXLIMIT %d %d\n", igate.tx_limit_1, igate.tx_limit_5)
+	g.printf("\n")
+	g.printf("# Number of position reports to send for the sender of a message\n")
+	g.printf("# passed from APRS-IS to radio.\n")
+	g.printf("#\n")
+	g.printf("#IGMSP %d\n", igate.igmsp)
+
+	g.section("BEACONING PROPERTIES")
+
+	g.printf("# Position beacon.  There are no beacons by default.\n")
+	g.printf("#\n")
+	g.printf("#PBEACON delay=1 every=30 overlay=S symbol=\"digi\" lat=42^37.14N long=071^20.83W power=50 height=20 gain=4 comment=\"Chelmsford MA\"\n")
+	g.printf("\n")
+	g.printf("# SmartBeaconing for a moving station with a GPS.\n")
+	g.printf("#	fast_speed(MPH) fast_rate(sec) slow_speed(MPH) slow_rate(sec)\n")
+	g.printf("#	turn_time(sec) turn_angle(degrees) turn_slope(degrees*MPH)\n")
+	g.printf("# Optional TURNDECAY=sec to halve the turn threshold over time, and\n")
+	g.printf("# MINTURNDIST=meters to travel before a turn sends a beacon.\n")
+	g.printf("#\n")
+	g.printf("#SMARTBEACONING %d %d %d %d %d %d %d\n",
+		misc.sb_fast_speed, misc.sb_fast_rate, misc.sb_slow_speed, misc.sb_slow_rate,
+		misc.sb_turn_time, misc.sb_turn_angle, misc.sb_turn_slope)
+	g.printf("\n")
+	g.printf("# Change tracker beacons in an area: RADIUS in km, or POLYGON=lat,long/lat,long/...\n")
+	g.printf("# ENTER and EXIT send a beacon with that comment.  COMMENT and\n")
+	g.printf("# SMARTBEACONING (same values, separated by commas) apply while inside.\n")
+	g.printf("#\n")
+	g.printf("#GEOFENCE hamfest LAT=42^37.14N LONG=071^20.83W RADIUS=2 ENTER=\"Arrived\" COMMENT=\"Talk-in 146.52\"\n")
+
+	g.section("CLIENT APPLICATION INTERFACES")
+
+	g.printf("# TCP port for the AGW network protocol.  0 to disable.\n")
+	g.printf("#\n")
+	g.printf("#AGWPORT %d\n", misc.agwpe_port)
+	g.printf("\n")
+	g.printf("# TCP port for KISS.  An optional second argument restricts the port\n")
+	g.printf("# to a single radio channel.  0 to disable.\n")
+	g.printf("#\n")
+	g.printf("#KISSPORT %d\n", misc.kiss_port[0])
+	g.printf("\n")
+	g.printf("# TCP port for HTTP, e.g. ws://localhost:8080/stream for received\n")
+	g.printf("# packets as JSON, and /healthz and /status for monitoring.\n")
+	g.printf("# Disabled by default.\n")
+	g.printf("#\n")
+	g.printf("#HTTPPORT 8080\n")
+	g.printf("\n")
+	g.printf("# Web pages served elsewhere which can open those WebSockets.\n")
+	g.printf("# Only pages from this HTTP server can by default.\n")
+	g.printf("#\n")
+	g.printf("#HTTPORIGIN https://map.example.org\n")
+	g.printf("\n")
+	g.printf("# Serial port for KISS, e.g. the end of a virtual null modem.\n")
+	g.printf("#\n")
+	g.printf("#NULLMODEM /dev/ttyS2\n")
+	g.printf("\n")
+	g.printf("# Announce the KISS TCP port with DNS Service Discovery.\n")
+	g.printf("#\n")
+	g.printf("#DNSSD %d\n", genconfBoolInt(misc.dns_sd_enabled))
+	g.printf("\n")
+	g.printf("# Unix socket for inspecting a running instance, e.g.\n")
+	g.printf("#	echo show channels | socat - UNIX-CONNECT:/run/samoyed/control.sock\n")
+	g.printf("# Disabled by default.\n")
+	g.printf("#\n")
+	g.printf("#CONTROLSOCKET /run/samoyed/control.sock\n")
+
+	g.section("GPS AND LOGGING")
+
+	g.printf("# GPS receiver, either directly attached or through gpsd.\n")
+	g.printf("#\n")
+	g.printf("#GPSNMEA /dev/ttyACM0 4800\n")
+	g.printf("#GPSD localhost %d\n", DEFAULT_GPSD_PORT)
+	g.printf("\n")
+	g.printf("# Surveyed location to use when the GPS has no fix, or hasn't sent\n")
+	g.printf("# anything for GPSTIMEOUT seconds.  Sources are tried in GPSPRIORITY order.\n")
+	g.printf("#\n")
+	g.printf("#GPSFIXED LAT=42^37.14N LONG=071^20.83W ALT=50\n")
+	g.printf("#GPSPRIORITY NMEA GPSD API FIXED\n")
+	g.printf("#GPSTIMEOUT 30\n")
+	g.printf("\n")
+	g.printf("# Accept our location from another system, with \"set position\" on the\n")
+	g.printf("# control socket or POST /position to the HTTP server.  POST needs\n")
+	g.printf("# the token, as \"Authorization: Bearer s3cret\".\n")
+	g.printf("#\n")
+	g.printf("#GPSAPI s3cret\n")
+	g.printf("\n")
+	g.printf("# Stations heard, as NMEA waypoints for OpenCPN or a chart plotter\n")
+	g.printf("# which connects to TCP port 10110.\n")
+	g.printf("#\n")
+	g.printf("#WAYPOINT TCP:10110 NA\n")
+	g.printf("\n")
+	g.printf("# Directory for daily log files of received packets.\n")
+	g.printf("#\n")
+	g.printf("#LOGDIR /var/log/samoyed\n")
+	g.printf("\n")
+	g.printf("# Limit the size and number of log files.\n")
+	g.printf("#\n")
+	g.printf("#LOGROTATE size=10M keep=30 days=90 compress\n")
+	g.printf("\n")
+	g.printf("# Alerts for an unattended station: audio input failed, IGate not\n")
+	g.printf("# connected for 10 minutes, or nothing decoded for 30 minutes.\n")
+	g.printf("# Each ALERTHOOK is a URL for an HTTP POST of JSON, or a command to run.\n")
+	g.printf("#\n")
+	g.printf("#ALERT AUDIO IGATE=10 NORX=30\n")
+	g.printf("#ALERTHOOK https://ntfy.example.com/mystation\n")
+
+	g.section("CONNECTED MODE")
+
+	g.printf("# Seconds to wait for acknowledgement, and number of retries.\n")
+	g.printf("#\n")
+	g.printf("#FRACK %d\n", misc.frack)
+	g.printf("#RETRY %d\n", misc.retry)
+	g.printf("\n")
+	g.printf("# Maximum bytes in the information part of a frame.\n")
+	g.printf("#\n")
+	g.printf("#PACLEN %d\n", misc.paclen)
+	g.printf("\n")
+	g.printf("# Window size for modulo 8 and modulo 128 sequence numbers.\n")
+	g.printf("#\n")
+	g.printf("#MAXFRAME %d\n", misc.maxframe_basic)
+	g.printf("#EMAXFRAME %d\n", misc.maxframe_extended)
+	g.printf("\n")
+	g.printf("# SABME attempts before falling back to AX.25 v2.0 SABM.\n")
+	g.printf("#\n")
+	g.printf("#MAXV22 %d\n", misc.maxv22)
+
+	return g.err
+}
+
+func genconfOnOff(b bool) string {
+	if b {
+		return "ON"
+	}
+
+	return "OFF"
+}
+
+func genconfBoolInt(b bool) int {
+	if b {
+		return 1
+	}
+
+	return 0
+}
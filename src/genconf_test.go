@@ -0,0 +1,59 @@
+package direwolf
+
+import (
+	"bytes"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_genconf_all_commented(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, genconf(&buf))
+
+	for _, line := range bytes.Split(buf.Bytes(), []byte("\n")) {
+		if len(line) > 0 {
+			assert.Equal(t, byte('#'), line[0], "uncommented line %q", line)
+		}
+	}
+}
+
+func Test_genconf_uncommented_matches_defaults(t *testing.T) {
+	var buf bytes.Buffer
+
+	require.NoError(t, genconf(&buf))
+
+	// Uncomment the settings themselves, leaving the prose and anything
+	// that would touch real hardware or need a callsign.
+	var setting = regexp.MustCompile(`(?m)^#((ARATE|ACHANNELS|MODEM|FIX_BITS|SLOTTIME|PERSIST|TXDELAY|TXTAIL|FULLDUP|DEDUPE|AGWPORT|KISSPORT|FRACK|RETRY|PACLEN|MAXFRAME|EMAXFRAME|MAXV22) )`)
+	var conf = setting.ReplaceAllString(buf.String(), "$1")
+
+	var audio, misc = configFromString(t, conf)
+
+	var defaultAudio = new(audio_s)
+	var defaultMisc = new(misc_config_s)
+	config_set_defaults(defaultAudio, new(digi_config_s), new(tt_config_s), new(igate_config_s), defaultMisc)
+
+	assert.Equal(t, defaultAudio.adev[0].samples_per_sec, audio.adev[0].samples_per_sec)
+	assert.Equal(t, defaultAudio.adev[0].num_channels, audio.adev[0].num_channels)
+	assert.Equal(t, defaultAudio.achan[0].baud, audio.achan[0].baud)
+	assert.Equal(t, defaultAudio.achan[0].mark_freq, audio.achan[0].mark_freq)
+	assert.Equal(t, defaultAudio.achan[0].space_freq, audio.achan[0].space_freq)
+	assert.Equal(t, defaultAudio.achan[0].fix_bits, audio.achan[0].fix_bits)
+	assert.Equal(t, defaultAudio.achan[0].slottime, audio.achan[0].slottime)
+	assert.Equal(t, defaultAudio.achan[0].persist, audio.achan[0].persist)
+	assert.Equal(t, defaultAudio.achan[0].txdelay, audio.achan[0].txdelay)
+	assert.Equal(t, defaultAudio.achan[0].txtail, audio.achan[0].txtail)
+	assert.Equal(t, defaultAudio.achan[0].fulldup, audio.achan[0].fulldup)
+	assert.Equal(t, defaultMisc.agwpe_port, misc.agwpe_port)
+	assert.Equal(t, defaultMisc.kiss_port[0], misc.kiss_port[0])
+	assert.Equal(t, defaultMisc.frack, misc.frack)
+	assert.Equal(t, defaultMisc.retry, misc.retry)
+	assert.Equal(t, defaultMisc.paclen, misc.paclen)
+	assert.Equal(t, defaultMisc.maxframe_basic, misc.maxframe_basic)
+	assert.Equal(t, defaultMisc.maxframe_extended, misc.maxframe_extended)
+	assert.Equal(t, defaultMisc.maxv22, misc.maxv22)
+}
@@ -0,0 +1,132 @@
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Geodesy shared by APRS decoding, APRStt, beacons,
+ *		waypoints, and the coordinate conversion tools.
+ *
+ * Description:	Latitude and longitude are always in degrees, negative
+ *		for south and west.  Distances are in km.
+ *
+ *		geo.go		Distance, bearing, and destination on a
+ *				sphere, which is close enough for radio.
+ *
+ *		maidenhead.go	Maidenhead locators ("grid squares").
+ *
+ *		utm.go		UTM, MGRS, and USNG, using coordconv for
+ *				the real work.
+ *
+ *		This used to be spread between latlong.go, coordconv.go,
+ *		aprs_tt.go, and the ll2utm and utm2ll tools, each with
+ *		its own degrees to radians conversion.
+ *
+ *------------------------------------------------------------------*/
+
+// Package geo converts between coordinate systems and calculates distance
+// and bearing, for APRS and the coordinate conversion tools.
+package geo
+
+import (
+	"math"
+)
+
+// EarthRadiusKM is the mean radius of the earth.
+const EarthRadiusKM = 6371
+
+// D2R converts degrees to radians.
+func D2R(d float64) float64 {
+	return d * math.Pi / 180
+}
+
+// R2D converts radians to degrees.
+func R2D(r float64) float64 {
+	return r * 180 / math.Pi
+}
+
+/*------------------------------------------------------------------
+ *
+ * Function:	DistanceKM
+ *
+ * Purpose:	Calculate distance between two locations.
+ *
+ * Inputs:	lat1, lon1	- One location, in degrees.
+ *		lat2, lon2	- other location
+ *
+ * Returns:	Distance in km.
+ *
+ * Description:	The Ubiquitous Haversine formula.
+ *
+ *------------------------------------------------------------------*/
+
+// DistanceKM is the great circle distance between two locations.
+func DistanceKM(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1 = D2R(lat1)
+	lon1 = D2R(lon1)
+	lat2 = D2R(lat2)
+	lon2 = D2R(lon2)
+
+	var a = math.Pow(math.Sin((lat2-lat1)/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin((lon2-lon1)/2), 2)
+
+	return (EarthRadiusKM * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a)))
+}
+
+/*------------------------------------------------------------------
+ *
+ * Function:	BearingDeg
+ *
+ * Purpose:	Calculate bearing between two locations.
+ *
+ * Inputs:	lat1, lon1	- starting location, in degrees.
+ *		lat2, lon2	- destination location
+ *
+ * Returns:	Initial Bearing, in degrees.
+ *		The calculation produces Range +- 180 degrees.
+ *		But I think that 0 - 360 would be more customary?
+ *
+ *------------------------------------------------------------------*/
+
+// BearingDeg is the initial bearing from one location to another, 0 to 360 degrees.
+func BearingDeg(lat1, lon1, lat2, lon2 float64) float64 {
+	lat1 = D2R(lat1)
+	lon1 = D2R(lon1)
+	lat2 = D2R(lat2)
+	lon2 = D2R(lon2)
+
+	var b = R2D(math.Atan2(math.Sin(lon2-lon1)*math.Cos(lat2),
+		math.Cos(lat1)*math.Sin(lat2)-math.Sin(lat1)*math.Cos(lat2)*math.Cos(lon2-lon1)))
+
+	if b < 0 {
+		b += 360
+	}
+
+	return (b)
+}
+
+/*------------------------------------------------------------------
+ *
+ * Function:	Destination
+ *
+ * Purpose:	Calculate the destination location given a starting point,
+ *		distance, and bearing,
+ *
+ * Inputs:	lat1, lon1	- starting location, in degrees.
+ *		dist		- distance in km.
+ *		bearing		- direction in degrees.  Shouldn't matter
+ *				  if it is in +- 180 or 0 to 360 range.
+ *
+ * Returns:	New latitude and longitude.
+ *
+ * Reference:	http://movable-type.co.uk/scripts/latlong.html
+ *
+ *------------------------------------------------------------------*/
+
+// Destination is where you get to going dist km from a location at a bearing.
+func Destination(lat1, lon1, dist, bearing float64) (float64, float64) {
+	lat1 = D2R(lat1) // Everything to radians.
+	lon1 = D2R(lon1)
+	bearing = D2R(bearing)
+
+	var lat2 = math.Asin(math.Sin(lat1)*math.Cos(dist/EarthRadiusKM) + math.Cos(lat1)*math.Sin(dist/EarthRadiusKM)*math.Cos(bearing))
+
+	var lon2 = lon1 + math.Atan2(math.Sin(bearing)*math.Sin(dist/EarthRadiusKM)*math.Cos(lat1), math.Cos(dist/EarthRadiusKM)-math.Sin(lat1)*math.Sin(lat2))
+
+	return R2D(lat2), R2D(lon2) // Back to degrees.
+}
@@ -0,0 +1,270 @@
+package geo
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// TestCoordinateDistanceSymmetry tests that distance is symmetric
+func TestCoordinateDistanceSymmetry(t *testing.T) {
+	tests := []struct {
+		name string
+		lat1 float64
+		lon1 float64
+		lat2 float64
+		lon2 float64
+	}{
+		{"Boston to Sydney", 42.3601, -71.0589, -33.8688, 151.2093},
+		{"Equator points", 0.0, 0.0, 0.0, 90.0},
+		{"Same latitude", 45.0, 45.0, 45.0, 135.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d1 := DistanceKM(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			d2 := DistanceKM(tt.lat2, tt.lon2, tt.lat1, tt.lon1)
+
+			assert.InDelta(t, d1, d2, 0.001, "distance should be symmetric")
+		})
+	}
+}
+
+// TestBearingAntipodal tests bearing to antipodal points
+func TestBearingAntipodal(t *testing.T) {
+	// Bearing from a point to its antipode (opposite side of Earth)
+	// should be consistent
+	lat := 45.0
+	lon := 45.0
+
+	antiLat := -lat
+
+	antiLon := lon + 180.0
+	if antiLon > 180.0 {
+		antiLon -= 360.0
+	}
+
+	bearing := BearingDeg(lat, lon, antiLat, antiLon)
+
+	// Bearing to antipode could be any direction (ambiguous), but should be valid
+	assert.GreaterOrEqual(t, bearing, 0.0, "bearing should be >= 0")
+	assert.Less(t, bearing, 360.0, "bearing should be < 360")
+}
+
+// TestDestinationZeroDistance tests that zero distance returns same point
+func TestDestinationZeroDistance(t *testing.T) {
+	lat := 42.3601
+	lon := -71.0589
+	dist := 0.0
+	bearing := 90.0 // arbitrary
+
+	newLat, newLon := Destination(lat, lon, dist, bearing)
+
+	assert.InDelta(t, lat, newLat, 0.0001, "zero distance should return same latitude")
+	assert.InDelta(t, lon, newLon, 0.0001, "zero distance should return same longitude")
+}
+
+// BenchmarkDistanceBearing benchmarks distance and bearing calculations
+func BenchmarkDistanceBearing(b *testing.B) {
+	lat1, lon1 := 42.3601, -71.0589
+	lat2, lon2 := -33.8688, 151.2093
+
+	b.Run("distance", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = DistanceKM(lat1, lon1, lat2, lon2)
+		}
+	})
+
+	b.Run("bearing", func(b *testing.B) {
+		for i := 0; i < b.N; i++ {
+			_ = BearingDeg(lat1, lon1, lat2, lon2)
+		}
+	})
+
+	b.Run("destination", func(b *testing.B) {
+		dist := 1000.0
+
+		bearing := 45.0
+		for i := 0; i < b.N; i++ {
+			_, _ = Destination(lat1, lon1, dist, bearing)
+		}
+	})
+}
+
+// TestHaversineFormulaAccuracy tests the accuracy of the haversine implementation
+func TestHaversineFormulaAccuracy(t *testing.T) {
+	// Test against known distances
+	tests := []struct {
+		name     string
+		lat1     float64
+		lon1     float64
+		lat2     float64
+		lon2     float64
+		expected float64 // in km
+		delta    float64 // tolerance
+	}{
+		{
+			name:     "London to Paris",
+			lat1:     51.5074,
+			lon1:     -0.1278,
+			lat2:     48.8566,
+			lon2:     2.3522,
+			expected: 344.0,
+			delta:    5.0,
+		},
+		{
+			name:     "New York to Los Angeles",
+			lat1:     40.7128,
+			lon1:     -74.0060,
+			lat2:     34.0522,
+			lon2:     -118.2437,
+			expected: 3936.0,
+			delta:    10.0,
+		},
+		{
+			name:     "Singapore to Tokyo",
+			lat1:     1.3521,
+			lon1:     103.8198,
+			lat2:     35.6762,
+			lon2:     139.6503,
+			expected: 5312.0,
+			delta:    10.0,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			distance := DistanceKM(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			assert.InDelta(t, tt.expected, distance, tt.delta,
+				"distance should match known value within tolerance")
+		})
+	}
+}
+
+// TestBearingCalculation tests bearing calculation accuracy
+func TestBearingCalculation(t *testing.T) {
+	tests := []struct {
+		name     string
+		lat1     float64
+		lon1     float64
+		lat2     float64
+		lon2     float64
+		expected float64 // degrees
+		delta    float64
+	}{
+		{
+			name:     "due north",
+			lat1:     0.0,
+			lon1:     0.0,
+			lat2:     1.0,
+			lon2:     0.0,
+			expected: 0.0,
+			delta:    0.1,
+		},
+		{
+			name:     "due east",
+			lat1:     0.0,
+			lon1:     0.0,
+			lat2:     0.0,
+			lon2:     1.0,
+			expected: 90.0,
+			delta:    0.1,
+		},
+		{
+			name:     "due south",
+			lat1:     1.0,
+			lon1:     0.0,
+			lat2:     0.0,
+			lon2:     0.0,
+			expected: 180.0,
+			delta:    0.1,
+		},
+		{
+			name:     "due west",
+			lat1:     0.0,
+			lon1:     1.0,
+			lat2:     0.0,
+			lon2:     0.0,
+			expected: 270.0,
+			delta:    0.1,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bearing := BearingDeg(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
+			assert.InDelta(t, tt.expected, bearing, tt.delta,
+				"bearing should match expected cardinal direction")
+		})
+	}
+}
+
+// TestSmallAngles tests coordinate functions with very small angles
+func TestSmallAngles(t *testing.T) {
+	// Test with very small differences in coordinates
+	lat1, lon1 := 42.0, -71.0
+	lat2, lon2 := 42.0001, -71.0001
+
+	dist := DistanceKM(lat1, lon1, lat2, lon2)
+	bearing := BearingDeg(lat1, lon1, lat2, lon2)
+
+	assert.Greater(t, dist, 0.0, "distance should be positive")
+	assert.Less(t, dist, 1.0, "distance should be very small")
+	assert.GreaterOrEqual(t, bearing, 0.0, "bearing should be valid")
+	assert.Less(t, bearing, 360.0, "bearing should be valid")
+
+	// Test round trip
+	newLat, newLon := Destination(lat1, lon1, dist, bearing)
+
+	assert.InDelta(t, lat2, newLat, 0.0001, "round trip latitude should match")
+	assert.InDelta(t, lon2, newLon, 0.0001, "round trip longitude should match")
+}
+
+// TestNaN tests that NaN inputs don't cause panics
+func TestNaN(t *testing.T) {
+	nan := math.NaN()
+
+	// These shouldn't panic
+	_ = DistanceKM(nan, 0, 0, 0)
+	_ = BearingDeg(nan, 0, 0, 0)
+	_, _ = Destination(nan, 0, 0, 0)
+}
+
+func Test_distance_bearing(t *testing.T) {
+	// http://www.movable-type.co.uk/scripts/latlong.html
+
+	assert.InDelta(t, 7872, DistanceKM(35., 45., 35., 135.), 10)
+	assert.InDelta(t, 60, BearingDeg(35., 45., 35., 135.), 0.3)
+
+	// Sydney to Kinsale.  https://woodshole.er.usgs.gov/staffpages/cpolloni/manitou/ccal.htm
+
+	assert.InDelta(t, 17445, DistanceKM(-33.8688, 151.2093, 51.7059, -8.5222), 10)
+	assert.InDelta(t, 327, BearingDeg(-33.8688, 151.2093, 51.7059, -8.5222), 1)
+
+	/*
+	 * Start at some location1 (lat1,lon1) and go some distance (d1) at some bearing (b1).
+	 * This results in a new location2 (lat2, lon2).
+	 * We then calculate the distance and bearing from location1 to location2 and compare with the intention.
+	 */
+	var d1 = 10.
+
+	for lat1 := -60.; lat1 <= 60; lat1 += 30 {
+		for lon1 := -180.; lon1 <= 180; lon1 += 30 {
+			for b1 := 0.; b1 < 360; b1 += 15 {
+				var lat2, lon2 = Destination(lat1, lon1, d1, b1)
+
+				var d2 = DistanceKM(lat1, lon1, lat2, lon2)
+
+				var b2 = BearingDeg(lat1, lon1, lat2, lon2)
+				if b2 > 359.9 && b2 < 360.1 {
+					b2 = 0
+				}
+
+				// must be within 0.1% of distance and 0.1 degree.
+				assert.InEpsilon(t, d1, d2, 0.001, "lat1=%.5f, lon1=%.5f, b1=%.1f", lat1, lon1, b1)
+				assert.InDelta(t, b1, b2, 0.1, "lat1=%.5f, lon1=%.5f, b1=%.1f", lat1, lon1, b1)
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package geo
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+/*------------------------------------------------------------------
+ *
+ * Function:	FromGridSquare
+ *
+ * Purpose:	Convert Maidenhead locator to latitude and longitude.
+ *
+ * Inputs:	maidenhead	- 2, 4, 6, 8, 10, or 12 character grid square locator.
+ *
+ * Returns:	Latitude and longitude of the center of the square.
+ *
+ * Reference:	A good converter for spot checking.  Only handles 4 or 6 characters :-(
+ *		http://home.arcor.de/waldemar.kebsch/The_Makrothen_Contest/fmaidenhead.html
+ *
+ * Rambling:	What sort of resolution does this provide?
+ *		For 8 character form, each latitude unit is 0.25 minute.
+ *		(Longitude can be up to twice that around the equator.)
+ *		6371 km * 2 * pi * 0.25 / 60 / 360 = 0.463 km.  Is that right?
+ *
+ *		Using this calculator, http://www.earthpoint.us/Convert.aspx
+ *		It gives lower left corner of square rather than the middle.  :-(
+ *
+ *		FN42MA00  -->  19T 334361mE 4651711mN
+ *		FN42MA11  -->  19T 335062mE 4652157mN
+ *				   ------   -------
+ *				      701       446    meters difference.
+ *
+ *		With another two pairs, we are down around 2 meters for latitude.
+ *
+ *------------------------------------------------------------------*/
+
+const MH_MIN_PAIR = 1
+const MH_MAX_PAIR = 6
+const MH_UNITS = (18 * 10 * 24 * 10 * 24 * 10 * 2)
+
+type mhPair struct {
+	position string
+	min_ch   byte
+	max_ch   byte
+	value    int
+}
+
+func mhPairs() []*mhPair {
+	return []*mhPair{
+		{"first", 'A', 'R', 10 * 24 * 10 * 24 * 10 * 2},
+		{"second", '0', '9', 24 * 10 * 24 * 10 * 2},
+		{"third", 'A', 'X', 10 * 24 * 10 * 2},
+		{"fourth", '0', '9', 24 * 10 * 2},
+		{"fifth", 'A', 'X', 10 * 2},
+		{"sixth", '0', '9', 2},
+	} // Even so we can get center of square.
+}
+
+// FromGridSquare converts a Maidenhead locator to the latitude and longitude of its center.
+func FromGridSquare(maidenhead string) (float64, float64, error) {
+	var np = len(maidenhead) / 2 /* Number of pairs of characters. */
+
+	if len(maidenhead)%2 != 0 || np < MH_MIN_PAIR || np > MH_MAX_PAIR {
+		return 0, 0, fmt.Errorf("maidenhead locator \"%s\" must from 1 to %d pairs of characters", maidenhead, MH_MAX_PAIR)
+	}
+
+	var mh = strings.ToUpper(maidenhead)
+
+	var pairs = mhPairs()
+
+	var ilat, ilon int
+
+	for n := range np {
+		if mh[2*n] < pairs[n].min_ch || mh[2*n] > pairs[n].max_ch ||
+			mh[2*n+1] < pairs[n].min_ch || mh[2*n+1] > pairs[n].max_ch {
+			return 0, 0, fmt.Errorf("the %s pair of characters in Maidenhead locator \"%s\" must be in range of %c thru %c",
+				pairs[n].position, maidenhead, pairs[n].min_ch, pairs[n].max_ch)
+		}
+
+		ilon += int(mh[2*n]-pairs[n].min_ch) * pairs[n].value
+		ilat += int(mh[2*n+1]-pairs[n].min_ch) * pairs[n].value
+
+		if n == np-1 { // If last pair, take center of square.
+			ilon += pairs[n].value / 2
+			ilat += pairs[n].value / 2
+		}
+	}
+
+	var (
+		dlat = float64(ilat)/MH_UNITS*180. - 90.
+		dlon = float64(ilon)/MH_UNITS*360. - 180.
+	)
+
+	return dlat, dlon, nil
+}
+
+/*------------------------------------------------------------------
+ *
+ * Function:	ToGridSquare
+ *
+ * Purpose:	Convert latitude and longitude to Maidenhead locator.
+ *
+ * Inputs:	dlat, dlon	- Latitude and longitude.
+ *
+ *		chars		- 2, 4, 6, 8, 10, or 12 characters wanted.
+ *
+ * Returns:	Locator of the square containing the point, in upper case.
+ *
+ * Description:	The inverse of FromGridSquare, using the same units
+ *		so converting back gives the center of the square.
+ *		The north pole and 180 degrees east are put in the last
+ *		square rather than one beyond the end.
+ *
+ *------------------------------------------------------------------*/
+
+// ToGridSquare converts latitude and longitude to a Maidenhead locator of 2 to 12 characters.
+func ToGridSquare(dlat float64, dlon float64, chars int) (string, error) {
+	var np = chars / 2
+
+	if chars%2 != 0 || np < MH_MIN_PAIR || np > MH_MAX_PAIR {
+		return "", fmt.Errorf("maidenhead locator must be from 1 to %d pairs of characters, not %d characters", MH_MAX_PAIR, chars)
+	}
+
+	if dlat < -90 || dlat > 90 || math.IsNaN(dlat) {
+		return "", fmt.Errorf("latitude %g is not in range of -90 to 90", dlat)
+	}
+
+	if dlon < -180 || dlon > 180 || math.IsNaN(dlon) {
+		return "", fmt.Errorf("longitude %g is not in range of -180 to 180", dlon)
+	}
+
+	var ilat = min(int(math.Floor((dlat+90.)/180.*MH_UNITS)), MH_UNITS-1)
+	var ilon = min(int(math.Floor((dlon+180.)/360.*MH_UNITS)), MH_UNITS-1)
+
+	var pairs = mhPairs()
+
+	var mh = make([]byte, 0, chars)
+
+	for n := range np {
+		mh = append(mh, pairs[n].min_ch+byte(ilon/pairs[n].value), pairs[n].min_ch+byte(ilat/pairs[n].value))
+		ilon %= pairs[n].value
+		ilat %= pairs[n].value
+	}
+
+	return string(mh), nil
+}
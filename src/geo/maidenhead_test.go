@@ -0,0 +1,154 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestGridSquareEdgeCases tests Maidenhead grid square conversion edge cases
+func TestGridSquareEdgeCases(t *testing.T) {
+	tests := []struct {
+		name      string
+		grid      string
+		expectErr bool
+		minLat    float64
+		maxLat    float64
+		minLon    float64
+		maxLon    float64
+	}{
+		{
+			name:      "2 character grid",
+			grid:      "BL",
+			expectErr: false,
+			minLat:    15.0,
+			maxLat:    35.0,
+			minLon:    -160.0,
+			maxLon:    -140.0,
+		},
+		{
+			name:      "4 character grid",
+			grid:      "BL11",
+			expectErr: false,
+			minLat:    20.49,
+			maxLat:    21.51,
+			minLon:    -157.01,
+			maxLon:    -156.99,
+		},
+		{
+			name:      "6 character grid",
+			grid:      "BL11BH",
+			expectErr: false,
+			minLat:    21.31,
+			maxLat:    21.32,
+			minLon:    -157.88,
+			maxLon:    -157.87,
+		},
+		{
+			name:      "lowercase should work",
+			grid:      "bl11bh",
+			expectErr: false,
+			minLat:    21.31,
+			maxLat:    21.32,
+			minLon:    -157.88,
+			maxLon:    -157.87,
+		},
+		{ //nolint: exhaustruct
+			name:      "odd number of characters fails",
+			grid:      "BL1",
+			expectErr: true,
+		},
+		{ //nolint: exhaustruct
+			name:      "empty string fails",
+			grid:      "",
+			expectErr: true,
+		},
+		{ //nolint: exhaustruct
+			name:      "too many pairs fails",
+			grid:      "BL11BH16OO66XX",
+			expectErr: true,
+		},
+		{ //nolint: exhaustruct
+			name:      "invalid first character",
+			grid:      "ZZ11",
+			expectErr: true,
+		},
+		{ //nolint: exhaustruct
+			name:      "invalid second pair character",
+			grid:      "BLA1",
+			expectErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lat, lon, err := FromGridSquare(tt.grid)
+
+			if tt.expectErr {
+				assert.Error(t, err, "should return error for invalid input")
+			} else {
+				require.NoError(t, err, "should not return error for valid input")
+				assert.GreaterOrEqual(t, lat, tt.minLat, "latitude should be >= min")
+				assert.LessOrEqual(t, lat, tt.maxLat, "latitude should be <= max")
+				assert.GreaterOrEqual(t, lon, tt.minLon, "longitude should be >= min")
+				assert.LessOrEqual(t, lon, tt.maxLon, "longitude should be <= max")
+			}
+		})
+	}
+}
+
+func Test_ToGridSquare(t *testing.T) {
+	var mh, err = ToGridSquare(42.662139, -71.365553, 10)
+	require.NoError(t, err)
+	assert.Equal(t, "FN42HP68DV", mh)
+
+	for _, chars := range []int{2, 4, 6, 8, 10, 12} {
+		mh, err = ToGridSquare(42.662139, -71.365553, chars)
+		require.NoError(t, err)
+		assert.Len(t, mh, chars)
+		assert.Equal(t, "FN42HP68DV"[:min(chars, 10)], mh[:min(chars, 10)])
+
+		// Back again gives the center of the square, which contains the original point.
+		var lat, lon, backErr = FromGridSquare(mh)
+		require.NoError(t, backErr)
+
+		var again, _ = ToGridSquare(lat, lon, chars)
+		assert.Equal(t, mh, again)
+	}
+
+	mh, err = ToGridSquare(-90, -180, 6)
+	require.NoError(t, err)
+	assert.Equal(t, "AA00AA", mh)
+
+	mh, err = ToGridSquare(90, 180, 6)
+	require.NoError(t, err)
+	assert.Equal(t, "RR99XX", mh)
+
+	_, err = ToGridSquare(91, 0, 6)
+	require.Error(t, err)
+
+	_, err = ToGridSquare(0, -181, 6)
+	require.Error(t, err)
+
+	_, err = ToGridSquare(0, 0, 5)
+	require.Error(t, err)
+
+	_, err = ToGridSquare(0, 0, 14)
+	require.Error(t, err)
+}
+
+func Test_FromGridSquare(t *testing.T) {
+	var dlat, dlon, err = FromGridSquare("BL11")
+	require.NoError(t, err)
+	assert.InDelta(t, 21.0, dlat, 0.5000001)
+	assert.InDelta(t, -157.0, dlon, 0.0000001)
+
+	dlat, dlon, err = FromGridSquare("BL11BH")
+	require.NoError(t, err)
+	assert.InDelta(t, 21.3125, dlat, 0.00001)
+	assert.InDelta(t, -157.875, dlon, 0.00001)
+
+	_, _, err = FromGridSquare("BLA1")
+	require.EqualError(t, err, "the second pair of characters in Maidenhead locator \"BLA1\" must be in range of 0 thru 9")
+}
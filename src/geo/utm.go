@@ -0,0 +1,139 @@
+package geo
+
+// UTM, MGRS, and USNG, as thin wrappers around https://github.com/tzneal/coordconv
+// taking and returning degrees rather than s2.LatLng.
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+
+	"github.com/golang/geo/s1"
+	"github.com/golang/geo/s2"
+	"github.com/tzneal/coordconv"
+)
+
+// HemisphereFromRune converts 'N' or 'S' to a coordconv hemisphere.
+func HemisphereFromRune(hemi rune) coordconv.Hemisphere {
+	switch hemi {
+	case 'N':
+		return coordconv.HemisphereNorth
+	case 'S':
+		return coordconv.HemisphereSouth
+	default:
+		return coordconv.HemisphereInvalid
+	}
+}
+
+// HemisphereToRune converts a coordconv hemisphere to 'N' or 'S'.
+func HemisphereToRune(h coordconv.Hemisphere) rune {
+	switch h {
+	case coordconv.HemisphereNorth:
+		return 'N'
+	case coordconv.HemisphereSouth:
+		return 'S'
+	case coordconv.HemisphereInvalid:
+		return '!'
+	default:
+		return '?'
+	}
+}
+
+func latLng(lat, lon float64) s2.LatLng {
+	return s2.LatLng{Lat: s1.Angle(D2R(lat)), Lng: s1.Angle(D2R(lon))}
+}
+
+func degrees(ll s2.LatLng) (float64, float64) {
+	return R2D(float64(ll.Lat)), R2D(float64(ll.Lng))
+}
+
+// ToUTM converts latitude and longitude to UTM.
+func ToUTM(lat, lon float64) (coordconv.UTMCoord, error) {
+	return coordconv.DefaultUTMConverter.ConvertFromGeodetic(latLng(lat, lon), 0)
+}
+
+// FromUTM converts UTM to latitude and longitude.
+func FromUTM(zone int, hemisphere coordconv.Hemisphere, easting, northing float64) (float64, float64, error) {
+	var ll, err = coordconv.DefaultUTMConverter.ConvertToGeodetic(coordconv.UTMCoord{
+		Zone:       zone,
+		Hemisphere: hemisphere,
+		Easting:    easting,
+		Northing:   northing,
+	})
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var lat, lon = degrees(ll)
+
+	return lat, lon, nil
+}
+
+// ToMGRS converts latitude and longitude to MGRS, with precision 0 to 5
+// digits for each of easting and northing.
+func ToMGRS(lat, lon float64, precision int) (string, error) {
+	var mgrs, err = coordconv.DefaultMGRSConverter.ConvertFromGeodetic(latLng(lat, lon), precision)
+
+	return string(mgrs), err
+}
+
+// FromMGRS converts an MGRS location, e.g. "19TCH06132600", to latitude and longitude.
+func FromMGRS(mgrs string) (float64, float64, error) {
+	var ll, err = coordconv.DefaultMGRSConverter.ConvertToGeodetic(mgrs)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var lat, lon = degrees(ll)
+
+	return lat, lon, nil
+}
+
+/*
+ * USNG (U.S. National Grid) uses the same grid as MGRS, on the NAD83
+ * datum which is close enough to WGS84 to make no difference here.
+ * It is normally written with spaces between the parts, e.g.
+ * "19T CH 0613 2600", and lower case is allowed.
+ */
+
+// FromUSNG converts a USNG location, with or without spaces, to latitude and longitude.
+func FromUSNG(usng string) (float64, float64, error) {
+	var compact = strings.ToUpper(strings.Join(strings.Fields(usng), ""))
+	if compact == "" {
+		return 0, 0, errors.New("empty USNG location")
+	}
+
+	return FromMGRS(compact)
+}
+
+// ToUSNG converts latitude and longitude to USNG, with precision 0 to 5
+// digits for each of easting and northing, in the usual form with spaces.
+func ToUSNG(lat, lon float64, precision int) (string, error) {
+	var mgrs, err = ToMGRS(lat, lon, precision)
+	if err != nil {
+		return "", err
+	}
+
+	return usngSpaces(mgrs), nil
+}
+
+/*
+ * Split up an MGRS string such as "19TCH06132600" into "19T CH 0613 2600".
+ * Polar (UPS) areas have no zone number, e.g. "ZAH1234" becomes "Z AH 12 34".
+ */
+
+func usngSpaces(mgrs string) string {
+	var band = strings.IndexFunc(mgrs, unicode.IsLetter)
+	if band < 0 || len(mgrs) < band+3 {
+		return mgrs
+	}
+
+	var result = mgrs[:band+1] + " " + mgrs[band+1:band+3]
+
+	var digits = mgrs[band+3:]
+	if digits != "" {
+		result += " " + digits[:len(digits)/2] + " " + digits[len(digits)/2:]
+	}
+
+	return result
+}
@@ -0,0 +1,66 @@
+package geo
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/tzneal/coordconv"
+)
+
+func Test_usngSpaces(t *testing.T) {
+	assert.Equal(t, "19T CH 0613 2600", usngSpaces("19TCH06132600"))
+	assert.Equal(t, "19T CH 0 2", usngSpaces("19TCH02"))
+	assert.Equal(t, "19T CH", usngSpaces("19TCH"))
+	assert.Equal(t, "4Q FJ 123 678", usngSpaces("4QFJ123678"))
+	assert.Equal(t, "Z AH 12 34", usngSpaces("ZAH1234"))
+}
+
+func Test_USNG(t *testing.T) {
+	var usng, err = ToUSNG(42.662139, -71.365553, 4)
+	require.NoError(t, err)
+	assert.Equal(t, "19T CH 0613 2600", usng)
+
+	for _, s := range []string{"19T CH 0613 2600", "19tch06132600", " 19T  CH 06132600 "} {
+		var lat, lon, err = FromUSNG(s)
+		require.NoError(t, err, s)
+		assert.InDelta(t, 42.662049, lat, 0.000001, s)
+		assert.InDelta(t, -71.365550, lon, 0.000001, s)
+	}
+
+	_, _, err = FromUSNG("")
+	require.Error(t, err)
+
+	_, _, err = FromUSNG("19T XX 0613 2600")
+	require.Error(t, err)
+}
+
+func Test_UTM(t *testing.T) {
+	var utm, err = ToUTM(42.662139, -71.365553)
+	require.NoError(t, err)
+	assert.Equal(t, 19, utm.Zone)
+	assert.Equal(t, 'N', HemisphereToRune(utm.Hemisphere))
+	assert.InDelta(t, 306130, utm.Easting, 1)
+	assert.InDelta(t, 4726010, utm.Northing, 1)
+
+	lat, lon, err := FromUTM(utm.Zone, HemisphereFromRune('N'), utm.Easting, utm.Northing)
+	require.NoError(t, err)
+	assert.InDelta(t, 42.662139, lat, 0.000001)
+	assert.InDelta(t, -71.365553, lon, 0.000001)
+
+	assert.Equal(t, coordconv.HemisphereInvalid, HemisphereFromRune('X'))
+}
+
+func Test_MGRS(t *testing.T) {
+	var mgrs, err = ToMGRS(42.662139, -71.365553, 5)
+	require.NoError(t, err)
+	assert.Equal(t, "19TCH0613026009", mgrs)
+
+	lat, lon, err := FromMGRS("19TCH0613026009")
+	require.NoError(t, err)
+	assert.InDelta(t, 42.662139, lat, 0.00001)
+	assert.InDelta(t, -71.365553, lon, 0.00001)
+
+	_, _, err = FromMGRS("nonsense")
+	require.Error(t, err)
+}
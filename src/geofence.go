@@ -0,0 +1,191 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Change tracker beacons in certain areas.
+ *
+ * Description:	Configured with
+ *
+ *			GEOFENCE hamfest LAT=42^37.14N LONG=071^20.83W RADIUS=2 ENTER="Arrived at the hamfest" COMMENT="Talk-in 146.52"
+ *			GEOFENCE city POLYGON=42.40,-71.10/42.40,-71.00/42.30,-71.00/42.30,-71.10 SMARTBEACONING=30,60,3,600,10,20,255
+ *
+ *		An area is a circle, RADIUS in km, or a polygon of
+ *		latitude,longitude corners, separated by /.
+ *
+ *		ENTER	- Send a tracker beacon right away, with this
+ *			  comment, on entering the area.
+ *		EXIT	- Same, on leaving it.
+ *		COMMENT	- Comment for tracker beacons while inside.
+ *		SMARTBEACONING - Different SmartBeaconing parameters while
+ *			  inside.  Same values as the SMARTBEACONING
+ *			  configuration item, separated by commas.
+ *
+ *		If areas overlap, the first one in the configuration file
+ *		wins for COMMENT and SMARTBEACONING.
+ *
+ *		Starting up inside an area doesn't count as entering it.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"time"
+
+	"github.com/doismellburning/samoyed/src/geo"
+)
+
+/* How often to check the GPS location, even if no beacon is due. */
+
+const GEOFENCE_CHECK_INTERVAL = 10 * time.Second
+
+/* SmartBeaconing parameters.  Units are the same as sb_... in misc_config_s. */
+
+type smartbeacon_s struct {
+	fast_speed int /* MPH */
+	fast_rate  int /* seconds */
+	slow_speed int /* MPH */
+	slow_rate  int /* seconds */
+	turn_time  int /* seconds */
+	turn_angle int /* degrees */
+	turn_slope int /* degrees * MPH */
+}
+
+func (mc *misc_config_s) smartbeacon() smartbeacon_s {
+	return smartbeacon_s{
+		fast_speed: mc.sb_fast_speed,
+		fast_rate:  mc.sb_fast_rate,
+		slow_speed: mc.sb_slow_speed,
+		slow_rate:  mc.sb_slow_rate,
+		turn_time:  mc.sb_turn_time,
+		turn_angle: mc.sb_turn_angle,
+		turn_slope: mc.sb_turn_slope,
+	}
+}
+
+func (mc *misc_config_s) set_smartbeacon(sb smartbeacon_s) {
+	mc.sb_fast_speed = sb.fast_speed
+	mc.sb_fast_rate = sb.fast_rate
+	mc.sb_slow_speed = sb.slow_speed
+	mc.sb_slow_rate = sb.slow_rate
+	mc.sb_turn_time = sb.turn_time
+	mc.sb_turn_angle = sb.turn_angle
+	mc.sb_turn_slope = sb.turn_slope
+}
+
+type geofence_s struct {
+	name   string
+	lineno int
+
+	/* Circle. */
+
+	lat       float64
+	lon       float64
+	radius_km float64 /* 0 for polygon. */
+
+	/* Polygon.  Corners as latitude, longitude. */
+
+	polygon [][2]float64
+
+	enter_comment string         /* Beacon on entering, or "" for none. */
+	exit_comment  string         /* Beacon on leaving, or "" for none. */
+	comment       string         /* Tracker beacon comment while inside, or "". */
+	sb            *smartbeacon_s /* SmartBeaconing while inside, or nil. */
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	contains
+ *
+ * Purpose:	Is a location inside the area?
+ *
+ * Description:	For a polygon, count how many edges a line going east
+ *		crosses.  Treating latitude and longitude as flat is
+ *		good enough for areas of a reasonable size, but not
+ *		across the 180 degree meridian.
+ *
+ *---------------------------------------------------------------*/
+
+func (g *geofence_s) contains(lat float64, lon float64) bool {
+	if g.radius_km > 0 {
+		return geo.DistanceKM(g.lat, g.lon, lat, lon) <= g.radius_km
+	}
+
+	var inside = false
+	var n = len(g.polygon)
+
+	for i, j := 0, n-1; i < n; j, i = i, i+1 {
+		var lat1, lon1 = g.polygon[i][0], g.polygon[i][1]
+		var lat2, lon2 = g.polygon[j][0], g.polygon[j][1]
+
+		if (lat1 > lat) != (lat2 > lat) {
+			var cross = lon1 + (lat-lat1)*(lon2-lon1)/(lat2-lat1)
+			if lon < cross {
+				inside = !inside
+			}
+		}
+	}
+
+	return inside
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	geofence_update
+ *
+ * Purpose:	Find which areas we are in and what has changed.
+ *
+ * Inputs:	fences	- From the configuration.
+ *
+ *		inside	- Whether we were in each one last time.
+ *			  nil the first time.
+ *
+ *		lat, lon - Current location.
+ *
+ * Returns:	Whether we are in each one now, and the areas
+ *		entered and left since last time, in order.
+ *
+ *---------------------------------------------------------------*/
+
+func geofence_update(fences []*geofence_s, inside []bool, lat float64, lon float64) ([]bool, []*geofence_s, []*geofence_s) {
+	var now = make([]bool, len(fences))
+	var entered, left []*geofence_s
+
+	for i, g := range fences {
+		now[i] = g.contains(lat, lon)
+
+		if inside == nil || now[i] == inside[i] {
+			continue
+		}
+
+		if now[i] {
+			entered = append(entered, g)
+		} else {
+			left = append(left, g)
+		}
+	}
+
+	return now, entered, left
+}
+
+/* COMMENT of the first area we are in that has one, or "" for none. */
+
+func geofence_comment(fences []*geofence_s, inside []bool) string {
+	for i, g := range fences {
+		if inside[i] && g.comment != "" {
+			return g.comment
+		}
+	}
+
+	return ""
+}
+
+/* SMARTBEACONING of the first area we are in that has it, or nil for none. */
+
+func geofence_smartbeacon(fences []*geofence_s, inside []bool) *smartbeacon_s {
+	for i, g := range fences {
+		if inside[i] && g.sb != nil {
+			return g.sb
+		}
+	}
+
+	return nil
+}
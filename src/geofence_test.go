@@ -0,0 +1,94 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_geofence_contains_circle(t *testing.T) {
+	var g = &geofence_s{name: "hamfest", lat: 42.619, lon: -71.347, radius_km: 2} //nolint:exhaustruct
+
+	assert.True(t, g.contains(42.619, -71.347))
+	assert.True(t, g.contains(42.630, -71.347))  // About 1.2 km north.
+	assert.False(t, g.contains(42.650, -71.347)) // About 3.4 km north.
+}
+
+func Test_geofence_contains_polygon(t *testing.T) {
+	// L shape so a corner is cut out.
+	var g = &geofence_s{ //nolint:exhaustruct
+		name: "city",
+		polygon: [][2]float64{
+			{42.0, -71.0}, {42.0, -70.0}, {42.5, -70.0}, {42.5, -70.5}, {43.0, -70.5}, {43.0, -71.0},
+		},
+	}
+
+	assert.True(t, g.contains(42.25, -70.25))
+	assert.True(t, g.contains(42.75, -70.75))
+	assert.False(t, g.contains(42.75, -70.25)) // The missing corner.
+	assert.False(t, g.contains(41.9, -70.5))
+	assert.False(t, g.contains(42.5, -69.9))
+}
+
+func Test_geofence_update(t *testing.T) {
+	var a = &geofence_s{name: "a", lat: 42.0, lon: -71.0, radius_km: 5, comment: "In A"}                                     //nolint:exhaustruct
+	var b = &geofence_s{name: "b", lat: 42.0, lon: -71.0, radius_km: 50, comment: "In B", sb: &smartbeacon_s{fast_rate: 60}} //nolint:exhaustruct
+	var fences = []*geofence_s{a, b}
+
+	// First time, nothing is entered, even though we are inside.
+
+	var inside, entered, left = geofence_update(fences, nil, 42.0, -71.0)
+	assert.Equal(t, []bool{true, true}, inside)
+	assert.Empty(t, entered)
+	assert.Empty(t, left)
+	assert.Equal(t, "In A", geofence_comment(fences, inside)) // First one wins.
+	require.NotNil(t, geofence_smartbeacon(fences, inside))
+	assert.Equal(t, 60, geofence_smartbeacon(fences, inside).fast_rate)
+
+	// Move out of a but not b.
+
+	inside, entered, left = geofence_update(fences, inside, 42.2, -71.0)
+	assert.Equal(t, []bool{false, true}, inside)
+	assert.Empty(t, entered)
+	assert.Equal(t, []*geofence_s{a}, left)
+	assert.Equal(t, "In B", geofence_comment(fences, inside))
+
+	// Out of both.
+
+	inside, _, left = geofence_update(fences, inside, 44.0, -71.0)
+	assert.Equal(t, []*geofence_s{b}, left)
+	assert.Empty(t, geofence_comment(fences, inside))
+	assert.Nil(t, geofence_smartbeacon(fences, inside))
+
+	// Back in.
+
+	_, entered, _ = geofence_update(fences, inside, 42.0, -71.0)
+	assert.Equal(t, []*geofence_s{a, b}, entered)
+}
+
+func Test_BeaconService_geofenceCheck_smartbeacon(t *testing.T) {
+	var mc = makeSBConfig()
+	var fast = &smartbeacon_s{fast_speed: 30, fast_rate: 60, slow_speed: 3, slow_rate: 600, turn_time: 10, turn_angle: 20, turn_slope: 255}
+	mc.geofence = []*geofence_s{{name: "city", lat: 42.0, lon: -71.0, radius_km: 5, comment: "Downtown", sb: fast}} //nolint:exhaustruct
+
+	var bs = &BeaconService{miscConfig: mc} //nolint:exhaustruct
+	bs.sbDefault = mc.smartbeacon()
+
+	var gpsinfo = new(dwgps_info_t)
+	dwgps_clear(gpsinfo)
+	gpsinfo.fix = DWFIX_2D
+	gpsinfo.dlat = 42.0
+	gpsinfo.dlon = -71.0
+
+	bs.geofenceCheck(mc.geofence, gpsinfo)
+	assert.Equal(t, 60, mc.sb_fast_rate)
+	assert.Equal(t, 20, mc.sb_turn_angle)
+	assert.Equal(t, "Downtown", bs.geofenceComment)
+
+	gpsinfo.dlat = 43.0
+	bs.geofenceCheck(mc.geofence, gpsinfo)
+	assert.Equal(t, 30, mc.sb_fast_rate)
+	assert.Equal(t, 30, mc.sb_turn_angle)
+	assert.Empty(t, bs.geofenceComment)
+}
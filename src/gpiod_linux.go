@@ -1,10 +1,67 @@
 package direwolf
 
+/*------------------------------------------------------------------
+ *
+ * Purpose:	GPIO lines for PTT, DCD, CON, and TXINH, using the Linux
+ *		GPIO character device (/dev/gpiochipN) directly.
+ *
+ * Description:	This is the uAPI that replaced /sys/class/gpio.  It is
+ *		all ioctl calls so no C library (libgpiod) is needed.
+ *
+ *		Active low and bias (pull up / pull down) are handled
+ *		by the kernel when the line is requested, so the rest
+ *		of the application only sees the logical value.
+ *
+ *------------------------------------------------------------------*/
+
 import (
 	gpiocdev "github.com/warthog618/go-gpiocdev"
 )
 
-func RequestGPIODLine(chipName string, lineNumber int, initialState int) (*gpiocdev.Line, error) {
+const GPIOD_CONSUMER = "samoyed"
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	gpiod_options
+ *
+ * Purpose:	Options common to input and output line requests.
+ *
+ * Inputs:	active_low	- Line is active when the voltage is low.
+ *
+ *		bias		- Pull up, pull down, disabled, or as is.
+ *
+ *--------------------------------------------------------------------*/
+
+func gpiod_options(active_low bool, bias gpio_bias_t) []gpiocdev.LineReqOption {
+	var opts = []gpiocdev.LineReqOption{gpiocdev.WithConsumer(GPIOD_CONSUMER)}
+
+	if active_low {
+		opts = append(opts, gpiocdev.AsActiveLow)
+	}
+
+	switch bias {
+	case GPIO_BIAS_DISABLED:
+		opts = append(opts, gpiocdev.WithBiasDisabled)
+	case GPIO_BIAS_PULL_UP:
+		opts = append(opts, gpiocdev.WithPullUp)
+	case GPIO_BIAS_PULL_DOWN:
+		opts = append(opts, gpiocdev.WithPullDown)
+	case GPIO_BIAS_AS_IS:
+	}
+
+	return opts
+}
+
+// RequestGPIODOutput requests an output line, initially inactive.
+func RequestGPIODOutput(chipName string, lineNumber int, activeLow bool, bias gpio_bias_t) (*gpiocdev.Line, error) {
+	var opts = append(gpiod_options(activeLow, bias), gpiocdev.AsOutput(0))
+
+	return gpiocdev.RequestLine(chipName, lineNumber, opts...)
+}
+
+// RequestGPIODInput requests an input line.
+func RequestGPIODInput(chipName string, lineNumber int, activeLow bool, bias gpio_bias_t) (*gpiocdev.Line, error) {
+	var opts = append(gpiod_options(activeLow, bias), gpiocdev.AsInput)
 
-	return gpiocdev.RequestLine(chipName, lineNumber, gpiocdev.AsOutput(initialState))
+	return gpiocdev.RequestLine(chipName, lineNumber, opts...)
 }
@@ -0,0 +1,22 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	gpiocdev "github.com/warthog618/go-gpiocdev"
+)
+
+func Test_gpiod_options(t *testing.T) {
+	assert.Equal(t, []gpiocdev.LineReqOption{gpiocdev.WithConsumer(GPIOD_CONSUMER)},
+		gpiod_options(false, GPIO_BIAS_AS_IS))
+
+	assert.Equal(t, []gpiocdev.LineReqOption{gpiocdev.WithConsumer(GPIOD_CONSUMER), gpiocdev.AsActiveLow, gpiocdev.WithPullUp},
+		gpiod_options(true, GPIO_BIAS_PULL_UP))
+
+	assert.Equal(t, []gpiocdev.LineReqOption{gpiocdev.WithConsumer(GPIOD_CONSUMER), gpiocdev.WithPullDown},
+		gpiod_options(false, GPIO_BIAS_PULL_DOWN))
+
+	assert.Equal(t, []gpiocdev.LineReqOption{gpiocdev.WithConsumer(GPIOD_CONSUMER), gpiocdev.WithBiasDisabled},
+		gpiod_options(false, GPIO_BIAS_DISABLED))
+}
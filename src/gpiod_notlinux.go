@@ -7,17 +7,26 @@ import (
 )
 
 // Basic interface implementation to keep ireturn happy
-type dummyGpiodOutputLine struct{}
+type dummyGpiodLine struct{}
 
-func (*dummyGpiodOutputLine) SetValue(_ int) error {
+func (*dummyGpiodLine) SetValue(_ int) error {
 	return nil
 }
 
-func (*dummyGpiodOutputLine) Close() error {
+func (*dummyGpiodLine) Value() (int, error) {
+	return 0, nil
+}
+
+func (*dummyGpiodLine) Close() error {
 	return nil
 }
 
-func RequestGPIODLine(chipName string, lineNumber int, initialState int) (*dummyGpiodOutputLine, error) {
+var errGPIODNotSupported = errors.New("GPIOD not supported on non-Linux operating systems")
+
+func RequestGPIODOutput(chipName string, lineNumber int, activeLow bool, bias gpio_bias_t) (*dummyGpiodLine, error) {
+	return nil, errGPIODNotSupported
+}
 
-	return nil, errors.New("GPIOD not supported on non-Linux operating systems")
+func RequestGPIODInput(chipName string, lineNumber int, activeLow bool, bias gpio_bias_t) (*dummyGpiodLine, error) {
+	return nil, errGPIODNotSupported
 }
@@ -9,6 +9,8 @@ package direwolf
 
 import (
 	"slices"
+
+	"github.com/doismellburning/samoyed/src/channelmodel"
 )
 
 /* Undo data scrambling for 9600 baud. */
@@ -98,25 +100,11 @@ func hdlc_rec_init(pa *audio_s) {
 	Assert(pa != nil)
 	g_audio_p = pa
 
+	hdlcRecChannel.BitErrorRate = pa.recv_ber
+
 	for ch := range MAX_RADIO_CHANS {
 		if pa.chan_medium[ch] == MEDIUM_RADIO {
-			num_subchannel[ch] = pa.achan[ch].num_subchan
-
-			Assert(num_subchannel[ch] >= 1 && num_subchannel[ch] <= MAX_SUBCHANS)
-
-			for sub := 0; sub < num_subchannel[ch]; sub++ {
-				for slice := range MAX_SLICERS {
-					var H = new(hdlc_state_s)
-					hdlc_state[ch][sub][slice] = H
-
-					H.olen = -1
-
-					// TODO: FIX13 wasteful if not needed.
-					// Should loop on number of slicers, not max.
-
-					H.rrbb = rrbb_new(ch, sub, slice, pa.achan[ch].modem_type == MODEM_SCRAMBLE, H.lfsr, H.prev_descram)
-				}
-			}
+			hdlc_rec_init_channel(pa, ch)
 		}
 	}
 
@@ -125,18 +113,33 @@ func hdlc_rec_init(pa *audio_s) {
 	hdlcRecWasInit = true
 }
 
-/* Own copy of random number generator so we can get */
-/* same predictable results on different operating systems. */
-/* TODO: Consolidate multiple copies somewhere. */
+/* Part of hdlc_rec_init for one channel.  Also used when a channel profile changes the modem. */
+
+func hdlc_rec_init_channel(pa *audio_s, ch int) {
+	num_subchannel[ch] = pa.achan[ch].num_subchan
 
-var hdlcRecRandSeed int32 = 1
-var hdlcRecRandMax int32 = 0x7fffffff
+	Assert(num_subchannel[ch] >= 1 && num_subchannel[ch] <= MAX_SUBCHANS)
 
-func hdlcRecRand() int32 {
-	hdlcRecRandSeed = int32((uint32(hdlcRecRandSeed)*1103515245)+12345) & hdlcRecRandMax
-	return hdlcRecRandSeed
+	for sub := 0; sub < num_subchannel[ch]; sub++ {
+		for slice := range MAX_SLICERS {
+			var H = new(hdlc_state_s)
+			hdlc_state[ch][sub][slice] = H
+
+			H.olen = -1
+
+			// TODO: FIX13 wasteful if not needed.
+			// Should loop on number of slicers, not max.
+
+			H.rrbb = rrbb_new(ch, sub, slice, pa.achan[ch].modem_type == MODEM_SCRAMBLE, H.lfsr, H.prev_descram)
+		}
+	}
 }
 
+/* Own channel model, and random number generator, for the -e option */
+/* so we get the same predictable results on different operating systems. */
+
+var hdlcRecChannel = channelmodel.New()
+
 /***********************************************************************************
  *
  * Name:	eas_rec_bit
@@ -394,15 +397,7 @@ func hdlc_rec_bit_new(channel int, subchannel int, slice int, _raw int, is_scram
 	// -e option can be used to artificially introduce the desired
 	// Bit Error Rate (BER) for testing.
 
-	if g_audio_p.recv_ber != 0 {
-		var r = float64(hdlcRecRand()) / float64(hdlcRecRandMax) // calculate as double to preserve all 31 bits.
-		if g_audio_p.recv_ber > r {
-			// FIXME
-			//text_color_set(DW_COLOR_DEBUG);
-			//dw_printf ("hdlc_rec_bit randomly clobber bit, ber = %.6f\n", g_audio_p.recv_ber);
-			raw = !raw
-		}
-	}
+	raw = hdlcRecChannel.FlipBit(raw)
 
 	// EAS does not use HDLC.
 
@@ -63,9 +63,9 @@ func layer2_send_frame(channel int, pp *packet_t, bad_fcs bool, audio_config_p *
 		// the FX.25 frame length is so limited.
 	}
 
-	var fbuf = AX25Pack(pp)
+	/* Only read, so no need to make a copy. */
 
-	return (ax25_only_hdlc_send_frame(channel, fbuf, bad_fcs))
+	return (ax25_only_hdlc_send_frame(channel, ax25_get_frame_data(pp), bad_fcs))
 }
 
 func ax25_only_hdlc_send_frame(channel int, fbuf []byte, bad_fcs bool) int {
@@ -0,0 +1,163 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Our own location over HTTP.
+ *
+ * Description:	GET /position is the location we are using now:
+ *
+ *			{"source":"NMEA","lat":42.61875,"lon":-71.347212,
+ *			 "alt":33.5,"speed":5.1,"course":291,"age_seconds":2}
+ *
+ *		alt is meters, speed is knots, and course is degrees.
+ *		Anything not known is left out.
+ *
+ *		With GPSAPI and a token in the configuration file,
+ *		an external system can POST the same thing, without
+ *		source and age_seconds, to set it.  Only lat and lon
+ *		are required.
+ *
+ *			curl -H 'Authorization: Bearer s3cret' \
+ *			     -H 'Content-Type: application/json' \
+ *			     -d '{"lat":42.61875,"lon":-71.347212}' \
+ *			     http://localhost:8080/position
+ *
+ *		The server listens on every interface, so the token
+ *		is required.  Only application/json is accepted, which
+ *		a web page in some other browser tab can't send without
+ *		asking first, so it can't move us either.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"mime"
+	"net/http"
+	"strings"
+	"time"
+)
+
+/* From GPSAPI.  POST is refused without one. */
+
+var s_http_position_token string
+
+type Position struct {
+	Source     string   `json:"source,omitempty"`
+	Lat        *float64 `json:"lat"`
+	Lon        *float64 `json:"lon"`
+	Alt        *float64 `json:"alt,omitempty"`
+	Speed      *float64 `json:"speed,omitempty"`
+	Course     *float64 `json:"course,omitempty"`
+	AgeSeconds *int     `json:"age_seconds,omitempty"`
+}
+
+/* G_UNKNOWN becomes nil, and back. */
+
+func position_known(v float64) *float64 {
+	if v == G_UNKNOWN {
+		return nil
+	}
+
+	return &v
+}
+
+func position_value(p *float64) float64 {
+	if p == nil {
+		return G_UNKNOWN
+	}
+
+	return *p
+}
+
+func http_position(gpsinfo *dwgps_info_t, now time.Time) Position {
+	var age = int(now.Sub(gpsinfo.timestamp).Seconds())
+
+	var p = Position{
+		Source:     gpsinfo.source.String(),
+		Lat:        position_known(gpsinfo.dlat),
+		Lon:        position_known(gpsinfo.dlon),
+		Alt:        nil,
+		Speed:      position_known(gpsinfo.speed_knots),
+		Course:     position_known(gpsinfo.track),
+		AgeSeconds: &age,
+	}
+
+	if gpsinfo.fix == DWFIX_3D {
+		p.Alt = position_known(gpsinfo.altitude)
+	}
+
+	return p
+}
+
+func http_serve_position(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet, http.MethodHead:
+		var gpsinfo dwgps_info_t
+		if dwgps_read(&gpsinfo) < DWFIX_2D {
+			http.Error(w, "no position", http.StatusNotFound)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(http_position(&gpsinfo, time.Now()))
+	case http.MethodPost:
+		if !http_position_authorized(w, r) {
+			return
+		}
+
+		var mediaType, _, mediaErr = mime.ParseMediaType(r.Header.Get("Content-Type"))
+		if mediaErr != nil || mediaType != "application/json" {
+			http.Error(w, "expected Content-Type application/json", http.StatusUnsupportedMediaType)
+
+			return
+		}
+
+		var p Position
+
+		var err = json.NewDecoder(http.MaxBytesReader(w, r.Body, 4096)).Decode(&p)
+		if err != nil || p.Lat == nil || p.Lon == nil {
+			http.Error(w, "expected JSON with lat and lon", http.StatusBadRequest)
+
+			return
+		}
+
+		err = dwgps_set_position(*p.Lat, *p.Lon, position_value(p.Alt), position_value(p.Speed), position_value(p.Course))
+		if err != nil {
+			var status = http.StatusBadRequest
+			if !dwgps_api_enabled() {
+				status = http.StatusForbidden
+			}
+
+			http.Error(w, err.Error(), status)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.Header().Set("Allow", "GET, HEAD, POST")
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+/* Check the token, answering the request if it is missing or wrong. */
+
+func http_position_authorized(w http.ResponseWriter, r *http.Request) bool {
+	if s_http_position_token == "" {
+		http.Error(w, "POST needs a token on the GPSAPI line", http.StatusForbidden)
+
+		return false
+	}
+
+	var token, ok = strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(s_http_position_token)) != 1 {
+		w.Header().Set("WWW-Authenticate", "Bearer")
+		http.Error(w, "wrong or missing token", http.StatusUnauthorized)
+
+		return false
+	}
+
+	return true
+}
@@ -0,0 +1,92 @@
+package direwolf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func position_test_post(body string, contentType string, token string) *httptest.ResponseRecorder {
+	var r = httptest.NewRequest(http.MethodPost, "/position", strings.NewReader(body))
+	r.Header.Set("Content-Type", contentType)
+
+	if token != "" {
+		r.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	var w = httptest.NewRecorder()
+	http_serve_position(w, r)
+
+	return w
+}
+
+func position_test_request(method string, body string) *httptest.ResponseRecorder {
+	if method == http.MethodPost {
+		return position_test_post(body, "application/json", "s3cret")
+	}
+
+	var w = httptest.NewRecorder()
+	http_serve_position(w, httptest.NewRequest(method, "/position", strings.NewReader(body)))
+
+	return w
+}
+
+func Test_http_position(t *testing.T) {
+	dwgps_test_reset(t, dwgps_default_priority, 30*time.Second)
+
+	s_http_position_token = "s3cret"
+	t.Cleanup(func() { s_http_position_token = "" })
+
+	assert.Equal(t, http.StatusNotFound, position_test_request(http.MethodGet, "").Code)
+	assert.Equal(t, http.StatusForbidden, position_test_request(http.MethodPost, `{"lat":42.5,"lon":-71.25}`).Code)
+
+	dwgps_test_enable_api(t)
+
+	assert.Equal(t, http.StatusBadRequest, position_test_request(http.MethodPost, `{"lat":42.5}`).Code)
+	assert.Equal(t, http.StatusBadRequest, position_test_request(http.MethodPost, `lat=42.5&lon=-71.25`).Code)
+	assert.Equal(t, http.StatusBadRequest, position_test_request(http.MethodPost, `{"lat":95,"lon":-71.25}`).Code)
+	assert.Equal(t, http.StatusMethodNotAllowed, position_test_request(http.MethodDelete, "").Code)
+
+	assert.Equal(t, http.StatusNoContent, position_test_request(http.MethodPost, `{"lat":42.5,"lon":-71.25,"speed":12.5}`).Code)
+
+	var w = position_test_request(http.MethodGet, "")
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, `{"source":"API","lat":42.5,"lon":-71.25,"speed":12.5,"age_seconds":0}`, w.Body.String())
+}
+
+func Test_http_position_post_needs_token_and_json(t *testing.T) {
+	dwgps_test_reset(t, dwgps_default_priority, 30*time.Second)
+	dwgps_test_enable_api(t)
+
+	var body = `{"lat":42.5,"lon":-71.25}`
+
+	// GPSAPI without a token still leaves the control socket.
+
+	assert.Equal(t, http.StatusForbidden, position_test_post(body, "application/json", "").Code)
+	assert.Equal(t, http.StatusForbidden, position_test_post(body, "application/json", "s3cret").Code)
+
+	s_http_position_token = "s3cret"
+	t.Cleanup(func() { s_http_position_token = "" })
+
+	var w = position_test_post(body, "application/json", "")
+	assert.Equal(t, http.StatusUnauthorized, w.Code)
+	assert.Equal(t, "Bearer", w.Header().Get("WWW-Authenticate"))
+	assert.Equal(t, http.StatusUnauthorized, position_test_post(body, "application/json", "guess").Code)
+
+	// A form or text/plain can be sent from any web page without asking.
+
+	assert.Equal(t, http.StatusUnsupportedMediaType, position_test_post(body, "text/plain", "s3cret").Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType, position_test_post(body, "application/x-www-form-urlencoded", "s3cret").Code)
+	assert.Equal(t, http.StatusUnsupportedMediaType, position_test_post(body, "", "s3cret").Code)
+
+	var gpsinfo dwgps_info_t
+	assert.Equal(t, DWFIX_NOT_SEEN, dwgps_read(&gpsinfo))
+
+	assert.Equal(t, http.StatusNoContent, position_test_post(body, "application/json; charset=utf-8", "s3cret").Code)
+	assert.Equal(t, DWFIX_2D, dwgps_read(&gpsinfo))
+}
@@ -0,0 +1,119 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	HTTP server for web front ends and monitoring.
+ *
+ * Description:	Disabled unless the configuration file has
+ *
+ *			HTTPPORT 8080
+ *
+ *		WebSockets from web pages served elsewhere also need
+ *
+ *			HTTPORIGIN https://map.example.org
+ *
+ *		Endpoints:
+ *
+ *			/stream		WebSocket with each received
+ *					packet as JSON.  See httpstream.go.
+ *
+ *			/healthz	"ok" for a liveness probe.
+ *
+ *			/status		JSON summary of channels, audio
+ *					devices, IGate, and clients.
+ *					See httpstatus.go.
+ *
+ *			/spectrum	Receive audio spectrum, latest or
+ *			/spectrum/stream  as a WebSocket.  See spectrum.go.
+ *
+ *			/track.gpx	Our own track.  See owntrack.go.
+ *
+ *			/position	Our own location, and setting it
+ *					with GPSAPI and a token.
+ *					See httpposition.go.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        http_server_init
+ *
+ * Purpose:     Start listening for HTTP connections.
+ *
+ * Inputs:	ac, ic	- Configuration to report in /status.
+ *
+ *		mc	- Configuration.  Nothing happens if http_port is 0.
+ *
+ *--------------------------------------------------------------------*/
+
+func http_server_init(ac *audio_s, ic *igate_config_s, mc *misc_config_s) {
+	if mc.http_port == 0 {
+		return
+	}
+
+	var listener, err = net.Listen("tcp", net.JoinHostPort("", strconv.Itoa(mc.http_port)))
+	if err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Can't listen for HTTP on port %d: %s\n", mc.http_port, err)
+
+		return
+	}
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("Ready to accept HTTP connections on port %d ...\n", mc.http_port)
+
+	spectrum.Enable(ac)
+
+	s_http_position_token = mc.gps_api_token
+
+	var server = new(http.Server)
+	server.Handler = http_server_mux(new_http_status(ac, ic), mc.http_origins)
+	server.ReadHeaderTimeout = 10 * time.Second
+
+	go func() {
+		var serveErr = server.Serve(listener)
+
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("HTTP server stopped: %s\n", serveErr)
+	}()
+}
+
+func http_server_mux(status *httpStatusService, origins []string) *http.ServeMux {
+	var mux = http.NewServeMux()
+
+	mux.Handle("/stream", packetStream.handler(origins))
+	mux.HandleFunc("/healthz", status.serveHealthz)
+	mux.HandleFunc("/status", status.serveStatus)
+	mux.HandleFunc("/spectrum", spectrum.serveLatest)
+	mux.Handle("/spectrum/stream", spectrum.stream.handler(origins))
+	mux.HandleFunc("/track.gpx", ownTrack.serveGPX)
+	mux.HandleFunc("/position", http_serve_position)
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/" {
+			http.NotFound(w, r)
+
+			return
+		}
+
+		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+		fmt.Fprintf(w, "Samoyed %s\n\n", SAMOYED_VERSION)
+		fmt.Fprintf(w, "/stream\tWebSocket with received packets as JSON.\n")
+		fmt.Fprintf(w, "/healthz\tLiveness.\n")
+		fmt.Fprintf(w, "/status\tJSON summary of channels, audio devices, IGate, and clients.\n")
+		fmt.Fprintf(w, "/spectrum?channel=n\tLatest receive audio spectrum as JSON.\n")
+		fmt.Fprintf(w, "/spectrum/stream\tWebSocket with each receive audio spectrum, for a waterfall.\n")
+		fmt.Fprintf(w, "/track.gpx\tOur own track from the GPS, with tracker beacons.\n")
+		fmt.Fprintf(w, "/position\tOur own location as JSON.  POST to set it, with GPSAPI.\n")
+	})
+
+	return mux
+}
@@ -0,0 +1,221 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Health and status over HTTP for monitoring.
+ *
+ * Description:	/healthz answers "ok" while the HTTP server is
+ *		running, for a container orchestrator's liveness probe.
+ *
+ *		/status is a JSON summary for remote monitoring:
+ *
+ *			{"version":"...","started":"...","uptime_seconds":3600,
+ *			 "channels":[{"channel":0,"name":"VHF","mycall":"Q1TEST",
+ *			   "medium":"radio","audio_device":0,"baud":1200,
+ *			   "mark":1200,"space":2200,"audio_level":35,
+ *			   "tx_duty_percent_10m":2.5,"tx_duty_percent_60m":1.2}],
+ *			 "audio_devices":[{"device":0,"input":"plughw:1,0",
+ *			   "output":"plughw:1,0","channels":1,"sample_rate":44100}],
+ *			 "igate":{"server":"noam.aprs2.net:14580","login":"Q1TEST-10",
+ *			   "connected":true,"connected_since":"...",
+ *			   "uplink_packets":120,"downlink_packets":4},
+ *			 "clients":{"agw":1,"kiss_tcp":2,"websocket":0}}
+ *
+ *		"igate" is only there if an IGate is configured.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type StatusChannel struct {
+	Channel     int      `json:"channel"`
+	Name        string   `json:"name,omitempty"`
+	MyCall      string   `json:"mycall,omitempty"`
+	Medium      string   `json:"medium"`
+	AudioDevice *int     `json:"audio_device,omitempty"` // Radio channels only, as are the rest.
+	Baud        int      `json:"baud,omitempty"`
+	Mark        int      `json:"mark,omitempty"`
+	Space       int      `json:"space,omitempty"`
+	AudioLevel  *int     `json:"audio_level,omitempty"`
+	TxDuty10m   *float64 `json:"tx_duty_percent_10m,omitempty"` // Percentage of time transmitting.  See tx_activity.go.
+	TxDuty60m   *float64 `json:"tx_duty_percent_60m,omitempty"`
+	NetTNC      string   `json:"net_tnc,omitempty"` // host:port for a network TNC.
+}
+
+type StatusAudioDevice struct {
+	Device     int    `json:"device"`
+	Input      string `json:"input"`
+	Output     string `json:"output"`
+	Channels   int    `json:"channels"`
+	SampleRate int    `json:"sample_rate"`
+}
+
+type StatusIGate struct {
+	Server          string     `json:"server"`
+	Login           string     `json:"login"`
+	Connected       bool       `json:"connected"`
+	ConnectedSince  *time.Time `json:"connected_since,omitempty"`
+	UplinkPackets   int        `json:"uplink_packets"`
+	DownlinkPackets int        `json:"downlink_packets"`
+}
+
+type StatusClients struct {
+	AGW       int `json:"agw"`
+	KISSTCP   int `json:"kiss_tcp"`
+	WebSocket int `json:"websocket"`
+}
+
+type Status struct {
+	Version       string              `json:"version"`
+	Started       time.Time           `json:"started"`
+	UptimeSeconds int64               `json:"uptime_seconds"`
+	Channels      []StatusChannel     `json:"channels"`
+	AudioDevices  []StatusAudioDevice `json:"audio_devices"`
+	IGate         *StatusIGate        `json:"igate,omitempty"`
+	Clients       StatusClients       `json:"clients"`
+}
+
+type httpStatusService struct {
+	audioConfigP *audio_s
+	igateConfigP *igate_config_s
+	txActivityP  *TxActivity
+	started      time.Time
+
+	/* Live state.  Replaced for testing. */
+
+	level          func(channel int) ALevel
+	igateConnected func() time.Time
+	clients        func() StatusClients
+}
+
+func new_http_status(ac *audio_s, ic *igate_config_s) *httpStatusService {
+	var s = new(httpStatusService)
+	s.audioConfigP = ac
+	s.igateConfigP = ic
+	s.txActivityP = txActivity
+	s.started = time.Now()
+	s.level = func(channel int) ALevel { return demod_get_audio_level(channel, 0) }
+	s.igateConnected = igate_get_connected_since
+	s.clients = func() StatusClients {
+		return StatusClients{
+			AGW:       server_num_clients(),
+			KISSTCP:   kissNetSvc.NumClients(-1),
+			WebSocket: packetStream.count(),
+		}
+	}
+
+	return s
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        status
+ *
+ * Purpose:     Gather everything for /status.
+ *
+ * Inputs:	now	- For uptime.
+ *
+ *--------------------------------------------------------------------*/
+
+func (s *httpStatusService) status(now time.Time) *Status {
+	var ac = s.audioConfigP
+	var ic = s.igateConfigP
+
+	var st = new(Status)
+	st.Version = SAMOYED_VERSION
+	st.Started = s.started
+	st.UptimeSeconds = int64(now.Sub(s.started).Seconds())
+	st.Channels = []StatusChannel{}
+	st.AudioDevices = []StatusAudioDevice{}
+	st.Clients = s.clients()
+
+	for ch := range MAX_TOTAL_CHANS {
+		var c StatusChannel
+		c.Channel = ch
+		c.Name = ac.chan_name[ch]
+
+		if !IsNoCall(ac.mycall[ch]) {
+			c.MyCall = ac.mycall[ch]
+		}
+
+		switch ac.chan_medium[ch] {
+		case MEDIUM_RADIO:
+			var a = &ac.achan[ch]
+			var adev = ACHAN2ADEV(ch)
+			var alevel = s.level(ch)
+			var duty10 = s.txActivityP.DutyCycle(ch, 10*time.Minute, now)
+			var duty60 = s.txActivityP.DutyCycle(ch, 60*time.Minute, now)
+
+			c.Medium = "radio"
+			c.AudioDevice = &adev
+			c.Baud = a.baud
+			c.Mark = a.mark_freq
+			c.Space = a.space_freq
+			c.AudioLevel = &alevel.rec
+			c.TxDuty10m = &duty10
+			c.TxDuty60m = &duty60
+		case MEDIUM_IGATE:
+			c.Medium = "igate"
+		case MEDIUM_NETTNC:
+			c.Medium = "nettnc"
+			if ac.nettnc_bridge[ch] {
+				c.Medium = "bridge"
+			}
+
+			c.NetTNC = fmt.Sprintf("%s:%d", ac.nettnc_addr[ch], ac.nettnc_port[ch])
+		default:
+			continue
+		}
+
+		st.Channels = append(st.Channels, c)
+	}
+
+	for a := range MAX_ADEVS {
+		if ac.adev[a].defined == 0 {
+			continue
+		}
+
+		st.AudioDevices = append(st.AudioDevices, StatusAudioDevice{
+			Device:     a,
+			Input:      ac.adev[a].adevice_in,
+			Output:     ac.adev[a].adevice_out,
+			Channels:   ac.adev[a].num_channels,
+			SampleRate: ac.adev[a].samples_per_sec,
+		})
+	}
+
+	if ic != nil && ic.t2_server_name != "" && ic.t2_login != "" {
+		var ig = new(StatusIGate)
+		ig.Server = fmt.Sprintf("%s:%d", ic.t2_server_name, ic.t2_server_port)
+		ig.Login = ic.t2_login
+		ig.UplinkPackets = igate_get_upl_cnt()
+		ig.DownlinkPackets = igate_get_dnl_cnt()
+
+		if since := s.igateConnected(); !since.IsZero() {
+			ig.Connected = true
+			ig.ConnectedSince = &since
+		}
+
+		st.IGate = ig
+	}
+
+	return st
+}
+
+func (s *httpStatusService) serveHealthz(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	fmt.Fprintf(w, "ok\n")
+}
+
+func (s *httpStatusService) serveStatus(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+
+	var enc = json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(s.status(time.Now()))
+}
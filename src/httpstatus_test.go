@@ -0,0 +1,115 @@
+package direwolf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func status_test_service(t *testing.T) *httpStatusService {
+	t.Helper()
+
+	var ac, _ = configFromString(t, "ADEVICE plughw:1,0\nCHANNEL 0 NAME=VHF\nMYCALL Q1TEST\nMODEM 1200\n")
+
+	var ic igate_config_s
+	ic.t2_server_name = "noam.aprs2.net"
+	ic.t2_server_port = 14580
+	ic.t2_login = "Q1TEST-10"
+
+	var s = new_http_status(ac, &ic)
+	s.level = func(int) ALevel { return ALevel{rec: 35, mark: 20, space: 18} }
+	s.clients = func() StatusClients { return StatusClients{AGW: 1, KISSTCP: 2, WebSocket: 3} }
+	s.txActivityP = new(TxActivity)
+
+	return s
+}
+
+func Test_http_status(t *testing.T) {
+	var s = status_test_service(t)
+
+	var connected = time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+	s.igateConnected = func() time.Time { return connected }
+
+	var now = s.started.Add(90 * time.Second)
+	s.txActivityP.Record(0, now.Add(-30*time.Second), now, []tx_reason_t{TX_REASON_BEACON})
+
+	var st = s.status(now)
+
+	assert.Equal(t, SAMOYED_VERSION, st.Version)
+	assert.Equal(t, int64(90), st.UptimeSeconds)
+
+	require.Len(t, st.Channels, 1)
+
+	var c = st.Channels[0]
+	assert.Equal(t, 0, c.Channel)
+	assert.Equal(t, "VHF", c.Name)
+	assert.Equal(t, "Q1TEST", c.MyCall)
+	assert.Equal(t, "radio", c.Medium)
+	assert.Equal(t, 1200, c.Baud)
+	assert.Equal(t, 1200, c.Mark)
+	assert.Equal(t, 2200, c.Space)
+	require.NotNil(t, c.AudioDevice)
+	assert.Equal(t, 0, *c.AudioDevice)
+	require.NotNil(t, c.AudioLevel)
+	assert.Equal(t, 35, *c.AudioLevel)
+	require.NotNil(t, c.TxDuty10m)
+	assert.InDelta(t, 5.0, *c.TxDuty10m, 0.001)
+	require.NotNil(t, c.TxDuty60m)
+	assert.InDelta(t, 30.0/36, *c.TxDuty60m, 0.001)
+
+	require.Len(t, st.AudioDevices, 1)
+	assert.Equal(t, "plughw:1,0", st.AudioDevices[0].Input)
+	assert.Equal(t, "plughw:1,0", st.AudioDevices[0].Output)
+	assert.Equal(t, 1, st.AudioDevices[0].Channels)
+
+	require.NotNil(t, st.IGate)
+	assert.Equal(t, "noam.aprs2.net:14580", st.IGate.Server)
+	assert.Equal(t, "Q1TEST-10", st.IGate.Login)
+	assert.True(t, st.IGate.Connected)
+	assert.Equal(t, &connected, st.IGate.ConnectedSince)
+
+	assert.Equal(t, StatusClients{AGW: 1, KISSTCP: 2, WebSocket: 3}, st.Clients)
+}
+
+func Test_http_status_igate(t *testing.T) {
+	var s = status_test_service(t)
+
+	s.igateConnected = func() time.Time { return time.Time{} }
+
+	var st = s.status(time.Now())
+	require.NotNil(t, st.IGate)
+	assert.False(t, st.IGate.Connected)
+	assert.Nil(t, st.IGate.ConnectedSince)
+
+	s.igateConfigP = nil
+	assert.Nil(t, s.status(time.Now()).IGate, "not configured")
+}
+
+func Test_http_status_endpoints(t *testing.T) {
+	var s = status_test_service(t)
+	s.igateConnected = func() time.Time { return time.Time{} }
+
+	var mux = http_server_mux(s, nil)
+
+	var rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "ok\n", rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/status", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+
+	var got map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Contains(t, got, "channels")
+	assert.Contains(t, got, "audio_devices")
+	assert.Contains(t, got, "igate")
+	assert.Equal(t, map[string]any{"agw": 1.0, "kiss_tcp": 2.0, "websocket": 3.0}, got["clients"])
+}
@@ -0,0 +1,334 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Push received packets to WebSocket clients as JSON.
+ *
+ * Description:	Connect to ws://host:port/stream, or
+ *		ws://host:port/stream?channel=1 for only one radio
+ *		channel, and each packet received arrives as a text
+ *		message like this:
+ *
+ *			{"time":"2024-05-06T12:34:56.789Z","channel":0,
+ *			 "source":"Q1TEST","destination":"APDW18",
+ *			 "path":["WIDE1-1*","WIDE2-1"],"heard":"Q1TEST",
+ *			 "audio_level":"50(25/24)","info":"!4237.14N/07120.83W-",
+ *			 "aprs":{"type":"Position","latitude":42.619,
+ *			 "longitude":-71.347,"symbol":"/-"}}
+ *
+ *		"aprs" is only there for APRS packets.  A client which
+ *		can't keep up misses packets rather than holding up
+ *		everything else.
+ *
+ *		A browser only connects from a page served by us,
+ *		unless HTTPORIGIN allows others.  See stream_check_origin.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+/* Packets waiting for each client before it starts missing them. */
+
+const STREAM_CLIENT_QUEUE = 100
+
+type StreamAPRS struct {
+	Type      string   `json:"type"`
+	Source    string   `json:"source,omitempty"` // If different, e.g. third party.
+	Name      string   `json:"name,omitempty"`   // Object or item.
+	Latitude  *float64 `json:"latitude,omitempty"`
+	Longitude *float64 `json:"longitude,omitempty"`
+	Symbol    string   `json:"symbol,omitempty"`
+	Addressee string   `json:"addressee,omitempty"`
+	Comment   string   `json:"comment,omitempty"`
+}
+
+type StreamPacket struct {
+	Time        time.Time   `json:"time"`
+	Channel     int         `json:"channel"`
+	Source      string      `json:"source"`
+	Destination string      `json:"destination"`
+	Path        []string    `json:"path"`
+	Heard       string      `json:"heard"`
+	AudioLevel  string      `json:"audio_level,omitempty"`
+	Info        string      `json:"info"`
+	APRS        *StreamAPRS `json:"aprs,omitempty"`
+}
+
+type streamClient struct {
+	channel int // -1 for all.
+	queue   chan []byte
+}
+
+type packetStreamService struct {
+	mu      sync.Mutex
+	clients map[*streamClient]bool
+}
+
+var packetStream = &packetStreamService{clients: make(map[*streamClient]bool)} //nolint:exhaustruct
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        stream_packet
+ *
+ * Purpose:     Make the JSON form of a packet.
+ *
+ * Inputs:	channel	- Radio channel where received.
+ *
+ *		pp	- Packet.
+ *
+ *		alevel	- Audio level, or negative rec if not applicable.
+ *
+ *		now	- Time received.
+ *
+ *--------------------------------------------------------------------*/
+
+func stream_packet(channel int, pp *packet_t, alevel ALevel, now time.Time) *StreamPacket {
+	var p = new(StreamPacket)
+
+	p.Time = now
+	p.Channel = channel
+	p.Path = []string{}
+	p.Info = string(AX25GetInfo(pp))
+
+	var num_addr = ax25_get_num_addr(pp)
+
+	if num_addr >= 2 {
+		p.Source = ax25_get_addr_with_ssid(pp, AX25_SOURCE)
+		p.Destination = ax25_get_addr_with_ssid(pp, AX25_DESTINATION)
+		p.Heard = ax25_get_addr_with_ssid(pp, ax25_get_heard(pp))
+
+		for n := AX25_REPEATER_1; n < num_addr; n++ {
+			var addr = ax25_get_addr_with_ssid(pp, n)
+			if ax25_get_h(pp, n) != 0 {
+				addr += "*"
+			}
+
+			p.Path = append(p.Path, addr)
+		}
+	}
+
+	if alevel.rec >= 0 {
+		p.AudioLevel = ax25_alevel_to_text(alevel)
+	}
+
+	if ax25_is_aprs(pp) {
+		var A = decode_aprs(pp, true, "")
+
+		var a = new(StreamAPRS)
+		a.Type = A.g_data_type_desc
+		a.Name = A.g_name
+		a.Addressee = A.g_addressee
+		a.Comment = A.g_comment
+
+		if A.g_src != p.Source {
+			a.Source = A.g_src
+		}
+
+		if A.g_lat != G_UNKNOWN && A.g_lon != G_UNKNOWN {
+			var lat, lon = A.g_lat, A.g_lon
+			a.Latitude = &lat
+			a.Longitude = &lon
+		}
+
+		if A.g_symbol_table != 0 && A.g_symbol_code != 0 {
+			a.Symbol = string([]byte{A.g_symbol_table, A.g_symbol_code})
+		}
+
+		p.APRS = a
+	}
+
+	return p
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        Send
+ *
+ * Purpose:     Send a received packet to all WebSocket clients.
+ *
+ * Description:	Does nothing, quickly, if there are none.
+ *
+ *--------------------------------------------------------------------*/
+
+func (s *packetStreamService) Send(channel int, pp *packet_t, alevel ALevel) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if len(s.clients) == 0 {
+		return
+	}
+
+	var msg, _ = json.Marshal(stream_packet(channel, pp, alevel, time.Now()))
+
+	s.publishLocked(channel, msg)
+}
+
+/* Send a message, already JSON, to clients for channel. */
+
+func (s *packetStreamService) publish(channel int, msg []byte) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.publishLocked(channel, msg)
+}
+
+func (s *packetStreamService) publishLocked(channel int, msg []byte) {
+	for c := range s.clients {
+		if c.channel >= 0 && c.channel != channel {
+			continue
+		}
+
+		select {
+		case c.queue <- msg:
+		default: // Client is too slow.  It misses this one.
+		}
+	}
+}
+
+func (s *packetStreamService) add(c *streamClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.clients[c] = true
+}
+
+func (s *packetStreamService) remove(c *streamClient) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.clients, c)
+}
+
+func (s *packetStreamService) count() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return len(s.clients)
+}
+
+/* Number of clients which get frames received on channel. */
+
+func (s *packetStreamService) countChannel(channel int) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var n = 0
+
+	for c := range s.clients {
+		if c.channel < 0 || c.channel == channel {
+			n++
+		}
+	}
+
+	return n
+}
+
+/* origins is from HTTPORIGIN. */
+
+func (s *packetStreamService) handler(origins []string) http.Handler {
+	var server = new(websocket.Server)
+
+	server.Handshake = func(_ *websocket.Config, r *http.Request) error { return stream_check_origin(r, origins) }
+	server.Handler = s.serve
+
+	return server
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        stream_check_origin
+ *
+ * Purpose:     Refuse a WebSocket from a page served somewhere else.
+ *
+ * Inputs:	r	- The request to upgrade.
+ *
+ *		origins	- From HTTPORIGIN, e.g. "https://map.example.org",
+ *			  or "*" for any.
+ *
+ * Returns:	nil to accept it.  An error is a 403 Forbidden.
+ *
+ * Description:	A browser lets any page open a WebSocket to anywhere,
+ *		so without this, a web site could read the packet stream
+ *		through the browser of anyone on the same network.  It
+ *		does always send Origin, which is accepted if it is us,
+ *		with the same host and port as the request, or listed.
+ *
+ *		No Origin at all is a program rather than a browser.
+ *
+ *--------------------------------------------------------------------*/
+
+func stream_check_origin(r *http.Request, origins []string) error {
+	var origin = r.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+
+	for _, o := range origins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return nil
+		}
+	}
+
+	var u, err = url.Parse(origin)
+	if err == nil && u.Host != "" && strings.EqualFold(u.Host, r.Host) {
+		return nil
+	}
+
+	return fmt.Errorf("origin %s is not allowed by HTTPORIGIN", origin)
+}
+
+func (s *packetStreamService) serve(ws *websocket.Conn) {
+	defer ws.Close()
+
+	var c = new(streamClient)
+	c.channel = -1
+	c.queue = make(chan []byte, STREAM_CLIENT_QUEUE)
+
+	if ch := ws.Request().URL.Query().Get("channel"); ch != "" {
+		var n, err = strconv.Atoi(ch)
+		if err != nil || n < 0 || n >= MAX_TOTAL_CHANS {
+			_ = websocket.Message.Send(ws, `{"error":"invalid channel"}`)
+
+			return
+		}
+
+		c.channel = n
+	}
+
+	s.add(c)
+	defer s.remove(c)
+
+	/* Anything from the client is ignored.  Reading finds out when it goes away. */
+
+	var gone = make(chan struct{})
+
+	go func() {
+		_, _ = io.Copy(io.Discard, ws)
+
+		close(gone)
+	}()
+
+	for {
+		select {
+		case msg := <-c.queue:
+			var err = websocket.Message.Send(ws, string(msg))
+			if err != nil {
+				return
+			}
+		case <-gone:
+			return
+		}
+	}
+}
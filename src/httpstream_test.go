@@ -0,0 +1,184 @@
+package direwolf
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func Test_stream_packet(t *testing.T) {
+	var pp = AX25FromText("Q1TEST>APDW18,Q2TEST*,WIDE2-1:!4237.14N/07120.83W-Hello", true)
+	require.NotNil(t, pp)
+
+	defer AX25Delete(pp)
+
+	var when = time.Date(2024, 5, 6, 12, 34, 56, 0, time.UTC)
+
+	var p = stream_packet(1, pp, ALevel{rec: 50, mark: 25, space: 24}, when)
+
+	var j, err = json.Marshal(p)
+	require.NoError(t, err)
+
+	assert.JSONEq(t, `{
+		"time": "2024-05-06T12:34:56Z",
+		"channel": 1,
+		"source": "Q1TEST",
+		"destination": "APDW18",
+		"path": ["Q2TEST*", "WIDE2-1"],
+		"heard": "Q2TEST",
+		"audio_level": "50(25/24)",
+		"info": "!4237.14N/07120.83W-Hello",
+		"aprs": {
+			"type": "Position",
+			"latitude": 42.619,
+			"longitude": -71.34716666666667,
+			"symbol": "/-",
+			"comment": "Hello"
+		}
+	}`, string(j))
+}
+
+func Test_stream_packet_not_aprs(t *testing.T) {
+	var pp = AX25FromText("Q1TEST>Q2TEST:Hello", true)
+	require.NotNil(t, pp)
+
+	defer AX25Delete(pp)
+
+	ax25_set_pid(pp, AX25_PID_NETROM)
+
+	var p = stream_packet(0, pp, ALevel{rec: -1, mark: 0, space: 0}, time.Now())
+
+	assert.Nil(t, p.APRS)
+	assert.Empty(t, p.AudioLevel)
+	assert.Equal(t, []string{}, p.Path)
+	assert.Equal(t, "Q1TEST", p.Heard)
+}
+
+func stream_test_connect(t *testing.T, url string, origin string) *websocket.Conn {
+	t.Helper()
+
+	var before = packetStream.count()
+
+	var ws, err = websocket.Dial(url, "", origin)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool { return packetStream.count() > before }, 5*time.Second, 10*time.Millisecond)
+
+	return ws
+}
+
+func Test_stream_websocket(t *testing.T) {
+	var server = httptest.NewServer(http_server_mux(new_http_status(new(audio_s), nil), nil))
+	defer server.Close()
+
+	var url = "ws" + strings.TrimPrefix(server.URL, "http") + "/stream"
+
+	var all = stream_test_connect(t, url, server.URL)
+	defer all.Close()
+
+	var ch1 = stream_test_connect(t, url+"?channel=1", server.URL)
+	defer ch1.Close()
+
+	for _, p := range []struct {
+		channel int
+		text    string
+	}{
+		{0, "Q1TEST>APDW18:>Channel 0"},
+		{1, "Q1TEST>APDW18:>Channel 1"},
+	} {
+		var pp = AX25FromText(p.text, true)
+		require.NotNil(t, pp)
+
+		packetStream.Send(p.channel, pp, ALevel{rec: 50, mark: 25, space: 24})
+		AX25Delete(pp)
+	}
+
+	var received = func(ws *websocket.Conn) StreamPacket {
+		var msg string
+
+		require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+		require.NoError(t, websocket.Message.Receive(ws, &msg))
+
+		var p StreamPacket
+
+		require.NoError(t, json.Unmarshal([]byte(msg), &p))
+
+		return p
+	}
+
+	assert.Equal(t, ">Channel 0", received(all).Info)
+	assert.Equal(t, ">Channel 1", received(all).Info)
+	assert.Equal(t, ">Channel 1", received(ch1).Info)
+
+	/* Client going away is noticed. */
+
+	all.Close()
+	ch1.Close()
+
+	require.Eventually(t, func() bool { return packetStream.count() == 0 }, 5*time.Second, 10*time.Millisecond)
+}
+
+func Test_stream_bad_channel(t *testing.T) {
+	var server = httptest.NewServer(http_server_mux(new_http_status(new(audio_s), nil), nil))
+	defer server.Close()
+
+	var ws, err = websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/stream?channel=x", "", server.URL)
+	require.NoError(t, err)
+
+	defer ws.Close()
+
+	var msg string
+
+	require.NoError(t, websocket.Message.Receive(ws, &msg))
+	assert.Contains(t, msg, "invalid channel")
+}
+
+func Test_stream_check_origin(t *testing.T) {
+	var request = func(origin string) *http.Request {
+		var r = httptest.NewRequest(http.MethodGet, "http://10.0.0.5:8080/stream", nil)
+		if origin != "" {
+			r.Header.Set("Origin", origin)
+		}
+
+		return r
+	}
+
+	require.NoError(t, stream_check_origin(request(""), nil), "not a browser")
+	require.NoError(t, stream_check_origin(request("http://10.0.0.5:8080"), nil))
+	require.Error(t, stream_check_origin(request("http://10.0.0.5:8000"), nil))
+	require.Error(t, stream_check_origin(request("https://evil.example.com"), nil))
+	require.Error(t, stream_check_origin(request("null"), nil))
+
+	var origins = []string{"https://map.example.org"}
+
+	require.NoError(t, stream_check_origin(request("https://map.example.org"), origins))
+	require.Error(t, stream_check_origin(request("http://map.example.org"), origins))
+	require.Error(t, stream_check_origin(request("https://evil.example.com"), origins))
+
+	require.NoError(t, stream_check_origin(request("https://evil.example.com"), []string{"*"}))
+}
+
+func Test_stream_other_origin(t *testing.T) {
+	var server = httptest.NewServer(http_server_mux(new_http_status(new(audio_s), nil), []string{"https://map.example.org"}))
+	defer server.Close()
+
+	var url = "ws" + strings.TrimPrefix(server.URL, "http")
+
+	for _, path := range []string{"/stream", "/spectrum/stream"} {
+		var _, err = websocket.Dial(url+path, "", "https://evil.example.com")
+		require.Error(t, err, path)
+
+		var ws, okErr = websocket.Dial(url+path, "", "https://map.example.org")
+		require.NoError(t, okErr, path)
+		ws.Close()
+	}
+
+	require.Eventually(t, func() bool { return packetStream.count() == 0 }, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,61 @@
+package direwolf
+
+/* Linux I2C bus access, through /dev/i2c-N, for mcp23017.go. */
+
+import (
+	"errors"
+	"os"
+
+	"golang.org/x/sys/unix"
+)
+
+const I2C_SLAVE = 0x0703 /* ioctl to set the address for following reads and writes. */
+
+type i2c_dev_s struct {
+	f *os.File
+}
+
+func i2c_open(path string, addr int) (i2cRegisters, error) {
+	var f, err = os.OpenFile(path, os.O_RDWR, 0) //nolint:gosec // This comes from user-supplied config
+	if err != nil {
+		return nil, err
+	}
+
+	err = unix.IoctlSetInt(int(f.Fd()), I2C_SLAVE, addr)
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+
+	return &i2c_dev_s{f: f}, nil
+}
+
+func (d *i2c_dev_s) ReadReg(reg byte) (byte, error) {
+	var _, err = d.f.Write([]byte{reg})
+	if err != nil {
+		return 0, err
+	}
+
+	var b = make([]byte, 1)
+
+	var n, readErr = d.f.Read(b)
+	if readErr != nil {
+		return 0, readErr
+	}
+
+	if n != 1 {
+		return 0, errors.New("short read from I2C device")
+	}
+
+	return b[0], nil
+}
+
+func (d *i2c_dev_s) WriteReg(reg byte, val byte) error {
+	var _, err = d.f.Write([]byte{reg, val})
+
+	return err
+}
+
+func (d *i2c_dev_s) Close() error {
+	return d.f.Close()
+}
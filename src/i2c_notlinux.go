@@ -0,0 +1,9 @@
+//go:build !linux
+
+package direwolf
+
+import "errors"
+
+func i2c_open(_ string, _ int) (i2cRegisters, error) {
+	return nil, errors.New("I2C is only supported on Linux")
+}
@@ -31,6 +31,7 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -137,7 +138,11 @@ var ok_to_send = false
 var save_igate_config_p *igate_config_s
 
 // TODO KG static struct digi_config_s 	*save_digi_config_p;
-var s_debug int
+var s_debug atomic.Int32 /* Changed from the control socket while running. */
+
+func igate_set_debug(n int) {
+	s_debug.Store(int32(n))
+}
 
 /*
  * Statistics for IGate function.
@@ -159,8 +164,7 @@ var stats_connects int //nolint:unused
 /* Could be larger if one disappears and we */
 /* try again to find a different one. */
 
-var stats_connect_at time.Time //nolint:unused
-/* Most recent time connection was established. */
+var stats_connect_at time.Time /* Most recent time connection was established. */
 /* can be used to determine elapsed connect time. */
 
 var stats_rf_recv_packets int //nolint:unused
@@ -216,6 +220,27 @@ func igate_get_dnl_cnt() int {
 	return (stats_downlink_packets)
 }
 
+/*
+ * The IGate can be turned off, in both directions, without
+ * disconnecting from the server, e.g. by DTMF remote control.
+ */
+
+var igate_disabled atomic.Bool
+
+func igate_set_enabled(on bool) {
+	igate_disabled.Store(!on)
+}
+
+/* For HTTP status.  Zero time if not connected to a server. */
+
+func igate_get_connected_since() time.Time {
+	if igate_sock == nil || !ok_to_send {
+		return time.Time{}
+	}
+
+	return stats_connect_at
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        igate_init
@@ -246,7 +271,7 @@ func igate_get_dnl_cnt() int {
  *--------------------------------------------------------------------*/
 
 func igate_init(p_audio_config *audio_s, p_igate_config *igate_config_s, p_digi_config *digi_config_s, debug_level int) {
-	s_debug = debug_level
+	s_debug.Store(int32(debug_level))
 	dp_queue_head = nil
 
 	/* TODO KG
@@ -458,13 +483,25 @@ const IGATE_MAX_MSG = 512 /* "All 'packets' sent to APRS-IS must be in the TNC2
 
 func igate_send_rec_packet(channel int, recv_pp *packet_t) {
 	if igate_sock == nil {
+		if save_igate_config_p != nil && save_igate_config_p.t2_server_name != "" {
+			trace_printf(recv_pp, "IGate: not connected to server")
+		}
+
 		return /* Silently discard if not connected. */
 	}
 
 	if !ok_to_send {
+		trace_printf(recv_pp, "IGate: login not complete")
+
 		return /* Login not complete. */
 	}
 
+	if igate_disabled.Load() {
+		trace_printf(recv_pp, "IGate: turned off")
+
+		return
+	}
+
 	/* Gather statistics. */
 
 	stats_rf_recv_packets++
@@ -487,11 +524,13 @@ func igate_send_rec_packet(channel int, recv_pp *packet_t) {
 			// Is this useful troubleshooting information or just distracting noise?
 			// Originally this was always printed but there was a request to add a "quiet" option to suppress this.
 			// version 1.4: Instead, make the default off and activate it only with the debug igate option.
-			if s_debug >= 1 {
+			if s_debug.Load() >= 1 {
 				text_color_set(DW_COLOR_INFO)
 				dw_printf("Packet from channel %d to IGate was rejected by filter: %s\n", channel, save_digi_config_p.filter_str[channel][MAX_TOTAL_CHANS])
 			}
 
+			trace_printf(recv_pp, "IGate: rejected by filter")
+
 			return
 		}
 	}
@@ -515,24 +554,26 @@ func igate_send_rec_packet(channel int, recv_pp *packet_t) {
 				via == "TCPXX" ||
 				via == "RFONLY" ||
 				via == "NOGATE" {
-				if s_debug >= 1 {
+				if s_debug.Load() >= 1 {
 					text_color_set(DW_COLOR_DEBUG)
 					dw_printf("Rx IGate: Do not relay with %s in path.\n", via)
 				}
 
+				trace_printf(pp, "IGate: not relayed with %s in third party path", via)
 				AX25Delete(pp)
 
 				return
 			}
 		}
 
-		if s_debug >= 1 {
+		if s_debug.Load() >= 1 {
 			text_color_set(DW_COLOR_DEBUG)
 			dw_printf("Rx IGate: Unwrap third party message.\n")
 		}
 
 		var inner_pp = ax25_unwrap_third_party(pp)
 		if inner_pp == nil {
+			trace_printf(pp, "IGate: can't unwrap third party payload")
 			AX25Delete(pp)
 			return
 		}
@@ -552,11 +593,12 @@ func igate_send_rec_packet(channel int, recv_pp *packet_t) {
 			via == "TCPXX" ||
 			via == "RFONLY" ||
 			via == "NOGATE" {
-			if s_debug >= 1 {
+			if s_debug.Load() >= 1 {
 				text_color_set(DW_COLOR_DEBUG)
 				dw_printf("Rx IGate: Do not relay with %s in path.\n", via)
 			}
 
+			trace_printf(pp, "IGate: not relayed with %s in path", via)
 			AX25Delete(pp)
 
 			return
@@ -568,11 +610,12 @@ func igate_send_rec_packet(channel int, recv_pp *packet_t) {
 	 * TODO:  Should probably block in other direction too, in case rf>is gateway did not drop.
 	 */
 	if ax25_get_dti(pp) == '?' {
-		if s_debug >= 1 {
+		if s_debug.Load() >= 1 {
 			text_color_set(DW_COLOR_DEBUG)
 			dw_printf("Rx IGate: Do not relay generic query.\n")
 		}
 
+		trace_printf(pp, "IGate: not relaying generic query")
 		AX25Delete(pp)
 
 		return
@@ -586,7 +629,7 @@ func igate_send_rec_packet(channel int, recv_pp *packet_t) {
 	 */
 
 	if ax25_cut_at_crlf(pp) > 0 {
-		if s_debug >= 1 {
+		if s_debug.Load() >= 1 {
 			text_color_set(DW_COLOR_DEBUG)
 			dw_printf("Rx IGate: Truncated information part at CR.\n")
 		}
@@ -598,11 +641,12 @@ func igate_send_rec_packet(channel int, recv_pp *packet_t) {
 	 * Someone around here occasionally sends a packet with no information part.
 	 */
 	if len(pinfo) == 0 {
-		if s_debug >= 1 {
+		if s_debug.Load() >= 1 {
 			text_color_set(DW_COLOR_DEBUG)
 			dw_printf("Rx IGate: Information part length is zero.\n")
 		}
 
+		trace_printf(pp, "IGate: empty information part")
 		AX25Delete(pp)
 
 		return
@@ -619,6 +663,7 @@ func igate_send_rec_packet(channel int, recv_pp *packet_t) {
 	if save_igate_config_p.satgate_delay > 0 &&
 		ax25_get_heard(pp) == AX25_SOURCE &&
 		ax25_get_num_repeaters(pp) > 0 {
+		trace_printf(pp, "IGate: delayed %d seconds for SATgate mode", save_igate_config_p.satgate_delay)
 		satgate_delay_packet(pp, channel)
 	} else {
 		send_packet_to_server(pp, channel)
@@ -656,11 +701,12 @@ func send_packet_to_server(pp *packet_t, channel int) {
 	 */
 
 	if !rx_to_ig_allow(pp) {
-		if s_debug >= 1 {
+		if s_debug.Load() >= 1 {
 			text_color_set(DW_COLOR_DEBUG)
 			dw_printf("Rx IGate: Drop duplicate of same packet seen recently.\n")
 		}
 
+		trace_printf(pp, "IGate: duplicate of one sent recently")
 		AX25Delete(pp)
 
 		return
@@ -771,6 +817,8 @@ func send_packet_to_server(pp *packet_t, channel int) {
 
 	send_msg_to_server(msg)
 
+	trace_printf(pp, "IGate: sent to server")
+
 	stats_uplink_packets++
 
 	/*
@@ -816,7 +864,7 @@ func send_msg_to_server(imsg string) {
 		}
 	*/
 
-	if s_debug >= 1 {
+	if s_debug.Load() >= 1 {
 		text_color_set(DW_COLOR_XMIT)
 		dw_printf("[rx>ig] ")
 		AX25SafePrint([]byte(imsg), false)
@@ -1249,6 +1297,10 @@ func is_message_message(infop string) bool {
 func maybe_xmit_packet_from_igate(message []byte, to_chan int) {
 	Assert(to_chan >= 0 && to_chan < MAX_TOTAL_CHANS)
 
+	if igate_disabled.Load() {
+		return
+	}
+
 	/*
 	 * Try to parse it into a packet object; we need this for the packet filtering.
 	 *
@@ -1298,7 +1350,7 @@ func maybe_xmit_packet_from_igate(message []byte, to_chan int) {
 			via == "TCPXX" || // TCPXX deprecated.
 			via == "RFONLY" ||
 			via == "NOGATE" {
-			if s_debug >= 1 {
+			if s_debug.Load() >= 1 {
 				text_color_set(DW_COLOR_DEBUG)
 				dw_printf("Tx IGate: Do not transmit with %s in path.\n", via)
 			}
@@ -1351,7 +1403,7 @@ func maybe_xmit_packet_from_igate(message []byte, to_chan int) {
 		if n > 0 {
 			msp_special_case = true
 
-			if s_debug >= 1 {
+			if s_debug.Load() >= 1 {
 				text_color_set(DW_COLOR_INFO)
 				dw_printf("Special case, allow position from message sender %s, %d remaining.\n", src, n-1)
 			}
@@ -1452,6 +1504,7 @@ func maybe_xmit_packet_from_igate(message []byte, to_chan int) {
 			#else
 			*/
 			/* This consumes packet so don't reference it again! */
+			pradio.tx_reason = TX_REASON_IGATE
 			tq_append(to_chan, TQ_PRIO_1_LO, pradio)
 			// TODO KG #endif
 			stats_rf_xmit_packets++ // Any type of packet.
@@ -1570,7 +1623,7 @@ func rx_to_ig_remember(pp *packet_t) {
 	rx2ig_time_stamp[rx2ig_insert_next] = time.Now()
 	rx2ig_checksum[rx2ig_insert_next] = int(ax25_dedupe_crc(pp))
 
-	if s_debug >= 3 {
+	if s_debug.Load() >= 3 {
 		var src = ax25_get_addr_with_ssid(pp, AX25_SOURCE)
 		var dest = ax25_get_addr_with_ssid(pp, AX25_DESTINATION)
 		var pinfo = AX25GetInfo(pp)
@@ -1593,7 +1646,7 @@ func rx_to_ig_allow(pp *packet_t) bool {
 	var crc = ax25_dedupe_crc(pp)
 	var now = time.Now()
 
-	if s_debug >= 2 {
+	if s_debug.Load() >= 2 {
 		var src = ax25_get_addr_with_ssid(pp, AX25_SOURCE)
 		var dest = ax25_get_addr_with_ssid(pp, AX25_DESTINATION)
 		var pinfo = AX25GetInfo(pp)
@@ -1605,7 +1658,7 @@ func rx_to_ig_allow(pp *packet_t) bool {
 	// Do we have duplicate checking at all in the RF>IS direction?
 
 	if save_igate_config_p.rx2ig_dedupe_time == 0 {
-		if s_debug >= 2 {
+		if s_debug.Load() >= 2 {
 			text_color_set(DW_COLOR_DEBUG)
 			dw_printf("rx_to_ig_allow? YES, no dedupe checking\n")
 		}
@@ -1617,7 +1670,7 @@ func rx_to_ig_allow(pp *packet_t) bool {
 
 	for j := range RX2IG_HISTORY_MAX {
 		if rx2ig_checksum[j] == int(crc) && !rx2ig_time_stamp[j].Before(now.Add(-time.Duration(save_igate_config_p.rx2ig_dedupe_time)*time.Second)) {
-			if s_debug >= 2 {
+			if s_debug.Load() >= 2 {
 				text_color_set(DW_COLOR_DEBUG)
 				// could be multiple entries and this might not be the most recent.
 				dw_printf("rx_to_ig_allow? NO. Seen %d seconds ago.\n", int(time.Since(rx2ig_time_stamp[j]).Seconds()))
@@ -1627,7 +1680,7 @@ func rx_to_ig_allow(pp *packet_t) bool {
 		}
 	}
 
-	if s_debug >= 2 {
+	if s_debug.Load() >= 2 {
 		text_color_set(DW_COLOR_DEBUG)
 		dw_printf("rx_to_ig_allow? YES\n")
 	}
@@ -1862,7 +1915,7 @@ func ig_to_tx_remember(pp *packet_t, channel int, bydigi int) {
 	var now = time.Now()
 	var crc = ax25_dedupe_crc(pp)
 
-	if s_debug >= 3 {
+	if s_debug.Load() >= 3 {
 		var src = ax25_get_addr_with_ssid(pp, AX25_SOURCE)
 		var dest = ax25_get_addr_with_ssid(pp, AX25_DESTINATION)
 		var pinfo = AX25GetInfo(pp)
@@ -1892,7 +1945,7 @@ func ig_to_tx_allow(pp *packet_t, channel int) bool {
 
 	var pinfo = AX25GetInfo(pp)
 
-	if s_debug >= 2 {
+	if s_debug.Load() >= 2 {
 		var src = ax25_get_addr_with_ssid(pp, AX25_SOURCE)
 		var dest = ax25_get_addr_with_ssid(pp, AX25_DESTINATION)
 
@@ -1910,13 +1963,13 @@ func ig_to_tx_allow(pp *packet_t, channel int) bool {
 				/* Suppose we transmit a message from station X and it doesn't get an ack back. */
 				/* Station X then sends exactly the same thing 20 seconds later.  */
 				/* We don't want to suppress the retry. */
-				if s_debug >= 2 {
+				if s_debug.Load() >= 2 {
 					text_color_set(DW_COLOR_DEBUG)
 					dw_printf("ig_to_tx_allow? Yes for duplicate message sent %d seconds ago. bydigi=%d\n", int(time.Since(ig2tx_time_stamp[j]).Seconds()), ig2tx_bydigi[j])
 				}
 			} else {
 				/* Normal (non-message) case. */
-				if s_debug >= 2 {
+				if s_debug.Load() >= 2 {
 					text_color_set(DW_COLOR_DEBUG)
 					// could be multiple entries and this might not be the most recent.
 					dw_printf("ig_to_tx_allow? NO. Duplicate sent %d seconds ago. bydigi=%d\n", int(time.Since(ig2tx_time_stamp[j]).Seconds()), ig2tx_bydigi[j])
@@ -1974,7 +2027,7 @@ func ig_to_tx_allow(pp *packet_t, channel int) bool {
 		return false
 	}
 
-	if s_debug >= 2 {
+	if s_debug.Load() >= 2 {
 		text_color_set(DW_COLOR_DEBUG)
 		dw_printf("ig_to_tx_allow? YES\n")
 	}
@@ -60,6 +60,7 @@ package direwolf
 
 import (
 	"os"
+	"sync/atomic"
 
 	"github.com/creack/pty"
 )
@@ -83,10 +84,10 @@ var pt_slave *os.File  /* Pseudo terminal slave */
 
 const TMP_KISSTNC_SYMLINK = "/tmp/kisstnc"
 
-var kisspt_debug = 0 /* Print information flowing from and to client. */
+var kisspt_debug atomic.Int32 /* Print information flowing from and to client. */
 
 func kisspt_set_debug(n int) {
-	kisspt_debug = n
+	kisspt_debug.Store(int32(n))
 }
 
 /*-------------------------------------------------------------------
@@ -268,7 +269,7 @@ func kisspt_send_rec_packet(channel int, kiss_cmd int, fbuf []byte, flen int, kp
 	var kiss_buff []byte
 
 	if flen < 0 {
-		if kisspt_debug > 0 {
+		if kisspt_debug.Load() > 0 {
 			kiss_debug_print(TO_CLIENT, "Fake command prompt", fbuf)
 		}
 
@@ -286,7 +287,7 @@ func kisspt_send_rec_packet(channel int, kiss_cmd int, fbuf []byte, flen int, kp
 		stemp = []byte{byte((channel << 4) | kiss_cmd)}
 		stemp = append(stemp, fbuf...)
 
-		if kisspt_debug >= 2 {
+		if kisspt_debug.Load() >= 2 {
 			/* AX.25 frame with the CRC removed. */
 			text_color_set(DW_COLOR_DEBUG)
 			dw_printf("\n")
@@ -298,7 +299,7 @@ func kisspt_send_rec_packet(channel int, kiss_cmd int, fbuf []byte, flen int, kp
 
 		/* This has KISS framing and escapes for sending to client app. */
 
-		if kisspt_debug > 0 {
+		if kisspt_debug.Load() > 0 {
 			kiss_debug_print(TO_CLIENT, "", kiss_buff)
 		}
 	}
@@ -435,6 +436,6 @@ func kisspt_listen_thread() {
 		if err != nil {
 			return
 		}
-		KissRecByte(kisspt_kf, ch, kisspt_debug, nil, -1, kisspt_send_rec_packet)
+		KissRecByte(kisspt_kf, ch, int(kisspt_debug.Load()), nil, -1, kisspt_send_rec_packet)
 	}
 }
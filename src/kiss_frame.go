@@ -77,6 +77,14 @@ const XKISS_CMD_DATA = 12 // Not supported. http://he.fi/pub/oh7lzb/bpq/multi-ki
 const XKISS_CMD_POLL = 14 // Not supported.
 const KISS_CMD_END_KISS = 15
 
+/* Indexed by command in lower nybble of first byte. */
+
+var kissCmdNames = []string{
+	"Data frame", "TXDELAY", "P", "SlotTime",
+	"TXtail", "FullDuplex", "SetHardware", "Invalid 7",
+	"Invalid 8", "Invalid 9", "Invalid 10", "Invalid 11",
+	"Invalid 12", "Invalid 13", "Invalid 14", "Return"}
+
 /*
  * Special characters used by SLIP protocol.
  */
@@ -135,6 +143,9 @@ type kissport_status_s struct {
 
 	tcp_port int // default 8001
 
+	remote string // host:port we connect to, for KISSCLIENT.
+	// Empty when listening on tcp_port.
+
 	channel int // Radio channel for this tcp port.
 	// -1 for all.
 
@@ -250,20 +261,52 @@ func KissEncapsulate(in []byte) []byte {
  *-----------------------------------------------------------------*/
 
 func kiss_unwrap(in []byte) []byte {
+	return kiss_unwrap_to(nil, in)
+} /* end kiss_unwrap */
+
+/*
+ * Same as kiss_unwrap but append the result to dst, which can be
+ * a pooled frame buffer, rather than allocating a new slice.
+ */
+
+func kiss_unwrap_to(dst []byte, in []byte) []byte {
+	var out, problems = kiss_unwrap_append(dst, in)
+
+	for _, problem := range problems {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("%s\n", problem)
+	}
+
+	return out
+}
+
+/*
+ * Same as kiss_unwrap but return the problems found rather than printing them.
+ * Used by samoyed-kissdump for checking what a TNC sends.
+ */
+
+func kiss_unwrap_check(in []byte) ([]byte, []string) {
+	var out, problems = kiss_unwrap_append(nil, in)
+	if out == nil {
+		out = []byte{}
+	}
+
+	return out, problems
+}
+
+func kiss_unwrap_append(dst []byte, in []byte) ([]byte, []string) {
+	var problems []string
+
 	if len(in) < 2 {
 		/* Need at least the "type indicator" byte and FEND. */
 		/* Probably more. */
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("KISS message less than minimum length.\n")
-
-		return []byte{}
+		return dst, append(problems, "KISS message less than minimum length.")
 	}
 
 	if in[len(in)-1] == FEND {
 		in = in[:len(in)-1] // Ignore last FEND
 	} else {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("KISS frame should end with FEND.\n")
+		problems = append(problems, "KISS frame should end with FEND.")
 	}
 
 	if in[0] == FEND {
@@ -271,35 +314,32 @@ func kiss_unwrap(in []byte) []byte {
 	}
 
 	var escapedMode = false
-	var buf bytes.Buffer
 
 	for _, b := range in {
 		if b == FEND {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("KISS frame should not have FEND in the middle.\n")
+			problems = append(problems, "KISS frame should not have FEND in the middle.")
 		}
 
 		if escapedMode {
 			switch b {
 			case TFESC:
-				buf.WriteByte(FESC)
+				dst = append(dst, FESC)
 			case TFEND:
-				buf.WriteByte(FEND)
+				dst = append(dst, FEND)
 			default:
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("KISS protocol error.  Found 0x%02x after FESC.\n", b)
+				problems = append(problems, fmt.Sprintf("KISS protocol error.  Found 0x%02x after FESC.", b))
 			}
 
 			escapedMode = false
 		} else if b == FESC {
 			escapedMode = true
 		} else {
-			buf.WriteByte(b)
+			dst = append(dst, b)
 		}
 	}
 
-	return buf.Bytes()
-} /* end kiss_unwrap */
+	return dst, problems
+}
 
 /*-------------------------------------------------------------------
  *
@@ -317,11 +357,6 @@ func kiss_unwrap(in []byte) []byte {
 func kiss_debug_print(fromto fromto_t, special string, pmsg []byte) {
 	var direction = []string{"from", "to"}
 	var prefix = []string{"<<<", ">>>"}
-	var function = []string{
-		"Data frame", "TXDELAY", "P", "SlotTime",
-		"TXtail", "FullDuplex", "SetHardware", "Invalid 7",
-		"Invalid 8", "Invalid 9", "Invalid 10", "Invalid 11",
-		"Invalid 12", "Invalid 13", "Invalid 14", "Return"}
 
 	text_color_set(DW_COLOR_DEBUG)
 
@@ -337,7 +372,7 @@ func kiss_debug_print(fromto fromto_t, special string, pmsg []byte) {
 			}
 
 			dw_printf("%s %s %s KISS client application, channel %d, total length = %d\n",
-				prefix[fromto], function[pmsg[0]&0xf], direction[fromto],
+				prefix[fromto], kissCmdNames[pmsg[0]&0xf], direction[fromto],
 				(pmsg[0]>>4)&0xf, len(pmsg))
 		} else {
 			dw_printf("%s %s %s KISS client application, total length = %d\n",
@@ -470,7 +505,11 @@ func KissRecByte(kf *KISSFrame, ch byte, debug int,
 				kiss_debug_print(FROM_CLIENT, "", kf.kiss_msg[:kf.kiss_len])
 			}
 
-			var unwrapped = kiss_unwrap(kf.kiss_msg[:kf.kiss_len])
+			/* Nothing keeps the unwrapped frame past kiss_process_msg so the buffer can be reused. */
+
+			var fb = frame_buf_get()
+			fb.data = kiss_unwrap_to(fb.data, kf.kiss_msg[:kf.kiss_len])
+			var unwrapped = fb.data
 
 			if debug >= 2 {
 				/* Append CRC to this and it goes out over the radio. */
@@ -484,6 +523,8 @@ func KissRecByte(kf *KISSFrame, ch byte, debug int,
 
 			kiss_process_msg(unwrapped, debug, kps, client, sendfun)
 
+			frame_buf_put(fb)
+
 			kf.state = KS_SEARCHING
 
 			return
@@ -523,6 +564,9 @@ func KissRecByte(kf *KISSFrame, ch byte, debug int,
  *		sendfun		- Function to send something to the client application.
  *				  "Set Hardware" can send a response.
  *
+ * Description:	kiss_msg is in a reused buffer and is valid only until
+ *		we return.  Make a copy of anything to be kept.
+ *
  *-----------------------------------------------------------------*/
 
 // This is used only by the TNC side.
@@ -638,6 +682,7 @@ func kiss_process_msg(kiss_msg []byte, debug int, kps *kissport_status_s, client
 			/* that digipeater has been used, it should go out quickly thru */
 			/* the high priority queue. */
 			/* Otherwise, it is an original for the low priority queue. */
+			pp.tx_reason = TX_REASON_CLIENT
 			if ax25_get_num_repeaters(pp) >= 1 &&
 				ax25_get_h(pp, AX25_REPEATER_1) > 0 {
 				tq_append(channel, TQ_PRIO_0_HI, pp)
@@ -0,0 +1,364 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Show exactly what a KISS TNC is sending, for debugging
+ *		a hardware TNC or serial link that isn't behaving.
+ *
+ * Description:	Connect to a KISS TNC by TCP or serial port, like
+ *		kissutil, but rather than only printing the packets,
+ *		print each KISS frame in hexadecimal with an explanation
+ *		of what it contains and anything wrong with it:
+ *
+ *		- Text or noise outside of a frame.
+ *		- Bad escape sequences.
+ *		- Frames that are too long.
+ *		- Commands a TNC should not send.
+ *		- Data frames that are not valid AX.25.
+ *
+ *		Nothing is sent to the TNC.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/spf13/pflag"
+)
+
+type kissdumpParser struct {
+	collecting bool
+	buf        []byte /* Frame so far, including escapes and leading FEND. */
+	noise      []byte /* Anything before the first FEND. */
+
+	frame func(wire []byte)  /* Called with a complete frame, FEND at each end. */
+	other func(noise []byte) /* Called with bytes that are not part of a frame. */
+}
+
+func KissdumpMain() {
+	TextColorInit(0)
+
+	var hostname = pflag.StringP("hostname", "h", "localhost", "Hostname of TCP KISS TNC.")
+	var port = pflag.StringP("port", "p", "8001", "Port.  If it does not start with a digit, it is treated as a serial port, e.g. /dev/ttyUSB0.")
+	var serialSpeed = pflag.IntP("serial-speed", "s", 9600, "Serial port speed.")
+	var raw = pflag.BoolP("raw", "r", false, "Show bytes exactly as received, including FEND and escapes.")
+	var help = pflag.Bool("help", false, "Display help text.")
+
+	pflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "%s - print everything a KISS TNC sends, in hexadecimal with explanations.\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "\n")
+		fmt.Fprintf(os.Stderr, "The TNC can be attached by TCP or a serial port.  Nothing is sent to it.\n")
+		fmt.Fprintf(os.Stderr, "\n")
+		pflag.PrintDefaults()
+	}
+
+	pflag.Parse()
+
+	if *help || pflag.NArg() > 0 {
+		pflag.Usage()
+		os.Exit(1)
+	}
+
+	var kp = &kissdumpParser{ //nolint:exhaustruct
+		frame: func(wire []byte) { kissdump_frame(time.Now(), wire, *raw) },
+		other: func(noise []byte) { kissdump_noise(time.Now(), noise) },
+	}
+
+	if *port != "" && unicode.IsDigit(rune((*port)[0])) {
+		var conn, err = net.Dial("tcp", net.JoinHostPort(*hostname, *port))
+		if err != nil {
+			fmt.Printf("Unable to connect to %s on port %s: %s\n", *hostname, *port, err)
+			os.Exit(1)
+		}
+
+		fmt.Printf("Connected to KISS TNC %s port %s.\n", *hostname, *port)
+
+		var data = make([]byte, 4096)
+
+		for {
+			var n, readErr = conn.Read(data)
+
+			for _, b := range data[:n] {
+				kp.add(b)
+			}
+
+			if readErr == io.EOF {
+				fmt.Printf("\nConnection closed by TNC.\n")
+				os.Exit(0)
+			} else if readErr != nil {
+				fmt.Printf("\nRead error from TCP KISS TNC: %s\n", readErr)
+				os.Exit(1)
+			}
+		}
+	}
+
+	var fd = SerialPortOpen(*port, *serialSpeed)
+	if fd == nil {
+		fmt.Printf("Unable to open KISS TNC serial port %s.\n", *port)
+		os.Exit(1)
+	}
+
+	fmt.Printf("Opened KISS TNC serial port %s at %d bps.\n", *port, *serialSpeed)
+
+	for {
+		var b, err = SerialPortGet1(fd)
+		if err != nil {
+			fmt.Printf("\nRead error from serial port KISS TNC: %s\n", err)
+			os.Exit(1)
+		}
+
+		kp.add(b)
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        add
+ *
+ * Purpose:     Process one byte from the TNC.
+ *
+ * Description:	Unlike KissRecByte, a FEND which ends one frame can
+ *		also start the next, so TNCs which send only one FEND
+ *		between frames are shown correctly.  Anything else
+ *		found between frames is then shown as a bad frame
+ *		rather than thrown away.
+ *
+ *--------------------------------------------------------------------*/
+
+func (kp *kissdumpParser) add(b byte) {
+	if !kp.collecting {
+		if b == FEND {
+			kp.flush_noise()
+			kp.collecting = true
+			kp.buf = []byte{FEND}
+
+			return
+		}
+
+		kp.noise = append(kp.noise, b)
+
+		if b == '\r' || len(kp.noise) >= MAX_NOISE_LEN {
+			kp.flush_noise()
+		}
+
+		return
+	}
+
+	kp.buf = append(kp.buf, b)
+
+	if b != FEND {
+		if len(kp.buf) > 2*MAX_KISS_LEN {
+			/* Give up waiting for the end. */
+			kp.frame(kp.buf)
+			kp.buf = nil
+			kp.collecting = false
+		}
+
+		return
+	}
+
+	if len(kp.buf) == 2 {
+		/* Empty frame.  Just go on collecting. */
+		kp.buf = kp.buf[:1]
+
+		return
+	}
+
+	kp.frame(kp.buf)
+	kp.buf = []byte{FEND}
+}
+
+func (kp *kissdumpParser) flush_noise() {
+	if len(kp.noise) > 0 {
+		kp.other(kp.noise)
+		kp.noise = nil
+	}
+}
+
+func kissdump_noise(t time.Time, noise []byte) {
+	text_color_set(DW_COLOR_ERROR)
+	dw_printf("\n%s  Not in a KISS frame, %d bytes\n", t.Format("15:04:05.000"), len(noise))
+	HexDump(noise)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        kissdump_frame
+ *
+ * Purpose:     Print one KISS frame with an explanation.
+ *
+ * Inputs:	t	- When it was received.
+ *
+ *		wire	- Frame as received, starting and ending with FEND.
+ *
+ *		raw	- Show the bytes as received rather than after
+ *			  removing the framing and escapes.
+ *
+ *--------------------------------------------------------------------*/
+
+func kissdump_frame(t time.Time, wire []byte, raw bool) {
+	var msg, problems = kiss_unwrap_check(wire)
+
+	if len(wire) > MAX_KISS_LEN {
+		problems = append(problems, fmt.Sprintf("KISS frame length %d is more than the usual maximum of %d.", len(wire), MAX_KISS_LEN))
+	}
+
+	var summary, details, more = kissdump_explain(msg)
+
+	problems = append(problems, more...)
+
+	text_color_set(DW_COLOR_DEBUG)
+	dw_printf("\n%s  %s, %d bytes\n", t.Format("15:04:05.000"), summary, len(msg))
+
+	if raw {
+		HexDump(wire)
+	} else {
+		HexDump(msg)
+	}
+
+	text_color_set(DW_COLOR_REC)
+
+	for _, d := range details {
+		dw_printf("  %s\n", d)
+	}
+
+	text_color_set(DW_COLOR_ERROR)
+
+	for _, p := range problems {
+		dw_printf("  !! %s\n", p)
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        kissdump_explain
+ *
+ * Purpose:     Describe a KISS message.
+ *
+ * Inputs:	msg	- KISS frame with FEND and escapes removed.
+ *
+ * Returns:	summary		- Command and channel.
+ *		details		- Lines describing the contents.
+ *		problems	- Anything wrong with it.
+ *
+ *--------------------------------------------------------------------*/
+
+func kissdump_explain(msg []byte) (string, []string, []string) {
+	var details, problems []string
+
+	if len(msg) == 0 {
+		return "Empty", nil, nil
+	}
+
+	if msg[0] == 0xff {
+		return "Return", nil, []string{"A TNC should not send the command to leave KISS mode."}
+	}
+
+	var channel = int(msg[0]>>4) & 0xf
+	var cmd = int(msg[0]) & 0xf
+	var data = msg[1:]
+
+	var summary = fmt.Sprintf("%s, channel %d", kissCmdNames[cmd], channel)
+
+	/* Parameters are one byte, in 10 ms units where it is a time. */
+
+	var param = func(format string, scale int) {
+		if len(data) != 1 {
+			problems = append(problems, fmt.Sprintf("%s should have 1 byte of data but has %d.", kissCmdNames[cmd], len(data)))
+		}
+
+		if len(data) > 0 {
+			details = append(details, fmt.Sprintf(format, int(data[0])*scale))
+		}
+
+		problems = append(problems, "Parameters normally go to a TNC, not come from it.")
+	}
+
+	switch cmd {
+	case KISS_CMD_DATA_FRAME:
+		var d, p = kissdump_ax25(data)
+		details = append(details, d...)
+		problems = append(problems, p...)
+	case KISS_CMD_TXDELAY:
+		param("Transmit delay %d ms.", 10)
+	case KISS_CMD_PERSISTENCE:
+		param("Persistence %d/256.", 1)
+	case KISS_CMD_SLOTTIME:
+		param("Slot time %d ms.", 10)
+	case KISS_CMD_TXTAIL:
+		param("Transmit tail %d ms.", 10)
+	case KISS_CMD_FULLDUPLEX:
+		param("Full duplex %d, 0 is off.", 1)
+	case KISS_CMD_SET_HARDWARE:
+		details = append(details, fmt.Sprintf("\"%s\"", kissdump_safe(data)))
+	case XKISS_CMD_DATA, XKISS_CMD_POLL:
+		problems = append(problems, "XKISS (multi-drop KISS) is not supported.")
+	default:
+		problems = append(problems, fmt.Sprintf("Command %d is not defined by KISS.  Is this really a KISS TNC?", cmd))
+	}
+
+	return summary, details, problems
+}
+
+/*
+ * Data frame should be AX.25 without the FCS.
+ */
+
+func kissdump_ax25(data []byte) ([]string, []string) {
+	if len(data) < AX25_MIN_PACKET_LEN || len(data) > AX25_MAX_PACKET_LEN {
+		return nil, []string{fmt.Sprintf("AX.25 frame length %d is not in allowable range of %d to %d.", len(data), AX25_MIN_PACKET_LEN, AX25_MAX_PACKET_LEN)}
+	}
+
+	var alevel ALevel
+
+	/* The length is fine, so nil means the address field made no sense. */
+
+	var pp = AX25FromFrame(data, alevel)
+	if pp == nil {
+		return nil, []string{"Address field is not valid.  Is this really an AX.25 frame?"}
+	}
+
+	defer AX25Delete(pp)
+
+	var details, problems []string
+
+	var _, desc, _, _, _, _ = ax25_frame_type(pp)
+
+	details = append(details, desc)
+	details = append(details, AX25FormatAddrs(pp)+kissdump_safe(AX25GetInfo(pp)))
+
+	for n := range ax25_get_num_addr(pp) {
+		var addr = ax25_get_addr_with_ssid(pp, n)
+
+		var _, _, _, ok = ax25_parse_addr(n, addr, 1)
+		if !ok {
+			problems = append(problems, fmt.Sprintf("Address \"%s\" is not valid for AX.25.", addr))
+		}
+	}
+
+	return details, problems
+}
+
+/*
+ * Anything other than printable ASCII in hexadecimal, like AX25SafePrint.
+ */
+
+func kissdump_safe(data []byte) string {
+	var s strings.Builder
+
+	for _, b := range data {
+		if b < ' ' || b > '~' {
+			fmt.Fprintf(&s, "<0x%02x>", b)
+		} else {
+			s.WriteByte(b)
+		}
+	}
+
+	return s.String()
+}
@@ -0,0 +1,107 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func kissdump_test_parser() (*kissdumpParser, *[][]byte, *[][]byte) {
+	var frames, noise [][]byte
+
+	var kp = &kissdumpParser{ //nolint:exhaustruct
+		frame: func(wire []byte) { frames = append(frames, append([]byte(nil), wire...)) },
+		other: func(n []byte) { noise = append(noise, append([]byte(nil), n...)) },
+	}
+
+	return kp, &frames, &noise
+}
+
+func Test_kissdump_parser(t *testing.T) {
+	var kp, frames, noise = kissdump_test_parser()
+
+	/* Noise, then frames with shared and doubled FENDs. */
+
+	for _, b := range []byte("cmd:\r\xc0\x00AB\xc0\x10CD\xc0\xc0\x20E\xc0") {
+		kp.add(b)
+	}
+
+	assert.Equal(t, [][]byte{[]byte("cmd:\r")}, *noise)
+	assert.Equal(t, [][]byte{
+		[]byte("\xc0\x00AB\xc0"),
+		[]byte("\xc0\x10CD\xc0"),
+		[]byte("\xc0\x20E\xc0"),
+	}, *frames)
+}
+
+func Test_kissdump_parser_too_long(t *testing.T) {
+	var kp, frames, _ = kissdump_test_parser()
+
+	kp.add(FEND)
+
+	for range 2 * MAX_KISS_LEN {
+		kp.add('x')
+	}
+
+	require.Len(t, *frames, 1)
+	assert.Len(t, (*frames)[0], 2*MAX_KISS_LEN+1)
+	assert.False(t, kp.collecting)
+}
+
+func Test_kiss_unwrap_check(t *testing.T) {
+	var out, problems = kiss_unwrap_check([]byte{FEND, 0x00, FESC, TFEND, FESC, TFESC, 'A', FEND})
+	assert.Equal(t, []byte{0x00, FEND, FESC, 'A'}, out)
+	assert.Empty(t, problems)
+
+	out, problems = kiss_unwrap_check([]byte{FEND, 0x00, FESC, 'A', FEND})
+	assert.Equal(t, []byte{0x00}, out)
+	assert.Equal(t, []string{"KISS protocol error.  Found 0x41 after FESC."}, problems)
+
+	_, problems = kiss_unwrap_check([]byte{FEND, 0x00, 'A'})
+	assert.Equal(t, []string{"KISS frame should end with FEND."}, problems)
+}
+
+func Test_kissdump_explain_data(t *testing.T) {
+	var pp = AX25FromText("Q1TEST>APDW18,WIDE2-1:>Hello", true)
+	require.NotNil(t, pp)
+
+	var msg = append([]byte{0x10}, AX25Pack(pp)...)
+
+	var summary, details, problems = kissdump_explain(msg)
+
+	assert.Equal(t, "Data frame, channel 1", summary)
+	assert.Equal(t, []string{"UI cc=11, p/f=0", "Q1TEST>APDW18,WIDE2-1:>Hello"}, details)
+	assert.Empty(t, problems)
+}
+
+func Test_kissdump_explain_bad_data(t *testing.T) {
+	var _, _, problems = kissdump_explain([]byte{0x00, 'h', 'i'})
+	assert.Equal(t, []string{"AX.25 frame length 2 is not in allowable range of 15 to 2123."}, problems)
+
+	/* No address field terminator. */
+	_, _, problems = kissdump_explain([]byte{0x00, 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p'})
+	assert.Equal(t, []string{"Address field is not valid.  Is this really an AX.25 frame?"}, problems)
+}
+
+func Test_kissdump_explain_commands(t *testing.T) {
+	var summary, details, problems = kissdump_explain([]byte{0x01, 30})
+	assert.Equal(t, "TXDELAY, channel 0", summary)
+	assert.Equal(t, []string{"Transmit delay 300 ms."}, details)
+	assert.Equal(t, []string{"Parameters normally go to a TNC, not come from it."}, problems)
+
+	_, _, problems = kissdump_explain([]byte{0x02})
+	assert.Equal(t, []string{"P should have 1 byte of data but has 0.", "Parameters normally go to a TNC, not come from it."}, problems)
+
+	summary, details, problems = kissdump_explain([]byte("\x06TXBUF:1\r"))
+	assert.Equal(t, "SetHardware, channel 0", summary)
+	assert.Equal(t, []string{`"TXBUF:1<0x0d>"`}, details)
+	assert.Empty(t, problems)
+
+	summary, _, problems = kissdump_explain([]byte{0x39, 0x00})
+	assert.Equal(t, "Invalid 9, channel 3", summary)
+	assert.Equal(t, []string{"Command 9 is not defined by KISS.  Is this really a KISS TNC?"}, problems)
+
+	summary, _, _ = kissdump_explain([]byte{0xff})
+	assert.Equal(t, "Return", summary)
+}
@@ -142,20 +142,42 @@ Of course, you could also use an application, capable of connecting to
 multiple single radio TNCs.  Separate TCP ports actually go to the
 same direwolf instance.
 
+
+	Connecting out with KISSCLIENT:
+
+Sometimes the other end can't accept connections, e.g. another
+instance behind NAT, or it is a TNC which only listens.  Then
+we make the connection instead.
+
+KISSCLIENT remote.example.net:8001 1
+
+                   +------------+    tcp, we connect     +------------------+
+Radio B  --------  |  direwolf  |  --------------------- |  remote KISS TCP |
+                   +------------+        KISS ch 0       +------------------+
+
+Once connected, it is handled exactly like a client application
+attached to "KISSPORT nnnn 1".  Anything received on radio channel 1
+is sent to it, and anything it sends is transmitted on radio channel 1.
+If the connection is lost, we keep trying to connect again.
+
 */
 
 import (
 	"fmt"
 	"net"
+	"sync/atomic"
 	"syscall"
+	"time"
 )
 
+const KISSCLIENT_RETRY_SEC = 10 /* Wait between attempts to connect to KISSCLIENT server. */
+
 // KissNetService manages KISS protocol TCP socket connections.
 // Each TCP port has its own status block in a linked list.
 type KissNetService struct {
 	miscConfigP *misc_config_s
 	allPorts    *kissport_status_s
-	debug       int /* Print information flowing from and to client. */
+	debug       atomic.Int32 /* Print information flowing from and to client. */
 }
 
 /*-------------------------------------------------------------------
@@ -169,6 +191,8 @@ type KissNetService struct {
  * Inputs:	mc.kiss_port	- TCP port for server.
  *				  0 means disable.  New in version 1.2.
  *
+ *		mc.kiss_client_addr - Servers to connect to ourselves, from KISSCLIENT.
+ *
  * Outputs:
  *
  * Description:	This starts two threads:
@@ -197,11 +221,49 @@ func NewKissNetService(mc *misc_config_s) *KissNetService {
 		}
 	}
 
+	for i := range MAX_KISS_TCP_PORTS {
+		if mc.kiss_client_addr[i] != "" {
+			var kps = new(kissport_status_s)
+
+			kps.remote = mc.kiss_client_addr[i]
+			kps.channel = mc.kiss_client_chan[i]
+
+			kps.pnext = kns.allPorts
+			kns.allPorts = kps
+
+			kns.initOne(kps)
+		}
+	}
+
 	return kns
 }
 
+// NumClients is the number of client applications connected which get
+// frames received on channel, or on any channel if it is -1.
+func (kns *KissNetService) NumClients(channel int) int {
+	if kns == nil {
+		return 0
+	}
+
+	var n = 0
+
+	for kps := kns.allPorts; kps != nil; kps = kps.pnext {
+		if channel != -1 && kps.channel != -1 && kps.channel != channel {
+			continue
+		}
+
+		for client := range MAX_NET_CLIENTS {
+			if kps.client_sock[client] != nil {
+				n++
+			}
+		}
+	}
+
+	return n
+}
+
 func (kns *KissNetService) SetDebug(n int) {
-	kns.debug = n
+	kns.debug.Store(int32(n))
 }
 
 /*-------------------------------------------------------------------
@@ -269,7 +331,7 @@ func (kns *KissNetService) SendRecPacket(channel int, kiss_cmd int, fbuf []byte,
 							dw_printf("For best results, configure for a KISS-only TNC to avoid this.\n")
 							dw_printf("In the case of APRSISCE/32, use \"Simply(KISS)\" rather than \"KISS.\"\n")
 
-							if kns.debug > 0 {
+							if kns.debug.Load() > 0 {
 								kiss_debug_print(TO_CLIENT, "Fake command prompt", fbuf)
 							}
 
@@ -295,7 +357,7 @@ func (kns *KissNetService) SendRecPacket(channel int, kiss_cmd int, fbuf []byte,
 
 							stemp = append(stemp, fbuf...)
 
-							if kns.debug >= 2 {
+							if kns.debug.Load() >= 2 {
 								/* AX.25 frame with the CRC removed. */
 								text_color_set(DW_COLOR_DEBUG)
 								dw_printf("\n")
@@ -307,7 +369,7 @@ func (kns *KissNetService) SendRecPacket(channel int, kiss_cmd int, fbuf []byte,
 
 							/* This has the escapes and the surrounding FENDs. */
 
-							if kns.debug > 0 {
+							if kns.debug.Load() > 0 {
 								kiss_debug_print(TO_CLIENT, "", kiss_buff)
 							}
 						}
@@ -315,7 +377,7 @@ func (kns *KissNetService) SendRecPacket(channel int, kiss_cmd int, fbuf []byte,
 						var _, err = kps.client_sock[client].Write(kiss_buff)
 						if err != nil {
 							text_color_set(DW_COLOR_ERROR)
-							dw_printf("\nError %s sending message to KISS client application %d on port %d.  Closing connection.\n\n", err, client, kps.tcp_port)
+							dw_printf("\nError %s sending message to KISS client application %d on %s.  Closing connection.\n\n", err, client, kps.where())
 							kps.client_sock[client].Close()
 							kps.client_sock[client] = nil
 						}
@@ -382,14 +444,14 @@ func (kns *KissNetService) Copy(_msg []byte, channel int, cmd int, from_kps *kis
 
 							/* This has the escapes and the surrounding FENDs. */
 
-							if kns.debug > 0 {
+							if kns.debug.Load() > 0 {
 								kiss_debug_print(TO_CLIENT, "", kiss_buff)
 							}
 
 							var _, err = kps.client_sock[client].Write(kiss_buff)
 							if err != nil {
 								text_color_set(DW_COLOR_ERROR)
-								dw_printf("\nError %s copying message to KISS TCP port %d client %d application.  Closing connection.\n\n", err, kps.tcp_port, client)
+								dw_printf("\nError %s copying message to KISS TCP %s client %d application.  Closing connection.\n\n", err, kps.where(), client)
 								kps.client_sock[client].Close()
 								kps.client_sock[client] = nil
 							}
@@ -451,7 +513,7 @@ func (kns *KissNetService) get(kps *kissport_status_s, client int) byte {
 		}
 
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("\nKISS client application %d on TCP port %d has gone away.\n\n", client, kps.tcp_port)
+		dw_printf("\nKISS client application %d on TCP %s has gone away.\n\n", client, kps.where())
 		c.Close()
 
 		kps.client_sock[client] = nil
@@ -481,7 +543,7 @@ func (kns *KissNetService) listenThread(kps *kissport_status_s, client int) {
 
 	for {
 		var ch = kns.get(kps, client)
-		KissRecByte(kps.kf[client], ch, kns.debug, kps, client, kns.SendRecPacket)
+		KissRecByte(kps.kf[client], ch, int(kns.debug.Load()), kps, client, kns.SendRecPacket)
 	}
 } /* end listenThread */
 
@@ -497,6 +559,16 @@ func (kns *KissNetService) initOne(kps *kissport_status_s) {
 		kps.kf[client] = new(KISSFrame)
 	}
 
+	if kps.remote != "" {
+		/*
+		 * We make the connection, so there is only ever one "client".
+		 */
+		go kns.connectRemoteThread(kps)
+		go kns.listenThread(kps, 0)
+
+		return
+	}
+
 	if kps.tcp_port == 0 {
 		text_color_set(DW_COLOR_INFO)
 		dw_printf("Disabled KISS network client port.\n")
@@ -617,4 +689,71 @@ func (kns *KissNetService) connectListenThread(kps *kissport_status_s) {
 	}
 }
 
+/*-------------------------------------------------------------------
+ *
+ * Name:        connectRemoteThread
+ *
+ * Purpose:     Connect to a KISS TCP server, for KISSCLIENT.
+ *
+ * Inputs:	kps		- KISS port status block, with remote set.
+ *
+ * Outputs:	client_sock[0]	- Connection to the server.
+ *
+ * Description:	Connect, and connect again whenever the connection
+ *		is lost.  listenThread and SendRecPacket then treat the
+ *		server just like a client application which connected to us.
+ *
+ *--------------------------------------------------------------------*/
+
+func (kns *KissNetService) connectRemoteThread(kps *kissport_status_s) {
+	var complained = false
+
+	for {
+		if kps.client_sock[0] != nil {
+			SLEEP_SEC(1) /* Still connected.  Check again later. */
+
+			continue
+		}
+
+		var conn, err = net.DialTimeout("tcp", kps.remote, KISSCLIENT_RETRY_SEC*time.Second)
+		if err != nil {
+			// Only once, rather than every time we try again.
+			if !complained {
+				text_color_set(DW_COLOR_ERROR)
+				dw_printf("Could not connect to KISS TCP server %s: %s\n", kps.remote, err)
+				dw_printf("Will keep trying every %d seconds.\n", KISSCLIENT_RETRY_SEC)
+
+				complained = true
+			}
+
+			SLEEP_SEC(KISSCLIENT_RETRY_SEC)
+
+			continue
+		}
+
+		// Reset the state and buffer before anything is read.
+		kps.kf[0] = new(KISSFrame)
+		kps.client_sock[0] = conn
+		complained = false
+
+		text_color_set(DW_COLOR_INFO)
+
+		if kps.channel == -1 {
+			dw_printf("\nConnected to KISS TCP server %s ...\n\n", kps.remote)
+		} else {
+			dw_printf("\nConnected to KISS TCP server %s (radio channel %d) ...\n\n", kps.remote, kps.channel)
+		}
+	}
+}
+
+/* For messages.  "port 8001", or "server host:port" for KISSCLIENT. */
+
+func (kps *kissport_status_s) where() string {
+	if kps.remote != "" {
+		return "server " + kps.remote
+	}
+
+	return fmt.Sprintf("port %d", kps.tcp_port)
+}
+
 /* end kissnet.go */
@@ -0,0 +1,57 @@
+package direwolf
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_KissNetService_client(t *testing.T) {
+	var listener, err = net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer listener.Close()
+
+	var mc = new(misc_config_s)
+	mc.kiss_client_addr[0] = listener.Addr().String()
+	mc.kiss_client_chan[0] = 1
+
+	var kns = NewKissNetService(mc)
+
+	var accept = func() net.Conn {
+		var conn, acceptErr = listener.Accept()
+		require.NoError(t, acceptErr)
+
+		require.Eventually(t, func() bool { return kns.NumClients(1) == 1 }, 5*time.Second, 10*time.Millisecond)
+
+		return conn
+	}
+
+	// Received on radio channel 1, so the server sees KISS channel 0.
+
+	var receive = func(conn net.Conn) {
+		kns.SendRecPacket(0, KISS_CMD_DATA_FRAME, []byte("other"), 5, nil, -1)
+		kns.SendRecPacket(1, KISS_CMD_DATA_FRAME, []byte("frame"), 5, nil, -1)
+
+		var got = make([]byte, 8)
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(5*time.Second)))
+		var _, readErr = io.ReadFull(conn, got)
+		require.NoError(t, readErr)
+		assert.Equal(t, []byte{FEND, 0x00, 'f', 'r', 'a', 'm', 'e', FEND}, got)
+	}
+
+	var conn = accept()
+	assert.Equal(t, 0, kns.NumClients(0), "only radio channel 1")
+	receive(conn)
+
+	// Connect again if the server goes away.
+
+	conn.Close()
+
+	conn = accept()
+	defer conn.Close()
+	receive(conn)
+}
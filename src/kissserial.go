@@ -70,6 +70,7 @@ package direwolf
 
 import (
 	"os"
+	"sync/atomic"
 
 	"github.com/pkg/term"
 )
@@ -88,10 +89,10 @@ var kf *KISSFrame
 
 var serialport_fd *term.Term
 
-var kissserial_debug = 0 /* Print information flowing from and to client. */
+var kissserial_debug atomic.Int32 /* Print information flowing from and to client. */
 
 func kissserial_set_debug(n int) {
-	kissserial_debug = n
+	kissserial_debug.Store(int32(n))
 }
 
 /*-------------------------------------------------------------------
@@ -192,7 +193,7 @@ func kissserial_send_rec_packet(channel int, kiss_cmd int, fbuf []byte, flen int
 	var kiss_buff []byte
 
 	if flen < 0 {
-		if kissserial_debug > 0 {
+		if kissserial_debug.Load() > 0 {
 			kiss_debug_print(TO_CLIENT, "Fake command prompt", fbuf)
 		}
 
@@ -209,7 +210,7 @@ func kissserial_send_rec_packet(channel int, kiss_cmd int, fbuf []byte, flen int
 			fbuf = fbuf[:AX25_MAX_PACKET_LEN]
 		}
 
-		if kissserial_debug >= 2 {
+		if kissserial_debug.Load() >= 2 {
 			/* AX.25 frame with the CRC removed. */
 			text_color_set(DW_COLOR_DEBUG)
 			dw_printf("\n")
@@ -221,7 +222,7 @@ func kissserial_send_rec_packet(channel int, kiss_cmd int, fbuf []byte, flen int
 
 		/* This has KISS framing and escapes for sending to client app. */
 
-		if kissserial_debug > 0 {
+		if kissserial_debug.Load() > 0 {
 			kiss_debug_print(TO_CLIENT, "", kiss_buff)
 		}
 	}
@@ -367,6 +368,6 @@ func kissserial_listen_thread() {
 			return
 		}
 
-		KissRecByte(kf, ch, kissserial_debug, nil, -1, kissserial_send_rec_packet)
+		KissRecByte(kf, ch, int(kissserial_debug.Load()), nil, -1, kissserial_send_rec_packet)
 	}
 }
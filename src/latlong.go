@@ -8,14 +8,15 @@ package direwolf
  *		Over time they might all be gathered into one place
  *		for consistency, reuse, and easier maintenance.
  *
+ *		Distance, bearing, Maidenhead locators, and UTM are
+ *		in the geo package.
+ *
  *---------------------------------------------------------------*/
 
 import (
-	"errors"
 	"fmt"
 	"math"
 	"strconv"
-	"strings"
 	"unicode"
 )
 
@@ -535,212 +536,3 @@ func longitude_from_nmea(pstr string, phemi byte) float64 {
 
 	return (lon)
 }
-
-/*------------------------------------------------------------------
- *
- * Function:	ll_distance_km
- *
- * Purpose:	Calculate distance between two locations.
- *
- * Inputs:	lat1, lon1	- One location, in degrees.
- *		lat2, lon2	- other location
- *
- * Returns:	Distance in km.
- *
- * Description:	The Ubiquitous Haversine formula.
- *
- *------------------------------------------------------------------*/
-
-const R_KM = 6371
-
-func ll_distance_km(lat1, lon1, lat2, lon2 float64) float64 {
-	lat1 *= math.Pi / 180
-	lon1 *= math.Pi / 180
-	lat2 *= math.Pi / 180
-	lon2 *= math.Pi / 180
-
-	var a = math.Pow(math.Sin((lat2-lat1)/2), 2) + math.Cos(lat1)*math.Cos(lat2)*math.Pow(math.Sin((lon2-lon1)/2), 2)
-
-	return (R_KM * 2 * math.Atan2(math.Sqrt(a), math.Sqrt(1-a)))
-}
-
-/*------------------------------------------------------------------
- *
- * Function:	ll_bearing_deg
- *
- * Purpose:	Calculate bearing between two locations.
- *
- * Inputs:	lat1, lon1	- starting location, in degrees.
- *		lat2, lon2	- destination location
- *
- * Returns:	Initial Bearing, in degrees.
- *		The calculation produces Range +- 180 degrees.
- *		But I think that 0 - 360 would be more customary?
- *
- *------------------------------------------------------------------*/
-
-func ll_bearing_deg(lat1, lon1, lat2, lon2 float64) float64 {
-	lat1 *= math.Pi / 180
-	lon1 *= math.Pi / 180
-	lat2 *= math.Pi / 180
-	lon2 *= math.Pi / 180
-
-	var b = math.Atan2(math.Sin(lon2-lon1)*math.Cos(lat2),
-		math.Cos(lat1)*math.Sin(lat2)-math.Sin(lat1)*math.Cos(lat2)*math.Cos(lon2-lon1))
-
-	b *= 180 / math.Pi
-	if b < 0 {
-		b += 360
-	}
-
-	return (b)
-}
-
-/*------------------------------------------------------------------
- *
- * Function:	ll_dest_lat
- *		ll_dest_lon
- *
- * Purpose:	Calculate the destination location given a starting point,
- *		distance, and bearing,
- *
- * Inputs:	lat1, lon1	- starting location, in degrees.
- *		dist		- distance in km.
- *		bearing		- direction in degrees.  Shouldn't matter
- *				  if it is in +- 180 or 0 to 360 range.
- *
- * Returns:	New latitude or longitude.
- *
- *------------------------------------------------------------------*/
-
-func ll_dest_lat(lat1, _, dist, bearing float64) float64 {
-	lat1 *= math.Pi / 180.0 // Everything to radians.
-	bearing *= math.Pi / 180.0
-
-	var lat2 = math.Asin(math.Sin(lat1)*math.Cos(dist/R_KM) + math.Cos(lat1)*math.Sin(dist/R_KM)*math.Cos(bearing))
-
-	lat2 *= 180.0 / math.Pi // Back to degrees.
-
-	return (lat2)
-}
-
-func ll_dest_lon(lat1, lon1, dist, bearing float64) float64 {
-	lat1 *= math.Pi / 180 // Everything to radians.
-	lon1 *= math.Pi / 180
-	bearing *= math.Pi / 180
-
-	var lat2 = math.Asin(math.Sin(lat1)*math.Cos(dist/R_KM) + math.Cos(lat1)*math.Sin(dist/R_KM)*math.Cos(bearing))
-
-	var lon2 = lon1 + math.Atan2(math.Sin(bearing)*math.Sin(dist/R_KM)*math.Cos(lat1), math.Cos(dist/R_KM)-math.Sin(lat1)*math.Sin(lat2))
-
-	lon2 *= 180 / math.Pi // Back to degrees.
-
-	return (lon2)
-}
-
-/*------------------------------------------------------------------
- *
- * Function:	ll_from_grid_square
- *
- * Purpose:	Convert Maidenhead locator to latitude and longitude.
- *
- * Inputs:	maidenhead	- 2, 4, 6, 8, 10, or 12 character grid square locator.
- *
- * Outputs:	dlat, dlon	- Latitude and longitude.
- *				  Original values unchanged if error.
- *
- * Returns:	1 for success, 0 if error.
- *
- * Reference:	A good converter for spot checking.  Only handles 4 or 6 characters :-(
- *		http://home.arcor.de/waldemar.kebsch/The_Makrothen_Contest/fmaidenhead.html
- *
- * Rambling:	What sort of resolution does this provide?
- *		For 8 character form, each latitude unit is 0.25 minute.
- *		(Longitude can be up to twice that around the equator.)
- *		6371 km * 2 * pi * 0.25 / 60 / 360 = 0.463 km.  Is that right?
- *
- *		Using this calculator, http://www.earthpoint.us/Convert.aspx
- *		It gives lower left corner of square rather than the middle.  :-(
- *
- *		FN42MA00  -->  19T 334361mE 4651711mN
- *		FN42MA11  -->  19T 335062mE 4652157mN
- *				   ------   -------
- *				      701       446    meters difference.
- *
- *		With another two pairs, we are down around 2 meters for latitude.
- *
- *------------------------------------------------------------------*/
-
-const MH_MIN_PAIR = 1
-const MH_MAX_PAIR = 6
-const MH_UNITS = (18 * 10 * 24 * 10 * 24 * 10 * 2)
-
-type mhPair struct {
-	position string
-	min_ch   byte
-	max_ch   byte
-	value    int
-}
-
-func mhPairs() []*mhPair {
-	return []*mhPair{
-		{"first", 'A', 'R', 10 * 24 * 10 * 24 * 10 * 2},
-		{"second", '0', '9', 24 * 10 * 24 * 10 * 2},
-		{"third", 'A', 'X', 10 * 24 * 10 * 2},
-		{"fourth", '0', '9', 24 * 10 * 2},
-		{"fifth", 'A', 'X', 10 * 2},
-		{"sixth", '0', '9', 2},
-	} // Even so we can get center of square.
-}
-
-func ll_from_grid_square(maidenhead string) (float64, float64, error) {
-	var np = len(maidenhead) / 2 /* Number of pairs of characters. */
-
-	if len(maidenhead)%2 != 0 || np < MH_MIN_PAIR || np > MH_MAX_PAIR {
-		text_color_set(DW_COLOR_ERROR)
-
-		var s = fmt.Sprintf("Maidenhead locator \"%s\" must from 1 to %d pairs of characters.\n", maidenhead, MH_MAX_PAIR)
-		dw_printf("%s", s)
-
-		return 0, 0, errors.New(s)
-	}
-
-	var mh = strings.ToUpper(maidenhead)
-
-	var pairs = mhPairs()
-
-	var ilat, ilon int
-
-	for n := range np {
-		if mh[2*n] < pairs[n].min_ch || mh[2*n] > pairs[n].max_ch ||
-			mh[2*n+1] < pairs[n].min_ch || mh[2*n+1] > pairs[n].max_ch {
-			text_color_set(DW_COLOR_ERROR)
-
-			var s = fmt.Sprintf("The %s pair of characters in Maidenhead locator \"%s\" must be in range of %c thru %c.\n",
-				pairs[n].position, maidenhead, pairs[n].min_ch, pairs[n].max_ch)
-			dw_printf("%s", s)
-
-			return 0, 0, errors.New(s)
-		}
-
-		ilon += int(mh[2*n]-pairs[n].min_ch) * pairs[n].value
-		ilat += int(mh[2*n+1]-pairs[n].min_ch) * pairs[n].value
-
-		if n == np-1 { // If last pair, take center of square.
-			ilon += pairs[n].value / 2
-			ilat += pairs[n].value / 2
-		}
-	}
-
-	var (
-		dlat = float64(ilat)/MH_UNITS*180. - 90.
-		dlon = float64(ilon)/MH_UNITS*360. - 180.
-	)
-
-	//text_color_set(DW_COLOR_DEBUG);
-	//dw_printf("DEBUG: Maidenhead conversion \"%s\" -> %.6f %.6f\n", maidenhead, *dlat, *dlon);
-
-	return dlat, dlon, nil
-}
-
-/* end ll_from_grid_square */
@@ -201,114 +201,6 @@ func Test_latlong(t *testing.T) {
 
 	// to be continued for others...  NMEA...
 
-	/* Distance & bearing - Take a couple examples from other places and see if we get similar results. */
-
-	// http://www.movable-type.co.uk/scripts/latlong.html
-
-	var d = ll_distance_km(35., 45., 35., 135.)
-	var b = ll_bearing_deg(35., 45., 35., 135.)
-
-	if d < 7862 || d > 7882 {
-		errors++
-
-		dw_printf("Error 5.1: Did not expect distance %.1f\n", d)
-	}
-
-	if b < 59.7 || b > 60.3 {
-		errors++
-
-		dw_printf("Error 5.2: Did not expect bearing %.1f\n", b)
-	}
-
-	// Sydney to Kinsale.  https://woodshole.er.usgs.gov/staffpages/cpolloni/manitou/ccal.htm
-
-	d = ll_distance_km(-33.8688, 151.2093, 51.7059, -8.5222)
-	b = ll_bearing_deg(-33.8688, 151.2093, 51.7059, -8.5222)
-
-	if d < 17435 || d > 17455 {
-		errors++
-
-		dw_printf("Error 5.3: Did not expect distance %.1f\n", d)
-	}
-
-	if b < 327-1 || b > 327+1 {
-		errors++
-
-		dw_printf("Error 5.4: Did not expect bearing %.1f\n", b)
-	}
-
-	/*
-	 * More distance and bearing.
-	 * Here we will start at some location1 (lat1,lon1) and go some distance (d1) at some bearing (b1).
-	 * This results in a new location2 (lat2, lon2).
-	 * We then calculate the distance and bearing from location1 to location2 and compare with the intention.
-	 */
-	var lat1, lon1, d1, b1 float64
-	d1 = 10
-
-	for lat1 = -60; lat1 <= 60; lat1 += 30 {
-		for lon1 = -180; lon1 <= 180; lon1 += 30 {
-			for b1 = 0; b1 < 360; b1 += 15 {
-				var lat2 = ll_dest_lat(lat1, lon1, d1, b1)
-				var lon2 = ll_dest_lon(lat1, lon1, d1, b1)
-
-				var d2 = ll_distance_km(lat1, lon1, lat2, lon2)
-
-				var b2 = ll_bearing_deg(lat1, lon1, lat2, lon2)
-				if b2 > 359.9 && b2 < 360.1 {
-					b2 = 0
-				}
-
-				// must be within 0.1% of distance and 0.1 degree.
-				if d2 < 0.999*d1 || d2 > 1.001*d1 {
-					errors++
-
-					dw_printf("Error 5.8: lat1=%.5f, lon1=%.5f, lat2=%.5f, lon2=%.5f, d1=%.1f, b1=%.1f, d2=%.2f\n", lat1, lon1, lat2, lon2, d1, b1, d2)
-				}
-
-				if b2 < b1-0.1 || b2 > b1+0.1 {
-					errors++
-
-					dw_printf("Error 5.9: lat1=%.5f, lon1=%.5f, lat2=%.5f, lon2=%.5f, d1=%.1f, b1=%.1f, b2=%.2f\n", lat1, lon1, lat2, lon2, d1, b1, b2)
-				}
-			}
-		}
-	}
-
-	/* Maidenhead locator to lat/long. */
-
-	dlat, dlon, err := ll_from_grid_square("BL11")
-	if err != nil || dlat < 20.4999999 || dlat > 21.5000001 || dlon < -157.0000001 || dlon > -156.9999999 {
-		errors++
-
-		dw_printf("Error 7.1: Did not expect %.6f %.6f\n", dlat, dlon)
-	}
-
-	dlat, dlon, err = ll_from_grid_square("BL11BH")
-	if err != nil || dlat < 21.31249 || dlat > 21.31251 || dlon < -157.87501 || dlon > -157.87499 {
-		errors++
-
-		dw_printf("Error 7.2: Did not expect %.6f %.6f\n", dlat, dlon)
-	}
-
-	// TODO: add more test cases after comparing results with other cconverters.
-	// Many other converters are limited to smaller number of characters,
-	// or return corner rather than center of square, or return 3 decimal places for degrees.
-
-	/*
-		ok = ll_from_grid_square ("BL11BH16", &dlat, &dlon);
-		if (!ok || dlat < 21.? || dlat > 21.? || dlon < -157.? || dlon > -157.?) {
-			errors++; dw_printf ("Error 7.3: Did not expect %.6f %.6f\n", dlat, dlon); }
-
-		ok = ll_from_grid_square ("BL11BH16oo", &dlat, &dlon);
-		if (!ok || dlat < 21.? || dlat > 21.? || dlon < -157.? || dlon > -157.?) {
-			errors++; dw_printf ("Error 7.4: Did not expect %.6f %.6f\n", dlat, dlon); }
-
-		ok = ll_from_grid_square ("BL11BH16oo66", &dlat, &dlon);
-		if (!ok || dlat < 21.? || dlat > 21.? || dlon < -157.? || dlon > -157.?) {
-			errors++; dw_printf ("Error 7.5: Did not expect %.6f %.6f\n", dlat, dlon); }
-	*/
-
 	if errors > 0 {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("\nLocation Coordinate Conversion Test - FAILED!\n")
@@ -6,7 +6,6 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
-	"github.com/stretchr/testify/require"
 )
 
 // TestLatitudeToNMEA tests conversion of latitude to NMEA format
@@ -446,97 +445,6 @@ func TestNMEARoundTrip(t *testing.T) {
 	}
 }
 
-// TestGridSquareEdgeCases tests Maidenhead grid square conversion edge cases
-func TestGridSquareEdgeCases(t *testing.T) {
-	tests := []struct {
-		name      string
-		grid      string
-		expectErr bool
-		minLat    float64
-		maxLat    float64
-		minLon    float64
-		maxLon    float64
-	}{
-		{
-			name:      "2 character grid",
-			grid:      "BL",
-			expectErr: false,
-			minLat:    15.0,
-			maxLat:    35.0,
-			minLon:    -160.0,
-			maxLon:    -140.0,
-		},
-		{
-			name:      "4 character grid",
-			grid:      "BL11",
-			expectErr: false,
-			minLat:    20.49,
-			maxLat:    21.51,
-			minLon:    -157.01,
-			maxLon:    -156.99,
-		},
-		{
-			name:      "6 character grid",
-			grid:      "BL11BH",
-			expectErr: false,
-			minLat:    21.31,
-			maxLat:    21.32,
-			minLon:    -157.88,
-			maxLon:    -157.87,
-		},
-		{
-			name:      "lowercase should work",
-			grid:      "bl11bh",
-			expectErr: false,
-			minLat:    21.31,
-			maxLat:    21.32,
-			minLon:    -157.88,
-			maxLon:    -157.87,
-		},
-		{ //nolint: exhaustruct
-			name:      "odd number of characters fails",
-			grid:      "BL1",
-			expectErr: true,
-		},
-		{ //nolint: exhaustruct
-			name:      "empty string fails",
-			grid:      "",
-			expectErr: true,
-		},
-		{ //nolint: exhaustruct
-			name:      "too many pairs fails",
-			grid:      "BL11BH16OO66XX",
-			expectErr: true,
-		},
-		{ //nolint: exhaustruct
-			name:      "invalid first character",
-			grid:      "ZZ11",
-			expectErr: true,
-		},
-		{ //nolint: exhaustruct
-			name:      "invalid second pair character",
-			grid:      "BLA1",
-			expectErr: true,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			lat, lon, err := ll_from_grid_square(tt.grid)
-
-			if tt.expectErr {
-				assert.Error(t, err, "should return error for invalid input")
-			} else {
-				require.NoError(t, err, "should not return error for valid input")
-				assert.GreaterOrEqual(t, lat, tt.minLat, "latitude should be >= min")
-				assert.LessOrEqual(t, lat, tt.maxLat, "latitude should be <= max")
-				assert.GreaterOrEqual(t, lon, tt.minLon, "longitude should be >= min")
-				assert.LessOrEqual(t, lon, tt.maxLon, "longitude should be <= max")
-			}
-		})
-	}
-}
-
 // TestCompressedFormatEdgeCases tests compressed format edge cases
 func TestCompressedFormatEdgeCases(t *testing.T) {
 	tests := []struct {
@@ -614,65 +522,6 @@ func TestCompressedFormatEdgeCases(t *testing.T) {
 	}
 }
 
-// TestCoordinateDistanceSymmetry tests that distance is symmetric
-func TestCoordinateDistanceSymmetry(t *testing.T) {
-	tests := []struct {
-		name string
-		lat1 float64
-		lon1 float64
-		lat2 float64
-		lon2 float64
-	}{
-		{"Boston to Sydney", 42.3601, -71.0589, -33.8688, 151.2093},
-		{"Equator points", 0.0, 0.0, 0.0, 90.0},
-		{"Same latitude", 45.0, 45.0, 45.0, 135.0},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			d1 := ll_distance_km(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
-			d2 := ll_distance_km(tt.lat2, tt.lon2, tt.lat1, tt.lon1)
-
-			assert.InDelta(t, d1, d2, 0.001, "distance should be symmetric")
-		})
-	}
-}
-
-// TestBearingAntipodal tests bearing to antipodal points
-func TestBearingAntipodal(t *testing.T) {
-	// Bearing from a point to its antipode (opposite side of Earth)
-	// should be consistent
-	lat := 45.0
-	lon := 45.0
-
-	antiLat := -lat
-
-	antiLon := lon + 180.0
-	if antiLon > 180.0 {
-		antiLon -= 360.0
-	}
-
-	bearing := ll_bearing_deg(lat, lon, antiLat, antiLon)
-
-	// Bearing to antipode could be any direction (ambiguous), but should be valid
-	assert.GreaterOrEqual(t, bearing, 0.0, "bearing should be >= 0")
-	assert.Less(t, bearing, 360.0, "bearing should be < 360")
-}
-
-// TestDestinationZeroDistance tests that zero distance returns same point
-func TestDestinationZeroDistance(t *testing.T) {
-	lat := 42.3601
-	lon := -71.0589
-	dist := 0.0
-	bearing := 90.0 // arbitrary
-
-	newLat := ll_dest_lat(lat, lon, dist, bearing)
-	newLon := ll_dest_lon(lat, lon, dist, bearing)
-
-	assert.InDelta(t, lat, newLat, 0.0001, "zero distance should return same latitude")
-	assert.InDelta(t, lon, newLon, 0.0001, "zero distance should return same longitude")
-}
-
 // TestLatitudeBoundaryClamping tests that out-of-range values are clamped
 func TestLatitudeBoundaryClamping(t *testing.T) {
 	tests := []struct {
@@ -749,164 +598,6 @@ func BenchmarkLatitudeConversions(b *testing.B) {
 	})
 }
 
-// BenchmarkDistanceBearing benchmarks distance and bearing calculations
-func BenchmarkDistanceBearing(b *testing.B) {
-	lat1, lon1 := 42.3601, -71.0589
-	lat2, lon2 := -33.8688, 151.2093
-
-	b.Run("distance", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			_ = ll_distance_km(lat1, lon1, lat2, lon2)
-		}
-	})
-
-	b.Run("bearing", func(b *testing.B) {
-		for i := 0; i < b.N; i++ {
-			_ = ll_bearing_deg(lat1, lon1, lat2, lon2)
-		}
-	})
-
-	b.Run("destination", func(b *testing.B) {
-		dist := 1000.0
-
-		bearing := 45.0
-		for i := 0; i < b.N; i++ {
-			_ = ll_dest_lat(lat1, lon1, dist, bearing)
-			_ = ll_dest_lon(lat1, lon1, dist, bearing)
-		}
-	})
-}
-
-// TestHaversineFormulaAccuracy tests the accuracy of the haversine implementation
-func TestHaversineFormulaAccuracy(t *testing.T) {
-	// Test against known distances
-	tests := []struct {
-		name     string
-		lat1     float64
-		lon1     float64
-		lat2     float64
-		lon2     float64
-		expected float64 // in km
-		delta    float64 // tolerance
-	}{
-		{
-			name:     "London to Paris",
-			lat1:     51.5074,
-			lon1:     -0.1278,
-			lat2:     48.8566,
-			lon2:     2.3522,
-			expected: 344.0,
-			delta:    5.0,
-		},
-		{
-			name:     "New York to Los Angeles",
-			lat1:     40.7128,
-			lon1:     -74.0060,
-			lat2:     34.0522,
-			lon2:     -118.2437,
-			expected: 3936.0,
-			delta:    10.0,
-		},
-		{
-			name:     "Singapore to Tokyo",
-			lat1:     1.3521,
-			lon1:     103.8198,
-			lat2:     35.6762,
-			lon2:     139.6503,
-			expected: 5312.0,
-			delta:    10.0,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			distance := ll_distance_km(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
-			assert.InDelta(t, tt.expected, distance, tt.delta,
-				"distance should match known value within tolerance")
-		})
-	}
-}
-
-// TestBearingCalculation tests bearing calculation accuracy
-func TestBearingCalculation(t *testing.T) {
-	tests := []struct {
-		name     string
-		lat1     float64
-		lon1     float64
-		lat2     float64
-		lon2     float64
-		expected float64 // degrees
-		delta    float64
-	}{
-		{
-			name:     "due north",
-			lat1:     0.0,
-			lon1:     0.0,
-			lat2:     1.0,
-			lon2:     0.0,
-			expected: 0.0,
-			delta:    0.1,
-		},
-		{
-			name:     "due east",
-			lat1:     0.0,
-			lon1:     0.0,
-			lat2:     0.0,
-			lon2:     1.0,
-			expected: 90.0,
-			delta:    0.1,
-		},
-		{
-			name:     "due south",
-			lat1:     1.0,
-			lon1:     0.0,
-			lat2:     0.0,
-			lon2:     0.0,
-			expected: 180.0,
-			delta:    0.1,
-		},
-		{
-			name:     "due west",
-			lat1:     0.0,
-			lon1:     1.0,
-			lat2:     0.0,
-			lon2:     0.0,
-			expected: 270.0,
-			delta:    0.1,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			bearing := ll_bearing_deg(tt.lat1, tt.lon1, tt.lat2, tt.lon2)
-			assert.InDelta(t, tt.expected, bearing, tt.delta,
-				"bearing should match expected cardinal direction")
-		})
-	}
-}
-
-// TestSmallAngles tests coordinate functions with very small angles
-func TestSmallAngles(t *testing.T) {
-	// Test with very small differences in coordinates
-	lat1, lon1 := 42.0, -71.0
-	lat2, lon2 := 42.0001, -71.0001
-
-	dist := ll_distance_km(lat1, lon1, lat2, lon2)
-	bearing := ll_bearing_deg(lat1, lon1, lat2, lon2)
-
-	assert.Greater(t, dist, 0.0, "distance should be positive")
-	assert.Less(t, dist, 1.0, "distance should be very small")
-	assert.GreaterOrEqual(t, bearing, 0.0, "bearing should be valid")
-	assert.Less(t, bearing, 360.0, "bearing should be valid")
-
-	// Test round trip
-	newLat := ll_dest_lat(lat1, lon1, dist, bearing)
-	newLon := ll_dest_lon(lat1, lon1, dist, bearing)
-
-	assert.InDelta(t, lat2, newLat, 0.0001, "round trip latitude should match")
-	assert.InDelta(t, lon2, newLon, 0.0001, "round trip longitude should match")
-}
-
 // TestAmbiguityLevels tests all ambiguity levels for latitude/longitude
 func TestAmbiguityLevels(t *testing.T) {
 	lat := 42.3601
@@ -960,6 +651,4 @@ func TestNaN(t *testing.T) {
 	_ = longitude_to_comp_str(nan)
 	_, _ = latitude_to_nmea(nan)
 	_, _ = longitude_to_nmea(nan)
-	_ = ll_distance_km(nan, 0, 0, 0)
-	_ = ll_bearing_deg(nan, 0, 0, 0)
 }
@@ -16,11 +16,26 @@ package direwolf
  *
  *		Use one or the other but not both.
  *
+ *		LOGROTATE in the configuration file limits the size
+ *		and number of files.  See log_rotate.go.
+ *
+ *		The last two columns, rigfreq and rigmode, are what the
+ *		radio was set to, with RIGPOLL.  See rig_poll.go.
+ *
+ *		--log-format json, or LOGFORMAT JSON, writes one JSON
+ *		object per line instead, for log collectors such as
+ *		Loki or Elasticsearch.  The names are the same as the
+ *		CSV columns, with numbers as numbers and anything
+ *		unknown left out.  The destination and digipeater path
+ *		are added.  Daily file names end with .jsonl.
+ *
  *------------------------------------------------------------------*/
 
 import (
 	"encoding/csv"
+	"encoding/json"
 	"fmt"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -36,6 +51,18 @@ type PacketLogger struct {
 	logPath    string     // Save directory or full name here for later use.
 	logFp      *os.File   // File pointer for writing. Note that file is kept open. We don't open/close for every new item.
 	openFname  string     // Name of currently open file. Applicable only when dailyNames is true.
+	jsonLines  bool       // One JSON object per line rather than CSV.
+
+	maxSize  int64 // Start a new file after this many bytes.  0 for no limit.
+	keep     int   // Number of old files to keep.  0 for all.
+	keepDays int   // Remove old files not written for this many days.  0 for never.
+	compress bool  // gzip old files.
+
+	timeNow  func() time.Time // Replaced for testing.
+	location *time.Location   // For isotime and daily file names.  UTC unless --log-time local.
+
+	tidyMu  sync.Mutex     // Only one tidy up of old files at a time.
+	tidying sync.WaitGroup // Tidy ups still running.
 }
 
 /*-------------------------------------------------------------------
@@ -57,6 +84,8 @@ type PacketLogger struct {
 func NewPacketLogger(daily_names bool, path string) *PacketLogger {
 	var pl = &PacketLogger{ //nolint:exhaustruct
 		dailyNames: daily_names,
+		timeNow:    time.Now,
+		location:   time.UTC,
 	}
 
 	if len(path) == 0 {
@@ -108,6 +137,50 @@ func NewPacketLogger(daily_names bool, path string) *PacketLogger {
 	return pl
 } /* end NewPacketLogger */
 
+/*-------------------------------------------------------------------
+ *
+ * Name:	SetLocation
+ *
+ * Purpose:	Time zone for the isotime column and daily file names.
+ *
+ * Inputs:	loc	- time.UTC, the default, or time.Local.
+ *
+ *---------------------------------------------------------------*/
+
+func (pl *PacketLogger) SetLocation(loc *time.Location) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	pl.location = loc
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	SetFormat
+ *
+ * Purpose:	Write JSON lines rather than CSV.
+ *
+ * Inputs:	format	- "csv", the default, or "json".
+ *
+ *---------------------------------------------------------------*/
+
+func (pl *PacketLogger) SetFormat(format string) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	pl.jsonLines = strings.EqualFold(format, "json")
+}
+
+/* Automatic daily file name for this time. */
+
+func (pl *PacketLogger) dailyNameLocked(now time.Time) string {
+	if pl.jsonLines {
+		return now.Format("2006-01-02.jsonl")
+	}
+
+	return now.Format("2006-01-02.log")
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        Write
@@ -134,7 +207,7 @@ func (pl *PacketLogger) Write(channel int, A *decode_aprs_t, pp *packet_t, aleve
 		return
 	}
 
-	var now = time.Now().UTC()
+	var now = pl.timeNow().In(pl.location)
 
 	if pl.dailyNames {
 		// Original strategy.  Automatic daily file names.
@@ -142,14 +215,16 @@ func (pl *PacketLogger) Write(channel int, A *decode_aprs_t, pp *packet_t, aleve
 		// Generate the file name from current date, UTC.
 		// Why UTC rather than local time?  I don't recall the reasoning.
 		// It's been there a few years and no on complained so leave it alone for now.
+		// --log-time local if you really want it.
 
 		// Microsoft doesn't recognize %F as equivalent to %Y-%m-%d
-		var fname = now.Format("2006-01-02.log")
+		var fname = pl.dailyNameLocked(now)
 
 		// Close current file if name has changed
 
 		if pl.logFp != nil && fname != pl.openFname {
 			pl.closeLocked()
+			pl.tidyLocked()
 		}
 
 		// Open for append if not already open.
@@ -183,8 +258,8 @@ func (pl *PacketLogger) Write(channel int, A *decode_aprs_t, pp *packet_t, aleve
 			// Write a header suitable for importing into a spreadsheet
 			// only if this will be the first line.
 
-			if !already_there {
-				fmt.Fprintf(pl.logFp, "chan,utime,isotime,source,heard,level,error,dti,name,symbol,latitude,longitude,speed,course,altitude,frequency,offset,tone,system,status,telemetry,comment\n")
+			if !already_there && !pl.jsonLines {
+				fmt.Fprintf(pl.logFp, "chan,utime,isotime,source,heard,level,error,dti,name,symbol,latitude,longitude,speed,course,altitude,frequency,offset,tone,system,status,telemetry,comment,rigfreq,rigmode\n")
 			}
 		}
 	} else {
@@ -216,8 +291,8 @@ func (pl *PacketLogger) Write(channel int, A *decode_aprs_t, pp *packet_t, aleve
 			// Write a header suitable for importing into a spreadsheet
 			// only if this will be the first line.
 
-			if !already_there {
-				fmt.Fprintf(pl.logFp, "chan,utime,isotime,source,heard,level,error,dti,name,symbol,latitude,longitude,speed,course,altitude,frequency,offset,tone,system,status,telemetry,comment\n")
+			if !already_there && !pl.jsonLines {
+				fmt.Fprintf(pl.logFp, "chan,utime,isotime,source,heard,level,error,dti,name,symbol,latitude,longitude,speed,course,altitude,frequency,offset,tone,system,status,telemetry,comment,rigfreq,rigmode\n")
 			}
 		}
 	}
@@ -225,29 +300,14 @@ func (pl *PacketLogger) Write(channel int, A *decode_aprs_t, pp *packet_t, aleve
 	// Add line to file if it is now open.
 
 	if pl.logFp != nil {
-		var itime = now.Format("2006-01-02T15:04:05Z")
+		var itime = now.Format(ISO_8601)
 
-		/* Who are we hearing?   Original station or digipeater? */
-		/* Similar code in direwolf.c.  Combine into one function? */
+		var heard = log_heard(pp)
 
-		var heard = ""
-		var h int
+		if pl.jsonLines {
+			pl.writeJSONLocked(packet_log_record(channel, now, A, pp, heard, alevel, retries))
 
-		if pp != nil {
-			if ax25_get_num_addr(pp) == 0 {
-				/* Not AX.25. No station to display below. */
-				h = -1
-			} else {
-				h = ax25_get_heard(pp)
-				heard = ax25_get_addr_with_ssid(pp, h)
-			}
-
-			if h >= AX25_REPEATER_2 &&
-				len(heard) == 5 &&
-				heard[:4] == "WIDE" &&
-				unicode.IsDigit(rune(heard[4])) {
-				heard = ax25_get_addr_with_ssid(pp, h-1) + "?"
-			}
+			return
 		}
 
 		var alevel_text = ax25_alevel_to_text(alevel)
@@ -313,6 +373,8 @@ func (pl *PacketLogger) Write(channel int, A *decode_aprs_t, pp *packet_t, aleve
 			stone = fmt.Sprintf("D%03o", A.g_dcs)
 		}
 
+		var srigfreq, srigmode = rig_freq_mode_text(channel)
+
 		var w = csv.NewWriter(pl.logFp)
 		w.Write([]string{
 			strconv.Itoa(channel), strconv.Itoa(int(now.Unix())), itime,
@@ -321,6 +383,7 @@ func (pl *PacketLogger) Write(channel int, A *decode_aprs_t, pp *packet_t, aleve
 			slat, slon, sspd, scse, salt,
 			sfreq, soffs, stone,
 			smfr, sstatus, stelemetry, scomment,
+			srigfreq, srigmode,
 		})
 		w.Flush()
 
@@ -328,9 +391,164 @@ func (pl *PacketLogger) Write(channel int, A *decode_aprs_t, pp *packet_t, aleve
 		if writeError != nil {
 			dw_printf("CSV write error: %s", writeError)
 		}
+
+		pl.rotateIfFullLocked()
 	}
 } /* end Write */
 
+/* Who are we hearing?   Original station or digipeater? */
+/* Similar code in direwolf.c. */
+
+func log_heard(pp *packet_t) string {
+	if pp == nil || ax25_get_num_addr(pp) == 0 {
+		/* Not AX.25. No station to display. */
+		return ""
+	}
+
+	var h = ax25_get_heard(pp)
+	var heard = ax25_get_addr_with_ssid(pp, h)
+
+	if h >= AX25_REPEATER_2 &&
+		len(heard) == 5 &&
+		heard[:4] == "WIDE" &&
+		unicode.IsDigit(rune(heard[4])) {
+		heard = ax25_get_addr_with_ssid(pp, h-1) + "?"
+	}
+
+	return heard
+}
+
+/* One line of --log-format json.  Same names as the CSV columns. */
+
+type packetLogRecord struct {
+	Chan        int             `json:"chan"`
+	Utime       int64           `json:"utime"`
+	Isotime     string          `json:"isotime"`
+	Source      string          `json:"source,omitempty"`
+	Destination string          `json:"destination,omitempty"`
+	Path        []string        `json:"path,omitempty"` // Digipeaters, with * after those used.
+	Heard       string          `json:"heard,omitempty"`
+	Level       *packetLogLevel `json:"level,omitempty"`
+	Error       int             `json:"error"`
+	DTI         string          `json:"dti,omitempty"`
+	Name        string          `json:"name,omitempty"`
+	Symbol      string          `json:"symbol,omitempty"`
+	Latitude    *float64        `json:"latitude,omitempty"`
+	Longitude   *float64        `json:"longitude,omitempty"`
+	Speed       *float64        `json:"speed,omitempty"`    // Knots.
+	Course      *float64        `json:"course,omitempty"`   // Degrees.
+	Altitude    *float64        `json:"altitude,omitempty"` // Meters.
+	Frequency   *float64        `json:"frequency,omitempty"`
+	Offset      *int            `json:"offset,omitempty"`
+	Tone        *float64        `json:"tone,omitempty"`
+	DCS         *int            `json:"dcs,omitempty"` // Octal code, as a decimal number, e.g. 23 for D023.
+	System      string          `json:"system,omitempty"`
+	Status      string          `json:"status,omitempty"`
+	Telemetry   string          `json:"telemetry,omitempty"`
+	Comment     string          `json:"comment,omitempty"`
+	Rigfreq     string          `json:"rigfreq,omitempty"`
+	Rigmode     string          `json:"rigmode,omitempty"`
+}
+
+type packetLogLevel struct {
+	Rec   int `json:"rec"`
+	Mark  int `json:"mark"`
+	Space int `json:"space"`
+}
+
+func packet_log_record(channel int, now time.Time, A *decode_aprs_t, pp *packet_t, heard string, alevel ALevel, retries BitFixLevel) *packetLogRecord {
+	var r = &packetLogRecord{ //nolint:exhaustruct
+		Chan:      channel,
+		Utime:     now.Unix(),
+		Isotime:   now.Format(ISO_8601),
+		Source:    A.g_src,
+		Heard:     heard,
+		Error:     int(retries),
+		Name:      A.g_src,
+		System:    A.g_mfr,
+		Status:    A.g_mic_e_status,
+		Telemetry: A.g_telemetry,
+		Comment:   A.g_comment,
+	}
+
+	if len(A.g_name) > 0 {
+		r.Name = A.g_name
+	}
+
+	if A.g_symbol_code != 0 {
+		r.Symbol = string(rune(A.g_symbol_table)) + string(rune(A.g_symbol_code))
+	}
+
+	if pp != nil {
+		r.Level = &packetLogLevel{Rec: alevel.rec, Mark: alevel.mark, Space: alevel.space}
+		r.DTI = string(rune(ax25_get_dti(pp)))
+
+		if ax25_get_num_addr(pp) >= 2 {
+			r.Destination = ax25_get_addr_with_ssid(pp, AX25_DESTINATION)
+		}
+
+		for n := AX25_REPEATER_1; n < ax25_get_num_addr(pp); n++ {
+			var digi = ax25_get_addr_with_ssid(pp, n)
+			if ax25_get_h(pp, n) != 0 {
+				digi += "*"
+			}
+
+			r.Path = append(r.Path, digi)
+		}
+	}
+
+	var known = func(v float64) *float64 {
+		if v == G_UNKNOWN {
+			return nil
+		}
+
+		return &v
+	}
+
+	if A.g_lat != G_UNKNOWN && A.g_lon != G_UNKNOWN {
+		r.Latitude = known(math.Round(A.g_lat*1e6) / 1e6)
+		r.Longitude = known(math.Round(A.g_lon*1e6) / 1e6)
+	}
+	r.Course = known(A.g_course)
+	r.Frequency = known(A.g_freq)
+	r.Tone = known(A.g_tone)
+
+	if A.g_speed_mph != G_UNKNOWN {
+		r.Speed = known(math.Round(DW_MPH_TO_KNOTS(float64(A.g_speed_mph))*10) / 10)
+	}
+
+	if A.g_altitude_ft != G_UNKNOWN {
+		r.Altitude = known(math.Round(DW_FEET_TO_METERS(float64(A.g_altitude_ft))*10) / 10)
+	}
+
+	if A.g_offset != G_UNKNOWN {
+		var offset = A.g_offset
+		r.Offset = &offset
+	}
+
+	if A.g_dcs != G_UNKNOWN {
+		var dcs, _ = strconv.Atoi(fmt.Sprintf("%o", A.g_dcs))
+		r.DCS = &dcs
+	}
+
+	r.Rigfreq, r.Rigmode = rig_freq_mode_text(channel)
+
+	return r
+}
+
+func (pl *PacketLogger) writeJSONLocked(r *packetLogRecord) {
+	var line, err = json.Marshal(r)
+	if err == nil {
+		_, err = pl.logFp.Write(append(line, '\n'))
+	}
+
+	if err != nil {
+		dw_printf("JSON log write error: %s\n", err)
+	}
+
+	pl.rotateIfFullLocked()
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        RRBits
@@ -351,25 +569,9 @@ func (pl *PacketLogger) RRBits(A *decode_aprs_t, pp *packet_t) {
 	/* Who are we hearing?   Original station or digipeater? */
 	/* Similar code in direwolf.c.  Combine into one function? */
 
-	var heard = ""
+	var heard = log_heard(pp)
 
 	if pp != nil {
-		var h int
-		if ax25_get_num_addr(pp) == 0 {
-			/* Not AX.25. No station to display below. */
-			h = -1
-		} else {
-			h = ax25_get_heard(pp)
-			heard = ax25_get_addr_with_ssid(pp, h)
-		}
-
-		if h >= AX25_REPEATER_2 &&
-			len(heard) == 5 &&
-			heard[:4] == "WIDE" &&
-			unicode.IsDigit(rune(heard[4])) {
-			heard = ax25_get_addr_with_ssid(pp, h-1) + "?"
-		}
-
 		var src_c = ax25_get_h(pp, AX25_SOURCE)
 		var dst_c = ax25_get_h(pp, AX25_DESTINATION)
 		var src_rr = ax25_get_rr(pp, AX25_SOURCE)
@@ -397,6 +599,8 @@ func (pl *PacketLogger) RRBits(A *decode_aprs_t, pp *packet_t) {
  * Purpose:	Close any open log file.
  *		Called when exiting or when date changes.
  *
+ *		Waits for any compressing or removing of old files.
+ *
  *------------------------------------------------------------------*/
 
 func (pl *PacketLogger) Close() {
@@ -404,6 +608,8 @@ func (pl *PacketLogger) Close() {
 	defer pl.mu.Unlock()
 
 	pl.closeLocked()
+
+	pl.tidying.Wait()
 } /* end Close */
 
 // closeLocked does the work of Close, assuming pl.mu is already held.
@@ -3,7 +3,17 @@
 
 package direwolf
 
-import "testing"
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
 
 // Regression test for a bug where heard[:4] and heard[4] were indexed
 // before checking len(heard) == 5, causing a panic whenever the heard
@@ -25,3 +35,50 @@ func TestLogRRBitsShortHeardDoesNotPanic(t *testing.T) {
 	var pl = NewPacketLogger(false, "")
 	pl.RRBits(&A, pp)
 }
+
+func Test_PacketLogger_json(t *testing.T) {
+	var dir = t.TempDir()
+
+	var pl = NewPacketLogger(true, dir)
+	pl.timeNow = func() time.Time { return time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC) }
+	pl.SetFormat("json")
+
+	var pp = AX25FromText("Q1TEST-9>APDW18,Q2OTHR*,WIDE2-1:!4237.14N/07120.83W-Test position", true)
+	require.NotNil(t, pp)
+
+	var A = decode_aprs(pp, true, "")
+
+	pl.Write(0, A, pp, ALevel{rec: 50, mark: 25, space: 24}, RETRY_NONE)
+	pl.Write(1, A, pp, ALevel{rec: 40, mark: 20, space: 20}, RETRY_INVERT_SINGLE)
+	pl.Close()
+
+	var data, err = os.ReadFile(filepath.Join(dir, "2024-05-06.jsonl"))
+	require.NoError(t, err)
+
+	var lines = strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+	require.Len(t, lines, 2, "no header")
+
+	var r map[string]any
+	require.NoError(t, json.Unmarshal([]byte(lines[0]), &r))
+
+	assert.InDelta(t, 0, r["chan"], 0)
+	assert.InDelta(t, 1714996800, r["utime"], 0)
+	assert.Equal(t, "2024-05-06T12:00:00Z", r["isotime"])
+	assert.Equal(t, "Q1TEST-9", r["source"])
+	assert.Equal(t, "APDW18", r["destination"])
+	assert.Equal(t, []any{"Q2OTHR*", "WIDE2-1"}, r["path"])
+	assert.Equal(t, "Q2OTHR", r["heard"])
+	assert.Equal(t, map[string]any{"rec": 50.0, "mark": 25.0, "space": 24.0}, r["level"])
+	assert.Equal(t, "!", r["dti"])
+	assert.Equal(t, "/-", r["symbol"])
+	assert.InDelta(t, 42.619, r["latitude"], 0.0001)
+	assert.InDelta(t, -71.347167, r["longitude"], 0, "6 decimal places, as for CSV")
+	assert.Equal(t, "Test position", r["comment"])
+
+	assert.NotContains(t, r, "speed", "unknown is left out")
+	assert.NotContains(t, r, "frequency")
+
+	require.NoError(t, json.Unmarshal([]byte(lines[1]), &r))
+	assert.InDelta(t, 1, r["chan"], 0)
+	assert.InDelta(t, float64(RETRY_INVERT_SINGLE), r["error"], 0)
+}
@@ -0,0 +1,346 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Keep packet log files from filling the disk.
+ *
+ * Description:	Configured with
+ *
+ *			LOGROTATE size=10M keep=30 days=90 compress
+ *
+ *		size	- Start a new file when the current one reaches
+ *			  this size.  K, M, or G suffix for kilobytes etc.
+ *			  The full one is renamed by adding .1, .2, ...
+ *			  with the highest number being the most recent.
+ *
+ *		keep	- Number of old files to keep.  Older ones are
+ *			  removed.
+ *
+ *		days	- Remove old files which have not been written
+ *			  for this many days.
+ *
+ *		compress - gzip old files: earlier days with daily
+ *			  names and those renamed because of size.
+ *
+ *		The file being written is never touched.  Tidying up
+ *		happens at startup, when a daily file is finished, and
+ *		after renaming because of size.  It is done in the
+ *		background so receiving isn't held up by compressing a
+ *		large file.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+/* Daily file names, including any renamed because of size and compressed. */
+
+var logDailyNameRegexp = regexp.MustCompile(`^\d{4}-\d{2}-\d{2}\.(log|jsonl)(\.\d+)?(\.gz)?$`)
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	log_parse_size
+ *
+ * Purpose:	Convert size such as "500K" or "10M" to bytes.
+ *
+ *--------------------------------------------------------------------*/
+
+func log_parse_size(s string) (int64, error) {
+	var multiplier int64 = 1
+
+	switch {
+	case strings.HasSuffix(strings.ToUpper(s), "K"):
+		multiplier = 1024
+	case strings.HasSuffix(strings.ToUpper(s), "M"):
+		multiplier = 1024 * 1024
+	case strings.HasSuffix(strings.ToUpper(s), "G"):
+		multiplier = 1024 * 1024 * 1024
+	}
+
+	var digits = s
+	if multiplier != 1 {
+		digits = s[:len(s)-1]
+	}
+
+	var n, err = strconv.ParseInt(digits, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid size \"%s\"", s)
+	}
+
+	return n * multiplier, nil
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	SetRotation
+ *
+ * Purpose:	Set limits for log files.
+ *
+ * Inputs:	max_size	- Start a new file after this many bytes.
+ *				  0 for no limit.
+ *
+ *		keep		- Number of old files to keep.  0 for all.
+ *
+ *		keep_days	- Remove old files not written for this
+ *				  many days.  0 to keep them forever.
+ *
+ *		compress	- True to gzip old files.
+ *
+ * Description:	Old files left from earlier runs are tidied up now.
+ *
+ *--------------------------------------------------------------------*/
+
+func (pl *PacketLogger) SetRotation(max_size int64, keep int, keep_days int, compress bool) {
+	pl.mu.Lock()
+	defer pl.mu.Unlock()
+
+	pl.maxSize = max_size
+	pl.keep = keep
+	pl.keepDays = keep_days
+	pl.compress = compress
+
+	if len(pl.logPath) > 0 {
+		pl.tidyLocked()
+	}
+}
+
+/* Full name of the file being written, or which will be written next. */
+
+func (pl *PacketLogger) currentPathLocked() string {
+	if pl.dailyNames {
+		return filepath.Join(pl.logPath, pl.dailyNameLocked(pl.timeNow().In(pl.location)))
+	}
+
+	return pl.logPath
+}
+
+/*
+ * Called after writing.  If the file has reached the size limit,
+ * close it and rename with the next unused number.
+ */
+
+func (pl *PacketLogger) rotateIfFullLocked() {
+	if pl.maxSize <= 0 || pl.logFp == nil {
+		return
+	}
+
+	var stat, err = pl.logFp.Stat()
+	if err != nil || stat.Size() < pl.maxSize {
+		return
+	}
+
+	var current = pl.logFp.Name()
+
+	pl.closeLocked()
+
+	var rotated = log_rotated_name(current)
+
+	err = os.Rename(current, rotated)
+	if err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Can't rename log file \"%s\" to \"%s\".\n", current, rotated)
+		dw_printf("%s\n", err)
+
+		return
+	}
+
+	pl.tidyLocked()
+}
+
+/* Next unused name for a full file, e.g. "2024-05-06.log.3". */
+
+func log_rotated_name(path string) string {
+	var highest = 0
+
+	var entries, _ = os.ReadDir(filepath.Dir(path))
+
+	var prefix = filepath.Base(path) + "."
+
+	for _, e := range entries {
+		var suffix, found = strings.CutPrefix(e.Name(), prefix)
+		if !found {
+			continue
+		}
+
+		suffix = strings.TrimSuffix(suffix, ".gz")
+
+		var n, err = strconv.Atoi(suffix)
+		if err == nil && n > highest {
+			highest = n
+		}
+	}
+
+	return path + "." + strconv.Itoa(highest+1)
+}
+
+/*
+ * Compress and remove old files in the background.  Only one
+ * tidy up runs at a time.  Close waits for it to finish.
+ */
+
+func (pl *PacketLogger) tidyLocked() {
+	if !pl.compress && pl.keep <= 0 && pl.keepDays <= 0 {
+		return
+	}
+
+	var current = pl.currentPathLocked()
+	var dailyNames = pl.dailyNames
+	var compress = pl.compress
+	var keep = pl.keep
+	var maxAge = time.Duration(pl.keepDays) * 24 * time.Hour
+	var now = pl.timeNow()
+
+	pl.tidying.Add(1)
+
+	go func() {
+		defer pl.tidying.Done()
+
+		pl.tidyMu.Lock()
+		defer pl.tidyMu.Unlock()
+
+		var old = log_old_files(current, dailyNames)
+
+		if compress {
+			for i, f := range old {
+				if !strings.HasSuffix(f.path, ".gz") {
+					old[i].path = log_compress(f.path, f.modTime)
+				}
+			}
+		}
+
+		/* Most recent first. */
+
+		slices.SortStableFunc(old, func(a, b logOldFile) int {
+			return b.modTime.Compare(a.modTime)
+		})
+
+		for i, f := range old {
+			var tooMany = keep > 0 && i >= keep
+			var tooOld = maxAge > 0 && now.Sub(f.modTime) > maxAge
+
+			if tooMany || tooOld {
+				text_color_set(DW_COLOR_INFO)
+				dw_printf("Removing old log file \"%s\".\n", f.path)
+
+				var err = os.Remove(f.path)
+				if err != nil {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("%s\n", err)
+				}
+			}
+		}
+	}()
+}
+
+type logOldFile struct {
+	path    string
+	modTime time.Time
+}
+
+/*
+ * Old log files, for the file currently being written.
+ * With daily names, these are earlier days and any renamed because of size.
+ * Otherwise, those renamed because of size.
+ */
+
+func log_old_files(current string, dailyNames bool) []logOldFile {
+	var dir = filepath.Dir(current)
+
+	var match func(name string) bool
+
+	if dailyNames {
+		match = func(name string) bool {
+			return logDailyNameRegexp.MatchString(name) && name != filepath.Base(current)
+		}
+	} else {
+		var re = regexp.MustCompile(`^` + regexp.QuoteMeta(filepath.Base(current)) + `\.\d+(\.gz)?$`)
+		match = re.MatchString
+	}
+
+	var entries, err = os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	var old []logOldFile
+
+	for _, e := range entries {
+		if !e.Type().IsRegular() || !match(e.Name()) {
+			continue
+		}
+
+		var info, infoErr = e.Info()
+		if infoErr != nil {
+			continue
+		}
+
+		old = append(old, logOldFile{path: filepath.Join(dir, e.Name()), modTime: info.ModTime()})
+	}
+
+	return old
+}
+
+/*
+ * gzip a file, keeping its modification time so age and order are
+ * unchanged.  Returns the new name, or the original if it failed.
+ */
+
+func log_compress(path string, modTime time.Time) string {
+	var gzPath = path + ".gz"
+
+	var err = log_compress_file(path, gzPath)
+	if err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Can't compress log file \"%s\".\n", path)
+		dw_printf("%s\n", err)
+
+		os.Remove(gzPath)
+
+		return path
+	}
+
+	os.Chtimes(gzPath, modTime, modTime)
+	os.Remove(path)
+
+	return gzPath
+}
+
+func log_compress_file(path string, gzPath string) error {
+	var in, inErr = os.Open(path) //nolint:gosec // Log directory is from config.
+	if inErr != nil {
+		return inErr
+	}
+	defer in.Close()
+
+	var out, outErr = os.OpenFile(gzPath, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644) //nolint:gosec // Same as log files.
+	if outErr != nil {
+		return outErr
+	}
+
+	var zw = gzip.NewWriter(out)
+	zw.Name = filepath.Base(path)
+
+	var _, copyErr = io.Copy(zw, in)
+	var zErr = zw.Close()
+	var closeErr = out.Close()
+
+	if copyErr != nil {
+		return copyErr
+	}
+
+	if zErr != nil {
+		return zErr
+	}
+
+	return closeErr
+}
@@ -0,0 +1,172 @@
+package direwolf
+
+import (
+	"compress/gzip"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_log_parse_size(t *testing.T) {
+	var tests = []struct {
+		in   string
+		want int64
+	}{
+		{"1000", 1000},
+		{"500K", 500 * 1024},
+		{"10m", 10 * 1024 * 1024},
+		{"2G", 2 * 1024 * 1024 * 1024},
+	}
+
+	for _, tt := range tests {
+		var got, err = log_parse_size(tt.in)
+		require.NoError(t, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+
+	for _, bad := range []string{"", "M", "0", "-5K", "10X", "ten"} {
+		var _, err = log_parse_size(bad)
+		assert.Error(t, err, bad)
+	}
+}
+
+func logRotateTestWrite(t *testing.T, pl *PacketLogger, count int) {
+	t.Helper()
+
+	var pp = AX25FromText("Q1TEST>APDW18:!4237.14N/07120.83W-Test position", true)
+	require.NotNil(t, pp)
+
+	var A = decode_aprs(pp, true, "")
+
+	for range count {
+		pl.Write(0, A, pp, ALevel{rec: 50, mark: 25, space: 25}, RETRY_NONE)
+	}
+}
+
+func logRotateTestTouch(t *testing.T, path string, modTime time.Time) {
+	t.Helper()
+
+	require.NoError(t, os.WriteFile(path, []byte("chan,utime\n"), 0o644))
+	require.NoError(t, os.Chtimes(path, modTime, modTime))
+}
+
+func Test_PacketLogger_size_rotation(t *testing.T) {
+	var dir = t.TempDir()
+	var path = filepath.Join(dir, "packets.log")
+
+	var pl = NewPacketLogger(false, path)
+	pl.SetRotation(300, 0, 0, false)
+
+	logRotateTestWrite(t, pl, 10)
+	pl.Close()
+
+	var rotated, _ = filepath.Glob(path + ".*")
+	assert.GreaterOrEqual(t, len(rotated), 2)
+
+	for _, name := range rotated {
+		var data, err = os.ReadFile(name)
+		require.NoError(t, err)
+		assert.True(t, strings.HasPrefix(string(data), "chan,utime,"), "header in %s", name)
+		assert.GreaterOrEqual(t, len(data), 300)
+	}
+
+	assert.Equal(t, path+"."+strconv.Itoa(len(rotated)+1), log_rotated_name(path))
+}
+
+func Test_PacketLogger_daily_compress_and_keep(t *testing.T) {
+	var dir = t.TempDir()
+	var now = time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+
+	logRotateTestTouch(t, filepath.Join(dir, "2024-05-03.log"), now.Add(-72*time.Hour))
+	logRotateTestTouch(t, filepath.Join(dir, "2024-05-04.log"), now.Add(-48*time.Hour))
+	logRotateTestTouch(t, filepath.Join(dir, "2024-05-05.log"), now.Add(-24*time.Hour))
+	logRotateTestTouch(t, filepath.Join(dir, "2024-05-06.log"), now)
+	logRotateTestTouch(t, filepath.Join(dir, "notes.txt"), now.Add(-1000*time.Hour))
+
+	var pl = NewPacketLogger(true, dir)
+	pl.timeNow = func() time.Time { return now }
+	pl.SetRotation(0, 2, 0, true)
+	pl.Close()
+
+	var names []string
+
+	var entries, err = os.ReadDir(dir)
+	require.NoError(t, err)
+
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+
+	// Today's is left alone.  Only the two most recent old ones are kept.
+	assert.Equal(t, []string{"2024-05-04.log.gz", "2024-05-05.log.gz", "2024-05-06.log", "notes.txt"}, names)
+
+	var f, openErr = os.Open(filepath.Join(dir, "2024-05-05.log.gz"))
+	require.NoError(t, openErr)
+
+	defer f.Close()
+
+	var zr, zErr = gzip.NewReader(f)
+	require.NoError(t, zErr)
+
+	var data, _ = io.ReadAll(zr)
+	assert.Equal(t, "chan,utime\n", string(data))
+
+	var stat, _ = os.Stat(filepath.Join(dir, "2024-05-05.log.gz"))
+	assert.True(t, stat.ModTime().Equal(now.Add(-24*time.Hour)), "modification time kept")
+}
+
+func Test_PacketLogger_daily_new_day_removes_old(t *testing.T) {
+	var dir = t.TempDir()
+	var now = time.Date(2024, 5, 6, 23, 59, 0, 0, time.UTC)
+
+	logRotateTestTouch(t, filepath.Join(dir, "2024-04-01.log"), now.Add(-35*24*time.Hour))
+	logRotateTestTouch(t, filepath.Join(dir, "2024-04-01.log.1.gz"), now.Add(-35*24*time.Hour))
+
+	var pl = NewPacketLogger(true, dir)
+	pl.timeNow = func() time.Time { return now }
+
+	logRotateTestWrite(t, pl, 1)
+
+	pl.SetRotation(0, 0, 30, false)
+
+	now = now.Add(2 * time.Minute)
+
+	logRotateTestWrite(t, pl, 1)
+	pl.Close()
+
+	var names, _ = filepath.Glob(filepath.Join(dir, "*"))
+	for i := range names {
+		names[i] = filepath.Base(names[i])
+	}
+
+	assert.Equal(t, []string{"2024-05-06.log", "2024-05-07.log"}, names)
+}
+
+func Test_PacketLogger_daily_local_time(t *testing.T) {
+	var dir = t.TempDir()
+
+	// Still the 6th in New York.
+	var now = time.Date(2024, 5, 7, 2, 0, 0, 0, time.UTC)
+
+	var pl = NewPacketLogger(true, dir)
+	pl.timeNow = func() time.Time { return now }
+	pl.SetLocation(time.FixedZone("EDT", -4*3600))
+
+	logRotateTestWrite(t, pl, 1)
+	pl.Close()
+
+	var names, _ = filepath.Glob(filepath.Join(dir, "*"))
+	require.Len(t, names, 1)
+	assert.Equal(t, "2024-05-06.log", filepath.Base(names[0]))
+
+	var data, err = os.ReadFile(names[0]) //nolint:gosec
+	require.NoError(t, err)
+	assert.Contains(t, string(data), ",2024-05-06T22:00:00-04:00,")
+}
@@ -0,0 +1,107 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	PTT, DCD, and other outputs through an MCP23017 I2C GPIO
+ *		expander, for builds with more radios than a Raspberry Pi
+ *		has spare GPIO pins.
+ *
+ * Description:	PTT  MCP23017  bus  address  [-]pin
+ *
+ *		bus	- I2C bus number, e.g. 1 for /dev/i2c-1, or device path.
+ *		address	- 0x20 thru 0x27, depending on the A0-A2 pins.
+ *		pin	- A0 thru A7 or B0 thru B7.  A leading - means active low.
+ *
+ *		Several channels and outputs can share one chip.  We can
+ *		only write a whole port at once, so the output latch is
+ *		read, one bit changed, and written back.  Pins not used
+ *		here, possibly by something else, are left alone.
+ *
+ *		Registers are at the power on addresses, IOCON.BANK = 0,
+ *		with port B one above port A.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+)
+
+const MCP23017_IODIRA = 0x00 /* 1 for input, 0 for output. */
+const MCP23017_OLATA = 0x14  /* Output latch. */
+
+const MCP23017_ADDR_MIN = 0x20
+const MCP23017_ADDR_MAX = 0x27
+
+/* Access to the registers of one chip.  Replaced in tests. */
+
+type i2cRegisters interface {
+	ReadReg(reg byte) (byte, error)
+	WriteReg(reg byte, val byte) error
+	Close() error
+}
+
+var mcp23017_port [MAX_RADIO_CHANS][NUM_OCTYPES]i2cRegisters
+
+/* For the read, modify, write of a port shared by more than one output. */
+
+var mcp23017_mutex sync.Mutex
+
+/* Pin 0 thru 15 to A0 thru B7. */
+
+func mcp23017_pin_name(pin int) string {
+	return fmt.Sprintf("%c%d", 'A'+pin/8, pin%8)
+}
+
+/* A0 thru B7, either case, to 0 thru 15. */
+
+func mcp23017_parse_pin(s string) (int, bool) {
+	if len(s) != 2 || s[1] < '0' || s[1] > '7' {
+		return 0, false
+	}
+
+	switch strings.ToUpper(s[:1]) {
+	case "A":
+		return int(s[1] - '0'), true
+	case "B":
+		return 8 + int(s[1]-'0'), true
+	}
+
+	return 0, false
+}
+
+/* Change one bit of a register, leaving the rest as they were. */
+
+func mcp23017_update(d i2cRegisters, base byte, pin int, on bool) error {
+	mcp23017_mutex.Lock()
+	defer mcp23017_mutex.Unlock()
+
+	var reg = base + byte(pin/8)
+	var bit = byte(1) << (pin % 8)
+
+	var val, err = d.ReadReg(reg)
+	if err != nil {
+		return err
+	}
+
+	if on {
+		val |= bit
+	} else {
+		val &^= bit
+	}
+
+	return d.WriteReg(reg, val)
+}
+
+/* Make a pin an output. */
+
+func mcp23017_output(d i2cRegisters, pin int) error {
+	return mcp23017_update(d, MCP23017_IODIRA, pin, false)
+}
+
+/* Set an output pin high (1) or low (0). */
+
+func mcp23017_set(d i2cRegisters, pin int, state int) error {
+	return mcp23017_update(d, MCP23017_OLATA, pin, state != 0)
+}
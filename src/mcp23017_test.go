@@ -0,0 +1,93 @@
+package direwolf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeMCP23017 holds register values, starting as after power on.
+type fakeMCP23017 struct {
+	regs   map[byte]byte
+	closed bool
+}
+
+func newFakeMCP23017() *fakeMCP23017 {
+	return &fakeMCP23017{regs: map[byte]byte{0x00: 0xff, 0x01: 0xff}} //nolint:exhaustruct
+}
+
+func (f *fakeMCP23017) ReadReg(reg byte) (byte, error) {
+	return f.regs[reg], nil
+}
+
+func (f *fakeMCP23017) WriteReg(reg byte, val byte) error {
+	f.regs[reg] = val
+	return nil
+}
+
+func (f *fakeMCP23017) Close() error {
+	f.closed = true
+	return nil
+}
+
+func Test_mcp23017_parse_pin(t *testing.T) {
+	for _, tt := range []struct {
+		in   string
+		want int
+	}{{"A0", 0}, {"a7", 7}, {"B0", 8}, {"b5", 13}} {
+		var pin, ok = mcp23017_parse_pin(tt.in)
+		assert.True(t, ok, tt.in)
+		assert.Equal(t, tt.want, pin, tt.in)
+		assert.Equal(t, strings.ToUpper(tt.in), mcp23017_pin_name(pin))
+	}
+
+	for _, bad := range []string{"", "A", "A8", "C1", "3", "A10"} {
+		var _, ok = mcp23017_parse_pin(bad)
+		assert.False(t, ok, bad)
+	}
+}
+
+func Test_mcp23017_set(t *testing.T) {
+	var d = newFakeMCP23017()
+
+	require.NoError(t, mcp23017_output(d, 3))
+	require.NoError(t, mcp23017_output(d, 9))
+	assert.Equal(t, byte(0xf7), d.regs[MCP23017_IODIRA])
+	assert.Equal(t, byte(0xfd), d.regs[MCP23017_IODIRA+1])
+
+	d.regs[MCP23017_OLATA] = 0x80 // Someone else's pin.
+
+	require.NoError(t, mcp23017_set(d, 3, 1))
+	require.NoError(t, mcp23017_set(d, 9, 1))
+	assert.Equal(t, byte(0x88), d.regs[MCP23017_OLATA])
+	assert.Equal(t, byte(0x02), d.regs[MCP23017_OLATA+1])
+
+	require.NoError(t, mcp23017_set(d, 3, 0))
+	assert.Equal(t, byte(0x80), d.regs[MCP23017_OLATA])
+	assert.Equal(t, byte(0x02), d.regs[MCP23017_OLATA+1])
+}
+
+func TestPttSetRealMCP23017(t *testing.T) {
+	var d = newFakeMCP23017()
+
+	var cfg audio_s
+	cfg.chan_medium[0] = MEDIUM_RADIO
+	cfg.achan[0].octrl[OCTYPE_DCD].ptt_method = PTT_METHOD_MCP23017
+	cfg.achan[0].octrl[OCTYPE_DCD].out_gpio_num = 12
+	cfg.achan[0].octrl[OCTYPE_DCD].ptt_invert = true
+	save_audio_config_p = &cfg
+	mcp23017_port[0][OCTYPE_DCD] = d
+
+	t.Cleanup(func() {
+		mcp23017_port[0][OCTYPE_DCD] = nil
+		save_audio_config_p = nil
+	})
+
+	ptt_set_real(OCTYPE_DCD, 0, 0)
+	assert.Equal(t, byte(0x10), d.regs[MCP23017_OLATA+1], "active low is high when off")
+
+	ptt_set_real(OCTYPE_DCD, 0, 1)
+	assert.Equal(t, byte(0x00), d.regs[MCP23017_OLATA+1])
+}
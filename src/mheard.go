@@ -24,12 +24,13 @@ package direwolf
 
 import (
 	"fmt"
-	"maps"
 	"slices"
 	"strings"
 	"sync"
 	"time"
 	"unicode"
+
+	"github.com/doismellburning/samoyed/src/geo"
 )
 
 /*
@@ -558,7 +559,7 @@ func (mdb *MHeardDB) WasRecentlyNearby(role string, callsign string, _time_limit
 	// Apply physical distance check?
 
 	if dlat != G_UNKNOWN && dlon != G_UNKNOWN && km != G_UNKNOWN && mptr.dlat != G_UNKNOWN && mptr.dlon != G_UNKNOWN {
-		var dist = ll_distance_km(float64(mptr.dlat), float64(mptr.dlon), float64(dlat), float64(dlon))
+		var dist = geo.DistanceKM(float64(mptr.dlat), float64(mptr.dlon), float64(dlat), float64(dlon))
 
 		if dist > km {
 			if role != "" {
@@ -649,33 +650,41 @@ func (mdb *MHeardDB) GetMSP(callsign string) int {
 	return (0)
 } /* end GetMSP */
 
-func (mdb *MHeardDB) dump() {
+/*------------------------------------------------------------------
+ *
+ * Function:	recent
+ *
+ * Purpose:	Copy of the stations heard, most recent first.
+ *
+ *------------------------------------------------------------------*/
+
+func (mdb *MHeardDB) recent() []mheard_t {
 	mdb.mu.RLock()
 	defer mdb.mu.RUnlock()
 
-	/* Get linear array of node pointers so they can be sorted easily. */
-	var stations = slices.Collect(maps.Values(mdb.db))
+	var stations = make([]mheard_t, 0, len(mdb.db))
+	for _, mptr := range mdb.db {
+		stations = append(stations, *mptr)
+	}
 
-	/* Sort most recently heard to the top then print. */
-	slices.SortFunc(stations, func(ma, mb *mheard_t) int {
-		var ta = ma.last_heard_rf
-		if ma.last_heard_is.After(ta) {
-			ta = ma.last_heard_is
-		}
+	/* Sort most recently heard to the top. */
+	slices.SortFunc(stations, func(ma, mb mheard_t) int {
+		return mheard_last(mb).Compare(mheard_last(ma))
+	})
 
-		var tb = mb.last_heard_rf
-		if mb.last_heard_is.After(tb) {
-			tb = mb.last_heard_is
-		}
+	return stations
+}
 
-		if ta.Before(tb) {
-			return 1
-		} else if ta.After(tb) {
-			return -1
-		} else {
-			return 0
-		}
-	})
+func mheard_last(m mheard_t) time.Time {
+	if m.last_heard_is.After(m.last_heard_rf) {
+		return m.last_heard_is
+	}
+
+	return m.last_heard_rf
+}
+
+func (mdb *MHeardDB) dump() {
+	var stations = mdb.recent()
 
 	text_color_set(DW_COLOR_DEBUG)
 
@@ -124,25 +124,47 @@ func multi_modem_init(pa *audio_s) {
 
 	for channel := range MAX_RADIO_CHANS {
 		if save_audio_config_p.chan_medium[channel] == MEDIUM_RADIO {
-			if save_audio_config_p.achan[channel].baud <= 0 {
-				text_color_set(DW_COLOR_ERROR)
-				dw_printf("Internal multi_modem_init error, channel=%d\n", channel)
-				save_audio_config_p.achan[channel].baud = DEFAULT_BAUD
-			}
+			multi_modem_set_process_age(channel)
+			//crc_queue_of_last_to_app[channel] = nil;
+		}
+	}
+}
 
-			var real_baud = save_audio_config_p.achan[channel].baud
-			if save_audio_config_p.achan[channel].modem_type == MODEM_QPSK {
-				real_baud = save_audio_config_p.achan[channel].baud / 2
-			}
+/*------------------------------------------------------------------------------
+ *
+ * Name:	multi_modem_init_channel
+ *
+ * Purpose:	Start over with new modem settings for one channel, after
+ *		a channel profile has changed them.
+ *
+ * Description:	Must be called from the thread reading audio for the
+ *		channel so it doesn't change under a sample being processed.
+ *
+ *------------------------------------------------------------------------------*/
 
-			if save_audio_config_p.achan[channel].modem_type == MODEM_8PSK {
-				real_baud = save_audio_config_p.achan[channel].baud / 3
-			}
+func multi_modem_init_channel(channel int) {
+	demod_init_channel(channel)
+	hdlc_rec_init_channel(save_audio_config_p, channel)
+	multi_modem_set_process_age(channel)
+}
 
-			process_age[channel] = PROCESS_AFTER_BITS * save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec / real_baud
-			//crc_queue_of_last_to_app[channel] = nil;
-		}
+func multi_modem_set_process_age(channel int) {
+	if save_audio_config_p.achan[channel].baud <= 0 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Internal multi_modem_init error, channel=%d\n", channel)
+		save_audio_config_p.achan[channel].baud = DEFAULT_BAUD
 	}
+
+	var real_baud = save_audio_config_p.achan[channel].baud
+	if save_audio_config_p.achan[channel].modem_type == MODEM_QPSK {
+		real_baud = save_audio_config_p.achan[channel].baud / 2
+	}
+
+	if save_audio_config_p.achan[channel].modem_type == MODEM_8PSK {
+		real_baud = save_audio_config_p.achan[channel].baud / 3
+	}
+
+	process_age[channel] = PROCESS_AFTER_BITS * save_audio_config_p.adev[ACHAN2ADEV(channel)].samples_per_sec / real_baud
 }
 
 /*------------------------------------------------------------------------------
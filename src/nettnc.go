@@ -10,6 +10,7 @@ package direwolf
  *---------------------------------------------------------------*/
 
 import (
+	"crypto/tls"
 	"net"
 	"os"
 	"strconv"
@@ -39,7 +40,26 @@ func nettnc_init(pa *audio_s) {
 	for i := range MAX_TOTAL_CHANS {
 		if pa.chan_medium[i] == MEDIUM_NETTNC {
 			text_color_set(DW_COLOR_DEBUG)
-			dw_printf("Channel %d: Network TNC %s %d\n", i, pa.nettnc_addr[i], pa.nettnc_port[i])
+
+			if pa.nettnc_bridge[i] {
+				dw_printf("Channel %d: Bridge to channel %d of %s %d\n", i, pa.nettnc_remote_chan[i], pa.nettnc_addr[i], pa.nettnc_port[i])
+
+				s_tnc_bridge[i] = true
+				s_tnc_remote_chan[i] = pa.nettnc_remote_chan[i]
+			} else {
+				dw_printf("Channel %d: Network TNC %s %d\n", i, pa.nettnc_addr[i], pa.nettnc_port[i])
+			}
+
+			if pa.nettnc_tls[i] {
+				var tlsConfig, tlsErr = bridge_tls_client_config(pa.nettnc_addr[i], pa.nettnc_tls_cert[i], pa.nettnc_tls_key[i], pa.nettnc_tls_ca[i])
+				if tlsErr != nil {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Channel %d: %s\n", i, tlsErr)
+					os.Exit(1)
+				}
+
+				s_tnc_tls[i] = tlsConfig
+			}
 
 			var e = nettnc_attach(i, pa.nettnc_addr[i], pa.nettnc_port[i])
 			if e < 0 {
@@ -81,6 +101,10 @@ var s_tnc_host [MAX_TOTAL_CHANS]string
 var s_tnc_port [MAX_TOTAL_CHANS]int
 var s_tnc_sock [MAX_TOTAL_CHANS]net.Conn // Socket handle or file descriptor. -1 for invalid.
 
+var s_tnc_bridge [MAX_TOTAL_CHANS]bool     // Other end is a BRIDGEPORT.
+var s_tnc_remote_chan [MAX_TOTAL_CHANS]int // Its channel for this one.
+var s_tnc_tls [MAX_TOTAL_CHANS]*tls.Config // nil for plain TCP.
+
 func nettnc_attach(channel int, host string, port int) int {
 	Assert(channel >= 0 && channel < MAX_TOTAL_CHANS)
 
@@ -88,10 +112,13 @@ func nettnc_attach(channel int, host string, port int) int {
 	s_tnc_port[channel] = port
 	s_tnc_sock[channel] = nil
 
-	var conn, connErr = net.Dial("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	var conn, connErr = nettnc_dial(channel)
 	if connErr == nil {
 		s_tnc_sock[channel] = conn
 	} else {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Channel %d: Can't connect to %s %d: %s\n", channel, host, port, connErr)
+
 		return -1
 	}
 
@@ -112,6 +139,18 @@ func nettnc_attach(channel int, host string, port int) int {
 	return (0)
 }
 
+/* Connect, or reconnect, with TLS if configured. */
+
+func nettnc_dial(channel int) (net.Conn, error) {
+	var address = net.JoinHostPort(s_tnc_host[channel], strconv.Itoa(s_tnc_port[channel]))
+
+	if s_tnc_tls[channel] != nil {
+		return tls.Dial("tcp", address, s_tnc_tls[channel])
+	}
+
+	return net.Dial("tcp", address)
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        nettnc_listen_thread
@@ -143,7 +182,7 @@ func nettnc_listen_thread(channel int) {
 			// avoid confusion with the AX.25 connect.
 			dw_printf("Attempting to reattach to network TNC...\n")
 
-			var conn, connErr = net.Dial("tcp", net.JoinHostPort(s_tnc_host[channel], strconv.Itoa(s_tnc_port[channel])))
+			var conn, connErr = nettnc_dial(channel)
 			if connErr == nil {
 				s_tnc_sock[channel] = conn
 
@@ -253,6 +292,15 @@ func my_kiss_rec_byte(kf *KISSFrame, b byte, debug int, channel_override int) {
 				HexDump(unwrapped[1:])
 			}
 
+			// A bridge sends all of its radio channels.  We want only the one for this channel.
+
+			if s_tnc_bridge[channel_override] &&
+				(len(unwrapped) < 1 || int(unwrapped[0]>>4) != s_tnc_remote_chan[channel_override] || unwrapped[0]&0xf != KISS_CMD_DATA_FRAME) {
+				kf.state = KS_SEARCHING
+
+				return
+			}
+
 			// Convert to packet object and send to received packet queue.
 			// Note that we use channel associated with the network TNC, not channel in KISS frame.
 
@@ -266,6 +314,10 @@ func my_kiss_rec_byte(kf *KISSFrame, b byte, debug int, channel_override int) {
 				var retries BitFixLevel
 
 				var spectrum = "Network TNC"
+				if s_tnc_bridge[channel_override] {
+					spectrum = "Bridge"
+				}
+
 				dlq_rec_frame(channel_override, subchan, slice, pp, alevel, fec_type, retries, spectrum)
 			} else {
 				text_color_set(DW_COLOR_ERROR)
@@ -313,12 +365,23 @@ func nettnc_send_packet(channel int, pp *packet_t) {
 	var fbuf = ax25_get_frame_data(pp)
 
 	var frame_buff = []byte{0} // For now, set channel to 0.
+	if s_tnc_bridge[channel] {
+		frame_buff[0] = byte(s_tnc_remote_chan[channel] << 4)
+	}
+
 	frame_buff = append(frame_buff, fbuf...)
 
 	// Next, encapsulate into KISS frame with surrounding FENDs and any escapes.
 
 	var kiss_buff = KissEncapsulate(frame_buff)
 
+	if s_tnc_sock[channel] == nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Network TNC for channel %d is not attached.  Frame discarded.\n", channel)
+
+		return
+	}
+
 	var _, err = s_tnc_sock[channel].Write(kiss_buff)
 	if err != nil {
 		text_color_set(DW_COLOR_ERROR)
@@ -0,0 +1,273 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	GPX track of our own movement, from the attached GPS.
+ *
+ * Description:	log2gpx makes tracks from received packets after the
+ *		fact.  This is for a mobile station to see where it has
+ *		been, as it happens, including where tracker beacons
+ *		were sent.
+ *
+ *		The GPS is checked every OWN_TRACK_INTERVAL.  A point is
+ *		added after moving OWN_TRACK_MIN_METERS, or every
+ *		OWN_TRACK_STATIONARY when not moving, so a parked
+ *		vehicle doesn't fill it up.  Only the most recent
+ *		OWN_TRACK_MAX_POINTS are kept.
+ *
+ *		Each tracker beacon sent adds a waypoint named "Beacon".
+ *
+ *		"direwolf --own-track file.gpx" rewrites the file after
+ *		each new point.  It is replaced as a whole, by renaming,
+ *		so something reading it never sees half of it.
+ *
+ *		With the HTTP server enabled, GET /track.gpx gives the
+ *		same thing.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/doismellburning/samoyed/src/geo"
+)
+
+const OWN_TRACK_INTERVAL = 10 * time.Second
+
+const OWN_TRACK_MIN_METERS = 20
+
+const OWN_TRACK_STATIONARY = 5 * time.Minute
+
+const OWN_TRACK_MAX_POINTS = 20000
+
+const OWN_TRACK_MAX_BEACONS = 2000
+
+type ownTrackPoint struct {
+	time   time.Time
+	lat    float64
+	lon    float64
+	alt    float64 // meters or G_UNKNOWN.
+	speed  float64 // knots or G_UNKNOWN.  GPX wants meters per second.
+	course float64 // degrees or G_UNKNOWN.
+}
+
+type OwnTrack struct {
+	mu      sync.Mutex
+	running bool
+	name    string // MYCALL, for the track name.
+	path    string // File to rewrite or "" for none.
+
+	points  []ownTrackPoint
+	beacons []ownTrackPoint
+}
+
+var ownTrack = new(OwnTrack)
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Start
+ *
+ * Purpose:	Start recording our own track.
+ *
+ * Inputs:	name	- Name for the track, normally MYCALL.
+ *
+ *		path	- GPX file to keep up to date or "" for only
+ *			  the HTTP server.
+ *
+ * Description:	Must be after dwgps_init.
+ *
+ *---------------------------------------------------------------*/
+
+func (ot *OwnTrack) Start(name string, path string) {
+	ot.mu.Lock()
+	ot.running = true
+	ot.name = name
+	ot.path = path
+	ot.mu.Unlock()
+
+	go func() {
+		for now := range time.Tick(OWN_TRACK_INTERVAL) {
+			var gpsinfo dwgps_info_t
+
+			if dwgps_read(&gpsinfo) >= DWFIX_2D {
+				ot.Position(now, &gpsinfo)
+			}
+		}
+	}()
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Position
+ *
+ * Purpose:	Maybe add a point to the track.
+ *
+ * Inputs:	now	- Time.
+ *
+ *		gpsinfo	- Current location, with a fix.
+ *
+ *---------------------------------------------------------------*/
+
+func (ot *OwnTrack) Position(now time.Time, gpsinfo *dwgps_info_t) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	if !ot.running {
+		return
+	}
+
+	if n := len(ot.points); n > 0 {
+		var prev = ot.points[n-1]
+		var meters = 1000 * geo.DistanceKM(prev.lat, prev.lon, gpsinfo.dlat, gpsinfo.dlon)
+
+		if meters < OWN_TRACK_MIN_METERS && now.Sub(prev.time) < OWN_TRACK_STATIONARY {
+			return
+		}
+	}
+
+	ot.points = append(ot.points, own_track_point(now, gpsinfo))
+	if len(ot.points) > OWN_TRACK_MAX_POINTS {
+		ot.points = ot.points[len(ot.points)-OWN_TRACK_MAX_POINTS:]
+	}
+
+	ot.saveLocked()
+}
+
+/* A tracker beacon was sent from this location. */
+
+func (ot *OwnTrack) Beacon(now time.Time, gpsinfo *dwgps_info_t) {
+	ot.mu.Lock()
+	defer ot.mu.Unlock()
+
+	if !ot.running {
+		return
+	}
+
+	ot.beacons = append(ot.beacons, own_track_point(now, gpsinfo))
+	if len(ot.beacons) > OWN_TRACK_MAX_BEACONS {
+		ot.beacons = ot.beacons[len(ot.beacons)-OWN_TRACK_MAX_BEACONS:]
+	}
+
+	ot.saveLocked()
+}
+
+func own_track_point(now time.Time, gpsinfo *dwgps_info_t) ownTrackPoint {
+	var p = ownTrackPoint{
+		time:   now,
+		lat:    gpsinfo.dlat,
+		lon:    gpsinfo.dlon,
+		alt:    G_UNKNOWN,
+		speed:  gpsinfo.speed_knots,
+		course: gpsinfo.track,
+	}
+
+	if gpsinfo.fix == DWFIX_3D {
+		p.alt = gpsinfo.altitude
+	}
+
+	return p
+}
+
+/* Replace the file, if there is one, by writing a new one and renaming it. */
+
+func (ot *OwnTrack) saveLocked() {
+	if ot.path == "" {
+		return
+	}
+
+	var tmp = filepath.Join(filepath.Dir(ot.path), "."+filepath.Base(ot.path)+".tmp")
+
+	var buf bytes.Buffer
+	ot.writeGPXLocked(&buf)
+
+	var err = os.WriteFile(tmp, buf.Bytes(), 0o644) //nolint:gosec // Named on the command line.
+	if err == nil {
+		err = os.Rename(tmp, ot.path)
+	}
+
+	if err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Can't write own track file %s: %s\n", ot.path, err)
+	}
+}
+
+func own_track_xml(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+
+	return buf.String()
+}
+
+/* Same style as log2gpx. */
+
+func (ot *OwnTrack) writeGPXLocked(w io.Writer) {
+	fmt.Fprintf(w, "<?xml version=\"1.0\" encoding=\"UTF-8\" standalone=\"yes\"?>\n")
+	fmt.Fprintf(w, "<gpx version=\"1.1\" creator=\"Dire Wolf\">\n")
+
+	for _, b := range ot.beacons {
+		fmt.Fprintf(w, "  <wpt lat=\"%.6f\" lon=\"%.6f\">\n", b.lat, b.lon)
+
+		if b.alt != G_UNKNOWN {
+			fmt.Fprintf(w, "    <ele>%.1f</ele>\n", b.alt)
+		}
+
+		fmt.Fprintf(w, "    <time>%s</time>\n", b.time.UTC().Format("2006-01-02T15:04:05Z"))
+		fmt.Fprintf(w, "    <name>Beacon</name>\n")
+		fmt.Fprintf(w, "  </wpt>\n")
+	}
+
+	if len(ot.points) > 0 {
+		fmt.Fprintf(w, "  <trk>\n")
+		fmt.Fprintf(w, "    <name>%s</name>\n", own_track_xml(ot.name))
+		fmt.Fprintf(w, "    <trkseg>\n")
+
+		for _, p := range ot.points {
+			fmt.Fprintf(w, "      <trkpt lat=\"%.6f\" lon=\"%.6f\">\n", p.lat, p.lon)
+
+			if p.speed != G_UNKNOWN {
+				fmt.Fprintf(w, "        <speed>%.1f</speed>\n", p.speed*0.514444)
+			}
+
+			if p.course != G_UNKNOWN {
+				fmt.Fprintf(w, "        <course>%.1f</course>\n", p.course)
+			}
+
+			if p.alt != G_UNKNOWN {
+				fmt.Fprintf(w, "        <ele>%.1f</ele>\n", p.alt)
+			}
+
+			fmt.Fprintf(w, "        <time>%s</time>\n", p.time.UTC().Format("2006-01-02T15:04:05Z"))
+			fmt.Fprintf(w, "      </trkpt>\n")
+		}
+
+		fmt.Fprintf(w, "    </trkseg>\n")
+		fmt.Fprintf(w, "  </trk>\n")
+	}
+
+	fmt.Fprintf(w, "</gpx>\n")
+}
+
+func (ot *OwnTrack) serveGPX(w http.ResponseWriter, r *http.Request) {
+	ot.mu.Lock()
+	var running = ot.running
+	var buf bytes.Buffer
+	ot.writeGPXLocked(&buf)
+	ot.mu.Unlock()
+
+	if !running {
+		http.Error(w, "no GPS configured", http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/gpx+xml")
+	_, _ = w.Write(buf.Bytes())
+}
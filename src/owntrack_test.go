@@ -0,0 +1,79 @@
+package direwolf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func own_track_gps(lat float64, lon float64) *dwgps_info_t {
+	var gpsinfo = new(dwgps_info_t)
+	dwgps_clear(gpsinfo)
+	gpsinfo.fix = DWFIX_3D
+	gpsinfo.dlat = lat
+	gpsinfo.dlon = lon
+	gpsinfo.speed_knots = 10
+	gpsinfo.track = 90
+	gpsinfo.altitude = 100
+
+	return gpsinfo
+}
+
+func Test_own_track(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "track.gpx")
+	var ot = new(OwnTrack)
+
+	var t0 = time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+
+	// Nothing until started.
+	ot.Position(t0, own_track_gps(42.0, -71.0))
+	assert.Empty(t, ot.points)
+
+	ot.mu.Lock()
+	ot.running = true
+	ot.name = "Q1TEST-9"
+	ot.path = path
+	ot.mu.Unlock()
+
+	ot.Position(t0, own_track_gps(42.0, -71.0))
+	ot.Position(t0.Add(10*time.Second), own_track_gps(42.00001, -71.0)) // About a meter.  Skipped.
+	ot.Position(t0.Add(20*time.Second), own_track_gps(42.001, -71.0))   // About 100 meters.
+	ot.Position(t0.Add(7*time.Minute), own_track_gps(42.001, -71.0))    // Parked for a while.
+	ot.Beacon(t0.Add(7*time.Minute), own_track_gps(42.001, -71.0))
+
+	require.Len(t, ot.points, 3)
+	require.Len(t, ot.beacons, 1)
+
+	var b, err = os.ReadFile(path)
+	require.NoError(t, err)
+
+	var gpx = string(b)
+	assert.True(t, strings.HasPrefix(gpx, "<?xml"))
+	assert.True(t, strings.HasSuffix(gpx, "</gpx>\n"))
+	assert.Contains(t, gpx, "<name>Q1TEST-9</name>")
+	assert.Equal(t, 3, strings.Count(gpx, "<trkpt "))
+	assert.Contains(t, gpx, "<trkpt lat=\"42.001000\" lon=\"-71.000000\">")
+	assert.Contains(t, gpx, "<speed>5.1</speed>") // Meters per second.
+	assert.Contains(t, gpx, "<ele>100.0</ele>")
+	assert.Contains(t, gpx, "<time>2024-05-06T12:07:00Z</time>\n    <name>Beacon</name>")
+
+	// Same from HTTP.
+
+	var rec = httptest.NewRecorder()
+	ot.serveGPX(rec, httptest.NewRequest(http.MethodGet, "/track.gpx", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, gpx, rec.Body.String())
+}
+
+func Test_own_track_not_running(t *testing.T) {
+	var rec = httptest.NewRecorder()
+	new(OwnTrack).serveGPX(rec, httptest.NewRequest(http.MethodGet, "/track.gpx", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
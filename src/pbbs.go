@@ -0,0 +1,533 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Personal mailbox, like the PBBS built into many
+ *		hardware TNCs, for when the station isn't attended.
+ *
+ * Description:	Configured with
+ *
+ *			PBBS  channel  callsign  file
+ *
+ *		Other stations connect to callsign (usually MYCALL with
+ *		a different SSID, e.g. -1) and can leave messages for
+ *		us or for each other.  Commands, one per line:
+ *
+ *			L		List all messages.
+ *			LM		List messages for you.
+ *			R n		Read message n.
+ *			S call		Send a message to call.  Asks for a
+ *					subject, then the message, ending
+ *					with /EX or Ctrl-Z on a line by itself.
+ *			K n		Kill message n.  Only the sender,
+ *					the one it's to, or us.
+ *			H		Help.
+ *			B		Bye.
+ *
+ *		Messages are kept in the file, as JSON, rewritten
+ *		after each change.
+ *
+ *		The mailbox is connected to the AX.25 link layer as if
+ *		it were an AGW client application, number PBBS_CLIENT,
+ *		which registered the callsign.  What the link layer
+ *		would send to that client comes here instead.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+/* Client number, just past those for AGW applications. */
+
+const PBBS_CLIENT = MAX_NET_CLIENTS
+
+const PBBS_MAX_MESSAGES = 500
+
+const PBBS_MAX_MESSAGE = 8192 /* Bytes of text in one message. */
+
+const PBBS_MAX_LINE = 256 /* Longer lines are cut. */
+
+const PBBS_SEND_CHUNK = 256 /* Bytes in each send to the link layer. */
+
+const PBBS_PROMPT = "(B,H,K,L,LM,R,S) >\r"
+
+type pbbsMessage struct {
+	Number  int       `json:"number"`
+	From    string    `json:"from"`
+	To      string    `json:"to"`
+	Date    time.Time `json:"date"`
+	Subject string    `json:"subject"`
+	Text    string    `json:"text"`
+	Read    bool      `json:"read"` // By the one it's to.
+}
+
+type pbbsFile struct {
+	Next     int            `json:"next"`
+	Messages []*pbbsMessage `json:"messages"`
+}
+
+/* What we're waiting for from a connected station. */
+
+type pbbs_state_e int
+
+const (
+	PBBS_COMMAND pbbs_state_e = iota
+	PBBS_SUBJECT
+	PBBS_TEXT
+)
+
+type pbbsSession struct {
+	channel int
+	remote  string
+	own     string
+
+	partial []byte /* Start of a line, not ended yet. */
+	lastCR  bool   /* So CR LF is one end of line. */
+
+	state pbbs_state_e
+	draft pbbsMessage
+}
+
+type PBBS struct {
+	channel int
+	mycall  string
+	path    string
+	clock   Clock
+
+	mu       sync.Mutex
+	store    pbbsFile
+	sessions map[string]*pbbsSession /* By channel and remote callsign. */
+
+	// To the link layer.  Tests replace these.
+
+	send       func(channel int, own string, remote string, data []byte)
+	disconnect func(channel int, own string, remote string)
+}
+
+var pbbsService *PBBS
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	NewPBBS
+ *
+ * Purpose:	Load the messages.
+ *
+ * Returns:	nil if there is no PBBS configured.
+ *
+ *---------------------------------------------------------------*/
+
+func NewPBBS(mc *misc_config_s) (*PBBS, error) {
+	if mc.pbbs_mycall == "" {
+		return nil, nil //nolint:nilnil
+	}
+
+	var p = &PBBS{ //nolint:exhaustruct
+		channel:    mc.pbbs_channel,
+		mycall:     mc.pbbs_mycall,
+		path:       mc.pbbs_file,
+		clock:      SystemClock{},
+		store:      pbbsFile{Next: 1, Messages: nil},
+		sessions:   make(map[string]*pbbsSession),
+		send:       pbbs_send,
+		disconnect: pbbs_disconnect,
+	}
+
+	var b, err = os.ReadFile(p.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return p, nil
+	}
+
+	if err == nil {
+		err = json.Unmarshal(b, &p.store)
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("PBBS file %s: %w", p.path, err)
+	}
+
+	return p, nil
+}
+
+/* Tell the link layer to send us connections for our callsign. */
+
+func (p *PBBS) Start() {
+	if p == nil {
+		return
+	}
+
+	if !agwConnectedModeAllowed(byte(p.channel)) {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("PBBS: Channel %d can't be used for connected mode.\n", p.channel)
+
+		return
+	}
+
+	dlq_register_callsign(p.mycall, p.channel, PBBS_CLIENT)
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("PBBS %s on channel %d, %d messages.\n", p.mycall, p.channel, len(p.store.Messages))
+}
+
+func pbbs_send(channel int, own string, remote string, data []byte) {
+	var addrs [AX25_MAX_ADDRS]string
+	addrs[AX25_SOURCE] = own
+	addrs[AX25_DESTINATION] = remote
+
+	dlq_xmit_data_request(addrs, 2, channel, PBBS_CLIENT, AX25_PID_NO_LAYER_3, data)
+}
+
+func pbbs_disconnect(channel int, own string, remote string) {
+	var addrs [AX25_MAX_ADDRS]string
+	addrs[AX25_SOURCE] = own
+	addrs[AX25_DESTINATION] = remote
+
+	dlq_disconnect_request(addrs, 2, channel, PBBS_CLIENT)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Deliver
+ *
+ * Purpose:	Take what the link layer would send to an AGW client.
+ *
+ * Inputs:	m	- 'C' connected, 'D' data, or 'd' disconnected.
+ *			  Anything else is ignored.
+ *
+ * Description:	Called by send_to_client, in the link layer thread.
+ *
+ *---------------------------------------------------------------*/
+
+func (p *PBBS) Deliver(m *AGWPEMessage) {
+	if p == nil {
+		return
+	}
+
+	var channel = int(m.Header.Portx)
+	var remote = ByteArrayToString(m.Header.CallFrom[:])
+	var own = ByteArrayToString(m.Header.CallTo[:])
+
+	switch m.Header.DataKind {
+	case 'C':
+		p.connected(channel, remote, own)
+	case 'D':
+		p.received(channel, remote, own, m.Data[:min(int(m.Header.DataLen), len(m.Data))])
+	case 'd':
+		p.disconnected(channel, remote)
+	}
+}
+
+func pbbs_key(channel int, remote string) string {
+	return strconv.Itoa(channel) + " " + remote
+}
+
+func pbbs_session(channel int, remote string, own string) *pbbsSession {
+	return &pbbsSession{channel: channel, remote: remote, own: own, partial: nil, lastCR: false, state: PBBS_COMMAND, draft: pbbsMessage{}} //nolint:exhaustruct // Empty draft.
+}
+
+func (p *PBBS) connected(channel int, remote string, own string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var s = pbbs_session(channel, remote, own)
+	p.sessions[pbbs_key(channel, remote)] = s
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("PBBS: %s connected on channel %d.\n", remote, channel)
+
+	var mine = 0
+
+	for _, m := range p.store.Messages {
+		if pbbs_same_call(m.To, remote) && !m.Read {
+			mine++
+		}
+	}
+
+	p.reply(s, fmt.Sprintf("%s mailbox.  %d messages, %d new for you.\r%s", p.mycall, len(p.store.Messages), mine, PBBS_PROMPT))
+}
+
+func (p *PBBS) disconnected(channel int, remote string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	delete(p.sessions, pbbs_key(channel, remote))
+}
+
+/* Gather whole lines.  Some programs end them with CR, some with LF, some both. */
+
+func (p *PBBS) received(channel int, remote string, own string, data []byte) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	var s = p.sessions[pbbs_key(channel, remote)]
+	if s == nil {
+		// Missed the connection somehow.
+		s = pbbs_session(channel, remote, own)
+		p.sessions[pbbs_key(channel, remote)] = s
+	}
+
+	for _, c := range data {
+		var afterCR = s.lastCR
+		s.lastCR = c == '\r'
+
+		if c == '\n' && afterCR {
+			continue
+		}
+
+		if c == '\r' || c == '\n' {
+			var line = string(s.partial)
+			s.partial = s.partial[:0]
+			p.line(s, line)
+
+			continue
+		}
+
+		if len(s.partial) < PBBS_MAX_LINE {
+			s.partial = append(s.partial, c)
+		}
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	line
+ *
+ * Purpose:	Act on one line from a connected station.
+ *
+ *---------------------------------------------------------------*/
+
+func (p *PBBS) line(s *pbbsSession, line string) {
+	switch s.state {
+	case PBBS_SUBJECT:
+		s.draft.Subject = strings.TrimSpace(line)
+		s.state = PBBS_TEXT
+		p.reply(s, "Enter message.  End with /EX on a line by itself.\r")
+
+		return
+	case PBBS_TEXT:
+		if strings.EqualFold(strings.TrimSpace(line), "/EX") || strings.TrimSpace(line) == "\x1a" {
+			p.save(s)
+			s.state = PBBS_COMMAND
+			p.reply(s, PBBS_PROMPT)
+		} else if len(s.draft.Text)+len(line) < PBBS_MAX_MESSAGE {
+			s.draft.Text += line + "\n"
+		}
+
+		return
+	case PBBS_COMMAND:
+	}
+
+	var words = strings.Fields(strings.ToUpper(line))
+	if len(words) == 0 {
+		p.reply(s, PBBS_PROMPT)
+
+		return
+	}
+
+	var arg = ""
+	if len(words) > 1 {
+		arg = words[1]
+	}
+
+	switch words[0] {
+	case "L", "LIST":
+		p.reply(s, p.list(""))
+	case "LM":
+		p.reply(s, p.list(s.remote))
+	case "R", "READ":
+		p.reply(s, p.read(s.remote, arg))
+	case "S", "SEND", "SP":
+		var _, _, _, ok = ax25_parse_addr(AX25_DESTINATION, arg, 1)
+		if !ok {
+			p.reply(s, "Send to whom?  e.g. S "+pbbs_base_call(p.mycall)+"\r")
+
+			break
+		}
+
+		if len(p.store.Messages) >= PBBS_MAX_MESSAGES {
+			p.reply(s, "Sorry, the mailbox is full.\r")
+
+			break
+		}
+
+		s.draft = pbbsMessage{From: s.remote, To: arg} //nolint:exhaustruct
+		s.state = PBBS_SUBJECT
+		p.reply(s, "Subject:\r")
+
+		return
+	case "K", "KILL":
+		p.reply(s, p.kill(s.remote, arg))
+	case "B", "BYE", "Q", "QUIT":
+		p.reply(s, "73\r")
+		p.disconnect(s.channel, s.own, s.remote)
+
+		return
+	case "H", "HELP", "?":
+		p.reply(s, "L       List all messages.\r"+
+			"LM      List messages for you.\r"+
+			"R n     Read message n.\r"+
+			"S call  Send a message.\r"+
+			"K n     Kill message n.\r"+
+			"B       Bye.\r")
+	default:
+		p.reply(s, "Unknown command.  H for help.\r")
+	}
+
+	p.reply(s, PBBS_PROMPT)
+}
+
+/* Messages are for a person, whichever SSID they connect with. */
+
+func pbbs_base_call(call string) string {
+	var base, _, _ = strings.Cut(call, "-")
+
+	return base
+}
+
+func pbbs_same_call(a string, b string) bool {
+	return strings.EqualFold(pbbs_base_call(a), pbbs_base_call(b))
+}
+
+func (p *PBBS) find(arg string) *pbbsMessage {
+	var n, err = strconv.Atoi(arg)
+	if err != nil {
+		return nil
+	}
+
+	for _, m := range p.store.Messages {
+		if m.Number == n {
+			return m
+		}
+	}
+
+	return nil
+}
+
+/* Newest first.  All, or only those for "to" if not empty. */
+
+func (p *PBBS) list(to string) string {
+	var b strings.Builder
+
+	for _, m := range slices.Backward(p.store.Messages) {
+		if to != "" && !pbbs_same_call(m.To, to) {
+			continue
+		}
+
+		if b.Len() == 0 {
+			b.WriteString("Msg#  To        From      Date    Subject\r")
+		}
+
+		var flag = " "
+		if !m.Read {
+			flag = "N"
+		}
+
+		fmt.Fprintf(&b, "%-4d%s %-9s %-9s %-7s %s\r", m.Number, flag, m.To, m.From, m.Date.Local().Format("Jan 02"), m.Subject)
+	}
+
+	if b.Len() == 0 {
+		return "No messages.\r"
+	}
+
+	return b.String()
+}
+
+func (p *PBBS) read(reader string, arg string) string {
+	var m = p.find(arg)
+	if m == nil {
+		return "No message " + arg + ".\r"
+	}
+
+	if pbbs_same_call(m.To, reader) && !m.Read {
+		m.Read = true
+		p.write()
+	}
+
+	var text = strings.ReplaceAll(strings.TrimRight(m.Text, "\n"), "\n", "\r")
+
+	return fmt.Sprintf("Msg %d  From %s  To %s  %s\rSubject: %s\r\r%s\r",
+		m.Number, m.From, m.To, m.Date.Local().Format("2006-01-02 15:04"), m.Subject, text)
+}
+
+func (p *PBBS) kill(caller string, arg string) string {
+	var m = p.find(arg)
+	if m == nil {
+		return "No message " + arg + ".\r"
+	}
+
+	if !pbbs_same_call(caller, m.From) && !pbbs_same_call(caller, m.To) && !pbbs_same_call(caller, p.mycall) {
+		return fmt.Sprintf("Message %d isn't yours to kill.\r", m.Number)
+	}
+
+	p.store.Messages = slices.DeleteFunc(p.store.Messages, func(x *pbbsMessage) bool { return x == m })
+	p.write()
+
+	return fmt.Sprintf("Message %d killed.\r", m.Number)
+}
+
+func (p *PBBS) save(s *pbbsSession) {
+	var m = s.draft
+	m.Number = p.store.Next
+	m.Date = p.clock.Now()
+
+	p.store.Next++
+	p.store.Messages = append(p.store.Messages, &m)
+	p.write()
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("PBBS: Message %d from %s to %s.\n", m.Number, m.From, m.To)
+
+	p.reply(s, fmt.Sprintf("Message %d saved.\r", m.Number))
+}
+
+/* Replace the file by writing a new one and renaming it, so it's never half written. */
+
+func (p *PBBS) write() {
+	var b, err = json.MarshalIndent(&p.store, "", "  ")
+	if err != nil {
+		return
+	}
+
+	var tmp = filepath.Join(filepath.Dir(p.path), "."+filepath.Base(p.path)+".tmp")
+
+	err = os.WriteFile(tmp, b, 0o600)
+	if err == nil {
+		err = os.Rename(tmp, p.path)
+	}
+
+	if err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Can't write PBBS file %s: %s\n", p.path, err)
+	}
+}
+
+/* A long reply goes in pieces, at ends of lines where possible. */
+
+func (p *PBBS) reply(s *pbbsSession, text string) {
+	var data = []byte(text)
+
+	for len(data) > 0 {
+		var n = len(data)
+
+		if n > PBBS_SEND_CHUNK {
+			n = bytes.LastIndexByte(data[:PBBS_SEND_CHUNK], '\r') + 1
+			if n == 0 {
+				n = PBBS_SEND_CHUNK
+			}
+		}
+
+		p.send(s.channel, s.own, s.remote, data[:n])
+		data = data[n:]
+	}
+}
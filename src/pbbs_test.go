@@ -0,0 +1,129 @@
+package direwolf
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_config_init_pbbs(t *testing.T) {
+	var _, misc = configFromString(t, "PBBS 1 q1test-1 /var/lib/samoyed/mail.json\n")
+
+	assert.Equal(t, 1, misc.pbbs_channel)
+	assert.Equal(t, "Q1TEST-1", misc.pbbs_mycall)
+	assert.Equal(t, "/var/lib/samoyed/mail.json", misc.pbbs_file)
+
+	_, misc = configFromString(t, "PBBS 0 Q1TEST-1\nPBBS x Q1TEST-1 mail.json\n")
+	assert.Empty(t, misc.pbbs_mycall)
+}
+
+/* The mailbox, connected to the link layer's calls for an AGW client. */
+
+func newTestPBBS(t *testing.T, path string) (*PBBS, *strings.Builder, *int) {
+	t.Helper()
+
+	var p, err = NewPBBS(&misc_config_s{pbbs_channel: 0, pbbs_mycall: "Q1TEST-1", pbbs_file: path}) //nolint:exhaustruct
+	require.NoError(t, err)
+	require.NotNil(t, p)
+
+	p.clock = NewFakeClock(time.Date(2026, 3, 4, 12, 0, 0, 0, time.UTC))
+
+	var sent = new(strings.Builder)
+	var disconnects = new(int)
+
+	p.send = func(channel int, own string, remote string, data []byte) {
+		assert.Equal(t, "Q1TEST-1", own)
+		assert.LessOrEqual(t, len(data), PBBS_SEND_CHUNK)
+		sent.Write(data)
+	}
+	p.disconnect = func(channel int, own string, remote string) {
+		*disconnects++
+	}
+
+	var saved = pbbsService
+	t.Cleanup(func() { pbbsService = saved })
+	pbbsService = p
+
+	return p, sent, disconnects
+}
+
+func pbbsSay(sent *strings.Builder, remote string, text string) string {
+	sent.Reset()
+	server_rec_conn_data(0, PBBS_CLIENT, remote, "Q1TEST-1", AX25_PID_NO_LAYER_3, []byte(text))
+
+	return sent.String()
+}
+
+func Test_PBBS(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "mail.json")
+	var _, sent, disconnects = newTestPBBS(t, path)
+
+	server_link_established(0, PBBS_CLIENT, "Q2OTHR-7", "Q1TEST-1", true)
+	assert.Contains(t, sent.String(), "0 messages, 0 new for you.")
+	assert.Contains(t, sent.String(), PBBS_PROMPT)
+
+	assert.Contains(t, pbbsSay(sent, "Q2OTHR-7", "L\r"), "No messages.")
+	assert.Contains(t, pbbsSay(sent, "Q2OTHR-7", "S\r"), "Send to whom?")
+
+	// Lines can end with CR, LF, or both, and come in pieces.
+
+	assert.Equal(t, "Subject:\r", pbbsSay(sent, "Q2OTHR-7", "s q1test\r"))
+	assert.Contains(t, pbbsSay(sent, "Q2OTHR-7", "Net tonight\r\n"), "End with /EX")
+	pbbsSay(sent, "Q2OTHR-7", "Line one\nLi")
+	pbbsSay(sent, "Q2OTHR-7", "ne two\r\r")
+	assert.Contains(t, pbbsSay(sent, "Q2OTHR-7", "/ex\r"), "Message 1 saved.")
+
+	var list = pbbsSay(sent, "Q2OTHR-7", "L\r")
+	assert.Contains(t, list, "1   N Q1TEST    Q2OTHR-7  Mar 04  Net tonight\r")
+
+	assert.Contains(t, pbbsSay(sent, "Q2OTHR-7", "LM\r"), "No messages.")
+	assert.Contains(t, pbbsSay(sent, "Q2OTHR-7", "R 2\r"), "No message 2.")
+	assert.Contains(t, pbbsSay(sent, "Q2OTHR-7", "Z\r"), "Unknown command.")
+
+	assert.Contains(t, pbbsSay(sent, "Q2OTHR-7", "B\r"), "73")
+	assert.Equal(t, 1, *disconnects)
+	server_link_terminated(0, PBBS_CLIENT, "Q2OTHR-7", "Q1TEST-1", false)
+
+	// Still there after a restart.  For us, with any SSID.
+
+	var _, sent2, _ = newTestPBBS(t, path)
+
+	server_link_established(0, PBBS_CLIENT, "Q1TEST-5", "Q1TEST-1", true)
+	assert.Contains(t, sent2.String(), "1 messages, 1 new for you.")
+	assert.Contains(t, pbbsSay(sent2, "Q1TEST-5", "LM\r"), "Net tonight")
+
+	var msg = pbbsSay(sent2, "Q1TEST-5", "R 1\r")
+	assert.Contains(t, msg, "From Q2OTHR-7  To Q1TEST")
+	assert.Contains(t, msg, "Subject: Net tonight\r\rLine one\rLine two\r"+PBBS_PROMPT, "trailing blank lines dropped")
+	assert.Contains(t, pbbsSay(sent2, "Q1TEST-5", "L\r"), "1     Q1TEST", "no longer new")
+
+	// Only the sender, the one it's to, or the owner can kill it.
+
+	server_link_established(0, PBBS_CLIENT, "Q3NOPE", "Q1TEST-1", true)
+	assert.Contains(t, pbbsSay(sent2, "Q3NOPE", "K 1\r"), "isn't yours")
+	assert.Contains(t, pbbsSay(sent2, "Q1TEST-5", "K 1\r"), "Message 1 killed.")
+	assert.Contains(t, pbbsSay(sent2, "Q3NOPE", "L\r"), "No messages.")
+
+	var p3, _, _ = newTestPBBS(t, path)
+	assert.Empty(t, p3.store.Messages)
+	assert.Equal(t, 2, p3.store.Next, "numbers aren't used again")
+}
+
+func Test_PBBS_long_reply(t *testing.T) {
+	var p, sent, _ = newTestPBBS(t, filepath.Join(t.TempDir(), "mail.json"))
+
+	for range 20 {
+		p.store.Messages = append(p.store.Messages, &pbbsMessage{Number: p.store.Next, From: "Q2OTHR", To: "Q1TEST", Subject: strings.Repeat("x", 40)}) //nolint:exhaustruct
+		p.store.Next++
+	}
+
+	server_link_established(0, PBBS_CLIENT, "Q2OTHR", "Q1TEST-1", true)
+
+	var list = pbbsSay(sent, "Q2OTHR", "L\r")
+	assert.Greater(t, len(list), PBBS_SEND_CHUNK, "sent in pieces")
+	assert.Equal(t, 21, strings.Count(list, "\r")-1, "heading, 20 messages, and the prompt")
+}
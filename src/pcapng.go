@@ -0,0 +1,361 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Save every frame sent and received in a pcapng file
+ *		so it can be examined with Wireshark.
+ *
+ * Description:	"direwolf --pcap file.pcapng" writes each AX.25 frame,
+ *		without the FCS, using link type LINKTYPE_AX25.  Each
+ *		one has a comment with the channel, direction, audio
+ *		level, and FEC used, which Wireshark shows as
+ *		"Packet comments".
+ *
+ *		Only the pcapng block types needed are written:
+ *		Section Header, Interface Description, and Enhanced
+ *		Packet.  See https://www.ietf.org/archive/id/draft-ietf-opsawg-pcapng-02.html
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+const PCAPNG_SHB = 0x0a0d0d0a // Section Header Block
+const PCAPNG_IDB = 0x00000001 // Interface Description Block
+const PCAPNG_EPB = 0x00000006 // Enhanced Packet Block
+
+const PCAPNG_BYTE_ORDER_MAGIC = 0x1a2b3c4d
+
+const PCAPNG_OPT_ENDOFOPT = 0
+const PCAPNG_OPT_COMMENT = 1
+const PCAPNG_SHB_USERAPPL = 4
+
+type PcapngWriter struct {
+	mu  sync.Mutex
+	w   io.Writer
+	f   *os.File // nil if not writing to a file.
+	err error    // First write error.  Nothing more is written after.
+}
+
+var pcapWriter *PcapngWriter
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	NewPcapngWriter
+ *
+ * Purpose:	Create a capture file.
+ *
+ * Inputs:	path	- File name.  An existing file is replaced.
+ *
+ *---------------------------------------------------------------*/
+
+func NewPcapngWriter(path string) (*PcapngWriter, error) {
+	var f, err = os.Create(path) //nolint:gosec // Named on the command line.
+	if err != nil {
+		return nil, err
+	}
+
+	var pw = new_pcapng_writer(f)
+	pw.f = f
+
+	if pw.err != nil {
+		f.Close()
+
+		return nil, pw.err
+	}
+
+	return pw, nil
+}
+
+func new_pcapng_writer(w io.Writer) *PcapngWriter {
+	var pw = &PcapngWriter{ //nolint:exhaustruct
+		w: w,
+	}
+
+	/* Section Header Block. */
+
+	var shb bytes.Buffer
+
+	binary.Write(&shb, binary.LittleEndian, uint32(PCAPNG_BYTE_ORDER_MAGIC))
+	binary.Write(&shb, binary.LittleEndian, uint16(1)) // Major version.
+	binary.Write(&shb, binary.LittleEndian, uint16(0)) // Minor version.
+	binary.Write(&shb, binary.LittleEndian, int64(-1)) // Section length not specified.
+	pcapng_option(&shb, PCAPNG_SHB_USERAPPL, "Samoyed "+SAMOYED_VERSION)
+	pcapng_option(&shb, PCAPNG_OPT_ENDOFOPT, "")
+
+	pw.block(PCAPNG_SHB, shb.Bytes())
+
+	/* Interface Description Block.  Time stamp resolution is the default microseconds. */
+
+	var idb bytes.Buffer
+
+	binary.Write(&idb, binary.LittleEndian, uint16(DLT_AX25))
+	binary.Write(&idb, binary.LittleEndian, uint16(0)) // Reserved.
+	binary.Write(&idb, binary.LittleEndian, uint32(0)) // No snap length limit.
+
+	pw.block(PCAPNG_IDB, idb.Bytes())
+
+	return pw
+}
+
+/*
+ * Option code, length, value padded to multiple of 4.
+ */
+
+func pcapng_option(b *bytes.Buffer, code uint16, value string) {
+	binary.Write(b, binary.LittleEndian, code)
+	binary.Write(b, binary.LittleEndian, uint16(len(value)))
+	b.WriteString(value)
+	b.Write(make([]byte, pcapng_pad(len(value))))
+}
+
+func pcapng_pad(n int) int {
+	return (4 - n%4) % 4
+}
+
+/*
+ * Block type, total length, body, total length again.
+ */
+
+func (pw *PcapngWriter) block(btype uint32, body []byte) {
+	if pw.err != nil {
+		return
+	}
+
+	var total = uint32(12 + len(body))
+
+	var b bytes.Buffer
+
+	binary.Write(&b, binary.LittleEndian, btype)
+	binary.Write(&b, binary.LittleEndian, total)
+	b.Write(body)
+	binary.Write(&b, binary.LittleEndian, total)
+
+	_, pw.err = pw.w.Write(b.Bytes())
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	WriteFrame
+ *
+ * Purpose:	Add one frame to the capture file.
+ *
+ * Inputs:	t	- When it was sent or received.
+ *
+ *		frame	- AX.25 frame without the FCS, from AX25Pack.
+ *
+ *		comment	- Displayed by Wireshark for this frame.
+ *
+ * Description:	Safe to call on a nil PcapngWriter, which does nothing,
+ *		so callers don't need to check whether capture is enabled.
+ *
+ *---------------------------------------------------------------*/
+
+func (pw *PcapngWriter) WriteFrame(t time.Time, frame []byte, comment string) {
+	if pw == nil {
+		return
+	}
+
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if pw.err != nil {
+		return
+	}
+
+	var usec = uint64(t.UnixMicro()) //nolint:gosec // Time is after 1970.
+
+	var epb bytes.Buffer
+
+	binary.Write(&epb, binary.LittleEndian, uint32(0)) // Interface.
+	binary.Write(&epb, binary.LittleEndian, uint32(usec>>32))
+	binary.Write(&epb, binary.LittleEndian, uint32(usec&0xffffffff))
+	binary.Write(&epb, binary.LittleEndian, uint32(len(frame))) // Captured length.
+	binary.Write(&epb, binary.LittleEndian, uint32(len(frame))) // Original length.
+	epb.Write(frame)
+	epb.Write(make([]byte, pcapng_pad(len(frame))))
+
+	if comment != "" {
+		pcapng_option(&epb, PCAPNG_OPT_COMMENT, comment)
+		pcapng_option(&epb, PCAPNG_OPT_ENDOFOPT, "")
+	}
+
+	pw.block(PCAPNG_EPB, epb.Bytes())
+
+	if pw.err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Error writing packet capture file: %s\n", pw.err)
+		dw_printf("No more frames will be saved.\n")
+	}
+}
+
+func (pw *PcapngWriter) Close() {
+	if pw == nil {
+		return
+	}
+
+	pw.mu.Lock()
+	defer pw.mu.Unlock()
+
+	if pw.f != nil {
+		pw.f.Close()
+		pw.f = nil
+	}
+}
+
+/*
+ * Comments for frames.
+ */
+
+func pcap_rec_comment(channel int, subchan int, slice int, alevel ALevel, fec_type fec_type_t, retries BitFixLevel) string {
+	var s = fmt.Sprintf("rx channel %d", channel)
+
+	if subchan >= 0 {
+		s += fmt.Sprintf(", demodulator %d, slicer %d", subchan, slice)
+	}
+
+	if text := ax25_alevel_to_text(alevel); text != "" {
+		s += ", audio level " + text
+	}
+
+	switch fec_type {
+	case fec_type_fx25:
+		s += ", FX.25"
+	case fec_type_il2p:
+		s += ", IL2P"
+	default:
+		if retries != RETRY_NONE {
+			s += ", fixed bits " + retries.String()
+		}
+	}
+
+	return s
+}
+
+func pcap_xmit_comment(channel int, prio int, pa *audio_s) string {
+	var s = fmt.Sprintf("tx channel %d, priority %c", channel, priorityToRune(prio))
+
+	switch pa.achan[channel].layer2_xmit {
+	case LAYER2_FX25:
+		s += ", FX.25"
+	case LAYER2_IL2P:
+		s += ", IL2P"
+	case LAYER2_AX25:
+	}
+
+	return s
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	pcapng_read
+ *
+ * Purpose:	Read the frames from a capture file.
+ *
+ * Inputs:	r	- Capture file.
+ *
+ *		fn	- Called for each AX.25 frame with its comment,
+ *			  or "" if none.
+ *
+ * Description:	Files from Wireshark are accepted too, as long as they
+ *		are little endian.  Frames from interfaces with another
+ *		link type are skipped.
+ *
+ *---------------------------------------------------------------*/
+
+func pcapng_read(r io.Reader, fn func(frame []byte, comment string)) error {
+	var linktypes []uint16
+
+	for {
+		var hdr [8]byte
+
+		var _, err = io.ReadFull(r, hdr[:])
+		if err == io.EOF {
+			return nil
+		} else if err != nil {
+			return err
+		}
+
+		var btype = binary.LittleEndian.Uint32(hdr[0:])
+		var total = binary.LittleEndian.Uint32(hdr[4:])
+
+		if total < 12 || total%4 != 0 {
+			return fmt.Errorf("invalid pcapng block length %d", total)
+		}
+
+		var body = make([]byte, total-8)
+
+		_, err = io.ReadFull(r, body)
+		if err != nil {
+			return err
+		}
+
+		body = body[:len(body)-4] // Repeated length.
+
+		switch btype {
+		case PCAPNG_SHB:
+			if len(body) < 4 || binary.LittleEndian.Uint32(body) != PCAPNG_BYTE_ORDER_MAGIC {
+				return errors.New("not a little endian pcapng file")
+			}
+
+			linktypes = nil
+		case PCAPNG_IDB:
+			if len(body) < 2 {
+				return errors.New("invalid pcapng interface description")
+			}
+
+			linktypes = append(linktypes, binary.LittleEndian.Uint16(body))
+		case PCAPNG_EPB:
+			if len(body) < 20 {
+				return errors.New("invalid pcapng packet block")
+			}
+
+			var iface = binary.LittleEndian.Uint32(body[0:])
+			var caplen = int(binary.LittleEndian.Uint32(body[12:]))
+
+			if caplen > len(body)-20 {
+				return errors.New("invalid pcapng packet length")
+			}
+
+			if int(iface) >= len(linktypes) || linktypes[iface] != DLT_AX25 {
+				continue
+			}
+
+			var frame = body[20 : 20+caplen]
+			var opts = body[20+caplen+pcapng_pad(caplen):]
+
+			fn(frame, pcapng_comment(opts))
+		}
+	}
+}
+
+/*
+ * First comment in the options, if any.
+ */
+
+func pcapng_comment(opts []byte) string {
+	for len(opts) >= 4 {
+		var code = binary.LittleEndian.Uint16(opts[0:])
+		var length = int(binary.LittleEndian.Uint16(opts[2:]))
+
+		if code == PCAPNG_OPT_ENDOFOPT || 4+length > len(opts) {
+			break
+		}
+
+		if code == PCAPNG_OPT_COMMENT {
+			return string(opts[4 : 4+length])
+		}
+
+		opts = opts[min(4+length+pcapng_pad(length), len(opts)):]
+	}
+
+	return ""
+}
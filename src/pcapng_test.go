@@ -0,0 +1,163 @@
+package direwolf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/*
+ * Split a capture into blocks, checking the lengths at each end match.
+ */
+
+type pcapngTestBlock struct {
+	btype uint32
+	body  []byte
+}
+
+func pcapng_test_blocks(t *testing.T, data []byte) []pcapngTestBlock {
+	t.Helper()
+
+	var blocks []pcapngTestBlock
+
+	for len(data) > 0 {
+		require.GreaterOrEqual(t, len(data), 12)
+
+		var btype = binary.LittleEndian.Uint32(data[0:])
+		var total = int(binary.LittleEndian.Uint32(data[4:]))
+
+		require.Zero(t, total%4, "block length must be multiple of 4")
+		require.LessOrEqual(t, total, len(data))
+		require.Equal(t, uint32(total), binary.LittleEndian.Uint32(data[total-4:])) //nolint:gosec
+
+		blocks = append(blocks, pcapngTestBlock{btype: btype, body: data[8 : total-4]})
+		data = data[total:]
+	}
+
+	return blocks
+}
+
+func Test_pcapng_writer(t *testing.T) {
+	var buf bytes.Buffer
+
+	var pw = new_pcapng_writer(&buf)
+	require.NoError(t, pw.err)
+
+	var pp = AX25FromText("Q1TEST>APDW18,WIDE2-1:>Hello", true)
+	require.NotNil(t, pp)
+
+	var frame = AX25Pack(pp)
+	var when = time.Date(2024, 5, 6, 7, 8, 9, 123456000, time.UTC)
+
+	pw.WriteFrame(when, frame, "rx channel 0")
+	pw.WriteFrame(when, frame, "")
+
+	var blocks = pcapng_test_blocks(t, buf.Bytes())
+	require.Len(t, blocks, 4)
+
+	/* Section header. */
+
+	assert.Equal(t, uint32(PCAPNG_SHB), blocks[0].btype)
+	assert.Equal(t, uint32(PCAPNG_BYTE_ORDER_MAGIC), binary.LittleEndian.Uint32(blocks[0].body[0:]))
+	assert.Contains(t, string(blocks[0].body), "Samoyed")
+
+	/* Interface is AX.25. */
+
+	assert.Equal(t, uint32(PCAPNG_IDB), blocks[1].btype)
+	assert.Equal(t, uint16(DLT_AX25), binary.LittleEndian.Uint16(blocks[1].body[0:]))
+
+	/* Frame with comment. */
+
+	var epb = blocks[2].body
+
+	assert.Equal(t, uint32(PCAPNG_EPB), blocks[2].btype)
+
+	var usec = uint64(binary.LittleEndian.Uint32(epb[4:]))<<32 | uint64(binary.LittleEndian.Uint32(epb[8:]))
+	assert.Equal(t, uint64(when.UnixMicro()), usec) //nolint:gosec
+
+	assert.Equal(t, uint32(len(frame)), binary.LittleEndian.Uint32(epb[12:])) //nolint:gosec
+	assert.Equal(t, uint32(len(frame)), binary.LittleEndian.Uint32(epb[16:])) //nolint:gosec
+	assert.Equal(t, frame, epb[20:20+len(frame)])
+
+	var opts = epb[20+len(frame)+pcapng_pad(len(frame)):]
+	assert.Equal(t, uint16(PCAPNG_OPT_COMMENT), binary.LittleEndian.Uint16(opts[0:]))
+	assert.Equal(t, uint16(12), binary.LittleEndian.Uint16(opts[2:]))
+	assert.Equal(t, "rx channel 0", string(opts[4:16]))
+	assert.Equal(t, []byte{0, 0, 0, 0}, opts[16:])
+
+	/* Frame without comment has no options. */
+
+	assert.Len(t, blocks[3].body, 20+len(frame)+pcapng_pad(len(frame)))
+}
+
+type pcapngFailWriter struct {
+	count int
+}
+
+func (w *pcapngFailWriter) Write(p []byte) (int, error) {
+	w.count++
+
+	return 0, errors.New("disk full")
+}
+
+func Test_pcapng_write_error(t *testing.T) {
+	var w = new(pcapngFailWriter)
+
+	var pw = new_pcapng_writer(w)
+	require.Error(t, pw.err)
+
+	pw.WriteFrame(time.Now(), []byte{1, 2, 3}, "")
+
+	assert.Equal(t, 1, w.count, "nothing more written after an error")
+}
+
+func Test_pcapng_nil(t *testing.T) {
+	var pw *PcapngWriter
+
+	pw.WriteFrame(time.Now(), []byte{1, 2, 3}, "")
+	pw.Close()
+}
+
+func Test_pcap_comments(t *testing.T) {
+	assert.Equal(t, "rx channel 0, demodulator 1, slicer 2, audio level 50(25/24), FX.25",
+		pcap_rec_comment(0, 1, 2, ALevel{rec: 50, mark: 25, space: 24}, fec_type_fx25, RETRY_NONE))
+
+	assert.Equal(t, "rx channel 1, demodulator 0, slicer 0, audio level 30(15/14), fixed bits SINGLE",
+		pcap_rec_comment(1, 0, 0, ALevel{rec: 30, mark: 15, space: 14}, fec_type_none, RETRY_INVERT_SINGLE))
+
+	var pa = new(audio_s)
+	pa.achan[1].layer2_xmit = LAYER2_IL2P
+
+	assert.Equal(t, "tx channel 0, priority H", pcap_xmit_comment(0, TQ_PRIO_0_HI, pa))
+	assert.Equal(t, "tx channel 1, priority L, IL2P", pcap_xmit_comment(1, TQ_PRIO_1_LO, pa))
+}
+
+func Test_pcapng_read(t *testing.T) {
+	var buf bytes.Buffer
+
+	var pw = new_pcapng_writer(&buf)
+
+	pw.WriteFrame(time.Now(), []byte("frame one"), "rx channel 0")
+	pw.WriteFrame(time.Now(), []byte("frame 2"), "")
+
+	var frames, comments []string
+
+	var err = pcapng_read(&buf, func(frame []byte, comment string) {
+		frames = append(frames, string(frame))
+		comments = append(comments, comment)
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, []string{"frame one", "frame 2"}, frames)
+	assert.Equal(t, []string{"rx channel 0", ""}, comments)
+}
+
+func Test_pcapng_read_bad(t *testing.T) {
+	var err = pcapng_read(bytes.NewReader([]byte("This is not pcapng at all.")), func([]byte, string) {})
+	assert.Error(t, err)
+}
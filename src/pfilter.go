@@ -22,6 +22,8 @@ import (
 	"strconv"
 	"strings"
 	"unicode"
+
+	"github.com/doismellburning/samoyed/src/geo"
 )
 
 /*
@@ -879,7 +881,7 @@ func filt_r(pf *pfstate_t) (int, string) {
 		return -1, ""
 	}
 
-	var km = ll_distance_km(dlat, dlon, float64(pf.decoded.g_lat), float64(pf.decoded.g_lon))
+	var km = geo.DistanceKM(dlat, dlon, float64(pf.decoded.g_lat), float64(pf.decoded.g_lon))
 	var sdist = fmt.Sprintf("%.2f km", km)
 
 	if km <= ddist {
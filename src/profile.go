@@ -0,0 +1,449 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Switch a radio channel between frequencies and modems,
+ *		e.g. 144.39 APRS by day and 30 meters overnight.
+ *
+ * Description:	After the usual settings for a channel, each PROFILE
+ *		gives another way of using it.
+ *
+ *			PROFILE  name  [ FREQ=MHz ]  [ MODE=mode ]  [ AT=hh:mm ]  [ MODEM speed [ options ] ]
+ *
+ *		FREQ and MODE are sent to the radio with hamlib or
+ *		rigctld, so they need PTT RIG.  MODE is a hamlib name
+ *		such as FM, USB or PKTUSB.  MODEM is the same as the
+ *		MODEM command.  Without it, the channel's own MODEM
+ *		settings are used.
+ *
+ *		A profile with AT becomes active at that local time
+ *		each day.  Any profile can be picked at any time with
+ *		"set profile" on the control socket.  That lasts until
+ *		the next scheduled change.
+ *
+ *		The radio is tuned right away.  The modem can't change
+ *		while a sample is being demodulated or a frame is being
+ *		sent, so the thread reading audio for the channel does
+ *		it between samples, when the channel isn't transmitting.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	goHamlib "github.com/xylo04/goHamlib"
+)
+
+/* The settings from the MODEM command. */
+
+type chan_modem_s struct {
+	modem_type      modem_t
+	v26_alternative v26_e
+	baud            int
+	mark_freq       int
+	space_freq      int
+	profiles        string
+	num_freq        int
+	offset          int
+	decimate        int
+	upsample        int
+}
+
+func chan_modem_from(a *achan_param_s) chan_modem_s {
+	return chan_modem_s{
+		modem_type:      a.modem_type,
+		v26_alternative: a.v26_alternative,
+		baud:            a.baud,
+		mark_freq:       a.mark_freq,
+		space_freq:      a.space_freq,
+		profiles:        a.profiles,
+		num_freq:        a.num_freq,
+		offset:          a.offset,
+		decimate:        a.decimate,
+		upsample:        a.upsample,
+	}
+}
+
+func (m *chan_modem_s) apply(a *achan_param_s) {
+	a.modem_type = m.modem_type
+	a.v26_alternative = m.v26_alternative
+	a.baud = m.baud
+	a.mark_freq = m.mark_freq
+	a.space_freq = m.space_freq
+	a.profiles = m.profiles
+	a.num_freq = m.num_freq
+	a.offset = m.offset
+	a.decimate = m.decimate
+	a.upsample = m.upsample
+}
+
+type chan_profile_s struct {
+	name string
+	freq float64 /* MHz.  0 to leave the radio alone. */
+	mode string  /* Hamlib mode name.  Empty to leave the radio alone. */
+	at   int     /* Minutes after local midnight to switch.  -1 for only on command. */
+
+	has_modem bool /* false to use the channel's own MODEM settings. */
+	modem     chan_modem_s
+}
+
+/* The part of goHamlib.Rig we need here.  Replaced in tests. */
+
+type rigTuner interface {
+	SetFreq(vfo goHamlib.VFOType, freq float64) error
+	SetMode(vfo goHamlib.VFOType, mode goHamlib.Mode, pbWidth int) error
+}
+
+/* Hamlib mode for a name such as "pktusb".  Case doesn't matter. */
+
+func rig_mode_value(name string) (goHamlib.Mode, bool) {
+	for mode, n := range goHamlib.ModeName {
+		if strings.EqualFold(n, name) {
+			return mode, true
+		}
+	}
+
+	return 0, false
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	profile_tune
+ *
+ * Purpose:	Set the radio's frequency and mode for a profile.
+ *
+ *--------------------------------------------------------------------*/
+
+func profile_tune(r rigTuner, p *chan_profile_s) error {
+	if p.freq != 0 {
+		var err = r.SetFreq(goHamlib.VFOCurrent, p.freq*1e6)
+		if err != nil {
+			return fmt.Errorf("can't set frequency to %.6f MHz: %w", p.freq, err)
+		}
+	}
+
+	if p.mode != "" {
+		var mode, ok = rig_mode_value(p.mode)
+		if !ok {
+			return fmt.Errorf("unknown mode %s", p.mode)
+		}
+
+		var err = r.SetMode(goHamlib.VFOCurrent, mode, 0) // 0 for the radio's normal passband.
+		if err != nil {
+			return fmt.Errorf("can't set mode to %s: %w", p.mode, err)
+		}
+	}
+
+	return nil
+}
+
+/* Hamlib can't be used by two goroutines at once.  See rig_poll.go. */
+
+func profile_tune_rig(channel int, p *chan_profile_s) error {
+	rig_mutex[channel].Lock()
+	defer rig_mutex[channel].Unlock()
+
+	var r = rig[channel][OCTYPE_PTT]
+	if r == nil {
+		return errors.New("radio isn't under hamlib control")
+	}
+
+	return profile_tune(r, p)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	profile_scheduled
+ *
+ * Purpose:	Which profile the schedule says should be in use now.
+ *
+ * Returns:	The one with the latest AT no later than now.  Before
+ *		the first of the day, the last one from yesterday.
+ *		nil if none of them has AT.
+ *
+ *--------------------------------------------------------------------*/
+
+func profile_scheduled(profiles []*chan_profile_s, now time.Time) *chan_profile_s {
+	var minutes = now.Hour()*60 + now.Minute()
+
+	var today, latest *chan_profile_s
+
+	for _, p := range profiles {
+		if p.at < 0 {
+			continue
+		}
+
+		if p.at <= minutes && (today == nil || p.at > today.at) {
+			today = p
+		}
+
+		if latest == nil || p.at > latest.at {
+			latest = p
+		}
+	}
+
+	if today != nil {
+		return today
+	}
+
+	return latest
+}
+
+type ProfileService struct {
+	mu sync.Mutex
+
+	pa *audio_s
+
+	clock Clock
+
+	active    [MAX_RADIO_CHANS]string /* Name of profile in use, "" for none yet. */
+	scheduled [MAX_RADIO_CHANS]string /* Last one picked by the schedule. */
+
+	modem [MAX_RADIO_CHANS]chan_modem_s /* Requested modem settings. */
+	base  [MAX_RADIO_CHANS]chan_modem_s /* From MODEM, for profiles without their own. */
+
+	pending [MAX_RADIO_CHANS]atomic.Bool /* modem to be applied by receive thread. */
+
+	tune   func(channel int, p *chan_profile_s) error /* profile_tune_rig, replaced for testing. */
+	reinit func(channel int)                          /* profile_reinit_modem, replaced for testing. */
+}
+
+var profileSvc *ProfileService //nolint:gochecknoglobals
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	NewProfileService
+ *
+ * Purpose:	Remember the channels' own modem settings, before any
+ *		profile changes them.
+ *
+ * Returns:	nil if no channel has a PROFILE.
+ *
+ *--------------------------------------------------------------------*/
+
+func NewProfileService(pa *audio_s, clock Clock) *ProfileService {
+	var s = &ProfileService{pa: pa, clock: clock, tune: profile_tune_rig, reinit: profile_reinit_modem} //nolint:exhaustruct
+
+	var found = false
+
+	for ch := range MAX_RADIO_CHANS {
+		if pa.chan_medium[ch] != MEDIUM_RADIO || len(pa.achan[ch].chan_profiles) == 0 {
+			continue
+		}
+
+		found = true
+		s.base[ch] = chan_modem_from(&pa.achan[ch])
+		s.modem[ch] = s.base[ch]
+	}
+
+	if !found {
+		return nil
+	}
+
+	return s
+}
+
+/* After the radios are opened by ptt_init. */
+
+func (s *ProfileService) Start() {
+	if s == nil {
+		return
+	}
+
+	go s.scheduleThread()
+}
+
+func (s *ProfileService) scheduleThread() {
+	for {
+		var now = s.clock.Now()
+
+		s.checkSchedule(now)
+
+		s.clock.Sleep(now.Truncate(time.Minute).Add(time.Minute).Sub(now))
+	}
+}
+
+func (s *ProfileService) checkSchedule(now time.Time) {
+	for ch := range MAX_RADIO_CHANS {
+		var p = profile_scheduled(s.pa.achan[ch].chan_profiles, now)
+		if p == nil {
+			continue
+		}
+
+		s.mu.Lock()
+		var changed = p.name != s.scheduled[ch]
+		s.scheduled[ch] = p.name
+		s.mu.Unlock()
+
+		if !changed {
+			continue
+		}
+
+		var err = s.Switch(ch, p.name)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Channel %d: Can't switch to profile %s: %s\n", ch, p.name, err)
+		}
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Switch
+ *
+ * Purpose:	Start using a profile.
+ *
+ * Inputs:	channel	- Radio channel.
+ *		name	- From PROFILE.  Case doesn't matter.
+ *
+ * Returns:	Error if there's no such profile or the radio can't be
+ *		tuned.  The modem is left alone if the radio can't be.
+ *
+ *--------------------------------------------------------------------*/
+
+func (s *ProfileService) Switch(channel int, name string) error {
+	if s == nil {
+		return errors.New("no profiles are configured")
+	}
+
+	var p = s.find(channel, name)
+	if p == nil {
+		return fmt.Errorf("channel %d has no profile %q", channel, name)
+	}
+
+	if p.freq != 0 || p.mode != "" {
+		var err = s.tune(channel, p)
+		if err != nil {
+			return err
+		}
+	}
+
+	var modem = s.modemFor(channel, p)
+
+	s.mu.Lock()
+
+	s.active[channel] = p.name
+
+	if modem != s.modem[channel] {
+		s.modem[channel] = modem
+		s.pending[channel].Store(true)
+	}
+
+	s.mu.Unlock()
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("Channel %d: Now using profile %s%s.\n", channel, p.name, p.describe(&modem))
+
+	return nil
+}
+
+func (s *ProfileService) find(channel int, name string) *chan_profile_s {
+	if channel < 0 || channel >= MAX_RADIO_CHANS {
+		return nil
+	}
+
+	for _, p := range s.pa.achan[channel].chan_profiles {
+		if strings.EqualFold(p.name, name) {
+			return p
+		}
+	}
+
+	return nil
+}
+
+func (s *ProfileService) modemFor(channel int, p *chan_profile_s) chan_modem_s {
+	if p.has_modem {
+		return p.modem
+	}
+
+	return s.base[channel]
+}
+
+/* Name of the profile in use, or "" if none has been picked yet. */
+
+func (s *ProfileService) Active(channel int) string {
+	if s == nil || channel < 0 || channel >= MAX_RADIO_CHANS {
+		return ""
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.active[channel]
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	ApplyPending
+ *
+ * Purpose:	Change the modem for a channel if a profile asked for it.
+ *
+ * Description:	Called by the thread reading audio for the channel,
+ *		between samples, so this is cheap when there's nothing
+ *		to do.  If the channel is transmitting, try again later.
+ *
+ *--------------------------------------------------------------------*/
+
+func (s *ProfileService) ApplyPending(channel int) {
+	if s == nil || !s.pending[channel].Load() {
+		return
+	}
+
+	if xmitSvc != nil {
+		var dev = &xmitSvc.arbiter[ACHAN2ADEV(channel)]
+		if !dev.TryAcquire() {
+			return
+		}
+
+		defer dev.Release()
+	}
+
+	s.mu.Lock()
+	var modem = s.modem[channel]
+	s.pending[channel].Store(false)
+	s.mu.Unlock()
+
+	modem.apply(&s.pa.achan[channel])
+
+	s.reinit(channel)
+}
+
+func profile_reinit_modem(channel int) {
+	multi_modem_init_channel(channel)
+	gen_tone_init_channel(save_audio_config_p, channel)
+
+	if xmitSvc != nil {
+		xmitSvc.SetBitsPerSec(channel, save_audio_config_p.achan[channel].baud)
+	}
+}
+
+/* e.g. ", 10.147600 MHz USB, 300 baud" */
+
+func (p *chan_profile_s) describe(modem *chan_modem_s) string {
+	var s = ""
+
+	if p.freq != 0 {
+		s += fmt.Sprintf(", %.6f MHz", p.freq)
+	}
+
+	if p.mode != "" {
+		s += " " + p.mode
+	}
+
+	s += fmt.Sprintf(", %d baud", modem.baud)
+
+	if modem.mark_freq != 0 && modem.space_freq != 0 {
+		s += fmt.Sprintf(" %d:%d", modem.mark_freq, modem.space_freq)
+	}
+
+	if p.at >= 0 {
+		s += fmt.Sprintf(", at %02d:%02d", p.at/60, p.at%60)
+	}
+
+	return s
+}
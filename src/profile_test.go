@@ -0,0 +1,249 @@
+package direwolf
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	goHamlib "github.com/xylo04/goHamlib"
+)
+
+func Test_config_init_profile(t *testing.T) {
+	var audio, _ = configFromString(t, "PTT RIG 2 localhost:4532\n"+
+		"PROFILE day FREQ=144.39 MODE=fm AT=07:00\n"+
+		"PROFILE night FREQ=10.1476 MODE=PKTUSB AT=19:30 MODEM 300 1600:1800 7@30\n"+
+		"PROFILE local\n")
+
+	var profiles = audio.achan[0].chan_profiles
+	require.Len(t, profiles, 3)
+
+	assert.Equal(t, "day", profiles[0].name)
+	assert.InDelta(t, 144.39, profiles[0].freq, 1e-9)
+	assert.Equal(t, "FM", profiles[0].mode)
+	assert.Equal(t, 7*60, profiles[0].at)
+	assert.False(t, profiles[0].has_modem)
+
+	assert.Equal(t, 19*60+30, profiles[1].at)
+	require.True(t, profiles[1].has_modem)
+	assert.Equal(t, 300, profiles[1].modem.baud)
+	assert.Equal(t, 1600, profiles[1].modem.mark_freq)
+	assert.Equal(t, 7, profiles[1].modem.num_freq)
+
+	assert.Equal(t, -1, profiles[2].at)
+	assert.Zero(t, profiles[2].freq)
+
+	// The channel's own modem isn't changed by a profile's MODEM.
+
+	assert.Equal(t, DEFAULT_BAUD, audio.achan[0].baud)
+	assert.Equal(t, DEFAULT_MARK_FREQ, audio.achan[0].mark_freq)
+	assert.Equal(t, 1, audio.achan[0].num_freq)
+}
+
+func Test_config_init_profile_errors(t *testing.T) {
+	for _, conf := range []string{
+		"PROFILE day FREQ=144.39\n",                         // No PTT RIG.
+		"PTT RIG 2 localhost:4532\nPROFILE day MODE=warp\n", // Not a hamlib mode.
+		"PTT RIG 2 localhost:4532\nPROFILE day AT=25:00\n",  // Not a time.
+		"PTT RIG 2 localhost:4532\nPROFILE FREQ=144.39\n",   // No name.
+		"PTT RIG 2 localhost:4532\nPROFILE day FREQ=fast\n", // Not a number.
+		"PTT RIG 2 localhost:4532\nPROFILE day 144.39\n",    // Not keyword=value.
+	} {
+		var audio, _ = configFromString(t, conf)
+		assert.Empty(t, audio.achan[0].chan_profiles, conf)
+	}
+
+	var audio, _ = configFromString(t, "PROFILE a MODEM 9600\nPROFILE A MODEM 300\n")
+	require.Len(t, audio.achan[0].chan_profiles, 1, "duplicate name")
+	assert.Equal(t, 9600, audio.achan[0].chan_profiles[0].modem.baud)
+}
+
+func Test_profile_scheduled(t *testing.T) {
+	var day = &chan_profile_s{name: "day", at: 7 * 60}         //nolint:exhaustruct
+	var night = &chan_profile_s{name: "night", at: 19*60 + 30} //nolint:exhaustruct
+	var manual = &chan_profile_s{name: "manual", at: -1}       //nolint:exhaustruct
+
+	var profiles = []*chan_profile_s{day, night, manual}
+
+	var at = func(hh, mm int) time.Time {
+		return time.Date(2026, 6, 1, hh, mm, 0, 0, time.Local)
+	}
+
+	assert.Equal(t, night, profile_scheduled(profiles, at(3, 0)), "still last night")
+	assert.Equal(t, day, profile_scheduled(profiles, at(7, 0)))
+	assert.Equal(t, day, profile_scheduled(profiles, at(19, 29)))
+	assert.Equal(t, night, profile_scheduled(profiles, at(19, 30)))
+
+	assert.Nil(t, profile_scheduled([]*chan_profile_s{manual}, at(12, 0)))
+	assert.Nil(t, profile_scheduled(nil, at(12, 0)))
+}
+
+type fakeTuner struct {
+	freq float64
+	mode goHamlib.Mode
+	err  error
+}
+
+func (f *fakeTuner) SetFreq(_ goHamlib.VFOType, freq float64) error {
+	if f.err != nil {
+		return f.err
+	}
+
+	f.freq = freq
+
+	return nil
+}
+
+func (f *fakeTuner) SetMode(_ goHamlib.VFOType, mode goHamlib.Mode, _ int) error {
+	f.mode = mode
+
+	return f.err
+}
+
+func Test_profile_tune(t *testing.T) {
+	var r = new(fakeTuner)
+
+	require.NoError(t, profile_tune(r, &chan_profile_s{freq: 14.105, mode: "USB"})) //nolint:exhaustruct
+	assert.InDelta(t, 14105000, r.freq, 1e-3)
+	assert.Equal(t, goHamlib.ModeUSB, r.mode)
+
+	// Nothing to do.
+	r = new(fakeTuner)
+	require.NoError(t, profile_tune(r, &chan_profile_s{})) //nolint:exhaustruct
+	assert.Zero(t, r.freq)
+
+	r = &fakeTuner{err: errors.New("timeout")}                                         //nolint:exhaustruct
+	assert.ErrorContains(t, profile_tune(r, &chan_profile_s{freq: 14.105}), "timeout") //nolint:exhaustruct
+}
+
+/* Profiles from a config file, with the radio and modem replaced. */
+
+func newTestProfileService(t *testing.T, conf string) (*ProfileService, *[]string, *[]int) {
+	t.Helper()
+
+	var audio, _ = configFromString(t, conf)
+
+	var s = NewProfileService(audio, NewFakeClock(time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local)))
+	require.NotNil(t, s)
+
+	var tuned []string
+
+	var reinit []int
+
+	s.tune = func(channel int, p *chan_profile_s) error {
+		if p.name == "broken" {
+			return errors.New("rigctld isn't answering")
+		}
+
+		tuned = append(tuned, p.name)
+
+		return nil
+	}
+
+	s.reinit = func(channel int) { reinit = append(reinit, channel) }
+
+	return s, &tuned, &reinit
+}
+
+func Test_ProfileService_Switch(t *testing.T) {
+	var s, tuned, reinit = newTestProfileService(t, "PTT RIG 2 localhost:4532\n"+
+		"PROFILE vhf FREQ=144.39 MODE=FM\n"+
+		"PROFILE hf FREQ=10.1476 MODE=USB MODEM 300\n"+
+		"PROFILE broken FREQ=7.0\n")
+
+	assert.Empty(t, s.Active(0))
+
+	// Same modem as the channel already has, so only the radio changes.
+
+	require.NoError(t, s.Switch(0, "VHF"))
+	assert.Equal(t, "vhf", s.Active(0))
+	assert.Equal(t, []string{"vhf"}, *tuned)
+
+	s.ApplyPending(0)
+	assert.Empty(t, *reinit)
+
+	// Different modem is applied by the receive thread.
+
+	require.NoError(t, s.Switch(0, "hf"))
+	assert.Equal(t, DEFAULT_BAUD, s.pa.achan[0].baud, "not yet")
+
+	s.ApplyPending(0)
+	assert.Equal(t, []int{0}, *reinit)
+	assert.Equal(t, 300, s.pa.achan[0].baud)
+	assert.Equal(t, 1600, s.pa.achan[0].mark_freq)
+
+	s.ApplyPending(0)
+	assert.Len(t, *reinit, 1, "only once")
+
+	// Back to the channel's own modem.
+
+	require.NoError(t, s.Switch(0, "vhf"))
+	s.ApplyPending(0)
+	assert.Equal(t, DEFAULT_BAUD, s.pa.achan[0].baud)
+
+	// Radio can't be tuned, so nothing changes.
+
+	assert.ErrorContains(t, s.Switch(0, "broken"), "rigctld")
+	assert.Equal(t, "vhf", s.Active(0))
+
+	assert.Error(t, s.Switch(0, "uhf"))
+	assert.Error(t, s.Switch(1, "vhf"))
+	assert.Error(t, s.Switch(-1, "vhf"))
+
+	var nobody *ProfileService
+	assert.Error(t, nobody.Switch(0, "vhf"))
+	nobody.ApplyPending(0) // Doesn't crash.
+}
+
+func Test_ProfileService_checkSchedule(t *testing.T) {
+	var s, tuned, _ = newTestProfileService(t, "PTT RIG 2 localhost:4532\n"+
+		"PROFILE day FREQ=144.39 AT=07:00\n"+
+		"PROFILE night FREQ=10.1476 AT=19:30\n"+
+		"PROFILE other FREQ=14.105\n")
+
+	var at = func(hh, mm int) time.Time {
+		return time.Date(2026, 6, 1, hh, mm, 0, 0, time.Local)
+	}
+
+	s.checkSchedule(at(12, 0))
+	assert.Equal(t, "day", s.Active(0))
+
+	// A command lasts until the next scheduled change.
+
+	require.NoError(t, s.Switch(0, "other"))
+	s.checkSchedule(at(12, 1))
+	assert.Equal(t, "other", s.Active(0))
+
+	s.checkSchedule(at(19, 30))
+	assert.Equal(t, "night", s.Active(0))
+
+	assert.Equal(t, []string{"day", "other", "night"}, *tuned)
+}
+
+func Test_control_profiles(t *testing.T) {
+	var s, _, _ = newTestProfileService(t, "PTT RIG 2 localhost:4532\n"+
+		"PROFILE day FREQ=144.39 MODE=FM AT=07:00\n"+
+		"PROFILE night FREQ=10.1476 MODE=USB MODEM 300\n")
+
+	profileSvc = s
+
+	t.Cleanup(func() { profileSvc = nil })
+
+	var cs = NewControlService(s.pa, new(igate_config_s), new(misc_config_s))
+
+	var reply = cs.command("set profile 0 night")
+	assert.True(t, strings.HasSuffix(reply, "OK\n"), reply)
+
+	reply = cs.command("show profiles")
+	assert.Equal(t, "0:  day, 144.390000 MHz FM, 1200 baud 1200:2200, at 07:00\n"+
+		"0: *night, 10.147600 MHz USB, 300 baud 1600:1800\n"+
+		"OK\n", reply)
+
+	reply = cs.command("set profile 0")
+	assert.Contains(t, reply, "ERROR: usage")
+
+	reply = cs.command("set profile x night")
+	assert.Contains(t, reply, "ERROR:")
+}
@@ -36,10 +36,13 @@ package direwolf
  *
  * Version 1.5:	Ability to use GPIO pins of CM108/CM119 for PTT signal.
  *
+ * GPIO:	sysfs GPIO is gone.  Everything uses the GPIO character
+ *		device, in Go, with active low and bias set by the kernel.
+ *
  *
  * References:	http://www.robbayer.com/files/serial-win.pdf
  *
- *		https://www.kernel.org/doc/Documentation/gpio.txt
+ *		https://docs.kernel.org/userspace-api/gpio/chardev.html
  *
  *---------------------------------------------------------------*/
 
@@ -122,356 +125,34 @@ import (
 	"fmt"
 	"io"
 	"os"
-	"strconv"
-	"strings"
+	"sync/atomic"
 	"time"
 
+	"github.com/pkg/term"
 	goHamlib "github.com/xylo04/goHamlib"
-	"golang.org/x/sys/unix"
 )
 
-func _TIOCM(fd int, value int, on bool) {
-	var stuff, _ = unix.IoctlGetInt(fd, unix.TIOCMGET)
-	if on {
-		stuff |= value
-	} else {
-		stuff &= ^value
-	}
-
-	unix.IoctlSetInt(fd, unix.TIOCMSET, stuff)
-}
-
-func RTS_ON(fd uintptr) {
-	_TIOCM(int(fd), unix.TIOCM_RTS, true)
-}
-
-func RTS_OFF(fd uintptr) {
-	_TIOCM(int(fd), unix.TIOCM_RTS, false)
-}
-
-func DTR_ON(fd uintptr) {
-	_TIOCM(int(fd), unix.TIOCM_DTR, true)
-}
-
-func DTR_OFF(fd uintptr) {
-	_TIOCM(int(fd), unix.TIOCM_DTR, false)
-}
-
 const LPT_IO_ADDR = 0x378
 
 // TODO KG static struct audio_s *save_audio_config_p;	/* Save config information for later use. */
 
-var ptt_debug_level = 0
+var ptt_debug_level atomic.Int32 /* Changed from the control socket while running. */
 
 func ptt_set_debug(debug int) {
-	ptt_debug_level = debug
+	ptt_debug_level.Store(int32(debug))
 }
 
-/*-------------------------------------------------------------------
- *
- * Name:	get_access_to_gpio
- *
- * Purpose:	Try to get access to the GPIO device.
- *
- * Inputs:	path		- Path to device node.
- *					/sys/class/gpio/export
- *					/sys/class/gpio/unexport
- *					/sys/class/gpio/gpio??/direction
- *					/sys/class/gpio/gpio??/value
- *
- * Description:	First see if we have access thru the usual uid/gid/mode method.
- *		If that fails, we try a hack where we use "sudo chmod ..." to open up access.
- *		That requires that sudo be configured to work without a password.
- *		That's the case for 'pi' user in Raspbian but not not be for other boards / operating systems.
- *
- * Debug:	Use the "-doo" command line option.
- *
- *------------------------------------------------------------------*/
-
-const MAX_GROUPS = 50
-
-func get_access_to_gpio(path string) {
-	/*
-	 * Does path even exist?
-	 */
-	var _, err = os.Stat(path)
-	if err != nil {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Can't get properties of %s: %s\n", path, err)
-		dw_printf("This system is not configured with the GPIO user interface.\n")
-		dw_printf("Use a different method for PTT control.\n")
-		os.Exit(1)
-	}
-
-	var my_uid = os.Geteuid()
-	var my_gid = os.Getegid()
-
-	var my_groups, groupsErr = os.Getgroups()
-	if groupsErr != nil {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Getgroups() failed to get supplementary groups, err=%s\n", groupsErr)
-	}
-
-	if ptt_debug_level >= 2 {
-		text_color_set(DW_COLOR_DEBUG)
-		// TODO KG dw_printf("%s: uid=%d, gid=%d, mode=o%o\n", path, finfo.st_uid, finfo.st_gid, finfo.st_mode)
-		dw_printf("my uid=%d, gid=%d, supplementary groups=", my_uid, my_gid)
-
-		for _, g := range my_groups {
-			dw_printf(" %d", g)
-		}
-
-		dw_printf("\n")
-	}
-
-	/*
-	 * Do we have permission to access it?
-	 *
-	 * On Debian 7 (Wheezy) we see this:
-	 *
-	 *	$ ls -l /sys/class/gpio/export
-	 *	--w------- 1 root root 4096 Feb 27 12:31 /sys/class/gpio/export
-	 *
-	 *
-	 * Only root can write to it.
-	 * Our work-around is change the protection so that everyone can write.
-	 * This requires that the current user can use sudo without a password.
-	 * This has been the case for the predefined "pi" user but can be a problem
-	 * when people add new user names.
-	 * Other operating systems could have different default configurations.
-	 *
-	 * A better solution is available in Debian 8 (Jessie).  The group is now "gpio"
-	 * so anyone in that group can now write to it.
-	 *
-	 *	$ ls -l /sys/class/gpio/export
-	 *	-rwxrwx--- 1 root gpio 4096 Mar  4 21:12 /sys/class/gpio/export
-	 *
-	 *
-	 * First see if we can access it by the usual file protection rules.
-	 * If not, we will try the "sudo chmod go+rw ..." hack.
-	 *
-	 */
-
-	// TODO KG I don't love what was here, but I need to figure out what (if anything) I want to replace it with
-}
-
-/*-------------------------------------------------------------------
- *
- * Name:	export_gpio
- *
- * Purpose:	Tell the GPIO subsystem to export a GPIO line for
- * 		us to use, and set the initial state of the GPIO.
- *
- * Inputs:	ch		- Radio Channel.
- *		ot		- Output type.
- *		invert:		- Is the GPIO active low?
- *		direction:	- 0 for input, 1 for output
- *
- * Outputs:	out_gpio_name	- in the audio configuration structure.
- *		in_gpio_name
- *
- *------------------------------------------------------------------*/
-
-func export_gpio(ch int, ot int, invert bool, direction int) {
-	// Raspberry Pi was easy.  GPIO 24 has the name gpio24.
-	// Others, such as the Cubieboard, take a little more effort.
-	// The name might be gpio24_ph11 meaning connector H, pin 11.
-	// When we "export" GPIO number, we will store the corresponding
-	// device name for future use when we want to access it.
-	var gpio_num int
-	var gpio_name string
-
-	if direction > 0 {
-		gpio_num = save_audio_config_p.achan[ch].octrl[ot].out_gpio_num
-		gpio_name = save_audio_config_p.achan[ch].octrl[ot].out_gpio_name
-	} else {
-		gpio_num = save_audio_config_p.achan[ch].ictrl[ot].in_gpio_num
-		gpio_name = save_audio_config_p.achan[ch].ictrl[ot].in_gpio_name
-	}
-
-	const gpio_export_path = "/sys/class/gpio/export"
-
-	get_access_to_gpio(gpio_export_path)
-
-	var fd, err = os.OpenFile(gpio_export_path, os.O_WRONLY, 0)
-	if err != nil {
-		// Not expected.  Above should have obtained permission or exited.
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Permissions do not allow access to GPIO.\n")
-		os.Exit(1)
-	}
-
-	var stemp = strconv.Itoa(gpio_num)
-
-	var n, writeErr = fd.WriteString(stemp)
-	if n != len(stemp) || writeErr != nil { //nolint: staticcheck
-		/* TODO KG Figure out write errs here
-
-		// Ignore EBUSY error which seems to mean the device node already exists.
-		if err != EBUSY {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Error writing \"%s\" to %s, errno=%d\n", stemp, gpio_export_path, e)
-			dw_printf("%s\n", strerror(e))
-
-			if e == 22 {
-				// It appears that error 22 occurs when sysfs gpio is not available.
-				// (See https://github.com/wb2osz/direwolf/issues/503)
-				//
-				// The solution might be to use the new gpiod approach.
-
-				dw_printf("It looks like gpio with sysfs is not supported on this operating system.\n")
-				dw_printf("Rather than the following form, in the configuration file,\n")
-				dw_printf("    PTT GPIO  %s\n", stemp)
-				dw_printf("try using gpiod form instead.  e.g.\n")
-				dw_printf("    PTT GPIOD  gpiochip0  %s\n", stemp)
-				dw_printf("You can get a list of gpio chip names and corresponding I/O lines with \"gpioinfo\" command.\n")
-			}
-			os.Exit(1)
-		}
-		*/
-	}
-	/* Wait for udev to adjust permissions after enabling GPIO. */
-	/* https://github.com/wb2osz/direwolf/issues/176 */
-	SLEEP_MS(250)
-	fd.Close()
-
-	/*
-	 *	Added in release 1.4.
-	 *
-	 *	On the RPi, the device path for GPIO number XX is simply /sys/class/gpio/gpioXX.
-	 *
-	 *	There was a report that it is different for the CubieBoard.  For instance
-	 *	GPIO 61 has gpio61_pi13 in the path.  This indicates connector "i" pin 13.
-	 *	https://github.com/cubieplayer/Cubian/wiki/GPIO-Introduction
-	 *
-	 *	For another similar single board computer, we find the same thing:
-	 *	https://www.olimex.com/wiki/A20-OLinuXino-LIME#GPIO_under_Linux
-	 *
-	 *	How should we deal with this?  Some possibilities:
-	 *
-	 *	(1) The user might explicitly mention the name in direwolf.conf.
-	 *	(2) We might be able to find the names in some system device config file.
-	 *	(3) Get a directory listing of /sys/class/gpio then search for a
-	 *		matching name.  Suppose we wanted GPIO 61.  First look for an exact
-	 *		match to "gpio61".  If that is not found, look for something
-	 *		matching the pattern "gpio61_*".
-	 *
-	 *	We are finally implementing the third choice.
-	 */
-
-	/*
-	 * Then we have the Odroid board with GPIO numbers starting around 480.
-	 * Can we simply use those numbers?
-	 * Apparently, the export names look like GPIOX.17
-	 * https://wiki.odroid.com/odroid-c4/hardware/expansion_connectors#gpio_map_for_wiringpi_library
-	 */
-
-	if ptt_debug_level >= 2 {
-		text_color_set(DW_COLOR_DEBUG)
-		dw_printf("Contents of /sys/class/gpio:\n")
-	}
-
-	var dirEntries, readDirErr = os.ReadDir("/sys/class/gpio")
-
-	var ok = false
-
-	if readDirErr != nil {
-		// Something went wrong.  Fill in the simple expected name and keep going.
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("ERROR! Could not get directory listing for /sys/class/gpio\n")
-
-		gpio_name = fmt.Sprintf("gpio%d", gpio_num)
-		ok = true
-	} else {
-		if ptt_debug_level >= 2 {
-			text_color_set(DW_COLOR_DEBUG)
-
-			for _, entry := range dirEntries {
-				dw_printf("\t%s\n", entry.Name())
-			}
-		}
-
-		// Look for exact name gpioNN
-
-		var lookfor = fmt.Sprintf("gpio%d", gpio_num)
-
-		for _, entry := range dirEntries {
-			if lookfor == entry.Name() {
-				gpio_name = entry.Name()
-				ok = true
-			}
-		}
-
-		// If not found, Look for gpioNN_*
-
-		lookfor = fmt.Sprintf("gpio%d_", gpio_num)
-
-		for _, entry := range dirEntries {
-			if strings.HasPrefix(entry.Name(), lookfor) {
-				gpio_name = entry.Name()
-				ok = true
-			}
-		}
-	}
-
-	/*
-	 * We should now have the corresponding node name.
-	 */
-	if ok {
-		if ptt_debug_level >= 2 {
-			text_color_set(DW_COLOR_DEBUG)
-			dw_printf("Path for gpio number %d is /sys/class/gpio/%s\n", gpio_num, gpio_name)
-		}
-	} else {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("ERROR! Could not find Path for gpio number %d.n", gpio_num)
-		os.Exit(1)
-	}
-
-	/*
-	 * Set output direction and initial state
-	 */
-
-	var gpio_direction_path = fmt.Sprintf("/sys/class/gpio/%s/direction", gpio_name)
-	get_access_to_gpio(gpio_direction_path)
-
-	fd, err = os.OpenFile(gpio_direction_path, os.O_WRONLY, 0) //nolint:gosec
-	if err != nil {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Error opening %s\n", gpio_direction_path)
-		dw_printf("%s\n", err)
-		os.Exit(1)
-	}
-
-	var gpio_val string
-
-	if direction != 0 {
-		if invert {
-			gpio_val = "high"
-		} else {
-			gpio_val = "low"
-		}
-	} else {
-		gpio_val = "in"
-	}
-
-	n, writeErr = fd.WriteString(gpio_val)
-	if n != len(gpio_val) || writeErr != nil {
-		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Error writing initial state to %s\n", gpio_direction_path)
-		dw_printf("%s\n", writeErr)
-		os.Exit(1)
-	}
-
-	fd.Close()
-
-	/*
-	 * Make sure that we have access to 'value'.
-	 * Do it once here, rather than each time we want to use it.
-	 */
+/*
+ * What to try when a GPIO line can't be requested.
+ * Line numbers are per chip now, not the global numbers sysfs used,
+ * so "GPIO nn" only means the same thing as before on a Raspberry Pi.
+ */
 
-	var gpio_value_path = fmt.Sprintf("/sys/class/gpio/%s/value", gpio_name)
-	get_access_to_gpio(gpio_value_path)
+func gpiod_hint() {
+	dw_printf("\"GPIO nn\" is line nn of /dev/gpiochip0.  For another chip, use \"GPIOD chip line\".\n")
+	dw_printf("You can get a list of gpio chip names and corresponding I/O lines with \"gpioinfo\" command.\n")
+	dw_printf("If the line is in use by something else, \"gpioinfo\" will show that too.\n")
+	dw_printf("For permission denied, add yourself to the \"gpio\" group, then log out and in again.\n")
 }
 
 /*-------------------------------------------------------------------
@@ -487,11 +168,13 @@ func export_gpio(ch int, ot int, invert bool, direction int) {
  *			ptt_method	Method for PTT signal.
  *					PTT_METHOD_NONE - not configured.  Could be using VOX.
  *					PTT_METHOD_SERIAL - serial (com) port.
- *					PTT_METHOD_GPIO - general purpose I/O (sysfs).
- *					PTT_METHOD_GPIOD - general purpose I/O (libgpiod).
+ *					PTT_METHOD_GPIOD - general purpose I/O (GPIO character device).
  *					PTT_METHOD_LPT - Parallel printer port.
  *                  			PTT_METHOD_HAMLIB - HAMLib rig control.
  *					PTT_METHOD_CM108 - GPIO pins of CM108 etc. USB Audio.
+ *					PTT_METHOD_CAT - radio's own CAT commands.
+ *					PTT_METHOD_HTTP, PTT_METHOD_MQTT - relay on the network.
+ *					PTT_METHOD_MCP23017 - I2C GPIO expander.
  *
  *			ptt_device	Name of serial port device.
  *					 e.g. COM1 or /dev/ttyS0.
@@ -500,8 +183,10 @@ func export_gpio(ch int, ot int, invert bool, direction int) {
  *
  *			ptt_line	RTS or DTR when using serial port.
  *
- *			out_gpio_num	GPIO number.  Only used for Linux.
- *					 Valid only when ptt_method is PTT_METHOD_GPIO.
+ *			out_gpio_name	GPIO chip device, e.g. /dev/gpiochip0.
+ *			out_gpio_num	GPIO line number on that chip.
+ *			out_gpio_bias	Pull up, pull down, or as is.
+ *					 Valid only when ptt_method is PTT_METHOD_GPIOD.
  *
  *			ptt_lpt_bit	Bit number for parallel printer port.
  *					 Bit 0 = pin 2, ..., bit 7 = pin 9.
@@ -509,6 +194,7 @@ func export_gpio(ch int, ot int, invert bool, direction int) {
  *
  *			ptt_invert	Invert the signal.
  *					 Normally higher voltage means transmit or LED on.
+ *					 For GPIOD the line is requested active low.
  *
  *			ptt_model	Only for HAMLIB.
  *					2 to communicate with rigctld.
@@ -528,6 +214,9 @@ var ptt_fd [MAX_RADIO_CHANS][NUM_OCTYPES]*os.File
 /* if using both RTS and DTR. */
 var rig [MAX_RADIO_CHANS][NUM_OCTYPES]*goHamlib.Rig
 
+/* Serial port for CAT commands without hamlib. */
+var cat_port [MAX_RADIO_CHANS][NUM_OCTYPES]*term.Term
+
 // gpiodOutputLine is the subset of gpiocdev.Line used for PTT output control.
 // The interface exists to allow dependency injection in tests.
 type gpiodOutputLine interface {
@@ -535,9 +224,20 @@ type gpiodOutputLine interface {
 	Close() error
 }
 
+// gpiodInputLine is the subset of gpiocdev.Line used for TXINH input.
+type gpiodInputLine interface {
+	Value() (int, error)
+	Close() error
+}
+
 /* GPIOD line handles, one per channel/output-type combination. */
 var gpiod_line [MAX_RADIO_CHANS][NUM_OCTYPES]gpiodOutputLine
 
+/* and one per channel/input-type combination. */
+var gpiod_in_line [MAX_RADIO_CHANS][NUM_ICTYPES]gpiodInputLine
+
+var cm108_in [MAX_RADIO_CHANS][NUM_ICTYPES]*cm108_input_s
+
 var otnames [NUM_OCTYPES]string
 
 func ptt_init(audio_config_p *audio_s) {
@@ -546,10 +246,16 @@ func ptt_init(audio_config_p *audio_s) {
 	otnames[OCTYPE_PTT] = "PTT"
 	otnames[OCTYPE_DCD] = "DCD"
 	otnames[OCTYPE_CON] = "CON"
+	otnames[OCTYPE_SEQ1] = "SEQ1"
+	otnames[OCTYPE_SEQ2] = "SEQ2"
+	otnames[OCTYPE_IND1] = "IND1"
+	otnames[OCTYPE_IND2] = "IND2"
+	otnames[OCTYPE_IND3] = "IND3"
+	otnames[OCTYPE_IND4] = "IND4"
 
 	for ch := range MAX_RADIO_CHANS {
 		for ot := range NUM_OCTYPES {
-			if ptt_debug_level >= 2 {
+			if ptt_debug_level.Load() >= 2 {
 				text_color_set(DW_COLOR_DEBUG)
 				dw_printf("ch=%d, %s method=%d, device=%s, line=%d, name=%s, gpio=%d, lpt_bit=%d, invert=%t\n",
 					ch,
@@ -576,21 +282,10 @@ func ptt_init(audio_config_p *audio_s) {
 		if audio_config_p.chan_medium[ch] == MEDIUM_RADIO {
 			for ot := range NUM_OCTYPES {
 				if audio_config_p.achan[ch].octrl[ot].ptt_method == PTT_METHOD_SERIAL {
-					/* Translate Windows device name into Linux name. */
-					/* COM1 -> /dev/ttyS0, etc. */
-					if strings.HasPrefix(strings.ToUpper(audio_config_p.achan[ch].octrl[ot].ptt_device), "COM") {
-						var n, _ = strconv.Atoi(audio_config_p.achan[ch].octrl[ot].ptt_device[3:])
+					/* Translate device name for this operating system. */
+					/* COM1 -> /dev/ttyS0 on Linux, etc. */
+					audio_config_p.achan[ch].octrl[ot].ptt_device = ptt_serial_device(audio_config_p.achan[ch].octrl[ot].ptt_device, otnames[ot])
 
-						text_color_set(DW_COLOR_INFO)
-						dw_printf("Converted %s device '%s'", audio_config_p.achan[ch].octrl[ot].ptt_device, otnames[ot])
-
-						if n < 1 {
-							n = 1
-						}
-
-						audio_config_p.achan[ch].octrl[ot].ptt_device = fmt.Sprintf("/dev/ttyS%d", n-1)
-						dw_printf(" to Linux equivalent '%s'\n", audio_config_p.achan[ch].octrl[ot].ptt_device)
-					}
 					/* Can't open the same device more than once so we */
 					/* need more logic to look for the case of multiple radio */
 					/* channels using different pins of the same COM port. */
@@ -619,7 +314,7 @@ func ptt_init(audio_config_p *audio_s) {
 						/* O_NONBLOCK added in version 0.9. */
 						/* Was hanging with some USB-serial adapters. */
 						/* https://bugs.launchpad.net/ubuntu/+source/linux/+bug/661321/comments/12 */
-						fd, openErr = os.Open(audio_config_p.achan[ch].octrl[ot].ptt_device)
+						fd, openErr = ptt_serial_open(audio_config_p.achan[ch].octrl[ot].ptt_device)
 					}
 
 					if openErr == nil {
@@ -646,79 +341,75 @@ func ptt_init(audio_config_p *audio_s) {
 
 	/*
 	 * Set up GPIO - for Linux only.
+	 *
+	 * This uses the GPIO character device so there is nothing to
+	 * export first, and active low is done by the kernel.
+	 * "PTT GPIO nn" in the configuration file becomes line nn
+	 * of /dev/gpiochip0, which is the same number on a Raspberry Pi.
 	 */
 
-	/*
-	 * Does any of them use GPIO?
-	 */
-
-	var using_gpio = false
-
-	for ch := range MAX_RADIO_CHANS {
-		if save_audio_config_p.chan_medium[ch] == MEDIUM_RADIO {
-			for ot := range NUM_OCTYPES {
-				if audio_config_p.achan[ch].octrl[ot].ptt_method == PTT_METHOD_GPIO {
-					using_gpio = true
-				}
-			}
-
-			for ot := range NUM_ICTYPES {
-				if audio_config_p.achan[ch].ictrl[ot].method == PTT_METHOD_GPIO {
-					using_gpio = true
-				}
-			}
-		}
-	}
-
-	if using_gpio {
-		get_access_to_gpio("/sys/class/gpio/export")
-	}
-	// GPIOD
 	for ch := range MAX_RADIO_CHANS {
 		if save_audio_config_p.chan_medium[ch] == MEDIUM_RADIO {
 			for ot := range NUM_OCTYPES {
 				if audio_config_p.achan[ch].octrl[ot].ptt_method == PTT_METHOD_GPIOD {
 					var chip_name = audio_config_p.achan[ch].octrl[ot].out_gpio_name
 					var line_number = audio_config_p.achan[ch].octrl[ot].out_gpio_num
-					var initialState = IfThenElse(audio_config_p.achan[ch].octrl[ot].ptt_invert, 1, 0) // Using "invert" as initial state means we always start "off"
 
-					var line, lineErr = RequestGPIODLine(chip_name, line_number, initialState)
+					var line, lineErr = RequestGPIODOutput(chip_name, line_number,
+						audio_config_p.achan[ch].octrl[ot].ptt_invert,
+						audio_config_p.achan[ch].octrl[ot].out_gpio_bias)
 					if lineErr != nil {
 						text_color_set(DW_COLOR_ERROR)
 						dw_printf("Can't request GPIOD line %d on %s: %v\n", line_number, chip_name, lineErr)
-						dw_printf("Terminating due to failed PTT on channel %d\n", ch)
+						gpiod_hint()
+						dw_printf("Terminating due to failed %s on channel %d\n", otnames[ot], ch)
 						os.Exit(1)
 					}
 
 					gpiod_line[ch][ot] = line
 
-					if ptt_debug_level >= 2 {
+					if ptt_debug_level.Load() >= 2 {
 						text_color_set(DW_COLOR_DEBUG)
 						dw_printf("GPIOD init OK. Chip: %s line: %d\n", chip_name, line_number)
 					}
-					// Set initial state off.  ptt_set will invert output signal if appropriate.
+					// Set initial state off.
 					ptt_set(ot, ch, 0)
 				}
 			}
-		}
-	}
-	/*
-	 * We should now be able to create the device nodes for
-	 * the pins we want to use.
-	 */
 
-	for ch := range MAX_RADIO_CHANS {
-		if save_audio_config_p.chan_medium[ch] == MEDIUM_RADIO {
-			// output control type, PTT, DCD, CON, ...
-			for ot := range NUM_OCTYPES {
-				if audio_config_p.achan[ch].octrl[ot].ptt_method == PTT_METHOD_GPIO {
-					export_gpio(ch, ot, audio_config_p.achan[ch].octrl[ot].ptt_invert, 1)
-				}
-			}
-			// input control type
 			for it := range NUM_ICTYPES {
-				if audio_config_p.achan[ch].ictrl[it].method == PTT_METHOD_GPIO {
-					export_gpio(ch, it, audio_config_p.achan[ch].ictrl[it].invert, 0)
+				if audio_config_p.achan[ch].ictrl[it].method == PTT_METHOD_GPIOD {
+					var chip_name = audio_config_p.achan[ch].ictrl[it].in_gpio_name
+					var line_number = audio_config_p.achan[ch].ictrl[it].in_gpio_num
+
+					var line, lineErr = RequestGPIODInput(chip_name, line_number,
+						audio_config_p.achan[ch].ictrl[it].invert,
+						audio_config_p.achan[ch].ictrl[it].in_gpio_bias)
+					if lineErr != nil {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Can't request GPIOD input line %d on %s: %v\n", line_number, chip_name, lineErr)
+						gpiod_hint()
+						dw_printf("Terminating due to failed TXINH on channel %d\n", ch)
+						os.Exit(1)
+					}
+
+					gpiod_in_line[ch][it] = line
+				}
+
+				if audio_config_p.achan[ch].ictrl[it].method == PTT_METHOD_CM108 {
+					var c, err = cm108_input_open(audio_config_p.achan[ch].ictrl[it].in_gpio_name)
+					if err != nil {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Can't open %s for CM108 GPIO input: %s\n", audio_config_p.achan[ch].ictrl[it].in_gpio_name, err)
+						dw_printf("Terminating due to failed TXINH on channel %d\n", ch)
+						os.Exit(1)
+					}
+
+					text_color_set(DW_COLOR_INFO)
+					dw_printf("Using %s GPIO %d for channel %d TXINH.\n",
+						audio_config_p.achan[ch].ictrl[it].in_gpio_name, audio_config_p.achan[ch].ictrl[it].in_gpio_num, ch)
+
+					cm108_in[ch][it] = c
 				}
 			}
 		}
@@ -876,6 +567,10 @@ func ptt_init(audio_config_p *audio_s) {
 
 						// Successful.  Later code should check for rig[ch][ot] not nil.
 						rig[ch][ot] = r
+
+						if audio_config_p.achan[ch].rig_poll > 0 {
+							rig_poll_start(ch, audio_config_p.achan[ch].rig_poll)
+						}
 					} else {
 						text_color_set(DW_COLOR_ERROR)
 						dw_printf("HAMLIB can only be used for PTT.  Not DCD or other output.\n")
@@ -885,21 +580,129 @@ func ptt_init(audio_config_p *audio_s) {
 		}
 	}
 
+	/*
+	 * CAT commands without hamlib.
+	 * Reading the frequency is a good way to find out if anyone is listening
+	 * before we try to transmit.  Not being able to is only a warning, because
+	 * some radios don't answer while in a menu, but PTT should still work.
+	 */
+
+	for ch := range MAX_RADIO_CHANS {
+		if audio_config_p.chan_medium[ch] == MEDIUM_RADIO {
+			for ot := range NUM_OCTYPES {
+				if audio_config_p.achan[ch].octrl[ot].ptt_method == PTT_METHOD_CAT {
+					var octrl = &audio_config_p.achan[ch].octrl[ot]
+
+					var fd = SerialPortOpen(octrl.ptt_device, octrl.ptt_rate)
+					if fd == nil {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("ERROR can't open device %s for channel %d CAT PTT control.\n", octrl.ptt_device, ch)
+						/* Don't try using it later if device open failed. */
+						octrl.ptt_method = PTT_METHOD_NONE
+
+						continue
+					}
+
+					fd.SetReadTimeout(200 * time.Millisecond) //nolint:errcheck
+					cat_port[ch][ot] = fd
+
+					var freq, err = cat_get_freq(fd, octrl.ptt_cat_type, octrl.ptt_cat_addr)
+					if err != nil {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("Channel %d %s CAT on %s: %s.\n", ch, cat_type_name(octrl.ptt_cat_type), octrl.ptt_device, err)
+						dw_printf("Check the serial port speed and, for Icom, the CI-V address.\n")
+					} else {
+						text_color_set(DW_COLOR_INFO)
+						dw_printf("Channel %d %s CAT on %s: radio is on %.4f MHz.\n", ch, cat_type_name(octrl.ptt_cat_type), octrl.ptt_device, float64(freq)/1e6)
+					}
+
+					ptt_set(ot, ch, 0)
+				}
+			}
+		}
+	}
+
+	/*
+	 * I2C GPIO expanders.
+	 */
+
+	for ch := range MAX_RADIO_CHANS {
+		if audio_config_p.chan_medium[ch] == MEDIUM_RADIO {
+			for ot := range NUM_OCTYPES {
+				var octrl = &audio_config_p.achan[ch].octrl[ot]
+
+				if octrl.ptt_method != PTT_METHOD_MCP23017 {
+					continue
+				}
+
+				var d, err = i2c_open(octrl.ptt_device, octrl.ptt_i2c_addr)
+				if err == nil {
+					err = mcp23017_output(d, octrl.out_gpio_num)
+				}
+
+				if err != nil {
+					text_color_set(DW_COLOR_ERROR)
+					dw_printf("Can't use MCP23017 0x%02x %s on %s for channel %d %s: %s\n",
+						octrl.ptt_i2c_addr, mcp23017_pin_name(octrl.out_gpio_num), octrl.ptt_device, ch, otnames[ot], err)
+					/* Don't try using it later. */
+					octrl.ptt_method = PTT_METHOD_NONE
+
+					if d != nil {
+						d.Close()
+					}
+
+					continue
+				}
+
+				mcp23017_port[ch][ot] = d
+
+				ptt_set(ot, ch, 0)
+			}
+		}
+	}
+
+	/*
+	 * Relays on the network.
+	 */
+
+	ptt_remote_init(audio_config_p)
+
+	for ch := range MAX_RADIO_CHANS {
+		if audio_config_p.chan_medium[ch] == MEDIUM_RADIO {
+			for ot := range NUM_OCTYPES {
+				if audio_config_p.achan[ch].octrl[ot].ptt_method == PTT_METHOD_HTTP ||
+					audio_config_p.achan[ch].octrl[ot].ptt_method == PTT_METHOD_MQTT {
+					ptt_set(ot, ch, 0)
+				}
+			}
+		}
+	}
+
 	/*
 	 * Confirm what is going on with CM108 GPIO output.
-	 * Could use some error checking for overlap.
+	 * PTT, DCD, and CON can share a device but not a pin.
 	 */
 
+	var cm108_used = make(map[string]string) // "device GPIO n" -> first user.
+
 	for ch := range MAX_RADIO_CHANS {
 		if audio_config_p.chan_medium[ch] == MEDIUM_RADIO {
 			for ot := range NUM_OCTYPES {
 				if audio_config_p.achan[ch].octrl[ot].ptt_method == PTT_METHOD_CM108 {
+					var pin = fmt.Sprintf("%s GPIO %d", audio_config_p.achan[ch].octrl[ot].ptt_device, audio_config_p.achan[ch].octrl[ot].out_gpio_num)
+					var user = fmt.Sprintf("channel %d %s", ch, otnames[ot])
+
+					if first, ok := cm108_used[pin]; ok {
+						text_color_set(DW_COLOR_ERROR)
+						dw_printf("%s is used for both %s and %s.\n", pin, first, user)
+					} else {
+						cm108_used[pin] = user
+					}
+
 					text_color_set(DW_COLOR_INFO)
-					dw_printf("Using %s GPIO %d for channel %d %s control.\n",
-						audio_config_p.achan[ch].octrl[ot].ptt_device,
-						audio_config_p.achan[ch].octrl[ot].out_gpio_num,
-						ch,
-						otnames[ot])
+					dw_printf("Using %s for %s control.\n", pin, user)
+
+					ptt_set(ot, ch, 0)
 				}
 			}
 		}
@@ -964,6 +767,20 @@ func ptt_init(audio_config_p *audio_s) {
 // JWL - save status and new get_ptt function.
 
 func ptt_set_real(ot int, channel int, ptt_signal int) {
+	if ot == OCTYPE_PTT && channel < MAX_RADIO_CHANS && ptt_sequence_used(channel) {
+		ptt_sequence(channel, ptt_signal)
+	} else {
+		ptt_set_one(ot, channel, ptt_signal)
+	}
+
+	if channel < MAX_RADIO_CHANS {
+		ptt_indicators(ot, channel, ptt_signal)
+	}
+}
+
+/* Set one output control line.  Only ptt_set_real and ptt_sequence should use this. */
+
+func ptt_set_one(ot int, channel int, ptt_signal int) {
 	var ptt = ptt_signal
 	var ptt2 = ptt_signal
 
@@ -974,7 +791,7 @@ func ptt_set_real(ot int, channel int, ptt_signal int) {
 		return // NCHANNEL: no physical PTT hardware to drive
 	}
 
-	if ptt_debug_level >= 1 {
+	if ptt_debug_level.Load() >= 1 {
 		text_color_set(DW_COLOR_DEBUG)
 		dw_printf("%s %d = %d\n", otnames[ot], channel, ptt_signal)
 	}
@@ -1010,11 +827,16 @@ func ptt_set_real(ot int, channel int, ptt_signal int) {
 	dlq_channel_busy(channel, ot, ptt_signal)
 	// #endif
 
+	if ot == OCTYPE_PTT {
+		ptt_watchdog(channel, ptt_signal)
+	}
+
 	/*
 	 * Inverted output?
 	 */
 
-	if save_audio_config_p.achan[channel].octrl[ot].ptt_invert {
+	if save_audio_config_p.achan[channel].octrl[ot].ptt_invert &&
+		save_audio_config_p.achan[channel].octrl[ot].ptt_method != PTT_METHOD_GPIOD { // Kernel does it for GPIOD.
 		ptt = 1 - ptt
 	}
 
@@ -1069,36 +891,13 @@ func ptt_set_real(ot int, channel int, ptt_signal int) {
 	 * Using GPIO?
 	 */
 
-	if save_audio_config_p.achan[channel].octrl[ot].ptt_method == PTT_METHOD_GPIO {
-		var gpio_value_path = fmt.Sprintf("/sys/class/gpio/%s/value", save_audio_config_p.achan[channel].octrl[ot].out_gpio_name)
-
-		var fd, err = os.OpenFile(gpio_value_path, os.O_WRONLY, 0) //nolint:gosec
-		if err != nil {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Error opening %s to set %s signal.\n", gpio_value_path, otnames[ot])
-			dw_printf("%s\n", err)
-
-			return
-		}
-		defer fd.Close()
-
-		var stemp = strconv.Itoa(ptt)
-
-		var _, writeErr = fd.WriteString(stemp)
-		if writeErr != nil {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Error setting GPIO %d for %s\n", save_audio_config_p.achan[channel].octrl[ot].out_gpio_num, otnames[ot])
-			dw_printf("%s\n", writeErr)
-		}
-	}
-
 	if save_audio_config_p.achan[channel].octrl[ot].ptt_method == PTT_METHOD_GPIOD {
 		if gpiod_line[channel][ot] != nil {
 			var err = gpiod_line[channel][ot].SetValue(ptt)
 			if err != nil {
 				text_color_set(DW_COLOR_ERROR)
 				dw_printf("Error setting GPIOD for channel %d %s: %v\n", channel, otnames[ot], err)
-			} else if ptt_debug_level >= 1 {
+			} else if ptt_debug_level.Load() >= 1 {
 				text_color_set(DW_COLOR_DEBUG)
 				dw_printf("PTT_METHOD_GPIOD chip: %s line: %d ptt: %d\n",
 					save_audio_config_p.achan[channel].octrl[ot].out_gpio_name,
@@ -1151,7 +950,9 @@ func ptt_set_real(ot int, channel int, ptt_signal int) {
 				onoff = goHamlib.RIG_PTT_ON
 			}
 
+			rig_mutex[channel].Lock()
 			var retcode = rig[channel][ot].SetPtt(goHamlib.VFOCurrent, onoff)
+			rig_mutex[channel].Unlock()
 			if retcode != nil {
 				text_color_set(DW_COLOR_ERROR)
 				dw_printf("Hamlib error: SetPtt command for channel %d %s\n", channel, otnames[ot])
@@ -1174,6 +975,50 @@ func ptt_set_real(ot int, channel int, ptt_signal int) {
 			dw_printf("ERROR:  %s for channel %d has failed.  See User Guide for troubleshooting tips.\n", otnames[ot], channel)
 		}
 	}
+
+	/*
+	 * Using the radio's own CAT commands?
+	 */
+
+	if save_audio_config_p.achan[channel].octrl[ot].ptt_method == PTT_METHOD_CAT &&
+		cat_port[channel][ot] != nil {
+		var cmd = cat_ptt_command(save_audio_config_p.achan[channel].octrl[ot].ptt_cat_type,
+			save_audio_config_p.achan[channel].octrl[ot].ptt_cat_addr, ptt != 0)
+
+		if SerialPortWrite(cat_port[channel][ot], cmd) != len(cmd) {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Error sending CAT command for channel %d %s.\n", channel, otnames[ot])
+		} else if ptt_debug_level.Load() >= 1 {
+			text_color_set(DW_COLOR_DEBUG)
+			dw_printf("PTT_METHOD_CAT %s % x\n", save_audio_config_p.achan[channel].octrl[ot].ptt_device, cmd)
+		}
+	}
+
+	/*
+	 * I2C GPIO expander?
+	 */
+
+	if save_audio_config_p.achan[channel].octrl[ot].ptt_method == PTT_METHOD_MCP23017 &&
+		mcp23017_port[channel][ot] != nil {
+		var err = mcp23017_set(mcp23017_port[channel][ot], save_audio_config_p.achan[channel].octrl[ot].out_gpio_num, ptt)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("ERROR:  %s for channel %d has failed: %s\n", otnames[ot], channel, err)
+		}
+	}
+
+	/*
+	 * Relay on the network?
+	 */
+
+	if save_audio_config_p.achan[channel].octrl[ot].ptt_method == PTT_METHOD_HTTP ||
+		save_audio_config_p.achan[channel].octrl[ot].ptt_method == PTT_METHOD_MQTT {
+		var err = ptt_remote_set(ot, channel, ptt)
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("ERROR:  %s for channel %d has failed: %s\n", otnames[ot], channel, err)
+		}
+	}
 } /* end ptt_set */
 
 /*-------------------------------------------------------------------
@@ -1200,50 +1045,29 @@ func get_input_real(it int, channel int) int {
 		return -1
 	}
 
-	if save_audio_config_p.achan[channel].ictrl[it].method == PTT_METHOD_GPIO {
-		var gpio_value_path = fmt.Sprintf("/sys/class/gpio/%s/value", save_audio_config_p.achan[channel].ictrl[it].in_gpio_name)
-
-		get_access_to_gpio(gpio_value_path)
-
-		var fd, openErr = os.Open(gpio_value_path) //nolint:gosec
-		if openErr != nil {
+	if save_audio_config_p.achan[channel].ictrl[it].method == PTT_METHOD_GPIOD &&
+		gpiod_in_line[channel][it] != nil {
+		// Active low was taken care of when the line was requested.
+		var v, err = gpiod_in_line[channel][it].Value()
+		if err != nil {
 			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Error opening %s to check input.\n", gpio_value_path)
-			dw_printf("%s\n", openErr)
+			dw_printf("Error getting GPIO %d value: %s\n", save_audio_config_p.achan[channel].ictrl[it].in_gpio_num, err)
 
 			return -1
 		}
 
-		var vtemp = make([]byte, 1)
-
-		var _, readErr = fd.Read(vtemp)
-		if readErr != nil {
-			text_color_set(DW_COLOR_ERROR)
-			dw_printf("Error getting GPIO %d value\n", save_audio_config_p.achan[channel].ictrl[it].in_gpio_num)
-			dw_printf("%s\n", readErr)
-		}
+		return IfThenElse(v == 0, 0, 1)
+	}
 
-		fd.Close()
+	if save_audio_config_p.achan[channel].ictrl[it].method == PTT_METHOD_CM108 &&
+		cm108_in[channel][it] != nil {
+		var v = cm108_in[channel][it].pin(save_audio_config_p.achan[channel].ictrl[it].in_gpio_num)
 
-		var v, parseErr = strconv.Atoi(string(vtemp))
-		if parseErr != nil {
-			dw_printf("Error parsing return value (%s) from GPIO %d: %s\n", vtemp, save_audio_config_p.achan[channel].ictrl[it].in_gpio_num, parseErr)
-			return -1
+		if save_audio_config_p.achan[channel].ictrl[it].invert {
+			v = 1 - v
 		}
 
-		if !save_audio_config_p.achan[channel].ictrl[it].invert {
-			if v == 0 {
-				return 0
-			} else {
-				return 1
-			}
-		} else {
-			if v == 0 {
-				return 1
-			} else {
-				return 0
-			}
-		}
+		return v
 	}
 
 	return -1 /* Method was none, or something went wrong */
@@ -1289,6 +1113,18 @@ func ptt_term() {
 					gpiod_line[n][ot] = nil
 				}
 			}
+
+			for it := range NUM_ICTYPES {
+				if gpiod_in_line[n][it] != nil {
+					gpiod_in_line[n][it].Close()
+					gpiod_in_line[n][it] = nil
+				}
+
+				if cm108_in[n][it] != nil {
+					cm108_in[n][it].close()
+					cm108_in[n][it] = nil
+				}
+			}
 		}
 	}
 
@@ -1296,13 +1132,27 @@ func ptt_term() {
 		if save_audio_config_p.chan_medium[n] == MEDIUM_RADIO {
 			for ot := range NUM_OCTYPES {
 				if rig[n][ot] != nil {
+					rig_mutex[n].Lock()
 					rig[n][ot].Close()   //nolint:errcheck
 					rig[n][ot].Cleanup() //nolint:errcheck
 					rig[n][ot] = nil
+					rig_mutex[n].Unlock()
+				}
+
+				if cat_port[n][ot] != nil {
+					serial_port_close(cat_port[n][ot])
+					cat_port[n][ot] = nil
+				}
+
+				if mcp23017_port[n][ot] != nil {
+					mcp23017_port[n][ot].Close()
+					mcp23017_port[n][ot] = nil
 				}
 			}
 		}
 	}
+
+	ptt_remote_term()
 }
 
 /*
@@ -1397,7 +1247,8 @@ func PTTTestMain() {
 	my_audio_config = audio_s{} //nolint:exhaustruct
 	my_audio_config.adev[0].num_channels = 1
 	my_audio_config.chan_medium[0] = MEDIUM_RADIO
-	my_audio_config.achan[0].octrl[OCTYPE_PTT].ptt_method = PTT_METHOD_GPIO
+	my_audio_config.achan[0].octrl[OCTYPE_PTT].ptt_method = PTT_METHOD_GPIOD
+	my_audio_config.achan[0].octrl[OCTYPE_PTT].out_gpio_name = "/dev/gpiochip0"
 	my_audio_config.achan[0].octrl[OCTYPE_PTT].out_gpio_num = 25
 
 	dw_printf("Try GPIO %d a few times...\n", my_audio_config.achan[0].octrl[OCTYPE_PTT].out_gpio_num)
@@ -0,0 +1,30 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	More than one output for PTT, DCD, or CON, e.g. to light
+ *		LEDs on a status panel as well as keying the radio.
+ *
+ * Description:	INDICATOR PTT GPIO 5
+ *		INDICATOR DCD HTTP http://panel/dcd/on http://panel/dcd/off
+ *
+ *		Each one takes one of the OCTYPE_IND1 and up outputs,
+ *		configured with any of the usual methods, and remembers
+ *		which output it follows.  Whenever that output changes,
+ *		so does the indicator.
+ *
+ *------------------------------------------------------------------*/
+
+func ptt_indicators(ot int, channel int, ptt_signal int) {
+	if ot >= OCTYPE_IND1 {
+		return
+	}
+
+	for ind := OCTYPE_IND1; ind < NUM_OCTYPES; ind++ {
+		var octrl = &save_audio_config_p.achan[channel].octrl[ind]
+
+		if octrl.ptt_method != PTT_METHOD_NONE && octrl.ind_follows == ot {
+			ptt_set_one(ind, channel, ptt_signal)
+		}
+	}
+}
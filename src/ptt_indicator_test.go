@@ -0,0 +1,46 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ptt_indicators(t *testing.T) {
+	var ptt, ind_ptt, ind_dcd = new(mockGPIODLine), new(mockGPIODLine), new(mockGPIODLine)
+
+	var cfg audio_s
+	cfg.chan_medium[0] = MEDIUM_RADIO
+	cfg.achan[0].octrl[OCTYPE_PTT].ptt_method = PTT_METHOD_GPIOD
+	cfg.achan[0].octrl[OCTYPE_IND1].ptt_method = PTT_METHOD_GPIOD
+	cfg.achan[0].octrl[OCTYPE_IND1].ind_follows = OCTYPE_PTT
+	cfg.achan[0].octrl[OCTYPE_IND2].ptt_method = PTT_METHOD_GPIOD
+	cfg.achan[0].octrl[OCTYPE_IND2].ind_follows = OCTYPE_DCD
+	save_audio_config_p = &cfg
+
+	gpiod_line[0][OCTYPE_PTT] = ptt
+	gpiod_line[0][OCTYPE_IND1] = ind_ptt
+	gpiod_line[0][OCTYPE_IND2] = ind_dcd
+
+	t.Cleanup(func() {
+		for ot := range NUM_OCTYPES {
+			gpiod_line[0][ot] = nil
+		}
+
+		save_audio_config_p = nil
+	})
+
+	ptt_set_real(OCTYPE_PTT, 0, 1)
+	assert.Equal(t, 1, ptt.value)
+	assert.Equal(t, 1, ind_ptt.value)
+	assert.Equal(t, 0, ind_dcd.value)
+
+	// DCD has only an indicator, no output of its own.
+	ptt_set_real(OCTYPE_DCD, 0, 1)
+	assert.Equal(t, 1, ind_dcd.value)
+
+	ptt_set_real(OCTYPE_PTT, 0, 0)
+	assert.Equal(t, 0, ptt.value)
+	assert.Equal(t, 0, ind_ptt.value)
+	assert.Equal(t, 1, ind_dcd.value)
+}
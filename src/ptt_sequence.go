@@ -0,0 +1,103 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Key a station in steps, for amplifiers and preamps
+ *		which must not be switched while RF is present.
+ *
+ * Description:	SEQ1 and SEQ2 are extra output lines, configured the same
+ *		way as PTT.  TXSEQ gives the delays, in milliseconds,
+ *		after each step.  For example,
+ *
+ *			PTT   GPIO 17		# Transmit / receive relay.
+ *			SEQ1  GPIO 27		# Amplifier.
+ *			TXSEQ 30 50
+ *
+ *		Turning on:	PTT, wait 30 ms, SEQ1, wait 50 ms, then audio.
+ *		Turning off:	(after TXTAIL) SEQ1, wait 30 ms, PTT.
+ *
+ *		So the last delay is only used when turning on.  Without
+ *		any SEQ lines, one delay just holds off the audio after PTT.
+ *
+ *		This is all done inside of ptt_set so the transmit thread
+ *		doesn't need to know anything about it, other than not
+ *		starting its timing until ptt_set returns.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"time"
+)
+
+var ptt_sequence_lines = []int{OCTYPE_SEQ1, OCTYPE_SEQ2}
+
+func ptt_sequence_used(channel int) bool {
+	if len(save_audio_config_p.achan[channel].txseq_ms) > 0 {
+		return true
+	}
+
+	for _, ot := range ptt_sequence_lines {
+		if save_audio_config_p.achan[channel].octrl[ot].ptt_method != PTT_METHOD_NONE {
+			return true
+		}
+	}
+
+	return false
+}
+
+/* Steps in order:  PTT, then whichever SEQ lines are configured. */
+
+func ptt_sequence_steps(channel int) []int {
+	var steps = []int{OCTYPE_PTT}
+
+	for _, ot := range ptt_sequence_lines {
+		if save_audio_config_p.achan[channel].octrl[ot].ptt_method != PTT_METHOD_NONE {
+			steps = append(steps, ot)
+		}
+	}
+
+	return steps
+}
+
+/* Delay after step n.  Anything not given is 0. */
+
+func ptt_sequence_delay(channel int, n int) time.Duration {
+	var ms = save_audio_config_p.achan[channel].txseq_ms
+	if n >= len(ms) {
+		return 0
+	}
+
+	return time.Duration(ms[n]) * time.Millisecond
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	ptt_sequence
+ *
+ * Purpose:	Turn on all of the steps, in order, or off in reverse order.
+ *
+ * Inputs:	channel		- Radio channel.
+ *		ptt_signal	- 1 for transmit, 0 for receive.
+ *
+ *--------------------------------------------------------------------*/
+
+func ptt_sequence(channel int, ptt_signal int) {
+	var steps = ptt_sequence_steps(channel)
+
+	if ptt_signal != 0 {
+		for n, ot := range steps {
+			ptt_set_one(ot, channel, 1)
+			time.Sleep(ptt_sequence_delay(channel, n))
+		}
+
+		return
+	}
+
+	for n := len(steps) - 1; n >= 0; n-- {
+		ptt_set_one(steps[n], channel, 0)
+
+		if n > 0 {
+			time.Sleep(ptt_sequence_delay(channel, n-1))
+		}
+	}
+}
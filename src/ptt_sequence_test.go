@@ -0,0 +1,76 @@
+package direwolf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// seqRecorder logs each SetValue, with the line's name, so the order can be checked.
+type seqRecorder struct {
+	name string
+	log  *[]string
+}
+
+func (r *seqRecorder) SetValue(v int) error {
+	*r.log = append(*r.log, r.name+IfThenElse(v != 0, " on", " off"))
+	return nil
+}
+
+func (r *seqRecorder) Close() error {
+	return nil
+}
+
+func Test_ptt_sequence(t *testing.T) {
+	var log []string
+
+	var cfg audio_s
+	cfg.chan_medium[0] = MEDIUM_RADIO
+
+	for _, ot := range []int{OCTYPE_PTT, OCTYPE_SEQ1, OCTYPE_SEQ2} {
+		cfg.achan[0].octrl[ot].ptt_method = PTT_METHOD_GPIOD
+		gpiod_line[0][ot] = &seqRecorder{name: []string{"PTT", "", "", "SEQ1", "SEQ2"}[ot], log: &log}
+	}
+
+	cfg.achan[0].txseq_ms = []int{20, 0, 40}
+	save_audio_config_p = &cfg
+
+	t.Cleanup(func() {
+		for ot := range NUM_OCTYPES {
+			gpiod_line[0][ot] = nil
+		}
+
+		save_audio_config_p = nil
+	})
+
+	var start = time.Now()
+
+	ptt_set_real(OCTYPE_PTT, 0, 1)
+
+	assert.Equal(t, []string{"PTT on", "SEQ1 on", "SEQ2 on"}, log)
+	assert.GreaterOrEqual(t, time.Since(start), 60*time.Millisecond, "all delays, including the one before audio")
+
+	log = nil
+	start = time.Now()
+
+	ptt_set_real(OCTYPE_PTT, 0, 0)
+
+	assert.Equal(t, []string{"SEQ2 off", "SEQ1 off", "PTT off"}, log)
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Less(t, time.Since(start), 40*time.Millisecond, "last delay is only used when turning on")
+}
+
+func Test_ptt_sequence_unused(t *testing.T) {
+	var cfg audio_s
+	cfg.achan[0].octrl[OCTYPE_PTT].ptt_method = PTT_METHOD_GPIOD
+	save_audio_config_p = &cfg
+
+	t.Cleanup(func() { save_audio_config_p = nil })
+
+	assert.False(t, ptt_sequence_used(0))
+
+	cfg.achan[0].octrl[OCTYPE_SEQ2].ptt_method = PTT_METHOD_GPIOD
+	assert.True(t, ptt_sequence_used(0))
+	assert.Equal(t, []int{OCTYPE_PTT, OCTYPE_SEQ2}, ptt_sequence_steps(0))
+}
@@ -0,0 +1,70 @@
+//go:build !windows
+
+package direwolf
+
+/*
+ * Serial port RTS and DTR for PTT, Linux and other Unix-like systems.
+ */
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/unix"
+)
+
+func _TIOCM(fd int, value int, on bool) {
+	var stuff, _ = unix.IoctlGetInt(fd, unix.TIOCMGET)
+	if on {
+		stuff |= value
+	} else {
+		stuff &= ^value
+	}
+
+	unix.IoctlSetInt(fd, unix.TIOCMSET, stuff)
+}
+
+func RTS_ON(fd uintptr) {
+	_TIOCM(int(fd), unix.TIOCM_RTS, true)
+}
+
+func RTS_OFF(fd uintptr) {
+	_TIOCM(int(fd), unix.TIOCM_RTS, false)
+}
+
+func DTR_ON(fd uintptr) {
+	_TIOCM(int(fd), unix.TIOCM_DTR, true)
+}
+
+func DTR_OFF(fd uintptr) {
+	_TIOCM(int(fd), unix.TIOCM_DTR, false)
+}
+
+/* Translate Windows device name into Linux name. */
+/* COM1 -> /dev/ttyS0, etc. */
+
+func ptt_serial_device(device string, otname string) string {
+	if !strings.HasPrefix(strings.ToUpper(device), "COM") {
+		return device
+	}
+
+	var n, _ = strconv.Atoi(device[3:])
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("Converted %s device '%s'", otname, device)
+
+	if n < 1 {
+		n = 1
+	}
+
+	device = fmt.Sprintf("/dev/ttyS%d", n-1)
+	dw_printf(" to Linux equivalent '%s'\n", device)
+
+	return device
+}
+
+func ptt_serial_open(device string) (*os.File, error) {
+	return os.Open(device) //nolint:gosec
+}
@@ -0,0 +1,16 @@
+//go:build !windows
+
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func Test_ptt_serial_device(t *testing.T) {
+	assert.Equal(t, "/dev/ttyUSB0", ptt_serial_device("/dev/ttyUSB0", "PTT"))
+	assert.Equal(t, "/dev/ttyS0", ptt_serial_device("COM1", "PTT"))
+	assert.Equal(t, "/dev/ttyS11", ptt_serial_device("com12", "PTT"))
+	assert.Equal(t, "/dev/ttyS0", ptt_serial_device("COM0", "PTT"))
+}
@@ -0,0 +1,71 @@
+package direwolf
+
+/*
+ * Serial port RTS and DTR for PTT, Windows.
+ *
+ * The same as the Linux version apart from EscapeCommFunction
+ * instead of ioctl, and the device names.
+ */
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"golang.org/x/sys/windows"
+)
+
+func escape_comm(fd uintptr, function uint32) {
+	var err = windows.EscapeCommFunction(windows.Handle(fd), function)
+	if err != nil && ptt_debug_level >= 1 {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("EscapeCommFunction failed: %s\n", err)
+	}
+}
+
+func RTS_ON(fd uintptr) {
+	escape_comm(fd, windows.SETRTS)
+}
+
+func RTS_OFF(fd uintptr) {
+	escape_comm(fd, windows.CLRRTS)
+}
+
+func DTR_ON(fd uintptr) {
+	escape_comm(fd, windows.SETDTR)
+}
+
+func DTR_OFF(fd uintptr) {
+	escape_comm(fd, windows.CLRDTR)
+}
+
+var linux_serial_re = regexp.MustCompile(`^/dev/ttyS(\d+)$`)
+
+/*
+ * COM1 to COM9 can be opened by name, but anything higher needs the
+ * \\.\COM10 form, so always use that.  Also translate /dev/ttyS0
+ * to COM1, etc., the reverse of what happens on Linux.
+ */
+
+func ptt_serial_device(device string, otname string) string {
+	if m := linux_serial_re.FindStringSubmatch(device); m != nil {
+		var n, _ = strconv.Atoi(m[1])
+
+		text_color_set(DW_COLOR_INFO)
+		dw_printf("Converted %s device '%s' to Windows equivalent 'COM%d'\n", otname, device, n+1)
+
+		device = fmt.Sprintf("COM%d", n+1)
+	}
+
+	if strings.HasPrefix(strings.ToUpper(device), "COM") {
+		return `\\.\` + strings.ToUpper(device)
+	}
+
+	return device
+}
+
+func ptt_serial_open(device string) (*os.File, error) {
+	return os.OpenFile(device, os.O_RDWR, 0) //nolint:gosec
+}
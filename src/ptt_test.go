@@ -66,24 +66,25 @@ func TestPttSetRealGPIOD_Deactivate(t *testing.T) {
 	assert.Equal(t, 0, mock.value, "line should be low when PTT is inactive")
 }
 
-// TestPttSetRealGPIOD_Invert_Activate verifies that ptt_invert flips the level
-// when PTT is active (signal=1 → line low).
+// TestPttSetRealGPIOD_Invert_Activate verifies that ptt_invert leaves the
+// logical value alone when PTT is active, because the line was requested
+// active low and the kernel inverts it.
 func TestPttSetRealGPIOD_Invert_Activate(t *testing.T) {
 	var mock = setupGPIODChannel(t, true)
 
 	ptt_set_real(OCTYPE_PTT, 0, 1)
 
-	assert.Equal(t, 0, mock.value, "inverted line should be low when PTT is active")
+	assert.Equal(t, 1, mock.value, "active low line should be logically active when PTT is active")
 }
 
-// TestPttSetRealGPIOD_Invert_Deactivate verifies that ptt_invert flips the level
-// when PTT is inactive (signal=0 → line high).
+// TestPttSetRealGPIOD_Invert_Deactivate verifies that ptt_invert leaves the
+// logical value alone when PTT is inactive.
 func TestPttSetRealGPIOD_Invert_Deactivate(t *testing.T) {
 	var mock = setupGPIODChannel(t, true)
 
 	ptt_set_real(OCTYPE_PTT, 0, 0)
 
-	assert.Equal(t, 1, mock.value, "inverted line should be high when PTT is inactive")
+	assert.Equal(t, 0, mock.value, "active low line should be logically inactive when PTT is inactive")
 }
 
 // TestPttSetRealGPIOD_NilLine verifies that ptt_set_real does not panic when
@@ -102,6 +103,46 @@ func TestPttSetRealGPIOD_NilLine(t *testing.T) {
 	})
 }
 
+// mockGPIODInput is a test double for gpiodInputLine.
+type mockGPIODInput struct {
+	value  int
+	closed bool
+}
+
+func (m *mockGPIODInput) Value() (int, error) {
+	return m.value, nil
+}
+
+func (m *mockGPIODInput) Close() error {
+	m.closed = true
+	return nil
+}
+
+// TestGetInputRealGPIOD verifies that TXINH reads the logical value of the line.
+func TestGetInputRealGPIOD(t *testing.T) {
+	var mock = new(mockGPIODInput)
+	gpiod_in_line[0][ICTYPE_TXINH] = mock
+
+	var cfg audio_s
+	cfg.chan_medium[0] = MEDIUM_RADIO
+	cfg.achan[0].ictrl[ICTYPE_TXINH].method = PTT_METHOD_GPIOD
+	save_audio_config_p = &cfg
+
+	t.Cleanup(func() {
+		gpiod_in_line[0][ICTYPE_TXINH] = nil
+		save_audio_config_p = nil
+	})
+
+	assert.Equal(t, 0, get_input_real(ICTYPE_TXINH, 0))
+
+	mock.value = 1
+	assert.Equal(t, 1, get_input_real(ICTYPE_TXINH, 0))
+
+	ptt_term()
+	assert.True(t, mock.closed, "ptt_term should close the input line")
+	assert.Nil(t, gpiod_in_line[0][ICTYPE_TXINH])
+}
+
 // TestPttTermGPIOD verifies that ptt_term closes every open line handle and
 // sets the slot to nil.
 func TestPttTermGPIOD(t *testing.T) {
@@ -0,0 +1,106 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Don't leave a transmitter keyed forever.
+ *
+ * Description:	TXTIMEOUT n sets the longest time, in seconds, that PTT
+ *		can stay on for a radio channel.  After that we turn it
+ *		off ourselves, throw away everything waiting to be sent
+ *		on the channel, and raise an alert.
+ *
+ *		Normally nothing takes anywhere near that long, but a
+ *		hung audio device or a bug could otherwise keep an
+ *		unattended transmitter on the air until someone notices.
+ *
+ *		When whatever was transmitting finally turns PTT off
+ *		itself, the alert is resolved.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+/* Unit for TXTIMEOUT.  Replaced for testing. */
+
+var ptt_watchdog_unit = time.Second
+
+var ptt_watchdog_mutex sync.Mutex
+
+var ptt_watchdog_timer [MAX_RADIO_CHANS]*time.Timer
+
+/* PTT was forced off and whatever was transmitting hasn't let go yet. */
+
+var ptt_watchdog_fired [MAX_RADIO_CHANS]bool
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	ptt_watchdog
+ *
+ * Purpose:	Start or stop the timer for a channel when PTT changes.
+ *
+ * Inputs:	channel		- Radio channel.
+ *		ptt_signal	- 1 for transmit, 0 for receive.
+ *
+ *--------------------------------------------------------------------*/
+
+func ptt_watchdog(channel int, ptt_signal int) {
+	var limit = save_audio_config_p.achan[channel].tx_timeout
+	if limit <= 0 {
+		return
+	}
+
+	ptt_watchdog_mutex.Lock()
+	defer ptt_watchdog_mutex.Unlock()
+
+	if ptt_signal != 0 {
+		if ptt_watchdog_timer[channel] == nil {
+			ptt_watchdog_timer[channel] = time.AfterFunc(time.Duration(limit)*ptt_watchdog_unit, func() {
+				ptt_watchdog_expired(channel)
+			})
+		}
+
+		return
+	}
+
+	if ptt_watchdog_timer[channel] != nil {
+		ptt_watchdog_timer[channel].Stop()
+		ptt_watchdog_timer[channel] = nil
+	}
+
+	if ptt_watchdog_fired[channel] {
+		ptt_watchdog_fired[channel] = false
+
+		text_color_set(DW_COLOR_INFO)
+		dw_printf("Channel %d transmitter was released normally after TXTIMEOUT.\n", channel)
+		alertService.PTTReleased(channel)
+	}
+}
+
+func ptt_watchdog_expired(channel int) {
+	ptt_watchdog_mutex.Lock()
+	ptt_watchdog_timer[channel] = nil
+	ptt_watchdog_mutex.Unlock()
+
+	var limit = save_audio_config_p.achan[channel].tx_timeout
+
+	text_color_set(DW_COLOR_ERROR)
+	dw_printf("Channel %d has been transmitting for more than TXTIMEOUT %d seconds.  Turning PTT off.\n", channel, limit)
+
+	// Turn it off before saying it was forced, so this isn't taken as a normal release.
+	ptt_set(OCTYPE_PTT, channel, 0)
+
+	var n = tq_flush(channel)
+	if n > 0 {
+		dw_printf("Discarded %d frames waiting to be sent on channel %d.\n", n, channel)
+	}
+
+	ptt_watchdog_mutex.Lock()
+	ptt_watchdog_fired[channel] = true
+	ptt_watchdog_mutex.Unlock()
+
+	alertService.PTTStuck(fmt.Sprintf("Channel %d PTT forced off after %d seconds.", channel, limit))
+}
@@ -0,0 +1,75 @@
+package direwolf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func setupWatchdog(t *testing.T, timeout int) {
+	t.Helper()
+
+	var cfg = new(audio_s)
+	cfg.chan_medium[0] = MEDIUM_RADIO
+	cfg.achan[0].tx_timeout = timeout
+	tq_init(cfg)
+
+	var unit = ptt_watchdog_unit
+	ptt_watchdog_unit = 10 * time.Millisecond
+
+	t.Cleanup(func() {
+		ptt_watchdog_unit = unit
+		tq_flush(0)
+		save_audio_config_p = nil
+	})
+}
+
+func watchdogFired() bool {
+	ptt_watchdog_mutex.Lock()
+	defer ptt_watchdog_mutex.Unlock()
+
+	return ptt_watchdog_fired[0]
+}
+
+func Test_ptt_watchdog_expires(t *testing.T) {
+	setupWatchdog(t, 2)
+
+	tq_append(0, TQ_PRIO_1_LO, AX25FromText("Q1TEST>APRS:one", true))
+	tq_append(0, TQ_PRIO_0_HI, AX25FromText("Q1TEST>APRS:two", true))
+
+	ptt_set_real(OCTYPE_PTT, 0, 1)
+
+	require.Eventually(t, watchdogFired, time.Second, 5*time.Millisecond)
+	assert.True(t, tq_is_empty(0), "transmit queue should be flushed")
+
+	// The transmit thread finally lets go.
+	ptt_set_real(OCTYPE_PTT, 0, 0)
+	assert.False(t, watchdogFired())
+}
+
+func Test_ptt_watchdog_normal(t *testing.T) {
+	setupWatchdog(t, 5)
+
+	tq_append(0, TQ_PRIO_1_LO, AX25FromText("Q1TEST>APRS:one", true))
+
+	ptt_set_real(OCTYPE_PTT, 0, 1)
+	ptt_set_real(OCTYPE_PTT, 0, 0)
+
+	time.Sleep(80 * time.Millisecond)
+	assert.False(t, watchdogFired())
+	assert.False(t, tq_is_empty(0))
+}
+
+func Test_ptt_watchdog_off(t *testing.T) {
+	setupWatchdog(t, 0)
+
+	ptt_set_real(OCTYPE_PTT, 0, 1)
+
+	ptt_watchdog_mutex.Lock()
+	assert.Nil(t, ptt_watchdog_timer[0])
+	ptt_watchdog_mutex.Unlock()
+
+	ptt_set_real(OCTYPE_PTT, 0, 0)
+}
@@ -0,0 +1,238 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Answer APRS queries, like full featured APRS clients do.
+ *
+ * Description:	QUERY  [ APRS ] [ APRSP ] [ APRSS ] [ VER ] [ INFO=text ] [ VIA=path ]
+ *
+ *		With none of the types, the first four are answered.
+ *
+ *			?APRS?		General query for all stations.  Send position beacons,
+ *					if within any footprint.
+ *			?APRSP		Directed to us.  Send position beacons.
+ *			?APRSS		Directed to us.  Send status beacons,
+ *					i.e. CBEACON with info starting with ">".
+ *			?VER		Directed to us.  Message back with our version.
+ *			?INFO		Directed to us.  Message back with the INFO text.
+ *
+ *		Only packets heard on a radio channel are answered, on the
+ *		same channel.  Queries aren't answered more than once a
+ *		minute for each station, so one can't keep the transmitter busy.
+ *
+ *		This is off by default in case an attached application wants
+ *		to answer the queries itself.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/doismellburning/samoyed/src/geo"
+)
+
+type query_config_s struct {
+	enabled bool
+
+	general bool /* ?APRS? */
+	aprsp   bool
+	aprss   bool
+	ver     bool
+
+	info string /* Answer for ?INFO, or empty not to answer. */
+
+	via string /* Path for answers, e.g. WIDE1-1, or empty for direct. */
+}
+
+/* Least time between answers to the same station, or general queries on one channel. */
+
+const QUERY_MIN_INTERVAL = time.Minute
+
+type QueryResponder struct {
+	config *query_config_s
+	modem  *audio_s
+
+	beacons func(match func(bp *beacon_s) bool) int /* BeaconService.SendMatching */
+
+	send func(channel int, text string) /* Monitor format to transmit.  Replaced for testing. */
+
+	mu   sync.Mutex
+	last map[string]time.Time /* Last answer, by channel, station, and query. */
+}
+
+func NewQueryResponder(config *query_config_s, modem *audio_s, beacons func(match func(bp *beacon_s) bool) int) *QueryResponder {
+	return &QueryResponder{ //nolint:exhaustruct
+		config:  config,
+		modem:   modem,
+		beacons: beacons,
+		send:    query_send,
+		last:    make(map[string]time.Time),
+	}
+}
+
+func query_send(channel int, text string) {
+	var pp = AX25FromText(text, true)
+	if pp == nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Internal error.  Couldn't make query answer from \"%s\"\n", text)
+
+		return
+	}
+
+	tq_append(channel, TQ_PRIO_1_LO, pp)
+}
+
+/* Only once a minute for each.  Returns true if it's ok to answer now. */
+
+func (qr *QueryResponder) allow(channel int, who string, query string, now time.Time) bool {
+	qr.mu.Lock()
+	defer qr.mu.Unlock()
+
+	var key = fmt.Sprintf("%d %s %s", channel, who, query)
+
+	// Forget old ones so this doesn't grow forever.
+
+	for k, t := range qr.last {
+		if now.Sub(t) >= QUERY_MIN_INTERVAL {
+			delete(qr.last, k)
+		}
+	}
+
+	if _, ok := qr.last[key]; ok {
+		return false
+	}
+
+	qr.last[key] = now
+
+	return true
+}
+
+/* Is this location inside the general query footprint?  Yes if either is unknown. */
+
+func query_in_footprint(A *decode_aprs_t, lat float64, lon float64) bool {
+	if A.g_footprint_radius == G_UNKNOWN || lat == G_UNKNOWN || lon == G_UNKNOWN {
+		return true
+	}
+
+	return geo.DistanceKM(A.g_footprint_lat, A.g_footprint_lon, lat, lon) <= DW_MILES_TO_KM(A.g_footprint_radius)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Heard
+ *
+ * Purpose:	Answer a received APRS packet if it is a query for us.
+ *
+ * Inputs:	channel	- Where it was heard.
+ *
+ *		A	- Decoded packet.
+ *
+ * Returns:	true if answered.
+ *
+ *--------------------------------------------------------------------*/
+
+func (qr *QueryResponder) Heard(channel int, A *decode_aprs_t) bool {
+	if !qr.config.enabled || channel < 0 || channel >= MAX_RADIO_CHANS ||
+		qr.modem.chan_medium[channel] != MEDIUM_RADIO || A.g_query_type == "" {
+		return false
+	}
+
+	var mycall = qr.modem.mycall[channel]
+
+	if A.g_message_subtype != message_subtype_directed_query {
+		/* General query for everyone. */
+
+		if A.g_query_type != "APRS" || !qr.config.general {
+			return false
+		}
+
+		// Only counts toward once a minute if we answer, so one from
+		// far away doesn't stop us answering a nearby one.
+
+		var checked, allowed = false, false
+
+		return qr.beacons(func(bp *beacon_s) bool {
+			if !query_position_beacon(bp, channel) || !query_in_footprint(A, bp.lat, bp.lon) {
+				return false
+			}
+
+			if !checked {
+				allowed = qr.allow(channel, "*", A.g_query_type, time.Now())
+				checked = true
+			}
+
+			return allowed
+		}) > 0
+	}
+
+	if !strings.EqualFold(strings.TrimSpace(A.g_addressee), mycall) {
+		return false
+	}
+
+	var answer string
+
+	switch A.g_query_type {
+	case "APRSP":
+		if !qr.config.aprsp {
+			return false
+		}
+	case "APRSS":
+		if !qr.config.aprss {
+			return false
+		}
+	case "VER":
+		if !qr.config.ver {
+			return false
+		}
+
+		answer = "Samoyed " + SAMOYED_VERSION
+	case "INFO":
+		if qr.config.info == "" {
+			return false
+		}
+
+		answer = qr.config.info
+	default:
+		return false
+	}
+
+	if !qr.allow(channel, A.g_src, A.g_query_type, time.Now()) {
+		return false
+	}
+
+	text_color_set(DW_COLOR_INFO)
+	dw_printf("Answering ?%s from %s on channel %d.\n", A.g_query_type, A.g_src, channel)
+
+	switch A.g_query_type {
+	case "APRSP":
+		return qr.beacons(func(bp *beacon_s) bool { return query_position_beacon(bp, channel) }) > 0
+	case "APRSS":
+		return qr.beacons(func(bp *beacon_s) bool { return query_status_beacon(bp, channel) }) > 0
+	}
+
+	var text = fmt.Sprintf("%s>%s%1d%1d", mycall, APP_TOCALL, MAJOR_VERSION, MINOR_VERSION)
+	if qr.config.via != "" {
+		text += "," + qr.config.via
+	}
+
+	qr.send(channel, fmt.Sprintf("%s::%-9s:%s", text, A.g_src, answer))
+
+	return true
+}
+
+/* Our position on this channel. */
+
+func query_position_beacon(bp *beacon_s, channel int) bool {
+	return (bp.btype == BEACON_POSITION || bp.btype == BEACON_TRACKER) &&
+		bp.sendto_type == SENDTO_XMIT && bp.sendto_chan == channel
+}
+
+/* Status on this channel, i.e. CBEACON INFO=">..." */
+
+func query_status_beacon(bp *beacon_s, channel int) bool {
+	return bp.btype == BEACON_CUSTOM && strings.HasPrefix(bp.custom_info, ">") &&
+		bp.sendto_type == SENDTO_XMIT && bp.sendto_chan == channel
+}
@@ -0,0 +1,126 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestQueryResponder(t *testing.T, config *query_config_s) (*QueryResponder, *[]string, *[]string) {
+	t.Helper()
+
+	var modem = new(audio_s)
+	modem.chan_medium[0] = MEDIUM_RADIO
+	modem.mycall[0] = "Q1TEST"
+
+	var beacons = []*beacon_s{
+		{btype: BEACON_POSITION, sendto_type: SENDTO_XMIT, sendto_chan: 0, lat: 42.6, lon: -71.3},    //nolint:exhaustruct
+		{btype: BEACON_CUSTOM, sendto_type: SENDTO_XMIT, sendto_chan: 0, custom_info: ">On the air"}, //nolint:exhaustruct
+		{btype: BEACON_POSITION, sendto_type: SENDTO_IGATE, lat: 42.6, lon: -71.3},                   //nolint:exhaustruct
+	}
+
+	var sent []string
+	var answers []string
+
+	var qr = NewQueryResponder(config, modem, func(match func(bp *beacon_s) bool) int {
+		var n = 0
+
+		for _, bp := range beacons {
+			if match(bp) {
+				sent = append(sent, IfThenElse(bp.btype == BEACON_CUSTOM, "status", "position"))
+				n++
+			}
+		}
+
+		return n
+	})
+	qr.send = func(_ int, text string) {
+		answers = append(answers, text)
+	}
+
+	return qr, &sent, &answers
+}
+
+func testQuery(t *testing.T, text string) *decode_aprs_t {
+	t.Helper()
+
+	var pp = AX25FromText(text, false)
+	require.NotNil(t, pp)
+
+	return decode_aprs(pp, true, "")
+}
+
+func Test_directed_station_query_decode(t *testing.T) {
+	var A = testQuery(t, "Q2OTHR>APRS::Q1TEST   :?APRSHVN0QBF{12")
+	assert.Equal(t, message_subtype_directed_query, A.g_message_subtype)
+	assert.Equal(t, "APRSH", A.g_query_type)
+	assert.Equal(t, "VN0QBF", A.g_query_callsign)
+
+	A = testQuery(t, "Q2OTHR>APRS::Q1TEST   :?ver")
+	assert.Equal(t, "VER", A.g_query_type)
+	assert.Empty(t, A.g_query_callsign)
+}
+
+func Test_query_off_by_default(t *testing.T) {
+	var qr, sent, answers = newTestQueryResponder(t, new(query_config_s))
+
+	assert.False(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS::Q1TEST   :?APRSP")))
+	assert.False(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS:?APRS?")))
+	assert.Empty(t, *sent)
+	assert.Empty(t, *answers)
+}
+
+func Test_query_directed(t *testing.T) {
+	var qr, sent, answers = newTestQueryResponder(t, &query_config_s{enabled: true, aprsp: true, aprss: true, ver: true, info: "Digi and IGate", via: "WIDE1-1"}) //nolint:exhaustruct
+
+	assert.True(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS::Q1TEST   :?APRSP")))
+	assert.Equal(t, []string{"position"}, *sent, "only the position on this channel")
+
+	assert.True(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS::Q1TEST   :?APRSS")))
+	assert.Equal(t, []string{"position", "status"}, *sent)
+
+	assert.True(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS::Q1TEST   :?INFO")))
+	assert.True(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS::Q1TEST   :?VER{3")))
+	require.Len(t, *answers, 2)
+	assert.Equal(t, "Q1TEST>"+APP_TOCALL+"00,WIDE1-1::Q2OTHR   :Digi and IGate", (*answers)[0])
+	assert.Contains(t, (*answers)[1], "::Q2OTHR   :Samoyed ")
+
+	assert.False(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS::Q1TEST   :?APRSP")), "not again so soon")
+	assert.True(t, qr.Heard(0, testQuery(t, "Q3OTHR>APRS::Q1TEST   :?APRSP")), "someone else can ask")
+
+	assert.False(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS::Q9ELSE   :?VER")), "not for us")
+	assert.False(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS::Q1TEST   :?APRSD")), "not supported")
+	assert.False(t, qr.Heard(1, testQuery(t, "Q4OTHR>APRS::Q1TEST   :?VER")), "not a radio channel")
+}
+
+func Test_query_general(t *testing.T) {
+	var qr, sent, _ = newTestQueryResponder(t, &query_config_s{enabled: true, general: true}) //nolint:exhaustruct
+
+	assert.False(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS:?APRS? 34.02,-117.15,0200")), "too far away")
+	assert.True(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS:?APRS? 42.5,-71.2,0020")))
+	assert.Equal(t, []string{"position"}, *sent)
+
+	assert.False(t, qr.Heard(0, testQuery(t, "Q3OTHR>APRS:?APRS?")), "once a minute for everyone")
+	assert.False(t, qr.Heard(0, testQuery(t, "Q2OTHR>APRS::Q1TEST   :?APRSP")), "directed not enabled")
+}
+
+func Test_config_init_query(t *testing.T) {
+	var _, misc = configFromString(t, "QUERY\n")
+	assert.True(t, misc.query.enabled)
+	assert.True(t, misc.query.general)
+	assert.True(t, misc.query.aprsp)
+	assert.True(t, misc.query.aprss)
+	assert.True(t, misc.query.ver)
+	assert.Empty(t, misc.query.info)
+
+	_, misc = configFromString(t, "QUERY VER INFO=\"Digi and IGate\" VIA=WIDE1-1\n")
+	assert.True(t, misc.query.enabled)
+	assert.False(t, misc.query.general)
+	assert.True(t, misc.query.ver)
+	assert.Equal(t, "Digi and IGate", misc.query.info)
+	assert.Equal(t, "WIDE1-1", misc.query.via)
+
+	_, misc = configFromString(t, "QUERY APRSX\n")
+	assert.False(t, misc.query.enabled)
+}
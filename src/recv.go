@@ -126,6 +126,8 @@ func recv_adev_thread(a int) {
 	var eof = false
 	for !eof {
 		for c := range num_chan {
+			profileSvc.ApplyPending(first_chan + c) // Between samples, so the modem can change.
+
 			var audio_sample = demod_get_sample(a)
 
 			if audio_sample >= 256*256 {
@@ -134,7 +136,13 @@ func recv_adev_thread(a int) {
 
 			// Future?  provide more flexible mapping.
 			// i.e. for each valid channel where audio_source[] is first_chan+c.
-			multi_modem_process_sample(first_chan+c, audio_sample)
+			// An APRStt gateway on a small computer might only want touch tones.
+
+			if save_pa.achan[first_chan+c].dtmf_decode != DTMF_DECODE_ONLY {
+				multi_modem_process_sample(first_chan+c, audio_sample)
+			}
+
+			spectrum.Sample(first_chan+c, audio_sample)
 
 			/* Originally, the DTMF decoder was always active. */
 			/* It took very little CPU time and the thinking was that an */
@@ -175,6 +183,7 @@ func recv_adev_thread(a int) {
 
 	text_color_set(DW_COLOR_ERROR)
 	dw_printf("Terminating after audio device %d input failure.\n", a)
+	alertService.AudioLost(a)
 	os.Exit(1)
 }
 
@@ -182,7 +191,11 @@ func recv_process() {
 	for {
 		var timeout_value = ax25_link_get_next_timer_expiry()
 
-		var timed_out = dlq_wait_while_empty(timeout_value)
+		/* Wake up in time to tell systemd we're still alive.  Early expiry is harmless. */
+
+		var timed_out = dlq_wait_while_empty(systemdNotifier.WaitUntil(timeout_value))
+
+		systemdNotifier.Watchdog()
 
 		if timed_out {
 			dl_timer_expiry()
@@ -0,0 +1,148 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Keep track of the radio's frequency and mode, when
+ *		hamlib or rigctld is used for PTT.
+ *
+ * Description:	RIGPOLL n, after PTT RIG, asks the radio every n seconds.
+ *		The result goes into the packet log, so it records which
+ *		frequency each packet was heard on, and into beacons
+ *		with RIGINFO=1.
+ *
+ *		Hamlib can't be used by two goroutines at once, so
+ *		rig_mutex is also held for SetPtt.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	goHamlib "github.com/xylo04/goHamlib"
+)
+
+var rig_mutex [MAX_RADIO_CHANS]sync.Mutex
+
+type rig_status_s struct {
+	freq float64 /* Hz.  0 if unknown. */
+	mode string  /* e.g. FM, PKTUSB.  Empty if unknown. */
+	when time.Time
+}
+
+var rig_status [MAX_RADIO_CHANS]rig_status_s
+var rig_status_mutex sync.Mutex
+
+/* How often to poll, for each channel. */
+
+var rig_poll_interval [MAX_RADIO_CHANS]time.Duration
+
+/* The part of goHamlib.Rig we need here.  Replaced in tests. */
+
+type rigReader interface {
+	GetFreq(vfo goHamlib.VFOType) (float64, error)
+	GetMode(vfo goHamlib.VFOType) (goHamlib.Mode, int, error)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	rig_poll_once
+ *
+ * Purpose:	Ask the radio for its frequency and mode and save them.
+ *
+ * Returns:	Error from hamlib.  The saved status is left alone.
+ *
+ *--------------------------------------------------------------------*/
+
+func rig_poll_once(channel int, r rigReader) error {
+	var freq, err = r.GetFreq(goHamlib.VFOCurrent)
+	if err != nil {
+		return err
+	}
+
+	var mode, _, modeErr = r.GetMode(goHamlib.VFOCurrent)
+	if modeErr != nil {
+		return modeErr
+	}
+
+	rig_status_mutex.Lock()
+	rig_status[channel] = rig_status_s{freq: freq, mode: goHamlib.ModeName[mode], when: time.Now()}
+	rig_status_mutex.Unlock()
+
+	return nil
+}
+
+/* Poll until the rig is closed by ptt_term.  Only one error message until it works again. */
+
+func rig_poll_thread(channel int) {
+	var failing = false
+
+	for {
+		rig_mutex[channel].Lock()
+
+		var r = rig[channel][OCTYPE_PTT]
+		if r == nil {
+			rig_mutex[channel].Unlock()
+			return
+		}
+
+		var err = rig_poll_once(channel, r)
+
+		rig_mutex[channel].Unlock()
+
+		if err != nil && !failing {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Hamlib: Can't get frequency and mode for channel %d: %s\n", channel, err)
+		}
+
+		failing = err != nil
+
+		time.Sleep(rig_poll_interval[channel])
+	}
+}
+
+func rig_poll_start(channel int, seconds int) {
+	rig_poll_interval[channel] = time.Duration(seconds) * time.Second
+
+	go rig_poll_thread(channel)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	rig_freq_mode
+ *
+ * Purpose:	Latest frequency and mode for a channel.
+ *
+ * Returns:	Frequency in MHz and mode, or 0 and "" if not known.
+ *		Anything older than a few polls is considered unknown,
+ *		rather than passing along an old frequency as current.
+ *
+ *--------------------------------------------------------------------*/
+
+func rig_freq_mode(channel int) (float64, string) {
+	if channel < 0 || channel >= MAX_RADIO_CHANS {
+		return 0, ""
+	}
+
+	rig_status_mutex.Lock()
+	defer rig_status_mutex.Unlock()
+
+	var s = rig_status[channel]
+	if s.when.IsZero() || time.Since(s.when) > 3*rig_poll_interval[channel] {
+		return 0, ""
+	}
+
+	return s.freq / 1e6, s.mode
+}
+
+/* For the log file.  Empty strings when not known. */
+
+func rig_freq_mode_text(channel int) (string, string) {
+	var mhz, mode = rig_freq_mode(channel)
+	if mhz == 0 {
+		return "", ""
+	}
+
+	return fmt.Sprintf("%.6f", mhz), mode
+}
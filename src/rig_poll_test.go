@@ -0,0 +1,95 @@
+package direwolf
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	goHamlib "github.com/xylo04/goHamlib"
+)
+
+type fakeRig struct {
+	freq float64
+	mode goHamlib.Mode
+	err  error
+}
+
+func (f *fakeRig) GetFreq(_ goHamlib.VFOType) (float64, error) {
+	return f.freq, f.err
+}
+
+func (f *fakeRig) GetMode(_ goHamlib.VFOType) (goHamlib.Mode, int, error) {
+	return f.mode, 0, f.err
+}
+
+func rigPollTestReset(t *testing.T) {
+	t.Helper()
+
+	t.Cleanup(func() {
+		rig_status[0] = rig_status_s{} //nolint:exhaustruct
+		rig_poll_interval[0] = 0
+	})
+}
+
+func Test_rig_poll_once(t *testing.T) {
+	rigPollTestReset(t)
+
+	rig_poll_interval[0] = time.Minute
+
+	var mhz, mode = rig_freq_mode(0)
+	assert.Zero(t, mhz, "unknown before the first poll")
+	assert.Empty(t, mode)
+
+	require.NoError(t, rig_poll_once(0, &fakeRig{freq: 144390000, mode: goHamlib.ModeFM})) //nolint:exhaustruct
+
+	mhz, mode = rig_freq_mode(0)
+	assert.InDelta(t, 144.39, mhz, 1e-9)
+	assert.Equal(t, "FM", mode)
+
+	// An error leaves the last good answer.
+	assert.Error(t, rig_poll_once(0, &fakeRig{err: errors.New("timeout")})) //nolint:exhaustruct
+
+	var sfreq, smode = rig_freq_mode_text(0)
+	assert.Equal(t, "144.390000", sfreq)
+	assert.Equal(t, "FM", smode)
+
+	// Too old is unknown.
+	rig_poll_interval[0] = time.Millisecond
+	time.Sleep(5 * time.Millisecond)
+
+	sfreq, smode = rig_freq_mode_text(0)
+	assert.Empty(t, sfreq)
+	assert.Empty(t, smode)
+}
+
+func Test_rig_poll_log(t *testing.T) {
+	rigPollTestReset(t)
+
+	rig_poll_interval[0] = time.Minute
+	require.NoError(t, rig_poll_once(0, &fakeRig{freq: 14105000, mode: goHamlib.ModeUSB})) //nolint:exhaustruct
+
+	var path = filepath.Join(t.TempDir(), "packets.log")
+	var pl = NewPacketLogger(false, path)
+
+	t.Cleanup(pl.Close)
+
+	var pp = AX25FromText("Q1TEST>APDW18:>on the air", true)
+	require.NotNil(t, pp)
+
+	pl.Write(0, decode_aprs(pp, true, ""), pp, ALevel{rec: 50, mark: 25, space: 25}, RETRY_NONE)
+	pl.Write(1, decode_aprs(pp, true, ""), pp, ALevel{rec: 50, mark: 25, space: 25}, RETRY_NONE)
+
+	var data, err = os.ReadFile(path) //nolint:gosec
+	require.NoError(t, err)
+
+	var lines = strings.Split(strings.TrimSpace(string(data)), "\n")
+	require.Len(t, lines, 3)
+	assert.True(t, strings.HasSuffix(lines[0], ",comment,rigfreq,rigmode"))
+	assert.True(t, strings.HasSuffix(lines[1], ",14.105000,USB"), lines[1])
+	assert.True(t, strings.HasSuffix(lines[2], ",,"), lines[2])
+}
@@ -14,9 +14,20 @@ package direwolf
  *
  *******************************************************************************/
 
+import "sync"
+
 var new_count = 0
 var delete_count = 0
 
+/*
+ * Each frame candidate gets one of these, for every slicer of every
+ * demodulator, so they come and go at a great rate.  At around 20K
+ * each, we keep the deleted ones for reuse rather than making
+ * the garbage collector deal with them.
+ */
+
+var rrbb_pool = sync.Pool{New: func() any { return new(rrbb_t) }}
+
 /*
  * Maximum number of bits in AX.25 frame excluding the flags.
  * Adequate for extreme case of bit stuffing after every 5 bits
@@ -75,12 +86,13 @@ func rrbb_new(channel int, subchannel int, slice int, is_scrambled bool, descram
 	Assert(subchannel >= 0 && subchannel < MAX_SUBCHANS)
 	Assert(slice >= 0 && slice < MAX_SLICERS)
 
-	var result = new(rrbb_t)
+	var result = rrbb_pool.Get().(*rrbb_t) //nolint:forcetypeassert
 
 	result.magic1 = MAGIC1
 	result.channel = channel
 	result.subchannel = subchannel
 	result.slice = slice
+	result.speed_error = 0
 	result.magic2 = MAGIC2
 
 	new_count++
@@ -237,7 +249,7 @@ func rrbb_get_bit(b *rrbb_t, ind int) byte {
  *
  * Name:	rrbb_delete
  *
- * Purpose:	Free the storage associated with the bit array.
+ * Purpose:	Done with the bit array.  It goes back in the pool for reuse.
  *
  * Inputs:	Handle for bit array.
  *
@@ -252,6 +264,9 @@ func rrbb_delete(b *rrbb_t) {
 	b.magic2 = 0
 
 	delete_count++
+
+	/* Nothing is copied out of fdata, so it's fine to leave it dirty. */
+	rrbb_pool.Put(b)
 }
 
 /***********************************************************************************
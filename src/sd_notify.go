@@ -0,0 +1,150 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Tell systemd when we are ready, and that we are still alive.
+ *
+ * Description:	With this in the service unit
+ *
+ *			[Service]
+ *			Type=notify
+ *			WatchdogSec=30
+ *
+ *		systemd waits for READY before starting anything which
+ *		depends on us, and restarts us if the receive loop stops
+ *		sending WATCHDOG pings, e.g. because it is stuck.
+ *
+ *		systemd gives the socket in $NOTIFY_SOCKET and the
+ *		watchdog time in $WATCHDOG_USEC.  Pings are sent at
+ *		half that interval.  Nothing happens without them so
+ *		this is harmless when not run by systemd.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"net"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+)
+
+type SystemdNotifier struct {
+	mu       sync.Mutex
+	socket   string
+	interval time.Duration // Between watchdog pings.  0 if the watchdog isn't enabled.
+	next     time.Time     // When the next ping is due.
+}
+
+var systemdNotifier *SystemdNotifier
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	NewSystemdNotifier
+ *
+ * Purpose:	Find out from the environment whether systemd wants to hear from us.
+ *
+ * Returns:	nil if not run by systemd with Type=notify.
+ *
+ *---------------------------------------------------------------*/
+
+func NewSystemdNotifier() *SystemdNotifier {
+	var socket = os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+
+	var n = new(SystemdNotifier)
+	n.socket = socket
+
+	/* WATCHDOG_PID, if set, says which process should send pings. */
+
+	var usec, err = strconv.ParseInt(os.Getenv("WATCHDOG_USEC"), 10, 64)
+	var pid = os.Getenv("WATCHDOG_PID")
+
+	if err == nil && usec > 0 && (pid == "" || pid == strconv.Itoa(os.Getpid())) {
+		n.interval = time.Duration(usec) * time.Microsecond / 2
+		n.next = time.Now()
+	}
+
+	return n
+}
+
+/* Send state, e.g. "READY=1", to systemd. */
+
+func (n *SystemdNotifier) notify(state string) {
+	if n == nil {
+		return
+	}
+
+	var conn, err = net.DialUnix("unixgram", nil, &net.UnixAddr{Name: n.socket, Net: "unixgram"})
+	if err == nil {
+		_, err = conn.Write([]byte(state))
+		conn.Close()
+	}
+
+	if err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Can't notify systemd of %s: %s\n", state, err)
+	}
+}
+
+/* After audio devices are open and network services are listening. */
+
+func (n *SystemdNotifier) Ready() {
+	n.notify("READY=1\nSTATUS=Receiving")
+}
+
+func (n *SystemdNotifier) Stopping() {
+	n.notify("STOPPING=1")
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	WaitUntil
+ *
+ * Purpose:	Limit how long the receive loop waits so it is in
+ *		time for the next watchdog ping.
+ *
+ * Inputs:	timeout	- When it would otherwise stop waiting.
+ *			  Zero time for forever.
+ *
+ *---------------------------------------------------------------*/
+
+func (n *SystemdNotifier) WaitUntil(timeout time.Time) time.Time {
+	if n == nil || n.interval == 0 {
+		return timeout
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if timeout.IsZero() || n.next.Before(timeout) {
+		return n.next
+	}
+
+	return timeout
+}
+
+/* Called each time around the receive loop.  Pings if due. */
+
+func (n *SystemdNotifier) Watchdog() {
+	if n == nil || n.interval == 0 {
+		return
+	}
+
+	n.mu.Lock()
+
+	var now = time.Now()
+	var due = !now.Before(n.next)
+
+	if due {
+		n.next = now.Add(n.interval)
+	}
+
+	n.mu.Unlock()
+
+	if due {
+		n.notify("WATCHDOG=1")
+	}
+}
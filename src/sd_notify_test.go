@@ -0,0 +1,105 @@
+package direwolf
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sdNotifyTestSocket(t *testing.T) *net.UnixConn {
+	t.Helper()
+
+	var path = filepath.Join(t.TempDir(), "notify")
+
+	var conn, err = net.ListenUnixgram("unixgram", &net.UnixAddr{Name: path, Net: "unixgram"})
+	require.NoError(t, err)
+
+	t.Cleanup(func() { conn.Close() })
+
+	t.Setenv("NOTIFY_SOCKET", path)
+
+	return conn
+}
+
+func sdNotifyTestReceive(t *testing.T, conn *net.UnixConn) string {
+	t.Helper()
+
+	var buf = make([]byte, 256)
+
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+
+	var n, err = conn.Read(buf)
+	require.NoError(t, err)
+
+	return string(buf[:n])
+}
+
+func Test_NewSystemdNotifier_not_systemd(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+
+	var n = NewSystemdNotifier()
+	assert.Nil(t, n)
+
+	// All safe to call when not run by systemd.
+	n.Ready()
+	n.Watchdog()
+	n.Stopping()
+
+	var timeout = time.Now().Add(time.Minute)
+	assert.Equal(t, timeout, n.WaitUntil(timeout))
+}
+
+func Test_SystemdNotifier_ready(t *testing.T) {
+	var conn = sdNotifyTestSocket(t)
+
+	t.Setenv("WATCHDOG_USEC", "")
+
+	var n = NewSystemdNotifier()
+	require.NotNil(t, n)
+
+	n.Ready()
+	assert.Equal(t, "READY=1\nSTATUS=Receiving", sdNotifyTestReceive(t, conn))
+
+	// No watchdog configured.
+	n.Watchdog()
+	assert.True(t, n.WaitUntil(time.Time{}).IsZero())
+
+	n.Stopping()
+	assert.Equal(t, "STOPPING=1", sdNotifyTestReceive(t, conn))
+}
+
+func Test_SystemdNotifier_watchdog(t *testing.T) {
+	var conn = sdNotifyTestSocket(t)
+
+	t.Setenv("WATCHDOG_USEC", "20000000")
+	t.Setenv("WATCHDOG_PID", strconv.Itoa(os.Getpid()+1))
+
+	var n = NewSystemdNotifier()
+	assert.Zero(t, n.interval, "watchdog is for another process")
+
+	t.Setenv("WATCHDOG_PID", "")
+
+	n = NewSystemdNotifier()
+	assert.Equal(t, 10*time.Second, n.interval, "half of WATCHDOG_USEC")
+
+	// First ping is due right away.
+	n.Watchdog()
+	assert.Equal(t, "WATCHDOG=1", sdNotifyTestReceive(t, conn))
+
+	// The next is not due for a while.  Waiting forever is limited to then.
+	var wait = n.WaitUntil(time.Time{})
+	assert.WithinDuration(t, time.Now().Add(10*time.Second), wait, time.Second)
+
+	var soon = time.Now().Add(time.Second)
+	assert.Equal(t, soon, n.WaitUntil(soon))
+
+	n.Watchdog()
+	n.Ready()
+	assert.Equal(t, "READY=1\nSTATUS=Receiving", sdNotifyTestReceive(t, conn), "no second ping yet")
+}
@@ -117,6 +117,7 @@ import (
 	"fmt"
 	"net"
 	"strings"
+	"sync/atomic"
 	"syscall"
 	"time"
 )
@@ -151,10 +152,38 @@ var enable_send_monitor_to_client [MAX_NET_CLIENTS]bool
  *
  *--------------------------------------------------------------------*/
 
-var debug_client int = 0 /* Debug option: Print information flowing from and to client. */
+/* Number of AGW client applications connected, for HTTP status. */
+
+func server_num_clients() int {
+	var n = 0
+
+	for client := range MAX_NET_CLIENTS {
+		if client_sock[client] != nil {
+			n++
+		}
+	}
+
+	return n
+}
+
+/* Number of AGW client applications which get received frames, for tracing. */
+
+func server_num_monitoring_clients() int {
+	var n = 0
+
+	for client := range MAX_NET_CLIENTS {
+		if client_sock[client] != nil && (enable_send_raw_to_client[client] || enable_send_monitor_to_client[client]) {
+			n++
+		}
+	}
+
+	return n
+}
+
+var debug_client atomic.Int32 /* Debug option: Print information flowing from and to client. */
 
 func server_set_debug(n int) {
-	debug_client = n
+	debug_client.Store(int32(n))
 }
 
 func debug_print(fromto fromto_t, client int, pmsg *AGWPEMessage) {
@@ -454,7 +483,14 @@ func server_connect_listen_thread(server_port int) {
 func server_send_rec_packet(channel int, pp *packet_t, fbuf []byte) {
 	/*
 	 * RAW format
+	 *
+	 * Same data for every client so build it once.
+	 * Stick in extra byte for the "TNC" to use.
 	 */
+	var fb = frame_buf_get()
+	fb.data = append(fb.data, byte(channel)<<4) // Was 0.  Fixed in 1.8.
+	fb.data = append(fb.data, fbuf...)
+
 	for client := range MAX_NET_CLIENTS {
 		if enable_send_raw_to_client[client] && client_sock[client] != nil {
 			var agwpe_msg = new(AGWPEMessage)
@@ -469,16 +505,10 @@ func server_send_rec_packet(channel int, pp *packet_t, fbuf []byte) {
 			var callTo = ax25_get_addr_with_ssid(pp, AX25_DESTINATION)
 			copy(agwpe_msg.Header.CallTo[:], []byte(callTo))
 
-			agwpe_msg.Header.DataLen = uint32(len(fbuf) + 1)
-			agwpe_msg.Data = make([]byte, len(fbuf)+1)
-
-			/* Stick in extra byte for the "TNC" to use. */
-
-			agwpe_msg.Data[0] = byte(channel) << 4 // Was 0.  Fixed in 1.8.
+			agwpe_msg.Header.DataLen = uint32(len(fb.data))
+			agwpe_msg.Data = fb.data
 
-			copy(agwpe_msg.Data[1:], fbuf)
-
-			if debug_client > 0 {
+			if debug_client.Load() > 0 {
 				debug_print(TO_CLIENT, client, agwpe_msg)
 			}
 
@@ -493,6 +523,8 @@ func server_send_rec_packet(channel int, pp *packet_t, fbuf []byte) {
 		}
 	}
 
+	frame_buf_put(fb)
+
 	// Application might want more human readable format.
 
 	server_send_monitored(channel, pp, 0)
@@ -581,7 +613,7 @@ func server_send_monitored(channel int, pp *packet_t, own_xmit int) {
 			msg_data_len++
 			agwpe_msg.Header.DataLen = uint32(msg_data_len) // TODO KG Just len(Data)
 
-			if debug_client > 0 {
+			if debug_client.Load() > 0 {
 				debug_print(TO_CLIENT, client, agwpe_msg)
 			}
 
@@ -914,6 +946,12 @@ func server_outstanding_frames_reply(channel int, client int, own_call string, r
  *--------------------------------------------------------------------*/
 
 func send_to_client(client int, reply_p *AGWPEMessage) {
+	if client == PBBS_CLIENT {
+		pbbsService.Deliver(reply_p)
+
+		return
+	}
+
 	if client_sock[client] == nil {
 		return
 	}
@@ -926,7 +964,7 @@ func send_to_client(client int, reply_p *AGWPEMessage) {
 		debug_print(TO_CLIENT, client, reply_p)
 	}
 
-	if debug_client > 0 {
+	if debug_client.Load() > 0 {
 		debug_print(TO_CLIENT, client, reply_p)
 	}
 
@@ -996,7 +1034,7 @@ func cmd_listen_thread(client int) {
 		 * print & process message from client.
 		 */
 
-		if debug_client > 0 {
+		if debug_client.Load() > 0 {
 			debug_print(FROM_CLIENT, client, cmd)
 		}
 
@@ -1054,6 +1092,13 @@ func handleClientCommand(client int, cmd *AGWPEMessage) {
 			fmt.Fprintf(&info, "%d;", count)
 
 			for j := range MAX_TOTAL_CHANS {
+				// A name from the configuration file says more than anything we could make up.
+				if save_audio_config_p.chan_medium[j] != MEDIUM_NONE && save_audio_config_p.chan_name[j] != "" {
+					fmt.Fprintf(&info, "Port%d %s;", j+1, save_audio_config_p.chan_name[j])
+
+					continue
+				}
+
 				switch save_audio_config_p.chan_medium[j] {
 				case MEDIUM_RADIO:
 					// Misleading if using stdin or udp.
@@ -1234,6 +1279,7 @@ func handleClientCommand(client int, cmd *AGWPEMessage) {
 			/* xastir when using the AGW interface.  */
 			/* The current version uses only the 'V' message, not 'K' for transmitting. */
 
+			pp.tx_reason = TX_REASON_CLIENT
 			tq_append(int(cmd.Header.Portx), TQ_PRIO_1_LO, pp)
 		}
 
@@ -1281,6 +1327,7 @@ func handleClientCommand(client int, cmd *AGWPEMessage) {
 				/* that digipeater has been used, it should go out quickly thru */
 				/* the high priority queue. */
 				/* Otherwise, it is an original for the low priority queue. */
+				pp.tx_reason = TX_REASON_CLIENT
 				if ax25_get_num_repeaters(pp) >= 1 &&
 					ax25_get_h(pp, AX25_REPEATER_1) > 0 {
 					tq_append(int(cmd.Header.Portx), TQ_PRIO_0_HI, pp)
@@ -1502,6 +1549,7 @@ func handleClientCommand(client int, cmd *AGWPEMessage) {
 			// Issue 527: NET/ROM routing broadcasts use PID 0xCF which was not preserved here.
 			ax25_set_pid(pp, pid)
 
+			pp.tx_reason = TX_REASON_CLIENT
 			tq_append(int(cmd.Header.Portx), TQ_PRIO_1_LO, pp)
 		}
 
@@ -1524,6 +1572,11 @@ func handleClientCommand(client int, cmd *AGWPEMessage) {
 			if cmd.Header.Portx < MAX_RADIO_CHANS {
 				// Count both normal and expedited in transmit queue for given channel.
 				n = tq_count(int(cmd.Header.Portx), -1, "", "", false)
+
+				// Audible responses wait their turn outside the transmit queue.
+				if response_queue != nil {
+					n += response_queue.Count(int(cmd.Header.Portx))
+				}
 			}
 
 			reply.Data = make([]byte, 4)
@@ -163,6 +163,27 @@ func TestHandleClientCommand_G_RadioChannelMono(t *testing.T) {
 	assert.Equal(t, "1;Port1 first soundcard mono;", string(reply.Data))
 }
 
+func TestHandleClientCommand_G_NamedChannels(t *testing.T) {
+	var cfg audio_s
+	cfg.chan_medium[0] = MEDIUM_RADIO
+	cfg.chan_medium[1] = MEDIUM_RADIO
+	cfg.adev[0].num_channels = 2
+	cfg.chan_name[0] = "VHF 144.39"
+	save_audio_config_p = &cfg
+	t.Cleanup(func() { save_audio_config_p = nil })
+
+	var client = setupClientPipe(t)
+	var replyCh = asyncReply(client)
+
+	var cmd = new(AGWPEMessage)
+	cmd.Header.DataKind = 'G'
+	handleClientCommand(0, cmd)
+
+	var reply = <-replyCh
+	require.NotNil(t, reply)
+	assert.Equal(t, "2;Port1 VHF 144.39;Port2 first soundcard right;", string(reply.Data))
+}
+
 func TestHandleClientCommand_y_EmptyQueueReturnsZero(t *testing.T) {
 	var client = setupClientPipe(t)
 	var replyCh = asyncReply(client)
@@ -181,6 +202,29 @@ func TestHandleClientCommand_y_EmptyQueueReturnsZero(t *testing.T) {
 	assert.Equal(t, uint32(0), binary.LittleEndian.Uint32(reply.Data))
 }
 
+func TestHandleClientCommand_y_CountsWaitingResponses(t *testing.T) {
+	var audio = new(audio_s)
+	audio.achan[0].response_max = 5
+	response_queue = NewResponseQueue(audio)
+	t.Cleanup(func() { response_queue = nil })
+
+	var pp = AX25FromText("APRSTT>MORSE:R", false)
+	require.NotNil(t, pp)
+	require.True(t, response_queue.Add(0, TQ_PRIO_0_HI, pp))
+
+	var client = setupClientPipe(t)
+	var replyCh = asyncReply(client)
+
+	var cmd = new(AGWPEMessage)
+	cmd.Header.DataKind = 'y'
+	handleClientCommand(0, cmd)
+
+	var reply = <-replyCh
+	require.NotNil(t, reply)
+	require.Len(t, reply.Data, 4)
+	assert.Equal(t, uint32(1), binary.LittleEndian.Uint32(reply.Data))
+}
+
 func TestHandleClientCommand_X_InvalidChannelReportsFailure(t *testing.T) {
 	var cfg audio_s
 	save_audio_config_p = &cfg
@@ -0,0 +1,249 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Spectrum of the receive audio for a waterfall display.
+ *
+ * Description:	While the HTTP server is enabled, the last
+ *		SPECTRUM_FFT_SIZE audio samples of each radio channel go
+ *		through an FFT a few times a second.  The result is
+ *		available as JSON:
+ *
+ *			{"time":"2024-05-06T12:34:56.789Z","channel":0,
+ *			 "bin_hz":43.07,"db":[-92,-95,...,-60,-58,...]}
+ *
+ *		db has the level, in dB relative to full scale, for
+ *		bins of bin_hz starting at 0 Hz, up to half the sample
+ *		rate.
+ *
+ *		GET /spectrum?channel=0 gives the latest.
+ *		ws://host:port/spectrum/stream?channel=0 sends each one
+ *		as it is made, for a waterfall.  Without channel,
+ *		all radio channels.
+ *
+ *		The FFT is done in the audio receive thread but it is
+ *		small and infrequent compared to demodulating.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"encoding/json"
+	"math"
+	"math/cmplx"
+	"net/http"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const SPECTRUM_FFT_SIZE = 1024 // Must be a power of 2.
+
+const SPECTRUM_PER_SECOND = 4
+
+const SPECTRUM_MIN_DB = -120
+
+type SpectrumRow struct {
+	Time    time.Time `json:"time"`
+	Channel int       `json:"channel"`
+	BinHz   float64   `json:"bin_hz"`
+	DB      []int     `json:"db"`
+}
+
+/* Used only by the audio receive thread for the channel. */
+
+type spectrumChannel struct {
+	samples [SPECTRUM_FFT_SIZE]float64 // Most recent, oldest at next.
+	next    int
+	count   int // Samples since the last row.
+}
+
+type spectrumService struct {
+	enabled    atomic.Bool
+	sampleRate [MAX_RADIO_CHANS]int
+
+	ch [MAX_RADIO_CHANS]spectrumChannel
+
+	mu     sync.Mutex
+	latest [MAX_RADIO_CHANS]*SpectrumRow
+
+	stream *packetStreamService
+}
+
+var spectrum = &spectrumService{stream: &packetStreamService{clients: make(map[*streamClient]bool)}} //nolint:exhaustruct
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        Enable
+ *
+ * Purpose:     Start making spectrum rows for radio channels.
+ *
+ * Inputs:	ac	- For sample rate of each channel.
+ *
+ *--------------------------------------------------------------------*/
+
+func (s *spectrumService) Enable(ac *audio_s) {
+	for ch := range MAX_RADIO_CHANS {
+		if ac.chan_medium[ch] == MEDIUM_RADIO {
+			s.sampleRate[ch] = ac.adev[ACHAN2ADEV(ch)].samples_per_sec
+		}
+	}
+
+	s.enabled.Store(true)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        Sample
+ *
+ * Purpose:     Take one audio sample.
+ *
+ * Inputs:	channel	- Radio channel.
+ *
+ *		sample	- Signed 16 bit audio sample.
+ *
+ * Description:	Does nothing, quickly, unless enabled.
+ *
+ *--------------------------------------------------------------------*/
+
+func (s *spectrumService) Sample(channel int, sample int) {
+	if !s.enabled.Load() || channel < 0 || channel >= MAX_RADIO_CHANS || s.sampleRate[channel] <= 0 {
+		return
+	}
+
+	var c = &s.ch[channel]
+
+	c.samples[c.next] = float64(sample) / 32768
+	c.next = (c.next + 1) % SPECTRUM_FFT_SIZE
+	c.count++
+
+	if c.count < s.sampleRate[channel]/SPECTRUM_PER_SECOND {
+		return
+	}
+
+	c.count = 0
+
+	var row = new(SpectrumRow)
+	row.Time = time.Now()
+	row.Channel = channel
+	row.BinHz = float64(s.sampleRate[channel]) / SPECTRUM_FFT_SIZE
+	row.DB = spectrum_db(c.samples[c.next:], c.samples[:c.next])
+
+	var msg, _ = json.Marshal(row)
+
+	s.mu.Lock()
+	s.latest[channel] = row
+	s.mu.Unlock()
+
+	s.stream.publish(channel, msg)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        spectrum_db
+ *
+ * Purpose:     Level in each frequency bin.
+ *
+ * Inputs:	older, newer	- SPECTRUM_FFT_SIZE samples, in two
+ *				  parts, from the ring buffer.
+ *
+ * Returns:	dB relative to full scale for SPECTRUM_FFT_SIZE/2 bins.
+ *
+ *--------------------------------------------------------------------*/
+
+func spectrum_db(older []float64, newer []float64) []int {
+	var n = len(older) + len(newer)
+	var x = make([]complex128, n)
+
+	/* Hann window to keep strong signals from spreading into other bins. */
+
+	for i := range n {
+		var v float64
+		if i < len(older) {
+			v = older[i]
+		} else {
+			v = newer[i-len(older)]
+		}
+
+		var w = 0.5 - 0.5*math.Cos(2*math.Pi*float64(i)/float64(n))
+		x[i] = complex(v*w, 0)
+	}
+
+	spectrum_fft(x)
+
+	var db = make([]int, n/2)
+
+	for i := range db {
+		// Full scale sine wave is 0 dB.  Window halves the amplitude.
+		var mag = 2 * cmplx.Abs(x[i]) / (float64(n) * 0.5)
+		var level = SPECTRUM_MIN_DB
+
+		if mag > 0 {
+			level = max(SPECTRUM_MIN_DB, int(math.Round(20*math.Log10(mag))))
+		}
+
+		db[i] = level
+	}
+
+	return db
+}
+
+/* In place radix 2 FFT.  len(x) must be a power of 2. */
+
+func spectrum_fft(x []complex128) {
+	var n = len(x)
+
+	/* Bit reversed order. */
+
+	for i, j := 1, 0; i < n; i++ {
+		var bit = n >> 1
+		for ; j&bit != 0; bit >>= 1 {
+			j ^= bit
+		}
+
+		j ^= bit
+
+		if i < j {
+			x[i], x[j] = x[j], x[i]
+		}
+	}
+
+	for size := 2; size <= n; size <<= 1 {
+		var step = cmplx.Exp(complex(0, -2*math.Pi/float64(size)))
+
+		for start := 0; start < n; start += size {
+			var w = complex(1, 0)
+
+			for k := range size / 2 {
+				var a = x[start+k]
+				var b = x[start+k+size/2] * w
+
+				x[start+k] = a + b
+				x[start+k+size/2] = a - b
+				w *= step
+			}
+		}
+	}
+}
+
+func (s *spectrumService) serveLatest(w http.ResponseWriter, r *http.Request) {
+	var ch, err = strconv.Atoi(r.URL.Query().Get("channel"))
+	if err != nil || ch < 0 || ch >= MAX_RADIO_CHANS {
+		http.Error(w, "channel must be a radio channel number", http.StatusBadRequest)
+
+		return
+	}
+
+	s.mu.Lock()
+	var row = s.latest[ch]
+	s.mu.Unlock()
+
+	if row == nil {
+		http.Error(w, "no spectrum for this channel", http.StatusNotFound)
+
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(row)
+}
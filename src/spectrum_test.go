@@ -0,0 +1,138 @@
+package direwolf
+
+import (
+	"encoding/json"
+	"math"
+	"math/cmplx"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/net/websocket"
+)
+
+func Test_spectrum_fft(t *testing.T) {
+	var x = []complex128{1, 2, 3, 4, 0, -1, 2, 0.5}
+
+	// Compare with the slow way.
+	var want = make([]complex128, len(x))
+	for k := range x {
+		for n, v := range x {
+			want[k] += v * cmplx.Exp(complex(0, -2*math.Pi*float64(k*n)/float64(len(x))))
+		}
+	}
+
+	spectrum_fft(x)
+
+	for k := range x {
+		assert.InDelta(t, real(want[k]), real(x[k]), 1e-9)
+		assert.InDelta(t, imag(want[k]), imag(x[k]), 1e-9)
+	}
+}
+
+func Test_spectrum_db(t *testing.T) {
+	// Half full scale at 1000 Hz, 8000 samples per second, is bin 128.
+	var samples = make([]float64, SPECTRUM_FFT_SIZE)
+	for i := range samples {
+		samples[i] = 0.5 * math.Sin(2*math.Pi*1000*float64(i)/8000)
+	}
+
+	var db = spectrum_db(samples[:100], samples[100:])
+	require.Len(t, db, SPECTRUM_FFT_SIZE/2)
+
+	assert.InDelta(t, -6, db[128], 1)
+	assert.Less(t, db[64], -40)
+	assert.Less(t, db[256], -40)
+
+	var quiet = spectrum_db(make([]float64, SPECTRUM_FFT_SIZE), nil)
+	assert.Equal(t, SPECTRUM_MIN_DB, quiet[0])
+}
+
+func spectrum_test_service(t *testing.T) *spectrumService {
+	t.Helper()
+
+	var ac, _ = configFromString(t, "ARATE 8000\nADEVICE plughw:1,0\nCHANNEL 0\nMYCALL Q1TEST\nMODEM 1200\n")
+
+	var s = &spectrumService{stream: &packetStreamService{clients: make(map[*streamClient]bool)}} //nolint:exhaustruct
+	s.Enable(ac)
+
+	return s
+}
+
+func Test_spectrum_service(t *testing.T) {
+	var s = spectrum_test_service(t)
+
+	var server = httptest.NewServer(http.HandlerFunc(s.serveLatest))
+	defer server.Close()
+
+	var resp, err = http.Get(server.URL + "?channel=0") //nolint:noctx
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode, "nothing yet")
+
+	resp, err = http.Get(server.URL + "?channel=x") //nolint:noctx
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	// A quarter second of 1000 Hz.
+	for i := range 8000 / SPECTRUM_PER_SECOND {
+		s.Sample(0, int(16384*math.Sin(2*math.Pi*1000*float64(i)/8000)))
+	}
+
+	resp, err = http.Get(server.URL + "?channel=0") //nolint:noctx
+	require.NoError(t, err)
+
+	defer resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	var row SpectrumRow
+	require.NoError(t, json.NewDecoder(resp.Body).Decode(&row))
+	assert.Equal(t, 0, row.Channel)
+	assert.InDelta(t, 8000.0/SPECTRUM_FFT_SIZE, row.BinHz, 1e-9)
+	require.Len(t, row.DB, SPECTRUM_FFT_SIZE/2)
+	assert.InDelta(t, -6, row.DB[128], 1)
+}
+
+func Test_spectrum_stream(t *testing.T) {
+	var s = spectrum_test_service(t)
+
+	var server = httptest.NewServer(s.stream.handler(nil))
+	defer server.Close()
+
+	var ws, err = websocket.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"?channel=0", "", server.URL)
+	require.NoError(t, err)
+
+	defer ws.Close()
+
+	require.Eventually(t, func() bool { return s.stream.count() == 1 }, 5*time.Second, 10*time.Millisecond)
+
+	for range 8000 / SPECTRUM_PER_SECOND {
+		s.Sample(0, 0)
+	}
+
+	var msg string
+
+	require.NoError(t, ws.SetReadDeadline(time.Now().Add(5*time.Second)))
+	require.NoError(t, websocket.Message.Receive(ws, &msg))
+
+	var row SpectrumRow
+	require.NoError(t, json.Unmarshal([]byte(msg), &row))
+	assert.Equal(t, SPECTRUM_MIN_DB, row.DB[0])
+}
+
+func Test_spectrum_disabled(t *testing.T) {
+	var s = &spectrumService{stream: &packetStreamService{clients: make(map[*streamClient]bool)}} //nolint:exhaustruct
+
+	for range 100000 {
+		s.Sample(0, 1000)
+	}
+
+	assert.Nil(t, s.latest[0])
+	assert.Zero(t, s.ch[0].count)
+}
@@ -0,0 +1,183 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Text to speech without writing a script.
+ *
+ * Description:	SPEECH  ESPEAK  [ voice ]
+ *
+ *		Rather than running a script which plays the speech on
+ *		some sound device of its own choosing, we run espeak-ng
+ *		(or the older espeak) with --stdout to get a WAV file
+ *		and send it through the channel's own audio output, the
+ *		same way as Morse code.  It goes out the right sound card,
+ *		on the right side of a stereo pair, at our usual amplitude,
+ *		and we know how long to hold PTT.
+ *
+ *		If no speech is configured, or espeak can't be run,
+ *		the text is sent as Morse code instead so the user
+ *		still hears something.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+/* Special value for the SPEECH command instead of a script. */
+
+const SPEECH_ESPEAK = "ESPEAK"
+
+/* Programs to look for, in order of preference. */
+
+var speech_espeak_names = []string{"espeak-ng", "espeak"}
+
+/* Amplitude, 0 .. 100, as for morse_init. */
+
+var speech_amp = 50
+
+func speech_init(amp int) {
+	speech_amp = amp
+}
+
+/* Full path of espeak-ng or espeak, or empty if neither is installed. */
+
+func speech_espeak_find() string {
+	for _, name := range speech_espeak_names {
+		var path, err = exec.LookPath(name)
+		if err == nil {
+			return path
+		}
+	}
+
+	return ""
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	speech_espeak
+ *
+ * Purpose:	Get speech for some text.
+ *
+ * Inputs:	prog	- espeak-ng or espeak.
+ *		voice	- e.g. "en-us", or empty for the default.
+ *		text	- What to say.
+ *
+ * Returns:	Samples and the sample rate, which is 22050 for espeak.
+ *
+ *--------------------------------------------------------------------*/
+
+func speech_espeak(prog string, voice string, text string) ([]int16, int, error) {
+	var args = []string{"--stdout"}
+	if voice != "" {
+		args = append(args, "-v", voice)
+	}
+
+	// Don't let the text be taken as an option.
+	args = append(args, strings.TrimLeft(text, "-"))
+
+	var wav, err = exec.Command(prog, args...).Output() //nolint:gosec // Trust the user-supplied config
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", prog, err)
+	}
+
+	return speech_parse_wav(wav)
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	speech_parse_wav
+ *
+ * Purpose:	Get the samples out of a 16 bit PCM WAV file.
+ *
+ * Description:	Only the first channel is kept.
+ *		Writing to a pipe, espeak can't go back to fill in the
+ *		lengths so the data chunk may claim to be larger than
+ *		what follows.  Take whatever is there.
+ *
+ *--------------------------------------------------------------------*/
+
+func speech_parse_wav(b []byte) ([]int16, int, error) {
+	var r = bytes.NewReader(b)
+
+	var wf, size, err = wav_read_header(r)
+	if err != nil {
+		return nil, 0, fmt.Errorf("speech: %w", err)
+	}
+
+	if wf.format != WAV_FORMAT_PCM || wf.bits != 16 || wf.channels < 1 {
+		return nil, 0, fmt.Errorf("WAV format %d with %d bits per sample, not 16 bit PCM", wf.format, wf.bits)
+	}
+
+	var data = b[len(b)-r.Len():]
+	data = data[:min(int(size), len(data))]
+
+	var frame = 2 * wf.channels
+	var samples = make([]int16, len(data)/frame)
+
+	for i := range samples {
+		samples[i] = int16(binary.LittleEndian.Uint16(data[i*frame:])) //nolint:gosec
+	}
+
+	return samples, wf.rate, nil
+}
+
+/* Change sample rate by straight line interpolation.  Good enough for speech. */
+
+func speech_resample(in []int16, from int, to int) []int16 {
+	if from == to || len(in) == 0 {
+		return in
+	}
+
+	var out = make([]int16, int64(len(in))*int64(to)/int64(from))
+
+	for i := range out {
+		var pos = float64(i) * float64(from) / float64(to)
+		var j = int(pos)
+		var frac = pos - float64(j)
+
+		var next = in[min(j+1, len(in)-1)]
+
+		out[i] = int16(float64(in[j])*(1-frac) + float64(next)*frac)
+	}
+
+	return out
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	speech_send
+ *
+ * Purpose:	Send speech out through the audio device for a channel.
+ *
+ * Inputs:	channel	- Radio channel.
+ *		samples	- Speech, at rate.
+ *		txdelay	- Quiet time (ms) after PTT on.
+ *		txtail	- Quiet time (ms) before PTT off.
+ *
+ * Returns:	Total number of milliseconds to hold PTT, as for morse_send.
+ *
+ *--------------------------------------------------------------------*/
+
+func speech_send(channel int, samples []int16, rate int, txdelay int, txtail int) int {
+	var a = ACHAN2ADEV(channel)
+	var out_rate = save_audio_config_p.adev[a].samples_per_sec
+
+	gen_tone_put_quiet_ms(channel, txdelay)
+
+	for _, s := range speech_resample(samples, rate, out_rate) {
+		gen_tone_put_sample(channel, a, int(s)*speech_amp/100)
+	}
+
+	gen_tone_put_quiet_ms(channel, txtail)
+
+	audio_flush(a)
+
+	var speech_ms = int64(len(samples)) * 1000 / int64(rate)
+
+	return txdelay + int(speech_ms) + txtail
+}
@@ -0,0 +1,56 @@
+package direwolf
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_speech_parse_wav(t *testing.T) {
+	var samples, rate, err = speech_parse_wav(wavTestFile(WAV_FORMAT_PCM, 1, 16, 22050, 8, wavTestSamples16(1, -2, 3000, -32768)))
+	require.NoError(t, err)
+	assert.Equal(t, 22050, rate)
+	assert.Equal(t, []int16{1, -2, 3000, -32768}, samples)
+
+	// Written to a pipe, the length isn't known.
+	samples, _, err = speech_parse_wav(wavTestFile(WAV_FORMAT_PCM, 1, 16, 22050, 0x7ffff000, wavTestSamples16(5, 6, 7)))
+	require.NoError(t, err)
+	assert.Equal(t, []int16{5, 6, 7}, samples)
+
+	// Only the left channel of stereo.
+	samples, rate, err = speech_parse_wav(wavTestFile(WAV_FORMAT_PCM, 2, 16, 44100, 8, wavTestSamples16(1, 100, 2, 200)))
+	require.NoError(t, err)
+	assert.Equal(t, 44100, rate)
+	assert.Equal(t, []int16{1, 2}, samples)
+
+	_, _, err = speech_parse_wav([]byte("not a WAV file at all"))
+	require.Error(t, err)
+
+	var wav = wavTestFile(WAV_FORMAT_PCM, 1, 16, 8000, 2, wavTestSamples16(1))
+	wav[34] = 8 // 8 bits per sample.
+	_, _, err = speech_parse_wav(wav)
+	require.Error(t, err)
+}
+
+func Test_speech_resample(t *testing.T) {
+	assert.Equal(t, []int16{1, 2, 3}, speech_resample([]int16{1, 2, 3}, 8000, 8000))
+
+	assert.Equal(t, []int16{0, 50, 100, 150, 200, 200}, speech_resample([]int16{0, 100, 200}, 22050, 44100))
+
+	assert.Equal(t, []int16{0, 200, 400}, speech_resample([]int16{0, 100, 200, 300, 400, 500}, 44100, 22050))
+
+	assert.Len(t, speech_resample(make([]int16, 22050), 22050, 48000), 48000)
+}
+
+func Test_speech_espeak(t *testing.T) {
+	var prog = speech_espeak_find()
+	if prog == "" {
+		t.Skip("espeak-ng not installed")
+	}
+
+	var samples, rate, err = speech_espeak(prog, "", "Hello")
+	require.NoError(t, err)
+	assert.Positive(t, rate)
+	assert.NotEmpty(t, samples)
+}
@@ -9,3 +9,19 @@ func Test_Text2TT(t *testing.T) {
 	AssertOutputContains(t, func() { Text2TT([]string{"abcdefg", "0123"}) }, "2A22A2223A33A33340A00122223333")
 	AssertOutputContains(t, func() { Text2TT([]string{"abcdefg", "0123"}) }, "2A2B2C3A3B3C4A0A0123")
 }
+
+func Test_Text2TT_location(t *testing.T) {
+	for _, tc := range tt_location_test_cases {
+		if tc.mhead != "" {
+			AssertOutputContains(t, func() { Text2TT([]string{tc.mhead}) }, "Maidenhead Grid Square Locator:\n\""+tc.buttons+"\"")
+		}
+
+		if tc.satsq != "" {
+			AssertOutputContains(t, func() { Text2TT([]string{tc.satsq}) }, "satellite gridsquare:\n\""+tc.buttons+"\"")
+		}
+	}
+}
+
+func Test_Text2TT_call10(t *testing.T) {
+	AssertOutputContains(t, func() { Text2TT([]string{"Q1TEST"}) }, "10 digit callsign:\n\"1183781133\"")
+}
@@ -20,7 +20,9 @@ func TextColorInit(level int) {
 	_text_color_level = level
 }
 
-func text_color_set(_ dw_color_e) {
+func text_color_set(c dw_color_e) {
+	log_color_set(c)
+
 	if _text_color_level == 0 {
 		return
 	}
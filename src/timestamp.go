@@ -0,0 +1,92 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Time stamps for the monitor output and log files.
+ *
+ * Description:	The monitor output and the log files are set
+ *		separately, since it is common to want local time on
+ *		the screen but UTC in files for later processing.
+ *
+ *		--monitor-time off|local|utc
+ *
+ *			Precede sent and received frames with the time.
+ *			ISO 8601 unless -T gives a strftime format.
+ *			-T by itself is local time, as before.
+ *
+ *		--log-time utc|local
+ *
+ *			For the isotime column and daily file names of
+ *			the -l or -L log, and the --tnc2-log time.
+ *			UTC by default, as before.
+ *
+ *		ISO 8601 is "2024-05-06T12:00:00Z" for UTC, or with the
+ *		offset, e.g. "2024-05-06T08:00:00-04:00", for local time.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/lestrrat-go/strftime"
+)
+
+const ISO_8601 = "2006-01-02T15:04:05Z07:00"
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	timestamp_location
+ *
+ * Purpose:	Time zone from --monitor-time or --log-time.
+ *
+ * Inputs:	name	- "utc", "local", or "off".  Case doesn't matter.
+ *
+ * Returns:	time.UTC or time.Local, or nil for "off".
+ *
+ *---------------------------------------------------------------*/
+
+func timestamp_location(name string) (*time.Location, error) {
+	switch strings.ToLower(name) {
+	case "utc":
+		return time.UTC, nil
+	case "local":
+		return time.Local, nil
+	case "off":
+		return nil, nil //nolint:nilnil // nil is no time stamp.
+	default:
+		return nil, fmt.Errorf("time zone must be utc, local, or off, not %q", name)
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	monitor_timestamp
+ *
+ * Purpose:	Time stamp to go after the channel number of a sent
+ *		or received frame.
+ *
+ * Inputs:	ac	- timestamp_location and timestamp_format.
+ *
+ *		t	- Time.
+ *
+ * Returns:	Time stamp with a leading space, or "" for none.
+ *
+ *---------------------------------------------------------------*/
+
+func monitor_timestamp(ac *audio_s, t time.Time) string {
+	if ac.timestamp_location == nil {
+		return ""
+	}
+
+	t = t.In(ac.timestamp_location)
+
+	if ac.timestamp_format == "" {
+		return " " + t.Format(ISO_8601)
+	}
+
+	var formattedTime, _ = strftime.Format(ac.timestamp_format, t)
+
+	return " " + formattedTime
+}
@@ -0,0 +1,45 @@
+package direwolf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_timestamp_location(t *testing.T) {
+	var loc, err = timestamp_location("UTC")
+	require.NoError(t, err)
+	assert.Equal(t, time.UTC, loc)
+
+	loc, err = timestamp_location("local")
+	require.NoError(t, err)
+	assert.Equal(t, time.Local, loc)
+
+	loc, err = timestamp_location("off")
+	require.NoError(t, err)
+	assert.Nil(t, loc)
+
+	_, err = timestamp_location("EST")
+	require.Error(t, err)
+}
+
+func Test_monitor_timestamp(t *testing.T) {
+	var ac = new(audio_s)
+	var when = time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+
+	assert.Empty(t, monitor_timestamp(ac, when))
+
+	ac.timestamp_location = time.UTC
+	assert.Equal(t, " 2024-05-06T12:00:00Z", monitor_timestamp(ac, when))
+
+	ac.timestamp_location = time.FixedZone("EDT", -4*3600)
+	assert.Equal(t, " 2024-05-06T08:00:00-04:00", monitor_timestamp(ac, when))
+
+	ac.timestamp_format = "%H:%M:%S"
+	assert.Equal(t, " 08:00:00", monitor_timestamp(ac, when))
+
+	ac.timestamp_location = time.UTC
+	assert.Equal(t, " 12:00:00", monitor_timestamp(ac, when))
+}
@@ -0,0 +1,133 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Save every received frame in TNC2 monitor format.
+ *
+ * Description:	"direwolf --tnc2-log file" appends one line per frame:
+ *
+ *			2024-05-06T12:00:00Z Q1TEST>APDW18,WIDE1-1*:>Hello
+ *
+ *		The time is ISO 8601, UTC unless --log-time local.  The rest is the usual TNC2
+ *		monitor format, with anything unprintable shown as <0x0d>
+ *		etc. so each frame stays on one line.  This is what many
+ *		analysis tools expect, rather than the CSV log from -l
+ *		or -L, and it includes frames which are not APRS.
+ *
+ *		The file is opened for append so an external logrotate
+ *		with "copytruncate" can be used to limit the size.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+)
+
+type Tnc2Logger struct {
+	mu       sync.Mutex
+	w        io.Writer
+	f        *os.File       // nil if not writing to a file.
+	err      error          // First write error.  Nothing more is written after.
+	location *time.Location // UTC unless SetLocation.
+}
+
+var tnc2Logger *Tnc2Logger
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	NewTnc2Logger
+ *
+ * Purpose:	Open the log file.
+ *
+ * Inputs:	path	- File name.  Appended to if it already exists.
+ *
+ *---------------------------------------------------------------*/
+
+func NewTnc2Logger(path string) (*Tnc2Logger, error) {
+	var f, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gosec // Named on the command line.
+	if err != nil {
+		return nil, err
+	}
+
+	var tl = new_tnc2_logger(f)
+	tl.f = f
+
+	return tl, nil
+}
+
+func new_tnc2_logger(w io.Writer) *Tnc2Logger {
+	return &Tnc2Logger{ //nolint:exhaustruct
+		w:        w,
+		location: time.UTC,
+	}
+}
+
+/* Time zone for the time at the start of each line.  time.UTC or time.Local. */
+
+func (tl *Tnc2Logger) SetLocation(loc *time.Location) {
+	if tl == nil {
+		return
+	}
+
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	tl.location = loc
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Write
+ *
+ * Purpose:	Add one received frame to the log.
+ *
+ * Inputs:	t	- When it was received.
+ *
+ *		pp	- Received packet.
+ *
+ * Description:	Safe to call on a nil Tnc2Logger, which does nothing,
+ *		so callers don't need to check whether it is enabled.
+ *
+ *---------------------------------------------------------------*/
+
+func (tl *Tnc2Logger) Write(t time.Time, pp *packet_t) {
+	if tl == nil {
+		return
+	}
+
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	if tl.err != nil {
+		return
+	}
+
+	_, tl.err = fmt.Fprintf(tl.w, "%s %s%s\n",
+		t.In(tl.location).Format(ISO_8601),
+		AX25FormatAddrs(pp),
+		ax25_safe_string(AX25GetInfo(pp), false))
+
+	if tl.err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Error writing TNC2 log file: %s\n", tl.err)
+		dw_printf("No more frames will be saved.\n")
+	}
+}
+
+func (tl *Tnc2Logger) Close() {
+	if tl == nil {
+		return
+	}
+
+	tl.mu.Lock()
+	defer tl.mu.Unlock()
+
+	if tl.f != nil {
+		tl.f.Close()
+		tl.f = nil
+	}
+}
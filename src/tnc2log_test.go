@@ -0,0 +1,95 @@
+package direwolf
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tnc2_logger(t *testing.T) {
+	var pp = AX25FromText("Q1TEST>APDW18,Q2TEST*,WIDE1-1:>Hello<0x0d>", true)
+	require.NotNil(t, pp)
+
+	var buf bytes.Buffer
+	var tl = new_tnc2_logger(&buf)
+
+	var when = time.Date(2024, 5, 6, 8, 0, 0, 0, time.FixedZone("EDT", -4*3600))
+	tl.Write(when, pp)
+
+	assert.Equal(t, "2024-05-06T12:00:00Z Q1TEST>APDW18,Q2TEST*,WIDE1-1:>Hello<0x0d>\n", buf.String())
+
+	// Can be read back.
+	var line = buf.String()[len("2024-05-06T12:00:00Z ") : buf.Len()-1]
+	var back = AX25FromText(line, true)
+	require.NotNil(t, back)
+	assert.Equal(t, AX25GetInfo(pp), AX25GetInfo(back))
+}
+
+func Test_tnc2_logger_local(t *testing.T) {
+	var pp = AX25FromText("Q1TEST>APDW18:>Hello", true)
+	require.NotNil(t, pp)
+
+	var buf bytes.Buffer
+	var tl = new_tnc2_logger(&buf)
+	tl.SetLocation(time.FixedZone("EDT", -4*3600))
+
+	tl.Write(time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC), pp)
+
+	assert.Equal(t, "2024-05-06T08:00:00-04:00 Q1TEST>APDW18:>Hello\n", buf.String())
+}
+
+func Test_tnc2_logger_nil(t *testing.T) {
+	var tl *Tnc2Logger
+
+	var pp = AX25FromText("Q1TEST>APDW18:>Hello", true)
+	require.NotNil(t, pp)
+
+	// All safe when not enabled.
+	tl.SetLocation(time.Local)
+	tl.Write(time.Now(), pp)
+	tl.Close()
+}
+
+func Test_tnc2_logger_file(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "rx.log")
+	require.NoError(t, os.WriteFile(path, []byte("earlier\n"), 0o600))
+
+	var pp = AX25FromText("Q1TEST>APDW18:>Hello", true)
+	require.NotNil(t, pp)
+
+	var tl, err = NewTnc2Logger(path)
+	require.NoError(t, err)
+
+	tl.Write(time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC), pp)
+	tl.Close()
+
+	var data, readErr = os.ReadFile(path) //nolint:gosec
+	require.NoError(t, readErr)
+	assert.Equal(t, "earlier\n2024-05-06T12:00:00Z Q1TEST>APDW18:>Hello\n", string(data), "appended")
+}
+
+type tnc2FailingWriter struct{}
+
+func (tnc2FailingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func Test_tnc2_logger_error(t *testing.T) {
+	var pp = AX25FromText("Q1TEST>APDW18:>Hello", true)
+	require.NotNil(t, pp)
+
+	var tl = new_tnc2_logger(tnc2FailingWriter{})
+
+	tl.Write(time.Now(), pp)
+	assert.Error(t, tl.err)
+
+	tl.w = new(bytes.Buffer)
+	tl.Write(time.Now(), pp)
+	assert.Zero(t, tl.w.(*bytes.Buffer).Len(), "nothing more after an error")
+}
@@ -19,8 +19,6 @@ package direwolf
 import (
 	"sync"
 	"time"
-
-	"github.com/lestrrat-go/strftime"
 )
 
 const TQ_NUM_PRIO = 2 /* Number of priorities. */
@@ -176,12 +174,7 @@ func tq_append(channel int, prio int, pp *packet_t) {
 
 	if save_audio_config_p.chan_medium[channel] == MEDIUM_IGATE ||
 		save_audio_config_p.chan_medium[channel] == MEDIUM_NETTNC {
-		var ts string // optional time stamp.
-
-		if save_audio_config_p.timestamp_format != "" {
-			var formattedTime, _ = strftime.Format(save_audio_config_p.timestamp_format, time.Now())
-			ts = " " + formattedTime // space after channel.
-		}
+		var ts = monitor_timestamp(save_audio_config_p, time.Now()) // optional time stamp.
 
 		// Formated addresses.
 		var stemp = AX25FormatAddrs(pp)
@@ -190,14 +183,14 @@ func tq_append(channel int, prio int, pp *packet_t) {
 		text_color_set(DW_COLOR_XMIT)
 
 		if save_audio_config_p.chan_medium[channel] == MEDIUM_IGATE {
-			dw_printf("[%d>is%s] ", channel, ts)
+			dw_printf("[%d>is%s%s] ", channel, chan_name_suffix(save_audio_config_p, channel), ts)
 			dw_printf("%s", stemp) /* stations followed by : */
 			AX25SafePrint(pinfo, !ax25_is_aprs(pp))
 			dw_printf("\n")
 
 			igate_send_rec_packet(channel, pp)
 		} else { // network TNC
-			dw_printf("[%d>nt%s] ", channel, ts)
+			dw_printf("[%d>nt%s%s] ", channel, chan_name_suffix(save_audio_config_p, channel), ts)
 			dw_printf("%s", stemp) /* stations followed by : */
 			AX25SafePrint(pinfo, !ax25_is_aprs(pp))
 			dw_printf("\n")
@@ -227,6 +220,12 @@ func tq_append(channel int, prio int, pp *packet_t) {
 		return
 	}
 
+	// Speech, Morse code and touch tones wait their turn in the response queue.
+
+	if response_queue != nil && response_queue.Add(channel, prio, pp) {
+		return
+	}
+
 	/*
 	 * Is transmit queue out of control?
 	 *
@@ -254,6 +253,11 @@ func tq_append(channel int, prio int, pp *packet_t) {
 		text_color_set(DW_COLOR_ERROR)
 		dw_printf("Transmit packet queue for channel %d is too long.  Discarding packet.\n", channel)
 		dw_printf("Perhaps the channel is so busy there is no opportunity to send.\n")
+
+		if pp.response {
+			response_queue.Done(channel)
+		}
+
 		AX25Delete(pp)
 
 		return
@@ -396,6 +400,8 @@ func lm_data_request(channel int, prio int, pp *packet_t) {
 		return
 	}
 
+	pp.tx_reason = TX_REASON_CLIENT // Connected mode, for an AGW or KISS client.
+
 	/* TODO KG
 	#if AX25MEMDEBUG
 
@@ -759,6 +765,36 @@ func tq_remove(channel int, prio int) *packet_t {
 	return (result_p)
 } /* end tq_remove */
 
+/*-------------------------------------------------------------------
+ *
+ * Name:        tq_flush
+ *
+ * Purpose:     Throw away everything waiting to be sent on a channel.
+ *
+ * Inputs:	channel	- Channel, 0 is first.
+ *
+ * Returns:	Number of frames discarded.
+ *
+ *--------------------------------------------------------------------*/
+
+func tq_flush(channel int) int {
+	tq_mutex.Lock()
+	defer tq_mutex.Unlock()
+
+	var n = 0
+
+	for prio := range TQ_NUM_PRIO {
+		for queue_head[channel][prio] != nil {
+			var pp = queue_head[channel][prio]
+			queue_head[channel][prio] = ax25_get_nextp(pp)
+			AX25Delete(pp)
+			n++
+		}
+	}
+
+	return n
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        tq_peek
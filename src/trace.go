@@ -0,0 +1,59 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Follow one received frame through everything done with it.
+ *
+ * Description:	Each received frame gets an ID.  Copies, such as those
+ *		made for digipeating, keep the same ID.  With the --trace
+ *		option, each decision about the frame is printed with
+ *		its ID, e.g.
+ *
+ *			trace 42: received on channel 0, Q1TEST>APDW18,WIDE1-1:
+ *			trace 42: sent to 1 AGW, 2 KISS TCP, 0 WebSocket clients
+ *			trace 42: IGate: sent to server
+ *			trace 42: APRS digipeater: to channel 0, high priority
+ *			trace 42: transmitted on channel 0
+ *
+ *		so "grep 'trace 42:'" shows the whole story of that frame.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+var traceEnabled bool
+
+var traceLastID atomic.Uint64
+
+/* Give a received frame an ID, unless it already has one. */
+
+func trace_assign(pp *packet_t) {
+	if pp.trace_id == 0 {
+		pp.trace_id = traceLastID.Add(1)
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        trace_printf
+ *
+ * Purpose:     Print one step for a frame, if tracing is enabled.
+ *
+ * Inputs:	pp	- Frame.  Nothing is printed if it has no ID,
+ *			  e.g. one we made ourselves.
+ *
+ *		format	- Like printf.  Newline is added.
+ *
+ *--------------------------------------------------------------------*/
+
+func trace_printf(pp *packet_t, format string, a ...any) {
+	if !traceEnabled || pp == nil || pp.trace_id == 0 {
+		return
+	}
+
+	text_color_set(DW_COLOR_DEBUG)
+	dw_printf("trace %d: %s\n", pp.trace_id, fmt.Sprintf(format, a...))
+}
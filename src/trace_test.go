@@ -0,0 +1,70 @@
+package direwolf
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_trace_assign(t *testing.T) {
+	var pp1 = AX25FromText("Q1TEST>APDW18,WIDE1-1:>one", true)
+	var pp2 = AX25FromText("Q2TEST>APDW18:}Q3TEST>APRS,TCPIP,Q2TEST*:>two", true)
+	require.NotNil(t, pp1)
+	require.NotNil(t, pp2)
+
+	assert.Zero(t, pp1.trace_id, "none until received")
+
+	trace_assign(pp1)
+	trace_assign(pp2)
+	assert.NotZero(t, pp1.trace_id)
+	assert.NotEqual(t, pp1.trace_id, pp2.trace_id)
+
+	var id = pp1.trace_id
+	trace_assign(pp1)
+	assert.Equal(t, id, pp1.trace_id, "kept if already assigned")
+
+	var copied = ax25_dup(pp1)
+	assert.Equal(t, pp1.trace_id, copied.trace_id, "copies for digipeating keep it")
+
+	var inner = ax25_unwrap_third_party(pp2)
+	require.NotNil(t, inner)
+	assert.Equal(t, pp2.trace_id, inner.trace_id, "third party payload keeps it")
+}
+
+func Test_trace_printf(t *testing.T) {
+	var pp = AX25FromText("Q1TEST>APDW18:>hello", true)
+	require.NotNil(t, pp)
+
+	var buf bytes.Buffer
+
+	var levels, _ = log_parse_levels(nil, "", "")
+	structuredLog.Store(new_dw_logger(&buf, levels))
+
+	t.Cleanup(func() {
+		structuredLog.Store(nil)
+		traceEnabled = false
+	})
+
+	traceEnabled = true
+	trace_printf(pp, "nothing without an ID")
+	assert.Empty(t, buf.String())
+
+	trace_assign(pp)
+
+	traceEnabled = false
+	trace_printf(pp, "nothing unless enabled")
+	assert.Empty(t, buf.String())
+
+	traceEnabled = true
+	trace_printf(pp, "IGate: sent to server")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "trace "+strconv.FormatUint(pp.trace_id, 10)+": IGate: sent to server", record["msg"])
+	assert.Equal(t, slog.LevelDebug.String(), record["level"])
+}
@@ -9,3 +9,15 @@ func Test_TT2Text(t *testing.T) {
 	AssertOutputContains(t, func() { TT2Text("2A22A2223A33A33340A00122223333") }, "ABCDEFG 0123")
 	AssertOutputContains(t, func() { TT2Text("2A22A2223A33A33340A00122223333") }, "A2A222D3D3334 00122223333")
 }
+
+func Test_TT2Text_location(t *testing.T) {
+	for _, tc := range tt_location_test_cases {
+		if tc.mhead != "" {
+			AssertOutputContains(t, func() { TT2Text(tc.buttons) }, "Maidenhead Locator from DTMF digits:\n\""+tc.mhead+"\"")
+		}
+
+		if tc.satsq != "" {
+			AssertOutputContains(t, func() { TT2Text(tc.buttons) }, "satellite gridsquare from 4 DTMF digits:\n\""+tc.satsq+"\"")
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package direwolf
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/doismellburning/samoyed/src/geo"
+	"github.com/stretchr/testify/assert"
+	"pgregory.net/rapid"
+)
+
+/*
+ * Maidenhead Locator and satellite gridsquare tone sequences, as used by
+ * TTMHEAD and TTSATSQ.  Shared with the tt2text test.  An empty string
+ * means the buttons are not valid for that form.
+ */
+
+var tt_location_test_cases = []struct {
+	buttons string
+	mhead   string
+	satsq   string
+}{
+	{"326129723278", "EM29QE78", ""},
+	{"336119", "FM19", ""},
+	{"3361", "FM", "FH61"},
+	{"1819", "", "FM19"},
+	{"9619", "", "JG19"},
+	{"3361197", "", ""},
+	{"18A9", "", ""},
+}
+
+func Test_TTText_location(t *testing.T) {
+	for _, tc := range tt_location_test_cases {
+		var text, errs = tt_mhead_to_text(tc.buttons, true)
+		assert.Equal(t, tc.mhead, text, "Unexpected Maidenhead value for buttons %s", tc.buttons)
+		assert.Equal(t, tc.mhead == "", errs != 0, tc.buttons)
+
+		text, errs = tt_satsq_to_text(tc.buttons, true)
+		assert.Equal(t, tc.satsq, text, "Unexpected SatSq value for buttons %s", tc.buttons)
+		assert.Equal(t, tc.satsq == "", errs != 0, tc.buttons)
+
+		// And back again.
+
+		if tc.mhead != "" {
+			var buttons, _ = tt_text_to_mhead(tc.mhead, true)
+			assert.Equal(t, tc.buttons, buttons, tc.mhead)
+		}
+
+		if tc.satsq != "" {
+			var buttons, _ = tt_text_to_satsq(tc.satsq, true)
+			assert.Equal(t, tc.buttons, buttons, tc.satsq)
+		}
+	}
+}
+
+/*
+ * Round trips.  Anything that can be encoded should decode to the same
+ * text, allowing for upper case.
+ */
+
+func Test_TTText_multipress_round_trip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		var text = rapid.StringMatching(`[A-Z0-9 ]{1,20}`).Draw(t, "text")
+
+		var buttons, errs = tt_text_to_multipress(text, true)
+		assert.Zero(t, errs)
+
+		var back, _ = tt_multipress_to_text(buttons, true)
+		assert.Equal(t, text, back, buttons)
+	})
+}
+
+func Test_TTText_two_key_round_trip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		var text = rapid.StringMatching(`[A-Za-z0-9]{1,20}`).Draw(t, "text")
+
+		var buttons, errs = tt_text_to_two_key(text, true)
+		assert.Zero(t, errs)
+
+		var back, _ = tt_two_key_to_text(buttons, true)
+		assert.Equal(t, strings.ToUpper(text), back, buttons)
+	})
+}
+
+func Test_TTText_call10_round_trip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		var call = rapid.StringMatching(`[A-Z0-9]{1,6}`).Draw(t, "call")
+
+		var buttons, errs = tt_text_to_call10(call, true)
+		assert.Zero(t, errs)
+		assert.Len(t, buttons, 10)
+
+		var back, _ = tt_call10_to_text(buttons, true)
+		assert.Equal(t, call, back, buttons)
+	})
+}
+
+func Test_TTText_mhead_round_trip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		var lat = rapid.Float64Range(-89.99, 89.99).Draw(t, "lat")
+		var lon = rapid.Float64Range(-179.99, 179.99).Draw(t, "lon")
+		var chars = rapid.SampledFrom([]int{2, 4, 6, 8, 10, 12}).Draw(t, "chars")
+
+		var locator, err = geo.ToGridSquare(lat, lon, chars)
+		assert.NoError(t, err)
+
+		var buttons, errs = tt_text_to_mhead(locator, true)
+		assert.Zero(t, errs, locator)
+
+		var back, _ = tt_mhead_to_text(buttons, true)
+		assert.Equal(t, locator, back, buttons)
+	})
+}
+
+func Test_TTText_satsq_round_trip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		var buttons = rapid.StringMatching(`[0-9]{4}`).Draw(t, "buttons")
+
+		var text, errs = tt_satsq_to_text(buttons, true)
+		assert.Zero(t, errs)
+
+		var back, _ = tt_text_to_satsq(text, true)
+		assert.Equal(t, buttons, back, text)
+	})
+}
+
+func Test_TTText_ascii2d_round_trip(t *testing.T) {
+	rapid.Check(t, func(t *rapid.T) {
+		var text = rapid.StringMatching(`[ -~]{1,20}`).Draw(t, "text")
+
+		var buttons, errs = tt_text_to_ascii2d(text, true)
+		assert.Zero(t, errs)
+
+		var back, _ = tt_ascii2d_to_text(buttons, true)
+		assert.Equal(t, text, back, buttons)
+	})
+}
@@ -0,0 +1,396 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Full screen monitor, for "direwolf --tui".
+ *
+ * Description:	Normally everything is printed as one interleaved
+ *		stream, which gets hard to follow with two or more
+ *		radio channels.  Instead, the screen is divided into:
+ *
+ *		- A pane for each radio channel, with the frames
+ *		  received and transmitted there.
+ *		- Messages which aren't for any one channel.
+ *		- Audio level and transmit queue for each channel.
+ *		- Stations heard, most recent first.
+ *
+ *		Output is still produced with dw_printf everywhere.
+ *		Standard output is redirected to a pipe and each line
+ *		is sent to a pane by the "[0]", "[1.2]", "[0L]", ...
+ *		prefix that identifies its channel.  A line without a
+ *		prefix, such as the APRS description, goes with the
+ *		line before it.  The "audio level" line comes just
+ *		before the frame it applies to, so it is held until
+ *		we know which channel that is.
+ *
+ *		Only plain ANSI escape sequences are used, which any
+ *		terminal emulator handles.  The screen is redrawn
+ *		twice a second.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/sys/unix"
+)
+
+const TUI_MAX_LINES = 500 /* Kept for each pane. */
+
+const TUI_REFRESH = 500 * time.Millisecond
+
+type tuiPane struct {
+	title string
+	lines []string
+}
+
+func (p *tuiPane) add(line string) {
+	p.lines = append(p.lines, line)
+
+	if len(p.lines) > TUI_MAX_LINES {
+		p.lines = p.lines[len(p.lines)-TUI_MAX_LINES:]
+	}
+}
+
+type tuiMonitor struct {
+	mu sync.Mutex
+
+	channels []int /* Radio channels shown, in order. */
+	panes    map[int]*tuiPane
+	other    *tuiPane
+
+	last    int    /* Channel of previous line, or -1 for other. */
+	pending string /* Audio level line waiting for its frame. */
+
+	/* Where the status comes from.  Replaced for testing. */
+
+	level func(channel int) ALevel
+	queue func(channel int, prio int) int
+	heard func() []mheard_t
+
+	term   *os.File /* The real standard output. */
+	writer *os.File
+	done   chan struct{}
+}
+
+var tuiMon *tuiMonitor
+
+func new_tui_monitor(pa *audio_s) *tuiMonitor {
+	var m = &tuiMonitor{ //nolint:exhaustruct
+		panes: make(map[int]*tuiPane),
+		other: &tuiPane{title: "Messages", lines: nil},
+		last:  -1,
+		level: func(channel int) ALevel { return demod_get_audio_level(channel, 0) },
+		queue: func(channel int, prio int) int { return tq_count(channel, prio, "", "", false) },
+		heard: func() []mheard_t { return nil },
+	}
+
+	for ch := range MAX_RADIO_CHANS {
+		if pa.chan_medium[ch] == MEDIUM_RADIO {
+			m.channels = append(m.channels, ch)
+			m.panes[ch] = &tuiPane{title: fmt.Sprintf("Channel %d%s", ch, chan_name_suffix(pa, ch)), lines: nil}
+		}
+	}
+
+	return m
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        tui_start
+ *
+ * Purpose:     Take over the screen.
+ *
+ * Inputs:	pa	- Audio configuration, for the radio channels.
+ *
+ * Description:	Must be after everything is initialized, so the
+ *		startup messages are still on the normal screen after
+ *		we finish.  tui_stop puts everything back.
+ *
+ *--------------------------------------------------------------------*/
+
+func tui_start(pa *audio_s) error {
+	var _, sizeErr = unix.IoctlGetWinsize(int(os.Stdout.Fd()), unix.TIOCGWINSZ)
+	if sizeErr != nil {
+		return fmt.Errorf("standard output is not a terminal: %w", sizeErr)
+	}
+
+	var reader, writer, err = os.Pipe()
+	if err != nil {
+		return err
+	}
+
+	var m = new_tui_monitor(pa)
+	m.term = os.Stdout
+	m.writer = writer
+	m.done = make(chan struct{})
+
+	if mheardDB != nil {
+		m.heard = mheardDB.recent
+	}
+
+	/* Alternate screen, hide cursor. */
+	fmt.Fprint(m.term, "\x1b[?1049h\x1b[?25l")
+
+	os.Stdout = writer
+	tuiMon = m
+
+	go m.read_lines(reader)
+
+	go func() {
+		var ticker = time.NewTicker(TUI_REFRESH)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-m.done:
+				return
+			case <-ticker.C:
+				m.draw()
+			}
+		}
+	}()
+
+	return nil
+}
+
+func tui_stop() {
+	var m = tuiMon
+	if m == nil {
+		return
+	}
+
+	tuiMon = nil
+
+	close(m.done)
+
+	os.Stdout = m.term
+	m.writer.Close()
+
+	/* Normal screen, show cursor. */
+	fmt.Fprint(m.term, "\x1b[?25h\x1b[?1049l")
+}
+
+func (m *tuiMonitor) read_lines(r io.Reader) {
+	var scanner = bufio.NewScanner(r)
+
+	for scanner.Scan() {
+		m.add(scanner.Text())
+	}
+}
+
+var tuiChannelPrefix = regexp.MustCompile(`^\[(\d+)[^\d]`)
+
+func (m *tuiMonitor) add(line string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	line = strings.ReplaceAll(strings.TrimRight(line, "\r"), "\t", "    ")
+
+	if strings.TrimSpace(line) == "" {
+		return
+	}
+
+	var channel = -1
+
+	if match := tuiChannelPrefix.FindStringSubmatch(line); match != nil {
+		var ch int
+
+		fmt.Sscanf(match[1], "%d", &ch)
+
+		if _, ok := m.panes[ch]; ok {
+			channel = ch
+		}
+
+		m.last = channel
+	} else if strings.Contains(line, " audio level = ") {
+		m.flush_pending()
+		m.pending = line
+
+		return
+	} else if strings.HasPrefix(line, "[") {
+		m.last = -1
+	} else {
+		channel = m.last
+	}
+
+	if channel < 0 {
+		m.flush_pending()
+		m.other.add(line)
+
+		return
+	}
+
+	if m.pending != "" {
+		m.panes[channel].add(m.pending)
+		m.pending = ""
+	}
+
+	m.panes[channel].add(line)
+}
+
+func (m *tuiMonitor) flush_pending() {
+	if m.pending != "" {
+		m.other.add(m.pending)
+		m.pending = ""
+	}
+}
+
+func (m *tuiMonitor) draw() {
+	var width, height = 80, 24
+
+	var ws, err = unix.IoctlGetWinsize(int(m.term.Fd()), unix.TIOCGWINSZ)
+	if err == nil && ws.Col > 0 && ws.Row > 0 {
+		width, height = int(ws.Col), int(ws.Row)
+	}
+
+	var screen = m.render(width, height, time.Now())
+
+	fmt.Fprint(m.term, "\x1b[H"+strings.Join(screen, "\r\n"))
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        render
+ *
+ * Purpose:     Lay out the screen.
+ *
+ * Returns:	Exactly height lines, each exactly width characters.
+ *
+ * Description:	Channel panes share the left side, above the other
+ *		messages.  The status and stations heard are on the
+ *		right, if there is room.
+ *
+ *--------------------------------------------------------------------*/
+
+func (m *tuiMonitor) render(width int, height int, now time.Time) []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var rightWidth = 0
+	if width >= 72 {
+		rightWidth = min(40, width/3)
+	}
+
+	var leftWidth = width
+	if rightWidth > 0 {
+		leftWidth = width - rightWidth - 1
+	}
+
+	/* Left side. */
+
+	var left []string
+
+	var messagesHeight = min(max(3, height/4), height)
+
+	if len(m.channels) == 0 {
+		messagesHeight = height
+	}
+
+	var channelArea = height - messagesHeight
+
+	for i, ch := range m.channels {
+		var h = channelArea / len(m.channels)
+		if i == len(m.channels)-1 {
+			h = channelArea - h*(len(m.channels)-1)
+		}
+
+		left = append(left, tui_pane(m.panes[ch].title, m.panes[ch].lines, leftWidth, h)...)
+	}
+
+	left = append(left, tui_pane(m.other.title, m.other.lines, leftWidth, messagesHeight)...)
+
+	if rightWidth == 0 {
+		return left
+	}
+
+	/* Right side. */
+
+	var status []string
+
+	for _, ch := range m.channels {
+		status = append(status, fmt.Sprintf("%-3d %-12s %4d %4d", ch,
+			ax25_alevel_to_text(m.level(ch)), m.queue(ch, TQ_PRIO_0_HI), m.queue(ch, TQ_PRIO_1_LO)))
+	}
+
+	var statusHeight = min(len(status)+1, height/2)
+
+	var right = tui_pane("Ch  Audio level   TxHi TxLo", status, rightWidth, statusHeight)
+
+	var heard = []string{"Callsign   Ch Hops   Age"}
+
+	for _, h := range m.heard() {
+		var hops = "  IS"
+		if !h.last_heard_rf.IsZero() {
+			hops = fmt.Sprintf("%4d", h.num_digi_hops)
+		}
+
+		heard = append(heard, fmt.Sprintf("%-9s %3d %s %s", h.callsign, h.channel, hops, mheard_age(now, mheard_last(h))))
+	}
+
+	/* Most recent at the top, so keep the start rather than the end. */
+	var heardHeight = height - statusHeight
+	if len(heard) > heardHeight-1 {
+		heard = heard[:max(heardHeight-1, 0)]
+	}
+
+	right = append(right, tui_pane("Stations heard", heard, rightWidth, heardHeight)...)
+
+	var screen = make([]string, height)
+	for i := range screen {
+		screen[i] = left[i] + "│" + right[i]
+	}
+
+	return screen
+}
+
+/*
+ * A title line and then the most recent lines that fit.
+ */
+
+func tui_pane(title string, lines []string, width int, height int) []string {
+	if height <= 0 {
+		return nil
+	}
+
+	var result = []string{tui_fit("─ "+title+" "+strings.Repeat("─", width), width)}
+
+	var body = height - 1
+	if len(lines) > body {
+		lines = lines[len(lines)-body:]
+	}
+
+	for _, line := range lines {
+		result = append(result, tui_fit(line, width))
+	}
+
+	for len(result) < height {
+		result = append(result, strings.Repeat(" ", width))
+	}
+
+	return result
+}
+
+/*
+ * Cut or pad to exactly width characters.
+ */
+
+func tui_fit(s string, width int) string {
+	var n = utf8.RuneCountInString(s)
+
+	if n > width {
+		var runes = []rune(s)
+
+		return string(runes[:width])
+	}
+
+	return s + strings.Repeat(" ", width-n)
+}
@@ -0,0 +1,96 @@
+package direwolf
+
+import (
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func tui_test_monitor() *tuiMonitor {
+	var pa = new(audio_s)
+	pa.chan_medium[0] = MEDIUM_RADIO
+	pa.chan_medium[1] = MEDIUM_RADIO
+	pa.chan_name[1] = "UHF"
+
+	var m = new_tui_monitor(pa)
+	m.level = func(channel int) ALevel { return ALevel{rec: 50 + channel, mark: 25, space: 24} }
+	m.queue = func(channel int, prio int) int { return channel*10 + prio }
+	m.heard = func() []mheard_t { return nil }
+
+	return m
+}
+
+func Test_tui_add(t *testing.T) {
+	var m = tui_test_monitor()
+
+	m.add("Q1TEST audio level = 50(25/24)   [NONE]   ___|||___")
+	m.add("[0.3] Q1TEST>APDW18,WIDE2-1:!4237.14N/07120.83W-")
+	m.add("Position, Other, DireWolf")
+	m.add("[1 UHF] Q1TEST-2>APDW18:>Hello")
+	m.add("")
+	m.add("[ig] # logresp Q1TEST verified")
+	m.add("Attempting connection to noam.aprs2.net")
+	m.add("[0L] Q1TEST>APDW18:>beacon")
+	m.add("[5] Q1TEST>APDW18:>Not a radio channel")
+
+	assert.Equal(t, []string{
+		"Q1TEST audio level = 50(25/24)   [NONE]   ___|||___",
+		"[0.3] Q1TEST>APDW18,WIDE2-1:!4237.14N/07120.83W-",
+		"Position, Other, DireWolf",
+		"[0L] Q1TEST>APDW18:>beacon",
+	}, m.panes[0].lines)
+	assert.Equal(t, []string{"[1 UHF] Q1TEST-2>APDW18:>Hello"}, m.panes[1].lines)
+	assert.Equal(t, []string{
+		"[ig] # logresp Q1TEST verified",
+		"Attempting connection to noam.aprs2.net",
+		"[5] Q1TEST>APDW18:>Not a radio channel",
+	}, m.other.lines)
+}
+
+func Test_tui_render(t *testing.T) {
+	var m = tui_test_monitor()
+
+	m.heard = func() []mheard_t {
+		return []mheard_t{{ //nolint:exhaustruct
+			callsign:      "Q1TEST-2",
+			channel:       1,
+			num_digi_hops: 1,
+			last_heard_rf: time.Date(2026, 1, 1, 11, 58, 0, 0, time.UTC),
+		}}
+	}
+
+	for range 30 {
+		m.add("[0] Q1TEST>APDW18:>Hello")
+	}
+
+	var now = time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	for _, size := range [][2]int{{120, 40}, {80, 24}, {60, 10}, {20, 3}} {
+		var screen = m.render(size[0], size[1], now)
+
+		require.Len(t, screen, size[1])
+
+		for _, line := range screen {
+			assert.Equal(t, size[0], utf8.RuneCountInString(line))
+		}
+	}
+
+	var screen = strings.Join(m.render(120, 40, now), "\n")
+
+	assert.Contains(t, screen, "─ Channel 0 ─")
+	assert.Contains(t, screen, "─ Channel 1 UHF ─")
+	assert.Contains(t, screen, "─ Messages ─")
+	assert.Contains(t, screen, "0   50(25/24)       0    1")
+	assert.Contains(t, screen, "1   51(25/24)      10   11")
+	assert.Contains(t, screen, "Q1TEST-2    1    1    0:02")
+}
+
+func Test_tui_fit(t *testing.T) {
+	assert.Equal(t, "abc  ", tui_fit("abc", 5))
+	assert.Equal(t, "ab", tui_fit("abc", 2))
+	assert.Equal(t, "maña", tui_fit("mañana", 4))
+}
@@ -0,0 +1,261 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Keep track of how much we transmit.
+ *
+ * Description:	Each time PTT is turned on and off, we remember how
+ *		long it was and why.  This gives the duty cycle for each
+ *		channel, over the last 10 and 60 minutes, which is shown
+ *		by /status.  See httpstatus.go.
+ *
+ *		"direwolf --tx-log file" also appends a CSV line for each
+ *		transmission, to check compliance with any duty cycle
+ *		limits, or find out why a transmitter keeps keying up:
+ *
+ *			isotime,chan,seconds,frames,reason
+ *			2024-05-06T12:00:00.250Z,0,0.850,2,beacon digipeat
+ *
+ *		reason is one or more of:
+ *
+ *			beacon		- PBEACON, OBEACON, etc.
+ *			digipeat	- Digipeater, APRS or connected mode.
+ *			client		- AGW or KISS client application.
+ *			igate		- APRS-IS to radio.
+ *			aprstt		- Response to APRStt, touch tones.
+ *			cwid		- Morse code identification.  See cwid.go.
+ *			other		- Anything else.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type tx_reason_t int
+
+const (
+	TX_REASON_OTHER tx_reason_t = iota
+	TX_REASON_BEACON
+	TX_REASON_DIGIPEAT
+	TX_REASON_CLIENT
+	TX_REASON_IGATE
+	TX_REASON_APRSTT
+	TX_REASON_CWID
+)
+
+const TX_NUM_REASONS = TX_REASON_CWID + 1
+
+func (r tx_reason_t) String() string {
+	switch r {
+	case TX_REASON_BEACON:
+		return "beacon"
+	case TX_REASON_DIGIPEAT:
+		return "digipeat"
+	case TX_REASON_CLIENT:
+		return "client"
+	case TX_REASON_IGATE:
+		return "igate"
+	case TX_REASON_APRSTT:
+		return "aprstt"
+	case TX_REASON_CWID:
+		return "cwid"
+	default:
+		return "other"
+	}
+}
+
+/* Longest time for duty cycle.  Older transmissions are forgotten. */
+
+const TX_ACTIVITY_HISTORY = 60 * time.Minute
+
+type txActivityRecord struct {
+	start time.Time
+	end   time.Time
+}
+
+type TxActivity struct {
+	mu      sync.Mutex
+	history [MAX_RADIO_CHANS][]txActivityRecord
+
+	last [MAX_RADIO_CHANS]time.Time /* End of last transmission, not counting CW ID. */
+
+	w   io.Writer // Optional log.
+	f   *os.File  // nil if not writing to a file.
+	err error     // First write error.  Nothing more is written after.
+}
+
+var txActivity = new(TxActivity)
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	SetLogFile
+ *
+ * Purpose:	Also log each transmission to a file.
+ *
+ * Inputs:	path	- File name.  Appended to if it already exists.
+ *
+ *---------------------------------------------------------------*/
+
+func (ta *TxActivity) SetLogFile(path string) error {
+	var _, statErr = os.Stat(path)
+	var already_there = statErr == nil
+
+	var f, err = os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o644) //nolint:gosec // Named on the command line.
+	if err != nil {
+		return err
+	}
+
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	ta.w = f
+	ta.f = f
+
+	// Header for a spreadsheet, only if this will be the first line.
+
+	if !already_there {
+		_, ta.err = fmt.Fprintf(ta.w, "isotime,chan,seconds,frames,reason\n")
+	}
+
+	return ta.err
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Record
+ *
+ * Purpose:	Remember one transmission.
+ *
+ * Inputs:	channel	- Radio channel.
+ *
+ *		start	- When PTT was turned on.
+ *
+ *		end	- When PTT was turned off.
+ *
+ *		reasons	- Why, for each frame sent.
+ *
+ *---------------------------------------------------------------*/
+
+func (ta *TxActivity) Record(channel int, start time.Time, end time.Time, reasons []tx_reason_t) {
+	if channel < 0 || channel >= MAX_RADIO_CHANS {
+		return
+	}
+
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	var h = append(ta.history[channel], txActivityRecord{start: start, end: end})
+
+	var forget = 0
+	for forget < len(h) && h[forget].end.Before(end.Add(-TX_ACTIVITY_HISTORY)) {
+		forget++
+	}
+
+	ta.history[channel] = h[forget:]
+
+	if len(reasons) == 0 || slices.ContainsFunc(reasons, func(r tx_reason_t) bool { return r != TX_REASON_CWID }) {
+		ta.last[channel] = end
+	}
+
+	if ta.w == nil || ta.err != nil {
+		return
+	}
+
+	var names []string
+	for _, r := range reasons {
+		if !slices.Contains(names, r.String()) {
+			names = append(names, r.String())
+		}
+	}
+
+	_, ta.err = fmt.Fprintf(ta.w, "%s,%d,%.3f,%d,%s\n",
+		start.UTC().Format("2006-01-02T15:04:05.000Z"),
+		channel,
+		end.Sub(start).Seconds(),
+		len(reasons),
+		strings.Join(names, " "))
+
+	if ta.err != nil {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Error writing transmit log file: %s\n", ta.err)
+		dw_printf("No more transmissions will be logged.\n")
+	}
+}
+
+/* End of the last transmission, other than a CW ID.  Zero if none. */
+
+func (ta *TxActivity) LastTransmit(channel int) time.Time {
+	if channel < 0 || channel >= MAX_RADIO_CHANS {
+		return time.Time{}
+	}
+
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	return ta.last[channel]
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	DutyCycle
+ *
+ * Purpose:	Percentage of time transmitting recently.
+ *
+ * Inputs:	channel	- Radio channel.
+ *
+ *		window	- How far back to look, up to TX_ACTIVITY_HISTORY.
+ *
+ *		now	- End of the window.
+ *
+ * Returns:	0 to 100.
+ *
+ *---------------------------------------------------------------*/
+
+func (ta *TxActivity) DutyCycle(channel int, window time.Duration, now time.Time) float64 {
+	if channel < 0 || channel >= MAX_RADIO_CHANS || window <= 0 {
+		return 0
+	}
+
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	var from = now.Add(-window)
+	var on time.Duration
+
+	for _, r := range ta.history[channel] {
+		// Only the part inside the window counts.
+		var start = r.start
+		if start.Before(from) {
+			start = from
+		}
+
+		var end = r.end
+		if end.After(now) {
+			end = now
+		}
+
+		if end.After(start) {
+			on += end.Sub(start)
+		}
+	}
+
+	return 100 * on.Seconds() / window.Seconds()
+}
+
+func (ta *TxActivity) Close() {
+	ta.mu.Lock()
+	defer ta.mu.Unlock()
+
+	if ta.f != nil {
+		ta.f.Close()
+		ta.f = nil
+		ta.w = nil
+	}
+}
@@ -0,0 +1,92 @@
+package direwolf
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tx_activity_duty_cycle(t *testing.T) {
+	var ta = new(TxActivity)
+	var now = time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+
+	assert.Zero(t, ta.DutyCycle(0, 10*time.Minute, now), "nothing sent yet")
+
+	// 30 seconds 5 minutes ago, 60 seconds 30 minutes ago.
+	ta.Record(0, now.Add(-5*time.Minute), now.Add(-5*time.Minute+30*time.Second), []tx_reason_t{TX_REASON_BEACON})
+	ta.Record(0, now.Add(-30*time.Minute), now.Add(-30*time.Minute+60*time.Second), []tx_reason_t{TX_REASON_DIGIPEAT})
+
+	assert.InDelta(t, 5.0, ta.DutyCycle(0, 10*time.Minute, now), 0.001)
+	assert.InDelta(t, 2.5, ta.DutyCycle(0, 60*time.Minute, now), 0.001)
+	assert.Zero(t, ta.DutyCycle(1, 10*time.Minute, now), "other channel")
+
+	// Only the part inside the window counts.
+	assert.InDelta(t, 25.0, ta.DutyCycle(0, time.Minute, now.Add(-5*time.Minute+15*time.Second)), 0.001)
+
+	// Out of range is ignored.
+	ta.Record(MAX_RADIO_CHANS, now, now.Add(time.Second), nil)
+	assert.Zero(t, ta.DutyCycle(MAX_RADIO_CHANS, 10*time.Minute, now))
+}
+
+func Test_tx_activity_history(t *testing.T) {
+	var ta = new(TxActivity)
+	var start = time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+
+	for i := range 10 {
+		var when = start.Add(time.Duration(i) * 20 * time.Minute)
+		ta.Record(0, when, when.Add(time.Second), []tx_reason_t{TX_REASON_BEACON})
+	}
+
+	assert.Len(t, ta.history[0], 4, "older than an hour is forgotten")
+}
+
+func Test_tx_activity_last_transmit(t *testing.T) {
+	var ta = new(TxActivity)
+	var start = time.Date(2024, 5, 6, 12, 0, 0, 0, time.UTC)
+
+	assert.True(t, ta.LastTransmit(0).IsZero())
+
+	ta.Record(0, start, start.Add(time.Second), []tx_reason_t{TX_REASON_BEACON})
+	ta.Record(0, start.Add(time.Minute), start.Add(time.Minute+time.Second), []tx_reason_t{TX_REASON_CWID})
+
+	assert.Equal(t, start.Add(time.Second), ta.LastTransmit(0), "the ID doesn't count")
+	assert.True(t, ta.LastTransmit(1).IsZero())
+}
+
+func Test_tx_activity_log(t *testing.T) {
+	var path = filepath.Join(t.TempDir(), "tx.csv")
+
+	var ta = new(TxActivity)
+	require.NoError(t, ta.SetLogFile(path))
+
+	var start = time.Date(2024, 5, 6, 12, 0, 0, 250000000, time.UTC)
+	ta.Record(0, start, start.Add(850*time.Millisecond), []tx_reason_t{TX_REASON_BEACON, TX_REASON_DIGIPEAT, TX_REASON_BEACON})
+	ta.Record(1, start, start.Add(2*time.Second), []tx_reason_t{TX_REASON_IGATE})
+	ta.Close()
+
+	// Header only once.
+	require.NoError(t, ta.SetLogFile(path))
+	ta.Record(0, start, start.Add(time.Second), nil)
+	ta.Close()
+
+	var data, err = os.ReadFile(path) //nolint:gosec
+	require.NoError(t, err)
+	assert.Equal(t, "isotime,chan,seconds,frames,reason\n"+
+		"2024-05-06T12:00:00.250Z,0,0.850,3,beacon digipeat\n"+
+		"2024-05-06T12:00:00.250Z,1,2.000,1,igate\n"+
+		"2024-05-06T12:00:00.250Z,0,1.000,0,\n", string(data))
+}
+
+func Test_tx_reason_String(t *testing.T) {
+	assert.Equal(t, "beacon", TX_REASON_BEACON.String())
+	assert.Equal(t, "digipeat", TX_REASON_DIGIPEAT.String())
+	assert.Equal(t, "client", TX_REASON_CLIENT.String())
+	assert.Equal(t, "igate", TX_REASON_IGATE.String())
+	assert.Equal(t, "aprstt", TX_REASON_APRSTT.String())
+	assert.Equal(t, "cwid", TX_REASON_CWID.String())
+	assert.Equal(t, "other", TX_REASON_OTHER.String())
+}
@@ -0,0 +1,260 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Take turns when more than one channel shares a transmitter.
+ *
+ * Description:	Both channels of a stereo audio device go out through
+ *		the same sound card, and channels mapped to the same device
+ *		with "ADEVICEn = n" would be the same radio.  Only one can
+ *		transmit at a time.  This used to be a mutex, polled every
+ *		10 ms, so whichever thread happened to look first won.  A
+ *		busy channel sending beacons could keep connected mode on
+ *		the other channel waiting until it gave up.
+ *
+ *		Now the channels wait in line for each audio device.  When
+ *		the transmitter is free, it goes to the highest priority
+ *		waiting, which depends on why the frame is being sent:
+ *
+ *			TXPRIORITY  reason=n  [ reason=n ... ]
+ *
+ *		reason is one of those in tx_activity.go: beacon, digipeat,
+ *		client, igate, aprstt, cwid, other.  Connected mode counts
+ *		as client.  Higher n goes first.  With equal priority,
+ *		the channel which transmitted longest ago goes first.
+ *
+ *		A channel with many frames queued up bundles them into one
+ *		long transmission.  If another channel is waiting, it has
+ *		to stop after
+ *
+ *			TXSLICE  seconds
+ *
+ *		and get back in line for the rest.  0 for no limit.
+ *
+ *		One audio device with one channel never waits for anything
+ *		here, so it's the same as before.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DEFAULT_TXSLICE = 5 /* Seconds. */
+
+const TX_PRIORITY_MAX = 9
+
+/* Digipeating and touch tone responses are time sensitive, beacons aren't. */
+
+func tx_priority_defaults() [TX_NUM_REASONS]int {
+	var p [TX_NUM_REASONS]int
+
+	p[TX_REASON_OTHER] = 1
+	p[TX_REASON_BEACON] = 0
+	p[TX_REASON_DIGIPEAT] = 3
+	p[TX_REASON_CLIENT] = 2
+	p[TX_REASON_IGATE] = 1
+	p[TX_REASON_APRSTT] = 3
+	p[TX_REASON_CWID] = 4
+
+	return p
+}
+
+/* "beacon" to TX_REASON_BEACON, etc. */
+
+func tx_reason_value(name string) (tx_reason_t, bool) {
+	for r := range TX_NUM_REASONS {
+		if strings.EqualFold(r.String(), name) {
+			return r, true
+		}
+	}
+
+	return TX_REASON_OTHER, false
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	tx_priority_parse
+ *
+ * Purpose:	Change priorities from TXPRIORITY.
+ *
+ * Inputs:	p	- Priorities to change.
+ *		item	- e.g. "beacon=0".
+ *
+ *--------------------------------------------------------------------*/
+
+func tx_priority_parse(p *[TX_NUM_REASONS]int, item string) error {
+	var name, value, found = strings.Cut(item, "=")
+	if !found {
+		return fmt.Errorf("expected reason=priority, not \"%s\"", item)
+	}
+
+	var r, ok = tx_reason_value(name)
+	if !ok {
+		return fmt.Errorf("\"%s\" isn't beacon, digipeat, client, igate, aprstt, cwid, or other", name)
+	}
+
+	var n, err = strconv.Atoi(value)
+	if err != nil || n < 0 || n > TX_PRIORITY_MAX {
+		return fmt.Errorf("priority for %s should be 0 to %d, not \"%s\"", name, TX_PRIORITY_MAX, value)
+	}
+
+	p[r] = n
+
+	return nil
+}
+
+type txWaiter struct {
+	channel  int
+	priority int
+	granted  bool
+	ready    chan struct{} /* Closed when it's our turn. */
+}
+
+/* One for each audio device. */
+
+type TxArbiter struct {
+	mu sync.Mutex
+
+	busy    bool
+	waiting []*txWaiter /* In order of arrival. */
+
+	turns uint64                  /* Number of times the transmitter has been handed out. */
+	last  [MAX_RADIO_CHANS]uint64 /* Value of turns when each channel last had it. */
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:	Acquire
+ *
+ * Purpose:	Wait for our turn to transmit.
+ *
+ * Inputs:	channel	- Radio channel.
+ *		priority - From TXPRIORITY for the frame to be sent.
+ *		timeout	- Longest time to wait.
+ *
+ * Returns:	true when it's ours, until Release.  false if we waited
+ *		too long.
+ *
+ *--------------------------------------------------------------------*/
+
+func (a *TxArbiter) Acquire(channel int, priority int, timeout time.Duration) bool {
+	a.mu.Lock()
+
+	if !a.busy && len(a.waiting) == 0 {
+		a.grant(channel)
+		a.mu.Unlock()
+
+		return true
+	}
+
+	var w = &txWaiter{channel: channel, priority: priority, granted: false, ready: make(chan struct{})}
+	a.waiting = append(a.waiting, w)
+	a.mu.Unlock()
+
+	var timer = time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case <-w.ready:
+		return true
+	case <-timer.C:
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	// It might have been handed to us just as the time ran out.
+
+	if w.granted {
+		return true
+	}
+
+	for i, x := range a.waiting {
+		if x == w {
+			a.waiting = append(a.waiting[:i], a.waiting[i+1:]...)
+
+			break
+		}
+	}
+
+	return false
+}
+
+/* Only if nobody is using it or waiting for it.  For changing the modem.  See profile.go. */
+
+func (a *TxArbiter) TryAcquire() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.busy || len(a.waiting) > 0 {
+		return false
+	}
+
+	a.busy = true // Not a turn to transmit, so last isn't changed.
+
+	return true
+}
+
+/* Done transmitting.  Hand it to whoever is next. */
+
+func (a *TxArbiter) Release() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	a.busy = false
+
+	var next = a.next()
+	if next < 0 {
+		return
+	}
+
+	var w = a.waiting[next]
+	a.waiting = append(a.waiting[:next], a.waiting[next+1:]...)
+
+	a.grant(w.channel)
+	w.granted = true
+	close(w.ready)
+}
+
+/* Highest priority, then the one which transmitted longest ago, then first to arrive.  -1 for none. */
+
+func (a *TxArbiter) next() int {
+	var best = -1
+
+	for i, w := range a.waiting {
+		if best < 0 {
+			best = i
+
+			continue
+		}
+
+		var b = a.waiting[best]
+
+		if w.priority > b.priority ||
+			(w.priority == b.priority && a.last[w.channel] < a.last[b.channel]) {
+			best = i
+		}
+	}
+
+	return best
+}
+
+func (a *TxArbiter) grant(channel int) {
+	a.busy = true
+	a.turns++
+	a.last[channel] = a.turns
+}
+
+/* Is another channel waiting?  Then a long transmission should stop after TXSLICE. */
+
+func (a *TxArbiter) Contended() bool {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	return len(a.waiting) > 0
+}
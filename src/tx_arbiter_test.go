@@ -0,0 +1,114 @@
+package direwolf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_tx_priority_parse(t *testing.T) {
+	var p = tx_priority_defaults()
+	assert.Greater(t, p[TX_REASON_CLIENT], p[TX_REASON_BEACON], "beacons can't hold up connected mode")
+
+	require.NoError(t, tx_priority_parse(&p, "Beacon=7"))
+	assert.Equal(t, 7, p[TX_REASON_BEACON])
+
+	for _, bad := range []string{"beacon", "beacon=10", "beacon=-1", "beacon=high", "chat=1"} {
+		assert.Error(t, tx_priority_parse(&p, bad), bad)
+	}
+
+	assert.Equal(t, 7, p[TX_REASON_BEACON])
+}
+
+func Test_config_init_txpriority(t *testing.T) {
+	var audio, _ = configFromString(t, "TXPRIORITY client=5 igate=0 nonsense=1\nTXSLICE 10\n")
+
+	assert.Equal(t, 5, audio.tx_priority[TX_REASON_CLIENT])
+	assert.Equal(t, 0, audio.tx_priority[TX_REASON_IGATE])
+	assert.Equal(t, tx_priority_defaults()[TX_REASON_DIGIPEAT], audio.tx_priority[TX_REASON_DIGIPEAT])
+	assert.Equal(t, 10, audio.tx_slice)
+
+	audio, _ = configFromString(t, "TXSLICE forever\n")
+	assert.Equal(t, DEFAULT_TXSLICE, audio.tx_slice)
+}
+
+/* Start waiting in another goroutine, and make sure it's in line before carrying on. */
+
+func waitForTurn(t *testing.T, a *TxArbiter, channel int, priority int, got chan<- int) {
+	t.Helper()
+
+	a.mu.Lock()
+	var before = len(a.waiting)
+	a.mu.Unlock()
+
+	go func() {
+		if a.Acquire(channel, priority, time.Minute) {
+			got <- channel
+		}
+	}()
+
+	require.Eventually(t, func() bool {
+		a.mu.Lock()
+		defer a.mu.Unlock()
+
+		return len(a.waiting) > before
+	}, time.Second, time.Millisecond)
+}
+
+func Test_TxArbiter_priority(t *testing.T) {
+	var a = new(TxArbiter)
+	var got = make(chan int, 3)
+
+	require.True(t, a.Acquire(0, 0, 0), "nobody else, so right away")
+	assert.False(t, a.Contended())
+
+	waitForTurn(t, a, 1, 0, got) // Beacon.
+	waitForTurn(t, a, 2, 2, got) // Connected mode.
+	assert.True(t, a.Contended())
+
+	a.Release()
+	assert.Equal(t, 2, <-got, "higher priority first, though it came later")
+
+	a.Release()
+	assert.Equal(t, 1, <-got)
+
+	a.Release()
+	assert.False(t, a.busy)
+}
+
+func Test_TxArbiter_fair(t *testing.T) {
+	var a = new(TxArbiter)
+	var got = make(chan int, 2)
+
+	// Channel 1 transmitted more recently than channel 0.
+
+	require.True(t, a.Acquire(0, 1, 0))
+	a.Release()
+	require.True(t, a.Acquire(1, 1, 0))
+
+	waitForTurn(t, a, 1, 1, got)
+	waitForTurn(t, a, 0, 1, got)
+
+	a.Release()
+	assert.Equal(t, 0, <-got, "longest since its last turn")
+
+	a.Release()
+	assert.Equal(t, 1, <-got)
+}
+
+func Test_TxArbiter_timeout(t *testing.T) {
+	var a = new(TxArbiter)
+
+	require.True(t, a.Acquire(0, 0, 0))
+	assert.False(t, a.Acquire(1, 9, 10*time.Millisecond))
+	assert.False(t, a.Contended(), "gave up, so no longer waiting")
+
+	// Changing the modem only happens if nobody wants to transmit.
+
+	assert.False(t, a.TryAcquire())
+	a.Release()
+	assert.True(t, a.TryAcquire())
+	a.Release()
+}
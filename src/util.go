@@ -3,7 +3,6 @@ package direwolf
 import (
 	"bytes"
 	"fmt"
-	"math"
 	"runtime"
 	"time"
 )
@@ -38,7 +37,15 @@ func ByteArrayToString(b []byte) string {
 func dw_printf(format string, a ...any) (int, error) {
 	// Can't call variadic functions through cgo, so let's define our own!
 	// Fortunately dw_printf doesn't do much
-	return fmt.Printf(format, a...)
+	var s = fmt.Sprintf(format, a...)
+
+	log_printed(s, 1)
+
+	if log_json_only {
+		return len(s), nil
+	}
+
+	return fmt.Print(s)
 }
 
 // #define ACHAN2ADEV(n) ((n)>>1)
@@ -113,14 +120,6 @@ func DW_KM_TO_MILES(x float64) float64 {
 	return x * 0.621371192
 }
 
-func D2R(d float64) float64 {
-	return d * math.Pi / 180
-}
-
-func R2D(r float64) float64 {
-	return r * 180 / math.Pi
-}
-
 // Can't be "assert" because of conflicts with stretchr/testify/assert, but otherwise, it's compatible enough
 func Assert(t bool) {
 	if !t {
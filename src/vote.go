@@ -0,0 +1,249 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Pick the best copy of a frame heard by several receivers.
+ *
+ * Description:	A station with receivers at several sites, attached as
+ *		radio channels or bridged from other instances with
+ *		BCHANNEL, hears the same transmission more than once.
+ *		Without voting, every copy would be digipeated, IGated
+ *		and sent to applications.
+ *
+ *			VOTE  chan  chan  [ chan ... ]  [ WINDOW=ms ]
+ *
+ *		The first copy of a frame on any of the channels starts
+ *		the count.  Copies arriving in the next WINDOW ms are
+ *		collected and the best one is passed along, as if heard
+ *		on the first channel listed.  Usually that's the channel
+ *		with the transmitter.
+ *
+ *		Best is the same as for multiple decoders on one channel:
+ *		FEC over none, then fewest bits fixed, then the highest
+ *		audio level.  Frames from network TNCs and bridges don't
+ *		have an audio level so a local receiver wins a tie.
+ *
+ *		A summary of which receivers won is printed every
+ *		VOTE_REPORT_EVERY frames.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+const DEFAULT_VOTE_WINDOW = 500 /* ms.  Allows for a bridge across the Internet. */
+
+const VOTE_REPORT_EVERY = 100 /* Frames between summaries. */
+
+type vote_config_s struct {
+	channels  []int /* First one is where the winner appears. */
+	window_ms int
+}
+
+type vote_copy_s struct {
+	channel  int
+	subchan  int
+	slice    int
+	pp       *packet_t
+	alevel   ALevel
+	fec_type fec_type_t
+	retries  BitFixLevel
+	spectrum string
+}
+
+type vote_group_s struct {
+	channels []int
+	window   time.Duration
+
+	pending map[string][]*vote_copy_s /* Keyed by frame content. */
+
+	frames int                  /* Frames voted on. */
+	copies int                  /* Copies of them received. */
+	wins   [MAX_TOTAL_CHANS]int /* Best copy came from this channel. */
+}
+
+type Voter struct {
+	mu sync.Mutex
+
+	group [MAX_TOTAL_CHANS]*vote_group_s /* nil for channels not voting. */
+
+	groups []*vote_group_s
+
+	clock Clock
+
+	release func(channel int, subchan int, slice int, pp *packet_t, alevel ALevel, fec_type fec_type_t, retries BitFixLevel, spectrum string) /* dlq_rec_frame_real, replaced for testing. */
+}
+
+var voter *Voter //nolint:gochecknoglobals
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        NewVoter
+ *
+ * Purpose:     Set up the VOTE groups from the configuration.
+ *
+ * Returns:	nil if there are none, so received frames go
+ *		straight through.
+ *
+ *--------------------------------------------------------------------*/
+
+func NewVoter(configs []*vote_config_s, clock Clock) *Voter {
+	if len(configs) == 0 {
+		return nil
+	}
+
+	var v = &Voter{clock: clock, release: dlq_rec_frame_real} //nolint:exhaustruct
+
+	for _, c := range configs {
+		var g = &vote_group_s{ //nolint:exhaustruct
+			channels: c.channels,
+			window:   time.Duration(c.window_ms) * time.Millisecond,
+			pending:  make(map[string][]*vote_copy_s),
+		}
+
+		v.groups = append(v.groups, g)
+
+		for _, ch := range c.channels {
+			v.group[ch] = g
+		}
+	}
+
+	return v
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        Add
+ *
+ * Purpose:     Take a received frame from dlq_rec_frame.
+ *
+ * Returns:	true if we took it, and the caller should do nothing more.
+ *		false for channels which aren't voting.
+ *
+ *--------------------------------------------------------------------*/
+
+func (v *Voter) Add(channel int, subchan int, slice int, pp *packet_t, alevel ALevel, fec_type fec_type_t, retries BitFixLevel, spectrum string) bool {
+	var g = v.group[channel]
+	if g == nil {
+		return false
+	}
+
+	var key = string(ax25_get_frame_data(pp))
+
+	var c = &vote_copy_s{
+		channel:  channel,
+		subchan:  subchan,
+		slice:    slice,
+		pp:       pp,
+		alevel:   alevel,
+		fec_type: fec_type,
+		retries:  retries,
+		spectrum: spectrum,
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	var first = len(g.pending[key]) == 0
+
+	g.pending[key] = append(g.pending[key], c)
+
+	if first {
+		go func() {
+			v.clock.Sleep(g.window)
+			v.decide(g, key)
+		}()
+	}
+
+	return true
+}
+
+/* Same as pick_best_candidate, with audio level to break a tie. */
+
+func vote_score(c *vote_copy_s) int {
+	var score int
+
+	if c.fec_type != fec_type_none {
+		score = 9000 - 100*int(c.retries)
+	} else {
+		score = int(RETRY_MAX)*1000 - int(c.retries)*1000 + 1
+	}
+
+	return score*1000 + max(c.alevel.rec, 0)
+}
+
+/* Time is up for one frame.  Pass along the best copy and discard the rest. */
+
+func (v *Voter) decide(g *vote_group_s, key string) {
+	v.mu.Lock()
+
+	var copies = g.pending[key]
+	delete(g.pending, key)
+
+	var best = copies[0]
+
+	for _, c := range copies[1:] {
+		if vote_score(c) > vote_score(best) {
+			best = c
+		}
+	}
+
+	var heard []string
+
+	for _, c := range copies {
+		if c != best {
+			AX25Delete(c.pp)
+		}
+
+		heard = append(heard, fmt.Sprintf("%d:%d", c.channel, c.alevel.rec))
+	}
+
+	g.frames++
+	g.copies += len(copies)
+	g.wins[best.channel]++
+
+	var report = ""
+	if g.frames%VOTE_REPORT_EVERY == 0 {
+		report = g.summary()
+	}
+
+	v.mu.Unlock()
+
+	if report != "" {
+		text_color_set(DW_COLOR_INFO)
+		dw_printf("%s\n", report)
+	}
+
+	var spectrum = fmt.Sprintf("vote %d/%d best %d (%s)", len(copies), len(g.channels), best.channel, strings.Join(heard, " "))
+	if best.spectrum != "" {
+		spectrum = best.spectrum + " " + spectrum
+	}
+
+	v.release(g.channels[0], best.subchan, best.slice, best.pp, best.alevel, best.fec_type, best.retries, spectrum)
+}
+
+/* e.g. "Vote 0 10 11: 100 frames, 2.4 copies each, best from channel 0 38, 10 55, 11 7" */
+
+func (g *vote_group_s) summary() string {
+	var s = "Vote"
+
+	for _, ch := range g.channels {
+		s += fmt.Sprintf(" %d", ch)
+	}
+
+	s += fmt.Sprintf(": %d frames, %.1f copies each, best from channel", g.frames, float64(g.copies)/float64(max(g.frames, 1)))
+
+	for i, ch := range g.channels {
+		if i > 0 {
+			s += ","
+		}
+
+		s += fmt.Sprintf(" %d %d", ch, g.wins[ch])
+	}
+
+	return s
+}
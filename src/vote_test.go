@@ -0,0 +1,133 @@
+package direwolf
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_config_init_vote(t *testing.T) {
+	var _, misc = configFromString(t, "NCHANNEL 10 localhost 8001\nNCHANNEL 11 localhost 8002\nVOTE 0 10 11 WINDOW=300\n")
+	require.Len(t, misc.vote, 1)
+	assert.Equal(t, []int{0, 10, 11}, misc.vote[0].channels)
+	assert.Equal(t, 300, misc.vote[0].window_ms)
+
+	_, misc = configFromString(t, "NCHANNEL 10 localhost 8001\nVOTE 10 0\n")
+	require.Len(t, misc.vote, 1)
+	assert.Equal(t, DEFAULT_VOTE_WINDOW, misc.vote[0].window_ms)
+
+	_, misc = configFromString(t, "VOTE 0\n") // Need two.
+	assert.Empty(t, misc.vote)
+
+	_, misc = configFromString(t, "VOTE 0 5\n") // Channel 5 isn't defined.
+	assert.Empty(t, misc.vote)
+
+	_, misc = configFromString(t, "NCHANNEL 10 localhost 8001\nNCHANNEL 11 localhost 8002\nVOTE 0 10\nVOTE 11 10\n")
+	assert.Len(t, misc.vote, 1)
+}
+
+type voteResult struct {
+	channel  int
+	pp       *packet_t
+	fec_type fec_type_t
+	alevel   ALevel
+	spectrum string
+}
+
+func newTestVoter(t *testing.T) (*Voter, *FakeClock, func() []voteResult) {
+	t.Helper()
+
+	var clock = NewFakeClock(time.Date(2026, 1, 1, 12, 0, 0, 0, time.UTC))
+
+	var v = NewVoter([]*vote_config_s{{channels: []int{0, 10, 11}, window_ms: 500}}, clock)
+
+	var mu sync.Mutex
+
+	var released []voteResult
+
+	v.release = func(channel int, _ int, _ int, pp *packet_t, alevel ALevel, fec_type fec_type_t, _ BitFixLevel, spectrum string) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		released = append(released, voteResult{channel: channel, pp: pp, fec_type: fec_type, alevel: alevel, spectrum: spectrum})
+	}
+
+	var get = func() []voteResult {
+		mu.Lock()
+		defer mu.Unlock()
+
+		return released
+	}
+
+	return v, clock, get
+}
+
+func Test_Voter_not_voting(t *testing.T) {
+	assert.Nil(t, NewVoter(nil, SystemClock{}))
+
+	var v, _, _ = newTestVoter(t)
+
+	var pp = AX25FromText("Q1TEST>Q2TEST:hello", true)
+	assert.False(t, v.Add(1, 0, 0, pp, ALevel{rec: 50}, fec_type_none, RETRY_NONE, ""))
+	AX25Delete(pp)
+}
+
+func Test_Voter_picks_best(t *testing.T) {
+	var v, clock, released = newTestVoter(t)
+
+	var heard = func() *packet_t {
+		var pp = AX25FromText("Q1TEST>Q2TEST,WIDE1-1:hello", true)
+		require.NotNil(t, pp)
+
+		return pp
+	}
+
+	assert.True(t, v.Add(0, 0, 0, heard(), ALevel{rec: 80}, fec_type_none, RETRY_INVERT_SINGLE, ""))
+	assert.True(t, v.Add(10, -3, 0, heard(), ALevel{rec: 0}, fec_type_fx25, 2, ""))
+	assert.True(t, v.Add(11, -3, 0, heard(), ALevel{rec: 0}, fec_type_none, RETRY_NONE, ""))
+
+	// A different frame is voted on separately.
+
+	var other = AX25FromText("Q2TEST>Q1TEST:other", true)
+	assert.True(t, v.Add(11, -3, 0, other, ALevel{rec: 0}, fec_type_none, RETRY_NONE, ""))
+
+	require.Eventually(t, func() bool { return clock.Sleepers() == 2 }, time.Second, time.Millisecond)
+	assert.Empty(t, released())
+
+	clock.Advance(500 * time.Millisecond)
+
+	require.Eventually(t, func() bool { return len(released()) == 2 }, time.Second, time.Millisecond)
+
+	for _, r := range released() {
+		assert.Equal(t, 0, r.channel, "all appear on the first channel")
+
+		if string(AX25GetInfo(r.pp)) == "hello" {
+			assert.Equal(t, fec_type_fx25, r.fec_type, "FEC wins")
+			assert.Contains(t, r.spectrum, "vote 3/3 best 10")
+		} else {
+			assert.Contains(t, r.spectrum, "vote 1/3 best 11")
+		}
+
+		AX25Delete(r.pp)
+	}
+
+	var g = v.group[0]
+	assert.Equal(t, 2, g.frames)
+	assert.Equal(t, 4, g.copies)
+	assert.Equal(t, 1, g.wins[10])
+	assert.Equal(t, 1, g.wins[11])
+	assert.Equal(t, "Vote 0 10 11: 2 frames, 2.0 copies each, best from channel 0 0, 10 1, 11 1", g.summary())
+}
+
+func Test_vote_score(t *testing.T) {
+	var score = func(rec int, retries BitFixLevel) int {
+		return vote_score(&vote_copy_s{alevel: ALevel{rec: rec}, fec_type: fec_type_none, retries: retries}) //nolint:exhaustruct
+	}
+
+	assert.Greater(t, score(90, RETRY_NONE), score(30, RETRY_NONE), "louder")
+	assert.Greater(t, score(30, RETRY_NONE), score(90, RETRY_INVERT_SINGLE), "fewer bits fixed")
+	assert.Greater(t, score(30, RETRY_NONE), score(0, RETRY_NONE), "network copy has no level")
+}
@@ -4,14 +4,28 @@ package direwolf
 // which was moved out of this package but still needs access to a few
 // unexported GPS internals.
 
+import "io"
+
 // DWGPSInit is a wrapper around dwgps_init, without exposing misc_config_s.
-func DWGPSInit(gpsnmeaPort string, debug int) {
+// speed 0 leaves the serial port speed alone.
+func DWGPSInit(gpsnmeaPort string, speed int, debug int) {
 	var config misc_config_s
 	config.gpsnmea_port = gpsnmeaPort
+	config.gpsnmea_speed = speed
+	config.gps_fixed_lat = G_UNKNOWN
+	config.gps_fixed_lon = G_UNKNOWN
+	config.gps_fixed_alt = G_UNKNOWN
 
 	dwgps_init(&config, debug)
 }
 
+// DWGPSReplay plays back a log of NMEA sentences in place of a GPS receiver,
+// at speed times real time, or as fast as possible for 0.
+// Use after DWGPSInit with no port.  The channel gets any read error at the end.
+func DWGPSReplay(r io.Reader, speed float64) <-chan error {
+	return dwgpsnmea_replay(r, speed)
+}
+
 // DWGPSRead is a wrapper around dwgps_read, without exposing dwgps_info_t.
 func DWGPSRead() (fix int, lat float64, lon float64, speedKnots float64, track float64, altitude float64) {
 	var info dwgps_info_t
@@ -19,3 +33,12 @@ func DWGPSRead() (fix int, lat float64, lon float64, speedKnots float64, track f
 
 	return int(f), info.dlat, info.dlon, info.speed_knots, info.track, info.altitude
 }
+
+// DWGPSReadQuality returns how good the fix from DWGPSRead is.
+// Each is G_UNKNOWN if the receiver hasn't said.
+func DWGPSReadQuality() (quality int, numSat int, pdop float64, hdop float64, vdop float64) {
+	var info dwgps_info_t
+	dwgps_read(&info)
+
+	return info.quality, info.num_sat, info.pdop, info.hdop, info.vdop
+}
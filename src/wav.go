@@ -0,0 +1,127 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:   	Find the format and samples of a WAV file.
+ *
+ * Description:	Shared by atest, receiving from a WAV file, and the
+ *		speech from espeak.  Each decides for itself which
+ *		formats it can use.
+ *
+ *		A WAV file is a RIFF file with a "fmt " chunk, then a
+ *		"data" chunk with the samples.  Anything else, such as
+ *		"LIST" or "fact", is skipped.
+ *
+ *---------------------------------------------------------------*/
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const WAV_FORMAT_PCM = 1
+
+const WAV_FORMAT_EXTENSIBLE = 0xfffe
+
+/* Some programs writing a stream don't know how long it will be. */
+
+const WAV_SIZE_UNKNOWN = 0xffffffff
+
+type wavFormat struct {
+	format     int /* WAV_FORMAT_PCM, from inside WAV_FORMAT_EXTENSIBLE if need be. */
+	channels   int
+	rate       int
+	blockAlign int /* Bytes for one sample of every channel. */
+	bits       int
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        wav_read_header
+ *
+ * Purpose:     Go through the chunks until the samples.
+ *
+ * Inputs:	r	- Start of the file.
+ *
+ * Returns:	Format, and size of the "data" chunk in bytes.  The
+ *		size can be 0 or WAV_SIZE_UNKNOWN for a stream.
+ *
+ *		r is left at the first sample.  Nothing more is read.
+ *
+ *--------------------------------------------------------------------*/
+
+func wav_read_header(r io.Reader) (wavFormat, uint32, error) {
+	var wf wavFormat
+
+	var riff [12]byte
+
+	var _, err = io.ReadFull(r, riff[:])
+	if err != nil || string(riff[0:4]) != "RIFF" || string(riff[8:12]) != "WAVE" {
+		return wf, 0, errors.New("not a WAV file")
+	}
+
+	var haveFormat = false
+
+	for {
+		var chunk [8]byte
+
+		_, err = io.ReadFull(r, chunk[:])
+		if err != nil {
+			return wf, 0, errors.New("no \"data\" chunk")
+		}
+
+		var id = string(chunk[0:4])
+		var size = binary.LittleEndian.Uint32(chunk[4:8])
+
+		switch id {
+		case "fmt ":
+			if size < 16 || size > 1024 {
+				return wf, 0, fmt.Errorf("\"fmt \" chunk size %d", size)
+			}
+
+			var body = make([]byte, size+size%2)
+
+			_, err = io.ReadFull(r, body)
+			if err != nil {
+				return wf, 0, errors.New("\"fmt \" chunk is cut short")
+			}
+
+			wf = wav_parse_format(body[:size])
+			haveFormat = true
+		case "data":
+			if !haveFormat {
+				return wf, 0, errors.New("\"data\" chunk before \"fmt \"")
+			}
+
+			return wf, size, nil
+		default:
+			// Chunks are padded to an even size.
+
+			_, err = io.CopyN(io.Discard, r, int64(size)+int64(size%2))
+			if err != nil {
+				return wf, 0, fmt.Errorf("\"%s\" chunk is cut short", strings.TrimSpace(id))
+			}
+		}
+	}
+}
+
+func wav_parse_format(body []byte) wavFormat {
+	var wf = wavFormat{
+		format:     int(binary.LittleEndian.Uint16(body[0:2])),
+		channels:   int(binary.LittleEndian.Uint16(body[2:4])),
+		rate:       int(binary.LittleEndian.Uint32(body[4:8])),
+		blockAlign: int(binary.LittleEndian.Uint16(body[12:14])),
+		bits:       int(binary.LittleEndian.Uint16(body[14:16])),
+	}
+
+	// WAVE_FORMAT_EXTENSIBLE has the real format at the start of a GUID.
+
+	if wf.format == WAV_FORMAT_EXTENSIBLE && len(body) >= 26 {
+		wf.format = int(binary.LittleEndian.Uint16(body[24:26]))
+	}
+
+	return wf
+}
@@ -0,0 +1,98 @@
+package direwolf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+/* A WAV file with a LIST chunk, of odd size, before the samples, the way some recorders do it. */
+
+func wavTestFile(format int, channels int, bits int, rate int, dataSize uint32, samples []byte) []byte {
+	var le = binary.LittleEndian
+	var b []byte
+
+	b = append(b, "RIFF"...)
+	b = le.AppendUint32(b, uint32(36+len(samples))) //nolint:gosec
+	b = append(b, "WAVE"...)
+
+	b = append(b, "fmt "...)
+	b = le.AppendUint32(b, 16)
+	b = le.AppendUint16(b, uint16(format))               //nolint:gosec
+	b = le.AppendUint16(b, uint16(channels))             //nolint:gosec
+	b = le.AppendUint32(b, uint32(rate))                 //nolint:gosec
+	b = le.AppendUint32(b, uint32(rate*channels*bits/8)) //nolint:gosec
+	b = le.AppendUint16(b, uint16(channels*bits/8))      //nolint:gosec
+	b = le.AppendUint16(b, uint16(bits))                 //nolint:gosec
+
+	b = append(b, "LIST"...)
+	b = le.AppendUint32(b, 3)
+	b = append(b, 'a', 'b', 'c', 0)
+
+	b = append(b, "data"...)
+	b = le.AppendUint32(b, dataSize)
+	b = append(b, samples...)
+
+	return b
+}
+
+/* 16 bit samples as they are in the file. */
+
+func wavTestSamples16(samples ...int16) []byte {
+	var b []byte
+
+	for _, s := range samples {
+		b = binary.LittleEndian.AppendUint16(b, uint16(s)) //nolint:gosec
+	}
+
+	return b
+}
+
+func Test_wav_read_header(t *testing.T) {
+	var r = bytes.NewReader(wavTestFile(WAV_FORMAT_PCM, 2, 16, 44100, 4, []byte{1, 2, 3, 4}))
+
+	var wf, size, err = wav_read_header(r)
+	require.NoError(t, err)
+	assert.Equal(t, wavFormat{format: WAV_FORMAT_PCM, channels: 2, rate: 44100, blockAlign: 4, bits: 16}, wf)
+	assert.Equal(t, uint32(4), size)
+
+	var rest, _ = io.ReadAll(r)
+	assert.Equal(t, []byte{1, 2, 3, 4}, rest, "left at the samples")
+}
+
+func Test_wav_read_header_extensible(t *testing.T) {
+	var b = wavTestFile(WAV_FORMAT_EXTENSIBLE, 1, 16, 8000, 0, nil)
+
+	// Make the "fmt " chunk 40 bytes, with PCM at the start of the GUID.
+
+	var ext = make([]byte, 24)
+	binary.LittleEndian.PutUint16(ext[0:2], 22)
+	binary.LittleEndian.PutUint16(ext[8:10], WAV_FORMAT_PCM)
+
+	binary.LittleEndian.PutUint32(b[16:20], 40)
+	b = append(b[:36], append(ext, b[36:]...)...)
+
+	var wf, _, err = wav_read_header(bytes.NewReader(b))
+	require.NoError(t, err)
+	assert.Equal(t, WAV_FORMAT_PCM, wf.format)
+}
+
+func Test_wav_read_header_bad(t *testing.T) {
+	var good = wavTestFile(WAV_FORMAT_PCM, 1, 16, 8000, 0, nil)
+
+	for name, b := range map[string][]byte{
+		"not a WAV":     []byte("not a WAV file at all"),
+		"no data":       good[:len(good)-8],
+		"cut short":     good[:40],
+		"data first":    append([]byte("RIFF\x00\x00\x00\x00WAVE"), good[len(good)-8:]...),
+		"tiny fmt":      append([]byte("RIFF\x00\x00\x00\x00WAVEfmt \x02\x00\x00\x00"), 1, 0),
+		"truncated fmt": good[:30],
+	} {
+		var _, _, err = wav_read_header(bytes.NewReader(b))
+		assert.Error(t, err, name)
+	}
+}
@@ -4,6 +4,12 @@ package direwolf
  *
  * Purpose:   	Send NMEA waypoint sentences to GPS display or mapping application.
  *
+ * Description:	Destinations can be a serial port, UDP to one host, or
+ *		a TCP port which any number of applications can connect
+ *		to.  OpenCPN, for example, can add a TCP network connection
+ *		to this port, usually 10110, and show APRS stations on the
+ *		chart.
+ *
  *---------------------------------------------------------------*/
 
 import (
@@ -11,6 +17,8 @@ import (
 	"net"
 	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/pkg/term"
@@ -19,8 +27,12 @@ import (
 type WaypointSender struct {
 	serialPortFd *term.Term
 	udpSock      net.Conn
-	formats      int // which formats should we generate?
-	debug        int // Print information flowing to attached device.
+	formats      int          // which formats should we generate?
+	debug        atomic.Int32 // Print information flowing to attached device.
+
+	tcpListener net.Listener
+	tcpMu       sync.Mutex
+	tcpClients  map[net.Conn]bool // Connected applications.
 }
 
 /*-------------------------------------------------------------------
@@ -37,6 +49,8 @@ type WaypointSender struct {
  *
  *		  ->waypoint_udp_portnum	- UDP port number.
  *
+ *		  ->waypoint_tcp_port	- Listen for applications to connect here.
+ *
  *		  (currently none)	- speed, baud.  Default 4800 if not set
  *
  *
@@ -46,6 +60,7 @@ type WaypointSender struct {
  * Description:	First to see if this is shared with GPS input.
  *		If not, open serial port.
  *		In version 1.6 UDP is added.  It is possible to use both.
+ *		TCP can be used along with them too.
  *
  * Restriction:	MUST be done after GPS init because we might be sharing the
  *		same serial port device.
@@ -64,6 +79,7 @@ func NewWaypointSender(mc *misc_config_s) (*WaypointSender, error) {
 
 	var udpRequested = mc.waypoint_udp_portnum > 0
 	var serialRequested = mc.waypoint_serial_port != ""
+	var tcpRequested = mc.waypoint_tcp_port > 0
 
 	if udpRequested {
 		var addr = net.JoinHostPort(mc.waypoint_udp_hostname, strconv.Itoa(mc.waypoint_udp_portnum))
@@ -77,6 +93,19 @@ func NewWaypointSender(mc *misc_config_s) (*WaypointSender, error) {
 		}
 	}
 
+	if tcpRequested {
+		var listener, err = net.Listen("tcp", ":"+strconv.Itoa(mc.waypoint_tcp_port))
+		if err != nil {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Couldn't listen on TCP port %d for waypoint output: %s\n", mc.waypoint_tcp_port, err)
+		} else {
+			ws.tcpListener = listener
+			ws.tcpClients = make(map[net.Conn]bool)
+
+			go ws.acceptTCP(listener)
+		}
+	}
+
 	/*
 	 * TODO:
 	 * Are we sharing with GPS input?
@@ -102,7 +131,7 @@ func NewWaypointSender(mc *misc_config_s) (*WaypointSender, error) {
 	// If the user asked for waypoint output but every destination they
 	// asked for failed to open, don't hand back a sender that will
 	// silently do nothing on every SendSentence/SendAIS call.
-	if (udpRequested || serialRequested) && ws.udpSock == nil && ws.serialPortFd == nil {
+	if (udpRequested || serialRequested || tcpRequested) && !ws.hasDestination() {
 		var requested []string
 		if udpRequested {
 			requested = append(requested, fmt.Sprintf("UDP %s", net.JoinHostPort(mc.waypoint_udp_hostname, strconv.Itoa(mc.waypoint_udp_portnum))))
@@ -110,6 +139,9 @@ func NewWaypointSender(mc *misc_config_s) (*WaypointSender, error) {
 		if serialRequested {
 			requested = append(requested, fmt.Sprintf("serial port %s", mc.waypoint_serial_port))
 		}
+		if tcpRequested {
+			requested = append(requested, fmt.Sprintf("TCP port %d", mc.waypoint_tcp_port))
+		}
 
 		return nil, fmt.Errorf("waypoint output requested but no destination could be opened (%s)", strings.Join(requested, ", "))
 	}
@@ -129,7 +161,36 @@ func NewWaypointSender(mc *misc_config_s) (*WaypointSender, error) {
 }
 
 func (ws *WaypointSender) SetDebug(n int) {
-	ws.debug = n
+	ws.debug.Store(int32(n))
+}
+
+func (ws *WaypointSender) hasDestination() bool {
+	return ws.serialPortFd != nil || ws.udpSock != nil || ws.tcpListener != nil
+}
+
+/* Keep accepting applications until the listener is closed. */
+
+func (ws *WaypointSender) acceptTCP(listener net.Listener) {
+	for {
+		var conn, err = listener.Accept()
+		if err != nil {
+			return
+		}
+
+		ws.tcpMu.Lock()
+		if ws.tcpClients == nil { // Closed meanwhile.
+			ws.tcpMu.Unlock()
+			conn.Close()
+
+			return
+		}
+
+		ws.tcpClients[conn] = true
+		ws.tcpMu.Unlock()
+
+		text_color_set(DW_COLOR_INFO)
+		dw_printf("Waypoint output connection from %s.\n", conn.RemoteAddr())
+	}
 }
 
 /*-------------------------------------------------------------------
@@ -178,8 +239,8 @@ func appendChecksum(sentence []byte) []byte {
  *		comment_in	- Description or message.
  *
  *
- * Description:	Currently we send multiple styles.  Maybe someday there might
- * 		be an option to send a selected subset.
+ * Description:	Send whichever of these styles were selected by the
+ *		formats on the WAYPOINT configuration line.
  *
  *			$GPWPL		- NMEA generic with only location and name.
  *			$PGRMW		- Garmin, adds altitude, symbol, and comment
@@ -211,7 +272,7 @@ func (ws *WaypointSender) SendSentence(name_in string, dlat float64, dlong float
 	*/
 
 	// Don't waste time if no destinations specified.
-	if ws.serialPortFd == nil && ws.udpSock == nil {
+	if !ws.hasDestination() {
 		return
 	}
 
@@ -527,7 +588,7 @@ func (ws *WaypointSender) SendSentence(name_in string, dlat float64, dlong float
  *--------------------------------------------------------------------*/
 
 func (ws *WaypointSender) SendAIS(sentence []byte) {
-	if ws.serialPortFd == nil && ws.udpSock == nil {
+	if !ws.hasDestination() {
 		return
 	}
 
@@ -546,6 +607,18 @@ func (ws *WaypointSender) Close() {
 		ws.udpSock.Close()
 		ws.udpSock = nil
 	}
+
+	if ws.tcpListener != nil {
+		ws.tcpListener.Close()
+		ws.tcpListener = nil
+
+		ws.tcpMu.Lock()
+		for conn := range ws.tcpClients {
+			conn.Close()
+		}
+		ws.tcpClients = nil
+		ws.tcpMu.Unlock()
+	}
 }
 
 /*
@@ -553,7 +626,7 @@ func (ws *WaypointSender) Close() {
  */
 
 func (ws *WaypointSender) send(sentence []byte) {
-	if ws.debug > 0 {
+	if ws.debug.Load() > 0 {
 		text_color_set(DW_COLOR_XMIT)
 		dw_printf("waypoint send sentence: \"%s\"\n", sentence)
 	}
@@ -575,4 +648,20 @@ func (ws *WaypointSender) send(sentence []byte) {
 			dw_printf("Failed to send waypoint via UDP, err=%s\n", err)
 		}
 	}
+
+	ws.tcpMu.Lock()
+	defer ws.tcpMu.Unlock()
+
+	for conn := range ws.tcpClients {
+		// Don't let one stuck application hold up everything else.
+		_ = conn.SetWriteDeadline(time.Now().Add(5 * time.Second))
+
+		var _, err = conn.Write(final)
+		if err != nil {
+			text_color_set(DW_COLOR_INFO)
+			dw_printf("Waypoint output connection from %s closed.\n", conn.RemoteAddr())
+			conn.Close()
+			delete(ws.tcpClients, conn)
+		}
+	}
 } /* send */
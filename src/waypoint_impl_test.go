@@ -5,6 +5,7 @@ package direwolf
 // A failing test may thus be a problem with the test itself rather than any future changes...
 
 import (
+	"bufio"
 	"fmt"
 	"net"
 	"strings"
@@ -395,3 +396,55 @@ func TestNewWaypointSenderUDPFailureReturnsError(t *testing.T) {
 	assert.Nil(t, ws)
 	assert.Contains(t, err.Error(), "12345", "error should identify the destination that failed to open")
 }
+
+// TestWaypointSendTCP verifies sentences go to each connected application.
+func TestWaypointSendTCP(t *testing.T) {
+	var mc = misc_config_s{ //nolint: exhaustruct
+		waypoint_tcp_port: 0, // Set below.
+		waypoint_formats:  WPL_FORMAT_NMEA_GENERIC,
+	}
+
+	// Find a free port.
+	var probe, err = net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	mc.waypoint_tcp_port = probe.Addr().(*net.TCPAddr).Port //nolint:forcetypeassert
+	require.NoError(t, probe.Close())
+
+	var ws, sendErr = NewWaypointSender(&mc)
+	require.NoError(t, sendErr)
+	t.Cleanup(ws.Close)
+
+	var addr = fmt.Sprintf("127.0.0.1:%d", mc.waypoint_tcp_port)
+	var conns []net.Conn
+	for range 2 {
+		var conn, dialErr = net.Dial("tcp", addr)
+		require.NoError(t, dialErr)
+		t.Cleanup(func() { conn.Close() }) //nolint:errcheck
+		conns = append(conns, conn)
+	}
+
+	require.Eventually(t, func() bool {
+		ws.tcpMu.Lock()
+		defer ws.tcpMu.Unlock()
+
+		return len(ws.tcpClients) == 2
+	}, 2*time.Second, 10*time.Millisecond)
+
+	ws.SendSentence("TEST", 42.0, -71.0, '/', 'a', G_UNKNOWN, G_UNKNOWN, G_UNKNOWN, "")
+	ws.SendAIS([]byte("!AIVDM,1,1,,A,35NO=dPOiAJriVDH@94E84AJ0000,0*4B")) // Not selected.
+	ws.SendSentence("TEST2", 42.0, -71.0, '/', 'a', G_UNKNOWN, G_UNKNOWN, G_UNKNOWN, "")
+
+	for _, conn := range conns {
+		require.NoError(t, conn.SetReadDeadline(time.Now().Add(2*time.Second)))
+
+		var r = bufio.NewReader(conn)
+		var line, readErr = r.ReadString('\n')
+		require.NoError(t, readErr)
+		assert.True(t, strings.HasPrefix(line, "$GPWPL,"), line)
+		assert.Contains(t, line, "TEST*")
+
+		line, readErr = r.ReadString('\n')
+		require.NoError(t, readErr)
+		assert.Contains(t, line, "TEST2*")
+	}
+}
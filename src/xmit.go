@@ -37,8 +37,6 @@ import (
 	"strconv"
 	"sync"
 	"time"
-
-	"github.com/lestrrat-go/strftime"
 )
 
 const MORSE_DEFAULT_WPM = 10
@@ -79,10 +77,14 @@ type XmitService struct {
 	/*
 	 * When an audio device is in stereo mode, we can have two
 	 * different channels that want to transmit at the same time.
-	 * We are not clever enough to multiplex them so use this
-	 * so only one is active at the same time.
+	 * We are not clever enough to multiplex them so they take
+	 * turns.  See tx_arbiter.go.
 	 */
-	audioOutDevMutex [MAX_ADEVS]sync.Mutex
+	arbiter [MAX_ADEVS]TxArbiter
+
+	tx_priority [TX_NUM_REASONS]int /* TXPRIORITY */
+
+	tx_slice time.Duration /* TXSLICE.  0 for no limit. */
 
 	p_modem *audio_s
 }
@@ -153,6 +155,9 @@ func NewXmitService(p_modem *audio_s, debug_xmit_packet bool) *XmitService {
 		xs.fulldup[j] = p_modem.achan[j].fulldup
 	}
 
+	xs.tx_priority = p_modem.tx_priority
+	xs.tx_slice = time.Duration(p_modem.tx_slice) * time.Second
+
 	/* TODO KG
 	#if DEBUG
 		text_color_set(DW_COLOR_DEBUG);
@@ -160,6 +165,7 @@ func NewXmitService(p_modem *audio_s, debug_xmit_packet bool) *XmitService {
 	#endif
 	*/
 	tq_init(p_modem)
+	response_queue_init(p_modem)
 
 	/* TODO KG
 	#if DEBUG
@@ -194,6 +200,7 @@ func NewXmitService(p_modem *audio_s, debug_xmit_packet bool) *XmitService {
  *		SetSlottime
  *		SetTxtail
  *		SetFulldup
+ *		SetBitsPerSec
  *
  *
  * Purpose:     The KISS protocol, and maybe others, can specify
@@ -201,6 +208,8 @@ func NewXmitService(p_modem *audio_s, debug_xmit_packet bool) *XmitService {
  *		specifies these, they will override what was read
  *		from the configuration file.
  *
+ *		A channel profile can change the speed.
+ *
  * Inputs:	channel	- should be 0 or 1.
  *
  *		value	- time values are in 10 mSec units.
@@ -245,6 +254,12 @@ func (xs *XmitService) SetFulldup(channel int, value bool) {
 	}
 }
 
+func (xs *XmitService) SetBitsPerSec(channel, value int) {
+	if channel >= 0 && channel < MAX_RADIO_CHANS {
+		xs.bits_per_sec[channel] = value
+	}
+}
+
 /*-------------------------------------------------------------------
  *
  * Name:        frame_flavor
@@ -370,7 +385,7 @@ func (xs *XmitService) xmit_thread(channel int) {
 			 * If there is something in the high priority queue, begin transmitting immediately.
 			 * Otherwise, wait a random amount of time, in hopes of minimizing collisions.
 			 */
-			var ok = xs.wait_for_clear_channel(channel, xs.slottime[channel], xs.persist[channel], xs.fulldup[channel])
+			var ok = xs.wait_for_clear_channel(channel, xs.slottime[channel], xs.persist[channel], xs.fulldup[channel], xs.next_priority(channel))
 
 			var prio = TQ_PRIO_1_LO
 
@@ -390,6 +405,8 @@ func (xs *XmitService) xmit_thread(channel int) {
 			// Shouldn't have nil here but be careful.
 
 			if pp != nil {
+				var response = pp.response
+
 				if ok {
 					/*
 					 * Channel is clear and we have lock on output device.
@@ -447,9 +464,9 @@ func (xs *XmitService) xmit_thread(channel int) {
 						xs.xmit_ax25_frames(channel, prio, pp, 256)
 					}
 
-					// Corresponding lock is in wait_for_clear_channel.
+					// Our turn started in wait_for_clear_channel.
 
-					xs.audioOutDevMutex[ACHAN2ADEV(channel)].Unlock()
+					xs.arbiter[ACHAN2ADEV(channel)].Release()
 				} else {
 					/*
 					 * Timeout waiting for clear channel.
@@ -459,18 +476,26 @@ func (xs *XmitService) xmit_thread(channel int) {
 					text_color_set(DW_COLOR_ERROR)
 					dw_printf("Waited too long for clear channel.  Discarding packet below.\n")
 
+					trace_printf(pp, "not transmitted on channel %d, waited too long for clear channel", channel)
+
 					var stemp = AX25FormatAddrs(pp)
 
 					var pinfo = AX25GetInfo(pp)
 
 					text_color_set(DW_COLOR_INFO)
-					dw_printf("[%d%c] ", channel, priorityToRune(prio))
+					dw_printf("[%d%c%s] ", channel, priorityToRune(prio), chan_name_suffix(xs.p_modem, channel))
 
 					dw_printf("%s", stemp) /* stations followed by : */
 					AX25SafePrint(pinfo, !ax25_is_aprs(pp))
 					dw_printf("\n")
 					AX25Delete(pp)
 				} /* wait for clear channel error. */
+
+				// Let the response queue release the next one.
+
+				if response {
+					response_queue.Done(channel)
+				}
 			} /* Have pp */
 		} /* while queue not empty */
 	} /* while 1 */
@@ -577,6 +602,11 @@ func (xs *XmitService) xmit_ax25_frames(channel int, prio int, pp *packet_t, max
 	*/
 	ptt_set(OCTYPE_PTT, channel, 1)
 
+	// Audio timing starts now, after any TXSEQ delays.
+	var time_audio = time.Now()
+
+	var reasons []tx_reason_t // For each frame sent.
+
 	// Inform data link state machine that we are now transmitting.
 
 	dlq_seize_confirm(channel) // C4.2.  "This primitive indicates, to the Data-link State
@@ -624,6 +654,7 @@ func (xs *XmitService) xmit_ax25_frames(channel int, prio int, pp *packet_t, max
 	num_bits += nb
 	if nb > 0 {
 		numframe++
+		reasons = append(reasons, pp.tx_reason)
 	}
 	/* TODO KG
 	#if DEBUG
@@ -639,6 +670,14 @@ func (xs *XmitService) xmit_ax25_frames(channel int, prio int, pp *packet_t, max
 
 	var done = false
 	for numframe < max_bundle && !done {
+		/*
+		 * Give another channel sharing the transmitter a turn.
+		 * What's left goes in our next transmission.
+		 */
+		if xs.tx_slice > 0 && time.Since(time_ptt) >= xs.tx_slice && xs.arbiter[ACHAN2ADEV(channel)].Contended() {
+			break
+		}
+
 		/*
 		 * Peek at what is available.
 		 * Don't remove from queue yet because it might not be eligible.
@@ -671,6 +710,7 @@ func (xs *XmitService) xmit_ax25_frames(channel int, prio int, pp *packet_t, max
 				num_bits += nb
 				if nb > 0 {
 					numframe++
+					reasons = append(reasons, pp.tx_reason)
 				}
 				/* TODO KG
 				#if DEBUG
@@ -722,7 +762,7 @@ func (xs *XmitService) xmit_ax25_frames(channel int, prio int, pp *packet_t, max
 	 * Wait additional time if necessary.
 	 */
 
-	var already = time.Since(time_ptt)
+	var already = time.Since(time_audio)
 	var wait_more = time.Duration(durationMS)*time.Millisecond - already
 
 	/* TODO KG
@@ -757,6 +797,7 @@ func (xs *XmitService) xmit_ax25_frames(channel int, prio int, pp *packet_t, max
 	*/
 
 	ptt_set(OCTYPE_PTT, channel, 0)
+	txActivity.Record(channel, time_ptt, time.Now(), reasons)
 } /* end xmit_ax25_frames */
 
 /*-------------------------------------------------------------------
@@ -815,7 +856,7 @@ func (xs *XmitService) send_one_frame(c int, p int, pp *packet_t) int {
 					ts);
 		#else
 	*/
-	dw_printf("[%d%c%s] ", c, priorityToRune(p), ts)
+	dw_printf("[%d%c%s%s] ", c, priorityToRune(p), chan_name_suffix(xs.p_modem, c), ts)
 	/* #endif */
 	dw_printf("%s", stemp) /* stations followed by : */
 
@@ -840,6 +881,8 @@ func (xs *XmitService) send_one_frame(c int, p int, pp *packet_t) int {
 
 	ax25_check_addresses(pp)
 
+	trace_printf(pp, "transmitted on channel %d", c)
+
 	/* Optional hex dump of packet. */
 
 	if xs.debugXmitPacket {
@@ -867,6 +910,8 @@ func (xs *XmitService) send_one_frame(c int, p int, pp *packet_t) int {
 		}
 	}
 
+	pcapWriter.WriteFrame(time.Now(), AX25Pack(pp), pcap_xmit_comment(c, p, xs.p_modem))
+
 	var nb = layer2_send_frame(c, pp, send_invalid_fcs2, xs.p_modem)
 
 	// Optionally send confirmation to AGW client app if monitoring enabled.
@@ -893,6 +938,9 @@ func (xs *XmitService) send_one_frame(c int, p int, pp *packet_t) int {
  *		Invoke the text-to-speech script.
  *		Turn off transmitter.
  *
+ *		With SPEECH ESPEAK, get the speech from espeak and send
+ *		it ourselves.  If neither is available, send Morse code.
+ *
  *--------------------------------------------------------------------*/
 
 func (xs *XmitService) xmit_speech(c int, pp *packet_t) {
@@ -904,20 +952,41 @@ func (xs *XmitService) xmit_speech(c int, pp *packet_t) {
 	var pinfo = AX25GetInfo(pp)
 
 	text_color_set(DW_COLOR_XMIT)
-	dw_printf("[%d.speech%s] \"%s\"\n", c, ts, string(pinfo))
+	dw_printf("[%d.speech%s%s] \"%s\"\n", c, chan_name_suffix(xs.p_modem, c), ts, string(pinfo))
+
+	if xs.p_modem.tts_espeak != "" {
+		var samples, rate, err = speech_espeak(xs.p_modem.tts_espeak, xs.p_modem.tts_voice, string(pinfo))
+		if err == nil {
+			xs.xmit_speech_samples(c, pp, samples, rate)
+			return
+		}
 
-	if xs.p_modem.tts_script == "" {
 		text_color_set(DW_COLOR_ERROR)
-		dw_printf("Text-to-speech script has not been configured.\n")
-		AX25Delete(pp)
+		dw_printf("Text-to-speech failed, sending Morse code instead.  %s\n", err)
+	}
+
+	if xs.p_modem.tts_script == "" {
+		if xs.p_modem.tts_espeak == "" {
+			text_color_set(DW_COLOR_ERROR)
+			dw_printf("Text-to-speech has not been configured.  Sending Morse code instead.\n")
+		}
+
+		xs.xmit_morse(c, pp, MORSE_DEFAULT_WPM)
 
 		return
 	}
 
+	// The script probably uses one sound card for every channel,
+	// so don't let speech for two channels talk over each other.
+
+	xmit_speech_script_mutex.Lock()
+	defer xmit_speech_script_mutex.Unlock()
+
 	/*
 	 * Turn on transmitter.
 	 */
 	ptt_set(OCTYPE_PTT, c, 1)
+	var start_ptt = time.Now()
 
 	/*
 	 * Invoke the speech-to-text script.
@@ -930,9 +999,32 @@ func (xs *XmitService) xmit_speech(c int, pp *packet_t) {
 	 */
 
 	ptt_set(OCTYPE_PTT, c, 0)
+	txActivity.Record(c, start_ptt, time.Now(), []tx_reason_t{pp.tx_reason})
 	AX25Delete(pp)
 } /* end xmit_speech */
 
+/* Built in speech goes out through our own audio device, like Morse code. */
+
+func (xs *XmitService) xmit_speech_samples(c int, pp *packet_t, samples []int16, rate int) {
+	ptt_set(OCTYPE_PTT, c, 1)
+	var start_ptt = time.Now()
+
+	var length_ms = speech_send(c, samples, rate, max(xs.txdelay[c]*10, 300), max(xs.txtail[c]*10, 250))
+
+	// there is probably still sound queued up in the output buffers.
+
+	var timeToWait = time.Until(start_ptt.Add(time.Duration(length_ms) * time.Millisecond))
+	if timeToWait.Milliseconds() > 0 {
+		SLEEP_MS(int(timeToWait.Milliseconds()))
+	}
+
+	ptt_set(OCTYPE_PTT, c, 0)
+	txActivity.Record(c, start_ptt, time.Now(), []tx_reason_t{pp.tx_reason})
+	AX25Delete(pp)
+}
+
+var xmit_speech_script_mutex sync.Mutex //nolint:gochecknoglobals
+
 /* Broken out into separate function so configuration can validate it. */
 
 func xmit_speak_it(script string, c int, msg string) error {
@@ -953,12 +1045,7 @@ func xmit_speak_it(script string, c int, msg string) error {
 }
 
 func (xs *XmitService) timestampPrefix() string {
-	if xs.p_modem.timestamp_format != "" {
-		var formattedTime, _ = strftime.Format(xs.p_modem.timestamp_format, time.Now())
-		return " " + formattedTime // space after channel.
-	}
-
-	return ""
+	return monitor_timestamp(xs.p_modem, time.Now())
 }
 
 /*-------------------------------------------------------------------
@@ -989,7 +1076,7 @@ func (xs *XmitService) xmit_morse(c int, pp *packet_t, wpm int) {
 	var pinfo = AX25GetInfo(pp)
 
 	text_color_set(DW_COLOR_XMIT)
-	dw_printf("[%d.morse%s] \"%s\"\n", c, ts, string(pinfo))
+	dw_printf("[%d.morse%s%s] \"%s\"\n", c, chan_name_suffix(xs.p_modem, c), ts, string(pinfo))
 
 	ptt_set(OCTYPE_PTT, c, 1)
 	var start_ptt = time.Now()
@@ -1009,6 +1096,7 @@ func (xs *XmitService) xmit_morse(c int, pp *packet_t, wpm int) {
 	}
 
 	ptt_set(OCTYPE_PTT, c, 0)
+	txActivity.Record(c, start_ptt, time.Now(), []tx_reason_t{pp.tx_reason})
 	AX25Delete(pp)
 } /* end xmit_morse */
 
@@ -1040,7 +1128,7 @@ func (xs *XmitService) xmit_dtmf(c int, pp *packet_t, speed int) {
 	var pinfo = AX25GetInfo(pp)
 
 	text_color_set(DW_COLOR_XMIT)
-	dw_printf("[%d.dtmf%s] \"%s\"\n", c, ts, string(pinfo))
+	dw_printf("[%d.dtmf%s%s] \"%s\"\n", c, chan_name_suffix(xs.p_modem, c), ts, string(pinfo))
 
 	ptt_set(OCTYPE_PTT, c, 1)
 	var start_ptt = time.Now()
@@ -1063,6 +1151,7 @@ func (xs *XmitService) xmit_dtmf(c int, pp *packet_t, speed int) {
 	}
 
 	ptt_set(OCTYPE_PTT, c, 0)
+	txActivity.Record(c, start_ptt, time.Now(), []tx_reason_t{pp.tx_reason})
 	AX25Delete(pp)
 } /* end xmit_dtmf */
 
@@ -1125,7 +1214,7 @@ func (xs *XmitService) xmit_dtmf(c int, pp *packet_t, speed int) {
 const WAIT_TIMEOUT_MS = 60 * 1000
 const WAIT_CHECK_EVERY_MS = 10
 
-func (xs *XmitService) wait_for_clear_channel(channel int, slottime int, persist int, fulldup bool) bool {
+func (xs *XmitService) wait_for_clear_channel(channel int, slottime int, persist int, fulldup bool, priority int) bool {
 	/*
 	 * For full duplex we skip the channel busy check and random wait.
 	 * We still need to wait if operating in stereo and the other audio
@@ -1187,19 +1276,25 @@ func (xs *XmitService) wait_for_clear_channel(channel int, slottime int, persist
 	 * That also allows better use of multiple cores for receiving.
 	 */
 
-	// TODO: review this.
+	var remaining = time.Duration(WAIT_TIMEOUT_MS-n*WAIT_CHECK_EVERY_MS) * time.Millisecond
 
-	for !xs.audioOutDevMutex[ACHAN2ADEV(channel)].TryLock() {
-		SLEEP_MS(WAIT_CHECK_EVERY_MS)
+	return xs.arbiter[ACHAN2ADEV(channel)].Acquire(channel, priority, max(remaining, 0))
+} /* end wait_for_clear_channel */
 
-		n++
-		if n > (WAIT_TIMEOUT_MS / WAIT_CHECK_EVERY_MS) {
-			return false
-		}
+/* TXPRIORITY for what will be sent next. */
+
+func (xs *XmitService) next_priority(channel int) int {
+	var pp = tq_peek(channel, TQ_PRIO_0_HI)
+	if pp == nil {
+		pp = tq_peek(channel, TQ_PRIO_1_LO)
 	}
 
-	return true
-} /* end wait_for_clear_channel */
+	if pp == nil {
+		return 0
+	}
+
+	return xs.tx_priority[pp.tx_reason]
+}
 
 func (xs *XmitService) bitsToMS(b, ch int) int {
 	return b * 1000 / xs.bits_per_sec[ch]
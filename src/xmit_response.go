@@ -0,0 +1,228 @@
+package direwolf
+
+/*------------------------------------------------------------------
+ *
+ * Purpose:	Hold audible responses (speech, Morse code, touch tones)
+ *		so they go out one at a time with a rest in between.
+ *
+ * Description:	A burst of APRStt activity could otherwise pile up
+ *		responses in the transmit queue which would all go out
+ *		back to back, keeping the transmitter on with nobody
+ *		else getting a word in.
+ *
+ *		tq_append hands these frames to us rather than putting
+ *		them in the transmit queue.  A thread for each channel
+ *		releases one at a time, high priority first.  The next
+ *		isn't released until the last one has been sent and
+ *		the channel has had a rest.
+ *
+ *			RESPONSEGAP  seconds  [ max-waiting ]
+ *
+ *		Responses beyond max-waiting are discarded.
+ *
+ *------------------------------------------------------------------*/
+
+import (
+	"sync"
+	"time"
+)
+
+const DEFAULT_RESPONSE_GAP = 3 /* Seconds between the end of one response and the next. */
+const DEFAULT_RESPONSE_MAX = 5 /* Responses waiting for each channel. */
+
+type ResponseQueue struct {
+	mu   sync.Mutex
+	cond *sync.Cond
+
+	pending [MAX_RADIO_CHANS][TQ_NUM_PRIO][]*packet_t
+
+	busy [MAX_RADIO_CHANS]bool /* One has been released and not sent yet. */
+
+	done [MAX_RADIO_CHANS]time.Time /* When the last one was sent. */
+
+	gap [MAX_RADIO_CHANS]time.Duration
+	max [MAX_RADIO_CHANS]int
+
+	release func(channel int, prio int, pp *packet_t) /* tq_append, replaced for testing. */
+}
+
+var response_queue *ResponseQueue //nolint:gochecknoglobals
+
+func NewResponseQueue(p_modem *audio_s) *ResponseQueue {
+	var rq = &ResponseQueue{release: tq_append} //nolint:exhaustruct
+	rq.cond = sync.NewCond(&rq.mu)
+
+	for c := range MAX_RADIO_CHANS {
+		rq.gap[c] = time.Duration(p_modem.achan[c].response_gap) * time.Second
+		rq.max[c] = p_modem.achan[c].response_max
+	}
+
+	return rq
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        response_queue_init
+ *
+ * Purpose:     Start diverting audible responses through the response
+ *		queue, with a thread for each radio channel.
+ *
+ *--------------------------------------------------------------------*/
+
+func response_queue_init(p_modem *audio_s) {
+	response_queue = NewResponseQueue(p_modem)
+
+	for c := range MAX_RADIO_CHANS {
+		if p_modem.chan_medium[c] == MEDIUM_RADIO {
+			go response_queue.thread(c)
+		}
+	}
+}
+
+/* Speech, Morse code and touch tones are audible responses. */
+
+func is_response(pp *packet_t) bool {
+	switch frame_flavor(pp) {
+	case FLAVOR_SPEECH, FLAVOR_MORSE, FLAVOR_DTMF:
+		return true
+	default:
+		return false
+	}
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        Add
+ *
+ * Purpose:     Take an audible response from tq_append.
+ *
+ * Returns:	true if we took it, or discarded it, and the caller
+ *		should do nothing more.
+ *		false for other frames, and for responses we have
+ *		already released, which go in the transmit queue.
+ *
+ *--------------------------------------------------------------------*/
+
+func (rq *ResponseQueue) Add(channel int, prio int, pp *packet_t) bool {
+	if pp.response || !is_response(pp) {
+		return false
+	}
+
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+
+	var waiting = len(rq.pending[channel][TQ_PRIO_0_HI]) + len(rq.pending[channel][TQ_PRIO_1_LO])
+	if waiting >= rq.max[channel] {
+		text_color_set(DW_COLOR_ERROR)
+		dw_printf("Too many audible responses waiting for channel %d.  Discarding \"%s\".\n", channel, string(AX25GetInfo(pp)))
+		AX25Delete(pp)
+
+		return true
+	}
+
+	rq.pending[channel][prio] = append(rq.pending[channel][prio], pp)
+	rq.cond.Broadcast()
+
+	return true
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        Count
+ *
+ * Purpose:     How many responses are waiting for a channel.
+ *
+ * Description:	Once released, a response is in the transmit queue
+ *		and counted there instead.
+ *
+ *--------------------------------------------------------------------*/
+
+func (rq *ResponseQueue) Count(channel int) int {
+	rq.mu.Lock()
+	defer rq.mu.Unlock()
+
+	return len(rq.pending[channel][TQ_PRIO_0_HI]) + len(rq.pending[channel][TQ_PRIO_1_LO])
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        next
+ *
+ * Purpose:     Pick the response to release next, if it is time.
+ *
+ * Inputs:	channel	- Radio channel.
+ *		now	- Current time.
+ *
+ * Returns:	Packet and its priority, or nil and how long to wait
+ *		before asking again.  0 means wait for Add or Done.
+ *
+ * Description:	Caller must hold rq.mu.
+ *
+ *--------------------------------------------------------------------*/
+
+func (rq *ResponseQueue) next(channel int, now time.Time) (*packet_t, int, time.Duration) {
+	if rq.busy[channel] {
+		return nil, 0, 0
+	}
+
+	var wait = rq.done[channel].Add(rq.gap[channel]).Sub(now)
+
+	for prio := range TQ_NUM_PRIO {
+		if len(rq.pending[channel][prio]) == 0 {
+			continue
+		}
+
+		if wait > 0 {
+			return nil, 0, wait
+		}
+
+		var pp = rq.pending[channel][prio][0]
+		rq.pending[channel][prio] = rq.pending[channel][prio][1:]
+		rq.busy[channel] = true
+		pp.response = true
+
+		return pp, prio, 0
+	}
+
+	return nil, 0, 0
+}
+
+/*-------------------------------------------------------------------
+ *
+ * Name:        Done
+ *
+ * Purpose:     Called by the transmit thread when a released response
+ *		has been sent, or discarded, so the rest can start.
+ *
+ *--------------------------------------------------------------------*/
+
+func (rq *ResponseQueue) Done(channel int) {
+	rq.mu.Lock()
+	rq.busy[channel] = false
+	rq.done[channel] = time.Now()
+	rq.cond.Broadcast()
+	rq.mu.Unlock()
+}
+
+func (rq *ResponseQueue) thread(channel int) {
+	for {
+		rq.mu.Lock()
+
+		var pp, prio, wait = rq.next(channel, time.Now())
+		for pp == nil {
+			if wait > 0 {
+				rq.mu.Unlock()
+				time.Sleep(wait)
+				rq.mu.Lock()
+			} else {
+				rq.cond.Wait()
+			}
+
+			pp, prio, wait = rq.next(channel, time.Now())
+		}
+
+		rq.mu.Unlock()
+
+		rq.release(channel, prio, pp)
+	}
+}
@@ -0,0 +1,105 @@
+package direwolf
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestResponseQueue(t *testing.T) *ResponseQueue {
+	t.Helper()
+
+	var audio = new(audio_s)
+	audio.achan[0].response_gap = 3
+	audio.achan[0].response_max = 3
+
+	return NewResponseQueue(audio)
+}
+
+func testResponse(t *testing.T, text string) *packet_t {
+	t.Helper()
+
+	var pp = AX25FromText(text, false)
+	require.NotNil(t, pp)
+
+	return pp
+}
+
+func Test_response_queue_not_ours(t *testing.T) {
+	var rq = newTestResponseQueue(t)
+
+	assert.False(t, rq.Add(0, TQ_PRIO_1_LO, testResponse(t, "Q1TEST>APDW18:>status")))
+
+	var pp = testResponse(t, "APRSTT>MORSE:R")
+	pp.response = true
+	assert.False(t, rq.Add(0, TQ_PRIO_0_HI, pp), "already released")
+}
+
+func Test_response_queue_order_and_gap(t *testing.T) {
+	var rq = newTestResponseQueue(t)
+
+	assert.True(t, rq.Add(0, TQ_PRIO_1_LO, testResponse(t, "BEACON>SPEECH:Beacon")))
+	assert.True(t, rq.Add(0, TQ_PRIO_0_HI, testResponse(t, "APRSTT>MORSE:R")))
+
+	var now = time.Now()
+
+	var pp, prio, wait = rq.next(0, now)
+	require.NotNil(t, pp)
+	assert.Equal(t, TQ_PRIO_0_HI, prio, "high priority first")
+	assert.Equal(t, "R", string(AX25GetInfo(pp)))
+	assert.True(t, pp.response)
+
+	pp, _, wait = rq.next(0, now)
+	assert.Nil(t, pp, "nothing more until the first has been sent")
+	assert.Zero(t, wait)
+
+	rq.Done(0)
+
+	pp, _, wait = rq.next(0, time.Now().Add(time.Second))
+	assert.Nil(t, pp, "rest between responses")
+	assert.InDelta(t, 2*time.Second, wait, float64(100*time.Millisecond))
+
+	pp, prio, _ = rq.next(0, time.Now().Add(3*time.Second))
+	require.NotNil(t, pp)
+	assert.Equal(t, TQ_PRIO_1_LO, prio)
+	assert.Equal(t, "Beacon", string(AX25GetInfo(pp)))
+}
+
+func Test_response_queue_max(t *testing.T) {
+	var rq = newTestResponseQueue(t)
+
+	for range 5 {
+		assert.True(t, rq.Add(0, TQ_PRIO_0_HI, testResponse(t, "APRSTT>MORSE:R")))
+	}
+
+	assert.Equal(t, 3, rq.Count(0), "the rest are discarded")
+	assert.Zero(t, rq.Count(1), "each channel has its own")
+}
+
+func Test_response_queue_release(t *testing.T) {
+	var rq = newTestResponseQueue(t)
+	rq.gap[0] = 0
+
+	var released = make(chan string, 2)
+	rq.release = func(_ int, _ int, pp *packet_t) {
+		released <- string(AX25GetInfo(pp))
+	}
+
+	go rq.thread(0)
+
+	rq.Add(0, TQ_PRIO_0_HI, testResponse(t, "APRSTT>MORSE:one"))
+	rq.Add(0, TQ_PRIO_0_HI, testResponse(t, "APRSTT>MORSE:two"))
+
+	assert.Equal(t, "one", <-released)
+
+	select {
+	case <-released:
+		t.Fatal("second released before the first was sent")
+	case <-time.After(100 * time.Millisecond):
+	}
+
+	rq.Done(0)
+	assert.Equal(t, "two", <-released)
+}